@@ -0,0 +1,235 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package analysis implements the static analysis gate for application
+// packages uploaded to the Ops Center
+package analysis
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gravitational/gravity/lib/loc"
+
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// ActionReject rejects packages that fail analysis
+	ActionReject = "reject"
+	// ActionQuarantine stores packages that fail analysis
+	// but marks them as quarantined so they cannot be installed
+	ActionQuarantine = "quarantine"
+
+	// StatusPassed is the status of a package that has passed analysis
+	StatusPassed = "passed"
+	// StatusQuarantined is the status of a package that has failed analysis
+	// and has been quarantined
+	StatusQuarantined = "quarantined"
+
+	// LabelStatus is the package label with the analysis status
+	LabelStatus = "analysis.gravitational.io/status"
+	// LabelReport is the package label with the JSON-encoded analysis report
+	LabelReport = "analysis.gravitational.io/report"
+)
+
+var log = logrus.WithField(trace.Component, "analysis")
+
+// Config defines the package analysis gate configuration
+type Config struct {
+	// Action is what to do with packages that fail analysis:
+	// reject (default) or quarantine
+	Action string `yaml:"action"`
+	// ManifestSchema enables strict validation of the application manifest
+	ManifestSchema bool `yaml:"manifest_schema"`
+	// DeprecatedAPIs enables detection of deprecated Kubernetes APIs
+	// in application resources
+	DeprecatedAPIs *DeprecatedAPIsConfig `yaml:"deprecated_apis"`
+	// ImageCVEBudget enables vulnerability scanning of application images
+	ImageCVEBudget *ImageCVEBudgetConfig `yaml:"image_cve_budget"`
+}
+
+// CheckAndSetDefaults validates the configuration and sets default values
+func (r *Config) CheckAndSetDefaults() error {
+	switch r.Action {
+	case "":
+		r.Action = ActionReject
+	case ActionReject, ActionQuarantine:
+	default:
+		return trace.BadParameter("unsupported action %q, supported are: %v, %v",
+			r.Action, ActionReject, ActionQuarantine)
+	}
+	if r.ImageCVEBudget != nil {
+		if err := r.ImageCVEBudget.Check(); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// Package describes an application package to analyze
+type Package struct {
+	// Locator identifies the package
+	Locator loc.Locator
+	// Manifest is the raw application manifest
+	Manifest []byte
+	// Dir is the directory with the unpacked package
+	Dir string
+}
+
+// Analyzer analyzes application packages
+type Analyzer interface {
+	// Name returns the analyzer name
+	Name() string
+	// Analyze returns the list of problems found in the package
+	Analyze(Package) (findings []string, err error)
+}
+
+// Report is the result of package analysis
+type Report struct {
+	// Status is the analysis status: passed or quarantined
+	Status string `json:"status"`
+	// Results lists the results of individual analyzers
+	Results []Result `json:"results"`
+}
+
+// Result is the result of a single analyzer
+type Result struct {
+	// Analyzer is the analyzer name
+	Analyzer string `json:"analyzer"`
+	// Findings lists the problems found by the analyzer
+	Findings []string `json:"findings,omitempty"`
+}
+
+// Failed returns true if any of the analyzers has found problems
+func (r Report) Failed() bool {
+	for _, result := range r.Results {
+		if len(result.Findings) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Quarantined returns true if the analyzed package has been quarantined
+func (r Report) Quarantined() bool {
+	return r.Status == StatusQuarantined
+}
+
+// String formats the problems found by the analyzers for output
+func (r Report) String() string {
+	var problems []string
+	for _, result := range r.Results {
+		for _, finding := range result.Findings {
+			problems = append(problems, fmt.Sprintf("%v: %v", result.Analyzer, finding))
+		}
+	}
+	return strings.Join(problems, "\n")
+}
+
+// Labels returns the package labels that record the report
+func (r Report) Labels() (map[string]string, error) {
+	bytes, err := json.Marshal(r)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return map[string]string{
+		LabelStatus: r.Status,
+		LabelReport: string(bytes),
+	}, nil
+}
+
+// FromLabels returns the analysis report recorded in the specified package labels.
+// Returns nil if the package has not been analyzed
+func FromLabels(labels map[string]string) (*Report, error) {
+	data, ok := labels[LabelReport]
+	if !ok {
+		return nil, nil
+	}
+	var report Report
+	if err := json.Unmarshal([]byte(data), &report); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &report, nil
+}
+
+// New returns a new package analysis gate
+func New(config Config) (*Gate, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var analyzers []Analyzer
+	if config.ManifestSchema {
+		analyzers = append(analyzers, manifestSchema{})
+	}
+	if config.DeprecatedAPIs != nil {
+		analyzer, err := newDeprecatedAPIs(*config.DeprecatedAPIs)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		analyzers = append(analyzers, analyzer)
+	}
+	if config.ImageCVEBudget != nil {
+		analyzers = append(analyzers, newImageCVEBudget(*config.ImageCVEBudget))
+	}
+	return &Gate{
+		Action:      config.Action,
+		analyzers:   analyzers,
+		FieldLogger: log,
+	}, nil
+}
+
+// NewWithAnalyzers returns a new gate that runs the specified analyzers
+func NewWithAnalyzers(action string, analyzers ...Analyzer) *Gate {
+	return &Gate{
+		Action:      action,
+		analyzers:   analyzers,
+		FieldLogger: log,
+	}
+}
+
+// Gate runs the configured analyzers on application packages
+type Gate struct {
+	// Action is what to do with packages that fail analysis
+	Action    string
+	analyzers []Analyzer
+	logrus.FieldLogger
+}
+
+// Analyze runs all configured analyzers on the specified package.
+// Analyzer failures are recorded as findings so that packages that
+// could not be analyzed do not pass the gate
+func (r *Gate) Analyze(pkg Package) Report {
+	report := Report{Status: StatusPassed}
+	for _, analyzer := range r.analyzers {
+		findings, err := analyzer.Analyze(pkg)
+		if err != nil {
+			r.Warnf("Analyzer %v failed on %v: %v.", analyzer.Name(), pkg.Locator, trace.DebugReport(err))
+			findings = append(findings, fmt.Sprintf("analysis failed: %v", trace.UserMessage(err)))
+		}
+		report.Results = append(report.Results, Result{
+			Analyzer: analyzer.Name(),
+			Findings: findings,
+		})
+	}
+	if report.Failed() {
+		report.Status = StatusQuarantined
+	}
+	r.Infof("Analyzed %v: %v.", pkg.Locator, report.Status)
+	return report
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analysis
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gravitational/gravity/lib/compare"
+	"github.com/gravitational/gravity/lib/loc"
+
+	"gopkg.in/check.v1"
+)
+
+func TestAnalysis(t *testing.T) { check.TestingT(t) }
+
+type AnalysisSuite struct{}
+
+var _ = check.Suite(&AnalysisSuite{})
+
+func (s *AnalysisSuite) TestManifestSchema(c *check.C) {
+	findings, err := manifestSchema{}.Analyze(Package{Manifest: []byte(testManifest)})
+	c.Assert(err, check.IsNil)
+	c.Assert(findings, check.HasLen, 0)
+
+	findings, err = manifestSchema{}.Analyze(Package{Manifest: []byte(testManifest + `
+nodeProfile:
+  - name: node
+`)})
+	c.Assert(err, check.IsNil)
+	c.Assert(findings, check.Not(check.HasLen), 0)
+
+	findings, err = manifestSchema{}.Analyze(Package{Manifest: []byte(`apiVersion: v1
+kind: Application
+`)})
+	c.Assert(err, check.IsNil)
+	c.Assert(findings, check.DeepEquals, []string{"manifest version v1 is deprecated"})
+}
+
+func (s *AnalysisSuite) TestDeprecatedAPIs(c *check.C) {
+	dir := c.MkDir()
+	writeFile(c, dir, "resources/app.yaml", testManifest)
+	writeFile(c, dir, "resources/resources.yaml", `apiVersion: extensions/v1beta1
+kind: Deployment
+metadata:
+  name: web
+---
+apiVersion: networking.k8s.io/v1beta1
+kind: Ingress
+metadata:
+  name: web
+---
+apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: agent
+`)
+	writeFile(c, dir, "registry/docker/config.yaml", "apiVersion: apps/v1beta1\nkind: Deployment\n")
+
+	analyzer, err := newDeprecatedAPIs(DeprecatedAPIsConfig{KubernetesVersion: "1.16"})
+	c.Assert(err, check.IsNil)
+	findings, err := analyzer.Analyze(Package{Dir: dir})
+	c.Assert(err, check.IsNil)
+	c.Assert(findings, check.DeepEquals, []string{
+		"resources/resources.yaml: Deployment extensions/v1beta1 is removed in Kubernetes 1.16.0, use apps/v1",
+	})
+
+	analyzer, err = newDeprecatedAPIs(DeprecatedAPIsConfig{})
+	c.Assert(err, check.IsNil)
+	findings, err = analyzer.Analyze(Package{Dir: dir})
+	c.Assert(err, check.IsNil)
+	c.Assert(findings, check.HasLen, 2)
+}
+
+func (s *AnalysisSuite) TestImageCVEBudget(c *check.C) {
+	dir := c.MkDir()
+	writeFile(c, dir, "resources/resources.yaml", `apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+spec:
+  containers:
+  - name: web
+    image: nginx:1.15
+`)
+	analyzer := newImageCVEBudget(ImageCVEBudgetConfig{
+		Budget: map[string]int{"critical": 0, "high": 1},
+	})
+	analyzer.scan = func(image string) (map[string]int, error) {
+		c.Assert(image, check.Equals, "nginx:1.15")
+		return parseScanReport([]byte(`{"Results": [{"Vulnerabilities": [
+{"Severity": "CRITICAL"}, {"Severity": "HIGH"}, {"Severity": "LOW"}, {"Severity": "LOW"}]}]}`))
+	}
+	findings, err := analyzer.Analyze(Package{Dir: dir})
+	c.Assert(err, check.IsNil)
+	c.Assert(findings, check.DeepEquals, []string{
+		"nginx:1.15: 1 CRITICAL vulnerabilities, budget is 0",
+	})
+}
+
+func (s *AnalysisSuite) TestParsesLegacyScanReport(c *check.C) {
+	counts, err := parseScanReport([]byte(`[{"Vulnerabilities": [{"Severity": "HIGH"}]}, {"Vulnerabilities": null}]`))
+	c.Assert(err, check.IsNil)
+	c.Assert(counts, check.DeepEquals, map[string]int{"HIGH": 1})
+}
+
+func (s *AnalysisSuite) TestGateRecordsReport(c *check.C) {
+	gate := NewWithAnalyzers(ActionQuarantine,
+		testAnalyzer{name: "clean"},
+		testAnalyzer{name: "strict", findings: []string{"problem"}})
+	report := gate.Analyze(Package{Locator: loc.MustParseLocator("example.com/app:1.0.0")})
+	c.Assert(report.Failed(), check.Equals, true)
+	c.Assert(report.Quarantined(), check.Equals, true)
+	c.Assert(report.String(), check.Equals, "strict: problem")
+
+	labels, err := report.Labels()
+	c.Assert(err, check.IsNil)
+	c.Assert(labels[LabelStatus], check.Equals, StatusQuarantined)
+	parsed, err := FromLabels(labels)
+	c.Assert(err, check.IsNil)
+	c.Assert(*parsed, compare.DeepEquals, report)
+
+	parsed, err = FromLabels(map[string]string{"purpose": "app"})
+	c.Assert(err, check.IsNil)
+	c.Assert(parsed, check.IsNil)
+}
+
+func (s *AnalysisSuite) TestValidatesConfig(c *check.C) {
+	_, err := New(Config{Action: "delete"})
+	c.Assert(err, check.NotNil)
+	_, err = New(Config{ImageCVEBudget: &ImageCVEBudgetConfig{Budget: map[string]int{"CRITICAL": 0}}})
+	c.Assert(err, check.NotNil)
+	gate, err := New(Config{ManifestSchema: true})
+	c.Assert(err, check.IsNil)
+	c.Assert(gate.Action, check.Equals, ActionReject)
+}
+
+type testAnalyzer struct {
+	name     string
+	findings []string
+}
+
+func (r testAnalyzer) Name() string { return r.name }
+
+func (r testAnalyzer) Analyze(Package) ([]string, error) { return r.findings, nil }
+
+func writeFile(c *check.C, dir, path, contents string) {
+	path = filepath.Join(dir, path)
+	c.Assert(os.MkdirAll(filepath.Dir(path), 0755), check.IsNil)
+	c.Assert(ioutil.WriteFile(path, []byte(contents), 0644), check.IsNil)
+}
+
+const testManifest = `apiVersion: app.gravitational.io/v2
+kind: Application
+metadata:
+  name: postgres
+  resourceVersion: 0.0.1
+`
@@ -0,0 +1,368 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analysis
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gravitational/gravity/lib/app/resources"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/schema"
+
+	"github.com/coreos/go-semver/semver"
+	"github.com/ghodss/yaml"
+	"github.com/gravitational/trace"
+)
+
+// manifestSchema strictly validates the application manifest:
+// unknown fields, which are otherwise silently ignored, and legacy
+// manifest versions are reported
+type manifestSchema struct{}
+
+// Name returns the analyzer name
+func (manifestSchema) Name() string {
+	return "manifest-schema"
+}
+
+// Analyze validates the package manifest
+func (manifestSchema) Analyze(pkg Package) (findings []string, err error) {
+	data, err := yaml.YAMLToJSON(pkg.Manifest)
+	if err != nil {
+		return []string{fmt.Sprintf("invalid manifest: %v", err)}, nil
+	}
+	var header schema.Header
+	if err := json.Unmarshal(data, &header); err != nil {
+		return []string{fmt.Sprintf("invalid manifest: %v", err)}, nil
+	}
+	if header.APIVersion == schema.APIVersionV1 {
+		return []string{fmt.Sprintf("manifest version %v is deprecated", schema.APIVersionV1)}, nil
+	}
+	if _, err := schema.ParseManifestYAMLNoValidate(pkg.Manifest); err != nil {
+		findings = append(findings, trace.UserMessage(err))
+	}
+	// serializableManifest drops the custom unmarshaler so the decoder
+	// checks the manifest fields
+	type serializableManifest schema.Manifest
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&serializableManifest{}); err != nil {
+		findings = append(findings, err.Error())
+	}
+	return findings, nil
+}
+
+// DeprecatedAPIsConfig configures detection of deprecated Kubernetes APIs
+type DeprecatedAPIsConfig struct {
+	// KubernetesVersion is the Kubernetes version packages are checked against,
+	// e.g. 1.16. Only APIs removed in this or earlier versions are reported.
+	// If unspecified, all deprecated APIs are reported
+	KubernetesVersion string `yaml:"kubernetes_version"`
+}
+
+func newDeprecatedAPIs(config DeprecatedAPIsConfig) (*deprecatedAPIs, error) {
+	if config.KubernetesVersion == "" {
+		return &deprecatedAPIs{}, nil
+	}
+	version, err := parseKubernetesVersion(config.KubernetesVersion)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &deprecatedAPIs{version: version}, nil
+}
+
+// deprecatedAPIs reports application resources that use
+// deprecated Kubernetes APIs
+type deprecatedAPIs struct {
+	// version is the Kubernetes version resources are checked against
+	version *semver.Version
+}
+
+// Name returns the analyzer name
+func (*deprecatedAPIs) Name() string {
+	return "deprecated-apis"
+}
+
+// Analyze looks up deprecated APIs in the package resources.
+// Resources are scanned textually since they might be templates
+func (r *deprecatedAPIs) Analyze(pkg Package) (findings []string, err error) {
+	err = walkResources(pkg.Dir, func(path, relPath string) error {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return trace.ConvertSystemError(err)
+		}
+		for _, document := range documentSeparator.Split(string(data), -1) {
+			apiVersion := firstMatch(apiVersionField, document)
+			kind := firstMatch(kindField, document)
+			if apiVersion == "" || kind == "" {
+				continue
+			}
+			api := findRemovedAPI(apiVersion, kind)
+			if api == nil {
+				continue
+			}
+			if r.version != nil && r.version.LessThan(*api.removedIn) {
+				continue
+			}
+			findings = append(findings, fmt.Sprintf("%v: %v %v is removed in Kubernetes %v, use %v",
+				relPath, kind, apiVersion, api.removedIn, api.replacement))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return findings, nil
+}
+
+// ImageCVEBudgetConfig configures vulnerability scanning of application images
+type ImageCVEBudgetConfig struct {
+	// Command is the scanner command. The image reference is appended
+	// to the command arguments. The command is expected to output
+	// the report in the trivy JSON format
+	Command []string `yaml:"command"`
+	// Budget is the maximum number of vulnerabilities allowed per image
+	// by severity, e.g. CRITICAL: 0. Severities not listed are not limited
+	Budget map[string]int `yaml:"budget"`
+}
+
+// Check validates the configuration
+func (r ImageCVEBudgetConfig) Check() error {
+	if len(r.Command) == 0 {
+		return trace.BadParameter("missing scanner command")
+	}
+	if len(r.Budget) == 0 {
+		return trace.BadParameter("missing vulnerability budget")
+	}
+	return nil
+}
+
+func newImageCVEBudget(config ImageCVEBudgetConfig) *imageCVEBudget {
+	budget := make(map[string]int, len(config.Budget))
+	for severity, limit := range config.Budget {
+		budget[strings.ToUpper(severity)] = limit
+	}
+	return &imageCVEBudget{
+		budget: budget,
+		scan:   commandScanner(config.Command),
+	}
+}
+
+// imageCVEBudget reports application images with more
+// vulnerabilities than allowed by the budget
+type imageCVEBudget struct {
+	budget map[string]int
+	// scan returns the number of vulnerabilities in the specified image by severity
+	scan func(image string) (map[string]int, error)
+}
+
+// Name returns the analyzer name
+func (*imageCVEBudget) Name() string {
+	return "image-cve-budget"
+}
+
+// Analyze scans the images referenced by the package resources
+func (r *imageCVEBudget) Analyze(pkg Package) (findings []string, err error) {
+	images, err := resourceImages(pkg.Dir)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	for _, image := range images {
+		counts, err := r.scan(image)
+		if err != nil {
+			return nil, trace.Wrap(err, "failed to scan %v", image)
+		}
+		for _, severity := range sortedKeys(r.budget) {
+			if counts[severity] > r.budget[severity] {
+				findings = append(findings, fmt.Sprintf("%v: %v %v vulnerabilities, budget is %v",
+					image, counts[severity], severity, r.budget[severity]))
+			}
+		}
+	}
+	return findings, nil
+}
+
+// commandScanner returns a scanner that runs the specified command
+// and parses its output as a trivy JSON report
+func commandScanner(command []string) func(string) (map[string]int, error) {
+	return func(image string) (map[string]int, error) {
+		args := append(append([]string{}, command[1:]...), image)
+		var stderr bytes.Buffer
+		cmd := exec.Command(command[0], args...)
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, trace.Wrap(err, "scanner failed: %s", stderr.Bytes())
+		}
+		return parseScanReport(out)
+	}
+}
+
+// parseScanReport counts vulnerabilities by severity in the trivy JSON
+// report. Both the report object and the legacy list of results are accepted
+func parseScanReport(data []byte) (map[string]int, error) {
+	type result struct {
+		Vulnerabilities []struct {
+			Severity string `json:"Severity"`
+		} `json:"Vulnerabilities"`
+	}
+	var results []result
+	if err := json.Unmarshal(data, &results); err != nil {
+		var report struct {
+			Results []result `json:"Results"`
+		}
+		if err := json.Unmarshal(data, &report); err != nil {
+			return nil, trace.BadParameter("failed to parse scanner output: %v", err)
+		}
+		results = report.Results
+	}
+	counts := make(map[string]int)
+	for _, result := range results {
+		for _, vulnerability := range result.Vulnerabilities {
+			counts[strings.ToUpper(vulnerability.Severity)]++
+		}
+	}
+	return counts, nil
+}
+
+// resourceImages returns the images referenced by the package resources.
+// Resources that cannot be decoded, e.g. templates, are skipped
+func resourceImages(dir string) (images []string, err error) {
+	var files resources.ResourceFiles
+	err = walkResources(dir, func(path, relPath string) error {
+		file, err := resources.NewResourceFile(path)
+		if err != nil {
+			log.Debugf("Skipping %v: %v.", relPath, err)
+			return nil
+		}
+		files = append(files, *file)
+		return nil
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	images, err = files.Images()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	sort.Strings(images)
+	return images, nil
+}
+
+// walkResources calls fn for each YAML file in the package directory
+// except the container registry
+func walkResources(dir string, fn func(path, relPath string) error) error {
+	return filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return trace.ConvertSystemError(err)
+		}
+		if fi.IsDir() && fi.Name() == defaults.RegistryDir {
+			return filepath.SkipDir
+		}
+		switch filepath.Ext(path) {
+		case ".yaml", ".yml":
+		default:
+			return nil
+		}
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		return fn(path, relPath)
+	})
+}
+
+// removedAPI describes a Kubernetes API removed in a particular version
+type removedAPI struct {
+	apiVersion string
+	// kind is the resource kind. Empty kind matches all kinds
+	kind        string
+	removedIn   *semver.Version
+	replacement string
+}
+
+func findRemovedAPI(apiVersion, kind string) *removedAPI {
+	for _, api := range removedAPIs {
+		if api.apiVersion == apiVersion && (api.kind == "" || api.kind == kind) {
+			return &api
+		}
+	}
+	return nil
+}
+
+// removedAPIs lists deprecated APIs along with the Kubernetes versions they are removed in
+var removedAPIs = []removedAPI{
+	{"extensions/v1beta1", "Deployment", semver.New("1.16.0"), "apps/v1"},
+	{"extensions/v1beta1", "DaemonSet", semver.New("1.16.0"), "apps/v1"},
+	{"extensions/v1beta1", "ReplicaSet", semver.New("1.16.0"), "apps/v1"},
+	{"extensions/v1beta1", "NetworkPolicy", semver.New("1.16.0"), "networking.k8s.io/v1"},
+	{"extensions/v1beta1", "PodSecurityPolicy", semver.New("1.16.0"), "policy/v1beta1"},
+	{"apps/v1beta1", "", semver.New("1.16.0"), "apps/v1"},
+	{"apps/v1beta2", "", semver.New("1.16.0"), "apps/v1"},
+	{"extensions/v1beta1", "Ingress", semver.New("1.22.0"), "networking.k8s.io/v1"},
+	{"networking.k8s.io/v1beta1", "Ingress", semver.New("1.22.0"), "networking.k8s.io/v1"},
+	{"rbac.authorization.k8s.io/v1alpha1", "", semver.New("1.22.0"), "rbac.authorization.k8s.io/v1"},
+	{"rbac.authorization.k8s.io/v1beta1", "", semver.New("1.22.0"), "rbac.authorization.k8s.io/v1"},
+	{"apiextensions.k8s.io/v1beta1", "CustomResourceDefinition", semver.New("1.22.0"), "apiextensions.k8s.io/v1"},
+	{"apiregistration.k8s.io/v1beta1", "APIService", semver.New("1.22.0"), "apiregistration.k8s.io/v1"},
+	{"batch/v1beta1", "CronJob", semver.New("1.25.0"), "batch/v1"},
+	{"policy/v1beta1", "PodDisruptionBudget", semver.New("1.25.0"), "policy/v1"},
+	{"autoscaling/v2beta1", "HorizontalPodAutoscaler", semver.New("1.25.0"), "autoscaling/v2"},
+}
+
+// parseKubernetesVersion parses the version in the major.minor[.patch] format
+func parseKubernetesVersion(version string) (*semver.Version, error) {
+	version = strings.TrimPrefix(version, "v")
+	if strings.Count(version, ".") == 1 {
+		version += ".0"
+	}
+	parsed, err := semver.NewVersion(version)
+	if err != nil {
+		return nil, trace.BadParameter("invalid Kubernetes version %q: %v", version, err)
+	}
+	return parsed, nil
+}
+
+func firstMatch(re *regexp.Regexp, s string) string {
+	match := re.FindStringSubmatch(s)
+	if len(match) < 2 {
+		return ""
+	}
+	return match[1]
+}
+
+func sortedKeys(m map[string]int) (keys []string) {
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var (
+	documentSeparator = regexp.MustCompile(`(?m)^---`)
+	apiVersionField   = regexp.MustCompile(`(?m)^apiVersion:\s*["']?([^\s"']+)`)
+	kindField         = regexp.MustCompile(`(?m)^kind:\s*["']?([^\s"']+)`)
+)
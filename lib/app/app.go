@@ -24,6 +24,7 @@ import (
 	"io"
 	"time"
 
+	"github.com/gravitational/gravity/lib/app/analysis"
 	"github.com/gravitational/gravity/lib/constants"
 	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/pack"
@@ -264,6 +265,11 @@ type InstallerRequest struct {
 	CACert string `json:"ca_cert,omitempty"`
 	// EncryptionKey is encryption key to encrypt installer packages with
 	EncryptionKey string `json:"encryption_key,omitempty"`
+	// License is the optional license to embed into the installer
+	License string `json:"license,omitempty"`
+	// Signer optionally signs the installer packages.
+	// The signer is not passed in API calls
+	Signer pack.Signer `json:"-"`
 }
 
 // Check validates this request
@@ -271,9 +277,6 @@ func (r InstallerRequest) Check() error {
 	if r.Application.IsEmpty() {
 		return trace.BadParameter("missing Application")
 	}
-	if r.EncryptionKey != "" && r.CACert == "" {
-		return trace.BadParameter("CACert is required when EncryptionKey is provided")
-	}
 	return nil
 }
 
@@ -289,6 +292,7 @@ func (r InstallerRequest) ToRaw() (*InstallerRequestRaw, error) {
 		TrustedCluster: json.RawMessage(bytes),
 		CACert:         r.CACert,
 		EncryptionKey:  r.EncryptionKey,
+		License:        r.License,
 	}, nil
 }
 
@@ -305,6 +309,8 @@ type InstallerRequestRaw struct {
 	CACert string `json:"ca_cert,omitempty"`
 	// EncryptionKey is encryption key to encrypt installer packages with
 	EncryptionKey string `json:"encryption_key,omitempty"`
+	// License is the optional license to embed into the installer
+	License string `json:"license,omitempty"`
 }
 
 // ToNative converts the request from API-friendly to its regular format
@@ -319,6 +325,7 @@ func (r InstallerRequestRaw) ToNative() (*InstallerRequest, error) {
 		TrustedCluster: cluster,
 		CACert:         r.CACert,
 		EncryptionKey:  r.EncryptionKey,
+		License:        r.License,
 	}, nil
 }
 
@@ -383,6 +390,15 @@ type Application struct {
 	PackageEnvelope pack.PackageEnvelope `json:"envelope"`
 	// Manifest defines the application install configuration
 	Manifest schema.Manifest `json:"manifest"`
+	// Analysis is the report of the static analysis the package has been
+	// subjected to when uploaded. Nil if the package has not been analyzed
+	Analysis *analysis.Report `json:"analysis,omitempty"`
+}
+
+// Quarantined returns true if the application package has failed
+// static analysis and has been quarantined
+func (a Application) Quarantined() bool {
+	return a.Analysis != nil && a.Analysis.Quarantined()
 }
 
 // String formats the specified application for output
@@ -19,6 +19,7 @@ package app
 import (
 	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/pack"
+	"github.com/gravitational/gravity/lib/schema"
 
 	"github.com/gravitational/trace"
 	log "github.com/sirupsen/logrus"
@@ -39,9 +40,15 @@ func GetDependencies(app *Application, apps Applications) (result *Dependencies,
 		result.Packages = append(result.Packages, *state.runtimePackage)
 	}
 	for _, locator := range state.apps {
-		if !locator.IsEqualTo(app.Package) {
-			result.Apps = append(result.Apps, locator)
+		if locator.IsEqualTo(app.Package) {
+			continue
 		}
+		if schema.ShouldOmitApp(app.Manifest, locator) {
+			log.Infof("Omitting application %v disabled by the %v installer profile.",
+				locator, app.Manifest.InstallerProfile())
+			continue
+		}
+		result.Apps = append(result.Apps, locator)
 	}
 	result.Packages = loc.Deduplicate(result.Packages)
 	return result, nil
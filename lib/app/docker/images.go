@@ -0,0 +1,84 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"archive/tar"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gravitational/gravity/lib/archive"
+	"github.com/gravitational/gravity/lib/defaults"
+
+	"github.com/gravitational/trace"
+)
+
+// ListTarballImages returns the list of images stored in the registry
+// directory of the application package tarball read from the specified reader.
+//
+// The images are discovered from the tag links of the registry 2.x layout
+// so the layers do not need to be unpacked.
+func ListTarballImages(reader io.Reader) (images []TagSpec, err error) {
+	err = archive.TarGlob(tar.NewReader(reader), ".", []string{registryTagLink},
+		func(match string, _ io.Reader) error {
+			if tag := tagFromLinkPath(match); tag != nil {
+				images = append(images, *tag)
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	sort.Slice(images, func(i, j int) bool {
+		return images[i].String() < images[j].String()
+	})
+	return images, nil
+}
+
+// tagFromLinkPath returns the image tag referenced by the specified path
+// of a tag link inside the registry directory, or nil if the path
+// is not a tag link
+func tagFromLinkPath(path string) *TagSpec {
+	path = filepath.ToSlash(filepath.Clean(path))
+	if !strings.HasPrefix(path, registryRepositoriesDir) {
+		return nil
+	}
+	path = strings.TrimPrefix(path, registryRepositoriesDir)
+	parts := strings.Split(path, registryTagsDir)
+	if len(parts) != 2 || parts[0] == "" {
+		return nil
+	}
+	tag := strings.TrimSuffix(parts[1], registryCurrentTagLink)
+	if tag == parts[1] || tag == "" || strings.Contains(tag, "/") {
+		return nil
+	}
+	return &TagSpec{Name: parts[0], Version: tag}
+}
+
+const (
+	// registryRepositoriesDir is the directory with repositories inside the
+	// application registry directory
+	registryRepositoriesDir = defaults.RegistryDir + "/docker/registry/v2/repositories/"
+	// registryTagsDir separates the repository name from the tag in a tag link path
+	registryTagsDir = "/_manifests/tags/"
+	// registryCurrentTagLink is the suffix of the link to the current tag revision
+	registryCurrentTagLink = "/current/" + registryTagLink
+	// registryTagLink is the name of the file with the tag link
+	registryTagLink = "link"
+)
@@ -0,0 +1,52 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"bytes"
+
+	"github.com/gravitational/gravity/lib/archive"
+
+	. "gopkg.in/check.v1"
+)
+
+type ImagesSuite struct{}
+
+var _ = Suite(&ImagesSuite{})
+
+func (s *ImagesSuite) TestListsTarballImages(c *C) {
+	const tags = "registry/docker/registry/v2/repositories"
+	var buf bytes.Buffer
+	tarball := archive.NewTarAppender(&buf)
+	err := tarball.Add(
+		archive.ItemFromString("resources/app.yaml", "kind: Bundle"),
+		archive.ItemFromString(tags+"/nginx/_manifests/tags/1.15/current/link", "sha256:a"),
+		archive.ItemFromString(tags+"/nginx/_manifests/tags/1.15/index/sha256/a/link", "sha256:a"),
+		archive.ItemFromString(tags+"/gravitational/debian-tall/_manifests/tags/0.0.1/current/link", "sha256:b"),
+		archive.ItemFromString(tags+"/nginx/_layers/sha256/a/link", "sha256:a"),
+		archive.ItemFromString("resources/"+tags+"/fake/_manifests/tags/1/current/link", "sha256:c"),
+	)
+	c.Assert(err, IsNil)
+	c.Assert(tarball.Close(), IsNil)
+
+	images, err := ListTarballImages(&buf)
+	c.Assert(err, IsNil)
+	c.Assert(images, DeepEquals, []TagSpec{
+		{Name: "gravitational/debian-tall", Version: "0.0.1"},
+		{Name: "nginx", Version: "1.15"},
+	})
+}
@@ -23,6 +23,7 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/gravitational/gravity/lib/archive"
 	"github.com/gravitational/gravity/lib/defaults"
 	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/pack"
@@ -129,7 +130,7 @@ func TranslateRuntimeImage(req TranslateImageRequest) error {
 	}
 
 	log.Info("Compressing intermediate package directory.")
-	reader, err := dockerarchive.Tar(packageDir, dockerarchive.Gzip)
+	reader, err := archive.Tar(packageDir, archive.DefaultOptions(), archive.DefaultCompression())
 	if err != nil {
 		return trace.Wrap(err)
 	}
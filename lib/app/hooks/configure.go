@@ -54,6 +54,9 @@ func configureJob(job *batchv1.Job, p Params) error {
 
 	configureVolumes(job, p)
 	configureVolumeMounts(job, p)
+	if err := configureValues(job, p); err != nil {
+		return trace.Wrap(err)
+	}
 	if err := configureSecurityContext(job, p); err != nil {
 		return trace.Wrap(err)
 	}
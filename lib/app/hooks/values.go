@@ -0,0 +1,227 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hooks
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/gravitational/trace"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/api/core/v1"
+)
+
+const (
+	// ValuesKind is the kind of the hook values resource
+	ValuesKind = "HookValues"
+	// ValuesVersion is the current version of the hook values format
+	ValuesVersion = "v1"
+
+	// ValuesDir is where the values file is mounted inside hook containers
+	ValuesDir = "/var/lib/gravity/values"
+	// ValuesFilename is the name of the values file inside ValuesDir
+	ValuesFilename = "values.yaml"
+	// ValuesEnv is the name of the environment variable with the path
+	// to the values file inside hook containers
+	ValuesEnv = "GRAVITY_HOOK_VALUES"
+	// ValuesAnnotation is the hook pod annotation the values are
+	// projected from into the values file
+	ValuesAnnotation = "hooks.gravitational.io/values"
+	// VolumeValues is the name of the volume with the values file
+	VolumeValues = "values"
+)
+
+const (
+	// ValueClusterName is the name of the cluster
+	ValueClusterName = "cluster.name"
+	// ValueClusterNodeCount is the number of nodes in the cluster
+	ValueClusterNodeCount = "cluster.nodeCount"
+	// ValueClusterMasterCount is the number of master nodes in the cluster
+	ValueClusterMasterCount = "cluster.masterCount"
+	// ValuePodCIDR is the cluster pod network CIDR
+	ValuePodCIDR = "network.podCIDR"
+	// ValueServiceCIDR is the cluster service network CIDR
+	ValueServiceCIDR = "network.serviceCIDR"
+	// ValueParameterPrefix prefixes application configuration parameters
+	ValueParameterPrefix = "parameters."
+	// ValueConfigMapPrefix prefixes data of the config maps requested by the hook
+	ValueConfigMapPrefix = "configMaps."
+)
+
+// Values is a versioned set of cluster values made available to hooks
+// as a file, so hooks do not have to query the cluster state themselves
+type Values struct {
+	// Kind is the resource kind, always ValuesKind
+	Kind string `json:"kind"`
+	// Version is the format version
+	Version string `json:"version"`
+	// Values maps value keys to values
+	Values map[string]string `json:"values"`
+}
+
+// NewValues returns a new empty set of values
+func NewValues() *Values {
+	return &Values{
+		Kind:    ValuesKind,
+		Version: ValuesVersion,
+		Values:  make(map[string]string),
+	}
+}
+
+// ReadValues reads values from the file at the specified path
+func ReadValues(path string) (*Values, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	return ParseValues(data)
+}
+
+// ReadValuesFromEnv reads values from the file referenced by ValuesEnv
+// environment variable, falling back to the default location.
+// Hooks use it to access the values injected by gravity
+func ReadValuesFromEnv() (*Values, error) {
+	path := os.Getenv(ValuesEnv)
+	if path == "" {
+		path = ValuesPath()
+	}
+	return ReadValues(path)
+}
+
+// ParseValues parses values from the provided serialized representation
+func ParseValues(data []byte) (*Values, error) {
+	var values Values
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if values.Kind != ValuesKind {
+		return nil, trace.BadParameter("expected kind %v, got %q", ValuesKind, values.Kind)
+	}
+	if values.Version != ValuesVersion {
+		return nil, trace.BadParameter("unsupported values version %q", values.Version)
+	}
+	if values.Values == nil {
+		values.Values = make(map[string]string)
+	}
+	return &values, nil
+}
+
+// ValuesPath returns the path to the values file inside hook containers
+func ValuesPath() string {
+	return fmt.Sprintf("%v/%v", ValuesDir, ValuesFilename)
+}
+
+// Get returns the value for the specified key
+func (v *Values) Get(key string) (value string, ok bool) {
+	value, ok = v.Values[key]
+	return value, ok
+}
+
+// Set sets the value for the specified key
+func (v *Values) Set(key, value string) {
+	v.Values[key] = value
+}
+
+// SetParameters adds the provided application configuration parameters
+func (v *Values) SetParameters(parameters map[string]string) {
+	for name, value := range parameters {
+		v.Set(ValueParameterPrefix+name, value)
+	}
+}
+
+// SetConfigMap adds the data of the specified config map
+func (v *Values) SetConfigMap(namespace, name string, data map[string]string) {
+	for key, value := range data {
+		v.Set(ConfigMapValueKey(namespace, name, key), value)
+	}
+}
+
+// Require returns an error if any of the specified keys is missing
+func (v *Values) Require(keys ...string) error {
+	var missing []string
+	for _, key := range keys {
+		if _, ok := v.Values[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) != 0 {
+		sort.Strings(missing)
+		return trace.NotFound("missing required hook values: %v",
+			strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// ConfigMapValueKey returns the key of the value with the specified config map data
+func ConfigMapValueKey(namespace, name, key string) string {
+	return fmt.Sprintf("%v%v.%v.%v", ValueConfigMapPrefix, namespace, name, key)
+}
+
+// configureValues makes the values available to the hook containers
+// as a file projected from the pod annotation
+func configureValues(job *batchv1.Job, p Params) error {
+	if p.Values == nil {
+		return nil
+	}
+	data, err := yaml.Marshal(p.Values)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	template := &job.Spec.Template
+	if template.ObjectMeta.Annotations == nil {
+		template.ObjectMeta.Annotations = make(map[string]string)
+	}
+	template.ObjectMeta.Annotations[ValuesAnnotation] = string(data)
+
+	template.Spec.Volumes = append(template.Spec.Volumes, v1.Volume{
+		Name: VolumeValues,
+		VolumeSource: v1.VolumeSource{
+			DownwardAPI: &v1.DownwardAPIVolumeSource{
+				Items: []v1.DownwardAPIVolumeFile{
+					{
+						Path: ValuesFilename,
+						FieldRef: &v1.ObjectFieldSelector{
+							FieldPath: fmt.Sprintf("metadata.annotations['%v']", ValuesAnnotation),
+						},
+					},
+				},
+			},
+		},
+	})
+
+	for i := range template.Spec.Containers {
+		template.Spec.Containers[i].VolumeMounts = append(
+			template.Spec.Containers[i].VolumeMounts,
+			v1.VolumeMount{
+				Name:      VolumeValues,
+				MountPath: ValuesDir,
+				ReadOnly:  true,
+			})
+		template.Spec.Containers[i].Env = append(
+			template.Spec.Containers[i].Env,
+			v1.EnvVar{
+				Name:  ValuesEnv,
+				Value: ValuesPath(),
+			})
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hooks
+
+import (
+	"github.com/ghodss/yaml"
+	"github.com/gravitational/trace"
+	"gopkg.in/check.v1"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/api/core/v1"
+)
+
+type ValuesSuite struct{}
+
+var _ = check.Suite(&ValuesSuite{})
+
+func (s *ValuesSuite) TestRoundtrip(c *check.C) {
+	values := NewValues()
+	values.Set(ValueClusterName, "example.com")
+	values.SetParameters(map[string]string{"replicas": "3"})
+	values.SetConfigMap("default", "app", map[string]string{"mode": "ha"})
+
+	data, err := yaml.Marshal(values)
+	c.Assert(err, check.IsNil)
+	parsed, err := ParseValues(data)
+	c.Assert(err, check.IsNil)
+	c.Assert(parsed, check.DeepEquals, values)
+
+	value, ok := parsed.Get("parameters.replicas")
+	c.Assert(ok, check.Equals, true)
+	c.Assert(value, check.Equals, "3")
+	value, ok = parsed.Get("configMaps.default.app.mode")
+	c.Assert(ok, check.Equals, true)
+	c.Assert(value, check.Equals, "ha")
+}
+
+func (s *ValuesSuite) TestRejectsUnknownVersion(c *check.C) {
+	_, err := ParseValues([]byte("kind: HookValues\nversion: v2\nvalues: {}"))
+	c.Assert(trace.IsBadParameter(err), check.Equals, true)
+	_, err = ParseValues([]byte("kind: ConfigMap\nversion: v1\nvalues: {}"))
+	c.Assert(trace.IsBadParameter(err), check.Equals, true)
+}
+
+func (s *ValuesSuite) TestRequire(c *check.C) {
+	values := NewValues()
+	values.Set(ValueClusterNodeCount, "3")
+	c.Assert(values.Require(ValueClusterNodeCount), check.IsNil)
+	err := values.Require(ValueClusterNodeCount, ValuePodCIDR, ValueClusterName)
+	c.Assert(trace.IsNotFound(err), check.Equals, true)
+	c.Assert(err, check.ErrorMatches, ".*cluster.name, network.podCIDR.*")
+}
+
+func (s *ValuesSuite) TestConfigureValues(c *check.C) {
+	job := &batchv1.Job{}
+	job.Spec.Template.Spec.Containers = []v1.Container{{Name: "hook"}}
+	values := NewValues()
+	values.Set(ValueClusterNodeCount, "3")
+
+	err := configureValues(job, Params{Values: values})
+	c.Assert(err, check.IsNil)
+
+	parsed, err := ParseValues([]byte(job.Spec.Template.Annotations[ValuesAnnotation]))
+	c.Assert(err, check.IsNil)
+	c.Assert(parsed, check.DeepEquals, values)
+
+	c.Assert(job.Spec.Template.Spec.Volumes, check.HasLen, 1)
+	volume := job.Spec.Template.Spec.Volumes[0]
+	c.Assert(volume.Name, check.Equals, VolumeValues)
+	c.Assert(volume.DownwardAPI.Items[0].FieldRef.FieldPath, check.Equals,
+		"metadata.annotations['hooks.gravitational.io/values']")
+
+	container := job.Spec.Template.Spec.Containers[0]
+	c.Assert(container.VolumeMounts, check.DeepEquals, []v1.VolumeMount{
+		{Name: VolumeValues, MountPath: ValuesDir, ReadOnly: true},
+	})
+	c.Assert(container.Env, check.DeepEquals, []v1.EnvVar{
+		{Name: ValuesEnv, Value: "/var/lib/gravity/values/values.yaml"},
+	})
+}
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -29,6 +30,7 @@ import (
 	"time"
 
 	appservice "github.com/gravitational/gravity/lib/app"
+	"github.com/gravitational/gravity/lib/app/analysis"
 	"github.com/gravitational/gravity/lib/app/docker"
 	"github.com/gravitational/gravity/lib/app/hooks"
 	"github.com/gravitational/gravity/lib/archive"
@@ -43,7 +45,6 @@ import (
 	"github.com/gravitational/gravity/lib/utils"
 	fileutils "github.com/gravitational/gravity/lib/utils"
 
-	dockerarchive "github.com/docker/docker/pkg/archive"
 	"github.com/gravitational/trace"
 	log "github.com/sirupsen/logrus"
 	"k8s.io/client-go/kubernetes"
@@ -86,6 +87,9 @@ type Config struct {
 	log.FieldLogger
 	// Charts provides chart repository methods.
 	Charts helm.Repository
+	// Analysis is the optional static analysis gate for uploaded
+	// application packages
+	Analysis *analysis.Gate
 }
 
 // New creates a new instance of the application manager
@@ -204,7 +208,7 @@ func (r *applications) withApp(locator loc.Locator, process processAppFn) (*apps
 
 	packagePath := pack.PackagePath(r.UnpackedDir, locator)
 
-	err = pack.UnpackIfNotUnpacked(r.Packages, locator, packagePath, nil)
+	err = pack.UnpackIfNotUnpacked(r.Packages, locator, packagePath, pack.UnpackOptions{})
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -302,6 +306,10 @@ func (r *applications) StartAppHook(ctx context.Context, req appservice.HookRunR
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+	values, err := r.hookValues(client, hook)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
 	params := hooks.Params{
 		Hook:               hook,
 		Locator:            req.Application,
@@ -316,6 +324,7 @@ func (r *applications) StartAppHook(ctx context.Context, req appservice.HookRunR
 		AgentPassword:      creds.Password,
 		GravityPackage:     req.GravityPackage,
 		ServiceUser:        req.ServiceUser,
+		Values:             values,
 	}
 
 	ref, err := runner.Start(ctx, params)
@@ -525,7 +534,7 @@ func (r *applications) UpsertApp(locator loc.Locator, reader io.Reader, labels m
 		return nil, trace.Wrap(err)
 	}
 
-	packageBytes, err := dockerarchive.Tar(tempDir, dockerarchive.Gzip)
+	packageBytes, err := archive.Tar(tempDir, archive.DefaultOptions(), archive.DefaultCompression())
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -559,7 +568,7 @@ func (r *applications) CreateApp(locator loc.Locator, reader io.Reader, labels m
 		return nil, trace.Wrap(err)
 	}
 
-	packageBytes, err := dockerarchive.Tar(tempDir, dockerarchive.Gzip)
+	packageBytes, err := archive.Tar(tempDir, archive.DefaultOptions(), archive.DefaultCompression())
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -584,6 +593,15 @@ func (r *applications) createApp(locator loc.Locator, packageBytes io.Reader, ma
 		return nil, trace.Wrap(err)
 	}
 
+	if r.Analysis != nil {
+		var cleanup func()
+		packageBytes, labels, cleanup, err = r.analyzePackage(locator, manifestBytes, packageBytes, labels)
+		defer cleanup()
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+
 	options := []pack.PackageOption{
 		pack.WithLabels(labels),
 		pack.WithManifest(string(appType), manifestBytes),
@@ -608,13 +626,73 @@ func (r *applications) createApp(locator loc.Locator, packageBytes io.Reader, ma
 		}
 	}
 
+	report, err := analysis.FromLabels(envelope.RuntimeLabels)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
 	return &appservice.Application{
 		Package:         locator,
 		PackageEnvelope: *envelope,
 		Manifest:        *manifest,
+		Analysis:        report,
 	}, nil
 }
 
+// analyzePackage runs the static analysis gate on the package.
+// Packages that fail analysis are rejected or have the analysis report
+// recorded in their labels, depending on the gate configuration.
+// Returns the reader with the package contents and the updated labels
+// along with the cleanup handler that is always safe to call
+func (r *applications) analyzePackage(locator loc.Locator, manifestBytes []byte, packageBytes io.Reader, labels map[string]string) (io.Reader, map[string]string, func(), error) {
+	file, err := ioutil.TempFile("", "package")
+	if err != nil {
+		return nil, nil, func() {}, trace.ConvertSystemError(err)
+	}
+	cleanup := func() {
+		file.Close()
+		if err := os.Remove(file.Name()); err != nil {
+			r.Warnf("Failed to remove %v: %v.", file.Name(), err)
+		}
+	}
+	if _, err = io.Copy(file, packageBytes); err != nil {
+		return nil, nil, cleanup, trace.Wrap(err)
+	}
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		return nil, nil, cleanup, trace.Wrap(err)
+	}
+	dir, cleanupDir, err := unpackedSource(file, true)
+	defer cleanupDir()
+	if err != nil {
+		return nil, nil, cleanup, trace.Wrap(err)
+	}
+	report := r.Analysis.Analyze(analysis.Package{
+		Locator:  locator,
+		Manifest: manifestBytes,
+		Dir:      dir,
+	})
+	if report.Failed() && r.Analysis.Action == analysis.ActionReject {
+		return nil, nil, cleanup, trace.BadParameter(
+			"package %v has been rejected by static analysis:\n%v", locator, report)
+	}
+	reportLabels, err := report.Labels()
+	if err != nil {
+		return nil, nil, cleanup, trace.Wrap(err)
+	}
+	// labels supplied by the uploader must not override the analysis result
+	result := make(map[string]string, len(labels)+len(reportLabels))
+	for name, value := range labels {
+		result[name] = value
+	}
+	for name, value := range reportLabels {
+		result[name] = value
+	}
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		return nil, nil, cleanup, trace.Wrap(err)
+	}
+	return file, result, cleanup, nil
+}
+
 // CreateImportOperation initiates import for an application specified with req.
 // Returns the import operation to keep track of the import progress.
 func (r *applications) CreateImportOperation(req *appservice.ImportRequest) (*storage.AppOperation, error) {
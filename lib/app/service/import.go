@@ -21,12 +21,13 @@ import (
 	"time"
 
 	"github.com/gravitational/gravity/lib/app"
+	"github.com/gravitational/gravity/lib/archive"
 	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/schema"
 	"github.com/gravitational/gravity/lib/storage"
 	"github.com/gravitational/trace"
 
-	"github.com/docker/docker/pkg/archive"
+	dockerarchive "github.com/docker/docker/pkg/archive"
 )
 
 // importApp imports an application from the specified directory
@@ -57,13 +58,12 @@ func (r *applications) importAppWithContext(ctx *operationContext, request *app.
 		return trace.Wrap(err)
 	}
 
-	archiveOptions := &archive.TarOptions{
-		Compression:     archive.Gzip,
+	archiveOptions := &dockerarchive.TarOptions{
 		ExcludePatterns: request.ExcludePatterns,
 		IncludeFiles:    request.IncludePaths,
 	}
 
-	packageBytes, err := archive.TarWithOptions(unpackedDir, archiveOptions)
+	packageBytes, err := archive.Tar(unpackedDir, archiveOptions, archive.DefaultCompression())
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -136,6 +136,9 @@ func (r *applications) GetAppInstaller(req appservice.InstallerRequest) (install
 	if req.EncryptionKey != "" {
 		localPackages = encryptedpack.New(localPackages, req.EncryptionKey)
 	}
+	if req.Signer != nil {
+		localPackages = pack.PackagesWithSigner(localPackages, req.Signer)
+	}
 
 	localApps, err := New(Config{
 		Backend:  localBackend,
@@ -156,6 +159,10 @@ func (r *applications) GetAppInstaller(req appservice.InstallerRequest) (install
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+	if app.Quarantined() {
+		return nil, trace.AccessDenied("application %v has been quarantined by static analysis",
+			req.Application)
+	}
 
 	manifestBytes, err := yaml.Marshal(app.Manifest)
 	if err != nil {
@@ -176,6 +183,11 @@ func (r *applications) GetAppInstaller(req appservice.InstallerRequest) (install
 		return nil, trace.Wrap(err)
 	}
 
+	if req.License != "" {
+		items = append(items, archive.ItemFromStringMode(
+			defaults.LicenseFileName, req.License, defaults.SharedReadMask))
+	}
+
 	reader, writer := io.Pipe()
 	go func() {
 		uploadScript, err := renderUploadScript(*app)
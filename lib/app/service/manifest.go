@@ -24,13 +24,13 @@ import (
 	"path/filepath"
 
 	"github.com/gravitational/gravity/lib/app"
+	"github.com/gravitational/gravity/lib/app/analysis"
 	"github.com/gravitational/gravity/lib/archive"
 	"github.com/gravitational/gravity/lib/defaults"
 	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/pack"
 	"github.com/gravitational/gravity/lib/schema"
 
-	dockerarchive "github.com/docker/docker/pkg/archive"
 	"github.com/gravitational/trace"
 	log "github.com/sirupsen/logrus"
 )
@@ -57,7 +57,7 @@ func manifestFromUnpackedSource(source io.Reader) (manifest []byte, dir string,
 // manifestFromSource reads an application manifest from the specified tarball
 // without unpacking it.
 func manifestFromSource(source io.Reader) (manifest []byte, err error) {
-	decompressed, err := dockerarchive.DecompressStream(source)
+	decompressed, err := archive.DecompressStream(source)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -119,7 +119,7 @@ func unpackedSource(source io.Reader, excludeRegistry bool) (dir string, cleanup
 		tarOptions.ExcludePatterns = []string{"registry"}
 	}
 
-	if err = dockerarchive.Untar(source, dir, tarOptions); err != nil {
+	if err = archive.Untar(source, dir, tarOptions); err != nil {
 		return dir, cleanup, trace.Wrap(err)
 	}
 	return dir, cleanup, nil
@@ -131,10 +131,15 @@ func toApp(pkg *pack.PackageEnvelope, apps *applications) (*app.Application, err
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+	report, err := analysis.FromLabels(pkg.RuntimeLabels)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
 	return &app.Application{
 		Package:         pkg.Locator,
 		PackageEnvelope: *pkg,
 		Manifest:        *manifest,
+		Analysis:        report,
 	}, nil
 }
 
@@ -148,10 +153,15 @@ func newApp(pkg *pack.PackageEnvelope, manifestBytes []byte, locator loc.Locator
 	if manifest.Metadata.Namespace == "" {
 		manifest.Metadata.Namespace = app.DefaultNamespace
 	}
+	report, err := analysis.FromLabels(pkg.RuntimeLabels)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
 	return &app.Application{
 		Package:         locator,
 		PackageEnvelope: *pkg,
 		Manifest:        *manifest,
+		Analysis:        report,
 	}, nil
 }
 
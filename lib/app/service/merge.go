@@ -49,6 +49,10 @@ func mergeManifests(target *schema.Manifest, source schema.Manifest) error {
 		mergeProviders(target.Providers, *source.Providers)
 	}
 
+	if target.Compatibility == nil {
+		target.Compatibility = source.Compatibility
+	}
+
 	return nil
 }
 
@@ -18,6 +18,7 @@ package service
 
 import (
 	"context"
+	"io"
 	"io/ioutil"
 	"sync"
 	"time"
@@ -26,7 +27,6 @@ import (
 	"github.com/gravitational/gravity/lib/defaults"
 	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/pack"
-	"github.com/gravitational/gravity/lib/run"
 	"github.com/gravitational/gravity/lib/schema"
 	"github.com/gravitational/gravity/lib/utils"
 
@@ -53,6 +53,10 @@ type PackagePullRequest struct {
 	Upsert bool
 	// MetadataOnly allows to pull only package metadata without body
 	MetadataOnly bool
+	// Cache is an optional package service with pre-seeded packages.
+	// Packages found in the cache with the same digest as in the source
+	// package service are read from the cache instead of the source
+	Cache pack.PackageService
 }
 
 // CheckAndSetDefaults checks the package pull request and sets some defaults
@@ -86,6 +90,10 @@ type AppPullRequest struct {
 	Upsert bool
 	// MetadataOnly allows to pull only app metadata without body
 	MetadataOnly bool
+	// Cache is an optional package service with pre-seeded packages.
+	// Packages found in the cache with the same digest as in the source
+	// package service are read from the cache instead of the source
+	Cache pack.PackageService
 	// Parallel defines the number of tasks to run in parallel.
 	// If < 0, the number of tasks is unrestricted.
 	// If in [0,1], the tasks are executed sequentially.
@@ -113,6 +121,7 @@ func (r *AppPullRequest) Clone(locator loc.Locator) AppPullRequest {
 		Progress:     r.Progress,
 		Parallel:     r.Parallel,
 		MetadataOnly: r.MetadataOnly,
+		Cache:        r.Cache,
 	}
 }
 
@@ -122,6 +131,77 @@ func PullPackage(req PackagePullRequest) (*pack.PackageEnvelope, error) {
 	return pullPackageWithRetries(req, state)
 }
 
+// PackagesPullRequest describes a request to pull multiple packages
+type PackagesPullRequest struct {
+	// Requests lists the packages to pull
+	Requests []PackagePullRequest
+	// Parallel defines the number of packages to pull in parallel.
+	// If < 0, the number of parallel pulls is unrestricted.
+	// If in [0,1], the packages are pulled sequentially.
+	Parallel int
+}
+
+// PullPackages pulls the packages described by the specified request using
+// a pool of workers.
+//
+// A failure to pull a package does not abort the pulls of the other packages,
+// instead the errors are aggregated for all packages that failed to pull
+func PullPackages(ctx context.Context, req PackagesPullRequest) error {
+	state := newPullState()
+	tasks := make([]func() error, 0, len(req.Requests))
+	for _, pullReq := range req.Requests {
+		pullReq := pullReq
+		tasks = append(tasks, func() error {
+			_, err := pullPackageWithRetries(pullReq, state)
+			return trace.Wrap(err, "failed to pull package %v", pullReq.Package)
+		})
+	}
+	return trace.Wrap(runParallel(ctx, req.Parallel, tasks))
+}
+
+// runParallel executes the specified tasks using a pool of the given number
+// of workers and returns the aggregate of errors of all failed tasks.
+// See PackagesPullRequest.Parallel for the meaning of parallel
+func runParallel(ctx context.Context, parallel int, tasks []func() error) error {
+	workers := parallel
+	if workers < 0 || workers > len(tasks) {
+		workers = len(tasks)
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	taskC := make(chan func() error, len(tasks))
+	for _, task := range tasks {
+		taskC <- task
+	}
+	close(taskC)
+	var mu sync.Mutex
+	var errors []error
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range taskC {
+				// skip the remaining tasks once the context is cancelled
+				if ctx.Err() != nil {
+					continue
+				}
+				if err := task(); err != nil {
+					mu.Lock()
+					errors = append(errors, err)
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		errors = append(errors, trace.Wrap(ctx.Err()))
+	}
+	return trace.NewAggregate(errors...)
+}
+
 // pullPackageHandler returns a function to pull the specified package loc for running
 // as part of parallel pull operation.
 // If the package already exists, the error is ignored
@@ -135,6 +215,7 @@ func pullPackageHandler(loc loc.Locator, req AppPullRequest, state *pullState) f
 			Upsert:       req.Upsert,
 			Progress:     req.Progress,
 			MetadataOnly: req.MetadataOnly,
+			Cache:        req.Cache,
 		}, state)
 		if !trace.IsAlreadyExists(err) {
 			return trace.Wrap(err)
@@ -184,7 +265,7 @@ func pullPackage(req PackagePullRequest) (*pack.PackageEnvelope, error) {
 	if req.MetadataOnly {
 		env, err = req.SrcPack.ReadPackageEnvelope(req.Package)
 	} else {
-		env, reader, err = req.SrcPack.ReadPackage(req.Package)
+		env, reader, err = readPackage(req.FieldLogger, req.SrcPack, req.DstPack, req.Cache, req.Package)
 	}
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -224,9 +305,36 @@ func pullPackage(req PackagePullRequest) (*pack.PackageEnvelope, error) {
 		return nil, trace.Wrap(err)
 	}
 
+	if base, ok := env.RuntimeLabels[pack.ConfigBaseLabel]; ok {
+		if err := pullConfigBase(req, base); err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+
 	return env, nil
 }
 
+// pullConfigBase pulls the package with the shared configuration
+// referenced by a layered configuration package
+func pullConfigBase(req PackagePullRequest, base string) error {
+	locator, err := loc.ParseLocator(base)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = pullPackage(PackagePullRequest{
+		FieldLogger:  req.FieldLogger,
+		SrcPack:      req.SrcPack,
+		DstPack:      req.DstPack,
+		Package:      *locator,
+		MetadataOnly: req.MetadataOnly,
+		Cache:        req.Cache,
+	})
+	if err != nil && !trace.IsAlreadyExists(err) {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
 // PullApp pulls the application specified with app, along with all its dependencies
 // and base application, from the "source" application service and replicates it in
 // the "destination" application service
@@ -264,6 +372,10 @@ func pullApp(req AppPullRequest, state *pullState) (*app.Application, error) {
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+	if application.Quarantined() {
+		return nil, trace.AccessDenied("application %v has been quarantined by static analysis",
+			req.Package)
+	}
 
 	// Use the raw manifest to avoid issues with remote side
 	// being unable to interpret recent changes to the manifest format
@@ -307,7 +419,7 @@ func pullApp(req AppPullRequest, state *pullState) (*app.Application, error) {
 	if req.MetadataOnly {
 		env, err = req.SrcPack.ReadPackageEnvelope(req.Package)
 	} else {
-		env, reader, err = req.SrcPack.ReadPackage(req.Package)
+		env, reader, err = readPackage(req.FieldLogger, req.SrcPack, req.DstPack, req.Cache, req.Package)
 	}
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -321,11 +433,21 @@ func pullApp(req AppPullRequest, state *pullState) (*app.Application, error) {
 	}
 	defer reader.Close()
 
+	labels := req.Labels
+	if signature := env.Signature(); signature != "" {
+		labels = utils.CombineLabels(labels,
+			map[string]string{pack.SignatureLabel: signature})
+	}
+	if lifecycle, ok := env.RuntimeLabels[pack.LifecycleLabel]; ok {
+		labels = utils.CombineLabels(labels,
+			map[string]string{pack.LifecycleLabel: lifecycle})
+	}
+
 	if req.Upsert {
-		application, err = req.DstApp.UpsertApp(env.Locator, reader, req.Labels)
+		application, err = req.DstApp.UpsertApp(env.Locator, reader, labels)
 	} else {
 		application, err = req.DstApp.CreateAppWithManifest(
-			env.Locator, env.Manifest, reader, req.Labels)
+			env.Locator, env.Manifest, reader, labels)
 	}
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -344,6 +466,9 @@ func pullAppDeps(req AppPullRequest, manifest schema.Manifest, state *pullState)
 	if err := req.CheckAndSetDefaults(); err != nil {
 		return trace.Wrap(err)
 	}
+	// the top-level application decides which applications are omitted
+	// from its installer
+	state.setManifest(manifest)
 	// pull base application if any
 	base := manifest.Base()
 	if base != nil {
@@ -357,20 +482,24 @@ func pullAppDeps(req AppPullRequest, manifest schema.Manifest, state *pullState)
 	}
 
 	// pull dependent packages
-	group, ctx := run.WithContext(context.TODO(), run.WithParallel(req.Parallel))
+	var tasks []func() error
 	for _, dep := range manifest.AllPackageDependencies() {
 		if state.pulled(dep) {
 			req.Infof("Package %v already pulled.", dep)
 			continue
 		}
-		group.Go(ctx, pullPackageHandler(dep, req, state))
+		tasks = append(tasks, pullPackageHandler(dep, req, state))
 	}
-	if err := group.Wait(); err != nil {
+	if err := runParallel(context.TODO(), req.Parallel, tasks); err != nil {
 		return trace.Wrap(err)
 	}
 
 	// pull dependent applications
 	for _, dep := range manifest.Dependencies.GetApps() {
+		if state.omitted(dep) {
+			req.Infof("Skipping application %v omitted from the installer.", dep)
+			continue
+		}
 		_, err := pullApp(req.Clone(dep), state)
 		if err != nil {
 			if !trace.IsAlreadyExists(err) {
@@ -402,9 +531,28 @@ func (r *pullState) markPulled(loc loc.Locator) {
 	r.packages[loc] = struct{}{}
 }
 
+// setManifest records the manifest of the top-level application being pulled
+func (r *pullState) setManifest(manifest schema.Manifest) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.manifest == nil {
+		r.manifest = &manifest
+	}
+}
+
+// omitted returns true if the specified application has been omitted
+// from the installer of the top-level application
+func (r *pullState) omitted(app loc.Locator) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.manifest != nil && schema.ShouldOmitApp(*r.manifest, app)
+}
+
 type pullState struct {
 	mu       sync.RWMutex
 	packages map[loc.Locator]struct{}
+	// manifest is the manifest of the top-level application
+	manifest *schema.Manifest
 }
 
 // IsMetadataPackage determines if the specified package is a metadata package.
@@ -413,3 +561,73 @@ type pullState struct {
 func IsMetadataPackage(envelope pack.PackageEnvelope) bool {
 	return envelope.RuntimeLabels[pack.PurposeLabel] == pack.PurposeMetadata
 }
+
+// readPackage returns the contents of the specified package.
+// If the cache has the package with the same digest as the source package
+// service, the contents are read from the cache
+func readPackage(logger logrus.FieldLogger, src, dst, cache pack.PackageService, locator loc.Locator) (*pack.PackageEnvelope, io.ReadCloser, error) {
+	if cache != nil {
+		env, err := src.ReadPackageEnvelope(locator)
+		if err != nil {
+			return nil, nil, trace.Wrap(err)
+		}
+		reader, err := readCachedPackage(cache, *env)
+		if err == nil {
+			logger.Infof("Using cached package %v.", locator)
+			return env, reader, nil
+		}
+		if !trace.IsNotFound(err) {
+			logger.Warnf("Failed to use cached package %v: %v.", locator, err)
+		}
+	}
+	env, reader, err := readPackageDelta(logger, src, dst, locator)
+	if err == nil {
+		return env, reader, nil
+	}
+	if !trace.IsNotFound(err) {
+		logger.Warnf("Failed to pull package %v as delta, pulling full package: %v.", locator, err)
+	}
+	return src.ReadPackage(locator)
+}
+
+// readPackageDelta reads the package from the source package service as a delta
+// against the latest version of the same package in the destination package service.
+// Returns trace.NotFound if the source does not support delta transfers or
+// there is no suitable base package
+func readPackageDelta(logger logrus.FieldLogger, src, dst pack.PackageService, locator loc.Locator) (*pack.PackageEnvelope, io.ReadCloser, error) {
+	deltas, ok := src.(pack.DeltaReader)
+	if !ok || dst == nil || locator.IsAlias() {
+		return nil, nil, trace.NotFound("delta transfer is not supported")
+	}
+	base, err := pack.FindLatestPackagePredicate(dst, locator.Repository, func(env pack.PackageEnvelope) bool {
+		return env.Locator.Name == locator.Name &&
+			env.Locator.Version != locator.Version &&
+			!env.Encrypted &&
+			env.SHA256 != "" &&
+			env.SizeBytes <= defaults.PackageDeltaMaxBaseSize
+	})
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil, nil, trace.NotFound("no base package for %v", locator)
+		}
+		return nil, nil, trace.Wrap(err)
+	}
+	logger.Infof("Pulling package %v as delta against %v.", locator, base)
+	return pack.ReadPackageWithDelta(deltas, dst, locator, *base)
+}
+
+// readCachedPackage returns the contents of the package from the cache
+// if its digest matches the expected one.
+// The contents are verified against the expected digest as they are read
+func readCachedPackage(cache pack.PackageService, env pack.PackageEnvelope) (io.ReadCloser, error) {
+	cached, reader, err := cache.ReadPackage(env.Locator)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if cached.SHA512 != env.SHA512 {
+		reader.Close()
+		return nil, trace.CompareFailed("cached package %v digest %v does not match %v",
+			env.Locator, cached.SHA512, env.SHA512)
+	}
+	return pack.NewDigestReader(reader, env.SHA256), nil
+}
@@ -18,6 +18,10 @@ package service
 
 import (
 	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"path/filepath"
 	"time"
 
@@ -85,6 +89,95 @@ func (s *PullerSuite) TestPullPackage(c *C) {
 	c.Assert(trace.IsAlreadyExists(err), Equals, true)
 }
 
+func (s *PullerSuite) TestPullPackageFromCache(c *C) {
+	_, cache, _ := setupServices(c)
+	err := cache.UpsertRepository("example.com", time.Time{})
+	c.Assert(err, IsNil)
+	logger := log.WithField("test", "PullPackageFromCache")
+
+	cached := loc.MustParseLocator("example.com/cached:0.0.1")
+	stale := loc.MustParseLocator("example.com/stale:0.0.1")
+	for _, locator := range []loc.Locator{cached, stale} {
+		_, err := s.srcPack.CreatePackage(locator, bytes.NewBufferString("data"))
+		c.Assert(err, IsNil)
+	}
+	_, err = cache.CreatePackage(cached, bytes.NewBufferString("data"))
+	c.Assert(err, IsNil)
+	_, err = cache.CreatePackage(stale, bytes.NewBufferString("stale data"))
+	c.Assert(err, IsNil)
+
+	// make the source unable to serve package contents so that
+	// only the cache can provide them
+	src := &envelopeOnlyPackages{PackageService: s.srcPack}
+	_, err = PullPackage(PackagePullRequest{
+		FieldLogger: logger,
+		SrcPack:     src,
+		DstPack:     s.dstPack,
+		Package:     cached,
+		Cache:       cache,
+	})
+	c.Assert(err, IsNil)
+	_, reader, err := s.dstPack.ReadPackage(cached)
+	c.Assert(err, IsNil)
+	defer reader.Close()
+	data, err := ioutil.ReadAll(reader)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "data")
+
+	// package with a mismatched digest is pulled from the source
+	_, err = PullPackage(PackagePullRequest{
+		FieldLogger: logger,
+		SrcPack:     src,
+		DstPack:     s.dstPack,
+		Package:     stale,
+		Cache:       cache,
+	})
+	c.Assert(trace.IsAccessDenied(err), Equals, true, Commentf("expected source read, got %v", err))
+
+	// corrupted cache contents fail the pull
+	_, err = PullPackage(PackagePullRequest{
+		FieldLogger: logger,
+		SrcPack:     src,
+		DstPack:     s.dstPack,
+		Package:     cached,
+		Cache:       &corruptedPackages{PackageService: cache},
+		Upsert:      true,
+	})
+	c.Assert(trace.IsCompareFailed(err), Equals, true, Commentf("expected corrupted contents, got %v", err))
+}
+
+func (s *PullerSuite) TestPullPackagesInParallel(c *C) {
+	var requests []PackagePullRequest
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		locator := loc.MustParseLocator(fmt.Sprintf("example.com/%v:0.0.1", name))
+		_, err := s.srcPack.CreatePackage(locator, bytes.NewBufferString(name))
+		c.Assert(err, IsNil)
+		requests = append(requests, PackagePullRequest{
+			SrcPack: s.srcPack,
+			DstPack: s.dstPack,
+			Package: locator,
+		})
+	}
+	missing := loc.MustParseLocator("example.com/missing:0.0.1")
+	requests = append(requests[:2], append([]PackagePullRequest{{
+		SrcPack: s.srcPack,
+		DstPack: s.dstPack,
+		Package: missing,
+	}}, requests[2:]...)...)
+
+	err := PullPackages(context.TODO(), PackagesPullRequest{
+		Requests: requests,
+		Parallel: 2,
+	})
+	c.Assert(err, NotNil)
+	c.Assert(err, ErrorMatches, "(?s).*example.com/missing:0.0.1.*")
+
+	// a failure does not abort pulling the other packages
+	envelopes, err := s.dstPack.GetPackages("example.com")
+	c.Assert(err, IsNil)
+	c.Assert(envelopes, HasLen, 5)
+}
+
 func (s *PullerSuite) TestPullApp(c *C) {
 	s.pullApp(c, 0)
 }
@@ -205,3 +298,25 @@ type packagesByName []loc.Locator
 func (r packagesByName) Len() int           { return len(r) }
 func (r packagesByName) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
 func (r packagesByName) Less(i, j int) bool { return r[i].String() < r[j].String() }
+
+// envelopeOnlyPackages is a package service that only serves package metadata
+type envelopeOnlyPackages struct {
+	pack.PackageService
+}
+
+func (r *envelopeOnlyPackages) ReadPackage(loc loc.Locator) (*pack.PackageEnvelope, io.ReadCloser, error) {
+	return nil, nil, trace.AccessDenied("reading package contents is not allowed")
+}
+
+// corruptedPackages serves package contents that do not match the package digest
+type corruptedPackages struct {
+	pack.PackageService
+}
+
+func (r *corruptedPackages) ReadPackage(loc loc.Locator) (*pack.PackageEnvelope, io.ReadCloser, error) {
+	env, err := r.PackageService.ReadPackageEnvelope(loc)
+	if err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+	return env, ioutil.NopCloser(bytes.NewBufferString("corrupted")), nil
+}
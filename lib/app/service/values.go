@@ -0,0 +1,119 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"strconv"
+
+	"github.com/gravitational/gravity/lib/app/hooks"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/schema"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// hookValues collects the cluster values for the specified hook and makes
+// sure that all values the hook requires are present
+func (r *applications) hookValues(client *kubernetes.Clientset, hook *schema.Hook) (*hooks.Values, error) {
+	values := hooks.NewValues()
+	if err := r.setClusterValues(values); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	nodes, err := client.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var masters int
+	for _, node := range nodes.Items {
+		if node.Labels[defaults.KubernetesRoleLabel] == defaults.RoleMaster {
+			masters++
+		}
+	}
+	values.Set(hooks.ValueClusterNodeCount, strconv.Itoa(len(nodes.Items)))
+	values.Set(hooks.ValueClusterMasterCount, strconv.Itoa(masters))
+
+	if hook.Values == nil {
+		return values, nil
+	}
+	for _, ref := range hook.Values.ConfigMaps {
+		namespace, name, err := schema.ParseConfigMapRef(ref)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		configMap, err := client.CoreV1().ConfigMaps(namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			return nil, trace.Wrap(err, "failed to fetch config map %v/%v for hook %v",
+				namespace, name, hook.Type)
+		}
+		values.SetConfigMap(namespace, name, configMap.Data)
+	}
+	if err := values.Require(hook.Values.Required...); err != nil {
+		return nil, trace.Wrap(err, "hook %v cannot run", hook.Type)
+	}
+	return values, nil
+}
+
+// setClusterValues adds the values derived from the local cluster
+// and its install operation
+func (r *applications) setClusterValues(values *hooks.Values) error {
+	values.Set(hooks.ValuePodCIDR, defaults.PodSubnet)
+	values.Set(hooks.ValueServiceCIDR, defaults.ServiceSubnet)
+
+	cluster, err := r.Backend.GetLocalSite(defaults.SystemAccountID)
+	if err != nil {
+		if trace.IsNotFound(err) {
+			// no cluster, e.g. hooks run outside of a cluster context
+			return nil
+		}
+		return trace.Wrap(err)
+	}
+	values.Set(hooks.ValueClusterName, cluster.Domain)
+
+	operations, err := r.Backend.GetSiteOperations(cluster.Domain)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	install := installOperation(operations)
+	if install == nil || install.InstallExpand == nil {
+		return nil
+	}
+	vars := install.InstallExpand.Vars
+	if vars.OnPrem.PodCIDR != "" {
+		values.Set(hooks.ValuePodCIDR, vars.OnPrem.PodCIDR)
+	}
+	if vars.OnPrem.ServiceCIDR != "" {
+		values.Set(hooks.ValueServiceCIDR, vars.OnPrem.ServiceCIDR)
+	}
+	values.SetParameters(vars.Parameters)
+	return nil
+}
+
+// installOperation returns the install operation from the provided list
+// or nil if there is none
+func installOperation(operations []storage.SiteOperation) *storage.SiteOperation {
+	for i, operation := range operations {
+		if operation.Type == ops.OperationInstall {
+			return &operations[i]
+		}
+	}
+	return nil
+}
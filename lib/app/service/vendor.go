@@ -42,7 +42,6 @@ import (
 	"github.com/gravitational/gravity/lib/schema"
 	"github.com/gravitational/gravity/lib/utils"
 
-	dockerarchive "github.com/docker/docker/pkg/archive"
 	teleutils "github.com/gravitational/teleport/lib/utils"
 	"github.com/gravitational/trace"
 	log "github.com/sirupsen/logrus"
@@ -129,6 +128,8 @@ type VendorRequest struct {
 	// If < 0, the number of tasks is unrestricted.
 	// If in [0,1], the tasks are executed sequentially.
 	Parallel int
+	// InstallerProfile is the optional installer profile to apply to the manifest
+	InstallerProfile string
 	// ProgressReporter is a special writer, if set, vendorer will output user-friendly
 	// information during vendoring
 	ProgressReporter utils.Progress
@@ -153,7 +154,7 @@ func (v *vendorer) VendorTarball(ctx context.Context, tarball io.ReadCloser, req
 		return "", trace.Wrap(err)
 	}
 
-	if err = dockerarchive.Untar(tarball, unpackedDir, archive.DefaultOptions()); err != nil {
+	if err = archive.Untar(tarball, unpackedDir, archive.DefaultOptions()); err != nil {
 		return "", trace.Wrap(err)
 	}
 	req.ManifestPath = filepath.Join(unpackedDir, defaults.ResourcesDir, defaults.ManifestFileName)
@@ -237,6 +238,7 @@ func (v *vendorer) VendorDir(ctx context.Context, unpackedDir string, req Vendor
 		makeRewriteDepsFunc(req.SetDeps),
 		makeRewritePackagesMetadataFunc(v.packages),
 		makeRewriteAppMetadataFunc(req.Repository, req.PackageName, req.PackageVersion),
+		makeRewriteInstallerProfileFunc(req.InstallerProfile),
 	}
 	if req.VendorRuntime {
 		manifestRewrites = append(manifestRewrites, fetchRuntimeImages(&runtimeImages))
@@ -539,6 +541,13 @@ func makeRewriteAppMetadataFunc(setRepository, setName, setVersion string) resou
 	}
 }
 
+// makeRewriteInstallerProfileFunc returns a function to apply the installer profile to the manifest
+func makeRewriteInstallerProfileFunc(profile string) resources.ManifestRewriteFunc {
+	return func(m *schema.Manifest) error {
+		return trace.Wrap(schema.ApplyInstallerProfile(m, profile))
+	}
+}
+
 // makeRewriteMultiSourceFunc returns a function that rewrites "multi-source" values in manifest
 // with their literal values
 func makeRewriteMultiSourceFunc(manifestPath string) resources.ManifestRewriteFunc {
@@ -710,6 +719,30 @@ func resourceFromChart(path string) (*resources.Resource, error) {
 	return resources.Decode(bytes.NewReader(out))
 }
 
+// ResourceImages returns the list of container images referenced by the resource
+// files and Helm charts found in the specified directory.
+// The resource files are not modified
+func ResourceImages(dir, manifestPath string, includePatterns, ignorePatterns []string) ([]string, error) {
+	resourceFiles, chartResources, err := resourcesFromPath(dir, includePatterns, ignorePatterns)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	// resolve hook jobs that might also contain image references
+	err = resourceFiles.RewriteManifest(makeRewriteMultiSourceFunc(manifestPath))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	images, err := resourceFiles.Images()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	chartImages, err := chartResources.Images()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return teleutils.Deduplicate(append(images, chartImages...)), nil
+}
+
 // resourcesFromPath collects resource files in root for further processing.
 // It will search for files starting with root and matching a set of file path patterns
 // specified with patterns.
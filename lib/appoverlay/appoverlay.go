@@ -0,0 +1,257 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package appoverlay applies local customizations of bundled system
+// applications, such as additional Grafana dashboards or changed retention
+// settings, on top of the applications after they have been installed or
+// upgraded so the customizations survive runtime upgrades.
+//
+// The customizations are described with the appoverlay cluster resource
+// and consist of config maps and secrets to create and of patches of the
+// objects created by the application
+package appoverlay
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gravitational/gravity/lib/app/resources"
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/ghodss/yaml"
+	"github.com/gravitational/rigging"
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubelabels "k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Objects returns the config maps and secrets the overlay creates.
+// The objects are labeled with the name of the customized application
+func Objects(overlay storage.AppOverlay) ([]runtime.Object, error) {
+	if strings.TrimSpace(overlay.GetResources()) == "" {
+		return nil, nil
+	}
+	var objects []runtime.Object
+	err := resources.ForEachObject(strings.NewReader(overlay.GetResources()), func(object runtime.Object) error {
+		var meta *metav1.ObjectMeta
+		switch resource := object.(type) {
+		case *v1.ConfigMap:
+			meta = &resource.ObjectMeta
+		case *v1.Secret:
+			meta = &resource.ObjectMeta
+		default:
+			return trace.BadParameter("overlay of %v can only create config maps and secrets, got %T",
+				overlay.GetName(), object)
+		}
+		if meta.Namespace == "" {
+			meta.Namespace = metav1.NamespaceDefault
+		}
+		if meta.Labels == nil {
+			meta.Labels = make(map[string]string)
+		}
+		meta.Labels[constants.AppOverlayLabel] = overlay.GetName()
+		objects = append(objects, object)
+		return nil
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return objects, nil
+}
+
+// Check verifies that the overlay resources can be decoded and
+// the patches target supported objects
+func Check(overlay storage.AppOverlay) error {
+	if err := overlay.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	if _, err := Objects(overlay); err != nil {
+		return trace.Wrap(err)
+	}
+	for _, patch := range overlay.GetPatches() {
+		if !isPatchable(patch.Kind) {
+			return trace.BadParameter("unsupported kind %q in patch of %v, supported are: %v",
+				patch.Kind, patch.Name, PatchableKinds)
+		}
+		if _, err := patchData(patch); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// Apply creates the overlay objects, removes the objects created by the
+// previous version of the overlay and applies the patches
+func Apply(ctx context.Context, client *kubernetes.Clientset, overlay storage.AppOverlay, logger logrus.FieldLogger) error {
+	if err := Check(overlay); err != nil {
+		return trace.Wrap(err)
+	}
+	objects, err := Objects(overlay)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	keep := make(map[string]bool)
+	for _, object := range objects {
+		control, err := newControl(client, object)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if err := control.Upsert(ctx); err != nil {
+			return trace.Wrap(err)
+		}
+		keep[objectKey(object)] = true
+	}
+	if err := prune(client, overlay.GetName(), keep); err != nil {
+		return trace.Wrap(err)
+	}
+	for _, patch := range overlay.GetPatches() {
+		logger.Infof("Patching %v.", patch)
+		if err := applyPatch(client, patch); err != nil {
+			return trace.Wrap(err, "failed to patch %v", patch)
+		}
+	}
+	return nil
+}
+
+// Remove deletes the objects created by the overlay of the specified
+// application. Patched objects are left as they are until the
+// application is upgraded or reinstalled
+func Remove(client *kubernetes.Clientset, appName string) error {
+	return trace.Wrap(prune(client, appName, nil))
+}
+
+// PatchableKinds lists the kinds of objects overlays can patch
+var PatchableKinds = []string{
+	"ConfigMap", "Secret", "Service", "Deployment", "DaemonSet", "StatefulSet",
+}
+
+func isPatchable(kind string) bool {
+	for _, patchable := range PatchableKinds {
+		if patchable == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// applyPatch applies the patch to the object it targets
+func applyPatch(client *kubernetes.Clientset, patch storage.AppOverlayPatch) error {
+	data, err := patchData(patch)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	patchType := patchTypes[patch.Type]
+	switch patch.Kind {
+	case "ConfigMap":
+		_, err = client.CoreV1().ConfigMaps(patch.Namespace).Patch(patch.Name, patchType, data)
+	case "Secret":
+		_, err = client.CoreV1().Secrets(patch.Namespace).Patch(patch.Name, patchType, data)
+	case "Service":
+		_, err = client.CoreV1().Services(patch.Namespace).Patch(patch.Name, patchType, data)
+	case "Deployment":
+		_, err = client.AppsV1().Deployments(patch.Namespace).Patch(patch.Name, patchType, data)
+	case "DaemonSet":
+		_, err = client.AppsV1().DaemonSets(patch.Namespace).Patch(patch.Name, patchType, data)
+	case "StatefulSet":
+		_, err = client.AppsV1().StatefulSets(patch.Namespace).Patch(patch.Name, patchType, data)
+	default:
+		return trace.BadParameter("unsupported kind %q", patch.Kind)
+	}
+	return trace.Wrap(rigging.ConvertError(err))
+}
+
+// patchData returns the patch in JSON format
+func patchData(patch storage.AppOverlayPatch) ([]byte, error) {
+	data, err := yaml.YAMLToJSON([]byte(patch.Patch))
+	if err != nil {
+		return nil, trace.BadParameter("invalid patch of %v: %v", patch, err)
+	}
+	return data, nil
+}
+
+var patchTypes = map[string]types.PatchType{
+	storage.AppOverlayPatchStrategic: types.StrategicMergePatchType,
+	storage.AppOverlayPatchMerge:     types.MergePatchType,
+	storage.AppOverlayPatchJSON:      types.JSONPatchType,
+}
+
+// prune deletes the config maps and secrets created by the overlay of
+// the specified application except for the ones in keep
+func prune(client *kubernetes.Clientset, appName string, keep map[string]bool) error {
+	options := metav1.ListOptions{
+		LabelSelector: kubelabels.Set{constants.AppOverlayLabel: appName}.String(),
+	}
+	configMaps, err := client.CoreV1().ConfigMaps(metav1.NamespaceAll).List(options)
+	if err != nil {
+		return trace.Wrap(rigging.ConvertError(err))
+	}
+	for _, configMap := range configMaps.Items {
+		if keep[objectKey(&configMap)] {
+			continue
+		}
+		err := client.CoreV1().ConfigMaps(configMap.Namespace).Delete(configMap.Name, nil)
+		if err := rigging.ConvertError(err); err != nil && !trace.IsNotFound(err) {
+			return trace.Wrap(err)
+		}
+	}
+	secrets, err := client.CoreV1().Secrets(metav1.NamespaceAll).List(options)
+	if err != nil {
+		return trace.Wrap(rigging.ConvertError(err))
+	}
+	for _, secret := range secrets.Items {
+		if keep[objectKey(&secret)] {
+			continue
+		}
+		err := client.CoreV1().Secrets(secret.Namespace).Delete(secret.Name, nil)
+		if err := rigging.ConvertError(err); err != nil && !trace.IsNotFound(err) {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// objectKey returns a key identifying the config map or secret
+func objectKey(object runtime.Object) string {
+	switch resource := object.(type) {
+	case *v1.ConfigMap:
+		return "configmap/" + resource.Namespace + "/" + resource.Name
+	case *v1.Secret:
+		return "secret/" + resource.Namespace + "/" + resource.Name
+	}
+	return ""
+}
+
+// control manages a single Kubernetes object
+type control interface {
+	// Upsert creates or updates the object
+	Upsert(context.Context) error
+}
+
+func newControl(client *kubernetes.Clientset, object runtime.Object) (control, error) {
+	switch resource := object.(type) {
+	case *v1.ConfigMap:
+		return rigging.NewConfigMapControl(rigging.ConfigMapConfig{ConfigMap: resource, Client: client})
+	case *v1.Secret:
+		return rigging.NewSecretControl(rigging.SecretConfig{Secret: resource, Client: client})
+	}
+	return nil, trace.BadParameter("unsupported overlay resource %T", object)
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package appoverlay
+
+import (
+	"testing"
+
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/storage"
+
+	check "gopkg.in/check.v1"
+	"k8s.io/api/core/v1"
+)
+
+func TestAppOverlay(t *testing.T) { check.TestingT(t) }
+
+type AppOverlaySuite struct{}
+
+var _ = check.Suite(&AppOverlaySuite{})
+
+func (s *AppOverlaySuite) TestLabelsObjects(c *check.C) {
+	overlay := storage.NewAppOverlay("monitoring-app", storage.AppOverlaySpecV2{
+		Resources: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: dashboards
+  namespace: monitoring
+  labels:
+    monitoring: dashboard
+data:
+  nodes.json: "{}"
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: grafana-creds
+stringData:
+  password: secret
+`,
+	})
+	objects, err := Objects(overlay)
+	c.Assert(err, check.IsNil)
+	c.Assert(objects, check.HasLen, 2)
+
+	configMap, ok := objects[0].(*v1.ConfigMap)
+	c.Assert(ok, check.Equals, true)
+	c.Assert(configMap.Labels, check.DeepEquals, map[string]string{
+		"monitoring":              "dashboard",
+		constants.AppOverlayLabel: "monitoring-app",
+	})
+	c.Assert(objectKey(configMap), check.Equals, "configmap/monitoring/dashboards")
+
+	secret, ok := objects[1].(*v1.Secret)
+	c.Assert(ok, check.Equals, true)
+	c.Assert(secret.Namespace, check.Equals, "default")
+	c.Assert(secret.Labels[constants.AppOverlayLabel], check.Equals, "monitoring-app")
+}
+
+func (s *AppOverlaySuite) TestValidatesOverlay(c *check.C) {
+	valid := storage.NewAppOverlay("logging-app", storage.AppOverlaySpecV2{
+		Patches: []storage.AppOverlayPatch{{
+			Kind:      "Deployment",
+			Namespace: "kube-system",
+			Name:      "log-collector",
+			Patch:     "spec:\n  replicas: 2\n",
+		}},
+	})
+	c.Assert(Check(valid), check.IsNil)
+
+	for _, spec := range []storage.AppOverlaySpecV2{
+		{Resources: "apiVersion: v1\nkind: Service\nmetadata:\n  name: grafana\n"},
+		{Patches: []storage.AppOverlayPatch{{Kind: "Pod", Name: "grafana", Patch: "{}"}}},
+		{Patches: []storage.AppOverlayPatch{{Kind: "ConfigMap", Name: "grafana", Patch: "data: [unterminated"}}},
+	} {
+		overlay := storage.NewAppOverlay("monitoring-app", spec)
+		c.Assert(Check(overlay), check.NotNil, check.Commentf("%#v", spec))
+	}
+}
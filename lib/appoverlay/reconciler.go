@@ -0,0 +1,161 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package appoverlay
+
+import (
+	"context"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ReconcilerConfig configures the application overlay reconciler
+type ReconcilerConfig struct {
+	// Operator is the cluster operator service
+	Operator ops.Operator
+	// Client is the Kubernetes client
+	Client *kubernetes.Clientset
+	// Interval is how often the overlays are reconciled
+	Interval time.Duration
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+}
+
+// CheckAndSetDefaults validates the configuration and sets default values
+func (r *ReconcilerConfig) CheckAndSetDefaults() error {
+	if r.Operator == nil {
+		return trace.BadParameter("missing Operator")
+	}
+	if r.Client == nil {
+		return trace.BadParameter("missing Client")
+	}
+	if r.Interval == 0 {
+		r.Interval = defaults.AppOverlaySyncInterval
+	}
+	if r.FieldLogger == nil {
+		r.FieldLogger = logrus.WithField(trace.Component, "appoverlay")
+	}
+	return nil
+}
+
+// NewReconciler returns a new application overlay reconciler
+func NewReconciler(config ReconcilerConfig) (*Reconciler, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &Reconciler{
+		ReconcilerConfig: config,
+		applied:          make(map[string]appliedOverlay),
+		cleaned:          make(map[string]bool),
+	}, nil
+}
+
+// Reconciler applies application overlays whenever either the overlay
+// or the version of the customized application changes
+type Reconciler struct {
+	ReconcilerConfig
+	// applied maps application names to the overlays applied last
+	applied map[string]appliedOverlay
+	// cleaned maps application names to whether the objects created by
+	// their deleted overlays have been removed
+	cleaned map[string]bool
+}
+
+// Run reconciles the overlays periodically until the context is canceled
+func (r *Reconciler) Run(ctx context.Context) error {
+	utils.RunPeriodically(ctx, r.Interval, r.FieldLogger,
+		"reconcile application overlays", r.Sync)
+	return nil
+}
+
+// Sync applies the overlays that have changed or whose applications
+// have been upgraded since the last time, and removes the objects
+// created by the overlays that have been deleted
+func (r *Reconciler) Sync(ctx context.Context) error {
+	cluster, err := r.Operator.GetLocalSite()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	overlays, err := r.Operator.GetAppOverlays(cluster.Key())
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	var errors []error
+	configured := make(map[string]bool)
+	for _, overlay := range overlays {
+		configured[overlay.GetName()] = true
+		locator, err := cluster.App.Manifest.Dependencies.ByName(overlay.GetName())
+		if err != nil {
+			if trace.IsNotFound(err) {
+				continue
+			}
+			return trace.Wrap(err)
+		}
+		if applied, ok := r.applied[overlay.GetName()]; ok && applied.equals(*locator, overlay) {
+			continue
+		}
+		r.Infof("Applying overlay of %v.", locator)
+		if err := Apply(ctx, r.Client, overlay, r.FieldLogger); err != nil {
+			errors = append(errors, trace.Wrap(err, "failed to apply overlay of %v", locator))
+			continue
+		}
+		r.applied[overlay.GetName()] = appliedOverlay{locator: *locator, overlay: overlay}
+		r.cleaned[overlay.GetName()] = false
+	}
+	for _, appName := range storage.OverlayApps {
+		if configured[appName] {
+			continue
+		}
+		if _, ok := r.applied[appName]; ok {
+			r.Infof("Overlay of %v has been deleted.", appName)
+			delete(r.applied, appName)
+		}
+		if r.cleaned[appName] {
+			continue
+		}
+		if err := Remove(r.Client, appName); err != nil {
+			errors = append(errors, trace.Wrap(err))
+			continue
+		}
+		r.cleaned[appName] = true
+	}
+	return trace.NewAggregate(errors...)
+}
+
+// appliedOverlay is the overlay applied on top of the specific
+// application package
+type appliedOverlay struct {
+	locator loc.Locator
+	overlay storage.AppOverlay
+}
+
+func (r appliedOverlay) equals(locator loc.Locator, overlay storage.AppOverlay) bool {
+	if !r.locator.IsEqualTo(locator) {
+		return false
+	}
+	dataA, errA := storage.MarshalAppOverlay(r.overlay)
+	dataB, errB := storage.MarshalAppOverlay(overlay)
+	return errA == nil && errB == nil && string(dataA) == string(dataB)
+}
@@ -0,0 +1,217 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package archive
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/gravitational/gravity/lib/constants"
+
+	dockerarchive "github.com/docker/docker/pkg/archive"
+	"github.com/gravitational/trace"
+	"github.com/klauspost/compress/zstd"
+	log "github.com/sirupsen/logrus"
+)
+
+// Compression defines the compression format of package tarballs
+type Compression string
+
+const (
+	// CompressionGzip is the gzip compression
+	CompressionGzip Compression = "gzip"
+	// CompressionZstd is the zstd compression
+	CompressionZstd Compression = "zstd"
+)
+
+// Check makes sure the compression is supported
+func (r Compression) Check() error {
+	switch r {
+	case CompressionGzip, CompressionZstd:
+		return nil
+	}
+	return trace.BadParameter("unsupported compression %q, supported are: %v, %v",
+		r, CompressionGzip, CompressionZstd)
+}
+
+// DefaultCompression returns the compression used for newly created packages.
+// Packages are gzip-compressed unless overridden with the
+// GRAVITY_PACKAGE_COMPRESSION environment variable
+func DefaultCompression() Compression {
+	value := os.Getenv(constants.PackageCompressionEnvVar)
+	if value == "" {
+		return CompressionGzip
+	}
+	compression := Compression(value)
+	if err := compression.Check(); err != nil {
+		log.Warnf("Ignoring %v: %v.", constants.PackageCompressionEnvVar, err)
+		return CompressionGzip
+	}
+	return compression
+}
+
+// CompressStream returns a writer that compresses the data written
+// to it using the specified compression and writes it into w.
+// The returned writer must be closed to flush the compressed data.
+//
+// The data is compressed in independent chunks (gzip members or zstd frames)
+// split at content-defined boundaries, so that data shared by two versions of
+// a package compresses to the same bytes and can be skipped by delta transfers
+func CompressStream(w io.Writer, compression Compression) (io.WriteCloser, error) {
+	switch compression {
+	case CompressionGzip:
+		return newRsyncableWriter(w, gzip.NewWriter(w)), nil
+	case CompressionZstd:
+		encoder, err := zstd.NewWriter(w)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		return newRsyncableWriter(w, encoder), nil
+	}
+	return nil, trace.Wrap(compression.Check())
+}
+
+// DecompressStream returns a reader with the decompressed contents of the
+// specified stream. The compression format is detected automatically:
+// zstd streams are decoded here, all other formats are handled by the docker
+// archive package
+func DecompressStream(r io.Reader) (io.ReadCloser, error) {
+	buf := bufio.NewReader(r)
+	magic, err := buf.Peek(len(zstdMagic))
+	if err != nil && err != io.EOF {
+		return nil, trace.ConvertSystemError(err)
+	}
+	if !bytes.Equal(magic, zstdMagic) {
+		decompressed, err := dockerarchive.DecompressStream(buf)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		return decompressed, nil
+	}
+	decoder, err := zstd.NewReader(buf)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return decoder.IOReadCloser(), nil
+}
+
+// Tar returns a tarball of the specified directory compressed
+// using the specified compression
+func Tar(dir string, options *dockerarchive.TarOptions, compression Compression) (io.ReadCloser, error) {
+	if err := compression.Check(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	opts := *options
+	opts.Compression = dockerarchive.Uncompressed
+	tarball, err := dockerarchive.TarWithOptions(dir, &opts)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	reader, writer := io.Pipe()
+	go func() {
+		defer tarball.Close()
+		writer.CloseWithError(compress(writer, tarball, compression))
+	}()
+	return reader, nil
+}
+
+// Untar extracts the specified tarball compressed with any
+// of the supported compressions into dir
+func Untar(r io.Reader, dir string, options *dockerarchive.TarOptions) error {
+	decompressed, err := DecompressStream(r)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer decompressed.Close()
+	return trace.Wrap(dockerarchive.Untar(decompressed, dir, options))
+}
+
+// UntarWithProgress extracts the specified tarball compressed with any
+// of the supported compressions into dir like Untar and invokes onEntry
+// with the header of each archive entry before it is extracted
+func UntarWithProgress(r io.Reader, dir string, options *dockerarchive.TarOptions, onEntry func(*tar.Header)) error {
+	decompressed, err := DecompressStream(r)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer decompressed.Close()
+	// the docker archive package does not expose the entries as they are
+	// extracted, so the stream is re-encoded through a pipe to intercept them
+	reader, writer := io.Pipe()
+	errC := make(chan error, 1)
+	go func() {
+		err := copyTarEntries(writer, decompressed, onEntry)
+		writer.CloseWithError(err)
+		errC <- err
+	}()
+	err = dockerarchive.Untar(reader, dir, options)
+	if err == nil {
+		// consume the remainder of the tarball (e.g. the end of archive marker)
+		_, err = io.Copy(ioutil.Discard, reader)
+	}
+	// unblock the copying goroutine if extraction failed
+	reader.CloseWithError(err)
+	if copyErr := <-errC; copyErr != nil && err == nil {
+		return trace.Wrap(copyErr)
+	}
+	return trace.Wrap(err)
+}
+
+// copyTarEntries copies the entries of the tarball from r to w
+// invoking onEntry for each entry
+func copyTarEntries(w io.Writer, r io.Reader, onEntry func(*tar.Header)) error {
+	tarball := tar.NewReader(r)
+	out := tar.NewWriter(w)
+	for {
+		header, err := tarball.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		onEntry(header)
+		if err := out.WriteHeader(header); err != nil {
+			return trace.Wrap(err)
+		}
+		if _, err := io.Copy(out, tarball); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return trace.Wrap(out.Close())
+}
+
+// compress writes the data from r compressed using the specified compression into w
+func compress(w io.Writer, r io.Reader, compression Compression) error {
+	compressed, err := CompressStream(w, compression)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if _, err := io.Copy(compressed, r); err != nil {
+		compressed.Close()
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(compressed.Close())
+}
+
+// zstdMagic is the magic number that starts a zstd frame
+var zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
@@ -0,0 +1,103 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package archive
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+
+	"github.com/gravitational/gravity/lib/constants"
+
+	. "gopkg.in/check.v1"
+)
+
+type CompressionSuite struct{}
+
+var _ = Suite(&CompressionSuite{})
+
+func (_ *CompressionSuite) TestRoundtrip(c *C) {
+	dir := c.MkDir()
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "app.yaml"), []byte(manifestBytes), 0644), IsNil)
+	c.Assert(os.MkdirAll(filepath.Join(dir, "resources"), 0755), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "resources", "data"), []byte("data"), 0644), IsNil)
+
+	for _, compression := range []Compression{CompressionGzip, CompressionZstd} {
+		comment := Commentf("compression %v", compression)
+		tarball, err := Tar(dir, DefaultOptions(), compression)
+		c.Assert(err, IsNil, comment)
+		data, err := ioutil.ReadAll(tarball)
+		c.Assert(err, IsNil, comment)
+		c.Assert(tarball.Close(), IsNil, comment)
+		c.Assert(bytes.HasPrefix(data, zstdMagic), Equals, compression == CompressionZstd, comment)
+
+		AssertArchiveHasFiles(c, ioutil.NopCloser(bytes.NewReader(data)), nil,
+			"app.yaml", "resources", "resources/data")
+
+		target := c.MkDir()
+		c.Assert(Untar(bytes.NewReader(data), target, DefaultOptions()), IsNil, comment)
+		contents, err := ioutil.ReadFile(filepath.Join(target, "resources", "data"))
+		c.Assert(err, IsNil, comment)
+		c.Assert(string(contents), Equals, "data", comment)
+	}
+}
+
+func (_ *CompressionSuite) TestDecompressesUncompressedStream(c *C) {
+	input := MustCreateMemArchive([]*Item{ItemFromString("app.yaml", manifestBytes)})
+	AssertArchiveHasFiles(c, ioutil.NopCloser(input), nil, "app.yaml")
+}
+
+func (_ *CompressionSuite) TestDefaultCompression(c *C) {
+	defer os.Unsetenv(constants.PackageCompressionEnvVar)
+	c.Assert(DefaultCompression(), Equals, CompressionGzip)
+	os.Setenv(constants.PackageCompressionEnvVar, "zstd")
+	c.Assert(DefaultCompression(), Equals, CompressionZstd)
+	os.Setenv(constants.PackageCompressionEnvVar, "lz4")
+	c.Assert(DefaultCompression(), Equals, CompressionGzip)
+}
+
+func (_ *CompressionSuite) TestCompressionResynchronizes(c *C) {
+	data := make([]byte, 8*1024*1024)
+	rand.New(rand.NewSource(1)).Read(data)
+	changed := append([]byte{}, data...)
+	changed[1024*1024] ^= 0xff
+
+	for _, compression := range []Compression{CompressionGzip, CompressionZstd} {
+		comment := Commentf("compression %v", compression)
+		a, b := compressBytes(c, data, compression), compressBytes(c, changed, compression)
+		// the compressed streams share most of the data after the change
+		var suffix int
+		for suffix < len(a) && suffix < len(b) && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+			suffix++
+		}
+		c.Assert(suffix > len(a)/2, Equals, true, Commentf("common suffix %v of %v, %v", suffix, len(a), compression))
+
+		decompressed, err := DecompressStream(bytes.NewReader(b))
+		c.Assert(err, IsNil, comment)
+		out, err := ioutil.ReadAll(decompressed)
+		c.Assert(err, IsNil, comment)
+		c.Assert(bytes.Equal(out, changed), Equals, true, comment)
+	}
+}
+
+func compressBytes(c *C, data []byte, compression Compression) []byte {
+	var buf bytes.Buffer
+	c.Assert(compress(&buf, bytes.NewReader(data), compression), IsNil)
+	return buf.Bytes()
+}
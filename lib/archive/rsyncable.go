@@ -0,0 +1,106 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package archive
+
+import (
+	"io"
+
+	"github.com/gravitational/trace"
+)
+
+// resettableWriter is a compressor that can start a new
+// independent stream (gzip member or zstd frame) once closed
+type resettableWriter interface {
+	io.WriteCloser
+	// Reset discards the compressor state and makes it write to w
+	Reset(w io.Writer)
+}
+
+// newRsyncableWriter returns a writer that compresses the data with
+// the compressor, restarting it at content-defined chunk boundaries.
+//
+// The boundaries are found with a gear rolling hash over the uncompressed
+// data, so they only depend on the few bytes preceding them: an insertion or
+// a change only alters the compressed output of the chunks it falls into,
+// similar to gzip --rsyncable
+func newRsyncableWriter(w io.Writer, compressor resettableWriter) *rsyncableWriter {
+	return &rsyncableWriter{
+		w:          w,
+		compressor: compressor,
+	}
+}
+
+type rsyncableWriter struct {
+	w          io.Writer
+	compressor resettableWriter
+	// hash is the rolling hash of the most recent input
+	hash uint64
+	// size is the number of bytes written into the current chunk
+	size int64
+}
+
+// Write compresses p restarting the compressor at chunk boundaries
+func (r *rsyncableWriter) Write(p []byte) (int, error) {
+	var written int
+	for i, b := range p {
+		r.hash = r.hash<<1 + gearTable[b]
+		r.size++
+		if r.size < rsyncableMinChunkSize || r.hash&rsyncableChunkMask != 0 {
+			continue
+		}
+		n, err := r.compressor.Write(p[written : i+1])
+		written += n
+		if err != nil {
+			return written, trace.Wrap(err)
+		}
+		if err := r.compressor.Close(); err != nil {
+			return written, trace.Wrap(err)
+		}
+		r.compressor.Reset(r.w)
+		r.size = 0
+	}
+	n, err := r.compressor.Write(p[written:])
+	written += n
+	return written, trace.Wrap(err)
+}
+
+// Close flushes the last chunk
+func (r *rsyncableWriter) Close() error {
+	return trace.Wrap(r.compressor.Close())
+}
+
+const (
+	// rsyncableMinChunkSize is the minimum size of the chunk compressed independently
+	rsyncableMinChunkSize = 64 * 1024
+	// rsyncableChunkMask defines the average chunk size of 1MB
+	rsyncableChunkMask = 1<<20 - 1
+)
+
+// gearTable maps bytes to the random values mixed into the rolling hash.
+// The values must never change as the chunk boundaries depend on them
+var gearTable = func() (table [256]uint64) {
+	// splitmix64 with a fixed seed
+	seed := uint64(0x6772617669747900)
+	for i := range table {
+		seed += 0x9e3779b97f4a7c15
+		z := seed
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		table[i] = z ^ (z >> 31)
+	}
+	return table
+}()
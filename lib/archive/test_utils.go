@@ -26,7 +26,6 @@ import (
 	"path/filepath"
 	"regexp"
 
-	"github.com/docker/docker/pkg/archive"
 	"github.com/gravitational/trace"
 	. "gopkg.in/check.v1"
 )
@@ -54,7 +53,7 @@ func FetchFiles(r io.ReadCloser, excludePatterns []string) (map[string]string, e
 	}
 
 	out := make(map[string]string)
-	stream, err := archive.DecompressStream(r)
+	stream, err := DecompressStream(r)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -89,7 +88,7 @@ archiveLoop:
 func AssertArchiveHasItems(c *C, r io.ReadCloser, excludePatterns []string, items ...TestItem) {
 	defer r.Close()
 
-	stream, err := archive.DecompressStream(r)
+	stream, err := DecompressStream(r)
 	c.Assert(err, IsNil)
 
 	var excludes []*regexp.Regexp
@@ -0,0 +1,193 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package baker pre-installs the packages of a cluster image into a node
+// image, so nodes booted from the image do not have to download and unpack
+// them when they join a cluster.
+// Application container images are not pre-installed, nodes pull them from
+// the cluster registry when they join
+package baker
+
+import (
+	"path/filepath"
+
+	"github.com/gravitational/gravity/lib/app"
+	"github.com/gravitational/gravity/lib/app/service"
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/localenv"
+	"github.com/gravitational/gravity/lib/pack"
+	"github.com/gravitational/gravity/lib/schema"
+	"github.com/gravitational/gravity/lib/state"
+
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+)
+
+// Config defines the configuration of the node image baker
+type Config struct {
+	// Packages is the package service of the cluster image
+	Packages pack.PackageService
+	// Apps is the application service of the cluster image
+	Apps app.Applications
+	// Package is the cluster application to bake into the image
+	Package loc.Locator
+	// StateDir is the gravity state directory of the node image.
+	// Defaults to the state directory of this host
+	StateDir string
+	// Role optionally specifies the node profile the image is baked for.
+	// If unspecified, the runtime packages of all profiles are pre-installed
+	Role string
+	// Arch is the architecture of the nodes the image is baked for
+	Arch string
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+}
+
+// CheckAndSetDefaults validates the configuration and sets defaults
+func (r *Config) CheckAndSetDefaults() (err error) {
+	if r.Packages == nil {
+		return trace.BadParameter("missing Packages")
+	}
+	if r.Apps == nil {
+		return trace.BadParameter("missing Apps")
+	}
+	if r.Package.IsEmpty() {
+		return trace.BadParameter("missing Package")
+	}
+	if r.StateDir == "" {
+		r.StateDir, err = state.GetStateDir()
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	if r.Arch == "" {
+		return trace.BadParameter("missing Arch")
+	}
+	if r.FieldLogger == nil {
+		r.FieldLogger = logrus.WithField(trace.Component, "baker")
+	}
+	return nil
+}
+
+// Bake pre-seeds the cluster application with all its dependencies into
+// the package cache of the node image and unpacks the runtime packages into
+// the locations they are unpacked into when the node joins a cluster.
+//
+// No cluster or node specific state is created: the node configuration
+// packages are pulled when the node joins the cluster.
+// Returns the list of unpacked packages
+func Bake(config Config) (unpacked []loc.Locator, err error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	cacheDir := filepath.Join(config.StateDir, defaults.PackageCacheDir)
+	cache, err := localenv.New(cacheDir)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer cache.Close()
+
+	application, err := config.Apps.GetApp(config.Package)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	config.Infof("Pre-seeding %v into %v.", config.Package, cacheDir)
+	_, err = service.PullApp(service.AppPullRequest{
+		FieldLogger: config.FieldLogger,
+		SrcPack:     config.Packages,
+		DstPack:     cache.Packages,
+		SrcApp:      config.Apps,
+		DstApp:      cache.Apps,
+		Package:     config.Package,
+		Upsert:      true,
+		Parallel:    defaults.PullPackagesConcurrency,
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	locators, err := unpackedPackages(config.Packages, application.Manifest, config.Role, config.Arch)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	unpackedDir := filepath.Join(config.StateDir, defaults.LocalDir,
+		defaults.PackagesDir, defaults.UnpackedDir)
+	for _, locator := range locators {
+		// the architecture variant of the runtime package
+		// is not an application dependency
+		_, err := cache.Packages.ReadPackageEnvelope(locator)
+		if err != nil && !trace.IsNotFound(err) {
+			return nil, trace.Wrap(err)
+		}
+		if trace.IsNotFound(err) {
+			config.Infof("Pre-seeding %v into %v.", locator, cacheDir)
+			_, err = service.PullPackage(service.PackagePullRequest{
+				FieldLogger: config.FieldLogger,
+				SrcPack:     config.Packages,
+				DstPack:     cache.Packages,
+				Package:     locator,
+			})
+			if err != nil {
+				return nil, trace.Wrap(err)
+			}
+		}
+		targetDir := pack.PackagePath(unpackedDir, locator)
+		config.Infof("Unpacking %v into %v.", locator, targetDir)
+		err = pack.UnpackIfNotUnpacked(cache.Packages, locator, targetDir, pack.UnpackOptions{})
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+	return locators, nil
+}
+
+// unpackedPackages returns the packages of the application that are unpacked
+// on every node: the runtime packages built for the specified architecture
+// and the packages of the system services
+func unpackedPackages(packages pack.PackageService, manifest schema.Manifest, role, arch string) ([]loc.Locator, error) {
+	profiles := manifest.NodeProfiles
+	if role != "" {
+		profile, err := manifest.NodeProfiles.ByName(role)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		profiles = schema.NodeProfiles{*profile}
+	}
+	var locators []loc.Locator
+	for _, profile := range profiles {
+		runtimePackage, err := manifest.RuntimePackage(profile)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		runtimePackage, err = pack.ResolveArch(packages, *runtimePackage, arch)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		locators = append(locators, *runtimePackage)
+	}
+	for _, name := range []string{constants.TeleportPackage, constants.WebAssetsPackage} {
+		locator, err := manifest.Dependencies.ByName(name)
+		if err != nil && !trace.IsNotFound(err) {
+			return nil, trace.Wrap(err)
+		}
+		if locator != nil {
+			locators = append(locators, *locator)
+		}
+	}
+	return loc.Deduplicate(locators), nil
+}
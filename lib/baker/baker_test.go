@@ -0,0 +1,117 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package baker
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	apptest "github.com/gravitational/gravity/lib/app/service/test"
+	"github.com/gravitational/gravity/lib/archive"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/localenv"
+	"github.com/gravitational/gravity/lib/pack"
+
+	. "gopkg.in/check.v1"
+)
+
+func TestBaker(t *testing.T) { TestingT(t) }
+
+type BakerSuite struct{}
+
+var _ = Suite(&BakerSuite{})
+
+func (s *BakerSuite) TestBakesImage(c *C) {
+	image, err := localenv.New(c.MkDir())
+	c.Assert(err, IsNil)
+	defer image.Close()
+	planet := loc.MustParseLocator("gravitational.io/planet:0.0.1")
+	apptest.CreatePackage(image.Packages, planet, []*archive.Item{
+		archive.ItemFromString("rootfs/planet", "planet"),
+	}, c)
+	apptest.CreateRuntimeApplication(image.Apps, c)
+	application := loc.MustParseLocator("example.com/app:0.0.1")
+	apptest.CreateDummyApplication(image.Apps, application, c)
+
+	stateDir := c.MkDir()
+	config := Config{
+		Packages: image.Packages,
+		Apps:     image.Apps,
+		Package:  application,
+		StateDir: stateDir,
+		Role:     "node",
+		Arch:     loc.DefaultArch,
+	}
+	unpacked, err := Bake(config)
+	c.Assert(err, IsNil)
+	c.Assert(unpacked, DeepEquals, []loc.Locator{planet})
+
+	unpackedDir := filepath.Join(stateDir, defaults.LocalDir,
+		defaults.PackagesDir, defaults.UnpackedDir)
+	data, err := ioutil.ReadFile(filepath.Join(pack.PackagePath(unpackedDir, planet), "rootfs", "planet"))
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "planet")
+
+	cache, err := localenv.New(filepath.Join(stateDir, defaults.PackageCacheDir))
+	c.Assert(err, IsNil)
+	_, err = cache.Apps.GetApp(application)
+	c.Assert(err, IsNil)
+	_, err = cache.Packages.ReadPackageEnvelope(planet)
+	c.Assert(err, IsNil)
+	c.Assert(cache.Close(), IsNil)
+
+	// baking again is a no-op
+	_, err = Bake(config)
+	c.Assert(err, IsNil)
+}
+
+func (s *BakerSuite) TestBakesImageForArch(c *C) {
+	image, err := localenv.New(c.MkDir())
+	c.Assert(err, IsNil)
+	defer image.Close()
+	planet := loc.MustParseLocator("gravitational.io/planet:0.0.1")
+	apptest.CreatePackage(image.Packages, planet, []*archive.Item{
+		archive.ItemFromString("rootfs/planet", "planet"),
+	}, c)
+	planetARM := planet.WithArch("arm64")
+	apptest.CreatePackage(image.Packages, planetARM, []*archive.Item{
+		archive.ItemFromString("rootfs/planet", "planet-arm64"),
+	}, c)
+	apptest.CreateRuntimeApplication(image.Apps, c)
+	application := loc.MustParseLocator("example.com/app:0.0.1")
+	apptest.CreateDummyApplication(image.Apps, application, c)
+
+	stateDir := c.MkDir()
+	unpacked, err := Bake(Config{
+		Packages: image.Packages,
+		Apps:     image.Apps,
+		Package:  application,
+		StateDir: stateDir,
+		Role:     "node",
+		Arch:     "arm64",
+	})
+	c.Assert(err, IsNil)
+	c.Assert(unpacked, DeepEquals, []loc.Locator{planetARM})
+
+	unpackedDir := filepath.Join(stateDir, defaults.LocalDir,
+		defaults.PackagesDir, defaults.UnpackedDir)
+	data, err := ioutil.ReadFile(filepath.Join(pack.PackagePath(unpackedDir, planetARM), "rootfs", "planet"))
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "planet-arm64")
+}
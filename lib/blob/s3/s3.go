@@ -0,0 +1,274 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package s3 implements BLOB storage in an S3-compatible object storage,
+// e.g. AWS S3 or MinIO
+package s3
+
+import (
+	"crypto/sha512"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/gravitational/gravity/lib/blob"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	awss3 "github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// Config defines the object storage configuration
+type Config struct {
+	// Bucket is the name of the bucket to store BLOBs in
+	Bucket string `yaml:"bucket"`
+	// Prefix is an optional key prefix of the BLOBs in the bucket
+	Prefix string `yaml:"prefix"`
+	// Region is the bucket region
+	Region string `yaml:"region"`
+	// Endpoint is an optional endpoint of an S3-compatible storage, e.g. MinIO
+	Endpoint string `yaml:"endpoint"`
+	// ForcePathStyle is whether to use path-style bucket addressing
+	// which is required by most S3-compatible storages
+	ForcePathStyle bool `yaml:"force_path_style"`
+	// AccessKeyID is an optional access key, if not set, the credentials
+	// are taken from the default AWS credentials chain
+	AccessKeyID string `yaml:"access_key_id"`
+	// SecretAccessKey is the secret key of the access key
+	SecretAccessKey string `yaml:"secret_access_key"`
+}
+
+// CheckAndSetDefaults validates the configuration and sets default values
+func (r *Config) CheckAndSetDefaults() error {
+	if r.Bucket == "" {
+		return trace.BadParameter("object storage configuration should specify the bucket")
+	}
+	if (r.AccessKeyID == "") != (r.SecretAccessKey == "") {
+		return trace.BadParameter("object storage configuration should specify both access key ID and secret access key")
+	}
+	if r.Region == "" {
+		r.Region = defaults.AWSRegion
+	}
+	r.Prefix = strings.Trim(r.Prefix, "/")
+	return nil
+}
+
+// New returns BLOB storage in the object storage with the specified configuration.
+// Temporary files are created in tempDir
+func New(config Config, tempDir string) (blob.Objects, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	awsConfig := &aws.Config{
+		Region:           aws.String(config.Region),
+		S3ForcePathStyle: aws.Bool(config.ForcePathStyle),
+	}
+	if config.Endpoint != "" {
+		awsConfig.Endpoint = aws.String(config.Endpoint)
+	}
+	if config.AccessKeyID != "" {
+		awsConfig.Credentials = credentials.NewStaticCredentials(
+			config.AccessKeyID, config.SecretAccessKey, "")
+	}
+	session, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return NewWithClient(awss3.New(session), config, tempDir)
+}
+
+// NewWithClient returns BLOB storage that uses the provided S3 client
+func NewWithClient(client s3iface.S3API, config Config, tempDir string) (blob.Objects, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if tempDir == "" {
+		return nil, trace.BadParameter("missing temporary directory")
+	}
+	if err := os.MkdirAll(tempDir, defaults.SharedDirMask); err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	return &objects{
+		client:   client,
+		uploader: s3manager.NewUploaderWithClient(client),
+		bucket:   config.Bucket,
+		prefix:   config.Prefix,
+		tempDir:  tempDir,
+	}, nil
+}
+
+type objects struct {
+	client   s3iface.S3API
+	uploader *s3manager.Uploader
+	bucket   string
+	prefix   string
+	tempDir  string
+}
+
+func (o *objects) key(hash string) string {
+	return path.Join(o.prefix, "blobs", hash)
+}
+
+func (o *objects) Close() error {
+	return nil
+}
+
+// GetBLOBs returns a list of BLOBs in the storage
+func (o *objects) GetBLOBs() ([]string, error) {
+	prefix := o.key("")
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	var out []string
+	err := o.client.ListObjectsV2Pages(&awss3.ListObjectsV2Input{
+		Bucket: aws.String(o.bucket),
+		Prefix: aws.String(prefix),
+	}, func(page *awss3.ListObjectsV2Output, _ bool) bool {
+		for _, object := range page.Contents {
+			name := strings.TrimPrefix(aws.StringValue(object.Key), prefix)
+			if name != "" && !strings.Contains(name, "/") {
+				out = append(out, name)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, trace.Wrap(utils.ConvertS3Error(err))
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// WriteBLOB writes object to the storage, returns object envelope.
+//
+// The data is written to a temporary file first to compute its hash
+// which is used as the object key
+func (o *objects) WriteBLOB(data io.Reader) (*blob.Envelope, error) {
+	f, err := ioutil.TempFile(o.tempDir, "blob")
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	defer func() {
+		f.Close()
+		if err := os.Remove(f.Name()); err != nil {
+			log.Warnf("Failed to remove %v: %v.", f.Name(), err)
+		}
+	}()
+	hasher := sha512.New()
+	if _, err := io.Copy(io.MultiWriter(f, hasher), data); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	hash := fmt.Sprintf("%x", hasher.Sum(nil)[:sha512.Size/2])
+	envelope, err := o.GetBLOBEnvelope(hash)
+	if err == nil {
+		// BLOBs are content-addressed so the existing object has the same data
+		return envelope, nil
+	}
+	if !trace.IsNotFound(err) {
+		return nil, trace.Wrap(err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	_, err = o.uploader.Upload(&s3manager.UploadInput{
+		Bucket: aws.String(o.bucket),
+		Key:    aws.String(o.key(hash)),
+		Body:   f,
+	})
+	if err != nil {
+		return nil, trace.Wrap(utils.ConvertS3Error(err))
+	}
+	return o.GetBLOBEnvelope(hash)
+}
+
+// GetBLOBEnvelope returns object information identified by hash
+func (o *objects) GetBLOBEnvelope(hash string) (*blob.Envelope, error) {
+	out, err := o.client.HeadObject(&awss3.HeadObjectInput{
+		Bucket: aws.String(o.bucket),
+		Key:    aws.String(o.key(hash)),
+	})
+	if err != nil {
+		return nil, trace.Wrap(utils.ConvertS3Error(err))
+	}
+	return &blob.Envelope{
+		SizeBytes: aws.Int64Value(out.ContentLength),
+		SHA512:    hash,
+		Modified:  aws.TimeValue(out.LastModified).UTC(),
+	}, nil
+}
+
+// OpenBLOB downloads the object identified by hash to a temporary file
+// and returns reader. The file is removed when the reader is closed
+func (o *objects) OpenBLOB(hash string) (blob.ReadSeekCloser, error) {
+	out, err := o.client.GetObject(&awss3.GetObjectInput{
+		Bucket: aws.String(o.bucket),
+		Key:    aws.String(o.key(hash)),
+	})
+	if err != nil {
+		return nil, trace.Wrap(utils.ConvertS3Error(err))
+	}
+	defer out.Body.Close()
+	f, err := ioutil.TempFile(o.tempDir, hash)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	file := &tempFile{File: f}
+	if _, err := io.Copy(f, out.Body); err != nil {
+		file.Close()
+		return nil, trace.Wrap(err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		file.Close()
+		return nil, trace.Wrap(err)
+	}
+	return file, nil
+}
+
+// DeleteBLOB deletes BLOB from the storage
+func (o *objects) DeleteBLOB(hash string) error {
+	// object storage does not report missing objects on delete
+	if _, err := o.GetBLOBEnvelope(hash); err != nil {
+		return trace.Wrap(err)
+	}
+	_, err := o.client.DeleteObject(&awss3.DeleteObjectInput{
+		Bucket: aws.String(o.bucket),
+		Key:    aws.String(o.key(hash)),
+	})
+	return trace.Wrap(utils.ConvertS3Error(err))
+}
+
+// tempFile is a temporary file that is removed when closed
+type tempFile struct {
+	*os.File
+}
+
+// Close closes and removes the file
+func (r *tempFile) Close() error {
+	err := r.File.Close()
+	os.Remove(r.File.Name())
+	return trace.Wrap(err)
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package s3
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gravitational/gravity/lib/blob/suite"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/client/metadata"
+	"github.com/aws/aws-sdk-go/aws/request"
+	awss3 "github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	. "gopkg.in/check.v1"
+)
+
+func TestS3(t *testing.T) { TestingT(t) }
+
+type S3Suite struct {
+	suite suite.BLOBSuite
+	s3    *fakeS3
+	dir   string
+}
+
+var _ = Suite(&S3Suite{})
+
+func (s *S3Suite) SetUpTest(c *C) {
+	s.dir = c.MkDir()
+	s.s3 = &fakeS3{objects: make(map[string]fakeObject)}
+	obj, err := NewWithClient(s.s3, Config{Bucket: "packages", Prefix: "/hub/"}, s.dir)
+	c.Assert(err, IsNil)
+	s.suite.Objects = obj
+}
+
+func (s *S3Suite) TestBLOB(c *C) {
+	s.suite.BLOB(c)
+}
+
+func (s *S3Suite) TestBLOBSeek(c *C) {
+	s.suite.BLOBSeek(c)
+}
+
+func (s *S3Suite) TestBLOBWriteTwice(c *C) {
+	s.suite.BLOBWriteTwice(c)
+}
+
+func (s *S3Suite) TestBLOBList(c *C) {
+	s.suite.BLOBList(c)
+}
+
+func (s *S3Suite) TestStoresBLOBsUnderPrefix(c *C) {
+	e, err := s.suite.Objects.WriteBLOB(bytes.NewBufferString("data"))
+	c.Assert(err, IsNil)
+	_, ok := s.s3.objects["hub/blobs/"+e.SHA512]
+	c.Assert(ok, Equals, true)
+
+	r, err := s.suite.Objects.OpenBLOB(e.SHA512)
+	c.Assert(err, IsNil)
+	c.Assert(r.Close(), IsNil)
+	files, err := ioutil.ReadDir(s.dir)
+	c.Assert(err, IsNil)
+	c.Assert(files, HasLen, 0, Commentf("temporary files should be removed"))
+}
+
+func (s *S3Suite) TestRejectsInvalidConfig(c *C) {
+	_, err := NewWithClient(s.s3, Config{}, s.dir)
+	c.Assert(err, NotNil)
+	_, err = NewWithClient(s.s3, Config{Bucket: "packages", AccessKeyID: "id"}, s.dir)
+	c.Assert(err, NotNil)
+	_, err = NewWithClient(s.s3, Config{Bucket: "packages"}, filepath.Join(s.dir, "tmp"))
+	c.Assert(err, IsNil)
+}
+
+// fakeS3 is an in-memory S3 client
+type fakeS3 struct {
+	s3iface.S3API
+	sync.Mutex
+	objects map[string]fakeObject
+}
+
+type fakeObject struct {
+	data     []byte
+	modified time.Time
+}
+
+func (s *fakeS3) PutObjectRequest(input *awss3.PutObjectInput) (*request.Request, *awss3.PutObjectOutput) {
+	output := &awss3.PutObjectOutput{}
+	req := request.New(aws.Config{}, metadata.ClientInfo{}, request.Handlers{}, nil,
+		&request.Operation{Name: "PutObject", HTTPMethod: http.MethodPut}, input, output)
+	req.Handlers.Send.PushBack(func(r *request.Request) {
+		data, err := ioutil.ReadAll(input.Body)
+		if err != nil {
+			r.Error = err
+			return
+		}
+		s.Lock()
+		defer s.Unlock()
+		s.objects[aws.StringValue(input.Key)] = fakeObject{
+			data:     data,
+			modified: time.Now().UTC().Truncate(time.Second),
+		}
+	})
+	return req, output
+}
+
+func (s *fakeS3) HeadObject(input *awss3.HeadObjectInput) (*awss3.HeadObjectOutput, error) {
+	s.Lock()
+	defer s.Unlock()
+	object, ok := s.objects[aws.StringValue(input.Key)]
+	if !ok {
+		return nil, awserr.New("NotFound", "Not Found", nil)
+	}
+	return &awss3.HeadObjectOutput{
+		ContentLength: aws.Int64(int64(len(object.data))),
+		LastModified:  aws.Time(object.modified),
+	}, nil
+}
+
+func (s *fakeS3) GetObject(input *awss3.GetObjectInput) (*awss3.GetObjectOutput, error) {
+	s.Lock()
+	defer s.Unlock()
+	object, ok := s.objects[aws.StringValue(input.Key)]
+	if !ok {
+		return nil, awserr.New(awss3.ErrCodeNoSuchKey, "The specified key does not exist.", nil)
+	}
+	return &awss3.GetObjectOutput{
+		Body:          ioutil.NopCloser(bytes.NewReader(object.data)),
+		ContentLength: aws.Int64(int64(len(object.data))),
+	}, nil
+}
+
+func (s *fakeS3) DeleteObject(input *awss3.DeleteObjectInput) (*awss3.DeleteObjectOutput, error) {
+	s.Lock()
+	defer s.Unlock()
+	delete(s.objects, aws.StringValue(input.Key))
+	return &awss3.DeleteObjectOutput{}, nil
+}
+
+func (s *fakeS3) ListObjectsV2Pages(input *awss3.ListObjectsV2Input, fn func(*awss3.ListObjectsV2Output, bool) bool) error {
+	s.Lock()
+	defer s.Unlock()
+	var objects []*awss3.Object
+	for key := range s.objects {
+		if strings.HasPrefix(key, aws.StringValue(input.Prefix)) {
+			objects = append(objects, &awss3.Object{Key: aws.String(key)})
+		}
+	}
+	fn(&awss3.ListObjectsV2Output{Contents: objects}, true)
+	return nil
+}
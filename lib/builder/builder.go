@@ -75,6 +75,10 @@ type Config struct {
 	SkipVersionCheck bool
 	// VendorReq combines vendoring options
 	VendorReq service.VendorRequest
+	// EncryptionKey is the optional passphrase to encrypt the installer packages with
+	EncryptionKey string
+	// Signer optionally signs the installer packages
+	Signer pack.Signer
 	// Generator is used to generate installer
 	Generator Generator
 	// NewSyncer is used to initialize package cache syncer for the builder
@@ -174,6 +178,10 @@ func New(config Config) (*Builder, error) {
 				trace.Unwrap(err)) // show original parsing error
 		}
 	}
+	err = schema.ApplyInstallerProfile(manifest, config.VendorReq.InstallerProfile)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
 	b := &Builder{
 		Config:   config,
 		Manifest: *manifest,
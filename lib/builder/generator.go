@@ -35,6 +35,8 @@ type generator struct{}
 // using the provided builder and returns its data as a stream
 func (g *generator) Generate(builder *Builder, application app.Application) (io.ReadCloser, error) {
 	return builder.Apps.GetAppInstaller(app.InstallerRequest{
-		Application: application.Package,
+		Application:   application.Package,
+		EncryptionKey: builder.EncryptionKey,
+		Signer:        builder.Signer,
 	})
 }
@@ -0,0 +1,177 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/gravitational/gravity/lib/app/docker"
+	"github.com/gravitational/gravity/lib/app/service"
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/schema"
+
+	"github.com/coreos/go-semver/semver"
+	"github.com/gravitational/trace"
+	"github.com/gravitational/version"
+	"github.com/sirupsen/logrus"
+)
+
+// LintConfig defines the application linter configuration
+type LintConfig struct {
+	// ManifestPath is the path to the application manifest
+	ManifestPath string
+	// RuntimeVersion is the runtime version the application is going to be built with.
+	// If unspecified, the version is selected based on the tele version
+	RuntimeVersion *semver.Version
+	// ResourcePatterns is a list of file path patterns to search for container images
+	ResourcePatterns []string
+	// IgnoreResourcePatterns is a list of file path patterns to ignore when searching for images
+	IgnoreResourcePatterns []string
+	// SkipImages disables the image availability check
+	SkipImages bool
+	// Puller is used to verify that the images are available.
+	// If unspecified, the local docker daemon is used
+	Puller docker.DockerPuller
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+}
+
+// CheckAndSetDefaults validates the config and sets defaults
+func (c *LintConfig) CheckAndSetDefaults() error {
+	if c.ManifestPath == "" {
+		return trace.BadParameter("missing ManifestPath")
+	}
+	if c.FieldLogger == nil {
+		c.FieldLogger = logrus.WithField(trace.Component, "lint")
+	}
+	return nil
+}
+
+// Lint verifies the application manifest and the resources it references
+// without building the application.
+//
+// Besides the checks done by schema.Lint, it verifies that the selected runtime
+// version is compatible with this tele binary and that the referenced container
+// images are available: images not present locally are pulled
+func Lint(ctx context.Context, config LintConfig) (schema.Findings, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	data, err := ioutil.ReadFile(config.ManifestPath)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	findings := schema.Lint(data, schema.LintConfig{
+		ManifestPath:   config.ManifestPath,
+		RuntimeVersion: config.RuntimeVersion,
+	})
+	if findings.HasErrors() {
+		// resources cannot be reliably inspected with an invalid manifest
+		return findings, nil
+	}
+	manifest, err := schema.ParseManifestYAMLNoValidate(schema.ExpandEnvVars(data))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	runtimeFindings, err := lintRuntimeVersion(*manifest, config.RuntimeVersion)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	findings = append(findings, runtimeFindings...)
+	if config.SkipImages {
+		return findings, nil
+	}
+	images, err := service.ResourceImages(filepath.Dir(config.ManifestPath), config.ManifestPath,
+		config.ResourcePatterns, config.IgnoreResourcePatterns)
+	if err != nil {
+		return append(findings, schema.Finding{
+			Severity: schema.LintSeverityError,
+			Rule:     schema.LintRuleImage,
+			Message:  trace.UserMessage(err),
+		}), nil
+	}
+	if config.Puller == nil {
+		client, err := docker.NewClientFromEnv()
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		config.Puller = docker.NewDockerPuller(client)
+	}
+	for _, image := range images {
+		if err := ctx.Err(); err != nil {
+			return nil, trace.Wrap(err)
+		}
+		if err := checkImage(config.Puller, image, config.FieldLogger); err != nil {
+			findings = append(findings, schema.Finding{
+				Severity: schema.LintSeverityError,
+				Rule:     schema.LintRuleImage,
+				Message: fmt.Sprintf("image %v is not available: %v",
+					image, trace.UserMessage(err)),
+			})
+		}
+	}
+	return findings, nil
+}
+
+// lintRuntimeVersion verifies that the runtime version the application is
+// going to be built with is compatible with this tele binary
+func lintRuntimeVersion(manifest schema.Manifest, runtimeVersion *semver.Version) (schema.Findings, error) {
+	if base := manifest.Base(); base != nil && base.Version != loc.LatestVersion {
+		var err error
+		runtimeVersion, err = base.SemVer()
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+	if runtimeVersion == nil {
+		// the runtime version is going to match the tele version
+		return nil, nil
+	}
+	teleVersion, err := semver.NewVersion(version.Get().Version)
+	if err != nil {
+		return nil, trace.Wrap(err, "failed to determine tele version")
+	}
+	if !versionsCompatible(*teleVersion, *runtimeVersion) {
+		return schema.Findings{{
+			Severity: schema.LintSeverityError,
+			Rule:     schema.LintRuleRuntimeVersion,
+			Message: fmt.Sprintf("tele version %v cannot build applications with runtime version %v",
+				teleVersion, runtimeVersion),
+		}}, nil
+	}
+	return nil, nil
+}
+
+// checkImage verifies that the specified image is either present locally
+// or can be pulled
+func checkImage(puller docker.DockerPuller, image string, logger logrus.FieldLogger) error {
+	present, err := puller.IsImagePresent(image)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if present {
+		return nil
+	}
+	logger.WithField("image", image).Info("Pulling image.")
+	if err := puller.Pull(image); err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/schema"
+
+	"github.com/gravitational/trace"
+	check "gopkg.in/check.v1"
+)
+
+type LintSuite struct{}
+
+var _ = check.Suite(&LintSuite{})
+
+func (s *LintSuite) TestChecksImages(c *check.C) {
+	dir := c.MkDir()
+	manifestPath := filepath.Join(dir, defaults.ManifestFileName)
+	c.Assert(ioutil.WriteFile(manifestPath, []byte(`apiVersion: app.gravitational.io/v2
+kind: Application
+metadata:
+  name: test
+  resourceVersion: 1.0.0
+`), defaults.SharedReadMask), check.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "pod.yaml"), []byte(`apiVersion: v1
+kind: Pod
+metadata:
+  name: test
+spec:
+  containers:
+    - name: present
+      image: example.com/present:1.0.0
+    - name: remote
+      image: example.com/remote:1.0.0
+    - name: missing
+      image: example.com/missing:1.0.0
+`), defaults.SharedReadMask), check.IsNil)
+
+	puller := &testPuller{
+		local:  map[string]bool{"example.com/present:1.0.0": true},
+		remote: map[string]bool{"example.com/remote:1.0.0": true},
+	}
+	findings, err := Lint(context.TODO(), LintConfig{
+		ManifestPath:     manifestPath,
+		ResourcePatterns: []string{defaults.VendorPattern},
+		Puller:           puller,
+	})
+	c.Assert(err, check.IsNil)
+	c.Assert(findings, check.DeepEquals, schema.Findings{{
+		Severity: schema.LintSeverityError,
+		Rule:     schema.LintRuleImage,
+		Message:  "image example.com/missing:1.0.0 is not available: not found",
+	}})
+	c.Assert(puller.pulled, check.DeepEquals, []string{
+		"example.com/remote:1.0.0",
+	})
+}
+
+type testPuller struct {
+	local  map[string]bool
+	remote map[string]bool
+	pulled []string
+}
+
+func (r *testPuller) IsImagePresent(image string) (bool, error) {
+	return r.local[image], nil
+}
+
+func (r *testPuller) Pull(image string) error {
+	if !r.remote[image] {
+		return trace.NotFound("not found")
+	}
+	r.pulled = append(r.pulled, image)
+	return nil
+}
@@ -0,0 +1,449 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/gravitational/gravity/lib/app/service"
+	"github.com/gravitational/gravity/lib/archive"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/httplib"
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+)
+
+// RemoteBuildRequest defines the parameters of a build delegated
+// to a remote builder
+type RemoteBuildRequest struct {
+	// PackageName optionally overrides the application name
+	PackageName string `json:"package_name,omitempty"`
+	// PackageVersion optionally overrides the application version
+	PackageVersion string `json:"package_version,omitempty"`
+	// ResourcePatterns is a list of file path patterns to search for container images
+	ResourcePatterns []string `json:"resource_patterns,omitempty"`
+	// IgnoreResourcePatterns is a list of file path patterns to ignore when searching for images
+	IgnoreResourcePatterns []string `json:"ignore_resource_patterns,omitempty"`
+	// SetImages is a list of images to rewrite to new versions
+	SetImages []loc.DockerImage `json:"set_images,omitempty"`
+	// SetDeps is a list of app dependencies to rewrite to new versions
+	SetDeps []loc.Locator `json:"set_deps,omitempty"`
+	// SkipVersionCheck allows to skip tele/runtime compatibility check
+	SkipVersionCheck bool `json:"skip_version_check,omitempty"`
+	// InstallerProfile is the optional installer profile, e.g. edge
+	InstallerProfile string `json:"installer_profile,omitempty"`
+	// EncryptionKey is the optional passphrase to encrypt the installer packages with
+	EncryptionKey string `json:"encryption_key,omitempty"`
+}
+
+// vendorRequest returns the vendoring options for this build
+func (r RemoteBuildRequest) vendorRequest() service.VendorRequest {
+	return service.VendorRequest{
+		PackageName:            r.PackageName,
+		PackageVersion:         r.PackageVersion,
+		ResourcePatterns:       r.ResourcePatterns,
+		IgnoreResourcePatterns: r.IgnoreResourcePatterns,
+		SetImages:              r.SetImages,
+		SetDeps:                r.SetDeps,
+		VendorRuntime:          true,
+		InstallerProfile:       r.InstallerProfile,
+	}
+}
+
+// RemoteBuilderConfig is the remote builder service configuration
+type RemoteBuilderConfig struct {
+	// StateDir is the optional build state directory
+	StateDir string
+	// Repository is the optional package source repository
+	Repository string
+	// Insecure disables verification of the repository TLS certificate chain
+	Insecure bool
+	// Token is the token clients authenticate with
+	Token string
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+}
+
+// CheckAndSetDefaults validates the remote builder config and fills in defaults
+func (c *RemoteBuilderConfig) CheckAndSetDefaults() error {
+	if c.Token == "" {
+		return trace.BadParameter("missing Token")
+	}
+	if c.FieldLogger == nil {
+		c.FieldLogger = logrus.WithField(trace.Component, "builder:remote")
+	}
+	return nil
+}
+
+// RemoteBuilder is the HTTP service that builds installers on behalf of
+// tele clients. The client uploads the application directory, the builder
+// pulls the images, assembles the packages and streams the resulting
+// installer tarball back.
+// Builds are executed one at a time since they share the local package cache
+type RemoteBuilder struct {
+	RemoteBuilderConfig
+	// mu serializes the builds
+	mu sync.Mutex
+}
+
+// NewRemoteBuilder returns a new remote builder service
+func NewRemoteBuilder(config RemoteBuilderConfig) (*RemoteBuilder, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &RemoteBuilder{RemoteBuilderConfig: config}, nil
+}
+
+// ServeHTTP handles the build requests
+//
+//	POST /v1/build
+//
+// The request is a multipart form with the JSON-encoded RemoteBuildRequest
+// in the "request" field followed by the gzipped tarball of the application
+// directory in the "context" file.
+// The response is the installer tarball
+func (r *RemoteBuilder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path != remoteBuildPath {
+		trace.WriteError(w, trace.NotFound("%v not found", req.URL.Path))
+		return
+	}
+	if req.Method != http.MethodPost {
+		trace.WriteError(w, trace.BadParameter("unsupported method %v", req.Method))
+		return
+	}
+	if err := r.authenticate(req); err != nil {
+		trace.WriteError(w, err)
+		return
+	}
+	if err := r.build(w, req); err != nil {
+		r.Warnf("Build failed: %v.", trace.DebugReport(err))
+		trace.WriteError(w, err)
+	}
+}
+
+func (r *RemoteBuilder) authenticate(req *http.Request) error {
+	creds, err := httplib.ParseAuthHeaders(req)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if !creds.IsToken() || subtle.ConstantTimeCompare([]byte(creds.Password), []byte(r.Token)) != 1 {
+		return trace.AccessDenied("invalid build token")
+	}
+	return nil
+}
+
+// build builds the installer from the uploaded application directory
+// and writes it into w
+func (r *RemoteBuilder) build(w http.ResponseWriter, req *http.Request) error {
+	dir, err := ioutil.TempDir("", "remote-build")
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	defer os.RemoveAll(dir)
+	contextDir := filepath.Join(dir, "context")
+	buildRequest, err := readBuildRequest(req, contextDir)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	manifestPath := contextDir
+	if _, err := os.Stat(filepath.Join(contextDir, defaults.ManifestFileName)); err == nil {
+		manifestPath = filepath.Join(contextDir, defaults.ManifestFileName)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ctx := req.Context()
+	installerBuilder, err := New(Config{
+		Context:          ctx,
+		StateDir:         r.StateDir,
+		Insecure:         r.Insecure,
+		ManifestPath:     manifestPath,
+		OutPath:          filepath.Join(dir, "installer.tar"),
+		Repository:       r.Repository,
+		SkipVersionCheck: buildRequest.SkipVersionCheck,
+		VendorReq:        buildRequest.vendorRequest(),
+		EncryptionKey:    buildRequest.EncryptionKey,
+		Progress:         utils.NewProgress(ctx, "Build", 6, true),
+		Silent:           true,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer installerBuilder.Close()
+	locator := installerBuilder.Locator()
+	r.Infof("Building %v for %v.", locator, req.RemoteAddr)
+	if err := Build(ctx, installerBuilder); err != nil {
+		return trace.Wrap(err)
+	}
+
+	installer, err := os.Open(installerBuilder.OutPath)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	defer installer.Close()
+	fi, err := installer.Stat()
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length", fmt.Sprint(fi.Size()))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+		"filename": fmt.Sprintf("%v-%v.tar", locator.Name, locator.Version),
+	}))
+	if _, err := io.Copy(w, installer); err != nil {
+		// the response has already been started so just log the error
+		r.Warnf("Failed to send installer to %v: %v.", req.RemoteAddr, trace.DebugReport(err))
+		return nil
+	}
+	r.Infof("Sent %v to %v.", locator, req.RemoteAddr)
+	return nil
+}
+
+// readBuildRequest reads the build request from the specified multipart
+// form and unpacks the application directory into dir
+func readBuildRequest(req *http.Request, dir string) (*RemoteBuildRequest, error) {
+	reader, err := req.MultipartReader()
+	if err != nil {
+		return nil, trace.BadParameter("expected multipart form: %v", err)
+	}
+	var buildRequest *RemoteBuildRequest
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		switch part.FormName() {
+		case remoteBuildRequestField:
+			if err := json.NewDecoder(part).Decode(&buildRequest); err != nil {
+				return nil, trace.BadParameter("invalid build request: %v", err)
+			}
+		case remoteBuildContextField:
+			if buildRequest == nil {
+				return nil, trace.BadParameter("build request should precede the build context")
+			}
+			if err := os.MkdirAll(dir, defaults.SharedDirMask); err != nil {
+				return nil, trace.ConvertSystemError(err)
+			}
+			if err := archive.Untar(part, dir, archive.DefaultOptions()); err != nil {
+				return nil, trace.Wrap(err)
+			}
+			return buildRequest, nil
+		}
+	}
+	return nil, trace.BadParameter("missing build context")
+}
+
+// RemoteBuildConfig configures a build delegated to a remote builder
+type RemoteBuildConfig struct {
+	// Address is the remote builder address, e.g. https://builder.example.com:3012
+	Address string
+	// Token is the token to authenticate with the remote builder
+	Token string
+	// Insecure disables verification of the remote builder TLS certificate chain
+	Insecure bool
+	// ManifestPath holds the path to the application manifest or Helm chart directory
+	ManifestPath string
+	// OutPath holds the path to the installer tarball to be output.
+	// Defaults to the name suggested by the remote builder
+	OutPath string
+	// Overwrite indicates whether or not to overwrite an existing installer file
+	Overwrite bool
+	// Request defines the build parameters
+	Request RemoteBuildRequest
+	// Progress allows to report build progress
+	utils.Progress
+	// Silent suppresses all std output when set to true
+	Silent bool
+}
+
+// CheckAndSetDefaults validates the remote build config and fills in defaults
+func (c *RemoteBuildConfig) CheckAndSetDefaults() error {
+	if c.Address == "" {
+		return trace.BadParameter("missing Address")
+	}
+	if !strings.Contains(c.Address, "://") {
+		c.Address = fmt.Sprintf("https://%v", c.Address)
+	}
+	if c.Token == "" {
+		return trace.BadParameter("missing Token")
+	}
+	if c.ManifestPath == "" {
+		return trace.BadParameter("missing ManifestPath")
+	}
+	if c.Progress == nil {
+		c.Progress = utils.NewProgress(context.TODO(), "Build", remoteBuildSteps, c.Silent)
+	}
+	return nil
+}
+
+// RemoteBuild uploads the application directory to the remote builder
+// and saves the installer tarball it produces
+func RemoteBuild(ctx context.Context, config RemoteBuildConfig) error {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	defer config.Progress.Stop()
+	manifestDir := config.ManifestPath
+	fi, err := os.Stat(config.ManifestPath)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	if !fi.IsDir() {
+		if filepath.Base(config.ManifestPath) != defaults.ManifestFileName {
+			return trace.BadParameter("manifest filename should be %q",
+				defaults.ManifestFileName)
+		}
+		manifestDir = filepath.Dir(config.ManifestPath)
+	}
+	if err := checkOutPath(config.OutPath, config.Overwrite); err != nil {
+		return trace.Wrap(err)
+	}
+
+	config.NextStep("Building on remote builder %v", config.Address)
+	resp, err := sendBuildRequest(ctx, config, manifestDir)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer resp.Body.Close()
+
+	outPath := config.OutPath
+	if outPath == "" {
+		_, params, _ := mime.ParseMediaType(resp.Header.Get("Content-Disposition"))
+		outPath = filepath.Base(params["filename"])
+		if outPath == "" || outPath == "." || outPath == string(filepath.Separator) {
+			return trace.BadParameter("remote builder did not suggest installer name, " +
+				"please specify the output file")
+		}
+		if err := checkOutPath(outPath, config.Overwrite); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	config.NextStep("Saving the snapshot as %v", outPath)
+	return trace.Wrap(writeFileAtomic(outPath, resp.Body))
+}
+
+// sendBuildRequest streams the build request along with the application
+// directory to the remote builder and returns the successful response
+func sendBuildRequest(ctx context.Context, config RemoteBuildConfig, dir string) (*http.Response, error) {
+	buildContext, err := archive.Tar(dir, archive.DefaultOptions(), archive.CompressionGzip)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	reader, writer := io.Pipe()
+	form := multipart.NewWriter(writer)
+	go func() {
+		defer buildContext.Close()
+		writer.CloseWithError(writeBuildForm(form, config.Request, buildContext))
+	}()
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(config.Address, "/")+remoteBuildPath, reader)
+	if err != nil {
+		reader.Close()
+		return nil, trace.Wrap(err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", fmt.Sprintf("%v %v", httplib.AuthBearer, config.Token))
+	resp, err := httplib.GetClient(config.Insecure).Do(req)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		data, _ := ioutil.ReadAll(resp.Body)
+		return nil, trace.ReadError(resp.StatusCode, data)
+	}
+	return resp, nil
+}
+
+// writeBuildForm writes the build request followed by the build context
+// as a multipart form
+func writeBuildForm(form *multipart.Writer, request RemoteBuildRequest, buildContext io.Reader) error {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if err := form.WriteField(remoteBuildRequestField, string(data)); err != nil {
+		return trace.Wrap(err)
+	}
+	part, err := form.CreateFormFile(remoteBuildContextField, "context.tar.gz")
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if _, err := io.Copy(part, buildContext); err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(form.Close())
+}
+
+// checkOutPath makes sure the installer tarball can be written to the specified path
+func checkOutPath(path string, overwrite bool) error {
+	if path == "" || overwrite {
+		return nil
+	}
+	if _, err := os.Stat(path); err == nil {
+		return trace.BadParameter("tarball %v already exists, please remove "+
+			"it first or provide '--force' flag to overwrite it", path)
+	}
+	return nil
+}
+
+// writeFileAtomic writes the data from r into a temporary file
+// that is then renamed to path
+func writeFileAtomic(path string, r io.Reader) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), ".tele-build")
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return trace.Wrap(err, "failed to receive installer")
+	}
+	if err := f.Close(); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	return trace.ConvertSystemError(os.Rename(f.Name(), path))
+}
+
+const (
+	// remoteBuildPath is the path of the remote builder build endpoint
+	remoteBuildPath = "/v1/build"
+	// remoteBuildRequestField is the name of the form field with the build request
+	remoteBuildRequestField = "request"
+	// remoteBuildContextField is the name of the form file with the application directory
+	remoteBuildContextField = "context"
+	// remoteBuildSteps is the number of steps when building on a remote builder
+	remoteBuildSteps = 2
+)
@@ -0,0 +1,87 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"github.com/gravitational/gravity/lib/archive"
+	"github.com/gravitational/gravity/lib/loc"
+
+	"github.com/gravitational/trace"
+	check "gopkg.in/check.v1"
+)
+
+type RemoteSuite struct{}
+
+var _ = check.Suite(&RemoteSuite{})
+
+func (s *RemoteSuite) TestBuildRequestRoundtrip(c *check.C) {
+	dir := c.MkDir()
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "app.yaml"), []byte("kind: Bundle"), 0644), check.IsNil)
+	buildContext, err := archive.Tar(dir, archive.DefaultOptions(), archive.CompressionGzip)
+	c.Assert(err, check.IsNil)
+	defer buildContext.Close()
+
+	request := RemoteBuildRequest{
+		PackageVersion: "1.0.0",
+		SetDeps:        []loc.Locator{loc.MustParseLocator("gravitational.io/dep:2.0.0")},
+	}
+	reader, writer := io.Pipe()
+	form := multipart.NewWriter(writer)
+	go func() {
+		writer.CloseWithError(writeBuildForm(form, request, buildContext))
+	}()
+	req := httptest.NewRequest(http.MethodPost, remoteBuildPath, reader)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	target := filepath.Join(c.MkDir(), "context")
+	obtained, err := readBuildRequest(req, target)
+	c.Assert(err, check.IsNil)
+	c.Assert(*obtained, check.DeepEquals, request)
+	data, err := ioutil.ReadFile(filepath.Join(target, "app.yaml"))
+	c.Assert(err, check.IsNil)
+	c.Assert(string(data), check.Equals, "kind: Bundle")
+}
+
+func (s *RemoteSuite) TestRejectsInvalidToken(c *check.C) {
+	remote, err := NewRemoteBuilder(RemoteBuilderConfig{Token: "secret"})
+	c.Assert(err, check.IsNil)
+	server := httptest.NewServer(remote)
+	defer server.Close()
+
+	dir := c.MkDir()
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "app.yaml"), []byte("kind: Bundle"), 0644), check.IsNil)
+	outPath := filepath.Join(c.MkDir(), "installer.tar")
+	err = RemoteBuild(context.TODO(), RemoteBuildConfig{
+		Address:      server.URL,
+		Token:        "invalid",
+		ManifestPath: filepath.Join(dir, "app.yaml"),
+		OutPath:      outPath,
+		Silent:       true,
+	})
+	c.Assert(trace.IsAccessDenied(err), check.Equals, true, check.Commentf("%v", err))
+	_, err = os.Stat(outPath)
+	c.Assert(os.IsNotExist(err), check.Equals, true)
+}
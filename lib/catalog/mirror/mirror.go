@@ -0,0 +1,292 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package mirror implements the read-only mirror mode of the Ops Center
+// which keeps the application catalog in sync with an upstream Ops Center
+package mirror
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gravitational/gravity/lib/app"
+	appservice "github.com/gravitational/gravity/lib/app/service"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/pack"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+)
+
+// Config defines the mirror mode configuration
+type Config struct {
+	// Upstream is the address of the upstream Ops Center,
+	// e.g. https://hub.example.com
+	Upstream string `yaml:"upstream"`
+	// Token is the API key used to authenticate with the upstream Ops Center
+	Token string `yaml:"token"`
+	// Repositories lists the package repositories to mirror,
+	// defaults to the system repository
+	Repositories []string `yaml:"repositories"`
+	// Interval is how often the catalog is synchronized with the upstream
+	Interval time.Duration `yaml:"interval"`
+	// Prune is whether to delete packages that are no longer
+	// present in the upstream Ops Center
+	Prune bool `yaml:"prune"`
+}
+
+// CheckAndSetDefaults validates the configuration and sets default values
+func (r *Config) CheckAndSetDefaults() error {
+	if r.Upstream == "" {
+		return trace.BadParameter("mirror configuration should specify the upstream Ops Center")
+	}
+	if r.Token == "" {
+		return trace.BadParameter("mirror configuration should specify the upstream API key")
+	}
+	if len(r.Repositories) == 0 {
+		r.Repositories = []string{defaults.SystemAccountOrg}
+	}
+	if r.Interval < 0 {
+		return trace.BadParameter("mirror sync interval cannot be negative")
+	}
+	if r.Interval == 0 {
+		r.Interval = defaults.CatalogMirrorSyncInterval
+	}
+	return nil
+}
+
+// IsMirrored returns true if the specified repository is mirrored
+func (r Config) IsMirrored(repository string) bool {
+	for _, mirrored := range r.Repositories {
+		if mirrored == repository {
+			return true
+		}
+	}
+	return false
+}
+
+// SyncerConfig configures the catalog synchronizer
+type SyncerConfig struct {
+	// Config is the mirror mode configuration
+	Config
+	// UpstreamPackages is the package service of the upstream Ops Center
+	UpstreamPackages pack.PackageService
+	// UpstreamApps is the application service of the upstream Ops Center
+	UpstreamApps app.Applications
+	// Packages is the writable local package service
+	Packages pack.PackageService
+	// Apps is the local application service backed by the writable packages
+	Apps app.Applications
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+}
+
+// CheckAndSetDefaults validates the configuration and sets default values
+func (r *SyncerConfig) CheckAndSetDefaults() error {
+	if err := r.Config.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	if r.UpstreamPackages == nil {
+		return trace.BadParameter("missing UpstreamPackages")
+	}
+	if r.UpstreamApps == nil {
+		return trace.BadParameter("missing UpstreamApps")
+	}
+	if r.Packages == nil {
+		return trace.BadParameter("missing Packages")
+	}
+	if r.Apps == nil {
+		return trace.BadParameter("missing Apps")
+	}
+	if r.FieldLogger == nil {
+		r.FieldLogger = logrus.WithField(trace.Component, "mirror")
+	}
+	return nil
+}
+
+// NewSyncer returns a new catalog synchronizer
+func NewSyncer(config SyncerConfig) (*Syncer, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &Syncer{SyncerConfig: config}, nil
+}
+
+// Syncer keeps the mirrored repositories consistent with the upstream
+type Syncer struct {
+	SyncerConfig
+}
+
+// Run synchronizes the catalog periodically until the context is canceled
+func (r *Syncer) Run(ctx context.Context) error {
+	utils.RunPeriodically(ctx, r.Interval, r.FieldLogger,
+		fmt.Sprintf("sync catalog with %v", r.Upstream), func(ctx context.Context) error {
+			report, err := r.Sync(ctx)
+			if report != nil {
+				r.logReport(*report)
+			}
+			return trace.Wrap(err)
+		})
+	return nil
+}
+
+// Sync pulls the packages missing from the mirrored repositories and
+// detects the packages that have diverged from the upstream.
+//
+// Packages with a different digest are replaced with the upstream version.
+// Packages that no longer exist upstream are deleted if pruning is enabled
+func (r *Syncer) Sync(ctx context.Context) (*Report, error) {
+	var report Report
+	var errors []error
+	for _, repository := range r.Repositories {
+		if err := r.syncRepository(ctx, repository, &report); err != nil {
+			errors = append(errors, trace.Wrap(err, "failed to sync repository %v", repository))
+		}
+	}
+	return &report, trace.NewAggregate(errors...)
+}
+
+func (r *Syncer) syncRepository(ctx context.Context, repository string, report *Report) error {
+	upstream, err := r.UpstreamPackages.GetPackages(repository)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	local, err := r.Packages.GetPackages(repository)
+	if err != nil && !trace.IsNotFound(err) {
+		return trace.Wrap(err)
+	}
+	localDigests := make(map[loc.Locator]string, len(local))
+	for _, env := range local {
+		localDigests[env.Locator] = env.SHA512
+	}
+	var errors []error
+	for _, env := range upstream {
+		if ctx.Err() != nil {
+			return trace.Wrap(ctx.Err())
+		}
+		digest, exists := localDigests[env.Locator]
+		delete(localDigests, env.Locator)
+		if exists && digest == env.SHA512 {
+			continue
+		}
+		if exists {
+			report.Diverged = append(report.Diverged, Divergence{
+				Package: env.Locator,
+				Reason:  ReasonDigestMismatch,
+			})
+		}
+		if err := r.pull(env, exists); err != nil {
+			errors = append(errors, trace.Wrap(err, "failed to pull %v", env.Locator))
+			continue
+		}
+		report.Pulled = append(report.Pulled, env.Locator)
+	}
+	for locator := range localDigests {
+		report.Diverged = append(report.Diverged, Divergence{
+			Package: locator,
+			Reason:  ReasonNotFoundUpstream,
+		})
+		if !r.Prune {
+			continue
+		}
+		if err := r.Packages.DeletePackage(locator); err != nil {
+			errors = append(errors, trace.Wrap(err, "failed to prune %v", locator))
+			continue
+		}
+		report.Pruned = append(report.Pruned, locator)
+	}
+	return trace.NewAggregate(errors...)
+}
+
+// pull pulls the upstream package into the local package service.
+// Application packages are pulled together with their dependencies
+func (r *Syncer) pull(env pack.PackageEnvelope, upsert bool) error {
+	if env.Type == "" {
+		_, err := appservice.PullPackage(appservice.PackagePullRequest{
+			FieldLogger: r.FieldLogger,
+			SrcPack:     r.UpstreamPackages,
+			DstPack:     r.Packages,
+			Package:     env.Locator,
+			Upsert:      upsert,
+		})
+		if err != nil && !trace.IsAlreadyExists(err) {
+			return trace.Wrap(err)
+		}
+		return nil
+	}
+	_, err := appservice.PullApp(appservice.AppPullRequest{
+		FieldLogger: r.FieldLogger,
+		SrcPack:     r.UpstreamPackages,
+		DstPack:     r.Packages,
+		SrcApp:      r.UpstreamApps,
+		DstApp:      r.Apps,
+		Package:     env.Locator,
+		Upsert:      upsert,
+	})
+	if err != nil && !trace.IsAlreadyExists(err) {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+func (r *Syncer) logReport(report Report) {
+	for _, divergence := range report.Diverged {
+		r.Warnf("Mirrored package %v.", divergence)
+	}
+	for _, locator := range report.Pruned {
+		r.Infof("Pruned package %v removed from %v.", locator, r.Upstream)
+	}
+	if len(report.Pulled) != 0 {
+		r.Infof("Pulled %v packages from %v.", len(report.Pulled), r.Upstream)
+	}
+}
+
+// Report describes the result of a catalog synchronization
+type Report struct {
+	// Pulled lists the packages pulled from the upstream
+	Pulled []loc.Locator
+	// Diverged lists the local packages that were found to differ
+	// from the upstream
+	Diverged []Divergence
+	// Pruned lists the packages deleted because they no longer
+	// exist upstream
+	Pruned []loc.Locator
+}
+
+// Divergence describes a mirrored package that differs from the upstream
+type Divergence struct {
+	// Package is the diverged package
+	Package loc.Locator
+	// Reason describes how the package has diverged
+	Reason string
+}
+
+// String returns a textual representation of the divergence
+func (r Divergence) String() string {
+	return fmt.Sprintf("%v %v", r.Package, r.Reason)
+}
+
+const (
+	// ReasonDigestMismatch is the divergence of a package whose
+	// contents differ from the upstream
+	ReasonDigestMismatch = "has a different digest than upstream"
+	// ReasonNotFoundUpstream is the divergence of a package that
+	// does not exist upstream
+	ReasonNotFoundUpstream = "does not exist upstream"
+)
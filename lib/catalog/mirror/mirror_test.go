@@ -0,0 +1,177 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mirror
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gravitational/gravity/lib/app"
+	appservice "github.com/gravitational/gravity/lib/app/service"
+	apptest "github.com/gravitational/gravity/lib/app/service/test"
+	"github.com/gravitational/gravity/lib/blob/fs"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/helm"
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/pack"
+	"github.com/gravitational/gravity/lib/pack/localpack"
+	"github.com/gravitational/gravity/lib/storage/keyval"
+
+	"github.com/gravitational/trace"
+	. "gopkg.in/check.v1"
+)
+
+func TestMirror(t *testing.T) { TestingT(t) }
+
+type MirrorSuite struct {
+	upstreamPack pack.PackageService
+	upstreamApps app.Applications
+	localPack    pack.PackageService
+	localApps    app.Applications
+}
+
+var _ = Suite(&MirrorSuite{})
+
+func (s *MirrorSuite) SetUpTest(c *C) {
+	s.upstreamPack, s.upstreamApps = setupServices(c)
+	s.localPack, s.localApps = setupServices(c)
+}
+
+func (s *MirrorSuite) TestSyncsCatalog(c *C) {
+	apptest.CreatePackage(s.upstreamPack, loc.MustParseLocator("gravitational.io/planet:0.0.1"), nil, c)
+	apptest.CreateRuntimeApplication(s.upstreamApps, c)
+	application := loc.MustParseLocator("example.com/app:0.0.1")
+	apptest.CreateDummyApplication(s.upstreamApps, application, c)
+	data := loc.MustParseLocator("example.com/data:0.0.1")
+	_, err := s.upstreamPack.CreatePackage(data, bytes.NewBufferString("data"))
+	c.Assert(err, IsNil)
+
+	syncer := s.newSyncer(c, false)
+	report, err := syncer.Sync(context.TODO())
+	c.Assert(err, IsNil)
+	c.Assert(report.Pulled, DeepEquals, []loc.Locator{application, data})
+	c.Assert(report.Diverged, HasLen, 0)
+
+	_, err = s.localApps.GetApp(application)
+	c.Assert(err, IsNil)
+
+	report, err = syncer.Sync(context.TODO())
+	c.Assert(err, IsNil)
+	c.Assert(*report, DeepEquals, Report{})
+}
+
+func (s *MirrorSuite) TestDetectsDivergence(c *C) {
+	data := loc.MustParseLocator("example.com/data:0.0.1")
+	_, err := s.upstreamPack.CreatePackage(data, bytes.NewBufferString("upstream data"))
+	c.Assert(err, IsNil)
+	_, err = s.localPack.CreatePackage(data, bytes.NewBufferString("local data"))
+	c.Assert(err, IsNil)
+	extra := loc.MustParseLocator("example.com/extra:0.0.1")
+	_, err = s.localPack.CreatePackage(extra, bytes.NewBufferString("extra"))
+	c.Assert(err, IsNil)
+
+	report, err := s.newSyncer(c, false).Sync(context.TODO())
+	c.Assert(err, IsNil)
+	c.Assert(report.Diverged, DeepEquals, []Divergence{
+		{Package: data, Reason: ReasonDigestMismatch},
+		{Package: extra, Reason: ReasonNotFoundUpstream},
+	})
+	c.Assert(report.Pruned, HasLen, 0)
+
+	upstream, err := s.upstreamPack.ReadPackageEnvelope(data)
+	c.Assert(err, IsNil)
+	local, err := s.localPack.ReadPackageEnvelope(data)
+	c.Assert(err, IsNil)
+	c.Assert(local.SHA512, Equals, upstream.SHA512)
+
+	report, err = s.newSyncer(c, true).Sync(context.TODO())
+	c.Assert(err, IsNil)
+	c.Assert(report.Pruned, DeepEquals, []loc.Locator{extra})
+	_, err = s.localPack.ReadPackageEnvelope(extra)
+	c.Assert(trace.IsNotFound(err), Equals, true)
+}
+
+func (s *MirrorSuite) TestRejectsPublishing(c *C) {
+	config := Config{
+		Upstream:     "https://hub.example.com",
+		Repositories: []string{"example.com"},
+	}
+	packages := NewReadOnlyPackages(s.localPack, config)
+
+	_, err := packages.CreatePackage(loc.MustParseLocator("example.com/data:0.0.1"),
+		bytes.NewBufferString("data"))
+	c.Assert(trace.IsAccessDenied(err), Equals, true)
+	err = packages.DeleteRepository("example.com")
+	c.Assert(trace.IsAccessDenied(err), Equals, true)
+
+	err = packages.UpsertRepository("other.com", time.Time{})
+	c.Assert(err, IsNil)
+	_, err = packages.CreatePackage(loc.MustParseLocator("other.com/data:0.0.1"),
+		bytes.NewBufferString("data"))
+	c.Assert(err, IsNil)
+}
+
+func (s *MirrorSuite) newSyncer(c *C, prune bool) *Syncer {
+	syncer, err := NewSyncer(SyncerConfig{
+		Config: Config{
+			Upstream:     "https://hub.example.com",
+			Token:        "token",
+			Repositories: []string{"example.com"},
+			Prune:        prune,
+		},
+		UpstreamPackages: s.upstreamPack,
+		UpstreamApps:     s.upstreamApps,
+		Packages:         s.localPack,
+		Apps:             s.localApps,
+	})
+	c.Assert(err, IsNil)
+	return syncer
+}
+
+func setupServices(c *C) (pack.PackageService, app.Applications) {
+	dir := c.MkDir()
+	backend, err := keyval.NewBolt(keyval.BoltConfig{
+		Path: filepath.Join(dir, "bolt.db"),
+	})
+	c.Assert(err, IsNil)
+	objects, err := fs.New(dir)
+	c.Assert(err, IsNil)
+	packages, err := localpack.New(localpack.Config{
+		Backend:     backend,
+		UnpackedDir: filepath.Join(dir, defaults.UnpackedDir),
+		Objects:     objects,
+	})
+	c.Assert(err, IsNil)
+	err = packages.UpsertRepository("example.com", time.Time{})
+	c.Assert(err, IsNil)
+	charts, err := helm.NewRepository(helm.Config{
+		Packages: packages,
+		Backend:  backend,
+	})
+	c.Assert(err, IsNil)
+	apps, err := appservice.New(appservice.Config{
+		Backend:  backend,
+		StateDir: filepath.Join(dir, defaults.ImportDir),
+		Packages: packages,
+		Charts:   charts,
+	})
+	c.Assert(err, IsNil)
+	return packages, apps
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mirror
+
+import (
+	"io"
+	"time"
+
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/pack"
+
+	"github.com/gravitational/trace"
+)
+
+// NewReadOnlyPackages returns the package service that rejects
+// modifications of the mirrored repositories.
+//
+// Mirrored repositories are only updated by the catalog synchronizer
+// which uses the underlying package service directly
+func NewReadOnlyPackages(packages pack.PackageService, config Config) *ReadOnlyPackages {
+	return &ReadOnlyPackages{
+		PackageService: packages,
+		config:         config,
+	}
+}
+
+// ReadOnlyPackages is a package service with read-only mirrored repositories
+type ReadOnlyPackages struct {
+	pack.PackageService
+	config Config
+}
+
+func (r *ReadOnlyPackages) check(repository string) error {
+	if r.config.IsMirrored(repository) {
+		return trace.AccessDenied("repository %v is a read-only mirror of %v",
+			repository, r.config.Upstream)
+	}
+	return nil
+}
+
+// UpsertRepository creates or updates the repository
+func (r *ReadOnlyPackages) UpsertRepository(repository string, expires time.Time) error {
+	if err := r.check(repository); err != nil {
+		return trace.Wrap(err)
+	}
+	return r.PackageService.UpsertRepository(repository, expires)
+}
+
+// DeleteRepository deletes the repository
+func (r *ReadOnlyPackages) DeleteRepository(repository string) error {
+	if err := r.check(repository); err != nil {
+		return trace.Wrap(err)
+	}
+	return r.PackageService.DeleteRepository(repository)
+}
+
+// CreatePackage creates package and adds it to the existing repository
+func (r *ReadOnlyPackages) CreatePackage(loc loc.Locator, data io.Reader, options ...pack.PackageOption) (*pack.PackageEnvelope, error) {
+	if err := r.check(loc.Repository); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return r.PackageService.CreatePackage(loc, data, options...)
+}
+
+// UpsertPackage creates or updates the package in the existing repository
+func (r *ReadOnlyPackages) UpsertPackage(loc loc.Locator, data io.Reader, options ...pack.PackageOption) (*pack.PackageEnvelope, error) {
+	if err := r.check(loc.Repository); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return r.PackageService.UpsertPackage(loc, data, options...)
+}
+
+// UpdatePackageLabels updates package labels
+func (r *ReadOnlyPackages) UpdatePackageLabels(loc loc.Locator, addLabels map[string]string, removeLabels []string) error {
+	if err := r.check(loc.Repository); err != nil {
+		return trace.Wrap(err)
+	}
+	return r.PackageService.UpdatePackageLabels(loc, addLabels, removeLabels)
+}
+
+// DeletePackage deletes the package
+func (r *ReadOnlyPackages) DeletePackage(loc loc.Locator) error {
+	if err := r.check(loc.Repository); err != nil {
+		return trace.Wrap(err)
+	}
+	return r.PackageService.DeletePackage(loc)
+}
+
+// PinPackage protects the package from deletion until it is unpinned
+func (r *ReadOnlyPackages) PinPackage(loc loc.Locator) error {
+	return pack.Pin(r, loc)
+}
+
+// UnpinPackage removes the deletion protection from the package
+func (r *ReadOnlyPackages) UnpinPackage(loc loc.Locator) error {
+	return pack.Unpin(r, loc)
+}
+
+// UpsertPackageAlias creates the package alias or updates its target
+func (r *ReadOnlyPackages) UpsertPackageAlias(alias, target loc.Locator) error {
+	if err := r.check(alias.Repository); err != nil {
+		return trace.Wrap(err)
+	}
+	return r.PackageService.UpsertPackageAlias(alias, target)
+}
+
+// DeletePackageAlias deletes the package alias
+func (r *ReadOnlyPackages) DeletePackageAlias(alias loc.Locator) error {
+	if err := r.check(alias.Repository); err != nil {
+		return trace.Wrap(err)
+	}
+	return r.PackageService.DeletePackageAlias(alias)
+}
+
+// PrunePackages deletes packages that are not in use.
+// Packages in the mirrored repositories are not pruned
+func (r *ReadOnlyPackages) PrunePackages(req pack.PruneRequest) (*pack.PruneResponse, error) {
+	repositories := req.Repositories
+	if len(repositories) == 0 {
+		var err error
+		repositories, err = r.GetRepositories()
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+	req.Repositories = nil
+	for _, repository := range repositories {
+		if !r.config.IsMirrored(repository) {
+			req.Repositories = append(req.Repositories, repository)
+		}
+	}
+	if len(req.Repositories) == 0 {
+		return &pack.PruneResponse{}, nil
+	}
+	return r.PackageService.PrunePackages(req)
+}
@@ -89,6 +89,28 @@ func ValidateManifest(
 	failedProbes = append(failedProbes, failed...)
 
 	failedProbes = append(failedProbes, schema.ValidateKubelet(profile, manifest)...)
+
+	failed, err = schema.ValidateCompatibility(manifest, profile, dockerConfig.StorageDriver)
+	if err != nil {
+		errors = append(errors, trace.Wrap(err,
+			"error validating node compatibility, see syslog for details"))
+	}
+	failedProbes = append(failedProbes, failed...)
+
+	failed, err = schema.ValidateOSPackages(profile)
+	if err != nil {
+		errors = append(errors, trace.Wrap(err,
+			"error validating OS packages, see syslog for details"))
+	}
+	failedProbes = append(failedProbes, failed...)
+
+	report, err := ValidateDisks(DiskLocations(stateDir))
+	if err != nil {
+		errors = append(errors, trace.Wrap(err,
+			"error validating disk layout, see syslog for details"))
+	} else {
+		failedProbes = append(failedProbes, report.Failed...)
+	}
 	return failedProbes, trace.NewAggregate(errors...)
 }
 
@@ -298,6 +320,10 @@ type Features struct {
 	// TestDockerDevice specifies if the docker device test should be executed.
 	// Docker device test is only applicable during install.
 	TestDockerDevice bool
+	// ClusterPorts lists the ports cluster nodes communicate with each other on.
+	// These ports are tested between all servers in addition to the ports
+	// from the node profiles. Only applicable during install.
+	ClusterPorts []ClusterPort
 }
 
 // String return textual representation of this server object
@@ -401,6 +427,11 @@ func (r *checker) Run(ctx context.Context) error {
 			errors = append(errors, err)
 		}
 
+		err = checkRawDisks(server, r.manifest.Storage)
+		if err != nil {
+			errors = append(errors, err)
+		}
+
 		err = r.checkTempDir(ctx, server)
 		if err != nil {
 			errors = append(errors, err)
@@ -529,7 +560,7 @@ func (r *checker) checkTempDir(ctx context.Context, server Server) error {
 
 // checkPorts makes sure ports specified in profile are unoccupied and reachable
 func (r *checker) checkPorts(ctx context.Context) error {
-	req, err := constructPingPongRequest(r.servers, r.requirements)
+	req, err := constructPingPongRequest(r.servers, r.requirements, r.ClusterPorts)
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -548,11 +579,28 @@ func (r *checker) checkPorts(ctx context.Context) error {
 
 	log.Infof("Ping pong response: %v.", resp)
 
-	if len(resp.Failures()) != 0 {
-		return trace.BadParameter(strings.Join(resp.Failures(), ", "))
+	var errors []error
+	for addr, result := range resp {
+		if result.Code != 0 {
+			errors = append(errors, trace.BadParameter("server %v: %v", addr, result.Message))
+		}
+		for _, listen := range result.ListenResults {
+			if listen.Code != 0 {
+				errors = append(errors, trace.BadParameter(
+					"server %v failed to bind to %v:%v",
+					addr, listen.Server.Network, listen.Server.Addr))
+			}
+		}
 	}
 
-	return nil
+	matrix := MatrixFromPingPong(resp, r.ClusterPorts)
+	if len(matrix.Blocked()) != 0 {
+		errors = append(errors, trace.BadParameter(
+			"servers failed to reach each other on the following paths:\n%v\n%v",
+			matrix.FormatBlocked(), matrix))
+	}
+
+	return trace.NewAggregate(errors...)
 }
 
 // checkBandwidth measures network bandwidth between servers and makes sure it satisfies
@@ -776,6 +824,47 @@ func checkDockerDevice(server Server, docker schema.Docker) error {
 	return nil
 }
 
+// checkRawDisks makes sure the server has the unformatted disks
+// required by the storage provider
+func checkRawDisks(server Server, config *schema.Storage) error {
+	if config == nil {
+		return nil
+	}
+	requirement, err := config.GetRawDisks()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if requirement == nil || !requirement.IsRequired(server.Server.Role) {
+		return nil
+	}
+	dockerDevice := storage.DeviceName(server.DockerDevice)
+	if dockerDevice == "" {
+		dockerDevice = server.Docker.Device.Name
+	}
+	var disks []string
+	for _, device := range server.GetDevices() {
+		if device.Type != storage.DeviceDisk || device.Name.Path() == dockerDevice.Path() {
+			continue
+		}
+		if device.SizeMB<<20 < requirement.MinSize.Bytes() {
+			continue
+		}
+		disks = append(disks, device.Name.Path())
+	}
+	if len(disks) < requirement.Count {
+		var size string
+		if requirement.MinSize != 0 {
+			size = fmt.Sprintf(" of at least %v", requirement.MinSize.String())
+		}
+		return trace.BadParameter("server %q has %v unformatted disk(s) while storage provider %v "+
+			"requires at least %v disk(s)%v without partitions or filesystem",
+			server.ServerInfo.GetHostname(), len(disks), config.Provider, requirement.Count, size)
+	}
+
+	log.Infof("Server %q passed raw disk check: %v.", server.ServerInfo.GetHostname(), disks)
+	return nil
+}
+
 // checkSameOS makes sure all servers have the same OS/version
 func checkSameOS(servers []Server) error {
 	osToNodes := make(map[string][]string)
@@ -925,12 +1014,23 @@ func defaultPortChecker(options *validationpb.ValidateOptions) health.Checker {
 }
 
 // constructPingPongRequest constructs a regular ping-pong game request
-func constructPingPongRequest(servers []Server, requirements map[string]Requirements) (PingPongGame, error) {
+// with the ports from node profiles and the specified cluster ports
+func constructPingPongRequest(servers []Server, requirements map[string]Requirements, clusterPorts []ClusterPort) (PingPongGame, error) {
 	game := make(PingPongGame, len(servers))
 	var listenServers []validationpb.Addr
 	for _, server := range servers {
 		profile := requirements[server.Server.Role]
-		if len(profile.Network.Ports.TCP) == 0 && len(profile.Network.Ports.UDP) == 0 {
+		tcp := append([]int{}, profile.Network.Ports.TCP...)
+		udp := append([]int{}, profile.Network.Ports.UDP...)
+		for _, port := range clusterPorts {
+			switch port.Network {
+			case "tcp":
+				tcp = appendPort(tcp, port.Port)
+			case "udp":
+				udp = appendPort(udp, port.Port)
+			}
+		}
+		if len(tcp) == 0 && len(udp) == 0 {
 			continue
 		}
 
@@ -938,7 +1038,7 @@ func constructPingPongRequest(servers []Server, requirements map[string]Requirem
 			Duration: defaults.PingPongDuration,
 			Mode:     ModePingPong,
 		}
-		for _, port := range profile.Network.Ports.TCP {
+		for _, port := range tcp {
 			listenServer := validationpb.Addr{
 				Addr:    fmt.Sprintf("%v:%v", server.AdvertiseIP, port),
 				Network: "tcp",
@@ -946,7 +1046,7 @@ func constructPingPongRequest(servers []Server, requirements map[string]Requirem
 			req.Listen = append(req.Listen, listenServer)
 			listenServers = append(listenServers, listenServer)
 		}
-		for _, port := range profile.Network.Ports.UDP {
+		for _, port := range udp {
 			listenServer := validationpb.Addr{
 				Addr:    fmt.Sprintf("%v:%v", server.AdvertiseIP, port),
 				Network: "udp",
@@ -966,6 +1066,16 @@ func constructPingPongRequest(servers []Server, requirements map[string]Requirem
 	return game, nil
 }
 
+// appendPort adds the specified port to the list unless it is already there
+func appendPort(ports []int, port int) []int {
+	for _, existing := range ports {
+		if existing == port {
+			return ports
+		}
+	}
+	return append(ports, port)
+}
+
 // constructBandwidthRequest constructs a ping-pong game request for a bandwidth test
 func constructBandwidthRequest(servers []Server) (PingPongGame, error) {
 	// use up to defaults.BandwidthTestMaxServers servers for the test
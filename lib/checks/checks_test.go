@@ -21,8 +21,10 @@ import (
 	"time"
 
 	"github.com/gravitational/gravity/lib/defaults"
+	pb "github.com/gravitational/gravity/lib/rpc/proto"
 	"github.com/gravitational/gravity/lib/schema"
 	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
 
 	"github.com/gravitational/trace"
 	. "gopkg.in/check.v1"
@@ -117,6 +119,38 @@ func (s *ChecksSuite) TestTime(c *C) {
 	c.Assert(trace.IsBadParameter(err), Equals, true, Commentf("expected BadParameter, got %v", err))
 }
 
+func (s *ChecksSuite) TestCheckRawDisks(c *C) {
+	server := Server{
+		Server: storage.Server{Role: "node"},
+		ServerInfo: ServerInfo{
+			RuntimeConfig: pb.RuntimeConfig{DockerDevice: "/dev/sdb"},
+			System: storage.NewSystemInfo(storage.SystemSpecV2{
+				Hostname: "node-1",
+				Devices: []storage.Device{
+					{Name: "/dev/sdb", Type: storage.DeviceDisk, SizeMB: 100 << 10},
+					{Name: "/dev/sdc", Type: storage.DeviceDisk, SizeMB: 100 << 10},
+					{Name: "/dev/sdd", Type: storage.DeviceDisk, SizeMB: 1 << 10},
+					{Name: "/dev/sda2", Type: storage.DevicePartition, SizeMB: 100 << 10},
+				},
+			}),
+		},
+	}
+	c.Assert(checkRawDisks(server, nil), IsNil)
+	c.Assert(checkRawDisks(server, &schema.Storage{Provider: schema.StorageProviderCeph}), IsNil)
+	c.Assert(checkRawDisks(server, &schema.Storage{
+		Provider: schema.StorageProviderCeph,
+		RawDisks: &schema.RawDisks{Count: 2},
+	}), IsNil)
+	c.Assert(checkRawDisks(server, &schema.Storage{
+		Provider: schema.StorageProviderCeph,
+		RawDisks: &schema.RawDisks{Count: 2, MinSize: utils.Capacity(10 << 30)},
+	}), NotNil)
+	c.Assert(checkRawDisks(server, &schema.Storage{
+		Provider: schema.StorageProviderCeph,
+		RawDisks: &schema.RawDisks{Count: 3, Profiles: []string{"storage"}},
+	}), IsNil)
+}
+
 func (s *ChecksSuite) TestCheckSameOS(c *C) {
 	infos := []Server{
 		{
@@ -0,0 +1,297 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package checks
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/systeminfo"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/dustin/go-humanize"
+	"github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/trace"
+)
+
+// DiskLocation describes a directory with cluster data and its storage requirements
+type DiskLocation struct {
+	// Name is the name of the data stored in the location, e.g. etcd
+	Name string
+	// Path is the directory path
+	Path string
+	// MinFree is the minimum free space required for the location
+	MinFree utils.Capacity
+	// MinIOPS is the minimum number of synchronous writes per second
+	// the disk of the location is required to sustain, 0 if not checked
+	MinIOPS uint64
+	// Dedicated is whether the location is recommended to have
+	// a filesystem of its own
+	Dedicated bool
+}
+
+// Requirements returns the location requirements in a human readable form
+func (r DiskLocation) Requirements() string {
+	requirements := []string{fmt.Sprintf("at least %v", r.MinFree)}
+	if r.MinIOPS != 0 {
+		requirements = append(requirements, fmt.Sprintf("%v IOPS", r.MinIOPS))
+	}
+	if r.Dedicated {
+		requirements = append(requirements, "dedicated disk")
+	}
+	return strings.Join(requirements, ", ")
+}
+
+// DiskLocations returns the locations of the cluster data with their
+// storage requirements for the specified state directory
+func DiskLocations(stateDir string) []DiskLocation {
+	return []DiskLocation{
+		{
+			Name:    "state",
+			Path:    stateDir,
+			MinFree: utils.MustParseCapacity(defaults.StateDiskMinFree),
+		},
+		{
+			Name:      "etcd",
+			Path:      filepath.Join(stateDir, defaults.PlanetDir, "etcd"),
+			MinFree:   utils.MustParseCapacity(defaults.EtcdDiskMinFree),
+			MinIOPS:   defaults.EtcdDiskMinIOPS,
+			Dedicated: true,
+		},
+		{
+			Name:    "docker",
+			Path:    filepath.Join(stateDir, defaults.PlanetDir, "docker"),
+			MinFree: utils.MustParseCapacity(defaults.DockerDiskMinFree),
+		},
+	}
+}
+
+// DiskStatus describes the disk of a single location
+type DiskStatus struct {
+	// DiskLocation is the checked location
+	DiskLocation
+	// Filesystem is the filesystem the location is on
+	Filesystem systeminfo.FilesystemInfo
+	// IOPS is the measured number of synchronous writes per second,
+	// only set if the location has IOPS requirement
+	IOPS uint64
+}
+
+// DiskReport is the outcome of the disk layout validation
+type DiskReport struct {
+	// Locations lists the status of every checked location
+	Locations []DiskStatus
+	// Failed lists the failed probes
+	Failed []*agentpb.Probe
+	// Relocate lists the locations that should be moved to dedicated disks
+	Relocate []DiskLocation
+}
+
+// ValidateDisks verifies the disk layout of the local node against the
+// requirements of the specified locations.
+//
+// Locations sharing a filesystem need the sum of their free space requirements.
+// Every failed probe describes the disks to mount to remediate the problem
+func ValidateDisks(locations []DiskLocation) (*DiskReport, error) {
+	return validateDisks(locations, hostDisks{})
+}
+
+func validateDisks(locations []DiskLocation, disks diskInfo) (*DiskReport, error) {
+	var report DiskReport
+	for _, location := range locations {
+		filesystem, err := disks.filesystemForDir(location.Path)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		status := DiskStatus{DiskLocation: location, Filesystem: *filesystem}
+		if location.MinIOPS != 0 {
+			status.IOPS, err = disks.measureIOPS(location.Path)
+			if err != nil {
+				return nil, trace.Wrap(err, "failed to measure IOPS of %v", location.Path)
+			}
+		}
+		report.Locations = append(report.Locations, status)
+	}
+
+	relocate := make(map[string]bool)
+	for _, group := range report.byFilesystem() {
+		filesystem := group[0].Filesystem
+		var required uint64
+		var layout []string
+		for _, status := range group {
+			required += status.MinFree.Bytes()
+			layout = append(layout, fmt.Sprintf("%v (%v)", status.Path, status.MinFree))
+		}
+		if filesystem.FreeBytes() >= required {
+			continue
+		}
+		for _, status := range group {
+			if status.Path != filesystem.Filesystem.DirName {
+				relocate[status.Path] = true
+			}
+		}
+		report.Failed = append(report.Failed, diskProbe(
+			"filesystem %v has %v free which is less than %v required. "+
+				"Free up space or mount dedicated disks of the required size: %v",
+			filesystem.Filesystem.DirName, humanize.Bytes(filesystem.FreeBytes()),
+			humanize.Bytes(required), strings.Join(layout, ", ")))
+	}
+
+	for _, status := range report.Locations {
+		if status.MinIOPS != 0 && status.IOPS < status.MinIOPS {
+			relocate[status.Path] = true
+			report.Failed = append(report.Failed, diskProbe(
+				"%v disk of %v sustains %v synchronous writes per second which is less than %v required. "+
+					"Mount a faster dedicated disk (e.g. SSD) of at least %v on %v",
+				status.Name, status.Path, status.IOPS, status.MinIOPS, status.MinFree, status.Path))
+		}
+		if status.Dedicated && report.sharesFilesystem(status) {
+			relocate[status.Path] = true
+		}
+	}
+
+	for _, status := range report.Locations {
+		if relocate[status.Path] {
+			report.Relocate = append(report.Relocate, status.DiskLocation)
+		}
+	}
+	return &report, nil
+}
+
+// byFilesystem groups the locations by their filesystem, in order of appearance
+func (r DiskReport) byFilesystem() (groups [][]DiskStatus) {
+	index := make(map[string]int)
+	for _, status := range r.Locations {
+		dir := status.Filesystem.Filesystem.DirName
+		i, ok := index[dir]
+		if !ok {
+			i = len(groups)
+			index[dir] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], status)
+	}
+	return groups
+}
+
+// sharesFilesystem returns true if the specified location shares
+// its filesystem with any other location
+func (r DiskReport) sharesFilesystem(status DiskStatus) bool {
+	for _, other := range r.Locations {
+		if other.Path != status.Path &&
+			other.Filesystem.Filesystem.DirName == status.Filesystem.Filesystem.DirName {
+			return true
+		}
+	}
+	return false
+}
+
+// FixPlan returns the shell commands that mount dedicated disks
+// for the locations that need relocation, with the devices
+// to be substituted by the user
+func (r DiskReport) FixPlan() string {
+	if len(r.Relocate) == 0 {
+		return ""
+	}
+	locations := append([]DiskLocation(nil), r.Relocate...)
+	// parent directories have to be mounted first
+	sort.Slice(locations, func(i, j int) bool {
+		return locations[i].Path < locations[j].Path
+	})
+	var buf bytes.Buffer
+	fmt.Fprintln(&buf, "# Replace the /dev/<...> placeholders with the disks to use.")
+	fmt.Fprintln(&buf, "# Mounting a directory hides its existing contents, run the commands before installation.")
+	for _, location := range locations {
+		device := fmt.Sprintf("/dev/<%v-disk>", location.Name)
+		fmt.Fprintf(&buf, "\n# %v: %v\n", location.Name, location.Requirements())
+		fmt.Fprintf(&buf, "mkfs.ext4 %v\n", device)
+		fmt.Fprintf(&buf, "mkdir -p %v\n", location.Path)
+		fmt.Fprintf(&buf, "echo '%v %v ext4 defaults 0 2' >> /etc/fstab\n", device, location.Path)
+		fmt.Fprintf(&buf, "mount %v\n", location.Path)
+	}
+	return buf.String()
+}
+
+func diskProbe(format string, args ...interface{}) *agentpb.Probe {
+	return &agentpb.Probe{
+		Checker: diskCheckerID,
+		Detail:  fmt.Sprintf(format, args...),
+		Status:  agentpb.Probe_Failed,
+	}
+}
+
+// diskInfo provides access to the disks of the node
+type diskInfo interface {
+	// filesystemForDir returns the filesystem the specified directory is on
+	filesystemForDir(dir string) (*systeminfo.FilesystemInfo, error)
+	// measureIOPS returns the number of synchronous writes per second
+	// the disk of the specified directory sustains
+	measureIOPS(dir string) (uint64, error)
+}
+
+// hostDisks implements diskInfo for the local host
+type hostDisks struct{}
+
+func (hostDisks) filesystemForDir(dir string) (*systeminfo.FilesystemInfo, error) {
+	return systeminfo.LocalFilesystemForDir(dir)
+}
+
+// measureIOPS makes a series of small writes, each flushed to disk, to a test
+// file in the closest existing parent of dir
+func (hostDisks) measureIOPS(dir string) (uint64, error) {
+	for {
+		if _, err := os.Stat(dir); err == nil || filepath.Dir(dir) == dir {
+			break
+		}
+		dir = filepath.Dir(dir)
+	}
+	file, err := ioutil.TempFile(dir, "iopstest")
+	if err != nil {
+		return 0, trace.ConvertSystemError(err)
+	}
+	defer func() {
+		file.Close()
+		os.Remove(file.Name())
+	}()
+	block := make([]byte, defaults.DiskIOPSTestBlockSize)
+	start := time.Now()
+	for i := 0; i < defaults.DiskIOPSTestWrites; i++ {
+		if _, err := file.Write(block); err != nil {
+			return 0, trace.ConvertSystemError(err)
+		}
+		if err := file.Sync(); err != nil {
+			return 0, trace.ConvertSystemError(err)
+		}
+	}
+	elapsed := time.Since(start)
+	if elapsed <= 0 {
+		elapsed = time.Nanosecond
+	}
+	return uint64(float64(defaults.DiskIOPSTestWrites) / elapsed.Seconds()), nil
+}
+
+const (
+	// diskCheckerID identifies the disk layout checker
+	diskCheckerID = "disk-layout"
+)
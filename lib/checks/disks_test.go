@@ -0,0 +1,141 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package checks
+
+import (
+	"strings"
+
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/systeminfo"
+
+	"github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/trace"
+	. "gopkg.in/check.v1"
+)
+
+type DisksSuite struct{}
+
+var _ = Suite(&DisksSuite{})
+
+func (s *DisksSuite) TestSharedFilesystemNeedsCombinedSpace(c *C) {
+	// 15GB free on the root filesystem is enough for each location
+	// alone but not for all of them combined
+	disks := testDisks{
+		mounts: map[string]uint64{"/": 15 * gigabyte},
+		iops:   1000,
+	}
+	report, err := validateDisks(DiskLocations("/var/lib/gravity"), disks)
+	c.Assert(err, IsNil)
+	c.Assert(probeDetails(report.Failed), DeepEquals, []string{
+		"filesystem / has 16GB free which is less than 17GB required. " +
+			"Free up space or mount dedicated disks of the required size: " +
+			"/var/lib/gravity (5.0GB), /var/lib/gravity/planet/etcd (2.0GB), " +
+			"/var/lib/gravity/planet/docker (10GB)",
+	})
+	c.Assert(locationPaths(report.Relocate), DeepEquals, []string{
+		"/var/lib/gravity",
+		"/var/lib/gravity/planet/etcd",
+		"/var/lib/gravity/planet/docker",
+	})
+	plan := report.FixPlan()
+	c.Assert(strings.Index(plan, "mount /var/lib/gravity\n") <
+		strings.Index(plan, "mount /var/lib/gravity/planet/docker\n"), Equals, true)
+	c.Assert(strings.Contains(plan,
+		"echo '/dev/<etcd-disk> /var/lib/gravity/planet/etcd ext4 defaults 0 2' >> /etc/fstab\n"), Equals, true)
+}
+
+func (s *DisksSuite) TestRecommendsDedicatedEtcdDisk(c *C) {
+	disks := testDisks{
+		mounts: map[string]uint64{
+			"/":                              100 * gigabyte,
+			"/var/lib/gravity/planet/docker": 20 * gigabyte,
+		},
+		iops: 1000,
+	}
+	report, err := validateDisks(DiskLocations("/var/lib/gravity"), disks)
+	c.Assert(err, IsNil)
+	c.Assert(report.Failed, HasLen, 0)
+	c.Assert(locationPaths(report.Relocate), DeepEquals, []string{"/var/lib/gravity/planet/etcd"})
+
+	disks.mounts["/var/lib/gravity/planet/etcd"] = 5 * gigabyte
+	report, err = validateDisks(DiskLocations("/var/lib/gravity"), disks)
+	c.Assert(err, IsNil)
+	c.Assert(report.Failed, HasLen, 0)
+	c.Assert(report.Relocate, HasLen, 0)
+	c.Assert(report.FixPlan(), Equals, "")
+}
+
+func (s *DisksSuite) TestSlowEtcdDisk(c *C) {
+	disks := testDisks{
+		mounts: map[string]uint64{
+			"/":                            100 * gigabyte,
+			"/var/lib/gravity/planet/etcd": 5 * gigabyte,
+		},
+		iops: 20,
+	}
+	report, err := validateDisks(DiskLocations("/var/lib/gravity"), disks)
+	c.Assert(err, IsNil)
+	c.Assert(probeDetails(report.Failed), DeepEquals, []string{
+		"etcd disk of /var/lib/gravity/planet/etcd sustains 20 synchronous writes per second " +
+			"which is less than 50 required. Mount a faster dedicated disk (e.g. SSD) " +
+			"of at least 2.0GB on /var/lib/gravity/planet/etcd",
+	})
+	c.Assert(locationPaths(report.Relocate), DeepEquals, []string{"/var/lib/gravity/planet/etcd"})
+}
+
+// testDisks implements diskInfo with the specified mount points
+type testDisks struct {
+	// mounts maps mount points to the free space on them
+	mounts map[string]uint64
+	// iops is the IOPS of all disks
+	iops uint64
+}
+
+func (r testDisks) filesystemForDir(dir string) (*systeminfo.FilesystemInfo, error) {
+	var filesystems []storage.Filesystem
+	stats := make(storage.FilesystemStats)
+	for mount, free := range r.mounts {
+		filesystems = append(filesystems, storage.Filesystem{DirName: mount, Type: "ext4"})
+		stats[mount] = storage.FilesystemUsage{TotalKB: free / 1024, FreeKB: free / 1024}
+	}
+	system := storage.NewSystemInfo(storage.SystemSpecV2{
+		Filesystems:     filesystems,
+		FilesystemStats: stats,
+	})
+	info, err := systeminfo.FilesystemForDir(system, dir)
+	return info, trace.Wrap(err)
+}
+
+func (r testDisks) measureIOPS(dir string) (uint64, error) {
+	return r.iops, nil
+}
+
+func probeDetails(probes []*agentpb.Probe) (details []string) {
+	for _, probe := range probes {
+		details = append(details, probe.Detail)
+	}
+	return details
+}
+
+func locationPaths(locations []DiskLocation) (paths []string) {
+	for _, location := range locations {
+		paths = append(paths, location.Path)
+	}
+	return paths
+}
+
+const gigabyte = 1024 * 1024 * 1024
@@ -0,0 +1,398 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package checks
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/cenkalti/backoff"
+	"github.com/gravitational/rigging"
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// DNSQueryCluster checks resolution of the fully qualified name
+	// of a cluster service
+	DNSQueryCluster = "cluster"
+	// DNSQuerySearch checks resolution of a cluster service name
+	// relative to the search domains
+	DNSQuerySearch = "search"
+	// DNSQueryOverride checks resolution of a host configured
+	// with the cluster DNS overrides
+	DNSQueryOverride = "override"
+	// DNSQueryStub checks resolution of a name from one of the
+	// stub domains configured with the cluster DNS overrides
+	DNSQueryStub = "stub"
+	// DNSQueryUpstream checks resolution of an external name
+	// by the upstream nameservers
+	DNSQueryUpstream = "upstream"
+)
+
+// DNSCheckConfig defines the configuration of the cluster DNS check
+type DNSCheckConfig struct {
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+	// Client is the Kubernetes client
+	Client kubernetes.Interface
+	// Nodes lists the names of the nodes to run the check on.
+	// If empty, the check runs on all cluster nodes
+	Nodes []string
+	// Overrides are the cluster DNS overrides
+	Overrides storage.DNSOverrides
+	// Names lists additional names to resolve. Names from one of
+	// the stub domains are checked as stub domain names, others as
+	// upstream names
+	Names []string
+	// Timeout limits the time to wait for the check to complete on a node
+	Timeout time.Duration
+}
+
+// CheckAndSetDefaults validates the config and sets default values
+func (r *DNSCheckConfig) CheckAndSetDefaults() error {
+	if r.Client == nil {
+		return trace.BadParameter("missing Kubernetes client")
+	}
+	if r.FieldLogger == nil {
+		r.FieldLogger = logrus.WithField(trace.Component, "checks:dns")
+	}
+	if r.Timeout == 0 {
+		r.Timeout = defaults.DNSCheckTimeout
+	}
+	return nil
+}
+
+// DNSQuery describes a name resolved by the DNS check
+type DNSQuery struct {
+	// Kind is the resolution path the query checks
+	Kind string
+	// Name is the name to resolve
+	Name string
+	// Expected is the address the name is expected to resolve to.
+	// If empty, any address is accepted
+	Expected string
+}
+
+// DNSResult is the result of a query performed on a single node
+type DNSResult struct {
+	// DNSQuery is the performed query
+	DNSQuery
+	// Node is the name of the node the query was performed on
+	Node string
+	// HostNetwork is whether the query was performed from
+	// the host network namespace rather than the pod network
+	HostNetwork bool
+	// Addresses lists the addresses the name resolved to
+	Addresses []string
+	// Error describes the reason the query could not be performed
+	Error string
+}
+
+// Network returns the network the query was performed from
+func (r DNSResult) Network() string {
+	if r.HostNetwork {
+		return "host"
+	}
+	return "pod"
+}
+
+// Failed returns true if the name did not resolve as expected
+func (r DNSResult) Failed() bool {
+	return r.Failure() != ""
+}
+
+// Failure returns the description of the failure or an empty string
+// if the name resolved as expected
+func (r DNSResult) Failure() string {
+	switch {
+	case r.Error != "":
+		return r.Error
+	case len(r.Addresses) == 0:
+		return "name did not resolve"
+	case r.Expected != "" && !utils.StringInSlice(r.Addresses, r.Expected):
+		return fmt.Sprintf("resolved to %v instead of %v",
+			strings.Join(r.Addresses, ", "), r.Expected)
+	}
+	return ""
+}
+
+// String returns the textual representation of the failed result
+func (r DNSResult) String() string {
+	return fmt.Sprintf("node %v (%v network): %v name %v: %v",
+		r.Node, r.Network(), r.Kind, r.Name, r.Failure())
+}
+
+// DNSReport is the result of the cluster DNS check
+type DNSReport struct {
+	// Results lists the results of all queries on all nodes
+	Results []DNSResult
+}
+
+// Failed returns the results of the queries that failed
+func (r DNSReport) Failed() (failed []DNSResult) {
+	for _, result := range r.Results {
+		if result.Failed() {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// FormatFailures returns the failed queries formatted as a list
+func (r DNSReport) FormatFailures() string {
+	var buf bytes.Buffer
+	for _, result := range r.Failed() {
+		fmt.Fprintf(&buf, "\t[x] %v\n", result)
+	}
+	return buf.String()
+}
+
+// CheckDNS validates the cluster DNS resolution paths by resolving
+// cluster service names, host overrides, stub domain and upstream names
+// from both the pod and the host network on each of the specified nodes
+func CheckDNS(ctx context.Context, config DNSCheckConfig) (*DNSReport, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	service, err := config.Client.CoreV1().Services(metav1.NamespaceDefault).
+		Get(defaults.KubernetesServiceName, metav1.GetOptions{})
+	if err != nil {
+		return nil, trace.Wrap(rigging.ConvertError(err))
+	}
+	queries := DNSQueries(config.Overrides, service.Spec.ClusterIP, config.Names)
+	nodes := config.Nodes
+	if len(nodes) == 0 {
+		nodes, err = nodeNames(config.Client)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+	var report DNSReport
+	for _, node := range nodes {
+		for _, hostNetwork := range []bool{false, true} {
+			results := queryNode(ctx, config, node, hostNetwork, queries)
+			report.Results = append(report.Results, results...)
+		}
+	}
+	return &report, nil
+}
+
+// DNSQueries returns the queries that check cluster DNS given the cluster
+// DNS overrides, the address of the Kubernetes API service and a list
+// of additional names
+func DNSQueries(overrides storage.DNSOverrides, serviceIP string, names []string) []DNSQuery {
+	serviceName := fmt.Sprintf("%v.%v", defaults.KubernetesServiceName, metav1.NamespaceDefault)
+	queries := []DNSQuery{
+		{
+			Kind:     DNSQueryCluster,
+			Name:     fmt.Sprintf("%v.svc.cluster.local", serviceName),
+			Expected: serviceIP,
+		},
+		{
+			Kind:     DNSQuerySearch,
+			Name:     serviceName,
+			Expected: serviceIP,
+		},
+	}
+	hosts := make([]string, 0, len(overrides.Hosts))
+	for host := range overrides.Hosts {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	for _, host := range hosts {
+		queries = append(queries, DNSQuery{
+			Kind:     DNSQueryOverride,
+			Name:     host,
+			Expected: overrides.Hosts[host],
+		})
+	}
+	for _, name := range names {
+		kind := DNSQueryUpstream
+		if inZones(name, overrides.Zones) {
+			kind = DNSQueryStub
+		}
+		queries = append(queries, DNSQuery{
+			Kind: kind,
+			Name: name,
+		})
+	}
+	return queries
+}
+
+// queryNode performs the specified queries on the node using a short-lived
+// pod and returns the results. Failure to run the pod is reported as the
+// failure of every query
+func queryNode(ctx context.Context, config DNSCheckConfig, node string, hostNetwork bool, queries []DNSQuery) []DNSResult {
+	results := make([]DNSResult, 0, len(queries))
+	for _, query := range queries {
+		results = append(results, DNSResult{
+			DNSQuery:    query,
+			Node:        node,
+			HostNetwork: hostNetwork,
+		})
+	}
+	output, err := runDNSPod(ctx, config, node, hostNetwork, queries)
+	if err != nil {
+		config.WithError(err).Warnf("Failed to check DNS on node %v.", node)
+		for i := range results {
+			results[i].Error = trace.UserMessage(err)
+		}
+		return results
+	}
+	addresses := parseDNSOutput(output)
+	for i := range results {
+		results[i].Addresses = addresses[results[i].Name]
+	}
+	return results
+}
+
+// runDNSPod runs the pod that resolves the names of the specified queries
+// on the node and returns its output
+func runDNSPod(ctx context.Context, config DNSCheckConfig, node string, hostNetwork bool, queries []DNSQuery) ([]byte, error) {
+	pods := config.Client.CoreV1().Pods(defaults.KubeSystemNamespace)
+	pod, err := pods.Create(dnsPod(node, hostNetwork, queries))
+	if err != nil {
+		return nil, trace.Wrap(rigging.ConvertError(err))
+	}
+	defer func() {
+		err := pods.Delete(pod.Name, &metav1.DeleteOptions{})
+		if err != nil {
+			config.WithError(err).Warnf("Failed to delete pod %v.", pod.Name)
+		}
+	}()
+	config.Debugf("Checking DNS on node %v with pod %v.", node, pod.Name)
+	err = utils.RetryWithInterval(ctx, utils.NewExponentialBackOff(config.Timeout), func() error {
+		pod, err := pods.Get(pod.Name, metav1.GetOptions{})
+		if err != nil {
+			return trace.Wrap(rigging.ConvertError(err))
+		}
+		switch pod.Status.Phase {
+		case v1.PodSucceeded:
+			return nil
+		case v1.PodFailed:
+			return &backoff.PermanentError{
+				Err: trace.BadParameter("check pod %v failed: %v", pod.Name, pod.Status.Message),
+			}
+		}
+		return trace.NotFound("check pod %v has not completed yet", pod.Name)
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	output, err := pods.GetLogs(pod.Name, &v1.PodLogOptions{}).Do().Raw()
+	if err != nil {
+		return nil, trace.Wrap(rigging.ConvertError(err))
+	}
+	return output, nil
+}
+
+// dnsPod returns the pod that resolves the names of the specified queries
+// on the node
+func dnsPod(node string, hostNetwork bool, queries []DNSQuery) *v1.Pod {
+	dnsPolicy := v1.DNSClusterFirst
+	if hostNetwork {
+		dnsPolicy = v1.DNSClusterFirstWithHostNet
+	}
+	command := []string{"/bin/sh", "-c", dnsCheckScript, "dns-check"}
+	for _, query := range queries {
+		command = append(command, query.Name)
+	}
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "dns-check-",
+			Namespace:    defaults.KubeSystemNamespace,
+			Labels:       map[string]string{constants.DNSCheckLabel: node},
+		},
+		Spec: v1.PodSpec{
+			NodeName:      node,
+			HostNetwork:   hostNetwork,
+			DNSPolicy:     dnsPolicy,
+			RestartPolicy: v1.RestartPolicyNever,
+			Tolerations:   []v1.Toleration{{Operator: v1.TolerationOpExists}},
+			Containers: []v1.Container{{
+				Name:    "dns-check",
+				Image:   fmt.Sprintf("%v/%v", constants.DockerRegistry, defaults.HookContainerNameTag),
+				Command: command,
+			}},
+		},
+	}
+}
+
+// parseDNSOutput parses the output of the check script into a mapping
+// of resolved names to their addresses
+func parseDNSOutput(output []byte) map[string][]string {
+	addresses := make(map[string][]string)
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		addresses[fields[0]] = nil
+		for _, addr := range fields[1:] {
+			if !utils.StringInSlice(addresses[fields[0]], addr) {
+				addresses[fields[0]] = append(addresses[fields[0]], addr)
+			}
+		}
+	}
+	return addresses
+}
+
+// nodeNames returns the names of all cluster nodes
+func nodeNames(client kubernetes.Interface) ([]string, error) {
+	nodes, err := client.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		return nil, trace.Wrap(rigging.ConvertError(err))
+	}
+	names := make([]string, 0, len(nodes.Items))
+	for _, node := range nodes.Items {
+		names = append(names, node.Name)
+	}
+	return names, nil
+}
+
+// inZones returns true if the name belongs to one of the specified zones
+func inZones(name string, zones map[string][]string) bool {
+	name = strings.TrimSuffix(name, ".")
+	for zone := range zones {
+		zone = strings.TrimSuffix(zone, ".")
+		if name == zone || strings.HasSuffix(name, "."+zone) {
+			return true
+		}
+	}
+	return false
+}
+
+// dnsCheckScript resolves each of its arguments and outputs a line
+// with the name followed by the addresses it resolved to
+const dnsCheckScript = `for name in "$@"; do
+  echo "$name" $(getent hosts "$name" | cut -d' ' -f1)
+done`
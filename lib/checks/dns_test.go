@@ -0,0 +1,82 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package checks
+
+import (
+	"github.com/gravitational/gravity/lib/storage"
+
+	. "gopkg.in/check.v1"
+)
+
+type DNSSuite struct{}
+
+var _ = Suite(&DNSSuite{})
+
+func (s *DNSSuite) TestQueries(c *C) {
+	queries := DNSQueries(storage.DNSOverrides{
+		Hosts: map[string]string{"registry.example.com": "10.0.0.5"},
+		Zones: map[string][]string{"example.internal.": {"10.0.0.53"}},
+	}, "10.100.0.1", []string{"consul.example.internal", "gravitational.com"})
+	c.Assert(queries, DeepEquals, []DNSQuery{
+		{Kind: DNSQueryCluster, Name: "kubernetes.default.svc.cluster.local", Expected: "10.100.0.1"},
+		{Kind: DNSQuerySearch, Name: "kubernetes.default", Expected: "10.100.0.1"},
+		{Kind: DNSQueryOverride, Name: "registry.example.com", Expected: "10.0.0.5"},
+		{Kind: DNSQueryStub, Name: "consul.example.internal"},
+		{Kind: DNSQueryUpstream, Name: "gravitational.com"},
+	})
+}
+
+func (s *DNSSuite) TestParsesOutput(c *C) {
+	output := []byte(`kubernetes.default.svc.cluster.local 10.100.0.1
+kubernetes.default
+gravitational.com 1.2.3.4 1.2.3.4 5.6.7.8
+`)
+	c.Assert(parseDNSOutput(output), DeepEquals, map[string][]string{
+		"kubernetes.default.svc.cluster.local": {"10.100.0.1"},
+		"kubernetes.default":                   nil,
+		"gravitational.com":                    {"1.2.3.4", "5.6.7.8"},
+	})
+}
+
+func (s *DNSSuite) TestReportsFailures(c *C) {
+	report := DNSReport{Results: []DNSResult{
+		{
+			DNSQuery:  DNSQuery{Kind: DNSQueryCluster, Name: "kubernetes.default.svc.cluster.local", Expected: "10.100.0.1"},
+			Node:      "node-1",
+			Addresses: []string{"10.100.0.1"},
+		},
+		{
+			DNSQuery:    DNSQuery{Kind: DNSQuerySearch, Name: "kubernetes.default", Expected: "10.100.0.1"},
+			Node:        "node-1",
+			HostNetwork: true,
+		},
+		{
+			DNSQuery:  DNSQuery{Kind: DNSQueryOverride, Name: "registry.example.com", Expected: "10.0.0.5"},
+			Node:      "node-2",
+			Addresses: []string{"10.0.0.6"},
+		},
+		{
+			DNSQuery: DNSQuery{Kind: DNSQueryUpstream, Name: "gravitational.com"},
+			Node:     "node-2",
+			Error:    "check pod dns-check-x failed",
+		},
+	}}
+	c.Assert(report.FormatFailures(), Equals,
+		"\t[x] node node-1 (host network): search name kubernetes.default: name did not resolve\n"+
+			"\t[x] node node-2 (pod network): override name registry.example.com: resolved to 10.0.0.6 instead of 10.0.0.5\n"+
+			"\t[x] node node-2 (pod network): upstream name gravitational.com: check pod dns-check-x failed\n")
+}
@@ -0,0 +1,422 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package checks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	rpcclient "github.com/gravitational/gravity/lib/rpc/client"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+)
+
+// ClusterPort describes a port cluster nodes use to communicate with each other
+type ClusterPort struct {
+	// Name is the name of the component listening on the port
+	Name string `json:"name,omitempty"`
+	// Network is the network protocol: tcp or udp
+	Network string `json:"network"`
+	// Port is the port number
+	Port int `json:"port"`
+	// FromMasters is whether only master nodes connect to the port
+	FromMasters bool `json:"from_masters,omitempty"`
+	// ToMasters is whether the port is only served on master nodes
+	ToMasters bool `json:"to_masters,omitempty"`
+}
+
+// String returns a textual representation of the port, e.g. "etcd 2379/tcp"
+func (r ClusterPort) String() string {
+	if r.Name == "" {
+		return fmt.Sprintf("%v/%v", r.Port, r.Network)
+	}
+	return fmt.Sprintf("%v %v/%v", r.Name, r.Port, r.Network)
+}
+
+// ClusterPorts returns the ports cluster nodes require to reach each other on.
+// vxlanPort specifies the overlay network port
+func ClusterPorts(vxlanPort int) []ClusterPort {
+	if vxlanPort == 0 {
+		vxlanPort = defaults.VxlanPort
+	}
+	return []ClusterPort{
+		{Name: "serf", Network: "tcp", Port: defaults.SerfAgentPort},
+		{Name: "etcd", Network: "tcp", Port: defaults.EtcdAPIPort, ToMasters: true},
+		{Name: "etcd-peer", Network: "tcp", Port: defaults.EtcdPeerPort, FromMasters: true, ToMasters: true},
+		{Name: "kubelet", Network: "tcp", Port: defaults.KubeletPort, FromMasters: true},
+		{Name: "vxlan", Network: "udp", Port: vxlanPort},
+	}
+}
+
+// NetworkPath describes the connectivity from one node to a port on another node
+type NetworkPath struct {
+	// From is the address of the source node
+	From string `json:"from"`
+	// To is the address of the target node
+	To string `json:"to"`
+	// Port is the target port
+	Port ClusterPort `json:"port"`
+	// Error describes the reason the target could not be reached
+	Error string `json:"error,omitempty"`
+}
+
+// Blocked returns true if the target could not be reached from the source
+func (r NetworkPath) Blocked() bool {
+	return r.Error != ""
+}
+
+// String returns a textual representation of the path
+func (r NetworkPath) String() string {
+	return fmt.Sprintf("%v -> %v: %v", r.From, r.To, r.Port)
+}
+
+// NetworkMatrix describes the connectivity between all pairs of cluster nodes
+type NetworkMatrix struct {
+	// Nodes lists the addresses of the checked nodes
+	Nodes []string `json:"nodes"`
+	// Paths lists the checked paths
+	Paths []NetworkPath `json:"paths"`
+}
+
+// Blocked returns the paths that could not be reached
+func (r NetworkMatrix) Blocked() (blocked []NetworkPath) {
+	for _, path := range r.Paths {
+		if path.Blocked() {
+			blocked = append(blocked, path)
+		}
+	}
+	return blocked
+}
+
+// String renders the matrix as a table with a row for each source node
+// and a column for each target node. Each cell lists the ports blocked
+// between the pair of nodes
+func (r NetworkMatrix) String() string {
+	cells := make(map[[2]string][]string)
+	checked := make(map[[2]string]bool)
+	for _, path := range r.Paths {
+		key := [2]string{path.From, path.To}
+		checked[key] = true
+		if path.Blocked() {
+			cells[key] = append(cells[key], path.Port.String())
+		}
+	}
+	var buf bytes.Buffer
+	w := new(tabwriter.Writer)
+	w.Init(&buf, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "FROM \\ TO\t%v\n", strings.Join(r.Nodes, "\t"))
+	for _, from := range r.Nodes {
+		row := []string{from}
+		for _, to := range r.Nodes {
+			key := [2]string{from, to}
+			switch {
+			case !checked[key]:
+				row = append(row, "-")
+			case len(cells[key]) == 0:
+				row = append(row, "ok")
+			default:
+				row = append(row, strings.Join(cells[key], ","))
+			}
+		}
+		fmt.Fprintf(w, "%v\n", strings.Join(row, "\t"))
+	}
+	w.Flush()
+	return buf.String()
+}
+
+// FormatBlocked returns the list of blocked paths formatted for output
+func (r NetworkMatrix) FormatBlocked() string {
+	var buf bytes.Buffer
+	for _, path := range r.Blocked() {
+		fmt.Fprintf(&buf, "\t[x] %v: %v\n", path, path.Error)
+	}
+	return buf.String()
+}
+
+// MatrixFromPingPong returns the connectivity matrix from the results
+// of a ping-pong game. ports are used to name the known ports
+func MatrixFromPingPong(results PingPongGameResults, ports []ClusterPort) NetworkMatrix {
+	var matrix NetworkMatrix
+	nodes := make(map[string]struct{})
+	for addr, result := range results {
+		from, _ := utils.SplitHostPort(addr, "")
+		nodes[from] = struct{}{}
+		for _, ping := range result.PingResults {
+			if ping.Server == nil {
+				continue
+			}
+			to, portS := utils.SplitHostPort(ping.Server.Addr, "")
+			nodes[to] = struct{}{}
+			port, _ := strconv.Atoi(portS)
+			path := NetworkPath{
+				From: from,
+				To:   to,
+				Port: clusterPort(ports, ping.Server.Network, port),
+			}
+			if ping.Code != 0 {
+				path.Error = ping.Error
+				if path.Error == "" {
+					path.Error = "unreachable"
+				}
+			}
+			matrix.Paths = append(matrix.Paths, path)
+		}
+	}
+	for node := range nodes {
+		matrix.Nodes = append(matrix.Nodes, node)
+	}
+	sort.Strings(matrix.Nodes)
+	sortPaths(matrix.Paths)
+	return matrix
+}
+
+// NetworkCheckConfig defines the configuration of the network connectivity check
+type NetworkCheckConfig struct {
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+	// Servers lists the nodes to check connectivity between
+	Servers []storage.Server
+	// Ports lists the ports to check. Only TCP ports are checked.
+	// Defaults to the cluster ports
+	Ports []ClusterPort
+	// Dialer dials the peers from a node
+	Dialer NodeDialer
+	// Timeout limits the time to wait for the check on a node
+	Timeout time.Duration
+}
+
+// CheckAndSetDefaults validates the config and sets default values
+func (r *NetworkCheckConfig) CheckAndSetDefaults() error {
+	if r.Dialer == nil {
+		return trace.BadParameter("missing Dialer")
+	}
+	if r.FieldLogger == nil {
+		r.FieldLogger = logrus.WithField(trace.Component, "checks:network")
+	}
+	if len(r.Ports) == 0 {
+		r.Ports = ClusterPorts(defaults.VxlanPort)
+	}
+	if r.Timeout == 0 {
+		r.Timeout = defaults.NetworkCheckTimeout
+	}
+	return nil
+}
+
+// NodeDialer dials TCP addresses from a cluster node
+type NodeDialer interface {
+	// DialPorts dials the specified addresses from the given node
+	// and returns the results in the same order
+	DialPorts(ctx context.Context, node storage.Server, addrs []string) ([]DialResult, error)
+}
+
+// AgentClients provides access to the agents running on cluster nodes
+type AgentClients interface {
+	// GetClient returns a client to the agent running on the node with the specified address
+	GetClient(ctx context.Context, addr string) (rpcclient.Client, error)
+}
+
+// NewAgentDialer returns a dialer that dials addresses from the nodes
+// with the agents running on them
+func NewAgentDialer(agents AgentClients) NodeDialer {
+	return &agentDialer{agents: agents}
+}
+
+// DialResult is the result of dialing a single address
+type DialResult struct {
+	// Addr is the dialed address
+	Addr string `json:"addr"`
+	// Error describes the reason the address could not be reached
+	Error string `json:"error,omitempty"`
+}
+
+// DialPorts dials the specified TCP addresses in parallel
+// and returns the results in the same order
+func DialPorts(ctx context.Context, addrs []string, timeout time.Duration) []DialResult {
+	results := make([]DialResult, len(addrs))
+	dialer := net.Dialer{Timeout: timeout}
+	var wg sync.WaitGroup
+	for i, addr := range addrs {
+		wg.Add(1)
+		go func(i int, addr string) {
+			defer wg.Done()
+			results[i].Addr = addr
+			conn, err := dialer.DialContext(ctx, "tcp", addr)
+			if err != nil {
+				results[i].Error = err.Error()
+				return
+			}
+			conn.Close()
+		}(i, addr)
+	}
+	wg.Wait()
+	return results
+}
+
+// CheckNetwork tests the connectivity between all pairs of the specified
+// nodes on the cluster ports. Nodes are checked in parallel.
+//
+// Only TCP ports are tested: UDP ports, like the overlay network port, are
+// in use by the running cluster and are only tested by the preflight checks
+func CheckNetwork(ctx context.Context, config NetworkCheckConfig) (*NetworkMatrix, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	matrix := &NetworkMatrix{}
+	type nodeResult struct {
+		paths []NetworkPath
+		err   error
+	}
+	resultsCh := make(chan nodeResult, len(config.Servers))
+	for _, server := range config.Servers {
+		matrix.Nodes = append(matrix.Nodes, server.AdvertiseIP)
+		go func(server storage.Server) {
+			paths, err := checkNodeNetwork(ctx, server, config)
+			resultsCh <- nodeResult{paths: paths, err: err}
+		}(server)
+	}
+	var errors []error
+	for range config.Servers {
+		result := <-resultsCh
+		if result.err != nil {
+			errors = append(errors, result.err)
+			continue
+		}
+		matrix.Paths = append(matrix.Paths, result.paths...)
+	}
+	if len(errors) != 0 {
+		return nil, trace.NewAggregate(errors...)
+	}
+	sort.Strings(matrix.Nodes)
+	sortPaths(matrix.Paths)
+	return matrix, nil
+}
+
+// checkNodeNetwork dials the cluster ports of all peers from the specified node
+func checkNodeNetwork(ctx context.Context, server storage.Server, config NetworkCheckConfig) ([]NetworkPath, error) {
+	paths := networkPaths(server, config.Servers, config.Ports)
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	addrs := make([]string, 0, len(paths))
+	for _, path := range paths {
+		addrs = append(addrs, net.JoinHostPort(path.To, strconv.Itoa(path.Port.Port)))
+	}
+	ctx, cancel := context.WithTimeout(ctx, config.Timeout)
+	defer cancel()
+	config.Infof("Checking connectivity from %v to %v.", server.AdvertiseIP, addrs)
+	results, err := config.Dialer.DialPorts(ctx, server, addrs)
+	if err != nil {
+		return nil, trace.Wrap(err, "failed to check connectivity from node %v", server.AdvertiseIP)
+	}
+	if len(results) != len(paths) {
+		return nil, trace.BadParameter("expected %v results from node %v, got %v",
+			len(paths), server.AdvertiseIP, len(results))
+	}
+	for i := range paths {
+		paths[i].Error = results[i].Error
+	}
+	return paths, nil
+}
+
+// networkPaths returns the TCP paths to check from the specified node
+func networkPaths(from storage.Server, servers []storage.Server, ports []ClusterPort) (paths []NetworkPath) {
+	for _, to := range servers {
+		if to.AdvertiseIP == from.AdvertiseIP {
+			continue
+		}
+		for _, port := range ports {
+			if port.Network != "tcp" {
+				continue
+			}
+			if (port.FromMasters && !from.IsMaster()) || (port.ToMasters && !to.IsMaster()) {
+				continue
+			}
+			paths = append(paths, NetworkPath{
+				From: from.AdvertiseIP,
+				To:   to.AdvertiseIP,
+				Port: port,
+			})
+		}
+	}
+	return paths
+}
+
+// clusterPort returns the port from the specified list matching the given
+// network and port number
+func clusterPort(ports []ClusterPort, network string, port int) ClusterPort {
+	for _, clusterPort := range ports {
+		if clusterPort.Network == network && clusterPort.Port == port {
+			return clusterPort
+		}
+	}
+	return ClusterPort{Network: network, Port: port}
+}
+
+func sortPaths(paths []NetworkPath) {
+	sort.Slice(paths, func(i, j int) bool {
+		if paths[i].From != paths[j].From {
+			return paths[i].From < paths[j].From
+		}
+		if paths[i].To != paths[j].To {
+			return paths[i].To < paths[j].To
+		}
+		if paths[i].Port.Network != paths[j].Port.Network {
+			return paths[i].Port.Network < paths[j].Port.Network
+		}
+		return paths[i].Port.Port < paths[j].Port.Port
+	})
+}
+
+type agentDialer struct {
+	agents AgentClients
+}
+
+// DialPorts dials the specified addresses from the given node using
+// the agent running on the node
+func (r *agentDialer) DialPorts(ctx context.Context, node storage.Server, addrs []string) ([]DialResult, error) {
+	clt, err := r.agents.GetClient(ctx, node.AdvertiseIP)
+	if err != nil {
+		return nil, trace.Wrap(err, "failed to connect to agent.\n"+
+			"Make sure the node has an agent running by "+
+			"issuing `gravity agent deploy`")
+	}
+	var buf bytes.Buffer
+	args := append([]string{"check", "dial",
+		fmt.Sprintf("--timeout=%v", defaults.NetworkCheckDialTimeout)}, addrs...)
+	err = clt.GravityCommand(ctx, logrus.WithField("server", node.AdvertiseIP), &buf, args...)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var results []DialResult
+	if err := json.Unmarshal(buf.Bytes(), &results); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return results, nil
+}
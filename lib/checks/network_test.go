@@ -0,0 +1,128 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package checks
+
+import (
+	"context"
+	"net"
+	"sort"
+	"sync"
+
+	pb "github.com/gravitational/gravity/lib/network/validation/proto"
+	"github.com/gravitational/gravity/lib/storage"
+
+	. "gopkg.in/check.v1"
+)
+
+type NetworkSuite struct{}
+
+var _ = Suite(&NetworkSuite{})
+
+func (s *NetworkSuite) TestChecksTCPPortsBetweenNodes(c *C) {
+	servers := []storage.Server{
+		{AdvertiseIP: "10.0.0.1", ClusterRole: "master"},
+		{AdvertiseIP: "10.0.0.2", ClusterRole: "node"},
+	}
+	dialer := &testDialer{blocked: map[string]string{
+		"10.0.0.2->10.0.0.1:2379": "i/o timeout",
+	}}
+	matrix, err := CheckNetwork(context.TODO(), NetworkCheckConfig{
+		Servers: servers,
+		Dialer:  dialer,
+	})
+	c.Assert(err, IsNil)
+	sort.Strings(dialer.dialed)
+	c.Assert(dialer.dialed, DeepEquals, []string{
+		"10.0.0.1->10.0.0.2:10250",
+		"10.0.0.1->10.0.0.2:7496",
+		"10.0.0.2->10.0.0.1:2379",
+		"10.0.0.2->10.0.0.1:7496",
+	})
+	c.Assert(matrix.Blocked(), DeepEquals, []NetworkPath{{
+		From:  "10.0.0.2",
+		To:    "10.0.0.1",
+		Port:  ClusterPort{Name: "etcd", Network: "tcp", Port: 2379, ToMasters: true},
+		Error: "i/o timeout",
+	}})
+	c.Assert(matrix.String(), Equals, ""+
+		"FROM \\ TO  10.0.0.1       10.0.0.2\n"+
+		"10.0.0.1   -              ok\n"+
+		"10.0.0.2   etcd 2379/tcp  -\n")
+	c.Assert(matrix.FormatBlocked(), Equals,
+		"\t[x] 10.0.0.2 -> 10.0.0.1: etcd 2379/tcp: i/o timeout\n")
+}
+
+func (s *NetworkSuite) TestMatrixFromPingPong(c *C) {
+	results := PingPongGameResults{
+		"10.0.0.1:3012": PingPongResult{PingResults: []pb.ServerResult{
+			{Server: &pb.Addr{Addr: "10.0.0.2:8472", Network: "udp"}, Code: 1, Error: "timeout"},
+			{Server: &pb.Addr{Addr: "10.0.0.2:7496", Network: "tcp"}},
+		}},
+		"10.0.0.2:3012": PingPongResult{PingResults: []pb.ServerResult{
+			{Server: &pb.Addr{Addr: "10.0.0.1:8472", Network: "udp"}},
+			{Server: &pb.Addr{Addr: "10.0.0.1:8080", Network: "tcp"}, Code: 1},
+		}},
+	}
+	matrix := MatrixFromPingPong(results, ClusterPorts(0))
+	c.Assert(matrix.Nodes, DeepEquals, []string{"10.0.0.1", "10.0.0.2"})
+	c.Assert(matrix.FormatBlocked(), Equals, ""+
+		"\t[x] 10.0.0.1 -> 10.0.0.2: vxlan 8472/udp: timeout\n"+
+		"\t[x] 10.0.0.2 -> 10.0.0.1: 8080/tcp: unreachable\n")
+}
+
+func (s *NetworkSuite) TestPingPongRequestIncludesClusterPorts(c *C) {
+	servers := []Server{
+		{Server: storage.Server{AdvertiseIP: "10.0.0.1", Role: "node"}},
+		{Server: storage.Server{AdvertiseIP: "10.0.0.2", Role: "node"}},
+	}
+	requirements := map[string]Requirements{
+		"node": {Network: Network{Ports: Ports{TCP: []int{7496, 8080}}}},
+	}
+	game, err := constructPingPongRequest(servers, requirements, []ClusterPort{
+		{Network: "tcp", Port: 7496},
+		{Network: "udp", Port: 8472},
+	})
+	c.Assert(err, IsNil)
+	c.Assert(game["10.0.0.1"].Listen, DeepEquals, []pb.Addr{
+		{Addr: "10.0.0.1:7496", Network: "tcp"},
+		{Addr: "10.0.0.1:8080", Network: "tcp"},
+		{Addr: "10.0.0.1:8472", Network: "udp"},
+	})
+	c.Assert(game["10.0.0.2"].Ping, HasLen, 6)
+	c.Assert(requirements["node"].Network.Ports.TCP, DeepEquals, []int{7496, 8080})
+}
+
+type testDialer struct {
+	sync.Mutex
+	// blocked maps "from->addr" to the dial error
+	blocked map[string]string
+	dialed  []string
+}
+
+func (r *testDialer) DialPorts(ctx context.Context, node storage.Server, addrs []string) (results []DialResult, err error) {
+	r.Lock()
+	defer r.Unlock()
+	for _, addr := range addrs {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			return nil, err
+		}
+		path := node.AdvertiseIP + "->" + addr
+		r.dialed = append(r.dialed, path)
+		results = append(results, DialResult{Addr: addr, Error: r.blocked[path]})
+	}
+	return results, nil
+}
@@ -0,0 +1,240 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package checks
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gravitational/gravity/lib/schema"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/dustin/go-humanize"
+)
+
+// Recommendation describes the recommended flavor and node profile
+// placement for a set of discovered nodes
+type Recommendation struct {
+	// Flavor is the largest application flavor the nodes can satisfy,
+	// empty if the application defines no flavors or none fits
+	Flavor string `json:"flavor,omitempty"`
+	// Nodes lists profile recommendations for each node
+	Nodes []NodeRecommendation `json:"nodes"`
+}
+
+// NodeRecommendation describes profile placement for a single node
+type NodeRecommendation struct {
+	// Hostname is the node hostname
+	Hostname string `json:"hostname"`
+	// Profile is the recommended profile for the node, empty if the node
+	// does not satisfy any profile
+	Profile string `json:"profile,omitempty"`
+	// Fits lists all profiles whose requirements the node satisfies
+	Fits []string `json:"fits,omitempty"`
+	// Mismatches maps profiles the node does not satisfy to the reasons why
+	Mismatches map[string][]string `json:"mismatches,omitempty"`
+}
+
+// Recommend returns the flavor and node profile placement recommendation
+// for the specified nodes based on the profile requirements of the manifest.
+//
+// Nodes are matched to the most demanding profile they satisfy so that
+// larger nodes are reserved for heavier profiles.
+func Recommend(manifest schema.Manifest, nodes []storage.System) Recommendation {
+	profiles := sortedByDemand(manifest.NodeProfiles)
+	var recommendation Recommendation
+	for _, node := range nodes {
+		rec := NodeRecommendation{Hostname: node.GetHostname()}
+		for _, profile := range profiles {
+			mismatches := profileMismatches(profile, node)
+			if len(mismatches) == 0 {
+				rec.Fits = append(rec.Fits, profile.Name)
+				continue
+			}
+			if rec.Mismatches == nil {
+				rec.Mismatches = make(map[string][]string)
+			}
+			rec.Mismatches[profile.Name] = mismatches
+		}
+		if len(rec.Fits) != 0 {
+			rec.Profile = rec.Fits[0]
+		}
+		recommendation.Nodes = append(recommendation.Nodes, rec)
+	}
+	if manifest.Installer == nil {
+		return recommendation
+	}
+	flavor, placement := largestFlavor(manifest.Installer.Flavors.Items, profiles, recommendation.Nodes)
+	if flavor == nil {
+		return recommendation
+	}
+	recommendation.Flavor = flavor.Name
+	for i, profile := range placement {
+		recommendation.Nodes[i].Profile = profile
+	}
+	return recommendation
+}
+
+// largestFlavor returns the flavor with the most nodes that can be placed
+// on the specified nodes along with the profile placement for each node
+func largestFlavor(flavors []schema.Flavor, profiles []schema.NodeProfile, nodes []NodeRecommendation) (*schema.Flavor, map[int]string) {
+	var best *schema.Flavor
+	var bestPlacement map[int]string
+	var bestCount int
+	for i, flavor := range flavors {
+		placement, ok := placeFlavor(flavor, profiles, nodes)
+		if !ok {
+			continue
+		}
+		if count := flavorNodeCount(flavor); best == nil || count > bestCount {
+			best, bestPlacement, bestCount = &flavors[i], placement, count
+		}
+	}
+	return best, bestPlacement
+}
+
+// placeFlavor assigns the profiles of the specified flavor to the nodes.
+// Most demanding profiles are placed first, each on the node that satisfies
+// the fewest profiles to keep versatile nodes available.
+// Returns false if the flavor cannot be placed
+func placeFlavor(flavor schema.Flavor, profiles []schema.NodeProfile, nodes []NodeRecommendation) (map[int]string, bool) {
+	placement := make(map[int]string)
+	for _, profile := range profiles {
+		for _, flavorNode := range flavor.Nodes {
+			if flavorNode.Profile != profile.Name {
+				continue
+			}
+			for i := 0; i < flavorNode.Count; i++ {
+				node := -1
+				for j, rec := range nodes {
+					if _, placed := placement[j]; placed || !fits(rec, profile.Name) {
+						continue
+					}
+					if node == -1 || len(rec.Fits) < len(nodes[node].Fits) {
+						node = j
+					}
+				}
+				if node == -1 {
+					return nil, false
+				}
+				placement[node] = profile.Name
+			}
+		}
+	}
+	return placement, true
+}
+
+func flavorNodeCount(flavor schema.Flavor) (count int) {
+	for _, node := range flavor.Nodes {
+		count += node.Count
+	}
+	return count
+}
+
+func fits(rec NodeRecommendation, profile string) bool {
+	for _, name := range rec.Fits {
+		if name == profile {
+			return true
+		}
+	}
+	return false
+}
+
+// profileMismatches returns the list of profile requirements the node does not satisfy
+func profileMismatches(profile schema.NodeProfile, node storage.System) (mismatches []string) {
+	requirements := profile.Requirements
+	if node.GetNumCPU() < uint(requirements.CPU.Min) {
+		mismatches = append(mismatches, fmt.Sprintf("requires at least %v CPUs, node has %v",
+			requirements.CPU.Min, node.GetNumCPU()))
+	}
+	if requirements.CPU.Max != 0 && node.GetNumCPU() > uint(requirements.CPU.Max) {
+		mismatches = append(mismatches, fmt.Sprintf("supports at most %v CPUs, node has %v",
+			requirements.CPU.Max, node.GetNumCPU()))
+	}
+	if node.GetMemory().Total < requirements.RAM.Min.Bytes() {
+		mismatches = append(mismatches, fmt.Sprintf("requires at least %v RAM, node has %v",
+			requirements.RAM.Min, humanize.Bytes(node.GetMemory().Total)))
+	}
+	if requirements.RAM.Max != 0 && node.GetMemory().Total > requirements.RAM.Max.Bytes() {
+		mismatches = append(mismatches, fmt.Sprintf("supports at most %v RAM, node has %v",
+			requirements.RAM.Max, humanize.Bytes(node.GetMemory().Total)))
+	}
+	hardware := node.GetHardware()
+	if hardware == nil {
+		return mismatches
+	}
+	// link speed is only compared when known
+	minRate := requirements.Network.MinTransferRate.BytesPerSecond()
+	if speed := hardware.MaxLinkSpeedMbps(); speed != 0 && minRate != 0 {
+		linkRate := uint64(speed) * 1000 * 1000 / 8
+		if linkRate < minRate {
+			mismatches = append(mismatches, fmt.Sprintf("requires transfer rate of %v, node link speed is %vMbps",
+				requirements.Network.MinTransferRate, speed))
+		}
+	}
+	var volumes uint64
+	for _, volume := range requirements.Volumes {
+		volumes += volume.Capacity.Bytes()
+	}
+	if total := hardware.TotalDiskBytes(); len(hardware.Disks) != 0 && total < volumes {
+		mismatches = append(mismatches, fmt.Sprintf("requires %v of disk capacity, node has %v",
+			humanize.Bytes(volumes), humanize.Bytes(total)))
+	}
+	return mismatches
+}
+
+// sortedByDemand returns the profiles sorted by their CPU and RAM
+// requirements in descending order
+func sortedByDemand(profiles schema.NodeProfiles) []schema.NodeProfile {
+	sorted := make([]schema.NodeProfile, len(profiles))
+	copy(sorted, profiles)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		ri, rj := sorted[i].Requirements, sorted[j].Requirements
+		if ri.CPU.Min != rj.CPU.Min {
+			return ri.CPU.Min > rj.CPU.Min
+		}
+		return ri.RAM.Min > rj.RAM.Min
+	})
+	return sorted
+}
+
+// FormatRecommendation returns a human-readable representation of the recommendation
+func FormatRecommendation(recommendation Recommendation) string {
+	var buf bytes.Buffer
+	if recommendation.Flavor != "" {
+		fmt.Fprintf(&buf, "Recommended flavor: %v\n", recommendation.Flavor)
+	}
+	for _, node := range recommendation.Nodes {
+		if node.Profile == "" {
+			fmt.Fprintf(&buf, "Node %v does not satisfy any profile:\n", node.Hostname)
+		} else {
+			fmt.Fprintf(&buf, "Node %v: recommended profile %q (fits: %v)\n",
+				node.Hostname, node.Profile, strings.Join(node.Fits, ", "))
+		}
+		var names []string
+		for name := range node.Mismatches {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(&buf, "    %v: %v\n", name, strings.Join(node.Mismatches[name], "; "))
+		}
+	}
+	return buf.String()
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package checks
+
+import (
+	"github.com/gravitational/gravity/lib/schema"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
+
+	. "gopkg.in/check.v1"
+)
+
+type RecommendSuite struct{}
+
+var _ = Suite(&RecommendSuite{})
+
+func (s *RecommendSuite) TestRecommendsProfilesAndFlavor(c *C) {
+	manifest := schema.Manifest{
+		NodeProfiles: schema.NodeProfiles{
+			{
+				Name: "worker",
+				Requirements: schema.Requirements{
+					CPU: schema.CPU{Min: 2},
+					RAM: schema.RAM{Min: utils.Capacity(2 * gigabyte)},
+				},
+			},
+			{
+				Name: "db",
+				Requirements: schema.Requirements{
+					CPU: schema.CPU{Min: 8},
+					RAM: schema.RAM{Min: utils.Capacity(16 * gigabyte)},
+				},
+			},
+		},
+		Installer: &schema.Installer{
+			Flavors: schema.Flavors{
+				Items: []schema.Flavor{
+					{Name: "small", Nodes: []schema.FlavorNode{{Profile: "worker", Count: 1}}},
+					{Name: "medium", Nodes: []schema.FlavorNode{
+						{Profile: "db", Count: 1}, {Profile: "worker", Count: 2}}},
+					{Name: "large", Nodes: []schema.FlavorNode{
+						{Profile: "db", Count: 2}, {Profile: "worker", Count: 2}}},
+				},
+			},
+		},
+	}
+	nodes := []storage.System{
+		newSystem("node-1", 4, 8*gigabyte),
+		newSystem("node-2", 16, 32*gigabyte),
+		newSystem("node-3", 2, 4*gigabyte),
+		newSystem("node-4", 1, 1*gigabyte),
+	}
+	recommendation := Recommend(manifest, nodes)
+	c.Assert(recommendation.Flavor, Equals, "medium")
+	c.Assert(recommendation.Nodes, HasLen, 4)
+	c.Assert(recommendation.Nodes[0].Profile, Equals, "worker")
+	c.Assert(recommendation.Nodes[1].Profile, Equals, "db")
+	c.Assert(recommendation.Nodes[1].Fits, DeepEquals, []string{"db", "worker"})
+	c.Assert(recommendation.Nodes[2].Profile, Equals, "worker")
+	c.Assert(recommendation.Nodes[3].Profile, Equals, "")
+	c.Assert(recommendation.Nodes[3].Mismatches["worker"], DeepEquals, []string{
+		"requires at least 2 CPUs, node has 1",
+		"requires at least 2.1GB RAM, node has 1.1GB",
+	})
+}
+
+func (s *RecommendSuite) TestChecksHardwareRequirements(c *C) {
+	profile := schema.NodeProfile{
+		Name: "storage",
+		Requirements: schema.Requirements{
+			Network: schema.Network{MinTransferRate: utils.TransferRate(1000 * 1000 * 1000 / 8 * 10)},
+			Volumes: []schema.Volume{{Capacity: utils.Capacity(500 * gigabyte)}},
+		},
+	}
+	node := newSystem("node-1", 4, 8*gigabyte)
+	node.Spec.Hardware = &storage.HardwareProfile{
+		Disks: []storage.DiskInfo{{Name: "sda", SizeBytes: 100 * gigabyte}},
+		NICs:  []storage.NICInfo{{Name: "eth0", SpeedMbps: 1000}},
+	}
+	c.Assert(profileMismatches(profile, node), HasLen, 2)
+
+	node.Spec.Hardware.Disks = append(node.Spec.Hardware.Disks, storage.DiskInfo{Name: "sdb", SizeBytes: 1000 * gigabyte})
+	node.Spec.Hardware.NICs = []storage.NICInfo{{Name: "eth0", SpeedMbps: 10000}}
+	c.Assert(profileMismatches(profile, node), HasLen, 0)
+}
+
+func newSystem(hostname string, cpus uint, memory uint64) *storage.SystemV2 {
+	return storage.NewSystemInfo(storage.SystemSpecV2{
+		Hostname: hostname,
+		NumCPU:   cpus,
+		Memory:   storage.Memory{Total: memory},
+	})
+}
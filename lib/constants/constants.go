@@ -139,6 +139,10 @@ const (
 	// PlanetConfigPackage is the package with planet configuration
 	PlanetConfigPackage = "planet-config"
 
+	// PlanetConfigBasePackage is the name prefix of packages with planet
+	// configuration shared between nodes
+	PlanetConfigBasePackage = "planet-config-base"
+
 	// PlanetRootfs is the planet's rootfs
 	PlanetRootfs = "rootfs"
 
@@ -204,6 +208,39 @@ const (
 	// If not empty, turns the preflight checks off
 	PreflightChecksOffEnvVar = "GRAVITY_CHECKS_OFF"
 
+	// ClockSkewHookEnvVar names the environment variable that specifies the command
+	// to execute when a TLS handshake with a remote agent fails due to clock skew.
+	// The command is executed with the shell and is expected to synchronize the time
+	ClockSkewHookEnvVar = "GRAVITY_CLOCK_SKEW_HOOK"
+
+	// ClockSkewNodeEnvVar names the environment variable with the address
+	// of the node the clock skew has been detected with, passed to the clock skew hook
+	ClockSkewNodeEnvVar = "GRAVITY_CLOCK_SKEW_NODE"
+
+	// ClockSkewLocalEnvVar names the environment variable passed to the clock skew hook
+	// that is set to "true" if the clock on the local node has been detected as skewed
+	ClockSkewLocalEnvVar = "GRAVITY_CLOCK_SKEW_LOCAL"
+
+	// ClockSkewSecondsEnvVar names the environment variable passed to the clock skew hook
+	// with the minimum detected clock offset of the local node in seconds
+	ClockSkewSecondsEnvVar = "GRAVITY_CLOCK_SKEW_SECONDS"
+
+	// PackageCompressionEnvVar names the environment variable that overrides
+	// the compression of newly created packages: "gzip" (default) or "zstd"
+	PackageCompressionEnvVar = "GRAVITY_PACKAGE_COMPRESSION"
+
+	// RemoteBuilderTokenEnvVar names the environment variable with the token
+	// to authenticate tele with the remote builder
+	RemoteBuilderTokenEnvVar = "TELE_BUILD_TOKEN"
+
+	// TeleEncryptionKeyEnvVar names the environment variable with the passphrase
+	// tele encrypts the installer packages with
+	TeleEncryptionKeyEnvVar = "TELE_ENCRYPTION_KEY"
+
+	// InstallerEncryptionKeyEnvVar names the environment variable with the passphrase
+	// to decrypt the encrypted installer packages with
+	InstallerEncryptionKeyEnvVar = "GRAVITY_ENCRYPTION_KEY"
+
 	// DockerRegistry is a default name for private docker registry
 	DockerRegistry = "leader.telekube.local:5000"
 
@@ -399,6 +436,10 @@ const (
 	// type per node profile that was picked by user
 	EnvTelekubeNodeProfileInstanceTypeTemplate = "TELEKUBE_NODE_PROFILE_INSTANCE_TYPE_%v"
 
+	// EnvTelekubeParameterTemplate is the environment variable template with
+	// the value of the application configuration parameter passed to hooks
+	EnvTelekubeParameterTemplate = "TELEKUBE_PARAMETER_%v"
+
 	// AWSClusterNameTag is a name of AWS tag which assigns resource to Kubernetes cluster
 	AWSClusterNameTag = "KubernetesCluster"
 
@@ -542,6 +583,109 @@ const (
 	// AuthGatewayConfigMap is the name of config map with auth gateway configuration.
 	AuthGatewayConfigMap = "auth-gateway"
 
+	// DNSProviderConfigMapPrefix is the name prefix of config maps with
+	// external DNS provider configuration
+	DNSProviderConfigMapPrefix = "dns-provider-"
+	// DNSProviderLabel is the label set on config maps with external
+	// DNS provider configuration
+	DNSProviderLabel = "gravitational.io/dns-provider"
+
+	// WebhookConfigMapPrefix is the name prefix of config maps with
+	// cluster lifecycle events webhook configuration
+	WebhookConfigMapPrefix = "webhook-"
+	// WebhookLabel is the label set on config maps with
+	// cluster lifecycle events webhook configuration
+	WebhookLabel = "gravitational.io/webhook"
+
+	// SecretBackendSecretPrefix is the name prefix of secrets with
+	// external secret backend configuration
+	SecretBackendSecretPrefix = "secret-backend-"
+	// SecretBackendLabel is the label set on secrets with external
+	// secret backend configuration
+	SecretBackendLabel = "gravitational.io/secret-backend"
+	// SecretBackendSourceAnnotation is the annotation set on Kubernetes
+	// secrets populated from an external secret backend
+	SecretBackendSourceAnnotation = "gravitational.io/secret-backend-source"
+
+	// PackageRetentionConfigMapPrefix is the name prefix of config maps with
+	// package retention policies
+	PackageRetentionConfigMapPrefix = "package-retention-"
+	// PackageRetentionLabel is the label set on config maps with
+	// package retention policies
+	PackageRetentionLabel = "gravitational.io/package-retention"
+
+	// IngressControllerConfigMap is the name of config map with
+	// the bundled ingress controller configuration
+	IngressControllerConfigMap = "ingress-controller"
+	// IngressControllerLabel is the label set on objects of the bundled
+	// ingress controller
+	IngressControllerLabel = "gravitational.io/ingress-controller"
+
+	// BandwidthProfileConfigMap is the name of config map with the
+	// cluster bandwidth profile
+	BandwidthProfileConfigMap = "bandwidth-profile"
+
+	// TimeSyncConfigMap is the name of config map with the node
+	// time synchronization configuration
+	TimeSyncConfigMap = "time-sync"
+
+	// QuarantinedNodesConfigMap is the name of config map with the nodes
+	// quarantined for flapping
+	QuarantinedNodesConfigMap = "quarantined-nodes"
+	// TimeSyncLabel is the label set on objects that apply the node
+	// time synchronization configuration
+	TimeSyncLabel = "gravitational.io/time-sync"
+
+	// LogRotationConfigMap is the name of config map with the node
+	// log rotation policy
+	LogRotationConfigMap = "log-rotation"
+	// LogRotationLabel is the label set on objects that enforce the node
+	// log rotation policy
+	LogRotationLabel = "gravitational.io/log-rotation"
+
+	// OperationReaperConfigMap is the name of config map with the
+	// abandoned operations cleanup configuration
+	OperationReaperConfigMap = "operation-reaper"
+
+	// TrustedCAConfigMap is the name of config map with the trusted
+	// CA bundle
+	TrustedCAConfigMap = "trusted-ca"
+	// TrustedCALabel is the label set on objects that install the
+	// trusted CA bundle on nodes
+	TrustedCALabel = "gravitational.io/trusted-ca"
+
+	// DNSCheckLabel is the label set on pods that check cluster DNS
+	// resolution on nodes
+	DNSCheckLabel = "gravitational.io/dns-check"
+
+	// AppOverlayConfigMapPrefix is the name prefix of config maps with
+	// bundled application overlays
+	AppOverlayConfigMapPrefix = "app-overlay-"
+	// AppOverlayLabel is the label set on config maps with bundled
+	// application overlays and on the objects created from them
+	AppOverlayLabel = "gravitational.io/app-overlay"
+
+	// SnapshotPolicyLabel is the label set on scheduled volume snapshots
+	// with the name of the snapshot policy that created them
+	SnapshotPolicyLabel = "gravitational.io/snapshot-policy"
+	// SnapshotAppLabel is the label set on scheduled volume snapshots
+	// with the name of the application that declares the snapshot policy
+	SnapshotAppLabel = "gravitational.io/snapshot-app"
+	// SnapshotClaimLabel is the label set on scheduled volume snapshots
+	// with the name of the snapshotted persistent volume claim
+	SnapshotClaimLabel = "gravitational.io/snapshot-claim"
+
+	// NamespaceAppLabel is the label set on namespaces declared in the
+	// application manifest with the name of the application
+	NamespaceAppLabel = "gravitational.io/namespace-app"
+	// NamespaceLimitsName is the name of the resource quota and limit
+	// range gravity manages in application namespaces
+	NamespaceLimitsName = "gravity-limits"
+
+	// NodeReplacementsConfigMap is the name of config map with identities
+	// of the nodes being replaced
+	NodeReplacementsConfigMap = "node-replacements"
+
 	// LVMSystemDir specifies the default location where lvm2 keeps state and configuration data
 	LVMSystemDir = "/etc/lvm"
 	// LVMSystemDirEnvvar defines the name of the environment variable that overrides the
@@ -90,6 +90,191 @@ const (
 	// PhaseTimeout is the default phase execution timeout
 	PhaseTimeout = "1h"
 
+	// UnattendedUpgradeRetries is the default number of times an unattended
+	// upgrade retries the operation plan after a failure
+	UnattendedUpgradeRetries = 2
+	// UnattendedUpgradeRetryInterval is the default interval between
+	// unattended upgrade retries
+	UnattendedUpgradeRetryInterval = 1 * time.Minute
+	// UnattendedUpgradeHealthTimeout is the default time an unattended upgrade
+	// waits for the cluster to become healthy
+	UnattendedUpgradeHealthTimeout = 10 * time.Minute
+	// UnattendedUpgradeRollbackTimeout is the default time an unattended upgrade
+	// waits for the failed operation to roll back
+	UnattendedUpgradeRollbackTimeout = 1 * time.Hour
+
+	// HarnessDockerImage is the default image of the test cluster nodes run as containers.
+	// The image is expected to run systemd
+	HarnessDockerImage = "centos/systemd"
+	// HarnessNodePrefix is the default name prefix of test cluster nodes run as containers
+	HarnessNodePrefix = "gravity-test"
+	// HarnessClusterName is the default name of test clusters
+	HarnessClusterName = "test.local"
+	// HarnessInstallTimeout is the default time a test cluster is allowed to install
+	HarnessInstallTimeout = 1 * time.Hour
+	// HarnessUpgradeTimeout is the default time a test cluster is allowed to upgrade
+	HarnessUpgradeTimeout = 1 * time.Hour
+
+	// InstallerCacheTTL is how long installer tarballs generated on demand
+	// are kept in the Ops Center cache since they were last requested
+	InstallerCacheTTL = 24 * time.Hour
+
+	// MetadataCacheTTL is how long cluster metadata queried by interactive
+	// CLI commands, like package lists and cluster status, is cached locally
+	MetadataCacheTTL = 10 * time.Second
+
+	// FileLockRetryPeriod is how often a lock on a file locked by
+	// another process is retried
+	FileLockRetryPeriod = 500 * time.Millisecond
+
+	// UnpackLockTimeout is how long unpacking a package waits for
+	// another process unpacking it into the same directory to finish
+	UnpackLockTimeout = 30 * time.Minute
+
+	// DNSProviderRecordTTL is the default TTL of records published
+	// to external DNS providers
+	DNSProviderRecordTTL = 1 * time.Minute
+	// DNSProviderSyncInterval is how often cluster endpoints are published
+	// to external DNS providers
+	DNSProviderSyncInterval = 1 * time.Minute
+	// DNSZoneTransferListenAddr is the default address the zone transfer
+	// DNS provider serves the zone on
+	DNSZoneTransferListenAddr = "0.0.0.0:10053"
+	// DNSNotifyTimeout is the timeout for notifying secondary DNS servers
+	// about zone changes
+	DNSNotifyTimeout = 5 * time.Second
+
+	// SecretBackendRefreshInterval is how often secrets are read
+	// from external secret backends
+	SecretBackendRefreshInterval = 5 * time.Minute
+	// SecretBackendMinRefreshInterval is the minimum allowed interval
+	// between reads from an external secret backend
+	SecretBackendMinRefreshInterval = 30 * time.Second
+	// SecretBackendTimeout is the timeout of a single request
+	// to an external secret backend
+	SecretBackendTimeout = 10 * time.Second
+
+	// PackageRPCTimeout is the default timeout of a single gRPC package service call
+	// that does not transfer package data
+	PackageRPCTimeout = 1 * time.Minute
+
+	// WebhookTimeout is the default timeout of a single webhook delivery attempt
+	WebhookTimeout = 10 * time.Second
+	// WebhookPollInterval is how often cluster operations are checked
+	// for lifecycle events to send to webhooks
+	WebhookPollInterval = 10 * time.Second
+	// WebhookMaxDeliveryTime limits the total time spent retrying
+	// the delivery of a single event to a webhook
+	WebhookMaxDeliveryTime = 10 * time.Minute
+
+	// StatusWatchInterval is how often the cluster status is refreshed
+	// in the status watch mode
+	StatusWatchInterval = 5 * time.Second
+	// StatusWatchHistory is the number of recent status transitions
+	// displayed in the status watch mode
+	StatusWatchHistory = 10
+
+	// IngressControllerSyncInterval is how often the bundled ingress
+	// controller is reconciled with its configuration
+	IngressControllerSyncInterval = 1 * time.Minute
+	// AppOverlaySyncInterval is how often the overlays of bundled
+	// applications are reconciled
+	AppOverlaySyncInterval = 1 * time.Minute
+	// IngressControllerHTTPPort is the default host port the bundled
+	// ingress controller serves HTTP traffic on
+	IngressControllerHTTPPort = 80
+	// IngressControllerHTTPSPort is the default host port the bundled
+	// ingress controller serves HTTPS traffic on
+	IngressControllerHTTPSPort = 443
+	// IngressControllerReplicas is the default number of bundled
+	// ingress controller replicas
+	IngressControllerReplicas = 2
+
+	// LowBandwidthHeartbeatInterval is how often the cluster refreshes the
+	// status it reports to the Ops Center in low-bandwidth mode
+	LowBandwidthHeartbeatInterval = 10 * time.Minute
+	// LowBandwidthInventoryInterval is how often the Ops Center collects
+	// the cluster inventory in low-bandwidth mode
+	LowBandwidthInventoryInterval = 1 * time.Hour
+	// LowBandwidthEventBatchInterval is how long cluster events are buffered
+	// before they are sent to the Ops Center in low-bandwidth mode
+	LowBandwidthEventBatchInterval = 15 * time.Minute
+	// InventorySyncInterval is how often the Ops Center collects
+	// the cluster inventory by default
+	InventorySyncInterval = 5 * time.Minute
+	// CatalogMirrorSyncInterval is how often a mirror Ops Center
+	// synchronizes its catalog with the upstream Ops Center by default
+	CatalogMirrorSyncInterval = 10 * time.Minute
+
+	// TimeSyncSyncInterval is how often the node time synchronization
+	// configuration is reconciled
+	TimeSyncSyncInterval = 1 * time.Minute
+	// ChronyConfigDir is the directory with chrony configuration inside planet
+	ChronyConfigDir = "/etc/chrony"
+	// ChronyConfigFile is the name of the chrony configuration file
+	ChronyConfigFile = "chrony.conf"
+	// ChronyServiceName is the name of the chrony service inside planet
+	ChronyServiceName = "chrony.service"
+
+	// LogRotationSyncInterval is how often the node log rotation
+	// policy is reconciled
+	LogRotationSyncInterval = 1 * time.Minute
+	// ContainerLogMaxFiles is the default number of rotated logs
+	// kept for every container
+	ContainerLogMaxFiles = 5
+	// ContainerLogCheckInterval is the default interval the size of
+	// the container logs is checked with
+	ContainerLogCheckInterval = 5 * time.Minute
+	// JournaldConfigDir is the directory with journald configuration
+	// drop-ins inside planet
+	JournaldConfigDir = "/etc/systemd/journald.conf.d"
+	// JournaldServiceName is the name of the journald service inside planet
+	JournaldServiceName = "systemd-journald.service"
+	// PlanetDockerDir is the docker data directory inside planet
+	PlanetDockerDir = "/ext/docker"
+
+	// TrustedCASyncInterval is how often the trusted CA bundle is reconciled
+	TrustedCASyncInterval = 1 * time.Minute
+	// TrustedCACheckInterval is how often nodes verify that the trusted
+	// CA bundle is still installed, e.g. after planet has been updated
+	TrustedCACheckInterval = 1 * time.Minute
+	// LocalCACertificatesDir is the directory with local CA certificates
+	// inside planet picked up by update-ca-certificates
+	LocalCACertificatesDir = "/usr/local/share/ca-certificates"
+	// NodeReplacementSyncInterval is how often the identities of the nodes
+	// being replaced are matched against the nodes that joined the cluster
+	NodeReplacementSyncInterval = 30 * time.Second
+
+	// KubeletCSRApprovalInterval is how often the pending kubelet
+	// certificate signing requests are reviewed
+	KubeletCSRApprovalInterval = 10 * time.Second
+	// KubeletCertExpiryWarning is how long before its expiration
+	// a kubelet certificate is reported as expiring
+	KubeletCertExpiryWarning = 7 * 24 * time.Hour
+	// KubeletCertProbeTimeout is the timeout for retrieving the kubelet
+	// serving certificate of a node
+	KubeletCertProbeTimeout = 5 * time.Second
+	// KubeletCertDir is the directory inside planet where kubelet
+	// keeps its rotated certificates
+	KubeletCertDir = "/var/lib/kubelet/pki"
+
+	// SnapshotSyncInterval is how often application volume snapshot
+	// policies are evaluated
+	SnapshotSyncInterval = 1 * time.Minute
+	// SnapshotMinInterval is the minimum allowed interval between
+	// scheduled snapshots of the same volume
+	SnapshotMinInterval = 10 * time.Minute
+	// SnapshotRetain is the default number of most recent scheduled
+	// snapshots kept for every volume
+	SnapshotRetain = 7
+
+	// UpgradeGateWindow is the default time the upgrade health gates
+	// are evaluated after the system software on a node has been updated
+	UpgradeGateWindow = 2 * time.Minute
+	// UpgradeGateInterval is the default interval between upgrade
+	// health gate evaluations
+	UpgradeGateInterval = 15 * time.Second
+
 	// UpdateTimeout is the max allowed time for system update
 	UpdateTimeout = 30 * time.Minute
 
@@ -135,6 +320,29 @@ const (
 	// MaxExpandConcurrency is the number of servers that can be joining the cluster concurrently
 	MaxExpandConcurrency = 5
 
+	// PullPackagesConcurrency is the number of packages pulled concurrently
+	// when syncing packages between package services
+	PullPackagesConcurrency = 4
+
+	// OperationLockStaleTimeout is the amount of time an operation has to make
+	// no progress before its locks can be released without forcing
+	OperationLockStaleTimeout = 10 * time.Minute
+
+	// OperationReaperThreshold is the default amount of time an operation
+	// has to show no activity before it is considered abandoned
+	OperationReaperThreshold = 1 * time.Hour
+	// OperationReaperInterval is how often the cluster looks for
+	// abandoned operations
+	OperationReaperInterval = 5 * time.Minute
+
+	// PackageRetentionInterval is how often package retention
+	// policies are enforced
+	PackageRetentionInterval = 1 * time.Hour
+
+	// PackageDeltaMaxBaseSize is the maximum size of the package
+	// used as the base for the delta transfer of another version
+	PackageDeltaMaxBaseSize = 4 * 1024 * 1024 * 1024
+
 	// DownloadRetryPeriod is the period between failed retry attempts
 	DownloadRetryPeriod = 5 * time.Second
 
@@ -194,6 +402,21 @@ const (
 	// GravityRPCAgentServiceName defines systemd unit service name
 	GravityRPCAgentServiceName = "gravity-agent.service"
 
+	// SSHAgentServiceName is the name of the transient systemd unit the installer
+	// runs agents as on the nodes it has connected to over SSH
+	SSHAgentServiceName = "gravity-ssh-agent.service"
+
+	// SSHPort is the default SSH port
+	SSHPort = 22
+
+	// SSHKeyPath is the default path to the SSH private key, relative
+	// to the user's home directory
+	SSHKeyPath = ".ssh/id_rsa"
+
+	// SSHKnownHostsPath is the default path to the SSH known hosts file,
+	// relative to the user's home directory
+	SSHKnownHostsPath = ".ssh/known_hosts"
+
 	// AgentValidationTimeout specifies the maximum amount of time for a remote validation
 	// request during the preflight test
 	AgentValidationTimeout = 1 * time.Minute
@@ -213,6 +436,14 @@ const (
 	// PeerConnectTimeout is the timeout of an RPC agent connecting to its peer
 	PeerConnectTimeout = 10 * time.Second
 
+	// BatchJoinTimeout is how long a batch expand operation waits for
+	// all nodes in the batch to join
+	BatchJoinTimeout = 30 * time.Minute
+
+	// BatchStatusInterval is how often the batch expand operation reports
+	// the status of individual nodes
+	BatchStatusInterval = 5 * time.Second
+
 	// GravityPackagePrefix defines base prefix of gravity package
 	GravityPackagePrefix = "gravitational.io/gravity"
 
@@ -237,6 +468,10 @@ const (
 	// HumanReasonableTimeout is amount of time certain command can run without producing any output
 	HumanReasonableTimeout = 3 * time.Second
 
+	// PlanetStatusTimeout is the maximum amount of time to wait for the
+	// planet status command to complete
+	PlanetStatusTimeout = 1 * time.Minute
+
 	// ClusterCheckTimeout is amount of time allotted to the test that verifies if cluster controller
 	// is accessible
 	ClusterCheckTimeout = 5 * time.Second
@@ -304,6 +539,9 @@ const (
 	// JournalctlBin is the default location of the journalctl inside planet
 	JournalctlBin = "/usr/bin/journalctl"
 
+	// DockerBin is the default location of the docker client inside planet
+	DockerBin = "/usr/bin/docker"
+
 	// SystemctlBin is systemctl executable inside planet
 	SystemctlBin = "/bin/systemctl"
 
@@ -365,6 +603,13 @@ const (
 	// PackagesDir is the place where we put all local packages
 	PackagesDir = "packages"
 
+	// PackageCacheDir is the gravity subdirectory with the packages
+	// pre-seeded into a node image by gravity baker
+	PackageCacheDir = "package-cache"
+
+	// PartialDir is the name of the directory with partially downloaded packages
+	PartialDir = "partial"
+
 	// UpdateDir is the gravity subdirectory where update related data is stored
 	UpdateDir = "update"
 
@@ -374,6 +619,15 @@ const (
 	// ImportDir is the place for app import state
 	ImportDir = "import"
 
+	// InstallerCacheDir is the place for installer tarballs generated on demand
+	InstallerCacheDir = "installers"
+
+	// MetadataCacheDir is the place for cluster metadata cached by CLI commands
+	MetadataCacheDir = "cache"
+
+	// LicenseFileName is the name of the license file embedded into installers
+	LicenseFileName = "license.pem"
+
 	// TempDir is the place for temp files and folders
 	TempDir = "tmp"
 
@@ -452,6 +706,14 @@ const (
 	// connection attempts
 	DialTimeout = 30 * time.Second
 
+	// ClockSkewCheckTimeout is the timeout for the TLS handshake used to diagnose
+	// clock skew after a failed connection attempt to a remote agent
+	ClockSkewCheckTimeout = 5 * time.Second
+
+	// ClockSkewHookTimeout is the maximum amount of time the clock skew
+	// remediation hook is allowed to run
+	ClockSkewHookTimeout = 1 * time.Minute
+
 	// ConnectionDeadlineTimeout specifies the connection deadline timeout for use
 	// with the vhost muxer.
 	// The muxer uses specified deadline for the duration of its routing decision and resets
@@ -522,6 +784,10 @@ const (
 	InfluxDBServiceAddr = "influxdb.monitoring.svc.cluster.local"
 	// InfluxDBServicePort is the API port of InfluxDB service
 	InfluxDBServicePort = 8086
+	// InfluxDBServiceName is the name of InfluxDB service
+	InfluxDBServiceName = "influxdb"
+	// InfluxDBDatabase is the name of InfluxDB database with cluster metrics
+	InfluxDBDatabase = "k8s"
 	// InfluxDBAdminUser is the InfluxDB admin user name
 	InfluxDBAdminUser = "root"
 	// InfluxDBAdminPassword is the InfluxDB admin user password
@@ -544,6 +810,10 @@ const (
 	// traffic behind SNI router
 	LocalAgentsAddr = "127.0.0.1:3012"
 
+	// RemoteBuilderListenAddr is the default address the remote
+	// installer builder service listens on
+	RemoteBuilderListenAddr = "0.0.0.0:3030"
+
 	// ManifestFileName is the name of the application manifest
 	ManifestFileName = "app.yaml"
 
@@ -560,6 +830,34 @@ const (
 	// SiteStatusCheckInterval is how often local gravity site will invoke app status hook
 	SiteStatusCheckInterval = 1 * time.Minute
 
+	// FlapDetectionInterval is how often the membership status of cluster
+	// nodes is sampled to detect flapping nodes
+	FlapDetectionInterval = 10 * time.Second
+
+	// FlapDetectionWindow is the time window the node membership
+	// transitions are counted in
+	FlapDetectionWindow = 10 * time.Minute
+
+	// FlapThreshold is the number of membership transitions within the
+	// detection window after which the node is quarantined
+	FlapThreshold = 4
+
+	// QuarantineReleasePeriod is how long a quarantined node has to stay
+	// online without transitions before it is released automatically
+	QuarantineReleasePeriod = 30 * time.Minute
+
+	// BLOBDeleteTimeout is how long a package BLOB deletion may take before
+	// the BLOB can be referenced by new packages again
+	BLOBDeleteTimeout = 5 * time.Minute
+
+	// WatchdogInterval is how often the service watchdog checks planet services
+	WatchdogInterval = 30 * time.Second
+	// WatchdogRestartWindow is the time window service restarts are counted in
+	WatchdogRestartWindow = 10 * time.Minute
+	// WatchdogMaxRestarts is the number of service restarts within the window
+	// after which the watchdog gives up and marks the cluster degraded
+	WatchdogMaxRestarts = 3
+
 	// OfflineCheckInterval is how often OpsCenter checks whether its sites are online/offline
 	OfflineCheckInterval = 10 * time.Second
 
@@ -570,6 +868,11 @@ const (
 
 	// KubeSystemNamespace is the name of k8s namespace where all our system stuff goes
 	KubeSystemNamespace = "kube-system"
+
+	// KubernetesServiceName is the name of the Kubernetes API service
+	// in the default namespace
+	KubernetesServiceName = "kubernetes"
+
 	// MonitoringNamespace is the name of k8s namespace for the monitoring-related resources
 	MonitoringNamespace = "monitoring"
 
@@ -762,6 +1065,21 @@ const (
 	// DiskTransferRate is the minimum required disk speed for some default locations
 	DiskTransferRate = "10MB/s"
 
+	// StateDiskMinFree is the minimum free space required for the state directory,
+	// not counting the etcd and docker data
+	StateDiskMinFree = "5GB"
+	// EtcdDiskMinFree is the minimum free space required for the etcd data
+	EtcdDiskMinFree = "2GB"
+	// EtcdDiskMinIOPS is the minimum number of synchronous writes per second
+	// the etcd disk is required to sustain
+	EtcdDiskMinIOPS = 50
+	// DockerDiskMinFree is the minimum free space required for the docker data
+	DockerDiskMinFree = "10GB"
+	// DiskIOPSTestWrites is the number of synchronous writes made to measure disk IOPS
+	DiskIOPSTestWrites = 100
+	// DiskIOPSTestBlockSize is the size of a single write made to measure disk IOPS
+	DiskIOPSTestBlockSize = 4096
+
 	// PingPongDuration is the duration of a ping-pong game agents play
 	PingPongDuration = 10 * time.Second
 	// BandwidthTestPort is the port for the bandwidth test agents do
@@ -837,11 +1155,24 @@ const (
 	// EtcdUpgradeBackupFile is the filename to store a temporary backup of the etcd database when recreating the etcd datastore
 	EtcdUpgradeBackupFile = "etcd.bak"
 
+	// EtcdBackupServiceName is the name of the systemd unit that takes scheduled etcd backups
+	EtcdBackupServiceName = "gravity-etcd-backup.service"
+	// EtcdBackupInterval is the default interval between scheduled etcd backups
+	EtcdBackupInterval = 24 * time.Hour
+	// EtcdBackupRetain is the default number of scheduled etcd backups to keep
+	EtcdBackupRetain = 7
+
 	// EtcdPeerPort is etcd inter-cluster communication port
 	EtcdPeerPort = 2380
 	// EtcdAPIPort is etcd client API port
 	EtcdAPIPort = 2379
 
+	// SerfAgentPort is the serf (health check agents) peer to peer port
+	SerfAgentPort = 7496
+
+	// KubeletPort is the kubelet API port
+	KubeletPort = 10250
+
 	// SchedulerKeyFilename is the kube-scheduler private key filename
 	SchedulerKeyFilename = "scheduler.key"
 	// SchedulerCertFilename is the kube-scheduler certificate filename
@@ -884,6 +1215,55 @@ const (
 	// DrainTimeout defines the total drain operation timeout
 	DrainTimeout = 1 * time.Hour
 
+	// OSUpdateHookTimeout limits the time the OS update hook is allowed to run on a single node
+	OSUpdateHookTimeout = 1 * time.Hour
+
+	// OSUpdateHealthTimeout limits the time to wait for a node to become healthy after OS update
+	OSUpdateHealthTimeout = 20 * time.Minute
+
+	// RollingRestartHealthTimeout limits the time to wait for the cluster
+	// to become healthy after a node has been restarted
+	RollingRestartHealthTimeout = 10 * time.Minute
+
+	// RollingRestartSettleDelay is the time to wait after a node has been
+	// restarted before checking its health
+	RollingRestartSettleDelay = 30 * time.Second
+
+	// DockerMigrationHealthTimeout limits the time to wait for the cluster
+	// to become healthy after docker storage driver has been migrated on a node.
+	// It is longer than the rolling restart timeout as the node needs to
+	// pull all images again
+	DockerMigrationHealthTimeout = 20 * time.Minute
+
+	// DNSCheckTimeout limits the time to wait for the DNS check to complete on a node
+	DNSCheckTimeout = 2 * time.Minute
+
+	// NetworkCheckTimeout limits the time to wait for the network connectivity
+	// check to complete on a node
+	NetworkCheckTimeout = time.Minute
+
+	// NetworkCheckDialTimeout limits the time to establish a connection to a peer
+	// during the network connectivity check
+	NetworkCheckDialTimeout = 5 * time.Second
+
+	// TracingFlushInterval specifies how often the collected trace spans are exported
+	TracingFlushInterval = 5 * time.Second
+
+	// TracingExportTimeout limits the time to export a batch of trace spans
+	TracingExportTimeout = 10 * time.Second
+
+	// TracingMaxQueueSize is the maximum number of trace spans buffered for export.
+	// Spans are dropped if the collector cannot keep up
+	TracingMaxQueueSize = 2048
+
+	// PackageWatchQueueSize is the maximum number of package events buffered
+	// for a single watcher before the watcher is dropped
+	PackageWatchQueueSize = 256
+
+	// PackageWatchKeepAlive is how often the package service sends
+	// keep-alive events to remote package watchers
+	PackageWatchKeepAlive = 30 * time.Second
+
 	// TerminationWaitTimeout defines an amount of time above the Kubernetes
 	// TerminationGracePeriod to wait for a pod to be terminated. Kubernetes
 	// may take some amount of time to force kill a pod, which we want to
@@ -996,6 +1376,10 @@ var (
 	// RPCAgentSecretsDir specifies the location of the unpacked credentials
 	RPCAgentSecretsDir = filepath.Join(GravityEphemeralDir, "rpcsecrets")
 
+	// SSHAgentDir is where the installer uploads the agent binary to on
+	// the nodes it has connected to over SSH
+	SSHAgentDir = filepath.Join(GravityEphemeralDir, "ssh-agent")
+
 	// WizardDir is where wizard login information is stored during install
 	WizardDir = filepath.Join(GravityEphemeralDir, "wizard")
 
@@ -1008,6 +1392,9 @@ var (
 	// UsedNamespaces lists the Kubernetes namespaces used by default
 	UsedNamespaces = []string{"default", "kube-system"}
 
+	// WatchdogServices lists the planet services monitored by the service watchdog
+	WatchdogServices = []string{"etcd.service", "kube-kubelet.service", "flanneld.service", "coredns.service"}
+
 	// KubernetesReportResourceTypes lists the kubernetes resource types used in diagnostics report
 	KubernetesReportResourceTypes = []string{"pods", "jobs", "services", "daemonsets", "deployments",
 		"endpoints", "replicationcontrollers", "replicasets"}
@@ -1040,12 +1427,57 @@ var (
 	MonitoringAppName = "monitoring-app"
 	// TillerAppName is the name of the tiller application
 	TillerAppName = "tiller-app"
+	// IngressAppName is the name of the bundled ingress controller application
+	IngressAppName = "ingress-app"
 
 	// KubeletArgs is a list of default command line options for kubelet
 	KubeletArgs = []string{
 		`--eviction-hard="nodefs.available<5%,imagefs.available<5%,nodefs.inodesFree<5%,imagefs.inodesFree<5%"`,
 		`--eviction-soft="nodefs.available<10%,imagefs.available<10%,nodefs.inodesFree<10%,imagefs.inodesFree<10%"`,
 		`--eviction-soft-grace-period="nodefs.available=1h,imagefs.available=1h,nodefs.inodesFree=1h,imagefs.inodesFree=1h"`,
+		// kubelet renews its client and serving certificates using
+		// the certificates API, see lib/kubelet for the approver
+		"--rotate-certificates",
+		"--rotate-server-certificates",
+	}
+
+	// LightweightKubeletArgs lists additional kubelet options for
+	// lightweight development clusters
+	LightweightKubeletArgs = []string{
+		"--image-gc-high-threshold=95",
+		"--image-gc-low-threshold=90",
+		"--serialize-image-pulls=false",
+	}
+
+	// LightweightEtcdArgs lists additional etcd options for
+	// lightweight development clusters
+	LightweightEtcdArgs = []string{
+		"--snapshot-count=5000",
+	}
+
+	// LightweightSkippedApps lists system applications that are not
+	// installed in lightweight development clusters
+	LightweightSkippedApps = []string{
+		LoggingAppName,
+		MonitoringAppName,
+		TillerAppName,
+	}
+
+	// PlanetNodeConfigParams lists planet configuration parameters specific to
+	// a single node. The rest of planet configuration is kept in a package shared
+	// between nodes with identical configuration
+	PlanetNodeConfigParams = []string{
+		"node-name",
+		"hostname",
+		"public-ip",
+		"etcd-member-name",
+		"etcd-proxy",
+		"etcd-initial-cluster-state",
+		"initial-cluster",
+		"election-enabled",
+		"role",
+		"node-label",
+		"taint",
 	}
 
 	// InstallGroupTTL is for how long installer IP is kept in a TTL map in
@@ -0,0 +1,102 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package dnsprovider publishes cluster endpoints to external DNS providers
+package dnsprovider
+
+import (
+	"context"
+	"reflect"
+	"sort"
+
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+)
+
+// Provider publishes DNS records to an external DNS provider
+type Provider interface {
+	// Publish publishes the specified records replacing the ones
+	// published previously
+	Publish(ctx context.Context, records []Record) error
+	// Close releases resources held by the provider
+	Close() error
+}
+
+// Record is a single DNS record resolving a name to a set of addresses
+type Record struct {
+	// Name is the fully qualified record name
+	Name string
+	// IPs lists the addresses the record resolves to
+	IPs []string
+}
+
+// New returns a new provider for the specified DNS provider resource
+func New(provider storage.DNSProvider) (Provider, error) {
+	switch provider.GetType() {
+	case storage.DNSProviderRoute53:
+		return newRoute53(provider)
+	case storage.DNSProviderZoneTransfer:
+		return newZoneTransfer(provider)
+	}
+	return nil, trace.BadParameter("unsupported DNS provider type %q", provider.GetType())
+}
+
+// Records computes the records of the specified provider for the given
+// set of cluster servers.
+//
+// Cluster records resolve to master nodes, ingress records resolve to all nodes
+// or, if a role is specified, to the nodes with the matching role.
+// Records without matching nodes are omitted
+func Records(provider storage.DNSProvider, servers storage.Servers) []Record {
+	var records []Record
+	for _, spec := range provider.GetRecords() {
+		var ips []string
+		for _, server := range servers {
+			switch spec.Target {
+			case storage.DNSTargetCluster:
+				if !server.IsMaster() {
+					continue
+				}
+			case storage.DNSTargetIngress:
+				if spec.Role != "" && server.Role != spec.Role {
+					continue
+				}
+			}
+			ips = append(ips, server.AdvertiseIP)
+		}
+		if len(ips) == 0 {
+			continue
+		}
+		sort.Strings(ips)
+		records = append(records, Record{
+			Name: spec.FQDN(provider.GetZone()),
+			IPs:  ips,
+		})
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Name < records[j].Name
+	})
+	return records
+}
+
+// recordsEqual returns true if both sets of records are the same
+func recordsEqual(a, b []Record) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dnsprovider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gravitational/gravity/lib/compare"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/miekg/dns"
+	"gopkg.in/check.v1"
+)
+
+func TestDNSProvider(t *testing.T) { check.TestingT(t) }
+
+type DNSProviderSuite struct{}
+
+var _ = check.Suite(&DNSProviderSuite{})
+
+func (s *DNSProviderSuite) TestComputesRecords(c *check.C) {
+	records := Records(newProvider(c, storage.DNSProviderZoneTransfer), testServers)
+	c.Assert(records, compare.DeepEquals, []Record{
+		{Name: "*.apps.example.com.", IPs: []string{"10.0.0.3"}},
+		{Name: "ops.example.com.", IPs: []string{"10.0.0.1", "10.0.0.2"}},
+	})
+}
+
+func (s *DNSProviderSuite) TestPublishesOnNodeChanges(c *check.C) {
+	operator := &testOperator{
+		providers: []storage.DNSProvider{newProvider(c, storage.DNSProviderRoute53)},
+		servers:   testServers[:1],
+	}
+	provider := &testProvider{}
+	publisher, err := NewPublisher(PublisherConfig{
+		Operator: operator,
+		New: func(storage.DNSProvider) (Provider, error) {
+			return provider, nil
+		},
+	})
+	c.Assert(err, check.IsNil)
+
+	c.Assert(publisher.Sync(context.TODO()), check.IsNil)
+	c.Assert(publisher.Sync(context.TODO()), check.IsNil)
+	c.Assert(provider.published, compare.DeepEquals, [][]Record{
+		{{Name: "ops.example.com.", IPs: []string{"10.0.0.2"}}},
+	})
+
+	operator.servers = testServers
+	c.Assert(publisher.Sync(context.TODO()), check.IsNil)
+	c.Assert(provider.published, check.HasLen, 2)
+	c.Assert(provider.published[1], check.HasLen, 2)
+
+	operator.providers = nil
+	c.Assert(publisher.Sync(context.TODO()), check.IsNil)
+	c.Assert(provider.closed, check.Equals, true)
+}
+
+func (s *DNSProviderSuite) TestServesZoneTransfer(c *check.C) {
+	resource := newProvider(c, storage.DNSProviderZoneTransfer)
+	resource.GetZoneTransfer().ListenAddr = "127.0.0.1:0"
+	provider, err := newZoneTransfer(resource)
+	c.Assert(err, check.IsNil)
+	defer provider.Close()
+
+	records := Records(resource, testServers)
+	c.Assert(provider.Publish(context.TODO(), records), check.IsNil)
+
+	transfer := new(dns.Transfer)
+	msg := new(dns.Msg)
+	msg.SetAxfr("example.com.")
+	envelopes, err := transfer.In(msg, provider.servers[1].Listener.Addr().String())
+	c.Assert(err, check.IsNil)
+	var names []string
+	for envelope := range envelopes {
+		c.Assert(envelope.Error, check.IsNil)
+		for _, rr := range envelope.RR {
+			names = append(names, rr.Header().Name)
+		}
+	}
+	c.Assert(names, compare.DeepEquals, []string{
+		"example.com.",
+		"*.apps.example.com.",
+		"ops.example.com.",
+		"ops.example.com.",
+		"example.com.",
+	})
+}
+
+func newProvider(c *check.C, providerType string) storage.DNSProvider {
+	provider := storage.NewDNSProvider("test", storage.DNSProviderSpecV2{
+		Type: providerType,
+		Zone: "example.com",
+		Records: []storage.DNSRecordSpec{
+			{Name: "ops", Target: storage.DNSTargetCluster},
+			{Name: "*.apps", Target: storage.DNSTargetIngress, Role: "worker"},
+		},
+		Route53: &storage.Route53Spec{HostedZoneID: "Z1"},
+	})
+	c.Assert(provider.CheckAndSetDefaults(), check.IsNil)
+	return provider
+}
+
+var testServers = storage.Servers{
+	{AdvertiseIP: "10.0.0.2", Role: "master", ClusterRole: "master"},
+	{AdvertiseIP: "10.0.0.1", Role: "master", ClusterRole: "master"},
+	{AdvertiseIP: "10.0.0.3", Role: "worker", ClusterRole: "node"},
+}
+
+// testOperator returns the configured cluster servers and DNS providers
+type testOperator struct {
+	ops.Operator
+	servers   storage.Servers
+	providers []storage.DNSProvider
+}
+
+func (r *testOperator) GetLocalSite() (*ops.Site, error) {
+	return &ops.Site{
+		Domain:       "example.com",
+		ClusterState: storage.ClusterState{Servers: r.servers},
+	}, nil
+}
+
+func (r *testOperator) GetDNSProviders(ops.SiteKey) ([]storage.DNSProvider, error) {
+	return r.providers, nil
+}
+
+// testProvider records the published records
+type testProvider struct {
+	published [][]Record
+	closed    bool
+}
+
+func (r *testProvider) Publish(ctx context.Context, records []Record) error {
+	r.published = append(r.published, records)
+	return nil
+}
+
+func (r *testProvider) Close() error {
+	r.closed = true
+	return nil
+}
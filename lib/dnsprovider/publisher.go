@@ -0,0 +1,173 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dnsprovider
+
+import (
+	"context"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+)
+
+// PublisherConfig configures the cluster endpoints publisher
+type PublisherConfig struct {
+	// Operator is the cluster operator service
+	Operator ops.Operator
+	// Interval is how often the endpoints are published
+	Interval time.Duration
+	// New creates a provider for the specified resource.
+	// Defaults to New
+	New func(storage.DNSProvider) (Provider, error)
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+}
+
+// CheckAndSetDefaults validates the configuration and sets default values
+func (r *PublisherConfig) CheckAndSetDefaults() error {
+	if r.Operator == nil {
+		return trace.BadParameter("missing Operator")
+	}
+	if r.Interval == 0 {
+		r.Interval = defaults.DNSProviderSyncInterval
+	}
+	if r.New == nil {
+		r.New = New
+	}
+	if r.FieldLogger == nil {
+		r.FieldLogger = logrus.WithField(trace.Component, "dnsprovider")
+	}
+	return nil
+}
+
+// NewPublisher returns a new publisher of cluster endpoints
+func NewPublisher(config PublisherConfig) (*Publisher, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &Publisher{
+		PublisherConfig: config,
+		providers:       make(map[string]*publishedProvider),
+	}, nil
+}
+
+// Publisher keeps the records of all configured DNS providers
+// in sync with the cluster servers
+type Publisher struct {
+	PublisherConfig
+	providers map[string]*publishedProvider
+}
+
+// Run publishes cluster endpoints periodically until the context is canceled
+func (r *Publisher) Run(ctx context.Context) error {
+	defer r.closeAll()
+	utils.RunPeriodically(ctx, r.Interval, r.FieldLogger,
+		"publish cluster endpoints", r.Sync)
+	return nil
+}
+
+// Sync publishes cluster endpoints to all configured DNS providers.
+// Records are only published to a provider if they have changed
+// since the last time
+func (r *Publisher) Sync(ctx context.Context) error {
+	cluster, err := r.Operator.GetLocalSite()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	resources, err := r.Operator.GetDNSProviders(cluster.Key())
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	active := make(map[string]struct{})
+	var errors []error
+	for _, resource := range resources {
+		active[resource.GetName()] = struct{}{}
+		if err := r.publish(ctx, resource, cluster.ClusterState.Servers); err != nil {
+			errors = append(errors, trace.Wrap(err, "failed to publish to DNS provider %v", resource.GetName()))
+		}
+	}
+	for name := range r.providers {
+		if _, ok := active[name]; !ok {
+			r.Infof("DNS provider %v has been removed.", name)
+			r.close(name)
+		}
+	}
+	return trace.NewAggregate(errors...)
+}
+
+func (r *Publisher) publish(ctx context.Context, resource storage.DNSProvider, servers storage.Servers) error {
+	if err := resource.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	published, ok := r.providers[resource.GetName()]
+	if ok && !specEqual(published.resource, resource) {
+		r.Infof("DNS provider %v has been updated.", resource.GetName())
+		r.close(resource.GetName())
+		ok = false
+	}
+	if !ok {
+		provider, err := r.New(resource)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		published = &publishedProvider{resource: resource, provider: provider}
+		r.providers[resource.GetName()] = published
+	}
+	records := Records(resource, servers)
+	if published.synced && recordsEqual(published.records, records) {
+		return nil
+	}
+	r.Infof("Publishing %v to DNS provider %v.", records, resource.GetName())
+	if err := published.provider.Publish(ctx, records); err != nil {
+		return trace.Wrap(err)
+	}
+	published.records = records
+	published.synced = true
+	return nil
+}
+
+func (r *Publisher) close(name string) {
+	if err := r.providers[name].provider.Close(); err != nil {
+		r.Warnf("Failed to close DNS provider %v: %v.", name, err)
+	}
+	delete(r.providers, name)
+}
+
+func (r *Publisher) closeAll() {
+	for name := range r.providers {
+		r.close(name)
+	}
+}
+
+// publishedProvider is a provider along with the records last published to it
+type publishedProvider struct {
+	resource storage.DNSProvider
+	provider Provider
+	records  []Record
+	synced   bool
+}
+
+func specEqual(a, b storage.DNSProvider) bool {
+	dataA, errA := storage.MarshalDNSProvider(a)
+	dataB, errB := storage.MarshalDNSProvider(b)
+	return errA == nil && errB == nil && string(dataA) == string(dataB)
+}
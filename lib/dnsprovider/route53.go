@@ -0,0 +1,195 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dnsprovider
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/gravitational/trace"
+)
+
+const (
+	// route53Endpoint is the Route 53 API endpoint
+	route53Endpoint = "https://route53.amazonaws.com/2013-04-01"
+	// route53Namespace is the XML namespace of Route 53 API requests
+	route53Namespace = "https://route53.amazonaws.com/doc/2013-04-01/"
+	// route53Region is the region Route 53 API requests are signed for
+	route53Region = "us-east-1"
+)
+
+// route53 publishes records to a Route 53 hosted zone.
+//
+// Records are upserted as A record sets. Records that are no longer
+// published are deleted using the values published previously
+type route53 struct {
+	zoneID   string
+	ttl      time.Duration
+	signer   *v4.Signer
+	client   *http.Client
+	endpoint string
+	// published maps record names to the records published last
+	published map[string]Record
+}
+
+func newRoute53(provider storage.DNSProvider) (*route53, error) {
+	spec := provider.GetRoute53()
+	if spec == nil {
+		return nil, trace.BadParameter("missing route53 provider settings")
+	}
+	var creds *credentials.Credentials
+	if spec.AccessKeyID != "" {
+		creds = credentials.NewStaticCredentials(spec.AccessKeyID, spec.SecretAccessKey, "")
+	} else {
+		sess, err := session.NewSession()
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		creds = sess.Config.Credentials
+	}
+	return &route53{
+		zoneID:    strings.TrimPrefix(spec.HostedZoneID, "/hostedzone/"),
+		ttl:       provider.GetTTL(),
+		signer:    v4.NewSigner(creds),
+		client:    &http.Client{Timeout: defaults.DialTimeout},
+		endpoint:  route53Endpoint,
+		published: make(map[string]Record),
+	}, nil
+}
+
+// Publish upserts the specified records and deletes records
+// that have been published before but are no longer present
+func (r *route53) Publish(ctx context.Context, records []Record) error {
+	var changes []route53Change
+	current := make(map[string]Record)
+	for _, record := range records {
+		current[record.Name] = record
+		changes = append(changes, r.change("UPSERT", record))
+	}
+	for name, record := range r.published {
+		if _, ok := current[name]; !ok {
+			changes = append(changes, r.change("DELETE", record))
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	if err := r.changeRecordSets(ctx, changes); err != nil {
+		return trace.Wrap(err)
+	}
+	r.published = current
+	return nil
+}
+
+// Close is a no-op for Route 53 provider
+func (r *route53) Close() error {
+	return nil
+}
+
+func (r *route53) change(action string, record Record) route53Change {
+	values := make([]route53Record, 0, len(record.IPs))
+	for _, ip := range record.IPs {
+		values = append(values, route53Record{Value: ip})
+	}
+	return route53Change{
+		Action: action,
+		RecordSet: route53RecordSet{
+			Name:    record.Name,
+			Type:    "A",
+			TTL:     int64(r.ttl / time.Second),
+			Records: values,
+		},
+	}
+}
+
+func (r *route53) changeRecordSets(ctx context.Context, changes []route53Change) error {
+	body, err := xml.Marshal(route53ChangeRequest{
+		Namespace: route53Namespace,
+		Comment:   "Cluster endpoints published by gravity",
+		Changes:   changes,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	url := fmt.Sprintf("%v/hostedzone/%v/rrset", r.endpoint, r.zoneID)
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/xml")
+	_, err = r.signer.Sign(req, bytes.NewReader(body), "route53", route53Region, time.Now())
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	respBody, _ := ioutil.ReadAll(resp.Body)
+	var errResp route53ErrorResponse
+	if err := xml.Unmarshal(respBody, &errResp); err == nil && errResp.Error.Message != "" {
+		return trace.BadParameter("route53: %v: %v", errResp.Error.Code, errResp.Error.Message)
+	}
+	return trace.BadParameter("route53: unexpected response %v: %s", resp.Status, respBody)
+}
+
+type route53ChangeRequest struct {
+	XMLName   xml.Name        `xml:"ChangeResourceRecordSetsRequest"`
+	Namespace string          `xml:"xmlns,attr"`
+	Comment   string          `xml:"ChangeBatch>Comment"`
+	Changes   []route53Change `xml:"ChangeBatch>Changes>Change"`
+}
+
+type route53Change struct {
+	Action    string           `xml:"Action"`
+	RecordSet route53RecordSet `xml:"ResourceRecordSet"`
+}
+
+type route53RecordSet struct {
+	Name    string          `xml:"Name"`
+	Type    string          `xml:"Type"`
+	TTL     int64           `xml:"TTL"`
+	Records []route53Record `xml:"ResourceRecords>ResourceRecord"`
+}
+
+type route53Record struct {
+	Value string `xml:"Value"`
+}
+
+type route53ErrorResponse struct {
+	Error struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	} `xml:"Error"`
+}
@@ -0,0 +1,218 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dnsprovider
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+	"github.com/miekg/dns"
+	"github.com/sirupsen/logrus"
+)
+
+// zoneTransfer serves the published records as an authoritative zone.
+//
+// Secondary servers, for example CoreDNS with the secondary plugin,
+// transfer the zone via AXFR and are sent a NOTIFY whenever
+// the records change
+type zoneTransfer struct {
+	logrus.FieldLogger
+	zone    string
+	ttl     uint32
+	notify  []string
+	servers []*dns.Server
+
+	mu      sync.RWMutex
+	serial  uint32
+	records []dns.RR
+}
+
+func newZoneTransfer(provider storage.DNSProvider) (*zoneTransfer, error) {
+	spec := provider.GetZoneTransfer()
+	if spec == nil {
+		return nil, trace.BadParameter("missing zonetransfer provider settings")
+	}
+	udpConn, err := net.ListenPacket("udp", spec.ListenAddr)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	tcpListener, err := net.Listen("tcp", spec.ListenAddr)
+	if err != nil {
+		udpConn.Close()
+		return nil, trace.ConvertSystemError(err)
+	}
+	r := &zoneTransfer{
+		FieldLogger: logrus.WithField(trace.Component, "dnsprovider:zonetransfer"),
+		zone:        dns.Fqdn(provider.GetZone()),
+		ttl:         uint32(provider.GetTTL() / time.Second),
+		notify:      spec.Notify,
+		serial:      uint32(time.Now().Unix()),
+	}
+	r.servers = []*dns.Server{
+		{PacketConn: udpConn, Handler: r},
+		{Listener: tcpListener, Handler: r},
+	}
+	for _, server := range r.servers {
+		go func(server *dns.Server) {
+			if err := server.ActivateAndServe(); err != nil {
+				r.Debugf("DNS server stopped: %v.", err)
+			}
+		}(server)
+	}
+	r.Infof("Serving zone %v on %v.", r.zone, spec.ListenAddr)
+	return r, nil
+}
+
+// Publish replaces the records in the zone and notifies secondary servers
+func (r *zoneTransfer) Publish(ctx context.Context, records []Record) error {
+	var rrs []dns.RR
+	for _, record := range records {
+		for _, ip := range record.IPs {
+			rrs = append(rrs, &dns.A{
+				Hdr: r.header(record.Name, dns.TypeA),
+				A:   net.ParseIP(ip),
+			})
+		}
+	}
+	r.mu.Lock()
+	r.records = rrs
+	r.serial = nextSerial(r.serial)
+	r.mu.Unlock()
+	var errors []error
+	for _, addr := range r.notify {
+		if err := r.sendNotify(addr); err != nil {
+			errors = append(errors, trace.Wrap(err, "failed to notify %v", addr))
+		}
+	}
+	return trace.NewAggregate(errors...)
+}
+
+// Close stops serving the zone
+func (r *zoneTransfer) Close() error {
+	var errors []error
+	for _, server := range r.servers {
+		if err := server.Shutdown(); err != nil {
+			errors = append(errors, err)
+		}
+	}
+	return trace.NewAggregate(errors...)
+}
+
+// ServeDNS answers SOA, A and zone transfer queries for the zone
+func (r *zoneTransfer) ServeDNS(w dns.ResponseWriter, req *dns.Msg) {
+	if len(req.Question) != 1 || !dns.IsSubDomain(r.zone, strings.ToLower(req.Question[0].Name)) {
+		msg := new(dns.Msg)
+		msg.SetRcode(req, dns.RcodeRefused)
+		w.WriteMsg(msg)
+		return
+	}
+	question := req.Question[0]
+	soa, records := r.snapshot()
+	if question.Qtype == dns.TypeAXFR {
+		r.transfer(w, req, soa, records)
+		return
+	}
+	msg := new(dns.Msg)
+	msg.SetReply(req)
+	msg.Authoritative = true
+	switch question.Qtype {
+	case dns.TypeSOA:
+		if strings.EqualFold(question.Name, r.zone) {
+			msg.Answer = []dns.RR{soa}
+		}
+	case dns.TypeA, dns.TypeANY:
+		for _, record := range records {
+			if strings.EqualFold(record.Header().Name, question.Name) {
+				msg.Answer = append(msg.Answer, record)
+			}
+		}
+	}
+	if len(msg.Answer) == 0 {
+		msg.Ns = []dns.RR{soa}
+	}
+	w.WriteMsg(msg)
+}
+
+func (r *zoneTransfer) transfer(w dns.ResponseWriter, req *dns.Msg, soa dns.RR, records []dns.RR) {
+	if _, ok := w.RemoteAddr().(*net.TCPAddr); !ok {
+		msg := new(dns.Msg)
+		msg.SetRcode(req, dns.RcodeRefused)
+		w.WriteMsg(msg)
+		return
+	}
+	envelopes := make(chan *dns.Envelope, 1)
+	envelopes <- &dns.Envelope{RR: append(append([]dns.RR{soa}, records...), soa)}
+	close(envelopes)
+	transfer := new(dns.Transfer)
+	if err := transfer.Out(w, req, envelopes); err != nil {
+		r.Warnf("Failed to transfer zone %v to %v: %v.", r.zone, w.RemoteAddr(), err)
+	}
+}
+
+func (r *zoneTransfer) snapshot() (soa dns.RR, records []dns.RR) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	soa = &dns.SOA{
+		Hdr:     r.header(r.zone, dns.TypeSOA),
+		Ns:      "ns." + r.zone,
+		Mbox:    "hostmaster." + r.zone,
+		Serial:  r.serial,
+		Refresh: r.ttl,
+		Retry:   r.ttl,
+		Expire:  r.ttl * 60,
+		Minttl:  r.ttl,
+	}
+	return soa, r.records
+}
+
+func (r *zoneTransfer) header(name string, rrtype uint16) dns.RR_Header {
+	return dns.RR_Header{
+		Name:   name,
+		Rrtype: rrtype,
+		Class:  dns.ClassINET,
+		Ttl:    r.ttl,
+	}
+}
+
+func (r *zoneTransfer) sendNotify(addr string) error {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "53")
+	}
+	msg := new(dns.Msg)
+	msg.SetNotify(r.zone)
+	client := &dns.Client{Timeout: defaults.DNSNotifyTimeout}
+	_, _, err := client.Exchange(msg, addr)
+	return trace.Wrap(err)
+}
+
+// nextSerial returns the zone serial following the specified one.
+// Serials are based on the current time so they keep increasing
+// across restarts
+func nextSerial(serial uint32) uint32 {
+	now := uint32(time.Now().Unix())
+	if now > serial {
+		return now
+	}
+	return serial + 1
+}
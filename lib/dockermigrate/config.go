@@ -0,0 +1,165 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dockermigrate
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/pack"
+
+	"github.com/gravitational/configure/cstrings"
+	"github.com/gravitational/trace"
+)
+
+// reconfigureRuntime rewrites the configuration package of the runtime
+// package installed on this node to use the overlay2 storage driver.
+// The new configuration replaces the existing package so the runtime
+// container picks it up the next time it starts
+func reconfigureRuntime(packages pack.PackageService, runtimePackage loc.Locator) error {
+	configPackage, err := pack.FindConfigPackage(packages, runtimePackage)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	manifest, err := pack.GetPackageManifest(packages, runtimePackage)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if manifest.Config == nil {
+		return trace.BadParameter("runtime package %v has no configuration parameters", runtimePackage)
+	}
+	names := dockerConfigVars{
+		backend: configVar(pack.ConfigEnvVars(manifest.Config, []string{dockerBackendParam})),
+		options: configVar(pack.ConfigEnvVars(manifest.Config, []string{dockerOptionsParam})),
+		volumes: configVar(pack.ConfigEnvVars(manifest.Config, []string{volumeParam})),
+	}
+	if names.backend == "" {
+		return trace.NotFound("runtime package %v does not define the %q parameter",
+			runtimePackage, dockerBackendParam)
+	}
+
+	vars, err := pack.ReadConfigVars(packages, *configPackage)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	envelope, err := packages.ReadPackageEnvelope(*configPackage)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	labels := make(map[string]string, len(envelope.RuntimeLabels))
+	for k, v := range envelope.RuntimeLabels {
+		labels[k] = v
+	}
+	// the rewritten package contains all variables and does not
+	// reference the shared configuration anymore
+	delete(labels, pack.ConfigBaseLabel)
+
+	var buf bytes.Buffer
+	if err := pack.WriteConfigVars(overlayConfigVars(vars, names), &buf); err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = packages.UpsertPackage(*configPackage, &buf, pack.WithLabels(labels))
+	return trace.Wrap(err)
+}
+
+// overlayConfigVars returns a copy of the runtime configuration variables
+// with docker configured to use the overlay2 storage driver.
+// The devicemapper storage options and the volumes that expose the LVM
+// devices to the runtime container are removed
+func overlayConfigVars(vars map[string]string, names dockerConfigVars) map[string]string {
+	result := make(map[string]string, len(vars))
+	for k, v := range vars {
+		result[k] = v
+	}
+	result[names.backend] = constants.DockerStorageDriverOverlay2
+
+	if names.options != "" {
+		var options []string
+		for _, option := range strings.Fields(vars[names.options]) {
+			if !strings.HasPrefix(option, devicemapperOptionPrefix) && option != overlayKernelOverride {
+				options = append(options, option)
+			}
+		}
+		options = append(options, overlayKernelOverride)
+		result[names.options] = strings.Join(options, " ")
+	}
+
+	if names.volumes != "" && vars[names.volumes] != "" {
+		var volumes []string
+		for _, volume := range cstrings.Split(',', '\\', vars[names.volumes]) {
+			if !isDevicemapperVolume(volume) {
+				volumes = append(volumes, strings.Replace(volume, ",", `\,`, -1))
+			}
+		}
+		result[names.volumes] = strings.Join(volumes, ",")
+	}
+	return result
+}
+
+// isDevicemapperVolume returns true if the specified volume
+// exposes the LVM devices or configuration to the runtime container
+func isDevicemapperVolume(volume string) bool {
+	parts := strings.Split(volume, ":")
+	if len(parts) < 2 {
+		return false
+	}
+	switch parts[1] {
+	case devicemapperDevicesDir, devicemapperDockerDir, constants.LVMSystemDir:
+		return true
+	}
+	return false
+}
+
+// configVar returns the single variable name from names or an empty string
+func configVar(names []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	return names[0]
+}
+
+// dockerConfigVars names the runtime configuration variables with docker settings
+type dockerConfigVars struct {
+	// backend is the variable with the docker storage driver
+	backend string
+	// options is the variable with the additional docker options
+	options string
+	// volumes is the variable with the volumes mounted into the runtime container
+	volumes string
+}
+
+const (
+	// dockerBackendParam is the runtime parameter with the docker storage driver
+	dockerBackendParam = "docker-backend"
+	// dockerOptionsParam is the runtime parameter with the additional docker options
+	dockerOptionsParam = "docker-options"
+	// volumeParam is the runtime parameter with the container volumes
+	volumeParam = "volume"
+	// devicemapperOptionPrefix is the prefix of the devicemapper storage options
+	devicemapperOptionPrefix = "--storage-opt=dm."
+	// devicemapperDevicesDir is the directory with the device mapper devices
+	devicemapperDevicesDir = "/dev/mapper"
+	// devicemapperDockerDir is the directory with the docker LVM volume group devices
+	devicemapperDockerDir = "/dev/docker"
+	// overlayKernelOverride disables the kernel version check of the overlay2 driver.
+	// See: https://github.com/docker/docker/issues/26559
+	overlayKernelOverride = "--storage-opt=overlay2.override_kernel_check=1"
+)
@@ -0,0 +1,79 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dockermigrate
+
+import (
+	"path"
+
+	libfsm "github.com/gravitational/gravity/lib/fsm"
+
+	"github.com/gravitational/trace"
+)
+
+// newMachine returns a new state machine for the migration operation
+func newMachine(config Config) (*libfsm.FSM, error) {
+	registry := libfsm.NewRegistry()
+	registry.MustRegister(ChecksPhase, func(params libfsm.ExecutorParams, remote libfsm.Remote) (libfsm.PhaseExecutor, error) {
+		return &checksExecutor{
+			FieldLogger:    config.WithField("phase", params.Phase.ID),
+			ExecutorParams: params,
+			checkHealth:    config.checkHealth,
+		}, nil
+	})
+	registry.MustRegister(MigratePhase, func(params libfsm.ExecutorParams, remote libfsm.Remote) (libfsm.PhaseExecutor, error) {
+		logger := config.WithField("phase", params.Phase.ID)
+		switch path.Base(params.Phase.ID) {
+		case drainStep:
+			return &drainExecutor{
+				FieldLogger:    logger,
+				ExecutorParams: params,
+				Config:         config,
+			}, nil
+		case dockerStep:
+			return &dockerExecutor{
+				FieldLogger:    logger,
+				ExecutorParams: params,
+				Config:         config,
+			}, nil
+		case uncordonStep:
+			return &uncordonExecutor{
+				FieldLogger:    logger,
+				ExecutorParams: params,
+				Config:         config,
+			}, nil
+		}
+		return nil, trace.BadParameter("unknown phase %q", params.Phase.ID)
+	})
+	registry.MustRegister(ConfigPhase, func(params libfsm.ExecutorParams, remote libfsm.Remote) (libfsm.PhaseExecutor, error) {
+		return &configExecutor{
+			FieldLogger:    config.WithField("phase", params.Phase.ID),
+			ExecutorParams: params,
+			Backend:        config.Backend,
+		}, nil
+	})
+	machine, err := libfsm.NewOperatorMachine(libfsm.OperatorEngineConfig{
+		Operator:    config.Operator,
+		Operation:   config.Operation.Key(),
+		Spec:        registry.GetExecutor,
+		Command:     []string{"system", "migrate-storage-driver"},
+		FieldLogger: config.FieldLogger,
+	}, config.Runner)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return machine, nil
+}
@@ -0,0 +1,250 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package dockermigrate implements the operation that migrates docker
+// storage driver on cluster nodes from devicemapper to overlay2.
+//
+// Nodes are migrated one at a time: every node is drained, the runtime
+// container is stopped, the devicemapper volumes and the docker data
+// directory are removed and the runtime container is started again with
+// the overlay2 storage driver. Once the node has become healthy it is
+// uncordoned and the operation proceeds to the next node.
+package dockermigrate
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/defaults"
+	libfsm "github.com/gravitational/gravity/lib/fsm"
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/pack"
+	"github.com/gravitational/gravity/lib/rpc"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/client-go/kubernetes"
+)
+
+// New returns a new docker storage driver migration for the specified configuration
+func New(config Config) (*Migrator, error) {
+	if err := config.checkAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return &Migrator{
+		Config: config,
+	}, nil
+}
+
+// Run executes the migration operation
+func (r *Migrator) Run(ctx context.Context, force bool) error {
+	machine, err := r.init()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	errCh := make(chan error, 1)
+	updateCh := make(chan ops.ProgressEntry)
+	go func() {
+		errCh <- r.executePlan(ctx, machine, force)
+	}()
+	go pollProgress(ctx, updateCh, r.Operation.Key(), r.Operator)
+
+L:
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case progress := <-updateCh:
+			r.Emitter.PrintStep(progress.Message)
+		case err = <-errCh:
+			break L
+		}
+	}
+
+	return trace.Wrap(err)
+}
+
+// RunPhase executes the specified phase of the migration operation
+func (r *Migrator) RunPhase(ctx context.Context, phase string, phaseTimeout time.Duration, force bool) error {
+	if phase == libfsm.RootPhase {
+		return trace.Wrap(r.Run(ctx, force))
+	}
+
+	machine, err := r.init()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, phaseTimeout)
+	defer cancel()
+
+	progress := utils.NewProgress(ctx, fmt.Sprintf("Executing phase %q", phase), -1, false)
+	defer progress.Stop()
+
+	return trace.Wrap(machine.ExecutePhase(ctx, libfsm.Params{
+		PhaseID:  phase,
+		Progress: progress,
+		Force:    force,
+	}))
+}
+
+// Create creates the migration operation plan but does not start it
+func (r *Migrator) Create(ctx context.Context) error {
+	_, err := r.init()
+	return trace.Wrap(err)
+}
+
+func (r *Migrator) init() (*libfsm.FSM, error) {
+	_, err := r.getOrCreateOperationPlan()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	machine, err := newMachine(r.Config)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return machine, nil
+}
+
+func (r *Migrator) executePlan(ctx context.Context, machine *libfsm.FSM, force bool) error {
+	planErr := machine.ExecutePlan(ctx, nil, force)
+	if planErr != nil {
+		r.Warnf("Failed to execute plan: %v.", trace.DebugReport(planErr))
+	}
+
+	err := machine.Complete(planErr)
+	if err == nil {
+		err = planErr
+	}
+
+	var addrs []string
+	for _, server := range r.Servers {
+		addrs = append(addrs, server.AdvertiseIP)
+	}
+
+	// Keep the agents running as long as the operation can be resumed
+	if planErr == nil {
+		if errShutdown := rpc.ShutdownAgents(ctx, addrs, r.FieldLogger, r.Runner); errShutdown != nil {
+			r.Warnf("Failed to shutdown agents: %v.", trace.DebugReport(errShutdown))
+		}
+	}
+	return trace.Wrap(err)
+}
+
+func (r *Config) checkAndSetDefaults() error {
+	if r.Operator == nil {
+		return trace.BadParameter("cluster operator service is required")
+	}
+	if r.Operation == nil {
+		return trace.BadParameter("operation is required")
+	}
+	if len(r.Servers) == 0 {
+		return trace.BadParameter("at least a single server is required")
+	}
+	if r.HealthTimeout == 0 {
+		r.HealthTimeout = defaults.DockerMigrationHealthTimeout
+	}
+	if r.checkHealth == nil {
+		r.checkHealth = checkPlanetHealth
+	}
+	if r.FieldLogger == nil {
+		r.FieldLogger = log.WithFields(log.Fields{
+			trace.Component:            "fsm:docker-migrate",
+			constants.FieldOperationID: r.Operation.ID,
+		})
+	}
+	if r.Emitter == nil {
+		r.Emitter = utils.NopEmitter()
+	}
+	return nil
+}
+
+// Config describes configuration of the docker storage driver migration
+type Config struct {
+	// Operator is the cluster operator service
+	Operator ops.Operator
+	// Operation references the migration operation
+	Operation *ops.SiteOperation
+	// Servers is the list of cluster servers
+	Servers []storage.Server
+	// Packages is the local package service of this node
+	Packages pack.PackageService
+	// RuntimePackage is the runtime package installed on this node
+	RuntimePackage *loc.Locator
+	// Backend is the cluster backend.
+	// Only available on master nodes
+	Backend storage.Backend
+	// Client is the cluster Kubernetes client.
+	// Only available on master nodes
+	Client *kubernetes.Clientset
+	// Runner specifies the runner for remote commands
+	Runner libfsm.AgentRepository
+	// HealthTimeout limits the time to wait for the cluster to become
+	// healthy after a node has been migrated
+	HealthTimeout time.Duration
+	// FieldLogger is the logger to use
+	log.FieldLogger
+	// Emitter outputs progress messages to stdout
+	utils.Emitter
+	// checkHealth returns an error if the cluster is not healthy.
+	// Overridden in tests
+	checkHealth func(context.Context, []storage.Server) error
+}
+
+// Migrator executes the docker storage driver migration operation
+type Migrator struct {
+	// Config is the migrator's configuration
+	Config
+}
+
+func pollProgress(ctx context.Context, updateCh chan<- ops.ProgressEntry, opKey ops.SiteOperationKey, operator ops.Operator) {
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+	var lastProgress *ops.ProgressEntry
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			progress, err := operator.GetSiteOperationProgress(opKey)
+			if err != nil {
+				log.Warnf("Failed to query operation progress: %v.",
+					trace.DebugReport(err))
+				continue
+			}
+			if lastProgress == nil || !lastProgress.IsEqual(*progress) {
+				select {
+				case <-ctx.Done():
+					return
+				case updateCh <- *progress:
+				}
+			}
+			if progress.IsCompleted() {
+				return
+			}
+			lastProgress = progress
+		}
+	}
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dockermigrate
+
+import (
+	"testing"
+
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/schema"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"gopkg.in/check.v1"
+)
+
+func TestDockerMigrate(t *testing.T) { check.TestingT(t) }
+
+type MigrateSuite struct{}
+
+var _ = check.Suite(&MigrateSuite{})
+
+func (s *MigrateSuite) TestPlanMigratesNodesBeforeMasters(c *check.C) {
+	servers := []storage.Server{
+		{Hostname: "master-1", ClusterRole: string(schema.ServiceRoleMaster)},
+		{Hostname: "node-1", ClusterRole: string(schema.ServiceRoleNode)},
+		{Hostname: "master-2", ClusterRole: string(schema.ServiceRoleMaster)},
+	}
+	operation := ops.SiteOperation{
+		ID:         "1",
+		AccountID:  "a",
+		SiteDomain: "example.com",
+		Type:       ops.OperationDockerMigrate,
+	}
+
+	plan, err := NewOperationPlan(operation, servers)
+	c.Assert(err, check.IsNil)
+	c.Assert(plan.Phases, check.HasLen, 3)
+
+	checks, migrate, config := plan.Phases[0], plan.Phases[1], plan.Phases[2]
+	c.Assert(checks.ID, check.Equals, ChecksPhase)
+	c.Assert(migrate.Requires, check.DeepEquals, []string{ChecksPhase})
+	c.Assert(config.Requires, check.DeepEquals, []string{MigratePhase})
+	c.Assert(config.Data.Server.Hostname, check.Equals, "master-1")
+
+	var ids []string
+	for i, phase := range migrate.Phases {
+		ids = append(ids, phase.ID)
+		if i == 0 {
+			c.Assert(phase.Requires, check.HasLen, 0)
+		} else {
+			c.Assert(phase.Requires, check.DeepEquals, []string{ids[i-1]})
+		}
+		c.Assert(phase.Phases, check.HasLen, 3)
+		drain, docker, uncordon := phase.Phases[0], phase.Phases[1], phase.Phases[2]
+		c.Assert(drain.ID, check.Equals, phase.ID+"/drain")
+		c.Assert(drain.Data.ExecServer.Hostname, check.Equals, "master-1")
+		c.Assert(docker.Requires, check.DeepEquals, []string{drain.ID})
+		c.Assert(docker.Data.ExecServer, check.IsNil)
+		c.Assert(uncordon.Requires, check.DeepEquals, []string{docker.ID})
+		c.Assert(uncordon.Data.ExecServer.Hostname, check.Equals, "master-1")
+	}
+	c.Assert(ids, check.DeepEquals, []string{
+		"/migrate/node-1",
+		"/migrate/master-1",
+		"/migrate/master-2",
+	})
+}
+
+func (s *MigrateSuite) TestPlanRequiresMaster(c *check.C) {
+	servers := []storage.Server{
+		{Hostname: "node-1", ClusterRole: string(schema.ServiceRoleNode)},
+	}
+	_, err := NewOperationPlan(ops.SiteOperation{ID: "1"}, servers)
+	c.Assert(err, check.NotNil)
+}
+
+func (s *MigrateSuite) TestOverlayConfigVars(c *check.C) {
+	names := dockerConfigVars{
+		backend: "PLANET_DOCKER_BACKEND",
+		options: "PLANET_DOCKER_OPTIONS",
+		volumes: "PLANET_VOLUME",
+	}
+	vars := map[string]string{
+		"PLANET_DOCKER_BACKEND": "devicemapper",
+		"PLANET_DOCKER_OPTIONS": "--log-level=warn --storage-opt=dm.override_udev_sync_check=1 " +
+			"--storage-opt=dm.fs=xfs --storage-opt=dm.thinpooldev=/dev/mapper/docker-thinpool",
+		"PLANET_VOLUME": "/var/lib/gravity/planet/docker:/ext/docker,/dev/mapper:/dev/mapper," +
+			"/dev/docker:/dev/docker,/etc/lvm:/etc/lvm",
+		"PLANET_ROLE": "master",
+	}
+
+	result := overlayConfigVars(vars, names)
+	c.Assert(result, check.DeepEquals, map[string]string{
+		"PLANET_DOCKER_BACKEND": "overlay2",
+		"PLANET_DOCKER_OPTIONS": "--log-level=warn --storage-opt=overlay2.override_kernel_check=1",
+		"PLANET_VOLUME":         "/var/lib/gravity/planet/docker:/ext/docker",
+		"PLANET_ROLE":           "master",
+	})
+	// original variables are left intact
+	c.Assert(vars["PLANET_DOCKER_BACKEND"], check.Equals, "devicemapper")
+	// rewriting the variables again does not change them
+	c.Assert(overlayConfigVars(result, names), check.DeepEquals, result)
+}
@@ -0,0 +1,318 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dockermigrate
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/devicemapper"
+	libfsm "github.com/gravitational/gravity/lib/fsm"
+	"github.com/gravitational/gravity/lib/kubernetes"
+	"github.com/gravitational/gravity/lib/status"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/systemservice"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/cenkalti/backoff"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+)
+
+// checksExecutor makes sure the cluster is healthy before any node is migrated
+type checksExecutor struct {
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+	// ExecutorParams is common executor params
+	libfsm.ExecutorParams
+	checkHealth func(context.Context, []storage.Server) error
+}
+
+// Execute verifies the cluster is healthy
+func (r *checksExecutor) Execute(ctx context.Context) error {
+	r.Progress.NextStep("Verifying the cluster is healthy")
+	err := r.checkHealth(ctx, r.Plan.Servers)
+	if err != nil {
+		return trace.Wrap(err, "refusing to migrate nodes of an unhealthy cluster")
+	}
+	return nil
+}
+
+// PreCheck is a no-op
+func (*checksExecutor) PreCheck(context.Context) error {
+	return nil
+}
+
+// PostCheck is a no-op
+func (*checksExecutor) PostCheck(context.Context) error {
+	return nil
+}
+
+// Rollback is a no-op
+func (*checksExecutor) Rollback(context.Context) error {
+	return nil
+}
+
+// drainExecutor drains the node before its docker data is removed
+type drainExecutor struct {
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+	// ExecutorParams is common executor params
+	libfsm.ExecutorParams
+	// Config is the migrator's configuration
+	Config
+}
+
+// PreCheck makes sure the cluster is healthy so that migrating
+// another node does not take down more than one node at a time
+func (r *drainExecutor) PreCheck(ctx context.Context) error {
+	if r.Client == nil {
+		return trace.BadParameter("phase %v must be executed on a master node", r.Phase.ID)
+	}
+	err := r.checkHealth(ctx, r.Plan.Servers)
+	if err != nil {
+		return trace.Wrap(err, "refusing to migrate node %v while the cluster is unhealthy",
+			r.Phase.Data.Server.Hostname)
+	}
+	return nil
+}
+
+// Execute drains the node
+func (r *drainExecutor) Execute(ctx context.Context) error {
+	server := r.Phase.Data.Server
+	r.Progress.NextStep("Draining node %v", server.Hostname)
+	ctx, cancel := context.WithTimeout(ctx, defaults.DrainTimeout)
+	defer cancel()
+	err := kubernetes.Drain(ctx, r.Client, server.KubeNodeID())
+	return trace.Wrap(err)
+}
+
+// PostCheck is a no-op
+func (*drainExecutor) PostCheck(context.Context) error {
+	return nil
+}
+
+// Rollback uncordons the node
+func (r *drainExecutor) Rollback(ctx context.Context) error {
+	return trace.Wrap(uncordon(ctx, r.Config, *r.Phase.Data.Server))
+}
+
+// dockerExecutor switches docker on the local node to the overlay2 storage driver
+type dockerExecutor struct {
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+	// ExecutorParams is common executor params
+	libfsm.ExecutorParams
+	// Config is the migrator's configuration
+	Config
+}
+
+// PreCheck makes sure the runtime package is installed on this node
+func (r *dockerExecutor) PreCheck(context.Context) error {
+	if r.RuntimePackage == nil || r.Packages == nil {
+		return trace.NotFound("runtime package is not installed on this node")
+	}
+	return nil
+}
+
+// Execute stops the runtime container, removes the devicemapper volumes
+// and docker data, reconfigures the runtime container to use overlay2 and
+// waits until the node and the cluster are healthy again.
+//
+// All steps are idempotent so the phase can be safely retried
+func (r *dockerExecutor) Execute(ctx context.Context) error {
+	server := r.Phase.Data.Server
+	services, err := systemservice.New()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	r.Progress.NextStep("Stopping runtime container on node %v", server.Hostname)
+	if err := services.StopPackageService(*r.RuntimePackage); err != nil {
+		return trace.Wrap(err)
+	}
+
+	r.Progress.NextStep("Removing devicemapper volumes on node %v", server.Hostname)
+	var out bytes.Buffer
+	if err := devicemapper.Unmount(&out, logrus.WithField("phase", r.Phase.ID)); err != nil {
+		return trace.Wrap(err, "failed to remove devicemapper volumes: %s", out.Bytes())
+	}
+
+	r.Progress.NextStep("Removing docker data on node %v", server.Hostname)
+	dockerDir := filepath.Join(server.StateDir(), defaults.PlanetDir, dockerDataDir)
+	if err := os.RemoveAll(dockerDir); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	if err := os.MkdirAll(dockerDir, defaults.SharedDirMask); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+
+	r.Progress.NextStep("Configuring runtime container on node %v", server.Hostname)
+	if err := reconfigureRuntime(r.Packages, *r.RuntimePackage); err != nil {
+		return trace.Wrap(err)
+	}
+
+	r.Progress.NextStep("Starting runtime container on node %v", server.Hostname)
+	if err := services.StartPackageService(*r.RuntimePackage, false); err != nil {
+		return trace.Wrap(err)
+	}
+
+	r.Progress.NextStep("Waiting for node %v to become healthy", server.Hostname)
+	// give the runtime container time to report its status so
+	// a stale health report is not mistaken for a recovered node
+	select {
+	case <-time.After(defaults.RollingRestartSettleDelay):
+	case <-ctx.Done():
+		return trace.Wrap(ctx.Err())
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.HealthTimeout)
+	defer cancel()
+	err = utils.RetryWithInterval(ctx, backoff.NewConstantBackOff(defaults.RetryInterval), func() error {
+		return trace.Wrap(r.checkHealth(ctx, r.Plan.Servers))
+	})
+	if err != nil {
+		return trace.Wrap(err, "node %v did not become healthy after migration", server.Hostname)
+	}
+	r.Infof("Migrated docker to %v storage driver.", constants.DockerStorageDriverOverlay2)
+	return nil
+}
+
+// PostCheck is a no-op
+func (*dockerExecutor) PostCheck(context.Context) error {
+	return nil
+}
+
+// Rollback is a no-op: docker data removed by this phase cannot be restored
+func (*dockerExecutor) Rollback(context.Context) error {
+	return nil
+}
+
+// uncordonExecutor makes the migrated node schedulable again
+type uncordonExecutor struct {
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+	// ExecutorParams is common executor params
+	libfsm.ExecutorParams
+	// Config is the migrator's configuration
+	Config
+}
+
+// PreCheck makes sure the phase is executed on a master node
+func (r *uncordonExecutor) PreCheck(context.Context) error {
+	if r.Client == nil {
+		return trace.BadParameter("phase %v must be executed on a master node", r.Phase.ID)
+	}
+	return nil
+}
+
+// Execute uncordons the node
+func (r *uncordonExecutor) Execute(ctx context.Context) error {
+	server := r.Phase.Data.Server
+	r.Progress.NextStep("Uncordoning node %v", server.Hostname)
+	return trace.Wrap(uncordon(ctx, r.Config, *server))
+}
+
+// PostCheck is a no-op
+func (*uncordonExecutor) PostCheck(context.Context) error {
+	return nil
+}
+
+// Rollback is a no-op
+func (*uncordonExecutor) Rollback(context.Context) error {
+	return nil
+}
+
+// configExecutor updates the cluster docker configuration so that
+// nodes that join the cluster later use the overlay2 storage driver
+type configExecutor struct {
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+	// ExecutorParams is common executor params
+	libfsm.ExecutorParams
+	// Backend is the cluster backend
+	Backend storage.Backend
+}
+
+// PreCheck makes sure the phase is executed on a master node
+func (r *configExecutor) PreCheck(context.Context) error {
+	if r.Backend == nil {
+		return trace.BadParameter("phase %v must be executed on a master node", r.Phase.ID)
+	}
+	return nil
+}
+
+// Execute updates the cluster docker configuration
+func (r *configExecutor) Execute(context.Context) error {
+	r.Progress.NextStep("Updating cluster docker configuration")
+	cluster, err := r.Backend.GetSite(r.Plan.ClusterName)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	cluster.ClusterState.Docker.StorageDriver = constants.DockerStorageDriverOverlay2
+	// devicemapper devices have been released on all nodes
+	for i := range cluster.ClusterState.Servers {
+		cluster.ClusterState.Servers[i].Docker = storage.Docker{}
+	}
+	_, err = r.Backend.UpdateSite(*cluster)
+	return trace.Wrap(err)
+}
+
+// PostCheck is a no-op
+func (*configExecutor) PostCheck(context.Context) error {
+	return nil
+}
+
+// Rollback is a no-op
+func (*configExecutor) Rollback(context.Context) error {
+	return nil
+}
+
+func uncordon(ctx context.Context, config Config, server storage.Server) error {
+	if config.Client == nil {
+		return trace.BadParameter("kubernetes client is required")
+	}
+	err := kubernetes.SetUnschedulable(ctx, config.Client.CoreV1().Nodes(), server.KubeNodeID(), false)
+	return trace.Wrap(err)
+}
+
+// checkPlanetHealth returns an error if planet agents report
+// the cluster or any of the specified servers as unhealthy
+func checkPlanetHealth(ctx context.Context, servers []storage.Server) error {
+	agent, err := status.FromPlanetAgent(ctx, servers)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	for _, node := range agent.Nodes {
+		if node.Status != status.NodeHealthy {
+			return trace.CompareFailed("node %v is %v", node.Hostname, node.Status)
+		}
+	}
+	if agent.GetSystemStatus() != pb.SystemStatus_Running {
+		return trace.CompareFailed("cluster is degraded")
+	}
+	return nil
+}
+
+// dockerDataDir is the name of the docker data directory
+// in the runtime container state directory
+const dockerDataDir = "docker"
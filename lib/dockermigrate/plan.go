@@ -0,0 +1,158 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dockermigrate
+
+import (
+	"fmt"
+	"path"
+
+	libfsm "github.com/gravitational/gravity/lib/fsm"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+)
+
+const (
+	// ChecksPhase is the phase that makes sure the cluster is healthy
+	// before any node is migrated
+	ChecksPhase = "/checks"
+	// MigratePhase is the phase that migrates nodes one by one
+	MigratePhase = "/migrate"
+	// ConfigPhase is the phase that updates the cluster docker configuration
+	// once all nodes have been migrated
+	ConfigPhase = "/config"
+
+	// drainStep is the node migration step that drains the node
+	drainStep = "drain"
+	// dockerStep is the node migration step that switches the storage driver
+	dockerStep = "docker"
+	// uncordonStep is the node migration step that uncordons the node
+	uncordonStep = "uncordon"
+)
+
+// NewOperationPlan returns a new plan that migrates docker storage
+// driver on the given servers one by one.
+// Regular nodes are migrated before masters
+func NewOperationPlan(operation ops.SiteOperation, servers []storage.Server) (*storage.OperationPlan, error) {
+	masters, nodes := libfsm.SplitServers(servers)
+	if len(masters) == 0 {
+		return nil, trace.NotFound("no master servers found in cluster state")
+	}
+	leadMaster := masters[0]
+
+	checks := storage.OperationPhase{
+		ID:          ChecksPhase,
+		Description: "Verify the cluster is healthy",
+		Data: &storage.OperationPhaseData{
+			Server: &leadMaster,
+		},
+	}
+
+	migrate := storage.OperationPhase{
+		ID:          MigratePhase,
+		Description: "Migrate docker storage driver on cluster nodes one by one",
+		Requires:    []string{checks.ID},
+	}
+	for _, server := range append(nodes, masters...) {
+		phase := newNodePhase(server, leadMaster)
+		if len(migrate.Phases) != 0 {
+			phase.Requires = []string{migrate.Phases[len(migrate.Phases)-1].ID}
+		}
+		migrate.Phases = append(migrate.Phases, phase)
+	}
+
+	config := storage.OperationPhase{
+		ID:          ConfigPhase,
+		Description: "Update cluster docker configuration",
+		Requires:    []string{migrate.ID},
+		Data: &storage.OperationPhaseData{
+			Server: &leadMaster,
+		},
+	}
+
+	return &storage.OperationPlan{
+		OperationID:   operation.ID,
+		OperationType: operation.Type,
+		AccountID:     operation.AccountID,
+		ClusterName:   operation.SiteDomain,
+		Phases:        []storage.OperationPhase{checks, migrate, config},
+		Servers:       servers,
+	}, nil
+}
+
+// newNodePhase returns the phase that migrates the specified server.
+// Draining and uncordoning the node requires access to the Kubernetes API
+// so these steps are executed on the lead master
+func newNodePhase(server, leadMaster storage.Server) storage.OperationPhase {
+	id := path.Join(MigratePhase, server.Hostname)
+	drain := storage.OperationPhase{
+		ID:          path.Join(id, drainStep),
+		Description: fmt.Sprintf("Drain node %q", server.Hostname),
+		Data: &storage.OperationPhaseData{
+			Server:     &server,
+			ExecServer: &leadMaster,
+		},
+	}
+	docker := storage.OperationPhase{
+		ID:          path.Join(id, dockerStep),
+		Description: fmt.Sprintf("Switch docker to overlay2 storage driver on node %q", server.Hostname),
+		Requires:    []string{drain.ID},
+		Data: &storage.OperationPhaseData{
+			Server: &server,
+		},
+	}
+	uncordon := storage.OperationPhase{
+		ID:          path.Join(id, uncordonStep),
+		Description: fmt.Sprintf("Uncordon node %q", server.Hostname),
+		Requires:    []string{docker.ID},
+		Data: &storage.OperationPhaseData{
+			Server:     &server,
+			ExecServer: &leadMaster,
+		},
+	}
+	return storage.OperationPhase{
+		ID:          id,
+		Description: fmt.Sprintf("Migrate docker storage driver on node %q", server.Hostname),
+		Phases:      []storage.OperationPhase{drain, docker, uncordon},
+	}
+}
+
+func (r *Migrator) getOrCreateOperationPlan() (plan *storage.OperationPlan, err error) {
+	plan, err = r.Operator.GetOperationPlan(r.Operation.Key())
+	if err != nil && !trace.IsNotFound(err) {
+		return nil, trace.Wrap(err)
+	}
+
+	if trace.IsNotFound(err) {
+		plan, err = NewOperationPlan(*r.Operation, r.Servers)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		err = r.Operator.CreateOperationPlan(r.Operation.Key(), *plan)
+		if err != nil {
+			if trace.IsNotFound(err) {
+				return nil, trace.NotImplemented(
+					"cluster operator does not implement the API required for docker storage driver migration. " +
+						"Please make sure you're running the command on a compatible cluster.")
+			}
+			return nil, trace.Wrap(err)
+		}
+	}
+
+	return plan, nil
+}
@@ -0,0 +1,402 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package etcdbackup implements taking, storing and restoring backups
+// of the cluster etcd database.
+//
+// Backups are taken with planet's etcd backup command and stored
+// together with their sha256 checksums either in a local directory
+// or in an S3 bucket:
+//
+// s3://bucket/prefix
+// ∟ etcd-20181016T120000Z.backup
+// ∟ etcd-20181016T120000Z.backup.sha256
+//
+// Backup names sort in the order they were taken which is used
+// to enforce the retention policy.
+package etcdbackup
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+	"github.com/sirupsen/logrus"
+)
+
+// Config defines the configuration of the etcd backup manager
+type Config struct {
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+	// Storage is where backups are kept
+	Storage Storage
+	// Retain is the number of most recent backups to keep.
+	// Zero value keeps all backups
+	Retain int
+	// WorkDir is the directory for temporary backup files.
+	// It must be accessible from inside the planet container
+	WorkDir string
+	// Clock is used to generate backup names
+	Clock clockwork.Clock
+	// planetCommand executes the specified planet command.
+	// Overridden in tests
+	planetCommand func(ctx context.Context, args ...string) error
+}
+
+// CheckAndSetDefaults validates the config and sets defaults
+func (c *Config) CheckAndSetDefaults() error {
+	if c.Storage == nil {
+		return trace.BadParameter("missing Storage")
+	}
+	if c.WorkDir == "" {
+		return trace.BadParameter("missing WorkDir")
+	}
+	if c.Retain < 0 {
+		return trace.BadParameter("retention count cannot be negative")
+	}
+	if c.FieldLogger == nil {
+		c.FieldLogger = logrus.WithField(trace.Component, "etcdbackup")
+	}
+	if c.Clock == nil {
+		c.Clock = clockwork.NewRealClock()
+	}
+	if c.planetCommand == nil {
+		c.planetCommand = func(ctx context.Context, args ...string) error {
+			out, err := utils.RunPlanetCommand(ctx, c.FieldLogger, args...)
+			if err != nil {
+				return trace.Wrap(err, "%s", out)
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// New returns a new etcd backup manager
+func New(config Config) (*Manager, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &Manager{Config: config}, nil
+}
+
+// Manager takes and restores etcd backups
+type Manager struct {
+	// Config is the manager configuration
+	Config
+}
+
+// Backup describes a single stored etcd backup
+type Backup struct {
+	// Name is the backup name
+	Name string
+	// Created is the time the backup was taken
+	Created time.Time
+}
+
+// String returns the backup name
+func (r Backup) String() string {
+	return r.Name
+}
+
+// Backup takes a new etcd backup, uploads it to the storage
+// and removes backups that exceed the retention count
+func (r *Manager) Backup(ctx context.Context) (*Backup, error) {
+	backup := Backup{Created: r.Clock.Now().UTC()}
+	backup.Name = backupName(backup.Created)
+	path, err := r.tempPath(backup.Name)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer os.Remove(path)
+
+	r.Infof("Taking etcd backup %v.", backup.Name)
+	if err := r.planetCommand(ctx, "etcd", "backup", path); err != nil {
+		return nil, trace.Wrap(err, "failed to take etcd backup")
+	}
+	checksum, err := validate(path)
+	if err != nil {
+		return nil, trace.Wrap(err, "etcd backup is invalid")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	defer f.Close()
+	if err := r.Storage.Put(backup.Name, f); err != nil {
+		return nil, trace.Wrap(err, "failed to upload %v to %v", backup.Name, r.Storage)
+	}
+	// Upload the checksum last so a partially uploaded backup is never
+	// considered complete
+	if err := r.Storage.Put(checksumName(backup.Name), strings.NewReader(checksum)); err != nil {
+		return nil, trace.Wrap(err, "failed to upload checksum for %v to %v", backup.Name, r.Storage)
+	}
+	r.Infof("Uploaded etcd backup %v to %v.", backup.Name, r.Storage)
+
+	if err := r.prune(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &backup, nil
+}
+
+// Run takes a backup every interval until the context is cancelled.
+// Failure to take a single backup is logged and does not stop the loop
+func (r *Manager) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := r.Backup(ctx); err != nil {
+			r.Errorf("Failed to take etcd backup: %v.", trace.DebugReport(err))
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Restore downloads the backup with the specified name, validates it
+// and restores the etcd database from it.
+// If name is empty, the most recent backup is restored
+func (r *Manager) Restore(ctx context.Context, name string) error {
+	path, err := r.download(name)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer os.Remove(path)
+
+	r.Infof("Restoring etcd from backup %v.", name)
+	if err := r.planetCommand(ctx, "etcd", "restore", path); err != nil {
+		return trace.Wrap(err, "failed to restore etcd from %v", name)
+	}
+	return nil
+}
+
+// Verify downloads the backup with the specified name and validates it
+// without restoring.
+// If name is empty, the most recent backup is verified
+func (r *Manager) Verify(name string) error {
+	path, err := r.download(name)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.ConvertSystemError(os.Remove(path))
+}
+
+// List returns all complete backups in the storage sorted from oldest to newest
+func (r *Manager) List() (backups []Backup, err error) {
+	names, err := r.Storage.List()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	complete := make(map[string]bool)
+	for _, name := range names {
+		complete[name] = true
+	}
+	for _, name := range names {
+		created, ok := parseBackupName(name)
+		if !ok || !complete[checksumName(name)] {
+			continue
+		}
+		backups = append(backups, Backup{Name: name, Created: created})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Created.Before(backups[j].Created)
+	})
+	return backups, nil
+}
+
+// download fetches the backup with the specified name (or the most recent
+// backup if name is empty) into a temporary file and verifies it against
+// the stored checksum.
+// Returns the path to the downloaded file
+func (r *Manager) download(name string) (path string, err error) {
+	if name == "" {
+		backups, err := r.List()
+		if err != nil {
+			return "", trace.Wrap(err)
+		}
+		if len(backups) == 0 {
+			return "", trace.NotFound("no etcd backups found in %v", r.Storage)
+		}
+		name = backups[len(backups)-1].Name
+	}
+	if _, ok := parseBackupName(name); !ok {
+		return "", trace.BadParameter("%q is not an etcd backup name", name)
+	}
+	expected, err := r.readChecksum(name)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	path, err = r.tempPath(name)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(path)
+		}
+	}()
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return "", trace.ConvertSystemError(err)
+	}
+	defer f.Close()
+	if err := r.Storage.Get(name, f); err != nil {
+		return "", trace.Wrap(err, "failed to download %v from %v", name, r.Storage)
+	}
+	checksum, err := validate(path)
+	if err != nil {
+		return "", trace.Wrap(err, "etcd backup %v is invalid", name)
+	}
+	if checksum != expected {
+		return "", trace.BadParameter("checksum mismatch for %v: stored %q, calculated %q",
+			name, expected, checksum)
+	}
+	r.Infof("Verified etcd backup %v: %v.", name, checksum)
+	return path, nil
+}
+
+func (r *Manager) readChecksum(name string) (string, error) {
+	f, err := ioutil.TempFile(r.WorkDir, checksumName(name))
+	if err != nil {
+		return "", trace.ConvertSystemError(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	if err := r.Storage.Get(checksumName(name), f); err != nil {
+		if trace.IsNotFound(err) {
+			return "", trace.NotFound("etcd backup %v not found in %v", name, r.Storage)
+		}
+		return "", trace.Wrap(err)
+	}
+	data, err := ioutil.ReadFile(f.Name())
+	if err != nil {
+		return "", trace.ConvertSystemError(err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// prune removes the oldest backups exceeding the retention count
+func (r *Manager) prune() error {
+	if r.Retain == 0 {
+		return nil
+	}
+	backups, err := r.List()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if len(backups) <= r.Retain {
+		return nil
+	}
+	var errors []error
+	for _, backup := range backups[:len(backups)-r.Retain] {
+		r.Infof("Removing etcd backup %v.", backup)
+		// Remove the checksum first so the backup is not considered
+		// complete if removing the data fails
+		for _, name := range []string{checksumName(backup.Name), backup.Name} {
+			if err := r.Storage.Delete(name); err != nil && !trace.IsNotFound(err) {
+				errors = append(errors, err)
+			}
+		}
+	}
+	return trace.NewAggregate(errors...)
+}
+
+func (r *Manager) tempPath(name string) (string, error) {
+	if err := os.MkdirAll(r.WorkDir, 0700); err != nil {
+		return "", trace.ConvertSystemError(err)
+	}
+	f, err := ioutil.TempFile(r.WorkDir, name)
+	if err != nil {
+		return "", trace.ConvertSystemError(err)
+	}
+	if err := f.Close(); err != nil {
+		return "", trace.ConvertSystemError(err)
+	}
+	return f.Name(), nil
+}
+
+// validate makes sure the backup file at the specified path looks like
+// an etcd backup and returns its sha256 checksum
+func validate(path string) (checksum string, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", trace.ConvertSystemError(err)
+	}
+	defer f.Close()
+	hash := sha256.New()
+	size, err := io.Copy(hash, f)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	if size == 0 {
+		return "", trace.BadParameter("backup file is empty")
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", trace.Wrap(err)
+	}
+	// The backup is a stream of JSON records
+	var header json.RawMessage
+	if err := json.NewDecoder(f).Decode(&header); err != nil {
+		return "", trace.BadParameter("backup file is not in etcd backup format: %v", err)
+	}
+	if !bytes.HasPrefix(bytes.TrimSpace(header), []byte("{")) {
+		return "", trace.BadParameter("backup file is not in etcd backup format")
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
+
+func backupName(created time.Time) string {
+	return backupPrefix + created.Format(timeFormat) + backupSuffix
+}
+
+func parseBackupName(name string) (created time.Time, ok bool) {
+	if !strings.HasPrefix(name, backupPrefix) || !strings.HasSuffix(name, backupSuffix) {
+		return created, false
+	}
+	created, err := time.Parse(timeFormat,
+		strings.TrimSuffix(strings.TrimPrefix(name, backupPrefix), backupSuffix))
+	if err != nil {
+		return created, false
+	}
+	return created, true
+}
+
+func checksumName(name string) string {
+	return name + checksumSuffix
+}
+
+const (
+	backupPrefix   = "etcd-"
+	backupSuffix   = ".backup"
+	checksumSuffix = ".sha256"
+	timeFormat     = "20060102T150405Z"
+)
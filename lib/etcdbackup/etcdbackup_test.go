@@ -0,0 +1,119 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package etcdbackup
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+	. "gopkg.in/check.v1"
+)
+
+func TestEtcdBackup(t *testing.T) { TestingT(t) }
+
+type BackupSuite struct {
+	clock    clockwork.FakeClock
+	storage  *dirStorage
+	restored []string
+	manager  *Manager
+}
+
+var _ = Suite(&BackupSuite{})
+
+func (s *BackupSuite) SetUpTest(c *C) {
+	s.clock = clockwork.NewFakeClockAt(time.Date(2018, 10, 16, 12, 0, 0, 0, time.UTC))
+	s.storage = NewDirStorage(c.MkDir())
+	s.restored = nil
+	var err error
+	s.manager, err = New(Config{
+		Storage: s.storage,
+		Retain:  2,
+		WorkDir: c.MkDir(),
+		Clock:   s.clock,
+		planetCommand: func(ctx context.Context, args ...string) error {
+			switch args[1] {
+			case "backup":
+				return ioutil.WriteFile(args[2], []byte(`{"version":"1"}`), 0600)
+			case "restore":
+				data, err := ioutil.ReadFile(args[2])
+				s.restored = append(s.restored, string(data))
+				return trace.Wrap(err)
+			}
+			return trace.BadParameter("unexpected command %v", args)
+		},
+	})
+	c.Assert(err, IsNil)
+}
+
+func (s *BackupSuite) TestKeepsRetainedBackups(c *C) {
+	for i := 0; i < 3; i++ {
+		_, err := s.manager.Backup(context.TODO())
+		c.Assert(err, IsNil)
+		s.clock.Advance(time.Hour)
+	}
+	backups, err := s.manager.List()
+	c.Assert(err, IsNil)
+	c.Assert(backups, DeepEquals, []Backup{
+		{Name: "etcd-20181016T130000Z.backup", Created: time.Date(2018, 10, 16, 13, 0, 0, 0, time.UTC)},
+		{Name: "etcd-20181016T140000Z.backup", Created: time.Date(2018, 10, 16, 14, 0, 0, 0, time.UTC)},
+	})
+	names, err := s.storage.List()
+	c.Assert(err, IsNil)
+	c.Assert(names, HasLen, 4)
+}
+
+func (s *BackupSuite) TestRestoresLatestBackup(c *C) {
+	_, err := s.manager.Backup(context.TODO())
+	c.Assert(err, IsNil)
+	c.Assert(s.manager.Restore(context.TODO(), ""), IsNil)
+	c.Assert(s.restored, DeepEquals, []string{`{"version":"1"}`})
+}
+
+func (s *BackupSuite) TestRefusesToRestoreCorruptedBackup(c *C) {
+	backup, err := s.manager.Backup(context.TODO())
+	c.Assert(err, IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(s.storage.dir, backup.Name), []byte(`{"version":"2"}`), 0600), IsNil)
+
+	err = s.manager.Restore(context.TODO(), backup.Name)
+	c.Assert(err, ErrorMatches, "checksum mismatch.*")
+	c.Assert(s.restored, HasLen, 0)
+}
+
+func (s *BackupSuite) TestRejectsInvalidBackup(c *C) {
+	path := filepath.Join(c.MkDir(), "backup")
+	c.Assert(ioutil.WriteFile(path, []byte("not a backup"), 0600), IsNil)
+	_, err := validate(path)
+	c.Assert(trace.IsBadParameter(err), Equals, true)
+}
+
+func (s *BackupSuite) TestParsesStorageLocation(c *C) {
+	storage, err := NewStorage("s3://bucket/backups/etcd?region=us-west-2")
+	c.Assert(err, IsNil)
+	c.Assert(storage.String(), Equals, "s3://bucket/backups/etcd")
+
+	storage, err = NewStorage("/var/lib/backups")
+	c.Assert(err, IsNil)
+	c.Assert(storage.String(), Equals, "/var/lib/backups")
+
+	_, err = NewStorage("ftp://host/backups")
+	c.Assert(trace.IsBadParameter(err), Equals, true)
+}
@@ -0,0 +1,239 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package etcdbackup
+
+import (
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/gravitational/trace"
+)
+
+// Storage defines a location where etcd backups are kept
+type Storage interface {
+	// Put stores the contents of the provided reader under the specified name
+	Put(name string, r io.Reader) error
+	// Get downloads the object with the specified name into the provided file
+	Get(name string, f *os.File) error
+	// List returns names of all objects in the storage
+	List() ([]string, error)
+	// Delete removes the object with the specified name
+	Delete(name string) error
+	// String returns the storage location
+	String() string
+}
+
+// NewStorage returns a new storage for the specified location.
+//
+// The location is either an S3 URL in the form s3://bucket/prefix[?region=region]
+// or a path to a local directory. S3 credentials are taken from the default
+// AWS credentials chain (environment, shared credentials file or instance role).
+func NewStorage(location string) (Storage, error) {
+	if location == "" {
+		return nil, trace.BadParameter("missing backup location")
+	}
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	switch u.Scheme {
+	case schemeS3:
+		if u.Host == "" {
+			return nil, trace.BadParameter("missing bucket name in %q", location)
+		}
+		region := u.Query().Get("region")
+		if region == "" {
+			region = defaults.AWSRegion
+		}
+		session, err := session.NewSession(&aws.Config{
+			Region: aws.String(region),
+		})
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		return NewS3Storage(s3.New(session), u.Host, strings.Trim(u.Path, "/")), nil
+	case "", schemeFile:
+		return NewDirStorage(u.Path), nil
+	}
+	return nil, trace.BadParameter("unsupported backup location %q, "+
+		"expected either a directory or s3://bucket/prefix", location)
+}
+
+// NewDirStorage returns a new storage that keeps backups in the specified local directory
+func NewDirStorage(dir string) *dirStorage {
+	return &dirStorage{dir: dir}
+}
+
+// Put stores the contents of the provided reader under the specified name
+func (r *dirStorage) Put(name string, reader io.Reader) error {
+	if err := os.MkdirAll(r.dir, defaults.SharedDirMask); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	f, err := ioutil.TempFile(r.dir, name)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	if _, err := io.Copy(f, reader); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := f.Sync(); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	return trace.ConvertSystemError(os.Rename(f.Name(), filepath.Join(r.dir, name)))
+}
+
+// Get downloads the object with the specified name into the provided file
+func (r *dirStorage) Get(name string, f *os.File) error {
+	src, err := os.Open(filepath.Join(r.dir, name))
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	defer src.Close()
+	_, err = io.Copy(f, src)
+	return trace.Wrap(err)
+}
+
+// List returns names of all objects in the storage
+func (r *dirStorage) List() (names []string, err error) {
+	files, err := ioutil.ReadDir(r.dir)
+	if err != nil {
+		err = trace.ConvertSystemError(err)
+		if trace.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, trace.Wrap(err)
+	}
+	for _, file := range files {
+		if file.Mode().IsRegular() {
+			names = append(names, file.Name())
+		}
+	}
+	return names, nil
+}
+
+// Delete removes the object with the specified name
+func (r *dirStorage) Delete(name string) error {
+	return trace.ConvertSystemError(os.Remove(filepath.Join(r.dir, name)))
+}
+
+// String returns the storage location
+func (r *dirStorage) String() string {
+	return r.dir
+}
+
+type dirStorage struct {
+	dir string
+}
+
+// NewS3Storage returns a new storage that keeps backups in the specified S3 bucket
+func NewS3Storage(client s3iface.S3API, bucket, prefix string) *s3Storage {
+	return &s3Storage{
+		client:     client,
+		bucket:     bucket,
+		prefix:     prefix,
+		uploader:   s3manager.NewUploaderWithClient(client),
+		downloader: s3manager.NewDownloaderWithClient(client),
+	}
+}
+
+// Put stores the contents of the provided reader under the specified name
+func (r *s3Storage) Put(name string, reader io.Reader) error {
+	_, err := r.uploader.Upload(&s3manager.UploadInput{
+		Bucket: aws.String(r.bucket),
+		Key:    aws.String(r.key(name)),
+		Body:   reader,
+	})
+	return trace.Wrap(utils.ConvertS3Error(err))
+}
+
+// Get downloads the object with the specified name into the provided file
+func (r *s3Storage) Get(name string, f *os.File) error {
+	_, err := r.downloader.Download(f, &s3.GetObjectInput{
+		Bucket: aws.String(r.bucket),
+		Key:    aws.String(r.key(name)),
+	})
+	return trace.Wrap(utils.ConvertS3Error(err))
+}
+
+// List returns names of all objects in the storage
+func (r *s3Storage) List() (names []string, err error) {
+	prefix := r.key("")
+	err = r.client.ListObjectsV2Pages(&s3.ListObjectsV2Input{
+		Bucket: aws.String(r.bucket),
+		Prefix: aws.String(prefix),
+	}, func(page *s3.ListObjectsV2Output, _ bool) bool {
+		for _, object := range page.Contents {
+			name := strings.TrimPrefix(aws.StringValue(object.Key), prefix)
+			if name != "" && !strings.Contains(name, "/") {
+				names = append(names, name)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, trace.Wrap(utils.ConvertS3Error(err))
+	}
+	return names, nil
+}
+
+// Delete removes the object with the specified name
+func (r *s3Storage) Delete(name string) error {
+	_, err := r.client.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(r.bucket),
+		Key:    aws.String(r.key(name)),
+	})
+	return trace.Wrap(utils.ConvertS3Error(err))
+}
+
+// String returns the storage location
+func (r *s3Storage) String() string {
+	return (&url.URL{Scheme: schemeS3, Host: r.bucket, Path: r.prefix}).String()
+}
+
+func (r *s3Storage) key(name string) string {
+	if r.prefix == "" {
+		return name
+	}
+	return r.prefix + "/" + name
+}
+
+type s3Storage struct {
+	client     s3iface.S3API
+	bucket     string
+	prefix     string
+	uploader   *s3manager.Uploader
+	downloader *s3manager.Downloader
+}
+
+const (
+	schemeS3   = "s3"
+	schemeFile = "file"
+)
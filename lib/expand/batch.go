@@ -0,0 +1,541 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package expand
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gravitational/gravity/lib/app"
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/fsm"
+	"github.com/gravitational/gravity/lib/install"
+	"github.com/gravitational/gravity/lib/localenv"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/pack"
+	"github.com/gravitational/gravity/lib/schema"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/fatih/color"
+	"github.com/ghodss/yaml"
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// Batch describes a set of nodes to add to the cluster within
+// a single expand operation
+type Batch struct {
+	// Nodes is the list of nodes to add to the cluster
+	Nodes []storage.BatchNode `json:"nodes"`
+}
+
+// ReadBatch reads the batch description from the specified YAML file
+func ReadBatch(path string) (*Batch, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	var batch Batch
+	err = yaml.Unmarshal(data, &batch)
+	if err != nil {
+		return nil, trace.BadParameter("failed to parse batch file %v: %v", path, err)
+	}
+	return &batch, nil
+}
+
+// Check makes sure the batch can be added to the cluster running
+// an application with the provided manifest
+func (b Batch) Check(manifest schema.Manifest) error {
+	if len(b.Nodes) == 0 {
+		return trace.BadParameter("batch does not have any nodes")
+	}
+	seen := make(map[string]struct{}, len(b.Nodes))
+	for _, node := range b.Nodes {
+		if net.ParseIP(node.AdvertiseIP) == nil {
+			return trace.BadParameter("invalid node advertise IP %q", node.AdvertiseIP)
+		}
+		if _, ok := seen[node.AdvertiseIP]; ok {
+			return trace.BadParameter("node %v is listed more than once", node.AdvertiseIP)
+		}
+		seen[node.AdvertiseIP] = struct{}{}
+		if node.Role == "" {
+			return trace.BadParameter("node %v does not have a role", node.AdvertiseIP)
+		}
+		profile, err := manifest.NodeProfiles.ByName(node.Role)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if profile.ServiceRole == schema.ServiceRoleMaster {
+			return trace.BadParameter("node %v has master role %q, master nodes "+
+				"cannot be added in a batch", node.AdvertiseIP, node.Role)
+		}
+	}
+	return nil
+}
+
+// Servers returns the number of nodes of each role in the batch
+func (b Batch) Servers() map[string]int {
+	servers := make(map[string]int)
+	for _, node := range b.Nodes {
+		servers[node.Role]++
+	}
+	return servers
+}
+
+// BatchConfig is the configuration of a batch join
+type BatchConfig struct {
+	// Batch is the set of nodes to add to the cluster
+	Batch Batch
+	// Operator is the ops service of the cluster
+	Operator ops.Operator
+	// Apps is the apps service of the cluster
+	Apps app.Applications
+	// Packages is the pack service of the cluster
+	Packages pack.PackageService
+	// LocalBackend is local backend of this node
+	LocalBackend storage.Backend
+	// LocalApps is local apps service of this node
+	LocalApps app.Applications
+	// LocalPackages is local package service of this node
+	LocalPackages pack.PackageService
+	// JoinBackend is the local backend where join-specific operation data is stored
+	JoinBackend storage.Backend
+	// JoinTimeout is how long to wait for all nodes in the batch to join
+	JoinTimeout time.Duration
+	// Silent allows batch join to output its progress
+	localenv.Silent
+	// FieldLogger is used for logging
+	log.FieldLogger
+	// DebugMode turns on FSM debug mode
+	DebugMode bool
+	// Insecure turns on FSM insecure mode
+	Insecure bool
+}
+
+// CheckAndSetDefaults validates the configuration and sets defaults
+func (c *BatchConfig) CheckAndSetDefaults() error {
+	if len(c.Batch.Nodes) == 0 {
+		return trace.BadParameter("missing Batch")
+	}
+	if c.Operator == nil {
+		return trace.BadParameter("missing Operator")
+	}
+	if c.Apps == nil {
+		return trace.BadParameter("missing Apps")
+	}
+	if c.Packages == nil {
+		return trace.BadParameter("missing Packages")
+	}
+	if c.LocalBackend == nil {
+		return trace.BadParameter("missing LocalBackend")
+	}
+	if c.LocalApps == nil {
+		return trace.BadParameter("missing LocalApps")
+	}
+	if c.LocalPackages == nil {
+		return trace.BadParameter("missing LocalPackages")
+	}
+	if c.JoinBackend == nil {
+		return trace.BadParameter("missing JoinBackend")
+	}
+	if c.JoinTimeout == 0 {
+		c.JoinTimeout = defaults.BatchJoinTimeout
+	}
+	if c.FieldLogger == nil {
+		c.FieldLogger = log.WithField(trace.Component, "batch")
+	}
+	return nil
+}
+
+// BatchJoin adds a batch of nodes to the cluster within a single
+// expand operation.
+//
+// It creates the operation, admits the nodes listed in the batch as they
+// join and then executes the operation plan on all of them concurrently
+type BatchJoin struct {
+	BatchConfig
+}
+
+// NewBatchJoin returns a new batch join
+func NewBatchJoin(config BatchConfig) (*BatchJoin, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &BatchJoin{BatchConfig: config}, nil
+}
+
+// Run creates the batch expand operation, waits for all nodes in the batch
+// to join and executes the operation
+func (b *BatchJoin) Run(ctx context.Context) (err error) {
+	cluster, err := b.Operator.GetLocalSite()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = b.Batch.Check(cluster.App.Manifest)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	operation, err := b.createOperation(*cluster)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	// the operation has not started from user's perspective until
+	// the plan has been created so remove it if anything goes wrong
+	var planned bool
+	defer func() {
+		if err == nil || planned {
+			return
+		}
+		b.Warnf("Cleaning up unstarted operation %v.", operation)
+		if err := b.Operator.DeleteSiteOperation(operation.Key()); err != nil {
+			b.Errorf("Failed to delete unstarted operation: %v.",
+				trace.DebugReport(err))
+		}
+	}()
+	err = b.printInstructions(*cluster, *operation)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = b.waitForNodes(ctx, *operation)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	creds, err := install.LoadRPCCredentials(ctx, b.Packages, b.FieldLogger)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	builder, err := newBatchPlanBuilder(operationContext{
+		Operator:  b.Operator,
+		Packages:  b.Packages,
+		Apps:      b.Apps,
+		Operation: *operation,
+		Cluster:   *cluster,
+		Creds:     *creds,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = b.Operator.CreateOperationPlan(operation.Key(), *builder.Build(*operation))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	planned = true
+	err = syncOperation(b.Operator, b.JoinBackend, *cluster, operation.Key())
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	machine, err := NewFSM(FSMConfig{
+		Operator:      b.Operator,
+		OperationKey:  operation.Key(),
+		Apps:          b.Apps,
+		Packages:      b.Packages,
+		LocalBackend:  b.LocalBackend,
+		LocalApps:     b.LocalApps,
+		LocalPackages: b.LocalPackages,
+		JoinBackend:   b.JoinBackend,
+		Credentials:   creds.Client,
+		DebugMode:     b.DebugMode,
+		Insecure:      b.Insecure,
+		DNSConfig:     cluster.DNSConfig,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(b.execute(ctx, machine, operation.Key(), builder.JoiningNodes))
+}
+
+// createOperation creates a new expand operation for the batch
+func (b *BatchJoin) createOperation(cluster ops.Site) (*ops.SiteOperation, error) {
+	key, err := b.Operator.CreateSiteExpandOperation(ops.CreateSiteExpandOperationRequest{
+		AccountID:   cluster.AccountID,
+		SiteDomain:  cluster.Domain,
+		Provisioner: schema.ProvisionerOnPrem,
+		Servers:     b.Batch.Servers(),
+		Variables: storage.OperationVariables{
+			System: storage.SystemVariables{
+				BatchNodes: b.Batch.Nodes,
+			},
+		},
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	err = b.Operator.SetOperationState(*key, ops.SetOperationStateRequest{
+		State: ops.OperationStateReady,
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	operation, err := b.Operator.GetSiteOperation(*key)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return operation, nil
+}
+
+// printInstructions outputs the commands to run on the nodes in the batch
+func (b *BatchJoin) printInstructions(cluster ops.Site, operation ops.SiteOperation) error {
+	token, err := b.Operator.GetExpandToken(cluster.Key())
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	masters := storage.Servers(cluster.ClusterState.Servers).Masters()
+	if len(masters) == 0 {
+		return trace.NotFound("cluster %v does not have master nodes", cluster.Domain)
+	}
+	b.Silent.Printf("Created batch expand operation %v for %v nodes.\n",
+		operation.ID, len(b.Batch.Nodes))
+	b.Silent.Println("Execute the following command on each node in the batch:")
+	for _, node := range b.Batch.Nodes {
+		b.Silent.Printf("\tgravity join %v --token=%v --advertise-addr=%v --role=%v\n",
+			masters[0].AdvertiseIP, token.Token, node.AdvertiseIP, node.Role)
+	}
+	return nil
+}
+
+// waitForNodes blocks until all nodes in the batch have joined the
+// operation and admits them
+func (b *BatchJoin) waitForNodes(ctx context.Context, operation ops.SiteOperation) error {
+	ctx, cancel := context.WithTimeout(ctx, b.JoinTimeout)
+	defer cancel()
+	ticker := time.NewTicker(defaults.BatchStatusInterval)
+	defer ticker.Stop()
+	joined := make(map[string]struct{}, len(b.Batch.Nodes))
+	for {
+		select {
+		case <-ticker.C:
+			report, err := b.Operator.GetSiteExpandOperationAgentReport(operation.Key())
+			if err != nil {
+				b.Warnf("Failed to query agent report: %v.", trace.DebugReport(err))
+				continue
+			}
+			for _, server := range report.Servers {
+				addr, _ := utils.SplitHostPort(server.AdvertiseAddr, "")
+				if _, ok := joined[addr]; ok {
+					continue
+				}
+				joined[addr] = struct{}{}
+				b.printStep("Node %v (%v) has joined (%v/%v)", addr,
+					server.GetHostname(), len(joined), len(b.Batch.Nodes))
+			}
+			if len(report.Servers) < len(b.Batch.Nodes) {
+				continue
+			}
+			req, err := install.GetServers(operation, report.Servers)
+			if err != nil {
+				return trace.Wrap(err)
+			}
+			err = b.Operator.UpdateExpandOperationState(operation.Key(), *req)
+			if err != nil {
+				return trace.Wrap(err)
+			}
+			b.printStep("All %v nodes have joined", len(b.Batch.Nodes))
+			return nil
+		case <-ctx.Done():
+			var missing []string
+			for _, node := range b.Batch.Nodes {
+				if _, ok := joined[node.AdvertiseIP]; !ok {
+					missing = append(missing, node.AdvertiseIP)
+				}
+			}
+			return trace.LimitExceeded("timed out waiting for nodes %v to join",
+				strings.Join(missing, ", "))
+		}
+	}
+}
+
+// execute executes the operation plan and reports the status of
+// individual nodes as it changes
+func (b *BatchJoin) execute(ctx context.Context, machine *fsm.FSM, key ops.SiteOperationKey, nodes storage.Servers) error {
+	start := time.Now()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- machine.ExecutePlan(ctx, utils.NewNopProgress(), false)
+	}()
+	ticker := time.NewTicker(defaults.BatchStatusInterval)
+	defer ticker.Stop()
+	reported := make(map[string]string, len(nodes))
+	for {
+		select {
+		case fsmErr := <-errCh:
+			b.reportStatus(key, nodes, reported)
+			if err := machine.Complete(fsmErr); err != nil {
+				b.Errorf("Failed to complete operation: %v.", trace.DebugReport(err))
+			}
+			if fsmErr != nil {
+				b.Silent.Println(color.RedString("Failed to add nodes to the cluster"))
+				b.printStatus(key, nodes)
+				return trace.Wrap(fsmErr)
+			}
+			b.printStep("%v", color.GreenString("Added %v nodes to the cluster in %v",
+				len(nodes), time.Since(start)))
+			return nil
+		case <-ticker.C:
+			b.reportStatus(key, nodes, reported)
+		}
+	}
+}
+
+// reportStatus outputs the status of the nodes that has changed since
+// the last report
+func (b *BatchJoin) reportStatus(key ops.SiteOperationKey, nodes storage.Servers, reported map[string]string) {
+	plan, err := fsm.GetOperationPlan(b.JoinBackend, key.SiteDomain, key.OperationID)
+	if err != nil {
+		b.Warnf("Failed to query operation plan: %v.", trace.DebugReport(err))
+		return
+	}
+	for _, status := range GetNodeStatuses(*plan, nodes) {
+		state := fmt.Sprintf("%v %v", status.State, status.Phase)
+		if reported[status.Server.AdvertiseIP] == state {
+			continue
+		}
+		reported[status.Server.AdvertiseIP] = state
+		b.printStep("%v", status)
+	}
+}
+
+// printStatus outputs the status of all nodes
+func (b *BatchJoin) printStatus(key ops.SiteOperationKey, nodes storage.Servers) {
+	plan, err := fsm.GetOperationPlan(b.JoinBackend, key.SiteDomain, key.OperationID)
+	if err != nil {
+		b.Warnf("Failed to query operation plan: %v.", trace.DebugReport(err))
+		return
+	}
+	for _, status := range GetNodeStatuses(*plan, nodes) {
+		b.Silent.Printf("\t%v\n", status)
+	}
+}
+
+func (b *BatchJoin) printStep(format string, args ...interface{}) {
+	b.Silent.Printf("%v\t%v\n", time.Now().UTC().Format(constants.HumanDateFormatSeconds),
+		fmt.Sprintf(format, args...))
+}
+
+// NodeStatus describes the progress of a single node within
+// a batch expand operation
+type NodeStatus struct {
+	// Server is the joining node
+	Server storage.Server
+	// State is the node state, one of the operation phase states
+	State string
+	// Phase is the ID of the phase that has last been executed on the node
+	Phase string
+	// Error is the error the failed phase has failed with
+	Error string
+}
+
+// String returns a textual representation of this node status
+func (s NodeStatus) String() string {
+	var phase string
+	if s.Phase != "" {
+		phase = fmt.Sprintf(" (%v)", s.Phase)
+	}
+	status := fmt.Sprintf("%v/%v: %v%v", s.Server.Hostname, s.Server.AdvertiseIP,
+		s.State, phase)
+	if s.Error != "" {
+		status = fmt.Sprintf("%v: %v", status, s.Error)
+	}
+	return status
+}
+
+// GetNodeStatuses computes the status of each of the specified joining
+// nodes from the state of the plan phases that operate on it
+func GetNodeStatuses(plan storage.OperationPlan, nodes storage.Servers) []NodeStatus {
+	statuses := make([]NodeStatus, 0, len(nodes))
+	for _, node := range nodes {
+		status := NodeStatus{
+			Server: node,
+			State:  storage.OperationPhaseStateUnstarted,
+		}
+		var phases, completed int
+		var lastUpdated time.Time
+		for _, phase := range fsm.FlattenPlan(&plan) {
+			if phase.HasSubphases() || phase.Data == nil || phase.Data.Server == nil ||
+				phase.Data.Server.AdvertiseIP != node.AdvertiseIP {
+				continue
+			}
+			phases++
+			switch {
+			case phase.IsFailed():
+				status.State = storage.OperationPhaseStateFailed
+				status.Phase = phase.ID
+				var phaseErr trace.TraceErr
+				if phase.Error != nil && utils.UnmarshalError(phase.Error.Err, &phaseErr) == nil && phaseErr.Err != nil {
+					status.Error = phaseErr.Err.Error()
+				}
+			case phase.IsCompleted():
+				completed++
+				if status.State != storage.OperationPhaseStateFailed &&
+					phase.GetLastUpdateTime().After(lastUpdated) {
+					lastUpdated = phase.GetLastUpdateTime()
+					status.Phase = phase.ID
+				}
+			case phase.IsInProgress():
+				if status.State != storage.OperationPhaseStateFailed {
+					status.State = storage.OperationPhaseStateInProgress
+					status.Phase = phase.ID
+				}
+			}
+		}
+		switch {
+		case status.State != storage.OperationPhaseStateUnstarted:
+		case phases != 0 && completed == phases:
+			status.State = storage.OperationPhaseStateCompleted
+		case completed != 0:
+			status.State = storage.OperationPhaseStateInProgress
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Server.AdvertiseIP < statuses[j].Server.AdvertiseIP
+	})
+	return statuses
+}
+
+// isBatchOperation returns true if the provided operation adds
+// a batch of nodes
+func isBatchOperation(operation ops.SiteOperation) bool {
+	return operation.InstallExpand != nil &&
+		len(operation.InstallExpand.Vars.System.BatchNodes) != 0
+}
+
+// findBatchNode returns the batch node with the specified advertise IP
+// if the provided operation is a batch expand operation awaiting nodes
+func findBatchNode(operation ops.SiteOperation, advertiseIP string) (*storage.BatchNode, error) {
+	if operation.Type != ops.OperationExpand || !isBatchOperation(operation) {
+		return nil, trace.NotFound("not a batch expand operation")
+	}
+	switch operation.State {
+	case ops.OperationStateExpandInitiated, ops.OperationStateReady:
+	default:
+		return nil, trace.NotFound("batch operation %v is not accepting nodes", operation.ID)
+	}
+	for _, node := range operation.InstallExpand.Vars.System.BatchNodes {
+		if node.AdvertiseIP == advertiseIP {
+			return &node, nil
+		}
+	}
+	return nil, trace.NotFound("node %v is not a part of batch operation %v",
+		advertiseIP, operation.ID)
+}
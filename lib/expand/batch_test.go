@@ -0,0 +1,227 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package expand
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/gravitational/gravity/lib/app"
+	installphases "github.com/gravitational/gravity/lib/install/phases"
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/schema"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"gopkg.in/check.v1"
+)
+
+type BatchSuite struct {
+	manifest schema.Manifest
+	nodes    storage.Servers
+}
+
+var _ = check.Suite(&BatchSuite{})
+
+func (s *BatchSuite) SetUpTest(c *check.C) {
+	s.manifest = schema.Manifest{
+		NodeProfiles: schema.NodeProfiles{
+			{Name: "master", ServiceRole: schema.ServiceRoleMaster},
+			{Name: "worker", ServiceRole: schema.ServiceRoleNode},
+		},
+	}
+	s.nodes = storage.Servers{
+		{Hostname: "node-2", AdvertiseIP: "10.0.0.2", Role: "worker"},
+		{Hostname: "node-1", AdvertiseIP: "10.0.0.1", Role: "worker"},
+	}
+}
+
+func (s *BatchSuite) TestReadBatch(c *check.C) {
+	dir := c.MkDir()
+	path := filepath.Join(dir, "batch.yaml")
+	err := ioutil.WriteFile(path, []byte(`nodes:
+- advertise_ip: 10.0.0.1
+  role: worker
+- advertise_ip: 10.0.0.2
+  role: worker
+`), os.ModePerm)
+	c.Assert(err, check.IsNil)
+
+	batch, err := ReadBatch(path)
+	c.Assert(err, check.IsNil)
+	c.Assert(batch, check.DeepEquals, &Batch{
+		Nodes: []storage.BatchNode{
+			{AdvertiseIP: "10.0.0.1", Role: "worker"},
+			{AdvertiseIP: "10.0.0.2", Role: "worker"},
+		},
+	})
+	c.Assert(batch.Servers(), check.DeepEquals, map[string]int{"worker": 2})
+	c.Assert(batch.Check(s.manifest), check.IsNil)
+}
+
+func (s *BatchSuite) TestCheckBatch(c *check.C) {
+	var testCases = []struct {
+		nodes   []storage.BatchNode
+		comment string
+	}{
+		{
+			comment: "empty batch",
+		},
+		{
+			nodes:   []storage.BatchNode{{AdvertiseIP: "node-1", Role: "worker"}},
+			comment: "invalid advertise IP",
+		},
+		{
+			nodes: []storage.BatchNode{
+				{AdvertiseIP: "10.0.0.1", Role: "worker"},
+				{AdvertiseIP: "10.0.0.1", Role: "worker"},
+			},
+			comment: "duplicate node",
+		},
+		{
+			nodes:   []storage.BatchNode{{AdvertiseIP: "10.0.0.1"}},
+			comment: "missing role",
+		},
+		{
+			nodes:   []storage.BatchNode{{AdvertiseIP: "10.0.0.1", Role: "db"}},
+			comment: "unknown role",
+		},
+		{
+			nodes:   []storage.BatchNode{{AdvertiseIP: "10.0.0.1", Role: "master"}},
+			comment: "master role",
+		},
+	}
+	for _, tc := range testCases {
+		err := Batch{Nodes: tc.nodes}.Check(s.manifest)
+		c.Assert(err, check.NotNil, check.Commentf(tc.comment))
+	}
+}
+
+func (s *BatchSuite) TestFindBatchNode(c *check.C) {
+	operation := ops.SiteOperation{
+		ID:    "1",
+		Type:  ops.OperationExpand,
+		State: ops.OperationStateReady,
+		InstallExpand: &storage.InstallExpandOperationState{
+			Vars: storage.OperationVariables{
+				System: storage.SystemVariables{
+					BatchNodes: []storage.BatchNode{
+						{AdvertiseIP: "10.0.0.1", Role: "worker"},
+					},
+				},
+			},
+		},
+	}
+	node, err := findBatchNode(operation, "10.0.0.1")
+	c.Assert(err, check.IsNil)
+	c.Assert(*node, check.DeepEquals, storage.BatchNode{AdvertiseIP: "10.0.0.1", Role: "worker"})
+
+	_, err = findBatchNode(operation, "10.0.0.2")
+	c.Assert(err, check.NotNil)
+
+	operation.State = ops.OperationStateExpandProvisioning
+	_, err = findBatchNode(operation, "10.0.0.1")
+	c.Assert(err, check.NotNil)
+}
+
+func (s *BatchSuite) TestPlan(c *check.C) {
+	plan := s.newBuilder().Build(ops.SiteOperation{
+		ID:         "1",
+		Type:       ops.OperationExpand,
+		AccountID:  "account",
+		SiteDomain: "example.com",
+	})
+
+	var phaseIDs []string
+	for _, phase := range plan.Phases {
+		phaseIDs = append(phaseIDs, phase.ID)
+	}
+	c.Assert(phaseIDs, check.DeepEquals, []string{
+		installphases.ConfigurePhase,
+		installphases.BootstrapPhase,
+		installphases.PullPhase,
+		SystemPhase,
+		installphases.WaitPhase,
+	})
+
+	pull := plan.Phases[2]
+	c.Assert(pull.Parallel, check.Equals, true)
+	c.Assert(pull.Phases, check.HasLen, 2)
+	c.Assert(pull.Phases[0].ID, check.Equals, "/pull/node-2")
+	c.Assert(pull.Phases[0].Requires, check.DeepEquals, []string{"/bootstrap/node-2"})
+	c.Assert(pull.Phases[0].Data.ExecServer.AdvertiseIP, check.Equals, "10.0.0.2")
+
+	system := plan.Phases[3]
+	c.Assert(system.Phases[1].ID, check.Equals, "/system/node-1")
+	c.Assert(system.Phases[1].Phases[0].ID, check.Equals, "/system/node-1/teleport")
+	c.Assert(system.Phases[1].Phases[1].ID, check.Equals, "/system/node-1/planet")
+
+	wait := plan.Phases[4]
+	c.Assert(wait.Phases[0].ID, check.Equals, WaitPlanetPhase)
+	c.Assert(wait.Phases[0].Phases[1].ID, check.Equals, "/wait/planet/node-1")
+	c.Assert(wait.Phases[1].Phases[1].ID, check.Equals, "/wait/k8s/node-1")
+	c.Assert(wait.Phases[1].Phases[1].Requires, check.DeepEquals, []string{"/wait/planet/node-1"})
+}
+
+func (s *BatchSuite) TestNodeStatuses(c *check.C) {
+	plan := s.newBuilder().Build(ops.SiteOperation{ID: "1", Type: ops.OperationExpand})
+	plan.Phases[0].State = storage.OperationPhaseStateCompleted
+	for i := range plan.Phases[1].Phases {
+		plan.Phases[1].Phases[i].State = storage.OperationPhaseStateCompleted
+	}
+	// node-2 has failed to pull packages, node-1 is still pulling
+	plan.Phases[2].Phases[0].State = storage.OperationPhaseStateFailed
+	plan.Phases[2].Phases[1].State = storage.OperationPhaseStateInProgress
+
+	statuses := GetNodeStatuses(*plan, s.nodes)
+	c.Assert(statuses, check.HasLen, 2)
+	c.Assert(statuses[0].Server.Hostname, check.Equals, "node-1")
+	c.Assert(statuses[0].State, check.Equals, storage.OperationPhaseStateInProgress)
+	c.Assert(statuses[0].Phase, check.Equals, "/pull/node-1")
+	c.Assert(statuses[1].Server.Hostname, check.Equals, "node-2")
+	c.Assert(statuses[1].State, check.Equals, storage.OperationPhaseStateFailed)
+	c.Assert(statuses[1].Phase, check.Equals, "/pull/node-2")
+
+	for i := range plan.Phases {
+		markCompleted(&plan.Phases[i])
+	}
+	for _, status := range GetNodeStatuses(*plan, s.nodes) {
+		c.Assert(status.State, check.Equals, storage.OperationPhaseStateCompleted)
+	}
+}
+
+func (s *BatchSuite) newBuilder() *batchPlanBuilder {
+	return &batchPlanBuilder{
+		Application: app.Application{
+			Package:  loc.MustParseLocator("gravitational.io/app:0.0.1"),
+			Manifest: s.manifest,
+		},
+		TeleportPackage: loc.MustParseLocator("gravitational.io/teleport:0.0.1"),
+		PlanetPackages: map[string]loc.Locator{
+			"worker": loc.MustParseLocator("gravitational.io/planet:0.0.1"),
+		},
+		JoiningNodes: s.nodes,
+	}
+}
+
+func markCompleted(phase *storage.OperationPhase) {
+	phase.State = storage.OperationPhaseStateCompleted
+	for i := range phase.Phases {
+		markCompleted(&phase.Phases[i])
+	}
+}
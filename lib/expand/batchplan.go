@@ -0,0 +1,362 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package expand
+
+import (
+	"fmt"
+
+	"github.com/gravitational/gravity/lib/app"
+	"github.com/gravitational/gravity/lib/constants"
+	installphases "github.com/gravitational/gravity/lib/install/phases"
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/pack"
+	"github.com/gravitational/gravity/lib/schema"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+)
+
+// batchPlanBuilder builds the plan of an expand operation that adds
+// several nodes to the cluster at once.
+//
+// Each stage of the plan runs concurrently on all joining nodes and
+// every per-node phase only depends on the phases of the same node,
+// so each node can execute its phases using its local state. The order
+// of the stages is maintained by the node coordinating the batch.
+type batchPlanBuilder struct {
+	// Application is the cluster application
+	Application app.Application
+	// TeleportPackage is the teleport package to install
+	TeleportPackage loc.Locator
+	// PlanetPackages maps node profiles to planet packages to install
+	PlanetPackages map[string]loc.Locator
+	// JoiningNodes is the list of nodes joining the cluster
+	JoiningNodes storage.Servers
+	// ClusterNodes is the list of existing cluster nodes
+	ClusterNodes storage.Servers
+	// RegularAgent is the cluster agent with non-admin privileges
+	RegularAgent storage.LoginEntry
+	// ServiceUser is the cluster system user
+	ServiceUser storage.OSUser
+	// DNSConfig specifies the custom cluster DNS configuration
+	DNSConfig storage.DNSConfig
+}
+
+// AddConfigurePhase appends package configuration phase to the plan.
+//
+// Packages for all joining nodes are configured at once by the cluster
+// controller so the phase is executed by the node coordinating the batch
+func (b *batchPlanBuilder) AddConfigurePhase(plan *storage.OperationPlan) {
+	plan.Phases = append(plan.Phases, storage.OperationPhase{
+		ID:          installphases.ConfigurePhase,
+		Description: "Configure packages for the joining nodes",
+	})
+}
+
+// AddOSPackagesPhase appends the phase that installs bundled OS packages
+// to the plan. Only nodes with profiles that require OS packages are
+// included and the phase is omitted if there are none
+func (b *batchPlanBuilder) AddOSPackagesPhase(plan *storage.OperationPlan) {
+	var packagePhases []storage.OperationPhase
+	for i, node := range b.JoiningNodes {
+		profile, err := b.Application.Manifest.NodeProfiles.ByName(node.Role)
+		if err != nil || len(profile.Requirements.OSPackages) == 0 {
+			continue
+		}
+		packagePhases = append(packagePhases, storage.OperationPhase{
+			ID:          nodePhase(installphases.OSPackagesPhase, node),
+			Description: fmt.Sprintf("Install OS packages on node %v", node.Hostname),
+			Data: &storage.OperationPhaseData{
+				Server:     &b.JoiningNodes[i],
+				ExecServer: &b.JoiningNodes[i],
+				Package:    &b.Application.Package,
+			},
+		})
+	}
+	if len(packagePhases) == 0 {
+		return
+	}
+	plan.Phases = append(plan.Phases, storage.OperationPhase{
+		ID:          installphases.OSPackagesPhase,
+		Description: "Install bundled OS packages on the joining nodes",
+		Phases:      packagePhases,
+		Parallel:    true,
+	})
+}
+
+// AddBootstrapPhase appends local nodes bootstrap phase to the plan
+func (b *batchPlanBuilder) AddBootstrapPhase(plan *storage.OperationPlan) {
+	var bootstrapPhases []storage.OperationPhase
+	for i, node := range b.JoiningNodes {
+		bootstrapPhases = append(bootstrapPhases, storage.OperationPhase{
+			ID:          nodePhase(installphases.BootstrapPhase, node),
+			Description: fmt.Sprintf("Bootstrap node %v", node.Hostname),
+			Data: &storage.OperationPhaseData{
+				Server:      &b.JoiningNodes[i],
+				ExecServer:  &b.JoiningNodes[i],
+				Package:     &b.Application.Package,
+				Agent:       &b.RegularAgent,
+				ServiceUser: &b.ServiceUser,
+				DNSConfig:   &b.DNSConfig,
+			},
+		})
+	}
+	plan.Phases = append(plan.Phases, storage.OperationPhase{
+		ID:          installphases.BootstrapPhase,
+		Description: "Bootstrap the joining nodes",
+		Phases:      bootstrapPhases,
+		Parallel:    true,
+	})
+}
+
+// AddPullPhase appends package pull phase to the plan
+func (b *batchPlanBuilder) AddPullPhase(plan *storage.OperationPlan) {
+	var pullPhases []storage.OperationPhase
+	for i, node := range b.JoiningNodes {
+		pullPhases = append(pullPhases, storage.OperationPhase{
+			ID:          nodePhase(installphases.PullPhase, node),
+			Description: fmt.Sprintf("Pull packages on node %v", node.Hostname),
+			Data: &storage.OperationPhaseData{
+				Server:      &b.JoiningNodes[i],
+				ExecServer:  &b.JoiningNodes[i],
+				Package:     &b.Application.Package,
+				ServiceUser: &b.ServiceUser,
+			},
+			Requires: []string{nodePhase(installphases.BootstrapPhase, node)},
+		})
+	}
+	plan.Phases = append(plan.Phases, storage.OperationPhase{
+		ID:          installphases.PullPhase,
+		Description: "Pull packages on the joining nodes",
+		Phases:      pullPhases,
+		Parallel:    true,
+	})
+}
+
+// AddPreHookPhase appends pre-expand hook phase to the plan.
+// The hook is executed once for the whole batch
+func (b *batchPlanBuilder) AddPreHookPhase(plan *storage.OperationPlan) {
+	node := b.JoiningNodes[0]
+	plan.Phases = append(plan.Phases, storage.OperationPhase{
+		ID:          PreHookPhase,
+		Description: fmt.Sprintf("Execute the application's %v hook", schema.HookNodeAdding),
+		Data: &storage.OperationPhaseData{
+			ExecServer:  &b.JoiningNodes[0],
+			Package:     &b.Application.Package,
+			ServiceUser: &b.ServiceUser,
+		},
+		Requires: []string{nodePhase(installphases.PullPhase, node)},
+	})
+}
+
+// AddSystemPhase appends teleport/planet installation phase to the plan
+func (b *batchPlanBuilder) AddSystemPhase(plan *storage.OperationPlan) {
+	var systemPhases []storage.OperationPhase
+	for i, node := range b.JoiningNodes {
+		planetPackage := b.PlanetPackages[node.Role]
+		systemPhases = append(systemPhases, storage.OperationPhase{
+			ID:          nodePhase(SystemPhase, node),
+			Description: fmt.Sprintf("Install system software on node %v", node.Hostname),
+			Phases: []storage.OperationPhase{
+				{
+					ID: fmt.Sprintf("%v/teleport", nodePhase(SystemPhase, node)),
+					Description: fmt.Sprintf("Install system package %v:%v",
+						b.TeleportPackage.Name, b.TeleportPackage.Version),
+					Data: &storage.OperationPhaseData{
+						Server:     &b.JoiningNodes[i],
+						ExecServer: &b.JoiningNodes[i],
+						Package:    &b.TeleportPackage,
+					},
+					Requires: []string{nodePhase(installphases.PullPhase, node)},
+				},
+				{
+					ID: fmt.Sprintf("%v/planet", nodePhase(SystemPhase, node)),
+					Description: fmt.Sprintf("Install system package %v:%v",
+						planetPackage.Name, planetPackage.Version),
+					Data: &storage.OperationPhaseData{
+						Server:     &b.JoiningNodes[i],
+						ExecServer: &b.JoiningNodes[i],
+						Package:    &planetPackage,
+						Labels:     pack.RuntimePackageLabels,
+					},
+					Requires: []string{nodePhase(installphases.PullPhase, node)},
+				},
+			},
+		})
+	}
+	plan.Phases = append(plan.Phases, storage.OperationPhase{
+		ID:          SystemPhase,
+		Description: "Install system software on the joining nodes",
+		Phases:      systemPhases,
+		Parallel:    true,
+	})
+}
+
+// AddWaitPhase appends planet startup wait phase to the plan
+func (b *batchPlanBuilder) AddWaitPhase(plan *storage.OperationPlan) {
+	var planetPhases, k8sPhases []storage.OperationPhase
+	for i, node := range b.JoiningNodes {
+		planetPhases = append(planetPhases, storage.OperationPhase{
+			ID:          nodePhase(WaitPlanetPhase, node),
+			Description: fmt.Sprintf("Wait for the planet to start on node %v", node.Hostname),
+			Data: &storage.OperationPhaseData{
+				Server:     &b.JoiningNodes[i],
+				ExecServer: &b.JoiningNodes[i],
+			},
+			Requires: []string{nodePhase(SystemPhase, node)},
+		})
+		k8sPhases = append(k8sPhases, storage.OperationPhase{
+			ID:          nodePhase(WaitK8sPhase, node),
+			Description: fmt.Sprintf("Wait for node %v to join Kubernetes cluster", node.Hostname),
+			Data: &storage.OperationPhaseData{
+				Server:     &b.JoiningNodes[i],
+				ExecServer: &b.JoiningNodes[i],
+			},
+			Requires: []string{nodePhase(WaitPlanetPhase, node)},
+		})
+	}
+	plan.Phases = append(plan.Phases, storage.OperationPhase{
+		ID:          installphases.WaitPhase,
+		Description: "Wait for the nodes to join the cluster",
+		Phases: []storage.OperationPhase{
+			{
+				ID:          WaitPlanetPhase,
+				Description: "Wait for the planet to start",
+				Phases:      planetPhases,
+				Parallel:    true,
+			},
+			{
+				ID:          WaitK8sPhase,
+				Description: "Wait for the nodes to join Kubernetes cluster",
+				Phases:      k8sPhases,
+				Parallel:    true,
+			},
+		},
+	})
+}
+
+// AddPostHookPhase appends post-expand hook phase to the plan.
+// The hook is executed once for the whole batch
+func (b *batchPlanBuilder) AddPostHookPhase(plan *storage.OperationPlan) {
+	node := b.JoiningNodes[0]
+	plan.Phases = append(plan.Phases, storage.OperationPhase{
+		ID:          PostHookPhase,
+		Description: fmt.Sprintf("Execute the application's %v hook", schema.HookNodeAdded),
+		Data: &storage.OperationPhaseData{
+			ExecServer:  &b.JoiningNodes[0],
+			Package:     &b.Application.Package,
+			ServiceUser: &b.ServiceUser,
+		},
+		Requires: []string{nodePhase(WaitK8sPhase, node)},
+	})
+}
+
+// Build returns the plan for the batch expand operation
+func (b *batchPlanBuilder) Build(operation ops.SiteOperation) *storage.OperationPlan {
+	plan := &storage.OperationPlan{
+		OperationID:   operation.ID,
+		OperationType: operation.Type,
+		AccountID:     operation.AccountID,
+		ClusterName:   operation.SiteDomain,
+		Servers:       b.ClusterNodes,
+	}
+
+	// have cluster controller configure packages for all joining nodes
+	b.AddConfigurePhase(plan)
+
+	// install bundled OS packages missing on the joining nodes
+	b.AddOSPackagesPhase(plan)
+
+	// bootstrap local state on the joining nodes
+	b.AddBootstrapPhase(plan)
+
+	// download configured packages to the joining nodes and unpack them
+	b.AddPullPhase(plan)
+
+	// run pre-join hook if the application has it
+	if b.Application.Manifest.HasHook(schema.HookNodeAdding) {
+		b.AddPreHookPhase(plan)
+	}
+
+	// install teleport and planet services on the joining nodes
+	b.AddSystemPhase(plan)
+
+	// wait for the planets to start up and the new Kubernetes nodes to register
+	b.AddWaitPhase(plan)
+
+	// run post-join hook if the application has it
+	if b.Application.Manifest.HasHook(schema.HookNodeAdded) {
+		b.AddPostHookPhase(plan)
+	}
+
+	fillSteps(plan)
+	return plan
+}
+
+func newBatchPlanBuilder(ctx operationContext) (*batchPlanBuilder, error) {
+	application, err := ctx.Apps.GetApp(ctx.Cluster.App.Package)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	teleportPackage, err := application.Manifest.Dependencies.ByName(
+		constants.TeleportPackage)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	regularAgent, err := ctx.Operator.GetClusterAgent(ops.ClusterAgentRequest{
+		AccountID:   ctx.Operation.AccountID,
+		ClusterName: ctx.Operation.SiteDomain,
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	operation, err := ctx.Operator.GetSiteOperation(ctx.Operation.Key())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if len(operation.Servers) == 0 {
+		return nil, trace.NotFound("operation does not have servers: %v",
+			operation)
+	}
+	planetPackages := make(map[string]loc.Locator)
+	for _, server := range operation.Servers {
+		if _, ok := planetPackages[server.Role]; ok {
+			continue
+		}
+		planetPackage, err := application.Manifest.RuntimePackageForProfile(server.Role)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		planetPackages[server.Role] = *planetPackage
+	}
+	return &batchPlanBuilder{
+		Application:     *application,
+		TeleportPackage: *teleportPackage,
+		PlanetPackages:  planetPackages,
+		JoiningNodes:    operation.Servers,
+		ClusterNodes:    storage.Servers(ctx.Cluster.ClusterState.Servers),
+		RegularAgent:    *regularAgent,
+		ServiceUser:     ctx.Cluster.ServiceUser,
+		DNSConfig:       ctx.Cluster.DNSConfig,
+	}, nil
+}
+
+// nodePhase returns the ID of the specified phase for the provided node
+func nodePhase(phaseID string, node storage.Server) string {
+	return fmt.Sprintf("%v/%v", phaseID, node.Hostname)
+}
@@ -71,6 +71,20 @@ func (b *planBuilder) AddConfigurePhase(plan *storage.OperationPlan) {
 	})
 }
 
+// AddOSPackagesPhase appends the phase that installs bundled OS packages
+// on the joining node to the plan
+func (b *planBuilder) AddOSPackagesPhase(plan *storage.OperationPlan) {
+	plan.Phases = append(plan.Phases, storage.OperationPhase{
+		ID:          installphases.OSPackagesPhase,
+		Description: "Install OS packages on the joining node",
+		Data: &storage.OperationPhaseData{
+			Server:     &b.JoiningNode,
+			ExecServer: &b.JoiningNode,
+			Package:    &b.Application.Package,
+		},
+	})
+}
+
 // AddBootstrapPhase appends local node bootstrap phase to the plan
 func (b *planBuilder) AddBootstrapPhase(plan *storage.OperationPlan) {
 	agent := &b.AdminAgent
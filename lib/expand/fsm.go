@@ -150,6 +150,7 @@ func (e *fsmEngine) GetExecutor(p fsm.ExecutorParams, remote fsm.Remote) (fsm.Ph
 		FieldLogger: logrus.WithField(constants.FieldPhase, p.Phase.ID),
 		Key:         p.Key(),
 		Operator:    e.Operator,
+		Phase:       p.Phase.ID,
 	}
 	executor, err := e.Spec(p, remote)
 	if err != nil {
@@ -192,7 +193,10 @@ func (e *fsmEngine) GetPlan() (*storage.OperationPlan, error) {
 // RunCommand executes the phase specified by params on the specified
 // server using the provided runner
 func (e *fsmEngine) RunCommand(ctx context.Context, runner fsm.RemoteRunner, node storage.Server, p fsm.Params) error {
-	args := []string{"join", "--phase", p.PhaseID, fmt.Sprintf("--force=%v", p.Force)}
+	// the operation ID lets the remote node synchronize the operation
+	// if it has not been executing it locally, e.g. when joining in a batch
+	args := []string{"join", "--phase", p.PhaseID, fmt.Sprintf("--force=%v", p.Force),
+		"--operation-id", e.OperationKey.OperationID}
 	if e.DebugMode {
 		args = append([]string{"--debug"}, args...)
 	}
@@ -35,6 +35,12 @@ func FSMSpec(config FSMConfig) fsm.FSMSpecFunc {
 			return installphases.NewConfigure(p,
 				config.Operator)
 
+		case strings.HasPrefix(p.Phase.ID, installphases.OSPackagesPhase):
+			return installphases.NewOSPackages(p,
+				config.Operator,
+				config.Apps,
+				remote)
+
 		case strings.HasPrefix(p.Phase.ID, installphases.BootstrapPhase):
 			return installphases.NewBootstrap(p,
 				config.Operator,
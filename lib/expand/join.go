@@ -193,6 +193,12 @@ func (p *Peer) dialSite(addr string) (*operationContext, error) {
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+	if p.OperationID == "" {
+		err = p.findBatchOperation(operator, *cluster)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
 	err = p.checkAndSetServerProfile(cluster.App)
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -257,6 +263,31 @@ func (p *Peer) createExpandOperation(operator ops.Operator, cluster ops.Site) (*
 	return operation, nil
 }
 
+// findBatchOperation looks up a batch expand operation this node is a part
+// of and, if found, configures the peer to join it
+func (p *Peer) findBatchOperation(operator ops.Operator, cluster ops.Site) error {
+	operations, err := operator.GetSiteOperations(cluster.Key())
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	for _, operation := range operations {
+		node, err := findBatchNode(ops.SiteOperation(operation), p.AdvertiseAddr)
+		if err != nil {
+			continue
+		}
+		if p.Role != "" && p.Role != node.Role {
+			return utils.Abort(trace.BadParameter(
+				"node %v is expected to join with role %q, got %q",
+				p.AdvertiseAddr, node.Role, p.Role))
+		}
+		p.Role = node.Role
+		p.OperationID = operation.ID
+		p.Infof("Joining batch operation %v.", operation.ID)
+		return nil
+	}
+	return nil
+}
+
 // getExpandOperation returns existing expand operation created via UI
 func (p *Peer) getExpandOperation(operator ops.Operator, cluster ops.Site) (*ops.SiteOperation, error) {
 	operation, err := operator.GetSiteOperation(ops.SiteOperationKey{
@@ -557,12 +588,16 @@ func (p *Peer) run() error {
 	p.agentDoneCh = p.agent.Done()
 	go p.agent.Serve()
 
-	if ctx.Operation.Type == ops.OperationExpand {
+	// operation of a batch of nodes is executed by the node that created it
+	if ctx.Operation.Type == ops.OperationExpand && !isBatchOperation(ctx.Operation) {
 		err = p.startExpandOperation(*ctx)
 		if err != nil {
 			return trace.Wrap(err)
 		}
 	}
+	if isBatchOperation(ctx.Operation) {
+		p.sendMessage("Waiting for the remaining nodes in the batch to join")
+	}
 
 	install.PollProgress(p.Context, p.send, ctx.Operator, ctx.Operation.Key(), p.agent.Done())
 	return nil
@@ -41,8 +41,10 @@ func NewAgentStart(p fsm.ExecutorParams, operator ops.Operator) (*agentStartExec
 		FieldLogger: logrus.WithFields(logrus.Fields{
 			constants.FieldPhase: p.Phase.ID,
 		}),
-		Key:      opKey(p.Plan),
-		Operator: operator,
+		Key:       opKey(p.Plan),
+		Operator:  operator,
+		Component: ops.LogComponentAgent,
+		Phase:     p.Phase.ID,
 	}
 	return &agentStartExecutor{
 		FieldLogger:    logger,
@@ -127,8 +129,10 @@ func NewAgentStop(p fsm.ExecutorParams, operator ops.Operator, packages pack.Pac
 		FieldLogger: logrus.WithFields(logrus.Fields{
 			constants.FieldPhase: p.Phase.ID,
 		}),
-		Key:      opKey(p.Plan),
-		Operator: operator,
+		Key:       opKey(p.Plan),
+		Operator:  operator,
+		Component: ops.LogComponentAgent,
+		Phase:     p.Phase.ID,
 	}
 	credentials, err := rpc.ClientCredentialsFromPackage(packages, loc.RPCSecrets)
 	if err != nil {
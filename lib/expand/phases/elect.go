@@ -38,9 +38,11 @@ func NewElect(p fsm.ExecutorParams, operator ops.Operator) (fsm.PhaseExecutor, e
 			constants.FieldAdvertiseIP: p.Phase.Data.Server.AdvertiseIP,
 			constants.FieldHostname:    p.Phase.Data.Server.Hostname,
 		}),
-		Key:      opKey(p.Plan),
-		Operator: operator,
-		Server:   p.Phase.Data.Server,
+		Key:       opKey(p.Plan),
+		Operator:  operator,
+		Server:    p.Phase.Data.Server,
+		Component: ops.LogComponentElection,
+		Phase:     p.Phase.ID,
 	}
 	return &electExecutor{
 		FieldLogger:    logger,
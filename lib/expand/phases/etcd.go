@@ -67,9 +67,11 @@ func NewEtcd(p fsm.ExecutorParams, operator ops.Operator, runner fsm.AgentReposi
 		FieldLogger: logrus.WithFields(logrus.Fields{
 			constants.FieldPhase: p.Phase.ID,
 		}),
-		Key:      opKey(p.Plan),
-		Operator: operator,
-		Server:   p.Phase.Data.Server,
+		Key:       opKey(p.Plan),
+		Operator:  operator,
+		Server:    p.Phase.Data.Server,
+		Component: ops.LogComponentEtcd,
+		Phase:     p.Phase.ID,
 	}
 	return &etcdExecutor{
 		FieldLogger:    logger,
@@ -205,9 +207,11 @@ func NewEtcdBackup(p fsm.ExecutorParams, operator ops.Operator, runner fsm.Agent
 		FieldLogger: logrus.WithFields(logrus.Fields{
 			constants.FieldPhase: p.Phase.ID,
 		}),
-		Key:      opKey(p.Plan),
-		Operator: operator,
-		Server:   p.Phase.Data.Server,
+		Key:       opKey(p.Plan),
+		Operator:  operator,
+		Server:    p.Phase.Data.Server,
+		Component: ops.LogComponentEtcd,
+		Phase:     p.Phase.ID,
 	}
 	return &etcdBackupExecutor{
 		FieldLogger:    logger,
@@ -40,9 +40,11 @@ func NewWaitPlanet(p fsm.ExecutorParams, operator ops.Operator) (*waitPlanetExec
 		FieldLogger: logrus.WithFields(logrus.Fields{
 			constants.FieldPhase: p.Phase.ID,
 		}),
-		Key:      opKey(p.Plan),
-		Operator: operator,
-		Server:   p.Phase.Data.Server,
+		Key:       opKey(p.Plan),
+		Operator:  operator,
+		Server:    p.Phase.Data.Server,
+		Component: ops.LogComponentPlanet,
+		Phase:     p.Phase.ID,
 	}
 	return &waitPlanetExecutor{
 		FieldLogger:    logger,
@@ -109,9 +111,11 @@ func NewWaitK8s(p fsm.ExecutorParams, operator ops.Operator, dnsConfig storage.D
 		FieldLogger: logrus.WithFields(logrus.Fields{
 			constants.FieldPhase: p.Phase.ID,
 		}),
-		Key:      opKey(p.Plan),
-		Operator: operator,
-		Server:   p.Phase.Data.Server,
+		Key:       opKey(p.Plan),
+		Operator:  operator,
+		Server:    p.Phase.Data.Server,
+		Component: ops.LogComponentKubernetes,
+		Phase:     p.Phase.ID,
 	}
 	return &waitK8sExecutor{
 		FieldLogger:    logger,
@@ -60,6 +60,12 @@ func (p *Peer) getOperationPlan(ctx operationContext) (*storage.OperationPlan, e
 	// have cluster controller configure packages for the joining node
 	builder.AddConfigurePhase(plan)
 
+	// install bundled OS packages missing on the joining node
+	profile, err := builder.Application.Manifest.NodeProfiles.ByName(builder.JoiningNode.Role)
+	if err == nil && len(profile.Requirements.OSPackages) != 0 {
+		builder.AddOSPackagesPhase(plan)
+	}
+
 	// bootstrap local state on the joining node
 	builder.AddBootstrapPhase(plan)
 
@@ -0,0 +1,275 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package firewall
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"strings"
+
+	"github.com/gravitational/trace"
+)
+
+const (
+	// firewalldService is the firewalld service with gravity ports
+	firewalldService = "gravity"
+	// firewalldTrustedZone is the firewalld zone trusted subnets are added to
+	firewalldTrustedZone = "trusted"
+	// chainName is the iptables and nftables chain with gravity rules
+	chainName = "GRAVITY-INPUT"
+	// nftablesFamily and nftablesTable identify the nftables table
+	// with the input filter chain
+	nftablesFamily = "inet"
+	nftablesTable  = "filter"
+	// nftablesInputChain is the nftables input filter chain
+	nftablesInputChain = "input"
+)
+
+// firewalld keeps gravity ports in a dedicated firewalld service
+// enabled in the default zone
+type firewalld struct{}
+
+func (firewalld) name() string { return BackendFirewalld }
+
+func (r firewalld) apply(ctx context.Context, config Config) error {
+	zone, err := r.defaultZone(ctx, config.Runner)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if err := r.removeService(ctx, config.Runner, zone); err != nil {
+		return trace.Wrap(err)
+	}
+	commands := [][]string{
+		{"--permanent", "--new-service=" + firewalldService},
+		{"--permanent", "--service=" + firewalldService, "--set-description=Ports used by gravity cluster"},
+	}
+	for _, rule := range config.Rules {
+		commands = append(commands, []string{"--permanent", "--service=" + firewalldService, "--add-port=" + rule.String()})
+	}
+	commands = append(commands, []string{"--permanent", "--zone=" + zone, "--add-service=" + firewalldService})
+	if subnets := sortedSubnets(config.TrustedSubnets); len(subnets) != 0 {
+		for _, subnet := range subnets {
+			commands = append(commands, []string{"--permanent", "--zone=" + firewalldTrustedZone, "--add-source=" + subnet})
+		}
+		commands = append(commands, []string{"--permanent", "--zone=" + firewalldTrustedZone, "--add-masquerade"})
+	}
+	commands = append(commands, []string{"--reload"})
+	for _, args := range commands {
+		if _, err := config.Runner.Run(ctx, "firewall-cmd", args...); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+func (r firewalld) remove(ctx context.Context, config Config) error {
+	zone, err := r.defaultZone(ctx, config.Runner)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if err := r.removeService(ctx, config.Runner, zone); err != nil {
+		return trace.Wrap(err)
+	}
+	for _, subnet := range sortedSubnets(config.TrustedSubnets) {
+		config.Runner.Run(ctx, "firewall-cmd", "--permanent", "--zone="+firewalldTrustedZone, "--remove-source="+subnet)
+	}
+	_, err = config.Runner.Run(ctx, "firewall-cmd", "--reload")
+	return trace.Wrap(err)
+}
+
+// removeService removes the gravity service from the zone and deletes it
+// if it exists
+func (firewalld) removeService(ctx context.Context, runner CommandRunner, zone string) error {
+	out, err := runner.Run(ctx, "firewall-cmd", "--permanent", "--get-services")
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if !containsField(out, firewalldService) {
+		return nil
+	}
+	out, err = runner.Run(ctx, "firewall-cmd", "--permanent", "--zone="+zone, "--list-services")
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if containsField(out, firewalldService) {
+		_, err = runner.Run(ctx, "firewall-cmd", "--permanent", "--zone="+zone, "--remove-service="+firewalldService)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	_, err = runner.Run(ctx, "firewall-cmd", "--permanent", "--delete-service="+firewalldService)
+	return trace.Wrap(err)
+}
+
+func (firewalld) defaultZone(ctx context.Context, runner CommandRunner) (string, error) {
+	out, err := runner.Run(ctx, "firewall-cmd", "--get-default-zone")
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	zone := strings.TrimSpace(string(out))
+	if zone == "" {
+		return "", trace.NotFound("firewalld default zone is not set")
+	}
+	return zone, nil
+}
+
+// iptables keeps gravity rules in a dedicated chain jumped to from INPUT
+type iptables struct{}
+
+func (iptables) name() string { return BackendIptables }
+
+func (r iptables) apply(ctx context.Context, config Config) error {
+	if _, err := config.Runner.Run(ctx, "iptables", "-n", "-L", chainName); err != nil {
+		if _, err := config.Runner.Run(ctx, "iptables", "-N", chainName); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	commands := [][]string{{"-F", chainName}}
+	for _, rule := range config.Rules {
+		commands = append(commands, []string{"-A", chainName, "-p", rule.Protocol,
+			"--dport", rule.portRange(":"), "-j", "ACCEPT"})
+	}
+	for _, subnet := range sortedSubnets(config.TrustedSubnets) {
+		if isIPv6(subnet) {
+			continue
+		}
+		commands = append(commands, []string{"-A", chainName, "-s", subnet, "-j", "ACCEPT"})
+	}
+	if _, err := config.Runner.Run(ctx, "iptables", "-C", "INPUT", "-j", chainName); err != nil {
+		commands = append(commands, []string{"-I", "INPUT", "1", "-j", chainName})
+	}
+	for _, args := range commands {
+		if _, err := config.Runner.Run(ctx, "iptables", args...); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+func (r iptables) remove(ctx context.Context, config Config) error {
+	if _, err := config.Runner.Run(ctx, "iptables", "-n", "-L", chainName); err != nil {
+		return nil
+	}
+	var commands [][]string
+	if _, err := config.Runner.Run(ctx, "iptables", "-C", "INPUT", "-j", chainName); err == nil {
+		commands = append(commands, []string{"-D", "INPUT", "-j", chainName})
+	}
+	commands = append(commands, []string{"-F", chainName}, []string{"-X", chainName})
+	for _, args := range commands {
+		if _, err := config.Runner.Run(ctx, "iptables", args...); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// nftables keeps gravity rules in a dedicated chain of the inet filter
+// table jumped to from its input chain
+type nftables struct{}
+
+func (nftables) name() string { return BackendNftables }
+
+func (r nftables) apply(ctx context.Context, config Config) error {
+	out, err := config.Runner.Run(ctx, "nft", "-a", "list", "chain", nftablesFamily, nftablesTable, nftablesInputChain)
+	if err != nil {
+		config.Warnf("No %v %v %v chain found, nothing filters incoming traffic: %v.",
+			nftablesFamily, nftablesTable, nftablesInputChain, err)
+		return nil
+	}
+	commands := [][]string{
+		{"add", "chain", nftablesFamily, nftablesTable, chainName},
+		{"flush", "chain", nftablesFamily, nftablesTable, chainName},
+	}
+	for _, rule := range config.Rules {
+		commands = append(commands, []string{"add", "rule", nftablesFamily, nftablesTable, chainName,
+			rule.Protocol, "dport", rule.Ports(), "accept"})
+	}
+	for _, subnet := range sortedSubnets(config.TrustedSubnets) {
+		family := "ip"
+		if isIPv6(subnet) {
+			family = "ip6"
+		}
+		commands = append(commands, []string{"add", "rule", nftablesFamily, nftablesTable, chainName,
+			family, "saddr", subnet, "accept"})
+	}
+	if len(jumpHandles(out)) == 0 {
+		commands = append(commands, []string{"insert", "rule", nftablesFamily, nftablesTable,
+			nftablesInputChain, "jump", chainName})
+	}
+	for _, args := range commands {
+		if _, err := config.Runner.Run(ctx, "nft", args...); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+func (r nftables) remove(ctx context.Context, config Config) error {
+	out, err := config.Runner.Run(ctx, "nft", "-a", "list", "chain", nftablesFamily, nftablesTable, nftablesInputChain)
+	if err != nil {
+		return nil
+	}
+	var commands [][]string
+	for _, handle := range jumpHandles(out) {
+		commands = append(commands, []string{"delete", "rule", nftablesFamily, nftablesTable,
+			nftablesInputChain, "handle", handle})
+	}
+	if _, err := config.Runner.Run(ctx, "nft", "list", "chain", nftablesFamily, nftablesTable, chainName); err == nil {
+		commands = append(commands,
+			[]string{"flush", "chain", nftablesFamily, nftablesTable, chainName},
+			[]string{"delete", "chain", nftablesFamily, nftablesTable, chainName})
+	}
+	for _, args := range commands {
+		if _, err := config.Runner.Run(ctx, "nft", args...); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// jumpHandles returns handles of the rules jumping to the gravity chain
+// in the output of "nft -a list chain"
+func jumpHandles(out []byte) (handles []string) {
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		for i := 0; i+1 < len(fields); i++ {
+			if fields[i] == "jump" && fields[i+1] == chainName {
+				if handle := fields[len(fields)-1]; len(fields) > 2 && fields[len(fields)-2] == "handle" {
+					handles = append(handles, handle)
+				}
+				break
+			}
+		}
+	}
+	return handles
+}
+
+// containsField returns true if the whitespace-separated output contains the field
+func containsField(out []byte, field string) bool {
+	for _, f := range strings.Fields(string(out)) {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+func isIPv6(subnet string) bool {
+	return strings.Contains(subnet, ":")
+}
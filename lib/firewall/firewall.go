@@ -0,0 +1,390 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package firewall opens the ports required by gravity, planet and the
+// application node profiles in the host firewall.
+//
+// The rules are kept in a dedicated firewalld service, iptables chain or
+// nftables chain owned by gravity so they can be reconciled on every
+// install, join and upgrade without touching the rules managed by the
+// operator.
+package firewall
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/schema"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// BackendFirewalld manages the rules with firewalld
+	BackendFirewalld = "firewalld"
+	// BackendNftables manages the rules with nftables
+	BackendNftables = "nftables"
+	// BackendIptables manages the rules with iptables
+	BackendIptables = "iptables"
+
+	// protocolTCP is the TCP protocol
+	protocolTCP = "tcp"
+	// protocolUDP is the UDP protocol
+	protocolUDP = "udp"
+)
+
+// Backends lists supported firewall backends in the order of detection
+var Backends = []string{BackendFirewalld, BackendNftables, BackendIptables}
+
+// Rule allows incoming traffic to a range of ports
+type Rule struct {
+	// Protocol is the port protocol, tcp or udp
+	Protocol string
+	// From is the first port of the range
+	From int
+	// To is the last port of the range
+	To int
+	// Description describes the service using the ports
+	Description string
+}
+
+// String returns the rule in the ports/protocol format, e.g. 3022-3025/tcp
+func (r Rule) String() string {
+	return fmt.Sprintf("%v/%v", r.Ports(), r.Protocol)
+}
+
+// Ports returns the port range of the rule, e.g. 3022-3025
+func (r Rule) Ports() string {
+	return r.portRange("-")
+}
+
+func (r Rule) portRange(separator string) string {
+	if r.From == r.To {
+		return strconv.Itoa(r.From)
+	}
+	return fmt.Sprintf("%v%v%v", r.From, separator, r.To)
+}
+
+// ClusterRules returns the rules for the ports gravity and planet use on
+// every cluster node. vxlanPort is the overlay network port, the default
+// port is used if it is 0
+func ClusterRules(vxlanPort int) []Rule {
+	if vxlanPort == 0 {
+		vxlanPort = defaults.VxlanPort
+	}
+	return []Rule{
+		tcp(53, 53, "Internal cluster DNS"),
+		udp(53, 53, "Internal cluster DNS"),
+		udp(vxlanPort, vxlanPort, "Overlay network"),
+		tcp(7373, 7373, "Serf peer to peer"),
+		tcp(7496, 7496, "Serf peer to peer"),
+		tcp(7575, 7575, "Cluster status gRPC API"),
+		tcp(2379, 2380, "Etcd server communications"),
+		tcp(4001, 4001, "Etcd server communications"),
+		tcp(7001, 7001, "Etcd server communications"),
+		tcp(6443, 6443, "Kubernetes API server"),
+		tcp(10248, 10250, "Kubernetes components"),
+		tcp(10255, 10255, "Kubernetes components"),
+		tcp(30000, 32767, "Kubernetes node ports"),
+		udp(30000, 32767, "Kubernetes node ports"),
+		tcp(5000, 5000, "Docker registry"),
+		tcp(3022, 3025, "Teleport SSH control plane"),
+		tcp(3080, 3080, "Teleport web UI"),
+		tcp(3008, 3012, "Internal gravity services"),
+		tcp(32009, 32009, "Gravity cluster UI"),
+	}
+}
+
+// ProfileRules returns the rules for the ports required by the node profile
+// in the application manifest
+func ProfileRules(profile schema.NodeProfile) (rules []Rule, err error) {
+	description := fmt.Sprintf("Node profile %v", profile.Name)
+	for _, port := range profile.Requirements.Network.Ports {
+		if port.Protocol != protocolTCP && port.Protocol != protocolUDP {
+			return nil, trace.BadParameter("unknown protocol for port: %q", port.Protocol)
+		}
+		for _, ranges := range port.Ranges {
+			for _, portRange := range strings.Split(ranges, ",") {
+				from, to, err := parseRange(portRange)
+				if err != nil {
+					return nil, trace.Wrap(err)
+				}
+				rules = append(rules, Rule{
+					Protocol:    port.Protocol,
+					From:        from,
+					To:          to,
+					Description: description,
+				})
+			}
+		}
+	}
+	return rules, nil
+}
+
+// Rules returns the rules for a node with the specified profile: the
+// cluster rules followed by the profile rules not covered by them
+func Rules(manifest schema.Manifest, profileName string, vxlanPort int) ([]Rule, error) {
+	profile, err := manifest.NodeProfiles.ByName(profileName)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	profileRules, err := ProfileRules(*profile)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	rules := ClusterRules(vxlanPort)
+	for _, rule := range profileRules {
+		if !covered(rules, rule) {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+// NodeConfig returns the firewall configuration of a node with the specified
+// profile. vars are the install operation variables with the overlay network
+// port and the pod and service subnets. Returns nil if the manifest does not
+// enable firewall management
+func NodeConfig(manifest schema.Manifest, profileName string, vars storage.OnPremVariables) (*Config, error) {
+	options := manifest.SystemOptions.ManagedFirewall()
+	if options == nil {
+		return nil, nil
+	}
+	rules, err := Rules(manifest, profileName, vars.VxlanPort)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	podSubnet, serviceSubnet := vars.PodCIDR, vars.ServiceCIDR
+	if podSubnet == "" {
+		podSubnet = defaults.PodSubnet
+	}
+	if serviceSubnet == "" {
+		serviceSubnet = defaults.ServiceSubnet
+	}
+	return &Config{
+		Rules:          rules,
+		TrustedSubnets: []string{podSubnet, serviceSubnet},
+		Backend:        options.Backend,
+	}, nil
+}
+
+// Config describes the firewall configuration of a node
+type Config struct {
+	// Rules lists the ports to open
+	Rules []Rule
+	// TrustedSubnets lists networks, such as the pod and service subnets,
+	// traffic from which is allowed unconditionally
+	TrustedSubnets []string
+	// Backend optionally forces the firewall backend, detected if empty
+	Backend string
+	// Runner executes firewall commands
+	Runner CommandRunner
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+}
+
+// CheckAndSetDefaults validates the configuration and sets default values
+func (r *Config) CheckAndSetDefaults() error {
+	for _, rule := range r.Rules {
+		if rule.Protocol != protocolTCP && rule.Protocol != protocolUDP {
+			return trace.BadParameter("unsupported protocol %q in rule %v", rule.Protocol, rule)
+		}
+		if rule.From <= 0 || rule.To > 65535 || rule.From > rule.To {
+			return trace.BadParameter("invalid port range in rule %v", rule)
+		}
+	}
+	if r.Backend != "" && !isSupported(r.Backend) {
+		return trace.BadParameter("unsupported firewall backend %q, supported are: %v",
+			r.Backend, Backends)
+	}
+	if r.Runner == nil {
+		r.Runner = execRunner{}
+	}
+	if r.FieldLogger == nil {
+		r.FieldLogger = logrus.WithField(trace.Component, "firewall")
+	}
+	return nil
+}
+
+// Apply opens the configured ports in the host firewall replacing
+// the rules applied previously. Returns the name of the backend used
+func Apply(ctx context.Context, config Config) (backend string, err error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return "", trace.Wrap(err)
+	}
+	b, err := getBackend(ctx, config)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	config.Infof("Applying %v firewall rules with %v.", len(config.Rules), b.name())
+	if err := b.apply(ctx, config); err != nil {
+		return "", trace.Wrap(err)
+	}
+	return b.name(), nil
+}
+
+// Remove removes the rules managed by gravity from the host firewall
+func Remove(ctx context.Context, config Config) error {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	b, err := getBackend(ctx, config)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	config.Infof("Removing firewall rules with %v.", b.name())
+	return trace.Wrap(b.remove(ctx, config))
+}
+
+// CommandRunner executes firewall management commands
+type CommandRunner interface {
+	// Run executes the command and returns its combined output
+	Run(ctx context.Context, name string, args ...string) ([]byte, error)
+}
+
+// backend manages gravity rules in a specific firewall implementation
+type backend interface {
+	// name returns the backend name
+	name() string
+	// apply replaces gravity rules with the configured ones
+	apply(context.Context, Config) error
+	// remove removes gravity rules
+	remove(context.Context, Config) error
+}
+
+// getBackend returns the configured backend or detects the one active
+// on the host
+func getBackend(ctx context.Context, config Config) (backend, error) {
+	if config.Backend != "" {
+		return newBackend(config.Backend), nil
+	}
+	name, err := Detect(ctx, config.Runner)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return newBackend(name), nil
+}
+
+// Detect returns the name of the firewall backend active on the host.
+// firewalld takes precedence as it manages nftables or iptables itself
+func Detect(ctx context.Context, runner CommandRunner) (string, error) {
+	if runner == nil {
+		runner = execRunner{}
+	}
+	if _, err := runner.Run(ctx, "firewall-cmd", "--state"); err == nil {
+		return BackendFirewalld, nil
+	}
+	if _, err := runner.Run(ctx, "nft", "list", "tables"); err == nil {
+		return BackendNftables, nil
+	}
+	if _, err := runner.Run(ctx, "iptables", "-n", "-L", "INPUT"); err == nil {
+		return BackendIptables, nil
+	}
+	return "", trace.NotFound("no supported firewall found, supported are: %v", Backends)
+}
+
+func newBackend(name string) backend {
+	switch name {
+	case BackendFirewalld:
+		return firewalld{}
+	case BackendNftables:
+		return nftables{}
+	default:
+		return iptables{}
+	}
+}
+
+func isSupported(name string) bool {
+	for _, backend := range Backends {
+		if backend == name {
+			return true
+		}
+	}
+	return false
+}
+
+// covered returns true if the rule's port range is contained in one of the rules
+func covered(rules []Rule, rule Rule) bool {
+	for _, r := range rules {
+		if r.Protocol == rule.Protocol && r.From <= rule.From && r.To >= rule.To {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedSubnets returns deduplicated non-empty subnets in a stable order
+func sortedSubnets(subnets []string) (result []string) {
+	seen := make(map[string]struct{})
+	for _, subnet := range subnets {
+		if _, ok := seen[subnet]; ok || subnet == "" {
+			continue
+		}
+		seen[subnet] = struct{}{}
+		result = append(result, subnet)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func parseRange(portRange string) (from, to int, err error) {
+	parts := strings.Split(strings.TrimSpace(portRange), "-")
+	if len(parts) > 2 {
+		return 0, 0, trace.BadParameter("invalid port range %q", portRange)
+	}
+	from, err = strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return 0, 0, trace.BadParameter("port must be integer, got: %v", parts[0])
+	}
+	to = from
+	if len(parts) == 2 {
+		to, err = strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return 0, 0, trace.BadParameter("port must be integer, got: %v", parts[1])
+		}
+	}
+	if from > to {
+		return 0, 0, trace.BadParameter("invalid port range %q", portRange)
+	}
+	return from, to, nil
+}
+
+func tcp(from, to int, description string) Rule {
+	return Rule{Protocol: protocolTCP, From: from, To: to, Description: description}
+}
+
+func udp(from, to int, description string) Rule {
+	return Rule{Protocol: protocolUDP, From: from, To: to, Description: description}
+}
+
+// execRunner executes commands on the host
+type execRunner struct{}
+
+// Run executes the command and returns its combined output
+func (execRunner) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	out, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+	if err != nil {
+		return out, trace.Wrap(err, "%v %v: %s", name, strings.Join(args, " "), out)
+	}
+	return out, nil
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package firewall
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/gravitational/gravity/lib/schema"
+
+	"github.com/gravitational/trace"
+	. "gopkg.in/check.v1"
+)
+
+func TestFirewall(t *testing.T) { TestingT(t) }
+
+type FirewallSuite struct{}
+
+var _ = Suite(&FirewallSuite{})
+
+func (s *FirewallSuite) TestRulesIncludeProfilePorts(c *C) {
+	manifest := schema.Manifest{
+		NodeProfiles: schema.NodeProfiles{{
+			Name: "node",
+			Requirements: schema.Requirements{
+				Network: schema.Network{
+					Ports: []schema.Port{
+						{Protocol: "tcp", Ranges: []string{"8080", "3023", "9000-9010,9100"}},
+						{Protocol: "udp", Ranges: []string{"30500"}},
+					},
+				},
+			},
+		}},
+	}
+	rules, err := Rules(manifest, "node", 9473)
+	c.Assert(err, IsNil)
+	var added []string
+	for _, rule := range rules[len(ClusterRules(0)):] {
+		added = append(added, rule.String())
+	}
+	// 3023/tcp and 30500/udp are already covered by the cluster rules
+	c.Assert(added, DeepEquals, []string{"8080/tcp", "9000-9010/tcp", "9100/tcp"})
+	c.Assert(covered(rules, udp(9473, 9473, "")), Equals, true)
+
+	_, err = Rules(manifest, "db", 0)
+	c.Assert(err, NotNil)
+}
+
+func (s *FirewallSuite) TestIptablesApply(c *C) {
+	runner := newRunner(
+		"iptables -n -L GRAVITY-INPUT",
+		"iptables -C INPUT -j GRAVITY-INPUT",
+	)
+	backend, err := Apply(context.TODO(), Config{
+		Rules:          []Rule{tcp(3022, 3025, ""), udp(53, 53, "")},
+		TrustedSubnets: []string{"10.244.0.0/16", "", "10.244.0.0/16"},
+		Backend:        BackendIptables,
+		Runner:         runner,
+	})
+	c.Assert(err, IsNil)
+	c.Assert(backend, Equals, BackendIptables)
+	c.Assert(runner.commands, DeepEquals, []string{
+		"iptables -n -L GRAVITY-INPUT",
+		"iptables -N GRAVITY-INPUT",
+		"iptables -C INPUT -j GRAVITY-INPUT",
+		"iptables -F GRAVITY-INPUT",
+		"iptables -A GRAVITY-INPUT -p tcp --dport 3022:3025 -j ACCEPT",
+		"iptables -A GRAVITY-INPUT -p udp --dport 53 -j ACCEPT",
+		"iptables -A GRAVITY-INPUT -s 10.244.0.0/16 -j ACCEPT",
+		"iptables -I INPUT 1 -j GRAVITY-INPUT",
+	})
+}
+
+func (s *FirewallSuite) TestNftablesReappliesWithoutDuplicateJump(c *C) {
+	runner := newRunner()
+	runner.outputs["nft -a list chain inet filter input"] = `table inet filter {
+	chain input { # handle 1
+		type filter hook input priority 0; policy drop;
+		jump GRAVITY-INPUT # handle 7
+		ct state established,related accept # handle 3
+	}
+}`
+	_, err := Apply(context.TODO(), Config{
+		Rules:   []Rule{tcp(6443, 6443, "")},
+		Backend: BackendNftables,
+		Runner:  runner,
+	})
+	c.Assert(err, IsNil)
+	c.Assert(runner.commands, DeepEquals, []string{
+		"nft -a list chain inet filter input",
+		"nft add chain inet filter GRAVITY-INPUT",
+		"nft flush chain inet filter GRAVITY-INPUT",
+		"nft add rule inet filter GRAVITY-INPUT tcp dport 6443 accept",
+	})
+
+	runner.commands = nil
+	err = Remove(context.TODO(), Config{Backend: BackendNftables, Runner: runner})
+	c.Assert(err, IsNil)
+	c.Assert(runner.commands, DeepEquals, []string{
+		"nft -a list chain inet filter input",
+		"nft list chain inet filter GRAVITY-INPUT",
+		"nft delete rule inet filter input handle 7",
+		"nft flush chain inet filter GRAVITY-INPUT",
+		"nft delete chain inet filter GRAVITY-INPUT",
+	})
+}
+
+func (s *FirewallSuite) TestDetectsBackend(c *C) {
+	backend, err := Detect(context.TODO(), newRunner("firewall-cmd --state"))
+	c.Assert(err, IsNil)
+	c.Assert(backend, Equals, BackendNftables)
+
+	_, err = Detect(context.TODO(), newRunner("firewall-cmd --state",
+		"nft list tables", "iptables -n -L INPUT"))
+	c.Assert(trace.IsNotFound(err), Equals, true)
+}
+
+func (s *FirewallSuite) TestValidatesConfig(c *C) {
+	for _, config := range []Config{
+		{Rules: []Rule{{Protocol: "sctp", From: 1, To: 1}}},
+		{Rules: []Rule{tcp(10, 5, "")}},
+		{Backend: "pf"},
+	} {
+		_, err := Apply(context.TODO(), config)
+		c.Assert(trace.IsBadParameter(err), Equals, true, Commentf("config %v", config))
+	}
+}
+
+// recordingRunner records executed commands and fails the configured ones
+type recordingRunner struct {
+	commands []string
+	failing  map[string]bool
+	outputs  map[string]string
+}
+
+func newRunner(failing ...string) *recordingRunner {
+	runner := &recordingRunner{
+		failing: make(map[string]bool),
+		outputs: make(map[string]string),
+	}
+	for _, command := range failing {
+		runner.failing[command] = true
+	}
+	return runner
+}
+
+func (r *recordingRunner) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	command := strings.Join(append([]string{name}, args...), " ")
+	r.commands = append(r.commands, command)
+	if r.failing[command] {
+		return nil, trace.BadParameter("%v failed", command)
+	}
+	return []byte(r.outputs[command]), nil
+}
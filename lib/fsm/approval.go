@@ -0,0 +1,107 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fsm
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+	"github.com/pborman/uuid"
+)
+
+// Phases can be gated with an operator approval: the execution of the plan
+// stops before a phase that requires approval until the phase is approved,
+// so the plan can run unattended through safe phases but pause for a human
+// before e.g. shutting down etcd.
+//
+// Approval gates are recorded in the plan changelog and are thus
+// synchronized the same way as phase state changes. Readers unaware of
+// approval gates, e.g. older gravity versions, take any changelog entry for
+// the state change of its phase, so approval entries carry the current state
+// of the phase to leave the state they resolve unchanged.
+
+// NewRequireApprovalChange returns the plan change that marks the specified
+// phase of the plan as requiring approval
+func NewRequireApprovalChange(plan storage.OperationPlan, phaseID string) (*storage.PlanChange, error) {
+	phase, err := FindPhase(&plan, phaseID)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if phase.IsCompleted() {
+		return nil, trace.BadParameter("phase %q has already been completed", phaseID)
+	}
+	if phase.IsInProgress() {
+		return nil, trace.BadParameter("phase %q is in progress", phaseID)
+	}
+	if phase.IsAwaitingApproval() {
+		return nil, trace.AlreadyExists("phase %q already requires approval", phaseID)
+	}
+	return newApprovalChange(plan, *phase, storage.PhaseApproval{Required: true}), nil
+}
+
+// NewApproveChange returns the plan change that approves the execution
+// of the specified phase of the plan by the specified user
+func NewApproveChange(plan storage.OperationPlan, phaseID, user string) (*storage.PlanChange, error) {
+	phase, err := FindPhase(&plan, phaseID)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if !phase.IsAwaitingApproval() {
+		return nil, trace.BadParameter("phase %q is not awaiting approval", phaseID)
+	}
+	return newApprovalChange(plan, *phase, storage.PhaseApproval{
+		Required:   true,
+		ApprovedBy: user,
+		Approved:   time.Now().UTC(),
+	}), nil
+}
+
+// IsAwaitingApproval returns true if the specified error indicates that
+// the execution has stopped before a phase that requires approval
+func IsAwaitingApproval(err error) bool {
+	_, ok := trace.Unwrap(err).(*ApprovalRequiredError)
+	return ok
+}
+
+func newApprovalChange(plan storage.OperationPlan, phase storage.OperationPhase, approval storage.PhaseApproval) *storage.PlanChange {
+	return &storage.PlanChange{
+		ID:          uuid.New(),
+		ClusterName: plan.ClusterName,
+		OperationID: plan.OperationID,
+		PhaseID:     phase.ID,
+		NewState:    phase.GetState(),
+		Created:     time.Now().UTC(),
+		Error:       phase.Error,
+		Approval:    &approval,
+	}
+}
+
+// ApprovalRequiredError is returned when the execution reaches
+// the phase that requires approval
+type ApprovalRequiredError struct {
+	// PhaseID is the ID of the phase that requires approval
+	PhaseID string
+}
+
+// Error returns the text representation of this error
+func (r *ApprovalRequiredError) Error() string {
+	return fmt.Sprintf("phase %q requires approval, approve it with "+
+		"'gravity plan approve %v' and resume the operation", r.PhaseID, r.PhaseID)
+}
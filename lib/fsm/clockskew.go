@@ -0,0 +1,71 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fsm
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/defaults"
+	rpcclient "github.com/gravitational/gravity/lib/rpc/client"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+// runClockSkewHook executes the time synchronization command configured with
+// the constants.ClockSkewHookEnvVar environment variable after a clock skew
+// has been detected when connecting to a remote agent.
+// Returns true if the hook has been configured and completed successfully
+func runClockSkewHook(ctx context.Context, skewErr rpcclient.ClockSkewError, logger logrus.FieldLogger) bool {
+	hook := os.Getenv(constants.ClockSkewHookEnvVar)
+	if hook == "" {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(ctx, defaults.ClockSkewHookTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", hook)
+	cmd.Env = append(os.Environ(), clockSkewHookEnv(skewErr)...)
+	logger = logger.WithField("hook", hook)
+	logger.Infof("Clock skew detected, running time synchronization hook: %v.", skewErr.Error())
+	var out bytes.Buffer
+	if err := utils.ExecL(cmd, &out, logger); err != nil {
+		logger.WithError(err).Warnf("Time synchronization hook failed: %s.", out.Bytes())
+		return false
+	}
+	return true
+}
+
+// clockSkewHookEnv returns the environment for the clock skew hook
+// describing the detected skew
+func clockSkewHookEnv(skewErr rpcclient.ClockSkewError) []string {
+	node := skewErr.ServerAddr
+	if host, _, err := net.SplitHostPort(node); err == nil {
+		node = host
+	}
+	return []string{
+		fmt.Sprintf("%v=%v", constants.ClockSkewNodeEnvVar, node),
+		fmt.Sprintf("%v=%v", constants.ClockSkewLocalEnvVar, strconv.FormatBool(skewErr.Local)),
+		fmt.Sprintf("%v=%v", constants.ClockSkewSecondsEnvVar, int64(skewErr.Skew.Seconds())),
+	}
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package fsm implements the machinery to execute and roll back operation plans.
+
+An operation plan (storage.OperationPlan) is a tree of phases. Leaf phases
+are executed by phase executors (PhaseExecutor), either locally or on the
+node the phase is bound to. Each phase state change is recorded so that
+an interrupted operation can be resumed, retried or rolled back phase by phase.
+
+The package surface intended for operation implementations is:
+
+  - Engine is the backend the state machine (FSM) uses to load the plan,
+    record phase state changes, resolve executors and run phases on remote nodes.
+  - OperatorEngine is the Engine that keeps the plan in the cluster operator
+    service. NewOperatorMachine creates a state machine on top of it.
+  - Registry maps phase IDs to executor factories (FSMSpecFunc).
+  - FindPhase, FlattenPlan, IsCompleted and related helpers inspect plans.
+
+A new operation typically builds its plan, stores it with the operator
+and then creates a state machine with its own executors:
+
+	registry := fsm.NewRegistry()
+	registry.MustRegister("/checks", newChecksExecutor)
+	registry.MustRegister("/rotate", newRotateExecutor)
+	machine, err := fsm.NewOperatorMachine(fsm.OperatorEngineConfig{
+		Operator:  operator,
+		Operation: key,
+		Spec:      registry.GetExecutor,
+		Command:   []string{"rotate-certs"},
+	}, runner)
+	err = machine.ExecutePlan(ctx, progress, force)
+	err = machine.Complete(err)
+
+The Command is the gravity command that executes a single phase; it must
+accept the --phase and --force flags so that phases bound to other nodes
+can be executed there.
+*/
+package fsm
@@ -80,7 +80,7 @@ func printPhase(w io.Writer, phase storage.OperationPhase, indent int) {
 		marker,
 		formatName(phase.ID),
 		phase.Description,
-		formatState(phase.GetState()),
+		formatPhaseState(phase),
 		formatNode(phase),
 		formatRequires(phase.Requires),
 		formatTimestamp(phase.GetLastUpdateTime()))
@@ -115,6 +115,13 @@ func formatTimestamp(t time.Time) string {
 	return t.Format(constants.HumanDateFormat)
 }
 
+func formatPhaseState(phase storage.OperationPhase) string {
+	if phase.IsAwaitingApproval() && phase.IsUnstarted() {
+		return "Awaiting Approval"
+	}
+	return formatState(phase.GetState())
+}
+
 func formatState(state string) string {
 	switch state {
 	case storage.OperationPhaseStateUnstarted:
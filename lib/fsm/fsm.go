@@ -23,6 +23,7 @@ import (
 
 	"github.com/gravitational/gravity/lib/ops"
 	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/tracing"
 	"github.com/gravitational/gravity/lib/utils"
 
 	"github.com/gravitational/trace"
@@ -71,6 +72,9 @@ type Params struct {
 	Force bool
 	// Progress is optional progress reporter
 	Progress utils.Progress
+	// Until optionally specifies the ID of the phase to stop the execution at.
+	// The phase itself is not executed
+	Until string
 }
 
 // CheckAndSetDefaults makes sure all required parameters are set
@@ -137,17 +141,38 @@ func New(config Config) (*FSM, error) {
 
 // ExecutePlan iterates over all phases of the plan and executes them in order
 func (f *FSM) ExecutePlan(ctx context.Context, progress utils.Progress, force bool) error {
+	return trace.Wrap(f.ExecutePlanUntil(ctx, progress, force, ""))
+}
+
+// ExecutePlanUntil iterates over phases of the plan and executes them in order
+// stopping right before the phase with the specified ID.
+// If until is empty, all phases of the plan are executed
+func (f *FSM) ExecutePlanUntil(ctx context.Context, progress utils.Progress, force bool, until string) error {
 	plan, err := f.GetPlan()
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	if until != "" {
+		if err := checkStopPhase(plan, until); err != nil {
+			return trace.Wrap(err)
+		}
+	}
 	for _, phase := range plan.Phases {
 		f.Debugf("Executing phase %q.", phase.ID)
 		err := f.ExecutePhase(ctx, Params{
 			PhaseID:  phase.ID,
 			Progress: progress,
 			Force:    force,
+			Until:    until,
 		})
+		if IsStopped(err) {
+			f.Infof("Stopped before phase %q.", until)
+			return nil
+		}
+		if IsAwaitingApproval(err) {
+			f.Infof("Paused: %v.", trace.Unwrap(err))
+			return trace.Wrap(err)
+		}
 		if err != nil {
 			return trace.Wrap(err, "failed to execute phase %q", phase.ID)
 		}
@@ -172,6 +197,12 @@ func (f *FSM) ExecutePhase(ctx context.Context, p Params) error {
 	if phase.IsCompleted() && !p.Force {
 		return nil
 	}
+	if phase.ID == p.Until {
+		return trace.Wrap(&stoppedError{phaseID: phase.ID})
+	}
+	if phase.IsAwaitingApproval() {
+		return trace.Wrap(&ApprovalRequiredError{PhaseID: phase.ID})
+	}
 	if phase.IsInProgress() && !(p.Force || phase.HasSubphases()) {
 		return trace.BadParameter(
 			"phase %q is in progress, use --force flag to force execution", phase.ID)
@@ -185,7 +216,10 @@ func (f *FSM) ExecutePhase(ctx context.Context, p Params) error {
 			return trace.Wrap(err)
 		}
 	}
+	ctx, span := tracing.StartSpan(ctx, fmt.Sprintf("phase %v", phase.ID),
+		phaseAttributes(*plan, *phase)...)
 	err = f.executePhase(ctx, p, *phase)
+	span.End(err)
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -259,6 +293,32 @@ func (f *FSM) RollbackPhase(ctx context.Context, p Params) error {
 	return nil
 }
 
+// RollbackPlan rolls back all phases of the plan that have been executed
+// in reverse order
+func (f *FSM) RollbackPlan(ctx context.Context, progress utils.Progress, force bool) error {
+	plan, err := f.GetPlan()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	phases := FlattenPlan(plan)
+	for i := len(phases) - 1; i >= 0; i-- {
+		phase := phases[i]
+		if phase.HasSubphases() || phase.IsUnstarted() || phase.IsRolledBack() {
+			continue
+		}
+		f.Debugf("Rolling back phase %q.", phase.ID)
+		err := f.RollbackPhase(ctx, Params{
+			PhaseID:  phase.ID,
+			Progress: progress,
+			Force:    force,
+		})
+		if err != nil {
+			return trace.Wrap(err, "failed to rollback phase %q", phase.ID)
+		}
+	}
+	return nil
+}
+
 // SetPreExec sets the hook that's called before phase execution
 func (f *FSM) SetPreExec(fn PhaseHookFn) {
 	f.preExecFn = fn
@@ -543,3 +603,65 @@ func (c StateChange) String() string {
 
 // RootPhase is the name of the top-level phase
 const RootPhase = "/"
+
+// IsStopped returns true if the specified error indicates that the execution
+// has reached the phase it was requested to stop at
+func IsStopped(err error) bool {
+	_, ok := trace.Unwrap(err).(*stoppedError)
+	return ok
+}
+
+// stoppedError is returned when the execution reaches the phase specified
+// with Params.Until
+type stoppedError struct {
+	phaseID string
+}
+
+// Error returns the text representation of this error
+func (r *stoppedError) Error() string {
+	return fmt.Sprintf("stopped before phase %q", r.phaseID)
+}
+
+// checkStopPhase makes sure that the execution of the specified plan
+// can be stopped at the phase with the specified ID
+func checkStopPhase(plan *storage.OperationPlan, phaseID string) error {
+	phase, err := FindPhase(plan, phaseID)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if phase.IsCompleted() {
+		return trace.BadParameter("phase %q has already been completed", phaseID)
+	}
+	if isConcurrent(plan.Phases, phaseID, false) {
+		return trace.BadParameter("phase %q is executed in parallel with other phases "+
+			"and cannot be stopped at, select one of its parent phases instead", phaseID)
+	}
+	return nil
+}
+
+// isConcurrent returns true if the phase with the specified ID is executed
+// concurrently with other phases, i.e. if any of its parent phases is parallel
+func isConcurrent(phases []storage.OperationPhase, phaseID string, parallel bool) bool {
+	for _, phase := range phases {
+		if phase.ID == phaseID {
+			return parallel
+		}
+		if isConcurrent(phase.Phases, phaseID, parallel || phase.Parallel) {
+			return true
+		}
+	}
+	return false
+}
+
+// phaseAttributes returns the trace span attributes describing the specified phase
+func phaseAttributes(plan storage.OperationPlan, phase storage.OperationPhase) []tracing.Attribute {
+	attrs := []tracing.Attribute{
+		tracing.String("operation.id", plan.OperationID),
+		tracing.String("operation.type", plan.OperationType),
+		tracing.String("phase.id", phase.ID),
+	}
+	if phase.Data != nil && phase.Data.Server != nil {
+		attrs = append(attrs, tracing.String("node", phase.Data.Server.Hostname))
+	}
+	return attrs
+}
@@ -0,0 +1,212 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fsm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+	. "gopkg.in/check.v1"
+)
+
+func TestFSM(t *testing.T) { TestingT(t) }
+
+type FSMSuite struct{}
+
+var _ = Suite(&FSMSuite{})
+
+func (s *FSMSuite) TestExecutePlanUntil(c *C) {
+	engine := newTestEngine(newTestPlan())
+	machine, err := New(Config{Engine: engine})
+	c.Assert(err, IsNil)
+
+	err = machine.ExecutePlanUntil(context.TODO(), nil, false, "/masters/node-2")
+	c.Assert(err, IsNil)
+	c.Assert(engine.executed, DeepEquals, []string{"/init", "/masters/node-1"})
+
+	err = machine.ExecutePlan(context.TODO(), nil, false)
+	c.Assert(err, IsNil)
+	c.Assert(engine.executed, DeepEquals, []string{
+		"/init", "/masters/node-1", "/masters/node-2", "/nodes/node-3", "/app"})
+}
+
+func (s *FSMSuite) TestExecutePlanUntilValidatesPhase(c *C) {
+	plan := newTestPlan()
+	plan.Phases[0].State = storage.OperationPhaseStateCompleted
+	machine, err := New(Config{Engine: newTestEngine(plan)})
+	c.Assert(err, IsNil)
+
+	for _, phaseID := range []string{"/unknown", "/init", "/nodes/node-3"} {
+		err = machine.ExecutePlanUntil(context.TODO(), nil, false, phaseID)
+		c.Assert(err, NotNil, Commentf(phaseID))
+	}
+}
+
+func (s *FSMSuite) TestRollbackPlan(c *C) {
+	engine := newTestEngine(newTestPlan())
+	machine, err := New(Config{Engine: engine})
+	c.Assert(err, IsNil)
+
+	err = machine.ExecutePlanUntil(context.TODO(), nil, false, "/nodes")
+	c.Assert(err, IsNil)
+
+	err = machine.RollbackPlan(context.TODO(), nil, false)
+	c.Assert(err, IsNil)
+	c.Assert(engine.rolledBack, DeepEquals, []string{"/masters/node-2", "/masters/node-1", "/init"})
+}
+
+func (s *FSMSuite) TestStopsBeforeApprovalGate(c *C) {
+	plan := newTestPlan()
+	change, err := NewRequireApprovalChange(*plan, "/masters/node-2")
+	c.Assert(err, IsNil)
+	plan = ResolvePlan(*plan, storage.PlanChangelog{*change})
+	engine := newTestEngine(plan)
+	machine, err := New(Config{Engine: engine})
+	c.Assert(err, IsNil)
+
+	err = machine.ExecutePlan(context.TODO(), nil, false)
+	c.Assert(IsAwaitingApproval(err), Equals, true, Commentf("%v", err))
+	c.Assert(engine.executed, DeepEquals, []string{"/init", "/masters/node-1"})
+
+	_, err = NewApproveChange(*plan, "/masters/node-1", "alice")
+	c.Assert(err, NotNil)
+	approve, err := NewApproveChange(*plan, "/masters/node-2", "alice")
+	c.Assert(err, IsNil)
+	engine.plan = ResolvePlan(*plan, storage.PlanChangelog{*change, *approve})
+	c.Assert(engine.plan.Phases[1].Phases[1].Approval.ApprovedBy, Equals, "alice")
+
+	err = machine.ExecutePlan(context.TODO(), nil, false)
+	c.Assert(err, IsNil)
+	c.Assert(engine.executed, DeepEquals, []string{
+		"/init", "/masters/node-1", "/masters/node-2", "/nodes/node-3", "/app"})
+}
+
+func (s *FSMSuite) TestApprovalKeepsPhaseState(c *C) {
+	plan := newTestPlan()
+	failed := storage.PlanChange{
+		ID:       "1",
+		PhaseID:  "/masters/node-2",
+		NewState: storage.OperationPhaseStateFailed,
+		Created:  time.Now().UTC(),
+		Error:    &trace.RawTrace{Message: "failure"},
+	}
+	plan = ResolvePlan(*plan, storage.PlanChangelog{failed})
+	change, err := NewRequireApprovalChange(*plan, "/masters/node-2")
+	c.Assert(err, IsNil)
+	c.Assert(change.NewState, Equals, storage.OperationPhaseStateFailed)
+
+	// readers unaware of approval gates resolve the same phase state
+	changelog := storage.PlanChangelog{failed, *change}
+	latest := changelog[len(changelog)-1]
+	c.Assert(latest.NewState, Equals, failed.NewState)
+	c.Assert(latest.Error, DeepEquals, failed.Error)
+
+	resolved := ResolvePlan(*plan, changelog)
+	phase, err := FindPhase(resolved, "/masters/node-2")
+	c.Assert(err, IsNil)
+	c.Assert(phase.IsFailed(), Equals, true)
+	c.Assert(phase.IsAwaitingApproval(), Equals, true)
+
+	_, err = NewRequireApprovalChange(*ResolvePlan(*plan, storage.PlanChangelog{{
+		ID:       "2",
+		PhaseID:  "/nodes/node-3",
+		NewState: storage.OperationPhaseStateInProgress,
+		Created:  time.Now().UTC(),
+	}}), "/nodes/node-3")
+	c.Assert(trace.IsBadParameter(err), Equals, true)
+}
+
+func newTestPlan() *storage.OperationPlan {
+	return &storage.OperationPlan{
+		Phases: []storage.OperationPhase{
+			{ID: "/init"},
+			{ID: "/masters", Phases: []storage.OperationPhase{
+				{ID: "/masters/node-1"},
+				{ID: "/masters/node-2"},
+			}},
+			{ID: "/nodes", Parallel: true, Phases: []storage.OperationPhase{
+				{ID: "/nodes/node-3"},
+			}},
+			{ID: "/app"},
+		},
+	}
+}
+
+func newTestEngine(plan *storage.OperationPlan) *testEngine {
+	return &testEngine{plan: plan}
+}
+
+// testEngine is the FSM engine that keeps the plan in memory
+// and records executed phases
+type testEngine struct {
+	plan       *storage.OperationPlan
+	executed   []string
+	rolledBack []string
+}
+
+func (e *testEngine) GetExecutor(p ExecutorParams, remote Remote) (PhaseExecutor, error) {
+	return &testExecutor{
+		FieldLogger: logrus.WithField("phase", p.Phase.ID),
+		phaseID:     p.Phase.ID,
+		engine:      e,
+	}, nil
+}
+
+func (e *testEngine) ChangePhaseState(ctx context.Context, change StateChange) error {
+	phase, err := FindPhase(e.plan, change.Phase)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	phase.State = change.State
+	return nil
+}
+
+func (e *testEngine) GetPlan() (*storage.OperationPlan, error) {
+	return e.plan, nil
+}
+
+func (e *testEngine) RunCommand(context.Context, RemoteRunner, storage.Server, Params) error {
+	return trace.NotImplemented("not implemented")
+}
+
+func (e *testEngine) Complete(error) error {
+	return nil
+}
+
+type testExecutor struct {
+	logrus.FieldLogger
+	phaseID string
+	engine  *testEngine
+}
+
+func (r *testExecutor) PreCheck(context.Context) error  { return nil }
+func (r *testExecutor) PostCheck(context.Context) error { return nil }
+
+func (r *testExecutor) Rollback(context.Context) error {
+	r.engine.rolledBack = append(r.engine.rolledBack, r.phaseID)
+	return nil
+}
+
+func (r *testExecutor) Execute(context.Context) error {
+	r.engine.executed = append(r.engine.executed, r.phaseID)
+	return nil
+}
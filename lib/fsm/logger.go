@@ -38,6 +38,10 @@ type Logger struct {
 	Operator ops.Operator
 	// Server is the optional server that will be attached to log entries
 	Server *storage.Server
+	// Component is the optional component that will be attached to log entries
+	Component string
+	// Phase is the optional ID of the phase that will be attached to log entries
+	Phase string
 }
 
 // Debug logs a debug message
@@ -119,6 +123,8 @@ func (l *Logger) makeLogEntry(message, severity string) ops.LogEntry {
 		Severity:    severity,
 		Message:     message,
 		Server:      l.Server,
+		Component:   l.Component,
+		Phase:       l.Phase,
 		Created:     time.Now().UTC(),
 	}
 }
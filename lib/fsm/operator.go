@@ -0,0 +1,248 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fsm
+
+import (
+	"context"
+	"time"
+
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/trace"
+	"github.com/pborman/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// OperatorEngineConfig configures the operator-backed engine
+type OperatorEngineConfig struct {
+	// Operator is the cluster operator service that stores the operation,
+	// its plan and the plan changelog
+	Operator ops.Operator
+	// Operation is the key of the operation the engine is executing
+	Operation ops.SiteOperationKey
+	// Spec resolves plan phases to executors.
+	// A *Registry can be used via its GetExecutor method
+	Spec FSMSpecFunc
+	// Command specifies the gravity command (without the binary name) that
+	// executes a single phase of this operation, e.g. ["system", "rolling-restart"].
+	// The engine appends the phase and force flags when running it on remote nodes
+	Command []string
+	// Store optionally keeps the operation plan and its changelog somewhere
+	// other than the operator service, e.g. in a node-local backend for
+	// operations that have to survive the cluster backend going down
+	Store PlanStore
+	// FieldLogger is the logger
+	logrus.FieldLogger
+}
+
+// CheckAndSetDefaults validates the config and sets defaults
+func (r *OperatorEngineConfig) CheckAndSetDefaults() error {
+	if r.Operator == nil {
+		return trace.BadParameter("operator service is required")
+	}
+	if r.Operation.OperationID == "" {
+		return trace.BadParameter("operation key is required")
+	}
+	if r.Spec == nil {
+		return trace.BadParameter("executor spec is required")
+	}
+	if len(r.Command) == 0 {
+		return trace.BadParameter("phase command is required")
+	}
+	if r.FieldLogger == nil {
+		r.FieldLogger = logrus.WithField(trace.Component, "fsm")
+	}
+	if r.Store == nil {
+		r.Store = &operatorStore{
+			operator:    r.Operator,
+			operation:   r.Operation,
+			FieldLogger: r.FieldLogger,
+		}
+	}
+	return nil
+}
+
+// PlanStore keeps the operation plan and records the state
+// changes of its phases
+type PlanStore interface {
+	// GetPlan returns the most up-to-date operation plan
+	GetPlan() (*storage.OperationPlan, error)
+	// ChangePhaseState records the phase state change
+	ChangePhaseState(context.Context, StateChange) error
+}
+
+// NewOperatorEngine returns a new engine that keeps the operation plan
+// and its state in the cluster operator service.
+//
+// It implements everything an operation-specific state machine needs besides
+// the phase executors themselves, so new operations only have to provide
+// the plan and the executors
+func NewOperatorEngine(config OperatorEngineConfig) (*OperatorEngine, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &OperatorEngine{
+		OperatorEngineConfig: config,
+	}, nil
+}
+
+// NewOperatorMachine returns a new state machine backed by an operator engine
+// created with the provided config. The machine reports phase progress
+// to the operator before executing each phase
+func NewOperatorMachine(config OperatorEngineConfig, runner RemoteRunner) (*FSM, error) {
+	engine, err := NewOperatorEngine(config)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	machine, err := New(Config{
+		Engine: engine,
+		Runner: runner,
+		Logger: engine.FieldLogger,
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	machine.SetPreExec(engine.UpdateProgress)
+	return machine, nil
+}
+
+// OperatorEngine is the Engine that stores the operation plan in the
+// cluster operator service
+type OperatorEngine struct {
+	// OperatorEngineConfig is the engine configuration
+	OperatorEngineConfig
+}
+
+// GetExecutor returns the appropriate phase executor based on the
+// provided parameters
+func (r *OperatorEngine) GetExecutor(params ExecutorParams, remote Remote) (PhaseExecutor, error) {
+	return r.Spec(params, remote)
+}
+
+// ChangePhaseState records the phase state change in the plan store
+func (r *OperatorEngine) ChangePhaseState(ctx context.Context, change StateChange) error {
+	return trace.Wrap(r.Store.ChangePhaseState(ctx, change))
+}
+
+// GetPlan returns the most up-to-date operation plan from the plan store
+func (r *OperatorEngine) GetPlan() (*storage.OperationPlan, error) {
+	plan, err := r.Store.GetPlan()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return plan, nil
+}
+
+// RunCommand executes the phase specified by params on the specified server
+// using the provided runner
+func (r *OperatorEngine) RunCommand(ctx context.Context, runner RemoteRunner, server storage.Server, params Params) error {
+	args := append([]string{}, r.Command...)
+	args = append(args, "--phase", params.PhaseID)
+	if params.Force {
+		args = append(args, "--force")
+	}
+	return runner.Run(ctx, server, args...)
+}
+
+// Complete marks the operation as either completed or failed based
+// on the state of the operation plan
+func (r *OperatorEngine) Complete(fsmErr error) error {
+	plan, err := r.GetPlan()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if IsCompleted(plan) {
+		err = ops.CompleteOperation(r.Operation, r.Operator)
+	} else {
+		var message string
+		if fsmErr != nil {
+			message = trace.Unwrap(fsmErr).Error()
+		}
+		err = ops.FailOperation(r.Operation, r.Operator, message)
+	}
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	r.Debug("Marked operation complete.")
+	return nil
+}
+
+// UpdateProgress creates an appropriate progress entry in the operator.
+// Can be used as a pre-execution hook
+func (r *OperatorEngine) UpdateProgress(ctx context.Context, params Params) error {
+	plan, err := r.GetPlan()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	phase, err := FindPhase(plan, params.PhaseID)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	entry := ops.ProgressEntry{
+		SiteDomain:  r.Operation.SiteDomain,
+		OperationID: r.Operation.OperationID,
+		Completion:  100 / utils.Max(len(FlattenPlan(plan)), 1) * phase.Step,
+		Step:        phase.Step,
+		State:       ops.ProgressStateInProgress,
+		Message:     phase.Description,
+		Created:     time.Now().UTC(),
+	}
+	err = r.Operator.CreateProgressEntry(r.Operation, entry)
+	if err != nil {
+		r.Warnf("Failed to create progress entry %v: %v.", entry,
+			trace.DebugReport(err))
+	}
+	return nil
+}
+
+// operatorStore is the plan store that keeps the plan
+// in the cluster operator service
+type operatorStore struct {
+	operator  ops.Operator
+	operation ops.SiteOperationKey
+	logrus.FieldLogger
+}
+
+// ChangePhaseState creates an new changelog entry
+func (r *operatorStore) ChangePhaseState(ctx context.Context, change StateChange) error {
+	err := r.operator.CreateOperationPlanChange(r.operation,
+		storage.PlanChange{
+			ID:          uuid.New(),
+			ClusterName: r.operation.SiteDomain,
+			OperationID: r.operation.OperationID,
+			PhaseID:     change.Phase,
+			NewState:    change.State,
+			Error:       utils.ToRawTrace(change.Error),
+			Created:     time.Now().UTC(),
+		})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	r.Debugf("Applied %v.", change)
+	return nil
+}
+
+// GetPlan returns the most up-to-date operation plan
+func (r *operatorStore) GetPlan() (*storage.OperationPlan, error) {
+	plan, err := r.operator.GetOperationPlan(r.operation)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return plan, nil
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fsm
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gravitational/trace"
+)
+
+// NewRegistry returns a new empty executor registry
+func NewRegistry() *Registry {
+	return &Registry{
+		specs:     make(map[string]FSMSpecFunc),
+		executors: make(map[string]FSMSpecFunc),
+	}
+}
+
+// Registry maps operation plan phases to executors.
+//
+// An executor is registered for a phase ID and is selected for the phase
+// with exactly this ID as well as for any of its sub-phases, e.g. an executor
+// registered for "/masters" also handles "/masters/node-1".
+// If several registered IDs match a phase, the longest one wins.
+//
+// Alternatively, an executor can be registered by name for the plans whose
+// phases specify the executor explicitly (see storage.OperationPhase.Executor).
+// Executors registered by name take precedence over the ones registered
+// for phase IDs.
+//
+// Registry is safe for concurrent use
+type Registry struct {
+	sync.RWMutex
+	// specs maps phase IDs to executor factories
+	specs map[string]FSMSpecFunc
+	// executors maps executor names to executor factories
+	executors map[string]FSMSpecFunc
+}
+
+// Register registers the executor factory for the specified phase ID
+func (r *Registry) Register(phaseID string, spec FSMSpecFunc) error {
+	if phaseID == "" {
+		return trace.BadParameter("phase ID is required")
+	}
+	if spec == nil {
+		return trace.BadParameter("executor for phase %q is required", phaseID)
+	}
+	phaseID = normalizePhaseID(phaseID)
+	r.Lock()
+	defer r.Unlock()
+	if _, exists := r.specs[phaseID]; exists {
+		return trace.AlreadyExists("executor for phase %q is already registered", phaseID)
+	}
+	r.specs[phaseID] = spec
+	return nil
+}
+
+// MustRegister registers the executor factory for the specified phase ID
+// and panics if the registration fails
+func (r *Registry) MustRegister(phaseID string, spec FSMSpecFunc) {
+	if err := r.Register(phaseID, spec); err != nil {
+		panic(err)
+	}
+}
+
+// RegisterExecutor registers the executor factory for the phases
+// that specify the executor with the specified name
+func (r *Registry) RegisterExecutor(name string, spec FSMSpecFunc) error {
+	if name == "" {
+		return trace.BadParameter("executor name is required")
+	}
+	if spec == nil {
+		return trace.BadParameter("executor %q is required", name)
+	}
+	r.Lock()
+	defer r.Unlock()
+	if _, exists := r.executors[name]; exists {
+		return trace.AlreadyExists("executor %q is already registered", name)
+	}
+	r.executors[name] = spec
+	return nil
+}
+
+// MustRegisterExecutor registers the executor factory with the specified
+// name and panics if the registration fails
+func (r *Registry) MustRegisterExecutor(name string, spec FSMSpecFunc) {
+	if err := r.RegisterExecutor(name, spec); err != nil {
+		panic(err)
+	}
+}
+
+// Phases returns the sorted list of phase IDs with registered executors
+func (r *Registry) Phases() (phases []string) {
+	r.RLock()
+	defer r.RUnlock()
+	for phaseID := range r.specs {
+		phases = append(phases, phaseID)
+	}
+	sort.Strings(phases)
+	return phases
+}
+
+// GetExecutor returns the executor for the phase specified with params.
+// Implements FSMSpecFunc
+func (r *Registry) GetExecutor(params ExecutorParams, remote Remote) (PhaseExecutor, error) {
+	if spec := r.lookupExecutor(params.Phase.Executor); spec != nil {
+		return spec(params, remote)
+	}
+	spec, err := r.lookup(params.Phase.ID)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return spec(params, remote)
+}
+
+func (r *Registry) lookupExecutor(name string) FSMSpecFunc {
+	if name == "" {
+		return nil
+	}
+	r.RLock()
+	defer r.RUnlock()
+	return r.executors[name]
+}
+
+func (r *Registry) lookup(phaseID string) (FSMSpecFunc, error) {
+	phaseID = normalizePhaseID(phaseID)
+	r.RLock()
+	defer r.RUnlock()
+	var match string
+	var spec FSMSpecFunc
+	for id, fn := range r.specs {
+		if id != phaseID && !strings.HasPrefix(phaseID, id+"/") && id != "/" {
+			continue
+		}
+		if len(id) > len(match) {
+			match, spec = id, fn
+		}
+	}
+	if spec == nil {
+		return nil, trace.NotFound("no executor registered for phase %q", phaseID)
+	}
+	return spec, nil
+}
+
+// normalizePhaseID returns the phase ID in its canonical absolute form
+func normalizePhaseID(phaseID string) string {
+	if !strings.HasPrefix(phaseID, "/") {
+		phaseID = "/" + phaseID
+	}
+	if phaseID != "/" {
+		phaseID = strings.TrimSuffix(phaseID, "/")
+	}
+	return phaseID
+}
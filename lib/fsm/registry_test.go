@@ -0,0 +1,137 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fsm
+
+import (
+	"context"
+
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+	. "gopkg.in/check.v1"
+)
+
+type RegistrySuite struct{}
+
+var _ = Suite(&RegistrySuite{})
+
+func (s *RegistrySuite) TestResolvesExecutors(c *C) {
+	registry := NewRegistry()
+	registry.MustRegister("/checks", namedSpec("checks"))
+	registry.MustRegister("masters", namedSpec("masters"))
+	registry.MustRegister("/masters/node-1/drain", namedSpec("drain"))
+
+	testCases := []struct {
+		phase    string
+		executor string
+		comment  string
+	}{
+		{phase: "/checks", executor: "checks", comment: "exact match"},
+		{phase: "/masters/node-1", executor: "masters", comment: "sub-phase"},
+		{phase: "/masters/node-1/drain", executor: "drain", comment: "longest match wins"},
+	}
+	for _, tc := range testCases {
+		executor, err := registry.GetExecutor(ExecutorParams{
+			Phase: storage.OperationPhase{ID: tc.phase},
+		}, nil)
+		c.Assert(err, IsNil, Commentf(tc.comment))
+		c.Assert(executor.(*namedExecutor).name, Equals, tc.executor, Commentf(tc.comment))
+	}
+
+	_, err := registry.GetExecutor(ExecutorParams{
+		Phase: storage.OperationPhase{ID: "/checksum"},
+	}, nil)
+	c.Assert(trace.IsNotFound(err), Equals, true, Commentf("expected not found, got %v", err))
+
+	c.Assert(registry.Phases(), DeepEquals, []string{"/checks", "/masters", "/masters/node-1/drain"})
+}
+
+func (s *RegistrySuite) TestResolvesExecutorsByName(c *C) {
+	registry := NewRegistry()
+	registry.MustRegister("/masters", namedSpec("masters"))
+	registry.MustRegisterExecutor("drain", namedSpec("drain"))
+
+	executor, err := registry.GetExecutor(ExecutorParams{
+		Phase: storage.OperationPhase{ID: "/masters/node-1/drain", Executor: "drain"},
+	}, nil)
+	c.Assert(err, IsNil)
+	c.Assert(executor.(*namedExecutor).name, Equals, "drain")
+
+	executor, err = registry.GetExecutor(ExecutorParams{
+		Phase: storage.OperationPhase{ID: "/masters/node-1", Executor: "unknown"},
+	}, nil)
+	c.Assert(err, IsNil)
+	c.Assert(executor.(*namedExecutor).name, Equals, "masters", Commentf("falls back to phase ID"))
+
+	err = registry.RegisterExecutor("drain", namedSpec("drain"))
+	c.Assert(trace.IsAlreadyExists(err), Equals, true, Commentf("expected already exists, got %v", err))
+}
+
+func (s *RegistrySuite) TestRejectsDuplicates(c *C) {
+	registry := NewRegistry()
+	c.Assert(registry.Register("/checks", namedSpec("checks")), IsNil)
+	err := registry.Register("/checks/", namedSpec("checks"))
+	c.Assert(trace.IsAlreadyExists(err), Equals, true, Commentf("expected already exists, got %v", err))
+}
+
+func (s *RegistrySuite) TestOperatorEngineRunsPhaseCommand(c *C) {
+	engine, err := NewOperatorEngine(OperatorEngineConfig{
+		Operator:  &ops.OperatorACL{},
+		Operation: ops.SiteOperationKey{SiteDomain: "example.com", OperationID: "1"},
+		Spec:      NewRegistry().GetExecutor,
+		Command:   []string{"rotate-certs"},
+	})
+	c.Assert(err, IsNil)
+
+	runner := &recordingRunner{}
+	err = engine.RunCommand(context.TODO(), runner, storage.Server{},
+		Params{PhaseID: "/rotate/node-1", Force: true})
+	c.Assert(err, IsNil)
+	c.Assert(runner.command, DeepEquals, []string{"rotate-certs", "--phase", "/rotate/node-1", "--force"})
+	c.Assert(engine.Command, DeepEquals, []string{"rotate-certs"})
+}
+
+func namedSpec(name string) FSMSpecFunc {
+	return func(ExecutorParams, Remote) (PhaseExecutor, error) {
+		return &namedExecutor{FieldLogger: logrus.StandardLogger(), name: name}, nil
+	}
+}
+
+type namedExecutor struct {
+	logrus.FieldLogger
+	name string
+}
+
+func (r *namedExecutor) PreCheck(context.Context) error  { return nil }
+func (r *namedExecutor) PostCheck(context.Context) error { return nil }
+func (r *namedExecutor) Execute(context.Context) error   { return nil }
+func (r *namedExecutor) Rollback(context.Context) error  { return nil }
+
+type recordingRunner struct {
+	command []string
+}
+
+func (r *recordingRunner) Run(ctx context.Context, server storage.Server, command ...string) error {
+	r.command = command
+	return nil
+}
+
+func (r *recordingRunner) CanExecute(context.Context, storage.Server) error { return nil }
+
+func (r *recordingRunner) Close() error { return nil }
@@ -166,13 +166,24 @@ func canExecuteOnServer(ctx context.Context, server storage.Server, runner Remot
 		return ExecutionCheckUndefined, trace.Wrap(err)
 	}
 
-	ctx, cancel := context.WithTimeout(ctx, defaults.DialTimeout)
-	defer cancel()
-	err = runner.CanExecute(ctx, server)
+	err = checkCanExecute(ctx, server, runner)
 	if err == nil {
 		return CanRunRemotely, nil
 	}
 
+	if skewErr := rpcclient.ClockSkew(err); skewErr != nil {
+		// The agent is running but cannot be connected to until
+		// the clocks are synchronized
+		if !runClockSkewHook(ctx, *skewErr, log) {
+			return ExecutionCheckUndefined, trace.Wrap(err)
+		}
+		err = checkCanExecute(ctx, server, runner)
+		if err != nil {
+			return ExecutionCheckUndefined, trace.Wrap(err)
+		}
+		return CanRunRemotely, nil
+	}
+
 	log.WithFields(logrus.Fields{
 		"error":  err,
 		"server": server,
@@ -180,6 +191,12 @@ func canExecuteOnServer(ctx context.Context, server storage.Server, runner Remot
 	return ShouldRunRemotely, nil
 }
 
+func checkCanExecute(ctx context.Context, server storage.Server, runner RemoteRunner) error {
+	ctx, cancel := context.WithTimeout(ctx, defaults.DialTimeout)
+	defer cancel()
+	return runner.CanExecute(ctx, server)
+}
+
 func serverName(server storage.Server) string {
 	return fmt.Sprintf("%s/%s", server.Hostname, server.AdvertiseIP)
 }
@@ -122,6 +122,9 @@ func ResolvePlan(plan storage.OperationPlan, changelog storage.PlanChangelog) *s
 			allPhases[i].Updated = latest.Created
 			allPhases[i].Error = latest.Error
 		}
+		if approval := changelog.LatestApproval(phase.ID); approval != nil {
+			allPhases[i].Approval = approval.Approval
+		}
 	}
 	return &plan
 }
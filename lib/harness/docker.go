@@ -0,0 +1,147 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package harness
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gravitational/gravity/lib/defaults"
+
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+)
+
+// DockerConfig configures the provisioner of nodes run as Docker containers
+type DockerConfig struct {
+	// Image is the node image, it is expected to run systemd
+	Image string
+	// Prefix is the container name prefix
+	Prefix string
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+}
+
+// CheckAndSetDefaults validates the configuration and sets default values
+func (r *DockerConfig) CheckAndSetDefaults() error {
+	if r.Image == "" {
+		r.Image = defaults.HarnessDockerImage
+	}
+	if r.Prefix == "" {
+		r.Prefix = defaults.HarnessNodePrefix
+	}
+	if r.FieldLogger == nil {
+		r.FieldLogger = logrus.WithField(trace.Component, "harness:docker")
+	}
+	return nil
+}
+
+// NewDockerProvisioner returns a provisioner that runs nodes as
+// privileged Docker containers on the local host
+func NewDockerProvisioner(config DockerConfig) (*DockerProvisioner, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &DockerProvisioner{DockerConfig: config}, nil
+}
+
+// DockerProvisioner runs nodes as Docker containers
+type DockerProvisioner struct {
+	// DockerConfig is the provisioner configuration
+	DockerConfig
+	// containers lists the names of created containers
+	containers []string
+}
+
+// Provision starts the specified number of node containers
+func (r *DockerProvisioner) Provision(ctx context.Context, count int) (nodes []Node, err error) {
+	for i := 0; i < count; i++ {
+		name := fmt.Sprintf("%v-%v", r.Prefix, i)
+		err := runCommand(ctx, r.FieldLogger, nil, "docker", "run", "--detach", "--privileged",
+			"--name", name, "--hostname", name,
+			"--tmpfs", "/run", "--tmpfs", "/run/lock",
+			"--volume", "/sys/fs/cgroup:/sys/fs/cgroup:ro",
+			"--volume", "/lib/modules:/lib/modules:ro",
+			// state directory has to be outside of the container
+			// filesystem for planet to run overlay storage on it
+			"--volume", defaults.GravityDir,
+			r.Image)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		r.containers = append(r.containers, name)
+		var out bytes.Buffer
+		err = runCommand(ctx, r.FieldLogger, &out, "docker", "inspect", "--format",
+			"{{range .NetworkSettings.Networks}}{{.IPAddress}}{{end}}", name)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		addr := strings.TrimSpace(out.String())
+		if addr == "" {
+			return nil, trace.NotFound("container %v has no IP address", name)
+		}
+		nodes = append(nodes, &dockerNode{
+			name:        name,
+			addr:        addr,
+			FieldLogger: r.WithField("node", name),
+		})
+	}
+	return nodes, nil
+}
+
+// Destroy removes all node containers along with their volumes
+func (r *DockerProvisioner) Destroy(ctx context.Context) error {
+	if len(r.containers) == 0 {
+		return nil
+	}
+	args := append([]string{"docker", "rm", "--force", "--volumes"}, r.containers...)
+	if err := runCommand(ctx, r.FieldLogger, nil, args...); err != nil {
+		return trace.Wrap(err)
+	}
+	r.containers = nil
+	return nil
+}
+
+type dockerNode struct {
+	name string
+	addr string
+	logrus.FieldLogger
+}
+
+// Name returns the container name
+func (r *dockerNode) Name() string {
+	return r.name
+}
+
+// Addr returns the container IP address
+func (r *dockerNode) Addr() string {
+	return r.addr
+}
+
+// Run runs the command in the container
+func (r *dockerNode) Run(ctx context.Context, command string, w io.Writer) error {
+	return runCommand(ctx, r.FieldLogger, w, "docker", "exec", r.name, "sh", "-c", command)
+}
+
+// CopyTo copies the local file into the container
+func (r *dockerNode) CopyTo(ctx context.Context, path, nodePath string) error {
+	return runCommand(ctx, r.FieldLogger, nil, "docker", "cp", path,
+		fmt.Sprintf("%v:%v", r.name, nodePath))
+}
@@ -0,0 +1,248 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package harness provisions throwaway clusters from installer tarballs
+// to test the install, upgrade and rollback paths of an application.
+//
+// Nodes are provided by a Provisioner: either privileged Docker containers
+// on the local host or existing hosts, e.g. VMs, accessed over SSH.
+// All operations are run with the gravity binary shipped with the respective
+// installer, exactly as a cluster administrator would run them
+package harness
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/gravitational/gravity/lib/archive"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/schema"
+	"github.com/gravitational/gravity/lib/status"
+	"github.com/gravitational/gravity/lib/update"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/trace"
+	"github.com/pborman/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// Config configures a test cluster
+type Config struct {
+	// Provisioner provides the cluster nodes
+	Provisioner Provisioner
+	// Nodes is the number of cluster nodes
+	Nodes int
+	// ClusterName is the name of the cluster
+	ClusterName string
+	// Flavor is the optional application flavor to install
+	Flavor string
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+}
+
+// CheckAndSetDefaults validates the configuration and sets default values
+func (r *Config) CheckAndSetDefaults() error {
+	if r.Provisioner == nil {
+		return trace.BadParameter("missing Provisioner")
+	}
+	if r.Nodes < 1 {
+		return trace.BadParameter("cluster needs at least one node")
+	}
+	if r.ClusterName == "" {
+		r.ClusterName = defaults.HarnessClusterName
+	}
+	if r.FieldLogger == nil {
+		r.FieldLogger = logrus.WithField(trace.Component, "harness")
+	}
+	return nil
+}
+
+// New provisions the nodes of a new test cluster.
+// The cluster has to be destroyed with Destroy after use
+func New(ctx context.Context, config Config) (*Cluster, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	nodes, err := config.Provisioner.Provision(ctx, config.Nodes)
+	if err != nil {
+		if errDestroy := config.Provisioner.Destroy(ctx); errDestroy != nil {
+			config.Warnf("Failed to destroy nodes: %v.", trace.DebugReport(errDestroy))
+		}
+		return nil, trace.Wrap(err)
+	}
+	return &Cluster{Config: config, nodes: nodes}, nil
+}
+
+// Cluster is a test cluster
+type Cluster struct {
+	// Config is the cluster configuration
+	Config
+	// nodes lists the cluster nodes, the first one is the installer node
+	nodes []Node
+}
+
+// Nodes returns the cluster nodes
+func (r *Cluster) Nodes() []Node {
+	return r.nodes
+}
+
+// Install installs the cluster from the specified installer tarball.
+// The first node runs the installer and the rest of the nodes join it
+func (r *Cluster) Install(ctx context.Context, installer string) error {
+	token := uuid.New()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	errors := make(chan error, len(r.nodes))
+	for i, node := range r.nodes {
+		go func(i int, node Node) {
+			errors <- trace.Wrap(r.installNode(ctx, i, node, installer, token))
+		}(i, node)
+	}
+	_, err := utils.Collect(ctx, cancel, errors, nil)
+	return trace.Wrap(err)
+}
+
+// installNode unpacks the installer on the specified node and either
+// starts the installation on the first node or joins the node to it
+func (r *Cluster) installNode(ctx context.Context, i int, node Node, installer, token string) error {
+	dir, err := r.unpack(ctx, node, installer)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	var command string
+	if i == 0 {
+		command = fmt.Sprintf("cd %v && ./gravity install --advertise-addr=%v --token=%v --cluster=%v",
+			dir, node.Addr(), token, r.ClusterName)
+		if r.Flavor != "" {
+			command = fmt.Sprintf("%v --flavor=%v", command, shellQuote(r.Flavor))
+		}
+	} else {
+		command = fmt.Sprintf("cd %v && ./gravity join %v --advertise-addr=%v --token=%v",
+			dir, r.nodes[0].Addr(), node.Addr(), token)
+	}
+	r.Infof("Installing node %v.", node.Name())
+	return trace.Wrap(node.Run(ctx, command, nil))
+}
+
+// Upgrade upgrades the cluster to the application from the specified
+// installer tarball with an unattended upgrade.
+// If rollback is set, the upgrade is rolled back after it completes.
+// Returns the result of the upgrade
+func (r *Cluster) Upgrade(ctx context.Context, installer string, rollback bool) (*update.UnattendedResult, error) {
+	node := r.nodes[0]
+	dir, err := r.unpack(ctx, node, installer)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	r.Infof("Uploading %v.", installer)
+	if err := node.Run(ctx, fmt.Sprintf("cd %v && ./upload", dir), nil); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	command := fmt.Sprintf("cd %v && ./gravity upgrade --unattended", dir)
+	if rollback {
+		command = fmt.Sprintf("%v --force-rollback", command)
+	}
+	r.Infof("Upgrading to %v.", installer)
+	var out bytes.Buffer
+	// the result is output even if the upgrade fails
+	errUpgrade := node.Run(ctx, command, &out)
+	var result update.UnattendedResult
+	if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+		return nil, trace.NewAggregate(errUpgrade, trace.Wrap(err, "failed to parse upgrade result %q", out.String()))
+	}
+	return &result, nil
+}
+
+// Status returns the status of the cluster
+func (r *Cluster) Status(ctx context.Context) (*status.Status, error) {
+	var out bytes.Buffer
+	if err := r.nodes[0].Run(ctx, "gravity status --output=json", &out); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var clusterStatus status.Status
+	if err := json.Unmarshal(out.Bytes(), &clusterStatus); err != nil {
+		return nil, trace.Wrap(err, "failed to parse cluster status %q", out.String())
+	}
+	if clusterStatus.Cluster == nil {
+		return nil, trace.NotFound("cluster status is not available")
+	}
+	return &clusterStatus, nil
+}
+
+// Destroy destroys the cluster nodes
+func (r *Cluster) Destroy(ctx context.Context) error {
+	return trace.Wrap(r.Provisioner.Destroy(ctx))
+}
+
+// unpack copies the installer tarball to the node and unpacks it into
+// a directory named after the tarball. Returns the directory
+func (r *Cluster) unpack(ctx context.Context, node Node, installer string) (dir string, err error) {
+	name := filepath.Base(installer)
+	tarball := path.Join("/tmp", name)
+	if err := node.CopyTo(ctx, installer, tarball); err != nil {
+		return "", trace.Wrap(err)
+	}
+	dir = path.Join("/tmp", fmt.Sprintf("%v.d", name))
+	err = node.Run(ctx, fmt.Sprintf("mkdir -p %[1]v && tar -xf %[2]v -C %[1]v",
+		shellQuote(dir), shellQuote(tarball)), nil)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	return shellQuote(dir), nil
+}
+
+// InstallerApp returns the locator of the application packaged in the
+// specified installer tarball
+func InstallerApp(installer string) (*loc.Locator, error) {
+	file, err := os.Open(installer)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	defer file.Close()
+	var manifest *schema.Manifest
+	err = archive.TarGlob(tar.NewReader(file), ".", []string{defaults.ManifestFileName},
+		func(match string, reader io.Reader) error {
+			if match != defaults.ManifestFileName {
+				return nil
+			}
+			data, err := ioutil.ReadAll(reader)
+			if err != nil {
+				return trace.Wrap(err)
+			}
+			manifest, err = schema.ParseManifestYAMLNoValidate(data)
+			if err != nil {
+				return trace.Wrap(err)
+			}
+			return archive.Abort
+		})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if manifest == nil {
+		return nil, trace.NotFound("no application manifest found in %v", installer)
+	}
+	locator := manifest.Locator()
+	return &locator, nil
+}
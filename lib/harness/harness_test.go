@@ -0,0 +1,248 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package harness
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/status"
+	"github.com/gravitational/gravity/lib/update"
+
+	"github.com/gravitational/trace"
+	check "gopkg.in/check.v1"
+)
+
+func TestHarness(t *testing.T) { check.TestingT(t) }
+
+type HarnessSuite struct {
+	dir string
+}
+
+var _ = check.Suite(&HarnessSuite{})
+
+func (s *HarnessSuite) SetUpTest(c *check.C) {
+	s.dir = c.MkDir()
+}
+
+func (s *HarnessSuite) TestReadsInstallerApp(c *check.C) {
+	installer := s.installer(c, "1.0.0")
+	locator, err := InstallerApp(installer)
+	c.Assert(err, check.IsNil)
+	c.Assert(locator.Name, check.Equals, "app")
+	c.Assert(locator.Version, check.Equals, "1.0.0")
+}
+
+func (s *HarnessSuite) TestUpgradePath(c *check.C) {
+	cluster := newTestCluster(map[string]string{
+		"app-1.0.0.tar": "1.0.0",
+		"app-2.0.0.tar": "2.0.0",
+	})
+	report, err := UpgradePath(context.TODO(), UpgradePathConfig{
+		Config: Config{
+			Provisioner: cluster,
+			Nodes:       2,
+		},
+		Installer: s.installer(c, "1.0.0"),
+		Upgrades:  []string{s.installer(c, "2.0.0")},
+		Rollback:  true,
+	})
+	c.Assert(err, check.IsNil)
+	c.Assert(report.Failed(), check.Equals, false, check.Commentf("%#v", report))
+	c.Assert(steps(report), check.DeepEquals, []string{
+		"install app:1.0.0",
+		"rollback app:2.0.0",
+		"upgrade app:2.0.0",
+	})
+	c.Assert(cluster.version, check.Equals, "2.0.0")
+	c.Assert(cluster.destroyed, check.Equals, true)
+	c.Assert(cluster.nodes[0].commands, check.DeepEquals, []string{
+		"mkdir -p '/tmp/app-1.0.0.tar.d' && tar -xf '/tmp/app-1.0.0.tar' -C '/tmp/app-1.0.0.tar.d'",
+		"cd '/tmp/app-1.0.0.tar.d' && ./gravity install --advertise-addr=10.0.0.1 --token=<token> --cluster=test.local",
+		"gravity status --output=json",
+		"mkdir -p '/tmp/app-2.0.0.tar.d' && tar -xf '/tmp/app-2.0.0.tar' -C '/tmp/app-2.0.0.tar.d'",
+		"cd '/tmp/app-2.0.0.tar.d' && ./upload",
+		"cd '/tmp/app-2.0.0.tar.d' && ./gravity upgrade --unattended --force-rollback",
+		"gravity status --output=json",
+		"mkdir -p '/tmp/app-2.0.0.tar.d' && tar -xf '/tmp/app-2.0.0.tar' -C '/tmp/app-2.0.0.tar.d'",
+		"cd '/tmp/app-2.0.0.tar.d' && ./upload",
+		"cd '/tmp/app-2.0.0.tar.d' && ./gravity upgrade --unattended",
+		"gravity status --output=json",
+	})
+	c.Assert(cluster.nodes[1].commands, check.DeepEquals, []string{
+		"mkdir -p '/tmp/app-1.0.0.tar.d' && tar -xf '/tmp/app-1.0.0.tar' -C '/tmp/app-1.0.0.tar.d'",
+		"cd '/tmp/app-1.0.0.tar.d' && ./gravity join 10.0.0.1 --advertise-addr=10.0.0.2 --token=<token>",
+	})
+}
+
+func (s *HarnessSuite) TestStopsAtFailedUpgrade(c *check.C) {
+	cluster := newTestCluster(map[string]string{
+		"app-1.0.0.tar": "1.0.0",
+		"app-2.0.0.tar": "2.0.0",
+		"app-3.0.0.tar": "3.0.0",
+	})
+	cluster.failUpgrade = "2.0.0"
+	report, err := UpgradePath(context.TODO(), UpgradePathConfig{
+		Config: Config{
+			Provisioner: cluster,
+			Nodes:       1,
+		},
+		Installer: s.installer(c, "1.0.0"),
+		Upgrades:  []string{s.installer(c, "2.0.0"), s.installer(c, "3.0.0")},
+		Keep:      true,
+	})
+	c.Assert(err, check.IsNil)
+	c.Assert(report.Failed(), check.Equals, true)
+	c.Assert(steps(report), check.DeepEquals, []string{
+		"install app:1.0.0",
+		"upgrade app:2.0.0",
+	})
+	c.Assert(report.Steps[1].Error, check.Matches, ".*expected upgrade to end up completed but it is rolled_back.*")
+	c.Assert(cluster.version, check.Equals, "1.0.0")
+	c.Assert(cluster.destroyed, check.Equals, false)
+}
+
+// installer creates an installer tarball with the manifest of the application
+// of the specified version
+func (s *HarnessSuite) installer(c *check.C, version string) string {
+	path := filepath.Join(s.dir, fmt.Sprintf("app-%v.tar", version))
+	file, err := os.Create(path)
+	c.Assert(err, check.IsNil)
+	defer file.Close()
+	manifest := fmt.Sprintf(`apiVersion: bundle.gravitational.io/v2
+kind: Bundle
+metadata:
+  name: app
+  resourceVersion: %q
+`, version)
+	writer := tar.NewWriter(file)
+	for _, item := range []struct{ name, data string }{
+		{"gravity", "binary"},
+		{"app.yaml", manifest},
+	} {
+		c.Assert(writer.WriteHeader(&tar.Header{
+			Name: item.name,
+			Mode: 0644,
+			Size: int64(len(item.data)),
+		}), check.IsNil)
+		_, err = writer.Write([]byte(item.data))
+		c.Assert(err, check.IsNil)
+	}
+	c.Assert(writer.Close(), check.IsNil)
+	return path
+}
+
+func steps(report *Report) (steps []string) {
+	for _, step := range report.Steps {
+		steps = append(steps, fmt.Sprintf("%v %v", step.Name, strings.TrimPrefix(step.App, defaults.SystemAccountOrg+"/")))
+	}
+	return steps
+}
+
+func newTestCluster(versions map[string]string) *testCluster {
+	return &testCluster{versions: versions}
+}
+
+// testCluster is a fake provisioner that simulates the cluster
+// by interpreting the commands run on its nodes
+type testCluster struct {
+	sync.Mutex
+	// versions maps installer tarball names to application versions
+	versions map[string]string
+	// failUpgrade is the version upgrades to which fail
+	failUpgrade string
+	nodes       []*testNode
+	version     string
+	destroyed   bool
+}
+
+func (r *testCluster) Provision(ctx context.Context, count int) (nodes []Node, err error) {
+	for i := 0; i < count; i++ {
+		node := &testNode{
+			cluster: r,
+			name:    fmt.Sprintf("node-%v", i+1),
+			addr:    fmt.Sprintf("10.0.0.%v", i+1),
+		}
+		r.nodes = append(r.nodes, node)
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+func (r *testCluster) Destroy(ctx context.Context) error {
+	r.destroyed = true
+	return nil
+}
+
+type testNode struct {
+	cluster  *testCluster
+	name     string
+	addr     string
+	commands []string
+}
+
+func (r *testNode) Name() string { return r.name }
+
+func (r *testNode) Addr() string { return r.addr }
+
+func (r *testNode) CopyTo(ctx context.Context, path, nodePath string) error {
+	return nil
+}
+
+func (r *testNode) Run(ctx context.Context, command string, w io.Writer) error {
+	r.cluster.Lock()
+	defer r.cluster.Unlock()
+	fields := strings.Fields(command)
+	for i, field := range fields {
+		if strings.HasPrefix(field, "--token=") {
+			fields[i] = "--token=<token>"
+		}
+	}
+	r.commands = append(r.commands, strings.Join(fields, " "))
+	version := r.cluster.versions[strings.TrimSuffix(strings.TrimPrefix(
+		strings.Trim(fields[1], "'"), "/tmp/"), ".d")]
+	switch {
+	case strings.Contains(command, "gravity install"):
+		r.cluster.version = version
+	case strings.Contains(command, "gravity upgrade"):
+		result := update.UnattendedResult{State: update.UnattendedRolledBack}
+		if !strings.Contains(command, "--force-rollback") && version != r.cluster.failUpgrade {
+			result.State = update.UnattendedCompleted
+			r.cluster.version = version
+		}
+		return trace.Wrap(json.NewEncoder(w).Encode(result))
+	case strings.Contains(command, "gravity status"):
+		return trace.Wrap(json.NewEncoder(w).Encode(status.Status{
+			Cluster: &status.Cluster{
+				App:   loc.MustParseLocator(fmt.Sprintf("%v/app:%v", defaults.SystemAccountOrg, r.cluster.version)),
+				State: ops.SiteStateActive,
+			},
+		}))
+	}
+	return nil
+}
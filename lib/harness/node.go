@@ -0,0 +1,72 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package harness
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"os/exec"
+	"strings"
+
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+)
+
+// Node is a node provisioned for a test cluster
+type Node interface {
+	// Name returns the node name
+	Name() string
+	// Addr returns the IP address the node is reachable on from other nodes
+	Addr() string
+	// Run runs the shell command on the node as root and writes its output to w
+	Run(ctx context.Context, command string, w io.Writer) error
+	// CopyTo copies the local file to the specified path on the node
+	CopyTo(ctx context.Context, path, nodePath string) error
+}
+
+// Provisioner creates and destroys nodes for test clusters
+type Provisioner interface {
+	// Provision creates the specified number of nodes
+	Provision(ctx context.Context, count int) ([]Node, error)
+	// Destroy destroys all provisioned nodes
+	Destroy(ctx context.Context) error
+}
+
+// runCommand runs the specified command locally and writes its output to w.
+// The error output of a failed command is returned with the error
+func runCommand(ctx context.Context, logger logrus.FieldLogger, w io.Writer, args ...string) error {
+	if w == nil {
+		w = ioutil.Discard
+	}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdout = w
+	cmd.Stderr = &stderr
+	logger.Debugf("Running %v.", args)
+	if err := cmd.Run(); err != nil {
+		return trace.Wrap(err, "command %q failed: %s",
+			strings.Join(args, " "), bytes.TrimSpace(stderr.Bytes()))
+	}
+	return nil
+}
+
+// shellQuote quotes the string for use as a single shell word
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
@@ -0,0 +1,239 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package harness
+
+import (
+	"context"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/update"
+
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+)
+
+// UpgradePathConfig describes the upgrade path to test
+type UpgradePathConfig struct {
+	// Config is the test cluster configuration
+	Config
+	// Installer is the installer tarball of the application to install
+	Installer string
+	// Upgrades lists the installer tarballs of the applications to upgrade
+	// to, in order
+	Upgrades []string
+	// Rollback is whether to roll back each upgrade first, and verify that
+	// the cluster is left intact, before upgrading to the application
+	Rollback bool
+	// Keep is whether to keep the cluster after the test
+	Keep bool
+	// InstallTimeout is the time the cluster is allowed to install
+	InstallTimeout time.Duration
+	// UpgradeTimeout is the time each upgrade is allowed to take
+	UpgradeTimeout time.Duration
+	// Clock is used to measure step durations
+	Clock clockwork.Clock
+}
+
+// CheckAndSetDefaults validates the configuration and sets default values
+func (r *UpgradePathConfig) CheckAndSetDefaults() error {
+	if r.Installer == "" {
+		return trace.BadParameter("missing Installer")
+	}
+	if err := r.Config.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	if r.InstallTimeout == 0 {
+		r.InstallTimeout = defaults.HarnessInstallTimeout
+	}
+	if r.UpgradeTimeout == 0 {
+		r.UpgradeTimeout = defaults.HarnessUpgradeTimeout
+	}
+	if r.Clock == nil {
+		r.Clock = clockwork.NewRealClock()
+	}
+	return nil
+}
+
+// Report describes the outcome of an upgrade path test
+type Report struct {
+	// Steps lists the executed test steps
+	Steps []Step `json:"steps"`
+}
+
+// Failed returns true if any of the steps has failed
+func (r Report) Failed() bool {
+	for _, step := range r.Steps {
+		if step.Error != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Step describes a single test step
+type Step struct {
+	// Name is the step name
+	Name string `json:"name"`
+	// App is the application the step has been run for
+	App string `json:"app"`
+	// Duration is how long the step has taken
+	Duration time.Duration `json:"duration"`
+	// Error is the error the step has failed with
+	Error string `json:"error,omitempty"`
+}
+
+const (
+	// StepInstall installs the cluster
+	StepInstall = "install"
+	// StepRollback upgrades the cluster and rolls the upgrade back
+	StepRollback = "rollback"
+	// StepUpgrade upgrades the cluster
+	StepUpgrade = "upgrade"
+)
+
+// UpgradePath installs a cluster from the configured installer and
+// upgrades it through the configured upgrades in order, verifying the
+// installed application after every step.
+// Testing stops at the first failed step
+func UpgradePath(ctx context.Context, config UpgradePathConfig) (*Report, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	installed, err := InstallerApp(config.Installer)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	upgrades := make([]loc.Locator, 0, len(config.Upgrades))
+	for _, installer := range config.Upgrades {
+		app, err := InstallerApp(installer)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		upgrades = append(upgrades, *app)
+	}
+	cluster, err := New(ctx, config.Config)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if !config.Keep {
+		defer func() {
+			if err := cluster.Destroy(context.Background()); err != nil {
+				config.Warnf("Failed to destroy cluster: %v.", trace.DebugReport(err))
+			}
+		}()
+	}
+	runner := &scenario{
+		UpgradePathConfig: config,
+		cluster:           cluster,
+		report:            &Report{},
+	}
+	err = runner.step(StepInstall, *installed, func() error {
+		ctx, cancel := context.WithTimeout(ctx, config.InstallTimeout)
+		defer cancel()
+		if err := cluster.Install(ctx, config.Installer); err != nil {
+			return trace.Wrap(err)
+		}
+		return trace.Wrap(runner.verify(ctx, *installed))
+	})
+	if err != nil {
+		return runner.report, nil
+	}
+	for i, installer := range config.Upgrades {
+		installer, app := installer, upgrades[i]
+		if config.Rollback {
+			err = runner.step(StepRollback, app, func() error {
+				ctx, cancel := context.WithTimeout(ctx, config.UpgradeTimeout)
+				defer cancel()
+				return trace.Wrap(runner.upgrade(ctx, installer, true, *installed))
+			})
+			if err != nil {
+				return runner.report, nil
+			}
+		}
+		err = runner.step(StepUpgrade, app, func() error {
+			ctx, cancel := context.WithTimeout(ctx, config.UpgradeTimeout)
+			defer cancel()
+			return trace.Wrap(runner.upgrade(ctx, installer, false, app))
+		})
+		if err != nil {
+			return runner.report, nil
+		}
+		installed = &app
+	}
+	return runner.report, nil
+}
+
+type scenario struct {
+	UpgradePathConfig
+	cluster *Cluster
+	report  *Report
+}
+
+// step runs the specified test step and records its outcome in the report
+func (r *scenario) step(name string, app loc.Locator, fn func() error) error {
+	r.Infof("Running %v step for %v.", name, app)
+	start := r.Clock.Now()
+	err := fn()
+	step := Step{
+		Name:     name,
+		App:      app.String(),
+		Duration: r.Clock.Now().Sub(start),
+	}
+	if err != nil {
+		r.Warnf("Step %v for %v failed: %v.", name, app, trace.DebugReport(err))
+		step.Error = trace.UserMessage(err)
+	}
+	r.report.Steps = append(r.report.Steps, step)
+	return trace.Wrap(err)
+}
+
+// upgrade upgrades the cluster with the specified installer and verifies
+// that the expected application is installed afterwards
+func (r *scenario) upgrade(ctx context.Context, installer string, rollback bool, expected loc.Locator) error {
+	result, err := r.cluster.Upgrade(ctx, installer, rollback)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	state := update.UnattendedCompleted
+	if rollback {
+		state = update.UnattendedRolledBack
+	}
+	if result.State != state {
+		return trace.CompareFailed("expected upgrade to end up %v but it is %v: %v",
+			state, result.State, result.Error)
+	}
+	return trace.Wrap(r.verify(ctx, expected))
+}
+
+// verify checks that the cluster is active and runs the expected application
+func (r *scenario) verify(ctx context.Context, expected loc.Locator) error {
+	status, err := r.cluster.Status(ctx)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if status.Cluster.State != ops.SiteStateActive {
+		return trace.CompareFailed("cluster is %v", status.Cluster.State)
+	}
+	if !status.Cluster.App.IsEqualTo(expected) {
+		return trace.CompareFailed("expected application %v but found %v",
+			expected, status.Cluster.App)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package harness
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+)
+
+// SSHConfig configures the provisioner of existing hosts, e.g. VMs, accessed over SSH
+type SSHConfig struct {
+	// Hosts lists the addresses of the hosts
+	Hosts []string
+	// User is the SSH user, it should be able to use sudo without a password
+	User string
+	// KeyPath is the path to the SSH private key
+	KeyPath string
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+}
+
+// CheckAndSetDefaults validates the configuration and sets default values
+func (r *SSHConfig) CheckAndSetDefaults() error {
+	if len(r.Hosts) == 0 {
+		return trace.BadParameter("missing Hosts")
+	}
+	if r.User == "" {
+		return trace.BadParameter("missing User")
+	}
+	if r.KeyPath == "" {
+		return trace.BadParameter("missing KeyPath")
+	}
+	if r.FieldLogger == nil {
+		r.FieldLogger = logrus.WithField(trace.Component, "harness:ssh")
+	}
+	return nil
+}
+
+// NewSSHProvisioner returns a provisioner that uses existing hosts accessed over SSH.
+// The hosts are not created but only cleaned up after the test
+func NewSSHProvisioner(config SSHConfig) (*SSHProvisioner, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &SSHProvisioner{SSHConfig: config}, nil
+}
+
+// SSHProvisioner uses existing hosts as nodes
+type SSHProvisioner struct {
+	// SSHConfig is the provisioner configuration
+	SSHConfig
+	// nodes lists the provisioned nodes
+	nodes []Node
+}
+
+// Provision returns the specified number of configured hosts
+func (r *SSHProvisioner) Provision(ctx context.Context, count int) ([]Node, error) {
+	if count > len(r.Hosts) {
+		return nil, trace.BadParameter("%v nodes requested but only %v hosts configured",
+			count, len(r.Hosts))
+	}
+	for _, host := range r.Hosts[:count] {
+		r.nodes = append(r.nodes, &sshNode{
+			host:        host,
+			config:      r.SSHConfig,
+			FieldLogger: r.WithField("node", host),
+		})
+	}
+	return r.nodes, nil
+}
+
+// Destroy uninstalls gravity from all provisioned hosts
+func (r *SSHProvisioner) Destroy(ctx context.Context) error {
+	var errors []error
+	for _, node := range r.nodes {
+		err := node.Run(ctx, "if command -v gravity; then gravity system uninstall --confirm; fi", nil)
+		if err != nil {
+			errors = append(errors, trace.Wrap(err))
+		}
+	}
+	r.nodes = nil
+	return trace.NewAggregate(errors...)
+}
+
+type sshNode struct {
+	host   string
+	config SSHConfig
+	logrus.FieldLogger
+}
+
+// Name returns the host address
+func (r *sshNode) Name() string {
+	return r.host
+}
+
+// Addr returns the host address
+func (r *sshNode) Addr() string {
+	return r.host
+}
+
+// Run runs the command on the host with sudo
+func (r *sshNode) Run(ctx context.Context, command string, w io.Writer) error {
+	args := append([]string{"ssh"}, r.options()...)
+	args = append(args, fmt.Sprintf("%v@%v", r.config.User, r.host),
+		"sudo", "sh", "-c", shellQuote(command))
+	return runCommand(ctx, r.FieldLogger, w, args...)
+}
+
+// CopyTo copies the local file to the host. The file is uploaded
+// to the user's home directory first and then moved in place
+func (r *sshNode) CopyTo(ctx context.Context, path, nodePath string) error {
+	tempPath := filepath.Base(path)
+	args := append([]string{"scp"}, r.options()...)
+	args = append(args, path, fmt.Sprintf("%v@%v:%v", r.config.User, r.host, tempPath))
+	if err := runCommand(ctx, r.FieldLogger, nil, args...); err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(r.Run(ctx, fmt.Sprintf("mv ~%v/%v %v",
+		r.config.User, shellQuote(tempPath), shellQuote(nodePath)), nil))
+}
+
+func (r *sshNode) options() []string {
+	return []string{
+		"-i", r.config.KeyPath,
+		"-o", "BatchMode=yes",
+		"-o", "StrictHostKeyChecking=no",
+		"-o", "UserKnownHostsFile=/dev/null",
+	}
+}
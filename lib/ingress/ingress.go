@@ -0,0 +1,232 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ingress manages the ingress controller bundled with the cluster.
+//
+// Controller manifests for each supported controller type are shipped
+// with the ingress application package and are customized according
+// to the ingresscontroller resource before they are applied
+package ingress
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gravitational/gravity/lib/app"
+	"github.com/gravitational/gravity/lib/app/resources"
+	"github.com/gravitational/gravity/lib/archive"
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/rigging"
+	"github.com/gravitational/trace"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// httpPortName is the name of the controller container port serving HTTP
+	httpPortName = "http"
+	// httpsPortName is the name of the controller container port serving HTTPS
+	httpsPortName = "https"
+	// defaultCertificateFlag is the controller flag with the secret
+	// of the default TLS certificate, supported by both nginx and haproxy
+	defaultCertificateFlag = "--default-ssl-certificate"
+)
+
+// Load returns the objects of the specified ingress controller type
+// from the resources of the ingress application package
+func Load(apps app.Applications, locator loc.Locator, controllerType string) ([]runtime.Object, error) {
+	reader, err := apps.GetAppResources(locator)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer reader.Close()
+	stream, err := archive.DecompressStream(reader)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer stream.Close()
+	var objects []runtime.Object
+	err = archive.TarGlob(
+		tar.NewReader(stream),
+		defaults.ResourcesDir,
+		[]string{fmt.Sprintf("%v.yaml", controllerType)},
+		func(_ string, reader io.Reader) error {
+			return resources.ForEachObject(reader, func(object runtime.Object) error {
+				objects = append(objects, object)
+				return nil
+			})
+		})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if len(objects) == 0 {
+		return nil, trace.NotFound("no %v ingress controller resources in %v",
+			controllerType, locator)
+	}
+	return objects, nil
+}
+
+// Configure customizes the controller objects according to the specified
+// configuration.
+//
+// Controller deployments, i.e. the ones with container ports named http
+// or https, are scaled to the configured number of replicas and scheduled
+// on nodes matching the node selector. Their http and https container ports
+// are exposed on the configured host ports
+func Configure(objects []runtime.Object, controller storage.IngressController) error {
+	for _, object := range objects {
+		accessor, err := meta.Accessor(object)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		labels := accessor.GetLabels()
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		labels[constants.IngressControllerLabel] = controller.GetType()
+		accessor.SetLabels(labels)
+		deployment, ok := object.(*appsv1.Deployment)
+		if !ok || !servesIngress(deployment.Spec.Template.Spec) {
+			continue
+		}
+		replicas := int32(controller.GetReplicas())
+		deployment.Spec.Replicas = &replicas
+		spec := &deployment.Spec.Template.Spec
+		if len(controller.GetNodeSelector()) != 0 {
+			if spec.NodeSelector == nil {
+				spec.NodeSelector = make(map[string]string)
+			}
+			for key, value := range controller.GetNodeSelector() {
+				spec.NodeSelector[key] = value
+			}
+		}
+		for i := range spec.Containers {
+			configureContainer(&spec.Containers[i], controller)
+		}
+	}
+	return nil
+}
+
+// servesIngress returns true if the pod has a container serving ingress traffic
+func servesIngress(spec v1.PodSpec) bool {
+	for _, container := range spec.Containers {
+		for _, port := range container.Ports {
+			if port.Name == httpPortName || port.Name == httpsPortName {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func configureContainer(container *v1.Container, controller storage.IngressController) {
+	var serves bool
+	for i, port := range container.Ports {
+		switch port.Name {
+		case httpPortName:
+			container.Ports[i].HostPort = int32(controller.GetHTTPPort())
+			serves = true
+		case httpsPortName:
+			container.Ports[i].HostPort = int32(controller.GetHTTPSPort())
+			serves = true
+		}
+	}
+	if !serves || controller.GetDefaultCertificate() == "" {
+		return
+	}
+	flag := fmt.Sprintf("%v=%v", defaultCertificateFlag, controller.GetDefaultCertificate())
+	for i, arg := range container.Args {
+		if strings.HasPrefix(arg, defaultCertificateFlag) {
+			container.Args[i] = flag
+			return
+		}
+	}
+	container.Args = append(container.Args, flag)
+}
+
+// Apply creates or updates the specified controller objects
+func Apply(ctx context.Context, client *kubernetes.Clientset, objects []runtime.Object) error {
+	for _, object := range objects {
+		control, err := newControl(client, object)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if err := control.Upsert(ctx); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// Delete deletes the specified controller objects in reverse order.
+// Objects that do not exist are ignored
+func Delete(ctx context.Context, client *kubernetes.Clientset, objects []runtime.Object) error {
+	var errors []error
+	for i := len(objects) - 1; i >= 0; i-- {
+		control, err := newControl(client, objects[i])
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		err = control.Delete(ctx, true)
+		if err != nil && !trace.IsNotFound(rigging.ConvertError(err)) {
+			errors = append(errors, trace.Wrap(err))
+		}
+	}
+	return trace.NewAggregate(errors...)
+}
+
+// control manages a single Kubernetes object
+type control interface {
+	// Upsert creates or updates the object
+	Upsert(context.Context) error
+	// Delete deletes the object
+	Delete(ctx context.Context, cascade bool) error
+}
+
+func newControl(client *kubernetes.Clientset, object runtime.Object) (control, error) {
+	switch resource := object.(type) {
+	case *v1.ServiceAccount:
+		return rigging.NewServiceAccountControl(rigging.ServiceAccountConfig{Account: *resource, Client: client})
+	case *v1.ConfigMap:
+		return rigging.NewConfigMapControl(rigging.ConfigMapConfig{ConfigMap: resource, Client: client})
+	case *v1.Service:
+		return rigging.NewServiceControl(rigging.ServiceConfig{Service: resource, Client: client})
+	case *rbacv1.ClusterRole:
+		return rigging.NewClusterRoleControl(rigging.ClusterRoleConfig{Role: *resource, Client: client})
+	case *rbacv1.ClusterRoleBinding:
+		return rigging.NewClusterRoleBindingControl(rigging.ClusterRoleBindingConfig{Binding: *resource, Client: client})
+	case *rbacv1.Role:
+		return rigging.NewRoleControl(rigging.RoleConfig{Role: *resource, Client: client})
+	case *rbacv1.RoleBinding:
+		return rigging.NewRoleBindingControl(rigging.RoleBindingConfig{Binding: *resource, Client: client})
+	case *appsv1.Deployment:
+		return rigging.NewDeploymentControl(rigging.DeploymentConfig{Deployment: resource, Client: client})
+	case *appsv1.DaemonSet:
+		return rigging.NewDSControl(rigging.DSConfig{DaemonSet: resource, Client: client})
+	}
+	return nil, trace.BadParameter("unsupported ingress controller resource %T", object)
+}
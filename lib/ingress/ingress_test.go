@@ -0,0 +1,117 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ingress
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/gravitational/gravity/lib/app/resources"
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/storage"
+
+	check "gopkg.in/check.v1"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestIngress(t *testing.T) { check.TestingT(t) }
+
+type IngressSuite struct{}
+
+var _ = check.Suite(&IngressSuite{})
+
+func (s *IngressSuite) TestConfiguresBundledControllers(c *check.C) {
+	for _, controllerType := range []string{storage.IngressControllerNginx, storage.IngressControllerHAProxy} {
+		comment := check.Commentf("controller type %v", controllerType)
+		controller := storage.NewIngressController(storage.IngressControllerSpecV2{
+			Type:               controllerType,
+			HTTPPort:           8080,
+			Replicas:           3,
+			DefaultCertificate: "default/ingress-tls",
+			NodeSelector:       map[string]string{"role": "ingress"},
+		})
+		c.Assert(controller.CheckAndSetDefaults(), check.IsNil)
+
+		objects := loadBundled(c, controllerType)
+		c.Assert(Configure(objects, controller), check.IsNil, comment)
+
+		var configured int
+		for _, object := range objects {
+			deployment, ok := object.(*appsv1.Deployment)
+			if !ok {
+				continue
+			}
+			c.Assert(deployment.Labels[constants.IngressControllerLabel], check.Equals, controllerType, comment)
+			if !servesIngress(deployment.Spec.Template.Spec) {
+				c.Assert(*deployment.Spec.Replicas, check.Equals, int32(1), comment)
+				continue
+			}
+			configured++
+			c.Assert(*deployment.Spec.Replicas, check.Equals, int32(3), comment)
+			c.Assert(deployment.Spec.Template.Spec.NodeSelector, check.DeepEquals,
+				map[string]string{"role": "ingress"}, comment)
+			container := deployment.Spec.Template.Spec.Containers[0]
+			c.Assert(hostPorts(container), check.DeepEquals,
+				map[string]int32{httpPortName: 8080, httpsPortName: 443}, comment)
+			c.Assert(container.Args[len(container.Args)-1], check.Equals,
+				"--default-ssl-certificate=default/ingress-tls", comment)
+		}
+		c.Assert(configured, check.Equals, 1, comment)
+	}
+}
+
+func (s *IngressSuite) TestReplacesDefaultCertificate(c *check.C) {
+	container := v1.Container{
+		Args:  []string{"--default-ssl-certificate=kube-system/old", "--v=2"},
+		Ports: []v1.ContainerPort{{Name: httpsPortName, ContainerPort: 443}},
+	}
+	controller := storage.NewIngressController(storage.IngressControllerSpecV2{
+		DefaultCertificate: "kube-system/new",
+	})
+	c.Assert(controller.CheckAndSetDefaults(), check.IsNil)
+	configureContainer(&container, controller)
+	c.Assert(container.Args, check.DeepEquals,
+		[]string{"--default-ssl-certificate=kube-system/new", "--v=2"})
+	c.Assert(container.Ports[0].HostPort, check.Equals, int32(443))
+}
+
+func loadBundled(c *check.C, controllerType string) (objects []runtime.Object) {
+	path := filepath.Join("..", "..", "assets", "ingress-app", "resources",
+		fmt.Sprintf("%v.yaml", controllerType))
+	err := resources.ForEachObjectInFile(path, func(object runtime.Object) error {
+		objects = append(objects, object)
+		return nil
+	})
+	c.Assert(err, check.IsNil)
+	for _, object := range objects {
+		_, err := newControl(nil, object)
+		// client is not set but the object type has to be supported
+		c.Assert(err, check.ErrorMatches, ".*missing parameter Client.*")
+	}
+	return objects
+}
+
+func hostPorts(container v1.Container) map[string]int32 {
+	ports := make(map[string]int32)
+	for _, port := range container.Ports {
+		ports[port.Name] = port.HostPort
+	}
+	return ports
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ingress
+
+import (
+	"context"
+	"time"
+
+	"github.com/gravitational/gravity/lib/app"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/schema"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ReconcilerConfig configures the ingress controller reconciler
+type ReconcilerConfig struct {
+	// Operator is the cluster operator service
+	Operator ops.Operator
+	// Apps is the cluster application service
+	Apps app.Applications
+	// Client is the Kubernetes client
+	Client *kubernetes.Clientset
+	// Interval is how often the controller is reconciled
+	Interval time.Duration
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+}
+
+// CheckAndSetDefaults validates the configuration and sets default values
+func (r *ReconcilerConfig) CheckAndSetDefaults() error {
+	if r.Operator == nil {
+		return trace.BadParameter("missing Operator")
+	}
+	if r.Apps == nil {
+		return trace.BadParameter("missing Apps")
+	}
+	if r.Client == nil {
+		return trace.BadParameter("missing Client")
+	}
+	if r.Interval == 0 {
+		r.Interval = defaults.IngressControllerSyncInterval
+	}
+	if r.FieldLogger == nil {
+		r.FieldLogger = logrus.WithField(trace.Component, "ingress")
+	}
+	return nil
+}
+
+// NewReconciler returns a new ingress controller reconciler
+func NewReconciler(config ReconcilerConfig) (*Reconciler, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &Reconciler{ReconcilerConfig: config}, nil
+}
+
+// Reconciler keeps the bundled ingress controller in sync with
+// its configuration and the version of the ingress application
+type Reconciler struct {
+	ReconcilerConfig
+	// applied is the configuration applied last
+	applied *appliedController
+	// cleaned is whether objects of controllers not in use have been removed
+	cleaned bool
+}
+
+// Run reconciles the ingress controller periodically until the context is canceled
+func (r *Reconciler) Run(ctx context.Context) error {
+	utils.RunPeriodically(ctx, r.Interval, r.FieldLogger,
+		"reconcile ingress controller", r.Sync)
+	return nil
+}
+
+// Sync applies the ingress controller if either its configuration or
+// the ingress application has changed since the last time, and removes
+// the controller if its configuration has been deleted
+func (r *Reconciler) Sync(ctx context.Context) error {
+	cluster, err := r.Operator.GetLocalSite()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	locator, err := cluster.App.Manifest.Dependencies.ByName(defaults.IngressAppName)
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil
+		}
+		return trace.Wrap(err)
+	}
+	if schema.ShouldSkipApp(cluster.App.Manifest, *locator) {
+		return nil
+	}
+	controller, err := r.Operator.GetIngressController(cluster.Key())
+	if err != nil && !trace.IsNotFound(err) {
+		return trace.Wrap(err)
+	}
+	if controller == nil {
+		return trace.Wrap(r.remove(ctx, *locator))
+	}
+	if err := controller.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	if r.applied != nil && r.applied.equals(*locator, controller) {
+		return nil
+	}
+	objects, err := Load(r.Apps, *locator, controller.GetType())
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if err := Configure(objects, controller); err != nil {
+		return trace.Wrap(err)
+	}
+	if r.applied == nil || r.applied.controller.GetType() != controller.GetType() {
+		r.cleaned = false
+	}
+	if err := r.cleanup(ctx, *locator, controller.GetType()); err != nil {
+		return trace.Wrap(err)
+	}
+	r.Infof("Applying %v ingress controller from %v.", controller.GetType(), locator)
+	if err := Apply(ctx, r.Client, objects); err != nil {
+		return trace.Wrap(err)
+	}
+	r.applied = &appliedController{locator: *locator, controller: controller}
+	return nil
+}
+
+// remove deletes the ingress controller after its configuration has been deleted
+func (r *Reconciler) remove(ctx context.Context, locator loc.Locator) error {
+	if r.applied != nil {
+		r.Infof("Ingress controller configuration has been deleted.")
+		r.applied = nil
+		r.cleaned = false
+	}
+	return trace.Wrap(r.cleanup(ctx, locator, ""))
+}
+
+// cleanup deletes the objects of all controller types other than
+// the specified one. It only runs once after the controller type has
+// changed since the objects of previous type are not known after restart
+func (r *Reconciler) cleanup(ctx context.Context, locator loc.Locator, keepType string) error {
+	if r.cleaned {
+		return nil
+	}
+	for _, controllerType := range []string{storage.IngressControllerNginx, storage.IngressControllerHAProxy} {
+		if controllerType == keepType {
+			continue
+		}
+		objects, err := Load(r.Apps, locator, controllerType)
+		if err != nil {
+			if trace.IsNotFound(err) {
+				continue
+			}
+			return trace.Wrap(err)
+		}
+		if err := Delete(ctx, r.Client, objects); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	r.cleaned = true
+	return nil
+}
+
+// appliedController is the ingress controller configuration applied
+// from the specific ingress application package
+type appliedController struct {
+	locator    loc.Locator
+	controller storage.IngressController
+}
+
+func (r appliedController) equals(locator loc.Locator, controller storage.IngressController) bool {
+	if !r.locator.IsEqualTo(locator) {
+		return false
+	}
+	dataA, errA := storage.MarshalIngressController(r.controller)
+	dataB, errB := storage.MarshalIngressController(controller)
+	return errA == nil && errB == nil && string(dataA) == string(dataB)
+}
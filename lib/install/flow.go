@@ -112,6 +112,7 @@ func (i *Installer) NewClusterRequest() ops.NewSiteRequest {
 		Provider:     i.CloudProvider,
 		DomainName:   i.SiteDomain,
 		Resources:    i.Resources,
+		License:      i.Config.License,
 		InstallToken: i.Config.Token,
 		ServiceUser: storage.OSUser{
 			Name: i.Config.ServiceUser.Name,
@@ -137,25 +138,33 @@ func (i *Installer) StartCLIInstall() (err error) {
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	err = i.checkAndSetServerProfile()
+	// fail early if configuration parameters are invalid, the operation
+	// validates them again before any hooks run
+	_, err = i.Cluster.App.Manifest.InstallerParameters().Resolve(i.Parameters)
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	err = checks.RunLocalChecks(checks.LocalChecksRequest{
-		Context:  i.Context,
-		Manifest: i.Cluster.App.Manifest,
-		Role:     i.Role,
-		Docker:   i.Docker,
-		Options: &validationpb.ValidateOptions{
-			VxlanPort: int32(i.VxlanPort),
-			DnsAddrs:  i.DNSConfig.Addrs,
-			DnsPort:   int32(i.DNSConfig.Port),
-		},
-		AutoFix: true,
-	})
+	err = i.checkAndSetServerProfile()
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	if !i.Lightweight {
+		err = checks.RunLocalChecks(checks.LocalChecksRequest{
+			Context:  i.Context,
+			Manifest: i.Cluster.App.Manifest,
+			Role:     i.Role,
+			Docker:   i.Docker,
+			Options: &validationpb.ValidateOptions{
+				VxlanPort: int32(i.VxlanPort),
+				DnsAddrs:  i.DNSConfig.Addrs,
+				DnsPort:   int32(i.DNSConfig.Port),
+			},
+			AutoFix: true,
+		})
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
 	return i.LaunchOperation(ops.CreateSiteInstallOperationRequest{
 		SiteDomain: i.Cluster.Domain,
 		AccountID:  i.Cluster.AccountID,
@@ -163,13 +172,15 @@ func (i *Installer) StartCLIInstall() (err error) {
 		Provisioner: schema.ProvisionerOnPrem,
 		Variables: storage.OperationVariables{
 			System: storage.SystemVariables{
-				Docker: i.Docker,
+				Docker:      i.Docker,
+				Lightweight: i.Lightweight,
 			},
 			OnPrem: storage.OnPremVariables{
 				PodCIDR:     i.PodCIDR,
 				ServiceCIDR: i.ServiceCIDR,
 				VxlanPort:   i.VxlanPort,
 			},
+			Parameters: i.Parameters,
 		},
 		Profiles: ServerRequirements(*i.flavor),
 	})
@@ -496,9 +507,42 @@ func (i *Installer) getFlavor() (*schema.Flavor, error) {
 	if flavor == nil {
 		return nil, trace.NotFound("install flavor %q is not found", i.Flavor)
 	}
+	if i.Lightweight {
+		return singleNodeFlavor(i.Cluster.App.Manifest, *flavor, i.Role)
+	}
 	return flavor, nil
 }
 
+// singleNodeFlavor returns the flavor that installs a single node with
+// the specified profile. If the profile is not set, the first profile of the
+// provided flavor that can run a master node is used
+func singleNodeFlavor(manifest schema.Manifest, flavor schema.Flavor, profile string) (*schema.Flavor, error) {
+	if profile == "" {
+		for _, node := range flavor.Nodes {
+			nodeProfile, err := manifest.NodeProfiles.ByName(node.Profile)
+			if err != nil {
+				return nil, trace.Wrap(err)
+			}
+			if nodeProfile.ServiceRole != schema.ServiceRoleNode {
+				profile = node.Profile
+				break
+			}
+		}
+	}
+	if profile == "" {
+		return nil, trace.BadParameter("install flavor %q does not have "+
+			"profiles that can run a master node", flavor.Name)
+	}
+	return &schema.Flavor{
+		Name:        flavor.Name,
+		Description: flavor.Description,
+		Nodes: []schema.FlavorNode{{
+			Profile: profile,
+			Count:   1,
+		}},
+	}, nil
+}
+
 func (i *Installer) checkAndSetServerProfile() error {
 	if i.Role == "" {
 		for _, node := range i.flavor.Nodes {
@@ -577,8 +621,10 @@ func GetServers(op ops.SiteOperation, servers []checks.ServerInfo) (*ops.Operati
 			Hostname:    serverInfo.GetHostname(),
 			Role:        serverInfo.Role,
 			OSInfo:      serverInfo.GetOS(),
+			Arch:        serverInfo.GetArch(),
 			Mounts:      mounts,
 			User:        serverInfo.GetUser(),
+			Hardware:    serverInfo.GetHardware(),
 			Provisioner: op.Provisioner,
 			Created:     time.Now().UTC(),
 		}
@@ -0,0 +1,57 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"github.com/gravitational/gravity/lib/schema"
+
+	"gopkg.in/check.v1"
+)
+
+type FlowSuite struct{}
+
+var _ = check.Suite(&FlowSuite{})
+
+func (s *FlowSuite) TestSingleNodeFlavor(c *check.C) {
+	manifest := schema.Manifest{
+		NodeProfiles: schema.NodeProfiles{
+			{Name: "worker", ServiceRole: schema.ServiceRoleNode},
+			{Name: "master", ServiceRole: schema.ServiceRoleMaster},
+		},
+	}
+	flavor := schema.Flavor{
+		Name: "large",
+		Nodes: []schema.FlavorNode{
+			{Profile: "worker", Count: 5},
+			{Profile: "master", Count: 3},
+		},
+	}
+	single, err := singleNodeFlavor(manifest, flavor, "")
+	c.Assert(err, check.IsNil)
+	c.Assert(*single, check.DeepEquals, schema.Flavor{
+		Name:  "large",
+		Nodes: []schema.FlavorNode{{Profile: "master", Count: 1}},
+	})
+
+	single, err = singleNodeFlavor(manifest, flavor, "worker")
+	c.Assert(err, check.IsNil)
+	c.Assert(single.Nodes, check.DeepEquals, []schema.FlavorNode{{Profile: "worker", Count: 1}})
+
+	flavor.Nodes = flavor.Nodes[:1]
+	_, err = singleNodeFlavor(manifest, flavor, "")
+	c.Assert(err, check.NotNil)
+}
@@ -231,6 +231,7 @@ func (f *fsmEngine) GetExecutor(p fsm.ExecutorParams, remote fsm.Remote) (fsm.Ph
 		FieldLogger: logrus.WithField(constants.FieldPhase, p.Phase.ID),
 		Key:         p.Key(),
 		Operator:    f.Operator,
+		Phase:       p.Phase.ID,
 	}
 	executor, err := f.Spec(p, remote)
 	if err != nil {
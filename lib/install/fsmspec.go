@@ -41,6 +41,11 @@ func FSMSpec(config FSMConfig) fsm.FSMSpecFunc {
 			return phases.NewConfigure(p,
 				config.Operator)
 
+		case strings.HasPrefix(p.Phase.ID, phases.OSPackagesPhase):
+			return phases.NewOSPackages(p,
+				config.Operator,
+				config.Apps, remote)
+
 		case strings.HasPrefix(p.Phase.ID, phases.BootstrapPhase):
 			return phases.NewBootstrap(p,
 				config.Operator,
@@ -91,6 +96,26 @@ func FSMSpec(config FSMConfig) fsm.FSMSpecFunc {
 				config.Operator,
 				client)
 
+		case p.Phase.ID == phases.NamespacesPhase:
+			client, _, err := httplib.GetClusterKubeClient(config.DNSConfig.Addr())
+			if err != nil {
+				return nil, trace.Wrap(err)
+			}
+			return phases.NewNamespaces(p,
+				config.Operator,
+				config.LocalApps,
+				client)
+
+		case p.Phase.ID == phases.StorageClassPhase:
+			client, _, err := httplib.GetClusterKubeClient(config.DNSConfig.Addr())
+			if err != nil {
+				return nil, trace.Wrap(err)
+			}
+			return phases.NewStorageClass(p,
+				config.Operator,
+				config.LocalApps,
+				client)
+
 		case p.Phase.ID == phases.ResourcesPhase:
 			return phases.NewResources(p,
 				config.Operator)
@@ -156,12 +156,16 @@ type Config struct {
 	SiteDomain string
 	// Flavor is installation flavor
 	Flavor string
+	// Parameters maps application configuration parameters to values
+	Parameters map[string]string
 	// Role is server role
 	Role string
 	// AppPackage is the application being installed
 	AppPackage *loc.Locator
 	// Resources is a file with resource specs
 	Resources []byte
+	// License is the cluster license
+	License string
 	// EventsC is channel with events indicating install progress
 	EventsC chan Event
 	// SystemDevice is a device for gravity data
@@ -203,6 +207,12 @@ type Config struct {
 	GCENodeTags []string
 	// NewProcess is used to launch gravity API server process
 	NewProcess process.NewGravityProcess
+	// PackageCache is the directory with pre-seeded packages on every node
+	PackageCache string
+	// Lightweight installs a minimal single-node cluster for local development:
+	// preflight checks are skipped, optional system applications are not
+	// installed and planet runs with reduced resource settings
+	Lightweight bool
 	// Silent allows installer to output its progress
 	localenv.Silent
 }
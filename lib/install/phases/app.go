@@ -54,9 +54,11 @@ func NewHook(p fsm.ExecutorParams, operator ops.Operator, apps app.Applications,
 		FieldLogger: logrus.WithFields(logrus.Fields{
 			constants.FieldPhase: p.Phase.ID,
 		}),
-		Key:      opKey(p.Plan),
-		Operator: operator,
-		Server:   p.Phase.Data.Server,
+		Key:       opKey(p.Plan),
+		Operator:  operator,
+		Server:    p.Phase.Data.Server,
+		Component: ops.LogComponentApp,
+		Phase:     p.Phase.ID,
 	}
 	return &hookExecutor{
 		FieldLogger:    logger,
@@ -94,11 +96,18 @@ func (p *hookExecutor) Execute(ctx context.Context) error {
 
 // runHooks runs specified app hooks
 func (p *hookExecutor) runHooks(ctx context.Context, hooks ...schema.HookType) error {
+	operation, err := p.Operator.GetSiteOperation(p.Key())
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	// pass application configuration parameters to hooks
+	env := schema.ParameterEnv(operation.GetVars().Parameters)
 	for _, hook := range hooks {
 		locator := *p.Phase.Data.Package
 		req := app.HookRunRequest{
 			Application: locator,
 			Hook:        hook,
+			Env:         env,
 			ServiceUser: storage.OSUser{
 				Name: p.ServiceUser.Name,
 				UID:  strconv.Itoa(p.ServiceUser.UID),
@@ -28,6 +28,7 @@ import (
 	"github.com/gravitational/gravity/lib/constants"
 	"github.com/gravitational/gravity/lib/defaults"
 	"github.com/gravitational/gravity/lib/devicemapper"
+	"github.com/gravitational/gravity/lib/firewall"
 	"github.com/gravitational/gravity/lib/fsm"
 	"github.com/gravitational/gravity/lib/ops"
 	"github.com/gravitational/gravity/lib/ops/opsservice"
@@ -82,9 +83,11 @@ func NewBootstrap(p fsm.ExecutorParams, operator ops.Operator, apps app.Applicat
 			constants.FieldAdvertiseIP: p.Phase.Data.Server.AdvertiseIP,
 			constants.FieldHostname:    p.Phase.Data.Server.Hostname,
 		}),
-		Key:      opKey(p.Plan),
-		Operator: operator,
-		Server:   p.Phase.Data.Server,
+		Key:       opKey(p.Plan),
+		Operator:  operator,
+		Server:    p.Phase.Data.Server,
+		Component: ops.LogComponentSystem,
+		Phase:     p.Phase.ID,
 	}
 	return &bootstrapExecutor{
 		FieldLogger:      logger,
@@ -145,6 +148,31 @@ func (p *bootstrapExecutor) Execute(ctx context.Context) error {
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	err = p.configureFirewall(ctx)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// configureFirewall opens the ports required by the node in the host
+// firewall if the application enables firewall management
+func (p *bootstrapExecutor) configureFirewall(ctx context.Context) error {
+	config, err := firewall.NodeConfig(p.Application.Manifest, p.Phase.Data.Server.Role,
+		p.InstallOperation.InstallExpand.Vars.OnPrem)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if config == nil {
+		return nil
+	}
+	p.Progress.NextStep("Configuring host firewall")
+	config.FieldLogger = p.FieldLogger
+	backend, err := firewall.Apply(ctx, *config)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	p.Infof("Opened %v ports with %v.", len(config.Rules), backend)
 	return nil
 }
 
@@ -34,6 +34,8 @@ func NewChecks(p fsm.ExecutorParams, operator ops.Operator, key ops.SiteOperatio
 		FieldLogger: log.WithField(constants.FieldPhase, p.Phase.ID),
 		Key:         opKey(p.Plan),
 		Operator:    operator,
+		Component:   ops.LogComponentChecks,
+		Phase:       p.Phase.ID,
 	}
 	return &checksExecutor{
 		FieldLogger:    logger,
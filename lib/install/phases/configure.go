@@ -33,8 +33,10 @@ func NewConfigure(p fsm.ExecutorParams, operator ops.Operator) (*configureExecut
 		FieldLogger: logrus.WithFields(logrus.Fields{
 			constants.FieldPhase: p.Phase.ID,
 		}),
-		Key:      opKey(p.Plan),
-		Operator: operator,
+		Key:       opKey(p.Plan),
+		Operator:  operator,
+		Component: ops.LogComponentPackages,
+		Phase:     p.Phase.ID,
 	}
 	return &configureExecutor{
 		FieldLogger:    logger,
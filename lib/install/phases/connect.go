@@ -52,9 +52,11 @@ func NewConnectInstaller(p fsm.ExecutorParams, operator ops.Operator) (*connectE
 		FieldLogger: logrus.WithFields(logrus.Fields{
 			constants.FieldPhase: p.Phase.ID,
 		}),
-		Key:      p.Key(),
-		Operator: operator,
-		Server:   p.Phase.Data.Server,
+		Key:       p.Key(),
+		Operator:  operator,
+		Server:    p.Phase.Data.Server,
+		Component: ops.LogComponentInstaller,
+		Phase:     p.Phase.ID,
 	}
 	return &connectExecutor{
 		FieldLogger:       logger,
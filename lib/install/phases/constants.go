@@ -25,6 +25,8 @@ const (
 	DecryptPhase = "/decrypt"
 	// ConfigurePhase is a phase that configures cluster packages
 	ConfigurePhase = "/configure"
+	// OSPackagesPhase is a phase that installs bundled OS packages missing on the nodes
+	OSPackagesPhase = "/ospackages"
 	// BootstrapPhase is a phase that prepares the nodes for installation
 	BootstrapPhase = "/bootstrap"
 	// PullPhase is a phase that pulls configured packages
@@ -41,6 +43,12 @@ const (
 	RBACPhase = "/rbac"
 	// CorednsPhase is a phase that generates coredns configuration for the cluster
 	CorednsPhase = "/coredns"
+	// NamespacesPhase is a phase that creates application namespaces
+	// with their resource quotas and limit ranges
+	NamespacesPhase = "/namespaces"
+	// StorageClassPhase is a phase that creates the default storage class
+	// of the storage provider declared by the application
+	StorageClassPhase = "/storage-class"
 	// ResourcesPhase is a phase that creates user supplied Kubernetes resources
 	ResourcesPhase = "/resources"
 	// ExportPhase is a phase that exports application layers to registries
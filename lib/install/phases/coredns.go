@@ -52,6 +52,8 @@ func NewCorednsPhase(p fsm.ExecutorParams, operator ops.Operator, client *kubern
 		FieldLogger: log.WithField(constants.FieldPhase, p.Phase.ID),
 		Key:         opKey(p.Plan),
 		Operator:    operator,
+		Component:   ops.LogComponentDNS,
+		Phase:       p.Phase.ID,
 	}
 
 	cluster, err := operator.GetSite(ops.SiteKey{
@@ -44,9 +44,11 @@ func NewEnableElectionPhase(p fsm.ExecutorParams, operator ops.Operator) (fsm.Ph
 			constants.FieldAdvertiseIP: p.Phase.Data.Server.AdvertiseIP,
 			constants.FieldHostname:    p.Phase.Data.Server.Hostname,
 		}),
-		Key:      opKey(p.Plan),
-		Operator: operator,
-		Server:   p.Phase.Data.Server,
+		Key:       opKey(p.Plan),
+		Operator:  operator,
+		Server:    p.Phase.Data.Server,
+		Component: ops.LogComponentElection,
+		Phase:     p.Phase.ID,
 	}
 	return &enableElectionExecutor{
 		FieldLogger:    logger,
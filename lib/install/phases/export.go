@@ -60,9 +60,11 @@ func NewExport(p fsm.ExecutorParams, operator ops.Operator, packages pack.Packag
 			constants.FieldAdvertiseIP: p.Phase.Data.Server.AdvertiseIP,
 			constants.FieldHostname:    p.Phase.Data.Server.Hostname,
 		}),
-		Key:      opKey(p.Plan),
-		Operator: operator,
-		Server:   p.Phase.Data.Server,
+		Key:       opKey(p.Plan),
+		Operator:  operator,
+		Server:    p.Phase.Data.Server,
+		Component: ops.LogComponentRegistry,
+		Phase:     p.Phase.ID,
 	}
 	return &exportExecutor{
 		FieldLogger:    logger,
@@ -143,7 +145,7 @@ func (p *exportExecutor) unpackApp(locator loc.Locator) error {
 	p.Progress.NextStep("Unpacking application %v:%v",
 		locator.Name, locator.Version)
 	return trace.Wrap(pack.UnpackIfNotUnpacked(p.Packages, locator,
-		p.packagePath(locator), nil))
+		p.packagePath(locator), pack.UnpackOptions{}))
 }
 
 func (p *exportExecutor) exportApp(ctx context.Context, locator loc.Locator) error {
@@ -0,0 +1,97 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package phases
+
+import (
+	"context"
+
+	"github.com/gravitational/gravity/lib/app"
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/fsm"
+	libkubernetes "github.com/gravitational/gravity/lib/kubernetes"
+	"github.com/gravitational/gravity/lib/ops"
+
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/kubernetes"
+)
+
+// NewNamespaces returns executor that creates namespaces declared
+// in the application manifest
+func NewNamespaces(p fsm.ExecutorParams, operator ops.Operator, apps app.Applications, client *kubernetes.Clientset) (*namespacesExecutor, error) {
+	if p.Phase.Data == nil || p.Phase.Data.Package == nil {
+		return nil, trace.BadParameter("application package is required")
+	}
+	logger := &fsm.Logger{
+		FieldLogger: logrus.WithFields(logrus.Fields{
+			constants.FieldPhase: p.Phase.ID,
+		}),
+		Key:       opKey(p.Plan),
+		Operator:  operator,
+		Server:    p.Phase.Data.Server,
+		Component: ops.LogComponentKubernetes,
+		Phase:     p.Phase.ID,
+	}
+	return &namespacesExecutor{
+		FieldLogger:    logger,
+		ExecutorParams: p,
+		Apps:           apps,
+		Client:         client,
+	}, nil
+}
+
+type namespacesExecutor struct {
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+	// ExecutorParams is common executor params
+	fsm.ExecutorParams
+	// Apps is the local application service
+	Apps app.Applications
+	// Client is the Kubernetes client
+	Client *kubernetes.Clientset
+}
+
+// Execute creates application namespaces with their quotas and limit ranges
+func (p *namespacesExecutor) Execute(ctx context.Context) error {
+	p.Progress.NextStep("Creating application namespaces")
+	application, err := p.Apps.GetApp(*p.Phase.Data.Package)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = libkubernetes.UpsertNamespaces(p.Client.CoreV1(), application.Package.Name,
+		application.Manifest.Namespaces, p.FieldLogger)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// Rollback is no-op for this phase: the namespaces are removed together
+// with the cluster and deleting them might destroy data
+func (*namespacesExecutor) Rollback(ctx context.Context) error {
+	return nil
+}
+
+// PreCheck is no-op for this phase
+func (*namespacesExecutor) PreCheck(ctx context.Context) error {
+	return nil
+}
+
+// PostCheck is no-op for this phase
+func (*namespacesExecutor) PostCheck(ctx context.Context) error {
+	return nil
+}
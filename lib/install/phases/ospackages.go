@@ -0,0 +1,221 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package phases
+
+import (
+	"archive/tar"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gravitational/gravity/lib/app"
+	"github.com/gravitational/gravity/lib/archive"
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/fsm"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/schema"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/satellite/monitoring"
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+)
+
+// NewOSPackages returns a new executor that installs the OS packages
+// bundled with the application on the node if they are missing
+func NewOSPackages(p fsm.ExecutorParams, operator ops.Operator, apps app.Applications, remote fsm.Remote) (*osPackagesExecutor, error) {
+	if p.Phase.Data == nil || p.Phase.Data.Server == nil {
+		return nil, trace.BadParameter("server is required: %#v", p.Phase.Data)
+	}
+	if p.Phase.Data.Package == nil {
+		return nil, trace.BadParameter("application package is required: %#v", p.Phase.Data)
+	}
+	application, err := apps.GetApp(*p.Phase.Data.Package)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	profile, err := application.Manifest.NodeProfiles.ByName(p.Phase.Data.Server.Role)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	logger := &fsm.Logger{
+		FieldLogger: logrus.WithFields(logrus.Fields{
+			constants.FieldPhase:       p.Phase.ID,
+			constants.FieldAdvertiseIP: p.Phase.Data.Server.AdvertiseIP,
+			constants.FieldHostname:    p.Phase.Data.Server.Hostname,
+		}),
+		Key:       opKey(p.Plan),
+		Operator:  operator,
+		Server:    p.Phase.Data.Server,
+		Component: ops.LogComponentPackages,
+		Phase:     p.Phase.ID,
+	}
+	return &osPackagesExecutor{
+		FieldLogger:    logger,
+		ExecutorParams: p,
+		Apps:           apps,
+		Packages:       profile.Requirements.OSPackages,
+		remote:         remote,
+	}, nil
+}
+
+type osPackagesExecutor struct {
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+	// ExecutorParams is common executor params
+	fsm.ExecutorParams
+	// Apps is the application service the package files are read from
+	Apps app.Applications
+	// Packages lists OS packages required on the node
+	Packages []schema.OSPackage
+	// remote specifies the server remote control interface
+	remote fsm.Remote
+}
+
+// Execute installs the required OS packages missing on the node
+func (p *osPackagesExecutor) Execute(ctx context.Context) error {
+	missing, err := schema.MissingOSPackages(p.Packages)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if len(missing) == 0 {
+		p.Info("All required OS packages are installed.")
+		return nil
+	}
+	release, err := monitoring.GetOSRelease()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	var patterns, names []string
+	for _, pkg := range missing {
+		files := pkg.FilesFor(*release)
+		if len(files) == 0 {
+			return trace.NotFound("OS package %v is not installed and is not bundled for %v",
+				pkg.Name, release.Name())
+		}
+		patterns = append(patterns, files...)
+		names = append(names, pkg.Name)
+	}
+	p.Progress.NextStep("Installing OS packages %v", strings.Join(names, ", "))
+	dir, err := ioutil.TempDir("", "ospackages")
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	defer os.RemoveAll(dir)
+	paths, err := p.extractFiles(patterns, dir)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(installOSPackages(ctx, paths, p.FieldLogger))
+}
+
+// extractFiles extracts the application resource files matching
+// the specified patterns into dir and returns their paths
+func (p *osPackagesExecutor) extractFiles(patterns []string, dir string) (paths []string, err error) {
+	reader, err := p.Apps.GetAppResources(*p.Phase.Data.Package)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer reader.Close()
+	stream, err := archive.DecompressStream(reader)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer stream.Close()
+	err = archive.TarGlob(tar.NewReader(stream), defaults.ResourcesDir, []string{"*"},
+		func(match string, file io.Reader) error {
+			if !schema.MatchesFile(patterns, match) {
+				return nil
+			}
+			path := filepath.Join(dir, filepath.Base(match))
+			if err := utils.CopyReaderWithPerms(path, file, defaults.SharedReadMask); err != nil {
+				return trace.Wrap(err)
+			}
+			paths = append(paths, path)
+			return nil
+		})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if len(paths) == 0 {
+		return nil, trace.NotFound("no OS package files matching %v found in %v",
+			patterns, p.Phase.Data.Package)
+	}
+	return paths, nil
+}
+
+// installOSPackages installs the specified package files with the package
+// manager matching their format. The files are installed in a single
+// transaction so that they can depend on each other
+func installOSPackages(ctx context.Context, paths []string, logger logrus.FieldLogger) error {
+	var rpms, debs []string
+	for _, path := range paths {
+		switch filepath.Ext(path) {
+		case ".rpm":
+			rpms = append(rpms, path)
+		case ".deb":
+			debs = append(debs, path)
+		default:
+			return trace.BadParameter("unsupported OS package file %v, "+
+				"only .rpm and .deb files are supported", filepath.Base(path))
+		}
+	}
+	if len(rpms) != 0 {
+		out, err := utils.RunCommand(ctx, logger, append([]string{"rpm", "-Uvh", "--replacepkgs"}, rpms...)...)
+		if err != nil {
+			return trace.Wrap(err, "failed to install OS packages: %s", out)
+		}
+	}
+	if len(debs) != 0 {
+		out, err := utils.RunCommand(ctx, logger, append([]string{"dpkg", "-i"}, debs...)...)
+		if err != nil {
+			return trace.Wrap(err, "failed to install OS packages: %s", out)
+		}
+	}
+	return nil
+}
+
+// Rollback is no-op for this phase: installed OS packages are left in place
+func (*osPackagesExecutor) Rollback(ctx context.Context) error {
+	return nil
+}
+
+// PreCheck makes sure this phase is executed on a proper server
+func (p *osPackagesExecutor) PreCheck(ctx context.Context) error {
+	return trace.Wrap(p.remote.CheckServer(ctx, *p.Phase.Data.Server))
+}
+
+// PostCheck makes sure all required OS packages are installed
+func (p *osPackagesExecutor) PostCheck(ctx context.Context) error {
+	missing, err := schema.MissingOSPackages(p.Packages)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if len(missing) != 0 {
+		var names []string
+		for _, pkg := range missing {
+			names = append(names, pkg.Name)
+		}
+		return trace.NotFound("OS packages %v are still missing after installation",
+			strings.Join(names, ", "))
+	}
+	return nil
+}
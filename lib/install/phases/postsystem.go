@@ -36,7 +36,6 @@ import (
 	"github.com/gravitational/gravity/lib/utils"
 	"github.com/gravitational/satellite/agent/proto/agentpb"
 
-	dockerarchive "github.com/docker/docker/pkg/archive"
 	"github.com/gravitational/trace"
 	"github.com/sirupsen/logrus"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -49,9 +48,11 @@ func NewWait(p fsm.ExecutorParams, operator ops.Operator, client *kubernetes.Cli
 		FieldLogger: logrus.WithFields(logrus.Fields{
 			constants.FieldPhase: p.Phase.ID,
 		}),
-		Key:      opKey(p.Plan),
-		Operator: operator,
-		Server:   p.Phase.Data.Server,
+		Key:       opKey(p.Plan),
+		Operator:  operator,
+		Server:    p.Phase.Data.Server,
+		Component: ops.LogComponentKubernetes,
+		Phase:     p.Phase.ID,
 	}
 	return &waitExecutor{
 		FieldLogger:    logger,
@@ -132,9 +133,11 @@ func NewHealth(p fsm.ExecutorParams, operator ops.Operator) (*healthExecutor, er
 		FieldLogger: logrus.WithFields(logrus.Fields{
 			constants.FieldPhase: p.Phase.ID,
 		}),
-		Key:      opKey(p.Plan),
-		Operator: operator,
-		Server:   p.Phase.Data.Server,
+		Key:       opKey(p.Plan),
+		Operator:  operator,
+		Server:    p.Phase.Data.Server,
+		Component: ops.LogComponentHealth,
+		Phase:     p.Phase.ID,
 	}
 	return &healthExecutor{
 		FieldLogger:    logger,
@@ -203,9 +206,11 @@ func NewRBAC(p fsm.ExecutorParams, operator ops.Operator, apps app.Applications,
 		FieldLogger: logrus.WithFields(logrus.Fields{
 			constants.FieldPhase: p.Phase.ID,
 		}),
-		Key:      opKey(p.Plan),
-		Operator: operator,
-		Server:   p.Phase.Data.Server,
+		Key:       opKey(p.Plan),
+		Operator:  operator,
+		Server:    p.Phase.Data.Server,
+		Component: ops.LogComponentKubernetes,
+		Phase:     p.Phase.ID,
 	}
 	return &rbacExecutor{
 		FieldLogger:    logger,
@@ -234,7 +239,7 @@ func (p *rbacExecutor) Execute(ctx context.Context) error {
 		return trace.Wrap(err)
 	}
 	defer reader.Close()
-	stream, err := dockerarchive.DecompressStream(reader)
+	stream, err := archive.DecompressStream(reader)
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -280,9 +285,11 @@ func NewResources(p fsm.ExecutorParams, operator ops.Operator) (*resourcesExecut
 		FieldLogger: logrus.WithFields(logrus.Fields{
 			constants.FieldPhase: p.Phase.ID,
 		}),
-		Key:      opKey(p.Plan),
-		Operator: operator,
-		Server:   p.Phase.Data.Server,
+		Key:       opKey(p.Plan),
+		Operator:  operator,
+		Server:    p.Phase.Data.Server,
+		Component: ops.LogComponentKubernetes,
+		Phase:     p.Phase.ID,
 	}
 	return &resourcesExecutor{
 		FieldLogger:    logger,
@@ -23,14 +23,18 @@ import (
 
 	"github.com/gravitational/gravity/lib/app"
 	"github.com/gravitational/gravity/lib/app/service"
+	blobfs "github.com/gravitational/gravity/lib/blob/fs"
 	"github.com/gravitational/gravity/lib/constants"
 	"github.com/gravitational/gravity/lib/defaults"
 	"github.com/gravitational/gravity/lib/fsm"
 	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/ops"
 	"github.com/gravitational/gravity/lib/pack"
+	"github.com/gravitational/gravity/lib/pack/localpack"
 	"github.com/gravitational/gravity/lib/schema"
 	"github.com/gravitational/gravity/lib/state"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/storage/keyval"
 	"github.com/gravitational/gravity/lib/systeminfo"
 	"github.com/gravitational/gravity/lib/utils"
 
@@ -60,6 +64,11 @@ func NewPull(p fsm.ExecutorParams, operator ops.Operator, wizardPack, localPack
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+	// pull the runtime variant built for the server's architecture
+	runtimePackage, err = pack.ResolveArch(wizardPack, *runtimePackage, p.Phase.Data.Server.Arch)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
 
 	logger := &fsm.Logger{
 		FieldLogger: logrus.WithFields(logrus.Fields{
@@ -67,9 +76,11 @@ func NewPull(p fsm.ExecutorParams, operator ops.Operator, wizardPack, localPack
 			constants.FieldAdvertiseIP: p.Phase.Data.Server.AdvertiseIP,
 			constants.FieldHostname:    p.Phase.Data.Server.Hostname,
 		}),
-		Key:      opKey(p.Plan),
-		Operator: operator,
-		Server:   p.Phase.Data.Server,
+		Key:       opKey(p.Plan),
+		Operator:  operator,
+		Server:    p.Phase.Data.Server,
+		Component: ops.LogComponentPackages,
+		Phase:     p.Phase.ID,
 	}
 	return &pullExecutor{
 		FieldLogger:    logger,
@@ -103,10 +114,28 @@ type pullExecutor struct {
 	remote fsm.Remote
 	// runtimePackage specifies the runtime container package to pull
 	runtimePackage loc.Locator
+	// cache is the optional package cache pre-seeded on the node
+	cache *packageCache
 }
 
 // Execute executes the pull phase
 func (p *pullExecutor) Execute(ctx context.Context) error {
+	cacheDir := p.Phase.Data.PackageCache
+	if cacheDir == "" {
+		cacheDir = bakedPackageCache()
+	}
+	if cacheDir != "" {
+		cache, err := openPackageCache(cacheDir)
+		if err != nil {
+			// the cache is an optimization, fall back to the installer
+			p.Warnf("Failed to open package cache in %v, will pull all packages from the installer: %v.",
+				cacheDir, trace.DebugReport(err))
+		} else {
+			p.Infof("Using package cache in %v.", cacheDir)
+			defer cache.Close()
+			p.cache = cache
+		}
+	}
 	err := p.pullUserApplication()
 	if err != nil {
 		return trace.Wrap(err)
@@ -115,7 +144,7 @@ func (p *pullExecutor) Execute(ctx context.Context) error {
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	err = p.pullConfiguredPackages()
+	err = p.pullConfiguredPackages(ctx)
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -149,11 +178,30 @@ func (p *pullExecutor) pullUserApplication() error {
 		SrcApp:      p.WizardApps,
 		DstApp:      p.LocalApps,
 		Package:     *p.Phase.Data.Package,
+		Cache:       p.cachedPackages(),
+		Parallel:    defaults.PullPackagesConcurrency,
 	})
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	return nil
+	// the runtime variant built for the node architecture
+	// is not an application dependency
+	_, err = p.LocalPackages.ReadPackageEnvelope(p.runtimePackage)
+	if err == nil {
+		return nil
+	}
+	if !trace.IsNotFound(err) {
+		return trace.Wrap(err)
+	}
+	p.Infof("Pulling runtime package %v.", p.runtimePackage)
+	_, err = service.PullPackage(service.PackagePullRequest{
+		FieldLogger: p.FieldLogger,
+		SrcPack:     p.WizardPackages,
+		DstPack:     p.LocalPackages,
+		Package:     p.runtimePackage,
+		Cache:       p.cachedPackages(),
+	})
+	return trace.Wrap(err)
 }
 
 // applyPackageLabels adds labels to system packages in order for update
@@ -194,7 +242,7 @@ func (p *pullExecutor) applyPackageLabels() error {
 	return nil
 }
 
-func (p *pullExecutor) pullConfiguredPackages() (err error) {
+func (p *pullExecutor) pullConfiguredPackages(ctx context.Context) (err error) {
 	p.Progress.NextStep("Pulling configured packages")
 	p.Info("Pulling configured packages.")
 	var envelopes []pack.PackageEnvelope
@@ -206,16 +254,25 @@ func (p *pullExecutor) pullConfiguredPackages() (err error) {
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	requests := make([]service.PackagePullRequest, 0, len(envelopes))
 	for _, e := range envelopes {
-		_, err := service.PullPackage(service.PackagePullRequest{
-			SrcPack: p.WizardPackages,
-			DstPack: p.LocalPackages,
-			Package: e.Locator,
-			Labels:  e.RuntimeLabels,
+		requests = append(requests, service.PackagePullRequest{
+			FieldLogger: p.FieldLogger,
+			SrcPack:     p.WizardPackages,
+			DstPack:     p.LocalPackages,
+			Package:     e.Locator,
+			Labels:      e.RuntimeLabels,
+			Cache:       p.cachedPackages(),
 		})
-		if err != nil {
-			return trace.Wrap(err)
-		}
+	}
+	err = service.PullPackages(ctx, service.PackagesPullRequest{
+		Requests: requests,
+		Parallel: defaults.PullPackagesConcurrency,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	for _, e := range envelopes {
 		if isSecret(e) {
 			err := p.unpackSecrets(e)
 			if err != nil {
@@ -321,7 +378,12 @@ func (p *pullExecutor) unpackPackages() error {
 	}
 	for _, locator := range locators {
 		p.Infof("Unpacking package %v.", locator)
-		err := pack.Unpack(p.LocalPackages, locator, "", nil)
+		targetDir, err := pack.DefaultUnpackDir(locator)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		// packages pre-unpacked into a node image are not unpacked again
+		err = pack.UnpackIfNotUnpacked(p.LocalPackages, locator, targetDir, pack.UnpackOptions{})
 		if err != nil {
 			return trace.Wrap(err)
 		}
@@ -352,3 +414,71 @@ func (*pullExecutor) PostCheck(ctx context.Context) error {
 func isSecret(e pack.PackageEnvelope) bool {
 	return e.HasLabel(pack.PurposeLabel, pack.PurposePlanetSecrets)
 }
+
+// cachedPackages returns the package service of the package cache
+// or nil if the node has no package cache
+func (p *pullExecutor) cachedPackages() pack.PackageService {
+	if p.cache == nil {
+		return nil
+	}
+	return p.cache.PackageService
+}
+
+// bakedPackageCache returns the directory with the packages pre-seeded into
+// the node image by gravity baker or an empty string if the node has none
+func bakedPackageCache() string {
+	stateDir, err := state.GetStateDir()
+	if err != nil {
+		return ""
+	}
+	dir := filepath.Join(stateDir, defaults.PackageCacheDir)
+	if _, err := utils.StatFile(filepath.Join(dir, defaults.GravityDBFile)); err != nil {
+		return ""
+	}
+	return dir
+}
+
+// openPackageCache opens the read-only package cache in the specified directory.
+// The cache has the same layout as the installer directory
+func openPackageCache(dir string) (*packageCache, error) {
+	dbPath := filepath.Join(dir, defaults.GravityDBFile)
+	if _, err := utils.StatFile(dbPath); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	backend, err := keyval.NewBolt(keyval.BoltConfig{
+		Path:     dbPath,
+		Readonly: true,
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	objects, err := blobfs.New(filepath.Join(dir, defaults.PackagesDir))
+	if err != nil {
+		backend.Close()
+		return nil, trace.Wrap(err)
+	}
+	packages, err := localpack.New(localpack.Config{
+		Backend:     backend,
+		UnpackedDir: filepath.Join(dir, defaults.PackagesDir, defaults.UnpackedDir),
+		Objects:     objects,
+	})
+	if err != nil {
+		backend.Close()
+		return nil, trace.Wrap(err)
+	}
+	return &packageCache{
+		PackageService: packages,
+		backend:        backend,
+	}, nil
+}
+
+// packageCache is a package service with pre-seeded packages
+type packageCache struct {
+	pack.PackageService
+	backend storage.Backend
+}
+
+// Close closes the cache backend
+func (r *packageCache) Close() error {
+	return r.backend.Close()
+}
@@ -37,9 +37,11 @@ func NewSystem(p fsm.ExecutorParams, operator ops.Operator, remote fsm.Remote) (
 			constants.FieldAdvertiseIP: p.Phase.Data.Server.AdvertiseIP,
 			constants.FieldHostname:    p.Phase.Data.Server.Hostname,
 		}),
-		Key:      opKey(p.Plan),
-		Operator: operator,
-		Server:   p.Phase.Data.Server,
+		Key:       opKey(p.Plan),
+		Operator:  operator,
+		Server:    p.Phase.Data.Server,
+		Component: ops.LogComponentSystem,
+		Phase:     p.Phase.ID,
 	}
 	return &systemExecutor{
 		FieldLogger:    logger,
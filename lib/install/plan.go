@@ -76,12 +76,17 @@ func (i *Installer) GetOperationPlan(cluster ops.Site, op ops.SiteOperation) (*s
 
 	switch i.Mode {
 	case constants.InstallModeCLI:
-		builder.AddChecksPhase(plan)
+		if !i.Lightweight {
+			builder.AddChecksPhase(plan)
+		}
 	}
 
 	// configure packages for all nodes
 	builder.AddConfigurePhase(plan)
 
+	// install bundled OS packages missing on the nodes
+	builder.AddOSPackagesPhase(plan)
+
 	// bootstrap each node: setup directories, users, etc.
 	builder.AddBootstrapPhase(plan)
 
@@ -102,6 +107,16 @@ func (i *Installer) GetOperationPlan(cluster ops.Site, op ops.SiteOperation) (*s
 	builder.AddRBACPhase(plan)
 	builder.AddCorednsPhase(plan)
 
+	// create namespaces with quotas declared by the application
+	if len(cluster.App.Manifest.Namespaces) != 0 {
+		builder.AddNamespacesPhase(plan)
+	}
+
+	// make the storage class of the declared storage provider default
+	if cluster.App.Manifest.Storage != nil {
+		builder.AddStorageClassPhase(plan)
+	}
+
 	// if installing a regular app, the resources might have been
 	// provided by a user
 	if len(i.Cluster.Resources) != 0 {
@@ -30,6 +30,7 @@ import (
 	"github.com/gravitational/gravity/lib/pack"
 	"github.com/gravitational/gravity/lib/schema"
 	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
 
 	"github.com/gravitational/trace"
 )
@@ -64,6 +65,10 @@ type PlanBuilder struct {
 	DNSConfig storage.DNSConfig
 	// InstallerTrustedCluster represents the trusted cluster for installer process
 	InstallerTrustedCluster storage.TrustedCluster
+	// PackageCache is the directory with pre-seeded packages on every node
+	PackageCache string
+	// Lightweight is whether a minimal development cluster is installed
+	Lightweight bool
 }
 
 // AddChecksPhase appends preflight checks phase to the provided plan
@@ -88,6 +93,40 @@ func (b *PlanBuilder) AddConfigurePhase(plan *storage.OperationPlan) {
 	})
 }
 
+// AddOSPackagesPhase appends the phase that installs bundled OS packages
+// to the provided plan. Only nodes with profiles that require OS packages
+// are included and the phase is omitted if there are none
+func (b *PlanBuilder) AddOSPackagesPhase(plan *storage.OperationPlan) {
+	var packagePhases []storage.OperationPhase
+	allNodes := append(b.Masters, b.Nodes...)
+	for i, node := range allNodes {
+		profile, err := b.Application.Manifest.NodeProfiles.ByName(node.Role)
+		if err != nil || len(profile.Requirements.OSPackages) == 0 {
+			continue
+		}
+		packagePhases = append(packagePhases, storage.OperationPhase{
+			ID:          fmt.Sprintf("%v/%v", phases.OSPackagesPhase, node.Hostname),
+			Description: fmt.Sprintf("Install OS packages on node %v", node.Hostname),
+			Data: &storage.OperationPhaseData{
+				Server:     &allNodes[i],
+				ExecServer: &allNodes[i],
+				Package:    &b.Application.Package,
+			},
+			Step: 3,
+		})
+	}
+	if len(packagePhases) == 0 {
+		return
+	}
+	plan.Phases = append(plan.Phases, storage.OperationPhase{
+		ID:          phases.OSPackagesPhase,
+		Description: "Install bundled OS packages",
+		Phases:      packagePhases,
+		Parallel:    true,
+		Step:        3,
+	})
+}
+
 // AddBootstrapPhase appends nodes bootstrap phase to the provided plan
 func (b *PlanBuilder) AddBootstrapPhase(plan *storage.OperationPlan) {
 	var bootstrapPhases []storage.OperationPhase
@@ -140,10 +179,11 @@ func (b *PlanBuilder) AddPullPhase(plan *storage.OperationPlan) {
 			ID:          fmt.Sprintf("%v/%v", phases.PullPhase, node.Hostname),
 			Description: fmt.Sprintf(description, node.Hostname),
 			Data: &storage.OperationPhaseData{
-				Server:      &allNodes[i],
-				ExecServer:  &allNodes[i],
-				Package:     &b.Application.Package,
-				ServiceUser: &b.ServiceUser,
+				Server:       &allNodes[i],
+				ExecServer:   &allNodes[i],
+				Package:      &b.Application.Package,
+				ServiceUser:  &b.ServiceUser,
+				PackageCache: b.PackageCache,
 			},
 			Requires: []string{phases.ConfigurePhase, phases.BootstrapPhase},
 			Step:     3,
@@ -335,6 +375,34 @@ func (b *PlanBuilder) AddCorednsPhase(plan *storage.OperationPlan) {
 	})
 }
 
+// AddNamespacesPhase appends application namespaces creation phase to the provided plan
+func (b *PlanBuilder) AddNamespacesPhase(plan *storage.OperationPlan) {
+	plan.Phases = append(plan.Phases, storage.OperationPhase{
+		ID:          phases.NamespacesPhase,
+		Description: "Create application namespaces",
+		Data: &storage.OperationPhaseData{
+			Server:  &b.Master,
+			Package: &b.Application.Package,
+		},
+		Requires: []string{phases.RBACPhase},
+		Step:     4,
+	})
+}
+
+// AddStorageClassPhase appends default storage class creation phase to the provided plan
+func (b *PlanBuilder) AddStorageClassPhase(plan *storage.OperationPlan) {
+	plan.Phases = append(plan.Phases, storage.OperationPhase{
+		ID:          phases.StorageClassPhase,
+		Description: "Create default storage class",
+		Data: &storage.OperationPhaseData{
+			Server:  &b.Master,
+			Package: &b.Application.Package,
+		},
+		Requires: []string{phases.RBACPhase},
+		Step:     4,
+	})
+}
+
 // AddResourcesPhase appends K8s resources initialization phase to the provided plan
 func (b *PlanBuilder) AddResourcesPhase(plan *storage.OperationPlan, resources []byte) {
 	plan.Phases = append(plan.Phases, storage.OperationPhase{
@@ -560,6 +628,8 @@ func (i *Installer) GetPlanBuilder(cluster ops.Site, op ops.SiteOperation) (*Pla
 		},
 		DNSConfig:               cluster.DNSConfig,
 		InstallerTrustedCluster: trustedCluster,
+		PackageCache:            i.Config.PackageCache,
+		Lightweight:             i.Config.Lightweight,
 	}, nil
 }
 
@@ -615,5 +685,8 @@ func (b *PlanBuilder) skipDependency(dep loc.Locator) bool {
 	if dep.Name == constants.BootstrapConfigPackage {
 		return true // rbac-app is installed separately
 	}
+	if b.Lightweight && utils.StringInSlice(defaults.LightweightSkippedApps, dep.Name) {
+		return true
+	}
 	return schema.ShouldSkipApp(b.Application.Manifest, dep)
 }
@@ -0,0 +1,457 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/ghodss/yaml"
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+)
+
+// SSHHosts describes the nodes the installer connects to over SSH
+// to start agents on, instead of the agents being started manually
+type SSHHosts struct {
+	// User is the SSH user used for the hosts that do not specify one.
+	// A user other than root should be able to use sudo without a password
+	User string `json:"user,omitempty"`
+	// KeyPath is the path to the SSH private key used for the hosts
+	// that do not specify one
+	KeyPath string `json:"key_path,omitempty"`
+	// KnownHostsPath is the path to the file with the host keys
+	// the hosts are verified against
+	KnownHostsPath string `json:"known_hosts_path,omitempty"`
+	// InsecureIgnoreHostKey turns off the verification of host keys
+	InsecureIgnoreHostKey bool `json:"insecure_ignore_host_key,omitempty"`
+	// Hosts lists the nodes to start agents on
+	Hosts []SSHHost `json:"hosts"`
+}
+
+// SSHHost describes a single node the installer starts an agent on
+type SSHHost struct {
+	// Addr is the SSH address of the node in host[:port] format
+	Addr string `json:"addr"`
+	// AdvertiseAddr is the IP address the node advertises to the cluster.
+	// Defaults to the host part of Addr
+	AdvertiseAddr string `json:"advertise_addr,omitempty"`
+	// Role is the node profile
+	Role string `json:"role"`
+	// User overrides the SSH user for this node
+	User string `json:"user,omitempty"`
+	// KeyPath overrides the path to the SSH private key for this node
+	KeyPath string `json:"key_path,omitempty"`
+}
+
+// ReadSSHHosts reads the hosts description from the specified YAML file
+func ReadSSHHosts(path string) (*SSHHosts, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	var hosts SSHHosts
+	err = yaml.Unmarshal(data, &hosts)
+	if err != nil {
+		return nil, trace.BadParameter("failed to parse hosts file %v: %v", path, err)
+	}
+	if err := hosts.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err, "invalid hosts file %v", path)
+	}
+	return &hosts, nil
+}
+
+// CheckAndSetDefaults validates the hosts and sets default values
+func (r *SSHHosts) CheckAndSetDefaults() error {
+	if len(r.Hosts) == 0 {
+		return trace.BadParameter("no hosts specified")
+	}
+	home := os.Getenv("HOME")
+	if r.User == "" {
+		r.User = defaults.SSHUser
+	}
+	if r.KeyPath == "" {
+		r.KeyPath = filepath.Join(home, defaults.SSHKeyPath)
+	}
+	if r.KnownHostsPath == "" {
+		r.KnownHostsPath = filepath.Join(home, defaults.SSHKnownHostsPath)
+	}
+	seen := make(map[string]struct{}, len(r.Hosts))
+	for i := range r.Hosts {
+		host := &r.Hosts[i]
+		if host.Addr == "" {
+			return trace.BadParameter("host #%v does not have an address", i+1)
+		}
+		hostname, port := utils.SplitHostPort(host.Addr, strconv.Itoa(defaults.SSHPort))
+		host.Addr = net.JoinHostPort(hostname, port)
+		if host.AdvertiseAddr == "" {
+			host.AdvertiseAddr = hostname
+		}
+		if net.ParseIP(host.AdvertiseAddr) == nil {
+			return trace.BadParameter("host %v: invalid advertise IP %q, "+
+				"set advertise_addr explicitly", host.Addr, host.AdvertiseAddr)
+		}
+		if _, ok := seen[host.AdvertiseAddr]; ok {
+			return trace.BadParameter("host %v is listed more than once", host.AdvertiseAddr)
+		}
+		seen[host.AdvertiseAddr] = struct{}{}
+		if host.Role == "" {
+			return trace.BadParameter("host %v does not have a role", host.Addr)
+		}
+		if host.User == "" {
+			host.User = r.User
+		}
+		if host.KeyPath == "" {
+			host.KeyPath = r.KeyPath
+		}
+	}
+	return nil
+}
+
+// SSHAgentsConfig configures the deployment of agents over SSH
+type SSHAgentsConfig struct {
+	// Hosts describes the nodes to start agents on
+	Hosts SSHHosts
+	// InstallerAddr is the advertise address of the installer node
+	InstallerAddr string
+	// Token is the install token the agents join with
+	Token string
+	// CloudProvider is the cloud provider the installer runs with
+	CloudProvider string
+	// GravityPath is the path to the gravity binary uploaded to
+	// the nodes. Defaults to the running binary
+	GravityPath string
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+}
+
+// CheckAndSetDefaults validates the configuration and sets default values
+func (r *SSHAgentsConfig) CheckAndSetDefaults() (err error) {
+	if err := r.Hosts.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	if r.InstallerAddr == "" {
+		return trace.BadParameter("missing InstallerAddr")
+	}
+	if r.Token == "" {
+		return trace.BadParameter("missing Token")
+	}
+	if r.GravityPath == "" {
+		if r.GravityPath, err = os.Executable(); err != nil {
+			return trace.ConvertSystemError(err)
+		}
+	}
+	if r.FieldLogger == nil {
+		r.FieldLogger = logrus.WithField(trace.Component, "sshagents")
+	}
+	return nil
+}
+
+// SSHAgents is the set of agents started by the installer over SSH
+type SSHAgents struct {
+	// SSHAgentsConfig is the deployment configuration
+	SSHAgentsConfig
+	// hostKeyCallback verifies the host keys
+	hostKeyCallback ssh.HostKeyCallback
+	// started lists the hosts the agents have been started on
+	started []SSHHost
+}
+
+// DeploySSHAgents connects to the configured nodes over SSH, uploads the
+// gravity binary and starts an agent on every node that joins the installer.
+// The agents are started as a transient systemd unit so they can be stopped
+// with Teardown once the installation is over.
+//
+// If an agent fails to start, the agents already started are stopped
+func DeploySSHAgents(ctx context.Context, config SSHAgentsConfig) (*SSHAgents, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	hostKeyCallback, err := newHostKeyCallback(config.Hosts)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	agents := &SSHAgents{
+		SSHAgentsConfig: config,
+		hostKeyCallback: hostKeyCallback,
+	}
+	type result struct {
+		host SSHHost
+		err  error
+	}
+	resultsC := make(chan result, len(config.Hosts.Hosts))
+	for _, host := range config.Hosts.Hosts {
+		go func(host SSHHost) {
+			resultsC <- result{host: host, err: agents.deploy(ctx, host)}
+		}(host)
+	}
+	var errors []error
+	for range config.Hosts.Hosts {
+		result := <-resultsC
+		if result.err != nil {
+			errors = append(errors, trace.Wrap(result.err,
+				"failed to start agent on %v", result.host.Addr))
+			continue
+		}
+		agents.started = append(agents.started, result.host)
+	}
+	if len(errors) != 0 {
+		if err := agents.Teardown(ctx); err != nil {
+			agents.WithError(err).Warn("Failed to stop agents.")
+		}
+		return nil, trace.NewAggregate(errors...)
+	}
+	return agents, nil
+}
+
+// Teardown stops the agents and removes the uploaded binary from the nodes
+func (r *SSHAgents) Teardown(ctx context.Context) error {
+	var errors []error
+	for _, host := range r.started {
+		if err := r.teardown(ctx, host); err != nil {
+			errors = append(errors, trace.Wrap(err,
+				"failed to stop agent on %v", host.Addr))
+		}
+	}
+	r.started = nil
+	return trace.NewAggregate(errors...)
+}
+
+func (r *SSHAgents) deploy(ctx context.Context, host SSHHost) error {
+	logger := r.WithField("host", host.Addr)
+	client, err := r.connect(host)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer client.Close()
+	logger.Info("Uploading agent.")
+	if err := r.upload(ctx, client, host); err != nil {
+		return trace.Wrap(err)
+	}
+	sudo := sudoPrefix(host)
+	err = utils.NewSSHCommands(client).
+		IgnoreError("%vsystemctl stop %v", sudo, defaults.SSHAgentServiceName).
+		IgnoreError("%vsystemctl reset-failed %v", sudo, defaults.SSHAgentServiceName).
+		C("%v%v", sudo, r.agentCommand(host)).
+		WithLogger(logger).
+		Run(ctx)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	logger.Info("Started agent.")
+	return nil
+}
+
+func (r *SSHAgents) teardown(ctx context.Context, host SSHHost) error {
+	client, err := r.connect(host)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer client.Close()
+	sudo := sudoPrefix(host)
+	err = utils.NewSSHCommands(client).
+		IgnoreError("%vsystemctl stop %v", sudo, defaults.SSHAgentServiceName).
+		IgnoreError("%vsystemctl reset-failed %v", sudo, defaults.SSHAgentServiceName).
+		C("%vrm -rf %v", sudo, defaults.SSHAgentDir).
+		WithLogger(r.WithField("host", host.Addr)).
+		Run(ctx)
+	return trace.Wrap(err)
+}
+
+// agentCommand returns the command that starts the agent on the specified host
+func (r *SSHAgents) agentCommand(host SSHHost) string {
+	args := []string{
+		"systemd-run",
+		fmt.Sprintf("--unit=%v", defaults.SSHAgentServiceName),
+		"--description=\"Gravity install agent\"",
+		filepath.Join(defaults.SSHAgentDir, constants.GravityBin),
+		"--debug", "join", r.InstallerAddr,
+		fmt.Sprintf("--token=%v", r.Token),
+		fmt.Sprintf("--advertise-addr=%v", host.AdvertiseAddr),
+		fmt.Sprintf("--role=%v", host.Role),
+	}
+	if r.CloudProvider != "" {
+		args = append(args, fmt.Sprintf("--cloud-provider=%v", r.CloudProvider))
+	}
+	return strings.Join(args, " ")
+}
+
+// upload copies the gravity binary to the agent directory on the host
+func (r *SSHAgents) upload(ctx context.Context, client *ssh.Client, host SSHHost) error {
+	f, err := os.Open(r.GravityPath)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	defer f.Close()
+	session, err := client.NewSession()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer session.Close()
+	var stderr bytes.Buffer
+	session.Stdin = f
+	session.Stderr = &stderr
+	path := filepath.Join(defaults.SSHAgentDir, constants.GravityBin)
+	errC := make(chan error, 1)
+	go func() {
+		errC <- session.Run(fmt.Sprintf("%vsh -c 'mkdir -p %v && cat > %v && chmod %o %v'",
+			sudoPrefix(host), defaults.SSHAgentDir, path, defaults.SharedExecutableMask, path))
+	}()
+	select {
+	case err = <-errC:
+	case <-ctx.Done():
+		return trace.Wrap(ctx.Err())
+	}
+	if err != nil {
+		return trace.Wrap(err, "failed to upload %v: %s", r.GravityPath, stderr.Bytes())
+	}
+	return nil
+}
+
+func (r *SSHAgents) connect(host SSHHost) (*ssh.Client, error) {
+	keyBytes, err := ioutil.ReadFile(host.KeyPath)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	signer, err := ssh.ParsePrivateKey(keyBytes)
+	if err != nil {
+		return nil, trace.Wrap(err, "failed to parse SSH key %v", host.KeyPath)
+	}
+	client, err := ssh.Dial("tcp", host.Addr, &ssh.ClientConfig{
+		User:            host.User,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: r.hostKeyCallback,
+		Timeout:         defaults.DialTimeout,
+	})
+	if err != nil {
+		return nil, trace.Wrap(err, "failed to connect to %v", host.Addr)
+	}
+	return client, nil
+}
+
+// sudoPrefix returns the prefix for the commands executed on the host
+// that require root privileges
+func sudoPrefix(host SSHHost) string {
+	if host.User == defaults.SSHUser {
+		return ""
+	}
+	return "sudo -n "
+}
+
+// newHostKeyCallback returns the callback that verifies host keys against
+// the known hosts file from the configuration.
+// Both plain and hashed host names are supported
+func newHostKeyCallback(hosts SSHHosts) (ssh.HostKeyCallback, error) {
+	if hosts.InsecureIgnoreHostKey {
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+	data, err := ioutil.ReadFile(hosts.KnownHostsPath)
+	if err != nil {
+		return nil, trace.Wrap(trace.ConvertSystemError(err),
+			"failed to read known hosts, set insecure_ignore_host_key "+
+				"to skip host key verification")
+	}
+	var entries []knownHost
+	for len(data) > 0 {
+		var entry knownHost
+		entry.marker, entry.hosts, entry.key, _, data, err = ssh.ParseKnownHosts(data)
+		if err != nil {
+			break
+		}
+		entries = append(entries, entry)
+	}
+	return func(addr string, remote net.Addr, key ssh.PublicKey) error {
+		hostname, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		name := hostname
+		if port != strconv.Itoa(defaults.SSHPort) {
+			name = fmt.Sprintf("[%v]:%v", hostname, port)
+		}
+		var found bool
+		for _, entry := range entries {
+			if !entry.matches(name) || entry.key.Type() != key.Type() {
+				continue
+			}
+			sameKey := bytes.Equal(entry.key.Marshal(), key.Marshal())
+			if entry.marker == "@revoked" && sameKey {
+				return trace.AccessDenied("host key of %v has been revoked", addr)
+			}
+			if entry.marker == "" && sameKey {
+				found = true
+			}
+		}
+		if !found {
+			return trace.AccessDenied("host key %v of %v is not found in %v",
+				ssh.FingerprintSHA256(key), addr, hosts.KnownHostsPath)
+		}
+		return nil
+	}, nil
+}
+
+// knownHost is an entry of the known hosts file
+type knownHost struct {
+	marker string
+	hosts  []string
+	key    ssh.PublicKey
+}
+
+// matches returns true if the entry lists the specified host name
+func (r knownHost) matches(name string) bool {
+	for _, host := range r.hosts {
+		if host == name || matchesHashedHost(host, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesHashedHost returns true if the hashed known hosts entry
+// in |1|salt|hash format is the hash of the specified host name
+func matchesHashedHost(entry, name string) bool {
+	parts := strings.Split(entry, "|")
+	if len(parts) != 4 || parts[0] != "" || parts[1] != "1" {
+		return false
+	}
+	salt, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	hash, err := base64.StdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha1.New, salt)
+	mac.Write([]byte(name))
+	return hmac.Equal(mac.Sum(nil), hash)
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+
+	"golang.org/x/crypto/ssh"
+	"gopkg.in/check.v1"
+)
+
+type SSHAgentsSuite struct{}
+
+var _ = check.Suite(&SSHAgentsSuite{})
+
+func (s *SSHAgentsSuite) TestReadsHosts(c *check.C) {
+	path := filepath.Join(c.MkDir(), "hosts.yaml")
+	err := ioutil.WriteFile(path, []byte(`user: centos
+key_path: /keys/id_rsa
+hosts:
+- addr: 10.0.0.2
+  role: node
+- addr: 10.0.0.3:2222
+  role: knode
+  advertise_addr: 192.168.0.3
+  user: root
+`), 0644)
+	c.Assert(err, check.IsNil)
+
+	hosts, err := ReadSSHHosts(path)
+	c.Assert(err, check.IsNil)
+	c.Assert(hosts.Hosts, check.DeepEquals, []SSHHost{
+		{
+			Addr:          "10.0.0.2:22",
+			AdvertiseAddr: "10.0.0.2",
+			Role:          "node",
+			User:          "centos",
+			KeyPath:       "/keys/id_rsa",
+		},
+		{
+			Addr:          "10.0.0.3:2222",
+			AdvertiseAddr: "192.168.0.3",
+			Role:          "knode",
+			User:          "root",
+			KeyPath:       "/keys/id_rsa",
+		},
+	})
+	c.Assert(sudoPrefix(hosts.Hosts[0]), check.Equals, "sudo -n ")
+	c.Assert(sudoPrefix(hosts.Hosts[1]), check.Equals, "")
+}
+
+func (s *SSHAgentsSuite) TestValidatesHosts(c *check.C) {
+	testCases := []struct {
+		hosts   []SSHHost
+		comment string
+	}{
+		{
+			hosts:   nil,
+			comment: "no hosts",
+		},
+		{
+			hosts:   []SSHHost{{Addr: "10.0.0.2"}},
+			comment: "missing role",
+		},
+		{
+			hosts:   []SSHHost{{Addr: "node-2", Role: "node"}},
+			comment: "advertise address is not an IP",
+		},
+		{
+			hosts: []SSHHost{
+				{Addr: "10.0.0.2", Role: "node"},
+				{Addr: "10.0.0.2:2222", Role: "node"},
+			},
+			comment: "duplicate host",
+		},
+	}
+	for _, tc := range testCases {
+		hosts := SSHHosts{Hosts: tc.hosts}
+		c.Assert(hosts.CheckAndSetDefaults(), check.NotNil, check.Commentf(tc.comment))
+	}
+}
+
+func (s *SSHAgentsSuite) TestAgentCommand(c *check.C) {
+	agents := &SSHAgents{SSHAgentsConfig: SSHAgentsConfig{
+		InstallerAddr: "10.0.0.1",
+		Token:         "token",
+		CloudProvider: "onprem",
+	}}
+	c.Assert(agents.agentCommand(SSHHost{AdvertiseAddr: "10.0.0.2", Role: "node"}), check.Equals,
+		`systemd-run --unit=gravity-ssh-agent.service --description="Gravity install agent" `+
+			`/usr/local/share/gravity/ssh-agent/gravity --debug join 10.0.0.1 `+
+			`--token=token --advertise-addr=10.0.0.2 --role=node --cloud-provider=onprem`)
+}
+
+func (s *SSHAgentsSuite) TestVerifiesHostKeys(c *check.C) {
+	known := newPublicKey(c)
+	unknown := newPublicKey(c)
+	revoked := newPublicKey(c)
+	salt := []byte("0123456789abcdef0123")
+	mac := hmac.New(sha1.New, salt)
+	mac.Write([]byte("10.0.0.3"))
+	hashed := fmt.Sprintf("|1|%v|%v", base64.StdEncoding.EncodeToString(salt),
+		base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+
+	path := filepath.Join(c.MkDir(), "known_hosts")
+	err := ioutil.WriteFile(path, []byte(fmt.Sprintf(`10.0.0.2,[10.0.0.4]:2222 %v
+%v %v
+@revoked * %v
+`, marshalKey(known), hashed, marshalKey(known), marshalKey(revoked))), 0644)
+	c.Assert(err, check.IsNil)
+
+	callback, err := newHostKeyCallback(SSHHosts{KnownHostsPath: path})
+	c.Assert(err, check.IsNil)
+	remote := &net.TCPAddr{}
+	c.Assert(callback("10.0.0.2:22", remote, known), check.IsNil)
+	c.Assert(callback("10.0.0.3:22", remote, known), check.IsNil)
+	c.Assert(callback("10.0.0.4:2222", remote, known), check.IsNil)
+	c.Assert(callback("10.0.0.4:22", remote, known), check.NotNil)
+	c.Assert(callback("10.0.0.2:22", remote, unknown), check.NotNil)
+	c.Assert(callback("10.0.0.5:22", remote, known), check.NotNil)
+	c.Assert(callback("10.0.0.2:22", remote, revoked), check.NotNil)
+
+	_, err = newHostKeyCallback(SSHHosts{KnownHostsPath: filepath.Join(c.MkDir(), "missing")})
+	c.Assert(err, check.NotNil)
+	callback, err = newHostKeyCallback(SSHHosts{InsecureIgnoreHostKey: true})
+	c.Assert(err, check.IsNil)
+	c.Assert(callback("10.0.0.5:22", remote, unknown), check.IsNil)
+}
+
+func newPublicKey(c *check.C) ssh.PublicKey {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	c.Assert(err, check.IsNil)
+	publicKey, err := ssh.NewPublicKey(&key.PublicKey)
+	c.Assert(err, check.IsNil)
+	return publicKey
+}
+
+func marshalKey(key ssh.PublicKey) string {
+	return fmt.Sprintf("%v %v", key.Type(), base64.StdEncoding.EncodeToString(key.Marshal()))
+}
@@ -0,0 +1,354 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package installers generates customer-specific installer tarballs
+// on demand and caches them.
+//
+// An installer is selected by application name and, optionally, the
+// application and runtime versions, and can have a license embedded.
+// Generated tarballs are kept in the cache directory until they have
+// not been requested for the configured TTL
+package installers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gravitational/gravity/lib/app"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/schema"
+
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+	"github.com/sirupsen/logrus"
+)
+
+// Config configures the installer cache
+type Config struct {
+	// Dir is the cache directory
+	Dir string
+	// TTL is how long an installer is kept since it was last requested
+	TTL time.Duration
+	// Clock is used to expire cached installers
+	Clock clockwork.Clock
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+}
+
+// CheckAndSetDefaults validates the configuration and sets default values
+func (r *Config) CheckAndSetDefaults() error {
+	if r.Dir == "" {
+		return trace.BadParameter("missing Dir")
+	}
+	if r.TTL == 0 {
+		r.TTL = defaults.InstallerCacheTTL
+	}
+	if r.Clock == nil {
+		r.Clock = clockwork.NewRealClock()
+	}
+	if r.FieldLogger == nil {
+		r.FieldLogger = logrus.WithField(trace.Component, "installers")
+	}
+	return nil
+}
+
+// New returns a new installer cache
+func New(config Config) (*Cache, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := os.MkdirAll(config.Dir, defaults.SharedDirMask); err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	return &Cache{
+		Config:   config,
+		inflight: make(map[string]chan struct{}),
+	}, nil
+}
+
+// Cache generates installer tarballs on demand and caches them
+type Cache struct {
+	Config
+	// mu guards inflight
+	mu sync.Mutex
+	// inflight maps cache keys of installers being generated to
+	// channels closed once the generation has finished
+	inflight map[string]chan struct{}
+}
+
+// Request describes an installer
+type Request struct {
+	// AccountID is the account the installer is generated for
+	AccountID string `json:"account_id"`
+	// Repository is the application repository
+	Repository string `json:"repository"`
+	// Name is the application name
+	Name string `json:"name"`
+	// Version is the optional application version, the latest by default
+	Version string `json:"version,omitempty"`
+	// RuntimeVersion is the optional version of the runtime the
+	// application has to be based on
+	RuntimeVersion string `json:"runtime_version,omitempty"`
+	// License is the optional license to embed into the installer
+	License string `json:"license,omitempty"`
+}
+
+// Check validates the request
+func (r Request) Check() error {
+	if r.Repository == "" {
+		return trace.BadParameter("missing repository")
+	}
+	if r.Name == "" {
+		return trace.BadParameter("missing application name")
+	}
+	return nil
+}
+
+// Version describes an application version installers can be generated for
+type Version struct {
+	// App is the application locator
+	App loc.Locator `json:"app"`
+	// Runtime is the locator of the runtime the application is based on
+	Runtime loc.Locator `json:"runtime"`
+}
+
+// Installer is a generated installer tarball
+type Installer struct {
+	// File is the open installer tarball
+	*os.File
+	// App is the application the installer is for
+	App loc.Locator
+	// Modified is when the installer has been generated
+	Modified time.Time
+}
+
+// Versions returns the versions of the specified application installers
+// can be generated for, newest first
+func Versions(apps app.Applications, repository, name string) ([]Version, error) {
+	items, err := apps.ListApps(app.ListAppsRequest{
+		Repository:    repository,
+		Pattern:       name,
+		ExcludeHidden: true,
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return versions(items, name), nil
+}
+
+// Get returns the installer matching the request from the cache,
+// generating it with the provided generator first if necessary.
+// Applications are looked up in the provided application service.
+// The caller is responsible for closing the returned installer
+func (r *Cache) Get(apps app.Applications, generator ops.Applications, req Request) (*Installer, error) {
+	if err := req.Check(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	versions, err := Versions(apps, req.Repository, req.Name)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	version, err := match(versions, req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := r.prune(); err != nil {
+		r.Warnf("Failed to prune installer cache: %v.", trace.DebugReport(err))
+	}
+	key := cacheKey(req.AccountID, version.App, req.License)
+	path := filepath.Join(r.Dir, fmt.Sprintf("%v%v", key, installerExt))
+	for {
+		r.mu.Lock()
+		if done, ok := r.inflight[key]; ok {
+			r.mu.Unlock()
+			<-done
+			continue
+		}
+		installer, err := r.open(path, version.App)
+		if err == nil || !trace.IsNotFound(err) {
+			r.mu.Unlock()
+			return installer, trace.Wrap(err)
+		}
+		done := make(chan struct{})
+		r.inflight[key] = done
+		r.mu.Unlock()
+
+		err = r.generate(generator, path, version.App, req)
+
+		r.mu.Lock()
+		delete(r.inflight, key)
+		close(done)
+		r.mu.Unlock()
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+}
+
+// open opens the cached installer and marks it as recently used
+func (r *Cache) open(path string, locator loc.Locator) (*Installer, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	fi, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, trace.ConvertSystemError(err)
+	}
+	now := r.Clock.Now()
+	if err := os.Chtimes(path, now, now); err != nil {
+		r.Warnf("Failed to update access time of %v: %v.", path, err)
+	}
+	return &Installer{
+		File:     file,
+		App:      locator,
+		Modified: fi.ModTime(),
+	}, nil
+}
+
+// generate generates the installer into the specified path
+func (r *Cache) generate(generator ops.Applications, path string, locator loc.Locator, req Request) error {
+	r.Infof("Generating installer for %v.", locator)
+	reader, err := generator.GetAppInstaller(ops.AppInstallerRequest{
+		AccountID:   req.AccountID,
+		Application: locator,
+		License:     req.License,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer reader.Close()
+	file, err := ioutil.TempFile(r.Dir, tempPrefix)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	defer os.Remove(file.Name())
+	_, err = io.Copy(file, reader)
+	if errClose := file.Close(); err == nil {
+		err = errClose
+	}
+	if err != nil {
+		return trace.Wrap(err, "failed to generate installer for %v", locator)
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	now := r.Clock.Now()
+	return trace.ConvertSystemError(os.Chtimes(path, now, now))
+}
+
+// prune removes installers that have not been requested for longer than TTL
+func (r *Cache) prune() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	files, err := ioutil.ReadDir(r.Dir)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	for _, fi := range files {
+		if !strings.HasSuffix(fi.Name(), installerExt) {
+			continue
+		}
+		if r.Clock.Now().Sub(fi.ModTime()) < r.TTL {
+			continue
+		}
+		r.Debugf("Removing expired installer %v.", fi.Name())
+		err := os.Remove(filepath.Join(r.Dir, fi.Name()))
+		if err != nil && !os.IsNotExist(err) {
+			return trace.ConvertSystemError(err)
+		}
+	}
+	return nil
+}
+
+// versions returns installable versions of the named application
+// among the specified applications, newest first
+func versions(apps []app.Application, name string) (result []Version) {
+	for _, application := range apps {
+		if application.Package.Name != name {
+			continue
+		}
+		switch application.Manifest.Kind {
+		case schema.KindBundle, schema.KindCluster:
+		default:
+			continue
+		}
+		version := Version{App: application.Package}
+		if base := application.Manifest.Base(); base != nil {
+			version.Runtime = *base
+		}
+		result = append(result, version)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		newer, err := result[i].App.IsNewerThan(result[j].App)
+		if err != nil {
+			return result[i].App.Version > result[j].App.Version
+		}
+		return newer
+	})
+	return result
+}
+
+// match returns the newest version matching the request
+func match(versions []Version, req Request) (*Version, error) {
+	latest := req.Version == "" || req.Version == loc.LatestVersion
+	for _, version := range versions {
+		if !latest && version.App.Version != req.Version {
+			continue
+		}
+		if req.RuntimeVersion != "" && version.Runtime.Version != req.RuntimeVersion {
+			continue
+		}
+		return &version, nil
+	}
+	description := req.Name
+	if !latest {
+		description = fmt.Sprintf("%v:%v", req.Name, req.Version)
+	}
+	if req.RuntimeVersion != "" {
+		return nil, trace.NotFound("no installable application %v based on runtime %v",
+			description, req.RuntimeVersion)
+	}
+	return nil, trace.NotFound("no installable application %v", description)
+}
+
+// cacheKey returns the key the installer is cached under
+func cacheKey(accountID string, locator loc.Locator, license string) string {
+	hash := sha256.New()
+	for _, value := range []string{accountID, locator.String(), license} {
+		fmt.Fprintf(hash, "%v\x00", value)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+const (
+	// installerExt is the extension of cached installer tarballs
+	installerExt = ".tar.gz"
+	// tempPrefix is the name prefix of installer tarballs being generated
+	tempPrefix = ".installer"
+)
@@ -0,0 +1,195 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package installers
+
+import (
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gravitational/gravity/lib/app"
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/schema"
+
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+	check "gopkg.in/check.v1"
+)
+
+func TestInstallers(t *testing.T) { check.TestingT(t) }
+
+type InstallersSuite struct {
+	clock clockwork.FakeClock
+	cache *Cache
+	apps  *testApps
+}
+
+var _ = check.Suite(&InstallersSuite{})
+
+func (s *InstallersSuite) SetUpTest(c *check.C) {
+	s.clock = clockwork.NewFakeClockAt(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+	var err error
+	s.cache, err = New(Config{
+		Dir:   c.MkDir(),
+		TTL:   time.Hour,
+		Clock: s.clock,
+	})
+	c.Assert(err, check.IsNil)
+	s.apps = &testApps{apps: []app.Application{
+		newApp("app:1.0.0", "kubernetes:5.0.0", schema.KindBundle),
+		newApp("app:1.1.0", "kubernetes:5.0.0", schema.KindBundle),
+		newApp("app:1.2.0", "kubernetes:5.2.0", schema.KindBundle),
+		newApp("app:2.0.0", "", schema.KindApplication),
+		newApp("other:3.0.0", "kubernetes:5.2.0", schema.KindBundle),
+	}}
+}
+
+func (s *InstallersSuite) TestListsVersions(c *check.C) {
+	versions, err := Versions(s.apps, "gravitational.io", "app")
+	c.Assert(err, check.IsNil)
+	c.Assert(versions, check.DeepEquals, []Version{
+		{App: locator("app:1.2.0"), Runtime: locator("kubernetes:5.2.0")},
+		{App: locator("app:1.1.0"), Runtime: locator("kubernetes:5.0.0")},
+		{App: locator("app:1.0.0"), Runtime: locator("kubernetes:5.0.0")},
+	})
+}
+
+func (s *InstallersSuite) TestSelectsVersion(c *check.C) {
+	testCases := []struct {
+		req      Request
+		expected string
+		err      string
+	}{
+		{req: Request{}, expected: "app:1.2.0"},
+		{req: Request{Version: loc.LatestVersion}, expected: "app:1.2.0"},
+		{req: Request{Version: "1.0.0"}, expected: "app:1.0.0"},
+		{req: Request{RuntimeVersion: "5.0.0"}, expected: "app:1.1.0"},
+		{req: Request{Version: "1.2.0", RuntimeVersion: "5.0.0"},
+			err: "no installable application app:1.2.0 based on runtime 5.0.0"},
+		{req: Request{Version: "2.0.0"}, err: "no installable application app:2.0.0"},
+	}
+	for _, tc := range testCases {
+		comment := check.Commentf("%#v", tc.req)
+		tc.req.Repository, tc.req.Name = "gravitational.io", "app"
+		generator := &testGenerator{}
+		installer, err := s.cache.Get(s.apps, generator, tc.req)
+		if tc.err != "" {
+			c.Assert(err, check.ErrorMatches, tc.err, comment)
+			c.Assert(trace.IsNotFound(err), check.Equals, true, comment)
+			continue
+		}
+		c.Assert(err, check.IsNil, comment)
+		c.Assert(installer.App, check.DeepEquals, locator(tc.expected), comment)
+		installer.Close()
+	}
+}
+
+func (s *InstallersSuite) TestCachesInstallers(c *check.C) {
+	generator := &testGenerator{}
+	req := Request{Repository: "gravitational.io", Name: "app", Version: "1.1.0"}
+	c.Assert(s.read(c, generator, req), check.Equals, "installer for gravitational.io/app:1.1.0")
+	c.Assert(s.read(c, generator, req), check.Equals, "installer for gravitational.io/app:1.1.0")
+	c.Assert(generator.requests, check.HasLen, 1)
+
+	// installers with different licenses are cached separately
+	req.License = "license"
+	c.Assert(s.read(c, generator, req), check.Equals, "installer for gravitational.io/app:1.1.0 with license")
+	c.Assert(generator.requests, check.HasLen, 2)
+	c.Assert(generator.requests[1].License, check.Equals, "license")
+
+	// installers are kept while they are requested
+	s.clock.Advance(50 * time.Minute)
+	s.read(c, generator, req)
+	s.clock.Advance(50 * time.Minute)
+	s.read(c, generator, req)
+	c.Assert(generator.requests, check.HasLen, 2)
+
+	// unused installers expire
+	s.clock.Advance(2 * time.Hour)
+	s.read(c, generator, req)
+	c.Assert(generator.requests, check.HasLen, 3)
+	files, err := ioutil.ReadDir(s.cache.Dir)
+	c.Assert(err, check.IsNil)
+	c.Assert(files, check.HasLen, 1)
+}
+
+func (s *InstallersSuite) TestDoesNotCacheFailedInstallers(c *check.C) {
+	req := Request{Repository: "gravitational.io", Name: "app"}
+	_, err := s.cache.Get(s.apps, &testGenerator{err: trace.ConnectionProblem(nil, "failed")}, req)
+	c.Assert(err, check.NotNil)
+	files, err := ioutil.ReadDir(s.cache.Dir)
+	c.Assert(err, check.IsNil)
+	c.Assert(files, check.HasLen, 0)
+}
+
+func (s *InstallersSuite) read(c *check.C, generator *testGenerator, req Request) string {
+	installer, err := s.cache.Get(s.apps, generator, req)
+	c.Assert(err, check.IsNil)
+	defer installer.Close()
+	data, err := ioutil.ReadAll(installer)
+	c.Assert(err, check.IsNil)
+	c.Assert(filepath.Dir(installer.Name()), check.Equals, s.cache.Dir)
+	return string(data)
+}
+
+func newApp(name, runtime, kind string) app.Application {
+	var manifest schema.Manifest
+	manifest.Kind = kind
+	if runtime != "" {
+		manifest.BaseImage = &schema.BaseImage{Locator: locator(runtime)}
+	}
+	return app.Application{Package: locator(name), Manifest: manifest}
+}
+
+func locator(name string) loc.Locator {
+	return loc.MustParseLocator("gravitational.io/" + name)
+}
+
+type testApps struct {
+	app.Applications
+	apps []app.Application
+}
+
+func (r *testApps) ListApps(req app.ListAppsRequest) (result []app.Application, err error) {
+	for _, application := range r.apps {
+		if strings.Contains(application.Package.Name, req.Pattern) {
+			result = append(result, application)
+		}
+	}
+	return result, nil
+}
+
+type testGenerator struct {
+	requests []ops.AppInstallerRequest
+	err      error
+}
+
+func (r *testGenerator) GetAppInstaller(req ops.AppInstallerRequest) (io.ReadCloser, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	r.requests = append(r.requests, req)
+	data := "installer for " + req.Application.String()
+	if req.License != "" {
+		data += " with license"
+	}
+	return ioutil.NopCloser(strings.NewReader(data)), nil
+}
@@ -0,0 +1,261 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubelet
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"strings"
+	"time"
+
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/rigging"
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+	certificates "k8s.io/api/certificates/v1beta1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ApproverConfig configures the kubelet certificate request approver
+type ApproverConfig struct {
+	// Client is the Kubernetes client
+	Client kubernetes.Interface
+	// Interval is how often the pending requests are reviewed
+	Interval time.Duration
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+}
+
+// CheckAndSetDefaults validates the configuration and sets default values
+func (r *ApproverConfig) CheckAndSetDefaults() error {
+	if r.Client == nil {
+		return trace.BadParameter("missing Client")
+	}
+	if r.Interval == 0 {
+		r.Interval = defaults.KubeletCSRApprovalInterval
+	}
+	if r.FieldLogger == nil {
+		r.FieldLogger = logrus.WithField(trace.Component, "kubelet")
+	}
+	return nil
+}
+
+// NewApprover returns a new kubelet certificate request approver
+func NewApprover(config ApproverConfig) (*Approver, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &Approver{ApproverConfig: config}, nil
+}
+
+// Approver approves the certificate signing requests kubelets
+// submit to renew their client and serving certificates
+type Approver struct {
+	ApproverConfig
+}
+
+// Run reviews pending requests periodically until the context is canceled
+func (r *Approver) Run(ctx context.Context) error {
+	utils.RunPeriodically(ctx, r.Interval, r.FieldLogger,
+		"review kubelet certificate requests", r.Sync)
+	return nil
+}
+
+// Sync approves every pending certificate signing request submitted by
+// a cluster node for its own client or serving certificate.
+// Requests that do not originate from nodes are left untouched
+func (r *Approver) Sync(ctx context.Context) error {
+	requests, err := r.Client.CertificatesV1beta1().CertificateSigningRequests().List(metav1.ListOptions{})
+	if err != nil {
+		return trace.Wrap(rigging.ConvertError(err))
+	}
+	var errors []error
+	for _, csr := range requests.Items {
+		if !isPending(csr) {
+			continue
+		}
+		req, err := parseRequest(csr)
+		if err != nil || !isNodeRequest(req) {
+			continue
+		}
+		if err := r.review(csr, req); err != nil {
+			errors = append(errors, trace.Wrap(err))
+		}
+	}
+	return trace.NewAggregate(errors...)
+}
+
+func (r *Approver) review(csr certificates.CertificateSigningRequest, req *x509.CertificateRequest) error {
+	nodeName := strings.TrimPrefix(req.Subject.CommonName, constants.ClusterNodeNamePrefix+":")
+	node, err := r.Client.CoreV1().Nodes().Get(nodeName, metav1.GetOptions{})
+	if err != nil {
+		err = rigging.ConvertError(err)
+		if trace.IsNotFound(err) {
+			r.Warnf("Not approving certificate request %v of unknown node %v.", csr.Name, nodeName)
+			return nil
+		}
+		return trace.Wrap(err)
+	}
+	if err := review(csr, req, *node); err != nil {
+		r.Warnf("Not approving certificate request %v of node %v: %v.", csr.Name, nodeName, err)
+		return nil
+	}
+	csr.Status.Conditions = append(csr.Status.Conditions, certificates.CertificateSigningRequestCondition{
+		Type:           certificates.CertificateApproved,
+		Reason:         "AutoApproved",
+		Message:        "Approved kubelet certificate renewal",
+		LastUpdateTime: metav1.Now(),
+	})
+	_, err = r.Client.CertificatesV1beta1().CertificateSigningRequests().UpdateApproval(&csr)
+	if err != nil {
+		return trace.Wrap(rigging.ConvertError(err))
+	}
+	r.Infof("Approved certificate request %v of node %v.", csr.Name, nodeName)
+	return nil
+}
+
+// review validates that the request has been submitted by the node
+// for its own client or serving certificate
+func review(csr certificates.CertificateSigningRequest, req *x509.CertificateRequest, node v1.Node) error {
+	if csr.Spec.Username != req.Subject.CommonName ||
+		!utils.StringInSlice(csr.Spec.Groups, constants.ClusterNodeGroup) {
+		return trace.AccessDenied("request for %v has been submitted by %v",
+			req.Subject.CommonName, csr.Spec.Username)
+	}
+	if req.Subject.CommonName != constants.ClusterNodeNamePrefix+":"+node.Name {
+		return trace.BadParameter("common name %v does not match node %v",
+			req.Subject.CommonName, node.Name)
+	}
+	switch {
+	case hasOnlyUsages(csr, clientUsages):
+		if len(req.DNSNames) != 0 || len(req.IPAddresses) != 0 || len(req.EmailAddresses) != 0 {
+			return trace.BadParameter("client certificate request should not have subject alternative names")
+		}
+	case hasOnlyUsages(csr, servingUsages):
+		return trace.Wrap(checkNodeAddresses(req, node))
+	default:
+		return trace.BadParameter("unexpected key usages %v", csr.Spec.Usages)
+	}
+	return nil
+}
+
+// checkNodeAddresses validates that the subject alternative names
+// of the serving certificate request are the node's addresses
+func checkNodeAddresses(req *x509.CertificateRequest, node v1.Node) error {
+	if len(req.DNSNames) == 0 && len(req.IPAddresses) == 0 {
+		return trace.BadParameter("serving certificate request should have subject alternative names")
+	}
+	if len(req.EmailAddresses) != 0 {
+		return trace.BadParameter("serving certificate request should not have email addresses")
+	}
+	var names, addrs []string
+	for _, addr := range node.Status.Addresses {
+		switch addr.Type {
+		case v1.NodeHostName, v1.NodeInternalDNS, v1.NodeExternalDNS:
+			names = append(names, addr.Address)
+		case v1.NodeInternalIP, v1.NodeExternalIP:
+			addrs = append(addrs, addr.Address)
+		}
+	}
+	for _, name := range req.DNSNames {
+		if !utils.StringInSlice(names, name) {
+			return trace.BadParameter("%v is not a hostname of node %v", name, node.Name)
+		}
+	}
+	for _, ip := range req.IPAddresses {
+		if !utils.StringInSlice(addrs, ip.String()) {
+			return trace.BadParameter("%v is not an address of node %v", ip, node.Name)
+		}
+	}
+	return nil
+}
+
+// isNodeRequest returns true if the request is for a node certificate
+func isNodeRequest(req *x509.CertificateRequest) bool {
+	return len(req.Subject.Organization) == 1 &&
+		req.Subject.Organization[0] == constants.ClusterNodeGroup &&
+		strings.HasPrefix(req.Subject.CommonName, constants.ClusterNodeNamePrefix+":")
+}
+
+// hasOnlyUsages returns true if the request asks for the required usage
+// and only for the allowed ones, the required usage being the first
+func hasOnlyUsages(csr certificates.CertificateSigningRequest, allowed []certificates.KeyUsage) bool {
+	var required bool
+	for _, usage := range csr.Spec.Usages {
+		if !hasUsage(allowed, usage) {
+			return false
+		}
+		if usage == allowed[0] {
+			required = true
+		}
+	}
+	return required
+}
+
+func hasUsage(usages []certificates.KeyUsage, usage certificates.KeyUsage) bool {
+	for _, u := range usages {
+		if u == usage {
+			return true
+		}
+	}
+	return false
+}
+
+// isPending returns true if the request has been neither approved nor denied
+func isPending(csr certificates.CertificateSigningRequest) bool {
+	for _, condition := range csr.Status.Conditions {
+		if condition.Type == certificates.CertificateApproved ||
+			condition.Type == certificates.CertificateDenied {
+			return false
+		}
+	}
+	return true
+}
+
+// parseRequest returns the x509 certificate request from the specified request
+func parseRequest(csr certificates.CertificateSigningRequest) (*x509.CertificateRequest, error) {
+	block, _ := pem.Decode(csr.Spec.Request)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return nil, trace.BadParameter("request %v does not contain a PEM encoded certificate request", csr.Name)
+	}
+	req, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return req, nil
+}
+
+var (
+	// clientUsages lists the key usages of the kubelet client certificate
+	clientUsages = []certificates.KeyUsage{
+		certificates.UsageClientAuth,
+		certificates.UsageDigitalSignature,
+		certificates.UsageKeyEncipherment,
+	}
+	// servingUsages lists the key usages of the kubelet serving certificate
+	servingUsages = []certificates.KeyUsage{
+		certificates.UsageServerAuth,
+		certificates.UsageDigitalSignature,
+		certificates.UsageKeyEncipherment,
+	}
+)
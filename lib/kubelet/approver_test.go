@@ -0,0 +1,162 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubelet
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gravitational/gravity/lib/constants"
+
+	certificates "k8s.io/api/certificates/v1beta1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	. "gopkg.in/check.v1"
+)
+
+func TestKubelet(t *testing.T) { TestingT(t) }
+
+type ApproverSuite struct{}
+
+var _ = Suite(&ApproverSuite{})
+
+func (s *ApproverSuite) TestApprovesNodeRequests(c *C) {
+	node := newNode("node-1", "10.0.0.1")
+	for _, csr := range []certificates.CertificateSigningRequest{
+		newRequest(c, "system:node:node-1", "system:node:node-1", nil, clientUsages...),
+		newRequest(c, "system:node:node-1", "system:node:node-1", []string{"node-1", "10.0.0.1"}, servingUsages...),
+		newRequest(c, "system:node:node-1", "system:node:node-1", []string{"10.0.0.1"},
+			certificates.UsageServerAuth, certificates.UsageDigitalSignature),
+	} {
+		req, err := parseRequest(csr)
+		c.Assert(err, IsNil)
+		c.Assert(isNodeRequest(req), Equals, true)
+		c.Assert(review(csr, req, node), IsNil, Commentf("%v", csr.Spec.Usages))
+	}
+}
+
+func (s *ApproverSuite) TestRejectsInvalidRequests(c *C) {
+	node := newNode("node-1", "10.0.0.1")
+	for _, tc := range []struct {
+		csr     certificates.CertificateSigningRequest
+		comment string
+	}{
+		{
+			csr:     newRequest(c, "system:node:node-2", "system:node:node-1", nil, clientUsages...),
+			comment: "submitted by another node",
+		},
+		{
+			csr:     newRequest(c, "system:node:node-2", "system:node:node-2", nil, clientUsages...),
+			comment: "for another node",
+		},
+		{
+			csr:     newRequest(c, "system:node:node-1", "system:node:node-1", []string{"10.0.0.1"}, clientUsages...),
+			comment: "client certificate with addresses",
+		},
+		{
+			csr:     newRequest(c, "system:node:node-1", "system:node:node-1", []string{"10.0.0.2"}, servingUsages...),
+			comment: "serving certificate for another address",
+		},
+		{
+			csr:     newRequest(c, "system:node:node-1", "system:node:node-1", []string{"example.com"}, servingUsages...),
+			comment: "serving certificate for another hostname",
+		},
+		{
+			csr: newRequest(c, "system:node:node-1", "system:node:node-1", []string{"10.0.0.1"},
+				certificates.UsageServerAuth, certificates.UsageClientAuth),
+			comment: "mixed usages",
+		},
+		{
+			csr: newRequest(c, "system:node:node-1", "system:node:node-1", nil,
+				certificates.UsageDigitalSignature),
+			comment: "missing client auth usage",
+		},
+	} {
+		req, err := parseRequest(tc.csr)
+		c.Assert(err, IsNil)
+		c.Assert(review(tc.csr, req, node), NotNil, Commentf(tc.comment))
+	}
+}
+
+func (s *ApproverSuite) TestIgnoresOtherRequests(c *C) {
+	req := &x509.CertificateRequest{Subject: pkix.Name{CommonName: "admin", Organization: []string{"admin"}}}
+	c.Assert(isNodeRequest(req), Equals, false)
+
+	csr := newRequest(c, "system:node:node-1", "system:node:node-1", nil, clientUsages...)
+	c.Assert(isPending(csr), Equals, true)
+	csr.Status.Conditions = append(csr.Status.Conditions, certificates.CertificateSigningRequestCondition{
+		Type: certificates.CertificateDenied,
+	})
+	c.Assert(isPending(csr), Equals, false)
+}
+
+func (s *ApproverSuite) TestDetectsExpiringCertificates(c *C) {
+	now := time.Now()
+	cert := x509.Certificate{NotAfter: now.Add(48 * time.Hour)}
+	c.Assert(IsExpiring(cert, now, 0), Equals, false)
+	c.Assert(IsExpiring(cert, now, 24*time.Hour), Equals, false)
+	c.Assert(IsExpiring(cert, now, 72*time.Hour), Equals, true)
+	c.Assert(IsExpiring(cert, now.Add(72*time.Hour), 0), Equals, true)
+}
+
+func newNode(name, addr string) v1.Node {
+	return v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: v1.NodeStatus{
+			Addresses: []v1.NodeAddress{
+				{Type: v1.NodeHostName, Address: name},
+				{Type: v1.NodeInternalIP, Address: addr},
+			},
+		},
+	}
+}
+
+func newRequest(c *C, username, commonName string, hosts []string, usages ...certificates.KeyUsage) certificates.CertificateSigningRequest {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	c.Assert(err, IsNil)
+	template := x509.CertificateRequest{
+		Subject: pkix.Name{
+			CommonName:   commonName,
+			Organization: []string{constants.ClusterNodeGroup},
+		},
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+	data, err := x509.CreateCertificateRequest(rand.Reader, &template, key)
+	c.Assert(err, IsNil)
+	return certificates.CertificateSigningRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "csr-" + commonName},
+		Spec: certificates.CertificateSigningRequestSpec{
+			Request:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: data}),
+			Username: username,
+			Groups:   []string{constants.ClusterNodeGroup, "system:authenticated"},
+			Usages:   usages,
+		},
+	}
+}
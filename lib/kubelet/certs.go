@@ -0,0 +1,94 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubelet
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"path/filepath"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+)
+
+// ServingCertificate returns the certificate served by the kubelet
+// running on the node with the specified address
+func ServingCertificate(ctx context.Context, addr string) (*x509.Certificate, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaults.KubeletCertProbeTimeout)
+	defer cancel()
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", fmt.Sprintf("%v:%v", addr, defaults.KubeletPort))
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	// the certificate is only inspected, not trusted
+	client := tls.Client(conn, &tls.Config{InsecureSkipVerify: true})
+	if err := client.Handshake(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	certs := client.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return nil, trace.NotFound("kubelet on %v did not present a certificate", addr)
+	}
+	return certs[0], nil
+}
+
+// IsExpiring returns true if the certificate expires
+// within the specified period after now
+func IsExpiring(cert x509.Certificate, now time.Time, period time.Duration) bool {
+	return !now.Add(period).Before(cert.NotAfter)
+}
+
+// ResetRotatedCertificates removes the certificates kubelet has obtained
+// by rotation and restarts it. Kubelet then falls back to the certificates
+// issued to the node by the cluster and requests new ones
+func ResetRotatedCertificates(ctx context.Context, log logrus.FieldLogger) error {
+	if err := RemoveRotatedCertificates(ctx, log); err != nil {
+		return trace.Wrap(err)
+	}
+	out, err := utils.RunInPlanetCommand(ctx, log, defaults.SystemctlBin, "restart", kubeletService)
+	if err != nil {
+		return trace.Wrap(err, "failed to restart kubelet: %s", out)
+	}
+	return nil
+}
+
+// RemoveRotatedCertificates removes the certificates kubelet has obtained
+// by rotation so it uses the certificates issued to the node by the cluster
+// once restarted
+func RemoveRotatedCertificates(ctx context.Context, log logrus.FieldLogger) error {
+	out, err := utils.RunInPlanetCommand(ctx, log, "/bin/sh", "-c",
+		fmt.Sprintf("rm -f %v", filepath.Join(defaults.KubeletCertDir, "kubelet-*.pem")))
+	if err != nil {
+		return trace.Wrap(err, "failed to remove rotated certificates: %s", out)
+	}
+	return nil
+}
+
+// kubeletService is the name of the kubelet service inside planet
+const kubeletService = "kube-kubelet"
@@ -0,0 +1,154 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/schema"
+
+	"github.com/gravitational/rigging"
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+// UpsertNamespaces creates or updates the namespaces declared in the manifest
+// of the specified application along with their resource quotas and limit ranges.
+//
+// Quotas and limit ranges that are no longer declared are removed while
+// namespaces themselves are never deleted to avoid losing application data
+func UpsertNamespaces(client corev1.CoreV1Interface, app string, namespaces []schema.Namespace, logger log.FieldLogger) error {
+	for _, namespace := range namespaces {
+		if err := upsertNamespace(client, app, namespace, logger); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+func upsertNamespace(client corev1.CoreV1Interface, app string, namespace schema.Namespace, logger log.FieldLogger) error {
+	labels := map[string]string{constants.NamespaceAppLabel: app}
+	for name, value := range namespace.Labels {
+		labels[name] = value
+	}
+	existing, err := client.Namespaces().Get(namespace.Name, metav1.GetOptions{})
+	err = rigging.ConvertError(err)
+	switch {
+	case trace.IsNotFound(err):
+		_, err = client.Namespaces().Create(&v1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   namespace.Name,
+				Labels: labels,
+			},
+		})
+		if err != nil {
+			return trace.Wrap(rigging.ConvertError(err))
+		}
+		logger.Infof("Created namespace %v.", namespace.Name)
+	case err != nil:
+		return trace.Wrap(err)
+	default:
+		if existing.Labels == nil {
+			existing.Labels = make(map[string]string)
+		}
+		for name, value := range labels {
+			existing.Labels[name] = value
+		}
+		_, err = client.Namespaces().Update(existing)
+		if err != nil {
+			return trace.Wrap(rigging.ConvertError(err))
+		}
+		logger.Infof("Updated namespace %v.", namespace.Name)
+	}
+	quota, err := namespace.ResourceQuotaSpec()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if err := upsertResourceQuota(client.ResourceQuotas(namespace.Name), quota, labels); err != nil {
+		return trace.Wrap(err)
+	}
+	limits, err := namespace.LimitRangeSpec()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if err := upsertLimitRange(client.LimitRanges(namespace.Name), limits, labels); err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// upsertResourceQuota creates or updates the resource quota managed by gravity
+// or removes it if spec is nil
+func upsertResourceQuota(client corev1.ResourceQuotaInterface, spec *v1.ResourceQuotaSpec, labels map[string]string) error {
+	if spec == nil {
+		err := rigging.ConvertError(client.Delete(constants.NamespaceLimitsName, nil))
+		if err != nil && !trace.IsNotFound(err) {
+			return trace.Wrap(err)
+		}
+		return nil
+	}
+	existing, err := client.Get(constants.NamespaceLimitsName, metav1.GetOptions{})
+	err = rigging.ConvertError(err)
+	if err != nil && !trace.IsNotFound(err) {
+		return trace.Wrap(err)
+	}
+	if err == nil {
+		existing.Spec = *spec
+		_, err = client.Update(existing)
+		return trace.Wrap(rigging.ConvertError(err))
+	}
+	_, err = client.Create(&v1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   constants.NamespaceLimitsName,
+			Labels: labels,
+		},
+		Spec: *spec,
+	})
+	return trace.Wrap(rigging.ConvertError(err))
+}
+
+// upsertLimitRange creates or updates the limit range managed by gravity
+// or removes it if spec is nil
+func upsertLimitRange(client corev1.LimitRangeInterface, spec *v1.LimitRangeSpec, labels map[string]string) error {
+	if spec == nil {
+		err := rigging.ConvertError(client.Delete(constants.NamespaceLimitsName, nil))
+		if err != nil && !trace.IsNotFound(err) {
+			return trace.Wrap(err)
+		}
+		return nil
+	}
+	existing, err := client.Get(constants.NamespaceLimitsName, metav1.GetOptions{})
+	err = rigging.ConvertError(err)
+	if err != nil && !trace.IsNotFound(err) {
+		return trace.Wrap(err)
+	}
+	if err == nil {
+		existing.Spec = *spec
+		_, err = client.Update(existing)
+		return trace.Wrap(rigging.ConvertError(err))
+	}
+	_, err = client.Create(&v1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   constants.NamespaceLimitsName,
+			Labels: labels,
+		},
+		Spec: *spec,
+	})
+	return trace.Wrap(rigging.ConvertError(err))
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"reflect"
+
+	"github.com/gravitational/gravity/lib/schema"
+
+	"github.com/gravitational/rigging"
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	storagev1client "k8s.io/client-go/kubernetes/typed/storage/v1"
+)
+
+// UpsertDefaultStorageClass creates or updates the specified storage class
+// and makes it the only default storage class of the cluster.
+//
+// Provisioner and parameters of a storage class cannot be updated so an existing
+// storage class with different ones is recreated. Volumes provisioned
+// from the storage class are not affected
+func UpsertDefaultStorageClass(client storagev1client.StorageClassInterface, class schema.StorageClass, logger log.FieldLogger) error {
+	classes, err := client.List(metav1.ListOptions{})
+	if err != nil {
+		return trace.Wrap(rigging.ConvertError(err))
+	}
+	for _, existing := range classes.Items {
+		if existing.Name == class.Name || !IsDefaultStorageClass(existing) {
+			continue
+		}
+		existing := existing
+		delete(existing.Annotations, DefaultStorageClassAnnotation)
+		delete(existing.Annotations, betaDefaultStorageClassAnnotation)
+		if _, err := client.Update(&existing); err != nil {
+			return trace.Wrap(rigging.ConvertError(err))
+		}
+		logger.Infof("Storage class %v is no longer default.", existing.Name)
+	}
+	reclaimPolicy := v1.PersistentVolumeReclaimPolicy(class.ReclaimPolicy)
+	storageClass := storagev1.StorageClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        class.Name,
+			Annotations: map[string]string{DefaultStorageClassAnnotation: "true"},
+		},
+		Provisioner:   class.Provisioner,
+		Parameters:    class.Parameters,
+		ReclaimPolicy: &reclaimPolicy,
+	}
+	existing, err := client.Get(class.Name, metav1.GetOptions{})
+	err = rigging.ConvertError(err)
+	switch {
+	case trace.IsNotFound(err):
+	case err != nil:
+		return trace.Wrap(err)
+	case isSameStorageClass(*existing, storageClass):
+		if existing.Annotations == nil {
+			existing.Annotations = make(map[string]string)
+		}
+		existing.Annotations[DefaultStorageClassAnnotation] = "true"
+		if _, err := client.Update(existing); err != nil {
+			return trace.Wrap(rigging.ConvertError(err))
+		}
+		logger.Infof("Updated storage class %v.", class.Name)
+		return nil
+	default:
+		if err := client.Delete(class.Name, nil); err != nil {
+			return trace.Wrap(rigging.ConvertError(err))
+		}
+	}
+	if _, err := client.Create(&storageClass); err != nil {
+		return trace.Wrap(rigging.ConvertError(err))
+	}
+	logger.Infof("Created storage class %v.", class.Name)
+	return nil
+}
+
+// IsDefaultStorageClass returns true if the storage class is marked as default
+func IsDefaultStorageClass(class storagev1.StorageClass) bool {
+	return class.Annotations[DefaultStorageClassAnnotation] == "true" ||
+		class.Annotations[betaDefaultStorageClassAnnotation] == "true"
+}
+
+func isSameStorageClass(existing, class storagev1.StorageClass) bool {
+	if existing.Provisioner != class.Provisioner {
+		return false
+	}
+	if len(existing.Parameters) != 0 || len(class.Parameters) != 0 {
+		if !reflect.DeepEqual(existing.Parameters, class.Parameters) {
+			return false
+		}
+	}
+	return existing.ReclaimPolicy != nil && *existing.ReclaimPolicy == *class.ReclaimPolicy
+}
+
+const (
+	// DefaultStorageClassAnnotation marks the default storage class of the cluster
+	DefaultStorageClassAnnotation = "storageclass.kubernetes.io/is-default-class"
+	// betaDefaultStorageClassAnnotation is the deprecated version
+	// of the default storage class annotation
+	betaDefaultStorageClassAnnotation = "storageclass.beta.kubernetes.io/is-default-class"
+)
@@ -0,0 +1,63 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loc
+
+import "regexp"
+
+const (
+	// DefaultArch is the architecture of the packages that are not
+	// built for a specific architecture
+	DefaultArch = "amd64"
+
+	// ArchSeparator separates the package version from the architecture
+	// of the package variant in the locator, e.g. example.com/planet:1.0.0_arm64.
+	// The separator is not allowed in semver versions and package aliases,
+	// so the architecture is never confused with a part of the version
+	ArchSeparator = "_"
+)
+
+// archRe specifies the format of the package architecture, e.g. arm64
+var archRe = regexp.MustCompile(`^[a-z][a-z0-9]*$`)
+
+// WithArch returns the locator of the variant of this package built
+// for the specified architecture, e.g. example.com/planet:1.0.0_arm64
+// is the arm64 variant of example.com/planet:1.0.0.
+// The default architecture has no variant and the returned locator
+// refers to the package itself
+func (l Locator) WithArch(arch string) Locator {
+	if arch == DefaultArch {
+		arch = ""
+	}
+	l.Arch = arch
+	return l
+}
+
+// ArchVersion returns the version of the package followed by the
+// architecture of the variant, if any, e.g. 1.0.0_arm64
+func (l Locator) ArchVersion() string {
+	if l.Arch == "" {
+		return l.Version
+	}
+	return l.Version + ArchSeparator + l.Arch
+}
+
+// WithoutArch returns the locator of the package this locator
+// is a variant of
+func (l Locator) WithoutArch() Locator {
+	l.Arch = ""
+	return l
+}
@@ -38,12 +38,26 @@ const (
 	LatestVersion = "0.0.0+latest"
 	// StableVersion defines a special placeholder for the latest stable version
 	StableVersion = "0.0.0+stable"
+
+	// AliasPrefix prefixes package alias versions, e.g. example.com/runtime:@current
+	AliasPrefix = "@"
 )
 
 // locRe expression specifies the format for package name that
 // consists of the repository name and a version separated by the :
 var locRe = regexp.MustCompile(`^([a-zA-Z0-9\-_\.]+):(.+)$`)
 
+// aliasRe specifies the format for package alias versions, e.g. "@current".
+// Aliases are always prefixed with AliasPrefix so that a mistyped
+// version is never mistaken for an alias
+var aliasRe = regexp.MustCompile(`^@[a-z][a-z0-9\-]*$`)
+
+// IsAlias returns true if the specified version is a package alias
+// rather than a concrete semver version
+func IsAlias(version string) bool {
+	return aliasRe.MatchString(version)
+}
+
 func NewDigest(t string, val []byte) (*Digest, error) {
 	if t != "sha512" {
 		return nil, trace.Errorf("unsupported digest type: '%v'", t)
@@ -87,10 +101,13 @@ func NewLocator(repository, name, ver string) (*Locator, error) {
 		return nil, trace.BadParameter(
 			"package name '%v' has invalid format, should be valid identifier e.g. package-name", name)
 	}
+	if IsAlias(ver) {
+		return &Locator{Repository: repository, Name: name, Version: ver}, nil
+	}
 	_, err := semver.NewVersion(ver)
 	if err != nil {
 		return nil, trace.BadParameter(
-			"unsupported version format, need semver format: %v, e.g 1.0.0", err)
+			"unsupported version format, need semver format or alias: %v, e.g 1.0.0 or @current", err)
 	}
 	return &Locator{Repository: repository, Name: name, Version: ver}, nil
 }
@@ -101,11 +118,15 @@ type Locator struct {
 	Repository string `json:"repository"` // package software repository
 	Name       string `json:"name"`       // example: "planet-dev"
 	Version    string `json:"version"`    // example: "0.0.36"
+	// Arch is the architecture of the package variant, e.g. arm64.
+	// Empty for packages built for the default architecture
+	// and architecture-independent packages
+	Arch string `json:"arch,omitempty"`
 }
 
 // ZeroVersion returns a special 0.0.0 version of the package
 func (l *Locator) ZeroVersion() Locator {
-	return Locator{Repository: l.Repository, Name: l.Name, Version: ZeroVersion}
+	return Locator{Repository: l.Repository, Name: l.Name, Version: ZeroVersion, Arch: l.Arch}
 }
 
 // SemVer obtains emver from a marshalled version
@@ -118,14 +139,22 @@ func (l *Locator) SemVer() (*semver.Version, error) {
 	return v, nil
 }
 
+// IsAlias returns true if this locator refers to a package alias,
+// e.g. gravitational.io/runtime:@current, that is resolved to a concrete
+// package version by the package service
+func (l Locator) IsAlias() bool {
+	return IsAlias(l.Version)
+}
+
 // EqualTo returns 'true' if this locator is equal to others
 func (l Locator) IsEqualTo(other Locator) bool {
-	return l.Repository == other.Repository && l.Name == other.Name && l.Version == other.Version
+	return l.Repository == other.Repository && l.Name == other.Name &&
+		l.Version == other.Version && l.Arch == other.Arch
 }
 
 // IsEmpty returns true if locator presents empty value
 func (l *Locator) IsEmpty() bool {
-	return l.Repository == "" && l.Name == "" && l.Version == ""
+	return l.Repository == "" && l.Name == "" && l.Version == "" && l.Arch == ""
 }
 
 // IsNewerThan returns true if this locator is of greater version than the provided locator
@@ -152,6 +181,7 @@ func (l *Locator) Set(v string) error {
 	l.Repository = p.Repository
 	l.Name = p.Name
 	l.Version = p.Version
+	l.Arch = p.Arch
 	return nil
 }
 
@@ -164,6 +194,9 @@ func (l Locator) String() string {
 	if l.Version != "" {
 		str = fmt.Sprintf("%v:%v", str, l.Version)
 	}
+	if l.Arch != "" {
+		str = fmt.Sprintf("%v%v%v", str, ArchSeparator, l.Arch)
+	}
 	return str
 }
 
@@ -173,10 +206,35 @@ func (l Locator) WithVersion(version *semver.Version) Locator {
 		Repository: l.Repository,
 		Name:       l.Name,
 		Version:    version.String(),
+		Arch:       l.Arch,
 	}
 }
 
+// ParseLocator parses the locator in the form repository/name:version,
+// optionally followed by the architecture of the package variant,
+// e.g. example.com/planet:1.0.0_arm64
 func ParseLocator(v string) (*Locator, error) {
+	var arch string
+	// package names may contain the separator, so it is only
+	// considered after the start of the version
+	name := strings.LastIndex(v, "/") + 1
+	if colon := strings.Index(v[name:], ":"); colon >= 0 {
+		if i := strings.LastIndex(v, ArchSeparator); i > name+colon+1 {
+			v, arch = v[:i], v[i+1:]
+			if !archRe.MatchString(arch) {
+				return nil, trace.BadParameter("invalid package architecture %q", arch)
+			}
+		}
+	}
+	locator, err := parseLocator(v)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	locator.Arch = arch
+	return locator, nil
+}
+
+func parseLocator(v string) (*Locator, error) {
 	parts := strings.Split(v, "/")
 	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
 		return nil, trace.Errorf(
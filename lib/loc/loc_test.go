@@ -50,6 +50,12 @@ func (s *LocatorSuite) TestLocatorOK(c *C) {
 			repo: "example.com",
 			ver:  "0.0.1+something",
 		},
+		{
+			loc:  "gravitational.io/runtime:@current",
+			name: "runtime",
+			repo: "gravitational.io",
+			ver:  "@current",
+		},
 	}
 	for i, tc := range tcs {
 		comment := Commentf("test #%d (%v) loc=%v", i+1, tc, tc.loc)
@@ -60,6 +66,12 @@ func (s *LocatorSuite) TestLocatorOK(c *C) {
 	}
 }
 
+func (s *LocatorSuite) TestAlias(c *C) {
+	c.Assert(MustParseLocator("gravitational.io/runtime:@current").IsAlias(), Equals, true)
+	c.Assert(MustParseLocator("gravitational.io/runtime:1.0.0").IsAlias(), Equals, false)
+	c.Assert(MustParseLocator("gravitational.io/runtime:0.0.0+latest").IsAlias(), Equals, false)
+}
+
 func (s *LocatorSuite) TestNewerThan(c *C) {
 	l1, err := ParseLocator("gravitational.io/k8s-aws:1.1.218-138")
 	c.Assert(err, IsNil)
@@ -74,6 +86,8 @@ func (s *LocatorSuite) TestLocatorFail(c *C) {
 	tcs := []string{
 		"example:0.0.1",                 // missing repository
 		"example.com/example:blabla",    // not a sem ver
+		"example.com/example:lates",     // not a sem ver
+		"example.com/example:@Current",  // not an alias
 		"example.com/example com:0.0.2", // unallowed chars
 		"", //emtpy
 		"arffewfaef aefeafaesf e", //garbage
@@ -155,3 +169,50 @@ func (s *LocatorSuite) TestDeduplicate(c *C) {
 	}
 	c.Assert(uniq, compare.DeepEquals, expected)
 }
+
+func (s *LocatorSuite) TestMakeLocator(c *C) {
+	var testCases = map[string]string{
+		"app":                   "gravitational.io/app:0.0.0+latest",
+		"app:latest":            "gravitational.io/app:0.0.0+latest",
+		"app:stable":            "gravitational.io/app:0.0.0+stable",
+		"app:latest.rc":         "gravitational.io/app:0.0.0+latest.rc",
+		"app:1.0.0":             "gravitational.io/app:1.0.0",
+		"example.com/app:1.0.0": "example.com/app:1.0.0",
+	}
+	for app, expected := range testCases {
+		locator, err := MakeLocator(app)
+		c.Assert(err, IsNil, Commentf(app))
+		c.Assert(locator.String(), Equals, expected, Commentf(app))
+	}
+}
+
+func (s *LocatorSuite) TestWithArch(c *C) {
+	planet := MustParseLocator("gravitational.io/planet:1.0.0")
+	c.Assert(planet.WithArch(""), Equals, planet)
+	c.Assert(planet.WithArch(DefaultArch), Equals, planet)
+
+	variant := planet.WithArch("arm64")
+	c.Assert(variant, Equals, Locator{
+		Repository: "gravitational.io",
+		Name:       "planet",
+		Version:    "1.0.0",
+		Arch:       "arm64",
+	})
+	c.Assert(variant.String(), Equals, "gravitational.io/planet:1.0.0_arm64")
+	c.Assert(MustParseLocator(variant.String()), Equals, variant)
+	c.Assert(variant.IsEqualTo(planet), Equals, false)
+	c.Assert(variant.WithoutArch(), Equals, planet)
+
+	alias := MustParseLocator("gravitational.io/planet:@current_arm64")
+	c.Assert(alias.Version, Equals, "@current")
+	c.Assert(alias.Arch, Equals, "arm64")
+	c.Assert(alias.IsAlias(), Equals, true)
+
+	_, err := ParseLocator("gravitational.io/planet:1.0.0_ARM")
+	c.Assert(err, NotNil)
+
+	// the separator is only considered in the version
+	locator := MustParseLocator("gravitational.io/planet_master:1.0.0")
+	c.Assert(locator.Name, Equals, "planet_master")
+	c.Assert(locator.Arch, Equals, "")
+}
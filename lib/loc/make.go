@@ -17,6 +17,7 @@ limitations under the License.
 package loc
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/gravitational/gravity/lib/constants"
@@ -29,6 +30,8 @@ import (
 //  - if it's in the 'repo/name:ver' format, returns it
 //  - if it's in the 'name:ver' format, returns locator with system repo (systemrepo/name:ver)
 //  - if it's in the 'name' format, returns locator with system repo and latest meta-version (systemrepo/name:0.0.0+latest)
+//  - if it's in the 'name:latest.<channel>' format, returns locator with system repo and latest meta-version
+//    in the specified release channel (systemrepo/name:0.0.0+latest.<channel>)
 func MakeLocator(app string) (*Locator, error) {
 	locator, err := ParseLocator(app)
 	if err == nil {
@@ -40,11 +43,14 @@ func MakeLocator(app string) (*Locator, error) {
 	}
 	if len(parts) == 2 {
 		version := parts[1]
-		switch version {
-		case constants.LatestVersion:
+		switch {
+		case version == constants.LatestVersion:
 			version = LatestVersion
-		case constants.StableVersion:
+		case version == constants.StableVersion:
 			version = StableVersion
+		case strings.HasPrefix(version, constants.LatestVersion+"."):
+			// latest version in the specified release channels, e.g. "latest.rc"
+			version = fmt.Sprintf("%v+%v", ZeroVersion, version)
 		}
 		return NewLocator(defaults.SystemAccountOrg, parts[0], version)
 	}
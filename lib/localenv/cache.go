@@ -0,0 +1,167 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package localenv
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+)
+
+// MetadataCacheConfig is the metadata cache configuration
+type MetadataCacheConfig struct {
+	// Dir is the directory with cached entries
+	Dir string
+	// TTL is how long the entries stay valid
+	TTL time.Duration
+	// Clock is used to expire entries
+	Clock clockwork.Clock
+}
+
+// CheckAndSetDefaults validates the config and sets default values
+func (r *MetadataCacheConfig) CheckAndSetDefaults() error {
+	if r.Dir == "" {
+		return trace.BadParameter("missing parameter Dir")
+	}
+	if r.TTL == 0 {
+		r.TTL = defaults.MetadataCacheTTL
+	}
+	if r.Clock == nil {
+		r.Clock = clockwork.NewRealClock()
+	}
+	return nil
+}
+
+// NewMetadataCache returns a new metadata cache
+func NewMetadataCache(config MetadataCacheConfig) (*MetadataCache, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &MetadataCache{MetadataCacheConfig: config}, nil
+}
+
+// MetadataCache caches cluster metadata queried by CLI commands on disk
+// so that the commands invoked repeatedly do not query the cluster
+// every time.
+//
+// Entries expire after a short TTL and can be invalidated explicitly,
+// e.g. by commands that change the cached data
+type MetadataCache struct {
+	MetadataCacheConfig
+}
+
+// Get unmarshals the value cached under the specified key into value.
+// Returns NotFound if there is no valid entry for the key
+func (c *MetadataCache) Get(key string, value interface{}) error {
+	entry, err := c.readEntry(c.path(key))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if entry.Key != key || !c.Clock.Now().Before(entry.Expires) {
+		return trace.NotFound("cache entry %q has expired", key)
+	}
+	if err := json.Unmarshal(entry.Value, value); err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// Put caches the value under the specified key
+func (c *MetadataCache) Put(key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	entry, err := json.Marshal(cacheEntry{
+		Key:     key,
+		Expires: c.Clock.Now().Add(c.TTL),
+		Value:   data,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if err := os.MkdirAll(c.Dir, defaults.PrivateDirMask); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	err = utils.CopyReaderWithPerms(c.path(key), bytes.NewReader(entry), defaults.PrivateFileMask)
+	return trace.Wrap(err)
+}
+
+// Invalidate removes all entries with keys starting with the specified prefix
+func (c *MetadataCache) Invalidate(prefix string) error {
+	paths, err := filepath.Glob(filepath.Join(c.Dir, "*"+cacheEntrySuffix))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	for _, path := range paths {
+		entry, err := c.readEntry(path)
+		if err == nil && !strings.HasPrefix(entry.Key, prefix) {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return trace.ConvertSystemError(err)
+		}
+	}
+	return nil
+}
+
+// Clear removes all cached entries
+func (c *MetadataCache) Clear() error {
+	return trace.Wrap(c.Invalidate(""))
+}
+
+func (c *MetadataCache) readEntry(path string) (*cacheEntry, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &entry, nil
+}
+
+// path returns the path to the file with the entry for the specified key
+func (c *MetadataCache) path(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return filepath.Join(c.Dir, hex.EncodeToString(hash[:])+cacheEntrySuffix)
+}
+
+// cacheEntry is a cached value with its key and expiration time
+type cacheEntry struct {
+	// Key is the key the value is cached under
+	Key string `json:"key"`
+	// Expires is when the entry expires
+	Expires time.Time `json:"expires"`
+	// Value is the cached value
+	Value json.RawMessage `json:"value"`
+}
+
+const cacheEntrySuffix = ".json"
@@ -0,0 +1,76 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package localenv
+
+import (
+	"time"
+
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+	"gopkg.in/check.v1"
+)
+
+type MetadataCacheSuite struct {
+	clock clockwork.FakeClock
+	cache *MetadataCache
+}
+
+var _ = check.Suite(&MetadataCacheSuite{})
+
+func (s *MetadataCacheSuite) SetUpTest(c *check.C) {
+	s.clock = clockwork.NewFakeClock()
+	var err error
+	s.cache, err = NewMetadataCache(MetadataCacheConfig{
+		Dir:   c.MkDir(),
+		TTL:   time.Minute,
+		Clock: s.clock,
+	})
+	c.Assert(err, check.IsNil)
+}
+
+func (s *MetadataCacheSuite) TestExpiresEntries(c *check.C) {
+	var value []string
+	err := s.cache.Get("packages/a", &value)
+	c.Assert(trace.IsNotFound(err), check.Equals, true, check.Commentf("%v", err))
+
+	c.Assert(s.cache.Put("packages/a", []string{"a", "b"}), check.IsNil)
+	c.Assert(s.cache.Get("packages/a", &value), check.IsNil)
+	c.Assert(value, check.DeepEquals, []string{"a", "b"})
+
+	s.clock.Advance(time.Minute)
+	err = s.cache.Get("packages/a", &value)
+	c.Assert(trace.IsNotFound(err), check.Equals, true, check.Commentf("%v", err))
+}
+
+func (s *MetadataCacheSuite) TestInvalidatesEntries(c *check.C) {
+	c.Assert(s.cache.Put("packages/a", "a"), check.IsNil)
+	c.Assert(s.cache.Put("packages/b", "b"), check.IsNil)
+	c.Assert(s.cache.Put("status", "status"), check.IsNil)
+
+	c.Assert(s.cache.Invalidate("packages/"), check.IsNil)
+	var value string
+	for _, key := range []string{"packages/a", "packages/b"} {
+		err := s.cache.Get(key, &value)
+		c.Assert(trace.IsNotFound(err), check.Equals, true, check.Commentf("%v: %v", key, err))
+	}
+	c.Assert(s.cache.Get("status", &value), check.IsNil)
+	c.Assert(value, check.Equals, "status")
+
+	c.Assert(s.cache.Clear(), check.IsNil)
+	err := s.cache.Get("status", &value)
+	c.Assert(trace.IsNotFound(err), check.Equals, true, check.Commentf("%v", err))
+}
@@ -381,7 +381,16 @@ func (env *LocalEnvironment) PackageService(opsCenterURL string, options ...http
 		return nil, trace.Wrap(err)
 	}
 
-	return client, nil
+	// persist partial downloads in the state directory so interrupted
+	// downloads of large packages can be resumed
+	return client.WithDownloadDir(filepath.Join(env.StateDir, defaults.PackagesDir, defaults.PartialDir)), nil
+}
+
+// MetadataCache returns the cache for cluster metadata queried by CLI commands
+func (env *LocalEnvironment) MetadataCache() (*MetadataCache, error) {
+	return NewMetadataCache(MetadataCacheConfig{
+		Dir: filepath.Join(env.StateDir, defaults.MetadataCacheDir),
+	})
 }
 
 // CurrentLogin returns the login entry for the cluster this environment
@@ -412,8 +421,12 @@ func (env *LocalEnvironment) CurrentPackages(options ...httplib.ClientOption) (p
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	return newPackClient(*entry, entry.OpsCenterURL,
+	client, err := newPackClient(*entry, entry.OpsCenterURL,
 		roundtrip.HTTPClient(env.HTTPClient(options...)))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return client, nil
 }
 
 // CurrentApps returns app service for the current login entry
@@ -619,14 +632,20 @@ func NewOpsClient(entry users.LoginEntry, opsCenterURL string, params ...opsclie
 	return client, trace.Wrap(err)
 }
 
-func newPackClient(entry users.LoginEntry, opsCenterURL string, params ...roundtrip.ClientParam) (client pack.PackageService, err error) {
+func newPackClient(entry users.LoginEntry, opsCenterURL string, params ...roundtrip.ClientParam) (client *webpack.Client, err error) {
 	if entry.Email != "" {
 		client, err = webpack.NewAuthenticatedClient(
 			opsCenterURL, entry.Email, entry.Password, params...)
 	} else {
 		client, err = webpack.NewBearerClient(opsCenterURL, entry.Password, params...)
 	}
-	return client, trace.Wrap(err)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if entry.RateLimit != 0 {
+		client = client.WithRateLimit(entry.RateLimit)
+	}
+	return client, nil
 }
 
 // NewAppsClient creates a new app service client.
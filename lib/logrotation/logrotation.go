@@ -0,0 +1,256 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package logrotation enforces the cluster log rotation policy on every
+// node: it installs a journald configuration drop-in inside planet and
+// rotates the container logs that outgrow the configured size
+package logrotation
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"path/filepath"
+
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/rigging"
+	"github.com/gravitational/trace"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// Name is the name of the objects that enforce the policy
+	Name = "log-rotation"
+	// journaldConfigFile is the name of the journald configuration drop-in
+	journaldConfigFile = "50-gravity.conf"
+	// scriptFile is the name of the script that enforces the policy
+	scriptFile = "rotate.sh"
+	// configVolume is the name of the volume with rendered configuration
+	configVolume = "config"
+	// journaldVolume is the name of the volume with journald configuration drop-ins
+	journaldVolume = "journald"
+	// containersVolume is the name of the volume with container logs
+	containersVolume = "containers"
+	// configHashAnnotation is the pod annotation with the hash of the
+	// rendered configuration which rolls the pods on every change
+	configHashAnnotation = "gravitational.io/log-rotation-config-hash"
+)
+
+// RenderJournald returns the journald configuration drop-in for the
+// specified policy or an empty string if the policy does not configure
+// the journal
+func RenderJournald(policy storage.LogRotation) string {
+	journal := policy.GetJournal()
+	if journal == nil {
+		return ""
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# This file is managed by gravity, do not edit.\n")
+	fmt.Fprintf(&buf, "# Use \"gravity resource create\" with %v resource instead.\n", storage.KindLogRotation)
+	fmt.Fprintf(&buf, "[Journal]\n")
+	if journal.MaxUse != 0 {
+		fmt.Fprintf(&buf, "SystemMaxUse=%v\n", journal.MaxUse.Bytes())
+	}
+	if journal.MaxFileSize != 0 {
+		fmt.Fprintf(&buf, "SystemMaxFileSize=%v\n", journal.MaxFileSize.Bytes())
+	}
+	if journal.MaxRetention != nil {
+		fmt.Fprintf(&buf, "MaxRetentionSec=%vs\n", int64(journal.MaxRetention.Value().Seconds()))
+	}
+	return buf.String()
+}
+
+// RenderScript returns the shell script that installs the journald
+// configuration, or removes the one installed previously, and then
+// rotates the container logs according to the specified policy
+func RenderScript(policy storage.LogRotation) string {
+	var buf bytes.Buffer
+	dropIn := filepath.Join(defaults.JournaldConfigDir, journaldConfigFile)
+	restart := fmt.Sprintf("nsenter -t 1 -m -- systemctl restart %v", defaults.JournaldServiceName)
+	if policy.GetJournal() != nil {
+		fmt.Fprintf(&buf, "if ! cmp -s /config/%[1]v %[2]v; then cp /config/%[1]v %[2]v && %[3]v; fi\n",
+			journaldConfigFile, dropIn, restart)
+	} else {
+		fmt.Fprintf(&buf, "if [ -f %[1]v ]; then rm -f %[1]v && %[2]v; fi\n", dropIn, restart)
+	}
+	containers := policy.GetContainers()
+	if containers == nil {
+		fmt.Fprintf(&buf, "while true; do sleep 3600; done\n")
+		return buf.String()
+	}
+	fmt.Fprintf(&buf, `while true; do
+  for log in /containers/*/*-json.log; do
+    [ -f "$log" ] || continue
+    if [ "$(wc -c < "$log")" -ge %[1]v ]; then
+      i=%[2]v
+      while [ $i -gt 1 ]; do
+        [ -f "$log.$((i-1))" ] && mv -f "$log.$((i-1))" "$log.$i"
+        i=$((i-1))
+      done
+      cp "$log" "$log.1" && : > "$log"
+    fi
+  done
+  sleep %[3]v
+done
+`, containers.MaxSize.Bytes(), containers.MaxFiles, int64(containers.CheckInterval.Value().Seconds()))
+	return buf.String()
+}
+
+// Objects returns the config map with rendered configuration and the
+// daemon set that enforces the policy on every cluster node. Nodes that
+// join the cluster later get the policy as soon as the daemon set pod
+// is scheduled on them
+func Objects(policy storage.LogRotation) (*v1.ConfigMap, *appsv1.DaemonSet) {
+	journald, script := RenderJournald(policy), RenderScript(policy)
+	labels := map[string]string{constants.LogRotationLabel: Name}
+	configMap := &v1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      configMapName,
+			Namespace: defaults.KubeSystemNamespace,
+			Labels:    labels,
+		},
+		Data: map[string]string{
+			journaldConfigFile: journald,
+			scriptFile:         script,
+		},
+	}
+	privileged := true
+	directoryOrCreate := v1.HostPathDirectoryOrCreate
+	daemonSet := &appsv1.DaemonSet{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "DaemonSet",
+			APIVersion: appsv1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      Name,
+			Namespace: defaults.KubeSystemNamespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+					Annotations: map[string]string{
+						configHashAnnotation: fmt.Sprintf("%x", sha256.Sum256([]byte(journald+script))),
+					},
+				},
+				Spec: v1.PodSpec{
+					HostPID:     true,
+					Tolerations: []v1.Toleration{{Operator: v1.TolerationOpExists}},
+					Containers: []v1.Container{{
+						Name:    Name,
+						Image:   fmt.Sprintf("%v/%v", constants.DockerRegistry, defaults.HookContainerNameTag),
+						Command: []string{"/bin/sh", filepath.Join("/config", scriptFile)},
+						SecurityContext: &v1.SecurityContext{
+							Privileged: &privileged,
+						},
+						VolumeMounts: []v1.VolumeMount{
+							{Name: configVolume, MountPath: "/config"},
+							{Name: journaldVolume, MountPath: defaults.JournaldConfigDir},
+							{Name: containersVolume, MountPath: "/containers"},
+						},
+					}},
+					Volumes: []v1.Volume{
+						{
+							Name: configVolume,
+							VolumeSource: v1.VolumeSource{
+								ConfigMap: &v1.ConfigMapVolumeSource{
+									LocalObjectReference: v1.LocalObjectReference{Name: configMapName},
+								},
+							},
+						},
+						{
+							Name: journaldVolume,
+							VolumeSource: v1.VolumeSource{
+								HostPath: &v1.HostPathVolumeSource{
+									Path: defaults.JournaldConfigDir,
+									Type: &directoryOrCreate,
+								},
+							},
+						},
+						{
+							Name: containersVolume,
+							VolumeSource: v1.VolumeSource{
+								HostPath: &v1.HostPathVolumeSource{
+									Path: filepath.Join(defaults.PlanetDockerDir, "containers"),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	return configMap, daemonSet
+}
+
+// Apply creates or updates the objects that enforce the specified policy
+func Apply(ctx context.Context, client *kubernetes.Clientset, policy storage.LogRotation) error {
+	configMap, daemonSet := Objects(policy)
+	configMapControl, err := rigging.NewConfigMapControl(rigging.ConfigMapConfig{
+		ConfigMap: configMap,
+		Client:    client,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if err := configMapControl.Upsert(ctx); err != nil {
+		return trace.Wrap(err)
+	}
+	daemonSetControl, err := rigging.NewDSControl(rigging.DSConfig{
+		DaemonSet: daemonSet,
+		Client:    client,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(daemonSetControl.Upsert(ctx))
+}
+
+// Delete removes the objects that enforce the policy. The journald
+// configuration already installed on the nodes is left intact
+func Delete(client *kubernetes.Clientset) error {
+	var errors []error
+	err := rigging.ConvertError(client.AppsV1().DaemonSets(defaults.KubeSystemNamespace).
+		Delete(Name, &metav1.DeleteOptions{PropagationPolicy: &propagationPolicy}))
+	if err != nil && !trace.IsNotFound(err) {
+		errors = append(errors, trace.Wrap(err))
+	}
+	err = rigging.ConvertError(client.CoreV1().ConfigMaps(defaults.KubeSystemNamespace).
+		Delete(configMapName, nil))
+	if err != nil && !trace.IsNotFound(err) {
+		errors = append(errors, trace.Wrap(err))
+	}
+	return trace.NewAggregate(errors...)
+}
+
+// configMapName is the name of the config map with rendered configuration.
+// It is different from the one that stores the resource
+var configMapName = fmt.Sprintf("%v-config", Name)
+
+var propagationPolicy = metav1.DeletePropagationForeground
@@ -0,0 +1,91 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logrotation
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
+
+	teleservices "github.com/gravitational/teleport/lib/services"
+	check "gopkg.in/check.v1"
+)
+
+func TestLogRotation(t *testing.T) { check.TestingT(t) }
+
+type LogRotationSuite struct{}
+
+var _ = check.Suite(&LogRotationSuite{})
+
+func (s *LogRotationSuite) TestRendersJournald(c *check.C) {
+	retention := teleservices.NewDuration(7 * 24 * time.Hour)
+	policy := storage.NewLogRotation(storage.LogRotationSpecV2{
+		Journal: &storage.JournalRotation{
+			MaxUse:       utils.Capacity(2 << 30),
+			MaxFileSize:  utils.Capacity(128 << 20),
+			MaxRetention: &retention,
+		},
+	})
+	c.Assert(policy.CheckAndSetDefaults(), check.IsNil)
+	c.Assert(RenderJournald(policy), check.Equals, `# This file is managed by gravity, do not edit.
+# Use "gravity resource create" with logrotation resource instead.
+[Journal]
+SystemMaxUse=2147483648
+SystemMaxFileSize=134217728
+MaxRetentionSec=604800s
+`)
+	script := RenderScript(policy)
+	c.Assert(strings.Contains(script, "cp /config/50-gravity.conf"), check.Equals, true)
+	c.Assert(strings.Contains(script, "sleep 3600"), check.Equals, true)
+}
+
+func (s *LogRotationSuite) TestRendersScript(c *check.C) {
+	policy := storage.NewLogRotation(storage.LogRotationSpecV2{
+		Containers: &storage.ContainerLogRotation{MaxSize: utils.Capacity(100 << 20)},
+	})
+	c.Assert(policy.CheckAndSetDefaults(), check.IsNil)
+	c.Assert(RenderJournald(policy), check.Equals, "")
+	script := RenderScript(policy)
+	c.Assert(strings.Contains(script, "rm -f /etc/systemd/journald.conf.d/50-gravity.conf"), check.Equals, true)
+	c.Assert(strings.Contains(script, `-ge 104857600 ]`), check.Equals, true)
+	c.Assert(strings.Contains(script, "i=5\n"), check.Equals, true)
+	c.Assert(strings.Contains(script, "sleep 300\n"), check.Equals, true)
+}
+
+func (s *LogRotationSuite) TestObjectsTrackPolicy(c *check.C) {
+	policy := storage.NewLogRotation(storage.LogRotationSpecV2{
+		Containers: &storage.ContainerLogRotation{MaxSize: utils.Capacity(100 << 20)},
+	})
+	c.Assert(policy.CheckAndSetDefaults(), check.IsNil)
+	configMap, daemonSet := Objects(policy)
+	c.Assert(configMap.Data[scriptFile], check.Equals, RenderScript(policy))
+	c.Assert(daemonSet.Labels[constants.LogRotationLabel], check.Equals, Name)
+	c.Assert(daemonSet.Spec.Template.Spec.HostPID, check.Equals, true)
+	hash := daemonSet.Spec.Template.Annotations[configHashAnnotation]
+	c.Assert(hash, check.Not(check.Equals), "")
+
+	policy = storage.NewLogRotation(storage.LogRotationSpecV2{
+		Containers: &storage.ContainerLogRotation{MaxSize: utils.Capacity(200 << 20)},
+	})
+	c.Assert(policy.CheckAndSetDefaults(), check.IsNil)
+	_, daemonSet = Objects(policy)
+	c.Assert(daemonSet.Spec.Template.Annotations[configHashAnnotation], check.Not(check.Equals), hash)
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logrotation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ReconcilerConfig configures the log rotation reconciler
+type ReconcilerConfig struct {
+	// Operator is the cluster operator service
+	Operator ops.Operator
+	// Client is the Kubernetes client
+	Client *kubernetes.Clientset
+	// Interval is how often the policy is reconciled
+	Interval time.Duration
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+}
+
+// CheckAndSetDefaults validates the configuration and sets default values
+func (r *ReconcilerConfig) CheckAndSetDefaults() error {
+	if r.Operator == nil {
+		return trace.BadParameter("missing Operator")
+	}
+	if r.Client == nil {
+		return trace.BadParameter("missing Client")
+	}
+	if r.Interval == 0 {
+		r.Interval = defaults.LogRotationSyncInterval
+	}
+	if r.FieldLogger == nil {
+		r.FieldLogger = logrus.WithField(trace.Component, "logrotation")
+	}
+	return nil
+}
+
+// NewReconciler returns a new log rotation reconciler
+func NewReconciler(config ReconcilerConfig) (*Reconciler, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &Reconciler{ReconcilerConfig: config}, nil
+}
+
+// Reconciler keeps the log rotation settings on cluster nodes in sync
+// with the log rotation resource
+type Reconciler struct {
+	ReconcilerConfig
+	// applied is the rendered policy applied last
+	applied string
+	// removed is whether the objects have been removed after
+	// the policy has been deleted
+	removed bool
+}
+
+// Run reconciles the policy periodically until the context is canceled
+func (r *Reconciler) Run(ctx context.Context) error {
+	utils.RunPeriodically(ctx, r.Interval, r.FieldLogger,
+		"reconcile log rotation policy", r.Sync)
+	return nil
+}
+
+// Sync applies the log rotation policy if it has changed since the last
+// time and removes the objects that enforce it if the policy has been deleted
+func (r *Reconciler) Sync(ctx context.Context) error {
+	cluster, err := r.Operator.GetLocalSite()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	policy, err := r.Operator.GetLogRotation(cluster.Key())
+	if err != nil && !trace.IsNotFound(err) {
+		return trace.Wrap(err)
+	}
+	if policy == nil {
+		return trace.Wrap(r.remove())
+	}
+	if err := policy.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	rendered := RenderJournald(policy) + RenderScript(policy)
+	if r.applied == rendered {
+		return nil
+	}
+	r.Infof("Applying log rotation policy: %v.", describe(policy))
+	if err := Apply(ctx, r.Client, policy); err != nil {
+		return trace.Wrap(err)
+	}
+	r.applied = rendered
+	r.removed = false
+	return nil
+}
+
+// remove deletes the objects that enforce the policy once
+// after the policy has been deleted
+func (r *Reconciler) remove() error {
+	if r.removed {
+		return nil
+	}
+	if r.applied != "" {
+		r.Info("Log rotation policy has been deleted.")
+	}
+	if err := Delete(r.Client); err != nil {
+		return trace.Wrap(err)
+	}
+	r.applied = ""
+	r.removed = true
+	return nil
+}
+
+func describe(policy storage.LogRotation) string {
+	var journal, containers string
+	if j := policy.GetJournal(); j != nil {
+		journal = fmt.Sprintf("max_use=%v max_file_size=%v", j.MaxUse, j.MaxFileSize)
+		if j.MaxRetention != nil {
+			journal = fmt.Sprintf("%v max_retention=%v", journal, j.MaxRetention.Value())
+		}
+	}
+	if c := policy.GetContainers(); c != nil {
+		containers = fmt.Sprintf("max_size=%v max_files=%v", c.MaxSize, c.MaxFiles)
+	}
+	return fmt.Sprintf("journal=[%v] containers=[%v]", journal, containers)
+}
@@ -34,6 +34,9 @@ type AgentReport struct {
 	// Servers returns a list of servers that have agents
 	// installed on them
 	Servers []checks.ServerInfo `json:"servers"`
+	// Recommendation is the flavor and profile placement
+	// recommended for the servers based on their hardware
+	Recommendation *checks.Recommendation `json:"recommendation,omitempty"`
 }
 
 // RawAgentReport is a transport-friendly agent report representation
@@ -43,12 +46,15 @@ type RawAgentReport struct {
 	// Servers returns a list of servers that have agents
 	// installed on them
 	Servers []checks.RawServerInfo `json:"servers"`
+	// Recommendation is the flavor and profile placement
+	// recommended for the servers based on their hardware
+	Recommendation *checks.Recommendation `json:"recommendation,omitempty"`
 }
 
 // Transport returns transport-friendly representation
 // of agent report
 func (r *AgentReport) Transport() (*RawAgentReport, error) {
-	resp := RawAgentReport{Message: r.Message}
+	resp := RawAgentReport{Message: r.Message, Recommendation: r.Recommendation}
 	for _, server := range r.Servers {
 		info, err := server.Transport()
 		if err != nil {
@@ -63,7 +69,7 @@ func (r *AgentReport) Transport() (*RawAgentReport, error) {
 // FromTransport converts from transport-friendly representation
 // of agent report
 func (r *RawAgentReport) FromTransport() (*AgentReport, error) {
-	resp := AgentReport{Message: r.Message}
+	resp := AgentReport{Message: r.Message, Recommendation: r.Recommendation}
 	for _, server := range r.Servers {
 		info, err := server.FromTransport()
 		if err != nil {
@@ -0,0 +1,60 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ops
+
+import (
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+)
+
+// PhaseApprovals defines the interface to gate the execution of
+// operation plan phases with an operator approval
+type PhaseApprovals interface {
+	// ApprovePlanPhase approves the execution of the operation plan phase,
+	// or marks the phase as requiring approval, and returns the plan change
+	// recorded in the plan changelog
+	ApprovePlanPhase(ApprovePlanPhaseRequest) (*storage.PlanChange, error)
+}
+
+// ApprovePlanPhaseRequest is a request to approve the execution
+// of an operation plan phase
+type ApprovePlanPhaseRequest struct {
+	// SiteOperationKey identifies the operation
+	SiteOperationKey `json:"key"`
+	// PhaseID is the ID of the plan phase
+	PhaseID string `json:"phase_id"`
+	// Require marks the phase as requiring approval instead of approving it
+	Require bool `json:"require"`
+	// User is the name of the user approving the phase.
+	// It is set from the authenticated user
+	User string `json:"user"`
+}
+
+// Check validates this request
+func (r ApprovePlanPhaseRequest) Check() error {
+	if err := r.SiteOperationKey.Check(); err != nil {
+		return trace.Wrap(err)
+	}
+	if r.PhaseID == "" {
+		return trace.BadParameter("missing phase ID")
+	}
+	if !r.Require && r.User == "" {
+		return trace.BadParameter("missing user approving the phase")
+	}
+	return nil
+}
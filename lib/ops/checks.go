@@ -35,9 +35,10 @@ import (
 // agentService is the access point to the agent cluster for running remote
 // commands.
 // manifest specifies the application manifest with requirements.
+// vxlanPort specifies the overlay network port tested between the servers.
 func CheckServers(ctx context.Context, opKey SiteOperationKey,
 	infos checks.ServerInfos, servers []storage.Server, agentService AgentService,
-	manifest schema.Manifest) error {
+	manifest schema.Manifest, vxlanPort int) error {
 	nodes, err := mergeServers(infos, servers)
 	if err != nil {
 		return trace.Wrap(err)
@@ -53,6 +54,7 @@ func CheckServers(ctx context.Context, opKey SiteOperationKey,
 	}
 	c.TestBandwidth = true
 	c.TestDockerDevice = true
+	c.ClusterPorts = checks.ClusterPorts(vxlanPort)
 	return trace.Wrap(c.Run(ctx))
 }
 
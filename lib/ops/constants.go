@@ -85,6 +85,12 @@ const (
 	SiteStateUninstalling = "uninstalling"
 	// SiteStateGarbageCollecting is the state of the cluster when it's removing unused resources
 	SiteStateGarbageCollecting = "collecting_garbage"
+	// SiteStateRestarting is the state of the cluster when its nodes are being
+	// restarted one by one
+	SiteStateRestarting = "restarting"
+	// SiteStateMigratingDocker is the state of the cluster when docker storage
+	// driver is being migrated on its nodes
+	SiteStateMigratingDocker = "migrating_docker"
 	// SiteStateDegraded means that the application installed on a deployed site is failing its health check
 	SiteStateDegraded = "degraded"
 	// SiteStateOffline means that OpsCenter cannot connect to remote site
@@ -124,6 +130,14 @@ const (
 	OperationGarbageCollect           = "operation_gc"
 	OperationGarbageCollectInProgress = "gc_in_progress"
 
+	// rolling restart operation
+	OperationRollingRestart           = "operation_restart"
+	OperationRollingRestartInProgress = "restart_in_progress"
+
+	// docker storage driver migration operation
+	OperationDockerMigrate           = "operation_docker_migrate"
+	OperationDockerMigrateInProgress = "docker_migrate_in_progress"
+
 	// common operation states
 	OperationStateCompleted = "completed"
 	OperationStateFailed    = "failed"
@@ -214,6 +228,8 @@ var (
 		OperationShrink:         SiteStateShrinking,
 		OperationUninstall:      SiteStateUninstalling,
 		OperationGarbageCollect: SiteStateGarbageCollecting,
+		OperationRollingRestart: SiteStateRestarting,
+		OperationDockerMigrate:  SiteStateMigratingDocker,
 	}
 
 	// OperationSucceededToClusterState defines states the cluster transitions
@@ -225,6 +241,8 @@ var (
 		OperationShrink:         SiteStateActive,
 		OperationUninstall:      SiteStateNotInstalled,
 		OperationGarbageCollect: SiteStateActive,
+		OperationRollingRestart: SiteStateActive,
+		OperationDockerMigrate:  SiteStateActive,
 	}
 
 	// OperationFailedToClusterState defines states the cluster transitions
@@ -238,5 +256,7 @@ var (
 		OperationShrink:         SiteStateActive,
 		OperationUninstall:      SiteStateFailed,
 		OperationGarbageCollect: SiteStateActive,
+		OperationRollingRestart: SiteStateActive,
+		OperationDockerMigrate:  SiteStateActive,
 	}
 )
@@ -0,0 +1,98 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ops
+
+import (
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+)
+
+// OperationLocks defines the interface to inspect and release
+// the cluster resources held by active operations
+type OperationLocks interface {
+	// GetOperationLocks returns the resources held by active cluster operations
+	GetOperationLocks(SiteKey) ([]storage.OperationLock, error)
+	// ReleaseOperationLocks forcibly releases the resources held by
+	// the specified operation by marking it failed
+	ReleaseOperationLocks(ReleaseOperationLocksRequest) error
+}
+
+// ReleaseOperationLocksRequest is a request to forcibly release
+// the resources held by an operation
+type ReleaseOperationLocksRequest struct {
+	// SiteOperationKey identifies the operation holding the locks
+	SiteOperationKey `json:"key"`
+	// Reason is the reason for the release recorded in the audit trail
+	Reason string `json:"reason"`
+	// User is the name of the user releasing the locks.
+	// It is set from the authenticated user
+	User string `json:"user"`
+	// Force releases the locks even if the operation has been
+	// updated recently and might still be running
+	Force bool `json:"force"`
+}
+
+// Check validates this request
+func (r ReleaseOperationLocksRequest) Check() error {
+	if err := r.SiteOperationKey.Check(); err != nil {
+		return trace.Wrap(err)
+	}
+	if r.Reason == "" {
+		return trace.BadParameter("the reason for releasing the locks is required")
+	}
+	return nil
+}
+
+// GetOperationLocks returns the cluster resources held by the specified operation.
+// Finished operations hold no locks
+func GetOperationLocks(operation SiteOperation) (locks []storage.OperationLock) {
+	if operation.IsFinished() {
+		return nil
+	}
+	newLock := func(scope, resource string) storage.OperationLock {
+		return storage.OperationLock{
+			Scope:          scope,
+			Resource:       resource,
+			OperationID:    operation.ID,
+			OperationType:  operation.Type,
+			OperationState: operation.State,
+			Since:          operation.Created,
+		}
+	}
+	if _, ok := OperationStartedToClusterState[operation.Type]; ok {
+		locks = append(locks, newLock(storage.OperationLockScopeCluster, operation.SiteDomain))
+	}
+	nodes := make(map[string]struct{})
+	addNode := func(addr string) {
+		if _, ok := nodes[addr]; ok || addr == "" {
+			return
+		}
+		nodes[addr] = struct{}{}
+		locks = append(locks, newLock(storage.OperationLockScopeNode, addr))
+	}
+	for _, server := range operation.Servers {
+		addNode(server.AdvertiseIP)
+	}
+	if operation.InstallExpand != nil {
+		// nodes in a batch are locked before they have joined
+		for _, node := range operation.InstallExpand.Vars.System.BatchNodes {
+			addNode(node.AdvertiseIP)
+		}
+	}
+	return locks
+}
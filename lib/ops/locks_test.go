@@ -0,0 +1,69 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ops
+
+import (
+	"time"
+
+	"github.com/gravitational/gravity/lib/storage"
+
+	check "gopkg.in/check.v1"
+)
+
+type LocksSuite struct{}
+
+var _ = check.Suite(&LocksSuite{})
+
+func (s *LocksSuite) TestOperationLocks(c *check.C) {
+	created := time.Date(2018, time.June, 1, 12, 0, 0, 0, time.UTC)
+	operation := SiteOperation{
+		ID:         "1",
+		SiteDomain: "example.com",
+		Type:       OperationExpand,
+		State:      OperationStateExpandProvisioning,
+		Created:    created,
+		Servers:    []storage.Server{{AdvertiseIP: "192.168.1.2"}},
+		InstallExpand: &storage.InstallExpandOperationState{
+			Vars: storage.OperationVariables{
+				System: storage.SystemVariables{
+					BatchNodes: []storage.BatchNode{
+						{AdvertiseIP: "192.168.1.2"},
+						{AdvertiseIP: "192.168.1.3"},
+					},
+				},
+			},
+		},
+	}
+	lock := func(scope, resource string) storage.OperationLock {
+		return storage.OperationLock{
+			Scope:          scope,
+			Resource:       resource,
+			OperationID:    "1",
+			OperationType:  OperationExpand,
+			OperationState: OperationStateExpandProvisioning,
+			Since:          created,
+		}
+	}
+	c.Assert(GetOperationLocks(operation), check.DeepEquals, []storage.OperationLock{
+		lock(storage.OperationLockScopeCluster, "example.com"),
+		lock(storage.OperationLockScopeNode, "192.168.1.2"),
+		lock(storage.OperationLockScopeNode, "192.168.1.3"),
+	})
+
+	operation.State = OperationStateCompleted
+	c.Assert(GetOperationLocks(operation), check.HasLen, 0)
+}
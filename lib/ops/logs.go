@@ -0,0 +1,139 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ops
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// LogComponentAgent tags log entries of the install agents
+	LogComponentAgent = "agent"
+	// LogComponentApp tags log entries of the application hooks and resources
+	LogComponentApp = "app"
+	// LogComponentChecks tags log entries of the preflight checks
+	LogComponentChecks = "checks"
+	// LogComponentDNS tags log entries of the cluster DNS
+	LogComponentDNS = "dns"
+	// LogComponentElection tags log entries of the leader election
+	LogComponentElection = "election"
+	// LogComponentEtcd tags log entries of the etcd cluster management
+	LogComponentEtcd = "etcd"
+	// LogComponentHealth tags log entries of the cluster health checks
+	LogComponentHealth = "health"
+	// LogComponentInstaller tags log entries of the installer process
+	LogComponentInstaller = "installer"
+	// LogComponentKubernetes tags log entries of the Kubernetes resource management
+	LogComponentKubernetes = "kubernetes"
+	// LogComponentPackages tags log entries of the package management
+	LogComponentPackages = "packages"
+	// LogComponentPlanet tags log entries of the runtime container
+	LogComponentPlanet = "planet"
+	// LogComponentRegistry tags log entries of the container registry
+	LogComponentRegistry = "registry"
+	// LogComponentSystem tags log entries of the system services and node setup
+	LogComponentSystem = "system"
+)
+
+// LogFilter selects operation log entries
+type LogFilter struct {
+	// Component selects entries emitted by the specified component
+	Component string `json:"component,omitempty"`
+	// Phase selects entries emitted by the specified phase and its subphases
+	Phase string `json:"phase,omitempty"`
+	// Level is the minimum severity of the selected entries, e.g. warn
+	Level string `json:"level,omitempty"`
+}
+
+// Check validates this filter
+func (r LogFilter) Check() error {
+	if r.Level != "" {
+		if _, err := logrus.ParseLevel(r.Level); err != nil {
+			return trace.BadParameter("invalid log level %q", r.Level)
+		}
+	}
+	return nil
+}
+
+// Matches returns true if the entry satisfies this filter
+func (r LogFilter) Matches(entry LogEntry) bool {
+	if r.Component != "" && entry.Component != r.Component {
+		return false
+	}
+	if r.Phase != "" && !isSubphase(entry.Phase, r.Phase) {
+		return false
+	}
+	if r.Level != "" {
+		level, err := logrus.ParseLevel(r.Level)
+		if err != nil {
+			return false
+		}
+		severity, err := logrus.ParseLevel(entry.Severity)
+		if err != nil {
+			severity = logrus.InfoLevel
+		}
+		// more severe levels have lower values
+		if severity > level {
+			return false
+		}
+	}
+	return true
+}
+
+// Values returns this filter as URL query values
+func (r LogFilter) Values() url.Values {
+	values := url.Values{}
+	if r.Component != "" {
+		values.Set("component", r.Component)
+	}
+	if r.Phase != "" {
+		values.Set("phase", r.Phase)
+	}
+	if r.Level != "" {
+		values.Set("level", r.Level)
+	}
+	return values
+}
+
+// LogFilterFromValues returns the log filter from the URL query values
+func LogFilterFromValues(values url.Values) LogFilter {
+	return LogFilter{
+		Component: values.Get("component"),
+		Phase:     values.Get("phase"),
+		Level:     values.Get("level"),
+	}
+}
+
+// FilterLogEntries returns the entries that satisfy the filter
+func FilterLogEntries(entries []LogEntry, filter LogFilter) (result []LogEntry) {
+	for _, entry := range entries {
+		if filter.Matches(entry) {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
+
+// isSubphase returns true if the phase is the parent phase or one of its subphases
+func isSubphase(phase, parent string) bool {
+	parent = strings.TrimSuffix(parent, "/")
+	return phase == parent || strings.HasPrefix(phase, parent+"/")
+}
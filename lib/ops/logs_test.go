@@ -0,0 +1,72 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ops
+
+import (
+	check "gopkg.in/check.v1"
+)
+
+type LogsSuite struct{}
+
+var _ = check.Suite(&LogsSuite{})
+
+func (s *LogsSuite) TestFiltersLogEntries(c *check.C) {
+	entries := []LogEntry{
+		{Severity: "debug", Component: LogComponentEtcd, Phase: "/masters/node-1/etcd"},
+		{Severity: "warn", Component: LogComponentEtcd, Phase: "/masters/node-10/etcd"},
+		{Severity: "error", Component: LogComponentPlanet, Phase: "/masters/node-1/planet"},
+		{Severity: "info"},
+	}
+	var testCases = []struct {
+		filter   LogFilter
+		expected []LogEntry
+		comment  string
+	}{
+		{
+			filter:   LogFilter{},
+			expected: entries,
+			comment:  "empty filter selects all entries",
+		},
+		{
+			filter:   LogFilter{Component: LogComponentEtcd},
+			expected: entries[:2],
+			comment:  "entries of a component",
+		},
+		{
+			filter:   LogFilter{Level: "warn"},
+			expected: entries[1:3],
+			comment:  "entries with the minimum severity",
+		},
+		{
+			filter:   LogFilter{Phase: "/masters/node-1/"},
+			expected: []LogEntry{entries[0], entries[2]},
+			comment:  "entries of a phase and its subphases",
+		},
+		{
+			filter:   LogFilter{Component: LogComponentEtcd, Phase: "/masters/node-1", Level: "info"},
+			expected: nil,
+			comment:  "all conditions must match",
+		},
+	}
+	for _, tc := range testCases {
+		comment := check.Commentf(tc.comment)
+		c.Assert(tc.filter.Check(), check.IsNil, comment)
+		c.Assert(FilterLogEntries(entries, tc.filter), check.DeepEquals, tc.expected, comment)
+		c.Assert(LogFilterFromValues(tc.filter.Values()), check.DeepEquals, tc.filter, comment)
+	}
+	c.Assert(LogFilter{Level: "loud"}.Check(), check.NotNil)
+}
@@ -20,12 +20,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/rigging"
 	"github.com/gravitational/roundtrip"
 	"github.com/gravitational/teleport/lib/httplib"
 	"github.com/gravitational/trace"
+
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 )
 
 type influxDB struct {
@@ -115,6 +119,58 @@ func (i *influxDB) PostForm(endpoint string, params url.Values) (*roundtrip.Resp
 	return httplib.ConvertResponse(i.Client.PostForm(endpoint, params))
 }
 
+// QueryValue executes the specified query against the cluster metrics database
+// through the Kubernetes API server service proxy and returns the last value
+// of the first series in the result.
+// Returns trace.NotFound if the query has not produced any values
+func QueryValue(client corev1.CoreV1Interface, query string) (float64, error) {
+	namespace, err := GetNamespace(client)
+	if err != nil {
+		return 0, trace.Wrap(err)
+	}
+	response, err := client.Services(namespace).ProxyGet("http",
+		defaults.InfluxDBServiceName, strconv.Itoa(defaults.InfluxDBServicePort),
+		"query", map[string]string{
+			"db": defaults.InfluxDBDatabase,
+			"q":  query,
+			"u":  defaults.InfluxDBAdminUser,
+			"p":  defaults.InfluxDBAdminPassword,
+		}).DoRaw()
+	if err != nil {
+		return 0, trace.Wrap(rigging.ConvertError(err))
+	}
+	return parseQueryValue(response)
+}
+
+// parseQueryValue returns the last value of the first series
+// in the specified InfluxDB API response
+func parseQueryValue(data []byte) (float64, error) {
+	var parsed influxDBResponse
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return 0, trace.Wrap(err)
+	}
+	if len(parsed.Results) == 0 {
+		return 0, trace.NotFound("results are empty")
+	}
+	result := parsed.Results[0]
+	if result.Error != "" {
+		return 0, trace.BadParameter("query failed: %v", result.Error)
+	}
+	if len(result.Series) == 0 || len(result.Series[0].Values) == 0 {
+		return 0, trace.NotFound("series are empty")
+	}
+	values := result.Series[0].Values
+	row := values[len(values)-1]
+	if len(row) == 0 || row[len(row)-1] == nil {
+		return 0, trace.NotFound("no value in series")
+	}
+	value, ok := row[len(row)-1].(float64)
+	if !ok {
+		return 0, trace.BadParameter("expected numeric value, got %v", row[len(row)-1])
+	}
+	return value, nil
+}
+
 // influxDBResponse represents a response from InfluxDB API
 type influxDBResponse struct {
 	// Results is the list of results
@@ -125,6 +181,8 @@ type influxDBResponse struct {
 type influxDBResult struct {
 	// Series is the list of series
 	Series []influxDBSeries `json:"series"`
+	// Error is the query error
+	Error string `json:"error,omitempty"`
 }
 
 // influxDBSeries represents a single series in InfluxDB API result
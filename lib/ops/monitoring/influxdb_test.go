@@ -0,0 +1,45 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"testing"
+
+	"github.com/gravitational/trace"
+	. "gopkg.in/check.v1"
+)
+
+func TestMonitoring(t *testing.T) { TestingT(t) }
+
+type InfluxDBSuite struct{}
+
+var _ = Suite(&InfluxDBSuite{})
+
+func (s *InfluxDBSuite) TestParseQueryValue(c *C) {
+	value, err := parseQueryValue([]byte(`{"results":[{"series":[{"name":"errors","columns":["time","last"],"values":[["2018-01-01T00:00:00Z",0.5],["2018-01-01T00:01:00Z",0.25]]}]}]}`))
+	c.Assert(err, IsNil)
+	c.Assert(value, Equals, 0.25)
+
+	_, err = parseQueryValue([]byte(`{"results":[{}]}`))
+	c.Assert(trace.IsNotFound(err), Equals, true)
+
+	_, err = parseQueryValue([]byte(`{"results":[{"series":[{"columns":["time","last"],"values":[["2018-01-01T00:00:00Z",null]]}]}]}`))
+	c.Assert(trace.IsNotFound(err), Equals, true)
+
+	_, err = parseQueryValue([]byte(`{"results":[{"error":"database not found: k8s"}]}`))
+	c.Assert(trace.IsBadParameter(err), Equals, true)
+}
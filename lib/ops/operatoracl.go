@@ -27,6 +27,7 @@ import (
 	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/modules"
 	"github.com/gravitational/gravity/lib/ops/monitoring"
+	"github.com/gravitational/gravity/lib/pack"
 	"github.com/gravitational/gravity/lib/storage"
 	"github.com/gravitational/gravity/lib/users"
 	"github.com/gravitational/gravity/lib/utils"
@@ -111,6 +112,35 @@ func (o *OperatorACL) ClusterAction(clusterName, resourceKind, action string) er
 	return o.checker.CheckAccessToRule(ctx, cluster.GetMetadata().Namespace, resourceKind, action, false)
 }
 
+// OperationAction checks access to start an operation of the specified type
+// in the cluster.
+//
+// Users who can update the cluster can start operations of any type unless
+// the type is denied to them by a deny rule for the operation resource.
+// Users who cannot update the cluster can be allowed to start operations
+// of specific types by allow rules for the operation resource
+func (o *OperatorACL) OperationAction(clusterName, operationType string) error {
+	ctx, cluster, err := o.clusterContext(clusterName)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	namespace := cluster.GetMetadata().Namespace
+	verb := OperationVerb(operationType)
+	err = o.checker.CheckAccessToRule(ctx, namespace, storage.KindOperation, verb, true)
+	if err == nil {
+		return nil
+	}
+	denied, err := users.IsDenied(o.checker, ctx, namespace, storage.KindOperation, verb)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if denied {
+		return trace.AccessDenied("access denied to start %v operation in cluster %v",
+			verb, clusterName)
+	}
+	return o.checker.CheckAccessToRule(ctx, namespace, storage.KindCluster, teleservices.VerbUpdate, false)
+}
+
 func (o *OperatorACL) repoContext(repoName string) *users.Context {
 	return &users.Context{
 		Context: teleservices.Context{
@@ -393,6 +423,48 @@ func (o *OperatorACL) CompleteFinalInstallStep(req CompleteFinalInstallStepReque
 	return o.operator.CompleteFinalInstallStep(req)
 }
 
+// GetQuarantinedNodes returns the list of quarantined nodes
+func (o *OperatorACL) GetQuarantinedNodes(key SiteKey) (storage.QuarantinedNodes, error) {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindCluster, teleservices.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return o.operator.GetQuarantinedNodes(key)
+}
+
+// QuarantineNode quarantines the specified node
+func (o *OperatorACL) QuarantineNode(key SiteKey, node storage.QuarantinedNode) error {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindCluster, teleservices.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return o.operator.QuarantineNode(key, node)
+}
+
+// ReleaseQuarantinedNode releases the specified node from quarantine
+func (o *OperatorACL) ReleaseQuarantinedNode(key SiteKey, advertiseIP string) error {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindCluster, teleservices.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return o.operator.ReleaseQuarantinedNode(key, advertiseIP)
+}
+
+// GetOperationLocks returns the resources held by active cluster operations
+func (o *OperatorACL) GetOperationLocks(key SiteKey) ([]storage.OperationLock, error) {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindCluster, teleservices.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return o.operator.GetOperationLocks(key)
+}
+
+// ReleaseOperationLocks forcibly releases the resources held by the specified operation
+func (o *OperatorACL) ReleaseOperationLocks(req ReleaseOperationLocksRequest) error {
+	if err := o.ClusterAction(req.SiteDomain, storage.KindCluster, teleservices.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	// record the authenticated user in the audit trail
+	req.User = o.username
+	return o.operator.ReleaseOperationLocks(req)
+}
+
 func (o *OperatorACL) CheckSiteStatus(key SiteKey) error {
 	// TODO(klizhentas) introduce more fine grained RBAC, right now
 	// we use this Update requirement to limit access to admin only users
@@ -432,35 +504,35 @@ func (o *OperatorACL) GetSiteOperation(key SiteOperationKey) (*SiteOperation, er
 }
 
 func (o *OperatorACL) CreateSiteInstallOperation(req CreateSiteInstallOperationRequest) (*SiteOperationKey, error) {
-	if err := o.ClusterAction(req.SiteDomain, storage.KindCluster, teleservices.VerbUpdate); err != nil {
+	if err := o.OperationAction(req.SiteDomain, OperationInstall); err != nil {
 		return nil, trace.Wrap(err)
 	}
 	return o.operator.CreateSiteInstallOperation(req)
 }
 
 func (o *OperatorACL) ResumeShrink(key SiteKey) (*SiteOperationKey, error) {
-	if err := o.ClusterAction(key.SiteDomain, storage.KindCluster, teleservices.VerbUpdate); err != nil {
+	if err := o.OperationAction(key.SiteDomain, OperationShrink); err != nil {
 		return nil, trace.Wrap(err)
 	}
 	return o.operator.ResumeShrink(key)
 }
 
 func (o *OperatorACL) CreateSiteExpandOperation(req CreateSiteExpandOperationRequest) (*SiteOperationKey, error) {
-	if err := o.ClusterAction(req.SiteDomain, storage.KindCluster, teleservices.VerbUpdate); err != nil {
+	if err := o.OperationAction(req.SiteDomain, OperationExpand); err != nil {
 		return nil, trace.Wrap(err)
 	}
 	return o.operator.CreateSiteExpandOperation(req)
 }
 
 func (o *OperatorACL) CreateSiteShrinkOperation(req CreateSiteShrinkOperationRequest) (*SiteOperationKey, error) {
-	if err := o.ClusterAction(req.SiteDomain, storage.KindCluster, teleservices.VerbUpdate); err != nil {
+	if err := o.OperationAction(req.SiteDomain, OperationShrink); err != nil {
 		return nil, trace.Wrap(err)
 	}
 	return o.operator.CreateSiteShrinkOperation(req)
 }
 
 func (o *OperatorACL) CreateSiteAppUpdateOperation(req CreateSiteAppUpdateOperationRequest) (*SiteOperationKey, error) {
-	if err := o.ClusterAction(req.SiteDomain, storage.KindCluster, teleservices.VerbUpdate); err != nil {
+	if err := o.OperationAction(req.SiteDomain, OperationUpdate); err != nil {
 		return nil, trace.Wrap(err)
 	}
 	return o.operator.CreateSiteAppUpdateOperation(req)
@@ -481,7 +553,7 @@ func (o *OperatorACL) SiteInstallOperationStart(key SiteOperationKey) error {
 }
 
 func (o *OperatorACL) CreateSiteUninstallOperation(req CreateSiteUninstallOperationRequest) (*SiteOperationKey, error) {
-	if err := o.ClusterAction(req.SiteDomain, storage.KindCluster, teleservices.VerbUpdate); err != nil {
+	if err := o.OperationAction(req.SiteDomain, OperationUninstall); err != nil {
 		return nil, trace.Wrap(err)
 	}
 	return o.operator.CreateSiteUninstallOperation(req)
@@ -489,12 +561,28 @@ func (o *OperatorACL) CreateSiteUninstallOperation(req CreateSiteUninstallOperat
 
 // CreateClusterGarbageCollectOperation creates a new garbage collection operation in the cluster
 func (o *OperatorACL) CreateClusterGarbageCollectOperation(req CreateClusterGarbageCollectOperationRequest) (*SiteOperationKey, error) {
-	if err := o.ClusterAction(req.ClusterName, storage.KindCluster, teleservices.VerbUpdate); err != nil {
+	if err := o.OperationAction(req.ClusterName, OperationGarbageCollect); err != nil {
 		return nil, trace.Wrap(err)
 	}
 	return o.operator.CreateClusterGarbageCollectOperation(req)
 }
 
+// CreateClusterRollingRestartOperation creates a new rolling restart operation in the cluster
+func (o *OperatorACL) CreateClusterRollingRestartOperation(req CreateClusterRollingRestartOperationRequest) (*SiteOperationKey, error) {
+	if err := o.OperationAction(req.ClusterName, OperationRollingRestart); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return o.operator.CreateClusterRollingRestartOperation(req)
+}
+
+// CreateClusterDockerMigrationOperation creates a new docker storage driver migration operation in the cluster
+func (o *OperatorACL) CreateClusterDockerMigrationOperation(req CreateClusterDockerMigrationOperationRequest) (*SiteOperationKey, error) {
+	if err := o.OperationAction(req.ClusterName, OperationDockerMigrate); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return o.operator.CreateClusterDockerMigrationOperation(req)
+}
+
 func (o *OperatorACL) GetSiteOperationLogs(key SiteOperationKey) (io.ReadCloser, error) {
 	if err := o.ClusterAction(key.SiteDomain, storage.KindCluster, teleservices.VerbRead); err != nil {
 		return nil, trace.Wrap(err)
@@ -502,6 +590,15 @@ func (o *OperatorACL) GetSiteOperationLogs(key SiteOperationKey) (io.ReadCloser,
 	return o.operator.GetSiteOperationLogs(key)
 }
 
+// GetSiteOperationLogEntries returns the structured log entries
+// of the operation that match the provided filter
+func (o *OperatorACL) GetSiteOperationLogEntries(key SiteOperationKey, filter LogFilter) ([]LogEntry, error) {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindCluster, teleservices.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return o.operator.GetSiteOperationLogEntries(key, filter)
+}
+
 func (o *OperatorACL) CreateLogEntry(key SiteOperationKey, entry LogEntry) error {
 	if err := o.ClusterAction(key.SiteDomain, storage.KindCluster, teleservices.VerbUpdate); err != nil {
 		return trace.Wrap(err)
@@ -623,6 +720,17 @@ func (o *OperatorACL) CreateOperationPlanChange(key SiteOperationKey, change sto
 	return o.operator.CreateOperationPlanChange(key, change)
 }
 
+// ApprovePlanPhase approves the execution of the operation plan phase
+// or marks the phase as requiring approval
+func (o *OperatorACL) ApprovePlanPhase(req ApprovePlanPhaseRequest) (*storage.PlanChange, error) {
+	if err := o.ClusterAction(req.SiteDomain, storage.KindCluster, teleservices.VerbUpdate); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	// record the authenticated user as the approver
+	req.User = o.username
+	return o.operator.ApprovePlanPhase(req)
+}
+
 // GetOperationPlan returns plan for the specified operation
 func (o *OperatorACL) GetOperationPlan(key SiteOperationKey) (*storage.OperationPlan, error) {
 	if err := o.ClusterAction(key.SiteDomain, storage.KindCluster, teleservices.VerbRead); err != nil {
@@ -776,6 +884,283 @@ func (o *OperatorACL) DeleteAlertTarget(key SiteKey) error {
 	return o.operator.DeleteAlertTarget(key)
 }
 
+// GetDNSProviders returns the list of configured DNS providers
+func (o *OperatorACL) GetDNSProviders(key SiteKey) ([]storage.DNSProvider, error) {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindDNSProvider, teleservices.VerbList); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return o.operator.GetDNSProviders(key)
+}
+
+// UpsertDNSProvider creates or updates the specified DNS provider
+func (o *OperatorACL) UpsertDNSProvider(key SiteKey, provider storage.DNSProvider) error {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindDNSProvider, teleservices.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return o.operator.UpsertDNSProvider(key, provider)
+}
+
+// DeleteDNSProvider deletes the DNS provider specified with name
+func (o *OperatorACL) DeleteDNSProvider(key SiteKey, name string) error {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindDNSProvider, teleservices.VerbDelete); err != nil {
+		return trace.Wrap(err)
+	}
+	return o.operator.DeleteDNSProvider(key, name)
+}
+
+// GetWebhooks returns the list of configured webhooks
+func (o *OperatorACL) GetWebhooks(key SiteKey) ([]storage.Webhook, error) {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindWebhook, teleservices.VerbList); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return o.operator.GetWebhooks(key)
+}
+
+// UpsertWebhook creates or updates the specified webhook
+func (o *OperatorACL) UpsertWebhook(key SiteKey, webhook storage.Webhook) error {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindWebhook, teleservices.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return o.operator.UpsertWebhook(key, webhook)
+}
+
+// DeleteWebhook deletes the webhook specified with name
+func (o *OperatorACL) DeleteWebhook(key SiteKey, name string) error {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindWebhook, teleservices.VerbDelete); err != nil {
+		return trace.Wrap(err)
+	}
+	return o.operator.DeleteWebhook(key, name)
+}
+
+// GetPackageRetentionPolicies returns the list of configured package retention policies
+func (o *OperatorACL) GetPackageRetentionPolicies(key SiteKey) ([]storage.PackageRetentionPolicy, error) {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindPackageRetention, teleservices.VerbList); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return o.operator.GetPackageRetentionPolicies(key)
+}
+
+// UpsertPackageRetentionPolicy creates or updates the specified package retention policy
+func (o *OperatorACL) UpsertPackageRetentionPolicy(key SiteKey, policy storage.PackageRetentionPolicy) error {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindPackageRetention, teleservices.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return o.operator.UpsertPackageRetentionPolicy(key, policy)
+}
+
+// DeletePackageRetentionPolicy deletes the package retention policy specified with name
+func (o *OperatorACL) DeletePackageRetentionPolicy(key SiteKey, name string) error {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindPackageRetention, teleservices.VerbDelete); err != nil {
+		return trace.Wrap(err)
+	}
+	return o.operator.DeletePackageRetentionPolicy(key, name)
+}
+
+// GetSecretBackends returns the list of configured secret backends
+func (o *OperatorACL) GetSecretBackends(key SiteKey, withSecrets bool) ([]storage.SecretBackend, error) {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindSecretBackend, teleservices.VerbList); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if withSecrets {
+		if err := o.ClusterAction(key.SiteDomain, storage.KindSecretBackend, teleservices.VerbRead); err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+	return o.operator.GetSecretBackends(key, withSecrets)
+}
+
+// UpsertSecretBackend creates or updates the specified secret backend
+func (o *OperatorACL) UpsertSecretBackend(key SiteKey, backend storage.SecretBackend) error {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindSecretBackend, teleservices.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return o.operator.UpsertSecretBackend(key, backend)
+}
+
+// DeleteSecretBackend deletes the secret backend specified with name
+func (o *OperatorACL) DeleteSecretBackend(key SiteKey, name string) error {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindSecretBackend, teleservices.VerbDelete); err != nil {
+		return trace.Wrap(err)
+	}
+	return o.operator.DeleteSecretBackend(key, name)
+}
+
+// GetPackageStats returns usage statistics of the cluster package store
+func (o *OperatorACL) GetPackageStats(key SiteKey) (*pack.StoreStats, error) {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindRepository, teleservices.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return o.operator.GetPackageStats(key)
+}
+
+// GetIngressController returns the ingress controller configuration
+func (o *OperatorACL) GetIngressController(key SiteKey) (storage.IngressController, error) {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindIngressController, teleservices.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return o.operator.GetIngressController(key)
+}
+
+// UpsertIngressController creates or updates the ingress controller configuration
+func (o *OperatorACL) UpsertIngressController(key SiteKey, controller storage.IngressController) error {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindIngressController, teleservices.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return o.operator.UpsertIngressController(key, controller)
+}
+
+// DeleteIngressController deletes the ingress controller configuration
+func (o *OperatorACL) DeleteIngressController(key SiteKey) error {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindIngressController, teleservices.VerbDelete); err != nil {
+		return trace.Wrap(err)
+	}
+	return o.operator.DeleteIngressController(key)
+}
+
+// GetTimeSync returns the time synchronization configuration
+func (o *OperatorACL) GetTimeSync(key SiteKey) (storage.TimeSync, error) {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindTimeSync, teleservices.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return o.operator.GetTimeSync(key)
+}
+
+// UpsertTimeSync creates or updates the time synchronization configuration
+func (o *OperatorACL) UpsertTimeSync(key SiteKey, config storage.TimeSync) error {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindTimeSync, teleservices.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return o.operator.UpsertTimeSync(key, config)
+}
+
+// DeleteTimeSync deletes the time synchronization configuration
+func (o *OperatorACL) DeleteTimeSync(key SiteKey) error {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindTimeSync, teleservices.VerbDelete); err != nil {
+		return trace.Wrap(err)
+	}
+	return o.operator.DeleteTimeSync(key)
+}
+
+// GetLogRotation returns the log rotation policy
+func (o *OperatorACL) GetLogRotation(key SiteKey) (storage.LogRotation, error) {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindLogRotation, teleservices.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return o.operator.GetLogRotation(key)
+}
+
+// UpsertLogRotation creates or updates the log rotation policy
+func (o *OperatorACL) UpsertLogRotation(key SiteKey, config storage.LogRotation) error {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindLogRotation, teleservices.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return o.operator.UpsertLogRotation(key, config)
+}
+
+// DeleteLogRotation deletes the log rotation policy
+func (o *OperatorACL) DeleteLogRotation(key SiteKey) error {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindLogRotation, teleservices.VerbDelete); err != nil {
+		return trace.Wrap(err)
+	}
+	return o.operator.DeleteLogRotation(key)
+}
+
+// GetOperationReaper returns the operation reaper configuration
+func (o *OperatorACL) GetOperationReaper(key SiteKey) (storage.OperationReaper, error) {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindOperationReaper, teleservices.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return o.operator.GetOperationReaper(key)
+}
+
+// UpsertOperationReaper creates or updates the operation reaper configuration
+func (o *OperatorACL) UpsertOperationReaper(key SiteKey, config storage.OperationReaper) error {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindOperationReaper, teleservices.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return o.operator.UpsertOperationReaper(key, config)
+}
+
+// DeleteOperationReaper deletes the operation reaper configuration
+func (o *OperatorACL) DeleteOperationReaper(key SiteKey) error {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindOperationReaper, teleservices.VerbDelete); err != nil {
+		return trace.Wrap(err)
+	}
+	return o.operator.DeleteOperationReaper(key)
+}
+
+// GetTrustedCA returns the trusted CA bundle
+func (o *OperatorACL) GetTrustedCA(key SiteKey) (storage.TrustedCA, error) {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindTrustedCA, teleservices.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return o.operator.GetTrustedCA(key)
+}
+
+// UpsertTrustedCA creates or updates the trusted CA bundle
+func (o *OperatorACL) UpsertTrustedCA(key SiteKey, bundle storage.TrustedCA) error {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindTrustedCA, teleservices.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return o.operator.UpsertTrustedCA(key, bundle)
+}
+
+// DeleteTrustedCA deletes the trusted CA bundle
+func (o *OperatorACL) DeleteTrustedCA(key SiteKey) error {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindTrustedCA, teleservices.VerbDelete); err != nil {
+		return trace.Wrap(err)
+	}
+	return o.operator.DeleteTrustedCA(key)
+}
+
+// GetBandwidthProfile returns the cluster bandwidth profile
+func (o *OperatorACL) GetBandwidthProfile(key SiteKey) (storage.BandwidthProfile, error) {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindBandwidthProfile, teleservices.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return o.operator.GetBandwidthProfile(key)
+}
+
+// UpsertBandwidthProfile creates or updates the cluster bandwidth profile
+func (o *OperatorACL) UpsertBandwidthProfile(key SiteKey, profile storage.BandwidthProfile) error {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindBandwidthProfile, teleservices.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return o.operator.UpsertBandwidthProfile(key, profile)
+}
+
+// DeleteBandwidthProfile deletes the cluster bandwidth profile
+func (o *OperatorACL) DeleteBandwidthProfile(key SiteKey) error {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindBandwidthProfile, teleservices.VerbDelete); err != nil {
+		return trace.Wrap(err)
+	}
+	return o.operator.DeleteBandwidthProfile(key)
+}
+
+// GetAppOverlays returns the list of configured application overlays
+func (o *OperatorACL) GetAppOverlays(key SiteKey) ([]storage.AppOverlay, error) {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindAppOverlay, teleservices.VerbList); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return o.operator.GetAppOverlays(key)
+}
+
+// UpsertAppOverlay creates or updates the specified application overlay
+func (o *OperatorACL) UpsertAppOverlay(key SiteKey, overlay storage.AppOverlay) error {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindAppOverlay, teleservices.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return o.operator.UpsertAppOverlay(key, overlay)
+}
+
+// DeleteAppOverlay deletes the overlay of the application specified with name
+func (o *OperatorACL) DeleteAppOverlay(key SiteKey, name string) error {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindAppOverlay, teleservices.VerbDelete); err != nil {
+		return trace.Wrap(err)
+	}
+	return o.operator.DeleteAppOverlay(key, name)
+}
+
 func (o *OperatorACL) GetApplicationEndpoints(key SiteKey) ([]Endpoint, error) {
 	if err := o.ClusterAction(key.SiteDomain, storage.KindCluster, teleservices.VerbRead); err != nil {
 		return nil, trace.Wrap(err)
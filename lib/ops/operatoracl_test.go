@@ -0,0 +1,155 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ops
+
+import (
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/storage"
+
+	teleservices "github.com/gravitational/teleport/lib/services"
+	"github.com/gravitational/trace"
+	check "gopkg.in/check.v1"
+)
+
+type OperatorACLSuite struct{}
+
+var _ = check.Suite(&OperatorACLSuite{})
+
+func (s *OperatorACLSuite) TestOperationAction(c *check.C) {
+	clusterUpdate := teleservices.Rule{
+		Resources: []string{storage.KindCluster},
+		Verbs:     []string{teleservices.VerbUpdate},
+	}
+	tcs := []struct {
+		comment string
+		allow   []teleservices.Rule
+		deny    []teleservices.Rule
+		allowed []string
+		denied  []string
+	}{
+		{
+			comment: "cluster update allows all operations",
+			allow:   []teleservices.Rule{clusterUpdate},
+			allowed: []string{OperationExpand, OperationShrink, OperationUpdate},
+		},
+		{
+			comment: "operation types denied explicitly",
+			allow:   []teleservices.Rule{clusterUpdate},
+			deny: []teleservices.Rule{{
+				Resources: []string{storage.KindOperation},
+				Verbs:     []string{"shrink", "update"},
+			}},
+			allowed: []string{OperationExpand, OperationGarbageCollect},
+			denied:  []string{OperationShrink, OperationUpdate},
+		},
+		{
+			comment: "operation types allowed explicitly",
+			allow: []teleservices.Rule{{
+				Resources: []string{storage.KindOperation},
+				Verbs:     []string{"expand"},
+			}},
+			allowed: []string{OperationExpand},
+			denied:  []string{OperationShrink, OperationUpdate},
+		},
+	}
+	for _, tc := range tcs {
+		role, err := teleservices.NewRole("test", teleservices.RoleSpecV3{
+			Allow: teleservices.RoleConditions{
+				Namespaces: []string{defaults.Namespace},
+				Rules:      tc.allow,
+			},
+			Deny: teleservices.RoleConditions{
+				Namespaces: []string{defaults.Namespace},
+				Rules:      tc.deny,
+			},
+		})
+		c.Assert(err, check.IsNil)
+		user := storage.NewUser("alice@example.com", storage.UserSpecV2{Type: storage.AdminUser})
+		acl := OperatorWithACL(&testClusterOperator{}, nil, user, teleservices.NewRoleSet(role))
+		for _, operationType := range tc.allowed {
+			c.Assert(acl.OperationAction("example.com", operationType), check.IsNil,
+				check.Commentf("%v: %v", tc.comment, operationType))
+		}
+		for _, operationType := range tc.denied {
+			err := acl.OperationAction("example.com", operationType)
+			c.Assert(trace.IsAccessDenied(err), check.Equals, true,
+				check.Commentf("%v: %v: %v", tc.comment, operationType, err))
+		}
+	}
+}
+
+func (s *OperatorACLSuite) TestApprovePlanPhaseRecordsUser(c *check.C) {
+	role, err := teleservices.NewRole("test", teleservices.RoleSpecV3{
+		Allow: teleservices.RoleConditions{
+			Namespaces: []string{defaults.Namespace},
+			Rules: []teleservices.Rule{{
+				Resources: []string{storage.KindCluster},
+				Verbs:     []string{teleservices.VerbUpdate},
+			}},
+		},
+	})
+	c.Assert(err, check.IsNil)
+	operator := &approvalOperator{}
+	user := storage.NewUser("alice@example.com", storage.UserSpecV2{Type: storage.AdminUser})
+	acl := OperatorWithACL(operator, nil, user, teleservices.NewRoleSet(role))
+
+	// the approver can not be set by the client
+	_, err = acl.ApprovePlanPhase(ApprovePlanPhaseRequest{
+		SiteOperationKey: SiteOperationKey{SiteDomain: "example.com"},
+		PhaseID:          "/etcd",
+		User:             "root",
+	})
+	c.Assert(err, check.IsNil)
+	c.Assert(operator.req.User, check.Equals, "alice@example.com")
+
+	reader, err := teleservices.NewRole("reader", teleservices.RoleSpecV3{
+		Allow: teleservices.RoleConditions{
+			Namespaces: []string{defaults.Namespace},
+			Rules: []teleservices.Rule{{
+				Resources: []string{storage.KindCluster},
+				Verbs:     []string{teleservices.VerbRead},
+			}},
+		},
+	})
+	c.Assert(err, check.IsNil)
+	acl = OperatorWithACL(operator, nil, user, teleservices.NewRoleSet(reader))
+	_, err = acl.ApprovePlanPhase(ApprovePlanPhaseRequest{
+		SiteOperationKey: SiteOperationKey{SiteDomain: "example.com"},
+		PhaseID:          "/etcd",
+	})
+	c.Assert(trace.IsAccessDenied(err), check.Equals, true, check.Commentf("%v", err))
+}
+
+// approvalOperator records the phase approval requests
+type approvalOperator struct {
+	testClusterOperator
+	req ApprovePlanPhaseRequest
+}
+
+func (r *approvalOperator) ApprovePlanPhase(req ApprovePlanPhaseRequest) (*storage.PlanChange, error) {
+	r.req = req
+	return &storage.PlanChange{}, nil
+}
+
+// testClusterOperator implements the subset of the operator used by the ACL checks
+type testClusterOperator struct {
+	Operator
+}
+
+func (r *testClusterOperator) GetSiteByDomain(domain string) (*Site, error) {
+	return &Site{Domain: domain}, nil
+}
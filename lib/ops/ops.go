@@ -124,6 +124,21 @@ type Operator interface {
 	Install
 	Updates
 	Identity
+	DNSProviders
+	Webhooks
+	IngressControllers
+	TimeSync
+	LogRotation
+	OperationReapers
+	PackageRetentionPolicies
+	SecretBackends
+	NodeQuarantine
+	TrustedCA
+	OperationLocks
+	PhaseApprovals
+	BandwidthProfiles
+	AppOverlays
+	PackageStats
 }
 
 // Accounts represents a collection of accounts in the portal
@@ -590,6 +605,14 @@ type Operations interface {
 	// in the cluster
 	CreateClusterGarbageCollectOperation(CreateClusterGarbageCollectOperationRequest) (*SiteOperationKey, error)
 
+	// CreateClusterRollingRestartOperation creates a new operation that
+	// restarts the runtime container or its services on cluster nodes one by one
+	CreateClusterRollingRestartOperation(CreateClusterRollingRestartOperationRequest) (*SiteOperationKey, error)
+
+	// CreateClusterDockerMigrationOperation creates a new operation that
+	// migrates docker storage driver on cluster nodes one by one
+	CreateClusterDockerMigrationOperation(CreateClusterDockerMigrationOperationRequest) (*SiteOperationKey, error)
+
 	// GetsiteOperation returns the operation information based on it's key
 	GetSiteOperation(SiteOperationKey) (*SiteOperation, error)
 
@@ -603,6 +626,10 @@ type Operations interface {
 	// CreateLogEntry appends the provided log entry to the operation's log file
 	CreateLogEntry(SiteOperationKey, LogEntry) error
 
+	// GetSiteOperationLogEntries returns the structured log entries
+	// of the operation that match the provided filter
+	GetSiteOperationLogEntries(SiteOperationKey, LogFilter) ([]LogEntry, error)
+
 	// GetSiteOperationProgress returns last progress entry of a given operation
 	//
 	// This method is called periodically after operation start
@@ -687,6 +714,10 @@ type LogEntry struct {
 	Message string `json:"message"`
 	// Server is an optional server that generated the log entry
 	Server *storage.Server `json:"server,omitempty"`
+	// Component is the optional component that generated the log entry, e.g. etcd
+	Component string `json:"component,omitempty"`
+	// Phase is the optional ID of the operation phase that generated the log entry
+	Phase string `json:"phase,omitempty"`
 	// Created is the log entry timestamp
 	Created time.Time `json:"created"`
 }
@@ -832,6 +863,8 @@ type AppInstallerRequest struct {
 	Application   loc.Locator
 	CACert        string
 	EncryptionKey string
+	// License is the optional license to embed into the installer
+	License string `json:",omitempty"`
 }
 
 // SiteOperation represents any operation that is performed on the site
@@ -929,6 +962,10 @@ func (s *SiteOperation) String() string {
 		typeS = "uninstall"
 	case OperationGarbageCollect:
 		typeS = "garbage collect"
+	case OperationRollingRestart:
+		typeS = "rolling restart"
+	case OperationDockerMigrate:
+		typeS = "docker storage driver migration"
 	}
 	return fmt.Sprintf("operation(%v, cluster=%v, state=%s)", typeS, s.SiteDomain, s.State)
 }
@@ -1125,6 +1162,46 @@ type CreateClusterGarbageCollectOperationRequest struct {
 	ClusterName string `json:"cluster_name"`
 }
 
+// Check validates this request
+func (r CreateClusterRollingRestartOperationRequest) Check() error {
+	if r.AccountID == "" {
+		return trace.BadParameter("missing AccountID")
+	}
+	if r.ClusterName == "" {
+		return trace.BadParameter("missing ClusterName")
+	}
+	return nil
+}
+
+// CreateClusterRollingRestartOperationRequest is a request
+// to restart the runtime container on cluster nodes one by one
+type CreateClusterRollingRestartOperationRequest struct {
+	// AccountID is id of the account
+	AccountID string `json:"account_id"`
+	// ClusterName is the name of the cluster
+	ClusterName string `json:"cluster_name"`
+}
+
+// Check validates this request
+func (r CreateClusterDockerMigrationOperationRequest) Check() error {
+	if r.AccountID == "" {
+		return trace.BadParameter("missing AccountID")
+	}
+	if r.ClusterName == "" {
+		return trace.BadParameter("missing ClusterName")
+	}
+	return nil
+}
+
+// CreateClusterDockerMigrationOperationRequest is a request
+// to migrate docker storage driver on cluster nodes one by one
+type CreateClusterDockerMigrationOperationRequest struct {
+	// AccountID is id of the account
+	AccountID string `json:"account_id"`
+	// ClusterName is the name of the cluster
+	ClusterName string `json:"cluster_name"`
+}
+
 // AgentService coordinates install agents that are started on every server
 // and report system information as well as receive instructions from
 // the operator service
@@ -1562,6 +1639,8 @@ type OperationUpdateRequest struct {
 	Servers []storage.Server `json:"servers"`
 	// ValidateServers specifies whether the update should validate the servers
 	ValidateServers bool `json:"validate,omitempty"`
+	// Parameters sets application configuration parameters
+	Parameters map[string]string `json:"parameters,omitempty"`
 }
 
 // SetOperationStateRequest specifies the request to update operation with a given state
@@ -1614,6 +1693,153 @@ type Monitoring interface {
 	DeleteAlertTarget(SiteKey) error
 }
 
+// DNSProviders defines the interface to manage external DNS providers
+// cluster endpoints are published to
+type DNSProviders interface {
+	// GetDNSProviders returns the list of configured DNS providers
+	GetDNSProviders(SiteKey) ([]storage.DNSProvider, error)
+	// UpsertDNSProvider creates or updates the specified DNS provider
+	UpsertDNSProvider(SiteKey, storage.DNSProvider) error
+	// DeleteDNSProvider deletes the DNS provider specified with name
+	DeleteDNSProvider(key SiteKey, name string) error
+}
+
+// Webhooks defines the interface to manage webhooks
+// cluster lifecycle events are sent to
+type Webhooks interface {
+	// GetWebhooks returns the list of configured webhooks
+	GetWebhooks(SiteKey) ([]storage.Webhook, error)
+	// UpsertWebhook creates or updates the specified webhook
+	UpsertWebhook(SiteKey, storage.Webhook) error
+	// DeleteWebhook deletes the webhook specified with name
+	DeleteWebhook(key SiteKey, name string) error
+}
+
+// IngressControllers defines the interface to manage the configuration
+// of the ingress controller bundled with the cluster
+type IngressControllers interface {
+	// GetIngressController returns the ingress controller configuration
+	GetIngressController(SiteKey) (storage.IngressController, error)
+	// UpsertIngressController creates or updates the ingress controller configuration
+	UpsertIngressController(SiteKey, storage.IngressController) error
+	// DeleteIngressController deletes the ingress controller configuration
+	// which removes the bundled ingress controller
+	DeleteIngressController(SiteKey) error
+}
+
+// TimeSync defines the interface to manage the time synchronization
+// configuration applied on every cluster node
+type TimeSync interface {
+	// GetTimeSync returns the time synchronization configuration
+	GetTimeSync(SiteKey) (storage.TimeSync, error)
+	// UpsertTimeSync creates or updates the time synchronization configuration
+	UpsertTimeSync(SiteKey, storage.TimeSync) error
+	// DeleteTimeSync deletes the time synchronization configuration
+	// which stops managing chrony configuration on cluster nodes
+	DeleteTimeSync(SiteKey) error
+}
+
+// LogRotation defines the interface to manage the journal and container
+// log rotation policy enforced on every cluster node
+type LogRotation interface {
+	// GetLogRotation returns the log rotation policy
+	GetLogRotation(SiteKey) (storage.LogRotation, error)
+	// UpsertLogRotation creates or updates the log rotation policy
+	UpsertLogRotation(SiteKey, storage.LogRotation) error
+	// DeleteLogRotation deletes the log rotation policy
+	// which stops enforcing it on cluster nodes
+	DeleteLogRotation(SiteKey) error
+}
+
+// OperationReapers defines the interface to manage the automatic cleanup
+// of operations abandoned in progress
+type OperationReapers interface {
+	// GetOperationReaper returns the operation reaper configuration
+	GetOperationReaper(SiteKey) (storage.OperationReaper, error)
+	// UpsertOperationReaper creates or updates the operation reaper configuration
+	UpsertOperationReaper(SiteKey, storage.OperationReaper) error
+	// DeleteOperationReaper deletes the operation reaper configuration
+	// which turns off the cleanup of abandoned operations
+	DeleteOperationReaper(SiteKey) error
+}
+
+// PackageRetentionPolicies defines the interface to manage the policies
+// that control which package versions are pruned from repositories
+type PackageRetentionPolicies interface {
+	// GetPackageRetentionPolicies returns the list of configured package retention policies
+	GetPackageRetentionPolicies(SiteKey) ([]storage.PackageRetentionPolicy, error)
+	// UpsertPackageRetentionPolicy creates or updates the specified package retention policy
+	UpsertPackageRetentionPolicy(SiteKey, storage.PackageRetentionPolicy) error
+	// DeletePackageRetentionPolicy deletes the package retention policy specified with name
+	DeletePackageRetentionPolicy(key SiteKey, name string) error
+}
+
+// SecretBackends defines the interface to manage external secret backends
+// sensitive cluster configuration is sourced from
+type SecretBackends interface {
+	// GetSecretBackends returns the list of configured secret backends.
+	// Backend credentials are only returned if withSecrets is true
+	GetSecretBackends(key SiteKey, withSecrets bool) ([]storage.SecretBackend, error)
+	// UpsertSecretBackend creates or updates the specified secret backend
+	UpsertSecretBackend(SiteKey, storage.SecretBackend) error
+	// DeleteSecretBackend deletes the secret backend specified with name
+	DeleteSecretBackend(key SiteKey, name string) error
+}
+
+// TrustedCA defines the interface to manage the bundle of custom
+// CA certificates installed into the trust stores on every cluster node
+type TrustedCA interface {
+	// GetTrustedCA returns the trusted CA bundle
+	GetTrustedCA(SiteKey) (storage.TrustedCA, error)
+	// UpsertTrustedCA creates or updates the trusted CA bundle
+	UpsertTrustedCA(SiteKey, storage.TrustedCA) error
+	// DeleteTrustedCA deletes the trusted CA bundle
+	// which removes it from the trust stores on cluster nodes
+	DeleteTrustedCA(SiteKey) error
+}
+
+// NodeQuarantine defines the interface to manage the nodes quarantined
+// for repeatedly joining and leaving the cluster
+type NodeQuarantine interface {
+	// GetQuarantinedNodes returns the list of quarantined nodes
+	GetQuarantinedNodes(SiteKey) (storage.QuarantinedNodes, error)
+	// QuarantineNode quarantines the specified node so that no operations
+	// are scheduled while it is flapping
+	QuarantineNode(SiteKey, storage.QuarantinedNode) error
+	// ReleaseQuarantinedNode releases the node with the specified advertise
+	// address from quarantine
+	ReleaseQuarantinedNode(key SiteKey, advertiseIP string) error
+}
+
+// BandwidthProfiles defines the interface to manage the bandwidth profile
+// that controls the traffic between the cluster and the Ops Center
+type BandwidthProfiles interface {
+	// GetBandwidthProfile returns the cluster bandwidth profile
+	GetBandwidthProfile(SiteKey) (storage.BandwidthProfile, error)
+	// UpsertBandwidthProfile creates or updates the cluster bandwidth profile
+	UpsertBandwidthProfile(SiteKey, storage.BandwidthProfile) error
+	// DeleteBandwidthProfile deletes the cluster bandwidth profile
+	// which restores the default bandwidth mode
+	DeleteBandwidthProfile(SiteKey) error
+}
+
+// AppOverlays defines the interface to manage the customizations of
+// bundled system applications that survive application upgrades
+type AppOverlays interface {
+	// GetAppOverlays returns the list of configured application overlays
+	GetAppOverlays(SiteKey) ([]storage.AppOverlay, error)
+	// UpsertAppOverlay creates or updates the specified application overlay
+	UpsertAppOverlay(SiteKey, storage.AppOverlay) error
+	// DeleteAppOverlay deletes the overlay of the application specified with name
+	DeleteAppOverlay(key SiteKey, name string) error
+}
+
+// PackageStats provides usage statistics of the cluster package store
+type PackageStats interface {
+	// GetPackageStats returns usage statistics of the cluster package store
+	GetPackageStats(SiteKey) (*pack.StoreStats, error)
+}
+
 // UpdateRetentionPolicyRequest is a request to update retention policy
 type UpdateRetentionPolicyRequest struct {
 	// AccountID is the site account ID
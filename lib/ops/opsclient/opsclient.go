@@ -422,6 +422,51 @@ func (c *Client) CheckSiteStatus(key ops.SiteKey) error {
 	return trace.Wrap(err)
 }
 
+// GetQuarantinedNodes returns the list of quarantined nodes
+func (c *Client) GetQuarantinedNodes(key ops.SiteKey) (storage.QuarantinedNodes, error) {
+	out, err := c.Get(c.Endpoint("accounts", key.AccountID, "sites", key.SiteDomain, "quarantine"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var nodes storage.QuarantinedNodes
+	if err = json.Unmarshal(out.Bytes(), &nodes); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return nodes, nil
+}
+
+// QuarantineNode quarantines the specified node
+func (c *Client) QuarantineNode(key ops.SiteKey, node storage.QuarantinedNode) error {
+	_, err := c.PostJSON(c.Endpoint("accounts", key.AccountID, "sites", key.SiteDomain, "quarantine"), node)
+	return trace.Wrap(err)
+}
+
+// ReleaseQuarantinedNode releases the specified node from quarantine
+func (c *Client) ReleaseQuarantinedNode(key ops.SiteKey, advertiseIP string) error {
+	_, err := c.Delete(c.Endpoint("accounts", key.AccountID, "sites", key.SiteDomain, "quarantine", advertiseIP))
+	return trace.Wrap(err)
+}
+
+// GetOperationLocks returns the resources held by active cluster operations
+func (c *Client) GetOperationLocks(key ops.SiteKey) ([]storage.OperationLock, error) {
+	out, err := c.Get(c.Endpoint("accounts", key.AccountID, "sites", key.SiteDomain, "locks"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var locks []storage.OperationLock
+	if err = json.Unmarshal(out.Bytes(), &locks); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return locks, nil
+}
+
+// ReleaseOperationLocks forcibly releases the resources held by the specified operation
+func (c *Client) ReleaseOperationLocks(req ops.ReleaseOperationLocksRequest) error {
+	_, err := c.PostJSON(c.Endpoint("accounts", req.AccountID, "sites", req.SiteDomain,
+		"operations", "common", req.OperationID, "locks", "release"), req)
+	return trace.Wrap(err)
+}
+
 func (c *Client) GetSiteOperations(siteKey ops.SiteKey) (ops.SiteOperations, error) {
 	out, err := c.Get(c.Endpoint("accounts", siteKey.AccountID, "sites", siteKey.SiteDomain, "operations", "common"),
 		url.Values{})
@@ -551,6 +596,34 @@ func (c *Client) CreateClusterGarbageCollectOperation(req ops.CreateClusterGarba
 	return &key, nil
 }
 
+// CreateClusterRollingRestartOperation creates a new rolling restart operation in the cluster
+func (c *Client) CreateClusterRollingRestartOperation(req ops.CreateClusterRollingRestartOperationRequest) (*ops.SiteOperationKey, error) {
+	out, err := c.PostJSON(c.Endpoint("accounts", req.AccountID, "sites", req.ClusterName, "operations", "restart"), req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var key ops.SiteOperationKey
+	if err := json.Unmarshal(out.Bytes(), &key); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &key, nil
+}
+
+// CreateClusterDockerMigrationOperation creates a new docker storage driver migration operation in the cluster
+func (c *Client) CreateClusterDockerMigrationOperation(req ops.CreateClusterDockerMigrationOperationRequest) (*ops.SiteOperationKey, error) {
+	out, err := c.PostJSON(c.Endpoint("accounts", req.AccountID, "sites", req.ClusterName, "operations", "docker-migrate"), req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var key ops.SiteOperationKey
+	if err := json.Unmarshal(out.Bytes(), &key); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &key, nil
+}
+
 func (c *Client) SiteUninstallOperationStart(req ops.SiteOperationKey) error {
 	_, err := c.PostJSON(c.Endpoint("accounts", req.AccountID, "sites", req.SiteDomain, "operations", "uninstall", req.OperationID, "start"), map[string]interface{}{})
 	if err != nil {
@@ -600,6 +673,20 @@ func (c *Client) GetSiteOperationLogs(key ops.SiteOperationKey) (io.ReadCloser,
 	return httplib.SetupWebsocketClient(context.TODO(), &c.Client, endpoint, c.dialer)
 }
 
+// GetSiteOperationLogEntries returns the structured log entries
+// of the operation that match the provided filter
+func (c *Client) GetSiteOperationLogEntries(key ops.SiteOperationKey, filter ops.LogFilter) ([]ops.LogEntry, error) {
+	out, err := c.Get(c.Endpoint("accounts", key.AccountID, "sites", key.SiteDomain, "operations", "common", key.OperationID, "logs", "entries"), filter.Values())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var entries []ops.LogEntry
+	if err := json.Unmarshal(out.Bytes(), &entries); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return entries, nil
+}
+
 func (c *Client) CreateLogEntry(key ops.SiteOperationKey, entry ops.LogEntry) error {
 	_, err := c.PostJSON(c.Endpoint("accounts", key.AccountID, "sites", key.SiteDomain, "operations", "common", key.OperationID, "logs", "entry"), entry)
 	if err != nil {
@@ -837,6 +924,22 @@ func (c *Client) CreateOperationPlanChange(key ops.SiteOperationKey, change stor
 	return nil
 }
 
+// ApprovePlanPhase approves the execution of the operation plan phase
+// or marks the phase as requiring approval
+func (c *Client) ApprovePlanPhase(req ops.ApprovePlanPhaseRequest) (*storage.PlanChange, error) {
+	out, err := c.PostJSON(c.Endpoint(
+		"accounts", req.AccountID, "sites", req.SiteDomain, "operations", "common", req.OperationID, "plan", "approve"),
+		req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var change storage.PlanChange
+	if err := json.Unmarshal(out.Bytes(), &change); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &change, nil
+}
+
 // GetOperationPlan returns plan for the specified operation
 func (c *Client) GetOperationPlan(key ops.SiteOperationKey) (*storage.OperationPlan, error) {
 	out, err := c.Get(c.Endpoint(
@@ -1090,6 +1193,465 @@ func (c *Client) DeleteAlertTarget(key ops.SiteKey) error {
 	return trace.Wrap(err)
 }
 
+// GetDNSProviders returns the list of configured DNS providers
+func (c *Client) GetDNSProviders(key ops.SiteKey) ([]storage.DNSProvider, error) {
+	response, err := c.Get(c.Endpoint(
+		"accounts", key.AccountID, "sites", key.SiteDomain, "dnsproviders"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var items []json.RawMessage
+	if err = json.Unmarshal(response.Bytes(), &items); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	providers := make([]storage.DNSProvider, len(items))
+	for i, item := range items {
+		provider, err := storage.UnmarshalDNSProvider(item)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		providers[i] = provider
+	}
+	return providers, nil
+}
+
+// UpsertDNSProvider creates or updates the specified DNS provider
+func (c *Client) UpsertDNSProvider(key ops.SiteKey, provider storage.DNSProvider) error {
+	bytes, err := storage.MarshalDNSProvider(provider)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	_, err = c.PutJSON(c.Endpoint("accounts", key.AccountID, "sites", key.SiteDomain,
+		"dnsproviders", provider.GetName()),
+		&UpsertResourceRawReq{Resource: bytes})
+	return trace.Wrap(err)
+}
+
+// DeleteDNSProvider deletes the DNS provider specified with name
+func (c *Client) DeleteDNSProvider(key ops.SiteKey, name string) error {
+	_, err := c.Delete(c.Endpoint("accounts", key.AccountID, "sites", key.SiteDomain, "dnsproviders", name))
+	return trace.Wrap(err)
+}
+
+// GetWebhooks returns the list of configured webhooks
+func (c *Client) GetWebhooks(key ops.SiteKey) ([]storage.Webhook, error) {
+	response, err := c.Get(c.Endpoint(
+		"accounts", key.AccountID, "sites", key.SiteDomain, "webhooks"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var items []json.RawMessage
+	if err = json.Unmarshal(response.Bytes(), &items); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	webhooks := make([]storage.Webhook, len(items))
+	for i, item := range items {
+		webhook, err := storage.UnmarshalWebhook(item)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		webhooks[i] = webhook
+	}
+	return webhooks, nil
+}
+
+// UpsertWebhook creates or updates the specified webhook
+func (c *Client) UpsertWebhook(key ops.SiteKey, webhook storage.Webhook) error {
+	bytes, err := storage.MarshalWebhook(webhook)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	_, err = c.PutJSON(c.Endpoint("accounts", key.AccountID, "sites", key.SiteDomain,
+		"webhooks", webhook.GetName()),
+		&UpsertResourceRawReq{Resource: bytes})
+	return trace.Wrap(err)
+}
+
+// DeleteWebhook deletes the webhook specified with name
+func (c *Client) DeleteWebhook(key ops.SiteKey, name string) error {
+	_, err := c.Delete(c.Endpoint("accounts", key.AccountID, "sites", key.SiteDomain, "webhooks", name))
+	return trace.Wrap(err)
+}
+
+// GetPackageRetentionPolicies returns the list of configured package retention policies
+func (c *Client) GetPackageRetentionPolicies(key ops.SiteKey) ([]storage.PackageRetentionPolicy, error) {
+	response, err := c.Get(c.Endpoint(
+		"accounts", key.AccountID, "sites", key.SiteDomain, "packageretention"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var items []json.RawMessage
+	if err = json.Unmarshal(response.Bytes(), &items); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	policies := make([]storage.PackageRetentionPolicy, len(items))
+	for i, item := range items {
+		policy, err := storage.UnmarshalPackageRetentionPolicy(item)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		policies[i] = policy
+	}
+	return policies, nil
+}
+
+// UpsertPackageRetentionPolicy creates or updates the specified package retention policy
+func (c *Client) UpsertPackageRetentionPolicy(key ops.SiteKey, policy storage.PackageRetentionPolicy) error {
+	bytes, err := storage.MarshalPackageRetentionPolicy(policy)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	_, err = c.PutJSON(c.Endpoint("accounts", key.AccountID, "sites", key.SiteDomain,
+		"packageretention", policy.GetName()),
+		&UpsertResourceRawReq{Resource: bytes})
+	return trace.Wrap(err)
+}
+
+// DeletePackageRetentionPolicy deletes the package retention policy specified with name
+func (c *Client) DeletePackageRetentionPolicy(key ops.SiteKey, name string) error {
+	_, err := c.Delete(c.Endpoint("accounts", key.AccountID, "sites", key.SiteDomain, "packageretention", name))
+	return trace.Wrap(err)
+}
+
+// GetSecretBackends returns the list of configured secret backends
+func (c *Client) GetSecretBackends(key ops.SiteKey, withSecrets bool) ([]storage.SecretBackend, error) {
+	response, err := c.Get(c.Endpoint(
+		"accounts", key.AccountID, "sites", key.SiteDomain, "secretbackends"),
+		url.Values{constants.WithSecretsParam: []string{fmt.Sprintf("%t", withSecrets)}})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var items []json.RawMessage
+	if err = json.Unmarshal(response.Bytes(), &items); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	backends := make([]storage.SecretBackend, len(items))
+	for i, item := range items {
+		backend, err := storage.UnmarshalSecretBackend(item)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		backends[i] = backend
+	}
+	return backends, nil
+}
+
+// UpsertSecretBackend creates or updates the specified secret backend
+func (c *Client) UpsertSecretBackend(key ops.SiteKey, backend storage.SecretBackend) error {
+	bytes, err := storage.MarshalSecretBackend(backend)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	_, err = c.PutJSON(c.Endpoint("accounts", key.AccountID, "sites", key.SiteDomain,
+		"secretbackends", backend.GetName()),
+		&UpsertResourceRawReq{Resource: bytes})
+	return trace.Wrap(err)
+}
+
+// DeleteSecretBackend deletes the secret backend specified with name
+func (c *Client) DeleteSecretBackend(key ops.SiteKey, name string) error {
+	_, err := c.Delete(c.Endpoint("accounts", key.AccountID, "sites", key.SiteDomain, "secretbackends", name))
+	return trace.Wrap(err)
+}
+
+// GetPackageStats returns usage statistics of the cluster package store
+func (c *Client) GetPackageStats(key ops.SiteKey) (*pack.StoreStats, error) {
+	response, err := c.Get(c.Endpoint(
+		"accounts", key.AccountID, "sites", key.SiteDomain, "packages", "stats"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var stats pack.StoreStats
+	if err := json.Unmarshal(response.Bytes(), &stats); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &stats, nil
+}
+
+// GetIngressController returns the ingress controller configuration
+func (c *Client) GetIngressController(key ops.SiteKey) (storage.IngressController, error) {
+	response, err := c.Get(c.Endpoint(
+		"accounts", key.AccountID, "sites", key.SiteDomain, "ingresscontroller"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var raw json.RawMessage
+	if err := json.Unmarshal(response.Bytes(), &raw); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	controller, err := storage.UnmarshalIngressController(raw)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return controller, nil
+}
+
+// UpsertIngressController creates or updates the ingress controller configuration
+func (c *Client) UpsertIngressController(key ops.SiteKey, controller storage.IngressController) error {
+	bytes, err := storage.MarshalIngressController(controller)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	_, err = c.PutJSON(c.Endpoint("accounts", key.AccountID, "sites", key.SiteDomain, "ingresscontroller"),
+		&UpsertResourceRawReq{Resource: bytes})
+	return trace.Wrap(err)
+}
+
+// DeleteIngressController deletes the ingress controller configuration
+func (c *Client) DeleteIngressController(key ops.SiteKey) error {
+	_, err := c.Delete(c.Endpoint("accounts", key.AccountID, "sites", key.SiteDomain, "ingresscontroller"))
+	return trace.Wrap(err)
+}
+
+// GetTimeSync returns the time synchronization configuration
+func (c *Client) GetTimeSync(key ops.SiteKey) (storage.TimeSync, error) {
+	response, err := c.Get(c.Endpoint(
+		"accounts", key.AccountID, "sites", key.SiteDomain, "timesync"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var raw json.RawMessage
+	if err := json.Unmarshal(response.Bytes(), &raw); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	config, err := storage.UnmarshalTimeSync(raw)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return config, nil
+}
+
+// UpsertTimeSync creates or updates the time synchronization configuration
+func (c *Client) UpsertTimeSync(key ops.SiteKey, config storage.TimeSync) error {
+	bytes, err := storage.MarshalTimeSync(config)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	_, err = c.PutJSON(c.Endpoint("accounts", key.AccountID, "sites", key.SiteDomain, "timesync"),
+		&UpsertResourceRawReq{Resource: bytes})
+	return trace.Wrap(err)
+}
+
+// DeleteTimeSync deletes the time synchronization configuration
+func (c *Client) DeleteTimeSync(key ops.SiteKey) error {
+	_, err := c.Delete(c.Endpoint("accounts", key.AccountID, "sites", key.SiteDomain, "timesync"))
+	return trace.Wrap(err)
+}
+
+// GetLogRotation returns the log rotation policy
+func (c *Client) GetLogRotation(key ops.SiteKey) (storage.LogRotation, error) {
+	response, err := c.Get(c.Endpoint(
+		"accounts", key.AccountID, "sites", key.SiteDomain, "logrotation"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var raw json.RawMessage
+	if err := json.Unmarshal(response.Bytes(), &raw); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	config, err := storage.UnmarshalLogRotation(raw)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return config, nil
+}
+
+// UpsertLogRotation creates or updates the log rotation policy
+func (c *Client) UpsertLogRotation(key ops.SiteKey, config storage.LogRotation) error {
+	bytes, err := storage.MarshalLogRotation(config)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	_, err = c.PutJSON(c.Endpoint("accounts", key.AccountID, "sites", key.SiteDomain, "logrotation"),
+		&UpsertResourceRawReq{Resource: bytes})
+	return trace.Wrap(err)
+}
+
+// DeleteLogRotation deletes the log rotation policy
+func (c *Client) DeleteLogRotation(key ops.SiteKey) error {
+	_, err := c.Delete(c.Endpoint("accounts", key.AccountID, "sites", key.SiteDomain, "logrotation"))
+	return trace.Wrap(err)
+}
+
+// GetOperationReaper returns the operation reaper configuration
+func (c *Client) GetOperationReaper(key ops.SiteKey) (storage.OperationReaper, error) {
+	response, err := c.Get(c.Endpoint(
+		"accounts", key.AccountID, "sites", key.SiteDomain, "operationreaper"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var raw json.RawMessage
+	if err := json.Unmarshal(response.Bytes(), &raw); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	config, err := storage.UnmarshalOperationReaper(raw)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return config, nil
+}
+
+// UpsertOperationReaper creates or updates the operation reaper configuration
+func (c *Client) UpsertOperationReaper(key ops.SiteKey, config storage.OperationReaper) error {
+	bytes, err := storage.MarshalOperationReaper(config)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	_, err = c.PutJSON(c.Endpoint("accounts", key.AccountID, "sites", key.SiteDomain, "operationreaper"),
+		&UpsertResourceRawReq{Resource: bytes})
+	return trace.Wrap(err)
+}
+
+// DeleteOperationReaper deletes the operation reaper configuration
+func (c *Client) DeleteOperationReaper(key ops.SiteKey) error {
+	_, err := c.Delete(c.Endpoint("accounts", key.AccountID, "sites", key.SiteDomain, "operationreaper"))
+	return trace.Wrap(err)
+}
+
+// GetTrustedCA returns the trusted CA bundle
+func (c *Client) GetTrustedCA(key ops.SiteKey) (storage.TrustedCA, error) {
+	response, err := c.Get(c.Endpoint(
+		"accounts", key.AccountID, "sites", key.SiteDomain, "trustedca"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var raw json.RawMessage
+	if err := json.Unmarshal(response.Bytes(), &raw); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	bundle, err := storage.UnmarshalTrustedCA(raw)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return bundle, nil
+}
+
+// UpsertTrustedCA creates or updates the trusted CA bundle
+func (c *Client) UpsertTrustedCA(key ops.SiteKey, bundle storage.TrustedCA) error {
+	bytes, err := storage.MarshalTrustedCA(bundle)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	_, err = c.PutJSON(c.Endpoint("accounts", key.AccountID, "sites", key.SiteDomain, "trustedca"),
+		&UpsertResourceRawReq{Resource: bytes})
+	return trace.Wrap(err)
+}
+
+// DeleteTrustedCA deletes the trusted CA bundle
+func (c *Client) DeleteTrustedCA(key ops.SiteKey) error {
+	_, err := c.Delete(c.Endpoint("accounts", key.AccountID, "sites", key.SiteDomain, "trustedca"))
+	return trace.Wrap(err)
+}
+
+// GetBandwidthProfile returns the cluster bandwidth profile
+func (c *Client) GetBandwidthProfile(key ops.SiteKey) (storage.BandwidthProfile, error) {
+	response, err := c.Get(c.Endpoint(
+		"accounts", key.AccountID, "sites", key.SiteDomain, "bandwidthprofile"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var raw json.RawMessage
+	if err := json.Unmarshal(response.Bytes(), &raw); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	profile, err := storage.UnmarshalBandwidthProfile(raw)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return profile, nil
+}
+
+// UpsertBandwidthProfile creates or updates the cluster bandwidth profile
+func (c *Client) UpsertBandwidthProfile(key ops.SiteKey, profile storage.BandwidthProfile) error {
+	bytes, err := storage.MarshalBandwidthProfile(profile)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	_, err = c.PutJSON(c.Endpoint("accounts", key.AccountID, "sites", key.SiteDomain, "bandwidthprofile"),
+		&UpsertResourceRawReq{Resource: bytes})
+	return trace.Wrap(err)
+}
+
+// DeleteBandwidthProfile deletes the cluster bandwidth profile
+func (c *Client) DeleteBandwidthProfile(key ops.SiteKey) error {
+	_, err := c.Delete(c.Endpoint("accounts", key.AccountID, "sites", key.SiteDomain, "bandwidthprofile"))
+	return trace.Wrap(err)
+}
+
+// GetAppOverlays returns the list of configured application overlays
+func (c *Client) GetAppOverlays(key ops.SiteKey) ([]storage.AppOverlay, error) {
+	response, err := c.Get(c.Endpoint(
+		"accounts", key.AccountID, "sites", key.SiteDomain, "appoverlays"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var items []json.RawMessage
+	if err = json.Unmarshal(response.Bytes(), &items); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	overlays := make([]storage.AppOverlay, len(items))
+	for i, item := range items {
+		overlay, err := storage.UnmarshalAppOverlay(item)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		overlays[i] = overlay
+	}
+	return overlays, nil
+}
+
+// UpsertAppOverlay creates or updates the specified application overlay
+func (c *Client) UpsertAppOverlay(key ops.SiteKey, overlay storage.AppOverlay) error {
+	bytes, err := storage.MarshalAppOverlay(overlay)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	_, err = c.PutJSON(c.Endpoint("accounts", key.AccountID, "sites", key.SiteDomain,
+		"appoverlays", overlay.GetName()),
+		&UpsertResourceRawReq{Resource: bytes})
+	return trace.Wrap(err)
+}
+
+// DeleteAppOverlay deletes the overlay of the application specified with name
+func (c *Client) DeleteAppOverlay(key ops.SiteKey, name string) error {
+	_, err := c.Delete(c.Endpoint("accounts", key.AccountID, "sites", key.SiteDomain, "appoverlays", name))
+	return trace.Wrap(err)
+}
+
 func (c *Client) GetApplicationEndpoints(key ops.SiteKey) ([]ops.Endpoint, error) {
 	out, err := c.Get(c.Endpoint("accounts", key.AccountID, "sites", key.SiteDomain, "endpoints"), url.Values{})
 	if err != nil {
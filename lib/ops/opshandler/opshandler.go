@@ -36,6 +36,7 @@ import (
 	"github.com/gravitational/gravity/lib/pack"
 	"github.com/gravitational/gravity/lib/schema"
 	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/tracing"
 	"github.com/gravitational/gravity/lib/users"
 
 	"github.com/gravitational/roundtrip"
@@ -78,6 +79,11 @@ func (h *WebHandler) GetConfig() WebHandlerConfig {
 	return h.cfg
 }
 
+// ServeHTTP records a trace span for the request and dispatches it
+func (h *WebHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	tracing.Handler("ops", &h.Router).ServeHTTP(w, r)
+}
+
 func NewWebHandler(cfg WebHandlerConfig) (*WebHandler, error) {
 	if cfg.Operator == nil {
 		return nil, trace.BadParameter("missing parameter Operator")
@@ -144,6 +150,11 @@ func NewWebHandler(cfg WebHandlerConfig) (*WebHandler, error) {
 
 	// Status API
 	h.GET("/portal/v1/accounts/:account_id/sites/:site_domain/status", h.needsAuth(h.checkSiteStatus))
+	h.GET("/portal/v1/accounts/:account_id/sites/:site_domain/quarantine", h.needsAuth(h.getQuarantinedNodes))
+	h.POST("/portal/v1/accounts/:account_id/sites/:site_domain/quarantine", h.needsAuth(h.quarantineNode))
+	h.DELETE("/portal/v1/accounts/:account_id/sites/:site_domain/quarantine/:advertise_ip", h.needsAuth(h.releaseQuarantinedNode))
+	h.GET("/portal/v1/accounts/:account_id/sites/:site_domain/locks", h.needsAuth(h.getOperationLocks))
+	h.POST("/portal/v1/accounts/:account_id/sites/:site_domain/operations/common/:operation_id/locks/release", h.needsAuth(h.releaseOperationLocks))
 
 	// TODO(klizhetas) refactor this method
 	h.GET("/portal/v1/sites/domain/:domain", h.needsAuth(h.getSiteByDomain))
@@ -191,6 +202,8 @@ func NewWebHandler(cfg WebHandlerConfig) (*WebHandler, error) {
 
 	// garbage collection
 	h.POST("/portal/v1/accounts/:account_id/sites/:site_domain/operations/gc", h.needsAuth(h.createClusterGarbageCollectOperation))
+	h.POST("/portal/v1/accounts/:account_id/sites/:site_domain/operations/restart", h.needsAuth(h.createClusterRollingRestartOperation))
+	h.POST("/portal/v1/accounts/:account_id/sites/:site_domain/operations/docker-migrate", h.needsAuth(h.createClusterDockerMigrationOperation))
 
 	// update - update installed application to a new version
 	h.POST("/portal/v1/accounts/:account_id/sites/:site_domain/operations/update", h.needsAuth(h.createSiteUpdateOperation))
@@ -202,6 +215,7 @@ func NewWebHandler(cfg WebHandlerConfig) (*WebHandler, error) {
 	h.DELETE("/portal/v1/accounts/:account_id/sites/:site_domain/operations/common/:operation_id", h.needsAuth(h.deleteOperation))
 	h.GET("/portal/v1/accounts/:account_id/sites/:site_domain/operations/common/:operation_id/logs", h.needsAuth(h.getSiteOperationLogs))
 	h.POST("/portal/v1/accounts/:account_id/sites/:site_domain/operations/common/:operation_id/logs/entry", h.needsAuth(h.createLogEntry))
+	h.GET("/portal/v1/accounts/:account_id/sites/:site_domain/operations/common/:operation_id/logs/entries", h.needsAuth(h.getSiteOperationLogEntries))
 	h.POST("/portal/v1/accounts/:account_id/sites/:site_domain/operations/common/:operation_id/logs", h.needsAuth(h.streamOperationLogs))
 	h.GET("/portal/v1/accounts/:account_id/sites/:site_domain/operations/common/:operation_id/progress", h.needsAuth(h.getSiteOperationProgress))
 	h.POST("/portal/v1/accounts/:account_id/sites/:site_domain/operations/common/:operation_id/progress", h.needsAuth(h.createProgressEntry))
@@ -209,6 +223,7 @@ func NewWebHandler(cfg WebHandlerConfig) (*WebHandler, error) {
 	h.PUT("/portal/v1/accounts/:account_id/sites/:site_domain/operations/common/:operation_id/complete", h.needsAuth(h.completeSiteOperation))
 	h.POST("/portal/v1/accounts/:account_id/sites/:site_domain/operations/common/:operation_id/plan", h.needsAuth(h.createOperationPlan))
 	h.POST("/portal/v1/accounts/:account_id/sites/:site_domain/operations/common/:operation_id/plan/changelog", h.needsAuth(h.createOperationPlanChange))
+	h.POST("/portal/v1/accounts/:account_id/sites/:site_domain/operations/common/:operation_id/plan/approve", h.needsAuth(h.approvePlanPhase))
 	h.GET("/portal/v1/accounts/:account_id/sites/:site_domain/operations/common/:operation_id/plan", h.needsAuth(h.getOperationPlan))
 	h.POST("/portal/v1/accounts/:account_id/sites/:site_domain/operations/common/:operation_id/plan/configure", h.needsAuth(h.configurePackages))
 
@@ -270,6 +285,64 @@ func NewWebHandler(cfg WebHandlerConfig) (*WebHandler, error) {
 	h.POST("/portal/v1/accounts/:account_id/sites/:site_domain/authgateway", h.needsAuth(h.upsertAuthGateway))
 	h.GET("/portal/v1/accounts/:account_id/sites/:site_domain/authgateway", h.needsAuth(h.getAuthGateway))
 
+	// external DNS providers
+	h.GET("/portal/v1/accounts/:account_id/sites/:site_domain/dnsproviders", h.needsAuth(h.getDNSProviders))
+	h.PUT("/portal/v1/accounts/:account_id/sites/:site_domain/dnsproviders/:name", h.needsAuth(h.upsertDNSProvider))
+	h.DELETE("/portal/v1/accounts/:account_id/sites/:site_domain/dnsproviders/:name", h.needsAuth(h.deleteDNSProvider))
+
+	// cluster lifecycle events webhooks
+	h.GET("/portal/v1/accounts/:account_id/sites/:site_domain/webhooks", h.needsAuth(h.getWebhooks))
+	h.PUT("/portal/v1/accounts/:account_id/sites/:site_domain/webhooks/:name", h.needsAuth(h.upsertWebhook))
+	h.DELETE("/portal/v1/accounts/:account_id/sites/:site_domain/webhooks/:name", h.needsAuth(h.deleteWebhook))
+
+	// package retention policies
+	h.GET("/portal/v1/accounts/:account_id/sites/:site_domain/packageretention", h.needsAuth(h.getPackageRetentionPolicies))
+	h.PUT("/portal/v1/accounts/:account_id/sites/:site_domain/packageretention/:name", h.needsAuth(h.upsertPackageRetentionPolicy))
+	h.DELETE("/portal/v1/accounts/:account_id/sites/:site_domain/packageretention/:name", h.needsAuth(h.deletePackageRetentionPolicy))
+
+	// external secret backends
+	h.GET("/portal/v1/accounts/:account_id/sites/:site_domain/secretbackends", h.needsAuth(h.getSecretBackends))
+	h.PUT("/portal/v1/accounts/:account_id/sites/:site_domain/secretbackends/:name", h.needsAuth(h.upsertSecretBackend))
+	h.DELETE("/portal/v1/accounts/:account_id/sites/:site_domain/secretbackends/:name", h.needsAuth(h.deleteSecretBackend))
+
+	// bundled ingress controller
+	h.GET("/portal/v1/accounts/:account_id/sites/:site_domain/ingresscontroller", h.needsAuth(h.getIngressController))
+	h.PUT("/portal/v1/accounts/:account_id/sites/:site_domain/ingresscontroller", h.needsAuth(h.upsertIngressController))
+	h.DELETE("/portal/v1/accounts/:account_id/sites/:site_domain/ingresscontroller", h.needsAuth(h.deleteIngressController))
+
+	// node time synchronization
+	h.GET("/portal/v1/accounts/:account_id/sites/:site_domain/timesync", h.needsAuth(h.getTimeSync))
+	h.PUT("/portal/v1/accounts/:account_id/sites/:site_domain/timesync", h.needsAuth(h.upsertTimeSync))
+	h.DELETE("/portal/v1/accounts/:account_id/sites/:site_domain/timesync", h.needsAuth(h.deleteTimeSync))
+
+	// node log rotation
+	h.GET("/portal/v1/accounts/:account_id/sites/:site_domain/logrotation", h.needsAuth(h.getLogRotation))
+	h.PUT("/portal/v1/accounts/:account_id/sites/:site_domain/logrotation", h.needsAuth(h.upsertLogRotation))
+	h.DELETE("/portal/v1/accounts/:account_id/sites/:site_domain/logrotation", h.needsAuth(h.deleteLogRotation))
+
+	// abandoned operations cleanup
+	h.GET("/portal/v1/accounts/:account_id/sites/:site_domain/operationreaper", h.needsAuth(h.getOperationReaper))
+	h.PUT("/portal/v1/accounts/:account_id/sites/:site_domain/operationreaper", h.needsAuth(h.upsertOperationReaper))
+	h.DELETE("/portal/v1/accounts/:account_id/sites/:site_domain/operationreaper", h.needsAuth(h.deleteOperationReaper))
+
+	// trusted CA bundle
+	h.GET("/portal/v1/accounts/:account_id/sites/:site_domain/trustedca", h.needsAuth(h.getTrustedCA))
+	h.PUT("/portal/v1/accounts/:account_id/sites/:site_domain/trustedca", h.needsAuth(h.upsertTrustedCA))
+	h.DELETE("/portal/v1/accounts/:account_id/sites/:site_domain/trustedca", h.needsAuth(h.deleteTrustedCA))
+
+	// bandwidth profile
+	h.GET("/portal/v1/accounts/:account_id/sites/:site_domain/bandwidthprofile", h.needsAuth(h.getBandwidthProfile))
+	h.PUT("/portal/v1/accounts/:account_id/sites/:site_domain/bandwidthprofile", h.needsAuth(h.upsertBandwidthProfile))
+	h.DELETE("/portal/v1/accounts/:account_id/sites/:site_domain/bandwidthprofile", h.needsAuth(h.deleteBandwidthProfile))
+
+	// bundled application overlays
+	h.GET("/portal/v1/accounts/:account_id/sites/:site_domain/appoverlays", h.needsAuth(h.getAppOverlays))
+	h.PUT("/portal/v1/accounts/:account_id/sites/:site_domain/appoverlays/:name", h.needsAuth(h.upsertAppOverlay))
+	h.DELETE("/portal/v1/accounts/:account_id/sites/:site_domain/appoverlays/:name", h.needsAuth(h.deleteAppOverlay))
+
+	// package store statistics
+	h.GET("/portal/v1/accounts/:account_id/sites/:site_domain/packages/stats", h.needsAuth(h.getPackageStats))
+
 	return h, nil
 }
 
@@ -1142,6 +1215,104 @@ func (h *WebHandler) checkSiteStatus(w http.ResponseWriter, r *http.Request, p h
 	return nil
 }
 
+/*  getQuarantinedNodes returns the list of nodes quarantined for flapping
+
+    GET /portal/v1/accounts/:account_id/sites/:site_domain/quarantine
+
+    Success response:
+
+    storage.QuarantinedNodes
+*/
+func (h *WebHandler) getQuarantinedNodes(w http.ResponseWriter, r *http.Request, p httprouter.Params, context *HandlerContext) error {
+	nodes, err := context.Operator.GetQuarantinedNodes(siteKey(p))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, nodes)
+	return nil
+}
+
+/*  quarantineNode quarantines the specified node
+
+    POST /portal/v1/accounts/:account_id/sites/:site_domain/quarantine
+
+    Input: storage.QuarantinedNode
+
+    Success response:
+    {
+      "message": "ok"
+    }
+*/
+func (h *WebHandler) quarantineNode(w http.ResponseWriter, r *http.Request, p httprouter.Params, context *HandlerContext) error {
+	var node storage.QuarantinedNode
+	if err := telehttplib.ReadJSON(r, &node); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := context.Operator.QuarantineNode(siteKey(p), node); err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, statusOK("ok"))
+	return nil
+}
+
+/*  releaseQuarantinedNode releases the specified node from quarantine
+
+    DELETE /portal/v1/accounts/:account_id/sites/:site_domain/quarantine/:advertise_ip
+
+    Success response:
+    {
+      "message": "ok"
+    }
+*/
+func (h *WebHandler) releaseQuarantinedNode(w http.ResponseWriter, r *http.Request, p httprouter.Params, context *HandlerContext) error {
+	if err := context.Operator.ReleaseQuarantinedNode(siteKey(p), p.ByName("advertise_ip")); err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, statusOK("ok"))
+	return nil
+}
+
+/*  getOperationLocks returns the resources held by active cluster operations
+
+    GET /portal/v1/accounts/:account_id/sites/:site_domain/locks
+
+    Success response:
+
+    []storage.OperationLock
+*/
+func (h *WebHandler) getOperationLocks(w http.ResponseWriter, r *http.Request, p httprouter.Params, context *HandlerContext) error {
+	locks, err := context.Operator.GetOperationLocks(siteKey(p))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, locks)
+	return nil
+}
+
+/*  releaseOperationLocks forcibly releases the resources held by the specified operation
+
+    POST /portal/v1/accounts/:account_id/sites/:site_domain/operations/common/:operation_id/locks/release
+
+    Input: ops.ReleaseOperationLocksRequest
+
+    Success response:
+    {
+      "message": "ok"
+    }
+*/
+func (h *WebHandler) releaseOperationLocks(w http.ResponseWriter, r *http.Request, p httprouter.Params, context *HandlerContext) error {
+	var req ops.ReleaseOperationLocksRequest
+	if err := telehttplib.ReadJSON(r, &req); err != nil {
+		return trace.Wrap(err)
+	}
+	req.SiteOperationKey = siteOperationKey(p)
+	if err := context.Operator.ReleaseOperationLocks(req); err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, statusOK("ok"))
+	return nil
+}
+
 /*  validateDomainName checks if the specified domain name has already been allocated
 
     GET /portal/v1/domains/:domain
@@ -1435,6 +1606,29 @@ func (h *WebHandler) createOperationPlanChange(w http.ResponseWriter, r *http.Re
 	return nil
 }
 
+/* approvePlanPhase approves the execution of the operation plan phase
+   or marks the phase as requiring approval
+
+   POST /portal/v1/accounts/:account_id/sites/:site_domain/operations/common/:operation_id/plan/approve
+
+   Input: ops.ApprovePlanPhaseRequest
+
+   Success response: storage.PlanChange
+*/
+func (h *WebHandler) approvePlanPhase(w http.ResponseWriter, r *http.Request, p httprouter.Params, context *HandlerContext) error {
+	var req ops.ApprovePlanPhaseRequest
+	if err := telehttplib.ReadJSON(r, &req); err != nil {
+		return trace.Wrap(err)
+	}
+	req.SiteOperationKey = siteOperationKey(p)
+	change, err := context.Operator.ApprovePlanPhase(req)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, change)
+	return nil
+}
+
 /* getOperationPlan returns plan for the specified operation
 
    GET /portal/v1/accos/:account_id/sites/:site_domain/operations/common/:operation_id/plan
@@ -1681,6 +1875,24 @@ func (h *WebHandler) getSiteOperationLogs(w http.ResponseWriter, r *http.Request
 	return getOpLogs(w, r, siteOperationKey(p), context)
 }
 
+/* getSiteOperationLogEntries returns the structured log entries of the operation
+   that match the filter specified with the optional query parameters
+
+   GET /portal/v1/accounts/:account_id/sites/:site_domain/operations/common/:operation_id/logs/entries?component=<component>&phase=<phase>&level=<level>
+*/
+func (h *WebHandler) getSiteOperationLogEntries(w http.ResponseWriter, r *http.Request, p httprouter.Params, context *HandlerContext) error {
+	filter := ops.LogFilterFromValues(r.URL.Query())
+	if err := filter.Check(); err != nil {
+		return trace.Wrap(err)
+	}
+	entries, err := context.Operator.GetSiteOperationLogEntries(siteOperationKey(p), filter)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, entries)
+	return nil
+}
+
 /* createLogEntry appends the provided log entry to the operation's log file
 
    POST /portal/v1/accounts/:account_id/sites/:site_domain/operations/common/:operation_id/logs/entry
@@ -1905,6 +2117,80 @@ func (h *WebHandler) createClusterGarbageCollectOperation(w http.ResponseWriter,
 	return nil
 }
 
+/* createClusterRollingRestartOperation creates a new rolling restart operation for the cluster
+
+   POST	/portal/v1/accounts/:account_id/sites/:site_domain/operations/restart
+
+   {
+      "account_id": "account id",
+      "site_id": "cluster_name",
+   }
+
+
+Success response:
+
+   {
+      "account_id": "account id",
+      "site_id": "cluster_name",
+      "operation_id": "operation id"
+   }
+*/
+func (h *WebHandler) createClusterRollingRestartOperation(w http.ResponseWriter, r *http.Request, p httprouter.Params, context *HandlerContext) error {
+	d := json.NewDecoder(r.Body)
+	var req ops.CreateClusterRollingRestartOperationRequest
+	if err := d.Decode(&req); err != nil {
+		return trace.BadParameter("%v", err)
+	}
+
+	key := siteKey(p)
+	req.AccountID = key.AccountID
+	req.ClusterName = key.SiteDomain
+	op, err := context.Operator.CreateClusterRollingRestartOperation(req)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	roundtrip.ReplyJSON(w, http.StatusOK, op)
+	return nil
+}
+
+/* createClusterDockerMigrationOperation creates a new docker storage driver migration operation for the cluster
+
+   POST	/portal/v1/accounts/:account_id/sites/:site_domain/operations/docker-migrate
+
+   {
+      "account_id": "account id",
+      "site_id": "cluster_name",
+   }
+
+
+Success response:
+
+   {
+      "account_id": "account id",
+      "site_id": "cluster_name",
+      "operation_id": "operation id"
+   }
+*/
+func (h *WebHandler) createClusterDockerMigrationOperation(w http.ResponseWriter, r *http.Request, p httprouter.Params, context *HandlerContext) error {
+	d := json.NewDecoder(r.Body)
+	var req ops.CreateClusterDockerMigrationOperationRequest
+	if err := d.Decode(&req); err != nil {
+		return trace.BadParameter("%v", err)
+	}
+
+	key := siteKey(p)
+	req.AccountID = key.AccountID
+	req.ClusterName = key.SiteDomain
+	op, err := context.Operator.CreateClusterDockerMigrationOperation(req)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	roundtrip.ReplyJSON(w, http.StatusOK, op)
+	return nil
+}
+
 /* getLogForwarders returns a list of configured log forwarders
 
    GET /portal/v1/accounts/:account_id/sites/:site_domain/logs/forwarders
@@ -2118,6 +2404,23 @@ func (h *WebHandler) getAppInstaller(w http.ResponseWriter, r *http.Request, p h
 	return trace.Wrap(err)
 }
 
+/* getPackageStats returns usage statistics of the cluster package store
+
+     GET /portal/v1/accounts/:account_id/sites/:site_domain/packages/stats
+
+   Success Response:
+
+     pack.StoreStats
+*/
+func (h *WebHandler) getPackageStats(w http.ResponseWriter, r *http.Request, p httprouter.Params, context *HandlerContext) error {
+	stats, err := context.Operator.GetPackageStats(siteKey(p))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, stats)
+	return nil
+}
+
 /* getClusterCert returns the cluster certificate
 
      GET /portal/v1/accounts/:account_id/sites/:site_domain/certificate
@@ -285,3 +285,700 @@ func rawMessage(w http.ResponseWriter, data []byte, err error) error {
 func message(msg string, args ...interface{}) map[string]interface{} {
 	return map[string]interface{}{"message": fmt.Sprintf(msg, args...)}
 }
+
+/* getDNSProviders returns a list of external DNS providers configured for the cluster
+
+     GET /portal/v1/accounts/:account_id/sites/:site_domain/dnsproviders
+
+   Success Response:
+
+     []storage.DNSProvider
+*/
+func (h *WebHandler) getDNSProviders(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *HandlerContext) error {
+	providers, err := ctx.Operator.GetDNSProviders(siteKey(p))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, providers)
+	return nil
+}
+
+/* upsertDNSProvider creates or updates the specified external DNS provider
+
+     PUT /portal/v1/accounts/:account_id/sites/:site_domain/dnsproviders/:name
+
+   Success Response:
+
+     {
+       "message": "DNS provider updated"
+     }
+*/
+func (h *WebHandler) upsertDNSProvider(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *HandlerContext) error {
+	var req opsclient.UpsertResourceRawReq
+	if err := telehttplib.ReadJSON(r, &req); err != nil {
+		return trace.Wrap(err)
+	}
+	provider, err := storage.UnmarshalDNSProvider(req.Resource)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = ctx.Operator.UpsertDNSProvider(siteKey(p), provider)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, statusOK("DNS provider updated"))
+	return nil
+}
+
+/* deleteDNSProvider deletes the specified external DNS provider
+
+     DELETE /portal/v1/accounts/:account_id/sites/:site_domain/dnsproviders/:name
+
+   Success Response:
+
+     {
+       "message": "DNS provider deleted"
+     }
+*/
+func (h *WebHandler) deleteDNSProvider(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *HandlerContext) error {
+	err := ctx.Operator.DeleteDNSProvider(siteKey(p), p.ByName("name"))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, statusOK("DNS provider deleted"))
+	return nil
+}
+
+/* getWebhooks returns a list of webhooks configured for the cluster
+
+     GET /portal/v1/accounts/:account_id/sites/:site_domain/webhooks
+
+   Success Response:
+
+     []storage.Webhook
+*/
+func (h *WebHandler) getWebhooks(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *HandlerContext) error {
+	webhooks, err := ctx.Operator.GetWebhooks(siteKey(p))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, webhooks)
+	return nil
+}
+
+/* upsertWebhook creates or updates the specified webhook
+
+     PUT /portal/v1/accounts/:account_id/sites/:site_domain/webhooks/:name
+
+   Success Response:
+
+     {
+       "message": "webhook updated"
+     }
+*/
+func (h *WebHandler) upsertWebhook(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *HandlerContext) error {
+	var req opsclient.UpsertResourceRawReq
+	if err := telehttplib.ReadJSON(r, &req); err != nil {
+		return trace.Wrap(err)
+	}
+	webhook, err := storage.UnmarshalWebhook(req.Resource)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = ctx.Operator.UpsertWebhook(siteKey(p), webhook)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, statusOK("webhook updated"))
+	return nil
+}
+
+/* deleteWebhook deletes the specified webhook
+
+     DELETE /portal/v1/accounts/:account_id/sites/:site_domain/webhooks/:name
+
+   Success Response:
+
+     {
+       "message": "webhook deleted"
+     }
+*/
+func (h *WebHandler) deleteWebhook(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *HandlerContext) error {
+	err := ctx.Operator.DeleteWebhook(siteKey(p), p.ByName("name"))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, statusOK("webhook deleted"))
+	return nil
+}
+
+/* getPackageRetentionPolicies returns a list of package retention policies configured for the cluster
+
+     GET /portal/v1/accounts/:account_id/sites/:site_domain/packageretention
+
+   Success Response:
+
+     []storage.PackageRetentionPolicy
+*/
+func (h *WebHandler) getPackageRetentionPolicies(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *HandlerContext) error {
+	policies, err := ctx.Operator.GetPackageRetentionPolicies(siteKey(p))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, policies)
+	return nil
+}
+
+/* upsertPackageRetentionPolicy creates or updates the specified package retention policy
+
+     PUT /portal/v1/accounts/:account_id/sites/:site_domain/packageretention/:name
+
+   Success Response:
+
+     {
+       "message": "package retention policy updated"
+     }
+*/
+func (h *WebHandler) upsertPackageRetentionPolicy(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *HandlerContext) error {
+	var req opsclient.UpsertResourceRawReq
+	if err := telehttplib.ReadJSON(r, &req); err != nil {
+		return trace.Wrap(err)
+	}
+	policy, err := storage.UnmarshalPackageRetentionPolicy(req.Resource)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = ctx.Operator.UpsertPackageRetentionPolicy(siteKey(p), policy)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, statusOK("package retention policy updated"))
+	return nil
+}
+
+/* deletePackageRetentionPolicy deletes the specified package retention policy
+
+     DELETE /portal/v1/accounts/:account_id/sites/:site_domain/packageretention/:name
+
+   Success Response:
+
+     {
+       "message": "package retention policy deleted"
+     }
+*/
+func (h *WebHandler) deletePackageRetentionPolicy(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *HandlerContext) error {
+	err := ctx.Operator.DeletePackageRetentionPolicy(siteKey(p), p.ByName("name"))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, statusOK("package retention policy deleted"))
+	return nil
+}
+
+/* getSecretBackends returns a list of external secret backends configured for the cluster
+
+     GET /portal/v1/accounts/:account_id/sites/:site_domain/secretbackends
+
+   Success Response:
+
+     []storage.SecretBackend
+*/
+func (h *WebHandler) getSecretBackends(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *HandlerContext) error {
+	withSecrets, _, err := telehttplib.ParseBool(r.URL.Query(), constants.WithSecretsParam)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	backends, err := ctx.Operator.GetSecretBackends(siteKey(p), withSecrets)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, backends)
+	return nil
+}
+
+/* upsertSecretBackend creates or updates the specified secret backend
+
+     PUT /portal/v1/accounts/:account_id/sites/:site_domain/secretbackends/:name
+
+   Success Response:
+
+     {
+       "message": "secret backend updated"
+     }
+*/
+func (h *WebHandler) upsertSecretBackend(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *HandlerContext) error {
+	var req opsclient.UpsertResourceRawReq
+	if err := telehttplib.ReadJSON(r, &req); err != nil {
+		return trace.Wrap(err)
+	}
+	backend, err := storage.UnmarshalSecretBackend(req.Resource)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = ctx.Operator.UpsertSecretBackend(siteKey(p), backend)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, statusOK("secret backend updated"))
+	return nil
+}
+
+/* deleteSecretBackend deletes the specified secret backend
+
+     DELETE /portal/v1/accounts/:account_id/sites/:site_domain/secretbackends/:name
+
+   Success Response:
+
+     {
+       "message": "secret backend deleted"
+     }
+*/
+func (h *WebHandler) deleteSecretBackend(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *HandlerContext) error {
+	err := ctx.Operator.DeleteSecretBackend(siteKey(p), p.ByName("name"))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, statusOK("secret backend deleted"))
+	return nil
+}
+
+/* getIngressController returns the configuration of the bundled ingress controller
+
+     GET /portal/v1/accounts/:account_id/sites/:site_domain/ingresscontroller
+
+   Success Response:
+
+     storage.IngressController
+*/
+func (h *WebHandler) getIngressController(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *HandlerContext) error {
+	controller, err := ctx.Operator.GetIngressController(siteKey(p))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, controller)
+	return nil
+}
+
+/* upsertIngressController creates or updates the configuration of the bundled ingress controller
+
+     PUT /portal/v1/accounts/:account_id/sites/:site_domain/ingresscontroller
+
+   Success Response:
+
+     {
+       "message": "ingress controller updated"
+     }
+*/
+func (h *WebHandler) upsertIngressController(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *HandlerContext) error {
+	var req opsclient.UpsertResourceRawReq
+	if err := telehttplib.ReadJSON(r, &req); err != nil {
+		return trace.Wrap(err)
+	}
+	controller, err := storage.UnmarshalIngressController(req.Resource)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = ctx.Operator.UpsertIngressController(siteKey(p), controller)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, statusOK("ingress controller updated"))
+	return nil
+}
+
+/* deleteIngressController deletes the configuration of the bundled ingress controller
+
+     DELETE /portal/v1/accounts/:account_id/sites/:site_domain/ingresscontroller
+
+   Success Response:
+
+     {
+       "message": "ingress controller deleted"
+     }
+*/
+func (h *WebHandler) deleteIngressController(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *HandlerContext) error {
+	err := ctx.Operator.DeleteIngressController(siteKey(p))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, statusOK("ingress controller deleted"))
+	return nil
+}
+
+/* getTimeSync returns the time synchronization configuration
+
+     GET /portal/v1/accounts/:account_id/sites/:site_domain/timesync
+
+   Success Response:
+
+     storage.TimeSync
+*/
+func (h *WebHandler) getTimeSync(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *HandlerContext) error {
+	config, err := ctx.Operator.GetTimeSync(siteKey(p))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, config)
+	return nil
+}
+
+/* upsertTimeSync creates or updates the time synchronization configuration
+
+     PUT /portal/v1/accounts/:account_id/sites/:site_domain/timesync
+
+   Success Response:
+
+     {
+       "message": "time sync configuration updated"
+     }
+*/
+func (h *WebHandler) upsertTimeSync(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *HandlerContext) error {
+	var req opsclient.UpsertResourceRawReq
+	if err := telehttplib.ReadJSON(r, &req); err != nil {
+		return trace.Wrap(err)
+	}
+	config, err := storage.UnmarshalTimeSync(req.Resource)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = ctx.Operator.UpsertTimeSync(siteKey(p), config)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, statusOK("time sync configuration updated"))
+	return nil
+}
+
+/* deleteTimeSync deletes the time synchronization configuration
+
+     DELETE /portal/v1/accounts/:account_id/sites/:site_domain/timesync
+
+   Success Response:
+
+     {
+       "message": "time sync configuration deleted"
+     }
+*/
+func (h *WebHandler) deleteTimeSync(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *HandlerContext) error {
+	err := ctx.Operator.DeleteTimeSync(siteKey(p))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, statusOK("time sync configuration deleted"))
+	return nil
+}
+
+/* getLogRotation returns the log rotation policy
+
+     GET /portal/v1/accounts/:account_id/sites/:site_domain/logrotation
+
+   Success Response:
+
+     storage.LogRotation
+*/
+func (h *WebHandler) getLogRotation(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *HandlerContext) error {
+	config, err := ctx.Operator.GetLogRotation(siteKey(p))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, config)
+	return nil
+}
+
+/* upsertLogRotation creates or updates the log rotation policy
+
+     PUT /portal/v1/accounts/:account_id/sites/:site_domain/logrotation
+
+   Success Response:
+
+     {
+       "message": "log rotation policy updated"
+     }
+*/
+func (h *WebHandler) upsertLogRotation(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *HandlerContext) error {
+	var req opsclient.UpsertResourceRawReq
+	if err := telehttplib.ReadJSON(r, &req); err != nil {
+		return trace.Wrap(err)
+	}
+	config, err := storage.UnmarshalLogRotation(req.Resource)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = ctx.Operator.UpsertLogRotation(siteKey(p), config)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, statusOK("log rotation policy updated"))
+	return nil
+}
+
+/* deleteLogRotation deletes the log rotation policy
+
+     DELETE /portal/v1/accounts/:account_id/sites/:site_domain/logrotation
+
+   Success Response:
+
+     {
+       "message": "log rotation policy deleted"
+     }
+*/
+func (h *WebHandler) deleteLogRotation(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *HandlerContext) error {
+	err := ctx.Operator.DeleteLogRotation(siteKey(p))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, statusOK("log rotation policy deleted"))
+	return nil
+}
+
+/* getOperationReaper returns the operation reaper configuration
+
+     GET /portal/v1/accounts/:account_id/sites/:site_domain/operationreaper
+
+   Success Response:
+
+     storage.OperationReaper
+*/
+func (h *WebHandler) getOperationReaper(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *HandlerContext) error {
+	config, err := ctx.Operator.GetOperationReaper(siteKey(p))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, config)
+	return nil
+}
+
+/* upsertOperationReaper creates or updates the operation reaper configuration
+
+     PUT /portal/v1/accounts/:account_id/sites/:site_domain/operationreaper
+
+   Success Response:
+
+     {
+       "message": "operation reaper updated"
+     }
+*/
+func (h *WebHandler) upsertOperationReaper(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *HandlerContext) error {
+	var req opsclient.UpsertResourceRawReq
+	if err := telehttplib.ReadJSON(r, &req); err != nil {
+		return trace.Wrap(err)
+	}
+	config, err := storage.UnmarshalOperationReaper(req.Resource)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = ctx.Operator.UpsertOperationReaper(siteKey(p), config)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, statusOK("operation reaper updated"))
+	return nil
+}
+
+/* deleteOperationReaper deletes the operation reaper configuration
+
+     DELETE /portal/v1/accounts/:account_id/sites/:site_domain/operationreaper
+
+   Success Response:
+
+     {
+       "message": "operation reaper deleted"
+     }
+*/
+func (h *WebHandler) deleteOperationReaper(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *HandlerContext) error {
+	err := ctx.Operator.DeleteOperationReaper(siteKey(p))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, statusOK("operation reaper deleted"))
+	return nil
+}
+
+/* getTrustedCA returns the trusted CA bundle
+
+     GET /portal/v1/accounts/:account_id/sites/:site_domain/trustedca
+
+   Success Response:
+
+     storage.TrustedCA
+*/
+func (h *WebHandler) getTrustedCA(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *HandlerContext) error {
+	bundle, err := ctx.Operator.GetTrustedCA(siteKey(p))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, bundle)
+	return nil
+}
+
+/* upsertTrustedCA creates or updates the trusted CA bundle
+
+     PUT /portal/v1/accounts/:account_id/sites/:site_domain/trustedca
+
+   Success Response:
+
+     {
+       "message": "trusted CA bundle updated"
+     }
+*/
+func (h *WebHandler) upsertTrustedCA(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *HandlerContext) error {
+	var req opsclient.UpsertResourceRawReq
+	if err := telehttplib.ReadJSON(r, &req); err != nil {
+		return trace.Wrap(err)
+	}
+	bundle, err := storage.UnmarshalTrustedCA(req.Resource)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = ctx.Operator.UpsertTrustedCA(siteKey(p), bundle)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, statusOK("trusted CA bundle updated"))
+	return nil
+}
+
+/* deleteTrustedCA deletes the trusted CA bundle
+
+     DELETE /portal/v1/accounts/:account_id/sites/:site_domain/trustedca
+
+   Success Response:
+
+     {
+       "message": "trusted CA bundle deleted"
+     }
+*/
+func (h *WebHandler) deleteTrustedCA(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *HandlerContext) error {
+	err := ctx.Operator.DeleteTrustedCA(siteKey(p))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, statusOK("trusted CA bundle deleted"))
+	return nil
+}
+
+/* getBandwidthProfile returns the cluster bandwidth profile
+
+     GET /portal/v1/accounts/:account_id/sites/:site_domain/bandwidthprofile
+
+   Success Response:
+
+     storage.BandwidthProfile
+*/
+func (h *WebHandler) getBandwidthProfile(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *HandlerContext) error {
+	profile, err := ctx.Operator.GetBandwidthProfile(siteKey(p))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, profile)
+	return nil
+}
+
+/* upsertBandwidthProfile creates or updates the cluster bandwidth profile
+
+     PUT /portal/v1/accounts/:account_id/sites/:site_domain/bandwidthprofile
+
+   Success Response:
+
+     {
+       "message": "bandwidth profile updated"
+     }
+*/
+func (h *WebHandler) upsertBandwidthProfile(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *HandlerContext) error {
+	var req opsclient.UpsertResourceRawReq
+	if err := telehttplib.ReadJSON(r, &req); err != nil {
+		return trace.Wrap(err)
+	}
+	profile, err := storage.UnmarshalBandwidthProfile(req.Resource)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = ctx.Operator.UpsertBandwidthProfile(siteKey(p), profile)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, statusOK("bandwidth profile updated"))
+	return nil
+}
+
+/* deleteBandwidthProfile deletes the cluster bandwidth profile
+
+     DELETE /portal/v1/accounts/:account_id/sites/:site_domain/bandwidthprofile
+
+   Success Response:
+
+     {
+       "message": "bandwidth profile deleted"
+     }
+*/
+func (h *WebHandler) deleteBandwidthProfile(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *HandlerContext) error {
+	err := ctx.Operator.DeleteBandwidthProfile(siteKey(p))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, statusOK("bandwidth profile deleted"))
+	return nil
+}
+
+/* getAppOverlays returns a list of bundled application overlays
+
+     GET /portal/v1/accounts/:account_id/sites/:site_domain/appoverlays
+
+   Success Response:
+
+     []storage.AppOverlay
+*/
+func (h *WebHandler) getAppOverlays(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *HandlerContext) error {
+	overlays, err := ctx.Operator.GetAppOverlays(siteKey(p))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, overlays)
+	return nil
+}
+
+/* upsertAppOverlay creates or updates the specified application overlay
+
+     PUT /portal/v1/accounts/:account_id/sites/:site_domain/appoverlays/:name
+
+   Success Response:
+
+     {
+       "message": "application overlay updated"
+     }
+*/
+func (h *WebHandler) upsertAppOverlay(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *HandlerContext) error {
+	var req opsclient.UpsertResourceRawReq
+	if err := telehttplib.ReadJSON(r, &req); err != nil {
+		return trace.Wrap(err)
+	}
+	overlay, err := storage.UnmarshalAppOverlay(req.Resource)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = ctx.Operator.UpsertAppOverlay(siteKey(p), overlay)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, statusOK("application overlay updated"))
+	return nil
+}
+
+/* deleteAppOverlay deletes the specified application overlay
+
+     DELETE /portal/v1/accounts/:account_id/sites/:site_domain/appoverlays/:name
+
+   Success Response:
+
+     {
+       "message": "application overlay deleted"
+     }
+*/
+func (h *WebHandler) deleteAppOverlay(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *HandlerContext) error {
+	err := ctx.Operator.DeleteAppOverlay(siteKey(p), p.ByName("name"))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, statusOK("application overlay deleted"))
+	return nil
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/gravitational/gravity/lib/ops"
 	"github.com/gravitational/gravity/lib/ops/monitoring"
 	"github.com/gravitational/gravity/lib/ops/opsservice"
+	"github.com/gravitational/gravity/lib/pack"
 	"github.com/gravitational/gravity/lib/storage"
 
 	teleservices "github.com/gravitational/teleport/lib/services"
@@ -257,6 +258,51 @@ func (r *Router) CheckSiteStatus(key ops.SiteKey) error {
 	return client.CheckSiteStatus(key)
 }
 
+// GetQuarantinedNodes returns the list of quarantined nodes
+func (r *Router) GetQuarantinedNodes(key ops.SiteKey) (storage.QuarantinedNodes, error) {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return client.GetQuarantinedNodes(key)
+}
+
+// QuarantineNode quarantines the specified node
+func (r *Router) QuarantineNode(key ops.SiteKey, node storage.QuarantinedNode) error {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return client.QuarantineNode(key, node)
+}
+
+// ReleaseQuarantinedNode releases the specified node from quarantine
+func (r *Router) ReleaseQuarantinedNode(key ops.SiteKey, advertiseIP string) error {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return client.ReleaseQuarantinedNode(key, advertiseIP)
+}
+
+// GetOperationLocks returns the resources held by active cluster operations
+func (r *Router) GetOperationLocks(key ops.SiteKey) ([]storage.OperationLock, error) {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return client.GetOperationLocks(key)
+}
+
+// ReleaseOperationLocks forcibly releases the resources held by the specified operation
+func (r *Router) ReleaseOperationLocks(req ops.ReleaseOperationLocksRequest) error {
+	client, err := r.RemoteClient(req.SiteDomain)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return client.ReleaseOperationLocks(req)
+}
+
 func (r *Router) GetSiteInstructions(tokenID string, serverProfile string, params url.Values) (string, error) {
 	token, err := r.Backend.GetProvisioningToken(tokenID)
 	if err != nil {
@@ -338,6 +384,16 @@ func (r *Router) CreateClusterGarbageCollectOperation(req ops.CreateClusterGarba
 	return r.Local.CreateClusterGarbageCollectOperation(req)
 }
 
+// CreateClusterRollingRestartOperation creates a new rolling restart operation in the cluster
+func (r *Router) CreateClusterRollingRestartOperation(req ops.CreateClusterRollingRestartOperationRequest) (*ops.SiteOperationKey, error) {
+	return r.Local.CreateClusterRollingRestartOperation(req)
+}
+
+// CreateClusterDockerMigrationOperation creates a new docker storage driver migration operation in the cluster
+func (r *Router) CreateClusterDockerMigrationOperation(req ops.CreateClusterDockerMigrationOperationRequest) (*ops.SiteOperationKey, error) {
+	return r.Local.CreateClusterDockerMigrationOperation(req)
+}
+
 func (r *Router) GetSiteOperationLogs(key ops.SiteOperationKey) (io.ReadCloser, error) {
 	client, err := r.PickOperationClient(key.SiteDomain)
 	if err != nil {
@@ -346,6 +402,16 @@ func (r *Router) GetSiteOperationLogs(key ops.SiteOperationKey) (io.ReadCloser,
 	return client.GetSiteOperationLogs(key)
 }
 
+// GetSiteOperationLogEntries returns the structured log entries
+// of the operation that match the provided filter
+func (r *Router) GetSiteOperationLogEntries(key ops.SiteOperationKey, filter ops.LogFilter) ([]ops.LogEntry, error) {
+	client, err := r.PickOperationClient(key.SiteDomain)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return client.GetSiteOperationLogEntries(key, filter)
+}
+
 func (r *Router) CreateLogEntry(key ops.SiteOperationKey, entry ops.LogEntry) error {
 	client, err := r.PickOperationClient(key.SiteDomain)
 	if err != nil {
@@ -485,6 +551,16 @@ func (r *Router) CreateOperationPlanChange(key ops.SiteOperationKey, change stor
 	return client.CreateOperationPlanChange(key, change)
 }
 
+// ApprovePlanPhase approves the execution of the operation plan phase
+// or marks the phase as requiring approval
+func (r *Router) ApprovePlanPhase(req ops.ApprovePlanPhaseRequest) (*storage.PlanChange, error) {
+	client, err := r.RemoteClient(req.SiteDomain)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return client.ApprovePlanPhase(req)
+}
+
 // GetOperationPlan returns plan for the specified operation
 func (r *Router) GetOperationPlan(key ops.SiteOperationKey) (*storage.OperationPlan, error) {
 	client, err := r.PickOperationClient(key.SiteDomain)
@@ -654,6 +730,312 @@ func (r *Router) DeleteAlertTarget(key ops.SiteKey) error {
 	return client.DeleteAlertTarget(key)
 }
 
+// GetDNSProviders returns the list of configured DNS providers
+func (r *Router) GetDNSProviders(key ops.SiteKey) ([]storage.DNSProvider, error) {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return client.GetDNSProviders(key)
+}
+
+// UpsertDNSProvider creates or updates the specified DNS provider
+func (r *Router) UpsertDNSProvider(key ops.SiteKey, provider storage.DNSProvider) error {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return client.UpsertDNSProvider(key, provider)
+}
+
+// DeleteDNSProvider deletes the DNS provider specified with name
+func (r *Router) DeleteDNSProvider(key ops.SiteKey, name string) error {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return client.DeleteDNSProvider(key, name)
+}
+
+// GetWebhooks returns the list of configured webhooks
+func (r *Router) GetWebhooks(key ops.SiteKey) ([]storage.Webhook, error) {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return client.GetWebhooks(key)
+}
+
+// UpsertWebhook creates or updates the specified webhook
+func (r *Router) UpsertWebhook(key ops.SiteKey, webhook storage.Webhook) error {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return client.UpsertWebhook(key, webhook)
+}
+
+// DeleteWebhook deletes the webhook specified with name
+func (r *Router) DeleteWebhook(key ops.SiteKey, name string) error {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return client.DeleteWebhook(key, name)
+}
+
+// GetPackageRetentionPolicies returns the list of configured package retention policies
+func (r *Router) GetPackageRetentionPolicies(key ops.SiteKey) ([]storage.PackageRetentionPolicy, error) {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return client.GetPackageRetentionPolicies(key)
+}
+
+// UpsertPackageRetentionPolicy creates or updates the specified package retention policy
+func (r *Router) UpsertPackageRetentionPolicy(key ops.SiteKey, policy storage.PackageRetentionPolicy) error {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return client.UpsertPackageRetentionPolicy(key, policy)
+}
+
+// DeletePackageRetentionPolicy deletes the package retention policy specified with name
+func (r *Router) DeletePackageRetentionPolicy(key ops.SiteKey, name string) error {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return client.DeletePackageRetentionPolicy(key, name)
+}
+
+// GetSecretBackends returns the list of configured secret backends
+func (r *Router) GetSecretBackends(key ops.SiteKey, withSecrets bool) ([]storage.SecretBackend, error) {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return client.GetSecretBackends(key, withSecrets)
+}
+
+// UpsertSecretBackend creates or updates the specified secret backend
+func (r *Router) UpsertSecretBackend(key ops.SiteKey, backend storage.SecretBackend) error {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return client.UpsertSecretBackend(key, backend)
+}
+
+// DeleteSecretBackend deletes the secret backend specified with name
+func (r *Router) DeleteSecretBackend(key ops.SiteKey, name string) error {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return client.DeleteSecretBackend(key, name)
+}
+
+// GetPackageStats returns usage statistics of the cluster package store
+func (r *Router) GetPackageStats(key ops.SiteKey) (*pack.StoreStats, error) {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return client.GetPackageStats(key)
+}
+
+// GetIngressController returns the ingress controller configuration
+func (r *Router) GetIngressController(key ops.SiteKey) (storage.IngressController, error) {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return client.GetIngressController(key)
+}
+
+// UpsertIngressController creates or updates the ingress controller configuration
+func (r *Router) UpsertIngressController(key ops.SiteKey, controller storage.IngressController) error {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return client.UpsertIngressController(key, controller)
+}
+
+// DeleteIngressController deletes the ingress controller configuration
+func (r *Router) DeleteIngressController(key ops.SiteKey) error {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return client.DeleteIngressController(key)
+}
+
+// GetTimeSync returns the time synchronization configuration
+func (r *Router) GetTimeSync(key ops.SiteKey) (storage.TimeSync, error) {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return client.GetTimeSync(key)
+}
+
+// UpsertTimeSync creates or updates the time synchronization configuration
+func (r *Router) UpsertTimeSync(key ops.SiteKey, config storage.TimeSync) error {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return client.UpsertTimeSync(key, config)
+}
+
+// DeleteTimeSync deletes the time synchronization configuration
+func (r *Router) DeleteTimeSync(key ops.SiteKey) error {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return client.DeleteTimeSync(key)
+}
+
+// GetLogRotation returns the log rotation policy
+func (r *Router) GetLogRotation(key ops.SiteKey) (storage.LogRotation, error) {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return client.GetLogRotation(key)
+}
+
+// UpsertLogRotation creates or updates the log rotation policy
+func (r *Router) UpsertLogRotation(key ops.SiteKey, config storage.LogRotation) error {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return client.UpsertLogRotation(key, config)
+}
+
+// DeleteLogRotation deletes the log rotation policy
+func (r *Router) DeleteLogRotation(key ops.SiteKey) error {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return client.DeleteLogRotation(key)
+}
+
+// GetOperationReaper returns the operation reaper configuration
+func (r *Router) GetOperationReaper(key ops.SiteKey) (storage.OperationReaper, error) {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return client.GetOperationReaper(key)
+}
+
+// UpsertOperationReaper creates or updates the operation reaper configuration
+func (r *Router) UpsertOperationReaper(key ops.SiteKey, config storage.OperationReaper) error {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return client.UpsertOperationReaper(key, config)
+}
+
+// DeleteOperationReaper deletes the operation reaper configuration
+func (r *Router) DeleteOperationReaper(key ops.SiteKey) error {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return client.DeleteOperationReaper(key)
+}
+
+// GetTrustedCA returns the trusted CA bundle
+func (r *Router) GetTrustedCA(key ops.SiteKey) (storage.TrustedCA, error) {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return client.GetTrustedCA(key)
+}
+
+// UpsertTrustedCA creates or updates the trusted CA bundle
+func (r *Router) UpsertTrustedCA(key ops.SiteKey, bundle storage.TrustedCA) error {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return client.UpsertTrustedCA(key, bundle)
+}
+
+// DeleteTrustedCA deletes the trusted CA bundle
+func (r *Router) DeleteTrustedCA(key ops.SiteKey) error {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return client.DeleteTrustedCA(key)
+}
+
+// GetBandwidthProfile returns the cluster bandwidth profile
+func (r *Router) GetBandwidthProfile(key ops.SiteKey) (storage.BandwidthProfile, error) {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return client.GetBandwidthProfile(key)
+}
+
+// UpsertBandwidthProfile creates or updates the cluster bandwidth profile
+func (r *Router) UpsertBandwidthProfile(key ops.SiteKey, profile storage.BandwidthProfile) error {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return client.UpsertBandwidthProfile(key, profile)
+}
+
+// DeleteBandwidthProfile deletes the cluster bandwidth profile
+func (r *Router) DeleteBandwidthProfile(key ops.SiteKey) error {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return client.DeleteBandwidthProfile(key)
+}
+
+// GetAppOverlays returns the list of configured application overlays
+func (r *Router) GetAppOverlays(key ops.SiteKey) ([]storage.AppOverlay, error) {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return client.GetAppOverlays(key)
+}
+
+// UpsertAppOverlay creates or updates the specified application overlay
+func (r *Router) UpsertAppOverlay(key ops.SiteKey, overlay storage.AppOverlay) error {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return client.UpsertAppOverlay(key, overlay)
+}
+
+// DeleteAppOverlay deletes the overlay of the application specified with name
+func (r *Router) DeleteAppOverlay(key ops.SiteKey, name string) error {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return client.DeleteAppOverlay(key, name)
+}
+
 func (r *Router) GetApplicationEndpoints(key ops.SiteKey) ([]ops.Endpoint, error) {
 	client, err := r.RemoteClient(key.SiteDomain)
 	if err != nil {
@@ -80,9 +80,16 @@ func (s *site) agentReport(ctx context.Context, opCtx *operationContext) (*ops.A
 		}
 	}
 
+	var nodes []storage.System
+	for _, info := range infos {
+		nodes = append(nodes, info.System)
+	}
+	recommendation := checks.Recommend(s.app.Manifest, nodes)
+
 	return &ops.AgentReport{
-		Message: message,
-		Servers: infos,
+		Message:        message,
+		Servers:        infos,
+		Recommendation: &recommendation,
 	}, nil
 
 }
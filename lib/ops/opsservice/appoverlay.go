@@ -0,0 +1,101 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opsservice
+
+import (
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/rigging"
+	"github.com/gravitational/trace"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubelabels "k8s.io/apimachinery/pkg/labels"
+)
+
+// GetAppOverlays returns the list of configured application overlays
+func (o *Operator) GetAppOverlays(key ops.SiteKey) ([]storage.AppOverlay, error) {
+	client, err := o.GetKubeClient()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	options := metav1.ListOptions{
+		LabelSelector: kubelabels.Set{constants.AppOverlayLabel: "true"}.String(),
+	}
+	configmaps, err := client.Core().ConfigMaps(defaults.KubeSystemNamespace).List(options)
+	if err != nil {
+		return nil, trace.Wrap(rigging.ConvertError(err))
+	}
+
+	overlays := make([]storage.AppOverlay, 0, len(configmaps.Items))
+	for _, config := range configmaps.Items {
+		data, ok := config.Data[constants.ResourceSpecKey]
+		if !ok {
+			continue
+		}
+		overlay, err := storage.UnmarshalAppOverlay([]byte(data))
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		overlays = append(overlays, overlay)
+	}
+	return overlays, nil
+}
+
+// UpsertAppOverlay creates or updates the specified application overlay
+func (o *Operator) UpsertAppOverlay(key ops.SiteKey, overlay storage.AppOverlay) error {
+	if err := overlay.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+
+	client, err := o.GetKubeClient()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	data, err := storage.MarshalAppOverlay(overlay)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	labels := map[string]string{
+		constants.AppOverlayLabel: "true",
+	}
+	return updateConfigMap(client.Core().ConfigMaps(defaults.KubeSystemNamespace),
+		appOverlayConfigMap(overlay.GetName()), defaults.KubeSystemNamespace, string(data), labels)
+}
+
+// DeleteAppOverlay deletes the overlay of the application specified with name
+func (o *Operator) DeleteAppOverlay(key ops.SiteKey, name string) error {
+	client, err := o.GetKubeClient()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	err = rigging.ConvertError(client.Core().ConfigMaps(defaults.KubeSystemNamespace).
+		Delete(appOverlayConfigMap(name), nil))
+	if trace.IsNotFound(err) {
+		return trace.NotFound("overlay of application %q not found", name)
+	}
+	return trace.Wrap(err)
+}
+
+func appOverlayConfigMap(name string) string {
+	return constants.AppOverlayConfigMapPrefix + name
+}
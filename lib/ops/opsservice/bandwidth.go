@@ -0,0 +1,88 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opsservice
+
+import (
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/rigging"
+	"github.com/gravitational/trace"
+)
+
+// GetBandwidthProfile returns the cluster bandwidth profile
+func (o *Operator) GetBandwidthProfile(key ops.SiteKey) (storage.BandwidthProfile, error) {
+	client, err := o.GetKubeClient()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	data, err := getConfigMap(client.Core().ConfigMaps(defaults.KubeSystemNamespace),
+		constants.BandwidthProfileConfigMap)
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil, trace.NotFound("bandwidth profile is not configured")
+		}
+		return nil, trace.Wrap(err)
+	}
+
+	profile, err := storage.UnmarshalBandwidthProfile([]byte(data))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := profile.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return profile, nil
+}
+
+// UpsertBandwidthProfile creates or updates the cluster bandwidth profile
+func (o *Operator) UpsertBandwidthProfile(key ops.SiteKey, profile storage.BandwidthProfile) error {
+	if err := profile.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+
+	client, err := o.GetKubeClient()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	data, err := storage.MarshalBandwidthProfile(profile)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	return updateConfigMap(client.Core().ConfigMaps(defaults.KubeSystemNamespace),
+		constants.BandwidthProfileConfigMap, defaults.KubeSystemNamespace, string(data), nil)
+}
+
+// DeleteBandwidthProfile deletes the cluster bandwidth profile
+func (o *Operator) DeleteBandwidthProfile(key ops.SiteKey) error {
+	client, err := o.GetKubeClient()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	err = rigging.ConvertError(client.Core().ConfigMaps(defaults.KubeSystemNamespace).
+		Delete(constants.BandwidthProfileConfigMap, nil))
+	if trace.IsNotFound(err) {
+		return trace.NotFound("bandwidth profile is not configured")
+	}
+	return trace.Wrap(err)
+}
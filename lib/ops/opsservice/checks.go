@@ -45,7 +45,7 @@ func (o *Operator) ValidateServers(req ops.ValidateServersRequest) error {
 	}
 
 	err = ops.CheckServers(context.TODO(), op.Key(), infos, req.Servers,
-		cluster.agentService(), cluster.app.Manifest)
+		cluster.agentService(), cluster.app.Manifest, op.GetVars().OnPrem.VxlanPort)
 	if err != nil {
 		return trace.Wrap(ops.FormatValidationError(err))
 	}
@@ -18,6 +18,7 @@ package opsservice
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net"
@@ -128,7 +129,60 @@ func (s *site) createLogEntry(key ops.SiteOperationKey, entry ops.LogEntry) erro
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	return nil
+	return trace.Wrap(s.appendJournalEntry(key, entry))
+}
+
+// appendJournalEntry appends the provided log entry to the operation's
+// structured journal that allows filtering entries on retrieval
+func (s *site) appendJournalEntry(key ops.SiteOperationKey, entry ops.LogEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	f, err := os.OpenFile(s.operationJournalPath(key),
+		os.O_CREATE|os.O_WRONLY|os.O_APPEND, defaults.SharedReadMask)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return trace.ConvertSystemError(err)
+}
+
+// getOperationLogEntries returns the entries from the operation's structured
+// journal that match the provided filter
+func (s *site) getOperationLogEntries(key ops.SiteOperationKey, filter ops.LogFilter) ([]ops.LogEntry, error) {
+	if err := filter.Check(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	_, err := s.backend().GetSiteOperation(key.SiteDomain, key.OperationID)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	f, err := os.Open(s.operationJournalPath(key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, trace.ConvertSystemError(err)
+	}
+	defer f.Close()
+	var entries []ops.LogEntry
+	decoder := json.NewDecoder(f)
+	for {
+		var entry ops.LogEntry
+		err := decoder.Decode(&entry)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		if filter.Matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
 }
 
 // executeOnServers runs the provided function on the specified list of servers concurrently.
@@ -166,7 +166,17 @@ func (s *site) configureExpandPackages(ctx context.Context, opCtx *operationCont
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	provisionedServer := opCtx.provisionedServers[0]
+	// batch expand operations add several servers at once
+	for _, provisionedServer := range opCtx.provisionedServers {
+		err := s.configureExpandServer(ctx, opCtx, teleportMaster, provisionedServer)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+func (s *site) configureExpandServer(ctx context.Context, opCtx *operationContext, teleportMaster *teleportServer, provisionedServer *ProvisionedServer) error {
 	etcdConfig, err := s.getEtcdConfig(ctx, opCtx, provisionedServer)
 	if err != nil {
 		return trace.Wrap(err)
@@ -894,12 +904,19 @@ func (s *site) getPlanetConfigPackage(
 	}
 	args = append(args, dockerArgs...)
 
+	lightweight := installOrExpand.InstallExpand.Vars.System.Lightweight
 	etcdArgs := manifest.EtcdArgs(*profile)
+	if lightweight {
+		etcdArgs = append(append([]string{}, defaults.LightweightEtcdArgs...), etcdArgs...)
+	}
 	if len(etcdArgs) != 0 {
 		args = append(args, fmt.Sprintf("--etcd-options=%v", strings.Join(etcdArgs, " ")))
 	}
 
 	kubeletArgs := defaults.KubeletArgs
+	if lightweight {
+		kubeletArgs = append(append([]string{}, kubeletArgs...), defaults.LightweightKubeletArgs...)
+	}
 	if len(manifest.KubeletArgs(*profile)) != 0 {
 		kubeletArgs = append(kubeletArgs, manifest.KubeletArgs(*profile)...)
 	}
@@ -953,14 +970,36 @@ func (s *site) getPlanetConfigPackage(
 		args = append(args, "--disable-flannel=true")
 	}
 
-	reader, err := pack.GetConfigPackage(s.packages(), config.planetPackage, config.configPackage, args)
+	// configuration shared between nodes is kept in a separate package
+	// so that nodes with identical configuration do not duplicate it
+	basePrefix := loc.Locator{
+		Repository: s.siteRepoName(),
+		Name:       constants.PlanetConfigBasePackage,
+		Version:    config.configPackage.Version,
+	}
+	layeredConfig, err := pack.GetLayeredConfigPackage(s.packages(), config.planetPackage,
+		basePrefix, args, defaults.PlanetNodeConfigParams)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
 
+	err = layeredConfig.CreateBase(s.packages(), map[string]string{
+		pack.PurposeLabel:       pack.PurposePlanetConfigBase,
+		pack.ConfigBaseForLabel: config.planetPackage.ZeroVersion().String(),
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	reader := &bytes.Buffer{}
+	if err := layeredConfig.Write(reader); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
 	labels := map[string]string{
 		pack.PurposeLabel:     pack.PurposePlanetConfig,
 		pack.ConfigLabel:      config.planetPackage.ZeroVersion().String(),
+		pack.ConfigBaseLabel:  layeredConfig.Base.String(),
 		pack.AdvertiseIPLabel: node.AdvertiseIP,
 		pack.OperationIDLabel: installOrExpand.ID,
 	}
@@ -1608,14 +1647,14 @@ func (r *exportBackend) GetSiteUsers(domain string) ([]storage.User, error) {
 	return r.site.backend().GetSiteUsers(domain)
 }
 
-// GetPackage returns a package specified with (repository, packageName, packageVersion) tuple
+// GetPackage returns a package specified with (repository, packageName, packageVersion, arch) tuple
 // using a pack.PackageService to enable package discovery with layered package implementation.
-func (r *exportBackend) GetPackage(repository, packageName, packageVersion string) (*storage.Package, error) {
+func (r *exportBackend) GetPackage(repository, packageName, packageVersion, arch string) (*storage.Package, error) {
 	locator, err := loc.NewLocator(repository, packageName, packageVersion)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	envelope, err := r.site.packages().ReadPackageEnvelope(*locator)
+	envelope, err := r.site.packages().ReadPackageEnvelope(locator.WithArch(arch))
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -1623,7 +1662,9 @@ func (r *exportBackend) GetPackage(repository, packageName, packageVersion strin
 		Repository:    repository,
 		Name:          packageName,
 		Version:       packageVersion,
+		Arch:          arch,
 		SHA512:        envelope.SHA512,
+		SHA256:        envelope.SHA256,
 		SizeBytes:     int(envelope.SizeBytes),
 		RuntimeLabels: envelope.RuntimeLabels,
 		Type:          envelope.Type,
@@ -0,0 +1,101 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opsservice
+
+import (
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/rigging"
+	"github.com/gravitational/trace"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubelabels "k8s.io/apimachinery/pkg/labels"
+)
+
+// GetDNSProviders returns the list of configured external DNS providers
+func (o *Operator) GetDNSProviders(key ops.SiteKey) ([]storage.DNSProvider, error) {
+	client, err := o.GetKubeClient()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	options := metav1.ListOptions{
+		LabelSelector: kubelabels.Set{constants.DNSProviderLabel: "true"}.String(),
+	}
+	configmaps, err := client.Core().ConfigMaps(defaults.KubeSystemNamespace).List(options)
+	if err != nil {
+		return nil, trace.Wrap(rigging.ConvertError(err))
+	}
+
+	providers := make([]storage.DNSProvider, 0, len(configmaps.Items))
+	for _, config := range configmaps.Items {
+		data, ok := config.Data[constants.ResourceSpecKey]
+		if !ok {
+			continue
+		}
+		provider, err := storage.UnmarshalDNSProvider([]byte(data))
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		providers = append(providers, provider)
+	}
+	return providers, nil
+}
+
+// UpsertDNSProvider creates or updates the specified external DNS provider
+func (o *Operator) UpsertDNSProvider(key ops.SiteKey, provider storage.DNSProvider) error {
+	if err := provider.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+
+	client, err := o.GetKubeClient()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	data, err := storage.MarshalDNSProvider(provider)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	labels := map[string]string{
+		constants.DNSProviderLabel: "true",
+	}
+	return updateConfigMap(client.Core().ConfigMaps(defaults.KubeSystemNamespace),
+		dnsProviderConfigMap(provider.GetName()), defaults.KubeSystemNamespace, string(data), labels)
+}
+
+// DeleteDNSProvider deletes the external DNS provider specified with name
+func (o *Operator) DeleteDNSProvider(key ops.SiteKey, name string) error {
+	client, err := o.GetKubeClient()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	err = rigging.ConvertError(client.Core().ConfigMaps(defaults.KubeSystemNamespace).
+		Delete(dnsProviderConfigMap(name), nil))
+	if trace.IsNotFound(err) {
+		return trace.NotFound("DNS provider %q not found", name)
+	}
+	return trace.Wrap(err)
+}
+
+func dnsProviderConfigMap(name string) string {
+	return constants.DNSProviderConfigMapPrefix + name
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opsservice
+
+import (
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/ops"
+
+	"github.com/gravitational/trace"
+	"github.com/pborman/uuid"
+)
+
+// createDockerMigrationOperation creates a new operation that migrates
+// docker storage driver from devicemapper to overlay2 in the cluster
+func (s *site) createDockerMigrationOperation(req ops.CreateClusterDockerMigrationOperationRequest) (*ops.SiteOperationKey, error) {
+	_, err := ops.GetCompletedInstallOperation(s.key, s.service)
+	if err != nil {
+		return nil, trace.Wrap(err, "docker storage driver can only be migrated on an installed cluster")
+	}
+
+	driver := s.dockerConfig().StorageDriver
+	if driver != constants.DockerStorageDriverDevicemapper {
+		return nil, trace.BadParameter("cluster uses %q docker storage driver, only %q can be migrated",
+			driver, constants.DockerStorageDriverDevicemapper)
+	}
+
+	op := ops.SiteOperation{
+		ID:         uuid.New(),
+		AccountID:  s.key.AccountID,
+		SiteDomain: s.key.SiteDomain,
+		Type:       ops.OperationDockerMigrate,
+		Created:    s.clock().UtcNow(),
+		Updated:    s.clock().UtcNow(),
+		State:      ops.OperationDockerMigrateInProgress,
+	}
+
+	key, err := s.getOperationGroup().createSiteOperation(op)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return key, nil
+}
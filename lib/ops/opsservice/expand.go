@@ -18,6 +18,7 @@ package opsservice
 
 import (
 	"context"
+	"strings"
 
 	"github.com/gravitational/gravity/lib/ops"
 	"github.com/gravitational/gravity/lib/schema"
@@ -120,7 +121,13 @@ func (s *site) expandOperationStart(ctx *operationContext) error {
 }
 
 func (s *site) validateExpand(op *ops.SiteOperation, req *ops.OperationUpdateRequest) error {
-	if op.Provisioner == schema.ProvisionerOnPrem {
+	batchNodes := op.InstallExpand.Vars.System.BatchNodes
+	if len(batchNodes) != 0 {
+		err := checkBatchServers(batchNodes, req.Servers)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	} else if op.Provisioner == schema.ProvisionerOnPrem {
 		if len(req.Servers) > 1 {
 			return trace.BadParameter(
 				"can only add one node at a time, stop agents on %v extra node(-s)", len(req.Servers)-1)
@@ -149,5 +156,58 @@ func (s *site) validateExpand(op *ops.SiteOperation, req *ops.OperationUpdateReq
 	}
 
 	err = setClusterRoles(req.Servers, *s.app, len(masters))
-	return trace.Wrap(err)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	// etcd membership changes have to happen one node at a time so master
+	// nodes cannot be added as a part of a batch
+	if len(batchNodes) != 0 {
+		for _, server := range req.Servers {
+			if server.IsMaster() {
+				return trace.BadParameter(
+					"node %v would join as a master and cannot be added in a batch, "+
+						"join it separately", server.AdvertiseIP)
+			}
+		}
+	}
+	return nil
+}
+
+// checkBatchServers makes sure that the servers that have joined a batch
+// expand operation match the nodes the batch was created for
+func checkBatchServers(nodes []storage.BatchNode, servers []storage.Server) error {
+	expected := make(map[string]storage.BatchNode, len(nodes))
+	for _, node := range nodes {
+		expected[node.AdvertiseIP] = node
+	}
+	joined := make(map[string]struct{}, len(servers))
+	for _, server := range servers {
+		node, ok := expected[server.AdvertiseIP]
+		if !ok {
+			return trace.AccessDenied(
+				"node %v is not a part of this batch", server.AdvertiseIP)
+		}
+		if node.Role != server.Role {
+			return trace.BadParameter(
+				"node %v joined with role %q, expected %q",
+				server.AdvertiseIP, server.Role, node.Role)
+		}
+		if _, ok := joined[server.AdvertiseIP]; ok {
+			return trace.AlreadyExists(
+				"node %v has joined more than once", server.AdvertiseIP)
+		}
+		joined[server.AdvertiseIP] = struct{}{}
+	}
+	var missing []string
+	for _, node := range nodes {
+		if _, ok := joined[node.AdvertiseIP]; !ok {
+			missing = append(missing, node.AdvertiseIP)
+		}
+	}
+	if len(missing) != 0 {
+		return trace.BadParameter(
+			"waiting for nodes %v to join the batch", strings.Join(missing, ", "))
+	}
+	return nil
 }
@@ -0,0 +1,86 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opsservice
+
+import (
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+	"gopkg.in/check.v1"
+)
+
+type ExpandSuite struct{}
+
+var _ = check.Suite(&ExpandSuite{})
+
+func (s *ExpandSuite) TestCheckBatchServers(c *check.C) {
+	nodes := []storage.BatchNode{
+		{AdvertiseIP: "10.0.0.1", Role: "node"},
+		{AdvertiseIP: "10.0.0.2", Role: "worker"},
+	}
+	var testCases = []struct {
+		servers []storage.Server
+		check   func(error) bool
+		comment string
+	}{
+		{
+			servers: []storage.Server{
+				{AdvertiseIP: "10.0.0.2", Role: "worker"},
+				{AdvertiseIP: "10.0.0.1", Role: "node"},
+			},
+			check:   func(err error) bool { return err == nil },
+			comment: "all nodes have joined",
+		},
+		{
+			servers: []storage.Server{
+				{AdvertiseIP: "10.0.0.1", Role: "node"},
+			},
+			check:   trace.IsBadParameter,
+			comment: "not all nodes have joined",
+		},
+		{
+			servers: []storage.Server{
+				{AdvertiseIP: "10.0.0.1", Role: "node"},
+				{AdvertiseIP: "10.0.0.2", Role: "worker"},
+				{AdvertiseIP: "10.0.0.3", Role: "node"},
+			},
+			check:   trace.IsAccessDenied,
+			comment: "unexpected node",
+		},
+		{
+			servers: []storage.Server{
+				{AdvertiseIP: "10.0.0.1", Role: "worker"},
+				{AdvertiseIP: "10.0.0.2", Role: "worker"},
+			},
+			check:   trace.IsBadParameter,
+			comment: "role mismatch",
+		},
+		{
+			servers: []storage.Server{
+				{AdvertiseIP: "10.0.0.1", Role: "node"},
+				{AdvertiseIP: "10.0.0.1", Role: "node"},
+				{AdvertiseIP: "10.0.0.2", Role: "worker"},
+			},
+			check:   trace.IsAlreadyExists,
+			comment: "duplicate node",
+		},
+	}
+	for _, tc := range testCases {
+		err := checkBatchServers(nodes, tc.servers)
+		c.Assert(tc.check(err), check.Equals, true, check.Commentf("%v: %v", tc.comment, err))
+	}
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opsservice
+
+import (
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/rigging"
+	"github.com/gravitational/trace"
+)
+
+// GetIngressController returns the bundled ingress controller configuration
+func (o *Operator) GetIngressController(key ops.SiteKey) (storage.IngressController, error) {
+	client, err := o.GetKubeClient()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	data, err := getConfigMap(client.Core().ConfigMaps(defaults.KubeSystemNamespace),
+		constants.IngressControllerConfigMap)
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil, trace.NotFound("ingress controller is not configured")
+		}
+		return nil, trace.Wrap(err)
+	}
+
+	return storage.UnmarshalIngressController([]byte(data))
+}
+
+// UpsertIngressController creates or updates the bundled ingress controller configuration
+func (o *Operator) UpsertIngressController(key ops.SiteKey, controller storage.IngressController) error {
+	if err := controller.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+
+	client, err := o.GetKubeClient()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	data, err := storage.MarshalIngressController(controller)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	return updateConfigMap(client.Core().ConfigMaps(defaults.KubeSystemNamespace),
+		constants.IngressControllerConfigMap, defaults.KubeSystemNamespace, string(data), nil)
+}
+
+// DeleteIngressController deletes the bundled ingress controller configuration
+func (o *Operator) DeleteIngressController(key ops.SiteKey) error {
+	client, err := o.GetKubeClient()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	err = rigging.ConvertError(client.Core().ConfigMaps(defaults.KubeSystemNamespace).
+		Delete(constants.IngressControllerConfigMap, nil))
+	if trace.IsNotFound(err) {
+		return trace.NotFound("ingress controller is not configured")
+	}
+	return trace.Wrap(err)
+}
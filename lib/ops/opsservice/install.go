@@ -67,6 +67,12 @@ func (o *Operator) StreamOperationLogs(key ops.SiteOperationKey, reader io.Reade
 // createInstallOperation initiates install operation for a given site
 // it makes sure that install operation is the first operation too
 func (s *site) createInstallOperation(req ops.CreateSiteInstallOperationRequest) (*ops.SiteOperationKey, error) {
+	// parameters can still be set when the operation is started so only
+	// make sure the values provided so far are valid
+	err := s.app.Manifest.InstallerParameters().Validate(req.Variables.Parameters)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
 	profiles := make(map[string]storage.ServerProfile)
 	for _, profile := range s.app.Manifest.NodeProfiles {
 		profiles[profile.Name] = storage.ServerProfile{
@@ -473,7 +479,30 @@ func (s *site) validateInstall(op *ops.SiteOperation, req *ops.OperationUpdateRe
 	}
 
 	err := setClusterRoles(req.Servers, *s.app, 0)
-	return trace.Wrap(err)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	return trace.Wrap(s.resolveParameters(op, *req))
+}
+
+// resolveParameters validates the application configuration parameters
+// set for the install operation and in the specified request, and updates
+// the operation with resolved values
+func (s *site) resolveParameters(op *ops.SiteOperation, req ops.OperationUpdateRequest) error {
+	values := make(map[string]string)
+	for name, value := range op.InstallExpand.Vars.Parameters {
+		values[name] = value
+	}
+	for name, value := range req.Parameters {
+		values[name] = value
+	}
+	resolved, err := s.app.Manifest.InstallerParameters().Resolve(values)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	op.InstallExpand.Vars.Parameters = resolved
+	return nil
 }
 
 // checkOnPremServers checks that onprem servers in the provided request satisfy profiles in
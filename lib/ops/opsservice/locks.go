@@ -0,0 +1,156 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opsservice
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// GetOperationLocks returns the cluster resources held by active operations
+func (o *Operator) GetOperationLocks(key ops.SiteKey) ([]storage.OperationLock, error) {
+	operations, err := ops.GetActiveOperations(key, o)
+	if err != nil && !trace.IsNotFound(err) {
+		return nil, trace.Wrap(err)
+	}
+	var locks []storage.OperationLock
+	for _, operation := range operations {
+		locks = append(locks, ops.GetOperationLocks(operation)...)
+	}
+	return locks, nil
+}
+
+// ReleaseOperationLocks forcibly releases the resources held by the
+// specified operation by marking it failed.
+// The release is recorded on the operation and in its progress log
+func (o *Operator) ReleaseOperationLocks(req ops.ReleaseOperationLocksRequest) error {
+	if err := req.Check(); err != nil {
+		return trace.Wrap(err)
+	}
+	return o.getOperationGroup(req.SiteKey()).releaseOperationLocks(req)
+}
+
+// releaseOperationLocks fails the operation specified with req and moves
+// the cluster into active state unless there are other active operations
+func (g *operationGroup) releaseOperationLocks(req ops.ReleaseOperationLocksRequest) error {
+	g.Lock()
+	defer g.Unlock()
+
+	operation, err := g.operator.GetSiteOperation(req.SiteOperationKey)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	locks := ops.GetOperationLocks(*operation)
+	if len(locks) == 0 {
+		return trace.NotFound("operation %v is %v and holds no locks",
+			operation.ID, operation.State)
+	}
+
+	switch operation.Type {
+	case ops.OperationInstall, ops.OperationUninstall:
+		return trace.BadParameter("locks held by %q operations cannot be released",
+			operation.Type)
+	}
+
+	now := g.operator.clock().UtcNow()
+	if !req.Force {
+		updated := operation.Updated
+		progress, err := g.operator.GetSiteOperationProgress(req.SiteOperationKey)
+		if err != nil && !trace.IsNotFound(err) {
+			return trace.Wrap(err)
+		}
+		if progress != nil && progress.Created.After(updated) {
+			updated = progress.Created
+		}
+		if err := checkOperationStale(updated, now); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+
+	site, err := g.operator.openSite(g.siteKey)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	operation.State = ops.OperationStateFailed
+	operation.LocksRelease = &storage.OperationLocksRelease{
+		User:     req.User,
+		Reason:   req.Reason,
+		Released: now,
+		Locks:    locks,
+	}
+	if _, err := site.updateSiteOperation(operation); err != nil {
+		return trace.Wrap(err)
+	}
+
+	err = g.operator.CreateProgressEntry(req.SiteOperationKey, ops.ProgressEntry{
+		SiteDomain:  operation.SiteDomain,
+		OperationID: operation.ID,
+		Created:     now,
+		Completion:  constants.Completed,
+		State:       ops.ProgressStateFailed,
+		Message:     releaseMessage(req),
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	log.WithFields(log.Fields{
+		"operation": operation.ID,
+		"user":      req.User,
+		"reason":    req.Reason,
+		"locks":     locks,
+	}).Warn("Operation locks forcibly released.")
+
+	_, err = ops.GetActiveOperations(g.siteKey, g.operator)
+	if err == nil {
+		// the cluster remains locked by other operations
+		return nil
+	}
+	if !trace.IsNotFound(err) {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(site.setSiteState(ops.SiteStateActive))
+}
+
+// checkOperationStale returns an error if the operation last updated
+// at the specified time might still be running
+func checkOperationStale(updated, now time.Time) error {
+	idle := now.Sub(updated)
+	if idle >= defaults.OperationLockStaleTimeout {
+		return nil
+	}
+	return trace.CompareFailed("operation was last updated %v ago and might still "+
+		"be running, locks can be released after it has made no progress for %v "+
+		"or forcibly", idle.Round(time.Second), defaults.OperationLockStaleTimeout)
+}
+
+func releaseMessage(req ops.ReleaseOperationLocksRequest) string {
+	if req.User == "" {
+		return fmt.Sprintf("Locks forcibly released: %v", req.Reason)
+	}
+	return fmt.Sprintf("Locks forcibly released by %v: %v", req.User, req.Reason)
+}
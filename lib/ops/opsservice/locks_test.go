@@ -0,0 +1,94 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opsservice
+
+import (
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+	"gopkg.in/check.v1"
+)
+
+// Makes sure locks held by a stuck operation can be released
+// and the release is recorded
+func (s *OperationGroupSuite) TestReleaseOperationLocks(c *check.C) {
+	group := s.operator.getOperationGroup(s.cluster.Key())
+
+	key, err := group.createSiteOperation(ops.SiteOperation{
+		AccountID:  s.cluster.AccountID,
+		SiteDomain: s.cluster.Domain,
+		Type:       ops.OperationInstall,
+		State:      ops.OperationStateInstallInitiated,
+	})
+	c.Assert(err, check.IsNil)
+	_, err = group.compareAndSwapOperationState(swap{
+		key:        *key,
+		newOpState: ops.OperationStateCompleted,
+	})
+	c.Assert(err, check.IsNil)
+
+	key, err = group.createSiteOperation(ops.SiteOperation{
+		AccountID:  s.cluster.AccountID,
+		SiteDomain: s.cluster.Domain,
+		Type:       ops.OperationUpdate,
+		State:      ops.OperationStateUpdateInProgress,
+	})
+	c.Assert(err, check.IsNil)
+	s.assertClusterState(c, ops.SiteStateUpdating)
+
+	locks, err := s.operator.GetOperationLocks(s.cluster.Key())
+	c.Assert(err, check.IsNil)
+	c.Assert(locks, check.HasLen, 1)
+	c.Assert(locks[0].Scope, check.Equals, storage.OperationLockScopeCluster)
+	c.Assert(locks[0].OperationID, check.Equals, key.OperationID)
+
+	req := ops.ReleaseOperationLocksRequest{
+		SiteOperationKey: *key,
+		Reason:           "update agent crashed",
+		User:             "admin@example.com",
+	}
+	// the operation has just made progress so it might still be running
+	err = s.operator.ReleaseOperationLocks(req)
+	c.Assert(trace.IsCompareFailed(err), check.Equals, true, check.Commentf("%v", err))
+	s.assertClusterState(c, ops.SiteStateUpdating)
+
+	req.Force = true
+	err = s.operator.ReleaseOperationLocks(req)
+	c.Assert(err, check.IsNil)
+	s.assertClusterState(c, ops.SiteStateActive)
+
+	operation, err := s.operator.GetSiteOperation(*key)
+	c.Assert(err, check.IsNil)
+	c.Assert(operation.State, check.Equals, ops.OperationStateFailed)
+	c.Assert(operation.LocksRelease, check.NotNil)
+	c.Assert(operation.LocksRelease.User, check.Equals, req.User)
+	c.Assert(operation.LocksRelease.Reason, check.Equals, req.Reason)
+	c.Assert(operation.LocksRelease.Locks, check.DeepEquals, locks)
+
+	progress, err := s.operator.GetSiteOperationProgress(*key)
+	c.Assert(err, check.IsNil)
+	c.Assert(progress.Message, check.Equals,
+		"Locks forcibly released by admin@example.com: update agent crashed")
+
+	locks, err = s.operator.GetOperationLocks(s.cluster.Key())
+	c.Assert(err, check.IsNil)
+	c.Assert(locks, check.HasLen, 0)
+
+	err = s.operator.ReleaseOperationLocks(req)
+	c.Assert(trace.IsNotFound(err), check.Equals, true, check.Commentf("%v", err))
+}
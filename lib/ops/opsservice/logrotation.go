@@ -0,0 +1,81 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opsservice
+
+import (
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/rigging"
+	"github.com/gravitational/trace"
+)
+
+// GetLogRotation returns the node log rotation policy
+func (o *Operator) GetLogRotation(key ops.SiteKey) (storage.LogRotation, error) {
+	client, err := o.GetKubeClient()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	data, err := getConfigMap(client.Core().ConfigMaps(defaults.KubeSystemNamespace),
+		constants.LogRotationConfigMap)
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil, trace.NotFound("log rotation is not configured")
+		}
+		return nil, trace.Wrap(err)
+	}
+
+	return storage.UnmarshalLogRotation([]byte(data))
+}
+
+// UpsertLogRotation creates or updates the node log rotation policy
+func (o *Operator) UpsertLogRotation(key ops.SiteKey, config storage.LogRotation) error {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+
+	client, err := o.GetKubeClient()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	data, err := storage.MarshalLogRotation(config)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	return updateConfigMap(client.Core().ConfigMaps(defaults.KubeSystemNamespace),
+		constants.LogRotationConfigMap, defaults.KubeSystemNamespace, string(data), nil)
+}
+
+// DeleteLogRotation deletes the node log rotation policy
+func (o *Operator) DeleteLogRotation(key ops.SiteKey) error {
+	client, err := o.GetKubeClient()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	err = rigging.ConvertError(client.Core().ConfigMaps(defaults.KubeSystemNamespace).
+		Delete(constants.LogRotationConfigMap, nil))
+	if trace.IsNotFound(err) {
+		return trace.NotFound("log rotation is not configured")
+	}
+	return trace.Wrap(err)
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opsservice
+
+import (
+	"time"
+
+	"github.com/gravitational/gravity/lib/ops"
+
+	"github.com/gravitational/trace"
+	"gopkg.in/check.v1"
+)
+
+// Makes sure structured operation log entries can be filtered on retrieval
+func (s *OperationGroupSuite) TestFiltersOperationLogEntries(c *check.C) {
+	group := s.operator.getOperationGroup(s.cluster.Key())
+	key, err := group.createSiteOperation(ops.SiteOperation{
+		AccountID:  s.cluster.AccountID,
+		SiteDomain: s.cluster.Domain,
+		Type:       ops.OperationInstall,
+		State:      ops.OperationStateInstallInitiated,
+	})
+	c.Assert(err, check.IsNil)
+
+	created := time.Date(2018, time.June, 1, 12, 0, 0, 0, time.UTC)
+	entries := []ops.LogEntry{
+		{Severity: "debug", Component: ops.LogComponentEtcd, Phase: "/etcd", Message: "adding member"},
+		{Severity: "warn", Component: ops.LogComponentEtcd, Phase: "/etcd", Message: "member is unhealthy"},
+		{Severity: "error", Component: ops.LogComponentPlanet, Phase: "/wait/planet", Message: "planet is not running"},
+		{Severity: "info", Message: "operation started"},
+	}
+	for i := range entries {
+		entries[i].AccountID = key.AccountID
+		entries[i].ClusterName = key.SiteDomain
+		entries[i].OperationID = key.OperationID
+		entries[i].Created = created
+		c.Assert(s.operator.CreateLogEntry(*key, entries[i]), check.IsNil)
+	}
+
+	result, err := s.operator.GetSiteOperationLogEntries(*key, ops.LogFilter{})
+	c.Assert(err, check.IsNil)
+	c.Assert(result, check.DeepEquals, entries)
+
+	result, err = s.operator.GetSiteOperationLogEntries(*key, ops.LogFilter{
+		Component: ops.LogComponentEtcd,
+		Level:     "warn",
+	})
+	c.Assert(err, check.IsNil)
+	c.Assert(result, check.DeepEquals, entries[1:2])
+
+	result, err = s.operator.GetSiteOperationLogEntries(*key, ops.LogFilter{Phase: "/wait"})
+	c.Assert(err, check.IsNil)
+	c.Assert(result, check.DeepEquals, entries[2:3])
+
+	_, err = s.operator.GetSiteOperationLogEntries(*key, ops.LogFilter{Level: "loud"})
+	c.Assert(trace.IsBadParameter(err), check.Equals, true)
+
+}
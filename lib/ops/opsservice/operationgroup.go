@@ -131,6 +131,15 @@ func (g *operationGroup) canCreateOperation(operation ops.SiteOperation) error {
 		}
 	}
 
+	switch operation.Type {
+	case ops.OperationInstall, ops.OperationUninstall, ops.OperationShrink, ops.OperationGarbageCollect:
+		// shrink is allowed to be able to remove the quarantined node
+	default:
+		if err := g.checkQuarantinedNodes(); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+
 	return nil
 }
 
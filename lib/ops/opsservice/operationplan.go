@@ -54,3 +54,29 @@ func (o *Operator) GetOperationPlan(key ops.SiteOperationKey) (*storage.Operatio
 	}
 	return fsm.ResolvePlan(*plan, changelog), nil
 }
+
+// ApprovePlanPhase approves the execution of the operation plan phase
+// or marks the phase as requiring approval.
+// The approval is recorded in the plan changelog
+func (o *Operator) ApprovePlanPhase(req ops.ApprovePlanPhaseRequest) (*storage.PlanChange, error) {
+	if err := req.Check(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	plan, err := o.GetOperationPlan(req.SiteOperationKey)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var change *storage.PlanChange
+	if req.Require {
+		change, err = fsm.NewRequireApprovalChange(*plan, req.PhaseID)
+	} else {
+		change, err = fsm.NewApproveChange(*plan, req.PhaseID, req.User)
+	}
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := o.CreateOperationPlanChange(req.SiteOperationKey, *change); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return change, nil
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opsservice
+
+import (
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+	"gopkg.in/check.v1"
+)
+
+// Makes sure plan phases can be gated with an approval and the approver is recorded
+func (s *OperationGroupSuite) TestApprovePlanPhase(c *check.C) {
+	group := s.operator.getOperationGroup(s.cluster.Key())
+	key, err := group.createSiteOperation(ops.SiteOperation{
+		AccountID:  s.cluster.AccountID,
+		SiteDomain: s.cluster.Domain,
+		Type:       ops.OperationInstall,
+		State:      ops.OperationStateInstallInitiated,
+	})
+	c.Assert(err, check.IsNil)
+	err = s.operator.CreateOperationPlan(*key, storage.OperationPlan{
+		OperationID:   key.OperationID,
+		OperationType: ops.OperationInstall,
+		AccountID:     key.AccountID,
+		ClusterName:   key.SiteDomain,
+		Phases:        []storage.OperationPhase{{ID: "/etcd"}},
+	})
+	c.Assert(err, check.IsNil)
+
+	req := ops.ApprovePlanPhaseRequest{
+		SiteOperationKey: *key,
+		PhaseID:          "/etcd",
+		User:             "admin@example.com",
+	}
+	_, err = s.operator.ApprovePlanPhase(req)
+	c.Assert(trace.IsBadParameter(err), check.Equals, true, check.Commentf("%v", err))
+
+	req.Require = true
+	_, err = s.operator.ApprovePlanPhase(req)
+	c.Assert(err, check.IsNil)
+	plan, err := s.operator.GetOperationPlan(*key)
+	c.Assert(err, check.IsNil)
+	c.Assert(plan.Phases[0].IsAwaitingApproval(), check.Equals, true)
+
+	req.Require = false
+	change, err := s.operator.ApprovePlanPhase(req)
+	c.Assert(err, check.IsNil)
+	c.Assert(change.Approval.ApprovedBy, check.Equals, req.User)
+	plan, err = s.operator.GetOperationPlan(*key)
+	c.Assert(err, check.IsNil)
+	c.Assert(plan.Phases[0].IsAwaitingApproval(), check.Equals, false)
+	c.Assert(plan.Phases[0].Approval.ApprovedBy, check.Equals, req.User)
+}
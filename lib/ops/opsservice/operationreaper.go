@@ -0,0 +1,81 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opsservice
+
+import (
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/rigging"
+	"github.com/gravitational/trace"
+)
+
+// GetOperationReaper returns the operation reaper configuration
+func (o *Operator) GetOperationReaper(key ops.SiteKey) (storage.OperationReaper, error) {
+	client, err := o.GetKubeClient()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	data, err := getConfigMap(client.Core().ConfigMaps(defaults.KubeSystemNamespace),
+		constants.OperationReaperConfigMap)
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil, trace.NotFound("operation reaper is not configured")
+		}
+		return nil, trace.Wrap(err)
+	}
+
+	return storage.UnmarshalOperationReaper([]byte(data))
+}
+
+// UpsertOperationReaper creates or updates the operation reaper configuration
+func (o *Operator) UpsertOperationReaper(key ops.SiteKey, config storage.OperationReaper) error {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+
+	client, err := o.GetKubeClient()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	data, err := storage.MarshalOperationReaper(config)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	return updateConfigMap(client.Core().ConfigMaps(defaults.KubeSystemNamespace),
+		constants.OperationReaperConfigMap, defaults.KubeSystemNamespace, string(data), nil)
+}
+
+// DeleteOperationReaper deletes the operation reaper configuration
+func (o *Operator) DeleteOperationReaper(key ops.SiteKey) error {
+	client, err := o.GetKubeClient()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	err = rigging.ConvertError(client.Core().ConfigMaps(defaults.KubeSystemNamespace).
+		Delete(constants.OperationReaperConfigMap, nil))
+	if trace.IsNotFound(err) {
+		return trace.NotFound("operation reaper is not configured")
+	}
+	return trace.Wrap(err)
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opsservice
+
+import (
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/rigging"
+	"github.com/gravitational/trace"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubelabels "k8s.io/apimachinery/pkg/labels"
+)
+
+// GetPackageRetentionPolicies returns the list of configured package retention policies
+func (o *Operator) GetPackageRetentionPolicies(key ops.SiteKey) ([]storage.PackageRetentionPolicy, error) {
+	client, err := o.GetKubeClient()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	options := metav1.ListOptions{
+		LabelSelector: kubelabels.Set{constants.PackageRetentionLabel: "true"}.String(),
+	}
+	configmaps, err := client.Core().ConfigMaps(defaults.KubeSystemNamespace).List(options)
+	if err != nil {
+		return nil, trace.Wrap(rigging.ConvertError(err))
+	}
+
+	policies := make([]storage.PackageRetentionPolicy, 0, len(configmaps.Items))
+	for _, config := range configmaps.Items {
+		data, ok := config.Data[constants.ResourceSpecKey]
+		if !ok {
+			continue
+		}
+		policy, err := storage.UnmarshalPackageRetentionPolicy([]byte(data))
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
+// UpsertPackageRetentionPolicy creates or updates the specified package retention policy.
+// A repository can only be governed by a single policy
+func (o *Operator) UpsertPackageRetentionPolicy(key ops.SiteKey, policy storage.PackageRetentionPolicy) error {
+	if err := policy.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+
+	policies, err := o.GetPackageRetentionPolicies(key)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	for _, existing := range policies {
+		if existing.GetName() != policy.GetName() && existing.GetRepository() == policy.GetRepository() {
+			return trace.AlreadyExists("repository %q already has retention policy %q",
+				policy.GetRepository(), existing.GetName())
+		}
+	}
+
+	client, err := o.GetKubeClient()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	data, err := storage.MarshalPackageRetentionPolicy(policy)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	labels := map[string]string{
+		constants.PackageRetentionLabel: "true",
+	}
+	return updateConfigMap(client.Core().ConfigMaps(defaults.KubeSystemNamespace),
+		packageRetentionConfigMap(policy.GetName()), defaults.KubeSystemNamespace, string(data), labels)
+}
+
+// DeletePackageRetentionPolicy deletes the package retention policy specified with name
+func (o *Operator) DeletePackageRetentionPolicy(key ops.SiteKey, name string) error {
+	client, err := o.GetKubeClient()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	err = rigging.ConvertError(client.Core().ConfigMaps(defaults.KubeSystemNamespace).
+		Delete(packageRetentionConfigMap(name), nil))
+	if trace.IsNotFound(err) {
+		return trace.NotFound("package retention policy %q not found", name)
+	}
+	return trace.Wrap(err)
+}
+
+func packageRetentionConfigMap(name string) string {
+	return constants.PackageRetentionConfigMapPrefix + name
+}
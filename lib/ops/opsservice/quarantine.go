@@ -0,0 +1,125 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opsservice
+
+import (
+	"encoding/json"
+
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// GetQuarantinedNodes returns the list of nodes quarantined for flapping
+func (o *Operator) GetQuarantinedNodes(key ops.SiteKey) (storage.QuarantinedNodes, error) {
+	client, err := o.GetKubeClient()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	data, err := getConfigMap(client.Core().ConfigMaps(defaults.KubeSystemNamespace),
+		constants.QuarantinedNodesConfigMap)
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, trace.Wrap(err)
+	}
+
+	var nodes storage.QuarantinedNodes
+	if err := json.Unmarshal([]byte(data), &nodes); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return nodes, nil
+}
+
+// QuarantineNode quarantines the specified node so that no operations
+// are scheduled while it is flapping
+func (o *Operator) QuarantineNode(key ops.SiteKey, node storage.QuarantinedNode) error {
+	if node.AdvertiseIP == "" {
+		return trace.BadParameter("missing node advertise address")
+	}
+
+	nodes, err := o.GetQuarantinedNodes(key)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if existing := nodes.Find(node.AdvertiseIP); existing != nil {
+		*existing = node
+	} else {
+		nodes = append(nodes, node)
+	}
+	return trace.Wrap(o.updateQuarantinedNodes(nodes))
+}
+
+// ReleaseQuarantinedNode releases the node with the specified advertise
+// address from quarantine
+func (o *Operator) ReleaseQuarantinedNode(key ops.SiteKey, advertiseIP string) error {
+	nodes, err := o.GetQuarantinedNodes(key)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if nodes.Find(advertiseIP) == nil {
+		return trace.NotFound("node %v is not quarantined", advertiseIP)
+	}
+	released := make(storage.QuarantinedNodes, 0, len(nodes)-1)
+	for _, node := range nodes {
+		if node.AdvertiseIP != advertiseIP {
+			released = append(released, node)
+		}
+	}
+	return trace.Wrap(o.updateQuarantinedNodes(released))
+}
+
+func (o *Operator) updateQuarantinedNodes(nodes storage.QuarantinedNodes) error {
+	client, err := o.GetKubeClient()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	data, err := json.Marshal(nodes)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	return updateConfigMap(client.Core().ConfigMaps(defaults.KubeSystemNamespace),
+		constants.QuarantinedNodesConfigMap, defaults.KubeSystemNamespace, string(data), nil)
+}
+
+// checkQuarantinedNodes returns an error if any of the cluster nodes
+// is quarantined for flapping
+func (g *operationGroup) checkQuarantinedNodes() error {
+	nodes, err := g.operator.GetQuarantinedNodes(g.siteKey)
+	if err != nil {
+		// the quarantine is advisory so do not block operations
+		// if it cannot be queried, e.g. outside of the cluster
+		log.Warnf("Failed to query quarantined nodes: %v.", trace.DebugReport(err))
+		return nil
+	}
+	if len(nodes) == 0 {
+		return nil
+	}
+	node := nodes[0]
+	return trace.CompareFailed("node %v is quarantined since %v after %v membership "+
+		"changes, investigate and remove it from the cluster or release it with "+
+		"'gravity release-node %v'", node.AdvertiseIP, node.Since.Format(constants.HumanDateFormat),
+		node.Transitions, node.AdvertiseIP)
+}
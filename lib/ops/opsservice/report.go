@@ -171,7 +171,7 @@ func (s *site) collectDebugInfoFromServers(dir string, servers []remoteServer, r
 }
 
 func (s *site) collectDebugInfo(reportWriter report.Writer, runner *serverRunner) error {
-	w, err := reportWriter("debug-logs.tar")
+	w, err := reportWriter(report.DebugLogsFilename)
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -186,7 +186,7 @@ func (s *site) collectDebugInfo(reportWriter report.Writer, runner *serverRunner
 }
 
 func (s *site) collectKubernetesInfo(reportWriter report.Writer, runner *serverRunner) error {
-	w, err := reportWriter("k8s-logs.tar")
+	w, err := reportWriter(report.KubernetesLogsFilename)
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -243,7 +243,7 @@ func collectOperationsLogs(site site, dir string) error {
 // collectSiteInfo returns JSON-formatted site information
 func collectSiteInfo(s storage.Site) collectorFn {
 	return func(reportWriter report.Writer, site site) error {
-		w, err := reportWriter(siteInfoFilename)
+		w, err := reportWriter(report.SiteInfoFilename)
 		if err != nil {
 			return trace.Wrap(err)
 		}
@@ -312,8 +312,6 @@ func getReportWriterForServer(dir string, server remoteServer) report.Writer {
 }
 
 const (
-	// siteInfoFilename is the name of the file with JSON-dumped site
-	siteInfoFilename = "site.json"
 	// dumpHookFilename is the name of the file with dump hook output
 	dumpHookFilename = "dump-hook"
 	// opLogsFilename defines the file pattern that stores operation log for a particular
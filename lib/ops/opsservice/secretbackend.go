@@ -0,0 +1,129 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opsservice
+
+import (
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/rigging"
+	"github.com/gravitational/trace"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubelabels "k8s.io/apimachinery/pkg/labels"
+)
+
+// GetSecretBackends returns the list of configured secret backends.
+//
+// Backends are stored in Kubernetes secrets since they
+// include the credentials to access the backend
+func (o *Operator) GetSecretBackends(key ops.SiteKey, withSecrets bool) ([]storage.SecretBackend, error) {
+	client, err := o.GetKubeClient()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	options := metav1.ListOptions{
+		LabelSelector: kubelabels.Set{constants.SecretBackendLabel: "true"}.String(),
+	}
+	secrets, err := client.Core().Secrets(defaults.KubeSystemNamespace).List(options)
+	if err != nil {
+		return nil, trace.Wrap(rigging.ConvertError(err))
+	}
+
+	backends := make([]storage.SecretBackend, 0, len(secrets.Items))
+	for _, secret := range secrets.Items {
+		data, ok := secret.Data[constants.ResourceSpecKey]
+		if !ok {
+			continue
+		}
+		backend, err := storage.UnmarshalSecretBackend(data)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		if !withSecrets {
+			backend = backend.WithoutSecrets()
+		}
+		backends = append(backends, backend)
+	}
+	return backends, nil
+}
+
+// UpsertSecretBackend creates or updates the specified secret backend
+func (o *Operator) UpsertSecretBackend(key ops.SiteKey, backend storage.SecretBackend) error {
+	if err := backend.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+
+	client, err := o.GetKubeClient()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	data, err := storage.MarshalSecretBackend(backend)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretBackendSecret(backend.GetName()),
+			Namespace: defaults.KubeSystemNamespace,
+			Labels: map[string]string{
+				constants.SecretBackendLabel: "true",
+			},
+		},
+		Data: map[string][]byte{
+			constants.ResourceSpecKey: data,
+		},
+		Type: v1.SecretTypeOpaque,
+	}
+
+	secrets := client.Core().Secrets(defaults.KubeSystemNamespace)
+	_, err = secrets.Create(secret)
+	err = rigging.ConvertError(err)
+	if err == nil {
+		return nil
+	}
+	if !trace.IsAlreadyExists(err) {
+		return trace.Wrap(err)
+	}
+	_, err = secrets.Update(secret)
+	return trace.Wrap(rigging.ConvertError(err))
+}
+
+// DeleteSecretBackend deletes the secret backend specified with name.
+// The secrets already written to their targets are left intact
+func (o *Operator) DeleteSecretBackend(key ops.SiteKey, name string) error {
+	client, err := o.GetKubeClient()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	err = rigging.ConvertError(client.Core().Secrets(defaults.KubeSystemNamespace).
+		Delete(secretBackendSecret(name), nil))
+	if trace.IsNotFound(err) {
+		return trace.NotFound("secret backend %q not found", name)
+	}
+	return trace.Wrap(err)
+}
+
+func secretBackendSecret(name string) string {
+	return constants.SecretBackendSecretPrefix + name
+}
@@ -954,6 +954,44 @@ func (o *Operator) CreateClusterGarbageCollectOperation(r ops.CreateClusterGarba
 	return key, nil
 }
 
+// CreateClusterRollingRestartOperation creates a new rolling restart operation in the cluster
+func (o *Operator) CreateClusterRollingRestartOperation(r ops.CreateClusterRollingRestartOperationRequest) (*ops.SiteOperationKey, error) {
+	err := r.Check()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	cluster, err := o.openSite(ops.SiteKey{AccountID: r.AccountID, SiteDomain: r.ClusterName})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	key, err := cluster.createRollingRestartOperation(r)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return key, nil
+}
+
+// CreateClusterDockerMigrationOperation creates a new docker storage driver migration operation in the cluster
+func (o *Operator) CreateClusterDockerMigrationOperation(r ops.CreateClusterDockerMigrationOperationRequest) (*ops.SiteOperationKey, error) {
+	err := r.Check()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	cluster, err := o.openSite(ops.SiteKey{AccountID: r.AccountID, SiteDomain: r.ClusterName})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	key, err := cluster.createDockerMigrationOperation(r)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return key, nil
+}
+
 func (o *Operator) SetOperationState(key ops.SiteOperationKey, req ops.SetOperationStateRequest) error {
 	o.Infof("%#v", req)
 	site, err := o.openSite(key.SiteKey())
@@ -1035,6 +1073,16 @@ func (o *Operator) GetSiteOperationLogs(key ops.SiteOperationKey) (io.ReadCloser
 	return site.getOperationLogs(key)
 }
 
+// GetSiteOperationLogEntries returns the structured log entries
+// of the operation that match the provided filter
+func (o *Operator) GetSiteOperationLogEntries(key ops.SiteOperationKey, filter ops.LogFilter) ([]ops.LogEntry, error) {
+	site, err := o.openSite(key.SiteKey())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return site.getOperationLogEntries(key, filter)
+}
+
 // CreateLogEntry appends the provided log entry to the operation's log file
 func (o *Operator) CreateLogEntry(key ops.SiteOperationKey, entry ops.LogEntry) error {
 	site, err := o.openSite(key.SiteKey())
@@ -1305,6 +1353,13 @@ func (o *Operator) GetAppInstaller(req ops.AppInstallerRequest) (io.ReadCloser,
 		}
 	}
 
+	if req.License != "" {
+		err = ops.VerifyLicense(o.packages(), req.License)
+		if err != nil {
+			return nil, trace.Wrap(err, "failed to validate provided license")
+		}
+	}
+
 	var cluster storage.TrustedCluster
 	if len(o.cfg.SeedConfig.TrustedClusters) != 0 {
 		cluster = o.cfg.SeedConfig.TrustedClusters[0]
@@ -1316,6 +1371,7 @@ func (o *Operator) GetAppInstaller(req ops.AppInstallerRequest) (io.ReadCloser,
 		TrustedCluster: cluster,
 		CACert:         caCert,
 		EncryptionKey:  req.EncryptionKey,
+		License:        req.License,
 	})
 }
 
@@ -543,6 +543,11 @@ func (s *site) deletePackages(server *ProvisionedServer) error {
 	}
 	for _, pkg := range serverPackages {
 		err = s.packages().DeletePackage(pkg)
+		if trace.IsCompareFailed(err) {
+			// the package is pinned or referenced by an alias
+			log.Warnf("Not deleting package %v: %v.", pkg, err)
+			continue
+		}
 		if err != nil && !trace.IsNotFound(err) {
 			return trace.Wrap(err, "failed to delete package %v", pkg)
 		}
@@ -130,6 +130,11 @@ func (s *site) operationLogPath(key ops.SiteOperationKey) string {
 	return s.siteDir(key.OperationID, fmt.Sprintf("%v.log", key.OperationID))
 }
 
+// operationJournalPath returns the path to the operation's structured journal
+func (s *site) operationJournalPath(key ops.SiteOperationKey) string {
+	return s.siteDir(key.OperationID, fmt.Sprintf("%v.journal", key.OperationID))
+}
+
 func (s *site) openFiles(filePaths ...string) ([]io.WriteCloser, error) {
 	var files []io.WriteCloser
 	for _, filePath := range filePaths {
@@ -360,6 +365,8 @@ func (s *site) systemVars(op ops.SiteOperation, variables storage.SystemVariable
 		Token:       token.Token,
 		Devmode:     s.service.cfg.Devmode || s.service.cfg.Local,
 		Docker:      variables.Docker,
+		Lightweight: variables.Lightweight,
+		BatchNodes:  variables.BatchNodes,
 	}, nil
 }
 
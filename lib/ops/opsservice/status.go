@@ -79,10 +79,13 @@ func (o *Operator) CheckSiteStatus(key ops.SiteKey) error {
 	return nil
 }
 
-// canActivate retursn true if the cluster is disabled b/c of status checks
+// canActivate retursn true if the cluster is disabled b/c of status checks.
+// Clusters degraded by the service watchdog are activated by the watchdog
+// once the failing service recovers
 func (s *site) canActivate() bool {
 	return s.backendSite.State == ops.SiteStateDegraded &&
-		s.backendSite.Reason != storage.ReasonLicenseInvalid
+		s.backendSite.Reason != storage.ReasonLicenseInvalid &&
+		s.backendSite.Reason != storage.ReasonServiceFailed
 }
 
 // checkPlanetStatus checks the cluster health using planet agents
@@ -400,9 +400,41 @@ func (s *site) checkUpdateParameters(update *pack.PackageEnvelope, provisioner s
 		return trace.Wrap(err)
 	}
 
+	if err = s.checkUpgradePath(*updateManifest); err != nil {
+		return trace.Wrap(err)
+	}
+
 	return nil
 }
 
+// checkUpgradePath verifies that the installed runtime can be upgraded
+// directly to the runtime of the update application as declared
+// by the upgrade path in the update runtime manifest
+func (s *site) checkUpgradePath(updateManifest schema.Manifest) error {
+	installedRuntime := s.app.Manifest.Base()
+	updateRuntime := updateManifest.Base()
+	if installedRuntime == nil || updateRuntime == nil {
+		return nil
+	}
+	runtimeApp, err := s.appService.GetApp(*updateRuntime)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	upgradePath := runtimeApp.Manifest.UpgradePath
+	if upgradePath == nil {
+		return nil
+	}
+	from, err := installedRuntime.SemVer()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	to, err := updateRuntime.SemVer()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(upgradePath.CheckUpgrade(*from, *to))
+}
+
 func (s *site) validateDockerConfig(updateManifest schema.Manifest) error {
 	docker := updateManifest.SystemOptions.DockerConfig()
 	if docker == nil {
@@ -0,0 +1,255 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package reaper implements the automatic cleanup of cluster operations
+// abandoned in progress, e.g. because the agent executing them crashed
+package reaper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/fsm"
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/pack"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+	"github.com/sirupsen/logrus"
+)
+
+// Config configures the operation reaper
+type Config struct {
+	// Operator is the cluster operator service
+	Operator ops.Operator
+	// Packages is the cluster package service the plans
+	// of reaped operations are archived to
+	Packages pack.PackageService
+	// Interval is how often the cluster is checked for abandoned operations
+	Interval time.Duration
+	// Clock is used to mock time in tests
+	Clock clockwork.Clock
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+}
+
+// CheckAndSetDefaults validates the configuration and sets default values
+func (r *Config) CheckAndSetDefaults() error {
+	if r.Operator == nil {
+		return trace.BadParameter("missing Operator")
+	}
+	if r.Packages == nil {
+		return trace.BadParameter("missing Packages")
+	}
+	if r.Interval == 0 {
+		r.Interval = defaults.OperationReaperInterval
+	}
+	if r.Clock == nil {
+		r.Clock = clockwork.NewRealClock()
+	}
+	if r.FieldLogger == nil {
+		r.FieldLogger = logrus.WithField(trace.Component, "reaper")
+	}
+	return nil
+}
+
+// New returns a new operation reaper
+func New(config Config) (*Reaper, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &Reaper{Config: config}, nil
+}
+
+// Reaper fails the operations that have shown no activity for longer
+// than the threshold configured with the operation reaper resource,
+// releasing the locks they hold and archiving their plans.
+//
+// An operation shows activity when it is updated, writes to its progress log
+// or changes the state of its plan phases. Install and uninstall operations
+// and operations paused before a phase awaiting approval are never reaped
+type Reaper struct {
+	Config
+}
+
+// Run checks for abandoned operations periodically until the context is canceled
+func (r *Reaper) Run(ctx context.Context) error {
+	utils.RunPeriodically(ctx, r.Interval, r.FieldLogger,
+		"reap abandoned operations", func(context.Context) error {
+			_, err := r.Reap()
+			return err
+		})
+	return nil
+}
+
+// Reap fails the abandoned operations and returns them.
+// It does nothing unless the operation reaper has been enabled
+func (r *Reaper) Reap() (reaped []ops.SiteOperation, err error) {
+	cluster, err := r.Operator.GetLocalSite()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	config, err := r.Operator.GetOperationReaper(cluster.Key())
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, trace.Wrap(err)
+	}
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if !config.IsEnabled() {
+		return nil, nil
+	}
+	operations, err := ops.GetActiveOperations(cluster.Key(), r.Operator)
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, trace.Wrap(err)
+	}
+	var errors []error
+	for _, operation := range operations {
+		ok, err := r.reap(operation, config.GetThreshold())
+		if err != nil {
+			errors = append(errors, trace.Wrap(err, "failed to reap operation %v", operation.ID))
+			continue
+		}
+		if ok {
+			reaped = append(reaped, operation)
+		}
+	}
+	return reaped, trace.NewAggregate(errors...)
+}
+
+// reap fails the specified operation if it has been abandoned
+func (r *Reaper) reap(operation ops.SiteOperation, threshold time.Duration) (bool, error) {
+	switch operation.Type {
+	case ops.OperationInstall, ops.OperationUninstall:
+		return false, nil
+	}
+	logger := r.WithField("operation", operation.ID)
+	plan, err := r.Operator.GetOperationPlan(operation.Key())
+	if err != nil && !trace.IsNotFound(err) {
+		return false, trace.Wrap(err)
+	}
+	if plan != nil && isAwaitingApproval(*plan) {
+		logger.Debug("Operation is awaiting approval.")
+		return false, nil
+	}
+	active, err := r.lastActive(operation, plan)
+	if err != nil {
+		return false, trace.Wrap(err)
+	}
+	idle := r.Clock.Now().UTC().Sub(active)
+	if idle < threshold {
+		return false, nil
+	}
+	if plan != nil {
+		if err := r.archivePlan(operation, *plan); err != nil {
+			return false, trace.Wrap(err)
+		}
+	}
+	err = r.Operator.ReleaseOperationLocks(ops.ReleaseOperationLocksRequest{
+		SiteOperationKey: operation.Key(),
+		User:             User,
+		Reason:           fmt.Sprintf("no activity for %v", idle.Round(time.Second)),
+		// the operation has already been checked for activity
+		Force: true,
+	})
+	if err != nil {
+		return false, trace.Wrap(err)
+	}
+	logger.WithField("idle", idle).Warn("Abandoned operation has been failed.")
+	return true, nil
+}
+
+// lastActive returns the time of the last activity of the operation
+func (r *Reaper) lastActive(operation ops.SiteOperation, plan *storage.OperationPlan) (time.Time, error) {
+	active := operation.Updated
+	if operation.Created.After(active) {
+		active = operation.Created
+	}
+	progress, err := r.Operator.GetSiteOperationProgress(operation.Key())
+	if err != nil && !trace.IsNotFound(err) {
+		return active, trace.Wrap(err)
+	}
+	if progress != nil && progress.Created.After(active) {
+		active = progress.Created
+	}
+	if plan != nil {
+		for _, phase := range fsm.FlattenPlan(plan) {
+			if phase.Updated.After(active) {
+				active = phase.Updated
+			}
+		}
+	}
+	return active, nil
+}
+
+// archivePlan saves the plan of the reaped operation in the cluster
+// package service. The archive is pinned so it is not pruned
+func (r *Reaper) archivePlan(operation ops.SiteOperation, plan storage.OperationPlan) error {
+	data, err := json.Marshal(plan)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	locator, err := PlanArchive(operation)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = r.Packages.UpsertPackage(*locator, bytes.NewReader(data), pack.WithLabels(
+		map[string]string{
+			pack.PurposeLabel:     pack.PurposeOperationPlan,
+			pack.OperationIDLabel: operation.ID,
+			pack.PinnedLabel:      pack.PinnedLabel,
+		}))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	r.WithField("operation", operation.ID).Infof("Archived operation plan to %v.", locator)
+	return nil
+}
+
+// PlanArchive returns the locator of the package with the archived
+// plan of the specified operation
+func PlanArchive(operation ops.SiteOperation) (*loc.Locator, error) {
+	return loc.ParseLocator(
+		fmt.Sprintf("%v/operation-plan-%v:0.0.1", operation.SiteDomain, operation.ID))
+}
+
+// isAwaitingApproval returns true if the execution of the plan
+// has been paused before a phase that requires approval
+func isAwaitingApproval(plan storage.OperationPlan) bool {
+	for _, phase := range fsm.FlattenPlan(&plan) {
+		if phase.IsAwaitingApproval() {
+			return true
+		}
+	}
+	return false
+}
+
+// User is the name recorded as the user who has released the
+// locks of the reaped operations
+const User = "operation-reaper"
@@ -0,0 +1,197 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reaper
+
+import (
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/pack"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+	"github.com/sirupsen/logrus"
+	check "gopkg.in/check.v1"
+)
+
+func TestReaper(t *testing.T) { check.TestingT(t) }
+
+type ReaperSuite struct {
+	clock clockwork.FakeClock
+}
+
+var _ = check.Suite(&ReaperSuite{})
+
+func (s *ReaperSuite) SetUpTest(c *check.C) {
+	s.clock = clockwork.NewFakeClockAt(time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC))
+}
+
+func (s *ReaperSuite) TestReapsAbandonedOperations(c *check.C) {
+	now := s.clock.Now()
+	operator := newTestOperator(true)
+	operator.add(newOperation("abandoned", ops.OperationUpdate, now.Add(-3*time.Hour)),
+		newPlan(now.Add(-2*time.Hour), false))
+	operator.add(newOperation("active", ops.OperationExpand, now.Add(-3*time.Hour)),
+		newPlan(now.Add(-10*time.Minute), false))
+	operator.add(newOperation("approval", ops.OperationUpdate, now.Add(-3*time.Hour)),
+		newPlan(now.Add(-2*time.Hour), true))
+	operator.add(newOperation("install", ops.OperationInstall, now.Add(-3*time.Hour)), nil)
+	packages := &testPackages{data: make(map[loc.Locator][]byte)}
+
+	reaper := s.newReaper(c, operator, packages)
+	reaped, err := reaper.Reap()
+	c.Assert(err, check.IsNil)
+	c.Assert(reaped, check.HasLen, 1)
+	c.Assert(reaped[0].ID, check.Equals, "abandoned")
+
+	c.Assert(operator.released, check.HasLen, 1)
+	c.Assert(operator.released[0].OperationID, check.Equals, "abandoned")
+	c.Assert(operator.released[0].User, check.Equals, User)
+	c.Assert(operator.released[0].Reason, check.Equals, "no activity for 2h0m0s")
+	c.Assert(operator.released[0].Force, check.Equals, true)
+
+	locator, err := PlanArchive(reaped[0])
+	c.Assert(err, check.IsNil)
+	c.Assert(locator.String(), check.Equals, "example.com/operation-plan-abandoned:0.0.1")
+	c.Assert(packages.data[*locator], check.NotNil)
+	c.Assert(packages.labels[pack.PurposeLabel], check.Equals, pack.PurposeOperationPlan)
+	c.Assert(packages.labels[pack.PinnedLabel], check.Equals, pack.PinnedLabel)
+}
+
+func (s *ReaperSuite) TestDoesNothingUnlessEnabled(c *check.C) {
+	operator := newTestOperator(false)
+	operator.add(newOperation("abandoned", ops.OperationUpdate, s.clock.Now().Add(-24*time.Hour)), nil)
+
+	reaped, err := s.newReaper(c, operator, &testPackages{}).Reap()
+	c.Assert(err, check.IsNil)
+	c.Assert(reaped, check.HasLen, 0)
+	c.Assert(operator.released, check.HasLen, 0)
+}
+
+func (s *ReaperSuite) newReaper(c *check.C, operator ops.Operator, packages pack.PackageService) *Reaper {
+	reaper, err := New(Config{
+		Operator:    operator,
+		Packages:    packages,
+		Clock:       s.clock,
+		FieldLogger: logrus.WithField("test", "reaper"),
+	})
+	c.Assert(err, check.IsNil)
+	return reaper
+}
+
+func newOperation(id, opType string, updated time.Time) storage.SiteOperation {
+	return storage.SiteOperation{
+		ID:         id,
+		AccountID:  "account",
+		SiteDomain: "example.com",
+		Type:       opType,
+		State:      "in_progress",
+		Created:    updated,
+		Updated:    updated,
+	}
+}
+
+func newPlan(updated time.Time, approval bool) *storage.OperationPlan {
+	plan := &storage.OperationPlan{
+		Phases: []storage.OperationPhase{
+			{ID: "/init", State: storage.OperationPhaseStateCompleted, Updated: updated},
+			{ID: "/etcd"},
+		},
+	}
+	if approval {
+		plan.Phases[1].Approval = &storage.PhaseApproval{Required: true}
+	}
+	return plan
+}
+
+func newTestOperator(enabled bool) *testOperator {
+	return &testOperator{
+		reaper: storage.NewOperationReaper(storage.OperationReaperSpecV1{Enabled: enabled}),
+		plans:  make(map[string]*storage.OperationPlan),
+	}
+}
+
+// testOperator implements the subset of the operator service used by the reaper
+type testOperator struct {
+	ops.Operator
+	reaper     storage.OperationReaper
+	operations ops.SiteOperations
+	plans      map[string]*storage.OperationPlan
+	released   []ops.ReleaseOperationLocksRequest
+}
+
+func (r *testOperator) add(operation storage.SiteOperation, plan *storage.OperationPlan) {
+	r.operations = append(r.operations, operation)
+	if plan != nil {
+		r.plans[operation.ID] = plan
+	}
+}
+
+func (r *testOperator) GetLocalSite() (*ops.Site, error) {
+	return &ops.Site{AccountID: "account", Domain: "example.com"}, nil
+}
+
+func (r *testOperator) GetOperationReaper(ops.SiteKey) (storage.OperationReaper, error) {
+	return r.reaper, nil
+}
+
+func (r *testOperator) GetSiteOperations(ops.SiteKey) (ops.SiteOperations, error) {
+	return r.operations, nil
+}
+
+func (r *testOperator) GetOperationPlan(key ops.SiteOperationKey) (*storage.OperationPlan, error) {
+	plan, ok := r.plans[key.OperationID]
+	if !ok {
+		return nil, trace.NotFound("operation %v has no plan", key.OperationID)
+	}
+	return plan, nil
+}
+
+func (r *testOperator) GetSiteOperationProgress(key ops.SiteOperationKey) (*ops.ProgressEntry, error) {
+	return nil, trace.NotFound("no progress for %v", key.OperationID)
+}
+
+func (r *testOperator) ReleaseOperationLocks(req ops.ReleaseOperationLocksRequest) error {
+	r.released = append(r.released, req)
+	return nil
+}
+
+// testPackages records the packages created by the reaper
+type testPackages struct {
+	pack.PackageService
+	data   map[loc.Locator][]byte
+	labels map[string]string
+}
+
+func (r *testPackages) UpsertPackage(locator loc.Locator, data io.Reader, options ...pack.PackageOption) (*pack.PackageEnvelope, error) {
+	bytes, err := ioutil.ReadAll(data)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var pkg storage.Package
+	for _, option := range options {
+		option(&pkg)
+	}
+	r.data[locator] = bytes
+	r.labels = pkg.RuntimeLabels
+	return &pack.PackageEnvelope{Locator: locator}, nil
+}
@@ -573,3 +573,500 @@ func (c *authGatewayCollection) WriteYAML(w io.Writer) error {
 func (c *authGatewayCollection) ToMarshal() interface{} {
 	return c.item
 }
+
+// WriteText serializes collection in human-friendly text format
+func (r dnsProviderCollection) WriteText(w io.Writer) error {
+	t := goterm.NewTable(0, 10, 5, ' ', 0)
+	common.PrintTableHeader(t, []string{"Name", "Type", "Zone", "Records"})
+	for _, provider := range r {
+		var records []string
+		for _, record := range provider.GetRecords() {
+			records = append(records, fmt.Sprintf("%v (%v)", record.Name, record.Target))
+		}
+		fmt.Fprintf(t, "%v\t%v\t%v\t%v\n", provider.GetName(), provider.GetType(),
+			provider.GetZone(), formatList(records))
+	}
+	_, err := io.WriteString(w, t.String())
+	return trace.Wrap(err)
+}
+
+// WriteJSON serializes collection into JSON format
+func (r dnsProviderCollection) WriteJSON(w io.Writer) error {
+	return utils.WriteJSON(r, w)
+}
+
+// WriteYAML serializes collection into YAML format
+func (r dnsProviderCollection) WriteYAML(w io.Writer) error {
+	return utils.WriteYAML(r, w)
+}
+
+func (r dnsProviderCollection) ToMarshal() interface{} {
+	if len(r) == 1 {
+		return r[0]
+	}
+	return r
+}
+
+// Resources returns the resources collection in the generic format
+func (r dnsProviderCollection) Resources() (resources []teleservices.UnknownResource, err error) {
+	for _, item := range r {
+		resource, err := utils.ToUnknownResource(item)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		resources = append(resources, *resource)
+	}
+	return resources, nil
+}
+
+// WriteText serializes collection in human-friendly text format
+func (r webhookCollection) WriteText(w io.Writer) error {
+	t := goterm.NewTable(0, 10, 5, ' ', 0)
+	common.PrintTableHeader(t, []string{"Name", "URL", "Events", "Signed"})
+	for _, webhook := range r {
+		events := webhook.GetEvents()
+		if len(events) == 0 {
+			events = []string{"all"}
+		}
+		fmt.Fprintf(t, "%v\t%v\t%v\t%v\n", webhook.GetName(), webhook.GetURL(),
+			formatList(events), webhook.GetSecret() != "")
+	}
+	_, err := io.WriteString(w, t.String())
+	return trace.Wrap(err)
+}
+
+// WriteJSON serializes collection into JSON format
+func (r webhookCollection) WriteJSON(w io.Writer) error {
+	return utils.WriteJSON(r, w)
+}
+
+// WriteYAML serializes collection into YAML format
+func (r webhookCollection) WriteYAML(w io.Writer) error {
+	return utils.WriteYAML(r, w)
+}
+
+func (r webhookCollection) ToMarshal() interface{} {
+	if len(r) == 1 {
+		return r[0]
+	}
+	return r
+}
+
+// Resources returns the resources collection in the generic format
+func (r webhookCollection) Resources() (resources []teleservices.UnknownResource, err error) {
+	for _, item := range r {
+		resource, err := utils.ToUnknownResource(item)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		resources = append(resources, *resource)
+	}
+	return resources, nil
+}
+
+type dnsProviderCollection []storage.DNSProvider
+
+type webhookCollection []storage.Webhook
+
+type packageRetentionCollection []storage.PackageRetentionPolicy
+
+// WriteText serializes collection in human-friendly text format
+func (r packageRetentionCollection) WriteText(w io.Writer) error {
+	t := goterm.NewTable(0, 10, 5, ' ', 0)
+	common.PrintTableHeader(t, []string{"Name", "Repository", "Keep Versions", "Max Age"})
+	for _, policy := range r {
+		keepVersions, maxAge := "-", "-"
+		if policy.GetKeepVersions() != 0 {
+			keepVersions = fmt.Sprintf("%v", policy.GetKeepVersions())
+		}
+		if policy.GetMaxAge() != 0 {
+			maxAge = policy.GetMaxAge().String()
+		}
+		fmt.Fprintf(t, "%v\t%v\t%v\t%v\n", policy.GetName(), policy.GetRepository(),
+			keepVersions, maxAge)
+	}
+	_, err := io.WriteString(w, t.String())
+	return trace.Wrap(err)
+}
+
+// WriteJSON serializes collection into JSON format
+func (r packageRetentionCollection) WriteJSON(w io.Writer) error {
+	return utils.WriteJSON(r, w)
+}
+
+// WriteYAML serializes collection into YAML format
+func (r packageRetentionCollection) WriteYAML(w io.Writer) error {
+	return utils.WriteYAML(r, w)
+}
+
+func (r packageRetentionCollection) ToMarshal() interface{} {
+	if len(r) == 1 {
+		return r[0]
+	}
+	return r
+}
+
+// Resources returns the resources collection in the generic format
+func (r packageRetentionCollection) Resources() (resources []teleservices.UnknownResource, err error) {
+	for _, item := range r {
+		resource, err := utils.ToUnknownResource(item)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		resources = append(resources, *resource)
+	}
+	return resources, nil
+}
+
+type secretBackendCollection []storage.SecretBackend
+
+// WriteText serializes collection in human-friendly text format
+func (r secretBackendCollection) WriteText(w io.Writer) error {
+	t := goterm.NewTable(0, 10, 5, ' ', 0)
+	common.PrintTableHeader(t, []string{"Name", "Type", "Refresh Interval", "Secrets"})
+	for _, backend := range r {
+		fmt.Fprintf(t, "%v\t%v\t%v\t%v\n", backend.GetName(), backend.GetType(),
+			backend.GetRefreshInterval(), len(backend.GetSecrets()))
+	}
+	_, err := io.WriteString(w, t.String())
+	return trace.Wrap(err)
+}
+
+// WriteJSON serializes collection into JSON format
+func (r secretBackendCollection) WriteJSON(w io.Writer) error {
+	return utils.WriteJSON(r, w)
+}
+
+// WriteYAML serializes collection into YAML format
+func (r secretBackendCollection) WriteYAML(w io.Writer) error {
+	return utils.WriteYAML(r, w)
+}
+
+func (r secretBackendCollection) ToMarshal() interface{} {
+	if len(r) == 1 {
+		return r[0]
+	}
+	return r
+}
+
+// Resources returns the resources collection in the generic format
+func (r secretBackendCollection) Resources() (resources []teleservices.UnknownResource, err error) {
+	for _, item := range r {
+		resource, err := utils.ToUnknownResource(item)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		resources = append(resources, *resource)
+	}
+	return resources, nil
+}
+
+type ingressControllerCollection struct {
+	item storage.IngressController
+}
+
+// Resources returns the resources collection in the generic format
+func (c *ingressControllerCollection) Resources() ([]teleservices.UnknownResource, error) {
+	resource, err := utils.ToUnknownResource(c.item)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return []teleservices.UnknownResource{*resource}, nil
+}
+
+// WriteText serializes ingress controller config in human-friendly text format
+func (c *ingressControllerCollection) WriteText(w io.Writer) error {
+	t := goterm.NewTable(0, 10, 5, ' ', 0)
+	common.PrintTableHeader(t, []string{"Type", "HTTP Port", "HTTPS Port", "Replicas", "Default Certificate"})
+	certificate := c.item.GetDefaultCertificate()
+	if certificate == "" {
+		certificate = "-"
+	}
+	fmt.Fprintf(t, "%v\t%v\t%v\t%v\t%v\n", c.item.GetType(), c.item.GetHTTPPort(),
+		c.item.GetHTTPSPort(), c.item.GetReplicas(), certificate)
+	_, err := io.WriteString(w, t.String())
+	return trace.Wrap(err)
+}
+
+// WriteJSON serializes collection into JSON format
+func (c *ingressControllerCollection) WriteJSON(w io.Writer) error {
+	return utils.WriteJSON(c, w)
+}
+
+// WriteYAML serializes collection into YAML format
+func (c *ingressControllerCollection) WriteYAML(w io.Writer) error {
+	return utils.WriteYAML(c, w)
+}
+
+// ToMarshal returns object that should be marshaled.
+func (c *ingressControllerCollection) ToMarshal() interface{} {
+	return c.item
+}
+
+type timeSyncCollection struct {
+	item storage.TimeSync
+}
+
+// Resources returns the resources collection in the generic format
+func (c *timeSyncCollection) Resources() ([]teleservices.UnknownResource, error) {
+	resource, err := utils.ToUnknownResource(c.item)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return []teleservices.UnknownResource{*resource}, nil
+}
+
+// WriteText serializes time sync config in human-friendly text format
+func (c *timeSyncCollection) WriteText(w io.Writer) error {
+	t := goterm.NewTable(0, 10, 5, ' ', 0)
+	common.PrintTableHeader(t, []string{"Servers", "Pools", "Options"})
+	fmt.Fprintf(t, "%v\t%v\t%v\n", formatList(c.item.GetServers()),
+		formatList(c.item.GetPools()), formatList(c.item.GetOptions()))
+	_, err := io.WriteString(w, t.String())
+	return trace.Wrap(err)
+}
+
+// WriteJSON serializes collection into JSON format
+func (c *timeSyncCollection) WriteJSON(w io.Writer) error {
+	return utils.WriteJSON(c, w)
+}
+
+// WriteYAML serializes collection into YAML format
+func (c *timeSyncCollection) WriteYAML(w io.Writer) error {
+	return utils.WriteYAML(c, w)
+}
+
+// ToMarshal returns object that should be marshaled.
+func (c *timeSyncCollection) ToMarshal() interface{} {
+	return c.item
+}
+
+type logRotationCollection struct {
+	item storage.LogRotation
+}
+
+// Resources returns the resources collection in the generic format
+func (c *logRotationCollection) Resources() ([]teleservices.UnknownResource, error) {
+	resource, err := utils.ToUnknownResource(c.item)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return []teleservices.UnknownResource{*resource}, nil
+}
+
+// WriteText serializes log rotation policy in human-friendly text format
+func (c *logRotationCollection) WriteText(w io.Writer) error {
+	t := goterm.NewTable(0, 10, 5, ' ', 0)
+	common.PrintTableHeader(t, []string{"Journal", "Containers"})
+	journal, containers := "-", "-"
+	if j := c.item.GetJournal(); j != nil {
+		journal = fmt.Sprintf("max use: %v, max file size: %v", j.MaxUse, j.MaxFileSize)
+		if j.MaxRetention != nil {
+			journal = fmt.Sprintf("%v, max retention: %v", journal, j.MaxRetention.Value())
+		}
+	}
+	if l := c.item.GetContainers(); l != nil {
+		containers = fmt.Sprintf("max size: %v, max files: %v", l.MaxSize, l.MaxFiles)
+	}
+	fmt.Fprintf(t, "%v\t%v\n", journal, containers)
+	_, err := io.WriteString(w, t.String())
+	return trace.Wrap(err)
+}
+
+// WriteJSON serializes collection into JSON format
+func (c *logRotationCollection) WriteJSON(w io.Writer) error {
+	return utils.WriteJSON(c, w)
+}
+
+// WriteYAML serializes collection into YAML format
+func (c *logRotationCollection) WriteYAML(w io.Writer) error {
+	return utils.WriteYAML(c, w)
+}
+
+// ToMarshal returns object that should be marshaled.
+func (c *logRotationCollection) ToMarshal() interface{} {
+	return c.item
+}
+
+type trustedCACollection struct {
+	item storage.TrustedCA
+}
+
+// Resources returns the resources collection in the generic format
+func (c *trustedCACollection) Resources() ([]teleservices.UnknownResource, error) {
+	resource, err := utils.ToUnknownResource(c.item)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return []teleservices.UnknownResource{*resource}, nil
+}
+
+// WriteText serializes trusted CA bundle in human-friendly text format
+func (c *trustedCACollection) WriteText(w io.Writer) error {
+	certs, err := c.item.ParseCertificates()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	t := goterm.NewTable(0, 10, 5, ' ', 0)
+	common.PrintTableHeader(t, []string{"Subject", "Expires", "Registries"})
+	registries := "-"
+	if len(c.item.GetRegistries()) != 0 {
+		registries = strings.Join(c.item.GetRegistries(), ", ")
+	}
+	for _, cert := range certs {
+		fmt.Fprintf(t, "%v\t%v\t%v\n", cert.Subject.CommonName,
+			cert.NotAfter.Format(constants.ShortDateFormat), registries)
+	}
+	_, err = io.WriteString(w, t.String())
+	return trace.Wrap(err)
+}
+
+// WriteJSON serializes collection into JSON format
+func (c *trustedCACollection) WriteJSON(w io.Writer) error {
+	return utils.WriteJSON(c, w)
+}
+
+// WriteYAML serializes collection into YAML format
+func (c *trustedCACollection) WriteYAML(w io.Writer) error {
+	return utils.WriteYAML(c, w)
+}
+
+// ToMarshal returns object that should be marshaled.
+func (c *trustedCACollection) ToMarshal() interface{} {
+	return c.item
+}
+
+type operationReaperCollection struct {
+	item storage.OperationReaper
+}
+
+// Resources returns the resources collection in the generic format
+func (c *operationReaperCollection) Resources() ([]teleservices.UnknownResource, error) {
+	resource, err := utils.ToUnknownResource(c.item)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return []teleservices.UnknownResource{*resource}, nil
+}
+
+// WriteText serializes operation reaper configuration in human-friendly text format
+func (c *operationReaperCollection) WriteText(w io.Writer) error {
+	t := goterm.NewTable(0, 10, 5, ' ', 0)
+	common.PrintTableHeader(t, []string{"Enabled", "Threshold"})
+	fmt.Fprintf(t, "%v\t%v\n", c.item.IsEnabled(), c.item.GetThreshold())
+	_, err := io.WriteString(w, t.String())
+	return trace.Wrap(err)
+}
+
+// WriteJSON serializes collection into JSON format
+func (c *operationReaperCollection) WriteJSON(w io.Writer) error {
+	return utils.WriteJSON(c, w)
+}
+
+// WriteYAML serializes collection into YAML format
+func (c *operationReaperCollection) WriteYAML(w io.Writer) error {
+	return utils.WriteYAML(c, w)
+}
+
+// ToMarshal returns object that should be marshaled.
+func (c *operationReaperCollection) ToMarshal() interface{} {
+	return c.item
+}
+
+type bandwidthProfileCollection struct {
+	item storage.BandwidthProfile
+}
+
+// Resources returns the resources collection in the generic format
+func (c *bandwidthProfileCollection) Resources() ([]teleservices.UnknownResource, error) {
+	resource, err := utils.ToUnknownResource(c.item)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return []teleservices.UnknownResource{*resource}, nil
+}
+
+// WriteText serializes bandwidth profile in human-friendly text format
+func (c *bandwidthProfileCollection) WriteText(w io.Writer) error {
+	t := goterm.NewTable(0, 10, 5, ' ', 0)
+	common.PrintTableHeader(t, []string{"Mode", "Heartbeat", "Inventory", "Event Batching", "Compress Inventory", "Package Pulls"})
+	pullWindow := "any time"
+	if window := c.item.GetPackagePullWindow(); window != nil {
+		pullWindow = window.String() + " UTC"
+	}
+	eventBatching := "off"
+	if interval := c.item.GetEventBatchInterval(); interval != 0 {
+		eventBatching = interval.String()
+	}
+	fmt.Fprintf(t, "%v\t%v\t%v\t%v\t%v\t%v\n", c.item.GetMode(),
+		c.item.GetHeartbeatInterval(), c.item.GetInventoryInterval(),
+		eventBatching, c.item.GetCompressInventory(), pullWindow)
+	_, err := io.WriteString(w, t.String())
+	return trace.Wrap(err)
+}
+
+// WriteJSON serializes collection into JSON format
+func (c *bandwidthProfileCollection) WriteJSON(w io.Writer) error {
+	return utils.WriteJSON(c, w)
+}
+
+// WriteYAML serializes collection into YAML format
+func (c *bandwidthProfileCollection) WriteYAML(w io.Writer) error {
+	return utils.WriteYAML(c, w)
+}
+
+// ToMarshal returns object that should be marshaled.
+func (c *bandwidthProfileCollection) ToMarshal() interface{} {
+	return c.item
+}
+
+type appOverlayCollection []storage.AppOverlay
+
+// WriteText serializes application overlays in human-friendly text format
+func (r appOverlayCollection) WriteText(w io.Writer) error {
+	t := goterm.NewTable(0, 10, 5, ' ', 0)
+	common.PrintTableHeader(t, []string{"Application", "Resources", "Patches"})
+	for _, overlay := range r {
+		var patches []string
+		for _, patch := range overlay.GetPatches() {
+			patches = append(patches, patch.String())
+		}
+		resources := "no"
+		if overlay.GetResources() != "" {
+			resources = "yes"
+		}
+		fmt.Fprintf(t, "%v\t%v\t%v\n", overlay.GetName(), resources, formatList(patches))
+	}
+	_, err := io.WriteString(w, t.String())
+	return trace.Wrap(err)
+}
+
+// WriteJSON serializes collection into JSON format
+func (r appOverlayCollection) WriteJSON(w io.Writer) error {
+	return utils.WriteJSON(r, w)
+}
+
+// WriteYAML serializes collection into YAML format
+func (r appOverlayCollection) WriteYAML(w io.Writer) error {
+	return utils.WriteYAML(r, w)
+}
+
+// ToMarshal returns object that should be marshaled.
+func (r appOverlayCollection) ToMarshal() interface{} {
+	if len(r) == 1 {
+		return r[0]
+	}
+	return r
+}
+
+// Resources returns the resources collection in the generic format
+func (r appOverlayCollection) Resources() (resources []teleservices.UnknownResource, err error) {
+	for _, item := range r {
+		resource, err := utils.ToUnknownResource(item)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		resources = append(resources, *resource)
+	}
+	return resources, nil
+}
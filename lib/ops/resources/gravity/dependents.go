@@ -0,0 +1,103 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gravity
+
+import (
+	"strings"
+
+	"github.com/gravitational/gravity/lib/ops/resources"
+	"github.com/gravitational/gravity/lib/storage"
+
+	teleservices "github.com/gravitational/teleport/lib/services"
+	"github.com/gravitational/trace"
+)
+
+// Dependents returns objects that will be affected if the resource
+// specified with req is removed
+func (r *Resources) Dependents(req resources.RemoveRequest) ([]resources.Dependent, error) {
+	if err := req.Check(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	switch req.Kind {
+	case teleservices.KindGithubConnector:
+		return r.connectorDependents(req.Name)
+	case teleservices.KindUser, "users":
+		return r.userDependents(req.Name)
+	}
+	return nil, nil
+}
+
+// connectorDependents returns users that log in via the connector with
+// the specified name as well as the authentication preference that
+// refers to it
+func (r *Resources) connectorDependents(name string) (dependents []resources.Dependent, err error) {
+	users, err := r.Operator.GetUsers(r.cluster.Key())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	for _, user := range users {
+		for _, identity := range user.GetGithubIdentities() {
+			if identity.ConnectorID == name {
+				dependents = append(dependents, resources.Dependent{
+					Kind:   teleservices.KindUser,
+					Name:   user.GetName(),
+					Reason: "authenticates via this connector",
+				})
+				break
+			}
+		}
+	}
+	preference, err := r.Operator.GetClusterAuthPreference(r.cluster.Key())
+	if err != nil && !trace.IsNotFound(err) {
+		return nil, trace.Wrap(err)
+	}
+	if preference != nil && preference.GetConnectorName() == name {
+		dependents = append(dependents, resources.Dependent{
+			Kind:   teleservices.KindClusterAuthPreference,
+			Name:   teleservices.MetaNameClusterAuthPreference,
+			Reason: "uses this connector as the default authentication connector",
+		})
+	}
+	return dependents, nil
+}
+
+// userDependents returns API tokens that will be revoked together
+// with the user with the specified name
+func (r *Resources) userDependents(name string) (dependents []resources.Dependent, err error) {
+	keys, err := r.Operator.GetAPIKeys(name)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	for _, key := range keys {
+		dependents = append(dependents, resources.Dependent{
+			Kind:   storage.KindToken,
+			Name:   maskToken(key.Token),
+			Reason: "will be revoked together with the user",
+		})
+	}
+	return dependents, nil
+}
+
+// maskToken hides all but the first few characters of the token so
+// reports do not leak secrets
+func maskToken(token string) string {
+	const visible = 4
+	if len(token) <= visible {
+		return strings.Repeat("*", len(token))
+	}
+	return token[:visible] + strings.Repeat("*", len(token)-visible)
+}
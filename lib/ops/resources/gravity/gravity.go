@@ -17,6 +17,7 @@ limitations under the License.
 package gravity
 
 import (
+	"github.com/gravitational/gravity/lib/appoverlay"
 	"github.com/gravitational/gravity/lib/localenv"
 	"github.com/gravitational/gravity/lib/modules"
 	"github.com/gravitational/gravity/lib/ops"
@@ -221,6 +222,149 @@ func (r *Resources) Create(req resources.CreateRequest) error {
 			return trace.Wrap(err)
 		}
 		r.Println("Updated auth gateway configuration")
+	case storage.KindDNSProvider:
+		provider, err := storage.UnmarshalDNSProvider(req.Resource.Raw)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if err := provider.CheckAndSetDefaults(); err != nil {
+			return trace.Wrap(err)
+		}
+		err = r.Operator.UpsertDNSProvider(r.cluster.Key(), provider)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		r.Printf("Updated DNS provider %q\n", provider.GetName())
+	case storage.KindWebhook:
+		webhook, err := storage.UnmarshalWebhook(req.Resource.Raw)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if err := webhook.CheckAndSetDefaults(); err != nil {
+			return trace.Wrap(err)
+		}
+		err = r.Operator.UpsertWebhook(r.cluster.Key(), webhook)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		r.Printf("Updated webhook %q\n", webhook.GetName())
+	case storage.KindPackageRetention:
+		policy, err := storage.UnmarshalPackageRetentionPolicy(req.Resource.Raw)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if err := policy.CheckAndSetDefaults(); err != nil {
+			return trace.Wrap(err)
+		}
+		err = r.Operator.UpsertPackageRetentionPolicy(r.cluster.Key(), policy)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		r.Printf("Updated package retention policy %q\n", policy.GetName())
+	case storage.KindSecretBackend:
+		backend, err := storage.UnmarshalSecretBackend(req.Resource.Raw)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if err := backend.CheckAndSetDefaults(); err != nil {
+			return trace.Wrap(err)
+		}
+		err = r.Operator.UpsertSecretBackend(r.cluster.Key(), backend)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		r.Printf("Updated secret backend %q\n", backend.GetName())
+	case storage.KindIngressController:
+		controller, err := storage.UnmarshalIngressController(req.Resource.Raw)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if err := controller.CheckAndSetDefaults(); err != nil {
+			return trace.Wrap(err)
+		}
+		err = r.Operator.UpsertIngressController(r.cluster.Key(), controller)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		r.Println("Updated ingress controller configuration")
+	case storage.KindTimeSync:
+		config, err := storage.UnmarshalTimeSync(req.Resource.Raw)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if err := config.CheckAndSetDefaults(); err != nil {
+			return trace.Wrap(err)
+		}
+		err = r.Operator.UpsertTimeSync(r.cluster.Key(), config)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		r.Println("Updated time sync configuration")
+	case storage.KindLogRotation:
+		policy, err := storage.UnmarshalLogRotation(req.Resource.Raw)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if err := policy.CheckAndSetDefaults(); err != nil {
+			return trace.Wrap(err)
+		}
+		err = r.Operator.UpsertLogRotation(r.cluster.Key(), policy)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		r.Println("Updated log rotation policy")
+	case storage.KindOperationReaper:
+		reaper, err := storage.UnmarshalOperationReaper(req.Resource.Raw)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if err := reaper.CheckAndSetDefaults(); err != nil {
+			return trace.Wrap(err)
+		}
+		err = r.Operator.UpsertOperationReaper(r.cluster.Key(), reaper)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		r.Println("Updated operation reaper configuration")
+	case storage.KindTrustedCA:
+		bundle, err := storage.UnmarshalTrustedCA(req.Resource.Raw)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if err := bundle.CheckAndSetDefaults(); err != nil {
+			return trace.Wrap(err)
+		}
+		err = r.Operator.UpsertTrustedCA(r.cluster.Key(), bundle)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		r.Println("Updated trusted CA bundle")
+	case storage.KindBandwidthProfile:
+		profile, err := storage.UnmarshalBandwidthProfile(req.Resource.Raw)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if err := profile.CheckAndSetDefaults(); err != nil {
+			return trace.Wrap(err)
+		}
+		err = r.Operator.UpsertBandwidthProfile(r.cluster.Key(), profile)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		r.Printf("Updated bandwidth profile, mode: %v\n", profile.GetMode())
+	case storage.KindAppOverlay:
+		overlay, err := storage.UnmarshalAppOverlay(req.Resource.Raw)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if err := appoverlay.Check(overlay); err != nil {
+			return trace.Wrap(err)
+		}
+		err = r.Operator.UpsertAppOverlay(r.cluster.Key(), overlay)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		r.Printf("Updated overlay of application %q\n", overlay.GetName())
 	case "":
 		return trace.BadParameter("missing resource kind")
 	default:
@@ -361,6 +505,145 @@ func (r *Resources) GetCollection(req resources.ListRequest) (resources.Collecti
 			return nil, trace.Wrap(err)
 		}
 		return alertTargetCollection(alertTargets), nil
+	case storage.KindDNSProvider, "dnsproviders":
+		providers, err := r.Operator.GetDNSProviders(r.cluster.Key())
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		var filtered []storage.DNSProvider
+		if req.Name != "" {
+			for i := range providers {
+				if providers[i].GetName() == req.Name {
+					filtered = append(filtered, providers[i])
+					break
+				}
+			}
+			if len(filtered) == 0 {
+				return nil, trace.NotFound("DNS provider %q is not found", req.Name)
+			}
+		} else {
+			filtered = providers
+		}
+		return dnsProviderCollection(filtered), nil
+	case storage.KindWebhook, "webhooks":
+		webhooks, err := r.Operator.GetWebhooks(r.cluster.Key())
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		var filtered []storage.Webhook
+		if req.Name != "" {
+			for i := range webhooks {
+				if webhooks[i].GetName() == req.Name {
+					filtered = append(filtered, webhooks[i])
+					break
+				}
+			}
+			if len(filtered) == 0 {
+				return nil, trace.NotFound("webhook %q is not found", req.Name)
+			}
+		} else {
+			filtered = webhooks
+		}
+		return webhookCollection(filtered), nil
+	case storage.KindPackageRetention, "packageretentions":
+		policies, err := r.Operator.GetPackageRetentionPolicies(r.cluster.Key())
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		var filtered []storage.PackageRetentionPolicy
+		if req.Name != "" {
+			for i := range policies {
+				if policies[i].GetName() == req.Name {
+					filtered = append(filtered, policies[i])
+					break
+				}
+			}
+			if len(filtered) == 0 {
+				return nil, trace.NotFound("package retention policy %q is not found", req.Name)
+			}
+		} else {
+			filtered = policies
+		}
+		return packageRetentionCollection(filtered), nil
+	case storage.KindSecretBackend, "secretbackends":
+		backends, err := r.Operator.GetSecretBackends(r.cluster.Key(), req.WithSecrets)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		var filtered []storage.SecretBackend
+		if req.Name != "" {
+			for i := range backends {
+				if backends[i].GetName() == req.Name {
+					filtered = append(filtered, backends[i])
+					break
+				}
+			}
+			if len(filtered) == 0 {
+				return nil, trace.NotFound("secret backend %q is not found", req.Name)
+			}
+		} else {
+			filtered = backends
+		}
+		return secretBackendCollection(filtered), nil
+	case storage.KindIngressController, "ingresscontrollers", "ingress":
+		controller, err := r.Operator.GetIngressController(r.cluster.Key())
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		return &ingressControllerCollection{controller}, nil
+	case storage.KindTimeSync, "ntp":
+		config, err := r.Operator.GetTimeSync(r.cluster.Key())
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		return &timeSyncCollection{config}, nil
+	case storage.KindLogRotation, "logs":
+		policy, err := r.Operator.GetLogRotation(r.cluster.Key())
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		return &logRotationCollection{policy}, nil
+	case storage.KindOperationReaper, "reaper":
+		reaper, err := r.Operator.GetOperationReaper(r.cluster.Key())
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		return &operationReaperCollection{reaper}, nil
+	case storage.KindTrustedCA:
+		bundle, err := r.Operator.GetTrustedCA(r.cluster.Key())
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		return &trustedCACollection{bundle}, nil
+	case storage.KindBandwidthProfile, "bandwidth":
+		profile, err := r.Operator.GetBandwidthProfile(r.cluster.Key())
+		if err != nil {
+			if !trace.IsNotFound(err) {
+				return nil, trace.Wrap(err)
+			}
+			profile = storage.DefaultBandwidthProfile()
+		}
+		return &bandwidthProfileCollection{profile}, nil
+	case storage.KindAppOverlay, "appoverlays", "overlays":
+		overlays, err := r.Operator.GetAppOverlays(r.cluster.Key())
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		var filtered []storage.AppOverlay
+		if req.Name != "" {
+			for i := range overlays {
+				if overlays[i].GetName() == req.Name {
+					filtered = append(filtered, overlays[i])
+					break
+				}
+			}
+			if len(filtered) == 0 {
+				return nil, trace.NotFound("overlay of application %q is not found", req.Name)
+			}
+		} else {
+			filtered = overlays
+		}
+		return appOverlayCollection(filtered), nil
 	}
 	return nil, trace.BadParameter("unsupported resource %q, supported are: %v",
 		req.Kind, modules.Get().SupportedResources())
@@ -442,6 +725,94 @@ func (r *Resources) Remove(req resources.RemoveRequest) error {
 			return trace.Wrap(err)
 		}
 		r.Println("Alert target has been deleted")
+	case storage.KindDNSProvider, "dnsproviders":
+		if err := r.Operator.DeleteDNSProvider(r.cluster.Key(), req.Name); err != nil {
+			if trace.IsNotFound(err) && req.Force {
+				return nil
+			}
+			return trace.Wrap(err)
+		}
+		r.Printf("DNS provider %q has been deleted\n", req.Name)
+	case storage.KindWebhook, "webhooks":
+		if err := r.Operator.DeleteWebhook(r.cluster.Key(), req.Name); err != nil {
+			if trace.IsNotFound(err) && req.Force {
+				return nil
+			}
+			return trace.Wrap(err)
+		}
+		r.Printf("Webhook %q has been deleted\n", req.Name)
+	case storage.KindPackageRetention, "packageretentions":
+		if err := r.Operator.DeletePackageRetentionPolicy(r.cluster.Key(), req.Name); err != nil {
+			if trace.IsNotFound(err) && req.Force {
+				return nil
+			}
+			return trace.Wrap(err)
+		}
+		r.Printf("Package retention policy %q has been deleted\n", req.Name)
+	case storage.KindSecretBackend, "secretbackends":
+		if err := r.Operator.DeleteSecretBackend(r.cluster.Key(), req.Name); err != nil {
+			if trace.IsNotFound(err) && req.Force {
+				return nil
+			}
+			return trace.Wrap(err)
+		}
+		r.Printf("Secret backend %q has been deleted\n", req.Name)
+	case storage.KindIngressController, "ingresscontrollers", "ingress":
+		if err := r.Operator.DeleteIngressController(r.cluster.Key()); err != nil {
+			if trace.IsNotFound(err) && req.Force {
+				return nil
+			}
+			return trace.Wrap(err)
+		}
+		r.Println("Ingress controller configuration has been deleted")
+	case storage.KindTimeSync, "ntp":
+		if err := r.Operator.DeleteTimeSync(r.cluster.Key()); err != nil {
+			if trace.IsNotFound(err) && req.Force {
+				return nil
+			}
+			return trace.Wrap(err)
+		}
+		r.Println("Time sync configuration has been deleted")
+	case storage.KindLogRotation, "logs":
+		if err := r.Operator.DeleteLogRotation(r.cluster.Key()); err != nil {
+			if trace.IsNotFound(err) && req.Force {
+				return nil
+			}
+			return trace.Wrap(err)
+		}
+		r.Println("Log rotation policy has been deleted")
+	case storage.KindOperationReaper, "reaper":
+		if err := r.Operator.DeleteOperationReaper(r.cluster.Key()); err != nil {
+			if trace.IsNotFound(err) && req.Force {
+				return nil
+			}
+			return trace.Wrap(err)
+		}
+		r.Println("Operation reaper configuration has been deleted")
+	case storage.KindTrustedCA:
+		if err := r.Operator.DeleteTrustedCA(r.cluster.Key()); err != nil {
+			if trace.IsNotFound(err) && req.Force {
+				return nil
+			}
+			return trace.Wrap(err)
+		}
+		r.Println("Trusted CA bundle has been deleted")
+	case storage.KindBandwidthProfile, "bandwidth":
+		if err := r.Operator.DeleteBandwidthProfile(r.cluster.Key()); err != nil {
+			if trace.IsNotFound(err) && req.Force {
+				return nil
+			}
+			return trace.Wrap(err)
+		}
+		r.Println("Bandwidth profile has been deleted")
+	case storage.KindAppOverlay, "appoverlays", "overlays":
+		if err := r.Operator.DeleteAppOverlay(r.cluster.Key(), req.Name); err != nil {
+			if trace.IsNotFound(err) && req.Force {
+				return nil
+			}
+			return trace.Wrap(err)
+		}
+		r.Printf("Overlay of application %q has been deleted\n", req.Name)
 	default:
 		return trace.BadParameter("unsupported resource %q, supported are: %v",
 			req.Kind, modules.Get().SupportedResourcesToRemove())
@@ -120,6 +120,23 @@ func (s *GravityResourcesSuite) TestToken(c *check.C) {
 	c.Assert(err, check.FitsTypeOf, trace.NotFound(""))
 }
 
+func (s *GravityResourcesSuite) TestUserDependents(c *check.C) {
+	token := storage.NewToken("dependent-token", s.s.Creds.Email)
+	err := s.r.Create(resources.CreateRequest{Resource: toUnknown(c, token)})
+	c.Assert(err, check.IsNil)
+	defer s.r.Remove(resources.RemoveRequest{Kind: "token", Name: "dependent-token", User: s.s.Creds.Email})
+
+	dependents, err := s.r.Dependents(resources.RemoveRequest{Kind: "user", Name: s.s.Creds.Email})
+	c.Assert(err, check.IsNil)
+	var names []string
+	for _, dependent := range dependents {
+		c.Assert(dependent.Kind, check.Equals, storage.KindToken)
+		names = append(names, dependent.Name)
+	}
+	c.Assert(utils.StringInSlice(names, "depe***********"), check.Equals, true,
+		check.Commentf("expected masked token in %v", names))
+}
+
 func toUnknown(c *check.C, resource teleservices.Resource) teleservices.UnknownResource {
 	unknown, err := utils.ToUnknownResource(resource)
 	c.Assert(err, check.IsNil)
@@ -17,6 +17,8 @@ limitations under the License.
 package resources
 
 import (
+	"bytes"
+	"fmt"
 	"io"
 
 	"github.com/gravitational/gravity/lib/constants"
@@ -85,7 +87,8 @@ type RemoveRequest struct {
 	Kind string
 	// Name is name of the resource
 	Name string
-	// Force is whether to suppress not found errors
+	// Force is whether to suppress not found errors and remove
+	// the resource even if it has dependents
 	Force bool
 	// User is the resource owner
 	User string
@@ -102,6 +105,29 @@ func (r RemoveRequest) Check() error {
 	return nil
 }
 
+// DependencyAnalyzer is implemented by resource controllers that can
+// find objects depending on a resource before it is removed
+type DependencyAnalyzer interface {
+	// Dependents returns objects that will be affected if the specified
+	// resource is removed
+	Dependents(RemoveRequest) ([]Dependent, error)
+}
+
+// Dependent describes an object that depends on a resource
+type Dependent struct {
+	// Kind is the kind of the dependent object, e.g. "user"
+	Kind string
+	// Name is the name of the dependent object
+	Name string
+	// Reason describes how the object depends on the resource
+	Reason string
+}
+
+// String returns a human-readable description of the dependent
+func (d Dependent) String() string {
+	return fmt.Sprintf("%v %q: %v", d.Kind, d.Name, d.Reason)
+}
+
 // Collection represents printable collection of resources
 // that can serialize itself into various format
 type Collection interface {
@@ -174,16 +200,75 @@ func (r *ResourceControl) Get(w io.Writer, kind, name string, withSecrets bool,
 		format, constants.OutputFormats)
 }
 
-// Remove removes the specified resource
+// Remove removes the specified resource.
+//
+// If the resource has dependents, it is only removed when force is set
 func (r *ResourceControl) Remove(kind, name string, force bool, user string) error {
-	err := r.Resources.Remove(RemoveRequest{
+	req := RemoveRequest{
 		Kind:  kind,
 		Name:  name,
 		Force: force,
 		User:  user,
-	})
+	}
+	if !force {
+		dependents, err := r.dependents(req)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if len(dependents) != 0 {
+			return trace.CompareFailed("%v %q has dependents that will break if it is removed:\n%v"+
+				"Use --force to remove it anyway", kind, name, formatDependents(dependents))
+		}
+	}
+	err := r.Resources.Remove(req)
 	if err != nil {
 		return trace.Wrap(err)
 	}
 	return nil
 }
+
+// DryRun outputs the report of objects that depend on the specified
+// resource without removing it
+func (r *ResourceControl) DryRun(w io.Writer, kind, name string, user string) error {
+	req := RemoveRequest{
+		Kind: kind,
+		Name: name,
+		User: user,
+	}
+	if err := req.Check(); err != nil {
+		return trace.Wrap(err)
+	}
+	dependents, err := r.dependents(req)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if len(dependents) == 0 {
+		_, err = fmt.Fprintf(w, "%v %q has no dependents and can be removed.\n", kind, name)
+		return trace.Wrap(err)
+	}
+	_, err = fmt.Fprintf(w, "Removing %v %q will affect:\n%v", kind, name, formatDependents(dependents))
+	return trace.Wrap(err)
+}
+
+// dependents returns objects depending on the resource specified with req.
+// Resource controllers that do not implement DependencyAnalyzer are
+// assumed to have no dependents
+func (r *ResourceControl) dependents(req RemoveRequest) ([]Dependent, error) {
+	analyzer, ok := r.Resources.(DependencyAnalyzer)
+	if !ok {
+		return nil, nil
+	}
+	dependents, err := analyzer.Dependents(req)
+	if err != nil && !trace.IsNotFound(err) {
+		return nil, trace.Wrap(err)
+	}
+	return dependents, nil
+}
+
+func formatDependents(dependents []Dependent) string {
+	var buf bytes.Buffer
+	for _, dependent := range dependents {
+		fmt.Fprintf(&buf, "  * %v\n", dependent)
+	}
+	return buf.String()
+}
@@ -63,6 +63,56 @@ kind1/resource3
 `)
 }
 
+func (s *ResourceControlSuite) TestRemoveRequiresForceWithDependents(c *check.C) {
+	control := NewControl(&dependentResources{
+		testResources: &testResources{},
+		dependents: map[string][]Dependent{
+			"connector/github": {{Kind: "user", Name: "alice", Reason: "authenticates via connector"}},
+		},
+	})
+	err := control.Create(strings.NewReader(`
+kind: connector
+metadata:
+  name: github
+---
+kind: connector
+metadata:
+  name: oidc
+`), false, "")
+	c.Assert(err, check.IsNil)
+
+	w := &bytes.Buffer{}
+	err = control.DryRun(w, "connector", "github", "")
+	c.Assert(err, check.IsNil)
+	c.Assert(w.String(), check.Equals, `Removing connector "github" will affect:
+  * user "alice": authenticates via connector
+`)
+
+	err = control.Remove("connector", "github", false, "")
+	c.Assert(trace.IsCompareFailed(err), check.Equals, true, check.Commentf("unexpected error: %v", err))
+
+	err = control.Remove("connector", "oidc", false, "")
+	c.Assert(err, check.IsNil)
+
+	err = control.Remove("connector", "github", true, "")
+	c.Assert(err, check.IsNil)
+
+	w.Reset()
+	err = control.Get(w, "", "", false, "text", "")
+	c.Assert(err, check.IsNil)
+	c.Assert(w.String(), check.Equals, "")
+}
+
+// dependentResources reports preconfigured dependents for resources
+type dependentResources struct {
+	*testResources
+	dependents map[string][]Dependent
+}
+
+func (r *dependentResources) Dependents(req RemoveRequest) ([]Dependent, error) {
+	return r.dependents[fmt.Sprintf("%v/%v", req.Kind, req.Name)], nil
+}
+
 // testResources keeps created resources in memory
 type testResources struct {
 	resources []teleservices.UnknownResource
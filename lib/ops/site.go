@@ -75,6 +75,7 @@ func ConvertOpsSite(in Site) storage.Site {
 			Name:          in.App.PackageEnvelope.Locator.Name,
 			Version:       in.App.PackageEnvelope.Locator.Version,
 			SHA512:        in.App.PackageEnvelope.SHA512,
+			SHA256:        in.App.PackageEnvelope.SHA256,
 			SizeBytes:     int(in.App.PackageEnvelope.SizeBytes),
 			Created:       in.App.PackageEnvelope.Created,
 			CreatedBy:     in.App.PackageEnvelope.CreatedBy,
@@ -195,6 +195,12 @@ func GetActiveOperationsByType(key SiteKey, operator Operator, opType string) (r
 	return result, nil
 }
 
+// OperationVerb returns the verb that controls access to operations
+// of the specified type in role rules, e.g. "expand" for expand operations
+func OperationVerb(operationType string) string {
+	return strings.TrimPrefix(operationType, "operation_")
+}
+
 // MatchOperation returns an operation that matches given match function.
 // Returns trace.NotFound if no operation matches
 func MatchOperation(siteKey SiteKey, operator Operator, match OperationMatcher) (op *SiteOperation, progress *ProgressEntry, err error) {
@@ -0,0 +1,285 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package opsapi is a self-contained Go client for the gravity ops and pack
+HTTP APIs.
+
+Unlike lib/ops/opsclient and lib/pack/webpack, which implement the internal
+service interfaces and pull in most of the gravity tree, this package only
+depends on the standard library and the trace package. It exposes typed
+request and response models, context-aware calls, automatic retries of
+transient failures and paginated listings:
+
+	client, err := opsapi.New(opsapi.Config{
+		URL:   "https://example.gravitational.io:3009",
+		Token: token,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	page, err := client.ListOperations(ctx, opsapi.ListOperationsRequest{
+		ClusterName: "example.com",
+		Limit:       20,
+	})
+*/
+package opsapi
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gravitational/trace"
+)
+
+const (
+	// SystemAccountID is the ID of the account all clusters belong to
+	SystemAccountID = "00000000-0000-0000-0000-000000000001"
+
+	// DefaultRetryAttempts is the default number of attempts for a single call
+	DefaultRetryAttempts = 5
+	// DefaultRetryInterval is the initial interval between retries,
+	// doubled with every subsequent attempt
+	DefaultRetryInterval = 500 * time.Millisecond
+	// DefaultMaxRetryInterval caps the interval between retries
+	DefaultMaxRetryInterval = 10 * time.Second
+	// DefaultTimeout is the default timeout of a single HTTP request
+	DefaultTimeout = time.Minute
+
+	opsVersion  = "portal/v1"
+	packVersion = "pack/v1"
+)
+
+// Config defines the client configuration
+type Config struct {
+	// URL is the address of the cluster or Ops Center API,
+	// e.g. https://example.gravitational.io:3009
+	URL string
+	// Token is the API key used as a bearer token
+	Token string
+	// Username and Password enable basic authentication instead of Token
+	Username, Password string
+	// Insecure disables verification of the server certificate
+	Insecure bool
+	// HTTPClient optionally overrides the HTTP client used for requests
+	HTTPClient *http.Client
+	// RetryAttempts is the maximum number of attempts for a single call
+	RetryAttempts int
+	// RetryInterval is the initial interval between retries
+	RetryInterval time.Duration
+	// MaxRetryInterval caps the interval between retries
+	MaxRetryInterval time.Duration
+}
+
+// CheckAndSetDefaults validates the config and sets default values
+func (c *Config) CheckAndSetDefaults() error {
+	if c.URL == "" {
+		return trace.BadParameter("missing URL")
+	}
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return trace.BadParameter("invalid URL %q: %v", c.URL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return trace.BadParameter("unsupported URL scheme %q", u.Scheme)
+	}
+	if c.Token != "" && c.Username != "" {
+		return trace.BadParameter("specify either token or username/password, not both")
+	}
+	if c.HTTPClient == nil {
+		c.HTTPClient = &http.Client{
+			Timeout: DefaultTimeout,
+			Transport: &http.Transport{
+				Proxy: http.ProxyFromEnvironment,
+				TLSClientConfig: &tls.Config{
+					InsecureSkipVerify: c.Insecure,
+				},
+			},
+		}
+	}
+	if c.RetryAttempts <= 0 {
+		c.RetryAttempts = DefaultRetryAttempts
+	}
+	if c.RetryInterval <= 0 {
+		c.RetryInterval = DefaultRetryInterval
+	}
+	if c.MaxRetryInterval <= 0 {
+		c.MaxRetryInterval = DefaultMaxRetryInterval
+	}
+	return nil
+}
+
+// Client is the ops/pack API client
+type Client struct {
+	Config
+	baseURL *url.URL
+}
+
+// New returns a new client for the specified configuration
+func New(config Config) (*Client, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	baseURL, err := url.Parse(strings.TrimSuffix(config.URL, "/"))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &Client{
+		Config:  config,
+		baseURL: baseURL,
+	}, nil
+}
+
+// opsEndpoint returns the URL of the ops API endpoint with the given path
+func (c *Client) opsEndpoint(params ...string) string {
+	return c.endpoint(opsVersion, params...)
+}
+
+// packEndpoint returns the URL of the pack API endpoint with the given path
+func (c *Client) packEndpoint(params ...string) string {
+	return c.endpoint(packVersion, params...)
+}
+
+func (c *Client) endpoint(version string, params ...string) string {
+	raw := append([]string{version}, params...)
+	escaped := make([]string, 0, len(raw))
+	for _, param := range raw {
+		escaped = append(escaped, url.PathEscape(param))
+	}
+	u := *c.baseURL
+	u.RawPath = strings.TrimSuffix(u.EscapedPath(), "/") + "/" + strings.Join(escaped, "/")
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + strings.Join(raw, "/")
+	return u.String()
+}
+
+// getJSON issues a GET request to the specified endpoint and decodes
+// the JSON response into out
+func (c *Client) getJSON(ctx context.Context, endpoint string, out interface{}) error {
+	resp, err := c.get(ctx, endpoint)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return trace.Wrap(err, "failed to decode response from %v", endpoint)
+	}
+	return nil
+}
+
+// get issues a GET request to the specified endpoint retrying transient
+// failures. The caller is responsible for closing the response body.
+func (c *Client) get(ctx context.Context, endpoint string) (*http.Response, error) {
+	interval := c.RetryInterval
+	var lastErr error
+	for attempt := 1; attempt <= c.RetryAttempts; attempt++ {
+		resp, err := c.roundtrip(ctx, endpoint)
+		if err == nil {
+			return resp, nil
+		}
+		lastErr = err
+		if !isTransient(err) || attempt == c.RetryAttempts {
+			break
+		}
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return nil, trace.ConnectionProblem(ctx.Err(), "request to %v canceled", endpoint)
+		}
+		interval *= 2
+		if interval > c.MaxRetryInterval {
+			interval = c.MaxRetryInterval
+		}
+	}
+	return nil, trace.Wrap(unwrapRetryError(lastErr))
+}
+
+func (c *Client) roundtrip(ctx context.Context, endpoint string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/json")
+	switch {
+	case c.Token != "":
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	case c.Username != "":
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, &permanentError{trace.ConnectionProblem(ctx.Err(), "request to %v canceled", endpoint)}
+		}
+		return nil, trace.ConnectionProblem(err, "failed to connect to %v", endpoint)
+	}
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorSize))
+	err = trace.ReadError(resp.StatusCode, body)
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError {
+		return nil, &transientError{err}
+	}
+	return nil, err
+}
+
+// isTransient returns true if the error is worth retrying
+func isTransient(err error) bool {
+	switch trace.Unwrap(err).(type) {
+	case *transientError:
+		return true
+	case *permanentError:
+		return false
+	}
+	if _, ok := trace.Unwrap(err).(net.Error); ok {
+		return true
+	}
+	return trace.IsConnectionProblem(err)
+}
+
+// unwrapRetryError strips the retry classification from the error
+func unwrapRetryError(err error) error {
+	switch e := err.(type) {
+	case *transientError:
+		return e.error
+	case *permanentError:
+		return e.error
+	}
+	return err
+}
+
+// transientError wraps server-side failures that should be retried
+type transientError struct {
+	error
+}
+
+// permanentError wraps failures that should never be retried
+type permanentError struct {
+	error
+}
+
+// maxErrorSize limits the size of the error response body read
+const maxErrorSize = 64 * 1024
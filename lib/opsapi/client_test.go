@@ -0,0 +1,149 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opsapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gravitational/trace"
+	. "gopkg.in/check.v1"
+)
+
+func TestOpsAPI(t *testing.T) { TestingT(t) }
+
+type ClientSuite struct{}
+
+var _ = Suite(&ClientSuite{})
+
+func (s *ClientSuite) TestListOperationsPaginates(c *C) {
+	base := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	var operations []Operation
+	for i := 0; i < 5; i++ {
+		operations = append(operations, Operation{
+			ID:      fmt.Sprintf("op-%v", i),
+			Type:    "operation_update",
+			State:   OperationStateCompleted,
+			Created: base.Add(time.Duration(i) * time.Hour),
+		})
+	}
+	operations = append(operations, Operation{ID: "install", Type: "operation_install", Created: base})
+	server := newServer(c, "/portal/v1/accounts/"+SystemAccountID+"/sites/example.com/operations/common", operations)
+	defer server.Close()
+	client := newClient(c, server.URL)
+
+	var ids []string
+	req := ListOperationsRequest{
+		ClusterName: "example.com",
+		Type:        "operation_update",
+		ListOptions: ListOptions{Limit: 2},
+	}
+	for {
+		resp, err := client.ListOperations(context.TODO(), req)
+		c.Assert(err, IsNil)
+		c.Assert(resp.Total, Equals, 5)
+		for _, op := range resp.Operations {
+			ids = append(ids, op.ID)
+		}
+		if !resp.HasMore() {
+			break
+		}
+		req.Offset = resp.NextOffset
+	}
+	c.Assert(ids, DeepEquals, []string{"op-4", "op-3", "op-2", "op-1", "op-0"})
+}
+
+func (s *ClientSuite) TestRetriesTransientErrors(c *C) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.Header.Get("Authorization"), Equals, "Bearer token")
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(Cluster{Domain: "example.com", State: "active"})
+	}))
+	defer server.Close()
+	client := newClient(c, server.URL)
+
+	cluster, err := client.GetCluster(context.TODO(), ClusterRequest{ClusterName: "example.com"})
+	c.Assert(err, IsNil)
+	c.Assert(cluster.State, Equals, "active")
+	c.Assert(atomic.LoadInt32(&attempts), Equals, int32(3))
+}
+
+func (s *ClientSuite) TestDoesNotRetryPermanentErrors(c *C) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"message": "not found"})
+	}))
+	defer server.Close()
+	client := newClient(c, server.URL)
+
+	_, err := client.GetOperation(context.TODO(), OperationRequest{
+		ClusterName: "example.com",
+		OperationID: "missing",
+	})
+	c.Assert(trace.IsNotFound(err), Equals, true, Commentf("unexpected error: %v", err))
+	c.Assert(atomic.LoadInt32(&attempts), Equals, int32(1))
+}
+
+func (s *ClientSuite) TestHonorsContext(c *C) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	client, err := New(Config{URL: server.URL, RetryAttempts: 100, RetryInterval: time.Second})
+	c.Assert(err, IsNil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = client.ListPackages(ctx, ListPackagesRequest{Repository: "gravitational.io"})
+	c.Assert(err, NotNil)
+	c.Assert(ctx.Err(), NotNil)
+}
+
+func (s *ClientSuite) TestValidatesRequests(c *C) {
+	client := newClient(c, "https://localhost:3009")
+	_, err := client.GetOperation(context.TODO(), OperationRequest{ClusterName: "example.com"})
+	c.Assert(trace.IsBadParameter(err), Equals, true)
+	_, err = client.ListClusters(context.TODO(), ListClustersRequest{ListOptions: ListOptions{Limit: -1}})
+	c.Assert(trace.IsBadParameter(err), Equals, true)
+	_, err = client.GetPackage(context.TODO(), Package{Repository: "gravitational.io", Name: "planet"})
+	c.Assert(trace.IsBadParameter(err), Equals, true)
+}
+
+func newServer(c *C, path string, response interface{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.URL.Path, Equals, path)
+		json.NewEncoder(w).Encode(response)
+	}))
+}
+
+func newClient(c *C, url string) *Client {
+	client, err := New(Config{URL: url, Token: "token", RetryInterval: time.Millisecond})
+	c.Assert(err, IsNil)
+	return client
+}
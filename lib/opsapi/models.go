@@ -0,0 +1,178 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opsapi
+
+import (
+	"fmt"
+	"time"
+)
+
+// Cluster describes a cluster managed by the ops API
+type Cluster struct {
+	// Domain is the cluster name
+	Domain string `json:"domain"`
+	// AccountID is the ID of the account the cluster belongs to
+	AccountID string `json:"account_id"`
+	// Created is the cluster creation time
+	Created time.Time `json:"created"`
+	// CreatedBy is the name of the user who created the cluster
+	CreatedBy string `json:"created_by"`
+	// State is the cluster state, e.g. "active" or "degraded"
+	State string `json:"state"`
+	// Reason is the reason for the degraded cluster state
+	Reason string `json:"reason,omitempty"`
+	// Provider is the cloud provider the cluster was installed with
+	Provider string `json:"provider"`
+	// App is the application package installed in the cluster
+	App Package `json:"app"`
+	// Local is whether the cluster is the one serving the API
+	Local bool `json:"local"`
+	// Labels is a set of cluster labels
+	Labels map[string]string `json:"labels,omitempty"`
+	// Location is the cluster location
+	Location string `json:"location,omitempty"`
+}
+
+// Package describes an application package
+type Package struct {
+	// Repository is the package repository
+	Repository string `json:"repository"`
+	// Name is the package name
+	Name string `json:"name"`
+	// Version is the package version
+	Version string `json:"version"`
+}
+
+// String returns the package locator in the repository/name:version format
+func (p Package) String() string {
+	return fmt.Sprintf("%v/%v:%v", p.Repository, p.Name, p.Version)
+}
+
+// Operation describes a cluster operation
+type Operation struct {
+	// ID is the operation ID
+	ID string `json:"id"`
+	// AccountID is the ID of the account the cluster belongs to
+	AccountID string `json:"account_id"`
+	// ClusterName is the name of the cluster the operation is for
+	ClusterName string `json:"site_domain"`
+	// Type is the operation type, e.g. "operation_install"
+	Type string `json:"type"`
+	// Created is the operation creation time
+	Created time.Time `json:"created"`
+	// Updated is the last time the operation was updated
+	Updated time.Time `json:"updated"`
+	// State is the operation state
+	State string `json:"state"`
+	// Provisioner is the provisioner used by the operation
+	Provisioner string `json:"provisioner"`
+	// Servers lists servers participating in the operation
+	Servers []Server `json:"servers,omitempty"`
+}
+
+// IsCompleted returns true if the operation has finished, either
+// successfully or with a failure
+func (o Operation) IsCompleted() bool {
+	return o.State == OperationStateCompleted || o.State == OperationStateFailed
+}
+
+const (
+	// OperationStateCompleted is the state of a successfully finished operation
+	OperationStateCompleted = "completed"
+	// OperationStateFailed is the state of a failed operation
+	OperationStateFailed = "failed"
+)
+
+// Server describes a cluster node
+type Server struct {
+	// AdvertiseIP is the IP address the node is advertised on
+	AdvertiseIP string `json:"advertise_ip"`
+	// Hostname is the node hostname
+	Hostname string `json:"hostname"`
+	// Role is the node application role
+	Role string `json:"role"`
+	// ClusterRole is the node Kubernetes role, master or node
+	ClusterRole string `json:"cluster_role,omitempty"`
+}
+
+// Progress describes the progress of an operation
+type Progress struct {
+	// OperationID is the ID of the operation
+	OperationID string `json:"operation_id"`
+	// Created is the time of the progress update
+	Created time.Time `json:"created"`
+	// Completion is the completion percentage
+	Completion int `json:"completion"`
+	// Step is the current step number
+	Step int `json:"step"`
+	// State is the operation state
+	State string `json:"state"`
+	// Message is the progress message
+	Message string `json:"message"`
+}
+
+// Plan describes an operation plan
+type Plan struct {
+	// OperationID is the ID of the operation the plan is for
+	OperationID string `json:"operation_id"`
+	// OperationType is the type of the operation
+	OperationType string `json:"operation_type"`
+	// ClusterName is the name of the cluster
+	ClusterName string `json:"cluster_name"`
+	// Phases lists the top-level plan phases
+	Phases []Phase `json:"phases"`
+	// CreatedAt is the plan creation time
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Phase describes a single operation plan phase
+type Phase struct {
+	// ID is the phase ID, e.g. "/masters/node-1"
+	ID string `json:"id"`
+	// Description is the phase description
+	Description string `json:"description,omitempty"`
+	// State is the phase state
+	State string `json:"state,omitempty"`
+	// Requires lists phases this phase depends on
+	Requires []string `json:"requires,omitempty"`
+	// Updated is the last time the phase state changed
+	Updated time.Time `json:"updated,omitempty"`
+	// Phases lists the subphases
+	Phases []Phase `json:"phases,omitempty"`
+}
+
+// PackageEnvelope describes a package stored in a package repository
+type PackageEnvelope struct {
+	// Locator identifies the package
+	Locator Package `json:"locator"`
+	// SizeBytes is the package size in bytes
+	SizeBytes int64 `json:"size_bytes"`
+	// SHA512 is the package checksum
+	SHA512 string `json:"sha512"`
+	// RuntimeLabels is a set of package labels
+	RuntimeLabels map[string]string `json:"runtime_labels,omitempty"`
+	// Hidden is whether the package is hidden from listings
+	Hidden bool `json:"hidden"`
+	// Encrypted is whether the package is encrypted
+	Encrypted bool `json:"encrypted"`
+	// Type is the package type, e.g. "app"
+	Type string `json:"type,omitempty"`
+	// Created is the package creation time
+	Created time.Time `json:"created"`
+	// CreatedBy is the name of the user who created the package
+	CreatedBy string `json:"created_by"`
+}
@@ -0,0 +1,285 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opsapi
+
+import (
+	"context"
+	"sort"
+
+	"github.com/gravitational/trace"
+)
+
+// ListOptions controls pagination of list calls
+type ListOptions struct {
+	// Limit is the maximum number of items per page, 0 means no limit
+	Limit int
+	// Offset is the number of items to skip
+	Offset int
+}
+
+// Check validates the list options
+func (o ListOptions) Check() error {
+	if o.Limit < 0 {
+		return trace.BadParameter("limit can't be negative")
+	}
+	if o.Offset < 0 {
+		return trace.BadParameter("offset can't be negative")
+	}
+	return nil
+}
+
+// bounds returns the [start, end) range of the page within total items
+func (o ListOptions) bounds(total int) (start, end int) {
+	start = o.Offset
+	if start > total {
+		start = total
+	}
+	end = total
+	if o.Limit > 0 && start+o.Limit < total {
+		end = start + o.Limit
+	}
+	return start, end
+}
+
+// PageInfo describes a page of results
+type PageInfo struct {
+	// Total is the total number of items matching the request
+	Total int
+	// NextOffset is the offset of the next page, or 0 if this is the last page
+	NextOffset int
+}
+
+// HasMore returns true if there are more pages after this one
+func (p PageInfo) HasMore() bool {
+	return p.NextOffset != 0
+}
+
+func newPageInfo(options ListOptions, total int) PageInfo {
+	_, end := options.bounds(total)
+	info := PageInfo{Total: total}
+	if end < total {
+		info.NextOffset = end
+	}
+	return info
+}
+
+// ListClustersRequest is a request to list clusters
+type ListClustersRequest struct {
+	ListOptions
+	// AccountID is the account to list clusters for, defaults to SystemAccountID
+	AccountID string
+}
+
+// CheckAndSetDefaults validates the request and sets defaults
+func (r *ListClustersRequest) CheckAndSetDefaults() error {
+	if r.AccountID == "" {
+		r.AccountID = SystemAccountID
+	}
+	return trace.Wrap(r.ListOptions.Check())
+}
+
+// ListClustersResponse is a page of clusters
+type ListClustersResponse struct {
+	PageInfo
+	// Clusters lists clusters on this page
+	Clusters []Cluster
+}
+
+// ListClusters returns a page of clusters sorted by name
+func (c *Client) ListClusters(ctx context.Context, req ListClustersRequest) (*ListClustersResponse, error) {
+	if err := req.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var clusters []Cluster
+	err := c.getJSON(ctx, c.opsEndpoint("accounts", req.AccountID, "sites"), &clusters)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		return clusters[i].Domain < clusters[j].Domain
+	})
+	start, end := req.bounds(len(clusters))
+	return &ListClustersResponse{
+		PageInfo: newPageInfo(req.ListOptions, len(clusters)),
+		Clusters: clusters[start:end],
+	}, nil
+}
+
+// ClusterRequest identifies a cluster
+type ClusterRequest struct {
+	// AccountID is the cluster account, defaults to SystemAccountID
+	AccountID string
+	// ClusterName is the cluster name
+	ClusterName string
+}
+
+// CheckAndSetDefaults validates the request and sets defaults
+func (r *ClusterRequest) CheckAndSetDefaults() error {
+	if r.ClusterName == "" {
+		return trace.BadParameter("missing cluster name")
+	}
+	if r.AccountID == "" {
+		r.AccountID = SystemAccountID
+	}
+	return nil
+}
+
+// GetCluster returns the specified cluster
+func (c *Client) GetCluster(ctx context.Context, req ClusterRequest) (*Cluster, error) {
+	if err := req.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var cluster Cluster
+	err := c.getJSON(ctx, c.opsEndpoint("accounts", req.AccountID, "sites", req.ClusterName), &cluster)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &cluster, nil
+}
+
+// ListOperationsRequest is a request to list cluster operations
+type ListOperationsRequest struct {
+	ListOptions
+	// AccountID is the cluster account, defaults to SystemAccountID
+	AccountID string
+	// ClusterName is the cluster name
+	ClusterName string
+	// Type optionally filters operations by type
+	Type string
+	// State optionally filters operations by state
+	State string
+}
+
+// CheckAndSetDefaults validates the request and sets defaults
+func (r *ListOperationsRequest) CheckAndSetDefaults() error {
+	if r.ClusterName == "" {
+		return trace.BadParameter("missing cluster name")
+	}
+	if r.AccountID == "" {
+		r.AccountID = SystemAccountID
+	}
+	return trace.Wrap(r.ListOptions.Check())
+}
+
+func (r ListOperationsRequest) matches(op Operation) bool {
+	if r.Type != "" && op.Type != r.Type {
+		return false
+	}
+	if r.State != "" && op.State != r.State {
+		return false
+	}
+	return true
+}
+
+// ListOperationsResponse is a page of operations
+type ListOperationsResponse struct {
+	PageInfo
+	// Operations lists operations on this page
+	Operations []Operation
+}
+
+// ListOperations returns a page of cluster operations, most recent first
+func (c *Client) ListOperations(ctx context.Context, req ListOperationsRequest) (*ListOperationsResponse, error) {
+	if err := req.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var operations []Operation
+	err := c.getJSON(ctx, c.opsEndpoint("accounts", req.AccountID, "sites", req.ClusterName,
+		"operations", "common"), &operations)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	filtered := operations[:0]
+	for _, op := range operations {
+		if req.matches(op) {
+			filtered = append(filtered, op)
+		}
+	}
+	sort.SliceStable(filtered, func(i, j int) bool {
+		return filtered[i].Created.After(filtered[j].Created)
+	})
+	start, end := req.bounds(len(filtered))
+	return &ListOperationsResponse{
+		PageInfo:   newPageInfo(req.ListOptions, len(filtered)),
+		Operations: filtered[start:end],
+	}, nil
+}
+
+// OperationRequest identifies a cluster operation
+type OperationRequest struct {
+	// AccountID is the cluster account, defaults to SystemAccountID
+	AccountID string
+	// ClusterName is the cluster name
+	ClusterName string
+	// OperationID is the operation ID
+	OperationID string
+}
+
+// CheckAndSetDefaults validates the request and sets defaults
+func (r *OperationRequest) CheckAndSetDefaults() error {
+	if r.ClusterName == "" {
+		return trace.BadParameter("missing cluster name")
+	}
+	if r.OperationID == "" {
+		return trace.BadParameter("missing operation ID")
+	}
+	if r.AccountID == "" {
+		r.AccountID = SystemAccountID
+	}
+	return nil
+}
+
+func (r OperationRequest) endpoint(c *Client, params ...string) string {
+	return c.opsEndpoint(append([]string{"accounts", r.AccountID, "sites", r.ClusterName,
+		"operations", "common", r.OperationID}, params...)...)
+}
+
+// GetOperation returns the specified operation
+func (c *Client) GetOperation(ctx context.Context, req OperationRequest) (*Operation, error) {
+	if err := req.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var operation Operation
+	if err := c.getJSON(ctx, req.endpoint(c), &operation); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &operation, nil
+}
+
+// GetOperationProgress returns the last progress entry of the specified operation
+func (c *Client) GetOperationProgress(ctx context.Context, req OperationRequest) (*Progress, error) {
+	if err := req.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var progress Progress
+	if err := c.getJSON(ctx, req.endpoint(c, "progress"), &progress); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &progress, nil
+}
+
+// GetOperationPlan returns the plan of the specified operation
+func (c *Client) GetOperationPlan(ctx context.Context, req OperationRequest) (*Plan, error) {
+	if err := req.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var plan Plan
+	if err := c.getJSON(ctx, req.endpoint(c, "plan"), &plan); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &plan, nil
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opsapi
+
+import (
+	"context"
+	"io"
+	"sort"
+
+	"github.com/gravitational/trace"
+)
+
+// ListPackagesRequest is a request to list packages in a repository
+type ListPackagesRequest struct {
+	ListOptions
+	// Repository is the package repository, e.g. "gravitational.io"
+	Repository string
+	// IncludeHidden includes hidden packages in the listing
+	IncludeHidden bool
+}
+
+// CheckAndSetDefaults validates the request and sets defaults
+func (r *ListPackagesRequest) CheckAndSetDefaults() error {
+	if r.Repository == "" {
+		return trace.BadParameter("missing repository")
+	}
+	return trace.Wrap(r.ListOptions.Check())
+}
+
+// ListPackagesResponse is a page of packages
+type ListPackagesResponse struct {
+	PageInfo
+	// Packages lists packages on this page
+	Packages []PackageEnvelope
+}
+
+// ListPackages returns a page of packages in the repository sorted by name and version
+func (c *Client) ListPackages(ctx context.Context, req ListPackagesRequest) (*ListPackagesResponse, error) {
+	if err := req.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var packages []PackageEnvelope
+	err := c.getJSON(ctx, c.packEndpoint("repositories", req.Repository, "packages"), &packages)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	filtered := packages[:0]
+	for _, pkg := range packages {
+		if !pkg.Hidden || req.IncludeHidden {
+			filtered = append(filtered, pkg)
+		}
+	}
+	sort.SliceStable(filtered, func(i, j int) bool {
+		if filtered[i].Locator.Name != filtered[j].Locator.Name {
+			return filtered[i].Locator.Name < filtered[j].Locator.Name
+		}
+		return filtered[i].Locator.Version < filtered[j].Locator.Version
+	})
+	start, end := req.bounds(len(filtered))
+	return &ListPackagesResponse{
+		PageInfo: newPageInfo(req.ListOptions, len(filtered)),
+		Packages: filtered[start:end],
+	}, nil
+}
+
+// GetPackage returns the envelope of the specified package
+func (c *Client) GetPackage(ctx context.Context, pkg Package) (*PackageEnvelope, error) {
+	if err := checkPackage(pkg); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var envelope PackageEnvelope
+	err := c.getJSON(ctx, c.packEndpoint("repositories", pkg.Repository,
+		"packages", pkg.Name, pkg.Version, "envelope"), &envelope)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &envelope, nil
+}
+
+// ReadPackage returns the contents of the specified package.
+// Only establishing the download is retried, the caller is responsible
+// for closing the returned reader.
+func (c *Client) ReadPackage(ctx context.Context, pkg Package) (io.ReadCloser, error) {
+	if err := checkPackage(pkg); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	resp, err := c.get(ctx, c.packEndpoint("repositories", pkg.Repository,
+		"packages", pkg.Name, pkg.Version, "file"))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return resp.Body, nil
+}
+
+func checkPackage(pkg Package) error {
+	if pkg.Repository == "" || pkg.Name == "" || pkg.Version == "" {
+		return trace.BadParameter("package %v should specify repository, name and version", pkg)
+	}
+	return nil
+}
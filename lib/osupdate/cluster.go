@@ -0,0 +1,72 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osupdate
+
+import (
+	"context"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/kubernetes"
+
+	"github.com/gravitational/rigging"
+	"github.com/gravitational/trace"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeapi "k8s.io/client-go/kubernetes"
+)
+
+// cluster controls cluster nodes during the update
+type cluster interface {
+	// nodes returns all cluster nodes
+	nodes() ([]v1.Node, error)
+	// node returns the node with the specified name
+	node(name string) (*v1.Node, error)
+	// drain cordons and drains the node with the specified name
+	drain(ctx context.Context, name string) error
+	// uncordon makes the node with the specified name schedulable
+	uncordon(ctx context.Context, name string) error
+}
+
+type kubeCluster struct {
+	client *kubeapi.Clientset
+}
+
+func (r *kubeCluster) nodes() ([]v1.Node, error) {
+	nodes, err := r.client.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		return nil, trace.Wrap(rigging.ConvertError(err))
+	}
+	return nodes.Items, nil
+}
+
+func (r *kubeCluster) node(name string) (*v1.Node, error) {
+	node, err := r.client.CoreV1().Nodes().Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, trace.Wrap(rigging.ConvertError(err))
+	}
+	return node, nil
+}
+
+func (r *kubeCluster) drain(ctx context.Context, name string) error {
+	ctx, cancel := context.WithTimeout(ctx, defaults.DrainTimeout)
+	defer cancel()
+	return trace.Wrap(kubernetes.Drain(ctx, r.client, name))
+}
+
+func (r *kubeCluster) uncordon(ctx context.Context, name string) error {
+	return trace.Wrap(kubernetes.SetUnschedulable(ctx, r.client.CoreV1().Nodes(), name, false))
+}
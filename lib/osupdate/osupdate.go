@@ -0,0 +1,242 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package osupdate coordinates host OS updates with the cluster state.
+//
+// Nodes are updated one at a time so that the cluster never loses more
+// than a single node. For every node the coordinator:
+//
+//   - makes sure all cluster nodes are healthy
+//   - drains the node
+//   - runs the user-supplied hook which patches (and optionally reboots) the node
+//   - waits for the node to become healthy
+//   - uncordons the node
+//
+// The hook runs on the node executing the coordinator and receives the details
+// of the node to update via environment variables (see EnvNodeName et al).
+// If a step fails, the node is left cordoned and the update stops so the operator
+// can investigate and resume with the remaining nodes.
+package osupdate
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/cenkalti/backoff"
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+	kubeapi "k8s.io/client-go/kubernetes"
+)
+
+// Config defines the configuration of the OS update coordinator
+type Config struct {
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+	// Emitter outputs progress messages
+	utils.Emitter
+	// Client is the kubernetes client
+	Client *kubeapi.Clientset
+	// Servers lists the nodes to update in order
+	Servers []storage.Server
+	// Hook specifies the command that updates a node
+	Hook []string
+	// ExpectReboot specifies whether the hook reboots the node.
+	// If set, the node is only considered updated after its boot ID has changed
+	ExpectReboot bool
+	// HookTimeout limits the time the hook is allowed to run
+	HookTimeout time.Duration
+	// HealthTimeout limits the time to wait for a node to become healthy
+	HealthTimeout time.Duration
+	// cluster controls the nodes. Overridden in tests
+	cluster cluster
+	// runHook runs the hook for the specified node. Overridden in tests
+	runHook func(ctx context.Context, server storage.Server) error
+}
+
+// CheckAndSetDefaults validates the config and sets defaults
+func (r *Config) CheckAndSetDefaults() error {
+	if len(r.Servers) == 0 {
+		return trace.BadParameter("no nodes to update")
+	}
+	if len(r.Hook) == 0 {
+		return trace.BadParameter("missing Hook")
+	}
+	if r.cluster == nil {
+		if r.Client == nil {
+			return trace.BadParameter("missing Client")
+		}
+		r.cluster = &kubeCluster{client: r.Client}
+	}
+	if r.FieldLogger == nil {
+		r.FieldLogger = logrus.WithField(trace.Component, "osupdate")
+	}
+	if r.Emitter == nil {
+		r.Emitter = utils.NopEmitter()
+	}
+	if r.HookTimeout == 0 {
+		r.HookTimeout = defaults.OSUpdateHookTimeout
+	}
+	if r.HealthTimeout == 0 {
+		r.HealthTimeout = defaults.OSUpdateHealthTimeout
+	}
+	if r.runHook == nil {
+		r.runHook = r.execHook
+	}
+	return nil
+}
+
+// New returns a new OS update coordinator
+func New(config Config) (*Coordinator, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &Coordinator{Config: config}, nil
+}
+
+// Coordinator updates the OS on cluster nodes one node at a time
+type Coordinator struct {
+	// Config is the coordinator configuration
+	Config
+}
+
+// Run updates all configured nodes in order
+func (r *Coordinator) Run(ctx context.Context) error {
+	for i, server := range r.Servers {
+		r.PrintStep("Updating node %v (%v of %v)", server.Hostname, i+1, len(r.Servers))
+		if err := r.updateNode(ctx, server); err != nil {
+			return trace.Wrap(err, "failed to update node %v, the node is left cordoned. "+
+				"Fix the problem and resume the update from this node", server.Hostname)
+		}
+	}
+	return nil
+}
+
+func (r *Coordinator) updateNode(ctx context.Context, server storage.Server) error {
+	name := server.KubeNodeID()
+	logger := r.WithField("node", name)
+	if err := r.checkClusterHealth(); err != nil {
+		return trace.Wrap(err)
+	}
+	node, err := r.cluster.node(name)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	bootID := node.Status.NodeInfo.BootID
+
+	r.PrintStep("Draining node %v", server.Hostname)
+	if err := r.cluster.drain(ctx, name); err != nil {
+		return trace.Wrap(err, "failed to drain node")
+	}
+
+	r.PrintStep("Running OS update hook on node %v", server.Hostname)
+	hookCtx, cancel := context.WithTimeout(ctx, r.HookTimeout)
+	defer cancel()
+	if err := r.runHook(hookCtx, server); err != nil {
+		return trace.Wrap(err, "OS update hook failed")
+	}
+
+	r.PrintStep("Waiting for node %v to become healthy", server.Hostname)
+	healthCtx, cancel := context.WithTimeout(ctx, r.HealthTimeout)
+	defer cancel()
+	err = utils.RetryWithInterval(healthCtx, backoff.NewConstantBackOff(defaults.RetryInterval), func() error {
+		node, err := r.cluster.node(name)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if r.ExpectReboot && node.Status.NodeInfo.BootID == bootID {
+			return trace.CompareFailed("node has not rebooted yet")
+		}
+		return trace.Wrap(checkNodeReady(*node))
+	})
+	if err != nil {
+		return trace.Wrap(err, "node did not become healthy after update")
+	}
+
+	r.PrintStep("Uncordoning node %v", server.Hostname)
+	if err := r.cluster.uncordon(ctx, name); err != nil {
+		return trace.Wrap(err, "failed to uncordon node")
+	}
+	logger.Info("Node updated.")
+	return nil
+}
+
+// checkClusterHealth makes sure all nodes are ready before taking
+// another node down so the cluster does not lose quorum
+func (r *Coordinator) checkClusterHealth() error {
+	nodes, err := r.cluster.nodes()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	for _, node := range nodes {
+		if err := checkNodeReady(node); err != nil {
+			return trace.Wrap(err, "refusing to take down another node while node %v is unhealthy", node.Name)
+		}
+	}
+	return nil
+}
+
+// execHook runs the hook command with the node details in its environment
+func (r *Config) execHook(ctx context.Context, server storage.Server) error {
+	var out bytes.Buffer
+	cmd := exec.CommandContext(ctx, r.Hook[0], r.Hook[1:]...)
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	cmd.Env = append(os.Environ(),
+		fmt.Sprintf("%v=%v", EnvNodeName, server.KubeNodeID()),
+		fmt.Sprintf("%v=%v", EnvNodeAddr, server.AdvertiseIP),
+		fmt.Sprintf("%v=%v", EnvNodeHostname, server.Hostname),
+		fmt.Sprintf("%v=%v", EnvNodeRole, server.ClusterRole),
+	)
+	r.WithField("cmd", r.Hook).Info("Run OS update hook.")
+	if err := cmd.Run(); err != nil {
+		return trace.Wrap(err, "%s", out.String())
+	}
+	r.Debugf("OS update hook output: %s", out.String())
+	return nil
+}
+
+// checkNodeReady returns an error if the specified node is not ready
+func checkNodeReady(node v1.Node) error {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == v1.NodeReady {
+			if condition.Status == v1.ConditionTrue {
+				return nil
+			}
+			return trace.CompareFailed("node %v is not ready: %v", node.Name, condition.Message)
+		}
+	}
+	return trace.CompareFailed("node %v has not reported readiness", node.Name)
+}
+
+const (
+	// EnvNodeName is the environment variable with the kubernetes name of the node to update
+	EnvNodeName = "GRAVITY_NODE_NAME"
+	// EnvNodeAddr is the environment variable with the advertise address of the node to update
+	EnvNodeAddr = "GRAVITY_NODE_ADDR"
+	// EnvNodeHostname is the environment variable with the hostname of the node to update
+	EnvNodeHostname = "GRAVITY_NODE_HOSTNAME"
+	// EnvNodeRole is the environment variable with the cluster role (master or node) of the node to update
+	EnvNodeRole = "GRAVITY_NODE_ROLE"
+)
@@ -0,0 +1,169 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osupdate
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+	. "gopkg.in/check.v1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestOSUpdate(t *testing.T) { TestingT(t) }
+
+type OSUpdateSuite struct{}
+
+var _ = Suite(&OSUpdateSuite{})
+
+func (*OSUpdateSuite) TestUpdatesNodesOneAtATime(c *C) {
+	cluster := newTestCluster("node-1", "node-2")
+	coordinator, err := New(Config{
+		Servers:      []storage.Server{{Hostname: "node-1", Nodename: "node-1"}, {Hostname: "node-2", Nodename: "node-2"}},
+		Hook:         []string{"patch"},
+		ExpectReboot: true,
+		cluster:      cluster,
+		runHook: func(ctx context.Context, server storage.Server) error {
+			cluster.record("hook %v", server.KubeNodeID())
+			cluster.reboot(server.KubeNodeID())
+			return nil
+		},
+	})
+	c.Assert(err, IsNil)
+
+	c.Assert(coordinator.Run(context.TODO()), IsNil)
+	c.Assert(cluster.log, DeepEquals, []string{
+		"drain node-1", "hook node-1", "uncordon node-1",
+		"drain node-2", "hook node-2", "uncordon node-2",
+	})
+}
+
+func (*OSUpdateSuite) TestRefusesToUpdateWithUnhealthyNode(c *C) {
+	cluster := newTestCluster("node-1", "node-2")
+	cluster.setReady("node-2", false)
+	coordinator, err := New(Config{
+		Servers: []storage.Server{{Hostname: "node-1", Nodename: "node-1"}},
+		Hook:    []string{"patch"},
+		cluster: cluster,
+		runHook: func(context.Context, storage.Server) error { return nil },
+	})
+	c.Assert(err, IsNil)
+
+	err = coordinator.Run(context.TODO())
+	c.Assert(err, ErrorMatches, "(?s).*node node-2 is unhealthy.*")
+	c.Assert(cluster.log, HasLen, 0)
+}
+
+func (*OSUpdateSuite) TestLeavesNodeCordonedOnHookFailure(c *C) {
+	cluster := newTestCluster("node-1", "node-2")
+	coordinator, err := New(Config{
+		Servers: []storage.Server{{Hostname: "node-1", Nodename: "node-1"}, {Hostname: "node-2", Nodename: "node-2"}},
+		Hook:    []string{"patch"},
+		cluster: cluster,
+		runHook: func(context.Context, storage.Server) error {
+			return trace.BadParameter("no updates available")
+		},
+	})
+	c.Assert(err, IsNil)
+
+	c.Assert(coordinator.Run(context.TODO()), NotNil)
+	c.Assert(cluster.log, DeepEquals, []string{"drain node-1"})
+}
+
+func (*OSUpdateSuite) TestFailsIfNodeDoesNotReboot(c *C) {
+	cluster := newTestCluster("node-1")
+	coordinator, err := New(Config{
+		Servers:       []storage.Server{{Hostname: "node-1", Nodename: "node-1"}},
+		Hook:          []string{"patch"},
+		ExpectReboot:  true,
+		HealthTimeout: 10 * time.Millisecond,
+		cluster:       cluster,
+		runHook:       func(context.Context, storage.Server) error { return nil },
+	})
+	c.Assert(err, IsNil)
+
+	c.Assert(coordinator.Run(context.TODO()), NotNil)
+	c.Assert(cluster.log, DeepEquals, []string{"drain node-1"})
+}
+
+func newTestCluster(names ...string) *testCluster {
+	cluster := &testCluster{items: make(map[string]*v1.Node)}
+	for _, name := range names {
+		cluster.items[name] = &v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: v1.NodeStatus{
+				NodeInfo:   v1.NodeSystemInfo{BootID: "boot-0"},
+				Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}},
+			},
+		}
+		cluster.names = append(cluster.names, name)
+	}
+	return cluster
+}
+
+func (r *testCluster) nodes() (nodes []v1.Node, err error) {
+	for _, name := range r.names {
+		nodes = append(nodes, *r.items[name])
+	}
+	return nodes, nil
+}
+
+func (r *testCluster) node(name string) (*v1.Node, error) {
+	node, ok := r.items[name]
+	if !ok {
+		return nil, trace.NotFound("node %v not found", name)
+	}
+	return node, nil
+}
+
+func (r *testCluster) drain(ctx context.Context, name string) error {
+	r.record("drain %v", name)
+	return nil
+}
+
+func (r *testCluster) uncordon(ctx context.Context, name string) error {
+	r.record("uncordon %v", name)
+	return nil
+}
+
+func (r *testCluster) reboot(name string) {
+	r.items[name].Status.NodeInfo.BootID = "boot-1"
+}
+
+func (r *testCluster) setReady(name string, ready bool) {
+	status := v1.ConditionTrue
+	if !ready {
+		status = v1.ConditionFalse
+	}
+	r.items[name].Status.Conditions[0].Status = status
+}
+
+func (r *testCluster) record(format string, args ...interface{}) {
+	r.log = append(r.log, fmt.Sprintf(format, args...))
+}
+
+type testCluster struct {
+	names []string
+	items map[string]*v1.Node
+	log   []string
+}
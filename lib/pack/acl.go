@@ -17,6 +17,7 @@ limitations under the License.
 package pack
 
 import (
+	"context"
 	"io"
 	"time"
 
@@ -124,6 +125,14 @@ func (a *ACLService) GetPackages(repository string) ([]PackageEnvelope, error) {
 	return a.packages.GetPackages(repository)
 }
 
+// GetPackagesBySelector returns a list of packages in repository matching the selector
+func (a *ACLService) GetPackagesBySelector(repository, selector string) ([]PackageEnvelope, error) {
+	if err := a.repoAction(repository, teleservices.VerbList); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.packages.GetPackagesBySelector(repository, selector)
+}
+
 // CreatePackage creates package and adds it to to the existing repository
 func (a *ACLService) CreatePackage(loc loc.Locator, data io.Reader, options ...PackageOption) (*PackageEnvelope, error) {
 	if err := a.repoAction(loc.Repository, teleservices.VerbCreate); err != nil {
@@ -151,6 +160,16 @@ func (a *ACLService) DeletePackage(loc loc.Locator) error {
 	return a.packages.DeletePackage(loc)
 }
 
+// PinPackage protects the package from deletion until it is unpinned
+func (a *ACLService) PinPackage(loc loc.Locator) error {
+	return Pin(a, loc)
+}
+
+// UnpinPackage removes the deletion protection from the package
+func (a *ACLService) UnpinPackage(loc loc.Locator) error {
+	return Unpin(a, loc)
+}
+
 func (a *ACLService) UpdatePackageLabels(loc loc.Locator, addLabels map[string]string, removeLabels []string) error {
 	if err := a.repoAction(loc.Repository, teleservices.VerbUpdate); err != nil {
 		return trace.Wrap(err)
@@ -179,6 +198,58 @@ func (a *ACLService) ReadPackageEnvelope(loc loc.Locator) (*PackageEnvelope, err
 	return a.packages.ReadPackageEnvelope(loc)
 }
 
+// UpsertPackageAlias creates the package alias or updates its target
+func (a *ACLService) UpsertPackageAlias(alias, target loc.Locator) error {
+	if err := a.repoAction(alias.Repository, teleservices.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.packages.UpsertPackageAlias(alias, target)
+}
+
+// GetPackageAliases returns a list of package aliases in repository
+func (a *ACLService) GetPackageAliases(repository string) ([]storage.PackageAlias, error) {
+	if err := a.repoAction(repository, teleservices.VerbList); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.packages.GetPackageAliases(repository)
+}
+
+// DeletePackageAlias deletes the package alias
+func (a *ACLService) DeletePackageAlias(alias loc.Locator) error {
+	if err := a.repoAction(alias.Repository, teleservices.VerbDelete); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.packages.DeletePackageAlias(alias)
+}
+
+// WatchPackages returns a channel that receives events about changes
+// to packages in the specified repository.
+// Watching all repositories requires access to all repositories
+func (a *ACLService) WatchPackages(ctx context.Context, repository string) (<-chan PackageEvent, error) {
+	if repository == "" {
+		for _, verb := range []string{teleservices.VerbList, teleservices.VerbRead} {
+			err := a.checker.CheckAccessToRule(a.context(), teledefaults.Namespace, storage.KindRepository, verb, false)
+			if err != nil {
+				return nil, trace.Wrap(err)
+			}
+		}
+		return a.packages.WatchPackages(ctx, repository)
+	}
+	if err := a.repoAction(repository, teleservices.VerbList); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := a.repoAction(repository, teleservices.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.packages.WatchPackages(ctx, repository)
+}
+
+// PrunePackages deletes packages that are not in use.
+// Access to the packages is checked for every repository and package
+func (a *ACLService) PrunePackages(req PruneRequest) (*PruneResponse, error) {
+	return Prune(a, req)
+}
+
 const (
 	// CollectionRepositories means access on all repositories that exist
 	CollectionRepositories = "repositories"
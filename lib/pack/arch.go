@@ -0,0 +1,61 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pack
+
+import (
+	"github.com/gravitational/gravity/lib/loc"
+
+	"github.com/gravitational/trace"
+)
+
+// ResolveArch returns the variant of the specified package built for
+// the architecture arch if it exists in the package service, or the package
+// itself otherwise
+func ResolveArch(packages PackageService, locator loc.Locator, arch string) (*loc.Locator, error) {
+	variant := locator.WithArch(arch)
+	if variant == locator {
+		return &locator, nil
+	}
+	_, err := packages.ReadPackageEnvelope(variant)
+	if err == nil {
+		return &variant, nil
+	}
+	if !trace.IsNotFound(err) {
+		return nil, trace.Wrap(err)
+	}
+	return &locator, nil
+}
+
+// FindLatestPackageForArch returns the latest package matching the provided
+// locator built for the architecture arch.
+//
+// The architecture variant of the package is preferred if the package service
+// has any version of it, otherwise the latest version of the package itself
+// is returned.
+// Versions that have reached the end of life are not considered
+func FindLatestPackageForArch(packages PackageService, filter loc.Locator, arch string) (*loc.Locator, error) {
+	if variant := filter.WithArch(arch); variant != filter {
+		locator, err := FindLatestPackage(packages, variant)
+		if err == nil {
+			return locator, nil
+		}
+		if !trace.IsNotFound(err) {
+			return nil, trace.Wrap(err)
+		}
+	}
+	return FindLatestPackage(packages, filter.WithoutArch())
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pack
+
+import (
+	"bytes"
+
+	"github.com/gravitational/gravity/lib/loc"
+	. "gopkg.in/check.v1"
+)
+
+type ArchSuite struct{}
+
+var _ = Suite(&ArchSuite{})
+
+func (s *ArchSuite) TestSelectsArchVariant(c *C) {
+	packages := newMemoryPackages()
+	for _, locator := range []string{
+		"example.com/planet:1.0.0",
+		"example.com/planet:1.1.0",
+		"example.com/planet:1.0.0_arm64",
+		"example.com/tool:2.0.0",
+	} {
+		_, err := packages.CreatePackage(loc.MustParseLocator(locator), bytes.NewReader(nil))
+		c.Assert(err, IsNil)
+	}
+
+	planet := loc.MustParseLocator("example.com/planet:0.0.1")
+	latest, err := FindLatestPackageForArch(packages, planet, loc.DefaultArch)
+	c.Assert(err, IsNil)
+	c.Assert(latest.String(), Equals, "example.com/planet:1.1.0")
+
+	latest, err = FindLatestPackageForArch(packages, planet, "arm64")
+	c.Assert(err, IsNil)
+	c.Assert(*latest, Equals, loc.MustParseLocator("example.com/planet:1.0.0_arm64"))
+	c.Assert(latest.Name, Equals, planet.Name)
+
+	// variants are only considered if requested explicitly
+	latest, err = FindLatestPackage(packages, planet)
+	c.Assert(err, IsNil)
+	c.Assert(latest.String(), Equals, "example.com/planet:1.1.0")
+	latest, err = FindLatestPackage(packages, planet.WithArch("arm64"))
+	c.Assert(err, IsNil)
+	c.Assert(latest.String(), Equals, "example.com/planet:1.0.0_arm64")
+
+	tool := loc.MustParseLocator("example.com/tool:0.0.1")
+	latest, err = FindLatestPackageForArch(packages, tool, "arm64")
+	c.Assert(err, IsNil)
+	c.Assert(latest.String(), Equals, "example.com/tool:2.0.0")
+
+	resolved, err := ResolveArch(packages, loc.MustParseLocator("example.com/planet:1.0.0"), "arm64")
+	c.Assert(err, IsNil)
+	c.Assert(resolved.String(), Equals, "example.com/planet:1.0.0_arm64")
+
+	resolved, err = ResolveArch(packages, loc.MustParseLocator("example.com/planet:1.1.0"), "arm64")
+	c.Assert(err, IsNil)
+	c.Assert(resolved.String(), Equals, "example.com/planet:1.1.0")
+
+	resolved, err = ResolveArch(packages, loc.MustParseLocator("example.com/planet:1.0.0"), loc.DefaultArch)
+	c.Assert(err, IsNil)
+	c.Assert(resolved.String(), Equals, "example.com/planet:1.0.0")
+}
@@ -17,14 +17,32 @@ limitations under the License.
 package pack
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"io"
 
+	"github.com/gravitational/gravity/lib/loc"
+
+	"github.com/gravitational/configure/schema"
 	"github.com/gravitational/trace"
 )
 
 func WriteConfigPackage(m *Manifest, w io.Writer) error {
-	vars := m.Config.EnvVars()
+	return writeConfigVars(m.Config.EnvVars(), nil, w)
+}
+
+// WriteConfigVars writes a self-contained configuration package
+// with the specified variables into w
+func WriteConfigVars(vars map[string]string, w io.Writer) error {
+	return writeConfigVars(vars, nil, w)
+}
+
+// writeConfigVars writes a configuration package with the specified variables.
+// If base is given, the package references it for the variables it does not define
+func writeConfigVars(vars map[string]string, base *loc.Locator, w io.Writer) error {
 	b, err := json.Marshal(vars)
 	if err != nil {
 		return trace.Wrap(err)
@@ -35,19 +53,151 @@ func WriteConfigPackage(m *Manifest, w io.Writer) error {
 			{Name: "type", Value: "orbit/config"},
 		},
 	}
+	if base != nil {
+		cm.Labels = append(cm.Labels, Label{Name: ConfigBaseLabel, Value: base.String()})
+	}
 	return WritePackage(cm, w, []PackageFile{
 		{Path: "vars.json", Contents: b},
 	})
 }
 
+// ReadConfigPackage returns the variables from the configuration package read from r.
+// Layered configuration packages cannot be read this way as they reference
+// another package, use ReadConfigVars instead
 func ReadConfigPackage(r io.Reader) (map[string]string, error) {
+	vars, base, err := readConfigPackage(r)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if base != nil {
+		return nil, trace.BadParameter(
+			"configuration package references shared configuration %v", base)
+	}
+	return vars, nil
+}
+
+// ReadConfigVars returns the variables from the specified configuration package.
+// For layered configuration packages, the variables are merged with the shared
+// variables from the base package
+func ReadConfigVars(packages PackageService, locator loc.Locator) (map[string]string, error) {
+	_, reader, err := packages.ReadPackage(locator)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer reader.Close()
+	vars, base, err := readConfigPackage(reader)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if base == nil {
+		return vars, nil
+	}
+	_, reader, err = packages.ReadPackage(*base)
+	if err != nil {
+		return nil, trace.Wrap(err, "failed to read shared configuration of %v", locator)
+	}
+	defer reader.Close()
+	merged, err := ReadConfigPackage(reader)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	for k, v := range vars {
+		merged[k] = v
+	}
+	return merged, nil
+}
+
+// NewLayeredConfig splits the configuration variables into variables
+// shared between nodes and the variables specific to a single node.
+// nodeVars lists the names of node-specific variables.
+//
+// The shared variables are stored in a base package with the repository and
+// version of prefix and named after prefix and the hash of the variables, so nodes
+// with identical shared configuration reference the same package
+func NewLayeredConfig(vars map[string]string, nodeVars []string, prefix loc.Locator) (*LayeredConfig, error) {
+	config := LayeredConfig{
+		BaseVars: make(map[string]string),
+		Vars:     make(map[string]string),
+	}
+	for k, v := range vars {
+		config.BaseVars[k] = v
+	}
+	for _, k := range nodeVars {
+		if v, ok := config.BaseVars[k]; ok {
+			config.Vars[k] = v
+			delete(config.BaseVars, k)
+		}
+	}
+	b, err := json.Marshal(config.BaseVars)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	hash := sha256.Sum256(b)
+	base, err := loc.NewLocator(prefix.Repository,
+		fmt.Sprintf("%v-%v", prefix.Name, hex.EncodeToString(hash[:])[:12]),
+		prefix.Version)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	config.Base = *base
+	return &config, nil
+}
+
+// LayeredConfig is a configuration split into a base package with
+// the variables shared between nodes and a node package with the variables
+// specific to a single node
+type LayeredConfig struct {
+	// Base references the package with the shared variables
+	Base loc.Locator
+	// BaseVars are the variables shared between nodes
+	BaseVars map[string]string
+	// Vars are the node-specific variables
+	Vars map[string]string
+}
+
+// CreateBase creates the base package with the shared variables in packages
+// unless it already exists
+func (r LayeredConfig) CreateBase(packages PackageService, labels map[string]string) error {
+	var buf bytes.Buffer
+	if err := writeConfigVars(r.BaseVars, nil, &buf); err != nil {
+		return trace.Wrap(err)
+	}
+	_, err := packages.CreatePackage(r.Base, &buf, WithLabels(labels))
+	if err != nil && !trace.IsAlreadyExists(err) {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// Write writes the node configuration package referencing the base package into w
+func (r LayeredConfig) Write(w io.Writer) error {
+	return writeConfigVars(r.Vars, &r.Base, w)
+}
+
+// ConfigEnvVars returns the names of the environment variables for
+// the configuration parameters with the specified command line names
+func ConfigEnvVars(config *schema.Config, params []string) (vars []string) {
+	for _, param := range config.Params {
+		for _, name := range params {
+			if param.CLIName() == name {
+				k, _ := param.EnvVars()
+				vars = append(vars, k)
+			}
+		}
+	}
+	return vars
+}
+
+// readConfigPackage returns the variables from the configuration package read from r
+// along with the reference to its base package, if any
+func readConfigPackage(r io.Reader) (vars map[string]string, base *loc.Locator, err error) {
 	m, files, err := ReadPackage(r)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	if m.Label("type") != "orbit/config" {
-		return nil, trace.Errorf(
+		return nil, nil, trace.Errorf(
 			"expected label 'type':'orbit/config', got: '%v'", m.Label("type"))
 	}
 
@@ -59,14 +209,20 @@ func ReadConfigPackage(r io.Reader) (map[string]string, error) {
 		}
 	}
 	if bytes == nil {
-		return nil, trace.Errorf(
+		return nil, nil, trace.Errorf(
 			"expected label 'type':'orbit/config', got: '%v'", m.Label("type"))
 	}
 
 	var vals map[string]string
 	if err := json.Unmarshal(bytes, &vals); err != nil {
-		return nil, trace.Wrap(err, "failed to decode variables")
+		return nil, nil, trace.Wrap(err, "failed to decode variables")
 	}
 
-	return vals, nil
+	if ref := m.Label(ConfigBaseLabel); ref != "" {
+		base, err = loc.ParseLocator(ref)
+		if err != nil {
+			return nil, nil, trace.Wrap(err)
+		}
+	}
+	return vals, base, nil
 }
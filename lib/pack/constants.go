@@ -23,12 +23,26 @@ const (
 	LatestLabel = "latest"
 	// ConfigLabel means that this is a configuration package for another package
 	ConfigLabel = "config-package-for"
+	// ConfigBaseLabel references the package with the shared part of a layered
+	// configuration package
+	ConfigBaseLabel = "config-base"
+	// ConfigBaseForLabel means that this is a package with shared configuration
+	// for another package
+	ConfigBaseForLabel = "config-base-for"
 	// PurposeLabel describes the package purpose
 	PurposeLabel = "purpose"
 	// AdvertiseIPLabel contains advertise IP of the server the package is for
 	AdvertiseIPLabel = "advertise-ip"
 	// OperationIDLabel contains ID of the operation the package was configured for
 	OperationIDLabel = "operation-id"
+	// SignatureLabel contains the package signature in the
+	// <key ID>:<base64-encoded signature> format
+	SignatureLabel = "signature"
+	// PinnedLabel marks packages protected from deletion
+	PinnedLabel = "pinned"
+	// LifecycleLabel contains the lifecycle status of a package version,
+	// e.g. deprecated or end-of-life
+	LifecycleLabel = "lifecycle"
 
 	// PurposeCA marks the planet certificate authority package
 	PurposeCA = "ca"
@@ -42,6 +56,8 @@ const (
 	PurposePlanetSecrets = "planet-secrets"
 	// PurposePlanetConfig marks packages with planet config
 	PurposePlanetConfig = "planet-config"
+	// PurposePlanetConfigBase marks packages with planet config shared between nodes
+	PurposePlanetConfigBase = "planet-config-base"
 	// PurposeRuntime marks a package as a runtime container package
 	PurposeRuntime = "runtime"
 	// PurposeTeleportMasterConfig marks package with teleport master config
@@ -55,6 +71,9 @@ const (
 	PurposeMetadata = "metadata"
 	// PurposeRPCCredentials marks a package as a package with agent RPC credentials
 	PurposeRPCCredentials = "rpc-secrets"
+	// PurposeOperationPlan marks a package with the archived plan
+	// of an abandoned operation
+	PurposeOperationPlan = "operation-plan"
 )
 
 // RuntimePackageLabels identifies the runtime package
@@ -62,6 +81,11 @@ var RuntimePackageLabels = map[string]string{
 	PurposeLabel: PurposeRuntime,
 }
 
+// PinnedLabels defines a label set for a pinned package
+var PinnedLabels = map[string]string{
+	PinnedLabel: PinnedLabel,
+}
+
 // InstalledLabels defines a label set for an installed package
 var InstalledLabels = map[string]string{
 	InstalledLabel: InstalledLabel,
@@ -0,0 +1,469 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pack
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/loc"
+
+	"github.com/gravitational/trace"
+	"github.com/klauspost/compress/zstd"
+)
+
+// DeltaReader is implemented by package services that can transfer
+// a package as a delta against another version of the same package
+type DeltaReader interface {
+	// ReadPackageDelta returns the envelope of the package specified with loc
+	// and the delta that reconstructs its data from the data of the base package.
+	// baseDigest is the expected SHA256 digest of the base package data
+	ReadPackageDelta(loc, base loc.Locator, baseDigest string) (*PackageEnvelope, io.ReadCloser, error)
+}
+
+// ReadPackageDelta computes the delta between the packages specified with
+// base and loc in the package service.
+//
+// Returns trace.CompareFailed if the digest of the base package does not
+// match baseDigest, i.e. the requester has different data under the same version
+func ReadPackageDelta(packages PackageService, loc, base loc.Locator, baseDigest string) (*PackageEnvelope, io.ReadCloser, error) {
+	baseEnv, err := packages.ReadPackageEnvelope(base)
+	if err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+	if baseEnv.SHA256 == "" || baseEnv.SHA256 != baseDigest {
+		return nil, nil, trace.CompareFailed("package %v digest %q does not match %q",
+			base, baseEnv.SHA256, baseDigest)
+	}
+	if baseEnv.SizeBytes > defaults.PackageDeltaMaxBaseSize {
+		return nil, nil, trace.BadParameter("package %v is too large to be used as delta base", base)
+	}
+	baseData, err := spoolPackage(packages, base)
+	if err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+	env, reader, err := packages.ReadPackage(loc)
+	if err != nil {
+		baseData.Close()
+		return nil, nil, trace.Wrap(err)
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		defer baseData.Close()
+		defer reader.Close()
+		pw.CloseWithError(WriteDelta(pw, baseData, reader))
+	}()
+	return env, pr, nil
+}
+
+// ReadPackageWithDelta reads the package specified with loc from src as a delta
+// against the package specified with base from dst.
+//
+// The package data is reconstructed into a temporary file and verified
+// against the digest from the envelope before it is returned.
+// The file is removed when the returned reader is closed
+func ReadPackageWithDelta(src DeltaReader, dst PackageService, loc, base loc.Locator) (*PackageEnvelope, io.ReadCloser, error) {
+	baseEnv, err := dst.ReadPackageEnvelope(base)
+	if err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+	baseData, err := spoolPackage(dst, base)
+	if err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+	defer baseData.Close()
+	env, delta, err := src.ReadPackageDelta(loc, base, baseEnv.SHA256)
+	if err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+	defer delta.Close()
+	if env.SHA256 == "" {
+		return nil, nil, trace.NotFound("package %v has no digest to verify the delta", loc)
+	}
+	file, err := newTempFile()
+	if err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+	hash := sha256.New()
+	err = ApplyDelta(io.MultiWriter(file, hash), baseData, delta)
+	if err == nil {
+		if digest := fmt.Sprintf("%x", hash.Sum(nil)); digest != env.SHA256 {
+			err = trace.CompareFailed("package %v reconstructed from delta: digest %v does not match expected %v",
+				loc, digest, env.SHA256)
+		}
+	}
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+		err = trace.ConvertSystemError(err)
+	}
+	if err != nil {
+		file.Close()
+		return nil, nil, trace.Wrap(err)
+	}
+	return env, file, nil
+}
+
+// WriteDelta writes the delta that reconstructs the data read from target
+// from the base data into w.
+//
+// The delta is computed rsync-style: the blocks of the base are indexed with
+// a weak rolling checksum that is matched against every offset of the target
+// and verified by comparing the data. The matching ranges are encoded as
+// references into the base, the rest of the target is sent as zstd-compressed
+// literal data
+func WriteDelta(w io.Writer, base io.ReaderAt, target io.Reader) error {
+	index, err := newDeltaIndex(base)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	encoder, err := newDeltaEncoder(w)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	in := bufio.NewReader(target)
+	buf := make([]byte, 0, deltaBufferSize)
+	var pos, lit int
+	var a, b uint32
+	var hashed, eof bool
+	var next int64
+	for {
+		if len(buf)-pos < deltaBlockSize {
+			if eof {
+				break
+			}
+			// flush the pending literal data and refill the buffer
+			if err := encoder.data(buf[lit:pos]); err != nil {
+				return trace.Wrap(err)
+			}
+			n := copy(buf, buf[pos:len(buf)])
+			buf = buf[:n]
+			pos, lit = 0, 0
+			n, err := io.ReadFull(in, buf[len(buf):cap(buf)])
+			buf = buf[:len(buf)+n]
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				eof = true
+			} else if err != nil {
+				return trace.Wrap(err)
+			}
+			continue
+		}
+		window := buf[pos : pos+deltaBlockSize]
+		if !hashed {
+			a, b = weakChecksum(window)
+			hashed = true
+		}
+		offset, ok, err := index.match(a|b<<16, window, next)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if ok {
+			if err := encoder.data(buf[lit:pos]); err != nil {
+				return trace.Wrap(err)
+			}
+			if err := encoder.copy(offset, deltaBlockSize); err != nil {
+				return trace.Wrap(err)
+			}
+			pos += deltaBlockSize
+			lit = pos
+			next = offset + deltaBlockSize
+			hashed = false
+			continue
+		}
+		if pos+deltaBlockSize < len(buf) {
+			out, added := uint32(buf[pos]), uint32(buf[pos+deltaBlockSize])
+			a = (a - out + added) & 0xffff
+			b = (b - deltaBlockSize*out + a) & 0xffff
+		} else {
+			hashed = false
+		}
+		pos++
+	}
+	if err := encoder.data(buf[lit:]); err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(encoder.close())
+}
+
+// ApplyDelta reconstructs the data from the base data and the delta
+// produced by WriteDelta and writes it into w
+func ApplyDelta(w io.Writer, base io.ReaderAt, delta io.Reader) error {
+	magic := make([]byte, len(deltaMagic))
+	if _, err := io.ReadFull(delta, magic); err != nil {
+		return trace.BadParameter("failed to read delta header: %v", err)
+	}
+	if !bytes.Equal(magic, deltaMagic) {
+		return trace.BadParameter("unsupported delta format")
+	}
+	decoder, err := zstd.NewReader(delta)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer decoder.Close()
+	in := bufio.NewReader(decoder)
+	for {
+		op, err := in.ReadByte()
+		if err != nil {
+			return trace.BadParameter("truncated delta: %v", err)
+		}
+		switch op {
+		case deltaOpCopy:
+			offset, err := binary.ReadUvarint(in)
+			if err != nil {
+				return trace.BadParameter("truncated delta: %v", err)
+			}
+			length, err := binary.ReadUvarint(in)
+			if err != nil {
+				return trace.BadParameter("truncated delta: %v", err)
+			}
+			n, err := io.Copy(w, io.NewSectionReader(base, int64(offset), int64(length)))
+			if err != nil {
+				return trace.Wrap(err)
+			}
+			if n != int64(length) {
+				return trace.BadParameter("delta references data past the end of the base")
+			}
+		case deltaOpData:
+			length, err := binary.ReadUvarint(in)
+			if err != nil {
+				return trace.BadParameter("truncated delta: %v", err)
+			}
+			if _, err := io.CopyN(w, in, int64(length)); err != nil {
+				return trace.BadParameter("truncated delta: %v", err)
+			}
+		case deltaOpEnd:
+			return nil
+		default:
+			return trace.BadParameter("unsupported delta operation %v", op)
+		}
+	}
+}
+
+// newDeltaIndex returns the index of the blocks of the base data
+func newDeltaIndex(base io.ReaderAt) (*deltaIndex, error) {
+	index := &deltaIndex{
+		base:    base,
+		blocks:  make(map[uint32][]int64),
+		scratch: make([]byte, deltaBlockSize),
+	}
+	block := make([]byte, deltaBlockSize)
+	for offset := int64(0); ; offset += deltaBlockSize {
+		n, err := base.ReadAt(block, offset)
+		if err != nil && err != io.EOF {
+			return nil, trace.Wrap(err)
+		}
+		if n < deltaBlockSize {
+			// the trailing partial block is never matched
+			return index, nil
+		}
+		a, b := weakChecksum(block)
+		checksum := a | b<<16
+		if len(index.blocks[checksum]) < deltaMaxCandidates {
+			index.blocks[checksum] = append(index.blocks[checksum], offset)
+		}
+		index.filter[checksum%deltaFilterBits/64] |= 1 << (checksum % 64)
+	}
+}
+
+type deltaIndex struct {
+	base io.ReaderAt
+	// blocks maps weak checksums to the offsets of the base blocks
+	blocks map[uint32][]int64
+	// filter is the bitmap of the weak checksums present in the index
+	// that rules out most mismatches without a map lookup
+	filter [deltaFilterBits / 64]uint64
+	// scratch is the buffer for the base blocks being compared
+	scratch []byte
+}
+
+// match returns the offset of the base block with the same data as the window.
+// The block following the previous match is preferred to keep copies contiguous
+func (r *deltaIndex) match(checksum uint32, window []byte, next int64) (offset int64, ok bool, err error) {
+	if r.filter[checksum%deltaFilterBits/64]&(1<<(checksum%64)) == 0 {
+		return 0, false, nil
+	}
+	candidates := r.blocks[checksum]
+	for _, candidate := range candidates {
+		if candidate == next {
+			candidates = append([]int64{next}, candidates...)
+			break
+		}
+	}
+	for _, candidate := range candidates {
+		n, err := r.base.ReadAt(r.scratch, candidate)
+		if n < deltaBlockSize {
+			return 0, false, trace.Wrap(err)
+		}
+		if bytes.Equal(r.scratch, window) {
+			return candidate, true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+// weakChecksum returns the two halves of the rsync rolling checksum of the block
+func weakChecksum(block []byte) (a, b uint32) {
+	for i, c := range block {
+		a += uint32(c)
+		b += uint32(len(block)-i) * uint32(c)
+	}
+	return a & 0xffff, b & 0xffff
+}
+
+func newDeltaEncoder(w io.Writer) (*deltaEncoder, error) {
+	if _, err := w.Write(deltaMagic); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	encoder, err := zstd.NewWriter(w)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &deltaEncoder{
+		encoder: encoder,
+		w:       bufio.NewWriter(encoder),
+	}, nil
+}
+
+// deltaEncoder writes the delta operations.
+// Adjacent copies are coalesced into a single operation
+type deltaEncoder struct {
+	encoder *zstd.Encoder
+	w       *bufio.Writer
+	// offset and length describe the pending copy
+	offset, length int64
+}
+
+func (r *deltaEncoder) copy(offset, length int64) error {
+	if r.length != 0 && r.offset+r.length == offset {
+		r.length += length
+		return nil
+	}
+	if err := r.flush(); err != nil {
+		return trace.Wrap(err)
+	}
+	r.offset, r.length = offset, length
+	return nil
+}
+
+func (r *deltaEncoder) data(data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	if err := r.flush(); err != nil {
+		return trace.Wrap(err)
+	}
+	r.op(deltaOpData, uint64(len(data)))
+	_, err := r.w.Write(data)
+	return trace.Wrap(err)
+}
+
+func (r *deltaEncoder) flush() error {
+	if r.length == 0 {
+		return nil
+	}
+	r.op(deltaOpCopy, uint64(r.offset), uint64(r.length))
+	r.length = 0
+	return nil
+}
+
+func (r *deltaEncoder) op(op byte, args ...uint64) {
+	var buf [binary.MaxVarintLen64]byte
+	r.w.WriteByte(op)
+	for _, arg := range args {
+		n := binary.PutUvarint(buf[:], arg)
+		r.w.Write(buf[:n])
+	}
+}
+
+func (r *deltaEncoder) close() error {
+	if err := r.flush(); err != nil {
+		return trace.Wrap(err)
+	}
+	r.w.WriteByte(deltaOpEnd)
+	if err := r.w.Flush(); err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(r.encoder.Close())
+}
+
+// spoolPackage returns the data of the specified package
+// as a temporary file removed when closed
+func spoolPackage(packages PackageService, loc loc.Locator) (*tempFile, error) {
+	_, reader, err := packages.ReadPackage(loc)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer reader.Close()
+	file, err := newTempFile()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if _, err := io.Copy(file, reader); err != nil {
+		file.Close()
+		return nil, trace.Wrap(err)
+	}
+	return file, nil
+}
+
+func newTempFile() (*tempFile, error) {
+	file, err := ioutil.TempFile("", "package-delta")
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	return &tempFile{File: file}, nil
+}
+
+// tempFile is a file removed when closed
+type tempFile struct {
+	*os.File
+}
+
+// Close closes and removes the file
+func (r *tempFile) Close() error {
+	err := r.File.Close()
+	if errRemove := os.Remove(r.Name()); errRemove != nil && err == nil {
+		err = errRemove
+	}
+	return trace.ConvertSystemError(err)
+}
+
+const (
+	// deltaBlockSize is the size of the base blocks matched in the target
+	deltaBlockSize = 4096
+	// deltaBufferSize is the size of the target data scanned at once
+	deltaBufferSize = 1024 * 1024
+	// deltaMaxCandidates is the maximum number of the base blocks
+	// indexed under the same checksum
+	deltaMaxCandidates = 8
+	// deltaFilterBits is the size of the weak checksum filter
+	deltaFilterBits = 1 << 20
+)
+
+const (
+	deltaOpEnd byte = iota
+	deltaOpCopy
+	deltaOpData
+)
+
+// deltaMagic starts a package delta
+var deltaMagic = []byte("GRAVDLT1")
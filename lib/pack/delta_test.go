@@ -0,0 +1,82 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pack
+
+import (
+	"bytes"
+	"math/rand"
+
+	. "gopkg.in/check.v1"
+)
+
+type DeltaSuite struct{}
+
+var _ = Suite(&DeltaSuite{})
+
+func (s *DeltaSuite) TestDeltaRoundtrip(c *C) {
+	random := rand.New(rand.NewSource(1))
+	base := make([]byte, 1024*1024+100)
+	random.Read(base)
+	inserted := make([]byte, 5000)
+	random.Read(inserted)
+
+	var target []byte
+	target = append(target, base[:300000]...)
+	target = append(target, inserted...)
+	target = append(target, base[300000:700000]...)
+	target = append(target, base[750000:]...)
+	target[900000] ^= 0xff
+
+	var delta bytes.Buffer
+	c.Assert(WriteDelta(&delta, bytes.NewReader(base), bytes.NewReader(target)), IsNil)
+	// only the inserted and changed data is sent
+	c.Assert(delta.Len() < 32*1024, Equals, true, Commentf("delta size %v", delta.Len()))
+
+	var out bytes.Buffer
+	c.Assert(ApplyDelta(&out, bytes.NewReader(base), &delta), IsNil)
+	c.Assert(bytes.Equal(out.Bytes(), target), Equals, true)
+}
+
+func (s *DeltaSuite) TestDeltaEdgeCases(c *C) {
+	random := rand.New(rand.NewSource(2))
+	data := make([]byte, 3*deltaBlockSize/2)
+	random.Read(data)
+	for _, tc := range []struct {
+		comment      string
+		base, target []byte
+	}{
+		{comment: "empty base", base: nil, target: data},
+		{comment: "empty target", base: data, target: nil},
+		{comment: "short target", base: data, target: data[:10]},
+		{comment: "identical", base: data, target: data},
+	} {
+		comment := Commentf(tc.comment)
+		var delta, out bytes.Buffer
+		c.Assert(WriteDelta(&delta, bytes.NewReader(tc.base), bytes.NewReader(tc.target)), IsNil, comment)
+		c.Assert(ApplyDelta(&out, bytes.NewReader(tc.base), &delta), IsNil, comment)
+		c.Assert(bytes.Equal(out.Bytes(), tc.target), Equals, true, comment)
+	}
+}
+
+func (s *DeltaSuite) TestRejectsTruncatedDelta(c *C) {
+	data := bytes.Repeat([]byte("data"), deltaBlockSize)
+	var delta bytes.Buffer
+	c.Assert(WriteDelta(&delta, bytes.NewReader(nil), bytes.NewReader(data)), IsNil)
+	truncated := delta.Bytes()[:delta.Len()-10]
+	var out bytes.Buffer
+	c.Assert(ApplyDelta(&out, bytes.NewReader(nil), bytes.NewReader(truncated)), NotNil)
+}
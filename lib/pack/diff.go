@@ -0,0 +1,161 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pack
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+
+	"github.com/gravitational/gravity/lib/archive"
+	"github.com/gravitational/gravity/lib/loc"
+
+	"github.com/gravitational/trace"
+)
+
+// PackageDiff describes the difference between the files of two packages
+type PackageDiff struct {
+	// From is the package the changes are relative to
+	From loc.Locator `json:"from"`
+	// To is the changed package
+	To loc.Locator `json:"to"`
+	// Added lists files that only exist in the changed package
+	Added []FileInfo `json:"added,omitempty"`
+	// Removed lists files that only exist in the original package
+	Removed []FileInfo `json:"removed,omitempty"`
+	// Changed lists files that differ between the packages
+	Changed []FileChange `json:"changed,omitempty"`
+}
+
+// IsEmpty returns true if the packages have the same files
+func (r PackageDiff) IsEmpty() bool {
+	return len(r.Added) == 0 && len(r.Removed) == 0 && len(r.Changed) == 0
+}
+
+// FileInfo describes a file in a package
+type FileInfo struct {
+	// Path is the path of the file in the package
+	Path string `json:"path"`
+	// SizeBytes is the size of the file
+	SizeBytes int64 `json:"size_bytes"`
+	// Digest is the SHA256 digest of the file contents
+	Digest string `json:"digest,omitempty"`
+	// Mode is the file mode
+	Mode int64 `json:"mode"`
+	// Link is the target of a symbolic or hard link
+	Link string `json:"link,omitempty"`
+}
+
+// differs returns true if the file differs from the other file
+func (r FileInfo) differs(other FileInfo) bool {
+	return r.Digest != other.Digest || r.Mode != other.Mode || r.Link != other.Link
+}
+
+// FileChange describes a file that differs between two packages
+type FileChange struct {
+	// Path is the path of the file in the package
+	Path string `json:"path"`
+	// Before describes the file in the original package
+	Before FileInfo `json:"before"`
+	// After describes the file in the changed package
+	After FileInfo `json:"after"`
+}
+
+// DiffPackages compares the files of the package specified with from
+// with the files of the package specified with to.
+// Directories are not compared
+func DiffPackages(packages PackageService, from, to loc.Locator) (*PackageDiff, error) {
+	before, err := readPackageFiles(packages, from)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	after, err := readPackageFiles(packages, to)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	diff := PackageDiff{From: from, To: to}
+	for path, file := range after {
+		original, ok := before[path]
+		if !ok {
+			diff.Added = append(diff.Added, file)
+			continue
+		}
+		if original.differs(file) {
+			diff.Changed = append(diff.Changed, FileChange{
+				Path:   path,
+				Before: original,
+				After:  file,
+			})
+		}
+	}
+	for path, file := range before {
+		if _, ok := after[path]; !ok {
+			diff.Removed = append(diff.Removed, file)
+		}
+	}
+	sort.Slice(diff.Added, func(i, j int) bool { return diff.Added[i].Path < diff.Added[j].Path })
+	sort.Slice(diff.Removed, func(i, j int) bool { return diff.Removed[i].Path < diff.Removed[j].Path })
+	sort.Slice(diff.Changed, func(i, j int) bool { return diff.Changed[i].Path < diff.Changed[j].Path })
+	return &diff, nil
+}
+
+// readPackageFiles returns the files of the specified package keyed by path
+func readPackageFiles(packages PackageService, locator loc.Locator) (map[string]FileInfo, error) {
+	_, reader, err := packages.ReadPackage(locator)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer reader.Close()
+	decompressed, err := archive.DecompressStream(reader)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer decompressed.Close()
+	files := make(map[string]FileInfo)
+	tarball := tar.NewReader(decompressed)
+	for {
+		header, err := tarball.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, trace.Wrap(err, "failed to read package %v", locator)
+		}
+		file := FileInfo{
+			Path: path.Clean(header.Name),
+			Mode: header.Mode,
+			Link: header.Linkname,
+		}
+		switch header.Typeflag {
+		case tar.TypeReg, tar.TypeRegA:
+			hash := sha256.New()
+			file.SizeBytes, err = io.Copy(hash, tarball)
+			if err != nil {
+				return nil, trace.Wrap(err, "failed to read %v from package %v", header.Name, locator)
+			}
+			file.Digest = fmt.Sprintf("%x", hash.Sum(nil))
+		case tar.TypeSymlink, tar.TypeLink:
+		default:
+			continue
+		}
+		files[file.Path] = file
+	}
+	return files, nil
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pack
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+
+	"github.com/gravitational/gravity/lib/archive"
+	"github.com/gravitational/gravity/lib/loc"
+
+	"github.com/gravitational/trace"
+	. "gopkg.in/check.v1"
+)
+
+type DiffSuite struct{}
+
+var _ = Suite(&DiffSuite{})
+
+func (s *DiffSuite) TestDiffsPackages(c *C) {
+	from := loc.MustParseLocator("example.com/planet:1.0.0")
+	to := loc.MustParseLocator("example.com/planet:1.0.1")
+	packages := dataPackages{data: map[loc.Locator][]byte{
+		from: archive.MustCreateMemArchive([]*archive.Item{
+			archive.DirItem("rootfs"),
+			archive.ItemFromString("rootfs/unchanged", "same"),
+			archive.ItemFromString("rootfs/changed", "before"),
+			archive.ItemFromStringMode("rootfs/mode", "same", 0644),
+			archive.ItemFromString("rootfs/removed", "removed"),
+		}).Bytes(),
+		to: archive.MustCreateMemArchive([]*archive.Item{
+			archive.DirItem("rootfs"),
+			archive.ItemFromString("rootfs/unchanged", "same"),
+			archive.ItemFromString("rootfs/changed", "after!"),
+			archive.ItemFromStringMode("rootfs/mode", "same", 0755),
+			archive.ItemFromString("rootfs/added", "added"),
+		}).Bytes(),
+	}}
+
+	diff, err := DiffPackages(packages, from, to)
+	c.Assert(err, IsNil)
+	c.Assert(diff.IsEmpty(), Equals, false)
+	c.Assert(diff.From, Equals, from)
+	c.Assert(diff.To, Equals, to)
+	c.Assert(paths(diff.Added), DeepEquals, []string{"rootfs/added"})
+	c.Assert(diff.Added[0].SizeBytes, Equals, int64(5))
+	c.Assert(paths(diff.Removed), DeepEquals, []string{"rootfs/removed"})
+	c.Assert(diff.Changed, HasLen, 2)
+	changed := diff.Changed[0]
+	c.Assert(changed.Path, Equals, "rootfs/changed")
+	c.Assert(changed.Before.SizeBytes, Equals, int64(6))
+	c.Assert(changed.After.SizeBytes, Equals, int64(6))
+	c.Assert(changed.Before.Digest, Not(Equals), changed.After.Digest)
+	c.Assert(diff.Changed[1].Path, Equals, "rootfs/mode")
+	c.Assert(diff.Changed[1].Before.Digest, Equals, diff.Changed[1].After.Digest)
+
+	diff, err = DiffPackages(packages, from, from)
+	c.Assert(err, IsNil)
+	c.Assert(diff.IsEmpty(), Equals, true)
+}
+
+func paths(files []FileInfo) (result []string) {
+	for _, file := range files {
+		result = append(result, file.Path)
+	}
+	return result
+}
+
+// dataPackages is a package service that serves package data from memory
+type dataPackages struct {
+	PackageService
+	data map[loc.Locator][]byte
+}
+
+func (r dataPackages) ReadPackage(locator loc.Locator) (*PackageEnvelope, io.ReadCloser, error) {
+	data, ok := r.data[locator]
+	if !ok {
+		return nil, nil, trace.NotFound("package %v not found", locator)
+	}
+	return &PackageEnvelope{Locator: locator, SizeBytes: int64(len(data))},
+		ioutil.NopCloser(bytes.NewReader(data)), nil
+}
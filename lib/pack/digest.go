@@ -0,0 +1,98 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pack
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+
+	"github.com/gravitational/trace"
+)
+
+// NewDigestReader returns a reader that computes the SHA256 digest of the data
+// read from r and fails with trace.CompareFailed once the stream is exhausted
+// if the digest does not match the expected one.
+//
+// If the expected digest is empty (e.g. for packages created before the
+// digest was recorded), r is returned as-is.
+//
+// If r is an io.ReadSeeker, so is the returned reader: the digest is only
+// verified if the data was read sequentially from the beginning of the stream
+func NewDigestReader(r io.ReadCloser, digest string) io.ReadCloser {
+	if digest == "" {
+		return r
+	}
+	reader := &digestReader{
+		ReadCloser: r,
+		expected:   digest,
+		hash:       sha256.New(),
+	}
+	if seeker, ok := r.(io.Seeker); ok {
+		return &digestReadSeeker{
+			digestReader: reader,
+			seeker:       seeker,
+		}
+	}
+	return reader
+}
+
+// Read reads data from the underlying reader and updates the digest.
+// Upon reaching the end of the stream, it compares the computed digest with
+// the expected one and returns trace.CompareFailed if they differ
+func (r *digestReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if r.skip {
+		return n, err
+	}
+	r.hash.Write(p[:n])
+	if err == io.EOF {
+		if digest := fmt.Sprintf("%x", r.hash.Sum(nil)); digest != r.expected {
+			return n, trace.CompareFailed(
+				"package data is corrupted: digest %v does not match expected %v",
+				digest, r.expected)
+		}
+	}
+	return n, err
+}
+
+type digestReader struct {
+	io.ReadCloser
+	expected string
+	hash     hash.Hash
+	// skip disables verification after a non-sequential read
+	skip bool
+}
+
+// Seek sets the offset for the next read.
+// Seeking to the beginning of the stream restarts verification,
+// seeking anywhere else disables it
+func (r *digestReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	pos, err := r.seeker.Seek(offset, whence)
+	if err != nil {
+		return pos, err
+	}
+	r.hash.Reset()
+	r.skip = pos != 0
+	return pos, nil
+}
+
+type digestReadSeeker struct {
+	*digestReader
+	seeker io.Seeker
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pack
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/gravitational/trace"
+	. "gopkg.in/check.v1"
+)
+
+type DigestSuite struct{}
+
+var _ = Suite(&DigestSuite{})
+
+func (s *DigestSuite) TestVerifiesDigest(c *C) {
+	path := s.writeFile(c, "package data")
+
+	f, err := os.Open(path)
+	c.Assert(err, IsNil)
+	defer f.Close()
+	data, err := ioutil.ReadAll(NewDigestReader(f, digest("package data")))
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "package data")
+
+	f, err = os.Open(path)
+	c.Assert(err, IsNil)
+	defer f.Close()
+	_, err = ioutil.ReadAll(NewDigestReader(f, digest("other data")))
+	c.Assert(trace.IsCompareFailed(err), Equals, true, Commentf("%v", err))
+}
+
+func (s *DigestSuite) TestSeekRestartsVerification(c *C) {
+	f, err := os.Open(s.writeFile(c, "package data"))
+	c.Assert(err, IsNil)
+	defer f.Close()
+
+	reader := NewDigestReader(f, digest("other data"))
+	seeker, ok := reader.(io.ReadSeeker)
+	c.Assert(ok, Equals, true)
+
+	// partial reads are not verified
+	_, err = seeker.Seek(8, io.SeekStart)
+	c.Assert(err, IsNil)
+	data, err := ioutil.ReadAll(seeker)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "data")
+
+	_, err = seeker.Seek(0, io.SeekStart)
+	c.Assert(err, IsNil)
+	_, err = ioutil.ReadAll(seeker)
+	c.Assert(trace.IsCompareFailed(err), Equals, true, Commentf("%v", err))
+}
+
+func (s *DigestSuite) writeFile(c *C, data string) (path string) {
+	path = filepath.Join(c.MkDir(), "package")
+	c.Assert(ioutil.WriteFile(path, []byte(data), 0644), IsNil)
+	return path
+}
+
+func digest(data string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(data)))
+}
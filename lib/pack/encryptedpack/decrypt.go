@@ -26,7 +26,7 @@ import (
 
 // DecryptPackage decrypts the specified package in the specified package service
 func DecryptPackage(p pack.PackageService, loc loc.Locator, encryptionKey string) error {
-	envelope, data, err := p.ReadPackage(loc)
+	envelope, err := p.ReadPackageEnvelope(loc)
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -40,10 +40,11 @@ func DecryptPackage(p pack.PackageService, loc loc.Locator, encryptionKey string
 
 	// wrap the package service into "encrypted pack" to decrypt the package
 	encryptedPack := New(p, encryptionKey)
-	envelope, data, err = encryptedPack.ReadPackage(loc)
+	envelope, data, err := encryptedPack.ReadPackage(loc)
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	defer data.Close()
 
 	options := envelope.Options()
 	options = append(options, pack.WithEncrypted(false))
@@ -52,3 +53,31 @@ func DecryptPackage(p pack.PackageService, loc loc.Locator, encryptionKey string
 	_, err = p.UpsertPackage(loc, data, options...)
 	return trace.Wrap(err)
 }
+
+// DecryptPackages decrypts all encrypted packages in the specified package service
+func DecryptPackages(p pack.PackageService, encryptionKey string) error {
+	locators, err := FindEncryptedPackages(p)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	for _, locator := range locators {
+		if err := DecryptPackage(p, locator, encryptionKey); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// FindEncryptedPackages returns all encrypted packages in the specified package service
+func FindEncryptedPackages(p pack.PackageService) (locators []loc.Locator, err error) {
+	err = pack.ForeachPackage(p, func(e pack.PackageEnvelope) error {
+		if e.Encrypted {
+			locators = append(locators, e.Locator)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return locators, nil
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package encryptedpack
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gravitational/gravity/lib/blob/fs"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/pack"
+	"github.com/gravitational/gravity/lib/pack/localpack"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/storage/keyval"
+
+	"github.com/gravitational/trace"
+	. "gopkg.in/check.v1"
+)
+
+func TestEncryptedPack(t *testing.T) { TestingT(t) }
+
+type DecryptSuite struct {
+	backend  storage.Backend
+	packages pack.PackageService
+}
+
+var _ = Suite(&DecryptSuite{})
+
+func (s *DecryptSuite) SetUpTest(c *C) {
+	dir := c.MkDir()
+	var err error
+	s.backend, err = keyval.NewBolt(keyval.BoltConfig{Path: filepath.Join(dir, "storage.db")})
+	c.Assert(err, IsNil)
+	objects, err := fs.New(dir)
+	c.Assert(err, IsNil)
+	s.packages, err = localpack.New(localpack.Config{
+		Backend:     s.backend,
+		UnpackedDir: filepath.Join(dir, defaults.UnpackedDir),
+		Objects:     objects,
+	})
+	c.Assert(err, IsNil)
+	c.Assert(s.packages.UpsertRepository("gravitational.io", time.Time{}), IsNil)
+}
+
+func (s *DecryptSuite) TearDownTest(c *C) {
+	if s.backend != nil {
+		s.backend.Close()
+	}
+}
+
+func (s *DecryptSuite) TestDecryptsPackages(c *C) {
+	encrypted := loc.MustParseLocator("gravitational.io/planet:1.0.0")
+	system := loc.MustParseLocator("gravitational.io/gravity:1.0.0")
+	encryptedPack := New(s.packages, "secret")
+	_, err := encryptedPack.CreatePackage(encrypted, strings.NewReader("planet"))
+	c.Assert(err, IsNil)
+	_, err = encryptedPack.CreatePackage(system, strings.NewReader("gravity"))
+	c.Assert(err, IsNil)
+
+	locators, err := FindEncryptedPackages(s.packages)
+	c.Assert(err, IsNil)
+	c.Assert(locators, DeepEquals, []loc.Locator{encrypted})
+
+	err = DecryptPackages(s.packages, "wrong")
+	c.Assert(trace.IsAccessDenied(err), Equals, true, Commentf("%v", err))
+
+	c.Assert(DecryptPackages(s.packages, "secret"), IsNil)
+	locators, err = FindEncryptedPackages(s.packages)
+	c.Assert(err, IsNil)
+	c.Assert(locators, HasLen, 0)
+
+	_, reader, err := s.packages.ReadPackage(encrypted)
+	c.Assert(err, IsNil)
+	defer reader.Close()
+	data, err := ioutil.ReadAll(reader)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "planet")
+}
@@ -17,8 +17,8 @@ limitations under the License.
 package encryptedpack
 
 import (
+	"context"
 	"io"
-	"io/ioutil"
 	"time"
 
 	"github.com/gravitational/gravity/lib/loc"
@@ -28,6 +28,7 @@ import (
 
 	"github.com/gravitational/trace"
 	log "github.com/sirupsen/logrus"
+	pgperrors "golang.org/x/crypto/openpgp/errors"
 )
 
 func New(packages pack.PackageService, encryptionKey string) *EncryptedPack {
@@ -70,6 +71,10 @@ func (p *EncryptedPack) GetPackages(repository string) ([]pack.PackageEnvelope,
 	return p.packages.GetPackages(repository)
 }
 
+func (p *EncryptedPack) GetPackagesBySelector(repository, selector string) ([]pack.PackageEnvelope, error) {
+	return p.packages.GetPackagesBySelector(repository, selector)
+}
+
 func (p *EncryptedPack) CreatePackage(locator loc.Locator, data io.Reader, options ...pack.PackageOption) (*pack.PackageEnvelope, error) {
 	if isSystemPackage(locator) {
 		return p.packages.CreatePackage(locator, data, options...)
@@ -104,6 +109,14 @@ func (p *EncryptedPack) DeletePackage(locator loc.Locator) error {
 	return p.packages.DeletePackage(locator)
 }
 
+func (p *EncryptedPack) PinPackage(locator loc.Locator) error {
+	return p.packages.PinPackage(locator)
+}
+
+func (p *EncryptedPack) UnpinPackage(locator loc.Locator) error {
+	return p.packages.UnpinPackage(locator)
+}
+
 func (p *EncryptedPack) ReadPackage(locator loc.Locator) (*pack.PackageEnvelope, io.ReadCloser, error) {
 	envelope, data, err := p.packages.ReadPackage(locator)
 	if err != nil {
@@ -115,15 +128,45 @@ func (p *EncryptedPack) ReadPackage(locator loc.Locator) (*pack.PackageEnvelope,
 	log.Infof("decrypting %v", locator)
 	decrypted, err := utils.DecryptPGP(data, p.encryptionKey)
 	if err != nil {
+		data.Close()
+		if trace.Unwrap(err) == pgperrors.ErrKeyIncorrect {
+			return nil, nil, trace.AccessDenied("incorrect encryption key for %v", locator)
+		}
 		return nil, nil, trace.Wrap(err)
 	}
-	return envelope, ioutil.NopCloser(decrypted), nil
+	// closing the decrypted stream releases the encrypted package data
+	return envelope, &readCloser{Reader: decrypted, Closer: data}, nil
 }
 
 func (p *EncryptedPack) ReadPackageEnvelope(locator loc.Locator) (*pack.PackageEnvelope, error) {
 	return p.packages.ReadPackageEnvelope(locator)
 }
 
+func (p *EncryptedPack) UpsertPackageAlias(alias, target loc.Locator) error {
+	return p.packages.UpsertPackageAlias(alias, target)
+}
+
+func (p *EncryptedPack) GetPackageAliases(repository string) ([]storage.PackageAlias, error) {
+	return p.packages.GetPackageAliases(repository)
+}
+
+func (p *EncryptedPack) DeletePackageAlias(alias loc.Locator) error {
+	return p.packages.DeletePackageAlias(alias)
+}
+
+func (p *EncryptedPack) WatchPackages(ctx context.Context, repository string) (<-chan pack.PackageEvent, error) {
+	return p.packages.WatchPackages(ctx, repository)
+}
+
+func (p *EncryptedPack) PrunePackages(req pack.PruneRequest) (*pack.PruneResponse, error) {
+	return p.packages.PrunePackages(req)
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
 func isSystemPackage(locator loc.Locator) bool {
 	for _, p := range systemPackages {
 		if p.Repository == locator.Repository && p.Name == locator.Name {
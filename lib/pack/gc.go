@@ -0,0 +1,309 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pack
+
+import (
+	"sort"
+
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/schema"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// PruneRequest describes which packages are in use when pruning
+// a package service
+type PruneRequest struct {
+	// Retain lists the packages that are in use, e.g. the installed
+	// cluster application or the update application of a pending operation.
+	// Retained application packages also retain their dependencies
+	Retain []loc.Locator `json:"retain,omitempty"`
+	// Operations lists the IDs of pending operations. Packages
+	// configured for these operations are retained
+	Operations []string `json:"operations,omitempty"`
+	// Nodes lists the advertise addresses of the current cluster nodes.
+	// Node configuration packages of other nodes are pruned.
+	// If empty, all nodes are considered current
+	Nodes []string `json:"nodes,omitempty"`
+	// Repositories lists the repositories to prune.
+	// If empty, all repositories are pruned
+	Repositories []string `json:"repositories,omitempty"`
+	// DryRun only returns the packages that would be pruned
+	DryRun bool `json:"dry_run"`
+}
+
+// PruneResponse describes the result of pruning a package service
+type PruneResponse struct {
+	// Packages lists the pruned packages or, in dry-run mode,
+	// the packages that would be pruned
+	Packages []PackageEnvelope `json:"packages"`
+}
+
+// SizeBytes returns the total size of the pruned packages
+func (r PruneResponse) SizeBytes() (size int64) {
+	for _, envelope := range r.Packages {
+		size += envelope.SizeBytes
+	}
+	return size
+}
+
+// Prune deletes the packages of the specified package service that are
+// not in use and returns them.
+//
+// A package is in use if it is installed or pinned, retained by the request, configured
+// for a pending operation, the latest node configuration package of a current
+// node, the target of a package alias, or if it is referenced by a package
+// in use: application packages reference their dependencies, configuration
+// packages reference their base configuration and are in turn referenced by
+// the package they configure.
+//
+// A failure to delete a package does not stop the pruning of other packages,
+// instead the errors are aggregated
+func Prune(packages PackageService, req PruneRequest) (*PruneResponse, error) {
+	repositories, err := packages.GetRepositories()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var envelopes []PackageEnvelope
+	var aliases []loc.Locator
+	for _, repository := range repositories {
+		items, err := packages.GetPackages(repository)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		envelopes = append(envelopes, items...)
+		repoAliases, err := packages.GetPackageAliases(repository)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		for _, alias := range repoAliases {
+			aliases = append(aliases, loc.Locator{
+				Repository: alias.Repository,
+				Name:       alias.Name,
+				Version:    alias.Version,
+			})
+		}
+	}
+	candidates, err := pruneCandidates(envelopes, aliases, req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if req.DryRun {
+		return &PruneResponse{Packages: candidates}, nil
+	}
+	var resp PruneResponse
+	var errors []error
+	for _, envelope := range candidates {
+		log.Infof("Pruning package %v.", envelope.Locator)
+		err := packages.DeletePackage(envelope.Locator)
+		if err != nil {
+			if !trace.IsNotFound(err) {
+				errors = append(errors, trace.Wrap(err, "failed to delete package %v",
+					envelope.Locator))
+			}
+			continue
+		}
+		resp.Packages = append(resp.Packages, envelope)
+	}
+	return &resp, trace.NewAggregate(errors...)
+}
+
+// pruneCandidates returns the packages from the specified list that are
+// not in use, ordered so that configuration packages are deleted before
+// the packages they reference
+func pruneCandidates(envelopes []PackageEnvelope, aliases []loc.Locator, req PruneRequest) ([]PackageEnvelope, error) {
+	index := make(map[loc.Locator]PackageEnvelope, len(envelopes))
+	for _, envelope := range envelopes {
+		index[envelope.Locator] = envelope
+	}
+	marker := &pruneMarker{
+		index:    index,
+		required: make(map[loc.Locator]struct{}),
+		configs:  configsByParent(envelopes, req.Nodes),
+	}
+	roots := append([]loc.Locator{}, req.Retain...)
+	roots = append(roots, aliases...)
+	roots = append(roots, latestNodePackages(envelopes, req)...)
+	for _, envelope := range envelopes {
+		if envelope.HasLabels(InstalledLabels) || envelope.IsPinned() {
+			roots = append(roots, envelope.Locator)
+		}
+		if isPendingOperation(envelope, req.Operations) {
+			roots = append(roots, envelope.Locator)
+		}
+	}
+	for _, root := range roots {
+		if err := marker.mark(root); err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+	var candidates []PackageEnvelope
+	for _, envelope := range envelopes {
+		if _, ok := marker.required[envelope.Locator]; ok {
+			continue
+		}
+		if len(req.Repositories) != 0 && !utils.StringInSlice(req.Repositories, envelope.Locator.Repository) {
+			continue
+		}
+		candidates = append(candidates, envelope)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return isConfigPackage(candidates[i]) && !isConfigPackage(candidates[j])
+	})
+	return candidates, nil
+}
+
+type pruneMarker struct {
+	index    map[loc.Locator]PackageEnvelope
+	required map[loc.Locator]struct{}
+	// configs maps a package to the configuration packages for it
+	configs map[loc.Locator][]loc.Locator
+}
+
+// mark marks the specified package and all packages it references as required
+func (r *pruneMarker) mark(locator loc.Locator) error {
+	if _, ok := r.required[locator]; ok {
+		return nil
+	}
+	r.required[locator] = struct{}{}
+	envelope, ok := r.index[locator]
+	if !ok {
+		return nil
+	}
+	references, err := packageReferences(envelope)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	references = append(references, r.configs[locator]...)
+	for _, reference := range references {
+		if err := r.mark(reference); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// packageReferences returns the packages the specified package references
+func packageReferences(envelope PackageEnvelope) (references []loc.Locator, err error) {
+	if base, ok := envelope.RuntimeLabels[ConfigBaseLabel]; ok {
+		locator, err := loc.ParseLocator(base)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		references = append(references, *locator)
+	}
+	if envelope.Type == "" || len(envelope.Manifest) == 0 {
+		return references, nil
+	}
+	manifest, err := schema.ParseManifestYAMLNoValidate(envelope.Manifest)
+	if err != nil {
+		return nil, trace.Wrap(err, "failed to parse manifest of %v", envelope.Locator)
+	}
+	references = append(references, manifest.AllPackageDependencies()...)
+	references = append(references, manifest.Dependencies.GetApps()...)
+	if base := manifest.Base(); base != nil {
+		references = append(references, *base)
+	}
+	return references, nil
+}
+
+// configsByParent maps packages to the configuration packages for them.
+// A configuration package configures the package with the name from its
+// configuration label and the same version.
+// Configuration packages of nodes other than the specified ones are skipped
+func configsByParent(envelopes []PackageEnvelope, nodes []string) map[loc.Locator][]loc.Locator {
+	configs := make(map[loc.Locator][]loc.Locator)
+	for _, envelope := range envelopes {
+		if !isCurrentNode(envelope, nodes) {
+			continue
+		}
+		parentRef, ok := envelope.RuntimeLabels[ConfigLabel]
+		if !ok {
+			parentRef, ok = envelope.RuntimeLabels[ConfigBaseForLabel]
+		}
+		if !ok {
+			continue
+		}
+		parent, err := loc.ParseLocator(parentRef)
+		if err != nil {
+			log.Warnf("Invalid configuration label on %v: %v.", envelope.Locator, err)
+			continue
+		}
+		key := loc.Locator{
+			Repository: parent.Repository,
+			Name:       parent.Name,
+			Version:    envelope.Locator.Version,
+		}
+		configs[key] = append(configs[key], envelope.Locator)
+	}
+	return configs
+}
+
+// latestNodePackages returns the latest version of each node configuration
+// package for the current nodes of the request.
+// Packages of pending operations are skipped as they are not in use yet
+func latestNodePackages(envelopes []PackageEnvelope, req PruneRequest) (latest []loc.Locator) {
+	byName := make(map[loc.Locator]loc.Locator)
+	for _, envelope := range envelopes {
+		if _, ok := envelope.RuntimeLabels[AdvertiseIPLabel]; !ok || !isCurrentNode(envelope, req.Nodes) {
+			continue
+		}
+		if isPendingOperation(envelope, req.Operations) {
+			continue
+		}
+		key := envelope.Locator.ZeroVersion()
+		current, ok := byName[key]
+		if !ok || isNewer(envelope.Locator, current) {
+			byName[key] = envelope.Locator
+		}
+	}
+	for _, locator := range byName {
+		latest = append(latest, locator)
+	}
+	return latest
+}
+
+// isNewer returns true if package a has a newer version than package b
+func isNewer(a, b loc.Locator) bool {
+	versionA, errA := a.SemVer()
+	versionB, errB := b.SemVer()
+	if errA != nil || errB != nil {
+		return a.Version > b.Version
+	}
+	return versionB.LessThan(*versionA)
+}
+
+// isCurrentNode returns false if the specified package is configured
+// for a node other than the specified ones
+func isCurrentNode(envelope PackageEnvelope, nodes []string) bool {
+	node, ok := envelope.RuntimeLabels[AdvertiseIPLabel]
+	return !ok || len(nodes) == 0 || utils.StringInSlice(nodes, node)
+}
+
+// isPendingOperation returns true if the specified package is configured
+// for one of the specified operations
+func isPendingOperation(envelope PackageEnvelope, operations []string) bool {
+	id, ok := envelope.RuntimeLabels[OperationIDLabel]
+	return ok && utils.StringInSlice(operations, id)
+}
+
+func isConfigPackage(envelope PackageEnvelope) bool {
+	_, ok := envelope.RuntimeLabels[ConfigLabel]
+	return ok
+}
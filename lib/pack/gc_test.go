@@ -0,0 +1,100 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pack
+
+import (
+	"github.com/gravitational/gravity/lib/loc"
+
+	. "gopkg.in/check.v1"
+)
+
+type GCSuite struct{}
+
+var _ = Suite(&GCSuite{})
+
+func (s *GCSuite) TestRetainsApplicationDependencies(c *C) {
+	envelopes := []PackageEnvelope{
+		{
+			Locator:  loc.MustParseLocator("example.com/app:2.0.0"),
+			Type:     "user",
+			Manifest: []byte(testManifest),
+		},
+		{Locator: loc.MustParseLocator("example.com/app:1.0.0")},
+		{Locator: loc.MustParseLocator("gravitational.io/planet:2.0.0")},
+		{Locator: loc.MustParseLocator("gravitational.io/planet:1.0.0")},
+		{Locator: loc.MustParseLocator("gravitational.io/dns-app:0.0.2")},
+		{Locator: loc.MustParseLocator("gravitational.io/dns-app:0.0.1")},
+		{
+			Locator: loc.MustParseLocator("example.com/planet-config-node1:2.0.0"),
+			RuntimeLabels: map[string]string{
+				ConfigLabel:      "gravitational.io/planet:0.0.0",
+				ConfigBaseLabel:  "example.com/planet-config-base:2.0.0",
+				AdvertiseIPLabel: "10.0.0.1",
+			},
+		},
+		{
+			Locator: loc.MustParseLocator("example.com/planet-config-node1:1.0.0"),
+			RuntimeLabels: map[string]string{
+				ConfigLabel:      "gravitational.io/planet:0.0.0",
+				ConfigBaseLabel:  "example.com/planet-config-base:1.0.0",
+				AdvertiseIPLabel: "10.0.0.1",
+			},
+		},
+		{
+			Locator: loc.MustParseLocator("example.com/planet-config-node2:2.0.0"),
+			RuntimeLabels: map[string]string{
+				ConfigLabel:      "gravitational.io/planet:0.0.0",
+				AdvertiseIPLabel: "10.0.0.2",
+			},
+		},
+		{Locator: loc.MustParseLocator("example.com/planet-config-base:2.0.0")},
+		{Locator: loc.MustParseLocator("example.com/planet-config-base:1.0.0")},
+	}
+	candidates, err := pruneCandidates(envelopes, nil, PruneRequest{
+		Retain: []loc.Locator{loc.MustParseLocator("example.com/app:2.0.0")},
+		Nodes:  []string{"10.0.0.1"},
+	})
+	c.Assert(err, IsNil)
+	c.Assert(locators(candidates), DeepEquals, []string{
+		// configuration packages go first
+		"example.com/planet-config-node1:1.0.0",
+		"example.com/planet-config-node2:2.0.0",
+		"example.com/app:1.0.0",
+		"gravitational.io/planet:1.0.0",
+		"gravitational.io/dns-app:0.0.1",
+		"example.com/planet-config-base:1.0.0",
+	})
+}
+
+func locators(envelopes []PackageEnvelope) (result []string) {
+	for _, envelope := range envelopes {
+		result = append(result, envelope.Locator.String())
+	}
+	return result
+}
+
+const testManifest = `apiVersion: bundle.gravitational.io/v2
+kind: Bundle
+metadata:
+  name: app
+  resourceVersion: 2.0.0
+dependencies:
+  packages:
+  - gravitational.io/planet:2.0.0
+  apps:
+  - gravitational.io/dns-app:0.0.2
+`
@@ -0,0 +1,437 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpcpack
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/pack"
+	"github.com/gravitational/gravity/lib/pack/grpcpack/proto"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+)
+
+// ClientConfig defines the gRPC package service client configuration
+type ClientConfig struct {
+	// Conn is the connection to the package service.
+	// The connection can be shared with other gRPC clients
+	Conn *grpc.ClientConn
+	// Timeout is the deadline of calls that do not transfer package data
+	Timeout time.Duration
+}
+
+// CheckAndSetDefaults validates the config and sets default values
+func (c *ClientConfig) CheckAndSetDefaults() error {
+	if c.Conn == nil {
+		return trace.BadParameter("missing Conn")
+	}
+	if c.Timeout == 0 {
+		c.Timeout = defaults.PackageRPCTimeout
+	}
+	return nil
+}
+
+// NewClient returns a new package service client that uses
+// the specified gRPC connection
+func NewClient(config ClientConfig) (*Client, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &Client{
+		ClientConfig: config,
+		client:       proto.NewPackageServiceClient(config.Conn),
+	}, nil
+}
+
+// Client is the gRPC package service client
+type Client struct {
+	ClientConfig
+	client proto.PackageServiceClient
+}
+
+// PackageDownloadURL is not supported by the gRPC transport
+func (c *Client) PackageDownloadURL(loc loc.Locator) string {
+	return ""
+}
+
+// PortalURL is not supported by the gRPC transport
+func (c *Client) PortalURL() string {
+	return ""
+}
+
+// UpsertRepository creates or updates a repository
+func (c *Client) UpsertRepository(repository string, expires time.Time) error {
+	ctx, cancel := c.callContext()
+	defer cancel()
+	req := &proto.UpsertRepositoryRequest{Repository: repository}
+	if !expires.IsZero() {
+		data, err := expires.MarshalText()
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		req.Expires = string(data)
+	}
+	_, err := c.client.UpsertRepository(ctx, req)
+	return fromStatus(err)
+}
+
+// DeleteRepository deletes a repository
+func (c *Client) DeleteRepository(repository string) error {
+	ctx, cancel := c.callContext()
+	defer cancel()
+	_, err := c.client.DeleteRepository(ctx, &proto.RepositoryRequest{Repository: repository})
+	return fromStatus(err)
+}
+
+// GetRepository returns the repository by name
+func (c *Client) GetRepository(repository string) (storage.Repository, error) {
+	ctx, cancel := c.callContext()
+	defer cancel()
+	payload, err := c.client.GetRepository(ctx, &proto.RepositoryRequest{Repository: repository})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return storage.UnmarshalRepository(payload.Data)
+}
+
+// GetRepositories returns the names of all repositories
+func (c *Client) GetRepositories() ([]string, error) {
+	ctx, cancel := c.callContext()
+	defer cancel()
+	repositories, err := c.client.GetRepositories(ctx, &types.Empty{})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return append([]string{}, repositories.Names...), nil
+}
+
+// GetPackages returns a list of packages in the repository
+func (c *Client) GetPackages(repository string) ([]pack.PackageEnvelope, error) {
+	return c.getPackages(repository, "")
+}
+
+// GetPackagesBySelector returns a list of packages in repository with labels
+// matching the selector. The selector is evaluated by the server
+func (c *Client) GetPackagesBySelector(repository, selector string) ([]pack.PackageEnvelope, error) {
+	// validate the selector locally to fail early
+	if _, err := pack.ParseSelector(selector); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return c.getPackages(repository, selector)
+}
+
+func (c *Client) getPackages(repository, selector string) ([]pack.PackageEnvelope, error) {
+	ctx, cancel := c.callContext()
+	defer cancel()
+	payload, err := c.client.GetPackages(ctx, &proto.GetPackagesRequest{
+		Repository: repository,
+		Selector:   selector,
+	})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	var packages []pack.PackageEnvelope
+	if err := json.Unmarshal(payload.Data, &packages); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return packages, nil
+}
+
+// CreatePackage creates a new package from the data
+func (c *Client) CreatePackage(loc loc.Locator, data io.Reader, options ...pack.PackageOption) (*pack.PackageEnvelope, error) {
+	return c.writePackage(loc, data, false, options...)
+}
+
+// UpsertPackage creates or replaces the package with the data
+func (c *Client) UpsertPackage(loc loc.Locator, data io.Reader, options ...pack.PackageOption) (*pack.PackageEnvelope, error) {
+	return c.writePackage(loc, data, true, options...)
+}
+
+func (c *Client) writePackage(loc loc.Locator, data io.Reader, upsert bool, options ...pack.PackageOption) (*pack.PackageEnvelope, error) {
+	pkg := storage.Package{
+		Repository: loc.Repository,
+		Name:       loc.Name,
+		Version:    loc.Version,
+	}
+	for _, option := range options {
+		option(&pkg)
+	}
+	// package transfers are not limited by the call timeout
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := c.client.WritePackage(ctx)
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	err = stream.Send(&proto.PackageChunk{
+		Header: &proto.PackageHeader{
+			Locator:   loc.String(),
+			Upsert:    upsert,
+			Labels:    pkg.RuntimeLabels,
+			Hidden:    pkg.Hidden,
+			Encrypted: pkg.Encrypted,
+			Type:      pkg.Type,
+			Manifest:  pkg.Manifest,
+			CreatedBy: pkg.CreatedBy,
+		},
+	})
+	if err == nil {
+		err = sendChunks(stream, data)
+	}
+	// the stream returns io.EOF if the server has failed,
+	// the actual error is returned by CloseAndRecv
+	if err != nil && err != io.EOF {
+		return nil, fromStatus(err)
+	}
+	payload, err := stream.CloseAndRecv()
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	var envelope pack.PackageEnvelope
+	if err := json.Unmarshal(payload.Data, &envelope); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &envelope, nil
+}
+
+// UpdatePackageLabels updates package's labels
+func (c *Client) UpdatePackageLabels(loc loc.Locator, addLabels map[string]string, removeLabels []string) error {
+	ctx, cancel := c.callContext()
+	defer cancel()
+	_, err := c.client.UpdatePackageLabels(ctx, &proto.UpdatePackageLabelsRequest{
+		Locator: loc.String(),
+		Add:     addLabels,
+		Remove:  removeLabels,
+	})
+	return fromStatus(err)
+}
+
+// DeletePackage deletes a package
+func (c *Client) DeletePackage(loc loc.Locator) error {
+	ctx, cancel := c.callContext()
+	defer cancel()
+	_, err := c.client.DeletePackage(ctx, &proto.PackageRequest{Locator: loc.String()})
+	return fromStatus(err)
+}
+
+// PinPackage protects a package from garbage collection
+func (c *Client) PinPackage(loc loc.Locator) error {
+	ctx, cancel := c.callContext()
+	defer cancel()
+	_, err := c.client.PinPackage(ctx, &proto.PackageRequest{Locator: loc.String()})
+	return fromStatus(err)
+}
+
+// UnpinPackage removes the garbage collection protection from a package
+func (c *Client) UnpinPackage(loc loc.Locator) error {
+	ctx, cancel := c.callContext()
+	defer cancel()
+	_, err := c.client.UnpinPackage(ctx, &proto.PackageRequest{Locator: loc.String()})
+	return fromStatus(err)
+}
+
+// ReadPackage returns the package envelope and the reader for the package data.
+// The caller is responsible for closing the reader
+func (c *Client) ReadPackage(loc loc.Locator) (*pack.PackageEnvelope, io.ReadCloser, error) {
+	// package transfers are not limited by the call timeout,
+	// the stream is canceled when the reader is closed
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := c.client.ReadPackage(ctx, &proto.PackageRequest{Locator: loc.String()})
+	if err != nil {
+		cancel()
+		return nil, nil, fromStatus(err)
+	}
+	chunk, err := stream.Recv()
+	if err != nil {
+		cancel()
+		return nil, nil, fromStatus(err)
+	}
+	var envelope pack.PackageEnvelope
+	if err := json.Unmarshal(chunk.Envelope, &envelope); err != nil {
+		cancel()
+		return nil, nil, trace.Wrap(err)
+	}
+	reader := &streamReader{
+		chunkReader: chunkReader{
+			buf: chunk.Data,
+			recv: func() ([]byte, error) {
+				chunk, err := stream.Recv()
+				if err != nil {
+					return nil, fromStatus(err)
+				}
+				return chunk.Data, nil
+			},
+		},
+		cancel: cancel,
+	}
+	return &envelope, pack.NewDigestReader(reader, envelope.SHA256), nil
+}
+
+// ReadPackageEnvelope returns the package envelope
+func (c *Client) ReadPackageEnvelope(loc loc.Locator) (*pack.PackageEnvelope, error) {
+	ctx, cancel := c.callContext()
+	defer cancel()
+	payload, err := c.client.ReadPackageEnvelope(ctx, &proto.PackageRequest{Locator: loc.String()})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	var envelope pack.PackageEnvelope
+	if err := json.Unmarshal(payload.Data, &envelope); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &envelope, nil
+}
+
+// UpsertPackageAlias creates the package alias or atomically updates
+// it to point to the specified target package
+func (c *Client) UpsertPackageAlias(alias, target loc.Locator) error {
+	ctx, cancel := c.callContext()
+	defer cancel()
+	_, err := c.client.UpsertPackageAlias(ctx, &proto.PackageAliasRequest{
+		Alias:  alias.String(),
+		Target: target.String(),
+	})
+	return fromStatus(err)
+}
+
+// GetPackageAliases returns a list of package aliases in repository
+func (c *Client) GetPackageAliases(repository string) ([]storage.PackageAlias, error) {
+	ctx, cancel := c.callContext()
+	defer cancel()
+	payload, err := c.client.GetPackageAliases(ctx, &proto.RepositoryRequest{Repository: repository})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	var aliases []storage.PackageAlias
+	if err := json.Unmarshal(payload.Data, &aliases); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return aliases, nil
+}
+
+// DeletePackageAlias deletes the package alias
+func (c *Client) DeletePackageAlias(alias loc.Locator) error {
+	ctx, cancel := c.callContext()
+	defer cancel()
+	_, err := c.client.DeletePackageAlias(ctx, &proto.PackageRequest{Locator: alias.String()})
+	return fromStatus(err)
+}
+
+// WatchPackages returns a channel that receives events about changes
+// to packages in the specified repository, or all repositories if
+// the repository is empty.
+// The channel is closed when ctx is canceled or the connection is lost
+func (c *Client) WatchPackages(ctx context.Context, repository string) (<-chan pack.PackageEvent, error) {
+	stream, err := c.client.WatchPackages(ctx, &proto.RepositoryRequest{Repository: repository})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	// wait for the server to establish the watch so that
+	// no events are missed after the method returns
+	if _, err := stream.Recv(); err != nil {
+		return nil, fromStatus(err)
+	}
+	eventsC := make(chan pack.PackageEvent)
+	go func() {
+		defer close(eventsC)
+		for {
+			payload, err := stream.Recv()
+			if err != nil {
+				if err != io.EOF && ctx.Err() == nil {
+					log.Debugf("Package watch interrupted: %v.", err)
+				}
+				return
+			}
+			var event pack.PackageEvent
+			if err := json.Unmarshal(payload.Data, &event); err != nil {
+				log.Warnf("Failed to decode package event: %v.", err)
+				return
+			}
+			select {
+			case eventsC <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return eventsC, nil
+}
+
+// PrunePackages deletes packages that are not in use and returns them
+func (c *Client) PrunePackages(req pack.PruneRequest) (*pack.PruneResponse, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	ctx, cancel := c.callContext()
+	defer cancel()
+	payload, err := c.client.PrunePackages(ctx, &proto.Payload{Data: data})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	var resp pack.PruneResponse
+	if err := json.Unmarshal(payload.Data, &resp); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &resp, nil
+}
+
+// callContext returns the context for a call that does not transfer package data
+func (c *Client) callContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), c.Timeout)
+}
+
+// sendChunks sends the data to the stream in chunks
+func sendChunks(stream proto.PackageService_WritePackageClient, data io.Reader) error {
+	buf := make([]byte, chunkSize)
+	for {
+		n, err := data.Read(buf)
+		if n > 0 {
+			if err := stream.Send(&proto.PackageChunk{Data: buf[:n]}); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
+}
+
+// streamReader reads package data from the stream and
+// cancels the stream when closed
+type streamReader struct {
+	chunkReader
+	cancel context.CancelFunc
+}
+
+// Close cancels the stream
+func (r *streamReader) Close() error {
+	r.cancel()
+	return nil
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpcpack
+
+import (
+	"io"
+
+	"github.com/gravitational/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// toStatus converts the specified error into gRPC status error
+// so that the error kind survives the transfer to the client
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	code := codes.Unknown
+	switch {
+	case trace.IsNotFound(err):
+		code = codes.NotFound
+	case trace.IsAlreadyExists(err):
+		code = codes.AlreadyExists
+	case trace.IsBadParameter(err):
+		code = codes.InvalidArgument
+	case trace.IsAccessDenied(err):
+		code = codes.PermissionDenied
+	case trace.IsCompareFailed(err):
+		code = codes.FailedPrecondition
+	case trace.IsLimitExceeded(err):
+		code = codes.ResourceExhausted
+	case trace.IsNotImplemented(err):
+		code = codes.Unimplemented
+	case trace.IsConnectionProblem(err):
+		code = codes.Unavailable
+	}
+	return status.Error(code, trace.UserMessage(err))
+}
+
+// fromStatus converts the specified gRPC status error into trace error
+func fromStatus(err error) error {
+	if err == nil || err == io.EOF {
+		return err
+	}
+	s, ok := status.FromError(err)
+	if !ok {
+		return trace.Wrap(err)
+	}
+	message := s.Message()
+	switch s.Code() {
+	case codes.NotFound:
+		return trace.NotFound("%v", message)
+	case codes.AlreadyExists:
+		return trace.AlreadyExists("%v", message)
+	case codes.InvalidArgument:
+		return trace.BadParameter("%v", message)
+	case codes.PermissionDenied, codes.Unauthenticated:
+		return trace.AccessDenied("%v", message)
+	case codes.FailedPrecondition:
+		return trace.CompareFailed("%v", message)
+	case codes.ResourceExhausted:
+		return trace.LimitExceeded("%v", message)
+	case codes.Unimplemented:
+		return trace.NotImplemented("%v", message)
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return trace.ConnectionProblem(err, "%v", message)
+	}
+	return trace.Wrap(err)
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpcpack
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gravitational/gravity/lib/blob/fs"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/pack"
+	"github.com/gravitational/gravity/lib/pack/localpack"
+	"github.com/gravitational/gravity/lib/pack/suite"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/storage/keyval"
+
+	"github.com/gravitational/trace"
+	"github.com/mailgun/timetools"
+	"google.golang.org/grpc"
+	. "gopkg.in/check.v1"
+)
+
+func TestGRPCPack(t *testing.T) { TestingT(t) }
+
+type GRPCPackSuite struct {
+	backend storage.Backend
+	server  *grpc.Server
+	conn    *grpc.ClientConn
+	suite   suite.PackageSuite
+	clock   *timetools.FreezedTime
+}
+
+var _ = Suite(&GRPCPackSuite{
+	clock: &timetools.FreezedTime{
+		CurrentTime: time.Date(2015, 11, 16, 1, 2, 3, 0, time.UTC),
+	},
+})
+
+func (s *GRPCPackSuite) SetUpTest(c *C) {
+	dir := c.MkDir()
+
+	var err error
+	s.backend, err = keyval.NewBolt(keyval.BoltConfig{Path: filepath.Join(dir, "bolt.db")})
+	c.Assert(err, IsNil)
+
+	objects, err := fs.New(dir)
+	c.Assert(err, IsNil)
+
+	packages, err := localpack.New(localpack.Config{
+		Backend:     s.backend,
+		UnpackedDir: filepath.Join(dir, defaults.UnpackedDir),
+		Clock:       s.clock,
+		Objects:     objects,
+	})
+	c.Assert(err, IsNil)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+	s.server = grpc.NewServer()
+	Register(s.server, packages)
+	go s.server.Serve(listener)
+
+	s.conn, err = grpc.Dial(listener.Addr().String(), grpc.WithInsecure())
+	c.Assert(err, IsNil)
+	s.suite.S, err = NewClient(ClientConfig{Conn: s.conn})
+	c.Assert(err, IsNil)
+	s.suite.O = objects
+	s.suite.C = s.clock
+}
+
+func (s *GRPCPackSuite) TearDownTest(c *C) {
+	s.conn.Close()
+	s.server.Stop()
+	c.Assert(s.backend.Close(), IsNil)
+}
+
+func (s *GRPCPackSuite) TestRepositoriesCRUD(c *C) {
+	s.suite.RepositoriesCRUD(c)
+}
+
+func (s *GRPCPackSuite) TestPackagesCRUD(c *C) {
+	s.suite.PackagesCRUD(c)
+}
+
+func (s *GRPCPackSuite) TestUpsertPackages(c *C) {
+	s.suite.UpsertPackages(c)
+}
+
+func (s *GRPCPackSuite) TestDeleteRepository(c *C) {
+	s.suite.DeleteRepository(c)
+}
+
+func (s *GRPCPackSuite) TestPackageAliases(c *C) {
+	s.suite.PackageAliases(c)
+}
+
+func (s *GRPCPackSuite) TestWatchPackages(c *C) {
+	s.suite.WatchPackages(c)
+}
+
+func (s *GRPCPackSuite) TestPrunePackages(c *C) {
+	s.suite.PrunePackages(c)
+}
+
+func (s *GRPCPackSuite) TestPinPackages(c *C) {
+	s.suite.PinPackages(c)
+}
+
+func (s *GRPCPackSuite) TestGetPackagesBySelector(c *C) {
+	s.suite.GetPackagesBySelector(c)
+}
+
+func (s *GRPCPackSuite) TestStreamsLargePackages(c *C) {
+	c.Assert(s.suite.S.UpsertRepository("example.com", time.Time{}), IsNil)
+	locator := loc.MustParseLocator("example.com/large:1.0.0")
+	// span several chunks with a partial last chunk
+	data := bytes.Repeat([]byte("0123456789abcdef"), 3*chunkSize/16+100)
+
+	envelope, err := s.suite.S.CreatePackage(locator, bytes.NewReader(data),
+		pack.WithLabels(map[string]string{"purpose": "test"}))
+	c.Assert(err, IsNil)
+	c.Assert(envelope.SizeBytes, Equals, int64(len(data)))
+	c.Assert(envelope.RuntimeLabels, DeepEquals, map[string]string{"purpose": "test"})
+
+	_, reader, err := s.suite.S.ReadPackage(locator)
+	c.Assert(err, IsNil)
+	defer reader.Close()
+	read, err := ioutil.ReadAll(reader)
+	c.Assert(err, IsNil)
+	c.Assert(bytes.Equal(read, data), Equals, true)
+}
+
+func (s *GRPCPackSuite) TestPreservesErrorKinds(c *C) {
+	c.Assert(s.suite.S.UpsertRepository("example.com", time.Time{}), IsNil)
+	locator := loc.MustParseLocator("example.com/package:1.0.0")
+
+	_, err := s.suite.S.ReadPackageEnvelope(locator)
+	c.Assert(trace.IsNotFound(err), Equals, true, Commentf("%T: %v", err, err))
+
+	_, _, err = s.suite.S.ReadPackage(locator)
+	c.Assert(trace.IsNotFound(err), Equals, true, Commentf("%T: %v", err, err))
+
+	_, err = s.suite.S.CreatePackage(locator, bytes.NewBufferString("data"))
+	c.Assert(err, IsNil)
+	_, err = s.suite.S.CreatePackage(locator, bytes.NewBufferString("data"))
+	c.Assert(trace.IsAlreadyExists(err), Equals, true, Commentf("%T: %v", err, err))
+}
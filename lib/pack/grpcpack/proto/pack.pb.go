@@ -0,0 +1,3755 @@
+// Code generated by protoc-gen-gogo. DO NOT EDIT.
+// source: pack.proto
+
+package proto
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/gogo/protobuf/proto"
+	types "github.com/gogo/protobuf/types"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	io "io"
+	math "math"
+	math_bits "math/bits"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.GoGoProtoPackageIsVersion2 // please upgrade the proto package
+
+// Payload is an opaque JSON-encoded value
+type Payload struct {
+	// Data is the JSON-encoded value
+	Data                 []byte   `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Payload) Reset()         { *m = Payload{} }
+func (m *Payload) String() string { return proto.CompactTextString(m) }
+func (*Payload) ProtoMessage()    {}
+func (*Payload) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3827fe073a5f7620, []int{0}
+}
+func (m *Payload) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Payload) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Payload.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Payload) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Payload.Merge(m, src)
+}
+func (m *Payload) XXX_Size() int {
+	return m.Size()
+}
+func (m *Payload) XXX_DiscardUnknown() {
+	xxx_messageInfo_Payload.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Payload proto.InternalMessageInfo
+
+func (m *Payload) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+// Repositories lists repository names
+type Repositories struct {
+	// Names lists names of repositories
+	Names                []string `protobuf:"bytes,1,rep,name=names,proto3" json:"names,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Repositories) Reset()         { *m = Repositories{} }
+func (m *Repositories) String() string { return proto.CompactTextString(m) }
+func (*Repositories) ProtoMessage()    {}
+func (*Repositories) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3827fe073a5f7620, []int{1}
+}
+func (m *Repositories) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Repositories) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Repositories.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Repositories) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Repositories.Merge(m, src)
+}
+func (m *Repositories) XXX_Size() int {
+	return m.Size()
+}
+func (m *Repositories) XXX_DiscardUnknown() {
+	xxx_messageInfo_Repositories.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Repositories proto.InternalMessageInfo
+
+func (m *Repositories) GetNames() []string {
+	if m != nil {
+		return m.Names
+	}
+	return nil
+}
+
+// RepositoryRequest specifies a repository
+type RepositoryRequest struct {
+	// Repository is the repository name
+	Repository           string   `protobuf:"bytes,1,opt,name=repository,proto3" json:"repository,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RepositoryRequest) Reset()         { *m = RepositoryRequest{} }
+func (m *RepositoryRequest) String() string { return proto.CompactTextString(m) }
+func (*RepositoryRequest) ProtoMessage()    {}
+func (*RepositoryRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3827fe073a5f7620, []int{2}
+}
+func (m *RepositoryRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *RepositoryRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_RepositoryRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *RepositoryRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RepositoryRequest.Merge(m, src)
+}
+func (m *RepositoryRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *RepositoryRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RepositoryRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RepositoryRequest proto.InternalMessageInfo
+
+func (m *RepositoryRequest) GetRepository() string {
+	if m != nil {
+		return m.Repository
+	}
+	return ""
+}
+
+// UpsertRepositoryRequest describes a repository to create or update
+type UpsertRepositoryRequest struct {
+	// Repository is the repository name
+	Repository string `protobuf:"bytes,1,opt,name=repository,proto3" json:"repository,omitempty"`
+	// Expires is the repository expiration time in RFC3339 format.
+	// Empty value means the repository does not expire
+	Expires              string   `protobuf:"bytes,2,opt,name=expires,proto3" json:"expires,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *UpsertRepositoryRequest) Reset()         { *m = UpsertRepositoryRequest{} }
+func (m *UpsertRepositoryRequest) String() string { return proto.CompactTextString(m) }
+func (*UpsertRepositoryRequest) ProtoMessage()    {}
+func (*UpsertRepositoryRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3827fe073a5f7620, []int{3}
+}
+func (m *UpsertRepositoryRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *UpsertRepositoryRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_UpsertRepositoryRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *UpsertRepositoryRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UpsertRepositoryRequest.Merge(m, src)
+}
+func (m *UpsertRepositoryRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *UpsertRepositoryRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_UpsertRepositoryRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UpsertRepositoryRequest proto.InternalMessageInfo
+
+func (m *UpsertRepositoryRequest) GetRepository() string {
+	if m != nil {
+		return m.Repository
+	}
+	return ""
+}
+
+func (m *UpsertRepositoryRequest) GetExpires() string {
+	if m != nil {
+		return m.Expires
+	}
+	return ""
+}
+
+// GetPackagesRequest specifies packages to list
+type GetPackagesRequest struct {
+	// Repository is the repository name
+	Repository string `protobuf:"bytes,1,opt,name=repository,proto3" json:"repository,omitempty"`
+	// Selector is an optional label selector to filter packages by
+	Selector             string   `protobuf:"bytes,2,opt,name=selector,proto3" json:"selector,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetPackagesRequest) Reset()         { *m = GetPackagesRequest{} }
+func (m *GetPackagesRequest) String() string { return proto.CompactTextString(m) }
+func (*GetPackagesRequest) ProtoMessage()    {}
+func (*GetPackagesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3827fe073a5f7620, []int{4}
+}
+func (m *GetPackagesRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *GetPackagesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_GetPackagesRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *GetPackagesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetPackagesRequest.Merge(m, src)
+}
+func (m *GetPackagesRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *GetPackagesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetPackagesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetPackagesRequest proto.InternalMessageInfo
+
+func (m *GetPackagesRequest) GetRepository() string {
+	if m != nil {
+		return m.Repository
+	}
+	return ""
+}
+
+func (m *GetPackagesRequest) GetSelector() string {
+	if m != nil {
+		return m.Selector
+	}
+	return ""
+}
+
+// PackageRequest specifies a package
+type PackageRequest struct {
+	// Locator is the package locator
+	Locator              string   `protobuf:"bytes,1,opt,name=locator,proto3" json:"locator,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PackageRequest) Reset()         { *m = PackageRequest{} }
+func (m *PackageRequest) String() string { return proto.CompactTextString(m) }
+func (*PackageRequest) ProtoMessage()    {}
+func (*PackageRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3827fe073a5f7620, []int{5}
+}
+func (m *PackageRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *PackageRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_PackageRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *PackageRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PackageRequest.Merge(m, src)
+}
+func (m *PackageRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *PackageRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_PackageRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PackageRequest proto.InternalMessageInfo
+
+func (m *PackageRequest) GetLocator() string {
+	if m != nil {
+		return m.Locator
+	}
+	return ""
+}
+
+// PackageHeader describes the package being written
+type PackageHeader struct {
+	// Locator is the package locator
+	Locator string `protobuf:"bytes,1,opt,name=locator,proto3" json:"locator,omitempty"`
+	// Upsert specifies whether an existing package should be replaced
+	Upsert bool `protobuf:"varint,2,opt,name=upsert,proto3" json:"upsert,omitempty"`
+	// Labels are the package runtime labels
+	Labels map[string]string `protobuf:"bytes,3,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// Hidden specifies whether the package is hidden
+	Hidden bool `protobuf:"varint,4,opt,name=hidden,proto3" json:"hidden,omitempty"`
+	// Encrypted specifies whether the package is encrypted
+	Encrypted bool `protobuf:"varint,5,opt,name=encrypted,proto3" json:"encrypted,omitempty"`
+	// Type is the package type
+	Type string `protobuf:"bytes,6,opt,name=type,proto3" json:"type,omitempty"`
+	// Manifest is the package manifest
+	Manifest []byte `protobuf:"bytes,7,opt,name=manifest,proto3" json:"manifest,omitempty"`
+	// CreatedBy is the package creator
+	CreatedBy            string   `protobuf:"bytes,8,opt,name=created_by,json=createdBy,proto3" json:"created_by,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PackageHeader) Reset()         { *m = PackageHeader{} }
+func (m *PackageHeader) String() string { return proto.CompactTextString(m) }
+func (*PackageHeader) ProtoMessage()    {}
+func (*PackageHeader) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3827fe073a5f7620, []int{6}
+}
+func (m *PackageHeader) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *PackageHeader) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_PackageHeader.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *PackageHeader) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PackageHeader.Merge(m, src)
+}
+func (m *PackageHeader) XXX_Size() int {
+	return m.Size()
+}
+func (m *PackageHeader) XXX_DiscardUnknown() {
+	xxx_messageInfo_PackageHeader.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PackageHeader proto.InternalMessageInfo
+
+func (m *PackageHeader) GetLocator() string {
+	if m != nil {
+		return m.Locator
+	}
+	return ""
+}
+
+func (m *PackageHeader) GetUpsert() bool {
+	if m != nil {
+		return m.Upsert
+	}
+	return false
+}
+
+func (m *PackageHeader) GetLabels() map[string]string {
+	if m != nil {
+		return m.Labels
+	}
+	return nil
+}
+
+func (m *PackageHeader) GetHidden() bool {
+	if m != nil {
+		return m.Hidden
+	}
+	return false
+}
+
+func (m *PackageHeader) GetEncrypted() bool {
+	if m != nil {
+		return m.Encrypted
+	}
+	return false
+}
+
+func (m *PackageHeader) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *PackageHeader) GetManifest() []byte {
+	if m != nil {
+		return m.Manifest
+	}
+	return nil
+}
+
+func (m *PackageHeader) GetCreatedBy() string {
+	if m != nil {
+		return m.CreatedBy
+	}
+	return ""
+}
+
+// PackageChunk is a portion of a package stream
+type PackageChunk struct {
+	// Header describes the package, set in the first chunk of WritePackage stream
+	Header *PackageHeader `protobuf:"bytes,1,opt,name=header,proto3" json:"header,omitempty"`
+	// Envelope is the JSON-encoded package envelope,
+	// set in the first chunk of ReadPackage stream
+	Envelope []byte `protobuf:"bytes,2,opt,name=envelope,proto3" json:"envelope,omitempty"`
+	// Data is the next portion of the package data
+	Data                 []byte   `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PackageChunk) Reset()         { *m = PackageChunk{} }
+func (m *PackageChunk) String() string { return proto.CompactTextString(m) }
+func (*PackageChunk) ProtoMessage()    {}
+func (*PackageChunk) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3827fe073a5f7620, []int{7}
+}
+func (m *PackageChunk) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *PackageChunk) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_PackageChunk.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *PackageChunk) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PackageChunk.Merge(m, src)
+}
+func (m *PackageChunk) XXX_Size() int {
+	return m.Size()
+}
+func (m *PackageChunk) XXX_DiscardUnknown() {
+	xxx_messageInfo_PackageChunk.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PackageChunk proto.InternalMessageInfo
+
+func (m *PackageChunk) GetHeader() *PackageHeader {
+	if m != nil {
+		return m.Header
+	}
+	return nil
+}
+
+func (m *PackageChunk) GetEnvelope() []byte {
+	if m != nil {
+		return m.Envelope
+	}
+	return nil
+}
+
+func (m *PackageChunk) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+// UpdatePackageLabelsRequest describes package label changes
+type UpdatePackageLabelsRequest struct {
+	// Locator is the package locator
+	Locator string `protobuf:"bytes,1,opt,name=locator,proto3" json:"locator,omitempty"`
+	// Add lists labels to add
+	Add map[string]string `protobuf:"bytes,2,rep,name=add,proto3" json:"add,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// Remove lists labels to remove
+	Remove               []string `protobuf:"bytes,3,rep,name=remove,proto3" json:"remove,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *UpdatePackageLabelsRequest) Reset()         { *m = UpdatePackageLabelsRequest{} }
+func (m *UpdatePackageLabelsRequest) String() string { return proto.CompactTextString(m) }
+func (*UpdatePackageLabelsRequest) ProtoMessage()    {}
+func (*UpdatePackageLabelsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3827fe073a5f7620, []int{8}
+}
+func (m *UpdatePackageLabelsRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *UpdatePackageLabelsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_UpdatePackageLabelsRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *UpdatePackageLabelsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UpdatePackageLabelsRequest.Merge(m, src)
+}
+func (m *UpdatePackageLabelsRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *UpdatePackageLabelsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_UpdatePackageLabelsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UpdatePackageLabelsRequest proto.InternalMessageInfo
+
+func (m *UpdatePackageLabelsRequest) GetLocator() string {
+	if m != nil {
+		return m.Locator
+	}
+	return ""
+}
+
+func (m *UpdatePackageLabelsRequest) GetAdd() map[string]string {
+	if m != nil {
+		return m.Add
+	}
+	return nil
+}
+
+func (m *UpdatePackageLabelsRequest) GetRemove() []string {
+	if m != nil {
+		return m.Remove
+	}
+	return nil
+}
+
+// PackageAliasRequest describes a package alias
+type PackageAliasRequest struct {
+	// Alias is the alias locator
+	Alias string `protobuf:"bytes,1,opt,name=alias,proto3" json:"alias,omitempty"`
+	// Target is the locator of the package the alias points to
+	Target               string   `protobuf:"bytes,2,opt,name=target,proto3" json:"target,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PackageAliasRequest) Reset()         { *m = PackageAliasRequest{} }
+func (m *PackageAliasRequest) String() string { return proto.CompactTextString(m) }
+func (*PackageAliasRequest) ProtoMessage()    {}
+func (*PackageAliasRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3827fe073a5f7620, []int{9}
+}
+func (m *PackageAliasRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *PackageAliasRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_PackageAliasRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *PackageAliasRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PackageAliasRequest.Merge(m, src)
+}
+func (m *PackageAliasRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *PackageAliasRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_PackageAliasRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PackageAliasRequest proto.InternalMessageInfo
+
+func (m *PackageAliasRequest) GetAlias() string {
+	if m != nil {
+		return m.Alias
+	}
+	return ""
+}
+
+func (m *PackageAliasRequest) GetTarget() string {
+	if m != nil {
+		return m.Target
+	}
+	return ""
+}
+
+func init() {
+	proto.RegisterType((*Payload)(nil), "proto.Payload")
+	proto.RegisterType((*Repositories)(nil), "proto.Repositories")
+	proto.RegisterType((*RepositoryRequest)(nil), "proto.RepositoryRequest")
+	proto.RegisterType((*UpsertRepositoryRequest)(nil), "proto.UpsertRepositoryRequest")
+	proto.RegisterType((*GetPackagesRequest)(nil), "proto.GetPackagesRequest")
+	proto.RegisterType((*PackageRequest)(nil), "proto.PackageRequest")
+	proto.RegisterType((*PackageHeader)(nil), "proto.PackageHeader")
+	proto.RegisterMapType((map[string]string)(nil), "proto.PackageHeader.LabelsEntry")
+	proto.RegisterType((*PackageChunk)(nil), "proto.PackageChunk")
+	proto.RegisterType((*UpdatePackageLabelsRequest)(nil), "proto.UpdatePackageLabelsRequest")
+	proto.RegisterMapType((map[string]string)(nil), "proto.UpdatePackageLabelsRequest.AddEntry")
+	proto.RegisterType((*PackageAliasRequest)(nil), "proto.PackageAliasRequest")
+}
+
+func init() { proto.RegisterFile("pack.proto", fileDescriptor_3827fe073a5f7620) }
+
+var fileDescriptor_3827fe073a5f7620 = []byte{
+	// 765 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x54, 0x4d, 0x4f, 0xeb, 0x46,
+	0x14, 0x95, 0x13, 0xf2, 0x75, 0x93, 0x50, 0x98, 0x50, 0xea, 0xba, 0x25, 0x4a, 0xad, 0x2e, 0x22,
+	0x54, 0x05, 0x04, 0x6a, 0x05, 0x85, 0x22, 0xf1, 0x25, 0x50, 0xd5, 0x45, 0x64, 0x84, 0x58, 0x56,
+	0x13, 0xfb, 0x92, 0x58, 0x71, 0x6c, 0xd7, 0x9e, 0x44, 0xf5, 0x6f, 0xeb, 0xb6, 0x8b, 0xb7, 0x7c,
+	0xd2, 0xfb, 0x03, 0x4f, 0xfc, 0x92, 0x27, 0xcf, 0x8c, 0x8d, 0x13, 0xe2, 0x07, 0x61, 0x65, 0x9f,
+	0x99, 0x73, 0xce, 0xdc, 0xb9, 0xf7, 0xce, 0x05, 0xf0, 0xa9, 0x39, 0xee, 0xf9, 0x81, 0xc7, 0x3c,
+	0x52, 0xe2, 0x1f, 0xed, 0x87, 0xa1, 0xe7, 0x0d, 0x1d, 0xdc, 0xe3, 0x68, 0x30, 0x7d, 0xdc, 0xc3,
+	0x89, 0xcf, 0x22, 0xc1, 0xd1, 0x77, 0xa0, 0xd2, 0xa7, 0x91, 0xe3, 0x51, 0x8b, 0x10, 0x58, 0xb3,
+	0x28, 0xa3, 0xaa, 0xd2, 0x51, 0xba, 0x0d, 0x83, 0xff, 0xeb, 0x3f, 0x43, 0xc3, 0x40, 0xdf, 0x0b,
+	0x6d, 0xe6, 0x05, 0x36, 0x86, 0x64, 0x0b, 0x4a, 0x2e, 0x9d, 0x60, 0xa8, 0x2a, 0x9d, 0x62, 0xb7,
+	0x66, 0x08, 0xa0, 0x1f, 0xc2, 0x66, 0xca, 0x8a, 0x0c, 0xfc, 0x67, 0x8a, 0x21, 0x23, 0x6d, 0x80,
+	0x20, 0x5d, 0xe4, 0xa6, 0x35, 0x23, 0xb3, 0xa2, 0xdf, 0xc1, 0x77, 0xf7, 0x7e, 0x88, 0x01, 0x5b,
+	0x59, 0x4a, 0x54, 0xa8, 0xe0, 0xbf, 0xbe, 0x1d, 0x60, 0xa8, 0x16, 0xf8, 0x66, 0x02, 0xf5, 0x3e,
+	0x90, 0x1b, 0x64, 0x7d, 0x6a, 0x8e, 0xe9, 0x10, 0xc3, 0xb7, 0xfa, 0x69, 0x50, 0x0d, 0xd1, 0x41,
+	0x93, 0x79, 0x81, 0x34, 0x4c, 0xb1, 0xbe, 0x0b, 0xeb, 0xd2, 0x2e, 0x71, 0x53, 0xa1, 0xe2, 0x78,
+	0x26, 0x8d, 0xc9, 0xc2, 0x2a, 0x81, 0xfa, 0x7f, 0x05, 0x68, 0x4a, 0xf2, 0x2d, 0x52, 0x0b, 0x83,
+	0x7c, 0x2e, 0xd9, 0x86, 0xf2, 0x94, 0x5f, 0x9f, 0x9f, 0x58, 0x35, 0x24, 0x22, 0x47, 0x50, 0x76,
+	0xe8, 0x00, 0x9d, 0x50, 0x2d, 0x76, 0x8a, 0xdd, 0xfa, 0x41, 0x47, 0x14, 0xaa, 0x37, 0xe7, 0xdb,
+	0xfb, 0x8b, 0x53, 0xae, 0x5d, 0x16, 0x44, 0x86, 0xe4, 0xc7, 0x8e, 0x23, 0xdb, 0xb2, 0xd0, 0x55,
+	0xd7, 0x84, 0xa3, 0x40, 0xe4, 0x47, 0xa8, 0xa1, 0x6b, 0x06, 0x91, 0xcf, 0xd0, 0x52, 0x4b, 0x7c,
+	0xeb, 0x79, 0x21, 0xae, 0x3a, 0x8b, 0x7c, 0x54, 0xcb, 0x3c, 0x3c, 0xfe, 0x1f, 0xe7, 0x63, 0x42,
+	0x5d, 0xfb, 0x11, 0x43, 0xa6, 0x56, 0x78, 0x37, 0xa4, 0x98, 0xec, 0x00, 0x98, 0x01, 0x52, 0x86,
+	0xd6, 0xdf, 0x83, 0x48, 0xad, 0x72, 0x55, 0x4d, 0xae, 0x5c, 0x44, 0xda, 0x31, 0xd4, 0x33, 0xb1,
+	0x91, 0x0d, 0x28, 0x8e, 0x31, 0x49, 0x79, 0xfc, 0x1b, 0x77, 0xd0, 0x8c, 0x3a, 0x53, 0x94, 0x89,
+	0x16, 0xe0, 0xf7, 0xc2, 0x91, 0xa2, 0x3b, 0xd0, 0x90, 0x97, 0xbc, 0x1c, 0x4d, 0xdd, 0x31, 0xf9,
+	0x05, 0xca, 0x23, 0x7e, 0x5b, 0x2e, 0xaf, 0x1f, 0x6c, 0x2d, 0xcb, 0x84, 0x21, 0x39, 0x71, 0xcc,
+	0xe8, 0xce, 0xd0, 0xf1, 0x7c, 0x61, 0xdd, 0x30, 0x52, 0x9c, 0x76, 0x76, 0x31, 0xd3, 0xd9, 0xff,
+	0x2b, 0xa0, 0xdd, 0xfb, 0x16, 0x65, 0x28, 0xfd, 0x44, 0xd8, 0xaf, 0x16, 0x99, 0x9c, 0x42, 0x91,
+	0x5a, 0x96, 0x5a, 0xe0, 0xd5, 0xd9, 0x95, 0x31, 0xe5, 0x3b, 0xf5, 0xce, 0x2d, 0x4b, 0xd4, 0x29,
+	0x96, 0xc5, 0x45, 0x0a, 0x70, 0xe2, 0xcd, 0x90, 0x97, 0xb7, 0x66, 0x48, 0xa4, 0xfd, 0x06, 0xd5,
+	0x84, 0xb8, 0x52, 0xd2, 0x2e, 0xa1, 0x25, 0x4f, 0x3d, 0x77, 0x6c, 0x9a, 0x86, 0xbf, 0x05, 0x25,
+	0x1a, 0x63, 0x69, 0x22, 0x40, 0x7c, 0x38, 0xa3, 0xc1, 0x10, 0x99, 0xf4, 0x91, 0xe8, 0xe0, 0x53,
+	0x35, 0x6d, 0xf2, 0x3b, 0x0c, 0x66, 0xb6, 0x89, 0xe4, 0x0c, 0xbe, 0xb9, 0x41, 0x36, 0xf7, 0xf6,
+	0xb7, 0x7b, 0x62, 0x90, 0xf4, 0x92, 0x41, 0xd2, 0xbb, 0x8e, 0x07, 0x89, 0xd6, 0x92, 0x39, 0x98,
+	0x23, 0x1f, 0x43, 0x33, 0xab, 0x8f, 0x88, 0xba, 0xc8, 0x4a, 0x5e, 0xbb, 0xb6, 0x9e, 0xd6, 0x55,
+	0xcc, 0xa1, 0x3f, 0x61, 0x63, 0x71, 0x30, 0x90, 0x76, 0x9a, 0xe7, 0xa5, 0x13, 0x43, 0xcb, 0x89,
+	0x8d, 0x5c, 0xc1, 0xc6, 0x15, 0x3a, 0xc8, 0xf0, 0x4d, 0x91, 0xe4, 0xb9, 0x1c, 0x41, 0x3d, 0x33,
+	0x55, 0xc8, 0xf7, 0xd2, 0xe0, 0xe5, 0xa4, 0x79, 0x71, 0x97, 0x53, 0x68, 0x19, 0x48, 0x2d, 0x49,
+	0xbb, 0x4e, 0x1a, 0xf2, 0xdb, 0xf9, 0x56, 0xce, 0x53, 0x9f, 0x40, 0x3d, 0xa3, 0xce, 0x53, 0xb5,
+	0xe6, 0x97, 0xf9, 0xe3, 0xd9, 0x57, 0xc8, 0xaf, 0xd0, 0x78, 0x08, 0xec, 0xb4, 0x29, 0xc9, 0x32,
+	0xda, 0xe2, 0x89, 0x5d, 0x85, 0xf4, 0xa1, 0xb5, 0xa4, 0x99, 0xc9, 0x4f, 0xaf, 0x36, 0x7a, 0x6e,
+	0xf6, 0xce, 0xa0, 0x29, 0x6a, 0xf0, 0xca, 0x3d, 0xf2, 0xf4, 0x27, 0x00, 0x7d, 0xdb, 0x7d, 0xa7,
+	0xf8, 0x0f, 0x68, 0xdc, 0xbb, 0xfe, 0xbb, 0xe5, 0xb7, 0x40, 0x44, 0xcb, 0x65, 0x1f, 0x19, 0xd1,
+	0xe6, 0x4d, 0xb2, 0x2f, 0xef, 0x2b, 0x81, 0x6c, 0x3e, 0xf7, 0x0b, 0x57, 0x60, 0xb8, 0xc2, 0xa3,
+	0xb8, 0x04, 0x32, 0x97, 0x44, 0x11, 0xc8, 0xca, 0x99, 0x6c, 0x3e, 0x50, 0x66, 0x8e, 0xd2, 0x4e,
+	0x7e, 0xf3, 0xf9, 0xfb, 0x0a, 0xd9, 0x83, 0x66, 0x3f, 0x98, 0xba, 0x98, 0x8a, 0x17, 0x28, 0x8b,
+	0x92, 0x8b, 0xc6, 0x87, 0xa7, 0xb6, 0xf2, 0xf1, 0xa9, 0xad, 0x7c, 0x7e, 0x6a, 0x2b, 0x83, 0x32,
+	0xdf, 0x3c, 0xfc, 0x32, 0x00, 0xd3, 0xa2, 0x09, 0xd8, 0xa1, 0x08, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// PackageServiceClient is the client API for PackageService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type PackageServiceClient interface {
+	// GetRepositories returns the names of all repositories
+	GetRepositories(ctx context.Context, in *types.Empty, opts ...grpc.CallOption) (*Repositories, error)
+	// GetRepository returns the repository specified with the request
+	GetRepository(ctx context.Context, in *RepositoryRequest, opts ...grpc.CallOption) (*Payload, error)
+	// UpsertRepository creates or updates a repository
+	UpsertRepository(ctx context.Context, in *UpsertRepositoryRequest, opts ...grpc.CallOption) (*types.Empty, error)
+	// DeleteRepository deletes a repository
+	DeleteRepository(ctx context.Context, in *RepositoryRequest, opts ...grpc.CallOption) (*types.Empty, error)
+	// GetPackages returns envelopes of packages in a repository,
+	// optionally matching a label selector
+	GetPackages(ctx context.Context, in *GetPackagesRequest, opts ...grpc.CallOption) (*Payload, error)
+	// ReadPackageEnvelope returns the envelope of a package
+	ReadPackageEnvelope(ctx context.Context, in *PackageRequest, opts ...grpc.CallOption) (*Payload, error)
+	// ReadPackage streams the package. The first chunk carries the package
+	// envelope, the following chunks carry the package data
+	ReadPackage(ctx context.Context, in *PackageRequest, opts ...grpc.CallOption) (PackageService_ReadPackageClient, error)
+	// WritePackage creates or updates a package from the stream.
+	// The first chunk carries the package header, the following chunks
+	// carry the package data
+	WritePackage(ctx context.Context, opts ...grpc.CallOption) (PackageService_WritePackageClient, error)
+	// UpdatePackageLabels adds and removes package labels
+	UpdatePackageLabels(ctx context.Context, in *UpdatePackageLabelsRequest, opts ...grpc.CallOption) (*types.Empty, error)
+	// DeletePackage deletes a package
+	DeletePackage(ctx context.Context, in *PackageRequest, opts ...grpc.CallOption) (*types.Empty, error)
+	// PinPackage protects a package from garbage collection
+	PinPackage(ctx context.Context, in *PackageRequest, opts ...grpc.CallOption) (*types.Empty, error)
+	// UnpinPackage removes the garbage collection protection from a package
+	UnpinPackage(ctx context.Context, in *PackageRequest, opts ...grpc.CallOption) (*types.Empty, error)
+	// UpsertPackageAlias points the alias to the target package
+	UpsertPackageAlias(ctx context.Context, in *PackageAliasRequest, opts ...grpc.CallOption) (*types.Empty, error)
+	// GetPackageAliases returns package aliases in a repository
+	GetPackageAliases(ctx context.Context, in *RepositoryRequest, opts ...grpc.CallOption) (*Payload, error)
+	// DeletePackageAlias deletes a package alias
+	DeletePackageAlias(ctx context.Context, in *PackageRequest, opts ...grpc.CallOption) (*types.Empty, error)
+	// WatchPackages streams package events in a repository.
+	// The first message is empty and confirms that the watch is established
+	WatchPackages(ctx context.Context, in *RepositoryRequest, opts ...grpc.CallOption) (PackageService_WatchPackagesClient, error)
+	// PrunePackages removes stale packages
+	PrunePackages(ctx context.Context, in *Payload, opts ...grpc.CallOption) (*Payload, error)
+}
+
+type packageServiceClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewPackageServiceClient(cc *grpc.ClientConn) PackageServiceClient {
+	return &packageServiceClient{cc}
+}
+
+func (c *packageServiceClient) GetRepositories(ctx context.Context, in *types.Empty, opts ...grpc.CallOption) (*Repositories, error) {
+	out := new(Repositories)
+	err := c.cc.Invoke(ctx, "/proto.PackageService/GetRepositories", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *packageServiceClient) GetRepository(ctx context.Context, in *RepositoryRequest, opts ...grpc.CallOption) (*Payload, error) {
+	out := new(Payload)
+	err := c.cc.Invoke(ctx, "/proto.PackageService/GetRepository", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *packageServiceClient) UpsertRepository(ctx context.Context, in *UpsertRepositoryRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	out := new(types.Empty)
+	err := c.cc.Invoke(ctx, "/proto.PackageService/UpsertRepository", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *packageServiceClient) DeleteRepository(ctx context.Context, in *RepositoryRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	out := new(types.Empty)
+	err := c.cc.Invoke(ctx, "/proto.PackageService/DeleteRepository", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *packageServiceClient) GetPackages(ctx context.Context, in *GetPackagesRequest, opts ...grpc.CallOption) (*Payload, error) {
+	out := new(Payload)
+	err := c.cc.Invoke(ctx, "/proto.PackageService/GetPackages", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *packageServiceClient) ReadPackageEnvelope(ctx context.Context, in *PackageRequest, opts ...grpc.CallOption) (*Payload, error) {
+	out := new(Payload)
+	err := c.cc.Invoke(ctx, "/proto.PackageService/ReadPackageEnvelope", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *packageServiceClient) ReadPackage(ctx context.Context, in *PackageRequest, opts ...grpc.CallOption) (PackageService_ReadPackageClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_PackageService_serviceDesc.Streams[0], "/proto.PackageService/ReadPackage", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &packageServiceReadPackageClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type PackageService_ReadPackageClient interface {
+	Recv() (*PackageChunk, error)
+	grpc.ClientStream
+}
+
+type packageServiceReadPackageClient struct {
+	grpc.ClientStream
+}
+
+func (x *packageServiceReadPackageClient) Recv() (*PackageChunk, error) {
+	m := new(PackageChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *packageServiceClient) WritePackage(ctx context.Context, opts ...grpc.CallOption) (PackageService_WritePackageClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_PackageService_serviceDesc.Streams[1], "/proto.PackageService/WritePackage", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &packageServiceWritePackageClient{stream}
+	return x, nil
+}
+
+type PackageService_WritePackageClient interface {
+	Send(*PackageChunk) error
+	CloseAndRecv() (*Payload, error)
+	grpc.ClientStream
+}
+
+type packageServiceWritePackageClient struct {
+	grpc.ClientStream
+}
+
+func (x *packageServiceWritePackageClient) Send(m *PackageChunk) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *packageServiceWritePackageClient) CloseAndRecv() (*Payload, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(Payload)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *packageServiceClient) UpdatePackageLabels(ctx context.Context, in *UpdatePackageLabelsRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	out := new(types.Empty)
+	err := c.cc.Invoke(ctx, "/proto.PackageService/UpdatePackageLabels", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *packageServiceClient) DeletePackage(ctx context.Context, in *PackageRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	out := new(types.Empty)
+	err := c.cc.Invoke(ctx, "/proto.PackageService/DeletePackage", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *packageServiceClient) PinPackage(ctx context.Context, in *PackageRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	out := new(types.Empty)
+	err := c.cc.Invoke(ctx, "/proto.PackageService/PinPackage", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *packageServiceClient) UnpinPackage(ctx context.Context, in *PackageRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	out := new(types.Empty)
+	err := c.cc.Invoke(ctx, "/proto.PackageService/UnpinPackage", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *packageServiceClient) UpsertPackageAlias(ctx context.Context, in *PackageAliasRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	out := new(types.Empty)
+	err := c.cc.Invoke(ctx, "/proto.PackageService/UpsertPackageAlias", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *packageServiceClient) GetPackageAliases(ctx context.Context, in *RepositoryRequest, opts ...grpc.CallOption) (*Payload, error) {
+	out := new(Payload)
+	err := c.cc.Invoke(ctx, "/proto.PackageService/GetPackageAliases", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *packageServiceClient) DeletePackageAlias(ctx context.Context, in *PackageRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	out := new(types.Empty)
+	err := c.cc.Invoke(ctx, "/proto.PackageService/DeletePackageAlias", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *packageServiceClient) WatchPackages(ctx context.Context, in *RepositoryRequest, opts ...grpc.CallOption) (PackageService_WatchPackagesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_PackageService_serviceDesc.Streams[2], "/proto.PackageService/WatchPackages", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &packageServiceWatchPackagesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type PackageService_WatchPackagesClient interface {
+	Recv() (*Payload, error)
+	grpc.ClientStream
+}
+
+type packageServiceWatchPackagesClient struct {
+	grpc.ClientStream
+}
+
+func (x *packageServiceWatchPackagesClient) Recv() (*Payload, error) {
+	m := new(Payload)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *packageServiceClient) PrunePackages(ctx context.Context, in *Payload, opts ...grpc.CallOption) (*Payload, error) {
+	out := new(Payload)
+	err := c.cc.Invoke(ctx, "/proto.PackageService/PrunePackages", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PackageServiceServer is the server API for PackageService service.
+type PackageServiceServer interface {
+	// GetRepositories returns the names of all repositories
+	GetRepositories(context.Context, *types.Empty) (*Repositories, error)
+	// GetRepository returns the repository specified with the request
+	GetRepository(context.Context, *RepositoryRequest) (*Payload, error)
+	// UpsertRepository creates or updates a repository
+	UpsertRepository(context.Context, *UpsertRepositoryRequest) (*types.Empty, error)
+	// DeleteRepository deletes a repository
+	DeleteRepository(context.Context, *RepositoryRequest) (*types.Empty, error)
+	// GetPackages returns envelopes of packages in a repository,
+	// optionally matching a label selector
+	GetPackages(context.Context, *GetPackagesRequest) (*Payload, error)
+	// ReadPackageEnvelope returns the envelope of a package
+	ReadPackageEnvelope(context.Context, *PackageRequest) (*Payload, error)
+	// ReadPackage streams the package. The first chunk carries the package
+	// envelope, the following chunks carry the package data
+	ReadPackage(*PackageRequest, PackageService_ReadPackageServer) error
+	// WritePackage creates or updates a package from the stream.
+	// The first chunk carries the package header, the following chunks
+	// carry the package data
+	WritePackage(PackageService_WritePackageServer) error
+	// UpdatePackageLabels adds and removes package labels
+	UpdatePackageLabels(context.Context, *UpdatePackageLabelsRequest) (*types.Empty, error)
+	// DeletePackage deletes a package
+	DeletePackage(context.Context, *PackageRequest) (*types.Empty, error)
+	// PinPackage protects a package from garbage collection
+	PinPackage(context.Context, *PackageRequest) (*types.Empty, error)
+	// UnpinPackage removes the garbage collection protection from a package
+	UnpinPackage(context.Context, *PackageRequest) (*types.Empty, error)
+	// UpsertPackageAlias points the alias to the target package
+	UpsertPackageAlias(context.Context, *PackageAliasRequest) (*types.Empty, error)
+	// GetPackageAliases returns package aliases in a repository
+	GetPackageAliases(context.Context, *RepositoryRequest) (*Payload, error)
+	// DeletePackageAlias deletes a package alias
+	DeletePackageAlias(context.Context, *PackageRequest) (*types.Empty, error)
+	// WatchPackages streams package events in a repository.
+	// The first message is empty and confirms that the watch is established
+	WatchPackages(*RepositoryRequest, PackageService_WatchPackagesServer) error
+	// PrunePackages removes stale packages
+	PrunePackages(context.Context, *Payload) (*Payload, error)
+}
+
+// UnimplementedPackageServiceServer can be embedded to have forward compatible implementations.
+type UnimplementedPackageServiceServer struct {
+}
+
+func (*UnimplementedPackageServiceServer) GetRepositories(ctx context.Context, req *types.Empty) (*Repositories, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRepositories not implemented")
+}
+func (*UnimplementedPackageServiceServer) GetRepository(ctx context.Context, req *RepositoryRequest) (*Payload, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRepository not implemented")
+}
+func (*UnimplementedPackageServiceServer) UpsertRepository(ctx context.Context, req *UpsertRepositoryRequest) (*types.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpsertRepository not implemented")
+}
+func (*UnimplementedPackageServiceServer) DeleteRepository(ctx context.Context, req *RepositoryRequest) (*types.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteRepository not implemented")
+}
+func (*UnimplementedPackageServiceServer) GetPackages(ctx context.Context, req *GetPackagesRequest) (*Payload, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPackages not implemented")
+}
+func (*UnimplementedPackageServiceServer) ReadPackageEnvelope(ctx context.Context, req *PackageRequest) (*Payload, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReadPackageEnvelope not implemented")
+}
+func (*UnimplementedPackageServiceServer) ReadPackage(req *PackageRequest, srv PackageService_ReadPackageServer) error {
+	return status.Errorf(codes.Unimplemented, "method ReadPackage not implemented")
+}
+func (*UnimplementedPackageServiceServer) WritePackage(srv PackageService_WritePackageServer) error {
+	return status.Errorf(codes.Unimplemented, "method WritePackage not implemented")
+}
+func (*UnimplementedPackageServiceServer) UpdatePackageLabels(ctx context.Context, req *UpdatePackageLabelsRequest) (*types.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdatePackageLabels not implemented")
+}
+func (*UnimplementedPackageServiceServer) DeletePackage(ctx context.Context, req *PackageRequest) (*types.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeletePackage not implemented")
+}
+func (*UnimplementedPackageServiceServer) PinPackage(ctx context.Context, req *PackageRequest) (*types.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PinPackage not implemented")
+}
+func (*UnimplementedPackageServiceServer) UnpinPackage(ctx context.Context, req *PackageRequest) (*types.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnpinPackage not implemented")
+}
+func (*UnimplementedPackageServiceServer) UpsertPackageAlias(ctx context.Context, req *PackageAliasRequest) (*types.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpsertPackageAlias not implemented")
+}
+func (*UnimplementedPackageServiceServer) GetPackageAliases(ctx context.Context, req *RepositoryRequest) (*Payload, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPackageAliases not implemented")
+}
+func (*UnimplementedPackageServiceServer) DeletePackageAlias(ctx context.Context, req *PackageRequest) (*types.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeletePackageAlias not implemented")
+}
+func (*UnimplementedPackageServiceServer) WatchPackages(req *RepositoryRequest, srv PackageService_WatchPackagesServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchPackages not implemented")
+}
+func (*UnimplementedPackageServiceServer) PrunePackages(ctx context.Context, req *Payload) (*Payload, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PrunePackages not implemented")
+}
+
+func RegisterPackageServiceServer(s *grpc.Server, srv PackageServiceServer) {
+	s.RegisterService(&_PackageService_serviceDesc, srv)
+}
+
+func _PackageService_GetRepositories_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(types.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PackageServiceServer).GetRepositories(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.PackageService/GetRepositories",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PackageServiceServer).GetRepositories(ctx, req.(*types.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PackageService_GetRepository_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RepositoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PackageServiceServer).GetRepository(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.PackageService/GetRepository",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PackageServiceServer).GetRepository(ctx, req.(*RepositoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PackageService_UpsertRepository_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpsertRepositoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PackageServiceServer).UpsertRepository(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.PackageService/UpsertRepository",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PackageServiceServer).UpsertRepository(ctx, req.(*UpsertRepositoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PackageService_DeleteRepository_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RepositoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PackageServiceServer).DeleteRepository(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.PackageService/DeleteRepository",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PackageServiceServer).DeleteRepository(ctx, req.(*RepositoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PackageService_GetPackages_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPackagesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PackageServiceServer).GetPackages(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.PackageService/GetPackages",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PackageServiceServer).GetPackages(ctx, req.(*GetPackagesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PackageService_ReadPackageEnvelope_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PackageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PackageServiceServer).ReadPackageEnvelope(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.PackageService/ReadPackageEnvelope",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PackageServiceServer).ReadPackageEnvelope(ctx, req.(*PackageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PackageService_ReadPackage_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(PackageRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PackageServiceServer).ReadPackage(m, &packageServiceReadPackageServer{stream})
+}
+
+type PackageService_ReadPackageServer interface {
+	Send(*PackageChunk) error
+	grpc.ServerStream
+}
+
+type packageServiceReadPackageServer struct {
+	grpc.ServerStream
+}
+
+func (x *packageServiceReadPackageServer) Send(m *PackageChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _PackageService_WritePackage_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(PackageServiceServer).WritePackage(&packageServiceWritePackageServer{stream})
+}
+
+type PackageService_WritePackageServer interface {
+	SendAndClose(*Payload) error
+	Recv() (*PackageChunk, error)
+	grpc.ServerStream
+}
+
+type packageServiceWritePackageServer struct {
+	grpc.ServerStream
+}
+
+func (x *packageServiceWritePackageServer) SendAndClose(m *Payload) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *packageServiceWritePackageServer) Recv() (*PackageChunk, error) {
+	m := new(PackageChunk)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _PackageService_UpdatePackageLabels_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdatePackageLabelsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PackageServiceServer).UpdatePackageLabels(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.PackageService/UpdatePackageLabels",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PackageServiceServer).UpdatePackageLabels(ctx, req.(*UpdatePackageLabelsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PackageService_DeletePackage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PackageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PackageServiceServer).DeletePackage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.PackageService/DeletePackage",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PackageServiceServer).DeletePackage(ctx, req.(*PackageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PackageService_PinPackage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PackageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PackageServiceServer).PinPackage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.PackageService/PinPackage",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PackageServiceServer).PinPackage(ctx, req.(*PackageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PackageService_UnpinPackage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PackageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PackageServiceServer).UnpinPackage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.PackageService/UnpinPackage",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PackageServiceServer).UnpinPackage(ctx, req.(*PackageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PackageService_UpsertPackageAlias_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PackageAliasRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PackageServiceServer).UpsertPackageAlias(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.PackageService/UpsertPackageAlias",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PackageServiceServer).UpsertPackageAlias(ctx, req.(*PackageAliasRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PackageService_GetPackageAliases_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RepositoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PackageServiceServer).GetPackageAliases(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.PackageService/GetPackageAliases",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PackageServiceServer).GetPackageAliases(ctx, req.(*RepositoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PackageService_DeletePackageAlias_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PackageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PackageServiceServer).DeletePackageAlias(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.PackageService/DeletePackageAlias",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PackageServiceServer).DeletePackageAlias(ctx, req.(*PackageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PackageService_WatchPackages_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(RepositoryRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PackageServiceServer).WatchPackages(m, &packageServiceWatchPackagesServer{stream})
+}
+
+type PackageService_WatchPackagesServer interface {
+	Send(*Payload) error
+	grpc.ServerStream
+}
+
+type packageServiceWatchPackagesServer struct {
+	grpc.ServerStream
+}
+
+func (x *packageServiceWatchPackagesServer) Send(m *Payload) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _PackageService_PrunePackages_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Payload)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PackageServiceServer).PrunePackages(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.PackageService/PrunePackages",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PackageServiceServer).PrunePackages(ctx, req.(*Payload))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _PackageService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "proto.PackageService",
+	HandlerType: (*PackageServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetRepositories",
+			Handler:    _PackageService_GetRepositories_Handler,
+		},
+		{
+			MethodName: "GetRepository",
+			Handler:    _PackageService_GetRepository_Handler,
+		},
+		{
+			MethodName: "UpsertRepository",
+			Handler:    _PackageService_UpsertRepository_Handler,
+		},
+		{
+			MethodName: "DeleteRepository",
+			Handler:    _PackageService_DeleteRepository_Handler,
+		},
+		{
+			MethodName: "GetPackages",
+			Handler:    _PackageService_GetPackages_Handler,
+		},
+		{
+			MethodName: "ReadPackageEnvelope",
+			Handler:    _PackageService_ReadPackageEnvelope_Handler,
+		},
+		{
+			MethodName: "UpdatePackageLabels",
+			Handler:    _PackageService_UpdatePackageLabels_Handler,
+		},
+		{
+			MethodName: "DeletePackage",
+			Handler:    _PackageService_DeletePackage_Handler,
+		},
+		{
+			MethodName: "PinPackage",
+			Handler:    _PackageService_PinPackage_Handler,
+		},
+		{
+			MethodName: "UnpinPackage",
+			Handler:    _PackageService_UnpinPackage_Handler,
+		},
+		{
+			MethodName: "UpsertPackageAlias",
+			Handler:    _PackageService_UpsertPackageAlias_Handler,
+		},
+		{
+			MethodName: "GetPackageAliases",
+			Handler:    _PackageService_GetPackageAliases_Handler,
+		},
+		{
+			MethodName: "DeletePackageAlias",
+			Handler:    _PackageService_DeletePackageAlias_Handler,
+		},
+		{
+			MethodName: "PrunePackages",
+			Handler:    _PackageService_PrunePackages_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ReadPackage",
+			Handler:       _PackageService_ReadPackage_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WritePackage",
+			Handler:       _PackageService_WritePackage_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "WatchPackages",
+			Handler:       _PackageService_WatchPackages_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "pack.proto",
+}
+
+func (m *Payload) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Payload) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Payload) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if len(m.Data) > 0 {
+		i -= len(m.Data)
+		copy(dAtA[i:], m.Data)
+		i = encodeVarintPack(dAtA, i, uint64(len(m.Data)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *Repositories) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Repositories) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Repositories) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if len(m.Names) > 0 {
+		for iNdEx := len(m.Names) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Names[iNdEx])
+			copy(dAtA[i:], m.Names[iNdEx])
+			i = encodeVarintPack(dAtA, i, uint64(len(m.Names[iNdEx])))
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *RepositoryRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *RepositoryRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *RepositoryRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if len(m.Repository) > 0 {
+		i -= len(m.Repository)
+		copy(dAtA[i:], m.Repository)
+		i = encodeVarintPack(dAtA, i, uint64(len(m.Repository)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *UpsertRepositoryRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *UpsertRepositoryRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *UpsertRepositoryRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if len(m.Expires) > 0 {
+		i -= len(m.Expires)
+		copy(dAtA[i:], m.Expires)
+		i = encodeVarintPack(dAtA, i, uint64(len(m.Expires)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Repository) > 0 {
+		i -= len(m.Repository)
+		copy(dAtA[i:], m.Repository)
+		i = encodeVarintPack(dAtA, i, uint64(len(m.Repository)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *GetPackagesRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *GetPackagesRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *GetPackagesRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if len(m.Selector) > 0 {
+		i -= len(m.Selector)
+		copy(dAtA[i:], m.Selector)
+		i = encodeVarintPack(dAtA, i, uint64(len(m.Selector)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Repository) > 0 {
+		i -= len(m.Repository)
+		copy(dAtA[i:], m.Repository)
+		i = encodeVarintPack(dAtA, i, uint64(len(m.Repository)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *PackageRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *PackageRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *PackageRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if len(m.Locator) > 0 {
+		i -= len(m.Locator)
+		copy(dAtA[i:], m.Locator)
+		i = encodeVarintPack(dAtA, i, uint64(len(m.Locator)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *PackageHeader) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *PackageHeader) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *PackageHeader) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if len(m.CreatedBy) > 0 {
+		i -= len(m.CreatedBy)
+		copy(dAtA[i:], m.CreatedBy)
+		i = encodeVarintPack(dAtA, i, uint64(len(m.CreatedBy)))
+		i--
+		dAtA[i] = 0x42
+	}
+	if len(m.Manifest) > 0 {
+		i -= len(m.Manifest)
+		copy(dAtA[i:], m.Manifest)
+		i = encodeVarintPack(dAtA, i, uint64(len(m.Manifest)))
+		i--
+		dAtA[i] = 0x3a
+	}
+	if len(m.Type) > 0 {
+		i -= len(m.Type)
+		copy(dAtA[i:], m.Type)
+		i = encodeVarintPack(dAtA, i, uint64(len(m.Type)))
+		i--
+		dAtA[i] = 0x32
+	}
+	if m.Encrypted {
+		i--
+		if m.Encrypted {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x28
+	}
+	if m.Hidden {
+		i--
+		if m.Hidden {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x20
+	}
+	if len(m.Labels) > 0 {
+		for k := range m.Labels {
+			v := m.Labels[k]
+			baseI := i
+			i -= len(v)
+			copy(dAtA[i:], v)
+			i = encodeVarintPack(dAtA, i, uint64(len(v)))
+			i--
+			dAtA[i] = 0x12
+			i -= len(k)
+			copy(dAtA[i:], k)
+			i = encodeVarintPack(dAtA, i, uint64(len(k)))
+			i--
+			dAtA[i] = 0xa
+			i = encodeVarintPack(dAtA, i, uint64(baseI-i))
+			i--
+			dAtA[i] = 0x1a
+		}
+	}
+	if m.Upsert {
+		i--
+		if m.Upsert {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.Locator) > 0 {
+		i -= len(m.Locator)
+		copy(dAtA[i:], m.Locator)
+		i = encodeVarintPack(dAtA, i, uint64(len(m.Locator)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *PackageChunk) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *PackageChunk) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *PackageChunk) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if len(m.Data) > 0 {
+		i -= len(m.Data)
+		copy(dAtA[i:], m.Data)
+		i = encodeVarintPack(dAtA, i, uint64(len(m.Data)))
+		i--
+		dAtA[i] = 0x1a
+	}
+	if len(m.Envelope) > 0 {
+		i -= len(m.Envelope)
+		copy(dAtA[i:], m.Envelope)
+		i = encodeVarintPack(dAtA, i, uint64(len(m.Envelope)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.Header != nil {
+		{
+			size, err := m.Header.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintPack(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *UpdatePackageLabelsRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *UpdatePackageLabelsRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *UpdatePackageLabelsRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if len(m.Remove) > 0 {
+		for iNdEx := len(m.Remove) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Remove[iNdEx])
+			copy(dAtA[i:], m.Remove[iNdEx])
+			i = encodeVarintPack(dAtA, i, uint64(len(m.Remove[iNdEx])))
+			i--
+			dAtA[i] = 0x1a
+		}
+	}
+	if len(m.Add) > 0 {
+		for k := range m.Add {
+			v := m.Add[k]
+			baseI := i
+			i -= len(v)
+			copy(dAtA[i:], v)
+			i = encodeVarintPack(dAtA, i, uint64(len(v)))
+			i--
+			dAtA[i] = 0x12
+			i -= len(k)
+			copy(dAtA[i:], k)
+			i = encodeVarintPack(dAtA, i, uint64(len(k)))
+			i--
+			dAtA[i] = 0xa
+			i = encodeVarintPack(dAtA, i, uint64(baseI-i))
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if len(m.Locator) > 0 {
+		i -= len(m.Locator)
+		copy(dAtA[i:], m.Locator)
+		i = encodeVarintPack(dAtA, i, uint64(len(m.Locator)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *PackageAliasRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *PackageAliasRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *PackageAliasRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if len(m.Target) > 0 {
+		i -= len(m.Target)
+		copy(dAtA[i:], m.Target)
+		i = encodeVarintPack(dAtA, i, uint64(len(m.Target)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Alias) > 0 {
+		i -= len(m.Alias)
+		copy(dAtA[i:], m.Alias)
+		i = encodeVarintPack(dAtA, i, uint64(len(m.Alias)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func encodeVarintPack(dAtA []byte, offset int, v uint64) int {
+	offset -= sovPack(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return base
+}
+func (m *Payload) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Data)
+	if l > 0 {
+		n += 1 + l + sovPack(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *Repositories) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Names) > 0 {
+		for _, s := range m.Names {
+			l = len(s)
+			n += 1 + l + sovPack(uint64(l))
+		}
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *RepositoryRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Repository)
+	if l > 0 {
+		n += 1 + l + sovPack(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *UpsertRepositoryRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Repository)
+	if l > 0 {
+		n += 1 + l + sovPack(uint64(l))
+	}
+	l = len(m.Expires)
+	if l > 0 {
+		n += 1 + l + sovPack(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *GetPackagesRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Repository)
+	if l > 0 {
+		n += 1 + l + sovPack(uint64(l))
+	}
+	l = len(m.Selector)
+	if l > 0 {
+		n += 1 + l + sovPack(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *PackageRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Locator)
+	if l > 0 {
+		n += 1 + l + sovPack(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *PackageHeader) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Locator)
+	if l > 0 {
+		n += 1 + l + sovPack(uint64(l))
+	}
+	if m.Upsert {
+		n += 2
+	}
+	if len(m.Labels) > 0 {
+		for k, v := range m.Labels {
+			_ = k
+			_ = v
+			mapEntrySize := 1 + len(k) + sovPack(uint64(len(k))) + 1 + len(v) + sovPack(uint64(len(v)))
+			n += mapEntrySize + 1 + sovPack(uint64(mapEntrySize))
+		}
+	}
+	if m.Hidden {
+		n += 2
+	}
+	if m.Encrypted {
+		n += 2
+	}
+	l = len(m.Type)
+	if l > 0 {
+		n += 1 + l + sovPack(uint64(l))
+	}
+	l = len(m.Manifest)
+	if l > 0 {
+		n += 1 + l + sovPack(uint64(l))
+	}
+	l = len(m.CreatedBy)
+	if l > 0 {
+		n += 1 + l + sovPack(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *PackageChunk) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Header != nil {
+		l = m.Header.Size()
+		n += 1 + l + sovPack(uint64(l))
+	}
+	l = len(m.Envelope)
+	if l > 0 {
+		n += 1 + l + sovPack(uint64(l))
+	}
+	l = len(m.Data)
+	if l > 0 {
+		n += 1 + l + sovPack(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *UpdatePackageLabelsRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Locator)
+	if l > 0 {
+		n += 1 + l + sovPack(uint64(l))
+	}
+	if len(m.Add) > 0 {
+		for k, v := range m.Add {
+			_ = k
+			_ = v
+			mapEntrySize := 1 + len(k) + sovPack(uint64(len(k))) + 1 + len(v) + sovPack(uint64(len(v)))
+			n += mapEntrySize + 1 + sovPack(uint64(mapEntrySize))
+		}
+	}
+	if len(m.Remove) > 0 {
+		for _, s := range m.Remove {
+			l = len(s)
+			n += 1 + l + sovPack(uint64(l))
+		}
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *PackageAliasRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Alias)
+	if l > 0 {
+		n += 1 + l + sovPack(uint64(l))
+	}
+	l = len(m.Target)
+	if l > 0 {
+		n += 1 + l + sovPack(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func sovPack(x uint64) (n int) {
+	return (math_bits.Len64(x|1) + 6) / 7
+}
+func sozPack(x uint64) (n int) {
+	return sovPack(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (m *Payload) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowPack
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Payload: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Payload: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Data", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPack
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthPack
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthPack
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Data = append(m.Data[:0], dAtA[iNdEx:postIndex]...)
+			if m.Data == nil {
+				m.Data = []byte{}
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipPack(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthPack
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Repositories) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowPack
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Repositories: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Repositories: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Names", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPack
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthPack
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthPack
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Names = append(m.Names, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipPack(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthPack
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *RepositoryRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowPack
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: RepositoryRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: RepositoryRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Repository", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPack
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthPack
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthPack
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Repository = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipPack(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthPack
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *UpsertRepositoryRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowPack
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: UpsertRepositoryRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: UpsertRepositoryRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Repository", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPack
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthPack
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthPack
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Repository = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Expires", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPack
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthPack
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthPack
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Expires = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipPack(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthPack
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *GetPackagesRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowPack
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: GetPackagesRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: GetPackagesRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Repository", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPack
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthPack
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthPack
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Repository = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Selector", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPack
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthPack
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthPack
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Selector = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipPack(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthPack
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *PackageRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowPack
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: PackageRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: PackageRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Locator", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPack
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthPack
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthPack
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Locator = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipPack(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthPack
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *PackageHeader) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowPack
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: PackageHeader: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: PackageHeader: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Locator", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPack
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthPack
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthPack
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Locator = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Upsert", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPack
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Upsert = bool(v != 0)
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Labels", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPack
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthPack
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthPack
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Labels == nil {
+				m.Labels = make(map[string]string)
+			}
+			var mapkey string
+			var mapvalue string
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowPack
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					var stringLenmapkey uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowPack
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapkey |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapkey := int(stringLenmapkey)
+					if intStringLenmapkey < 0 {
+						return ErrInvalidLengthPack
+					}
+					postStringIndexmapkey := iNdEx + intStringLenmapkey
+					if postStringIndexmapkey < 0 {
+						return ErrInvalidLengthPack
+					}
+					if postStringIndexmapkey > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
+					iNdEx = postStringIndexmapkey
+				} else if fieldNum == 2 {
+					var stringLenmapvalue uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowPack
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapvalue |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapvalue := int(stringLenmapvalue)
+					if intStringLenmapvalue < 0 {
+						return ErrInvalidLengthPack
+					}
+					postStringIndexmapvalue := iNdEx + intStringLenmapvalue
+					if postStringIndexmapvalue < 0 {
+						return ErrInvalidLengthPack
+					}
+					if postStringIndexmapvalue > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapvalue = string(dAtA[iNdEx:postStringIndexmapvalue])
+					iNdEx = postStringIndexmapvalue
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipPack(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if (skippy < 0) || (iNdEx+skippy) < 0 {
+						return ErrInvalidLengthPack
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
+				}
+			}
+			m.Labels[mapkey] = mapvalue
+			iNdEx = postIndex
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Hidden", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPack
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Hidden = bool(v != 0)
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Encrypted", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPack
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Encrypted = bool(v != 0)
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Type", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPack
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthPack
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthPack
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Type = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Manifest", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPack
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthPack
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthPack
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Manifest = append(m.Manifest[:0], dAtA[iNdEx:postIndex]...)
+			if m.Manifest == nil {
+				m.Manifest = []byte{}
+			}
+			iNdEx = postIndex
+		case 8:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CreatedBy", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPack
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthPack
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthPack
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.CreatedBy = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipPack(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthPack
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *PackageChunk) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowPack
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: PackageChunk: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: PackageChunk: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Header", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPack
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthPack
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthPack
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Header == nil {
+				m.Header = &PackageHeader{}
+			}
+			if err := m.Header.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Envelope", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPack
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthPack
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthPack
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Envelope = append(m.Envelope[:0], dAtA[iNdEx:postIndex]...)
+			if m.Envelope == nil {
+				m.Envelope = []byte{}
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Data", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPack
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthPack
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthPack
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Data = append(m.Data[:0], dAtA[iNdEx:postIndex]...)
+			if m.Data == nil {
+				m.Data = []byte{}
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipPack(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthPack
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *UpdatePackageLabelsRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowPack
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: UpdatePackageLabelsRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: UpdatePackageLabelsRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Locator", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPack
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthPack
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthPack
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Locator = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Add", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPack
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthPack
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthPack
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Add == nil {
+				m.Add = make(map[string]string)
+			}
+			var mapkey string
+			var mapvalue string
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowPack
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					var stringLenmapkey uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowPack
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapkey |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapkey := int(stringLenmapkey)
+					if intStringLenmapkey < 0 {
+						return ErrInvalidLengthPack
+					}
+					postStringIndexmapkey := iNdEx + intStringLenmapkey
+					if postStringIndexmapkey < 0 {
+						return ErrInvalidLengthPack
+					}
+					if postStringIndexmapkey > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
+					iNdEx = postStringIndexmapkey
+				} else if fieldNum == 2 {
+					var stringLenmapvalue uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowPack
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapvalue |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapvalue := int(stringLenmapvalue)
+					if intStringLenmapvalue < 0 {
+						return ErrInvalidLengthPack
+					}
+					postStringIndexmapvalue := iNdEx + intStringLenmapvalue
+					if postStringIndexmapvalue < 0 {
+						return ErrInvalidLengthPack
+					}
+					if postStringIndexmapvalue > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapvalue = string(dAtA[iNdEx:postStringIndexmapvalue])
+					iNdEx = postStringIndexmapvalue
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipPack(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if (skippy < 0) || (iNdEx+skippy) < 0 {
+						return ErrInvalidLengthPack
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
+				}
+			}
+			m.Add[mapkey] = mapvalue
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Remove", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPack
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthPack
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthPack
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Remove = append(m.Remove, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipPack(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthPack
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *PackageAliasRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowPack
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: PackageAliasRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: PackageAliasRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Alias", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPack
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthPack
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthPack
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Alias = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Target", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPack
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthPack
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthPack
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Target = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipPack(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthPack
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func skipPack(dAtA []byte) (n int, err error) {
+	l := len(dAtA)
+	iNdEx := 0
+	depth := 0
+	for iNdEx < l {
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return 0, ErrIntOverflowPack
+			}
+			if iNdEx >= l {
+				return 0, io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		wireType := int(wire & 0x7)
+		switch wireType {
+		case 0:
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return 0, ErrIntOverflowPack
+				}
+				if iNdEx >= l {
+					return 0, io.ErrUnexpectedEOF
+				}
+				iNdEx++
+				if dAtA[iNdEx-1] < 0x80 {
+					break
+				}
+			}
+		case 1:
+			iNdEx += 8
+		case 2:
+			var length int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return 0, ErrIntOverflowPack
+				}
+				if iNdEx >= l {
+					return 0, io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				length |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if length < 0 {
+				return 0, ErrInvalidLengthPack
+			}
+			iNdEx += length
+		case 3:
+			depth++
+		case 4:
+			if depth == 0 {
+				return 0, ErrUnexpectedEndOfGroupPack
+			}
+			depth--
+		case 5:
+			iNdEx += 4
+		default:
+			return 0, fmt.Errorf("proto: illegal wireType %d", wireType)
+		}
+		if iNdEx < 0 {
+			return 0, ErrInvalidLengthPack
+		}
+		if depth == 0 {
+			return iNdEx, nil
+		}
+	}
+	return 0, io.ErrUnexpectedEOF
+}
+
+var (
+	ErrInvalidLengthPack        = fmt.Errorf("proto: negative length found during unmarshaling")
+	ErrIntOverflowPack          = fmt.Errorf("proto: integer overflow")
+	ErrUnexpectedEndOfGroupPack = fmt.Errorf("proto: unexpected end of group")
+)
@@ -0,0 +1,349 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package grpcpack implements the gRPC transport for the package service.
+//
+// Compared to the HTTP package service, the gRPC transport multiplexes
+// all calls over a single connection and streams package data in chunks,
+// which makes it suitable for internal components that transfer many
+// packages, like RPC agents and operation executors.
+package grpcpack
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/pack"
+	"github.com/gravitational/gravity/lib/pack/grpcpack/proto"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/gravitational/trace"
+	"google.golang.org/grpc"
+)
+
+// Register registers the package service with the gRPC server.
+// The server is expected to authenticate clients using transport credentials
+func Register(server *grpc.Server, packages pack.PackageService) {
+	proto.RegisterPackageServiceServer(server, &Server{packages: packages})
+}
+
+// Server serves the package service over gRPC
+type Server struct {
+	packages pack.PackageService
+}
+
+// GetRepositories returns the names of all repositories
+func (s *Server) GetRepositories(ctx context.Context, _ *types.Empty) (*proto.Repositories, error) {
+	repositories, err := s.packages.GetRepositories()
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &proto.Repositories{Names: repositories}, nil
+}
+
+// GetRepository returns the repository specified with the request
+func (s *Server) GetRepository(ctx context.Context, req *proto.RepositoryRequest) (*proto.Payload, error) {
+	repository, err := s.packages.GetRepository(req.Repository)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	data, err := storage.MarshalRepository(repository)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &proto.Payload{Data: data}, nil
+}
+
+// UpsertRepository creates or updates a repository
+func (s *Server) UpsertRepository(ctx context.Context, req *proto.UpsertRepositoryRequest) (*types.Empty, error) {
+	var expires time.Time
+	if req.Expires != "" {
+		if err := expires.UnmarshalText([]byte(req.Expires)); err != nil {
+			return nil, toStatus(trace.BadParameter("invalid expiration time %q", req.Expires))
+		}
+	}
+	if err := s.packages.UpsertRepository(req.Repository, expires); err != nil {
+		return nil, toStatus(err)
+	}
+	return &types.Empty{}, nil
+}
+
+// DeleteRepository deletes a repository
+func (s *Server) DeleteRepository(ctx context.Context, req *proto.RepositoryRequest) (*types.Empty, error) {
+	if err := s.packages.DeleteRepository(req.Repository); err != nil {
+		return nil, toStatus(err)
+	}
+	return &types.Empty{}, nil
+}
+
+// GetPackages returns envelopes of packages in a repository,
+// optionally matching a label selector
+func (s *Server) GetPackages(ctx context.Context, req *proto.GetPackagesRequest) (*proto.Payload, error) {
+	var packages []pack.PackageEnvelope
+	var err error
+	if req.Selector != "" {
+		packages, err = s.packages.GetPackagesBySelector(req.Repository, req.Selector)
+	} else {
+		packages, err = s.packages.GetPackages(req.Repository)
+	}
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return newPayload(packages)
+}
+
+// ReadPackageEnvelope returns the envelope of a package
+func (s *Server) ReadPackageEnvelope(ctx context.Context, req *proto.PackageRequest) (*proto.Payload, error) {
+	locator, err := loc.ParseLocator(req.Locator)
+	if err != nil {
+		return nil, toStatus(trace.BadParameter("%v", err))
+	}
+	envelope, err := s.packages.ReadPackageEnvelope(*locator)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return newPayload(envelope)
+}
+
+// ReadPackage streams the package. The first chunk carries the package
+// envelope, the following chunks carry the package data
+func (s *Server) ReadPackage(req *proto.PackageRequest, stream proto.PackageService_ReadPackageServer) error {
+	locator, err := loc.ParseLocator(req.Locator)
+	if err != nil {
+		return toStatus(trace.BadParameter("%v", err))
+	}
+	envelope, reader, err := s.packages.ReadPackage(*locator)
+	if err != nil {
+		return toStatus(err)
+	}
+	defer reader.Close()
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		return toStatus(err)
+	}
+	if err := stream.Send(&proto.PackageChunk{Envelope: data}); err != nil {
+		return err
+	}
+	buf := make([]byte, chunkSize)
+	for {
+		n, err := reader.Read(buf)
+		if n > 0 {
+			if err := stream.Send(&proto.PackageChunk{Data: buf[:n]}); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return toStatus(err)
+		}
+	}
+}
+
+// WritePackage creates or updates a package from the stream.
+// The first chunk carries the package header, the following chunks
+// carry the package data
+func (s *Server) WritePackage(stream proto.PackageService_WritePackageServer) error {
+	chunk, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	header := chunk.Header
+	if header == nil {
+		return toStatus(trace.BadParameter("missing package header"))
+	}
+	locator, err := loc.ParseLocator(header.Locator)
+	if err != nil {
+		return toStatus(trace.BadParameter("%v", err))
+	}
+	options := []pack.PackageOption{
+		pack.WithLabels(header.Labels),
+		pack.WithHidden(header.Hidden),
+		pack.WithEncrypted(header.Encrypted),
+	}
+	if header.Type != "" || len(header.Manifest) != 0 {
+		options = append(options, pack.WithManifest(header.Type, header.Manifest))
+	}
+	if header.CreatedBy != "" {
+		options = append(options, pack.WithCreatedBy(header.CreatedBy))
+	}
+	reader := &chunkReader{
+		buf: chunk.Data,
+		recv: func() ([]byte, error) {
+			chunk, err := stream.Recv()
+			if err != nil {
+				return nil, err
+			}
+			return chunk.Data, nil
+		},
+	}
+	var envelope *pack.PackageEnvelope
+	if header.Upsert {
+		envelope, err = s.packages.UpsertPackage(*locator, reader, options...)
+	} else {
+		envelope, err = s.packages.CreatePackage(*locator, reader, options...)
+	}
+	if err != nil {
+		return toStatus(err)
+	}
+	payload, err := newPayload(envelope)
+	if err != nil {
+		return err
+	}
+	return stream.SendAndClose(payload)
+}
+
+// UpdatePackageLabels adds and removes package labels
+func (s *Server) UpdatePackageLabels(ctx context.Context, req *proto.UpdatePackageLabelsRequest) (*types.Empty, error) {
+	locator, err := loc.ParseLocator(req.Locator)
+	if err != nil {
+		return nil, toStatus(trace.BadParameter("%v", err))
+	}
+	if err := s.packages.UpdatePackageLabels(*locator, req.Add, req.Remove); err != nil {
+		return nil, toStatus(err)
+	}
+	return &types.Empty{}, nil
+}
+
+// DeletePackage deletes a package
+func (s *Server) DeletePackage(ctx context.Context, req *proto.PackageRequest) (*types.Empty, error) {
+	return s.withLocator(req, s.packages.DeletePackage)
+}
+
+// PinPackage protects a package from garbage collection
+func (s *Server) PinPackage(ctx context.Context, req *proto.PackageRequest) (*types.Empty, error) {
+	return s.withLocator(req, s.packages.PinPackage)
+}
+
+// UnpinPackage removes the garbage collection protection from a package
+func (s *Server) UnpinPackage(ctx context.Context, req *proto.PackageRequest) (*types.Empty, error) {
+	return s.withLocator(req, s.packages.UnpinPackage)
+}
+
+// UpsertPackageAlias points the alias to the target package
+func (s *Server) UpsertPackageAlias(ctx context.Context, req *proto.PackageAliasRequest) (*types.Empty, error) {
+	alias, err := loc.ParseLocator(req.Alias)
+	if err != nil {
+		return nil, toStatus(trace.BadParameter("%v", err))
+	}
+	target, err := loc.ParseLocator(req.Target)
+	if err != nil {
+		return nil, toStatus(trace.BadParameter("%v", err))
+	}
+	if err := s.packages.UpsertPackageAlias(*alias, *target); err != nil {
+		return nil, toStatus(err)
+	}
+	return &types.Empty{}, nil
+}
+
+// GetPackageAliases returns package aliases in a repository
+func (s *Server) GetPackageAliases(ctx context.Context, req *proto.RepositoryRequest) (*proto.Payload, error) {
+	aliases, err := s.packages.GetPackageAliases(req.Repository)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return newPayload(aliases)
+}
+
+// DeletePackageAlias deletes a package alias
+func (s *Server) DeletePackageAlias(ctx context.Context, req *proto.PackageRequest) (*types.Empty, error) {
+	return s.withLocator(req, s.packages.DeletePackageAlias)
+}
+
+// WatchPackages streams package events in a repository.
+// The first message is empty and confirms that the watch is established
+func (s *Server) WatchPackages(req *proto.RepositoryRequest, stream proto.PackageService_WatchPackagesServer) error {
+	eventsC, err := s.packages.WatchPackages(stream.Context(), req.Repository)
+	if err != nil {
+		return toStatus(err)
+	}
+	if err := stream.Send(&proto.Payload{}); err != nil {
+		return err
+	}
+	for event := range eventsC {
+		payload, err := newPayload(event)
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PrunePackages removes stale packages
+func (s *Server) PrunePackages(ctx context.Context, req *proto.Payload) (*proto.Payload, error) {
+	var prune pack.PruneRequest
+	if err := json.Unmarshal(req.Data, &prune); err != nil {
+		return nil, toStatus(trace.BadParameter("%v", err))
+	}
+	resp, err := s.packages.PrunePackages(prune)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return newPayload(resp)
+}
+
+func (s *Server) withLocator(req *proto.PackageRequest, fn func(loc.Locator) error) (*types.Empty, error) {
+	locator, err := loc.ParseLocator(req.Locator)
+	if err != nil {
+		return nil, toStatus(trace.BadParameter("%v", err))
+	}
+	if err := fn(*locator); err != nil {
+		return nil, toStatus(err)
+	}
+	return &types.Empty{}, nil
+}
+
+func newPayload(value interface{}) (*proto.Payload, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &proto.Payload{Data: data}, nil
+}
+
+// chunkReader reads data from a stream of chunks
+type chunkReader struct {
+	// buf is the unread part of the current chunk
+	buf []byte
+	// recv receives the next chunk
+	recv func() ([]byte, error)
+}
+
+// Read reads the data from the current chunk, receiving
+// the next one once it is exhausted
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		data, err := r.recv()
+		if err != nil {
+			return 0, err
+		}
+		r.buf = data
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// chunkSize is the maximum size of the package data in a single message
+const chunkSize = 256 * 1024
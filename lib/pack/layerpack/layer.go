@@ -17,6 +17,7 @@ limitations under the License.
 package layerpack
 
 import (
+	"context"
 	"io"
 	"sort"
 	"time"
@@ -124,6 +125,13 @@ func (l *Layer) GetPackages(repository string) ([]pack.PackageEnvelope, error) {
 	return packages, nil
 }
 
+// GetPackagesBySelector returns a list of packages in repository matching the selector.
+// The selector is evaluated after the layers have been merged as the outer layer
+// may override package labels
+func (l *Layer) GetPackagesBySelector(repository, selector string) ([]pack.PackageEnvelope, error) {
+	return pack.GetPackagesBySelector(l, repository, selector)
+}
+
 // UpdatePackageLabels updates package's labels
 func (l *Layer) UpdatePackageLabels(loc loc.Locator, addLabels map[string]string, removeLabels []string) error {
 	err := l.inner.UpdatePackageLabels(loc, addLabels, removeLabels)
@@ -150,6 +158,16 @@ func (l *Layer) DeletePackage(loc loc.Locator) error {
 	return l.outer.DeletePackage(loc)
 }
 
+// PinPackage protects the package from deletion until it is unpinned
+func (l *Layer) PinPackage(loc loc.Locator) error {
+	return pack.Pin(l, loc)
+}
+
+// UnpinPackage removes the deletion protection from the package
+func (l *Layer) UnpinPackage(loc loc.Locator) error {
+	return pack.Unpin(l, loc)
+}
+
 // Read package opens and returns package contents
 func (l *Layer) ReadPackage(loc loc.Locator) (*pack.PackageEnvelope, io.ReadCloser, error) {
 	e, rc, err := l.outer.ReadPackage(loc)
@@ -173,3 +191,78 @@ func (l *Layer) ReadPackageEnvelope(loc loc.Locator) (*pack.PackageEnvelope, err
 	}
 	return l.inner.ReadPackageEnvelope(loc)
 }
+
+// UpsertPackageAlias creates or updates the package alias in the outer layer
+func (l *Layer) UpsertPackageAlias(alias, target loc.Locator) error {
+	return l.outer.UpsertPackageAlias(alias, target)
+}
+
+// GetPackageAliases returns a list of package aliases in repository.
+// Aliases in the outer layer take precedence over the inner layer
+func (l *Layer) GetPackageAliases(repository string) ([]storage.PackageAlias, error) {
+	innerAliases, err := l.inner.GetPackageAliases(repository)
+	if err != nil {
+		if !trace.IsNotFound(err) {
+			return nil, trace.Wrap(err)
+		}
+	}
+	outerAliases, err := l.outer.GetPackageAliases(repository)
+	if err != nil {
+		if !trace.IsNotFound(err) {
+			return nil, trace.Wrap(err)
+		}
+	}
+	aliasesSet := make(map[string]storage.PackageAlias)
+	for _, a := range append(innerAliases, outerAliases...) {
+		aliasesSet[a.Locator().String()] = a
+	}
+	aliases := make([]storage.PackageAlias, 0, len(aliasesSet))
+	for _, a := range aliasesSet {
+		aliases = append(aliases, a)
+	}
+	sort.Slice(aliases, func(i, j int) bool {
+		return aliases[i].Locator().String() < aliases[j].Locator().String()
+	})
+	return aliases, nil
+}
+
+// DeletePackageAlias deletes the package alias from the outer layer
+func (l *Layer) DeletePackageAlias(alias loc.Locator) error {
+	return l.outer.DeletePackageAlias(alias)
+}
+
+// WatchPackages watches packages in the outer layer.
+// The inner layer is read-only so its packages never change
+func (l *Layer) WatchPackages(ctx context.Context, repository string) (<-chan pack.PackageEvent, error) {
+	return l.outer.WatchPackages(ctx, repository)
+}
+
+// PrunePackages deletes packages of the outer layer that are not in use.
+// The inner layer is read-only so its packages, along with the packages
+// they reference, are always retained
+func (l *Layer) PrunePackages(req pack.PruneRequest) (*pack.PruneResponse, error) {
+	repositories, err := l.inner.GetRepositories()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	for _, repository := range repositories {
+		envelopes, err := l.inner.GetPackages(repository)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		for _, envelope := range envelopes {
+			req.Retain = append(req.Retain, envelope.Locator)
+		}
+	}
+	return pack.Prune(l, req)
+}
+
+// GetStats returns usage statistics of the outer layer.
+// The inner layer is a read-only mirror and does not grow
+func (l *Layer) GetStats() (*pack.StoreStats, error) {
+	reporter, ok := l.outer.(pack.StatsReporter)
+	if !ok {
+		return nil, trace.NotImplemented("package service does not report usage statistics")
+	}
+	return reporter.GetStats()
+}
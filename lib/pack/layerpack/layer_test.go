@@ -114,6 +114,38 @@ func (s *LayerSuite) TestDeleteRepository(c *C) {
 	s.suite.DeleteRepository(c)
 }
 
+func (s *LayerSuite) TestPackageAliases(c *C) {
+	s.suite.PackageAliases(c)
+}
+
+func (s *LayerSuite) TestLayeredConfig(c *C) {
+	s.suite.LayeredConfig(c)
+}
+
+func (s *LayerSuite) TestConfigurePackage(c *C) {
+	s.suite.ConfigurePackage(c)
+}
+
+func (s *LayerSuite) TestTransactions(c *C) {
+	s.suite.Transactions(c)
+}
+
+func (s *LayerSuite) TestWatchPackages(c *C) {
+	s.suite.WatchPackages(c)
+}
+
+func (s *LayerSuite) TestWatchAllPackages(c *C) {
+	s.suite.WatchAllPackages(c)
+}
+
+func (s *LayerSuite) TestPrunePackages(c *C) {
+	s.suite.PrunePackages(c)
+}
+
+func (s *LayerSuite) TestPinPackages(c *C) {
+	s.suite.PinPackages(c)
+}
+
 func (s *LayerSuite) TestLayers(c *C) {
 	// create one package in the inner layer
 	c.Assert(s.server.inner.UpsertRepository("inner.example.com", time.Time{}), IsNil)
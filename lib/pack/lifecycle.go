@@ -0,0 +1,76 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pack
+
+import (
+	"github.com/gravitational/gravity/lib/loc"
+
+	"github.com/gravitational/trace"
+)
+
+// SetLifecycle marks the specified package version with the lifecycle status.
+// Marking the package as supported removes the lifecycle label
+func SetLifecycle(packages PackageService, locator loc.Locator, lifecycle string) error {
+	if err := CheckLifecycle(lifecycle); err != nil {
+		return trace.Wrap(err)
+	}
+	if lifecycle == LifecycleSupported {
+		return trace.Wrap(packages.UpdatePackageLabels(locator, nil, []string{LifecycleLabel}))
+	}
+	return trace.Wrap(packages.UpdatePackageLabels(locator,
+		map[string]string{LifecycleLabel: lifecycle}, nil))
+}
+
+// CheckLifecycle returns an error if the specified lifecycle status is not valid
+func CheckLifecycle(lifecycle string) error {
+	switch lifecycle {
+	case LifecycleSupported, LifecycleDeprecated, LifecycleEndOfLife:
+		return nil
+	}
+	return trace.BadParameter("unknown lifecycle status %q, supported are: %q, %q, %q",
+		lifecycle, LifecycleSupported, LifecycleDeprecated, LifecycleEndOfLife)
+}
+
+// Lifecycle returns the lifecycle status of the package version
+func (p PackageEnvelope) Lifecycle() string {
+	if lifecycle, ok := p.RuntimeLabels[LifecycleLabel]; ok {
+		return lifecycle
+	}
+	return LifecycleSupported
+}
+
+// IsDeprecated returns true if the package version has been deprecated
+func (p PackageEnvelope) IsDeprecated() bool {
+	return p.Lifecycle() == LifecycleDeprecated
+}
+
+// IsEndOfLife returns true if the package version has reached the end of life
+func (p PackageEnvelope) IsEndOfLife() bool {
+	return p.Lifecycle() == LifecycleEndOfLife
+}
+
+const (
+	// LifecycleSupported is the status of a supported version
+	LifecycleSupported = "supported"
+	// LifecycleDeprecated is the status of a version that is still supported
+	// but is going to reach the end of life
+	LifecycleDeprecated = "deprecated"
+	// LifecycleEndOfLife is the status of a version that is no longer supported.
+	// End-of-life versions are not considered when resolving the latest version
+	// of a package
+	LifecycleEndOfLife = "eol"
+)
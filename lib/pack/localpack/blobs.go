@@ -0,0 +1,77 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package localpack
+
+import (
+	"io"
+
+	"github.com/gravitational/gravity/lib/blob"
+	"github.com/gravitational/gravity/lib/loc"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// BLOBs are addressed by the digest of their contents, so packages with
+// identical contents, e.g. the same planet package in several versions of
+// a cluster image, reference a single BLOB. The backend counts the packages
+// that reference each BLOB and a BLOB is deleted only after the last package
+// that references it has been deleted or replaced.
+
+// writeBLOB writes the package contents to the BLOB storage and calls fn
+// to record the package that references the BLOB.
+//
+// If the BLOB has been deleted concurrently before the package has been
+// recorded, the package is deleted and CompareFailed is returned so the
+// caller can retry. If fn fails, the BLOB is released
+func (p *PackageServer) writeBLOB(loc loc.Locator, data io.Reader, fn func(*blob.Envelope) error) error {
+	envelope, err := p.cfg.Objects.WriteBLOB(data)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = fn(envelope)
+	if err != nil {
+		if errRelease := p.releaseBLOB(envelope.SHA512); errRelease != nil {
+			log.Warnf("Failed to release BLOB %v: %v.", envelope.SHA512, errRelease)
+		}
+		return trace.Wrap(err)
+	}
+	_, err = p.cfg.Objects.GetBLOBEnvelope(envelope.SHA512)
+	if err == nil {
+		return nil
+	}
+	if !trace.IsNotFound(err) {
+		return trace.Wrap(err)
+	}
+	if err := p.backend.DeletePackage(loc.Repository, loc.Name, loc.Version, loc.Arch); err != nil && !trace.IsNotFound(err) {
+		return trace.Wrap(err)
+	}
+	return trace.CompareFailed("contents of package %v have been deleted concurrently, retry", loc)
+}
+
+// releaseBLOB deletes the BLOB with the specified hash unless
+// it is still referenced by any package
+func (p *PackageServer) releaseBLOB(hash string) error {
+	return p.backend.DeleteBLOB(hash, func() error {
+		log.Infof("Deleting BLOB %v.", hash)
+		err := p.cfg.Objects.DeleteBLOB(hash)
+		if err != nil && !trace.IsNotFound(err) {
+			return trace.Wrap(err)
+		}
+		return nil
+	})
+}
@@ -17,18 +17,27 @@ limitations under the License.
 package localpack
 
 import (
+	"bytes"
+	"context"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/gravitational/gravity/lib/archive"
 	"github.com/gravitational/gravity/lib/blob"
 	"github.com/gravitational/gravity/lib/blob/fs"
 	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/pack"
 	"github.com/gravitational/gravity/lib/pack/suite"
 	"github.com/gravitational/gravity/lib/storage"
 	"github.com/gravitational/gravity/lib/storage/keyval"
+	"github.com/gravitational/gravity/lib/utils"
 
+	"github.com/gravitational/trace"
 	log "github.com/sirupsen/logrus"
 	"github.com/mailgun/timetools"
 	. "gopkg.in/check.v1"
@@ -95,3 +104,451 @@ func (s *LocalSuite) TestUpsertPackages(c *C) {
 func (s *LocalSuite) TestDeleteRepository(c *C) {
 	s.suite.DeleteRepository(c)
 }
+
+func (s *LocalSuite) TestPackageAliases(c *C) {
+	s.suite.PackageAliases(c)
+}
+
+func (s *LocalSuite) TestLayeredConfig(c *C) {
+	s.suite.LayeredConfig(c)
+}
+
+func (s *LocalSuite) TestConfigurePackage(c *C) {
+	s.suite.ConfigurePackage(c)
+}
+
+func (s *LocalSuite) TestTransactions(c *C) {
+	s.suite.Transactions(c)
+}
+
+func (s *LocalSuite) TestWatchPackages(c *C) {
+	s.suite.WatchPackages(c)
+}
+
+func (s *LocalSuite) TestWatchAllPackages(c *C) {
+	s.suite.WatchAllPackages(c)
+}
+
+func (s *LocalSuite) TestPrunePackages(c *C) {
+	s.suite.PrunePackages(c)
+}
+
+func (s *LocalSuite) TestPinPackages(c *C) {
+	s.suite.PinPackages(c)
+}
+
+func (s *LocalSuite) TestGetPackagesBySelector(c *C) {
+	s.suite.GetPackagesBySelector(c)
+}
+
+func (s *LocalSuite) TestDetectsCorruptedPackage(c *C) {
+	server := s.suite.S.(*PackageServer)
+	c.Assert(server.UpsertRepository("example.com", time.Time{}), IsNil)
+	locator := loc.MustParseLocator("example.com/a:1.0.0")
+	envelope, err := server.CreatePackage(locator, strings.NewReader("data"))
+	c.Assert(err, IsNil)
+	c.Assert(envelope.SHA256, Not(Equals), "")
+
+	// simulate on-disk corruption of the package BLOB
+	paths, err := filepath.Glob(filepath.Join(s.dir, "blobs", "*", envelope.SHA512))
+	c.Assert(err, IsNil)
+	c.Assert(paths, HasLen, 1)
+	c.Assert(ioutil.WriteFile(paths[0], []byte("dada"), defaults.SharedReadMask), IsNil)
+
+	_, reader, err := server.ReadPackage(locator)
+	c.Assert(err, IsNil)
+	defer reader.Close()
+	_, err = ioutil.ReadAll(reader)
+	c.Assert(trace.IsCompareFailed(err), Equals, true, Commentf("%v", err))
+}
+
+func (s *LocalSuite) TestUnpackReportsProgress(c *C) {
+	server := s.suite.S.(*PackageServer)
+	c.Assert(server.UpsertRepository("example.com", time.Time{}), IsNil)
+	locator := loc.MustParseLocator("example.com/a:1.0.0")
+	data := archive.MustCreateMemArchive([]*archive.Item{
+		archive.DirItem("dir"),
+		archive.ItemFromString("dir/file", "hello"),
+		archive.ItemFromString("other", "world"),
+	})
+	envelope, err := server.CreatePackage(locator, data)
+	c.Assert(err, IsNil)
+
+	var last pack.Progress
+	var entries []string
+	dir := c.MkDir()
+	err = pack.UnpackWithOptions(server, locator, dir, pack.UnpackOptions{
+		Progress: func(progress pack.Progress) {
+			if progress.Entry != last.Entry {
+				entries = append(entries, progress.Entry)
+			}
+			last = progress
+		},
+	})
+	c.Assert(err, IsNil)
+	c.Assert(entries, DeepEquals, []string{"dir/", "dir/file", "other"})
+	c.Assert(last.Current, Equals, envelope.SizeBytes)
+	c.Assert(last.Total, Equals, envelope.SizeBytes)
+
+	contents, err := ioutil.ReadFile(filepath.Join(dir, "dir", "file"))
+	c.Assert(err, IsNil)
+	c.Assert(string(contents), Equals, "hello")
+}
+
+func (s *LocalSuite) TestUnpacksIfNotUnpacked(c *C) {
+	server := s.suite.S.(*PackageServer)
+	c.Assert(server.UpsertRepository("example.com", time.Time{}), IsNil)
+	locator := loc.MustParseLocator("example.com/a:1.0.0")
+	envelope, err := server.CreatePackage(locator, archive.MustCreateMemArchive([]*archive.Item{
+		archive.ItemFromString("file", "hello"),
+		archive.ItemFromString("stale", "world"),
+	}))
+	c.Assert(err, IsNil)
+
+	dir := c.MkDir()
+	unpack := func(verify bool) {
+		c.Assert(pack.UnpackIfNotUnpacked(server, locator, dir, pack.UnpackOptions{Verify: verify}), IsNil)
+	}
+	unpack(false)
+	unpacked, err := pack.IsUnpacked(dir, envelope.SHA512, false)
+	c.Assert(err, IsNil)
+	c.Assert(unpacked, Equals, true)
+	// checksums are not recorded without verify mode
+	unpacked, err = pack.IsUnpacked(dir, envelope.SHA512, true)
+	c.Assert(err, IsNil)
+	c.Assert(unpacked, Equals, false)
+
+	// directory unpacked before the markers were introduced is not unpacked again
+	c.Assert(os.Remove(filepath.Join(dir, pack.UnpackedMarkerFile)), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "file"), []byte("bye"), defaults.SharedReadMask), IsNil)
+	unpack(false)
+	assertFile(c, filepath.Join(dir, "file"), "bye")
+	_, err = os.Stat(filepath.Join(dir, pack.UnpackedMarkerFile))
+	c.Assert(err, IsNil)
+
+	// interrupted unpack is repeated
+	c.Assert(os.Remove(filepath.Join(dir, pack.UnpackedMarkerFile)), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, ".unpacking"), nil, defaults.SharedReadMask), IsNil)
+	unpack(false)
+	assertFile(c, filepath.Join(dir, "file"), "hello")
+	_, err = os.Stat(filepath.Join(dir, ".unpacking"))
+	c.Assert(os.IsNotExist(err), Equals, true)
+
+	// modified files are only detected in verify mode
+	unpack(true)
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "file"), []byte("bye"), defaults.SharedReadMask), IsNil)
+	unpack(false)
+	assertFile(c, filepath.Join(dir, "file"), "bye")
+	unpack(true)
+	assertFile(c, filepath.Join(dir, "file"), "hello")
+
+	// stale contents are removed once the package has changed
+	envelope, err = server.UpsertPackage(locator, archive.MustCreateMemArchive([]*archive.Item{
+		archive.ItemFromString("file", "updated"),
+	}))
+	c.Assert(err, IsNil)
+	unpacked, err = pack.IsUnpacked(dir, envelope.SHA512, false)
+	c.Assert(err, IsNil)
+	c.Assert(unpacked, Equals, false)
+	unpack(false)
+	assertFile(c, filepath.Join(dir, "file"), "updated")
+	_, err = os.Stat(filepath.Join(dir, "stale"))
+	c.Assert(os.IsNotExist(err), Equals, true)
+}
+
+func (s *LocalSuite) TestUnpackFailsFastWhileLocked(c *C) {
+	server := s.suite.S.(*PackageServer)
+	c.Assert(server.UpsertRepository("example.com", time.Time{}), IsNil)
+	locator := loc.MustParseLocator("example.com/a:1.0.0")
+	_, err := server.CreatePackage(locator, archive.MustCreateMemArchive([]*archive.Item{
+		archive.ItemFromString("file", "hello"),
+	}))
+	c.Assert(err, IsNil)
+
+	dir := filepath.Join(c.MkDir(), "a")
+	lock, err := utils.LockFile(context.TODO(), dir+pack.UnpackLockSuffix, false)
+	c.Assert(err, IsNil)
+	err = pack.UnpackIfNotUnpacked(server, locator, dir, pack.UnpackOptions{FailFast: true})
+	c.Assert(trace.IsCompareFailed(err), Equals, true, Commentf("%v", err))
+	err = pack.UnpackWithOptions(server, locator, dir, pack.UnpackOptions{LockTimeout: 100 * time.Millisecond})
+	c.Assert(trace.IsLimitExceeded(err), Equals, true, Commentf("%v", err))
+
+	c.Assert(lock.Unlock(), IsNil)
+	c.Assert(pack.UnpackIfNotUnpacked(server, locator, dir, pack.UnpackOptions{FailFast: true}), IsNil)
+	assertFile(c, filepath.Join(dir, "file"), "hello")
+}
+
+func (s *LocalSuite) TestDeleteRemovesUnpackLock(c *C) {
+	server := s.suite.S.(*PackageServer)
+	c.Assert(server.UpsertRepository("example.com", time.Time{}), IsNil)
+	locator := loc.MustParseLocator("example.com/a:1.0.0")
+	_, err := server.CreatePackage(locator, archive.MustCreateMemArchive([]*archive.Item{
+		archive.ItemFromString("file", "hello"),
+	}))
+	c.Assert(err, IsNil)
+	c.Assert(server.Unpack(locator, ""), IsNil)
+	dir, err := server.UnpackedPath(locator)
+	c.Assert(err, IsNil)
+	_, err = os.Stat(dir + pack.UnpackLockSuffix)
+	c.Assert(err, IsNil)
+
+	c.Assert(server.DeletePackage(locator), IsNil)
+	for _, path := range []string{dir, dir + pack.UnpackLockSuffix} {
+		_, err = os.Stat(path)
+		c.Assert(os.IsNotExist(err), Equals, true, Commentf(path))
+	}
+}
+
+func (s *LocalSuite) TestCancelsPackageCommand(c *C) {
+	server := s.suite.S.(*PackageServer)
+	c.Assert(server.UpsertRepository("example.com", time.Time{}), IsNil)
+	locator := loc.MustParseLocator("example.com/tool:1.0.0")
+	manifest := pack.Manifest{
+		Version: pack.Version,
+		Commands: []pack.Command{{
+			Name: "wait",
+			Args: []string{"/bin/sh", "-c", "echo started; exec sleep 60"},
+		}},
+	}
+	data, err := manifest.EncodeJSON()
+	c.Assert(err, IsNil)
+	_, err = server.CreatePackage(locator, archive.MustCreateMemArchive([]*archive.Item{
+		archive.ItemFromString(pack.ManifestFilename, string(data)),
+	}))
+	c.Assert(err, IsNil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// the command is cancelled once it has started producing output
+	w := &cancelWriter{cancel: cancel}
+	start := time.Now()
+	err = pack.ExecutePackageCommand(ctx, server, "wait", locator, nil, nil, c.MkDir(), w)
+	c.Assert(err, NotNil)
+	c.Assert(trace.Unwrap(err), Equals, context.Canceled)
+	c.Assert(time.Since(start) < 30*time.Second, Equals, true)
+	c.Assert(w.buf.String(), Equals, "started\n")
+}
+
+func (s *LocalSuite) TestExecutesUnpackedPackageCommand(c *C) {
+	server := s.suite.S.(*PackageServer)
+	c.Assert(server.UpsertRepository("example.com", time.Time{}), IsNil)
+	locator := loc.MustParseLocator("example.com/tool:1.0.0")
+	manifest := pack.Manifest{
+		Version: pack.Version,
+		Commands: []pack.Command{{
+			Name: "status",
+			Args: []string{"/bin/sh", "-c", "cat file"},
+		}},
+	}
+	data, err := manifest.EncodeJSON()
+	c.Assert(err, IsNil)
+	_, err = server.CreatePackage(locator, archive.MustCreateMemArchive([]*archive.Item{
+		archive.ItemFromString(pack.ManifestFilename, string(data)),
+		archive.ItemFromString("file", "packaged"),
+	}))
+	c.Assert(err, IsNil)
+
+	// the package is not unpacked if it is missing
+	dir := filepath.Join(c.MkDir(), "tool")
+	var buf bytes.Buffer
+	err = pack.ExecuteUnpackedPackageCommand(context.TODO(), server, "status", dir, nil, nil, &buf)
+	c.Assert(err, NotNil)
+	_, err = os.Stat(dir)
+	c.Assert(os.IsNotExist(err), Equals, true)
+
+	// nor unpacked over the existing contents
+	c.Assert(server.Unpack(locator, dir), IsNil)
+	c.Assert(os.Remove(filepath.Join(dir, pack.UnpackedMarkerFile)), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "file"), []byte("live"), defaults.SharedReadMask), IsNil)
+	err = pack.ExecuteUnpackedPackageCommand(context.TODO(), server, "status", dir, nil, nil, &buf)
+	c.Assert(err, IsNil)
+	c.Assert(buf.String(), Equals, "live")
+	_, err = os.Stat(filepath.Join(dir, pack.UnpackedMarkerFile))
+	c.Assert(os.IsNotExist(err), Equals, true)
+}
+
+// cancelWriter is an io.Writer that invokes cancel on the first write
+type cancelWriter struct {
+	buf    bytes.Buffer
+	cancel context.CancelFunc
+}
+
+func (w *cancelWriter) Write(p []byte) (int, error) {
+	defer w.cancel()
+	return w.buf.Write(p)
+}
+
+func assertFile(c *C, path, contents string) {
+	data, err := ioutil.ReadFile(path)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, contents)
+}
+
+func (s *LocalSuite) TestStats(c *C) {
+	server := s.suite.S.(*PackageServer)
+	for _, repo := range []string{"example.com", "gravitational.io"} {
+		c.Assert(server.UpsertRepository(repo, time.Time{}), IsNil)
+	}
+	for _, pkg := range []string{"example.com/a:1.0.0", "example.com/b:1.0.0", "gravitational.io/c:1.0.0"} {
+		_, err := server.CreatePackage(loc.MustParseLocator(pkg), strings.NewReader("data"))
+		c.Assert(err, IsNil)
+	}
+	_, err := s.suite.O.WriteBLOB(strings.NewReader("orphan"))
+	c.Assert(err, IsNil)
+	c.Assert(os.MkdirAll(filepath.Join(s.dir, defaults.UnpackedDir, "a"), defaults.SharedDirMask), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(s.dir, defaults.UnpackedDir, "a", "file"),
+		[]byte("unpacked"), defaults.SharedReadMask), IsNil)
+
+	stats, err := server.GetStats()
+	c.Assert(err, IsNil)
+	c.Assert(stats, DeepEquals, &pack.StoreStats{
+		TotalSizeBytes: 12,
+		TotalPackages:  3,
+		Repositories: []pack.RepositoryStats{
+			{Repository: "example.com", Packages: 2, SizeBytes: 8},
+			{Repository: "gravitational.io", Packages: 1, SizeBytes: 4},
+		},
+		StoredSizeBytes:   4,
+		UnpackedSizeBytes: 8,
+		OrphanedBLOBs:     1,
+		OrphanedSizeBytes: 6,
+	})
+}
+
+func (s *LocalSuite) TestSharesBLOBs(c *C) {
+	server := s.suite.S.(*PackageServer)
+	c.Assert(server.UpsertRepository("example.com", time.Time{}), IsNil)
+	a := loc.MustParseLocator("example.com/a:1.0.0")
+	b := loc.MustParseLocator("example.com/b:1.0.0")
+	var envelopes []*pack.PackageEnvelope
+	for _, locator := range []loc.Locator{a, b} {
+		envelope, err := server.CreatePackage(locator, strings.NewReader("data"))
+		c.Assert(err, IsNil)
+		envelopes = append(envelopes, envelope)
+	}
+	c.Assert(envelopes[0].SHA512, Equals, envelopes[1].SHA512)
+	blobs, err := s.suite.O.GetBLOBs()
+	c.Assert(err, IsNil)
+	c.Assert(blobs, DeepEquals, []string{envelopes[0].SHA512})
+
+	refs, err := s.backend.GetBLOBReferences(envelopes[0].SHA512)
+	c.Assert(err, IsNil)
+	c.Assert(refs, Equals, 2)
+
+	// the BLOB is kept while referenced by another package
+	c.Assert(server.DeletePackage(a), IsNil)
+	refs, err = s.backend.GetBLOBReferences(envelopes[0].SHA512)
+	c.Assert(err, IsNil)
+	c.Assert(refs, Equals, 1)
+	_, reader, err := server.ReadPackage(b)
+	c.Assert(err, IsNil)
+	data, err := ioutil.ReadAll(reader)
+	c.Assert(err, IsNil)
+	c.Assert(reader.Close(), IsNil)
+	c.Assert(string(data), Equals, "data")
+
+	// the BLOB is released when the last package is replaced
+	_, err = server.UpsertPackage(b, strings.NewReader("new data"))
+	c.Assert(err, IsNil)
+	_, err = s.suite.O.GetBLOBEnvelope(envelopes[0].SHA512)
+	c.Assert(trace.IsNotFound(err), Equals, true)
+
+	// the BLOB of a package that failed to be created is released
+	_, err = server.CreatePackage(b, strings.NewReader("other data"))
+	c.Assert(trace.IsAlreadyExists(err), Equals, true)
+	blobs, err = s.suite.O.GetBLOBs()
+	c.Assert(err, IsNil)
+	c.Assert(blobs, HasLen, 1)
+}
+
+// TestReplacesPackageAtomically makes sure that readers always see either
+// the old or the new contents of a package while it is being replaced
+func (s *LocalSuite) TestReplacesPackageAtomically(c *C) {
+	server := s.suite.S.(*PackageServer)
+	c.Assert(server.UpsertRepository("example.com", time.Time{}), IsNil)
+	locator := loc.MustParseLocator("example.com/config:0.0.1")
+	contents := []string{strings.Repeat("a", 1<<20), strings.Repeat("b", 1<<20)}
+	_, err := server.CreatePackage(locator, strings.NewReader(contents[0]))
+	c.Assert(err, IsNil)
+
+	stopC := make(chan struct{})
+	errC := make(chan error, 1)
+	go func() {
+		for {
+			select {
+			case <-stopC:
+				errC <- nil
+				return
+			default:
+			}
+			_, reader, err := server.ReadPackage(locator)
+			if err != nil {
+				errC <- trace.Wrap(err)
+				return
+			}
+			data, err := ioutil.ReadAll(reader)
+			reader.Close()
+			if err != nil {
+				errC <- trace.Wrap(err)
+				return
+			}
+			if string(data) != contents[0] && string(data) != contents[1] {
+				errC <- trace.CompareFailed("read %v bytes of a partially written package", len(data))
+				return
+			}
+		}
+	}()
+	for i := 1; i <= 20 && err == nil; i++ {
+		_, err = server.UpsertPackage(locator, strings.NewReader(contents[i%2]))
+	}
+	close(stopC)
+	c.Assert(<-errC, IsNil)
+	c.Assert(err, IsNil)
+}
+
+func (s *LocalSuite) TestVerifiesPackages(c *C) {
+	server := s.suite.S.(*PackageServer)
+	server.cfg.Verifier = testVerifier{}
+	c.Assert(server.UpsertRepository("example.com", time.Time{}), IsNil)
+	data := archive.MustCreateMemArchive([]*archive.Item{
+		archive.ItemFromString("file", "hello"),
+	})
+
+	unsigned := loc.MustParseLocator("example.com/a:1.0.0")
+	_, err := server.CreatePackage(unsigned, strings.NewReader("data"))
+	c.Assert(trace.IsAccessDenied(err), Equals, true)
+	_, err = server.UpsertPackage(unsigned, strings.NewReader("data"))
+	c.Assert(trace.IsAccessDenied(err), Equals, true)
+	_, err = server.ReadPackageEnvelope(unsigned)
+	c.Assert(trace.IsNotFound(err), Equals, true)
+
+	signed := loc.MustParseLocator("example.com/b:1.0.0")
+	_, err = server.CreatePackage(signed, data,
+		pack.WithLabels(map[string]string{pack.SignatureLabel: "valid"}))
+	c.Assert(err, IsNil)
+
+	// signature cannot be removed or replaced
+	err = server.UpdatePackageLabels(signed, nil, []string{pack.SignatureLabel})
+	c.Assert(trace.IsAccessDenied(err), Equals, true)
+	err = server.UpdatePackageLabels(signed, map[string]string{pack.SignatureLabel: "invalid"}, nil)
+	c.Assert(trace.IsAccessDenied(err), Equals, true)
+	c.Assert(server.UpdatePackageLabels(signed, pack.InstalledLabels, nil), IsNil)
+	c.Assert(server.Unpack(signed, filepath.Join(c.MkDir(), "unpacked")), IsNil)
+
+	// packages are verified before unpacking
+	err = s.backend.UpdatePackageRuntimeLabels(signed.Repository, signed.Name, signed.Version, "",
+		nil, []string{pack.SignatureLabel})
+	c.Assert(err, IsNil)
+	err = server.Unpack(signed, filepath.Join(c.MkDir(), "unpacked"))
+	c.Assert(trace.IsAccessDenied(err), Equals, true)
+}
+
+// testVerifier accepts packages with the "valid" signature
+type testVerifier struct{}
+
+func (testVerifier) Verify(envelope pack.PackageEnvelope) error {
+	if envelope.Signature() != "valid" {
+		return trace.AccessDenied("package %v has invalid signature", envelope.Locator)
+	}
+	return nil
+}
@@ -17,6 +17,10 @@ limitations under the License.
 package localpack
 
 import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"hash"
 	"io"
 	"os"
 	"strings"
@@ -27,11 +31,13 @@ import (
 	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/pack"
 	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
 
 	"github.com/gravitational/configure/cstrings"
 	"github.com/gravitational/trace"
 	"github.com/mailgun/timetools"
 	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 // Config represents package server configuration
@@ -52,6 +58,10 @@ type Config struct {
 
 	// UnpackedDir is the path for unpacked packages
 	UnpackedDir string
+
+	// Verifier optionally verifies package signatures. If set, packages
+	// that fail verification can be neither created nor unpacked
+	Verifier pack.Verifier
 }
 
 // PackageServer manages BLOBs of data and their metadata as packages
@@ -59,6 +69,8 @@ type PackageServer struct {
 	cfg     Config
 	clock   timetools.TimeProvider
 	backend storage.Backend
+	// events distributes package changes to watchers
+	events *pack.Broadcaster
 }
 
 func New(cfg Config) (*PackageServer, error) {
@@ -80,6 +92,7 @@ func New(cfg Config) (*PackageServer, error) {
 	s := &PackageServer{
 		cfg:     cfg,
 		backend: cfg.Backend,
+		events:  pack.NewBroadcaster(),
 	}
 	return s, nil
 }
@@ -92,7 +105,7 @@ func (p *PackageServer) PortalURL() string {
 func (p *PackageServer) PackageDownloadURL(loc loc.Locator) string {
 	return strings.Join([]string{
 		p.cfg.DownloadURL, "pack", "v1", "repositories", loc.Repository,
-		"packages", loc.Name, loc.Version, "file"}, "/")
+		"packages", loc.Name, loc.ArchVersion(), "file"}, "/")
 }
 
 // Get repositories returns a list of repositories
@@ -116,20 +129,38 @@ func (p *PackageServer) GetRepository(repository string) (storage.Repository, er
 
 // GetPackages returns a list of package in a given repository
 func (p *PackageServer) GetPackages(repository string) ([]pack.PackageEnvelope, error) {
+	return p.getPackages(repository, labels.Everything())
+}
+
+// GetPackagesBySelector returns a list of packages in a given repository
+// with labels matching the selector
+func (p *PackageServer) GetPackagesBySelector(repository, expr string) ([]pack.PackageEnvelope, error) {
+	selector, err := pack.ParseSelector(expr)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return p.getPackages(repository, selector)
+}
+
+func (p *PackageServer) getPackages(repository string, selector labels.Selector) ([]pack.PackageEnvelope, error) {
 	envelopes := []pack.PackageEnvelope{}
 	packages, err := p.backend.GetPackages(repository)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
 	for _, p := range packages {
+		if !selector.Matches(labels.Set(p.RuntimeLabels)) {
+			continue
+		}
 		loc, err := loc.NewLocator(repository, p.Name, p.Version)
 		if err != nil {
 			return nil, trace.Wrap(err)
 		}
 		envelopes = append(envelopes, pack.PackageEnvelope{
-			Locator:       *loc,
+			Locator:       loc.WithArch(p.Arch),
 			SizeBytes:     int64(p.SizeBytes),
 			SHA512:        p.SHA512,
+			SHA256:        p.SHA256,
 			RuntimeLabels: p.RuntimeLabels,
 			Hidden:        p.Hidden,
 			Encrypted:     p.Encrypted,
@@ -145,103 +176,145 @@ func (p *PackageServer) GetPackages(repository string) ([]pack.PackageEnvelope,
 
 // CreatePackage creates a new package in existing repository
 func (p *PackageServer) CreatePackage(loc loc.Locator, data io.Reader, options ...pack.PackageOption) (*pack.PackageEnvelope, error) {
-	// check that the repository exists
-	_, err := p.backend.GetRepository(loc.Repository)
-	if err != nil {
-		return nil, trace.Wrap(err)
-	}
-	blobEnvelope, err := p.cfg.Objects.WriteBLOB(data)
-	if err != nil {
-		return nil, trace.Wrap(err)
+	if loc.IsAlias() {
+		return nil, trace.BadParameter("can not create package with alias version %v", loc)
 	}
-
-	pkg := storage.Package{
-		Repository: loc.Repository,
-		Name:       loc.Name,
-		Version:    loc.Version,
-		SHA512:     blobEnvelope.SHA512,
-		SizeBytes:  int(blobEnvelope.SizeBytes),
-		Created:    p.cfg.Clock.UtcNow(),
-	}
-	for _, option := range options {
-		option(&pkg)
-	}
-
-	envelope := &pack.PackageEnvelope{
-		Locator:       loc,
-		SHA512:        blobEnvelope.SHA512,
-		SizeBytes:     blobEnvelope.SizeBytes,
-		RuntimeLabels: pkg.RuntimeLabels,
-		Hidden:        pkg.Hidden,
-		Encrypted:     pkg.Encrypted,
-		Type:          pkg.Type,
-		Manifest:      pkg.Manifest,
-		Created:       pkg.Created,
-		CreatedBy:     pkg.CreatedBy,
-	}
-
 	// check that the repository exists
-	_, err = p.backend.GetRepository(loc.Repository)
+	_, err := p.backend.GetRepository(loc.Repository)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-
-	// create package in repository
-	_, err = p.backend.CreatePackage(pkg)
+	var envelope *pack.PackageEnvelope
+	digest := sha256.New()
+	err = p.writeBLOB(loc, io.TeeReader(data, digest), func(blobEnvelope *blob.Envelope) error {
+		pkg := newPackage(loc, blobEnvelope, digest, p.cfg.Clock.UtcNow(), options...)
+		envelope = newEnvelope(loc, &pkg)
+		if err := p.verify(*envelope); err != nil {
+			return trace.Wrap(err)
+		}
+		// check that the repository exists
+		_, err := p.backend.GetRepository(loc.Repository)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		// create package in repository
+		_, err = p.backend.CreatePackage(pkg)
+		return trace.Wrap(err)
+	})
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
 
+	p.events.Publish(pack.PackageEvent{Type: pack.PackageCreated, Envelope: *envelope})
 	return envelope, nil
 }
 
 // UpsertPackage upserts package and repository
 func (p *PackageServer) UpsertPackage(loc loc.Locator, data io.Reader, options ...pack.PackageOption) (*pack.PackageEnvelope, error) {
-	blobEnvelope, err := p.cfg.Objects.WriteBLOB(data)
+	if loc.IsAlias() {
+		return nil, trace.BadParameter("can not create package with alias version %v", loc)
+	}
+	var envelope *pack.PackageEnvelope
+	var replaced *storage.Package
+	digest := sha256.New()
+	err := p.writeBLOB(loc, io.TeeReader(data, digest), func(blobEnvelope *blob.Envelope) error {
+		pkg := newPackage(loc, blobEnvelope, digest, p.cfg.Clock.UtcNow(), options...)
+		envelope = newEnvelope(loc, &pkg)
+		if err := p.verify(*envelope); err != nil {
+			return trace.Wrap(err)
+		}
+		_, err := p.backend.CreateRepository(storage.NewRepository(loc.Repository))
+		if err != nil && !trace.IsAlreadyExists(err) {
+			return trace.Wrap(err)
+		}
+		replaced, err = p.backend.GetPackage(loc.Repository, loc.Name, loc.Version, loc.Arch)
+		if err != nil && !trace.IsNotFound(err) {
+			return trace.Wrap(err)
+		}
+		_, err = p.backend.UpsertPackage(pkg)
+		return trace.Wrap(err)
+	})
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
 
+	p.events.Publish(pack.PackageEvent{Type: pack.PackageCreated, Envelope: *envelope})
+	if replaced != nil && replaced.SHA512 != envelope.SHA512 {
+		if err := p.releaseBLOB(replaced.SHA512); err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+	return envelope, nil
+}
+
+// newPackage returns the package metadata that references the BLOB
+func newPackage(loc loc.Locator, blobEnvelope *blob.Envelope, digest hash.Hash, created time.Time, options ...pack.PackageOption) storage.Package {
 	pkg := storage.Package{
 		Repository: loc.Repository,
 		Name:       loc.Name,
 		Version:    loc.Version,
+		Arch:       loc.Arch,
 		SHA512:     blobEnvelope.SHA512,
+		SHA256:     fmt.Sprintf("%x", digest.Sum(nil)),
 		SizeBytes:  int(blobEnvelope.SizeBytes),
-		Created:    p.cfg.Clock.UtcNow(),
+		Created:    created,
 	}
 	for _, option := range options {
 		option(&pkg)
 	}
+	return pkg
+}
 
-	envelope := &pack.PackageEnvelope{
-		Locator:       loc,
-		SHA512:        blobEnvelope.SHA512,
-		SizeBytes:     blobEnvelope.SizeBytes,
-		RuntimeLabels: pkg.RuntimeLabels,
-		Hidden:        pkg.Hidden,
-		Encrypted:     pkg.Encrypted,
-		Type:          pkg.Type,
-		Manifest:      pkg.Manifest,
-		Created:       pkg.Created,
-		CreatedBy:     pkg.CreatedBy,
+// verify makes sure the package satisfies the verification policy
+func (p *PackageServer) verify(envelope pack.PackageEnvelope) error {
+	if p.cfg.Verifier == nil {
+		return nil
 	}
+	return p.cfg.Verifier.Verify(envelope)
+}
 
-	_, err = p.backend.CreateRepository(storage.NewRepository(loc.Repository))
-	if err != nil {
-		if !trace.IsAlreadyExists(err) {
-			return nil, trace.Wrap(err)
-		}
+// verifyLabels makes sure the package still satisfies the verification policy
+// after the labels that affect it have been updated
+func (p *PackageServer) verifyLabels(loc loc.Locator, addLabels map[string]string, removeLabels []string) error {
+	if p.cfg.Verifier == nil || !affectsVerification(addLabels, removeLabels) {
+		return nil
 	}
-	_, err = p.backend.UpsertPackage(pkg)
+	pkg, err := p.backend.GetPackage(loc.Repository, loc.Name, loc.Version, loc.Arch)
 	if err != nil {
-		return nil, trace.Wrap(err)
+		return trace.Wrap(err)
+	}
+	envelope := newEnvelope(loc, pkg)
+	labels := utils.CombineLabels(envelope.RuntimeLabels, addLabels)
+	for _, name := range removeLabels {
+		delete(labels, name)
 	}
+	envelope.RuntimeLabels = labels
+	return p.cfg.Verifier.Verify(*envelope)
+}
 
-	return envelope, nil
+// affectsVerification returns true if the label update changes any of
+// the labels the package verification depends on
+func affectsVerification(addLabels map[string]string, removeLabels []string) bool {
+	for _, name := range []string{pack.SignatureLabel, pack.PurposeLabel, pack.ConfigLabel} {
+		if _, ok := addLabels[name]; ok {
+			return true
+		}
+		if utils.StringInSlice(removeLabels, name) {
+			return true
+		}
+	}
+	return false
 }
 
 func (p *PackageServer) processMetadata(locator loc.Locator) (loc.Locator, error) {
+	if locator.IsAlias() {
+		alias, err := p.backend.GetPackageAlias(locator.Repository, locator.Name, locator.Version)
+		if err != nil {
+			return locator, trace.Wrap(err)
+		}
+		// aliases point to the same version of all variants of the package
+		return alias.Target().WithArch(locator.Arch), nil
+	}
 	locatorPtr, err := pack.ProcessMetadata(p, &locator)
 	if err != nil {
 		return locator, trace.Wrap(err)
@@ -256,23 +329,51 @@ func (p *PackageServer) ReadPackage(loc loc.Locator) (*pack.PackageEnvelope, io.
 	if err != nil {
 		return nil, nil, trace.Wrap(err)
 	}
-	pk, err := p.backend.GetPackage(loc.Repository, loc.Name, loc.Version)
+	_, err = p.backend.GetRepository(loc.Repository)
 	if err != nil {
 		return nil, nil, trace.Wrap(err)
 	}
-	_, err = p.backend.GetRepository(loc.Repository)
+	pk, f, err := p.openPackage(loc)
 	if err != nil {
 		return nil, nil, trace.Wrap(err)
 	}
-	f, err := p.cfg.Objects.OpenBLOB(pk.SHA512)
+	return newEnvelope(loc, pk), pack.NewDigestReader(f, pk.SHA256), nil
+}
+
+// openPackage returns the package metadata along with the opened package BLOB.
+//
+// The package can be replaced with UpsertPackage between reading its metadata
+// and opening the BLOB, which releases the BLOB the metadata refers to.
+// In this case the metadata is read again so the reader gets the new package
+func (p *PackageServer) openPackage(loc loc.Locator) (*storage.Package, io.ReadCloser, error) {
+	pk, err := p.backend.GetPackage(loc.Repository, loc.Name, loc.Version, loc.Arch)
 	if err != nil {
 		return nil, nil, trace.Wrap(err)
 	}
-	return newEnvelope(loc, pk), f, nil
+	for {
+		f, err := p.cfg.Objects.OpenBLOB(pk.SHA512)
+		if err == nil {
+			return pk, f, nil
+		}
+		if !trace.IsNotFound(err) {
+			return nil, nil, trace.Wrap(err)
+		}
+		current, errGet := p.backend.GetPackage(loc.Repository, loc.Name, loc.Version, loc.Arch)
+		if errGet != nil {
+			return nil, nil, trace.Wrap(errGet)
+		}
+		if current.SHA512 == pk.SHA512 {
+			return nil, nil, trace.Wrap(err)
+		}
+		pk = current
+	}
 }
 
 // DeletePackage removes package from all repository and deletes the package
 func (p *PackageServer) DeletePackage(loc loc.Locator) error {
+	if loc.IsAlias() {
+		return trace.BadParameter("%v is a package alias, use DeletePackageAlias to delete it", loc)
+	}
 	repo, err := p.backend.GetRepository(loc.Repository)
 	if err != nil {
 		return trace.Wrap(err)
@@ -281,18 +382,24 @@ func (p *PackageServer) DeletePackage(loc loc.Locator) error {
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	pk, err := p.backend.GetPackage(repo.GetName(), loc.Name, loc.Version)
+	if err := p.checkNotAliased(loc); err != nil {
+		return trace.Wrap(err)
+	}
+	pk, err := p.backend.GetPackage(repo.GetName(), loc.Name, loc.Version, loc.Arch)
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	if err := pack.CheckNotPinned(loc, pk.RuntimeLabels); err != nil {
+		return trace.Wrap(err)
+	}
 	// remove package from all repositories
-	err = p.backend.DeletePackage(loc.Repository, loc.Name, loc.Version)
+	err = p.backend.DeletePackage(loc.Repository, loc.Name, loc.Version, loc.Arch)
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	p.events.Publish(pack.PackageEvent{Type: pack.PackageDeleted, Envelope: *newEnvelope(loc, pk)})
 
-	log.Infof("DeletePackages deleting %v BLOB %v", loc, pk.SHA512)
-	if err := p.cfg.Objects.DeleteBLOB(pk.SHA512); err != nil {
+	if err := p.releaseBLOB(pk.SHA512); err != nil {
 		return trace.Wrap(err)
 	}
 	unpackedPath, err := p.UnpackedPath(loc)
@@ -301,13 +408,23 @@ func (p *PackageServer) DeletePackage(loc loc.Locator) error {
 	}
 	if _, err := os.Stat(unpackedPath); err == nil {
 		log.Infof("DeletePackages delete unpacked: %v", unpackedPath)
-		if err := os.RemoveAll(unpackedPath); err != nil {
+		if err := pack.RemoveUnpacked(unpackedPath); err != nil {
 			return trace.Wrap(err)
 		}
 	}
 	return nil
 }
 
+// PinPackage protects the package from deletion until it is unpinned
+func (p *PackageServer) PinPackage(loc loc.Locator) error {
+	return pack.Pin(p, loc)
+}
+
+// UnpinPackage removes the deletion protection from the package
+func (p *PackageServer) UnpinPackage(loc loc.Locator) error {
+	return pack.Unpin(p, loc)
+}
+
 // UpdatePackageLabels updates package's labels
 func (p *PackageServer) UpdatePackageLabels(loc loc.Locator, addLabels map[string]string, removeLabels []string) error {
 	var err error
@@ -315,8 +432,19 @@ func (p *PackageServer) UpdatePackageLabels(loc loc.Locator, addLabels map[strin
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	err = p.backend.UpdatePackageRuntimeLabels(loc.Repository, loc.Name, loc.Version, addLabels, removeLabels)
-	return trace.Wrap(err)
+	if err := p.verifyLabels(loc, addLabels, removeLabels); err != nil {
+		return trace.Wrap(err)
+	}
+	err = p.backend.UpdatePackageRuntimeLabels(loc.Repository, loc.Name, loc.Version, loc.Arch, addLabels, removeLabels)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	pk, err := p.backend.GetPackage(loc.Repository, loc.Name, loc.Version, loc.Arch)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	p.events.Publish(pack.PackageEvent{Type: pack.PackageLabelsUpdated, Envelope: *newEnvelope(loc, pk)})
+	return nil
 }
 
 // UpsertRepository creates or updates repository, note that expiration
@@ -341,19 +469,33 @@ func (p *PackageServer) UpsertRepository(repository string, expires time.Time) e
 	if err != nil {
 		return trace.Wrap(err)
 	}
-
+	p.events.Publish(pack.PackageEvent{Type: pack.RepositoryCreated, Repository: repository})
 	return nil
 }
 
 // DeleteRepository deletes repository and all its packages
 func (p *PackageServer) DeleteRepository(repository string) error {
-	err := pack.ForeachPackageInRepo(p, repository, func(e pack.PackageEnvelope) error {
+	aliases, err := p.backend.GetPackageAliases(repository)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	for _, alias := range aliases {
+		err := p.backend.DeletePackageAlias(alias.Repository, alias.Name, alias.Alias)
+		if err != nil && !trace.IsNotFound(err) {
+			return trace.Wrap(err)
+		}
+	}
+	err = pack.ForeachPackageInRepo(p, repository, func(e pack.PackageEnvelope) error {
 		return trace.Wrap(p.DeletePackage(e.Locator))
 	})
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	return trace.Wrap(p.backend.DeleteRepository(repository))
+	if err := p.backend.DeleteRepository(repository); err != nil {
+		return trace.Wrap(err)
+	}
+	p.events.Publish(pack.PackageEvent{Type: pack.RepositoryDeleted, Repository: repository})
+	return nil
 }
 
 // Readpack.PackageEnvelope returns package envelope without reading the BLOB
@@ -363,13 +505,100 @@ func (p *PackageServer) ReadPackageEnvelope(loc loc.Locator) (*pack.PackageEnvel
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	pk, err := p.backend.GetPackage(loc.Repository, loc.Name, loc.Version)
+	pk, err := p.backend.GetPackage(loc.Repository, loc.Name, loc.Version, loc.Arch)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
 	return newEnvelope(loc, pk), nil
 }
 
+// UpsertPackageAlias creates the package alias or atomically updates
+// it to point to the specified target package
+func (p *PackageServer) UpsertPackageAlias(alias, target loc.Locator) error {
+	if !alias.IsAlias() {
+		return trace.BadParameter("%v is not a package alias, alias version should be a name, e.g. current", alias)
+	}
+	if target.IsAlias() {
+		return trace.BadParameter("alias %v can not point to another alias %v", alias, target)
+	}
+	if alias.Repository != target.Repository || alias.Name != target.Name {
+		return trace.BadParameter("alias %v and target %v should refer to the same package", alias, target)
+	}
+	target, err := p.processMetadata(target)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	// make sure the target package exists
+	if _, err := p.backend.GetPackage(target.Repository, target.Name, target.Version, target.Arch); err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = p.backend.UpsertPackageAlias(storage.PackageAlias{
+		Repository: alias.Repository,
+		Name:       alias.Name,
+		Alias:      alias.Version,
+		Version:    target.Version,
+		Updated:    p.cfg.Clock.UtcNow(),
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	log.Infof("Package alias %v now points to %v.", alias, target)
+	return nil
+}
+
+// GetPackageAliases returns a list of package aliases in repository
+func (p *PackageServer) GetPackageAliases(repository string) ([]storage.PackageAlias, error) {
+	aliases, err := p.backend.GetPackageAliases(repository)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return aliases, nil
+}
+
+// DeletePackageAlias deletes the package alias
+func (p *PackageServer) DeletePackageAlias(alias loc.Locator) error {
+	if !alias.IsAlias() {
+		return trace.BadParameter("%v is not a package alias", alias)
+	}
+	err := p.backend.DeletePackageAlias(alias.Repository, alias.Name, alias.Version)
+	return trace.Wrap(err)
+}
+
+// WatchPackages returns a channel that receives events about changes
+// to packages in the specified repository, or all repositories if
+// the repository is empty.
+// Only changes made through this package server are reported
+func (p *PackageServer) WatchPackages(ctx context.Context, repository string) (<-chan pack.PackageEvent, error) {
+	if repository != "" {
+		if _, err := p.backend.GetRepository(repository); err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+	return p.events.Watch(ctx, repository), nil
+}
+
+// PrunePackages deletes packages that are not in use
+func (p *PackageServer) PrunePackages(req pack.PruneRequest) (*pack.PruneResponse, error) {
+	return pack.Prune(p, req)
+}
+
+// checkNotAliased returns an error if any package alias points to the specified package.
+// Aliases resolve to the variant of the package for the requested architecture,
+// so all variants of an aliased package are considered referenced
+func (p *PackageServer) checkNotAliased(locator loc.Locator) error {
+	aliases, err := p.backend.GetPackageAliases(locator.Repository)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	for _, alias := range aliases {
+		if alias.Target().WithoutArch().IsEqualTo(locator.WithoutArch()) {
+			return trace.CompareFailed("package %v is referenced by alias %v, update or delete the alias first",
+				locator, alias.Locator())
+		}
+	}
+	return nil
+}
+
 func (p *PackageServer) UnpackedPath(loc loc.Locator) (string, error) {
 	// the path can not be too long because it leads to problems like this:
 	// https: //github.com/golang/go/issues/6895
@@ -395,17 +624,12 @@ func (p *PackageServer) Unpack(loc loc.Locator, targetDir string) error {
 			return trace.Wrap(err)
 		}
 	}
-	unpacked, err := pack.IsUnpacked(targetDir)
+	err = pack.UnpackIfNotUnpacked(p, loc, targetDir, pack.UnpackOptions{
+		Verifier: p.cfg.Verifier,
+	})
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	if unpacked {
-		log.Infof("%v is already unpacked", loc)
-		return nil
-	}
-	if err := pack.Unpack(p, loc, targetDir, nil); err != nil {
-		return trace.Wrap(err)
-	}
 	return nil
 }
 
@@ -439,6 +663,7 @@ func newEnvelope(loc loc.Locator, p *storage.Package) *pack.PackageEnvelope {
 		Locator:       loc,
 		SizeBytes:     int64(p.SizeBytes),
 		SHA512:        p.SHA512,
+		SHA256:        p.SHA256,
 		RuntimeLabels: p.RuntimeLabels,
 		Hidden:        p.Hidden,
 		Encrypted:     p.Encrypted,
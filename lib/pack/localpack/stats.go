@@ -0,0 +1,99 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package localpack
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/gravitational/gravity/lib/pack"
+
+	"github.com/gravitational/trace"
+)
+
+// GetStats returns usage statistics of this package store: sizes of
+// all packages and repositories, space used by the distinct package BLOBs,
+// disk space used by unpacked packages and the number of BLOBs not
+// referenced by any package
+func (p *PackageServer) GetStats() (*pack.StoreStats, error) {
+	repos, err := p.backend.GetRepositories()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var stats pack.StoreStats
+	referenced := make(map[string]struct{})
+	for _, repo := range repos {
+		packages, err := p.backend.GetPackages(repo.GetName())
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		repoStats := pack.RepositoryStats{Repository: repo.GetName()}
+		for _, pkg := range packages {
+			repoStats.Packages++
+			repoStats.SizeBytes += int64(pkg.SizeBytes)
+			if _, ok := referenced[pkg.SHA512]; !ok {
+				stats.StoredSizeBytes += int64(pkg.SizeBytes)
+			}
+			referenced[pkg.SHA512] = struct{}{}
+		}
+		stats.Repositories = append(stats.Repositories, repoStats)
+		stats.TotalPackages += repoStats.Packages
+		stats.TotalSizeBytes += repoStats.SizeBytes
+	}
+	hashes, err := p.cfg.Objects.GetBLOBs()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	for _, hash := range hashes {
+		if _, ok := referenced[hash]; ok {
+			continue
+		}
+		stats.OrphanedBLOBs++
+		envelope, err := p.cfg.Objects.GetBLOBEnvelope(hash)
+		if err != nil && !trace.IsNotFound(err) {
+			return nil, trace.Wrap(err)
+		}
+		if envelope != nil {
+			stats.OrphanedSizeBytes += envelope.SizeBytes
+		}
+	}
+	stats.UnpackedSizeBytes, err = dirSize(p.cfg.UnpackedDir)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &stats, nil
+}
+
+// dirSize returns the total size of regular files under the specified directory
+func dirSize(dir string) (size int64, err error) {
+	err = filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return trace.ConvertSystemError(err)
+		}
+		if fi.Mode().IsRegular() {
+			size += fi.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, trace.Wrap(err)
+	}
+	return size, nil
+}
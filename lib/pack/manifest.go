@@ -30,7 +30,6 @@ import (
 	"github.com/gravitational/gravity/lib/archive"
 	"github.com/gravitational/gravity/lib/systemservice"
 
-	dockerarchive "github.com/docker/docker/pkg/archive"
 	"github.com/gravitational/configure/schema"
 	"github.com/gravitational/trace"
 )
@@ -125,13 +124,11 @@ func Tar(path string, checkManifest bool) (io.ReadCloser, error) {
 		}
 	}
 
-	options := archive.DefaultOptions()
-	options.Compression = dockerarchive.Gzip
-	return dockerarchive.TarWithOptions(path, options)
+	return archive.Tar(path, archive.DefaultOptions(), archive.DefaultCompression())
 }
 
 func Untar(r io.Reader, target string) (*Manifest, error) {
-	if err := dockerarchive.Untar(
+	if err := archive.Untar(
 		r, target, archive.DefaultOptions()); err != nil {
 		return nil, trace.Wrap(err)
 	}
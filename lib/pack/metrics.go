@@ -0,0 +1,88 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pack
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// NewStatsCollector returns a prometheus collector that exports
+// usage statistics of the package store reported by the specified reporter
+func NewStatsCollector(reporter StatsReporter) prometheus.Collector {
+	return &statsCollector{
+		reporter: reporter,
+		totalSize: prometheus.NewDesc("gravity_package_store_size_bytes",
+			"Total size of all packages in the package store", nil, nil),
+		totalPackages: prometheus.NewDesc("gravity_package_store_packages",
+			"Total number of packages in the package store", nil, nil),
+		repoSize: prometheus.NewDesc("gravity_package_repository_size_bytes",
+			"Total size of packages in a repository", []string{"repository"}, nil),
+		repoPackages: prometheus.NewDesc("gravity_package_repository_packages",
+			"Number of packages in a repository", []string{"repository"}, nil),
+		storedSize: prometheus.NewDesc("gravity_package_store_stored_size_bytes",
+			"Space used by the distinct package BLOBs", nil, nil),
+		unpackedSize: prometheus.NewDesc("gravity_package_store_unpacked_size_bytes",
+			"Disk space used by unpacked packages", nil, nil),
+		orphanedBLOBs: prometheus.NewDesc("gravity_package_store_orphaned_blobs",
+			"Number of BLOBs not referenced by any package", nil, nil),
+		orphanedSize: prometheus.NewDesc("gravity_package_store_orphaned_size_bytes",
+			"Total size of BLOBs not referenced by any package", nil, nil),
+	}
+}
+
+type statsCollector struct {
+	reporter      StatsReporter
+	totalSize     *prometheus.Desc
+	totalPackages *prometheus.Desc
+	repoSize      *prometheus.Desc
+	repoPackages  *prometheus.Desc
+	storedSize    *prometheus.Desc
+	unpackedSize  *prometheus.Desc
+	orphanedBLOBs *prometheus.Desc
+	orphanedSize  *prometheus.Desc
+}
+
+// Describe sends descriptors of all metrics exported by this collector
+func (c *statsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.totalSize
+	ch <- c.totalPackages
+	ch <- c.repoSize
+	ch <- c.repoPackages
+	ch <- c.storedSize
+	ch <- c.unpackedSize
+	ch <- c.orphanedBLOBs
+	ch <- c.orphanedSize
+}
+
+// Collect computes package store statistics and sends them as metrics
+func (c *statsCollector) Collect(ch chan<- prometheus.Metric) {
+	stats, err := c.reporter.GetStats()
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.totalSize, err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.totalSize, prometheus.GaugeValue, float64(stats.TotalSizeBytes))
+	ch <- prometheus.MustNewConstMetric(c.totalPackages, prometheus.GaugeValue, float64(stats.TotalPackages))
+	for _, repo := range stats.Repositories {
+		ch <- prometheus.MustNewConstMetric(c.repoSize, prometheus.GaugeValue, float64(repo.SizeBytes), repo.Repository)
+		ch <- prometheus.MustNewConstMetric(c.repoPackages, prometheus.GaugeValue, float64(repo.Packages), repo.Repository)
+	}
+	ch <- prometheus.MustNewConstMetric(c.storedSize, prometheus.GaugeValue, float64(stats.StoredSizeBytes))
+	ch <- prometheus.MustNewConstMetric(c.unpackedSize, prometheus.GaugeValue, float64(stats.UnpackedSizeBytes))
+	ch <- prometheus.MustNewConstMetric(c.orphanedBLOBs, prometheus.GaugeValue, float64(stats.OrphanedBLOBs))
+	ch <- prometheus.MustNewConstMetric(c.orphanedSize, prometheus.GaugeValue, float64(stats.OrphanedSizeBytes))
+}
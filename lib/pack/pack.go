@@ -18,6 +18,7 @@ limitations under the License.
 package pack
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"time"
@@ -46,6 +47,10 @@ type PackageEnvelope struct {
 	SizeBytes int64 `json:"size_bytes"`
 	// SHA512 specifies the sha-512 checksum of the package contents
 	SHA512 string `json:"sha512"`
+	// SHA256 specifies the sha-256 digest of the package contents
+	// recorded when the package was created.
+	// Package data is verified against it when read
+	SHA256 string `json:"sha256,omitempty"`
 	// RuntimeLabels specifies a set of labels attached to the package
 	RuntimeLabels map[string]string `json:"runtime_labels"`
 	// Hidden is whether the package should not be displayed
@@ -91,6 +96,12 @@ func (p *PackageEnvelope) HasLabels(labels map[string]string) bool {
 	return true
 }
 
+// Signature returns the package signature or an empty string
+// if the package is not signed
+func (p PackageEnvelope) Signature() string {
+	return p.RuntimeLabels[SignatureLabel]
+}
+
 func (p PackageEnvelope) String() string {
 	desc := fmt.Sprintf("%v %v", p.Locator.String(), humanize.Bytes(uint64(p.SizeBytes)))
 	if p.Encrypted {
@@ -132,6 +143,7 @@ func (p PackageEnvelope) ToPackagePtr() *storage.Package {
 		Name:          p.Locator.Name,
 		Version:       p.Locator.Version,
 		SHA512:        p.SHA512,
+		SHA256:        p.SHA256,
 		SizeBytes:     int(p.SizeBytes),
 		Created:       p.Created,
 		CreatedBy:     p.CreatedBy,
@@ -167,6 +179,12 @@ type PackageService interface {
 	// GetPackages returns a list of packages in repository
 	GetPackages(repository string) ([]PackageEnvelope, error)
 
+	// GetPackagesBySelector returns a list of packages in repository with labels
+	// matching the selector expression, see ParseSelector for the syntax.
+	// The selector is evaluated by the service so remote clients do not
+	// have to fetch all packages in the repository
+	GetPackagesBySelector(repository, selector string) ([]PackageEnvelope, error)
+
 	// CreatePackage creates package and adds it to to the existing repository
 	CreatePackage(loc loc.Locator, data io.Reader, options ...PackageOption) (*PackageEnvelope, error)
 
@@ -176,14 +194,45 @@ type PackageService interface {
 	// UpdatePackageLabels updates package's labels
 	UpdatePackageLabels(loc loc.Locator, addLabels map[string]string, removeLabels []string) error
 
-	// DeletePackage deletes package from repository
+	// DeletePackage deletes package from repository.
+	// Pinned packages cannot be deleted
 	DeletePackage(locator loc.Locator) error
 
+	// PinPackage protects the package from deletion until it is unpinned
+	PinPackage(locator loc.Locator) error
+
+	// UnpinPackage removes the deletion protection from the package
+	UnpinPackage(locator loc.Locator) error
+
 	// Read package opens and returns package contents
 	ReadPackage(loc loc.Locator) (*PackageEnvelope, io.ReadCloser, error)
 
 	// ReadPackageEnvelope returns package envelope
 	ReadPackageEnvelope(loc loc.Locator) (*PackageEnvelope, error)
+
+	// UpsertPackageAlias creates the package alias, e.g. example.com/runtime:@current,
+	// or atomically updates it to point to the specified target package
+	UpsertPackageAlias(alias, target loc.Locator) error
+
+	// GetPackageAliases returns a list of package aliases in repository
+	GetPackageAliases(repository string) ([]storage.PackageAlias, error)
+
+	// DeletePackageAlias deletes the package alias.
+	// The package the alias points to is not affected
+	DeletePackageAlias(alias loc.Locator) error
+
+	// WatchPackages returns a channel that receives events about changes
+	// to packages in the specified repository until ctx is canceled.
+	// If the repository is empty, the channel receives events about
+	// all repositories and their packages, including created and
+	// deleted repositories.
+	// The channel is closed if the watch is interrupted, in which case
+	// the caller should re-read the packages and watch again
+	WatchPackages(ctx context.Context, repository string) (<-chan PackageEvent, error)
+
+	// PrunePackages deletes packages that are not in use and returns them.
+	// In dry-run mode, it only returns the packages that would be deleted
+	PrunePackages(req PruneRequest) (*PruneResponse, error)
 }
 
 // PackageSorter is a package sort helper,
@@ -0,0 +1,47 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pack
+
+import (
+	"github.com/gravitational/gravity/lib/loc"
+
+	"github.com/gravitational/trace"
+)
+
+// Pin pins the specified package by labeling it with the pinned label.
+// Pinned packages cannot be deleted and are retained by garbage collection
+func Pin(packages PackageService, locator loc.Locator) error {
+	return trace.Wrap(packages.UpdatePackageLabels(locator, PinnedLabels, nil))
+}
+
+// Unpin removes the pinned label from the specified package
+func Unpin(packages PackageService, locator loc.Locator) error {
+	return trace.Wrap(packages.UpdatePackageLabels(locator, nil, []string{PinnedLabel}))
+}
+
+// IsPinned returns true if the package is pinned
+func (p PackageEnvelope) IsPinned() bool {
+	return p.HasLabels(PinnedLabels)
+}
+
+// CheckNotPinned returns an error if the package with the specified labels is pinned
+func CheckNotPinned(locator loc.Locator, labels map[string]string) error {
+	if _, ok := labels[PinnedLabel]; ok {
+		return trace.CompareFailed("package %v is pinned, unpin it first", locator)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pack
+
+import (
+	"io"
+	"sync"
+)
+
+// Progress describes the progress of a package transfer
+type Progress struct {
+	// Current is the number of package bytes read so far
+	Current int64
+	// Total is the total size of the package in bytes
+	Total int64
+	// Entry is the name of the archive entry being unpacked.
+	// Only set when unpacking packages
+	Entry string
+}
+
+// ProgressFunc is a callback that receives the package transfer progress.
+// It can also be used as a ProgressReporter
+type ProgressFunc func(Progress)
+
+// Report reports the number of transferred bytes
+func (f ProgressFunc) Report(current, total int64) {
+	f(Progress{Current: current, Total: total})
+}
+
+// NewProgressReader returns a reader that reports the progress
+// of reading the package data of the specified total size from r
+func NewProgressReader(r io.ReadCloser, total int64, fn ProgressFunc) *ProgressReader {
+	return &ProgressReader{
+		ReadCloser: r,
+		progress:   Progress{Total: total},
+		fn:         fn,
+	}
+}
+
+// Read reads data from the underlying reader and reports the progress
+func (r *ProgressReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		r.mu.Lock()
+		r.progress.Current += int64(n)
+		r.fn(r.progress)
+		r.mu.Unlock()
+	}
+	return n, err
+}
+
+// SetEntry sets the name of the archive entry being unpacked
+// and reports the progress
+func (r *ProgressReader) SetEntry(entry string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.progress.Entry = entry
+	r.fn(r.progress)
+}
+
+// ProgressReader reports the progress of reading package data
+type ProgressReader struct {
+	io.ReadCloser
+	mu       sync.Mutex
+	progress Progress
+	fn       ProgressFunc
+}
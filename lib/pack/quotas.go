@@ -0,0 +1,212 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pack
+
+import (
+	"io"
+	"path/filepath"
+
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/trace"
+)
+
+// RepositoryQuota limits the number and the total size of packages
+// in the matching repositories
+type RepositoryQuota struct {
+	// Repository is the repository name pattern in the filepath.Match format
+	Repository string `json:"repository" yaml:"repository"`
+	// MaxSize is the maximum total size of packages in the repository,
+	// e.g. "10GB". Zero means no limit
+	MaxSize utils.Capacity `json:"max_size,omitempty" yaml:"max_size,omitempty"`
+	// MaxPackages is the maximum number of packages in the repository.
+	// Zero means no limit
+	MaxPackages int `json:"max_packages,omitempty" yaml:"max_packages,omitempty"`
+}
+
+// Check makes sure the quota is valid
+func (q RepositoryQuota) Check() error {
+	if q.Repository == "" {
+		return trace.BadParameter("quota should specify repository")
+	}
+	if _, err := filepath.Match(q.Repository, ""); err != nil {
+		return trace.BadParameter("invalid repository pattern %q", q.Repository)
+	}
+	if q.MaxPackages < 0 {
+		return trace.BadParameter("maximum number of packages cannot be negative")
+	}
+	return nil
+}
+
+// RepositoryQuotas is a list of repository quotas
+type RepositoryQuotas []RepositoryQuota
+
+// Check makes sure all quotas are valid
+func (q RepositoryQuotas) Check() error {
+	for _, quota := range q {
+		if err := quota.Check(); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// For returns the first quota that matches the specified repository
+// or nil if the repository has no quota
+func (q RepositoryQuotas) For(repository string) *RepositoryQuota {
+	for i, quota := range q {
+		if match, _ := filepath.Match(quota.Repository, repository); match {
+			return &q[i]
+		}
+	}
+	return nil
+}
+
+// RepositoryUsage describes resources used by a repository
+type RepositoryUsage struct {
+	// Repository is the repository name
+	Repository string `json:"repository"`
+	// Packages is the number of packages in the repository
+	Packages int `json:"packages"`
+	// SizeBytes is the total size of packages in the repository
+	SizeBytes int64 `json:"size_bytes"`
+	// Quota is the quota that applies to the repository, if any
+	Quota *RepositoryQuota `json:"quota,omitempty"`
+}
+
+// GetRepositoryUsage computes resource usage for the specified repository
+func GetRepositoryUsage(packages PackageService, repository string, quotas RepositoryQuotas) (*RepositoryUsage, error) {
+	envelopes, err := packages.GetPackages(repository)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	usage := RepositoryUsage{
+		Repository: repository,
+		Packages:   len(envelopes),
+		Quota:      quotas.For(repository),
+	}
+	for _, envelope := range envelopes {
+		usage.SizeBytes += envelope.SizeBytes
+	}
+	return &usage, nil
+}
+
+// PackagesWithQuotas returns the package service that enforces the quotas
+// when packages are created.
+// If there are no quotas, packages are returned as-is
+func PackagesWithQuotas(packages PackageService, quotas RepositoryQuotas) PackageService {
+	if len(quotas) == 0 {
+		return packages
+	}
+	return &QuotaService{
+		PackageService: packages,
+		quotas:         quotas,
+	}
+}
+
+// QuotaService is a package service that enforces repository quotas.
+// Only the methods that create packages are subject to quotas
+type QuotaService struct {
+	PackageService
+	quotas RepositoryQuotas
+}
+
+// CreatePackage creates package unless it exceeds the repository quota
+func (q *QuotaService) CreatePackage(loc loc.Locator, data io.Reader, options ...PackageOption) (*PackageEnvelope, error) {
+	data, err := q.checkQuota(loc, data, nil)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return q.PackageService.CreatePackage(loc, data, options...)
+}
+
+// UpsertPackage creates or replaces package unless it exceeds the repository quota
+func (q *QuotaService) UpsertPackage(loc loc.Locator, data io.Reader, options ...PackageOption) (*PackageEnvelope, error) {
+	existing, err := q.PackageService.ReadPackageEnvelope(loc)
+	if err != nil && !trace.IsNotFound(err) {
+		return nil, trace.Wrap(err)
+	}
+	data, err = q.checkQuota(loc, data, existing)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return q.PackageService.UpsertPackage(loc, data, options...)
+}
+
+// checkQuota makes sure the package can be added to the repository and returns
+// the reader that fails once the package data exceeds the remaining size quota.
+// existing is the package being replaced, if any: it does not count towards
+// the quota as its space is reclaimed
+func (q *QuotaService) checkQuota(loc loc.Locator, data io.Reader, existing *PackageEnvelope) (io.Reader, error) {
+	quota := q.quotas.For(loc.Repository)
+	if quota == nil {
+		return data, nil
+	}
+	usage, err := GetRepositoryUsage(q.PackageService, loc.Repository, q.quotas)
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return data, nil
+		}
+		return nil, trace.Wrap(err)
+	}
+	var existingSize int64
+	if existing != nil {
+		existingSize = existing.SizeBytes
+	}
+	if quota.MaxPackages > 0 && existing == nil && usage.Packages >= quota.MaxPackages {
+		return nil, trace.LimitExceeded(
+			"repository %v has reached its quota of %v packages, delete unused packages and retry",
+			loc.Repository, quota.MaxPackages)
+	}
+	if quota.MaxSize == 0 {
+		return data, nil
+	}
+	remaining := int64(quota.MaxSize.Bytes()) - usage.SizeBytes + existingSize
+	if remaining <= 0 {
+		return nil, trace.LimitExceeded(
+			"repository %v has reached its size quota of %v, delete unused packages and retry",
+			loc.Repository, quota.MaxSize)
+	}
+	return &quotaReader{
+		Reader:     data,
+		remaining:  remaining,
+		repository: loc.Repository,
+		quota:      quota.MaxSize,
+	}, nil
+}
+
+// quotaReader fails with the quota exceeded error once
+// more than the remaining number of bytes has been read
+type quotaReader struct {
+	io.Reader
+	remaining  int64
+	repository string
+	quota      utils.Capacity
+}
+
+// Read reads from the underlying reader and fails if the quota is exceeded
+func (r *quotaReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.remaining -= int64(n)
+	if r.remaining < 0 {
+		return n, trace.LimitExceeded(
+			"package exceeds size quota %v of repository %v, delete unused packages and retry",
+			r.quota, r.repository)
+	}
+	return n, err
+}
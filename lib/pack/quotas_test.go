@@ -0,0 +1,120 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pack
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/trace"
+	. "gopkg.in/check.v1"
+)
+
+type QuotasSuite struct{}
+
+var _ = Suite(&QuotasSuite{})
+
+func (s *QuotasSuite) TestEnforcesPackageCount(c *C) {
+	packages := newMemoryPackages()
+	service := PackagesWithQuotas(packages, RepositoryQuotas{
+		{Repository: "ci-*", MaxPackages: 1},
+	})
+
+	_, err := service.CreatePackage(loc.MustParseLocator("ci-apps/app:0.0.1"), bytes.NewReader([]byte("data")))
+	c.Assert(err, IsNil)
+	_, err = service.CreatePackage(loc.MustParseLocator("ci-apps/app:0.0.2"), bytes.NewReader([]byte("data")))
+	c.Assert(trace.IsLimitExceeded(err), Equals, true)
+
+	// replacing existing package does not count towards quota
+	_, err = service.UpsertPackage(loc.MustParseLocator("ci-apps/app:0.0.1"), bytes.NewReader([]byte("new data")))
+	c.Assert(err, IsNil)
+
+	// other repositories are not affected
+	_, err = service.CreatePackage(loc.MustParseLocator("gravitational.io/app:0.0.1"), bytes.NewReader([]byte("data")))
+	c.Assert(err, IsNil)
+	_, err = service.CreatePackage(loc.MustParseLocator("gravitational.io/app:0.0.2"), bytes.NewReader([]byte("data")))
+	c.Assert(err, IsNil)
+}
+
+func (s *QuotasSuite) TestEnforcesSize(c *C) {
+	packages := newMemoryPackages()
+	quotas := RepositoryQuotas{{Repository: "ci", MaxSize: utils.Capacity(10)}}
+	service := PackagesWithQuotas(packages, quotas)
+
+	_, err := service.CreatePackage(loc.MustParseLocator("ci/app:0.0.1"), bytes.NewReader(make([]byte, 6)))
+	c.Assert(err, IsNil)
+	_, err = service.CreatePackage(loc.MustParseLocator("ci/app:0.0.2"), bytes.NewReader(make([]byte, 6)))
+	c.Assert(trace.IsLimitExceeded(err), Equals, true)
+	_, err = service.UpsertPackage(loc.MustParseLocator("ci/app:0.0.1"), bytes.NewReader(make([]byte, 10)))
+	c.Assert(err, IsNil)
+
+	usage, err := GetRepositoryUsage(service, "ci", quotas)
+	c.Assert(err, IsNil)
+	c.Assert(usage, DeepEquals, &RepositoryUsage{
+		Repository: "ci",
+		Packages:   1,
+		SizeBytes:  10,
+		Quota:      &quotas[0],
+	})
+}
+
+// memoryPackages is a package service that keeps package sizes in memory
+type memoryPackages struct {
+	PackageService
+	packages map[loc.Locator]int64
+}
+
+func newMemoryPackages() *memoryPackages {
+	return &memoryPackages{packages: make(map[loc.Locator]int64)}
+}
+
+func (m *memoryPackages) GetPackages(repository string) (envelopes []PackageEnvelope, err error) {
+	for locator, size := range m.packages {
+		if locator.Repository == repository {
+			envelopes = append(envelopes, PackageEnvelope{Locator: locator, SizeBytes: size})
+		}
+	}
+	return envelopes, nil
+}
+
+func (m *memoryPackages) ReadPackageEnvelope(locator loc.Locator) (*PackageEnvelope, error) {
+	size, ok := m.packages[locator]
+	if !ok {
+		return nil, trace.NotFound("package %v not found", locator)
+	}
+	return &PackageEnvelope{Locator: locator, SizeBytes: size}, nil
+}
+
+func (m *memoryPackages) CreatePackage(locator loc.Locator, data io.Reader, options ...PackageOption) (*PackageEnvelope, error) {
+	if _, ok := m.packages[locator]; ok {
+		return nil, trace.AlreadyExists("package %v already exists", locator)
+	}
+	return m.UpsertPackage(locator, data, options...)
+}
+
+func (m *memoryPackages) UpsertPackage(locator loc.Locator, data io.Reader, options ...PackageOption) (*PackageEnvelope, error) {
+	bytes, err := ioutil.ReadAll(data)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	m.packages[locator] = int64(len(bytes))
+	return &PackageEnvelope{Locator: locator, SizeBytes: int64(len(bytes))}, nil
+}
@@ -23,6 +23,8 @@ import (
 	"io/ioutil"
 	"path/filepath"
 
+	gravityarchive "github.com/gravitational/gravity/lib/archive"
+
 	"github.com/docker/docker/pkg/archive"
 	"github.com/gravitational/trace"
 )
@@ -80,7 +82,7 @@ func WritePackage(m Manifest, w io.Writer, files []PackageFile) error {
 }
 
 func ReadPackage(r io.Reader) (*Manifest, []PackageFile, error) {
-	rc, err := archive.DecompressStream(r)
+	rc, err := gravityarchive.DecompressStream(r)
 	if err != nil {
 		return nil, nil, trace.Wrap(err)
 	}
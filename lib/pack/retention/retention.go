@@ -0,0 +1,203 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package retention implements the periodic pruning of package repositories
+// according to the retention policies configured for them
+package retention
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/pack"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+	"github.com/sirupsen/logrus"
+)
+
+// Config configures the retention reaper
+type Config struct {
+	// Operator is the cluster operator service
+	Operator ops.Operator
+	// Packages is the cluster package service
+	Packages pack.PackageService
+	// Interval is how often the retention policies are enforced
+	Interval time.Duration
+	// Clock is used to mock time in tests
+	Clock clockwork.Clock
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+}
+
+// CheckAndSetDefaults validates the configuration and sets default values
+func (r *Config) CheckAndSetDefaults() error {
+	if r.Operator == nil {
+		return trace.BadParameter("missing Operator")
+	}
+	if r.Packages == nil {
+		return trace.BadParameter("missing Packages")
+	}
+	if r.Interval == 0 {
+		r.Interval = defaults.PackageRetentionInterval
+	}
+	if r.Clock == nil {
+		r.Clock = clockwork.NewRealClock()
+	}
+	if r.FieldLogger == nil {
+		r.FieldLogger = logrus.WithField(trace.Component, "retention")
+	}
+	return nil
+}
+
+// New returns a new retention reaper
+func New(config Config) (*Reaper, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &Reaper{Config: config}, nil
+}
+
+// Reaper prunes the package versions not covered by the retention
+// policy of their repository. Repositories without a policy are left intact.
+//
+// The installed cluster application, the packages of operations
+// in progress, installed and pinned packages and all packages they
+// depend on are never pruned
+type Reaper struct {
+	Config
+}
+
+// Run enforces the retention policies periodically until the context is canceled
+func (r *Reaper) Run(ctx context.Context) error {
+	utils.RunPeriodically(ctx, r.Interval, r.FieldLogger,
+		"enforce retention policies", func(context.Context) error {
+			_, err := r.Enforce()
+			return err
+		})
+	return nil
+}
+
+// Enforce prunes the repositories with retention policies and
+// returns the pruned packages
+func (r *Reaper) Enforce() (pruned []pack.PackageEnvelope, err error) {
+	cluster, err := r.Operator.GetLocalSite()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	policies, err := r.Operator.GetPackageRetentionPolicies(cluster.Key())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if len(policies) == 0 {
+		return nil, nil
+	}
+	operations, err := ops.GetActiveOperations(cluster.Key(), r.Operator)
+	if err != nil && !trace.IsNotFound(err) {
+		return nil, trace.Wrap(err)
+	}
+	req := pack.PruneRequest{
+		Retain: []loc.Locator{cluster.App.Package},
+	}
+	for _, operation := range operations {
+		req.Operations = append(req.Operations, operation.ID)
+	}
+	var errors []error
+	for _, policy := range policies {
+		resp, err := Apply(r.Packages, policy, r.Clock.Now(), req)
+		if err != nil {
+			errors = append(errors, trace.Wrap(err, "failed to enforce retention policy %q",
+				policy.GetName()))
+		}
+		if resp == nil {
+			continue
+		}
+		for _, envelope := range resp.Packages {
+			r.WithField("policy", policy.GetName()).Infof("Pruned package %v.", envelope.Locator)
+		}
+		pruned = append(pruned, resp.Packages...)
+	}
+	return pruned, trace.NewAggregate(errors...)
+}
+
+// Apply prunes the package versions in the repository of the specified policy
+// that the policy does not keep. The packages retained by the provided request
+// are kept as well. In dry-run mode, the packages that would be pruned are returned
+func Apply(packages pack.PackageService, policy storage.PackageRetentionPolicy, now time.Time, req pack.PruneRequest) (*pack.PruneResponse, error) {
+	if err := policy.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	envelopes, err := packages.GetPackages(policy.GetRepository())
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return &pack.PruneResponse{}, nil
+		}
+		return nil, trace.Wrap(err)
+	}
+	req.Retain = append(append([]loc.Locator{}, req.Retain...),
+		KeptPackages(envelopes, policy, now)...)
+	req.Repositories = []string{policy.GetRepository()}
+	resp, err := pack.Prune(packages, req)
+	return resp, trace.Wrap(err)
+}
+
+// KeptPackages returns the packages from the specified list the policy keeps:
+// the configured number of latest versions of each package and the versions
+// younger than the configured age
+func KeptPackages(envelopes []pack.PackageEnvelope, policy storage.PackageRetentionPolicy, now time.Time) (kept []loc.Locator) {
+	versions := make(map[string][]pack.PackageEnvelope)
+	for _, envelope := range envelopes {
+		if envelope.Locator.Repository != policy.GetRepository() {
+			continue
+		}
+		versions[envelope.Locator.Name] = append(versions[envelope.Locator.Name], envelope)
+	}
+	for _, items := range versions {
+		sortLatestFirst(items)
+		for i, envelope := range items {
+			if i < policy.GetKeepVersions() ||
+				(policy.GetMaxAge() != 0 && now.Sub(envelope.Created) < policy.GetMaxAge()) {
+				kept = append(kept, envelope.Locator)
+			}
+		}
+	}
+	return kept
+}
+
+// sortLatestFirst orders the versions of a package from the latest to the oldest.
+// Versions that are not in semver format are ordered by creation time
+// after the semver ones
+func sortLatestFirst(envelopes []pack.PackageEnvelope) {
+	sort.SliceStable(envelopes, func(i, j int) bool {
+		vi, erri := envelopes[i].Locator.SemVer()
+		vj, errj := envelopes[j].Locator.SemVer()
+		switch {
+		case erri == nil && errj == nil:
+			return vj.LessThan(*vi)
+		case erri == nil:
+			return true
+		case errj == nil:
+			return false
+		}
+		return envelopes[i].Created.After(envelopes[j].Created)
+	})
+}
@@ -0,0 +1,240 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package retention
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gravitational/gravity/lib/compare"
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/pack"
+	"github.com/gravitational/gravity/lib/storage"
+
+	teleservices "github.com/gravitational/teleport/lib/services"
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+	"github.com/sirupsen/logrus"
+	check "gopkg.in/check.v1"
+)
+
+func TestRetention(t *testing.T) { check.TestingT(t) }
+
+type RetentionSuite struct {
+	clock clockwork.FakeClock
+}
+
+var _ = check.Suite(&RetentionSuite{})
+
+func (s *RetentionSuite) SetUpTest(c *check.C) {
+	s.clock = clockwork.NewFakeClockAt(time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC))
+}
+
+func (s *RetentionSuite) TestKeepsLatestVersions(c *check.C) {
+	now := s.clock.Now()
+	packages := newTestPackages(
+		newPackage("example.com/app:1.0.0", now.Add(-96*time.Hour)),
+		newPackage("example.com/app:1.10.0", now.Add(-72*time.Hour)),
+		newPackage("example.com/app:1.2.0", now.Add(-48*time.Hour)),
+		newPackage("example.com/app:2.0.0", now.Add(-24*time.Hour)),
+		newPackage("example.com/tool:0.0.1", now.Add(-96*time.Hour)),
+		newPackage("other.com/app:1.0.0", now.Add(-96*time.Hour)),
+	)
+	policy := storage.NewPackageRetentionPolicy("example", storage.PackageRetentionPolicySpecV2{
+		Repository:   "example.com",
+		KeepVersions: 2,
+	})
+
+	resp, err := Apply(packages, policy, now, pack.PruneRequest{})
+	c.Assert(err, check.IsNil)
+	compare.DeepCompare(c, locators(resp.Packages), []string{
+		"example.com/app:1.0.0",
+		"example.com/app:1.2.0",
+	})
+	compare.DeepCompare(c, packages.deleted, []string{
+		"example.com/app:1.0.0",
+		"example.com/app:1.2.0",
+	})
+}
+
+func (s *RetentionSuite) TestKeepsRecentVersions(c *check.C) {
+	now := s.clock.Now()
+	maxAge := teleservices.NewDuration(60 * time.Hour)
+	packages := newTestPackages(
+		newPackage("example.com/app:1.0.0", now.Add(-96*time.Hour)),
+		newPackage("example.com/app:1.1.0", now.Add(-72*time.Hour)),
+		newPackage("example.com/app:1.2.0", now.Add(-48*time.Hour)),
+		newPackage("example.com/app:1.3.0", now.Add(-24*time.Hour)),
+	)
+	policy := storage.NewPackageRetentionPolicy("example", storage.PackageRetentionPolicySpecV2{
+		Repository: "example.com",
+		MaxAge:     &maxAge,
+	})
+
+	resp, err := Apply(packages, policy, now, pack.PruneRequest{DryRun: true})
+	c.Assert(err, check.IsNil)
+	compare.DeepCompare(c, locators(resp.Packages), []string{
+		"example.com/app:1.0.0",
+		"example.com/app:1.1.0",
+	})
+	c.Assert(packages.deleted, check.HasLen, 0)
+}
+
+func (s *RetentionSuite) TestKeepsInstalledAndPinnedVersions(c *check.C) {
+	now := s.clock.Now()
+	installed := newPackage("example.com/app:1.0.0", now.Add(-96*time.Hour))
+	installed.RuntimeLabels = pack.InstalledLabels
+	pinned := newPackage("example.com/app:1.1.0", now.Add(-72*time.Hour))
+	pinned.RuntimeLabels = pack.PinnedLabels
+	packages := newTestPackages(
+		installed,
+		pinned,
+		newPackage("example.com/app:1.2.0", now.Add(-48*time.Hour)),
+		newPackage("example.com/app:1.3.0", now.Add(-24*time.Hour)),
+		newPackage("example.com/app:1.4.0", now.Add(-12*time.Hour)),
+	)
+	policy := storage.NewPackageRetentionPolicy("example", storage.PackageRetentionPolicySpecV2{
+		Repository:   "example.com",
+		KeepVersions: 1,
+	})
+
+	resp, err := Apply(packages, policy, now, pack.PruneRequest{
+		Retain: []loc.Locator{loc.MustParseLocator("example.com/app:1.3.0")},
+	})
+	c.Assert(err, check.IsNil)
+	compare.DeepCompare(c, locators(resp.Packages), []string{"example.com/app:1.2.0"})
+}
+
+func (s *RetentionSuite) TestEnforcesPolicies(c *check.C) {
+	now := s.clock.Now()
+	packages := newTestPackages(
+		newPackage("example.com/app:1.0.0", now.Add(-96*time.Hour)),
+		newPackage("example.com/app:1.1.0", now.Add(-72*time.Hour)),
+		newPackage("example.com/app:1.2.0", now.Add(-48*time.Hour)),
+		newPackage("other.com/app:1.0.0", now.Add(-96*time.Hour)),
+		newPackage("other.com/app:1.1.0", now.Add(-72*time.Hour)),
+	)
+	operator := &testOperator{
+		app: loc.MustParseLocator("example.com/app:1.0.0"),
+		policies: []storage.PackageRetentionPolicy{
+			storage.NewPackageRetentionPolicy("example", storage.PackageRetentionPolicySpecV2{
+				Repository:   "example.com",
+				KeepVersions: 1,
+			}),
+		},
+	}
+	reaper, err := New(Config{
+		Operator:    operator,
+		Packages:    packages,
+		Clock:       s.clock,
+		FieldLogger: logrus.WithField("test", "retention"),
+	})
+	c.Assert(err, check.IsNil)
+
+	pruned, err := reaper.Enforce()
+	c.Assert(err, check.IsNil)
+	compare.DeepCompare(c, locators(pruned), []string{"example.com/app:1.1.0"})
+}
+
+func (s *RetentionSuite) TestValidatesPolicy(c *check.C) {
+	policy := storage.NewPackageRetentionPolicy("example", storage.PackageRetentionPolicySpecV2{
+		Repository: "example.com",
+	})
+	c.Assert(policy.CheckAndSetDefaults(), check.NotNil)
+	policy = storage.NewPackageRetentionPolicy("example", storage.PackageRetentionPolicySpecV2{
+		KeepVersions: 1,
+	})
+	c.Assert(policy.CheckAndSetDefaults(), check.NotNil)
+}
+
+func newPackage(locator string, created time.Time) pack.PackageEnvelope {
+	return pack.PackageEnvelope{
+		Locator: loc.MustParseLocator(locator),
+		Created: created,
+	}
+}
+
+func locators(envelopes []pack.PackageEnvelope) (result []string) {
+	for _, envelope := range envelopes {
+		result = append(result, envelope.Locator.String())
+	}
+	return result
+}
+
+func newTestPackages(envelopes ...pack.PackageEnvelope) *testPackages {
+	return &testPackages{envelopes: envelopes}
+}
+
+// testPackages implements the subset of the package service used for pruning
+type testPackages struct {
+	pack.PackageService
+	envelopes []pack.PackageEnvelope
+	deleted   []string
+}
+
+func (r *testPackages) GetRepositories() (repositories []string, err error) {
+	seen := make(map[string]bool)
+	for _, envelope := range r.envelopes {
+		if !seen[envelope.Locator.Repository] {
+			seen[envelope.Locator.Repository] = true
+			repositories = append(repositories, envelope.Locator.Repository)
+		}
+	}
+	return repositories, nil
+}
+
+func (r *testPackages) GetPackages(repository string) (envelopes []pack.PackageEnvelope, err error) {
+	for _, envelope := range r.envelopes {
+		if envelope.Locator.Repository == repository {
+			envelopes = append(envelopes, envelope)
+		}
+	}
+	return envelopes, nil
+}
+
+func (r *testPackages) GetPackageAliases(repository string) ([]storage.PackageAlias, error) {
+	return nil, nil
+}
+
+func (r *testPackages) DeletePackage(locator loc.Locator) error {
+	r.deleted = append(r.deleted, locator.String())
+	return nil
+}
+
+// testOperator implements the subset of the operator service used by the reaper
+type testOperator struct {
+	ops.Operator
+	app      loc.Locator
+	policies []storage.PackageRetentionPolicy
+}
+
+func (r *testOperator) GetLocalSite() (*ops.Site, error) {
+	return &ops.Site{
+		AccountID: "account",
+		Domain:    "example.com",
+		App:       ops.Application{Package: r.app},
+	}, nil
+}
+
+func (r *testOperator) GetPackageRetentionPolicies(ops.SiteKey) ([]storage.PackageRetentionPolicy, error) {
+	return r.policies, nil
+}
+
+func (r *testOperator) GetSiteOperations(ops.SiteKey) (ops.SiteOperations, error) {
+	return nil, trace.NotFound("no operations")
+}
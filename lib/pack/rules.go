@@ -0,0 +1,338 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pack
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"time"
+
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/storage"
+
+	teleservices "github.com/gravitational/teleport/lib/services"
+	"github.com/gravitational/trace"
+)
+
+// RepositoryRule restricts the set of repositories the matching users can
+// access via the package service.
+//
+// Rules are applied in addition to the role-based access checks and allow
+// to limit the scope of external clients (e.g. CI systems) to specific
+// repositories without creating dedicated roles for them
+type RepositoryRule struct {
+	// Users lists the names of the users the rule applies to,
+	// the wildcard "*" matches any user
+	Users []string `json:"users" yaml:"users"`
+	// Repositories lists the repository name patterns in the
+	// filepath.Match format the rule grants access to
+	Repositories []string `json:"repositories" yaml:"repositories"`
+	// Verbs lists the allowed actions, e.g. "read" or "create",
+	// the wildcard "*" allows any action
+	Verbs []string `json:"verbs" yaml:"verbs"`
+}
+
+// Check makes sure the rule is valid
+func (r RepositoryRule) Check() error {
+	if len(r.Users) == 0 {
+		return trace.BadParameter("repository rule should specify at least one user")
+	}
+	if len(r.Repositories) == 0 {
+		return trace.BadParameter("repository rule should specify at least one repository")
+	}
+	for _, pattern := range r.Repositories {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return trace.BadParameter("invalid repository pattern %q", pattern)
+		}
+	}
+	if len(r.Verbs) == 0 {
+		return trace.BadParameter("repository rule should specify at least one verb")
+	}
+	for _, verb := range r.Verbs {
+		if !isRuleVerb(verb) {
+			return trace.BadParameter("unsupported verb %q, supported verbs are: %v",
+				verb, ruleVerbs)
+		}
+	}
+	return nil
+}
+
+// RepositoryRules is a list of repository access rules
+type RepositoryRules []RepositoryRule
+
+// Check makes sure all rules are valid
+func (r RepositoryRules) Check() error {
+	for _, rule := range r {
+		if err := rule.Check(); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// AppliesTo returns true if any of the rules applies to the specified user.
+// Users not covered by any rule are only subject to the role-based checks
+func (r RepositoryRules) AppliesTo(user string) bool {
+	for _, rule := range r {
+		if matchesAny(rule.Users, user) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckAccess returns an access denied error unless one of the rules
+// allows the user to perform the action on the repository
+func (r RepositoryRules) CheckAccess(user, repository, verb string) error {
+	for _, rule := range r {
+		if !matchesAny(rule.Users, user) || !matchesAny(rule.Verbs, verb) {
+			continue
+		}
+		for _, pattern := range rule.Repositories {
+			if match, _ := filepath.Match(pattern, repository); match {
+				return nil
+			}
+		}
+	}
+	return trace.AccessDenied("user %v is not allowed to %v repository %v",
+		user, verb, repository)
+}
+
+// PackagesWithRepositoryRules returns the package service that restricts
+// the specified user's access to repositories according to the rules.
+// If none of the rules applies to the user, packages are returned as-is
+func PackagesWithRepositoryRules(packages PackageService, user string, rules RepositoryRules) PackageService {
+	if !rules.AppliesTo(user) {
+		return packages
+	}
+	return &RulesService{
+		packages: packages,
+		user:     user,
+		rules:    rules,
+	}
+}
+
+// RulesService is a package service that checks repository rules
+// before every operation
+type RulesService struct {
+	packages PackageService
+	user     string
+	rules    RepositoryRules
+}
+
+func (r *RulesService) check(repository string, verbs ...string) error {
+	for _, verb := range verbs {
+		if err := r.rules.CheckAccess(r.user, repository, verb); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+func (r *RulesService) PortalURL() string {
+	return r.packages.PortalURL()
+}
+
+func (r *RulesService) PackageDownloadURL(loc loc.Locator) string {
+	return r.packages.PackageDownloadURL(loc)
+}
+
+// UpsertRepository creates or updates the repository
+func (r *RulesService) UpsertRepository(repository string, expires time.Time) error {
+	if err := r.check(repository, teleservices.VerbCreate, teleservices.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return r.packages.UpsertRepository(repository, expires)
+}
+
+// DeleteRepository deletes the repository
+func (r *RulesService) DeleteRepository(repository string) error {
+	if err := r.check(repository, teleservices.VerbDelete); err != nil {
+		return trace.Wrap(err)
+	}
+	return r.packages.DeleteRepository(repository)
+}
+
+// GetRepositories returns the repositories the user is allowed to list
+func (r *RulesService) GetRepositories() ([]string, error) {
+	repositories, err := r.packages.GetRepositories()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var allowed []string
+	for _, repository := range repositories {
+		if r.check(repository, teleservices.VerbList) == nil {
+			allowed = append(allowed, repository)
+		}
+	}
+	return allowed, nil
+}
+
+// GetRepository returns repository by name
+func (r *RulesService) GetRepository(repository string) (storage.Repository, error) {
+	if err := r.check(repository, teleservices.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return r.packages.GetRepository(repository)
+}
+
+// GetPackages returns a list of packages in repository
+func (r *RulesService) GetPackages(repository string) ([]PackageEnvelope, error) {
+	if err := r.check(repository, teleservices.VerbList); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return r.packages.GetPackages(repository)
+}
+
+// GetPackagesBySelector returns a list of packages in repository matching the selector
+func (r *RulesService) GetPackagesBySelector(repository, selector string) ([]PackageEnvelope, error) {
+	if err := r.check(repository, teleservices.VerbList); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return r.packages.GetPackagesBySelector(repository, selector)
+}
+
+// CreatePackage creates package and adds it to the existing repository
+func (r *RulesService) CreatePackage(loc loc.Locator, data io.Reader, options ...PackageOption) (*PackageEnvelope, error) {
+	if err := r.check(loc.Repository, teleservices.VerbCreate); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return r.packages.CreatePackage(loc, data, options...)
+}
+
+// UpsertPackage creates or updates the package in the existing repository
+func (r *RulesService) UpsertPackage(loc loc.Locator, data io.Reader, options ...PackageOption) (*PackageEnvelope, error) {
+	if err := r.check(loc.Repository, teleservices.VerbCreate, teleservices.VerbUpdate); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return r.packages.UpsertPackage(loc, data, options...)
+}
+
+// UpdatePackageLabels updates package labels
+func (r *RulesService) UpdatePackageLabels(loc loc.Locator, addLabels map[string]string, removeLabels []string) error {
+	if err := r.check(loc.Repository, teleservices.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return r.packages.UpdatePackageLabels(loc, addLabels, removeLabels)
+}
+
+// DeletePackage deletes the package
+func (r *RulesService) DeletePackage(loc loc.Locator) error {
+	if err := r.check(loc.Repository, teleservices.VerbDelete); err != nil {
+		return trace.Wrap(err)
+	}
+	return r.packages.DeletePackage(loc)
+}
+
+// PinPackage protects the package from deletion until it is unpinned
+func (r *RulesService) PinPackage(loc loc.Locator) error {
+	return Pin(r, loc)
+}
+
+// UnpinPackage removes the deletion protection from the package
+func (r *RulesService) UnpinPackage(loc loc.Locator) error {
+	return Unpin(r, loc)
+}
+
+// ReadPackage opens and returns package contents
+func (r *RulesService) ReadPackage(loc loc.Locator) (*PackageEnvelope, io.ReadCloser, error) {
+	if err := r.check(loc.Repository, teleservices.VerbRead); err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+	return r.packages.ReadPackage(loc)
+}
+
+// ReadPackageEnvelope returns package envelope
+func (r *RulesService) ReadPackageEnvelope(loc loc.Locator) (*PackageEnvelope, error) {
+	if err := r.check(loc.Repository, teleservices.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return r.packages.ReadPackageEnvelope(loc)
+}
+
+// UpsertPackageAlias creates the package alias or updates its target
+func (r *RulesService) UpsertPackageAlias(alias, target loc.Locator) error {
+	if err := r.check(alias.Repository, teleservices.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return r.packages.UpsertPackageAlias(alias, target)
+}
+
+// GetPackageAliases returns a list of package aliases in repository
+func (r *RulesService) GetPackageAliases(repository string) ([]storage.PackageAlias, error) {
+	if err := r.check(repository, teleservices.VerbList); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return r.packages.GetPackageAliases(repository)
+}
+
+// DeletePackageAlias deletes the package alias
+func (r *RulesService) DeletePackageAlias(alias loc.Locator) error {
+	if err := r.check(alias.Repository, teleservices.VerbDelete); err != nil {
+		return trace.Wrap(err)
+	}
+	return r.packages.DeletePackageAlias(alias)
+}
+
+// WatchPackages returns a channel that receives events about changes
+// to packages in the specified repository.
+// When watching all repositories, only events about the repositories
+// the user is allowed to read are received
+func (r *RulesService) WatchPackages(ctx context.Context, repository string) (<-chan PackageEvent, error) {
+	if repository == "" {
+		eventsC, err := r.packages.WatchPackages(ctx, repository)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		return FilterEvents(ctx, eventsC, func(event PackageEvent) bool {
+			return r.check(event.Repository, teleservices.VerbList, teleservices.VerbRead) == nil
+		}), nil
+	}
+	if err := r.check(repository, teleservices.VerbList, teleservices.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return r.packages.WatchPackages(ctx, repository)
+}
+
+// PrunePackages deletes packages that are not in use.
+// Repository rules are checked for every repository and package
+func (r *RulesService) PrunePackages(req PruneRequest) (*PruneResponse, error) {
+	return Prune(r, req)
+}
+
+func matchesAny(values []string, value string) bool {
+	for _, v := range values {
+		if v == teleservices.Wildcard || v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func isRuleVerb(verb string) bool {
+	return verb == teleservices.Wildcard || matchesAny(ruleVerbs, verb)
+}
+
+// ruleVerbs lists verbs supported by repository rules
+var ruleVerbs = []string{
+	teleservices.VerbList,
+	teleservices.VerbRead,
+	teleservices.VerbCreate,
+	teleservices.VerbUpdate,
+	teleservices.VerbDelete,
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pack
+
+import (
+	"testing"
+
+	"github.com/gravitational/gravity/lib/loc"
+
+	"github.com/gravitational/trace"
+	. "gopkg.in/check.v1"
+)
+
+func TestPack(t *testing.T) { TestingT(t) }
+
+type RulesSuite struct{}
+
+var _ = Suite(&RulesSuite{})
+
+func (s *RulesSuite) TestCheckAccess(c *C) {
+	rules := RepositoryRules{
+		{Users: []string{"ci@example.com"}, Repositories: []string{"ci-*"}, Verbs: []string{"*"}},
+		{Users: []string{"ci@example.com"}, Repositories: []string{"gravitational.io"}, Verbs: []string{"read", "list"}},
+	}
+	c.Assert(rules.Check(), IsNil)
+	c.Assert(rules.AppliesTo("ci@example.com"), Equals, true)
+	c.Assert(rules.AppliesTo("admin@example.com"), Equals, false)
+
+	var testCases = []struct {
+		repository string
+		verb       string
+		allowed    bool
+	}{
+		{repository: "ci-apps", verb: "create", allowed: true},
+		{repository: "ci-apps", verb: "delete", allowed: true},
+		{repository: "gravitational.io", verb: "read", allowed: true},
+		{repository: "gravitational.io", verb: "create", allowed: false},
+		{repository: "example.com", verb: "read", allowed: false},
+	}
+	for _, tc := range testCases {
+		comment := Commentf("%v %v", tc.verb, tc.repository)
+		err := rules.CheckAccess("ci@example.com", tc.repository, tc.verb)
+		if tc.allowed {
+			c.Assert(err, IsNil, comment)
+		} else {
+			c.Assert(trace.IsAccessDenied(err), Equals, true, comment)
+		}
+	}
+}
+
+func (s *RulesSuite) TestValidatesRules(c *C) {
+	var testCases = []RepositoryRule{
+		{Repositories: []string{"ci"}, Verbs: []string{"read"}},
+		{Users: []string{"ci"}, Verbs: []string{"read"}},
+		{Users: []string{"ci"}, Repositories: []string{"["}, Verbs: []string{"read"}},
+		{Users: []string{"ci"}, Repositories: []string{"ci"}},
+		{Users: []string{"ci"}, Repositories: []string{"ci"}, Verbs: []string{"push"}},
+	}
+	for i, tc := range testCases {
+		c.Assert(RepositoryRules{tc}.Check(), NotNil, Commentf("test case %v", i))
+	}
+}
+
+func (s *RulesSuite) TestRestrictsPackages(c *C) {
+	rules := RepositoryRules{
+		{Users: []string{"ci"}, Repositories: []string{"ci"}, Verbs: []string{"list", "read"}},
+	}
+	packages := &repositoriesService{repositories: []string{"ci", "gravitational.io"}}
+	c.Assert(PackagesWithRepositoryRules(packages, "admin", rules), Equals, packages)
+
+	service := PackagesWithRepositoryRules(packages, "ci", rules)
+	repositories, err := service.GetRepositories()
+	c.Assert(err, IsNil)
+	c.Assert(repositories, DeepEquals, []string{"ci"})
+
+	_, err = service.ReadPackageEnvelope(loc.MustParseLocator("gravitational.io/app:0.0.1"))
+	c.Assert(trace.IsAccessDenied(err), Equals, true)
+	err = service.DeletePackage(loc.MustParseLocator("ci/app:0.0.1"))
+	c.Assert(trace.IsAccessDenied(err), Equals, true)
+}
+
+// repositoriesService is a package service that only lists repositories
+type repositoriesService struct {
+	PackageService
+	repositories []string
+}
+
+func (r *repositoriesService) GetRepositories() ([]string, error) {
+	return r.repositories, nil
+}
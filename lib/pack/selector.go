@@ -0,0 +1,77 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pack
+
+import (
+	"github.com/gravitational/trace"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// ParseSelector parses the package label selector expression.
+//
+// The expression uses the Kubernetes label selector syntax and supports
+// both equality-based and set-based requirements, e.g.:
+//
+//	purpose=runtime,installed
+//	purpose in (runtime, planet-config),!pinned
+//
+// An empty expression selects all packages
+func ParseSelector(expr string) (labels.Selector, error) {
+	selector, err := labels.Parse(expr)
+	if err != nil {
+		return nil, trace.BadParameter("invalid label selector %q: %v", expr, err)
+	}
+	return selector, nil
+}
+
+// SelectorFromLabels returns the selector expression that matches
+// packages with all of the provided labels
+func SelectorFromLabels(packageLabels map[string]string) string {
+	return labels.SelectorFromSet(labels.Set(packageLabels)).String()
+}
+
+// MatchesSelector returns true if the envelope labels match the selector
+func (p *PackageEnvelope) MatchesSelector(selector labels.Selector) bool {
+	return selector.Matches(labels.Set(p.RuntimeLabels))
+}
+
+// FilterPackages returns the envelopes with labels matching the selector
+func FilterPackages(envelopes []PackageEnvelope, selector labels.Selector) []PackageEnvelope {
+	filtered := make([]PackageEnvelope, 0, len(envelopes))
+	for _, envelope := range envelopes {
+		if envelope.MatchesSelector(selector) {
+			filtered = append(filtered, envelope)
+		}
+	}
+	return filtered
+}
+
+// GetPackagesBySelector returns the packages from the specified repository
+// with labels matching the selector expression by filtering the complete list
+// of packages. Package services that cannot evaluate the selector any more
+// efficiently use it to implement the method of the same name
+func GetPackagesBySelector(packages PackageService, repository, expr string) ([]PackageEnvelope, error) {
+	selector, err := ParseSelector(expr)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	envelopes, err := packages.GetPackages(repository)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return FilterPackages(envelopes, selector), nil
+}
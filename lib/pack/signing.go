@@ -0,0 +1,355 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pack
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"strings"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/trace"
+)
+
+// Signer signs packages
+type Signer interface {
+	// Sign returns the signature of the package with the specified locator
+	// and SHA256 digest of its contents in the format of the signature label
+	Sign(locator loc.Locator, digest string) (string, error)
+}
+
+// Verifier verifies package signatures
+type Verifier interface {
+	// Verify returns an error if the package described by the envelope
+	// is not signed with one of the trusted keys
+	Verify(envelope PackageEnvelope) error
+}
+
+// SigningKey is a private key used to sign packages.
+// ECDSA and RSA keys in PEM format are supported
+type SigningKey struct {
+	// ID identifies the public part of the key
+	ID  string
+	key crypto.Signer
+}
+
+// ReadSigningKey reads the PEM-encoded private key from the specified file
+func ReadSigningKey(path string) (*SigningKey, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	return ParseSigningKey(data)
+}
+
+// ParseSigningKey parses the PEM-encoded private key
+func ParseSigningKey(data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, trace.BadParameter("signing key is not PEM-encoded")
+	}
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, trace.BadParameter("unsupported signing key type %q", block.Type)
+	}
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var signer crypto.Signer
+	switch key := key.(type) {
+	case *ecdsa.PrivateKey:
+		signer = key
+	case *rsa.PrivateKey:
+		signer = key
+	default:
+		return nil, trace.BadParameter("unsupported signing key %T", key)
+	}
+	id, err := keyID(signer.Public())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &SigningKey{ID: id, key: signer}, nil
+}
+
+// Sign returns the signature of the package with the specified locator
+// and SHA256 digest of its contents
+func (r *SigningKey) Sign(locator loc.Locator, digest string) (string, error) {
+	signature, err := r.key.Sign(rand.Reader, signedDigest(locator, digest), crypto.SHA256)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	return fmt.Sprintf("%v:%v", r.ID, base64.StdEncoding.EncodeToString(signature)), nil
+}
+
+// VerificationPolicy defines which packages the cluster package service
+// requires to be signed and the keys it trusts
+type VerificationPolicy struct {
+	// TrustedKeys lists paths to the PEM-encoded public keys
+	// that packages can be signed with
+	TrustedKeys []string `yaml:"trusted_keys"`
+	// Repositories lists repository name patterns in the filepath.Match format
+	// that require signed packages. Defaults to the system repository
+	Repositories []string `yaml:"repositories"`
+}
+
+// Enabled returns true if the policy requires packages to be signed
+func (r VerificationPolicy) Enabled() bool {
+	return len(r.TrustedKeys) != 0
+}
+
+// Check makes sure the policy is valid
+func (r VerificationPolicy) Check() error {
+	if !r.Enabled() && len(r.Repositories) != 0 {
+		return trace.BadParameter("verification policy should specify trusted keys")
+	}
+	for _, repository := range r.Repositories {
+		if _, err := filepath.Match(repository, ""); err != nil {
+			return trace.BadParameter("invalid repository pattern %q", repository)
+		}
+	}
+	return nil
+}
+
+// NewVerifier returns the verifier that enforces this policy.
+// Returns nil if the policy is not enabled
+func (r VerificationPolicy) NewVerifier() (Verifier, error) {
+	if !r.Enabled() {
+		return nil, nil
+	}
+	if err := r.Check(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	keys := make(map[string]crypto.PublicKey)
+	for _, path := range r.TrustedKeys {
+		id, key, err := ReadPublicKey(path)
+		if err != nil {
+			return nil, trace.Wrap(err, "failed to read trusted key %v", path)
+		}
+		keys[id] = key
+	}
+	repositories := r.Repositories
+	if len(repositories) == 0 {
+		repositories = []string{defaults.SystemAccountOrg}
+	}
+	return &verifier{keys: keys, repositories: repositories}, nil
+}
+
+// ReadPublicKey reads the PEM-encoded public key from the specified file
+// and returns it along with its ID
+func ReadPublicKey(path string) (id string, key crypto.PublicKey, err error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", nil, trace.ConvertSystemError(err)
+	}
+	return ParsePublicKey(data)
+}
+
+// ParsePublicKey parses the PEM-encoded public key and returns it along with its ID
+func ParsePublicKey(data []byte) (id string, key crypto.PublicKey, err error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return "", nil, trace.BadParameter("public key is not PEM-encoded")
+	}
+	switch block.Type {
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return "", nil, trace.BadParameter("unsupported public key type %q", block.Type)
+	}
+	if err != nil {
+		return "", nil, trace.Wrap(err)
+	}
+	id, err = keyID(key)
+	if err != nil {
+		return "", nil, trace.Wrap(err)
+	}
+	return id, key, nil
+}
+
+type verifier struct {
+	// keys maps key IDs to trusted public keys
+	keys         map[string]crypto.PublicKey
+	repositories []string
+}
+
+// ecdsaSignature is the ASN.1 encoding of the ECDSA signature
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+// Verify returns an error if the package is subject to the policy and
+// is not signed with one of the trusted keys
+func (r *verifier) Verify(envelope PackageEnvelope) error {
+	if !r.applies(envelope) {
+		return nil
+	}
+	signature := envelope.Signature()
+	if signature == "" {
+		return trace.AccessDenied("package %v is not signed", envelope.Locator)
+	}
+	id, encoded, err := parseSignature(signature)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	key, ok := r.keys[id]
+	if !ok {
+		return trace.AccessDenied("package %v is signed with untrusted key %v",
+			envelope.Locator, id)
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return trace.BadParameter("invalid signature of package %v", envelope.Locator)
+	}
+	digest := signedDigest(envelope.Locator, envelope.SHA256)
+	var valid bool
+	switch key := key.(type) {
+	case *ecdsa.PublicKey:
+		var sig ecdsaSignature
+		if _, err := asn1.Unmarshal(data, &sig); err == nil {
+			valid = ecdsa.Verify(key, digest, sig.R, sig.S)
+		}
+	case *rsa.PublicKey:
+		valid = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest, data) == nil
+	}
+	if !valid {
+		return trace.AccessDenied("package %v has invalid signature", envelope.Locator)
+	}
+	return nil
+}
+
+// applies returns true if the package requires a signature.
+// Packages generated by the cluster itself, e.g. configuration and
+// secrets packages, are not signed and are not subject to the policy
+func (r *verifier) applies(envelope PackageEnvelope) bool {
+	if _, ok := envelope.RuntimeLabels[ConfigLabel]; ok {
+		return false
+	}
+	if purpose, ok := envelope.RuntimeLabels[PurposeLabel]; ok && purpose != PurposeRuntime {
+		return false
+	}
+	for _, repository := range r.repositories {
+		if match, _ := filepath.Match(repository, envelope.Locator.Repository); match {
+			return true
+		}
+	}
+	return false
+}
+
+// PackagesWithSigner returns the package service that signs packages
+// as they are created
+func PackagesWithSigner(packages PackageService, signer Signer) PackageService {
+	return &SigningService{
+		PackageService: packages,
+		signer:         signer,
+	}
+}
+
+// SigningService is a package service that signs created packages.
+// The signature is computed over the package data as it was passed in
+// so the packages stay verifiable if the underlying service encrypts them
+type SigningService struct {
+	PackageService
+	signer Signer
+}
+
+// CreatePackage creates a new signed package
+func (r *SigningService) CreatePackage(loc loc.Locator, data io.Reader, options ...PackageOption) (*PackageEnvelope, error) {
+	digest := sha256.New()
+	envelope, err := r.PackageService.CreatePackage(loc, io.TeeReader(data, digest), options...)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return r.sign(*envelope, digest)
+}
+
+// UpsertPackage creates or replaces a signed package
+func (r *SigningService) UpsertPackage(loc loc.Locator, data io.Reader, options ...PackageOption) (*PackageEnvelope, error) {
+	digest := sha256.New()
+	envelope, err := r.PackageService.UpsertPackage(loc, io.TeeReader(data, digest), options...)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return r.sign(*envelope, digest)
+}
+
+func (r *SigningService) sign(envelope PackageEnvelope, digest hash.Hash) (*PackageEnvelope, error) {
+	signature, err := r.signer.Sign(envelope.Locator, fmt.Sprintf("%x", digest.Sum(nil)))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	err = r.PackageService.UpdatePackageLabels(envelope.Locator,
+		map[string]string{SignatureLabel: signature}, nil)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	envelope.RuntimeLabels = utils.CombineLabels(envelope.RuntimeLabels,
+		map[string]string{SignatureLabel: signature})
+	return &envelope, nil
+}
+
+// signedDigest returns the digest of the data signed for the package
+// with the specified locator and SHA256 digest of its contents
+func signedDigest(locator loc.Locator, digest string) []byte {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%v\n%v", locator, digest)))
+	return sum[:]
+}
+
+// parseSignature splits the signature label value into the key ID
+// and base64-encoded signature
+func parseSignature(signature string) (id, encoded string, err error) {
+	parts := strings.SplitN(signature, ":", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", trace.BadParameter("invalid signature format %q", signature)
+	}
+	return parts[0], parts[1], nil
+}
+
+// keyID returns the ID of the public key computed as the prefix of the
+// SHA256 digest of its DER encoding
+func keyID(key crypto.PublicKey) (string, error) {
+	data, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(data))[:16], nil
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pack
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/trace"
+	. "gopkg.in/check.v1"
+)
+
+type SigningSuite struct{}
+
+var _ = Suite(&SigningSuite{})
+
+func (s *SigningSuite) TestSignsAndVerifiesPackages(c *C) {
+	for _, newKey := range []func(*C) crypto.Signer{newECDSAKey, newRSAKey} {
+		key := newKey(c)
+		signer := newSigningKey(c, key)
+		verifier := newVerifier(c, VerificationPolicy{}, key.Public())
+
+		packages := &labelPackages{memoryPackages: newMemoryPackages()}
+		service := PackagesWithSigner(packages, signer)
+		locator := loc.MustParseLocator("gravitational.io/app:0.0.1")
+		envelope, err := service.CreatePackage(locator, strings.NewReader("data"))
+		c.Assert(err, IsNil)
+		c.Assert(envelope.Signature(), Not(Equals), "")
+		c.Assert(packages.labels[locator], DeepEquals, envelope.RuntimeLabels)
+
+		envelope.SHA256 = digestOf("data")
+		c.Assert(verifier.Verify(*envelope), IsNil)
+
+		// signature does not match modified contents
+		modified := *envelope
+		modified.SHA256 = digestOf("other data")
+		c.Assert(trace.IsAccessDenied(verifier.Verify(modified)), Equals, true)
+
+		// signature does not match other package
+		modified = *envelope
+		modified.Locator = loc.MustParseLocator("gravitational.io/app:0.0.2")
+		c.Assert(trace.IsAccessDenied(verifier.Verify(modified)), Equals, true)
+	}
+}
+
+func (s *SigningSuite) TestRejectsUnsignedAndUntrustedPackages(c *C) {
+	trusted := newECDSAKey(c)
+	verifier := newVerifier(c, VerificationPolicy{}, trusted.Public())
+	locator := loc.MustParseLocator("gravitational.io/app:0.0.1")
+
+	err := verifier.Verify(PackageEnvelope{Locator: locator, SHA256: digestOf("data")})
+	c.Assert(trace.IsAccessDenied(err), Equals, true)
+	c.Assert(err, ErrorMatches, ".*is not signed.*")
+
+	untrusted := newSigningKey(c, newECDSAKey(c))
+	signature, err := untrusted.Sign(locator, digestOf("data"))
+	c.Assert(err, IsNil)
+	err = verifier.Verify(PackageEnvelope{
+		Locator:       locator,
+		SHA256:        digestOf("data"),
+		RuntimeLabels: map[string]string{SignatureLabel: signature},
+	})
+	c.Assert(trace.IsAccessDenied(err), Equals, true)
+	c.Assert(err, ErrorMatches, ".*untrusted key.*")
+}
+
+func (s *SigningSuite) TestVerifiesMatchingRepositories(c *C) {
+	verifier := newVerifier(c, VerificationPolicy{Repositories: []string{"*.example.com"}},
+		newECDSAKey(c).Public())
+
+	for _, envelope := range []PackageEnvelope{
+		{Locator: loc.MustParseLocator("gravitational.io/app:0.0.1")},
+		{Locator: loc.MustParseLocator("apps.example.com/secrets:0.0.1"),
+			RuntimeLabels: map[string]string{PurposeLabel: PurposePlanetSecrets}},
+		{Locator: loc.MustParseLocator("apps.example.com/config:0.0.1"),
+			RuntimeLabels: map[string]string{ConfigLabel: "apps.example.com/app:0.0.1"}},
+	} {
+		c.Assert(verifier.Verify(envelope), IsNil, Commentf("%v", envelope.Locator))
+	}
+
+	for _, envelope := range []PackageEnvelope{
+		{Locator: loc.MustParseLocator("apps.example.com/app:0.0.1")},
+		{Locator: loc.MustParseLocator("apps.example.com/planet:0.0.1"),
+			RuntimeLabels: RuntimePackageLabels},
+	} {
+		c.Assert(trace.IsAccessDenied(verifier.Verify(envelope)), Equals, true,
+			Commentf("%v", envelope.Locator))
+	}
+}
+
+func (s *SigningSuite) TestValidatesPolicy(c *C) {
+	policy, err := VerificationPolicy{}.NewVerifier()
+	c.Assert(err, IsNil)
+	c.Assert(policy, IsNil)
+
+	c.Assert(VerificationPolicy{Repositories: []string{"gravitational.io"}}.Check(), NotNil)
+	c.Assert(VerificationPolicy{TrustedKeys: []string{"key.pem"}, Repositories: []string{"["}}.Check(), NotNil)
+
+	_, err = VerificationPolicy{TrustedKeys: []string{filepath.Join(c.MkDir(), "missing.pem")}}.NewVerifier()
+	c.Assert(trace.IsNotFound(err), Equals, true)
+}
+
+// labelPackages is a memory package service that keeps package labels
+type labelPackages struct {
+	*memoryPackages
+	labels map[loc.Locator]map[string]string
+}
+
+func (m *labelPackages) UpdatePackageLabels(locator loc.Locator, addLabels map[string]string, removeLabels []string) error {
+	if m.labels == nil {
+		m.labels = make(map[loc.Locator]map[string]string)
+	}
+	m.labels[locator] = utils.CombineLabels(m.labels[locator], addLabels)
+	return nil
+}
+
+func newVerifier(c *C, policy VerificationPolicy, keys ...crypto.PublicKey) Verifier {
+	dir := c.MkDir()
+	for i, key := range keys {
+		data, err := x509.MarshalPKIXPublicKey(key)
+		c.Assert(err, IsNil)
+		path := filepath.Join(dir, fmt.Sprintf("key%v.pem", i))
+		err = ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: data}), 0600)
+		c.Assert(err, IsNil)
+		policy.TrustedKeys = append(policy.TrustedKeys, path)
+	}
+	verifier, err := policy.NewVerifier()
+	c.Assert(err, IsNil)
+	return verifier
+}
+
+func newSigningKey(c *C, key crypto.Signer) *SigningKey {
+	var block *pem.Block
+	switch key := key.(type) {
+	case *ecdsa.PrivateKey:
+		data, err := x509.MarshalECPrivateKey(key)
+		c.Assert(err, IsNil)
+		block = &pem.Block{Type: "EC PRIVATE KEY", Bytes: data}
+	case *rsa.PrivateKey:
+		block = &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}
+	}
+	signer, err := ParseSigningKey(pem.EncodeToMemory(block))
+	c.Assert(err, IsNil)
+	return signer
+}
+
+func newECDSAKey(c *C) crypto.Signer {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	c.Assert(err, IsNil)
+	return key
+}
+
+func newRSAKey(c *C) crypto.Signer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	c.Assert(err, IsNil)
+	return key
+}
+
+func digestOf(data string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(data)))
+}
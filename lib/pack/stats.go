@@ -0,0 +1,53 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pack
+
+// StatsReporter is implemented by package services that can report
+// usage statistics of the underlying package store
+type StatsReporter interface {
+	// GetStats returns usage statistics of the package store
+	GetStats() (*StoreStats, error)
+}
+
+// StoreStats describes disk usage of a package store
+type StoreStats struct {
+	// TotalSizeBytes is the total size of all packages in the store
+	TotalSizeBytes int64 `json:"total_size_bytes"`
+	// TotalPackages is the total number of packages in the store
+	TotalPackages int `json:"total_packages"`
+	// Repositories lists usage statistics per repository
+	Repositories []RepositoryStats `json:"repositories"`
+	// StoredSizeBytes is the space used by the package contents. Packages
+	// with identical contents share a BLOB so it can be less than TotalSizeBytes
+	StoredSizeBytes int64 `json:"stored_size_bytes"`
+	// UnpackedSizeBytes is the disk space used by unpacked packages
+	UnpackedSizeBytes int64 `json:"unpacked_size_bytes"`
+	// OrphanedBLOBs is the number of BLOBs not referenced by any package
+	OrphanedBLOBs int `json:"orphaned_blobs"`
+	// OrphanedSizeBytes is the total size of the orphaned BLOBs
+	OrphanedSizeBytes int64 `json:"orphaned_size_bytes"`
+}
+
+// RepositoryStats describes usage of a single repository
+type RepositoryStats struct {
+	// Repository is the repository name
+	Repository string `json:"repository"`
+	// Packages is the number of packages in the repository
+	Packages int `json:"packages"`
+	// SizeBytes is the total size of packages in the repository
+	SizeBytes int64 `json:"size_bytes"`
+}
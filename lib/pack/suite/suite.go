@@ -20,10 +20,12 @@ package suite
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"time"
 
+	"github.com/gravitational/gravity/lib/archive"
 	"github.com/gravitational/gravity/lib/blob"
 	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/pack"
@@ -169,7 +171,7 @@ func (s *PackageSuite) UpsertPackages(c *C) {
 	c.Assert(r1.Close(), IsNil)
 
 	labels := map[string]string{"hello": "there"}
-	pack1NewData := []byte("hello, world!")
+	pack1NewData := []byte("hello, new world!")
 	pack1, err = s.S.UpsertPackage(loc1, bytes.NewBuffer(pack1NewData), pack.WithLabels(labels))
 	c.Assert(err, IsNil)
 
@@ -181,7 +183,17 @@ func (s *PackageSuite) UpsertPackages(c *C) {
 	c.Assert(string(out), DeepEquals, string(pack1NewData))
 	c.Assert(pack1.SizeBytes, Equals, int64(len(pack1NewData)))
 	c.Assert(pack1.SHA512, Equals, hash(pack1NewData))
+	c.Assert(pack1.RuntimeLabels, DeepEquals, labels)
 	c.Assert(r2.Close(), IsNil)
+
+	// upsert replaces the labels of the existing package
+	newLabels := map[string]string{"purpose": "config"}
+	pack1, err = s.S.UpsertPackage(loc1, bytes.NewBuffer(pack1Data), pack.WithLabels(newLabels))
+	c.Assert(err, IsNil)
+	o3, err := s.S.ReadPackageEnvelope(loc1)
+	c.Assert(err, IsNil)
+	c.Assert(o3.RuntimeLabels, DeepEquals, newLabels)
+	c.Assert(o3.SHA512, Equals, hash(pack1Data))
 }
 
 // DeleteRepository makes sure that when repository is deleted, all its package blobs are also deleted
@@ -205,6 +217,470 @@ func (s *PackageSuite) DeleteRepository(c *C) {
 	c.Assert(trace.IsNotFound(err), Equals, true)
 }
 
+func (s *PackageSuite) PackageAliases(c *C) {
+	err := s.S.UpsertRepository("example.com", time.Time{})
+	c.Assert(err, IsNil)
+
+	v1 := loc.MustParseLocator("example.com/package:0.0.1")
+	v2 := loc.MustParseLocator("example.com/package:0.0.2")
+	current := loc.MustParseLocator("example.com/package:@current")
+
+	_, err = s.S.CreatePackage(v1, bytes.NewBuffer([]byte("v1")))
+	c.Assert(err, IsNil)
+	_, err = s.S.CreatePackage(v2, bytes.NewBuffer([]byte("v2")))
+	c.Assert(err, IsNil)
+
+	// alias can not point to a package that does not exist
+	err = s.S.UpsertPackageAlias(current, loc.MustParseLocator("example.com/package:0.0.3"))
+	c.Assert(trace.IsNotFound(err), Equals, true, Commentf("unexpected type: %T", err))
+
+	err = s.S.UpsertPackageAlias(current, v1)
+	c.Assert(err, IsNil)
+
+	envelope, rc, err := s.S.ReadPackage(current)
+	c.Assert(err, IsNil)
+	data, err := ioutil.ReadAll(rc)
+	rc.Close()
+	c.Assert(err, IsNil)
+	c.Assert(envelope.Locator, DeepEquals, v1)
+	c.Assert(string(data), Equals, "v1")
+
+	// retarget the alias
+	err = s.S.UpsertPackageAlias(current, v2)
+	c.Assert(err, IsNil)
+
+	envelope, err = s.S.ReadPackageEnvelope(current)
+	c.Assert(err, IsNil)
+	c.Assert(envelope.Locator, DeepEquals, v2)
+
+	aliases, err := s.S.GetPackageAliases("example.com")
+	c.Assert(err, IsNil)
+	c.Assert(aliases, HasLen, 1)
+	c.Assert(aliases[0].Locator(), DeepEquals, current)
+	c.Assert(aliases[0].Target(), DeepEquals, v2)
+
+	// alias resolves to the architecture variant of the package
+	variant := v2.WithArch("arm64")
+	_, err = s.S.CreatePackage(variant, bytes.NewBuffer([]byte("v2 arm64")))
+	c.Assert(err, IsNil)
+	envelope, err = s.S.ReadPackageEnvelope(current.WithArch("arm64"))
+	c.Assert(err, IsNil)
+	c.Assert(envelope.Locator, DeepEquals, variant)
+
+	// aliased package and its variants can not be deleted
+	err = s.S.DeletePackage(v2)
+	c.Assert(err, NotNil)
+	err = s.S.DeletePackage(variant)
+	c.Assert(err, NotNil)
+	err = s.S.DeletePackage(current)
+	c.Assert(err, NotNil)
+
+	err = s.S.DeletePackageAlias(current)
+	c.Assert(err, IsNil)
+
+	_, err = s.S.ReadPackageEnvelope(current)
+	c.Assert(trace.IsNotFound(err), Equals, true, Commentf("unexpected type: %T", err))
+
+	err = s.S.DeletePackage(v2)
+	c.Assert(err, IsNil)
+	err = s.S.DeletePackage(variant)
+	c.Assert(err, IsNil)
+}
+
+func (s *PackageSuite) LayeredConfig(c *C) {
+	err := s.S.UpsertRepository("example.com", time.Time{})
+	c.Assert(err, IsNil)
+
+	prefix := loc.MustParseLocator("example.com/config-base:0.0.1")
+	nodeVars := []string{"NODE_NAME", "PUBLIC_IP"}
+	var configs []*pack.LayeredConfig
+	for i, node := range []string{"node-1", "node-2"} {
+		config, err := pack.NewLayeredConfig(map[string]string{
+			"NODE_NAME":     node,
+			"PUBLIC_IP":     fmt.Sprintf("10.0.0.%v", i+1),
+			"CLUSTER_ID":    "example.com",
+			"POD_SUBNET":    "10.244.0.0/16",
+			"SERVICE_UID":   "1000",
+			"DNS_ZONES":     "",
+			"DOCKER_DRIVER": "overlay2",
+		}, nodeVars, prefix)
+		c.Assert(err, IsNil)
+		c.Assert(config.CreateBase(s.S, nil), IsNil)
+
+		var buf bytes.Buffer
+		c.Assert(config.Write(&buf), IsNil)
+		_, err = s.S.CreatePackage(loc.MustParseLocator(
+			fmt.Sprintf("example.com/config-%v:0.0.1", node)), &buf)
+		c.Assert(err, IsNil)
+		configs = append(configs, config)
+	}
+	// nodes with identical shared configuration share the base package
+	c.Assert(configs[0].Base, Equals, configs[1].Base)
+	c.Assert(configs[0].Base.Version, Equals, "0.0.1")
+
+	vars, err := pack.ReadConfigVars(s.S, loc.MustParseLocator("example.com/config-node-2:0.0.1"))
+	c.Assert(err, IsNil)
+	c.Assert(vars, DeepEquals, map[string]string{
+		"NODE_NAME":     "node-2",
+		"PUBLIC_IP":     "10.0.0.2",
+		"CLUSTER_ID":    "example.com",
+		"POD_SUBNET":    "10.244.0.0/16",
+		"SERVICE_UID":   "1000",
+		"DNS_ZONES":     "",
+		"DOCKER_DRIVER": "overlay2",
+	})
+
+	// layered configuration cannot be read without the base package
+	_, reader, err := s.S.ReadPackage(loc.MustParseLocator("example.com/config-node-1:0.0.1"))
+	c.Assert(err, IsNil)
+	defer reader.Close()
+	_, err = pack.ReadConfigPackage(reader)
+	c.Assert(trace.IsBadParameter(err), Equals, true, Commentf("unexpected type: %T", err))
+
+	vars, err = pack.ReadConfigVars(s.S, configs[0].Base)
+	c.Assert(err, IsNil)
+	c.Assert(vars["NODE_NAME"], Equals, "")
+	c.Assert(vars["CLUSTER_ID"], Equals, "example.com")
+}
+
+// ConfigurePackage makes sure that reconfiguring a package replaces
+// the existing configuration package
+func (s *PackageSuite) ConfigurePackage(c *C) {
+	err := s.S.UpsertRepository("example.com", time.Time{})
+	c.Assert(err, IsNil)
+
+	locator := loc.MustParseLocator("example.com/service:0.0.1")
+	_, err = s.S.CreatePackage(locator, archive.MustCreateMemArchive([]*archive.Item{
+		archive.ItemFromString(pack.ManifestFilename, serviceManifest),
+	}))
+	c.Assert(err, IsNil)
+
+	config := loc.MustParseLocator("example.com/service-config:0.0.1")
+	for _, value := range []string{"first", "second"} {
+		err = pack.ConfigurePackage(s.S, locator, config, []string{"--config-string", value},
+			map[string]string{"value": value})
+		c.Assert(err, IsNil)
+
+		vars, err := pack.ReadConfigVars(s.S, config)
+		c.Assert(err, IsNil)
+		c.Assert(vars["SERVICE_CONFIG"], Equals, value)
+		envelope, err := s.S.ReadPackageEnvelope(config)
+		c.Assert(err, IsNil)
+		c.Assert(envelope.RuntimeLabels["value"], Equals, value)
+		c.Assert(envelope.HasLabel(pack.ConfigLabel, locator.ZeroVersion().String()), Equals, true)
+	}
+}
+
+func (s *PackageSuite) Transactions(c *C) {
+	err := s.S.UpsertRepository("example.com", time.Time{})
+	c.Assert(err, IsNil)
+
+	config := loc.MustParseLocator("example.com/config:0.0.1")
+	secrets := loc.MustParseLocator("example.com/secrets:0.0.1")
+	existing := loc.MustParseLocator("example.com/existing:0.0.1")
+	removed := loc.MustParseLocator("example.com/removed:0.0.1")
+
+	_, err = s.S.CreatePackage(config, bytes.NewBufferString("config v1"),
+		pack.WithLabels(map[string]string{"purpose": "config"}))
+	c.Assert(err, IsNil)
+	_, err = s.S.CreatePackage(existing, bytes.NewBufferString("existing"))
+	c.Assert(err, IsNil)
+	_, err = s.S.CreatePackage(removed, bytes.NewBufferString("removed"),
+		pack.WithLabels(map[string]string{"installed": "installed"}))
+	c.Assert(err, IsNil)
+
+	// the last operation fails so none should be applied
+	tx := pack.NewTransaction(s.S)
+	tx.UpsertPackage(config, bytes.NewBufferString("config v2"))
+	tx.CreatePackage(secrets, bytes.NewBufferString("secrets"))
+	tx.UpdatePackageLabels(existing, map[string]string{"installed": "installed"}, nil)
+	tx.DeletePackage(removed)
+	tx.CreatePackage(existing, bytes.NewBufferString("duplicate"))
+	err = tx.Commit()
+	c.Assert(err, NotNil)
+
+	envelope, data := s.readPackage(c, config)
+	c.Assert(data, Equals, "config v1")
+	c.Assert(envelope.RuntimeLabels, DeepEquals, map[string]string{"purpose": "config"})
+	_, err = s.S.ReadPackageEnvelope(secrets)
+	c.Assert(trace.IsNotFound(err), Equals, true, Commentf("unexpected type: %T", err))
+	envelope, _ = s.readPackage(c, existing)
+	c.Assert(envelope.HasLabel("installed", "installed"), Equals, false)
+	envelope, data = s.readPackage(c, removed)
+	c.Assert(data, Equals, "removed")
+	c.Assert(envelope.RuntimeLabels, DeepEquals, map[string]string{"installed": "installed"})
+
+	// all operations succeed
+	tx = pack.NewTransaction(s.S)
+	tx.UpsertPackage(config, bytes.NewBufferString("config v2"))
+	tx.CreatePackage(secrets, bytes.NewBufferString("secrets"))
+	tx.DeletePackage(removed)
+	c.Assert(tx.Commit(), IsNil)
+	c.Assert(tx.Commit(), NotNil)
+
+	_, data = s.readPackage(c, config)
+	c.Assert(data, Equals, "config v2")
+	_, data = s.readPackage(c, secrets)
+	c.Assert(data, Equals, "secrets")
+	_, err = s.S.ReadPackageEnvelope(removed)
+	c.Assert(trace.IsNotFound(err), Equals, true, Commentf("unexpected type: %T", err))
+}
+
+func (s *PackageSuite) WatchPackages(c *C) {
+	err := s.S.UpsertRepository("example.com", time.Time{})
+	c.Assert(err, IsNil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	eventsC, err := s.S.WatchPackages(ctx, "example.com")
+	c.Assert(err, IsNil)
+
+	locator := loc.MustParseLocator("example.com/config:0.0.1")
+	_, err = s.S.CreatePackage(locator, bytes.NewBufferString("config"))
+	c.Assert(err, IsNil)
+	_, err = s.S.UpsertPackage(locator, bytes.NewBufferString("config v2"))
+	c.Assert(err, IsNil)
+	err = s.S.UpdatePackageLabels(locator, map[string]string{"installed": "installed"}, nil)
+	c.Assert(err, IsNil)
+	err = s.S.DeletePackage(locator)
+	c.Assert(err, IsNil)
+
+	for _, expected := range []pack.PackageEventType{
+		pack.PackageCreated,
+		pack.PackageCreated,
+		pack.PackageLabelsUpdated,
+		pack.PackageDeleted,
+	} {
+		select {
+		case event := <-eventsC:
+			c.Assert(event.Type, Equals, expected)
+			c.Assert(event.Envelope.Locator, DeepEquals, locator)
+			if expected == pack.PackageLabelsUpdated {
+				c.Assert(event.Envelope.HasLabel("installed", "installed"), Equals, true)
+			}
+		case <-time.After(5 * time.Second):
+			c.Fatalf("timeout waiting for %v event", expected)
+		}
+	}
+
+	cancel()
+	select {
+	case _, ok := <-eventsC:
+		c.Assert(ok, Equals, false)
+	case <-time.After(5 * time.Second):
+		c.Fatal("timeout waiting for watch to stop")
+	}
+}
+
+func (s *PackageSuite) WatchAllPackages(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	eventsC, err := s.S.WatchPackages(ctx, "")
+	c.Assert(err, IsNil)
+
+	c.Assert(s.S.UpsertRepository("example.org", time.Time{}), IsNil)
+	locator := loc.MustParseLocator("example.org/package:0.0.1")
+	_, err = s.S.CreatePackage(locator, bytes.NewBufferString("package"))
+	c.Assert(err, IsNil)
+	c.Assert(s.S.DeleteRepository("example.org"), IsNil)
+
+	for _, expected := range []pack.PackageEventType{
+		pack.RepositoryCreated,
+		pack.PackageCreated,
+		pack.PackageDeleted,
+		pack.RepositoryDeleted,
+	} {
+		select {
+		case event := <-eventsC:
+			c.Assert(event.Type, Equals, expected)
+			c.Assert(event.Repository, Equals, "example.org")
+			if !event.IsRepositoryEvent() {
+				c.Assert(event.Envelope.Locator, DeepEquals, locator)
+			}
+		case <-time.After(5 * time.Second):
+			c.Fatalf("timeout waiting for %v event", expected)
+		}
+	}
+}
+
+func (s *PackageSuite) PrunePackages(c *C) {
+	c.Assert(s.S.UpsertRepository("gravitational.io", time.Time{}), IsNil)
+	c.Assert(s.S.UpsertRepository("example.com", time.Time{}), IsNil)
+
+	packages := []struct {
+		locator string
+		labels  map[string]string
+	}{
+		{locator: "gravitational.io/planet:2.0.0", labels: pack.InstalledLabels},
+		{locator: "gravitational.io/planet:1.0.0"},
+		{
+			locator: "example.com/planet-config-node1:2.0.0",
+			labels: map[string]string{
+				pack.ConfigLabel:      "gravitational.io/planet:0.0.0",
+				pack.AdvertiseIPLabel: "10.0.0.1",
+			},
+		},
+		{
+			locator: "example.com/planet-config-node1:1.0.0",
+			labels: map[string]string{
+				pack.ConfigLabel:      "gravitational.io/planet:0.0.0",
+				pack.AdvertiseIPLabel: "10.0.0.1",
+			},
+		},
+		{
+			locator: "example.com/planet-10.0.0.1-secrets:0.0.2",
+			labels:  map[string]string{pack.AdvertiseIPLabel: "10.0.0.1"},
+		},
+		{
+			locator: "example.com/planet-10.0.0.1-secrets:0.0.1",
+			labels:  map[string]string{pack.AdvertiseIPLabel: "10.0.0.1"},
+		},
+		{
+			locator: "example.com/planet-10.0.0.1-secrets:0.0.3",
+			labels: map[string]string{
+				pack.AdvertiseIPLabel: "10.0.0.1",
+				pack.OperationIDLabel: "operation-1",
+			},
+		},
+	}
+	for _, p := range packages {
+		_, err := s.S.CreatePackage(loc.MustParseLocator(p.locator),
+			bytes.NewBufferString(p.locator), pack.WithLabels(p.labels))
+		c.Assert(err, IsNil)
+	}
+	req := pack.PruneRequest{
+		Operations: []string{"operation-1"},
+		Nodes:      []string{"10.0.0.1"},
+		DryRun:     true,
+	}
+	expected := []string{
+		"example.com/planet-config-node1:1.0.0",
+		"example.com/planet-10.0.0.1-secrets:0.0.1",
+		"gravitational.io/planet:1.0.0",
+	}
+
+	resp, err := s.S.PrunePackages(req)
+	c.Assert(err, IsNil)
+	CompareAsSets(c, expected, prunedPackages(resp))
+	for _, p := range packages {
+		_, err := s.S.ReadPackageEnvelope(loc.MustParseLocator(p.locator))
+		c.Assert(err, IsNil, Commentf("dry run deleted %v", p.locator))
+	}
+
+	req.DryRun = false
+	resp, err = s.S.PrunePackages(req)
+	c.Assert(err, IsNil)
+	CompareAsSets(c, expected, prunedPackages(resp))
+	for _, locator := range expected {
+		_, err := s.S.ReadPackageEnvelope(loc.MustParseLocator(locator))
+		c.Assert(trace.IsNotFound(err), Equals, true, Commentf("expected %v to be pruned", locator))
+	}
+
+	resp, err = s.S.PrunePackages(req)
+	c.Assert(err, IsNil)
+	c.Assert(resp.Packages, HasLen, 0)
+}
+
+func (s *PackageSuite) PinPackages(c *C) {
+	c.Assert(s.S.UpsertRepository("example.com", time.Time{}), IsNil)
+
+	pinned := loc.MustParseLocator("example.com/package:1.0.0")
+	_, err := s.S.CreatePackage(pinned, bytes.NewBufferString("pinned"))
+	c.Assert(err, IsNil)
+	_, err = s.S.CreatePackage(loc.MustParseLocator("example.com/package:2.0.0"),
+		bytes.NewBufferString("latest"), pack.WithLabels(pack.InstalledLabels))
+	c.Assert(err, IsNil)
+
+	c.Assert(s.S.PinPackage(pinned), IsNil)
+	envelope, err := s.S.ReadPackageEnvelope(pinned)
+	c.Assert(err, IsNil)
+	c.Assert(envelope.IsPinned(), Equals, true)
+
+	err = s.S.DeletePackage(pinned)
+	c.Assert(trace.IsCompareFailed(err), Equals, true, Commentf("expected pinned package to be protected, got %v", err))
+
+	resp, err := s.S.PrunePackages(pack.PruneRequest{})
+	c.Assert(err, IsNil)
+	c.Assert(resp.Packages, HasLen, 0)
+
+	c.Assert(s.S.UnpinPackage(pinned), IsNil)
+	c.Assert(s.S.DeletePackage(pinned), IsNil)
+	_, err = s.S.ReadPackageEnvelope(pinned)
+	c.Assert(trace.IsNotFound(err), Equals, true)
+}
+
+func (s *PackageSuite) GetPackagesBySelector(c *C) {
+	c.Assert(s.S.UpsertRepository("example.com", time.Time{}), IsNil)
+	packages := []struct {
+		locator string
+		labels  map[string]string
+	}{
+		{"example.com/planet:1.0.0", map[string]string{pack.PurposeLabel: pack.PurposeRuntime}},
+		{"example.com/planet:2.0.0", map[string]string{pack.PurposeLabel: pack.PurposeRuntime, pack.InstalledLabel: pack.InstalledLabel}},
+		{"example.com/planet-config:2.0.0", map[string]string{pack.PurposeLabel: pack.PurposePlanetConfig}},
+		{"example.com/teleport:1.0.0", nil},
+	}
+	for _, p := range packages {
+		_, err := s.S.CreatePackage(loc.MustParseLocator(p.locator),
+			bytes.NewBufferString(p.locator), pack.WithLabels(p.labels))
+		c.Assert(err, IsNil)
+	}
+	testCases := []struct {
+		selector string
+		expected []string
+	}{
+		{
+			selector: "",
+			expected: []string{"example.com/planet:1.0.0", "example.com/planet:2.0.0",
+				"example.com/planet-config:2.0.0", "example.com/teleport:1.0.0"},
+		},
+		{
+			selector: "purpose=runtime",
+			expected: []string{"example.com/planet:1.0.0", "example.com/planet:2.0.0"},
+		},
+		{
+			selector: "purpose=runtime,installed",
+			expected: []string{"example.com/planet:2.0.0"},
+		},
+		{
+			selector: "purpose in (runtime, planet-config),!installed",
+			expected: []string{"example.com/planet:1.0.0", "example.com/planet-config:2.0.0"},
+		},
+		{
+			selector: "!purpose",
+			expected: []string{"example.com/teleport:1.0.0"},
+		},
+	}
+	for _, tc := range testCases {
+		envelopes, err := s.S.GetPackagesBySelector("example.com", tc.selector)
+		c.Assert(err, IsNil)
+		var locators []string
+		for _, envelope := range envelopes {
+			locators = append(locators, envelope.Locator.String())
+		}
+		CompareAsSets(c, tc.expected, locators)
+	}
+
+	_, err := s.S.GetPackagesBySelector("example.com", "purpose in runtime")
+	c.Assert(trace.IsBadParameter(err), Equals, true, Commentf("expected invalid selector error, got %v", err))
+}
+
+func prunedPackages(resp *pack.PruneResponse) (locators []string) {
+	for _, envelope := range resp.Packages {
+		locators = append(locators, envelope.Locator.String())
+	}
+	return locators
+}
+
+func (s *PackageSuite) readPackage(c *C, locator loc.Locator) (*pack.PackageEnvelope, string) {
+	envelope, rc, err := s.S.ReadPackage(locator)
+	c.Assert(err, IsNil)
+	defer rc.Close()
+	data, err := ioutil.ReadAll(rc)
+	c.Assert(err, IsNil)
+	return envelope, string(data)
+}
+
 func hash(v []byte) string {
 	h, err := utils.SHA512Half(v)
 	if err != nil {
@@ -224,3 +700,18 @@ func CompareAsSets(c *C, expected, actual []string) {
 	}
 	c.Assert(expectedMap, DeepEquals, actualMap)
 }
+
+// serviceManifest is the manifest of a package with a configuration parameter
+const serviceManifest = `{
+  "version": "0.0.1",
+  "config": {
+    "params": [
+      {
+        "type": "String",
+        "name": "configString",
+        "env": "SERVICE_CONFIG",
+        "cli": {"name": "config-string"}
+      }
+    ]
+  }
+}`
@@ -0,0 +1,243 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pack
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+
+	"github.com/gravitational/gravity/lib/loc"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// NewTransaction returns a new transaction that groups operations
+// on the specified package service
+func NewTransaction(packages PackageService) *Transaction {
+	return &Transaction{
+		packages:    packages,
+		FieldLogger: log.WithField(trace.Component, "pack:tx"),
+	}
+}
+
+// Transaction groups a set of package operations so that either
+// all of them are applied or none are.
+//
+// Operations are recorded and only applied on Commit. If any operation fails,
+// the operations applied so far are reverted in reverse order using the
+// package state captured before each of them was applied.
+//
+// As the previous package state is kept in memory, transactions are meant
+// for small packages like configuration and secrets packages
+type Transaction struct {
+	log.FieldLogger
+	packages PackageService
+	ops      []txOperation
+	done     bool
+}
+
+// CreatePackage records the creation of the specified package
+func (t *Transaction) CreatePackage(locator loc.Locator, data io.Reader, options ...PackageOption) {
+	t.ops = append(t.ops, &txUpsert{locator: locator, data: data, options: options, create: true})
+}
+
+// UpsertPackage records the creation or update of the specified package
+func (t *Transaction) UpsertPackage(locator loc.Locator, data io.Reader, options ...PackageOption) {
+	t.ops = append(t.ops, &txUpsert{locator: locator, data: data, options: options})
+}
+
+// UpdatePackageLabels records the update of the specified package's labels
+func (t *Transaction) UpdatePackageLabels(locator loc.Locator, addLabels map[string]string, removeLabels []string) {
+	t.ops = append(t.ops, &txLabels{locator: locator, add: addLabels, remove: removeLabels})
+}
+
+// DeletePackage records the removal of the specified package
+func (t *Transaction) DeletePackage(locator loc.Locator) {
+	t.ops = append(t.ops, &txDelete{locator: locator})
+}
+
+// Commit applies all recorded operations.
+// If any operation fails, the operations applied so far are reverted
+// and the error is returned
+func (t *Transaction) Commit() error {
+	if t.done {
+		return trace.BadParameter("transaction has already been committed")
+	}
+	t.done = true
+	for i, op := range t.ops {
+		err := op.apply(t.packages)
+		if err == nil {
+			continue
+		}
+		t.Warnf("Failed to %v: %v, rolling back.", op, trace.DebugReport(err))
+		if errRollback := t.rollback(t.ops[:i]); errRollback != nil {
+			return trace.NewAggregate(trace.Wrap(err, "failed to %v", op), errRollback)
+		}
+		return trace.Wrap(err, "failed to %v", op)
+	}
+	return nil
+}
+
+func (t *Transaction) rollback(applied []txOperation) error {
+	var errors []error
+	for i := len(applied) - 1; i >= 0; i-- {
+		if err := applied[i].revert(t.packages); err != nil {
+			t.Warnf("Failed to revert %v: %v.", applied[i], trace.DebugReport(err))
+			errors = append(errors, trace.Wrap(err, "failed to revert %v", applied[i]))
+		}
+	}
+	return trace.NewAggregate(errors...)
+}
+
+// txOperation is a single reversible transaction operation
+type txOperation interface {
+	// apply applies the operation capturing the state required to revert it
+	apply(PackageService) error
+	// revert undoes the applied operation
+	revert(PackageService) error
+	// String describes the operation
+	String() string
+}
+
+// txUpsert creates or updates a package
+type txUpsert struct {
+	locator  loc.Locator
+	data     io.Reader
+	options  []PackageOption
+	create   bool
+	previous *packageState
+}
+
+func (r *txUpsert) apply(packages PackageService) (err error) {
+	if !r.create {
+		r.previous, err = capturePackage(packages, r.locator)
+		if err != nil && !trace.IsNotFound(err) {
+			return trace.Wrap(err)
+		}
+		_, err = packages.UpsertPackage(r.locator, r.data, r.options...)
+		return trace.Wrap(err)
+	}
+	_, err = packages.CreatePackage(r.locator, r.data, r.options...)
+	return trace.Wrap(err)
+}
+
+func (r *txUpsert) revert(packages PackageService) error {
+	if r.previous != nil {
+		return trace.Wrap(r.previous.restore(packages))
+	}
+	return trace.Wrap(packages.DeletePackage(r.locator))
+}
+
+func (r *txUpsert) String() string {
+	if r.create {
+		return "create package " + r.locator.String()
+	}
+	return "upsert package " + r.locator.String()
+}
+
+// txLabels updates package labels
+type txLabels struct {
+	locator  loc.Locator
+	add      map[string]string
+	remove   []string
+	previous map[string]string
+}
+
+func (r *txLabels) apply(packages PackageService) error {
+	envelope, err := packages.ReadPackageEnvelope(r.locator)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	r.previous = envelope.RuntimeLabels
+	return trace.Wrap(packages.UpdatePackageLabels(r.locator, r.add, r.remove))
+}
+
+func (r *txLabels) revert(packages PackageService) error {
+	restore := make(map[string]string)
+	var remove []string
+	for label := range r.add {
+		if value, ok := r.previous[label]; ok {
+			restore[label] = value
+		} else {
+			remove = append(remove, label)
+		}
+	}
+	for _, label := range r.remove {
+		if value, ok := r.previous[label]; ok {
+			restore[label] = value
+		}
+	}
+	return trace.Wrap(packages.UpdatePackageLabels(r.locator, restore, remove))
+}
+
+func (r *txLabels) String() string {
+	return "update labels of package " + r.locator.String()
+}
+
+// txDelete deletes a package
+type txDelete struct {
+	locator  loc.Locator
+	previous *packageState
+}
+
+func (r *txDelete) apply(packages PackageService) (err error) {
+	r.previous, err = capturePackage(packages, r.locator)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(packages.DeletePackage(r.locator))
+}
+
+func (r *txDelete) revert(packages PackageService) error {
+	return trace.Wrap(r.previous.restore(packages))
+}
+
+func (r *txDelete) String() string {
+	return "delete package " + r.locator.String()
+}
+
+// packageState is the captured package data and metadata
+type packageState struct {
+	envelope PackageEnvelope
+	data     []byte
+}
+
+func capturePackage(packages PackageService, locator loc.Locator) (*packageState, error) {
+	envelope, rc, err := packages.ReadPackage(locator)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer rc.Close()
+	data, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &packageState{envelope: *envelope, data: data}, nil
+}
+
+// restore writes the captured package back
+func (r *packageState) restore(packages PackageService) error {
+	_, err := packages.UpsertPackage(r.envelope.Locator, bytes.NewReader(r.data),
+		WithLabels(r.envelope.RuntimeLabels),
+		WithHidden(r.envelope.Hidden),
+		WithEncrypted(r.envelope.Encrypted),
+		WithManifest(r.envelope.Type, r.envelope.Manifest),
+		WithCreatedBy(r.envelope.CreatedBy))
+	return trace.Wrap(err)
+}
@@ -0,0 +1,226 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pack
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gravitational/gravity/lib/defaults"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// UnpackedMarkerFile is the name of the file written to the directory
+// with the unpacked package once the package has been completely unpacked
+const UnpackedMarkerFile = ".unpacked"
+
+// unpackingMarkerFile is the name of the file written to the directory
+// a package is being unpacked into for the duration of the unpack
+const unpackingMarkerFile = ".unpacking"
+
+// UnpackLockSuffix is appended to the path of the directory a package is
+// unpacked into to name the file locking the directory during unpack
+const UnpackLockSuffix = ".lock"
+
+// unpackedMarker describes the package unpacked to a directory
+type unpackedMarker struct {
+	// SHA512 is the digest of the unpacked package
+	SHA512 string `json:"sha512"`
+	// Files maps the paths of the unpacked files, relative to
+	// the package directory, to their sha-256 checksums.
+	// The checksums are only recorded if the package was unpacked in verify mode
+	Files map[string]string `json:"files,omitempty"`
+}
+
+// IsUnpacked returns true if the package with the specified digest has been
+// completely unpacked to the provided directory, i.e. the directory has
+// the unpack marker that records the same package digest.
+//
+// Directories without the marker that have been unpacked before the markers
+// were introduced are considered unpacked, unless the unpack was interrupted.
+//
+// If verify is set, the unpacked files are also checked against the checksums
+// recorded at unpack time. Packages unpacked without the checksums are
+// not considered unpacked in this mode
+func IsUnpacked(targetDir, digest string, verify bool) (bool, error) {
+	marker, err := readUnpackedMarker(targetDir)
+	if err != nil {
+		return false, trace.Wrap(err)
+	}
+	if marker == nil {
+		return isUnpackedWithoutMarker(targetDir)
+	}
+	if marker.SHA512 != digest {
+		return false, nil
+	}
+	if !verify {
+		return true, nil
+	}
+	if marker.Files == nil {
+		return false, nil
+	}
+	for path, checksum := range marker.Files {
+		actual, err := fileChecksum(filepath.Join(targetDir, path))
+		if err != nil && !trace.IsNotFound(err) {
+			return false, trace.Wrap(err)
+		}
+		if actual != checksum {
+			log.Warnf("Unpacked file %v does not match its checksum.",
+				filepath.Join(targetDir, path))
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// isUnpackedWithoutMarker returns true if the specified directory without
+// the unpack marker exists and is not being unpacked into
+func isUnpackedWithoutMarker(targetDir string) (bool, error) {
+	info, err := os.Stat(targetDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, trace.ConvertSystemError(err)
+	}
+	if !info.IsDir() {
+		return false, trace.BadParameter(
+			"expected %v to be a directory, got %v", targetDir, info)
+	}
+	_, err = os.Stat(filepath.Join(targetDir, unpackingMarkerFile))
+	if err == nil {
+		// the previous unpack was interrupted
+		return false, nil
+	}
+	if !os.IsNotExist(err) {
+		return false, trace.ConvertSystemError(err)
+	}
+	return true, nil
+}
+
+// readUnpackedMarker returns the unpack marker of the specified directory
+// or nil if the directory has no marker
+func readUnpackedMarker(targetDir string) (*unpackedMarker, error) {
+	data, err := ioutil.ReadFile(filepath.Join(targetDir, UnpackedMarkerFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, trace.ConvertSystemError(err)
+	}
+	var marker unpackedMarker
+	if err := json.Unmarshal(data, &marker); err != nil {
+		log.Warnf("Invalid unpack marker in %v: %v.", targetDir, err)
+		return nil, nil
+	}
+	return &marker, nil
+}
+
+// writeUnpackingMarker marks the specified directory as being unpacked into
+func writeUnpackingMarker(targetDir string) error {
+	if err := os.MkdirAll(targetDir, defaults.SharedDirMask); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	err := ioutil.WriteFile(filepath.Join(targetDir, unpackingMarkerFile), nil, defaults.SharedReadMask)
+	return trace.ConvertSystemError(err)
+}
+
+// writeUnpackedMarker records the package digest in the unpack marker of
+// the specified directory. If withChecksums is set, the checksums of
+// the unpacked files are recorded as well
+func writeUnpackedMarker(targetDir, digest string, withChecksums bool) error {
+	marker := unpackedMarker{
+		SHA512: digest,
+	}
+	if withChecksums {
+		files, err := unpackedChecksums(targetDir)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		marker.Files = files
+	}
+	data, err := json.Marshal(marker)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	// the marker is written last and replaced atomically so that
+	// an interrupted unpack never leaves a valid marker behind
+	path := filepath.Join(targetDir, UnpackedMarkerFile)
+	if err := ioutil.WriteFile(path+".tmp", data, defaults.SharedReadMask); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	err = os.Remove(filepath.Join(targetDir, unpackingMarkerFile))
+	if err != nil && !os.IsNotExist(err) {
+		return trace.ConvertSystemError(err)
+	}
+	return nil
+}
+
+// unpackedChecksums returns the sha-256 checksums of the files unpacked
+// to the specified directory keyed by their paths relative to the directory
+func unpackedChecksums(targetDir string) (map[string]string, error) {
+	files := make(map[string]string)
+	err := filepath.Walk(targetDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return trace.ConvertSystemError(err)
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(targetDir, path)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if strings.HasPrefix(rel, UnpackedMarkerFile) || rel == unpackingMarkerFile {
+			return nil
+		}
+		checksum, err := fileChecksum(path)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		files[rel] = checksum
+		return nil
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return files, nil
+}
+
+func fileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", trace.ConvertSystemError(err)
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", trace.ConvertSystemError(err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -19,17 +19,21 @@ package pack
 import (
 	"archive/tar"
 	"bytes"
+	"context"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"time"
 
 	"github.com/gravitational/gravity/lib/archive"
 	"github.com/gravitational/gravity/lib/defaults"
 	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/state"
 	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
 
 	dockerarchive "github.com/docker/docker/pkg/archive"
 	"github.com/gravitational/trace"
@@ -38,91 +42,254 @@ import (
 
 // PackagePath generates a path to the package composed of base directory,
 // repository name, package name and version
-func PackagePath(baseDir string, loc loc.Locator) string {
+func PackagePath(baseDir string, locator loc.Locator) string {
 	// the path can not be too long because it leads to problems like this:
 	// https: //github.com/golang/go/issues/6895
-	return filepath.Join(baseDir, loc.Repository, loc.Name, loc.Version)
+	// variants are unpacked next to the package they are a variant of
+	return filepath.Join(baseDir, locator.Repository, locator.Name, locator.ArchVersion())
 }
 
-// IsUnpacked checks if the package has been unpacked at the provided directory
-// (currently just by checking if the dir exists)
-func IsUnpacked(targetDir string) (bool, error) {
-	info, err := os.Stat(targetDir)
+// Unpack reads the package from the package service and unpacks its contents
+// to base directory targetDir
+func Unpack(p PackageService, loc loc.Locator, targetDir string, opts *dockerarchive.TarOptions) error {
+	return UnpackWithOptions(p, loc, targetDir, UnpackOptions{TarOptions: opts})
+}
+
+// UnpackOptions defines the options to unpack a package
+type UnpackOptions struct {
+	// TarOptions specifies optional tarball extraction options
+	TarOptions *dockerarchive.TarOptions
+	// Progress is an optional callback to report the unpack progress
+	Progress ProgressFunc
+	// Verifier optionally verifies the package signature before unpacking
+	Verifier Verifier
+	// Verify makes UnpackIfNotUnpacked record the checksums of the unpacked
+	// files and check the already unpacked files against them
+	Verify bool
+	// FailFast makes unpack fail immediately instead of waiting if another
+	// process is unpacking a package into the same directory
+	FailFast bool
+	// LockTimeout is how long to wait for another process unpacking
+	// a package into the same directory. Defaults to defaults.UnpackLockTimeout
+	LockTimeout time.Duration
+}
+
+// DefaultUnpackDir returns the directory the specified package is
+// unpacked into if no target directory is given
+func DefaultUnpackDir(loc loc.Locator) (string, error) {
+	stateDir, err := state.GetStateDir()
 	if err != nil {
-		if os.IsNotExist(err) {
-			return false, nil
-		}
-		return false, trace.Wrap(err)
+		return "", trace.Wrap(err)
 	}
-	if !info.IsDir() {
-		return false, trace.Errorf(
-			"expected %v to be a directory, got %v", targetDir, info)
-	}
-	return true, nil
+	baseDir := filepath.Join(stateDir, defaults.LocalDir,
+		defaults.PackagesDir, defaults.UnpackedDir)
+	return PackagePath(baseDir, loc), nil
 }
 
-// Unpack reads the package from the package service and unpacks its contents
-// to base directory targetDir
-func Unpack(p PackageService, loc loc.Locator, targetDir string, opts *dockerarchive.TarOptions) error {
+// UnpackWithOptions reads the package from the package service and unpacks its contents
+// to base directory targetDir using the specified options
+func UnpackWithOptions(p PackageService, loc loc.Locator, targetDir string, opts UnpackOptions) error {
 	var err error
 	// if target dir is not provided, unpack to the default location
 	if targetDir == "" {
-		stateDir, err := state.GetStateDir()
+		targetDir, err = DefaultUnpackDir(loc)
 		if err != nil {
 			return trace.Wrap(err)
 		}
-		baseDir := filepath.Join(stateDir, defaults.LocalDir,
-			defaults.PackagesDir, defaults.UnpackedDir)
-		targetDir = PackagePath(baseDir, loc)
 		log.Infof("Unpacking %v into the default directory %v.",
 			loc, targetDir)
 	}
+	lock, err := lockUnpackDir(targetDir, opts)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer lock.Unlock()
+	return trace.Wrap(unpack(p, loc, targetDir, opts))
+}
+
+// lockUnpackDir locks the specified directory against packages being unpacked
+// into it by other processes at the same time.
+// The lock file is kept next to the directory rather than inside it since
+// stale directory contents are removed before a package is unpacked again.
+// The lock file is removed along with the directory by RemoveUnpacked
+func lockUnpackDir(targetDir string, opts UnpackOptions) (*utils.FileLock, error) {
+	if err := os.MkdirAll(filepath.Dir(targetDir), defaults.SharedDirMask); err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	timeout := opts.LockTimeout
+	if timeout == 0 {
+		timeout = defaults.UnpackLockTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	lock, err := utils.LockFile(ctx, filepath.Clean(targetDir)+UnpackLockSuffix, !opts.FailFast)
+	if err != nil {
+		if trace.IsCompareFailed(err) {
+			return nil, trace.CompareFailed("another process is unpacking into %v", targetDir)
+		}
+		return nil, trace.Wrap(err)
+	}
+	return lock, nil
+}
+
+// RemoveUnpacked removes the directory the package has been unpacked into
+// along with the file locking the directory
+func RemoveUnpacked(targetDir string) error {
+	lock, err := lockUnpackDir(targetDir, UnpackOptions{})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if err := os.RemoveAll(targetDir); err != nil {
+		lock.Unlock()
+		return trace.ConvertSystemError(err)
+	}
+	return trace.Wrap(lock.Remove())
+}
+
+// unpack unpacks the package into targetDir.
+// The caller is expected to hold the lock on the directory
+func unpack(p PackageService, loc loc.Locator, targetDir string, opts UnpackOptions) error {
 	if err := os.MkdirAll(targetDir, defaults.SharedDirMask); err != nil {
 		return trace.Wrap(err)
 	}
-	_, reader, err := p.ReadPackage(loc)
+	env, reader, err := p.ReadPackage(loc)
 	if err != nil {
 		return trace.Wrap(err)
 	}
 	defer reader.Close()
 
-	if opts == nil {
-		opts = archive.DefaultOptions()
+	if opts.Verifier != nil {
+		if err := opts.Verifier.Verify(*env); err != nil {
+			return trace.Wrap(err)
+		}
 	}
 
-	if err := dockerarchive.Untar(reader, targetDir, opts); err != nil {
+	tarOptions := opts.TarOptions
+	if tarOptions == nil {
+		tarOptions = archive.DefaultOptions()
+	}
+
+	if opts.Progress != nil {
+		progressReader := NewProgressReader(reader, env.SizeBytes, opts.Progress)
+		reader = progressReader
+		err = archive.UntarWithProgress(reader, targetDir, tarOptions, func(header *tar.Header) {
+			progressReader.SetEntry(header.Name)
+		})
+	} else {
+		err = archive.Untar(reader, targetDir, tarOptions)
+	}
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	// consume the remainder of the package so its digest gets verified
+	if _, err := io.Copy(ioutil.Discard, reader); err != nil {
 		return trace.Wrap(err)
 	}
 	return nil
 }
 
-// UnpackIfNotUnpacked unpacks the specified package only if it's not yet unpacked
-func UnpackIfNotUnpacked(p PackageService, loc loc.Locator, targetDir string, opts *dockerarchive.TarOptions) error {
-	isUnpacked, err := IsUnpacked(targetDir)
+// UnpackIfNotUnpacked unpacks the specified package only if it's not yet unpacked.
+//
+// The package is unpacked again if the previous unpack was interrupted
+// or if the unpack marker records a different package digest, in which case
+// the stale contents are removed first. A directory unpacked before the
+// markers were introduced is considered unpacked and gets the marker instead
+func UnpackIfNotUnpacked(p PackageService, loc loc.Locator, targetDir string, opts UnpackOptions) error {
+	// check the directory under the lock so the package is not unpacked again
+	// after another process has finished unpacking it
+	lock, err := lockUnpackDir(targetDir, opts)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer lock.Unlock()
+
+	envelope, err := p.ReadPackageEnvelope(loc)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	marker, err := readUnpackedMarker(targetDir)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	isUnpacked, err := IsUnpacked(targetDir, envelope.SHA512, opts.Verify)
 	if err != nil {
 		return trace.Wrap(err)
 	}
 
 	if isUnpacked {
+		if marker == nil {
+			log.Infof("Marking %v in %v as unpacked.", loc, targetDir)
+			return trace.Wrap(writeUnpackedMarker(targetDir, envelope.SHA512, opts.Verify))
+		}
 		return nil
 	}
 
-	err = Unpack(p, loc, targetDir, opts)
+	if marker != nil && marker.SHA512 != envelope.SHA512 {
+		log.Infof("Removing stale contents of %v from %v.", loc, targetDir)
+		if err := os.RemoveAll(targetDir); err != nil {
+			return trace.ConvertSystemError(err)
+		}
+	}
+	err = os.Remove(filepath.Join(targetDir, UnpackedMarkerFile))
+	if err != nil && !os.IsNotExist(err) {
+		return trace.ConvertSystemError(err)
+	}
+	if err := writeUnpackingMarker(targetDir); err != nil {
+		return trace.Wrap(err)
+	}
+
+	err = unpack(p, loc, targetDir, opts)
 	if err != nil {
 		return trace.Wrap(err)
 	}
 
-	return nil
+	return trace.Wrap(writeUnpackedMarker(targetDir, envelope.SHA512, opts.Verify))
 }
 
 // GetConfigPackage creates the config package without saving it into package service
 func GetConfigPackage(p PackageService, loc loc.Locator, confLoc loc.Locator, args []string) (io.Reader, error) {
+	manifest, err := getConfigManifest(p, loc, args)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	// now create a new package with configuration inside
+	buf := &bytes.Buffer{}
+	if err := WriteConfigPackage(manifest, buf); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return buf, nil
+}
+
+// GetLayeredConfigPackage creates the configuration for the package loc split into
+// the variables shared between nodes and the variables of configuration parameters
+// listed in nodeParams. The shared base package is named after basePrefix
+func GetLayeredConfigPackage(p PackageService, loc loc.Locator, basePrefix loc.Locator, args, nodeParams []string) (*LayeredConfig, error) {
+	manifest, err := getConfigManifest(p, loc, args)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	config, err := NewLayeredConfig(manifest.Config.EnvVars(),
+		ConfigEnvVars(manifest.Config, nodeParams), basePrefix)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return config, nil
+}
+
+// getConfigManifest returns the manifest of the package loc with
+// configuration parameters set from args
+func getConfigManifest(p PackageService, loc loc.Locator, args []string) (*Manifest, error) {
 	_, reader, err := p.ReadPackage(loc)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+	defer reader.Close()
 
-	decompressed, err := dockerarchive.DecompressStream(reader)
+	decompressed, err := archive.DecompressStream(reader)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -142,14 +309,7 @@ func GetConfigPackage(p PackageService, loc loc.Locator, confLoc loc.Locator, ar
 		log.Warnf("Failed to parse arguments: %v.", err)
 		return nil, trace.Wrap(err)
 	}
-
-	// now create a new package with configuration inside
-	buf := &bytes.Buffer{}
-	if err := WriteConfigPackage(manifest, buf); err != nil {
-		return nil, trace.Wrap(err)
-	}
-
-	return buf, nil
+	return manifest, nil
 }
 
 // GetPackageManifest will retrieve the manifest file for the specified package
@@ -159,7 +319,7 @@ func GetPackageManifest(p PackageService, loc loc.Locator) (*Manifest, error) {
 		return nil, trace.Wrap(err)
 	}
 
-	decompressed, err := dockerarchive.DecompressStream(reader)
+	decompressed, err := archive.DecompressStream(reader)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -174,7 +334,9 @@ func GetPackageManifest(p PackageService, loc loc.Locator) (*Manifest, error) {
 }
 
 // ConfigurePackage reads the given package, and configures it using arguments passed,
-// the resulting package is created within the scope of the same package service
+// the resulting package is created within the scope of the same package service.
+// An existing configuration package is replaced atomically so an interrupted
+// reconfiguration never leaves the configuration package missing
 func ConfigurePackage(p PackageService, loc loc.Locator, confLoc loc.Locator, args []string, labels map[string]string) error {
 	reader, err := GetConfigPackage(p, loc, confLoc, args)
 	if err != nil {
@@ -186,47 +348,51 @@ func ConfigurePackage(p PackageService, loc loc.Locator, confLoc loc.Locator, ar
 	for k, v := range labels {
 		allLabels[k] = v
 	}
-	_, err = p.CreatePackage(confLoc, reader, WithLabels(allLabels))
+	_, err = p.UpsertPackage(confLoc, reader, WithLabels(allLabels))
 	if err != nil {
 		return trace.Wrap(err)
 	}
 	return nil
 }
 
-// ExecutePackageCommand executes command specified in the package and returns
-// results of CombinedOutput call on the package binary
-func ExecutePackageCommand(p PackageService, cmd string, loc loc.Locator, confLoc *loc.Locator, execArgs []string, storageDir string) ([]byte, error) {
-	log.Infof("exec with config %v %v", loc, confLoc)
-
+// ExecutePackageCommand executes command specified in the package.
+// The package is unpacked into storageDir unless it's already unpacked.
+// The combined stdout and stderr of the command are streamed to w as the command
+// runs, or to os.Stdout if w is nil. The command is killed if the context
+// is cancelled or its deadline expires
+func ExecutePackageCommand(ctx context.Context, p PackageService, cmd string, loc loc.Locator, confLoc *loc.Locator, execArgs []string, storageDir string, w io.Writer) error {
 	unpackedPath := PackagePath(storageDir, loc)
-	if err := Unpack(p, loc, unpackedPath, nil); err != nil {
-		return nil, trace.Wrap(err)
+	if err := UnpackIfNotUnpacked(p, loc, unpackedPath, UnpackOptions{}); err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(ExecuteUnpackedPackageCommand(ctx, p, cmd, unpackedPath, confLoc, execArgs, w))
+}
+
+// ExecuteUnpackedPackageCommand executes command specified in the package
+// that has already been unpacked into unpackedPath, without unpacking it again.
+// See ExecutePackageCommand for details
+func ExecuteUnpackedPackageCommand(ctx context.Context, p PackageService, cmd string, unpackedPath string, confLoc *loc.Locator, execArgs []string, w io.Writer) error {
+	log.Infof("exec %v with config %v", unpackedPath, confLoc)
+
+	if w == nil {
+		w = os.Stdout
 	}
 	manifest, err := OpenManifest(unpackedPath)
 	if err != nil {
-		return nil, trace.Wrap(err)
-	}
-	if err := Unpack(p, loc, unpackedPath, nil); err != nil {
-		return nil, trace.Wrap(err)
+		return trace.Wrap(err)
 	}
 
 	manifestCmdSpec, err := manifest.Command(cmd)
 	if err != nil {
-		return nil, trace.Wrap(err)
+		return trace.Wrap(err)
 	}
 
 	env := []string{fmt.Sprintf("PATH=%v", os.Getenv("PATH"))}
 	// read package with configuration if it's provided
 	if confLoc != nil && confLoc.Name != "" {
-		_, reader, err := p.ReadPackage(*confLoc)
-		if err != nil {
-			return nil, trace.Wrap(err)
-		}
-		defer reader.Close()
-
-		vars, err := ReadConfigPackage(reader)
+		vars, err := ReadConfigVars(p, *confLoc)
 		if err != nil {
-			return nil, trace.Wrap(err)
+			return trace.Wrap(err)
 		}
 		for k, v := range vars {
 			env = append(env, fmt.Sprintf("%v=%v", k, v))
@@ -234,18 +400,22 @@ func ExecutePackageCommand(p PackageService, cmd string, loc loc.Locator, confLo
 	}
 
 	args := append(manifestCmdSpec.Args, execArgs...)
-	command := exec.Command(args[0], args[1:]...)
+	command := exec.CommandContext(ctx, args[0], args[1:]...)
 	command.Dir = unpackedPath
 	command.Env = env
+	command.Stdout = w
+	command.Stderr = w
 
 	log.Infof("ExecutePackageCommand(%v %v %v, unpacked=%v)",
 		manifestCmdSpec.Args[0], cmd, execArgs, unpackedPath)
 
-	out, err := command.CombinedOutput()
-	if err != nil {
-		return out, trace.Wrap(err)
+	if err := command.Run(); err != nil {
+		if ctx.Err() != nil {
+			return trace.Wrap(ctx.Err(), "command %v did not complete: %v", cmd, err)
+		}
+		return trace.Wrap(err)
 	}
-	return out, nil
+	return nil
 }
 
 // FindPackage finds package matching the predicate fn
@@ -315,8 +485,10 @@ func FindInstalledPackage(packages PackageService, filter loc.Locator) (*loc.Loc
 
 // FindConfigPackage returns configuration package for given package
 func FindConfigPackage(packages PackageService, filter loc.Locator) (*loc.Locator, error) {
+	// configuration is shared by all variants of the package
+	target := filter.WithoutArch()
 	configPkg, err := FindPackage(packages, func(e PackageEnvelope) bool {
-		return e.HasLabel(ConfigLabel, filter.ZeroVersion().String())
+		return e.HasLabel(ConfigLabel, target.ZeroVersion().String())
 	})
 	if err != nil {
 		if trace.IsNotFound(err) {
@@ -343,16 +515,30 @@ func FindInstalledPackageWithConfig(packages PackageService, filter loc.Locator)
 	return locator, configLocator, nil
 }
 
-// ProcessMetadata processes some special metadata conventions, e.g. 'latest' metadata label
+// ProcessMetadata processes some special metadata conventions, e.g. 'latest' metadata label,
+// and resolves package aliases to concrete package versions
 func ProcessMetadata(packages PackageService, loc *loc.Locator) (*loc.Locator, error) {
+	if loc.IsAlias() {
+		// aliases are resolved by the package service
+		envelope, err := packages.ReadPackageEnvelope(*loc)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		return &envelope.Locator, nil
+	}
 	ver, err := loc.SemVer()
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	if ver.Metadata == LatestLabel {
-		return FindLatestPackage(packages, *loc)
+	policy, ok := VersionPolicyFromMetadata(ver.Metadata)
+	if !ok {
+		return loc, nil
 	}
-	return loc, nil
+	latest, err := FindLatestPackageWithPolicy(packages, *loc, policy)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return latest, nil
 }
 
 // FindLatestPackageWithLabels returns the latest package matching the provided
@@ -368,11 +554,15 @@ func FindLatestPackageWithLabels(packages PackageService, repository string, lab
 }
 
 // FindLatestPackage returns package the latest package matching the provided
-// locator
+// locator. Only the packages built for the architecture of the locator are
+// considered, use FindLatestPackageForArch to find the package for a node.
+// Prerelease versions and versions that have reached the end of life are not
+// considered, use FindLatestPackageWithPolicy to opt into prerelease channels
 func FindLatestPackage(packages PackageService, filter loc.Locator) (*loc.Locator, error) {
-	loc, err := FindLatestPackagePredicate(packages, filter.Repository, func(e PackageEnvelope) bool {
+	loc, err := findLatestPackage(packages, filter.Repository, StableVersionPolicy, func(e PackageEnvelope) bool {
 		return e.Locator.Repository == filter.Repository &&
-			e.Locator.Name == filter.Name
+			e.Locator.Name == filter.Name &&
+			e.Locator.Arch == filter.Arch
 	})
 	if err != nil && trace.IsNotFound(err) {
 		return nil, trace.NotFound("latest package with filter %v not found", filter)
@@ -395,12 +585,21 @@ func FindLatestPackageByName(packages PackageService, name string) (*loc.Locator
 // predicate function
 //
 // If the provided repository is empty, searches all repositories.
+// All versions, including prereleases and end-of-life versions, are
+// considered: use FindLatestPackageWithPolicy to resolve user-facing versions
 func FindLatestPackagePredicate(packages PackageService, repository string, filter func(PackageEnvelope) bool) (*loc.Locator, error) {
+	return findLatestPackage(packages, repository, allVersionsPolicy, filter)
+}
+
+// findLatestPackage returns the latest package matching the provided predicate
+// function among the versions allowed by the policy
+func findLatestPackage(packages PackageService, repository string, policy VersionPolicy, filter func(PackageEnvelope) bool) (*loc.Locator, error) {
 	var max *loc.Locator
 	predicate := func(e PackageEnvelope) error {
-		if !filter(e) {
+		if !filter(e) || !policy.AllowsPackage(e) {
 			return nil
 		}
+		verb, _ := e.Locator.SemVer()
 		if max == nil {
 			max = &e.Locator
 			return nil
@@ -409,8 +608,7 @@ func FindLatestPackagePredicate(packages PackageService, repository string, filt
 		if err != nil {
 			return nil
 		}
-		verb, err := e.Locator.SemVer()
-		if err != nil {
+		if verb == nil {
 			return nil
 		}
 		if verb.Compare(*vera) > 0 {
@@ -437,7 +635,7 @@ func FindLatestPackagePredicate(packages PackageService, repository string, filt
 func FindNewerPackages(packages PackageService, filter loc.Locator) ([]loc.Locator, error) {
 	var result []loc.Locator
 	err := ForeachPackageInRepo(packages, filter.Repository, func(e PackageEnvelope) error {
-		if e.Locator.Name != filter.Name {
+		if e.Locator.Name != filter.Name || e.Locator.Arch != filter.Arch {
 			return nil
 		}
 		vera, err := filter.SemVer()
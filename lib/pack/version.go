@@ -0,0 +1,204 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pack
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/gravitational/gravity/lib/loc"
+
+	msemver "github.com/Masterminds/semver"
+	"github.com/coreos/go-semver/semver"
+	"github.com/gravitational/trace"
+)
+
+// VersionPolicy defines which package versions are candidates
+// when resolving the latest version of a package.
+//
+// Stable versions are always candidates, prerelease versions (e.g. 1.0.0-rc.1)
+// are only candidates if their release channel has been opted into.
+// Prerelease versions without a channel name, e.g. the build numbers
+// in 5.5.9-11309, are builds of stable versions and are always candidates.
+// Versions that have reached the end of life are not candidates unless
+// explicitly included
+type VersionPolicy struct {
+	// Channels lists prerelease channels, e.g. "rc" or "beta", whose versions
+	// are candidates in addition to stable versions.
+	// The wildcard "*" includes all prerelease versions
+	Channels []string
+	// EndOfLife includes versions marked as end-of-life which are
+	// not candidates by default
+	EndOfLife bool
+}
+
+// StableVersionPolicy only considers stable versions
+var StableVersionPolicy = VersionPolicy{}
+
+// AnyVersionPolicy considers all versions including prereleases
+var AnyVersionPolicy = VersionPolicy{Channels: []string{AnyChannel}}
+
+// allVersionsPolicy considers all versions including prereleases
+// and end-of-life versions
+var allVersionsPolicy = VersionPolicy{Channels: []string{AnyChannel}, EndOfLife: true}
+
+// Allows returns true if the specified version is a candidate under this policy
+func (p VersionPolicy) Allows(version semver.Version) bool {
+	channel := PrereleaseChannel(version)
+	if channel == "" {
+		return true
+	}
+	for _, c := range p.Channels {
+		if c == AnyChannel || c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsPackage returns true if the specified package is a candidate under this policy
+func (p VersionPolicy) AllowsPackage(envelope PackageEnvelope) bool {
+	if envelope.IsEndOfLife() && !p.EndOfLife {
+		return false
+	}
+	version, err := envelope.Locator.SemVer()
+	if err != nil {
+		return true
+	}
+	return p.Allows(*version)
+}
+
+// String returns a textual representation of the policy
+func (p VersionPolicy) String() string {
+	channels := append([]string{"stable"}, p.Channels...)
+	if p.EndOfLife {
+		channels = append(channels, LifecycleEndOfLife)
+	}
+	return strings.Join(channels, ",")
+}
+
+// PrereleaseChannel returns the release channel of the specified version,
+// e.g. "rc" for 1.0.0-rc.1 or "beta" for 1.0.0-beta2.
+// Returns an empty string for stable versions
+func PrereleaseChannel(version semver.Version) string {
+	if version.PreRelease == "" {
+		return ""
+	}
+	return strings.ToLower(channelRe.FindString(string(version.PreRelease)))
+}
+
+// VersionPolicyFromMetadata returns the version policy specified with
+// the version metadata of a package locator:
+//
+//   - "latest" resolves to the latest stable version
+//   - "latest.rc" (or "latest.rc.beta") additionally considers prereleases in
+//     the specified channels
+//   - "latest.all" considers all versions, including any prereleases
+//   - "stable" resolves to the latest stable version
+//
+// Returns false if the metadata does not specify a version to resolve
+func VersionPolicyFromMetadata(metadata string) (policy VersionPolicy, ok bool) {
+	parts := strings.Split(metadata, ".")
+	switch parts[0] {
+	case LatestLabel:
+		for _, channel := range parts[1:] {
+			if channel == AllChannels {
+				channel = AnyChannel
+			}
+			policy.Channels = append(policy.Channels, strings.ToLower(channel))
+		}
+		return policy, true
+	case StableLabel:
+		if len(parts) == 1 {
+			return StableVersionPolicy, true
+		}
+	}
+	return VersionPolicy{}, false
+}
+
+// FindLatestPackageWithPolicy returns the latest version of the package
+// matching the provided locator among the versions allowed by the policy
+func FindLatestPackageWithPolicy(packages PackageService, filter loc.Locator, policy VersionPolicy) (*loc.Locator, error) {
+	loc, err := findLatestPackage(packages, filter.Repository, policy, func(e PackageEnvelope) bool {
+		return e.Locator.Repository == filter.Repository &&
+			e.Locator.Name == filter.Name &&
+			e.Locator.Arch == filter.Arch
+	})
+	if err != nil && trace.IsNotFound(err) {
+		return nil, trace.NotFound("latest %v package with filter %v not found", policy, filter)
+	}
+	return loc, trace.Wrap(err)
+}
+
+// ParseVersionConstraint parses the semver range constraint, e.g. ">=5.5.0, <6.0.0"
+// or "~5.5".
+// See https://github.com/Masterminds/semver#checking-version-constraints for
+// the constraint syntax
+func ParseVersionConstraint(constraint string) (*msemver.Constraints, error) {
+	constraints, err := msemver.NewConstraint(constraint)
+	if err != nil {
+		return nil, trace.BadParameter("invalid version constraint %q: %v", constraint, err)
+	}
+	return constraints, nil
+}
+
+// FindLatestPackageInRange returns the latest stable version of the package
+// matching the provided locator among the versions that satisfy the semver
+// range constraint
+func FindLatestPackageInRange(packages PackageService, filter loc.Locator, constraint string) (*loc.Locator, error) {
+	loc, err := FindLatestPackagePredicateInRange(packages, filter.Repository, constraint, StableVersionPolicy, func(e PackageEnvelope) bool {
+		return e.Locator.Repository == filter.Repository &&
+			e.Locator.Name == filter.Name &&
+			e.Locator.Arch == filter.Arch
+	})
+	if err != nil && trace.IsNotFound(err) {
+		return nil, trace.NotFound("latest package with filter %v in range %q not found", filter, constraint)
+	}
+	return loc, trace.Wrap(err)
+}
+
+// FindLatestPackagePredicateInRange returns the latest package matching the
+// provided predicate function among the versions that satisfy the semver
+// range constraint and are allowed by the policy
+//
+// If the provided repository is empty, searches all repositories
+func FindLatestPackagePredicateInRange(packages PackageService, repository, constraint string, policy VersionPolicy, filter func(PackageEnvelope) bool) (*loc.Locator, error) {
+	constraints, err := ParseVersionConstraint(constraint)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return findLatestPackage(packages, repository, policy, func(e PackageEnvelope) bool {
+		version, err := msemver.NewVersion(e.Locator.Version)
+		if err != nil {
+			return false
+		}
+		return constraints.Check(version) && filter(e)
+	})
+}
+
+const (
+	// StableLabel is a pseudo label that allows system to find the latest stable version
+	StableLabel = "stable"
+	// AnyChannel includes prerelease versions from all channels
+	AnyChannel = "*"
+	// AllChannels is the version metadata form of AnyChannel, as "*"
+	// is not allowed in version metadata
+	AllChannels = "all"
+)
+
+// channelRe extracts the channel name from a prerelease version
+var channelRe = regexp.MustCompile(`^[a-zA-Z]+`)
@@ -0,0 +1,222 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pack
+
+import (
+	"bytes"
+
+	"github.com/gravitational/gravity/lib/loc"
+
+	"github.com/coreos/go-semver/semver"
+	"github.com/gravitational/trace"
+	. "gopkg.in/check.v1"
+)
+
+type VersionSuite struct{}
+
+var _ = Suite(&VersionSuite{})
+
+func (s *VersionSuite) TestPrereleaseChannel(c *C) {
+	var testCases = map[string]string{
+		"1.0.0":        "",
+		"1.0.0-rc.1":   "rc",
+		"1.0.0-beta2":  "beta",
+		"1.0.0-RC.3":   "rc",
+		"1.0.0-1":      "",
+		"1.0.0-alpha":  "alpha",
+		"1.0.0+latest": "",
+	}
+	for version, channel := range testCases {
+		c.Assert(PrereleaseChannel(*semver.New(version)), Equals, channel, Commentf(version))
+	}
+}
+
+func (s *VersionSuite) TestPolicyFromMetadata(c *C) {
+	var testCases = []struct {
+		metadata string
+		policy   VersionPolicy
+		ok       bool
+	}{
+		{metadata: "latest", policy: StableVersionPolicy, ok: true},
+		{metadata: "stable", policy: StableVersionPolicy, ok: true},
+		{metadata: "latest.rc.beta", policy: VersionPolicy{Channels: []string{"rc", "beta"}}, ok: true},
+		{metadata: "latest.all", policy: AnyVersionPolicy, ok: true},
+		{metadata: "", ok: false},
+		{metadata: "build.1", ok: false},
+	}
+	for _, tc := range testCases {
+		policy, ok := VersionPolicyFromMetadata(tc.metadata)
+		c.Assert(ok, Equals, tc.ok, Commentf(tc.metadata))
+		if tc.ok {
+			c.Assert(policy, DeepEquals, tc.policy, Commentf(tc.metadata))
+		}
+	}
+}
+
+func (s *VersionSuite) TestResolvesLatest(c *C) {
+	packages := newMemoryPackages()
+	for _, version := range []string{"1.0.0", "1.1.0-beta.1", "1.1.0-rc.1", "1.0.1"} {
+		_, err := packages.CreatePackage(loc.MustParseLocator("example.com/app:"+version), bytes.NewReader(nil))
+		c.Assert(err, IsNil)
+	}
+
+	var testCases = map[string]string{
+		"0.0.0+latest":      "example.com/app:1.0.1",
+		"0.0.0+stable":      "example.com/app:1.0.1",
+		"0.0.0+latest.beta": "example.com/app:1.1.0-beta.1",
+		"0.0.0+latest.rc":   "example.com/app:1.1.0-rc.1",
+		"0.0.0+latest.all":  "example.com/app:1.1.0-rc.1",
+		"1.0.0":             "example.com/app:1.0.0",
+	}
+	for version, expected := range testCases {
+		filter := loc.MustCreateLocator("example.com", "app", version)
+		locator, err := ProcessMetadata(packages, &filter)
+		c.Assert(err, IsNil, Commentf(version))
+		c.Assert(locator.String(), Equals, expected, Commentf(version))
+	}
+}
+
+func (s *VersionSuite) TestPrereleasesAreOptIn(c *C) {
+	packages := newMemoryPackages()
+	for _, locator := range []string{"example.com/app:1.0.0-rc.1", "example.com/planet:5.5.9-11309", "example.com/planet:5.5.10-rc.1"} {
+		_, err := packages.CreatePackage(loc.MustParseLocator(locator), bytes.NewReader(nil))
+		c.Assert(err, IsNil)
+	}
+
+	for _, version := range []string{loc.LatestVersion, loc.StableVersion} {
+		filter := loc.MustCreateLocator("example.com", "app", version)
+		_, err := ProcessMetadata(packages, &filter)
+		c.Assert(trace.IsNotFound(err), Equals, true, Commentf(version))
+	}
+	_, err := FindLatestPackage(packages, loc.MustParseLocator("example.com/app:0.0.1"))
+	c.Assert(trace.IsNotFound(err), Equals, true)
+	_, err = FindLatestPackageInRange(packages, loc.MustParseLocator("example.com/app:0.0.1"), ">=1.0.0-rc.1")
+	c.Assert(trace.IsNotFound(err), Equals, true)
+
+	filter := loc.MustCreateLocator("example.com", "app", "0.0.0+latest.rc")
+	locator, err := ProcessMetadata(packages, &filter)
+	c.Assert(err, IsNil)
+	c.Assert(locator.String(), Equals, "example.com/app:1.0.0-rc.1")
+
+	// build numbers are not release channels
+	locator, err = FindLatestPackage(packages, loc.MustParseLocator("example.com/planet:0.0.1"))
+	c.Assert(err, IsNil)
+	c.Assert(locator.String(), Equals, "example.com/planet:5.5.9-11309")
+}
+
+func (s *VersionSuite) TestSkipsEndOfLife(c *C) {
+	packages := &labeledPackages{
+		memoryPackages: newMemoryPackages(),
+		labels: map[string]map[string]string{
+			"1.0.1":      {LifecycleLabel: LifecycleDeprecated},
+			"1.1.0":      {LifecycleLabel: LifecycleEndOfLife},
+			"1.2.0-rc.1": {LifecycleLabel: LifecycleEndOfLife},
+		},
+	}
+	for _, version := range []string{"1.0.0", "1.0.1", "1.1.0", "1.2.0-rc.1"} {
+		_, err := packages.CreatePackage(loc.MustParseLocator("example.com/app:"+version), bytes.NewReader(nil))
+		c.Assert(err, IsNil)
+	}
+
+	latest, err := FindLatestPackage(packages, loc.MustParseLocator("example.com/app:0.0.1"))
+	c.Assert(err, IsNil)
+	c.Assert(latest.String(), Equals, "example.com/app:1.0.1")
+
+	for _, version := range []string{loc.LatestVersion, "0.0.0+latest.all"} {
+		filter := loc.MustCreateLocator("example.com", "app", version)
+		locator, err := ProcessMetadata(packages, &filter)
+		c.Assert(err, IsNil, Commentf(version))
+		c.Assert(locator.String(), Equals, "example.com/app:1.0.1", Commentf(version))
+	}
+
+	latest, err = FindLatestPackageWithPolicy(packages, loc.MustParseLocator("example.com/app:0.0.1"),
+		VersionPolicy{EndOfLife: true})
+	c.Assert(err, IsNil)
+	c.Assert(latest.String(), Equals, "example.com/app:1.1.0")
+
+	// end-of-life versions can still be found by labels
+	latest, err = FindLatestPackageWithLabels(packages, "example.com",
+		map[string]string{LifecycleLabel: LifecycleEndOfLife})
+	c.Assert(err, IsNil)
+	c.Assert(latest.String(), Equals, "example.com/app:1.2.0-rc.1")
+}
+
+func (s *VersionSuite) TestFindsLatestInRange(c *C) {
+	packages := newMemoryPackages()
+	for _, locator := range []string{
+		"example.com/app:5.4.3",
+		"example.com/app:5.5.0",
+		"example.com/app:5.5.7",
+		"example.com/app:6.0.0-rc.1",
+		"example.com/app:6.0.1",
+		"example.com/app:5.5.9_arm64",
+	} {
+		_, err := packages.CreatePackage(loc.MustParseLocator(locator), bytes.NewReader(nil))
+		c.Assert(err, IsNil)
+	}
+	filter := loc.MustParseLocator("example.com/app:0.0.0")
+
+	var testCases = map[string]string{
+		">=5.5.0, <6.0.0": "example.com/app:5.5.7",
+		"~5.4":            "example.com/app:5.4.3",
+		"^5.4":            "example.com/app:5.5.7",
+		"*":               "example.com/app:6.0.1",
+	}
+	for constraint, expected := range testCases {
+		latest, err := FindLatestPackageInRange(packages, filter, constraint)
+		c.Assert(err, IsNil, Commentf(constraint))
+		c.Assert(latest.String(), Equals, expected, Commentf(constraint))
+	}
+
+	latest, err := FindLatestPackageInRange(packages, filter.WithArch("arm64"), "~5.5")
+	c.Assert(err, IsNil)
+	c.Assert(latest.String(), Equals, "example.com/app:5.5.9_arm64")
+
+	_, err = FindLatestPackageInRange(packages, filter, "~5.6")
+	c.Assert(trace.IsNotFound(err), Equals, true)
+
+	// prereleases are only considered if opted into
+	_, err = FindLatestPackageInRange(packages, filter, "6.0.0-rc.1")
+	c.Assert(trace.IsNotFound(err), Equals, true)
+	latest, err = FindLatestPackagePredicateInRange(packages, "example.com", "6.0.0-rc.1",
+		VersionPolicy{Channels: []string{"rc"}}, func(PackageEnvelope) bool { return true })
+	c.Assert(err, IsNil)
+	c.Assert(latest.String(), Equals, "example.com/app:6.0.0-rc.1")
+
+	for _, constraint := range []string{"", ">=", "~5.a", ">=5.0.0 ||"} {
+		_, err = FindLatestPackageInRange(packages, filter, constraint)
+		c.Assert(trace.IsBadParameter(err), Equals, true, Commentf(constraint))
+	}
+}
+
+// labeledPackages is a package service that assigns labels to package versions
+type labeledPackages struct {
+	*memoryPackages
+	// labels maps package versions to their labels
+	labels map[string]map[string]string
+}
+
+func (m *labeledPackages) GetPackages(repository string) ([]PackageEnvelope, error) {
+	envelopes, err := m.memoryPackages.GetPackages(repository)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	for i, envelope := range envelopes {
+		envelopes[i].RuntimeLabels = m.labels[envelope.Locator.Version]
+	}
+	return envelopes, nil
+}
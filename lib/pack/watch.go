@@ -0,0 +1,167 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pack
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/gravitational/gravity/lib/defaults"
+)
+
+// PackageEventType defines the type of a package change
+type PackageEventType string
+
+const (
+	// PackageCreated is sent when a package has been created or overwritten
+	PackageCreated PackageEventType = "created"
+	// PackageLabelsUpdated is sent when package labels have been updated
+	PackageLabelsUpdated PackageEventType = "labels_updated"
+	// PackageDeleted is sent when a package has been deleted
+	PackageDeleted PackageEventType = "deleted"
+	// RepositoryCreated is sent when a repository has been created
+	RepositoryCreated PackageEventType = "repository_created"
+	// RepositoryDeleted is sent when a repository and all its packages
+	// have been deleted
+	RepositoryDeleted PackageEventType = "repository_deleted"
+)
+
+// PackageEvent describes a change to a package or a repository
+type PackageEvent struct {
+	// Type is the type of the change
+	Type PackageEventType `json:"type"`
+	// Repository is the repository of the changed package
+	// or the changed repository
+	Repository string `json:"repository,omitempty"`
+	// Envelope is the package envelope after the change.
+	// For deleted packages, it is the last envelope of the package.
+	// Empty for repository events
+	Envelope PackageEnvelope `json:"envelope"`
+}
+
+// IsRepositoryEvent returns true if this event describes a change to a repository
+func (r PackageEvent) IsRepositoryEvent() bool {
+	return r.Type == RepositoryCreated || r.Type == RepositoryDeleted
+}
+
+// String returns a textual representation of this event
+func (r PackageEvent) String() string {
+	if r.IsRepositoryEvent() {
+		return fmt.Sprintf("PackageEvent(%v, %v)", r.Type, r.Repository)
+	}
+	return fmt.Sprintf("PackageEvent(%v, %v)", r.Type, r.Envelope.Locator)
+}
+
+// NewBroadcaster returns a new package event broadcaster
+func NewBroadcaster() *Broadcaster {
+	return &Broadcaster{
+		watchers: make(map[*watcher]struct{}),
+	}
+}
+
+// Broadcaster distributes package events to watchers
+type Broadcaster struct {
+	mu       sync.Mutex
+	watchers map[*watcher]struct{}
+}
+
+// Watch returns a channel that receives events about packages in the specified
+// repository until ctx is canceled. If the repository is empty, the channel
+// receives events about all repositories and their packages.
+//
+// The channel is closed when ctx is canceled or if the watcher falls
+// too far behind, in which case it should re-read the state it is interested
+// in and watch again
+func (r *Broadcaster) Watch(ctx context.Context, repository string) <-chan PackageEvent {
+	w := &watcher{
+		repository: repository,
+		eventsC:    make(chan PackageEvent, defaults.PackageWatchQueueSize),
+	}
+	r.mu.Lock()
+	r.watchers[w] = struct{}{}
+	r.mu.Unlock()
+	go func() {
+		<-ctx.Done()
+		r.remove(w)
+	}()
+	return w.eventsC
+}
+
+// Publish sends the event to all watchers of the package repository
+func (r *Broadcaster) Publish(event PackageEvent) {
+	if event.Repository == "" {
+		event.Repository = event.Envelope.Locator.Repository
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for w := range r.watchers {
+		if w.repository != "" && w.repository != event.Repository {
+			continue
+		}
+		select {
+		case w.eventsC <- event:
+		default:
+			// drop the watcher instead of blocking the package service
+			delete(r.watchers, w)
+			close(w.eventsC)
+		}
+	}
+}
+
+func (r *Broadcaster) remove(w *watcher) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.watchers[w]; ok {
+		delete(r.watchers, w)
+		close(w.eventsC)
+	}
+}
+
+type watcher struct {
+	repository string
+	eventsC    chan PackageEvent
+}
+
+// FilterEvents returns a channel that receives the events from eventsC
+// accepted by the filter. The returned channel is closed when eventsC
+// is closed or ctx is canceled
+func FilterEvents(ctx context.Context, eventsC <-chan PackageEvent, filter func(PackageEvent) bool) <-chan PackageEvent {
+	filteredC := make(chan PackageEvent)
+	go func() {
+		defer close(filteredC)
+		for {
+			select {
+			case event, ok := <-eventsC:
+				if !ok {
+					return
+				}
+				if !filter(event) {
+					continue
+				}
+				select {
+				case filteredC <- event:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return filteredC
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webpack
+
+import (
+	"net/http"
+
+	"github.com/gravitational/gravity/lib/httplib"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/users"
+
+	teleservices "github.com/gravitational/teleport/lib/services"
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// RequestAuthenticator authenticates package service requests
+type RequestAuthenticator interface {
+	// Authenticate authenticates the request and returns the user it was made by
+	// along with the user's access checker.
+	// Returns trace.NotFound if the request does not carry credentials
+	// this authenticator supports so the next authenticator can be tried
+	Authenticate(w http.ResponseWriter, r *http.Request) (storage.User, teleservices.AccessChecker, error)
+}
+
+// NewClientCertAuthenticator returns an authenticator that authenticates
+// requests made with a verified TLS client certificate.
+//
+// The certificate's common name specifies the name of the user the request
+// is made on behalf of. Verifying client certificates is up to the TLS server
+func NewClientCertAuthenticator(identity users.Identity) RequestAuthenticator {
+	return &clientCertAuthenticator{users: identity}
+}
+
+type clientCertAuthenticator struct {
+	users users.Identity
+}
+
+// Authenticate authenticates the request with the verified client certificate
+func (a *clientCertAuthenticator) Authenticate(w http.ResponseWriter, r *http.Request) (storage.User, teleservices.AccessChecker, error) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil, nil, trace.NotFound("no verified client certificate")
+	}
+	name := r.TLS.VerifiedChains[0][0].Subject.CommonName
+	if name == "" {
+		return nil, nil, trace.AccessDenied("client certificate does not specify user")
+	}
+	user, err := a.users.GetTelekubeUser(name)
+	if err != nil {
+		log.Debugf("authenticate error: %v", err)
+		return nil, nil, trace.AccessDenied("bad client certificate")
+	}
+	checker, err := accessChecker(a.users, user)
+	if err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+	return user, checker, nil
+}
+
+// sessionAuthenticator authenticates requests made with a web session
+type sessionAuthenticator struct {
+	authenticator httplib.Authenticator
+	users         users.Identity
+}
+
+// Authenticate authenticates the request with the web session cookie
+func (a *sessionAuthenticator) Authenticate(w http.ResponseWriter, r *http.Request) (storage.User, teleservices.AccessChecker, error) {
+	cookie, err := r.Cookie("session")
+	if err != nil || cookie == nil || cookie.Value == "" {
+		return nil, nil, trace.NotFound("no session cookie")
+	}
+	if a.authenticator == nil {
+		// we hide the error from the remote user to avoid giving any hints
+		return nil, nil, trace.AccessDenied("web sessions are not supported")
+	}
+	session, err := a.authenticator(w, r, true)
+	if err != nil {
+		log.Debugf("authenticate error: %v", err)
+		// we hide the error from the remote user to avoid giving any hints
+		return nil, nil, trace.AccessDenied("bad username or password")
+	}
+	user, err := a.users.GetTelekubeUser(session.GetUser())
+	if err != nil {
+		log.Debugf("authenticate error: %v", err)
+		return nil, nil, trace.AccessDenied("bad username or password")
+	}
+	checker, err := accessChecker(a.users, user)
+	if err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+	return user, checker, nil
+}
+
+// credentialsAuthenticator authenticates requests made with basic auth
+// credentials or bearer tokens (e.g. API keys)
+type credentialsAuthenticator struct {
+	users users.Identity
+}
+
+// Authenticate authenticates the request with the credentials from the authorization header
+func (a *credentialsAuthenticator) Authenticate(w http.ResponseWriter, r *http.Request) (storage.User, teleservices.AccessChecker, error) {
+	authCreds, err := httplib.ParseAuthHeaders(r)
+	if err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+	user, checker, err := a.users.AuthenticateUser(*authCreds)
+	if err != nil {
+		log.Debugf("authenticate error: %v", err)
+		// we hide the error from the remote user to avoid giving any hints
+		return nil, nil, trace.AccessDenied("bad username or password")
+	}
+	return user, checker, nil
+}
+
+func accessChecker(identity users.Identity, user storage.User) (teleservices.AccessChecker, error) {
+	checker, err := identity.GetAccessChecker(user)
+	if err != nil {
+		log.Errorf("failed to fetch roles %v", trace.DebugReport(err))
+		return nil, trace.BadParameter("internal server error")
+	}
+	return checker, nil
+}
@@ -17,26 +17,44 @@ limitations under the License.
 package webpack
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
 	"time"
 
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/httplib"
 	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/pack"
 	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
 
 	"github.com/gravitational/roundtrip"
 	telehttplib "github.com/gravitational/teleport/lib/httplib"
 	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
 )
 
 const CurrentVersion = "pack/v1"
 
 type Client struct {
 	roundtrip.Client
+	// progress is an optional callback to report package download progress
+	progress pack.ProgressFunc
+	// downloadDir is an optional directory to persist partial package
+	// downloads in so interrupted downloads can be resumed
+	downloadDir string
+	// limiter optionally limits the rate of package uploads and downloads
+	limiter *rate.Limiter
 }
 
 // NewAuthenticatedClient returns client authenticated as a user with given password
@@ -51,12 +69,52 @@ func NewBearerClient(addr, token string, params ...roundtrip.ClientParam) (*Clie
 	return NewClient(addr, params...)
 }
 
+// NewClientWithCert returns a new client that authenticates with the TLS
+// client certificate and verifies the package service with the specified
+// CA certificate, so both sides of the connection are authenticated
+func NewClientWithCert(addr string, cert tls.Certificate, caPEM []byte, params ...roundtrip.ClientParam) (*Client, error) {
+	if !x509.NewCertPool().AppendCertsFromPEM(caPEM) {
+		return nil, trace.BadParameter("CA certificate is required to verify package service %v", addr)
+	}
+	httpClient := httplib.GetClient(false, httplib.WithClientCert(cert), httplib.WithCA(caPEM))
+	params = append(params, roundtrip.HTTPClient(httpClient))
+	return NewClient(addr, params...)
+}
+
 func NewClient(addr string, params ...roundtrip.ClientParam) (*Client, error) {
 	c, err := roundtrip.NewClient(addr, CurrentVersion, params...)
 	if err != nil {
 		return nil, err
 	}
-	return &Client{*c}, nil
+	return &Client{Client: *c}, nil
+}
+
+// WithProgress returns a copy of this client that reports
+// the progress of package downloads using the specified callback
+func (c *Client) WithProgress(fn pack.ProgressFunc) *Client {
+	client := *c
+	client.progress = fn
+	return &client
+}
+
+// WithRateLimit returns a copy of this client that uploads and downloads
+// package data no faster than the specified rate.
+// The rate is shared by all concurrent transfers of the returned client
+func (c *Client) WithRateLimit(limit utils.TransferRate) *Client {
+	client := *c
+	client.limiter = utils.NewRateLimiter(limit)
+	return &client
+}
+
+// WithDownloadDir returns a copy of this client that downloads package
+// data into the specified directory first and resumes interrupted downloads
+// from the last received offset.
+// Partial downloads are kept in the directory until they complete so
+// the download can also be resumed by another client
+func (c *Client) WithDownloadDir(dir string) *Client {
+	client := *c
+	client.downloadDir = dir
+	return &client
 }
 
 func (c *Client) PortalURL() string {
@@ -106,6 +164,19 @@ func (c *Client) GetRepository(repository string) (storage.Repository, error) {
 	return storage.UnmarshalRepository(out.Bytes())
 }
 
+// GetRepositoryUsage returns the resource usage and quota of the specified repository
+func (c *Client) GetRepositoryUsage(repository string) (*pack.RepositoryUsage, error) {
+	out, err := c.Get(c.Endpoint("repositories", repository, "usage"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var usage pack.RepositoryUsage
+	if err := json.Unmarshal(out.Bytes(), &usage); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &usage, nil
+}
+
 func (c *Client) GetRepositories() ([]string, error) {
 	out, err := c.Get(c.Endpoint("repositories"), url.Values{})
 	if err != nil {
@@ -130,6 +201,28 @@ func (c *Client) GetPackages(repository string) ([]pack.PackageEnvelope, error)
 	return packages, nil
 }
 
+// GetPackagesBySelector returns a list of packages in repository with labels
+// matching the selector. The selector is evaluated by the server
+func (c *Client) GetPackagesBySelector(repository, selector string) ([]pack.PackageEnvelope, error) {
+	// validate the selector locally to fail early
+	parsed, err := pack.ParseSelector(selector)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	out, err := c.Get(c.Endpoint("repositories", repository, "packages"), url.Values{
+		"selector": []string{selector},
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var packages []pack.PackageEnvelope
+	if err := json.Unmarshal(out.Bytes(), &packages); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	// servers that do not support selectors return all packages
+	return pack.FilterPackages(packages, parsed), nil
+}
+
 func (c *Client) CreatePackage(loc loc.Locator, data io.Reader, options ...pack.PackageOption) (*pack.PackageEnvelope, error) {
 	return c.createOrUpsertPackage(loc, data, false, options...)
 }
@@ -139,6 +232,9 @@ func (c *Client) UpsertPackage(loc loc.Locator, data io.Reader, options ...pack.
 }
 
 func (c *Client) createOrUpsertPackage(loc loc.Locator, data io.Reader, upsert bool, options ...pack.PackageOption) (*pack.PackageEnvelope, error) {
+	if c.limiter != nil {
+		data = utils.NewRateLimitedReader(data, c.limiter)
+	}
 	file := roundtrip.File{
 		Name:     "package",
 		Filename: loc.String(),
@@ -157,9 +253,10 @@ func (c *Client) createOrUpsertPackage(loc loc.Locator, data io.Reader, upsert b
 		return nil, trace.Wrap(err)
 	}
 	values := url.Values{
-		"labels": []string{string(labelsJSON)},
-		"hidden": []string{fmt.Sprintf("%t", pkg.Hidden)},
-		"upsert": []string{fmt.Sprintf("%t", upsert)},
+		"locator": []string{loc.String()},
+		"labels":  []string{string(labelsJSON)},
+		"hidden":  []string{fmt.Sprintf("%t", pkg.Hidden)},
+		"upsert":  []string{fmt.Sprintf("%t", upsert)},
 	}
 	if pkg.Type != "" {
 		values["type"] = []string{pkg.Type}
@@ -180,7 +277,7 @@ func (c *Client) createOrUpsertPackage(loc loc.Locator, data io.Reader, upsert b
 
 // UpdatePackageLabels updates package's labels
 func (c *Client) UpdatePackageLabels(loc loc.Locator, addLabels map[string]string, removeLabels []string) error {
-	_, err := c.PostJSON(c.Endpoint("repositories", loc.Repository, "packages", loc.Name, loc.Version),
+	_, err := c.PostJSON(c.Endpoint("repositories", loc.Repository, "packages", loc.Name, loc.ArchVersion()),
 		labels{
 			AddLabels:    addLabels,
 			RemoveLabels: removeLabels,
@@ -191,19 +288,32 @@ func (c *Client) UpdatePackageLabels(loc loc.Locator, addLabels map[string]strin
 func (c *Client) DeletePackage(locator loc.Locator) error {
 	_, err := c.Delete(
 		c.Endpoint("repositories", locator.Repository, "packages",
-			locator.Name, locator.Version))
+			locator.Name, locator.ArchVersion()))
 	if err != nil {
 		return trace.Wrap(err)
 	}
 	return nil
 }
 
+// PinPackage protects the package from deletion until it is unpinned
+func (c *Client) PinPackage(locator loc.Locator) error {
+	return pack.Pin(c, locator)
+}
+
+// UnpinPackage removes the deletion protection from the package
+func (c *Client) UnpinPackage(locator loc.Locator) error {
+	return pack.Unpin(c, locator)
+}
+
 func (c *Client) ReadPackage(loc loc.Locator) (*pack.PackageEnvelope, io.ReadCloser, error) {
 	envelope, err := c.ReadPackageEnvelope(loc)
 	if err != nil {
 		return nil, nil, trace.Wrap(err)
 	}
-	endpoint := c.Endpoint("repositories", loc.Repository, "packages", loc.Name, loc.Version, "file")
+	// use the resolved locator so the data matches the envelope
+	// even if the alias is updated in the meantime
+	loc = envelope.Locator
+	endpoint := c.Endpoint("repositories", loc.Repository, "packages", loc.Name, loc.ArchVersion(), "file")
 
 	_, err = telehttplib.ConvertResponse(c.RoundTrip(func() (*http.Response, error) {
 		req, err := http.NewRequest("HEAD", endpoint, nil)
@@ -216,18 +326,199 @@ func (c *Client) ReadPackage(loc loc.Locator) (*pack.PackageEnvelope, io.ReadClo
 	if err != nil {
 		return nil, nil, trace.Wrap(err, "failed to read package %s", loc.String())
 	}
+	if c.downloadDir != "" && envelope.SHA256 != "" {
+		file, err := c.downloadPackage(endpoint, *envelope)
+		if err != nil {
+			return nil, nil, trace.Wrap(err, "failed to download package %s", loc.String())
+		}
+		return envelope, pack.NewDigestReader(file, envelope.SHA256), nil
+	}
 	re, err := c.Client.GetFile(endpoint, url.Values{})
 	if err != nil {
 		return nil, nil, trace.Wrap(err)
 	}
 
-	return envelope, re.Body(), nil
+	reader := pack.NewDigestReader(c.rateLimited(re.Body()), envelope.SHA256)
+	if c.progress != nil {
+		reader = pack.NewProgressReader(reader, envelope.SizeBytes, c.progress)
+	}
+	return envelope, reader, nil
+}
+
+// ReadPackageDelta returns the envelope of the package specified with loc
+// and the delta that reconstructs its data from the base package
+// with the specified digest
+func (c *Client) ReadPackageDelta(loc, base loc.Locator, baseDigest string) (*pack.PackageEnvelope, io.ReadCloser, error) {
+	envelope, err := c.ReadPackageEnvelope(loc)
+	if err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+	loc = envelope.Locator
+	re, err := c.Client.GetFile(c.Endpoint("repositories", loc.Repository, "packages", loc.Name, loc.ArchVersion(), "delta"),
+		url.Values{
+			"base":        []string{base.Version},
+			"base_sha256": []string{baseDigest},
+		})
+	if err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+	if re.Code() != http.StatusOK {
+		defer re.Body().Close()
+		body, err := ioutil.ReadAll(re.Body())
+		if err != nil {
+			return nil, nil, trace.Wrap(err)
+		}
+		// servers that do not support delta transfers reply with trace.NotFound
+		return nil, nil, trace.ReadError(re.Code(), body)
+	}
+	return envelope, c.rateLimited(re.Body()), nil
+}
+
+// downloadPackage downloads the data of the package described by envelope
+// into a partial file in the download directory and returns the file.
+//
+// If the partial file has been left by an interrupted download, the download
+// resumes from its end. The download is retried from the last received offset
+// if the connection fails. The file is removed when closed
+func (c *Client) downloadPackage(endpoint string, envelope pack.PackageEnvelope) (*partialFile, error) {
+	err := os.MkdirAll(c.downloadDir, defaults.SharedDirMask)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	path := filepath.Join(c.downloadDir, fmt.Sprintf("%v.partial", envelope.SHA256))
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, defaults.SharedReadMask)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	err = utils.Retry(defaults.DownloadRetryPeriod, defaults.DownloadRetryAttempts, func() error {
+		offset, err := file.Seek(0, io.SeekEnd)
+		if err != nil {
+			return utils.Abort(trace.ConvertSystemError(err))
+		}
+		if offset == envelope.SizeBytes {
+			return nil
+		}
+		if offset > envelope.SizeBytes {
+			log.Warnf("Partial download %v is larger than package %v, restarting download.",
+				path, envelope.Locator)
+			if err := truncate(file); err != nil {
+				return utils.Abort(err)
+			}
+			offset = 0
+		}
+		return c.downloadRange(endpoint, envelope, file, offset)
+	})
+	if err != nil {
+		file.Close()
+		return nil, trace.Wrap(err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		file.Close()
+		return nil, trace.ConvertSystemError(err)
+	}
+	return &partialFile{File: file}, nil
+}
+
+// downloadRange downloads package data starting at the specified offset
+// and appends it to the file.
+// Errors that cannot be resolved by retrying the download abort the retry loop
+func (c *Client) downloadRange(endpoint string, envelope pack.PackageEnvelope, file *os.File, offset int64) error {
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return utils.Abort(trace.Wrap(err))
+	}
+	c.SetAuthHeader(req.Header)
+	if offset > 0 {
+		log.Infof("Resuming download of package %v from offset %v.", envelope.Locator, offset)
+		req.Header.Set("Range", fmt.Sprintf("bytes=%v-", offset))
+		// the range only applies if the package data has not changed,
+		// otherwise the server returns the complete data
+		req.Header.Set("If-Range", packageETag(envelope))
+	}
+	resp, err := c.HTTPClient().Do(req)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusOK:
+		if offset > 0 {
+			log.Infof("Server returned complete data for package %v, restarting download.",
+				envelope.Locator)
+			if err := truncate(file); err != nil {
+				return utils.Abort(err)
+			}
+			offset = 0
+		}
+	case http.StatusRequestedRangeNotSatisfiable:
+		if err := truncate(file); err != nil {
+			return utils.Abort(err)
+		}
+		return trace.BadParameter("requested range is not satisfiable, restarting download")
+	default:
+		body, _ := ioutil.ReadAll(resp.Body)
+		err := trace.ReadError(resp.StatusCode, body)
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return err
+		}
+		return utils.Abort(err)
+	}
+	reader := c.rateLimited(resp.Body)
+	if c.progress != nil {
+		reader = pack.NewProgressReader(reader, envelope.SizeBytes, func(progress pack.Progress) {
+			progress.Current += offset
+			c.progress(progress)
+		})
+	}
+	_, err = io.Copy(file, reader)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// rateLimited returns the reader limited to the client's transfer rate
+func (c *Client) rateLimited(r io.ReadCloser) io.ReadCloser {
+	if c.limiter == nil {
+		return r
+	}
+	return utils.NewRateLimitedReadCloser(r, c.limiter)
+}
+
+// packageETag returns the entity tag of the data of the package
+// described by envelope
+func packageETag(envelope pack.PackageEnvelope) string {
+	return fmt.Sprintf("%q", envelope.SHA256)
+}
+
+// truncate discards the contents of the specified file
+func truncate(file *os.File) error {
+	if err := file.Truncate(0); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	_, err := file.Seek(0, io.SeekStart)
+	return trace.ConvertSystemError(err)
+}
+
+// partialFile is a completely downloaded partial file that is removed when closed
+type partialFile struct {
+	*os.File
+}
+
+// Close closes and removes the file
+func (r *partialFile) Close() error {
+	err := r.File.Close()
+	if errRemove := os.Remove(r.Name()); errRemove != nil && !os.IsNotExist(errRemove) {
+		log.Warnf("Failed to remove %v: %v.", r.Name(), errRemove)
+	}
+	return trace.ConvertSystemError(err)
 }
 
 func (c *Client) ReadPackageEnvelope(loc loc.Locator) (*pack.PackageEnvelope, error) {
 	out, err := c.Get(
 		c.Endpoint("repositories", loc.Repository,
-			"packages", loc.Name, loc.Version, "envelope"), url.Values{})
+			"packages", loc.Name, loc.ArchVersion(), "envelope"), url.Values{})
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -238,6 +529,92 @@ func (c *Client) ReadPackageEnvelope(loc loc.Locator) (*pack.PackageEnvelope, er
 	return envelope, nil
 }
 
+// UpsertPackageAlias creates the package alias or atomically updates
+// it to point to the specified target package
+func (c *Client) UpsertPackageAlias(alias, target loc.Locator) error {
+	_, err := c.PostJSON(c.Endpoint("repositories", alias.Repository, "aliases", alias.Name, alias.Version),
+		aliasTarget{Version: target.Version})
+	return trace.Wrap(err)
+}
+
+// GetPackageAliases returns a list of package aliases in repository
+func (c *Client) GetPackageAliases(repository string) ([]storage.PackageAlias, error) {
+	out, err := c.Get(c.Endpoint("repositories", repository, "aliases"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var aliases []storage.PackageAlias
+	if err := json.Unmarshal(out.Bytes(), &aliases); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return aliases, nil
+}
+
+// DeletePackageAlias deletes the package alias
+func (c *Client) DeletePackageAlias(alias loc.Locator) error {
+	_, err := c.Delete(c.Endpoint("repositories", alias.Repository, "aliases", alias.Name, alias.Version))
+	return trace.Wrap(err)
+}
+
+// WatchPackages returns a channel that receives events about changes
+// to packages in the specified repository, or all repositories if
+// the repository is empty.
+// The channel is closed when ctx is canceled or the connection is lost
+func (c *Client) WatchPackages(ctx context.Context, repository string) (<-chan pack.PackageEvent, error) {
+	endpoint := c.Endpoint("watch")
+	if repository != "" {
+		endpoint = c.Endpoint("repositories", repository, "watch")
+	}
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	c.SetAuthHeader(req.Header)
+	resp, err := c.HTTPClient().Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, trace.ReadError(resp.StatusCode, body)
+	}
+	eventsC := make(chan pack.PackageEvent)
+	go func() {
+		defer close(eventsC)
+		defer resp.Body.Close()
+		decoder := json.NewDecoder(resp.Body)
+		for {
+			var event pack.PackageEvent
+			if err := decoder.Decode(&event); err != nil {
+				if err != io.EOF && ctx.Err() == nil {
+					log.Debugf("Package watch interrupted: %v.", err)
+				}
+				return
+			}
+			select {
+			case eventsC <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return eventsC, nil
+}
+
+// PrunePackages deletes packages that are not in use and returns them
+func (c *Client) PrunePackages(req pack.PruneRequest) (*pack.PruneResponse, error) {
+	out, err := c.PostJSON(c.Endpoint("prune"), req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var resp pack.PruneResponse
+	if err := json.Unmarshal(out.Bytes(), &resp); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &resp, nil
+}
+
 // PostForm is a generic method that issues http POST request to the server
 func (c *Client) PostForm(
 	endpoint string,
@@ -248,6 +625,11 @@ func (c *Client) PostForm(
 		c.Client.PostForm(endpoint, vals, files...))
 }
 
+// PostJSON issues http POST request to the server with the JSON-encoded data
+func (c *Client) PostJSON(endpoint string, data interface{}) (*roundtrip.Response, error) {
+	return telehttplib.ConvertResponse(c.Client.PostJSON(endpoint, data))
+}
+
 // Get issues http GET request to the server
 func (c *Client) Get(u string, params url.Values) (*roundtrip.Response, error) {
 	return telehttplib.ConvertResponse(c.Client.Get(u, params))
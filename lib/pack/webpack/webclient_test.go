@@ -0,0 +1,133 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webpack
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/pack"
+
+	. "gopkg.in/check.v1"
+)
+
+type WebclientSuite struct {
+	data     []byte
+	envelope pack.PackageEnvelope
+	// ranges lists the Range headers of the received file requests
+	ranges []string
+	// etag is the entity tag served with the package data
+	etag       string
+	server     *httptest.Server
+	client     *Client
+	partialDir string
+}
+
+var _ = Suite(&WebclientSuite{})
+
+func (s *WebclientSuite) SetUpTest(c *C) {
+	s.data = bytes.Repeat([]byte("package data "), 1000)
+	s.envelope = pack.PackageEnvelope{
+		Locator:   loc.MustParseLocator("example.com/package:0.0.1"),
+		SizeBytes: int64(len(s.data)),
+		SHA256:    fmt.Sprintf("%x", sha256.Sum256(s.data)),
+	}
+	s.etag = packageETag(s.envelope)
+	s.ranges = nil
+	s.server = httptest.NewServer(http.HandlerFunc(s.serve))
+	var err error
+	s.client, err = NewClient(s.server.URL)
+	c.Assert(err, IsNil)
+	s.partialDir = c.MkDir()
+	s.client = s.client.WithDownloadDir(s.partialDir)
+}
+
+func (s *WebclientSuite) TearDownTest(c *C) {
+	s.server.Close()
+}
+
+func (s *WebclientSuite) TestResumesPartialDownload(c *C) {
+	offset := len(s.data) / 2
+	s.writePartial(c, s.data[:offset])
+
+	var progress []pack.Progress
+	_, reader, err := s.client.WithProgress(func(p pack.Progress) {
+		progress = append(progress, p)
+	}).ReadPackage(s.envelope.Locator)
+	c.Assert(err, IsNil)
+	data, err := ioutil.ReadAll(reader)
+	c.Assert(err, IsNil)
+	c.Assert(reader.Close(), IsNil)
+
+	c.Assert(data, DeepEquals, s.data)
+	c.Assert(s.ranges, DeepEquals, []string{fmt.Sprintf("bytes=%v-", offset)})
+	c.Assert(progress[len(progress)-1], DeepEquals, pack.Progress{
+		Current: int64(len(s.data)),
+		Total:   int64(len(s.data)),
+	})
+	s.assertNoPartial(c)
+}
+
+func (s *WebclientSuite) TestRestartsDownloadIfDataChanged(c *C) {
+	s.writePartial(c, []byte("stale data"))
+	s.etag = `"other"`
+
+	_, reader, err := s.client.ReadPackage(s.envelope.Locator)
+	c.Assert(err, IsNil)
+	data, err := ioutil.ReadAll(reader)
+	c.Assert(err, IsNil)
+	c.Assert(reader.Close(), IsNil)
+
+	c.Assert(data, DeepEquals, s.data)
+	c.Assert(s.ranges, DeepEquals, []string{"bytes=10-"})
+	s.assertNoPartial(c)
+}
+
+func (s *WebclientSuite) writePartial(c *C, data []byte) {
+	path := filepath.Join(s.partialDir, fmt.Sprintf("%v.partial", s.envelope.SHA256))
+	c.Assert(ioutil.WriteFile(path, data, 0644), IsNil)
+}
+
+func (s *WebclientSuite) assertNoPartial(c *C) {
+	files, err := ioutil.ReadDir(s.partialDir)
+	c.Assert(err, IsNil)
+	c.Assert(files, HasLen, 0)
+}
+
+func (s *WebclientSuite) serve(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/envelope"):
+		json.NewEncoder(w).Encode(s.envelope)
+	case strings.HasSuffix(r.URL.Path, "/file"):
+		if r.Method == "GET" {
+			s.ranges = append(s.ranges, r.Header.Get("Range"))
+		}
+		w.Header().Set("ETag", s.etag)
+		http.ServeContent(w, r, "package", time.Time{}, bytes.NewReader(s.data))
+	default:
+		http.NotFound(w, r)
+	}
+}
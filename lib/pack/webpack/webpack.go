@@ -17,14 +17,17 @@ limitations under the License.
 package webpack
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/gravitational/gravity/lib/defaults"
 	"github.com/gravitational/gravity/lib/httplib"
 	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/pack"
@@ -43,12 +46,20 @@ type Config struct {
 	Packages      pack.PackageService
 	Users         users.Identity
 	Authenticator httplib.Authenticator
+	// Authenticators lists additional request authenticators that are tried,
+	// in order, before the web session and the basic/bearer credentials
+	Authenticators []RequestAuthenticator
+	// Rules optionally restricts access of specific users to repositories
+	Rules pack.RepositoryRules
+	// Quotas optionally limits the number and size of packages in repositories
+	Quotas pack.RepositoryQuotas
 }
 
 type Server struct {
 	httprouter.Router
-	cfg        Config
-	fileServer http.Handler
+	cfg            Config
+	fileServer     http.Handler
+	authenticators []RequestAuthenticator
 }
 
 func NewHandler(cfg Config) (*Server, error) {
@@ -58,21 +69,41 @@ func NewHandler(cfg Config) (*Server, error) {
 	if cfg.Users == nil {
 		return nil, trace.BadParameter("missing parameter Users")
 	}
+	if err := cfg.Rules.Check(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := cfg.Quotas.Check(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	cfg.Packages = pack.PackagesWithQuotas(cfg.Packages, cfg.Quotas)
 	h := &Server{
 		cfg: cfg,
 	}
+	h.authenticators = append(h.authenticators, cfg.Authenticators...)
+	h.authenticators = append(h.authenticators,
+		&sessionAuthenticator{authenticator: cfg.Authenticator, users: cfg.Users},
+		&credentialsAuthenticator{users: cfg.Users},
+	)
 
 	h.POST("/pack/v1/repositories", h.needsAuth(h.createRepository))
 	h.DELETE("/pack/v1/repositories/:repository", h.needsAuth(h.deleteRepository))
 	h.GET("/pack/v1/repositories", h.needsAuth(h.getRepositories))
 	h.GET("/pack/v1/repositories/:repository", h.needsAuth(h.getRepository))
+	h.GET("/pack/v1/repositories/:repository/usage", h.needsAuth(h.getRepositoryUsage))
 	h.POST("/pack/v1/repositories/:repository/packages", h.needsAuth(h.createPackage))
 	h.GET("/pack/v1/repositories/:repository/packages", h.needsAuth(h.getPackages))
 	h.GET("/pack/v1/repositories/:repository/packages/:package_name/:package_version/file", h.needsAuth(h.getPackageFile))
 	h.HEAD("/pack/v1/repositories/:repository/packages/:package_name/:package_version/file", h.needsAuth(h.getPackageFile))
 	h.GET("/pack/v1/repositories/:repository/packages/:package_name/:package_version/envelope", h.needsAuth(h.getPackageEnvelope))
+	h.GET("/pack/v1/repositories/:repository/packages/:package_name/:package_version/delta", h.needsAuth(h.getPackageDelta))
 	h.POST("/pack/v1/repositories/:repository/packages/:package_name/:package_version", h.needsAuth(h.updatePackageLabels))
 	h.DELETE("/pack/v1/repositories/:repository/packages/:package_name/:package_version", h.needsAuth(h.deletePackage))
+	h.GET("/pack/v1/repositories/:repository/watch", h.needsAuth(h.watchPackages))
+	h.GET("/pack/v1/watch", h.needsAuth(h.watchPackages))
+	h.POST("/pack/v1/prune", h.needsAuth(h.prunePackages))
+	h.GET("/pack/v1/repositories/:repository/aliases", h.needsAuth(h.getPackageAliases))
+	h.POST("/pack/v1/repositories/:repository/aliases/:package_name/:alias", h.needsAuth(h.upsertPackageAlias))
+	h.DELETE("/pack/v1/repositories/:repository/aliases/:package_name/:alias", h.needsAuth(h.deletePackageAlias))
 
 	return h, nil
 }
@@ -109,6 +140,15 @@ func (s *Server) getRepository(w http.ResponseWriter, r *http.Request, p httprou
 	return nil
 }
 
+func (s *Server) getRepositoryUsage(w http.ResponseWriter, r *http.Request, p httprouter.Params, service pack.PackageService) error {
+	usage, err := pack.GetRepositoryUsage(service, p.ByName("repository"), s.cfg.Quotas)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, usage)
+	return nil
+}
+
 func (s *Server) getRepositories(w http.ResponseWriter, r *http.Request, p httprouter.Params, service pack.PackageService) error {
 	repositories, err := service.GetRepositories()
 	if err != nil {
@@ -126,7 +166,7 @@ func (s *Server) deleteRepository(w http.ResponseWriter, r *http.Request, p http
 }
 
 func (s *Server) deletePackage(w http.ResponseWriter, r *http.Request, p httprouter.Params, service pack.PackageService) error {
-	loc, err := loc.NewLocator(p.ByName("repository"), p.ByName("package_name"), p.ByName("package_version"))
+	loc, err := parsePackageLocator(p)
 	if err != nil {
 		return trace.BadParameter(err.Error())
 	}
@@ -136,12 +176,54 @@ func (s *Server) deletePackage(w http.ResponseWriter, r *http.Request, p httprou
 	return nil
 }
 
+// parseUploadLocator returns the locator of the uploaded package.
+// Clients send the locator in the locator form field, older clients only
+// send it as the name of the uploaded file, which multipart readers strip
+// of the repository as if it were a directory, so the repository is then
+// taken from the request path.
+// The package must belong to the repository of the request path
+func parseUploadLocator(p httprouter.Params, locator, filename string) (*loc.Locator, error) {
+	repository := p.ByName("repository")
+	if locator == "" {
+		locator = filename
+		if !strings.Contains(locator, "/") {
+			locator = fmt.Sprintf("%v/%v", repository, locator)
+		}
+	}
+	parsed, err := loc.ParseLocator(locator)
+	if err != nil {
+		return nil, trace.BadParameter(err.Error())
+	}
+	if parsed.Repository != repository {
+		return nil, trace.BadParameter("package %v does not belong to repository %q",
+			parsed, repository)
+	}
+	return parsed, nil
+}
+
+// parsePackageLocator returns the locator of the package addressed by the request.
+// The version of a package variant is followed by its architecture, e.g. 1.0.0_arm64
+func parsePackageLocator(p httprouter.Params) (*loc.Locator, error) {
+	return loc.ParseLocator(fmt.Sprintf("%v/%v:%v",
+		p.ByName("repository"), p.ByName("package_name"), p.ByName("package_version")))
+}
+
+// getPackages returns a list of packages in the repository,
+// optionally filtered with the label selector
+//
+// GET /pack/v1/repositories/:repository/packages?selector=<selector>
 func (s *Server) getPackages(w http.ResponseWriter, r *http.Request, p httprouter.Params, service pack.PackageService) error {
 	repoName := p.ByName("repository")
-	log.Infof("getPackages(%v)", repoName)
-
-	packages, err := service.GetPackages(repoName)
+	selector := r.URL.Query().Get("selector")
+	log.Infof("getPackages(%v, %q)", repoName, selector)
 
+	var packages []pack.PackageEnvelope
+	var err error
+	if selector != "" {
+		packages, err = service.GetPackagesBySelector(repoName, selector)
+	} else {
+		packages, err = service.GetPackages(repoName)
+	}
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -151,7 +233,7 @@ func (s *Server) getPackages(w http.ResponseWriter, r *http.Request, p httproute
 }
 
 func (s *Server) getPackageEnvelope(w http.ResponseWriter, r *http.Request, p httprouter.Params, service pack.PackageService) error {
-	loc, err := loc.NewLocator(p.ByName("repository"), p.ByName("package_name"), p.ByName("package_version"))
+	loc, err := parsePackageLocator(p)
 	if err != nil {
 		return trace.BadParameter(err.Error())
 	}
@@ -164,12 +246,12 @@ func (s *Server) getPackageEnvelope(w http.ResponseWriter, r *http.Request, p ht
 }
 
 func (s *Server) getPackageFile(w http.ResponseWriter, r *http.Request, p httprouter.Params, service pack.PackageService) error {
-	loc, err := loc.NewLocator(p.ByName("repository"), p.ByName("package_name"), p.ByName("package_version"))
+	loc, err := parsePackageLocator(p)
 	if err != nil {
 		return trace.BadParameter(err.Error())
 	}
 
-	_, fileObject, err := service.ReadPackage(*loc)
+	envelope, fileObject, err := service.ReadPackage(*loc)
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -180,10 +262,43 @@ func (s *Server) getPackageFile(w http.ResponseWriter, r *http.Request, p httpro
 		return trace.BadParameter("expected read seeker object")
 	}
 	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename=%v`, loc.String()))
+	if envelope.SHA256 != "" {
+		// the entity tag allows clients to resume interrupted downloads
+		// with conditional range requests
+		w.Header().Set("ETag", packageETag(*envelope))
+	}
 	http.ServeContent(w, r, loc.String(), time.Now(), readSeeker)
 	return nil
 }
 
+// GET /pack/v1/repositories/:repository/packages/:package_name/:package_version/delta?base=<version>&base_sha256=<digest>
+func (s *Server) getPackageDelta(w http.ResponseWriter, r *http.Request, p httprouter.Params, service pack.PackageService) error {
+	query := r.URL.Query()
+	base, err := loc.NewLocator(p.ByName("repository"), p.ByName("package_name"), query.Get("base"))
+	if err != nil {
+		return trace.BadParameter("%v", err)
+	}
+	loc, err := parsePackageLocator(p)
+	if err != nil {
+		return trace.BadParameter("%v", err)
+	}
+	// the delta is computed against the same variant of the package
+	*base = base.WithArch(loc.Arch)
+	_, delta, err := pack.ReadPackageDelta(service, *loc, *base, query.Get("base_sha256"))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer delta.Close()
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, delta); err != nil {
+		// the delta is incomplete without the end marker
+		// so the client will fail to apply it
+		log.Warnf("Failed to send delta of package %v: %v.", loc, err)
+	}
+	return nil
+}
+
 func (s *Server) createPackage(w http.ResponseWriter, r *http.Request, p httprouter.Params, service pack.PackageService) error {
 	var files form.Files
 	var labelsMap string
@@ -191,9 +306,11 @@ func (s *Server) createPackage(w http.ResponseWriter, r *http.Request, p httprou
 	var hiddenS string
 	var packageType string
 	var manifest string
+	var locator string
 
 	err := form.Parse(r,
 		form.FileSlice("package", &files),
+		form.String("locator", &locator),
 		form.String("labels", &labelsMap),
 		form.String("upsert", &upsertS),
 		form.String("hidden", &hiddenS),
@@ -236,9 +353,9 @@ func (s *Server) createPackage(w http.ResponseWriter, r *http.Request, p httprou
 		}
 	}()
 
-	loc, err := loc.ParseLocator(files[0].Name())
+	loc, err := parseUploadLocator(p, locator, files[0].Name())
 	if err != nil {
-		return trace.BadParameter(err.Error())
+		return trace.Wrap(err)
 	}
 
 	// configure package attributes
@@ -262,7 +379,7 @@ func (s *Server) createPackage(w http.ResponseWriter, r *http.Request, p httprou
 }
 
 func (s *Server) updatePackageLabels(w http.ResponseWriter, r *http.Request, p httprouter.Params, service pack.PackageService) error {
-	loc, err := loc.NewLocator(p.ByName("repository"), p.ByName("package_name"), p.ByName("package_version"))
+	loc, err := parsePackageLocator(p)
 	if err != nil {
 		return trace.BadParameter(err.Error())
 	}
@@ -283,67 +400,134 @@ func (s *Server) updatePackageLabels(w http.ResponseWriter, r *http.Request, p h
 	return nil
 }
 
+func (s *Server) getPackageAliases(w http.ResponseWriter, r *http.Request, p httprouter.Params, service pack.PackageService) error {
+	aliases, err := service.GetPackageAliases(p.ByName("repository"))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, aliases)
+	return nil
+}
+
+func (s *Server) upsertPackageAlias(w http.ResponseWriter, r *http.Request, p httprouter.Params, service pack.PackageService) error {
+	alias, err := loc.NewLocator(p.ByName("repository"), p.ByName("package_name"), p.ByName("alias"))
+	if err != nil {
+		return trace.BadParameter("%v", err)
+	}
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	var req aliasTarget
+	if err := json.Unmarshal(data, &req); err != nil {
+		return trace.BadParameter("%v", err)
+	}
+	target, err := loc.NewLocator(alias.Repository, alias.Name, req.Version)
+	if err != nil {
+		return trace.BadParameter("%v", err)
+	}
+	if err := service.UpsertPackageAlias(*alias, *target); err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, map[string]string{"status": "ok", "message": "alias updated"})
+	return nil
+}
+
+func (s *Server) deletePackageAlias(w http.ResponseWriter, r *http.Request, p httprouter.Params, service pack.PackageService) error {
+	alias, err := loc.NewLocator(p.ByName("repository"), p.ByName("package_name"), p.ByName("alias"))
+	if err != nil {
+		return trace.BadParameter("%v", err)
+	}
+	if err := service.DeletePackageAlias(*alias); err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, map[string]string{"status": "ok", "message": "alias deleted"})
+	return nil
+}
+
+// watchPackages streams package events as a sequence of JSON objects.
+// Events about all repositories are streamed if the repository is omitted
+//
+// GET /pack/v1/repositories/:repository/watch
+// GET /pack/v1/watch
+func (s *Server) watchPackages(w http.ResponseWriter, r *http.Request, p httprouter.Params, service pack.PackageService) error {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return trace.BadParameter("streaming is not supported")
+	}
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	eventsC, err := service.WatchPackages(ctx, p.ByName("repository"))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	ticker := time.NewTicker(defaults.PackageWatchKeepAlive)
+	defer ticker.Stop()
+	encoder := json.NewEncoder(w)
+	for {
+		select {
+		case event, ok := <-eventsC:
+			if !ok {
+				return nil
+			}
+			if err := encoder.Encode(event); err != nil {
+				log.Debugf("Failed to send package event: %v.", err)
+				return nil
+			}
+		case <-ticker.C:
+			// whitespace is skipped by the decoder and keeps the connection alive
+			if _, err := w.Write([]byte("\n")); err != nil {
+				return nil
+			}
+		case <-ctx.Done():
+			return nil
+		}
+		flusher.Flush()
+	}
+}
+
+// prunePackages deletes packages that are not in use
+//
+// POST /pack/v1/prune
+func (s *Server) prunePackages(w http.ResponseWriter, r *http.Request, p httprouter.Params, service pack.PackageService) error {
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	var req pack.PruneRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return trace.BadParameter("%v", err)
+	}
+	resp, err := service.PrunePackages(req)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, resp)
+	return nil
+}
+
 func (s *Server) needsAuth(fn authHandle) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
 		log.WithFields(log.Fields{
 			"method": r.Method,
 		}).Debugf(r.URL.Path)
 
-		authCreds, err := httplib.ParseAuthHeaders(r)
+		user, checker, err := s.authenticate(w, r)
 		if err != nil {
 			trace.WriteError(w, err)
 			return
 		}
-		cookie, err := r.Cookie("session")
-		hasCookie := err == nil && cookie != nil && cookie.Value != ""
-		var user storage.User
-		var checker teleservices.AccessChecker
-		if !hasCookie {
-			user, checker, err = s.cfg.Users.AuthenticateUser(*authCreds)
-			if err != nil {
-				log.Debugf("authenticate error: %v", err)
-				// we hide the error from the remote user to avoid giving any hints
-				trace.WriteError(
-					w, trace.AccessDenied("bad username or password"))
-				return
-			}
-		} else {
-			if s.cfg.Authenticator == nil {
-				log.Debugf("web sessions are not supported: %v", err)
-				// we hide the error from the remote user to avoid giving any hints
-				trace.WriteError(
-					w, trace.AccessDenied("web sessions are not supported"))
-				return
-			}
-			session, err := s.cfg.Authenticator(w, r, true)
-			if err != nil {
-				log.Debugf("authenticate error: %v", err)
-				// we hide the error from the remote user to avoid giving any hints
-				trace.WriteError(
-					w, trace.AccessDenied("bad username or password"))
-				return
-			}
-			user, err := s.cfg.Users.GetTelekubeUser(session.GetUser())
-			if err != nil {
-				log.Debugf("authenticate error: %v", err)
-				// we hide the error from the remote user to avoid giving any hints
-				trace.WriteError(
-					w, trace.AccessDenied("bad username or password"))
-				return
-			}
-			checker, err = s.cfg.Users.GetAccessChecker(user)
-			if err != nil {
-				log.Errorf("failed to fetch roles %v", trace.DebugReport(err))
-				trace.WriteError(
-					w, trace.BadParameter("internal server error"))
-				return
-			}
-		}
 
 		// create a ACL aware wrapper packages service
 		// and pass it to the handlers, so every action will be automatically
 		// checked against current user
 		service := pack.PackagesWithACL(s.cfg.Packages, s.cfg.Users, user, checker)
+		// further restrict the service to the repositories the user
+		// is allowed to access, if configured
+		service = pack.PackagesWithRepositoryRules(service, user.GetName(), s.cfg.Rules)
 		if err := fn(w, r, p, service); err != nil {
 			if trace.IsAccessDenied(err) {
 				log.Debugf("access denied: %v", err)
@@ -355,6 +539,22 @@ func (s *Server) needsAuth(fn authHandle) httprouter.Handle {
 	}
 }
 
+// authenticate authenticates the request with the first authenticator
+// that supports the credentials the request carries
+func (s *Server) authenticate(w http.ResponseWriter, r *http.Request) (storage.User, teleservices.AccessChecker, error) {
+	for _, authenticator := range s.authenticators {
+		user, checker, err := authenticator.Authenticate(w, r)
+		if err != nil {
+			if trace.IsNotFound(err) {
+				continue
+			}
+			return nil, nil, trace.Wrap(err)
+		}
+		return user, checker, nil
+	}
+	return nil, nil, trace.AccessDenied("unauthorized")
+}
+
 type authHandle func(
 	http.ResponseWriter, *http.Request, httprouter.Params, pack.PackageService) error
 
@@ -364,6 +564,12 @@ type authContext struct {
 	SiteID    string
 }
 
+// aliasTarget is the request to update a package alias
+type aliasTarget struct {
+	// Version is the version of the package the alias should point to
+	Version string `json:"version"`
+}
+
 type labels struct {
 	AddLabels    map[string]string `json:"add_labels"`
 	RemoveLabels []string          `json:"remove_labels"`
@@ -19,8 +19,13 @@ package webpack
 import (
 	"bytes"
 	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
 	"sort"
@@ -29,17 +34,22 @@ import (
 
 	"github.com/gravitational/gravity/lib/blob/fs"
 	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/httplib"
 	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/pack"
 	"github.com/gravitational/gravity/lib/pack/localpack"
 	"github.com/gravitational/gravity/lib/pack/suite"
 	"github.com/gravitational/gravity/lib/storage"
 	"github.com/gravitational/gravity/lib/storage/keyval"
 	"github.com/gravitational/gravity/lib/users"
 	"github.com/gravitational/gravity/lib/users/usersservice"
+	"github.com/gravitational/gravity/lib/utils"
 
 	"github.com/gravitational/roundtrip"
 	teleservices "github.com/gravitational/teleport/lib/services"
+	"github.com/gravitational/teleport/lib/tlsca"
 	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
 	"github.com/mailgun/timetools"
 	log "github.com/sirupsen/logrus"
 	. "gopkg.in/check.v1"
@@ -50,6 +60,7 @@ func TestWebpack(t *testing.T) { TestingT(t) }
 type WebpackSuite struct {
 	server    *Server
 	backend   storage.Backend
+	packages  pack.PackageService
 	suite     suite.PackageSuite
 	webServer *httptest.Server
 	users     users.Identity
@@ -105,6 +116,7 @@ func (s *WebpackSuite) SetUpTest(c *C) {
 		Objects:     objects,
 	})
 	c.Assert(err, IsNil)
+	s.packages = service
 	webHandler, err := NewHandler(Config{
 		Users:    s.users,
 		Packages: service,
@@ -293,3 +305,385 @@ func (s *WebpackSuite) TestUpsertPackages(c *C) {
 func (s *WebpackSuite) TestDeleteRepository(c *C) {
 	s.suite.DeleteRepository(c)
 }
+
+func (s *WebpackSuite) TestPackageAliases(c *C) {
+	s.suite.PackageAliases(c)
+}
+
+func (s *WebpackSuite) TestLayeredConfig(c *C) {
+	s.suite.LayeredConfig(c)
+}
+
+func (s *WebpackSuite) TestConfigurePackage(c *C) {
+	s.suite.ConfigurePackage(c)
+}
+
+func (s *WebpackSuite) TestTransactions(c *C) {
+	s.suite.Transactions(c)
+}
+
+func (s *WebpackSuite) TestWatchPackages(c *C) {
+	s.suite.WatchPackages(c)
+}
+
+func (s *WebpackSuite) TestWatchAllPackages(c *C) {
+	s.suite.WatchAllPackages(c)
+}
+
+func (s *WebpackSuite) TestPrunePackages(c *C) {
+	s.suite.PrunePackages(c)
+}
+
+func (s *WebpackSuite) TestPinPackages(c *C) {
+	s.suite.PinPackages(c)
+}
+
+func (s *WebpackSuite) TestGetPackagesBySelector(c *C) {
+	s.suite.GetPackagesBySelector(c)
+}
+
+func (s *WebpackSuite) TestUploadsPackageWithLocator(c *C) {
+	client := s.suite.S.(*Client)
+	c.Assert(client.UpsertRepository("example.com", time.Time{}), IsNil)
+
+	variant := loc.MustParseLocator("example.com/app:1.0.0_arm64")
+	envelope, err := client.CreatePackage(variant, bytes.NewBufferString("arm64"))
+	c.Assert(err, IsNil)
+	c.Assert(envelope.Locator, Equals, variant)
+	envelope, err = s.packages.ReadPackageEnvelope(variant)
+	c.Assert(err, IsNil)
+	c.Assert(envelope.Locator, Equals, variant)
+
+	upload := func(values url.Values, filename string) error {
+		values.Set("labels", "{}")
+		_, err := client.PostForm(client.Endpoint("repositories", "example.com", "packages"), values,
+			roundtrip.File{Name: "package", Filename: filename, Reader: bytes.NewBufferString("data")})
+		return err
+	}
+
+	// older clients only send the locator as the file name
+	c.Assert(upload(url.Values{}, "example.com/legacy:1.0.0"), IsNil)
+	_, err = s.packages.ReadPackageEnvelope(loc.MustParseLocator("example.com/legacy:1.0.0"))
+	c.Assert(err, IsNil)
+
+	// packages can only be uploaded to their own repository
+	err = upload(url.Values{"locator": []string{"other.example.com/app:1.0.0"}}, "app:1.0.0")
+	c.Assert(trace.IsBadParameter(err), Equals, true, Commentf("%v", err))
+}
+
+func (s *WebpackSuite) TestReadPackageDelta(c *C) {
+	base := bytes.Repeat([]byte("base package data "), 10000)
+	target := append(append([]byte{}, base...), []byte("patch")...)
+	c.Assert(s.packages.UpsertRepository("example.com", time.Time{}), IsNil)
+	_, err := s.packages.CreatePackage(loc.MustParseLocator("example.com/app:1.0.0"), bytes.NewReader(base))
+	c.Assert(err, IsNil)
+	_, err = s.packages.CreatePackage(loc.MustParseLocator("example.com/app:1.0.1"), bytes.NewReader(target))
+	c.Assert(err, IsNil)
+
+	dir := c.MkDir()
+	backend, err := keyval.NewBolt(keyval.BoltConfig{Path: filepath.Join(dir, "bolt.db")})
+	c.Assert(err, IsNil)
+	defer backend.Close()
+	objects, err := fs.New(dir)
+	c.Assert(err, IsNil)
+	dst, err := localpack.New(localpack.Config{
+		Backend:     backend,
+		UnpackedDir: filepath.Join(dir, defaults.UnpackedDir),
+		Objects:     objects,
+	})
+	c.Assert(err, IsNil)
+	c.Assert(dst.UpsertRepository("example.com", time.Time{}), IsNil)
+	_, err = dst.CreatePackage(loc.MustParseLocator("example.com/app:1.0.0"), bytes.NewReader(base))
+	c.Assert(err, IsNil)
+
+	client := s.suite.S.(*Client)
+	env, reader, err := pack.ReadPackageWithDelta(client, dst,
+		loc.MustParseLocator("example.com/app:1.0.1"), loc.MustParseLocator("example.com/app:1.0.0"))
+	c.Assert(err, IsNil)
+	defer reader.Close()
+	c.Assert(env.Locator, Equals, loc.MustParseLocator("example.com/app:1.0.1"))
+	data, err := ioutil.ReadAll(reader)
+	c.Assert(err, IsNil)
+	c.Assert(bytes.Equal(data, target), Equals, true)
+
+	// the base digest must match the data on the server
+	_, _, err = client.ReadPackageDelta(loc.MustParseLocator("example.com/app:1.0.1"),
+		loc.MustParseLocator("example.com/app:1.0.0"), "invalid")
+	c.Assert(trace.IsCompareFailed(err), Equals, true, Commentf("%v", err))
+}
+
+func (s *WebpackSuite) TestReadPackageWithRateLimit(c *C) {
+	data := bytes.Repeat([]byte("rate limited data "), 2000)
+	c.Assert(s.packages.UpsertRepository("example.com", time.Time{}), IsNil)
+	locator := loc.MustParseLocator("example.com/app:1.0.0")
+	_, err := s.packages.CreatePackage(locator, bytes.NewReader(data))
+	c.Assert(err, IsNil)
+
+	client := s.suite.S.(*Client).WithRateLimit(utils.TransferRate(64 * 1024))
+	start := time.Now()
+	_, reader, err := client.ReadPackage(locator)
+	c.Assert(err, IsNil)
+	defer reader.Close()
+	read, err := ioutil.ReadAll(reader)
+	c.Assert(err, IsNil)
+	c.Assert(bytes.Equal(read, data), Equals, true)
+	// 36000 bytes at 64KB/s with the initial burst of 6.4KB
+	c.Assert(time.Since(start) > 400*time.Millisecond, Equals, true,
+		Commentf("read took %v", time.Since(start)))
+}
+
+func (s *WebpackSuite) TestTransferWithClientCert(c *C) {
+	ca, caPEM := newTestCA(c)
+	cert := newClientCert(c, ca, s.adminUser.GetName())
+	server := s.newClientCertServer(c, caPEM, nil)
+	defer server.Close()
+	serverCA := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	// the server can not be verified without the CA certificate
+	_, err := NewClientWithCert(server.URL, cert, nil)
+	c.Assert(trace.IsBadParameter(err), Equals, true, Commentf("%v", err))
+
+	client, err := NewClientWithCert(server.URL, cert, serverCA)
+	c.Assert(err, IsNil)
+
+	// push a package authenticating with the client certificate only
+	pushed := loc.MustParseLocator("example.com/pushed:1.0.0")
+	c.Assert(client.UpsertRepository("example.com", time.Time{}), IsNil)
+	_, err = client.CreatePackage(pushed, bytes.NewReader([]byte("pushed")))
+	c.Assert(err, IsNil)
+	_, reader, err := s.packages.ReadPackage(pushed)
+	c.Assert(err, IsNil)
+	data, err := ioutil.ReadAll(reader)
+	reader.Close()
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "pushed")
+
+	// pull a package authenticating with the client certificate only
+	pulled := loc.MustParseLocator("example.com/pulled:1.0.0")
+	_, err = s.packages.CreatePackage(pulled, bytes.NewReader([]byte("pulled")))
+	c.Assert(err, IsNil)
+	_, reader, err = client.ReadPackage(pulled)
+	c.Assert(err, IsNil)
+	data, err = ioutil.ReadAll(reader)
+	reader.Close()
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "pulled")
+
+	// requests without the client certificate are rejected
+	anonymous, err := NewClient(server.URL, roundtrip.HTTPClient(
+		httplib.GetClient(false, httplib.WithCA(serverCA))))
+	c.Assert(err, IsNil)
+	_, _, err = anonymous.ReadPackage(pulled)
+	c.Assert(trace.IsAccessDenied(err), Equals, true, Commentf("%v", err))
+}
+
+func (s *WebpackSuite) TestClientCertAuthenticator(c *C) {
+	ca, _ := newTestCA(c)
+	adminCert := newClientCert(c, ca, s.adminUser.GetName())
+	unknownCert := newClientCert(c, ca, "unknown@example.com")
+	authenticator := NewClientCertAuthenticator(s.users)
+
+	// requests without TLS are left to the other authenticators
+	r := httptest.NewRequest(http.MethodGet, "/pack/v1/repositories", nil)
+	_, _, err := authenticator.Authenticate(httptest.NewRecorder(), r)
+	c.Assert(trace.IsNotFound(err), Equals, true, Commentf("%v", err))
+
+	// so are requests with a certificate that has not been verified
+	r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{adminCert.Leaf}}
+	_, _, err = authenticator.Authenticate(httptest.NewRecorder(), r)
+	c.Assert(trace.IsNotFound(err), Equals, true, Commentf("%v", err))
+
+	r.TLS = &tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{adminCert.Leaf},
+		VerifiedChains:   [][]*x509.Certificate{{adminCert.Leaf}},
+	}
+	user, checker, err := authenticator.Authenticate(httptest.NewRecorder(), r)
+	c.Assert(err, IsNil)
+	c.Assert(user.GetName(), Equals, s.adminUser.GetName())
+	c.Assert(checker, NotNil)
+
+	// verified certificates of unknown users are rejected
+	r.TLS = &tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{unknownCert.Leaf},
+		VerifiedChains:   [][]*x509.Certificate{{unknownCert.Leaf}},
+	}
+	_, _, err = authenticator.Authenticate(httptest.NewRecorder(), r)
+	c.Assert(trace.IsAccessDenied(err), Equals, true, Commentf("%v", err))
+}
+
+func (s *WebpackSuite) TestAuthenticatorsChainOrder(c *C) {
+	var calls []string
+	authenticator := func(name string, user storage.User, err error) RequestAuthenticator {
+		return authenticatorFunc(func(http.ResponseWriter, *http.Request) (storage.User, teleservices.AccessChecker, error) {
+			calls = append(calls, name)
+			return user, nil, err
+		})
+	}
+	handler, err := NewHandler(Config{
+		Users:    s.users,
+		Packages: s.packages,
+		Authenticators: []RequestAuthenticator{
+			authenticator("first", nil, trace.NotFound("no credentials")),
+			authenticator("second", s.adminUser, nil),
+			authenticator("third", nil, trace.AccessDenied("should not be called")),
+		},
+	})
+	c.Assert(err, IsNil)
+
+	r := httptest.NewRequest(http.MethodGet, "/pack/v1/repositories", nil)
+	user, _, err := handler.authenticate(httptest.NewRecorder(), r)
+	c.Assert(err, IsNil)
+	c.Assert(user.GetName(), Equals, s.adminUser.GetName())
+	c.Assert(calls, DeepEquals, []string{"first", "second"})
+
+	// an authenticator that rejects the credentials stops the chain
+	calls = nil
+	handler, err = NewHandler(Config{
+		Users:    s.users,
+		Packages: s.packages,
+		Authenticators: []RequestAuthenticator{
+			authenticator("first", nil, trace.AccessDenied("bad credentials")),
+			authenticator("second", s.adminUser, nil),
+		},
+	})
+	c.Assert(err, IsNil)
+	_, _, err = handler.authenticate(httptest.NewRecorder(), r)
+	c.Assert(trace.IsAccessDenied(err), Equals, true, Commentf("%v", err))
+	c.Assert(calls, DeepEquals, []string{"first"})
+
+	// requests without credentials that any authenticator supports are rejected
+	calls = nil
+	handler, err = NewHandler(Config{
+		Users:    s.users,
+		Packages: s.packages,
+		Authenticators: []RequestAuthenticator{
+			authenticator("first", nil, trace.NotFound("no credentials")),
+		},
+	})
+	c.Assert(err, IsNil)
+	_, _, err = handler.authenticate(httptest.NewRecorder(), r)
+	c.Assert(trace.IsAccessDenied(err), Equals, true, Commentf("%v", err))
+	c.Assert(calls, DeepEquals, []string{"first"})
+}
+
+func (s *WebpackSuite) TestClientCertWithRepositoryRules(c *C) {
+	ciUser := storage.NewUser("ci@example.com", storage.UserSpecV2{
+		Password: "ci-password",
+		Type:     storage.AdminUser,
+		Roles:    s.adminUser.GetRoles(),
+	})
+	c.Assert(s.users.UpsertUser(ciUser), IsNil)
+
+	ca, caPEM := newTestCA(c)
+	server := s.newClientCertServer(c, caPEM, pack.RepositoryRules{{
+		Users:        []string{ciUser.GetName()},
+		Repositories: []string{"example.com"},
+		Verbs:        []string{teleservices.VerbList, teleservices.VerbRead},
+	}})
+	defer server.Close()
+	serverCA := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	c.Assert(s.packages.UpsertRepository("example.com", time.Time{}), IsNil)
+	c.Assert(s.packages.UpsertRepository("other.example.com", time.Time{}), IsNil)
+	allowed := loc.MustParseLocator("example.com/allowed:1.0.0")
+	_, err := s.packages.CreatePackage(allowed, bytes.NewReader([]byte("allowed")))
+	c.Assert(err, IsNil)
+	denied := loc.MustParseLocator("other.example.com/denied:1.0.0")
+	_, err = s.packages.CreatePackage(denied, bytes.NewReader([]byte("denied")))
+	c.Assert(err, IsNil)
+
+	// the verified certificate authenticates the user the rules apply to
+	client, err := NewClientWithCert(server.URL, newClientCert(c, ca, ciUser.GetName()), serverCA)
+	c.Assert(err, IsNil)
+	_, err = client.ReadPackageEnvelope(allowed)
+	c.Assert(err, IsNil)
+	_, err = client.ReadPackageEnvelope(denied)
+	c.Assert(trace.IsAccessDenied(err), Equals, true, Commentf("%v", err))
+
+	// requests without a certificate fall through to basic auth
+	admin, err := NewAuthenticatedClient(server.URL, s.adminUser.GetName(), "admin-password",
+		roundtrip.HTTPClient(httplib.GetClient(false, httplib.WithCA(serverCA))))
+	c.Assert(err, IsNil)
+	_, err = admin.ReadPackageEnvelope(denied)
+	c.Assert(err, IsNil)
+
+	// and bearer tokens
+	key, err := s.users.CreateAPIKey(storage.APIKey{UserEmail: s.adminUser.GetName()}, false)
+	c.Assert(err, IsNil)
+	bearer, err := NewBearerClient(server.URL, key.Token,
+		roundtrip.HTTPClient(httplib.GetClient(false, httplib.WithCA(serverCA))))
+	c.Assert(err, IsNil)
+	_, err = bearer.ReadPackageEnvelope(denied)
+	c.Assert(err, IsNil)
+
+	// the client certificate takes precedence over other credentials
+	both, err := NewClientWithCert(server.URL, newClientCert(c, ca, ciUser.GetName()), serverCA,
+		roundtrip.BasicAuth(s.adminUser.GetName(), "admin-password"))
+	c.Assert(err, IsNil)
+	_, err = both.ReadPackageEnvelope(denied)
+	c.Assert(trace.IsAccessDenied(err), Equals, true, Commentf("%v", err))
+
+	// certificates issued by an unknown authority are not accepted
+	otherCA, _ := newTestCA(c)
+	untrusted, err := NewClientWithCert(server.URL, newClientCert(c, otherCA, s.adminUser.GetName()), serverCA)
+	c.Assert(err, IsNil)
+	_, err = untrusted.ReadPackageEnvelope(allowed)
+	c.Assert(err, NotNil)
+}
+
+// newClientCertServer starts the package service that authenticates
+// requests with client certificates issued by the specified CA
+func (s *WebpackSuite) newClientCertServer(c *C, caPEM []byte, rules pack.RepositoryRules) *httptest.Server {
+	handler, err := NewHandler(Config{
+		Users:          s.users,
+		Packages:       s.packages,
+		Authenticators: []RequestAuthenticator{NewClientCertAuthenticator(s.users)},
+		Rules:          rules,
+	})
+	c.Assert(err, IsNil)
+	clientCAs := x509.NewCertPool()
+	c.Assert(clientCAs.AppendCertsFromPEM(caPEM), Equals, true)
+	server := httptest.NewUnstartedServer(handler)
+	server.TLS = &tls.Config{
+		ClientCAs:  clientCAs,
+		ClientAuth: tls.VerifyClientCertIfGiven,
+	}
+	server.StartTLS()
+	return server
+}
+
+func newTestCA(c *C) (*tlsca.CertAuthority, []byte) {
+	caKey, caCert, err := tlsca.GenerateSelfSignedCA(pkix.Name{CommonName: "client-ca"}, nil, time.Hour)
+	c.Assert(err, IsNil)
+	ca, err := tlsca.New(caCert, caKey)
+	c.Assert(err, IsNil)
+	return ca, caCert
+}
+
+// newClientCert returns the client certificate for the specified user
+// issued by the given CA
+func newClientCert(c *C, ca *tlsca.CertAuthority, user string) tls.Certificate {
+	keyPEM, err := tlsca.GenerateRSAPrivateKeyPEM()
+	c.Assert(err, IsNil)
+	signer, err := tlsca.ParsePrivateKeyPEM(keyPEM)
+	c.Assert(err, IsNil)
+	certPEM, err := ca.GenerateCertificate(tlsca.CertificateRequest{
+		Clock:     clockwork.NewRealClock(),
+		PublicKey: signer.Public(),
+		Subject:   pkix.Name{CommonName: user},
+		NotAfter:  time.Now().Add(time.Hour),
+	})
+	c.Assert(err, IsNil)
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	c.Assert(err, IsNil)
+	cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0])
+	c.Assert(err, IsNil)
+	return cert
+}
+
+type authenticatorFunc func(http.ResponseWriter, *http.Request) (storage.User, teleservices.AccessChecker, error)
+
+// Authenticate invokes the function to authenticate the request
+func (f authenticatorFunc) Authenticate(w http.ResponseWriter, r *http.Request) (storage.User, teleservices.AccessChecker, error) {
+	return f(w, r)
+}
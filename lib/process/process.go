@@ -19,6 +19,7 @@ package process
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"io/ioutil"
 	"net"
@@ -32,44 +33,63 @@ import (
 	"time"
 
 	"github.com/gravitational/gravity/lib/app"
+	"github.com/gravitational/gravity/lib/app/analysis"
+	appclient "github.com/gravitational/gravity/lib/app/client"
 	apphandler "github.com/gravitational/gravity/lib/app/handler"
 	appservice "github.com/gravitational/gravity/lib/app/service"
+	"github.com/gravitational/gravity/lib/appoverlay"
 	"github.com/gravitational/gravity/lib/autoscale/aws"
 	"github.com/gravitational/gravity/lib/blob"
 	blobclient "github.com/gravitational/gravity/lib/blob/client"
 	blobcluster "github.com/gravitational/gravity/lib/blob/cluster"
 	blobfs "github.com/gravitational/gravity/lib/blob/fs"
 	blobhandler "github.com/gravitational/gravity/lib/blob/handler"
+	blobs3 "github.com/gravitational/gravity/lib/blob/s3"
+	"github.com/gravitational/gravity/lib/catalog/mirror"
 	"github.com/gravitational/gravity/lib/clients"
 	cloudaws "github.com/gravitational/gravity/lib/cloudprovider/aws"
 	"github.com/gravitational/gravity/lib/constants"
 	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/dnsprovider"
 	"github.com/gravitational/gravity/lib/docker"
 	"github.com/gravitational/gravity/lib/helm"
 	"github.com/gravitational/gravity/lib/httplib"
+	"github.com/gravitational/gravity/lib/installers"
+	"github.com/gravitational/gravity/lib/ingress"
+	"github.com/gravitational/gravity/lib/kubelet"
 	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/logrotation"
 	"github.com/gravitational/gravity/lib/modules"
 	"github.com/gravitational/gravity/lib/ops"
 	"github.com/gravitational/gravity/lib/ops/monitoring"
 	"github.com/gravitational/gravity/lib/ops/opshandler"
 	"github.com/gravitational/gravity/lib/ops/opsroute"
 	"github.com/gravitational/gravity/lib/ops/opsservice"
+	"github.com/gravitational/gravity/lib/ops/reaper"
 	"github.com/gravitational/gravity/lib/pack"
 	"github.com/gravitational/gravity/lib/pack/layerpack"
 	"github.com/gravitational/gravity/lib/pack/localpack"
+	"github.com/gravitational/gravity/lib/pack/retention"
 	"github.com/gravitational/gravity/lib/pack/webpack"
 	"github.com/gravitational/gravity/lib/processconfig"
+	"github.com/gravitational/gravity/lib/replace"
 	"github.com/gravitational/gravity/lib/rpc"
 	pb "github.com/gravitational/gravity/lib/rpc/proto"
 	rpcserver "github.com/gravitational/gravity/lib/rpc/server"
 	"github.com/gravitational/gravity/lib/schema"
+	"github.com/gravitational/gravity/lib/secretbackend"
+	"github.com/gravitational/gravity/lib/snapshots"
+	"github.com/gravitational/gravity/lib/status"
 	"github.com/gravitational/gravity/lib/storage"
 	"github.com/gravitational/gravity/lib/storage/keyval"
+	"github.com/gravitational/gravity/lib/timesync"
+	"github.com/gravitational/gravity/lib/trustedca"
 	"github.com/gravitational/gravity/lib/users"
 	"github.com/gravitational/gravity/lib/users/usersservice"
 	"github.com/gravitational/gravity/lib/utils"
 	web "github.com/gravitational/gravity/lib/webapi"
 	"github.com/gravitational/gravity/lib/webapi/ui"
+	"github.com/gravitational/gravity/lib/webhook"
 
 	telelib "github.com/gravitational/teleport/lib"
 	teleauth "github.com/gravitational/teleport/lib/auth"
@@ -89,6 +109,7 @@ import (
 	"github.com/gravitational/teleport"
 	"github.com/gravitational/trace"
 	"github.com/julienschmidt/httprouter"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/version"
 	"k8s.io/client-go/kubernetes"
@@ -208,7 +229,13 @@ func New(ctx context.Context, cfg processconfig.Config, tcfg telecfg.FileConfig)
 		return nil, trace.Wrap(err)
 	}
 
-	objects, err := blobfs.New(filepath.Join(cfg.DataDir, defaults.PackagesDir))
+	var objects blob.Objects
+	if cfg.Pack.S3 != nil {
+		objects, err = blobs3.New(*cfg.Pack.S3,
+			filepath.Join(cfg.DataDir, defaults.PackagesDir, "tmp"))
+	} else {
+		objects, err = blobfs.New(filepath.Join(cfg.DataDir, defaults.PackagesDir))
+	}
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -243,11 +270,24 @@ func New(ctx context.Context, cfg processconfig.Config, tcfg telecfg.FileConfig)
 		return nil, trace.Wrap(err)
 	}
 
+	verifier, err := cfg.Pack.Verification.NewVerifier()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	// BLOBs in the object storage are shared by all instances
+	// and do not need to be replicated between them
+	packageObjects := clusterObjects
+	if cfg.Pack.S3 != nil {
+		packageObjects = objects
+	}
+
 	packages, err := localpack.New(localpack.Config{
 		Backend:     backend,
 		DownloadURL: fmt.Sprintf("https://%v", cfg.Pack.GetAddr().Addr),
 		UnpackedDir: filepath.Join(cfg.DataDir, defaults.PackagesDir, defaults.UnpackedDir),
-		Objects:     clusterObjects,
+		Objects:     packageObjects,
+		Verifier:    verifier,
 	})
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -595,26 +635,250 @@ func (p *Process) startSiteStatusChecker(ctx context.Context) error {
 	}
 
 	p.Info("Starting cluster status checker.")
-	ticker := time.NewTicker(defaults.SiteStatusCheckInterval)
+	key := ops.SiteKey{
+		AccountID:  site.AccountID,
+		SiteDomain: site.Domain,
+	}
 	for {
+		// the interval is re-evaluated every time so that changes
+		// to the bandwidth profile take effect without a restart
+		timer := time.NewTimer(p.statusCheckInterval(key))
 		select {
-		case <-ticker.C:
-			key := ops.SiteKey{
-				AccountID:  site.AccountID,
-				SiteDomain: site.Domain,
-			}
+		case <-timer.C:
 			if err := p.operator.CheckSiteStatus(key); err != nil {
 				p.Errorf("Cluster status check failed: %v.",
 					trace.DebugReport(err))
 			}
 		case <-ctx.Done():
 			p.Info("Stopping cluster status checker.")
-			ticker.Stop()
+			timer.Stop()
 			return nil
 		}
 	}
 }
 
+// startNodeQuarantine quarantines the nodes that repeatedly join and
+// leave the cluster so that no operations are started while they are flapping
+func (p *Process) startNodeQuarantine(ctx context.Context) error {
+	quarantine, err := status.NewQuarantine(status.QuarantineConfig{
+		Operator: p.operator,
+		Client:   p.KubeClient(),
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return p.runClusterService(ctx, "node quarantine", quarantine)
+}
+
+// statusCheckInterval returns how often the cluster status is checked
+// according to the cluster bandwidth profile
+func (p *Process) statusCheckInterval(key ops.SiteKey) time.Duration {
+	profile, err := p.operator.GetBandwidthProfile(key)
+	if err != nil {
+		if !trace.IsNotFound(err) {
+			p.Warnf("Failed to query bandwidth profile: %v.", trace.DebugReport(err))
+		}
+		return defaults.SiteStatusCheckInterval
+	}
+	return profile.GetHeartbeatInterval()
+}
+
+// startDNSPublisher publishes cluster endpoints to the configured external
+// DNS providers whenever cluster nodes change
+func (p *Process) startDNSPublisher(ctx context.Context) error {
+	publisher, err := dnsprovider.NewPublisher(dnsprovider.PublisherConfig{
+		Operator: p.operator,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return p.runClusterService(ctx, "DNS publisher", publisher)
+}
+
+// startWebhookNotifier sends cluster lifecycle events to the configured webhooks
+func (p *Process) startWebhookNotifier(ctx context.Context) error {
+	notifier, err := webhook.NewNotifier(webhook.NotifierConfig{
+		Operator: p.operator,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return p.runClusterService(ctx, "webhook notifier", notifier)
+}
+
+// startIngressReconciler keeps the bundled ingress controller in sync
+// with its configuration and applies new versions after cluster updates
+func (p *Process) startIngressReconciler(ctx context.Context) error {
+	reconciler, err := ingress.NewReconciler(ingress.ReconcilerConfig{
+		Operator: p.operator,
+		Apps:     p.applications,
+		Client:   p.KubeClient(),
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return p.runClusterService(ctx, "ingress controller reconciler", reconciler)
+}
+
+// startTimeSyncReconciler keeps chrony configuration on cluster nodes
+// in sync with the time synchronization resource
+func (p *Process) startTimeSyncReconciler(ctx context.Context) error {
+	reconciler, err := timesync.NewReconciler(timesync.ReconcilerConfig{
+		Operator: p.operator,
+		Client:   p.KubeClient(),
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return p.runClusterService(ctx, "time sync reconciler", reconciler)
+}
+
+// startLogRotationReconciler keeps the journal and container log rotation
+// settings on cluster nodes in sync with the log rotation resource
+func (p *Process) startLogRotationReconciler(ctx context.Context) error {
+	reconciler, err := logrotation.NewReconciler(logrotation.ReconcilerConfig{
+		Operator: p.operator,
+		Client:   p.KubeClient(),
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return p.runClusterService(ctx, "log rotation reconciler", reconciler)
+}
+
+// startTrustedCAReconciler keeps the trusted CA bundle installed
+// on cluster nodes in sync with the trusted CA resource
+func (p *Process) startTrustedCAReconciler(ctx context.Context) error {
+	reconciler, err := trustedca.NewReconciler(trustedca.ReconcilerConfig{
+		Operator: p.operator,
+		Client:   p.KubeClient(),
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return p.runClusterService(ctx, "trusted CA reconciler", reconciler)
+}
+
+// startOperationReaper fails the operations abandoned in progress
+// once the cleanup has been enabled with the operation reaper resource
+func (p *Process) startOperationReaper(ctx context.Context) error {
+	operationReaper, err := reaper.New(reaper.Config{
+		Operator: p.operator,
+		Packages: p.packages,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return p.runClusterService(ctx, "operation reaper", operationReaper)
+}
+
+// startPackageRetentionReaper prunes the package versions not kept
+// by the retention policies configured for package repositories
+func (p *Process) startPackageRetentionReaper(ctx context.Context) error {
+	retentionReaper, err := retention.New(retention.Config{
+		Operator: p.operator,
+		Packages: p.packages,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return p.runClusterService(ctx, "package retention reaper", retentionReaper)
+}
+
+// startSecretBackendSyncer writes the secrets sourced from the configured
+// external secret backends to their targets in the cluster
+func (p *Process) startSecretBackendSyncer(ctx context.Context) error {
+	syncer, err := secretbackend.NewSyncer(secretbackend.SyncerConfig{
+		Operator: p.operator,
+		Secrets:  p.KubeClient().CoreV1(),
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return p.runClusterService(ctx, "secret backend syncer", syncer)
+}
+
+// startAppOverlayReconciler keeps the customizations of bundled
+// applications applied across application upgrades
+func (p *Process) startAppOverlayReconciler(ctx context.Context) error {
+	reconciler, err := appoverlay.NewReconciler(appoverlay.ReconcilerConfig{
+		Operator: p.operator,
+		Client:   p.KubeClient(),
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return p.runClusterService(ctx, "application overlay reconciler", reconciler)
+}
+
+// startNodeIdentityRestorer restores labels and taints of replaced
+// nodes on their replacements once they have joined the cluster
+func (p *Process) startNodeIdentityRestorer(ctx context.Context) error {
+	restorer, err := replace.NewRestorer(replace.RestorerConfig{
+		Client: p.KubeClient(),
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return p.runClusterService(ctx, "node identity restorer", restorer)
+}
+
+// startKubeletCSRApprover approves the certificate signing requests
+// kubelets submit to rotate their client and serving certificates
+func (p *Process) startKubeletCSRApprover(ctx context.Context) error {
+	approver, err := kubelet.NewApprover(kubelet.ApproverConfig{
+		Client: p.KubeClient(),
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return p.runClusterService(ctx, "kubelet certificate request approver", approver)
+}
+
+// catalogMirror returns the service that keeps the mirrored repositories
+// in sync with the upstream Ops Center using the writable local services
+func (p *Process) catalogMirror(packages pack.PackageService, apps app.Applications) clusterService {
+	return func(ctx context.Context) error {
+		config := *p.cfg.OpsCenter.Mirror
+		upstreamPackages, err := webpack.NewBearerClient(config.Upstream, config.Token)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		upstreamApps, err := appclient.NewBearerClient(config.Upstream, config.Token)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		syncer, err := mirror.NewSyncer(mirror.SyncerConfig{
+			Config:           config,
+			UpstreamPackages: upstreamPackages,
+			UpstreamApps:     upstreamApps,
+			Packages:         packages,
+			Apps:             apps,
+		})
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		p.Infof("Starting catalog mirror of %v.", config.Upstream)
+		err = syncer.Run(ctx)
+		p.Info("Stopping catalog mirror.")
+		return trace.Wrap(err)
+	}
+}
+
+// startSnapshotController takes scheduled snapshots of application
+// persistent volumes according to the application snapshot policies
+func (p *Process) startSnapshotController(ctx context.Context) error {
+	controller, err := snapshots.NewController(snapshots.ControllerConfig{
+		Operator: p.operator,
+		Apps:     p.applications,
+		Client:   p.KubeClient(),
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return p.runClusterService(ctx, "volume snapshot controller", controller)
+}
+
 // startElection starts leader election process and watches the changes
 func (p *Process) startElection() error {
 	// elect gravity site leader - all other sites will remain
@@ -670,6 +934,22 @@ func (p *Process) onSiteLeader(oldLeaderID string) {
 	p.startClusterServices(p.clusterServices)
 }
 
+// runClusterService runs the service until the context is canceled
+// and logs when the service starts and stops
+func (p *Process) runClusterService(ctx context.Context, name string, service periodicService) error {
+	p.Infof("Starting %v.", name)
+	err := service.Run(ctx)
+	p.Infof("Stopping %v.", name)
+	return trace.Wrap(err)
+}
+
+// periodicService is a cluster service that performs its action
+// periodically until the context is canceled
+type periodicService interface {
+	// Run runs the service until the context is canceled
+	Run(context.Context) error
+}
+
 // clusterService represents a blocking function that performs some cluster-specific
 // periodic action (e.g. runs status hook) and can be stopped by canceling the
 // provided context
@@ -1073,16 +1353,39 @@ func (p *Process) initService(ctx context.Context) (err error) {
 		p.packages = layerpack.New(readPackages, p.packages)
 	}
 
+	if reporter, ok := p.packages.(pack.StatsReporter); ok {
+		err = prometheus.Register(pack.NewStatsCollector(reporter))
+		if err != nil {
+			if _, ok := err.(prometheus.AlreadyRegisteredError); !ok {
+				return trace.Wrap(err)
+			}
+		}
+	}
+
+	// in mirror mode, the mirrored repositories are only updated
+	// by the catalog synchronizer
+	writablePackages := p.packages
+	if p.cfg.OpsCenter.Mirror != nil {
+		p.packages = mirror.NewReadOnlyPackages(p.packages, *p.cfg.OpsCenter.Mirror)
+	}
+
 	seedConfig, err := p.initOpsCenterSeedConfig()
 	if err != nil {
 		return trace.Wrap(err)
 	}
 	p.Debugf("%s.", seedConfig)
 
+	var authenticators []webpack.RequestAuthenticator
+	if p.cfg.Pack.ClientCAFile != "" {
+		authenticators = append(authenticators, webpack.NewClientCertAuthenticator(p.identity))
+	}
 	p.handlers.Packages, err = webpack.NewHandler(webpack.Config{
-		Packages:      p.packages,
-		Users:         p.identity,
-		Authenticator: p.handlers.WebProxy.GetHandler().AuthenticateRequest,
+		Packages:       p.packages,
+		Users:          p.identity,
+		Authenticator:  p.handlers.WebProxy.GetHandler().AuthenticateRequest,
+		Authenticators: authenticators,
+		Rules:          p.cfg.Pack.RepositoryRules,
+		Quotas:         p.cfg.Pack.Quotas,
 	})
 	if err != nil {
 		return trace.Wrap(err)
@@ -1114,7 +1417,15 @@ func (p *Process) initService(ctx context.Context) (err error) {
 			p.cfg.Charts.Backend, helm.BackendLocal)
 	}
 
-	applications, err := appservice.New(appservice.Config{
+	var analysisGate *analysis.Gate
+	if p.cfg.OpsCenter.PackageAnalysis != nil {
+		analysisGate, err = analysis.New(*p.cfg.OpsCenter.PackageAnalysis)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
+
+	appConfig := appservice.Config{
 		StateDir:       filepath.Join(p.cfg.DataDir, defaults.ImportDir),
 		Backend:        p.backend,
 		Packages:       p.packages,
@@ -1124,12 +1435,23 @@ func (p *Process) initService(ctx context.Context) (err error) {
 		CacheResources: true,
 		UnpackedDir:    filepath.Join(p.cfg.DataDir, defaults.PackagesDir, defaults.UnpackedDir),
 		GetClient:      tryGetPrivilegedKubeClient,
-	})
+		Analysis:       analysisGate,
+	}
+	applications, err := appservice.New(appConfig)
 	if err != nil {
 		return trace.Wrap(err)
 	}
 	p.applications = applications
 
+	if p.cfg.OpsCenter.Mirror != nil {
+		appConfig.Packages = writablePackages
+		mirrorApps, err := appservice.New(appConfig)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		p.RegisterClusterService(p.catalogMirror(writablePackages, mirrorApps))
+	}
+
 	if p.inKubernetes() {
 		p.handlers.Registry, err = docker.NewRegistry(docker.Config{
 			Context: ctx,
@@ -1239,6 +1561,23 @@ func (p *Process) initService(ctx context.Context) (err error) {
 
 	// site status checker executes status hook periodically
 	p.RegisterClusterService(p.startSiteStatusChecker)
+	p.RegisterClusterService(p.startNodeQuarantine)
+
+	// DNS publisher keeps cluster endpoints published to external DNS providers
+	p.RegisterClusterService(p.startDNSPublisher)
+	p.RegisterClusterService(p.startSecretBackendSyncer)
+	// webhook notifier sends cluster lifecycle events to external systems
+	p.RegisterClusterService(p.startWebhookNotifier)
+	p.RegisterClusterService(p.startIngressReconciler)
+	p.RegisterClusterService(p.startTimeSyncReconciler)
+	p.RegisterClusterService(p.startLogRotationReconciler)
+	p.RegisterClusterService(p.startOperationReaper)
+	p.RegisterClusterService(p.startPackageRetentionReaper)
+	p.RegisterClusterService(p.startTrustedCAReconciler)
+	p.RegisterClusterService(p.startAppOverlayReconciler)
+	p.RegisterClusterService(p.startSnapshotController)
+	p.RegisterClusterService(p.startNodeIdentityRestorer)
+	p.RegisterClusterService(p.startKubeletCSRApprover)
 
 	// a few services that are running only when gravity is started in
 	// local site mode
@@ -1349,6 +1688,16 @@ func (p *Process) initService(ctx context.Context) (err error) {
 
 	providers := web.NewProviders(applications)
 
+	var installerCache *installers.Cache
+	if p.mode != constants.ComponentInstaller {
+		installerCache, err = installers.New(installers.Config{
+			Dir: filepath.Join(p.cfg.DataDir, defaults.InstallerCacheDir),
+		})
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
+
 	p.handlers.WebAPI, err = web.NewAPI(web.Config{
 		Identity:         p.identity,
 		Auth:             authClient,
@@ -1365,6 +1714,7 @@ func (p *Process) initService(ctx context.Context) (err error) {
 		Mode:             p.mode,
 		ProxyHost:        sshProxyHost,
 		ServiceUser:      *p.cfg.ServiceUser,
+		Installers:       installerCache,
 	})
 
 	if err != nil {
@@ -1505,11 +1855,53 @@ func (p *Process) initMux(ctx context.Context) error {
 		mux.Handler(method, "/v2/*rest", p.handlers.Registry)
 		mux.HandlerFunc(method, "/readyz", p.ReportReadiness)
 		mux.HandlerFunc(method, "/healthz", p.ReportHealth)
+		mux.Handler(method, "/metrics", prometheus.Handler())
 	}
 	mux.NotFound = p.handlers.Web.NotFound
 
-	return trace.Wrap(p.ServeLocal(ctx, httplib.GRPCHandlerFunc(
-		p.agentServer, mux), p.cfg.Pack.ListenAddr.Addr))
+	err := p.ServeLocal(ctx, httplib.GRPCHandlerFunc(p.agentServer, mux), p.cfg.Pack.ListenAddr.Addr)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if p.cfg.Pack.ClientCAFile != "" {
+		return trace.Wrap(p.servePackagesWithClientAuth(ctx))
+	}
+	return nil
+}
+
+// servePackagesWithClientAuth starts serving the package service on a
+// dedicated listener that verifies TLS client certificates issued by
+// the configured client CA.
+//
+// Client certificates are only requested on this listener so the other
+// endpoints are not affected
+func (p *Process) servePackagesWithClientAuth(ctx context.Context) error {
+	caPEM, err := ioutil.ReadFile(p.cfg.Pack.ClientCAFile)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(caPEM) {
+		return trace.BadParameter("failed to parse client CA certificate from %v",
+			p.cfg.Pack.ClientCAFile)
+	}
+	mux := &httprouter.Router{}
+	for _, method := range httplib.Methods {
+		mux.Handler(method, "/pack/*packages", p.handlers.Packages)
+	}
+	addr := p.cfg.Pack.ClientAuthListenAddr.Addr
+	p.RegisterFunc("gravity.pack.listener", func() error {
+		return trace.Wrap(p.serve(ctx, mux, addr, func() (*tls.Config, error) {
+			config, err := p.getTLSConfig()
+			if err != nil {
+				return nil, trace.Wrap(err)
+			}
+			config.ClientCAs = clientCAs
+			config.ClientAuth = tls.VerifyClientCertIfGiven
+			return config, nil
+		}))
+	})
+	return nil
 }
 
 // ServeLocal starts serving provided handler mux on the specified address
@@ -1517,43 +1909,51 @@ func (p *Process) initMux(ctx context.Context) error {
 // The listener is restarted when a certificate change event is detected.
 func (p *Process) ServeLocal(ctx context.Context, mux http.Handler, addr string) error {
 	p.RegisterFunc("gravity.listener", func() error {
-		webListener, err := p.startListening(mux, addr)
-		if err != nil {
-			return trace.Wrap(err)
-		}
+		return trace.Wrap(p.serve(ctx, mux, addr, p.getTLSConfig))
+	})
 
-		eventsCh := make(chan service.Event)
-		p.WaitForEvent(ctx, constants.ClusterCertificateUpdatedEvent, eventsCh)
+	return nil
+}
 
-		for {
-			select {
-			case event := <-eventsCh:
-				p.Infof("Got event %q, restarting listener %v.", event, addr)
+// serve serves the provided handler on the specified address until
+// the context is canceled.
+//
+// The listener is restarted when a certificate change event is detected.
+func (p *Process) serve(ctx context.Context, handler http.Handler, addr string, getTLSConfig func() (*tls.Config, error)) error {
+	webListener, err := p.startListening(handler, addr, getTLSConfig)
+	if err != nil {
+		return trace.Wrap(err)
+	}
 
-				err = webListener.Close()
-				if err != nil {
-					return trace.Wrap(err)
-				}
+	eventsCh := make(chan service.Event)
+	p.WaitForEvent(ctx, constants.ClusterCertificateUpdatedEvent, eventsCh)
 
-				webListener, err = p.startListening(mux, addr)
-				if err != nil {
-					return trace.Wrap(err)
-				}
+	for {
+		select {
+		case event := <-eventsCh:
+			p.Infof("Got event %q, restarting listener %v.", event, addr)
 
-			case <-ctx.Done():
-				p.Infof("Stopping listener %v.", addr)
-				return nil
+			err = webListener.Close()
+			if err != nil {
+				return trace.Wrap(err)
 			}
-		}
-	})
 
-	return nil
+			webListener, err = p.startListening(handler, addr, getTLSConfig)
+			if err != nil {
+				return trace.Wrap(err)
+			}
+
+		case <-ctx.Done():
+			p.Infof("Stopping listener %v.", addr)
+			return nil
+		}
+	}
 }
 
 // startListening initializes the TLS listener and starts serving on the specified
 // address using the provided handler
-func (p *Process) startListening(handler http.Handler, addr string) (net.Listener, error) {
-	tlsConfig, err := p.getTLSConfig()
+func (p *Process) startListening(handler http.Handler, addr string, getTLSConfig func() (*tls.Config, error)) (net.Listener, error) {
+	tlsConfig, err := getTLSConfig()
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -24,12 +24,16 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/gravitational/gravity/lib/app/analysis"
+	blobs3 "github.com/gravitational/gravity/lib/blob/s3"
+	"github.com/gravitational/gravity/lib/catalog/mirror"
 	"github.com/gravitational/gravity/lib/constants"
 	"github.com/gravitational/gravity/lib/defaults"
 	"github.com/gravitational/gravity/lib/helm"
 	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/modules"
 	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/pack"
 	"github.com/gravitational/gravity/lib/storage"
 	"github.com/gravitational/gravity/lib/storage/keyval"
 	"github.com/gravitational/gravity/lib/systeminfo"
@@ -192,6 +196,35 @@ func (cfg *Config) CheckAndSetDefaults() error {
 		return trace.BadParameter("missing pack service advertise address")
 	}
 
+	if cfg.Pack.ClientCAFile != "" && cfg.Pack.ClientAuthListenAddr.IsEmpty() {
+		return trace.BadParameter("client_auth_listen_addr is required with client_ca_file")
+	}
+
+	if err := cfg.Pack.RepositoryRules.Check(); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := cfg.Pack.Quotas.Check(); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := cfg.Pack.Verification.Check(); err != nil {
+		return trace.Wrap(err)
+	}
+	if cfg.Pack.S3 != nil {
+		if err := cfg.Pack.S3.CheckAndSetDefaults(); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	if cfg.OpsCenter.PackageAnalysis != nil {
+		if err := cfg.OpsCenter.PackageAnalysis.CheckAndSetDefaults(); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	if cfg.OpsCenter.Mirror != nil {
+		if err := cfg.OpsCenter.Mirror.CheckAndSetDefaults(); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+
 	if cfg.HealthAddr.IsEmpty() {
 		cfg.HealthAddr = teleutils.NetAddr{
 			AddrNetwork: "tcp",
@@ -308,6 +341,24 @@ type PackageServiceConfig struct {
 
 	// ReadDir is an optional directory with extra packages
 	ReadDir string `yaml:"read_dir"`
+
+	// ClientCAFile is an optional path to the CA certificate used to verify
+	// TLS client certificates. If set, clients can authenticate with
+	// the package service using certificates issued by this CA
+	ClientCAFile string `yaml:"client_ca_file"`
+	// ClientAuthListenAddr is the listen address for a server that serves
+	// only the package service and verifies TLS client certificates.
+	// Required if ClientCAFile is set
+	ClientAuthListenAddr teleutils.NetAddr `yaml:"client_auth_listen_addr"`
+	// RepositoryRules optionally restricts users' access to specific repositories
+	RepositoryRules pack.RepositoryRules `yaml:"repository_rules"`
+	// Quotas optionally limits the number and size of packages in repositories
+	Quotas pack.RepositoryQuotas `yaml:"quotas"`
+	// Verification optionally requires packages to be signed with trusted keys
+	Verification pack.VerificationPolicy `yaml:"verification"`
+	// S3 optionally stores package BLOBs in an S3-compatible object storage
+	// instead of the local filesystem. Package metadata is kept in the backend
+	S3 *blobs3.Config `yaml:"s3"`
 }
 
 // PeerAddr returns peer address of the package service instance
@@ -369,6 +420,12 @@ func (c *ChartsConfig) CheckAndSetDefaults() error {
 type OpsCenterConfig struct {
 	// SeedConfig defines optional configuration to apply on OpsCenter start
 	SeedConfig *ops.SeedConfig `yaml:"seed_config"`
+	// PackageAnalysis configures optional static analysis of application
+	// packages uploaded to the OpsCenter
+	PackageAnalysis *analysis.Config `yaml:"package_analysis"`
+	// Mirror turns the OpsCenter into a read-only mirror of the
+	// catalog of an upstream OpsCenter
+	Mirror *mirror.Config `yaml:"mirror"`
 }
 
 type packageLocator loc.Locator
@@ -0,0 +1,232 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package rehome implements migration of a cluster from one Ops Center to
+// another without reinstalling it.
+//
+// The cluster's relationship with an Ops Center is captured by a trusted
+// cluster resource: it establishes the certificate authority exchange and the
+// reverse tunnel (used for remote access and cluster status reporting) and
+// controls whether the cluster pulls application updates from the Ops Center.
+// The Ops Center agent user is used to authenticate to the Ops Center's package
+// and application services when downloading updates.
+//
+// Migration connects the cluster to the new Ops Center first, carrying over
+// the update settings, and only then disconnects it from the old one so the
+// cluster is never left without an Ops Center if any step fails.
+package rehome
+
+import (
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/users"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// Config defines the cluster migration configuration
+type Config struct {
+	// Backend is the cluster backend
+	Backend storage.Backend
+	// Users is the cluster identity service
+	Users users.Identity
+	// ClusterName is the name of the local cluster
+	ClusterName string
+	// Source is the name of the Ops Center to migrate from.
+	// Can be omitted if the cluster is connected to a single Ops Center
+	Source string
+	// Target describes the Ops Center to migrate to
+	Target storage.TrustedCluster
+	// FieldLogger is the logger
+	log.FieldLogger
+}
+
+// CheckAndSetDefaults validates the configuration and sets defaults
+func (r *Config) CheckAndSetDefaults() error {
+	if r.Backend == nil {
+		return trace.BadParameter("backend is required")
+	}
+	if r.Users == nil {
+		return trace.BadParameter("users service is required")
+	}
+	if r.ClusterName == "" {
+		return trace.BadParameter("cluster name is required")
+	}
+	if r.Target == nil {
+		return trace.BadParameter("target Ops Center is required")
+	}
+	if r.Target.GetWizard() {
+		return trace.BadParameter("cannot migrate to an installer Ops Center")
+	}
+	if err := r.Target.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	if r.FieldLogger == nil {
+		r.FieldLogger = log.WithField(trace.Component, "rehome")
+	}
+	return nil
+}
+
+// Result describes the outcome of a successful migration
+type Result struct {
+	// Source is the Ops Center the cluster has been disconnected from
+	Source storage.TrustedCluster
+	// Target is the Ops Center the cluster is now connected to
+	Target storage.TrustedCluster
+}
+
+// Migrate moves the cluster from the source Ops Center to the target one
+func Migrate(config Config) (*Result, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	source, err := FindSource(config.Users, config.Source)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if source.GetName() == config.Target.GetName() {
+		return nil, trace.BadParameter("cluster is already connected to %v",
+			source.GetName())
+	}
+	target := config.Target
+	carryOver(source, target)
+	m := &migration{Config: config}
+	if err := m.connect(target); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := m.disconnect(source); err != nil {
+		m.Warnf("Failed to disconnect from %v: %v.", source.GetName(), trace.DebugReport(err))
+		if errRollback := m.rollback(target); errRollback != nil {
+			m.Warnf("Failed to roll back connection to %v: %v.",
+				target.GetName(), trace.DebugReport(errRollback))
+		}
+		return nil, trace.Wrap(err)
+	}
+	return &Result{
+		Source: source,
+		Target: target,
+	}, nil
+}
+
+// FindSource returns the Ops Center the cluster is currently connected to.
+// If name is empty, the cluster is expected to be connected to exactly one
+// Ops Center
+func FindSource(identity users.Identity, name string) (storage.TrustedCluster, error) {
+	clusters, err := identity.GetTrustedClusters()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var sources []storage.TrustedCluster
+	for _, cluster := range clusters {
+		trustedCluster, ok := cluster.(storage.TrustedCluster)
+		if !ok || trustedCluster.GetWizard() {
+			continue
+		}
+		if name != "" && trustedCluster.GetName() != name {
+			continue
+		}
+		sources = append(sources, trustedCluster)
+	}
+	switch len(sources) {
+	case 0:
+		if name != "" {
+			return nil, trace.NotFound("cluster is not connected to %v", name)
+		}
+		return nil, trace.NotFound("cluster is not connected to any Ops Center")
+	case 1:
+		return sources[0], nil
+	}
+	var names []string
+	for _, source := range sources {
+		names = append(names, source.GetName())
+	}
+	return nil, trace.BadParameter("cluster is connected to multiple Ops Centers (%v), "+
+		"specify the one to migrate from", names)
+}
+
+// carryOver updates the target Ops Center trusted cluster with the
+// settings of the source Ops Center so the cluster keeps pulling updates
+// and grants the same access after the migration
+func carryOver(source, target storage.TrustedCluster) {
+	target.SetEnabled(true)
+	if source.GetPullUpdates() {
+		target.SetPullUpdates(true)
+	}
+	if source.GetSystem() {
+		target.SetSystem(true)
+	}
+	if len(target.GetRoles()) == 0 && len(target.GetRoleMap()) == 0 {
+		target.SetRoles(source.GetRoles())
+		target.SetRoleMap(source.GetRoleMap())
+	}
+}
+
+type migration struct {
+	Config
+}
+
+// connect creates the agent for the target Ops Center and establishes
+// trust with it
+func (r *migration) connect(target storage.TrustedCluster) error {
+	agent, _, err := users.CreateOpsCenterAgent(target.GetName(), r.ClusterName, r.Users)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	r.Infof("Created agent %v for %v.", agent.GetName(), target.GetName())
+	_, err = r.Users.UpsertTrustedCluster(target)
+	if err != nil {
+		if errDelete := r.Users.DeleteUser(agent.GetName()); errDelete != nil {
+			r.Warnf("Failed to delete agent %v: %v.", agent.GetName(), errDelete)
+		}
+		return trace.Wrap(err)
+	}
+	r.Infof("Connected to %v.", target.GetName())
+	return nil
+}
+
+// disconnect removes the trust relationship with the source Ops Center
+// and its agent
+func (r *migration) disconnect(source storage.TrustedCluster) error {
+	err := r.Users.DeleteTrustedCluster(source.GetName())
+	if err != nil && !trace.IsNotFound(err) {
+		return trace.Wrap(err)
+	}
+	r.Infof("Disconnected from %v.", source.GetName())
+	agent, _, err := users.GetOpsCenterAgent(source.GetName(), r.ClusterName, r.Backend)
+	if err != nil {
+		if !trace.IsNotFound(err) {
+			r.Warnf("Failed to find agent for %v: %v.", source.GetName(), err)
+		}
+		return nil
+	}
+	if err := r.Users.DeleteUser(agent.GetName()); err != nil {
+		r.Warnf("Failed to delete agent %v: %v.", agent.GetName(), err)
+	}
+	return nil
+}
+
+// rollback removes the connection to the target Ops Center
+func (r *migration) rollback(target storage.TrustedCluster) error {
+	err := r.Users.DeleteTrustedCluster(target.GetName())
+	if err != nil && !trace.IsNotFound(err) {
+		return trace.Wrap(err)
+	}
+	agent, _, err := users.GetOpsCenterAgent(target.GetName(), r.ClusterName, r.Backend)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(r.Users.DeleteUser(agent.GetName()))
+}
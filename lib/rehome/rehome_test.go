@@ -0,0 +1,96 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rehome
+
+import (
+	"testing"
+
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/users"
+
+	teleservices "github.com/gravitational/teleport/lib/services"
+	"github.com/gravitational/trace"
+	"gopkg.in/check.v1"
+)
+
+func TestRehome(t *testing.T) { check.TestingT(t) }
+
+type RehomeSuite struct{}
+
+var _ = check.Suite(&RehomeSuite{})
+
+func (s *RehomeSuite) TestCarriesOverSettings(c *check.C) {
+	source := newTrustedCluster("old.example.com")
+	source.SetPullUpdates(true)
+	source.SetSystem(true)
+	source.SetRoles([]string{"@teleadmin"})
+	target := newTrustedCluster("new.example.com")
+	target.SetEnabled(false)
+
+	carryOver(source, target)
+	c.Assert(target.GetEnabled(), check.Equals, true)
+	c.Assert(target.GetPullUpdates(), check.Equals, true)
+	c.Assert(target.GetSystem(), check.Equals, true)
+	c.Assert(target.GetRoles(), check.DeepEquals, []string{"@teleadmin"})
+
+	target = newTrustedCluster("new.example.com")
+	target.SetRoles([]string{"admin"})
+	carryOver(source, target)
+	c.Assert(target.GetRoles(), check.DeepEquals, []string{"admin"},
+		check.Commentf("explicit roles should be preserved"))
+}
+
+func (s *RehomeSuite) TestFindsSource(c *check.C) {
+	wizard := newTrustedCluster("installer")
+	wizard.SetWizard(true)
+	identity := &testIdentity{clusters: []teleservices.TrustedCluster{
+		wizard,
+		newTrustedCluster("old.example.com"),
+	}}
+	source, err := FindSource(identity, "")
+	c.Assert(err, check.IsNil)
+	c.Assert(source.GetName(), check.Equals, "old.example.com")
+
+	_, err = FindSource(identity, "other.example.com")
+	c.Assert(trace.IsNotFound(err), check.Equals, true)
+
+	identity.clusters = append(identity.clusters, newTrustedCluster("other.example.com"))
+	_, err = FindSource(identity, "")
+	c.Assert(trace.IsBadParameter(err), check.Equals, true,
+		check.Commentf("expected ambiguous source to be rejected, got %v", err))
+	source, err = FindSource(identity, "other.example.com")
+	c.Assert(err, check.IsNil)
+	c.Assert(source.GetName(), check.Equals, "other.example.com")
+}
+
+func newTrustedCluster(name string) storage.TrustedCluster {
+	return storage.NewTrustedCluster(name, storage.TrustedClusterSpecV2{
+		Enabled:              true,
+		Token:                "token",
+		ProxyAddress:         name + ":32009",
+		ReverseTunnelAddress: name + ":3024",
+	})
+}
+
+type testIdentity struct {
+	users.Identity
+	clusters []teleservices.TrustedCluster
+}
+
+func (r *testIdentity) GetTrustedClusters() ([]teleservices.TrustedCluster, error) {
+	return r.clusters, nil
+}
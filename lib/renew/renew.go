@@ -0,0 +1,292 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package renew implements recovery of cluster nodes whose certificates
+// have expired, for example after the cluster has been powered off for
+// longer than the certificates are valid.
+//
+// Every certificate issued to the node by the cluster certificate authority
+// is reissued with the same subject, alternative names and private key,
+// after which the runtime container services are restarted in dependency
+// order: etcd first, then the Kubernetes control plane and finally
+// the node services.
+package renew
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/cloudflare/cfssl/csr"
+	"github.com/gravitational/license/authority"
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+)
+
+// Config defines the certificate renewal configuration
+type Config struct {
+	// SecretsDir is the directory with the node certificates
+	SecretsDir string
+	// CertAuthority is the cluster certificate authority key pair
+	CertAuthority *authority.TLSKeyPair
+	// ValidFor is the validity period of the renewed certificates.
+	// The certificates never outlive the certificate authority
+	ValidFor time.Duration
+	// Now returns the current time
+	Now func() time.Time
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+}
+
+// CheckAndSetDefaults validates the configuration and sets default values
+func (r *Config) CheckAndSetDefaults() error {
+	if r.SecretsDir == "" {
+		return trace.BadParameter("missing SecretsDir")
+	}
+	if r.CertAuthority == nil {
+		return trace.BadParameter("missing CertAuthority")
+	}
+	if r.ValidFor <= 0 {
+		return trace.BadParameter("validity period should be positive")
+	}
+	if r.Now == nil {
+		r.Now = time.Now
+	}
+	if r.FieldLogger == nil {
+		r.FieldLogger = logrus.WithField(trace.Component, "renew")
+	}
+	return nil
+}
+
+// Certificate describes a renewed certificate
+type Certificate struct {
+	// Name is the name of the certificate, e.g. "apiserver"
+	Name string
+	// Expired is whether the certificate had expired before renewal
+	Expired bool
+	// NotAfter is the expiration time of the renewed certificate
+	NotAfter time.Time
+}
+
+// Renew reissues every certificate in the secrets directory that has been
+// issued by the certificate authority and returns the renewed certificates.
+// Certificates issued by other authorities are left intact
+func Renew(config Config) ([]Certificate, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	caCert, err := ParseCertificate(config.CertAuthority.CertPEM)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	now := config.Now()
+	if !now.Before(caCert.NotAfter) {
+		return nil, trace.BadParameter("certificate authority %v expired on %v and cannot be used to renew certificates",
+			caCert.Subject.CommonName, caCert.NotAfter.Format(time.RFC3339))
+	}
+	validFor := config.ValidFor
+	if now.Add(validFor).After(caCert.NotAfter) {
+		validFor = caCert.NotAfter.Sub(now)
+	}
+	names, err := certificateNames(config.SecretsDir)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var renewed []Certificate
+	for _, name := range names {
+		certPath := filepath.Join(config.SecretsDir, name+certExt)
+		cert, err := ReadCertificate(certPath)
+		if err != nil {
+			return renewed, trace.Wrap(err)
+		}
+		if cert.IsCA || cert.CheckSignatureFrom(caCert) != nil {
+			config.Debugf("Skipping %v not issued by the certificate authority.", certPath)
+			continue
+		}
+		keyPath := filepath.Join(config.SecretsDir, name+keyExt)
+		keyPEM, err := ioutil.ReadFile(keyPath)
+		if err != nil {
+			return renewed, trace.ConvertSystemError(err)
+		}
+		keyPair, err := authority.GenerateCertificate(CertificateRequest(cert),
+			config.CertAuthority, keyPEM, validFor)
+		if err != nil {
+			return renewed, trace.Wrap(err)
+		}
+		err = ioutil.WriteFile(certPath, keyPair.CertPEM, defaults.SharedReadMask)
+		if err != nil {
+			return renewed, trace.ConvertSystemError(err)
+		}
+		renewedCert, err := ParseCertificate(keyPair.CertPEM)
+		if err != nil {
+			return renewed, trace.Wrap(err)
+		}
+		config.Infof("Renewed certificate %v.", certPath)
+		renewed = append(renewed, Certificate{
+			Name:     name,
+			Expired:  !now.Before(cert.NotAfter),
+			NotAfter: renewedCert.NotAfter,
+		})
+	}
+	return renewed, nil
+}
+
+// Services manages the services of the runtime container
+type Services interface {
+	// IsEnabled returns true if the service is enabled on this node
+	IsEnabled(ctx context.Context, name string) (bool, error)
+	// Restart restarts the service
+	Restart(ctx context.Context, name string) error
+}
+
+// RestartServices restarts the services enabled on this node in the
+// dependency order so that each one starts with the renewed certificates.
+// The callback is invoked before each service is restarted
+func RestartServices(ctx context.Context, services Services, progress func(service string)) error {
+	for _, service := range ServiceOrder {
+		enabled, err := services.IsEnabled(ctx, service)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if !enabled {
+			continue
+		}
+		if progress != nil {
+			progress(service)
+		}
+		if err := services.Restart(ctx, service); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// NewPlanetServices returns the services of the runtime container
+// on this node
+func NewPlanetServices(log logrus.FieldLogger) Services {
+	return &planetServices{FieldLogger: log}
+}
+
+type planetServices struct {
+	logrus.FieldLogger
+}
+
+// IsEnabled returns true if the service is enabled inside the runtime container
+func (r *planetServices) IsEnabled(ctx context.Context, name string) (bool, error) {
+	out, err := utils.RunInPlanetCommand(ctx, r.FieldLogger, defaults.SystemctlBin, "is-enabled", name)
+	if err != nil {
+		// is-enabled exits with a non-zero code for disabled and unknown units
+		r.Debugf("Service %v is not enabled: %s.", name, out)
+		return false, nil
+	}
+	return true, nil
+}
+
+// Restart restarts the service inside the runtime container
+func (r *planetServices) Restart(ctx context.Context, name string) error {
+	out, err := utils.RunInPlanetCommand(ctx, r.FieldLogger, defaults.SystemctlBin, "restart", name)
+	if err != nil {
+		return trace.Wrap(err, "failed to restart %v: %s", name, out)
+	}
+	return nil
+}
+
+// ReadCertificate returns the parsed x509 certificate from the provided path
+func ReadCertificate(path string) (*x509.Certificate, error) {
+	certPEM, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	cert, err := ParseCertificate(certPEM)
+	if err != nil {
+		return nil, trace.Wrap(err, "failed to decode certificate at %v", path)
+	}
+	return cert, nil
+}
+
+// ParseCertificate returns the parsed x509 certificate from the PEM data
+func ParseCertificate(certPEM []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return nil, trace.BadParameter("failed to decode certificate")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return cert, nil
+}
+
+// CertificateRequest creates a new certificate request using the data
+// from the provided certificate
+func CertificateRequest(cert *x509.Certificate) csr.CertificateRequest {
+	req := csr.CertificateRequest{
+		CN:    cert.Subject.CommonName,
+		Hosts: cert.DNSNames,
+	}
+	for _, ip := range cert.IPAddresses {
+		req.Hosts = append(req.Hosts, ip.String())
+	}
+	for _, o := range cert.Subject.Organization {
+		req.Names = append(req.Names, csr.Name{O: o})
+	}
+	return req
+}
+
+// certificateNames returns the names of the certificates in the directory
+// that have a private key, excluding the certificate authority
+func certificateNames(dir string) (names []string, err error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*"+certExt))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	for _, path := range matches {
+		if filepath.Base(path) == defaults.RootCertFilename {
+			continue
+		}
+		name := strings.TrimSuffix(filepath.Base(path), certExt)
+		if _, err := utils.StatFile(filepath.Join(dir, name+keyExt)); err != nil {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// ServiceOrder lists the runtime container services
+// that use certificates in the order they are restarted
+var ServiceOrder = []string{
+	"etcd",
+	"kube-apiserver",
+	"kube-controller-manager",
+	"kube-scheduler",
+	"kube-kubelet",
+	"kube-proxy",
+}
+
+const (
+	certExt = ".cert"
+	keyExt  = ".key"
+)
@@ -0,0 +1,156 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package renew
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+
+	"github.com/cloudflare/cfssl/csr"
+	"github.com/gravitational/license/authority"
+	"github.com/gravitational/trace"
+	. "gopkg.in/check.v1"
+)
+
+func TestRenew(t *testing.T) { TestingT(t) }
+
+type RenewSuite struct{}
+
+var _ = Suite(&RenewSuite{})
+
+func (s *RenewSuite) TestRenewsCertificates(c *C) {
+	dir := c.MkDir()
+	ca := newCA(c, "cluster-ca")
+	other := newCA(c, "other-ca")
+	writeFile(c, filepath.Join(dir, defaults.RootCertFilename), ca.CertPEM)
+	apiserver := newKeyPair(c, dir, "apiserver", ca, "127.0.0.1", "node-1")
+	newKeyPair(c, dir, "kubelet", ca)
+	foreign := newKeyPair(c, dir, "foreign", other)
+	// a certificate without a private key is not renewed
+	writeFile(c, filepath.Join(dir, "orphan.cert"), apiserver.CertPEM)
+
+	renewed, err := Renew(Config{
+		SecretsDir:    dir,
+		CertAuthority: ca,
+		ValidFor:      24 * time.Hour,
+		// pretend the certificates have expired
+		Now: func() time.Time { return time.Now().Add(2 * time.Hour) },
+	})
+	c.Assert(err, IsNil)
+	c.Assert(renewed, HasLen, 2)
+	c.Assert(renewed[0].Name, Equals, "apiserver")
+	c.Assert(renewed[0].Expired, Equals, true)
+	c.Assert(renewed[1].Name, Equals, "kubelet")
+
+	oldCert, err := ParseCertificate(apiserver.CertPEM)
+	c.Assert(err, IsNil)
+	newCert, err := ReadCertificate(filepath.Join(dir, "apiserver.cert"))
+	c.Assert(err, IsNil)
+	caCert, err := ParseCertificate(ca.CertPEM)
+	c.Assert(err, IsNil)
+	c.Assert(newCert.CheckSignatureFrom(caCert), IsNil)
+	c.Assert(newCert.NotAfter.After(oldCert.NotAfter), Equals, true)
+	c.Assert(newCert.Subject.CommonName, Equals, oldCert.Subject.CommonName)
+	c.Assert(newCert.Subject.Organization, DeepEquals, oldCert.Subject.Organization)
+	c.Assert(newCert.DNSNames, DeepEquals, oldCert.DNSNames)
+	c.Assert(newCert.IPAddresses, DeepEquals, oldCert.IPAddresses)
+	c.Assert(newCert.PublicKey, DeepEquals, oldCert.PublicKey)
+
+	// certificates issued by other authorities are left intact
+	data, err := ioutil.ReadFile(filepath.Join(dir, "foreign.cert"))
+	c.Assert(err, IsNil)
+	c.Assert(data, DeepEquals, foreign.CertPEM)
+}
+
+func (s *RenewSuite) TestRefusesExpiredAuthority(c *C) {
+	dir := c.MkDir()
+	ca := newCA(c, "cluster-ca")
+	caCert, err := ParseCertificate(ca.CertPEM)
+	c.Assert(err, IsNil)
+	_, err = Renew(Config{
+		SecretsDir:    dir,
+		CertAuthority: ca,
+		ValidFor:      24 * time.Hour,
+		Now:           func() time.Time { return caCert.NotAfter.Add(time.Hour) },
+	})
+	c.Assert(trace.IsBadParameter(err), Equals, true)
+}
+
+func (s *RenewSuite) TestRestartsServicesInOrder(c *C) {
+	services := &testServices{disabled: map[string]bool{
+		"kube-apiserver":          true,
+		"kube-controller-manager": true,
+		"kube-scheduler":          true,
+	}}
+	var progress []string
+	err := RestartServices(context.TODO(), services, func(service string) {
+		progress = append(progress, service)
+	})
+	c.Assert(err, IsNil)
+	c.Assert(services.restarted, DeepEquals, []string{"etcd", "kube-kubelet", "kube-proxy"})
+	c.Assert(progress, DeepEquals, services.restarted)
+
+	services = &testServices{failing: "kube-apiserver"}
+	err = RestartServices(context.TODO(), services, nil)
+	c.Assert(err, NotNil)
+	c.Assert(services.restarted, DeepEquals, []string{"etcd"})
+}
+
+type testServices struct {
+	disabled  map[string]bool
+	failing   string
+	restarted []string
+}
+
+func (r *testServices) IsEnabled(ctx context.Context, name string) (bool, error) {
+	return !r.disabled[name], nil
+}
+
+func (r *testServices) Restart(ctx context.Context, name string) error {
+	if name == r.failing {
+		return trace.ConnectionProblem(nil, "failed to restart %v", name)
+	}
+	r.restarted = append(r.restarted, name)
+	return nil
+}
+
+func newCA(c *C, name string) *authority.TLSKeyPair {
+	ca, err := authority.GenerateSelfSignedCA(csr.CertificateRequest{CN: name})
+	c.Assert(err, IsNil)
+	return ca
+}
+
+func newKeyPair(c *C, dir, name string, ca *authority.TLSKeyPair, hosts ...string) *authority.TLSKeyPair {
+	keyPair, err := authority.GenerateCertificate(csr.CertificateRequest{
+		CN:    name,
+		Hosts: hosts,
+		Names: []csr.Name{{O: "system:nodes"}},
+	}, ca, nil, time.Hour)
+	c.Assert(err, IsNil)
+	writeFile(c, filepath.Join(dir, name+".cert"), keyPair.CertPEM)
+	writeFile(c, filepath.Join(dir, name+".key"), keyPair.KeyPEM)
+	return keyPair
+}
+
+func writeFile(c *C, path string, data []byte) {
+	c.Assert(ioutil.WriteFile(path, data, defaults.SharedReadMask), IsNil)
+}
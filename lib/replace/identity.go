@@ -0,0 +1,226 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package replace implements replacement of a failed cluster node with new
+// hardware that takes over the identity of the failed node.
+//
+// Before the failed node is removed from the cluster, its identity - hostname,
+// advertise address, role, Kubernetes labels and taints - is recorded.
+// Once the replacement node has joined the cluster, the recorded labels and
+// taints are restored on it so workloads scheduled by node selectors and
+// tolerations land on the replacement just as they did on the failed node.
+package replace
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/schema"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+	"k8s.io/api/core/v1"
+)
+
+// Identity describes the cluster identity of a node being replaced
+type Identity struct {
+	// Hostname is the hostname of the node
+	Hostname string `json:"hostname"`
+	// AdvertiseIP is the advertise address of the node
+	AdvertiseIP string `json:"advertise_ip"`
+	// NodeName is the name of the Kubernetes node
+	NodeName string `json:"node_name"`
+	// Role is the node profile name
+	Role string `json:"role"`
+	// ClusterRole is the node cluster role, master or node
+	ClusterRole string `json:"cluster_role"`
+	// Labels are the Kubernetes labels to restore
+	Labels map[string]string `json:"labels,omitempty"`
+	// Taints are the Kubernetes taints to restore
+	Taints []v1.Taint `json:"taints,omitempty"`
+	// Created is when the identity has been recorded
+	Created time.Time `json:"created"`
+}
+
+// NewIdentity returns the identity of the specified server. If the Kubernetes
+// node is available, its labels and taints are recorded as well, save for
+// the ones that are managed by Kubernetes itself
+func NewIdentity(server storage.Server, node *v1.Node, now time.Time) Identity {
+	identity := Identity{
+		Hostname:    server.Hostname,
+		AdvertiseIP: server.AdvertiseIP,
+		NodeName:    server.KubeNodeID(),
+		Role:        server.Role,
+		ClusterRole: server.ClusterRole,
+		Created:     now.UTC(),
+	}
+	if node == nil {
+		return identity
+	}
+	for key, value := range node.Labels {
+		if isManagedLabel(key) {
+			continue
+		}
+		if identity.Labels == nil {
+			identity.Labels = make(map[string]string)
+		}
+		identity.Labels[key] = value
+	}
+	for _, taint := range node.Spec.Taints {
+		if isManagedTaint(taint) {
+			continue
+		}
+		taint.TimeAdded = nil
+		identity.Taints = append(identity.Taints, taint)
+	}
+	return identity
+}
+
+// Check makes sure the identity is valid
+func (r Identity) Check() error {
+	if r.Hostname == "" {
+		return trace.BadParameter("missing hostname")
+	}
+	if r.AdvertiseIP == "" {
+		return trace.BadParameter("missing advertise address")
+	}
+	return nil
+}
+
+// Matches returns true if the specified Kubernetes node is the replacement
+// for the node with this identity: it has either the same address or the
+// same hostname and has registered after the identity has been recorded
+func (r Identity) Matches(node v1.Node) bool {
+	if !node.CreationTimestamp.Time.After(r.Created) {
+		return false
+	}
+	if node.Name == r.NodeName {
+		return true
+	}
+	for _, addr := range node.Status.Addresses {
+		switch addr.Type {
+		case v1.NodeInternalIP:
+			if addr.Address == r.AdvertiseIP {
+				return true
+			}
+		case v1.NodeHostName:
+			if addr.Address == r.Hostname {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Restore applies the recorded labels and taints to the specified node.
+// Returns true if the node has been modified
+func (r Identity) Restore(node *v1.Node) (updated bool) {
+	for key, value := range r.Labels {
+		if existing, ok := node.Labels[key]; ok && existing == value {
+			continue
+		}
+		if node.Labels == nil {
+			node.Labels = make(map[string]string)
+		}
+		node.Labels[key] = value
+		updated = true
+	}
+	for _, taint := range r.Taints {
+		if hasTaint(node.Spec.Taints, taint) {
+			continue
+		}
+		node.Spec.Taints = append(node.Spec.Taints, taint)
+		updated = true
+	}
+	return updated
+}
+
+// IsMaster returns true if the node has a master role
+func (r Identity) IsMaster() bool {
+	return r.ClusterRole == string(schema.ServiceRoleMaster)
+}
+
+// String returns a textual representation of this identity
+func (r Identity) String() string {
+	return fmt.Sprintf("node(hostname=%v, addr=%v, role=%v)",
+		r.Hostname, r.AdvertiseIP, r.Role)
+}
+
+func marshalIdentity(identity Identity) (string, error) {
+	bytes, err := json.Marshal(identity)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	return string(bytes), nil
+}
+
+func unmarshalIdentity(data string) (*Identity, error) {
+	var identity Identity
+	if err := json.Unmarshal([]byte(data), &identity); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := identity.Check(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &identity, nil
+}
+
+func hasTaint(taints []v1.Taint, taint v1.Taint) bool {
+	for _, existing := range taints {
+		if existing.MatchTaint(&taint) {
+			return true
+		}
+	}
+	return false
+}
+
+// isManagedLabel returns true if the label with the specified key
+// is set by Kubernetes or the runtime for every node and should not be restored
+func isManagedLabel(key string) bool {
+	switch key {
+	case defaults.KubernetesHostnameLabel, defaults.KubernetesAdvertiseIPLabel:
+		return true
+	}
+	for _, prefix := range managedPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isManagedTaint returns true if the taint is set by Kubernetes
+// itself, e.g. because the failed node is unreachable
+func isManagedTaint(taint v1.Taint) bool {
+	for _, prefix := range managedPrefixes {
+		if strings.HasPrefix(taint.Key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// managedPrefixes lists prefixes of labels and taints
+// managed by Kubernetes
+var managedPrefixes = []string{
+	"beta.kubernetes.io/",
+	"node.kubernetes.io/",
+	"node.cloudprovider.kubernetes.io/",
+	"failure-domain.beta.kubernetes.io/",
+}
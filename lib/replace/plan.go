@@ -0,0 +1,102 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replace
+
+import (
+	"fmt"
+
+	"github.com/gravitational/trace"
+)
+
+// PlanConfig describes the node replacement
+type PlanConfig struct {
+	// Identity is the identity of the node being replaced
+	Identity Identity
+	// Peer is the address of the cluster node the replacement joins to
+	Peer string
+	// Token is the cluster join token
+	Token string
+}
+
+// Check makes sure the configuration is valid
+func (r PlanConfig) Check() error {
+	if err := r.Identity.Check(); err != nil {
+		return trace.Wrap(err)
+	}
+	if r.Peer == "" {
+		return trace.BadParameter("missing Peer")
+	}
+	if r.Token == "" {
+		return trace.BadParameter("missing Token")
+	}
+	return nil
+}
+
+// Step is a single step of the node replacement
+type Step struct {
+	// Description describes what the step does
+	Description string
+	// Command is the command the user runs to perform the step.
+	// Empty if the step is performed automatically
+	Command string
+	// Rollback describes how to revert the step
+	Rollback string
+}
+
+// NewPlan returns the steps of the node replacement
+func NewPlan(config PlanConfig) ([]Step, error) {
+	if err := config.Check(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	identity := config.Identity
+	remove := fmt.Sprintf("Remove the failed node %v from Kubernetes and cluster state", identity.Hostname)
+	join := fmt.Sprintf("Join the replacement node to the cluster as %q: "+
+		"system packages are pulled from the cluster", identity.Role)
+	if identity.IsMaster() {
+		remove = fmt.Sprintf("Remove the failed node %v from etcd, Kubernetes and cluster state", identity.Hostname)
+		join += " and the node joins etcd as a new member"
+	}
+	return []Step{
+		{
+			Description: fmt.Sprintf("Record the identity of %v: role, %v labels and %v taints",
+				identity, len(identity.Labels), len(identity.Taints)),
+			Rollback: fmt.Sprintf("gravity replace --cancel %v", identity.Hostname),
+		},
+		{
+			Description: remove,
+			Rollback: fmt.Sprintf("Removal cannot be reverted, the failed node can only rejoin as a new node. "+
+				"If the removal fails, run 'gravity replace %v' again to retry", identity.Hostname),
+		},
+		{
+			Description: fmt.Sprintf("Set the hostname of the replacement node to %v and, if possible, assign it the address %v",
+				identity.Hostname, identity.AdvertiseIP),
+			Command:  fmt.Sprintf("sudo hostnamectl set-hostname %v", identity.Hostname),
+			Rollback: "Nothing to revert",
+		},
+		{
+			Description: join,
+			Command: fmt.Sprintf("sudo gravity join %v --advertise-addr=%v --token=%v --role=%v",
+				config.Peer, identity.AdvertiseIP, config.Token, identity.Role),
+			Rollback: "Use 'gravity plan' on the replacement node to resume or roll back the join operation. " +
+				"The identity is kept until a replacement node has joined",
+		},
+		{
+			Description: "Restore the recorded labels and taints on the replacement node once it becomes ready",
+			Rollback:    "Remove unwanted labels and taints with 'kubectl label' and 'kubectl taint'",
+		},
+	}, nil
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replace
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/schema"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"gopkg.in/check.v1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestReplace(t *testing.T) { check.TestingT(t) }
+
+type ReplaceSuite struct{}
+
+var _ = check.Suite(&ReplaceSuite{})
+
+func (s *ReplaceSuite) TestRecordsUserLabelsAndTaints(c *check.C) {
+	node := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "10.0.0.1",
+			Labels: map[string]string{
+				defaults.KubernetesHostnameLabel:    "10.0.0.1",
+				defaults.KubernetesAdvertiseIPLabel: "10.0.0.1",
+				"beta.kubernetes.io/os":             "linux",
+				"disk":                              "ssd",
+			},
+		},
+		Spec: v1.NodeSpec{
+			Taints: []v1.Taint{
+				{Key: "dedicated", Value: "db", Effect: v1.TaintEffectNoSchedule},
+				{Key: "node.kubernetes.io/unreachable", Effect: v1.TaintEffectNoExecute},
+			},
+		},
+	}
+	identity := NewIdentity(newServer(), node, time.Now())
+	c.Assert(identity.NodeName, check.Equals, "10.0.0.1")
+	c.Assert(identity.IsMaster(), check.Equals, true)
+	c.Assert(identity.Labels, check.DeepEquals, map[string]string{"disk": "ssd"})
+	c.Assert(identity.Taints, check.DeepEquals, []v1.Taint{
+		{Key: "dedicated", Value: "db", Effect: v1.TaintEffectNoSchedule},
+	})
+
+	data, err := marshalIdentity(identity)
+	c.Assert(err, check.IsNil)
+	parsed, err := unmarshalIdentity(data)
+	c.Assert(err, check.IsNil)
+	c.Assert(parsed.Labels, check.DeepEquals, identity.Labels)
+	c.Assert(parsed.Taints, check.DeepEquals, identity.Taints)
+}
+
+func (s *ReplaceSuite) TestMatchesReplacementNode(c *check.C) {
+	recorded := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	identity := NewIdentity(newServer(), nil, recorded)
+
+	var testCases = []struct {
+		node    v1.Node
+		matches bool
+		comment string
+	}{
+		{
+			node:    newNode("10.0.0.1", "node-1", "10.0.0.1", recorded.Add(-time.Hour)),
+			matches: false,
+			comment: "failed node itself",
+		},
+		{
+			node:    newNode("10.0.0.1", "node-new", "10.0.0.1", recorded.Add(time.Hour)),
+			matches: true,
+			comment: "same address",
+		},
+		{
+			node:    newNode("10.0.0.9", "node-1", "10.0.0.9", recorded.Add(time.Hour)),
+			matches: true,
+			comment: "same hostname",
+		},
+		{
+			node:    newNode("10.0.0.9", "node-9", "10.0.0.9", recorded.Add(time.Hour)),
+			matches: false,
+			comment: "unrelated node",
+		},
+	}
+	for _, tc := range testCases {
+		c.Assert(identity.Matches(tc.node), check.Equals, tc.matches, check.Commentf(tc.comment))
+	}
+}
+
+func (s *ReplaceSuite) TestRestoresLabelsAndTaints(c *check.C) {
+	identity := Identity{
+		Labels: map[string]string{"disk": "ssd"},
+		Taints: []v1.Taint{{Key: "dedicated", Value: "db", Effect: v1.TaintEffectNoSchedule}},
+	}
+	node := newNode("10.0.0.9", "node-1", "10.0.0.9", time.Now())
+	c.Assert(identity.Restore(&node), check.Equals, true)
+	c.Assert(node.Labels["disk"], check.Equals, "ssd")
+	c.Assert(node.Labels[defaults.KubernetesHostnameLabel], check.Equals, "10.0.0.9")
+	c.Assert(node.Spec.Taints, check.HasLen, 1)
+	c.Assert(identity.Restore(&node), check.Equals, false)
+}
+
+func (s *ReplaceSuite) TestPlanJoinsWithRecordedIdentity(c *check.C) {
+	identity := NewIdentity(newServer(), nil, time.Now())
+	steps, err := NewPlan(PlanConfig{
+		Identity: identity,
+		Peer:     "10.0.0.2:3009",
+		Token:    "token",
+	})
+	c.Assert(err, check.IsNil)
+	c.Assert(steps, check.HasLen, 5)
+	c.Assert(steps[3].Command, check.Equals,
+		"sudo gravity join 10.0.0.2:3009 --advertise-addr=10.0.0.1 --token=token --role=master")
+	for _, step := range steps {
+		c.Assert(step.Rollback, check.Not(check.Equals), "", check.Commentf(step.Description))
+	}
+}
+
+func newServer() storage.Server {
+	return storage.Server{
+		Hostname:    "node-1",
+		AdvertiseIP: "10.0.0.1",
+		Role:        "master",
+		ClusterRole: string(schema.ServiceRoleMaster),
+	}
+}
+
+func newNode(name, hostname, addr string, created time.Time) v1.Node {
+	return v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			CreationTimestamp: metav1.NewTime(created),
+			Labels:            map[string]string{defaults.KubernetesHostnameLabel: name},
+		},
+		Status: v1.NodeStatus{
+			Addresses: []v1.NodeAddress{
+				{Type: v1.NodeInternalIP, Address: addr},
+				{Type: v1.NodeHostName, Address: hostname},
+			},
+		},
+	}
+}
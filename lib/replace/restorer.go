@@ -0,0 +1,141 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replace
+
+import (
+	"context"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/rigging"
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// RestorerConfig configures the node identity restorer
+type RestorerConfig struct {
+	// Client is the Kubernetes client
+	Client kubernetes.Interface
+	// Interval is how often the replacement nodes are looked up
+	Interval time.Duration
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+}
+
+// CheckAndSetDefaults validates the configuration and sets default values
+func (r *RestorerConfig) CheckAndSetDefaults() error {
+	if r.Client == nil {
+		return trace.BadParameter("missing Client")
+	}
+	if r.Interval == 0 {
+		r.Interval = defaults.NodeReplacementSyncInterval
+	}
+	if r.FieldLogger == nil {
+		r.FieldLogger = logrus.WithField(trace.Component, "replace")
+	}
+	return nil
+}
+
+// NewRestorer returns a new node identity restorer
+func NewRestorer(config RestorerConfig) (*Restorer, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &Restorer{RestorerConfig: config}, nil
+}
+
+// Restorer restores the recorded identities on the replacement
+// nodes once they have joined the cluster
+type Restorer struct {
+	RestorerConfig
+}
+
+// Run restores identities periodically until the context is canceled
+func (r *Restorer) Run(ctx context.Context) error {
+	utils.RunPeriodically(ctx, r.Interval, r.FieldLogger,
+		"restore node identities", r.Sync)
+	return nil
+}
+
+// Sync restores the identity of every node being replaced whose
+// replacement has joined the cluster and become ready.
+// The identity is forgotten once it has been restored
+func (r *Restorer) Sync(ctx context.Context) error {
+	identities, err := List(r.Client)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if len(identities) == 0 {
+		return nil
+	}
+	nodes, err := r.Client.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		return trace.Wrap(rigging.ConvertError(err))
+	}
+	var errors []error
+	for _, identity := range identities {
+		node := findReplacement(identity, nodes.Items)
+		if node == nil {
+			continue
+		}
+		if err := r.restore(identity, *node); err != nil {
+			errors = append(errors, trace.Wrap(err))
+		}
+	}
+	return trace.NewAggregate(errors...)
+}
+
+func (r *Restorer) restore(identity Identity, node v1.Node) error {
+	if identity.Restore(&node) {
+		r.Infof("Restoring identity of %v on node %v.", identity, node.Name)
+		_, err := r.Client.CoreV1().Nodes().Update(&node)
+		if err != nil {
+			return trace.Wrap(rigging.ConvertError(err))
+		}
+	}
+	err := Delete(r.Client, identity.Hostname)
+	if err != nil && !trace.IsNotFound(err) {
+		return trace.Wrap(err)
+	}
+	r.Infof("Node %v has been replaced with %v.", identity, node.Name)
+	return nil
+}
+
+// findReplacement returns the ready node that replaces
+// the node with the specified identity
+func findReplacement(identity Identity, nodes []v1.Node) *v1.Node {
+	for _, node := range nodes {
+		if identity.Matches(node) && isReady(node) {
+			return &node
+		}
+	}
+	return nil
+}
+
+func isReady(node v1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == v1.NodeReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
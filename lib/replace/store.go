@@ -0,0 +1,112 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replace
+
+import (
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/defaults"
+
+	"github.com/gravitational/rigging"
+	"github.com/gravitational/trace"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Save records the identity of the node being replaced.
+// Identities are stored in a config map keyed by hostname
+func Save(client kubernetes.Interface, identity Identity) error {
+	if err := identity.Check(); err != nil {
+		return trace.Wrap(err)
+	}
+	data, err := marshalIdentity(identity)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	configMaps := client.CoreV1().ConfigMaps(defaults.KubeSystemNamespace)
+	configMap, err := configMaps.Get(constants.NodeReplacementsConfigMap, metav1.GetOptions{})
+	err = rigging.ConvertError(err)
+	if err != nil && !trace.IsNotFound(err) {
+		return trace.Wrap(err)
+	}
+	if trace.IsNotFound(err) {
+		_, err = configMaps.Create(&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      constants.NodeReplacementsConfigMap,
+				Namespace: defaults.KubeSystemNamespace,
+			},
+			Data: map[string]string{identity.Hostname: data},
+		})
+		return trace.Wrap(rigging.ConvertError(err))
+	}
+	if configMap.Data == nil {
+		configMap.Data = make(map[string]string)
+	}
+	configMap.Data[identity.Hostname] = data
+	_, err = configMaps.Update(configMap)
+	return trace.Wrap(rigging.ConvertError(err))
+}
+
+// List returns identities of all nodes being replaced
+func List(client kubernetes.Interface) (identities []Identity, err error) {
+	configMap, err := client.CoreV1().ConfigMaps(defaults.KubeSystemNamespace).
+		Get(constants.NodeReplacementsConfigMap, metav1.GetOptions{})
+	if err != nil {
+		err = rigging.ConvertError(err)
+		if trace.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, trace.Wrap(err)
+	}
+	for hostname, data := range configMap.Data {
+		identity, err := unmarshalIdentity(data)
+		if err != nil {
+			return nil, trace.Wrap(err, "invalid identity of node %v", hostname)
+		}
+		identities = append(identities, *identity)
+	}
+	return identities, nil
+}
+
+// Get returns the identity of the node with the specified hostname
+func Get(client kubernetes.Interface, hostname string) (*Identity, error) {
+	identities, err := List(client)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	for _, identity := range identities {
+		if identity.Hostname == hostname {
+			return &identity, nil
+		}
+	}
+	return nil, trace.NotFound("node %v is not being replaced", hostname)
+}
+
+// Delete removes the identity of the node with the specified hostname
+func Delete(client kubernetes.Interface, hostname string) error {
+	configMaps := client.CoreV1().ConfigMaps(defaults.KubeSystemNamespace)
+	configMap, err := configMaps.Get(constants.NodeReplacementsConfigMap, metav1.GetOptions{})
+	if err != nil {
+		return trace.Wrap(rigging.ConvertError(err))
+	}
+	if _, ok := configMap.Data[hostname]; !ok {
+		return trace.NotFound("node %v is not being replaced", hostname)
+	}
+	delete(configMap.Data, hostname)
+	_, err = configMaps.Update(configMap)
+	return trace.Wrap(rigging.ConvertError(err))
+}
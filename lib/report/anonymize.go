@@ -0,0 +1,325 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+)
+
+// Anonymize writes a copy of the cluster diagnostics report from the file
+// at the specified path into w, with hostnames, IP addresses, cluster names
+// and user names replaced with pseudonyms.
+//
+// The same value is replaced with the same pseudonym throughout the report,
+// including nested archives and file names, so that the correlations between
+// the report files are kept. Hostnames, cluster and user names are read from
+// the cluster information in the report, IPv4 addresses are detected anywhere
+// in the report contents
+func Anonymize(filename string, w io.Writer) error {
+	anonymizer := newAnonymizer()
+	if err := anonymizer.collect(filename); err != nil {
+		return trace.Wrap(err)
+	}
+	file, err := os.Open(filename)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	defer file.Close()
+	return trace.Wrap(anonymizer.stream(w, file))
+}
+
+func newAnonymizer() *anonymizer {
+	return &anonymizer{
+		pseudonyms: make(map[string]string),
+		counters:   make(map[string]int),
+		ips:        make(map[string]string),
+	}
+}
+
+type anonymizer struct {
+	// pseudonyms maps lower-cased identifiers to their pseudonyms
+	pseudonyms map[string]string
+	// counters counts identifiers in each category
+	counters map[string]int
+	// identifiers matches any of the known identifiers
+	identifiers *regexp.Regexp
+	// ips maps IP addresses to their pseudonyms
+	ips map[string]string
+}
+
+// collect reads the identifiers to replace from the report
+func (r *anonymizer) collect(filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return trace.Wrap(err, "report is not a gzipped tarball")
+	}
+	defer gz.Close()
+	tarball := tar.NewReader(gz)
+	for {
+		header, err := tarball.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		name := path.Base(header.Name)
+		for _, suffix := range []string{"-" + DebugLogsFilename, "-" + KubernetesLogsFilename} {
+			if strings.HasSuffix(name, suffix) {
+				r.add(categoryNode, strings.TrimSuffix(name, suffix))
+			}
+		}
+		if name != SiteInfoFilename {
+			continue
+		}
+		var site storage.Site
+		if err := json.NewDecoder(tarball).Decode(&site); err != nil {
+			return trace.Wrap(err, "failed to read cluster information")
+		}
+		r.add(categoryCluster, site.Domain)
+		r.add(categoryUser, site.CreatedBy)
+		for _, server := range site.ClusterState.Servers {
+			r.add(categoryNode, server.Hostname)
+			r.add(categoryNode, server.Nodename)
+		}
+	}
+	r.compile()
+	return nil
+}
+
+// add assigns the next pseudonym of the category to the identifier.
+// IP addresses are skipped as they are replaced separately
+func (r *anonymizer) add(category, identifier string) {
+	identifier = strings.ToLower(strings.TrimSpace(identifier))
+	if identifier == "" || net.ParseIP(identifier) != nil {
+		return
+	}
+	if _, ok := r.pseudonyms[identifier]; ok {
+		return
+	}
+	r.counters[category]++
+	r.pseudonyms[identifier] = fmt.Sprintf("%v-%v", category, r.counters[category])
+}
+
+// compile builds the expression that matches the identifiers,
+// longer identifiers are matched first
+func (r *anonymizer) compile() {
+	if len(r.pseudonyms) == 0 {
+		return
+	}
+	identifiers := make([]string, 0, len(r.pseudonyms))
+	for identifier := range r.pseudonyms {
+		identifiers = append(identifiers, regexp.QuoteMeta(identifier))
+	}
+	sort.Slice(identifiers, func(i, j int) bool {
+		if len(identifiers[i]) != len(identifiers[j]) {
+			return len(identifiers[i]) > len(identifiers[j])
+		}
+		return identifiers[i] < identifiers[j]
+	})
+	r.identifiers = regexp.MustCompile(`(?i)` + strings.Join(identifiers, "|"))
+}
+
+// stream anonymizes the data from reader into w.
+// Gzipped data and tarballs are processed recursively
+func (r *anonymizer) stream(w io.Writer, reader io.Reader) error {
+	buf := bufio.NewReaderSize(reader, 64*1024)
+	header, err := buf.Peek(tarHeaderSize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return trace.Wrap(err)
+	}
+	switch {
+	case bytes.HasPrefix(header, gzipMagic):
+		gz, err := gzip.NewReader(buf)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		defer gz.Close()
+		out := gzip.NewWriter(w)
+		if err := r.stream(out, gz); err != nil {
+			return trace.Wrap(err)
+		}
+		return trace.Wrap(out.Close())
+	case len(header) >= tarHeaderSize && bytes.HasPrefix(header[tarMagicOffset:], tarMagic):
+		return trace.Wrap(r.tarball(w, buf))
+	}
+	for {
+		line, err := buf.ReadBytes('\n')
+		if len(line) != 0 {
+			if _, err := w.Write(r.replace(line)); err != nil {
+				return trace.Wrap(err)
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
+}
+
+// tarball anonymizes the names and the contents of the tarball entries
+func (r *anonymizer) tarball(w io.Writer, reader io.Reader) error {
+	tarball := tar.NewReader(reader)
+	out := tar.NewWriter(w)
+	for {
+		header, err := tarball.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		header.Name = string(r.replace([]byte(header.Name)))
+		header.Linkname = string(r.replace([]byte(header.Linkname)))
+		delete(header.PAXRecords, "path")
+		delete(header.PAXRecords, "linkpath")
+		if header.Typeflag != tar.TypeReg && header.Typeflag != tar.TypeRegA {
+			if err := out.WriteHeader(header); err != nil {
+				return trace.Wrap(err)
+			}
+			continue
+		}
+		if err := r.entry(out, header, tarball); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return trace.Wrap(out.Close())
+}
+
+// entry writes the anonymized contents of the tarball entry.
+// The contents are buffered in a temporary file since
+// the size of the entry has to be known in advance
+func (r *anonymizer) entry(out *tar.Writer, header *tar.Header, reader io.Reader) error {
+	file, err := ioutil.TempFile("", "report")
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	defer func() {
+		file.Close()
+		os.Remove(file.Name())
+	}()
+	if err := r.stream(file, reader); err != nil {
+		return trace.Wrap(err)
+	}
+	size, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	header.Size = size
+	if err := out.WriteHeader(header); err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = io.Copy(out, file)
+	return trace.Wrap(err)
+}
+
+// replace returns the data with IP addresses and identifiers replaced
+func (r *anonymizer) replace(data []byte) []byte {
+	data = ipv4Regexp.ReplaceAllFunc(data, r.replaceIP)
+	if r.identifiers == nil {
+		return data
+	}
+	matches := r.identifiers.FindAllIndex(data, -1)
+	if len(matches) == 0 {
+		return data
+	}
+	var out bytes.Buffer
+	var last int
+	for _, match := range matches {
+		start, end := match[0], match[1]
+		// only replace whole words so that e.g. node1 is not replaced in node10
+		if (start > 0 && isAlphanumeric(data[start-1])) || (end < len(data) && isAlphanumeric(data[end])) {
+			continue
+		}
+		out.Write(data[last:start])
+		out.WriteString(r.pseudonyms[strings.ToLower(string(data[start:end]))])
+		last = end
+	}
+	out.Write(data[last:])
+	return out.Bytes()
+}
+
+// replaceIP returns the pseudonym of the IP address.
+// Loopback and unspecified addresses, as well as network masks, are kept
+func (r *anonymizer) replaceIP(value []byte) []byte {
+	ip := net.ParseIP(string(value)).To4()
+	if ip == nil || ip.IsLoopback() || ip.IsUnspecified() {
+		return value
+	}
+	if _, bits := net.IPMask(ip).Size(); bits != 0 {
+		return value
+	}
+	if pseudonym, ok := r.ips[ip.String()]; ok {
+		return []byte(pseudonym)
+	}
+	// pseudonyms are allocated from the 198.18.0.0/15 range
+	// reserved for benchmarking which is not used in real networks
+	n := len(r.ips) + 1
+	pseudonym := net.IPv4(198, byte(18+(n>>16)&1), byte(n>>8), byte(n)).String()
+	r.ips[ip.String()] = pseudonym
+	return []byte(pseudonym)
+}
+
+func isAlphanumeric(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+const (
+	// KubernetesLogsFilename is the name suffix of the cluster report file
+	// with the Kubernetes diagnostics
+	KubernetesLogsFilename = "k8s-logs.tar"
+
+	categoryNode    = "node"
+	categoryCluster = "cluster"
+	categoryUser    = "user"
+
+	tarHeaderSize  = 512
+	tarMagicOffset = 257
+)
+
+var (
+	gzipMagic  = []byte{0x1f, 0x8b}
+	tarMagic   = []byte("ustar")
+	ipv4Regexp = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)
+)
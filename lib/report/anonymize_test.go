@@ -0,0 +1,77 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+)
+
+type AnonymizeSuite struct{}
+
+var _ = Suite(&AnonymizeSuite{})
+
+func (r *AnonymizeSuite) TestAnonymizesReport(c *C) {
+	report := newTarball(c, map[string]string{
+		SiteInfoFilename: `{"domain": "prod.acme.com", "created_by": "alice@acme.com",
+"cluster_state": {"servers": [{"hostname": "node1", "advertise_ip": "10.0.0.1"}]}}`,
+		"node1-" + DebugLogsFilename: newTarball(c, map[string]string{
+			"node1/syslog": "node1 kubelet: connected to 10.0.0.1:6443 from 127.0.0.1\n" +
+				"NODE1 joined prod.acme.com, node10 mask 255.255.255.0\n",
+		}),
+	})
+	path := filepath.Join(c.MkDir(), "report.tar.gz")
+	c.Assert(ioutil.WriteFile(path, []byte(report), 0644), IsNil)
+
+	var out bytes.Buffer
+	c.Assert(Anonymize(path, &out), IsNil)
+
+	files := readTarball(c, &out)
+	c.Assert(files, HasLen, 2)
+	c.Assert(files[SiteInfoFilename], Equals, `{"domain": "cluster-1", "created_by": "user-1",
+"cluster_state": {"servers": [{"hostname": "node-1", "advertise_ip": "198.18.0.1"}]}}`)
+	logs, ok := files["node-1-"+DebugLogsFilename]
+	c.Assert(ok, Equals, true)
+	c.Assert(readTarball(c, bytes.NewBufferString(logs)), DeepEquals, map[string]string{
+		"node-1/syslog": "node-1 kubelet: connected to 198.18.0.1:6443 from 127.0.0.1\n" +
+			"node-1 joined cluster-1, node10 mask 255.255.255.0\n",
+	})
+}
+
+func readTarball(c *C, r io.Reader) map[string]string {
+	gz, err := gzip.NewReader(r)
+	c.Assert(err, IsNil)
+	tarball := tar.NewReader(gz)
+	files := make(map[string]string)
+	for {
+		header, err := tarball.Next()
+		if err == io.EOF {
+			break
+		}
+		c.Assert(err, IsNil)
+		data, err := ioutil.ReadAll(tarball)
+		c.Assert(err, IsNil)
+		files[header.Name] = string(data)
+	}
+	return files
+}
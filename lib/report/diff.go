@@ -0,0 +1,397 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/trace"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	// SiteInfoFilename is the name of the cluster report file with the
+	// JSON-dumped cluster
+	SiteInfoFilename = "site.json"
+	// DebugLogsFilename is the name suffix of the cluster report files
+	// with the system diagnostics of individual nodes
+	DebugLogsFilename = "debug-logs.tar"
+	// PackagesFilename is the name of the system report file with
+	// the list of packages
+	PackagesFilename = "gravity-packages.yaml"
+	// PlanetStatusFilename is the name of the system report file with
+	// the output of planet status
+	PlanetStatusFilename = "planet-status"
+	// EtcFilename is the name of the system report file with the
+	// contents of /etc
+	EtcFilename = "etc-logs.tar.gz"
+)
+
+// Summary describes the parts of a cluster diagnostics report
+// that are compared between reports
+type Summary struct {
+	// Cluster maps cluster configuration attributes to values
+	Cluster map[string]string
+	// Health maps health probes to their status
+	Health map[string]string
+	// Nodes maps node names to node summaries
+	Nodes map[string]*NodeSummary
+}
+
+// NodeSummary describes the system diagnostics of a single node
+type NodeSummary struct {
+	// Packages maps package names to versions
+	Packages map[string]string
+	// Files maps configuration file paths to content digests
+	Files map[string]string
+}
+
+// LoadSummary summarizes the cluster diagnostics report in the specified file
+func LoadSummary(path string) (*Summary, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	defer file.Close()
+	summary, err := ReadSummary(file)
+	if err != nil {
+		return nil, trace.Wrap(err, "failed to read report %v", path)
+	}
+	return summary, nil
+}
+
+// ReadSummary summarizes the cluster diagnostics report, a gzipped tarball,
+// from the specified reader
+func ReadSummary(r io.Reader) (*Summary, error) {
+	summary := &Summary{
+		Cluster: make(map[string]string),
+		Health:  make(map[string]string),
+		Nodes:   make(map[string]*NodeSummary),
+	}
+	err := forEachFile(r, func(name string, r io.Reader) error {
+		switch {
+		case name == SiteInfoFilename:
+			return trace.Wrap(summary.readCluster(r))
+		case strings.HasSuffix(name, "-"+DebugLogsFilename):
+			node := strings.TrimSuffix(name, "-"+DebugLogsFilename)
+			return trace.Wrap(summary.readNode(node, r), "failed to read diagnostics of %v", node)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return summary, nil
+}
+
+func (r *Summary) readCluster(reader io.Reader) error {
+	var cluster map[string]interface{}
+	if err := json.NewDecoder(reader).Decode(&cluster); err != nil {
+		return trace.Wrap(err)
+	}
+	for _, key := range ignoredClusterAttributes {
+		delete(cluster, key)
+	}
+	flatten("", cluster, r.Cluster)
+	return nil
+}
+
+func (r *Summary) readNode(name string, reader io.Reader) error {
+	node := &NodeSummary{
+		Packages: make(map[string]string),
+		Files:    make(map[string]string),
+	}
+	r.Nodes[name] = node
+	return forEachFile(reader, func(name string, reader io.Reader) error {
+		switch name {
+		case PackagesFilename:
+			return trace.Wrap(node.readPackages(reader))
+		case PlanetStatusFilename:
+			return trace.Wrap(r.readHealth(reader))
+		case EtcFilename:
+			return trace.Wrap(node.readFiles(reader))
+		}
+		return nil
+	})
+}
+
+func (r *NodeSummary) readPackages(reader io.Reader) error {
+	versions := make(map[string][]string)
+	decoder := yaml.NewDecoder(reader)
+	for {
+		var envelope struct {
+			Name string `yaml:"name"`
+		}
+		err := decoder.Decode(&envelope)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if envelope.Name == "" {
+			continue
+		}
+		name, version := envelope.Name, ""
+		if i := strings.LastIndex(envelope.Name, ":"); i != -1 {
+			name, version = envelope.Name[:i], envelope.Name[i+1:]
+		}
+		versions[name] = append(versions[name], version)
+	}
+	// several versions of the same package can be present on the node
+	for name, list := range versions {
+		sort.Strings(list)
+		r.Packages[name] = strings.Join(list, ", ")
+	}
+	return nil
+}
+
+func (r *NodeSummary) readFiles(reader io.Reader) error {
+	return forEachFile(reader, func(name string, reader io.Reader) error {
+		hash := sha256.New()
+		if _, err := io.Copy(hash, reader); err != nil {
+			return trace.Wrap(err)
+		}
+		r.Files[path.Join("/", name)] = hex.EncodeToString(hash.Sum(nil))[:12]
+		return nil
+	})
+}
+
+func (r *Summary) readHealth(reader io.Reader) error {
+	var status pb.SystemStatus
+	if err := json.NewDecoder(reader).Decode(&status); err != nil {
+		return trace.Wrap(err)
+	}
+	r.Health["cluster"] = status.Status.String()
+	for _, node := range status.Nodes {
+		r.Health[node.Name] = node.Status.String()
+		for _, probe := range node.Probes {
+			key := fmt.Sprintf("%v %v", node.Name, probe.Checker)
+			if probe.Detail != "" {
+				key = fmt.Sprintf("%v %v", key, probe.Detail)
+			}
+			value := probe.Status.String()
+			if probe.Error != "" {
+				value = fmt.Sprintf("%v: %v", value, probe.Error)
+			}
+			r.Health[key] = value
+		}
+	}
+	return nil
+}
+
+// Change describes a difference between two reports
+type Change struct {
+	// Section is the report section: cluster, health, packages or files
+	Section string
+	// Node is the node the change is for, empty for cluster-wide changes
+	Node string
+	// Key is the changed attribute
+	Key string
+	// Old is the value in the first report, empty if the attribute has been added
+	Old string
+	// New is the value in the second report, empty if the attribute has been removed
+	New string
+}
+
+// String formats the change as a single line
+func (r Change) String() string {
+	key := r.Key
+	if r.Node != "" {
+		key = fmt.Sprintf("%v: %v", r.Node, r.Key)
+	}
+	switch {
+	case r.Old == "":
+		return fmt.Sprintf("+ %v = %v", key, r.New)
+	case r.New == "":
+		return fmt.Sprintf("- %v = %v", key, r.Old)
+	}
+	return fmt.Sprintf("~ %v: %v -> %v", key, r.Old, r.New)
+}
+
+// Diff returns the changes between the specified reports
+func Diff(a, b *Summary) (changes []Change) {
+	changes = append(changes, diffMaps(SectionCluster, "", a.Cluster, b.Cluster)...)
+	changes = append(changes, diffMaps(SectionHealth, "", a.Health, b.Health)...)
+	nodes := make(map[string]struct{})
+	for name := range a.Nodes {
+		nodes[name] = struct{}{}
+	}
+	for name := range b.Nodes {
+		nodes[name] = struct{}{}
+	}
+	for _, name := range sortedKeys(nodes) {
+		nodeA, nodeB := a.Nodes[name], b.Nodes[name]
+		switch {
+		case nodeA == nil:
+			changes = append(changes, Change{Section: SectionNodes, Key: name, New: "present"})
+			continue
+		case nodeB == nil:
+			changes = append(changes, Change{Section: SectionNodes, Key: name, Old: "present"})
+			continue
+		}
+		changes = append(changes, diffMaps(SectionPackages, name, nodeA.Packages, nodeB.Packages)...)
+		changes = append(changes, diffMaps(SectionFiles, name, nodeA.Files, nodeB.Files)...)
+	}
+	return changes
+}
+
+const (
+	// SectionCluster lists changes of the cluster configuration
+	SectionCluster = "cluster"
+	// SectionHealth lists changes of the health probes
+	SectionHealth = "health"
+	// SectionNodes lists added and removed nodes
+	SectionNodes = "nodes"
+	// SectionPackages lists changes of packages on nodes
+	SectionPackages = "packages"
+	// SectionFiles lists changes of configuration files on nodes
+	SectionFiles = "files"
+)
+
+func diffMaps(section, node string, a, b map[string]string) (changes []Change) {
+	keys := make(map[string]struct{})
+	for key := range a {
+		keys[key] = struct{}{}
+	}
+	for key := range b {
+		keys[key] = struct{}{}
+	}
+	for _, key := range sortedKeys(keys) {
+		if a[key] == b[key] {
+			continue
+		}
+		changes = append(changes, Change{
+			Section: section,
+			Node:    node,
+			Key:     key,
+			Old:     a[key],
+			New:     b[key],
+		})
+	}
+	return changes
+}
+
+func sortedKeys(keys map[string]struct{}) []string {
+	result := make([]string, 0, len(keys))
+	for key := range keys {
+		result = append(result, key)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// flatten converts the specified JSON value into a set of attributes
+// with dot-separated paths as keys
+func flatten(prefix string, value interface{}, out map[string]string) {
+	key := func(name string) string {
+		if prefix == "" {
+			return name
+		}
+		return fmt.Sprintf("%v.%v", prefix, name)
+	}
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for name, item := range value {
+			flatten(key(name), item, out)
+		}
+	case []interface{}:
+		for i, item := range value {
+			flatten(key(itemName(i, item)), item, out)
+		}
+	case nil:
+	default:
+		if s := fmt.Sprint(value); s != "" {
+			out[prefix] = s
+		}
+	}
+}
+
+// itemName returns the name of the list item used in attribute paths.
+// Items are named after the value of one of the well-known identifying
+// fields, so that reordered lists do not produce spurious differences
+func itemName(i int, item interface{}) string {
+	if object, ok := item.(map[string]interface{}); ok {
+		for _, field := range []string{"hostname", "name", "advertise_ip"} {
+			if name, ok := object[field].(string); ok && name != "" {
+				return name
+			}
+		}
+	}
+	return fmt.Sprint(i)
+}
+
+// ignoredClusterAttributes lists cluster attributes that are either
+// irrelevant or change without changes to the cluster
+var ignoredClusterAttributes = []string{
+	"created",
+	"license",
+	"provisioner_state",
+	"resources",
+	"next_update_check",
+}
+
+// forEachFile invokes handler for each regular file in the tarball from
+// the specified reader. Compressed tarballs are detected automatically
+func forEachFile(r io.Reader, handler func(name string, r io.Reader) error) error {
+	reader, err := decompress(r)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	tarball := tar.NewReader(reader)
+	for {
+		header, err := tarball.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if header.Typeflag != tar.TypeReg && header.Typeflag != tar.TypeRegA {
+			continue
+		}
+		name := strings.TrimPrefix(path.Clean(header.Name), "/")
+		if err := handler(name, tarball); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+}
+
+// decompress returns a reader that decompresses the data if it is gzipped
+func decompress(r io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(r)
+	magic, err := buffered.Peek(2)
+	if err != nil && err != io.EOF {
+		return nil, trace.Wrap(err)
+	}
+	if len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		return gzip.NewReader(buffered)
+	}
+	return buffered, nil
+}
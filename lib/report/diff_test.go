@@ -0,0 +1,141 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+type DiffSuite struct{}
+
+var _ = Suite(&DiffSuite{})
+
+func (r *DiffSuite) TestDiffsReports(c *C) {
+	before := newTestReport(c, testCluster{
+		site: `{"domain": "example.com", "created": "2018-01-01T00:00:00Z",
+"cluster_state": {"servers": [{"hostname": "node-1", "advertise_ip": "10.0.0.1", "role": "master"}]}}`,
+		nodes: map[string]testNode{
+			"node-1": {
+				packages: []string{"gravitational.io/planet:5.0.0", "gravitational.io/teleport:2.4.0"},
+				status:   `{"status": "running", "nodes": [{"name": "node-1", "status": "running", "probes": [{"checker": "docker", "status": "running"}]}]}`,
+				etc:      map[string]string{"etc/hosts": "127.0.0.1 localhost", "etc/resolv.conf": "nameserver 8.8.8.8"},
+			},
+		},
+	})
+	after := newTestReport(c, testCluster{
+		site: `{"domain": "example.com", "created": "2018-02-01T00:00:00Z",
+"cluster_state": {"servers": [{"hostname": "node-1", "advertise_ip": "10.0.0.1", "role": "node"}]}}`,
+		nodes: map[string]testNode{
+			"node-1": {
+				packages: []string{"gravitational.io/planet:5.0.1", "gravitational.io/dns-app:0.1.0"},
+				status:   `{"status": "degraded", "nodes": [{"name": "node-1", "status": "degraded", "probes": [{"checker": "docker", "status": "failed", "error": "docker is down"}]}]}`,
+				etc:      map[string]string{"etc/hosts": "127.0.0.1 localhost", "etc/resolv.conf": "nameserver 1.1.1.1"},
+			},
+			"node-2": {},
+		},
+	})
+
+	var changes []string
+	for _, change := range Diff(before, after) {
+		changes = append(changes, change.Section+" "+change.String())
+	}
+	c.Assert(changes, DeepEquals, []string{
+		"cluster ~ cluster_state.servers.node-1.role: master -> node",
+		"health ~ cluster: Running -> Degraded",
+		"health ~ node-1: Running -> Degraded",
+		"health ~ node-1 docker: Running -> Failed: docker is down",
+		"packages + node-1: gravitational.io/dns-app = 0.1.0",
+		"packages ~ node-1: gravitational.io/planet: 5.0.0 -> 5.0.1",
+		"packages - node-1: gravitational.io/teleport = 2.4.0",
+		"files ~ node-1: /etc/resolv.conf: " + digest("nameserver 8.8.8.8") + " -> " + digest("nameserver 1.1.1.1"),
+		"nodes + node-2 = present",
+	})
+}
+
+func (r *DiffSuite) TestIdenticalReportsHaveNoChanges(c *C) {
+	cluster := testCluster{
+		site: `{"domain": "example.com"}`,
+		nodes: map[string]testNode{
+			"node-1": {packages: []string{"gravitational.io/planet:5.0.0"}},
+		},
+	}
+	c.Assert(Diff(newTestReport(c, cluster), newTestReport(c, cluster)), HasLen, 0)
+}
+
+type testCluster struct {
+	site  string
+	nodes map[string]testNode
+}
+
+type testNode struct {
+	packages []string
+	status   string
+	etc      map[string]string
+}
+
+func newTestReport(c *C, cluster testCluster) *Summary {
+	files := map[string]string{SiteInfoFilename: cluster.site}
+	for name, node := range cluster.nodes {
+		var packages []string
+		for _, pkg := range node.packages {
+			packages = append(packages, "name: "+pkg+"\nsize: 1024\n")
+		}
+		system := map[string]string{
+			PackagesFilename: strings.Join(packages, "---\n"),
+			EtcFilename:      newTarball(c, node.etc),
+		}
+		if node.status != "" {
+			system[PlanetStatusFilename] = node.status
+		}
+		files[name+"-"+DebugLogsFilename] = newTarball(c, system)
+	}
+	summary, err := ReadSummary(strings.NewReader(newTarball(c, files)))
+	c.Assert(err, IsNil)
+	return summary
+}
+
+func newTarball(c *C, files map[string]string) string {
+	var buf bytes.Buffer
+	compressed := gzip.NewWriter(&buf)
+	tarball := tar.NewWriter(compressed)
+	for name, data := range files {
+		err := tarball.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(data)),
+			Typeflag: tar.TypeReg,
+		})
+		c.Assert(err, IsNil)
+		_, err = tarball.Write([]byte(data))
+		c.Assert(err, IsNil)
+	}
+	c.Assert(tarball.Close(), IsNil)
+	c.Assert(compressed.Close(), IsNil)
+	return buf.String()
+}
+
+func digest(data string) string {
+	hash := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(hash[:])[:12]
+}
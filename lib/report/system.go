@@ -60,7 +60,7 @@ func basicSystemInfo() Collectors {
 		Cmd("systemctl-host", "/bin/systemctl", "status"),
 		Cmd("dmesg", "cat", "/var/log/dmesg"),
 		// Fetch world-readable parts of /etc/
-		Script("etc-logs.tar.gz", tarball("/etc/")),
+		Script(EtcFilename, tarball("/etc/")),
 		// memory
 		Cmd("free", "free", "--human"),
 		Cmd("slabtop", "slabtop", "--once"),
@@ -73,7 +73,7 @@ func planetServices() Collectors {
 	return Collectors{
 		// etcd cluster health
 		Cmd("etcdctl", utils.PlanetCommandArgs("/usr/bin/etcdctl", "cluster-health")...),
-		Cmd("planet-status", utils.PlanetCommandArgs("/usr/bin/planet", "status")...),
+		Cmd(PlanetStatusFilename, utils.PlanetCommandArgs("/usr/bin/planet", "status")...),
 		// status of systemd units
 		Cmd("systemctl", utils.PlanetCommandArgs("/bin/systemctl", "status")...),
 	}
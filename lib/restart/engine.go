@@ -0,0 +1,53 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restart
+
+import (
+	libfsm "github.com/gravitational/gravity/lib/fsm"
+
+	"github.com/gravitational/trace"
+)
+
+// newMachine returns a new state machine for the rolling restart operation
+func newMachine(config Config) (*libfsm.FSM, error) {
+	registry := libfsm.NewRegistry()
+	registry.MustRegister(ChecksPhase, func(params libfsm.ExecutorParams, remote libfsm.Remote) (libfsm.PhaseExecutor, error) {
+		return &checksExecutor{
+			FieldLogger:    config.WithField("phase", params.Phase.ID),
+			ExecutorParams: params,
+			checkHealth:    config.checkHealth,
+		}, nil
+	})
+	registry.MustRegister(RestartPhase, func(params libfsm.ExecutorParams, remote libfsm.Remote) (libfsm.PhaseExecutor, error) {
+		return &restartExecutor{
+			FieldLogger:    config.WithField("phase", params.Phase.ID),
+			ExecutorParams: params,
+			Config:         config,
+		}, nil
+	})
+	machine, err := libfsm.NewOperatorMachine(libfsm.OperatorEngineConfig{
+		Operator:    config.Operator,
+		Operation:   config.Operation.Key(),
+		Spec:        registry.GetExecutor,
+		Command:     []string{"system", "rolling-restart"},
+		FieldLogger: config.FieldLogger,
+	}, config.Runner)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return machine, nil
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restart
+
+import (
+	"context"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	libfsm "github.com/gravitational/gravity/lib/fsm"
+	"github.com/gravitational/gravity/lib/status"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/systemservice"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/cenkalti/backoff"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+)
+
+// checksExecutor makes sure the cluster is healthy before any node is restarted
+type checksExecutor struct {
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+	// ExecutorParams is common executor params
+	libfsm.ExecutorParams
+	checkHealth func(context.Context, []storage.Server) error
+}
+
+// Execute verifies the cluster is healthy
+func (r *checksExecutor) Execute(ctx context.Context) error {
+	r.Progress.NextStep("Verifying the cluster is healthy")
+	err := r.checkHealth(ctx, r.Plan.Servers)
+	if err != nil {
+		return trace.Wrap(err, "refusing to restart nodes of an unhealthy cluster")
+	}
+	return nil
+}
+
+// PreCheck is a no-op
+func (*checksExecutor) PreCheck(context.Context) error {
+	return nil
+}
+
+// PostCheck is a no-op
+func (*checksExecutor) PostCheck(context.Context) error {
+	return nil
+}
+
+// Rollback is a no-op
+func (*checksExecutor) Rollback(context.Context) error {
+	return nil
+}
+
+// restartExecutor restarts the runtime container or one of its
+// services on the local node and waits for the cluster to recover
+type restartExecutor struct {
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+	// ExecutorParams is common executor params
+	libfsm.ExecutorParams
+	// Config is the restarter's configuration
+	Config
+}
+
+// PreCheck makes sure the cluster is healthy so that restarting
+// another node does not take down more than one node at a time
+func (r *restartExecutor) PreCheck(ctx context.Context) error {
+	err := r.checkHealth(ctx, r.Plan.Servers)
+	if err != nil {
+		return trace.Wrap(err, "refusing to restart node %v while the cluster is unhealthy",
+			r.Phase.Data.Server.Hostname)
+	}
+	return nil
+}
+
+// Execute restarts the runtime container or the service and waits
+// until the node and the cluster are healthy again
+func (r *restartExecutor) Execute(ctx context.Context) error {
+	server := r.Phase.Data.Server
+	unit := r.Phase.Data.Data
+	r.Progress.NextStep("Restarting %v on node %v", describeUnit(unit), server.Hostname)
+	if err := r.restart(ctx, unit); err != nil {
+		return trace.Wrap(err)
+	}
+
+	r.Progress.NextStep("Waiting for node %v to become healthy", server.Hostname)
+	// give the restarted services time to report their status so
+	// a stale health report is not mistaken for a recovered node
+	select {
+	case <-time.After(defaults.RollingRestartSettleDelay):
+	case <-ctx.Done():
+		return trace.Wrap(ctx.Err())
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.HealthTimeout)
+	defer cancel()
+	err := utils.RetryWithInterval(ctx, backoff.NewConstantBackOff(defaults.RetryInterval), func() error {
+		return trace.Wrap(r.checkHealth(ctx, r.Plan.Servers))
+	})
+	if err != nil {
+		return trace.Wrap(err, "node %v did not become healthy after restart", server.Hostname)
+	}
+	r.Infof("Restarted %v.", describeUnit(unit))
+	return nil
+}
+
+func (r *restartExecutor) restart(ctx context.Context, unit string) error {
+	if unit != "" {
+		out, err := utils.RunInPlanetCommand(ctx, r.FieldLogger, defaults.SystemctlBin, "restart", unit)
+		return trace.Wrap(err, "failed to restart %v: %s", unit, out)
+	}
+	if r.RuntimePackage == nil {
+		return trace.NotFound("runtime package is not installed on this node")
+	}
+	services, err := systemservice.New()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(services.RestartPackageService(*r.RuntimePackage))
+}
+
+// PostCheck is a no-op
+func (*restartExecutor) PostCheck(context.Context) error {
+	return nil
+}
+
+// Rollback is a no-op
+func (*restartExecutor) Rollback(context.Context) error {
+	return nil
+}
+
+// checkPlanetHealth returns an error if planet agents report
+// the cluster or any of the specified servers as unhealthy
+func checkPlanetHealth(ctx context.Context, servers []storage.Server) error {
+	agent, err := status.FromPlanetAgent(ctx, servers)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	for _, node := range agent.Nodes {
+		if node.Status != status.NodeHealthy {
+			return trace.CompareFailed("node %v is %v", node.Hostname, node.Status)
+		}
+	}
+	if agent.GetSystemStatus() != pb.SystemStatus_Running {
+		return trace.CompareFailed("cluster is degraded")
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restart
+
+import (
+	"fmt"
+	"path"
+
+	libfsm "github.com/gravitational/gravity/lib/fsm"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+)
+
+const (
+	// ChecksPhase is the phase that makes sure the cluster is healthy
+	// before any node is restarted
+	ChecksPhase = "/checks"
+	// RestartPhase is the phase that restarts nodes one by one
+	RestartPhase = "/restart"
+)
+
+// NewOperationPlan returns a new plan that restarts the runtime container,
+// or the specified unit inside it, on the given servers one by one.
+// Regular nodes are restarted before masters
+func NewOperationPlan(operation ops.SiteOperation, servers []storage.Server, unit string) (*storage.OperationPlan, error) {
+	masters, nodes := libfsm.SplitServers(servers)
+	if len(masters) == 0 {
+		return nil, trace.NotFound("no master servers found in cluster state")
+	}
+
+	checks := storage.OperationPhase{
+		ID:          ChecksPhase,
+		Description: "Verify the cluster is healthy",
+		Data: &storage.OperationPhaseData{
+			Server: &masters[0],
+		},
+	}
+
+	restart := storage.OperationPhase{
+		ID:          RestartPhase,
+		Description: fmt.Sprintf("Restart %v on cluster nodes one by one", describeUnit(unit)),
+		Requires:    []string{checks.ID},
+	}
+	for _, server := range append(nodes, masters...) {
+		server := server
+		phase := storage.OperationPhase{
+			ID:          path.Join(restart.ID, server.Hostname),
+			Description: fmt.Sprintf("Restart %v on node %q", describeUnit(unit), server.Hostname),
+			Data: &storage.OperationPhaseData{
+				Server: &server,
+				Data:   unit,
+			},
+		}
+		if len(restart.Phases) != 0 {
+			phase.Requires = []string{restart.Phases[len(restart.Phases)-1].ID}
+		}
+		restart.Phases = append(restart.Phases, phase)
+	}
+
+	return &storage.OperationPlan{
+		OperationID:   operation.ID,
+		OperationType: operation.Type,
+		AccountID:     operation.AccountID,
+		ClusterName:   operation.SiteDomain,
+		Phases:        []storage.OperationPhase{checks, restart},
+		Servers:       servers,
+	}, nil
+}
+
+func (r *Restarter) getOrCreateOperationPlan() (plan *storage.OperationPlan, err error) {
+	plan, err = r.Operator.GetOperationPlan(r.Operation.Key())
+	if err != nil && !trace.IsNotFound(err) {
+		return nil, trace.Wrap(err)
+	}
+
+	if trace.IsNotFound(err) {
+		plan, err = NewOperationPlan(*r.Operation, r.Servers, r.Unit)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		err = r.Operator.CreateOperationPlan(r.Operation.Key(), *plan)
+		if err != nil {
+			if trace.IsNotFound(err) {
+				return nil, trace.NotImplemented(
+					"cluster operator does not implement the API required for rolling restart. " +
+						"Please make sure you're running the command on a compatible cluster.")
+			}
+			return nil, trace.Wrap(err)
+		}
+	}
+
+	return plan, nil
+}
+
+// describeUnit returns the human-readable name of the restarted unit
+func describeUnit(unit string) string {
+	if unit == "" {
+		return "runtime container"
+	}
+	return fmt.Sprintf("service %v", unit)
+}
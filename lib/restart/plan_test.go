@@ -0,0 +1,105 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restart
+
+import (
+	"testing"
+
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/schema"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"gopkg.in/check.v1"
+)
+
+func TestRestart(t *testing.T) { check.TestingT(t) }
+
+type RestartSuite struct{}
+
+var _ = check.Suite(&RestartSuite{})
+
+func (s *RestartSuite) TestPlanRestartsNodesBeforeMasters(c *check.C) {
+	servers := []storage.Server{
+		{Hostname: "master-1", ClusterRole: string(schema.ServiceRoleMaster)},
+		{Hostname: "node-1", ClusterRole: string(schema.ServiceRoleNode)},
+		{Hostname: "master-2", ClusterRole: string(schema.ServiceRoleMaster)},
+	}
+	operation := ops.SiteOperation{
+		ID:         "1",
+		AccountID:  "a",
+		SiteDomain: "example.com",
+		Type:       ops.OperationRollingRestart,
+	}
+
+	plan, err := NewOperationPlan(operation, servers, "kube-kubelet")
+	c.Assert(err, check.IsNil)
+	c.Assert(plan.Phases, check.HasLen, 2)
+
+	checks, restart := plan.Phases[0], plan.Phases[1]
+	c.Assert(checks.ID, check.Equals, ChecksPhase)
+	c.Assert(checks.Data.Server.Hostname, check.Equals, "master-1")
+	c.Assert(restart.Requires, check.DeepEquals, []string{ChecksPhase})
+
+	var ids []string
+	for i, phase := range restart.Phases {
+		ids = append(ids, phase.ID)
+		c.Assert(phase.Data.Data, check.Equals, "kube-kubelet")
+		if i == 0 {
+			c.Assert(phase.Requires, check.HasLen, 0)
+		} else {
+			c.Assert(phase.Requires, check.DeepEquals, []string{ids[i-1]})
+		}
+	}
+	c.Assert(ids, check.DeepEquals, []string{
+		"/restart/node-1",
+		"/restart/master-1",
+		"/restart/master-2",
+	})
+}
+
+func (s *RestartSuite) TestPlanRequiresMaster(c *check.C) {
+	servers := []storage.Server{
+		{Hostname: "node-1", ClusterRole: string(schema.ServiceRoleNode)},
+	}
+	_, err := NewOperationPlan(ops.SiteOperation{ID: "1"}, servers, "")
+	c.Assert(err, check.NotNil)
+}
+
+func (s *RestartSuite) TestNormalizeUnit(c *check.C) {
+	var testCases = []struct {
+		unit     string
+		expected string
+		err      bool
+		comment  string
+	}{
+		{unit: "", expected: "", comment: "runtime container"},
+		{unit: "kubelet", expected: "kube-kubelet", comment: "alias"},
+		{unit: "etcd", expected: "etcd", comment: "unit name"},
+		{unit: "docker.service", expected: "docker.service", comment: "unit name with suffix"},
+		{unit: "etcd; reboot", err: true, comment: "invalid characters"},
+	}
+	for _, tc := range testCases {
+		comment := check.Commentf(tc.comment)
+		unit, err := NormalizeUnit(tc.unit)
+		if tc.err {
+			c.Assert(err, check.NotNil, comment)
+			continue
+		}
+		c.Assert(err, check.IsNil, comment)
+		c.Assert(unit, check.Equals, tc.expected, comment)
+	}
+}
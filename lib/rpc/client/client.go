@@ -25,6 +25,7 @@ import (
 	validationpb "github.com/gravitational/gravity/lib/network/validation/proto"
 	pb "github.com/gravitational/gravity/lib/rpc/proto"
 	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/tracing"
 
 	"github.com/gravitational/satellite/agent/proto/agentpb"
 	"github.com/gravitational/trace"
@@ -75,10 +76,17 @@ func New(ctx context.Context, config Config) (*client, error) {
 		grpc.WithBackoffMaxDelay(defaults.RPCAgentBackoffThreshold),
 		grpc.WithBlock(),
 		grpc.WithTransportCredentials(config.Credentials),
+		grpc.WithUnaryInterceptor(tracing.UnaryClientInterceptor),
+		grpc.WithStreamInterceptor(tracing.StreamClientInterceptor),
 	})
 
 	conn, err := grpc.DialContext(ctx, config.ServerAddr, opts...)
 	if err != nil {
+		// The handshake error is not returned by the blocking dial,
+		// so check explicitly whether it has failed due to clock skew
+		if skewErr := checkClockSkew(config.ServerAddr, config.Credentials); skewErr != nil {
+			return nil, trace.Wrap(skewErr)
+		}
 		return nil, trace.ConnectionProblem(err,
 			"failed to establish connection to server at %v", config.ServerAddr)
 	}
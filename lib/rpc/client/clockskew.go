@@ -0,0 +1,175 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/defaults"
+
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/credentials"
+)
+
+// ClockSkewError describes a failed TLS handshake with a remote agent
+// caused by the clocks of the local and the remote node being out of sync
+type ClockSkewError struct {
+	// ServerAddr is the address of the remote agent
+	ServerAddr string
+	// Local is true if the local clock is outside of the validity period
+	// of the remote certificate. Otherwise, the remote node has rejected
+	// the certificate of this node
+	Local bool
+	// Skew is the minimum detected offset of the local clock.
+	// Positive value means the local clock is behind, negative - ahead.
+	// Only set if Local is true
+	Skew time.Duration
+	// LocalTime is the local time at the moment of the handshake
+	LocalTime time.Time
+	// NotBefore is the start of the remote certificate validity period
+	NotBefore time.Time
+	// NotAfter is the end of the remote certificate validity period
+	NotAfter time.Time
+}
+
+// Error returns the diagnostic message for this error
+func (r *ClockSkewError) Error() string {
+	const hint = "synchronize the time on the cluster nodes, e.g. using NTP"
+	if !r.Local {
+		return fmt.Sprintf("TLS handshake with %v failed: the remote node rejected "+
+			"the certificate of this node as expired or not yet valid, the clock on %[1]v "+
+			"is likely out of sync with this node, %v", r.ServerAddr, hint)
+	}
+	if r.Skew > 0 {
+		return fmt.Sprintf("TLS handshake with %v failed: the clock on this node (%v) "+
+			"is behind by at least %v, the certificate of %[1]v is not valid before %v, %v",
+			r.ServerAddr, r.LocalTime.Format(constants.HumanDateFormatSeconds),
+			r.Skew, r.NotBefore.Format(constants.HumanDateFormatSeconds), hint)
+	}
+	return fmt.Sprintf("TLS handshake with %v failed: the clock on this node (%v) "+
+		"is ahead by at least %v, the certificate of %[1]v expired at %v, %v",
+		r.ServerAddr, r.LocalTime.Format(constants.HumanDateFormatSeconds),
+		-r.Skew, r.NotAfter.Format(constants.HumanDateFormatSeconds), hint)
+}
+
+// IsClockSkewError returns true if the specified error
+// is a TLS handshake failure caused by clock skew
+func IsClockSkewError(err error) bool {
+	return ClockSkew(err) != nil
+}
+
+// ClockSkew returns the clock skew error wrapped in the specified error
+// or nil, if err is not a clock skew error
+func ClockSkew(err error) *ClockSkewError {
+	if skewErr, ok := trace.Unwrap(err).(*ClockSkewError); ok {
+		return skewErr
+	}
+	return nil
+}
+
+// CheckClockSkew performs a TLS handshake with the agent at addr using the specified
+// credentials and returns a *ClockSkewError if the handshake fails because
+// the clock of either node is outside of the validity period of the peer's certificate.
+// Returns nil if the handshake succeeds or fails for any other reason
+func CheckClockSkew(ctx context.Context, addr string, creds credentials.TransportCredentials) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		logrus.WithError(err).Debugf("Failed to dial %v.", addr)
+		return nil
+	}
+	defer conn.Close()
+	tlsConn, _, err := creds.ClientHandshake(ctx, addr, conn)
+	if err == nil {
+		// With TLS 1.3 the server verifies the client certificate after
+		// the client has completed the handshake so the rejection
+		// is only observed on the first read
+		deadline, ok := ctx.Deadline()
+		if !ok {
+			deadline = time.Now().Add(defaults.ClockSkewCheckTimeout)
+		}
+		tlsConn.SetReadDeadline(deadline)
+		_, err = tlsConn.Read(make([]byte, 1))
+		tlsConn.Close()
+	}
+	if err == nil {
+		return nil
+	}
+	if skewErr := clockSkewFromError(addr, err, time.Now().UTC()); skewErr != nil {
+		return skewErr
+	}
+	logrus.WithError(err).Debugf("No clock skew detected with %v.", addr)
+	return nil
+}
+
+// checkClockSkew runs the clock skew diagnostic against the agent at addr
+// with the default timeout
+func checkClockSkew(addr string, creds credentials.TransportCredentials) error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaults.ClockSkewCheckTimeout)
+	defer cancel()
+	return CheckClockSkew(ctx, addr, creds)
+}
+
+// clockSkewFromError returns a *ClockSkewError if the specified TLS handshake error
+// has been caused by the certificate validity period check on either side
+func clockSkewFromError(addr string, err error, now time.Time) *ClockSkewError {
+	var certErr x509.CertificateInvalidError
+	if errors.As(err, &certErr) && certErr.Reason == x509.Expired && certErr.Cert != nil {
+		return &ClockSkewError{
+			ServerAddr: addr,
+			Local:      true,
+			Skew:       certificateSkew(*certErr.Cert, now),
+			LocalTime:  now,
+			NotBefore:  certErr.Cert.NotBefore,
+			NotAfter:   certErr.Cert.NotAfter,
+		}
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "remote error" &&
+		opErr.Err != nil && opErr.Err.Error() == tlsAlertCertificateExpired {
+		return &ClockSkewError{
+			ServerAddr: addr,
+			LocalTime:  now,
+		}
+	}
+	return nil
+}
+
+// certificateSkew returns the minimum offset of the clock with the specified
+// current time relative to the validity period of the given certificate.
+// Positive value means the clock is behind, negative - ahead
+func certificateSkew(cert x509.Certificate, now time.Time) time.Duration {
+	switch {
+	case now.Before(cert.NotBefore):
+		return cert.NotBefore.Sub(now).Round(time.Second)
+	case now.After(cert.NotAfter):
+		return cert.NotAfter.Sub(now).Round(time.Second)
+	}
+	return 0
+}
+
+// tlsAlertCertificateExpired is the description of the TLS alert
+// sent by the peer that failed to validate the certificate
+// due to its validity period
+const tlsAlertCertificateExpired = "tls: expired certificate"
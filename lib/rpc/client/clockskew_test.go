@@ -0,0 +1,187 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	pb "github.com/gravitational/gravity/lib/rpc/proto"
+
+	"github.com/gravitational/trace"
+	"google.golang.org/grpc/credentials"
+	"gopkg.in/check.v1"
+)
+
+func TestClient(t *testing.T) { check.TestingT(t) }
+
+type ClockSkewSuite struct{}
+
+var _ = check.Suite(&ClockSkewSuite{})
+
+func (s *ClockSkewSuite) TestDetectsLocalClockSkew(c *check.C) {
+	now := time.Date(2018, time.June, 1, 12, 0, 0, 0, time.UTC)
+	cert := &x509.Certificate{
+		NotBefore: now.Add(time.Hour),
+		NotAfter:  now.Add(10 * time.Hour),
+	}
+	err := &tls.CertificateVerificationError{
+		Err: x509.CertificateInvalidError{Cert: cert, Reason: x509.Expired},
+	}
+	skewErr := clockSkewFromError("192.168.1.2:3012", err, now)
+	c.Assert(skewErr, check.NotNil)
+	c.Assert(skewErr.Local, check.Equals, true)
+	c.Assert(skewErr.Skew, check.Equals, time.Hour)
+	c.Assert(skewErr.Error(), check.Matches, ".*behind by at least 1h0m0s.*")
+
+	skewErr = clockSkewFromError("192.168.1.2:3012", err, now.Add(12*time.Hour))
+	c.Assert(skewErr, check.NotNil)
+	c.Assert(skewErr.Skew, check.Equals, -2*time.Hour)
+	c.Assert(skewErr.Error(), check.Matches, ".*ahead by at least 2h0m0s.*")
+}
+
+func (s *ClockSkewSuite) TestDetectsRemoteClockSkew(c *check.C) {
+	err := &net.OpError{Op: "remote error", Err: errors.New(tlsAlertCertificateExpired)}
+	skewErr := clockSkewFromError("192.168.1.2:3012", err, time.Now())
+	c.Assert(skewErr, check.NotNil)
+	c.Assert(skewErr.Local, check.Equals, false)
+	c.Assert(IsClockSkewError(trace.Wrap(skewErr)), check.Equals, true)
+}
+
+func (s *ClockSkewSuite) TestIgnoresOtherErrors(c *check.C) {
+	errs := []error{
+		&tls.CertificateVerificationError{
+			Err: x509.UnknownAuthorityError{},
+		},
+		&net.OpError{Op: "remote error", Err: errors.New("tls: bad certificate")},
+		trace.ConnectionProblem(nil, "connection refused"),
+	}
+	for _, err := range errs {
+		c.Assert(clockSkewFromError("192.168.1.2:3012", err, time.Now()), check.IsNil,
+			check.Commentf("%v", err))
+	}
+}
+
+func (s *ClockSkewSuite) TestChecksServerCertificate(c *check.C) {
+	now := time.Now()
+	serverCert := newCertificate(c, now.Add(time.Hour), now.Add(2*time.Hour))
+	clientCert := newCertificate(c, now.Add(-time.Hour), now.Add(time.Hour))
+	listener := serveTLS(c, serverCert, clientCert)
+	defer listener.Close()
+
+	err := CheckClockSkew(context.TODO(), listener.Addr().String(), clientCredentials(serverCert, clientCert))
+	skewErr := ClockSkew(err)
+	c.Assert(skewErr, check.NotNil)
+	c.Assert(skewErr.Local, check.Equals, true)
+	c.Assert(skewErr.Skew > 59*time.Minute, check.Equals, true,
+		check.Commentf("unexpected skew %v", skewErr.Skew))
+}
+
+func (s *ClockSkewSuite) TestChecksClientCertificate(c *check.C) {
+	now := time.Now()
+	serverCert := newCertificate(c, now.Add(-time.Hour), now.Add(time.Hour))
+	clientCert := newCertificate(c, now.Add(time.Hour), now.Add(2*time.Hour))
+	listener := serveTLS(c, serverCert, clientCert)
+	defer listener.Close()
+
+	err := CheckClockSkew(context.TODO(), listener.Addr().String(), clientCredentials(serverCert, clientCert))
+	skewErr := ClockSkew(err)
+	c.Assert(skewErr, check.NotNil)
+	c.Assert(skewErr.Local, check.Equals, false)
+}
+
+func (s *ClockSkewSuite) TestNoSkew(c *check.C) {
+	now := time.Now()
+	serverCert := newCertificate(c, now.Add(-time.Hour), now.Add(time.Hour))
+	clientCert := newCertificate(c, now.Add(-time.Hour), now.Add(time.Hour))
+	listener := serveTLS(c, serverCert, clientCert)
+	defer listener.Close()
+
+	err := CheckClockSkew(context.TODO(), listener.Addr().String(), clientCredentials(serverCert, clientCert))
+	c.Assert(err, check.IsNil)
+}
+
+// serveTLS starts a TLS server that requires a client certificate
+func serveTLS(c *check.C, serverCert, clientCert tls.Certificate) net.Listener {
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientCAs:    certPool(clientCert),
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	})
+	c.Assert(err, check.IsNil)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				if err := conn.(*tls.Conn).Handshake(); err == nil {
+					conn.Write([]byte("ok"))
+				}
+			}()
+		}
+	}()
+	return listener
+}
+
+func clientCredentials(serverCert, clientCert tls.Certificate) credentials.TransportCredentials {
+	return credentials.NewTLS(&tls.Config{
+		ServerName:   pb.ServerName,
+		Certificates: []tls.Certificate{clientCert},
+		RootCAs:      certPool(serverCert),
+	})
+}
+
+func certPool(cert tls.Certificate) *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(cert.Leaf)
+	return pool
+}
+
+// newCertificate generates a self-signed certificate with the specified validity period
+func newCertificate(c *check.C, notBefore, notAfter time.Time) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	c.Assert(err, check.IsNil)
+	template := x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: pb.ServerName},
+		DNSNames:              []string{pb.ServerName},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	c.Assert(err, check.IsNil)
+	leaf, err := x509.ParseCertificate(der)
+	c.Assert(err, check.IsNil)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
@@ -17,11 +17,13 @@ limitations under the License.
 package server
 
 import (
+	"os"
 	"os/exec"
 	"sync/atomic"
 	"syscall"
 
 	pb "github.com/gravitational/gravity/lib/rpc/proto"
+	"github.com/gravitational/gravity/lib/tracing"
 
 	"github.com/gravitational/trace"
 	log "github.com/sirupsen/logrus"
@@ -43,6 +45,10 @@ func osExec(ctx context.Context, stream pb.OutgoingMessageStream, args []string,
 func (c *osCommand) exec(ctx context.Context, stream pb.OutgoingMessageStream, args []string, log log.FieldLogger) error {
 	seq := atomic.AddInt32(&c.seq, 1)
 	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	// Continue the trace of the request in the command
+	if env := tracing.Environ(ctx); len(env) != 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
 	cmd.Stdout = &streamWriter{stream, pb.ExecOutput_STDOUT, seq}
 	cmd.Stderr = &streamWriter{stream, pb.ExecOutput_STDERR, seq}
 
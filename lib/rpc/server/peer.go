@@ -233,6 +233,10 @@ func (r serverPeer) Reconnect(ctx context.Context) (Client, error) {
 
 	clt, err := newClient(ctx, r.creds, r.serverAddr)
 	if err != nil {
+		if client.IsClockSkewError(err) {
+			// Retrying will not help until the clocks are synchronized
+			return nil, &backoff.PermanentError{Err: trace.Wrap(err)}
+		}
 		return nil, trace.Wrap(err)
 	}
 
@@ -324,6 +328,11 @@ func newClient(ctx context.Context, creds credentials.TransportCredentials, addr
 
 	conn, err := grpc.DialContext(ctx, addr, opts...)
 	if err != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), defaults.ClockSkewCheckTimeout)
+		defer cancel()
+		if skewErr := client.CheckClockSkew(ctx, addr, creds); skewErr != nil {
+			return nil, trace.Wrap(skewErr)
+		}
 		return nil, trace.Wrap(err)
 	}
 	return &agentClient{pb.NewAgentClient(conn), healthpb.NewHealthClient(conn), conn}, nil
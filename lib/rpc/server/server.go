@@ -28,6 +28,7 @@ import (
 	pb "github.com/gravitational/gravity/lib/rpc/proto"
 	"github.com/gravitational/gravity/lib/storage"
 	"github.com/gravitational/gravity/lib/systeminfo"
+	"github.com/gravitational/gravity/lib/tracing"
 	"github.com/gravitational/gravity/lib/utils"
 
 	"github.com/gravitational/trace"
@@ -60,6 +61,8 @@ func New(config Config, log logrus.FieldLogger) (*agentServer, error) {
 
 	opts := append([]grpc.ServerOption{},
 		grpc.Creds(config.Credentials.Server),
+		grpc.UnaryInterceptor(tracing.UnaryServerInterceptor),
+		grpc.StreamInterceptor(tracing.StreamServerInterceptor),
 	)
 
 	ctx, cancel := context.WithCancel(context.TODO())
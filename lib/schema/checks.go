@@ -44,10 +44,7 @@ func ValidateDocker(d Docker, dir string) (failed []*pb.Probe, err error) {
 
 	switch d.StorageDriver {
 	case constants.DockerStorageDriverOverlay, constants.DockerStorageDriverOverlay2:
-		checkers = append(checkers,
-			monitoring.NewKernelModuleChecker(moduleName("overlay")),
-			monitoring.NewDTypeChecker(dir),
-		)
+		checkers = append(checkers, monitoring.NewDTypeChecker(dir))
 	}
 
 	all := monitoring.NewCompositeChecker("docker", checkers)
@@ -57,7 +54,8 @@ func ValidateDocker(d Docker, dir string) (failed []*pb.Probe, err error) {
 	return probes.GetFailed(), nil
 }
 
-// ValidateKubelet will check kubelet configuration
+// ValidateKubelet will check kubelet configuration.
+// Required kernel modules are verified by ValidateCompatibility
 func ValidateKubelet(profile NodeProfile, manifest Manifest) (failed []*pb.Probe) {
 	hairpinMode := manifest.HairpinMode(profile)
 	if hairpinMode != constants.HairpinModePromiscuousBridge {
@@ -65,17 +63,9 @@ func ValidateKubelet(profile NodeProfile, manifest Manifest) (failed []*pb.Probe
 		return nil
 	}
 
-	checkers := append([]health.Checker{},
-		monitoring.NewKernelModuleChecker(
-			moduleName("ebtables"),
-			moduleName("ip_tables"),
-			moduleName("iptable_filter"),
-			moduleName("iptable_nat"),
-			moduleName("br_netfilter", "bridge"),
-		),
+	checker := monitoring.NewCompositeChecker("kubelet", []health.Checker{
 		monitoring.NewCGroupChecker("cpu", "cpuacct", "cpuset", "memory"),
-	)
-	checker := monitoring.NewCompositeChecker("kubelet", checkers)
+	})
 
 	var probes health.Probes
 	checker.Check(context.TODO(), &probes)
@@ -0,0 +1,235 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schema
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gravitational/satellite/agent/health"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/satellite/monitoring"
+	"github.com/gravitational/trace"
+)
+
+// ValidateCompatibility verifies the local node against the compatibility
+// matrix of the cluster runtime and returns the list of failed probes.
+// Every failed probe describes the steps to remediate the problem
+func ValidateCompatibility(manifest Manifest, profile NodeProfile, storageDriver string) (failed []*pb.Probe, err error) {
+	return validateCompatibility(manifest.CompatibilityMatrix(), hostNode{},
+		manifest.HairpinMode(profile), storageDriver)
+}
+
+func validateCompatibility(matrix Compatibility, node nodeInfo, hairpinMode, storageDriver string) (failed []*pb.Probe, err error) {
+	var errors []error
+	if matrix.Kernel != nil {
+		probe, err := checkKernelVersion(*matrix.Kernel, node)
+		if err != nil {
+			errors = append(errors, trace.Wrap(err))
+		}
+		if probe != nil {
+			failed = append(failed, probe)
+		}
+	}
+
+	for _, module := range matrix.KernelModules {
+		if !module.IsRequired(hairpinMode, storageDriver) {
+			continue
+		}
+		loaded, err := node.isModuleLoaded(moduleName(module.Name, module.Names...))
+		if err != nil {
+			errors = append(errors, trace.Wrap(err))
+			continue
+		}
+		if !loaded {
+			failed = append(failed, compatibilityProbe(
+				"kernel module %[1]v is not loaded. Load the module with 'modprobe %[1]v' "+
+					"and add it to /etc/modules-load.d/ to have it loaded on boot",
+				module.Name))
+		}
+	}
+
+	if len(matrix.UnsupportedOS) != 0 {
+		probe, err := checkUnsupportedOS(matrix.UnsupportedOS, node)
+		if err != nil {
+			errors = append(errors, trace.Wrap(err))
+		}
+		if probe != nil {
+			failed = append(failed, probe)
+		}
+	}
+	return failed, trace.NewAggregate(errors...)
+}
+
+func checkKernelVersion(kernel KernelVersionRange, node nodeInfo) (*pb.Probe, error) {
+	release, err := node.kernelRelease()
+	if err != nil {
+		return nil, trace.Wrap(err, "failed to read kernel version")
+	}
+	version, err := parseKernelVersion(release)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if kernel.Min != "" {
+		min, err := parseKernelVersion(kernel.Min)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		if compareKernelVersions(version, min) < 0 {
+			return compatibilityProbe("kernel %v is not supported, minimum supported "+
+				"kernel version is %v. Upgrade the kernel and reboot the node", release, kernel.Min), nil
+		}
+	}
+	if kernel.Max != "" {
+		max, err := parseKernelVersion(kernel.Max)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		if compareKernelVersions(version, max) > 0 {
+			return compatibilityProbe("kernel %v is not supported, maximum supported "+
+				"kernel version is %v. Install a supported kernel and reboot the node", release, kernel.Max), nil
+		}
+	}
+	return nil, nil
+}
+
+func checkUnsupportedOS(distros []UnsupportedOS, node nodeInfo) (*pb.Probe, error) {
+	info, err := node.osRelease()
+	if err != nil {
+		return nil, trace.Wrap(err, "failed to query OS version")
+	}
+	for _, distro := range distros {
+		expr, err := regexp.Compile(strings.ToLower(distro.Name))
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		if !expr.MatchString(strings.ToLower(info.ID)) {
+			continue
+		}
+		if len(distro.Versions) != 0 && !hasVersionPrefix(info.VersionID, distro.Versions) {
+			continue
+		}
+		detail := fmt.Sprintf("%v is not supported", info.Name())
+		if distro.Reason != "" {
+			detail = fmt.Sprintf("%v: %v", detail, distro.Reason)
+		}
+		return compatibilityProbe("%v. Use a supported OS distribution for this node", detail), nil
+	}
+	return nil, nil
+}
+
+func hasVersionPrefix(version string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(version, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseKernelVersion parses the numeric components of the specified kernel
+// release, e.g. 3.10.0-862.el7.x86_64 is parsed as [3 10 0]
+func parseKernelVersion(release string) (version []int, err error) {
+	match := reKernelVersion.FindString(release)
+	if match == "" {
+		return nil, trace.BadParameter("invalid kernel version %q", release)
+	}
+	for _, component := range strings.Split(match, ".") {
+		n, err := strconv.Atoi(component)
+		if err != nil {
+			return nil, trace.BadParameter("invalid kernel version %q", release)
+		}
+		version = append(version, n)
+	}
+	return version, nil
+}
+
+// compareKernelVersions compares version against bound only up to
+// the number of components in bound.
+// Returns a negative value if version is less than bound, a positive value
+// if it is greater and 0 if the versions are equal
+func compareKernelVersions(version, bound []int) int {
+	for i, expected := range bound {
+		var actual int
+		if i < len(version) {
+			actual = version[i]
+		}
+		if actual != expected {
+			return actual - expected
+		}
+	}
+	return 0
+}
+
+func compatibilityProbe(format string, args ...interface{}) *pb.Probe {
+	return &pb.Probe{
+		Checker: compatibilityCheckerID,
+		Detail:  fmt.Sprintf(format, args...),
+		Status:  pb.Probe_Failed,
+	}
+}
+
+// nodeInfo provides access to the node attributes checked against
+// the compatibility matrix
+type nodeInfo interface {
+	// kernelRelease returns the kernel release, as in uname -r
+	kernelRelease() (string, error)
+	// osRelease returns the OS distribution information
+	osRelease() (*monitoring.OSRelease, error)
+	// isModuleLoaded returns true if the specified kernel module is loaded
+	isModuleLoaded(monitoring.ModuleRequest) (bool, error)
+}
+
+// hostNode implements nodeInfo for the local host
+type hostNode struct{}
+
+func (hostNode) kernelRelease() (string, error) {
+	release, err := ioutil.ReadFile(kernelReleasePath)
+	if err != nil {
+		return "", trace.ConvertSystemError(err)
+	}
+	return strings.TrimSpace(string(release)), nil
+}
+
+func (hostNode) osRelease() (*monitoring.OSRelease, error) {
+	return monitoring.GetOSRelease()
+}
+
+func (hostNode) isModuleLoaded(module monitoring.ModuleRequest) (bool, error) {
+	var probes health.Probes
+	monitoring.NewKernelModuleChecker(module).Check(context.TODO(), &probes)
+	for _, probe := range probes.GetFailed() {
+		if probe.Error != "" {
+			return false, trace.BadParameter("%v", probe.Error)
+		}
+		return false, nil
+	}
+	return true, nil
+}
+
+const (
+	// compatibilityCheckerID identifies the compatibility checker
+	compatibilityCheckerID = "compatibility"
+	// kernelReleasePath is the path to the file with the kernel release
+	kernelReleasePath = "/proc/sys/kernel/osrelease"
+)
+
+var reKernelVersion = regexp.MustCompile(`^\d+(\.\d+)*`)
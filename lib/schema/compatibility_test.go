@@ -0,0 +1,139 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schema
+
+import (
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/utils"
+
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/satellite/monitoring"
+	. "gopkg.in/check.v1"
+)
+
+type CompatibilitySuite struct{}
+
+var _ = Suite(&CompatibilitySuite{})
+
+func (s *CompatibilitySuite) TestKernelVersion(c *C) {
+	matrix := Compatibility{Kernel: &KernelVersionRange{Min: "3.10.0", Max: "4.19"}}
+	var testCases = []struct {
+		release string
+		failed  bool
+	}{
+		{release: "3.10.0-862.el7.x86_64"},
+		{release: "4.19.112-generic"},
+		{release: "3.9.8", failed: true},
+		{release: "4.20.0", failed: true},
+	}
+	for _, tc := range testCases {
+		comment := Commentf(tc.release)
+		failed, err := validateCompatibility(matrix, testNode{release: tc.release}, "", "")
+		c.Assert(err, IsNil, comment)
+		c.Assert(len(failed) != 0, Equals, tc.failed, comment)
+	}
+}
+
+func (s *CompatibilitySuite) TestKernelModules(c *C) {
+	node := testNode{modules: []string{"ip_tables", "bridge"}}
+
+	failed, err := validateCompatibility(DefaultCompatibility, node,
+		constants.HairpinModeVeth, constants.DockerStorageDriverDevicemapper)
+	c.Assert(err, IsNil)
+	c.Assert(failed, HasLen, 0)
+
+	failed, err = validateCompatibility(DefaultCompatibility, node,
+		constants.HairpinModePromiscuousBridge, constants.DockerStorageDriverOverlay2)
+	c.Assert(err, IsNil)
+	c.Assert(details(failed), DeepEquals, []string{
+		"kernel module overlay is not loaded. Load the module with 'modprobe overlay' " +
+			"and add it to /etc/modules-load.d/ to have it loaded on boot",
+		"kernel module ebtables is not loaded. Load the module with 'modprobe ebtables' " +
+			"and add it to /etc/modules-load.d/ to have it loaded on boot",
+		"kernel module iptable_filter is not loaded. Load the module with 'modprobe iptable_filter' " +
+			"and add it to /etc/modules-load.d/ to have it loaded on boot",
+		"kernel module iptable_nat is not loaded. Load the module with 'modprobe iptable_nat' " +
+			"and add it to /etc/modules-load.d/ to have it loaded on boot",
+	})
+}
+
+func (s *CompatibilitySuite) TestUnsupportedOS(c *C) {
+	matrix := Compatibility{UnsupportedOS: []UnsupportedOS{
+		{Name: "centos", Versions: []string{"6"}, Reason: "systemd is required"},
+		{Name: "alpine"},
+	}}
+	var testCases = []struct {
+		os     monitoring.OSRelease
+		detail string
+	}{
+		{os: monitoring.OSRelease{ID: "centos", VersionID: "7.5"}},
+		{
+			os:     monitoring.OSRelease{ID: "centos", VersionID: "6.9"},
+			detail: "centos 6.9 is not supported: systemd is required. Use a supported OS distribution for this node",
+		},
+		{
+			os:     monitoring.OSRelease{ID: "alpine", VersionID: "3.8"},
+			detail: "alpine 3.8 is not supported. Use a supported OS distribution for this node",
+		},
+	}
+	for _, tc := range testCases {
+		comment := Commentf(tc.os.Name())
+		failed, err := validateCompatibility(matrix, testNode{os: tc.os}, "", "")
+		c.Assert(err, IsNil, comment)
+		if tc.detail == "" {
+			c.Assert(failed, HasLen, 0, comment)
+			continue
+		}
+		c.Assert(details(failed), DeepEquals, []string{tc.detail}, comment)
+	}
+}
+
+func details(probes []*pb.Probe) (result []string) {
+	for _, probe := range probes {
+		result = append(result, probe.Detail)
+	}
+	return result
+}
+
+type testNode struct {
+	release string
+	os      monitoring.OSRelease
+	modules []string
+	// packages lists installed OS packages
+	packages []string
+}
+
+func (r testNode) kernelRelease() (string, error) {
+	return r.release, nil
+}
+
+func (r testNode) osRelease() (*monitoring.OSRelease, error) {
+	return &r.os, nil
+}
+
+func (r testNode) isModuleLoaded(module monitoring.ModuleRequest) (bool, error) {
+	for _, name := range r.modules {
+		if name == module.Name || utils.StringInSlice(module.Names, name) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r testNode) isPackageInstalled(name string) (bool, error) {
+	return utils.StringInSlice(r.packages, name), nil
+}
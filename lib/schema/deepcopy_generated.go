@@ -80,6 +80,45 @@ func (in *CPU) DeepCopy() *CPU {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Compatibility) DeepCopyInto(out *Compatibility) {
+	*out = *in
+	if in.Kernel != nil {
+		in, out := &in.Kernel, &out.Kernel
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(KernelVersionRange)
+			**out = **in
+		}
+	}
+	if in.KernelModules != nil {
+		in, out := &in.KernelModules, &out.KernelModules
+		*out = make([]KernelModule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.UnsupportedOS != nil {
+		in, out := &in.UnsupportedOS, &out.UnsupportedOS
+		*out = make([]UnsupportedOS, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Compatibility.
+func (in *Compatibility) DeepCopy() *Compatibility {
+	if in == nil {
+		return nil
+	}
+	out := new(Compatibility)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ConfigurationExtension) DeepCopyInto(out *ConfigurationExtension) {
 	*out = *in
@@ -297,6 +336,22 @@ func (in *Extensions) DeepCopy() *Extensions {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Firewall) DeepCopyInto(out *Firewall) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Firewall.
+func (in *Firewall) DeepCopy() *Firewall {
+	if in == nil {
+		return nil
+	}
+	out := new(Firewall)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Flavor) DeepCopyInto(out *Flavor) {
 	*out = *in
@@ -395,6 +450,15 @@ func (in *Header) DeepCopy() *Header {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Hook) DeepCopyInto(out *Hook) {
 	*out = *in
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(HookValues)
+			(*in).DeepCopyInto(*out)
+		}
+	}
 	return
 }
 
@@ -408,6 +472,32 @@ func (in *Hook) DeepCopy() *Hook {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HookValues) DeepCopyInto(out *HookValues) {
+	*out = *in
+	if in.Required != nil {
+		in, out := &in.Required, &out.Required
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ConfigMaps != nil {
+		in, out := &in.ConfigMaps, &out.ConfigMaps
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HookValues.
+func (in *HookValues) DeepCopy() *HookValues {
+	if in == nil {
+		return nil
+	}
+	out := new(HookValues)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Hooks) DeepCopyInto(out *Hooks) {
 	*out = *in
@@ -417,7 +507,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.ClusterDeprovision != nil {
@@ -426,7 +516,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.NodesProvision != nil {
@@ -435,7 +525,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.NodesDeprovision != nil {
@@ -444,7 +534,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.Install != nil {
@@ -453,7 +543,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.Installed != nil {
@@ -462,7 +552,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.Uninstall != nil {
@@ -471,7 +561,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.Uninstalling != nil {
@@ -480,7 +570,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.NodeAdding != nil {
@@ -489,7 +579,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.NodeAdded != nil {
@@ -498,7 +588,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.NodeRemoving != nil {
@@ -507,7 +597,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.NodeRemoved != nil {
@@ -516,7 +606,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.BeforeUpdate != nil {
@@ -525,7 +615,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.Updating != nil {
@@ -534,7 +624,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.Updated != nil {
@@ -543,7 +633,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.Rollback != nil {
@@ -552,7 +642,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.RolledBack != nil {
@@ -561,7 +651,16 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.SmokeTest != nil {
+		in, out := &in.SmokeTest, &out.SmokeTest
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(Hook)
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.Status != nil {
@@ -570,7 +669,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.Info != nil {
@@ -579,7 +678,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.LicenseUpdated != nil {
@@ -588,7 +687,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.Start != nil {
@@ -597,7 +696,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.Stop != nil {
@@ -606,7 +705,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.Dump != nil {
@@ -615,7 +714,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.Backup != nil {
@@ -624,7 +723,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.Restore != nil {
@@ -633,7 +732,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 
@@ -643,7 +742,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.NetworkUpdate != nil {
@@ -652,7 +751,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.NetworkRollback != nil {
@@ -661,7 +760,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	return
@@ -708,6 +807,18 @@ func (in *Installer) DeepCopyInto(out *Installer) {
 		copy(*out, *in)
 	}
 	in.Flavors.DeepCopyInto(&out.Flavors)
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make(Parameters, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ParameterGroups != nil {
+		in, out := &in.ParameterGroups, &out.ParameterGroups
+		*out = make([]ParameterGroup, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -721,6 +832,53 @@ func (in *Installer) DeepCopy() *Installer {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KernelModule) DeepCopyInto(out *KernelModule) {
+	*out = *in
+	if in.Names != nil {
+		in, out := &in.Names, &out.Names
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.HairpinModes != nil {
+		in, out := &in.HairpinModes, &out.HairpinModes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.StorageDrivers != nil {
+		in, out := &in.StorageDrivers, &out.StorageDrivers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KernelModule.
+func (in *KernelModule) DeepCopy() *KernelModule {
+	if in == nil {
+		return nil
+	}
+	out := new(KernelModule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KernelVersionRange) DeepCopyInto(out *KernelVersionRange) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KernelVersionRange.
+func (in *KernelVersionRange) DeepCopy() *KernelVersionRange {
+	if in == nil {
+		return nil
+	}
+	out := new(KernelVersionRange)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Kubelet) DeepCopyInto(out *Kubelet) {
 	*out = *in
@@ -774,6 +932,50 @@ func (in *License) DeepCopy() *License {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LimitRange) DeepCopyInto(out *LimitRange) {
+	*out = *in
+	if in.Default != nil {
+		in, out := &in.Default, &out.Default
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.DefaultRequest != nil {
+		in, out := &in.DefaultRequest, &out.DefaultRequest
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Max != nil {
+		in, out := &in.Max, &out.Max
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Min != nil {
+		in, out := &in.Min, &out.Min
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LimitRange.
+func (in *LimitRange) DeepCopy() *LimitRange {
+	if in == nil {
+		return nil
+	}
+	out := new(LimitRange)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LogsExtension) DeepCopyInto(out *LogsExtension) {
 	*out = *in
@@ -863,6 +1065,47 @@ func (in *Manifest) DeepCopyInto(out *Manifest) {
 			(*in).DeepCopyInto(*out)
 		}
 	}
+	if in.Compatibility != nil {
+		in, out := &in.Compatibility, &out.Compatibility
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(Compatibility)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.Snapshots != nil {
+		in, out := &in.Snapshots, &out.Snapshots
+		*out = make([]SnapshotPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]Namespace, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(Storage)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.UpgradePath != nil {
+		in, out := &in.UpgradePath, &out.UpgradePath
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(UpgradePath)
+			(*in).DeepCopyInto(*out)
+		}
+	}
 	return
 }
 
@@ -917,6 +1160,45 @@ func (in *MonitoringExtension) DeepCopy() *MonitoringExtension {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Namespace) DeepCopyInto(out *Namespace) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ResourceQuota != nil {
+		in, out := &in.ResourceQuota, &out.ResourceQuota
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.LimitRange != nil {
+		in, out := &in.LimitRange, &out.LimitRange
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(LimitRange)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Namespace.
+func (in *Namespace) DeepCopy() *Namespace {
+	if in == nil {
+		return nil
+	}
+	out := new(Namespace)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Network) DeepCopyInto(out *Network) {
 	*out = *in
@@ -1048,11 +1330,98 @@ func (in *OS) DeepCopy() *OS {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Port) DeepCopyInto(out *Port) {
+func (in *Parameter) DeepCopyInto(out *Parameter) {
 	*out = *in
-	if in.Ranges != nil {
-		in, out := &in.Ranges, &out.Ranges
-		*out = make([]string, len(*in))
+	in.Validation.DeepCopyInto(&out.Validation)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Parameter.
+func (in *Parameter) DeepCopy() *Parameter {
+	if in == nil {
+		return nil
+	}
+	out := new(Parameter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ParameterGroup) DeepCopyInto(out *ParameterGroup) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ParameterGroup.
+func (in *ParameterGroup) DeepCopy() *ParameterGroup {
+	if in == nil {
+		return nil
+	}
+	out := new(ParameterGroup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ParameterValidation) DeepCopyInto(out *ParameterValidation) {
+	*out = *in
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Min != nil {
+		in, out := &in.Min, &out.Min
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Max != nil {
+		in, out := &in.Max, &out.Max
+		*out = new(int64)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ParameterValidation.
+func (in *ParameterValidation) DeepCopy() *ParameterValidation {
+	if in == nil {
+		return nil
+	}
+	out := new(ParameterValidation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodRestartsGate) DeepCopyInto(out *PodRestartsGate) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodRestartsGate.
+func (in *PodRestartsGate) DeepCopy() *PodRestartsGate {
+	if in == nil {
+		return nil
+	}
+	out := new(PodRestartsGate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Port) DeepCopyInto(out *Port) {
+	*out = *in
+	if in.Ranges != nil {
+		in, out := &in.Ranges, &out.Ranges
+		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
 	return
@@ -1103,6 +1472,27 @@ func (in *RAM) DeepCopy() *RAM {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RawDisks) DeepCopyInto(out *RawDisks) {
+	*out = *in
+	if in.Profiles != nil {
+		in, out := &in.Profiles, &out.Profiles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RawDisks.
+func (in *RawDisks) DeepCopy() *RawDisks {
+	if in == nil {
+		return nil
+	}
+	out := new(RawDisks)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Requirements) DeepCopyInto(out *Requirements) {
 	*out = *in
@@ -1153,6 +1543,110 @@ func (in *Runtime) DeepCopy() *Runtime {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SnapshotPolicy) DeepCopyInto(out *SnapshotPolicy) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SnapshotPolicy.
+func (in *SnapshotPolicy) DeepCopy() *SnapshotPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(SnapshotPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Storage) DeepCopyInto(out *Storage) {
+	*out = *in
+	if in.StorageClass != nil {
+		in, out := &in.StorageClass, &out.StorageClass
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(StorageClass)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.RawDisks != nil {
+		in, out := &in.RawDisks, &out.RawDisks
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(RawDisks)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Storage.
+func (in *Storage) DeepCopy() *Storage {
+	if in == nil {
+		return nil
+	}
+	out := new(Storage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorageClass) DeepCopyInto(out *StorageClass) {
+	*out = *in
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageClass.
+func (in *StorageClass) DeepCopy() *StorageClass {
+	if in == nil {
+		return nil
+	}
+	out := new(StorageClass)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorageProvider) DeepCopyInto(out *StorageProvider) {
+	*out = *in
+	in.StorageClass.DeepCopyInto(&out.StorageClass)
+	if in.KernelModules != nil {
+		in, out := &in.KernelModules, &out.KernelModules
+		*out = make([]KernelModule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageProvider.
+func (in *StorageProvider) DeepCopy() *StorageProvider {
+	if in == nil {
+		return nil
+	}
+	out := new(StorageProvider)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SystemOptions) DeepCopyInto(out *SystemOptions) {
 	*out = *in
@@ -1192,6 +1686,24 @@ func (in *SystemOptions) DeepCopyInto(out *SystemOptions) {
 			(*in).DeepCopyInto(*out)
 		}
 	}
+	if in.Upgrade != nil {
+		in, out := &in.Upgrade, &out.Upgrade
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(Upgrade)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.Firewall != nil {
+		in, out := &in.Firewall, &out.Firewall
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(Firewall)
+			**out = **in
+		}
+	}
 	return
 }
 
@@ -1205,6 +1717,148 @@ func (in *SystemOptions) DeepCopy() *SystemOptions {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UnsupportedOS) DeepCopyInto(out *UnsupportedOS) {
+	*out = *in
+	if in.Versions != nil {
+		in, out := &in.Versions, &out.Versions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnsupportedOS.
+func (in *UnsupportedOS) DeepCopy() *UnsupportedOS {
+	if in == nil {
+		return nil
+	}
+	out := new(UnsupportedOS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Upgrade) DeepCopyInto(out *Upgrade) {
+	*out = *in
+	if in.Taint != nil {
+		in, out := &in.Taint, &out.Taint
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(v1.Taint)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.Gates != nil {
+		in, out := &in.Gates, &out.Gates
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(UpgradeGates)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Upgrade.
+func (in *Upgrade) DeepCopy() *Upgrade {
+	if in == nil {
+		return nil
+	}
+	out := new(Upgrade)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeGate) DeepCopyInto(out *UpgradeGate) {
+	*out = *in
+	if in.PodRestarts != nil {
+		in, out := &in.PodRestarts, &out.PodRestarts
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(PodRestartsGate)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.Max != nil {
+		in, out := &in.Max, &out.Max
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(float64)
+			**out = **in
+		}
+	}
+	if in.Min != nil {
+		in, out := &in.Min, &out.Min
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(float64)
+			**out = **in
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeGate.
+func (in *UpgradeGate) DeepCopy() *UpgradeGate {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeGate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeGates) DeepCopyInto(out *UpgradeGates) {
+	*out = *in
+	if in.Checks != nil {
+		in, out := &in.Checks, &out.Checks
+		*out = make([]UpgradeGate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeGates.
+func (in *UpgradeGates) DeepCopy() *UpgradeGates {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeGates)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradePath) DeepCopyInto(out *UpgradePath) {
+	*out = *in
+	if in.Via != nil {
+		in, out := &in.Via, &out.Via
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradePath.
+func (in *UpgradePath) DeepCopy() *UpgradePath {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradePath)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Volume) DeepCopyInto(out *Volume) {
 	*out = *in
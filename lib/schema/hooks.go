@@ -18,6 +18,9 @@ package schema
 
 import (
 	"reflect"
+	"strings"
+
+	"github.com/gravitational/gravity/lib/defaults"
 
 	"github.com/gravitational/trace"
 
@@ -61,6 +64,8 @@ type Hooks struct {
 	Rollback *Hook `json:"rollback,omitempty"`
 	// RolledBack is called after successful rollback
 	RolledBack *Hook `json:"postRollback,omitempty"`
+	// SmokeTest verifies the application after a successful update
+	SmokeTest *Hook `json:"smokeTest,omitempty"`
 	// Status is called every minute to check application status
 	Status *Hook `json:"status,omitempty"`
 	// Info is used to obtain application information
@@ -105,6 +110,60 @@ type Hook struct {
 	Type HookType `json:"type,omitempty"`
 	// Job is a URL of (file:// or http://) or a literal value of a k8s job
 	Job string `json:"job,omitempty"`
+	// Values declares cluster values the hook depends on
+	Values *HookValues `json:"values,omitempty"`
+}
+
+// HookValues declares the cluster values a hook depends on.
+//
+// Every hook receives a values file with the common cluster values
+// (see lib/app/hooks for the list of keys), HookValues allows a hook
+// to additionally request data from config maps and to mark values that must
+// be present for the hook to run.
+type HookValues struct {
+	// Required lists keys of values that must be present for the hook to run
+	Required []string `json:"required,omitempty"`
+	// ConfigMaps lists config maps (in the namespace/name format) whose
+	// data is added to the hook values
+	ConfigMaps []string `json:"configMaps,omitempty"`
+}
+
+// Check makes sure the hook values declaration is valid
+func (v *HookValues) Check() error {
+	if v == nil {
+		return nil
+	}
+	for _, key := range v.Required {
+		if strings.TrimSpace(key) == "" {
+			return trace.BadParameter("required value key cannot be empty")
+		}
+	}
+	for _, configMap := range v.ConfigMaps {
+		if _, _, err := ParseConfigMapRef(configMap); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// ParseConfigMapRef parses the config map reference in the namespace/name format.
+// If the namespace is omitted, the default kube-system namespace is assumed
+func ParseConfigMapRef(ref string) (namespace, name string, err error) {
+	parts := strings.Split(ref, "/")
+	switch len(parts) {
+	case 1:
+		namespace, name = defaults.KubeSystemNamespace, parts[0]
+	case 2:
+		namespace, name = parts[0], parts[1]
+	default:
+		return "", "", trace.BadParameter(
+			"config map reference %q should be in the namespace/name format", ref)
+	}
+	if namespace == "" || name == "" {
+		return "", "", trace.BadParameter(
+			"config map reference %q should be in the namespace/name format", ref)
+	}
+	return namespace, name, nil
 }
 
 // Empty determines if the hook set is empty
@@ -164,6 +223,9 @@ const (
 	HookRollback HookType = "rollback"
 	// HookRolledBack defines the application post rollback hook
 	HookRolledBack HookType = "postRollback"
+	// HookSmokeTest defines the application hook that verifies the application
+	// after the update. The update fails if the hook fails
+	HookSmokeTest HookType = "smokeTest"
 	// HookNodeAdding defines the before expand hook
 	HookNodeAdding HookType = "preNodeAdd"
 	// HookNodeAdded defines the post expand hook
@@ -220,6 +282,7 @@ func AllHooks() []HookType {
 		HookUpdated,
 		HookRollback,
 		HookRolledBack,
+		HookSmokeTest,
 		HookNodeAdding,
 		HookNodeAdded,
 		HookNodeRemoving,
@@ -272,6 +335,8 @@ func HookFromString(hookType HookType, manifest Manifest) (*Hook, error) {
 		hook = manifest.Hooks.Rollback
 	case HookRolledBack:
 		hook = manifest.Hooks.RolledBack
+	case HookSmokeTest:
+		hook = manifest.Hooks.SmokeTest
 	case HookNodeAdding:
 		hook = manifest.Hooks.NodeAdding
 	case HookNodeAdded:
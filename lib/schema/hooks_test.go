@@ -135,3 +135,56 @@ hooks:
 	c.Assert(err, IsNil)
 	c.Assert(installJob, DeepEquals, job)
 }
+
+func (r *HooksSuite) TestDecodesHookValues(c *C) {
+	const manifest = `
+apiVersion: bundle.gravitational.io/v2
+kind: Bundle
+metadata:
+  name: test
+  resourceVersion: 0.0.1
+hooks:
+  install:
+    values:
+      required: ["cluster.nodeCount", "configMaps.default.app-config.replicas"]
+      configMaps: ["default/app-config", "cluster-info"]
+    job: |
+      apiVersion: batch/v1
+      kind: Job
+      metadata:
+        name: install
+`
+	m, err := ParseManifestYAML([]byte(manifest))
+	c.Assert(err, IsNil)
+	c.Assert(m.Hooks.Install.Values, DeepEquals, &HookValues{
+		Required:   []string{"cluster.nodeCount", "configMaps.default.app-config.replicas"},
+		ConfigMaps: []string{"default/app-config", "cluster-info"},
+	})
+	c.Assert(m.Hooks.Install.Values.Check(), IsNil)
+}
+
+func (r *HooksSuite) TestParsesConfigMapRefs(c *C) {
+	var testCases = []struct {
+		ref       string
+		namespace string
+		name      string
+		err       bool
+	}{
+		{ref: "default/config", namespace: "default", name: "config"},
+		{ref: "config", namespace: "kube-system", name: "config"},
+		{ref: "a/b/c", err: true},
+		{ref: "default/", err: true},
+		{ref: "", err: true},
+	}
+	for _, tc := range testCases {
+		comment := Commentf("ref %q", tc.ref)
+		namespace, name, err := ParseConfigMapRef(tc.ref)
+		if tc.err {
+			c.Assert(err, NotNil, comment)
+			continue
+		}
+		c.Assert(err, IsNil, comment)
+		c.Assert(namespace, Equals, tc.namespace, comment)
+		c.Assert(name, Equals, tc.name, comment)
+	}
+}
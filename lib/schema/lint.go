@@ -0,0 +1,269 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gravitational/gravity/lib/loc"
+
+	"github.com/coreos/go-semver/semver"
+	"github.com/ghodss/yaml"
+	"github.com/gravitational/trace"
+	"github.com/santhosh-tekuri/jsonschema"
+)
+
+// LintConfig defines the manifest linter configuration
+type LintConfig struct {
+	// ManifestPath is the path to the manifest file.
+	// Hook jobs referenced with file:// are resolved relative to it
+	ManifestPath string
+	// RuntimeVersion is the runtime version the application is going to be built with
+	RuntimeVersion *semver.Version
+}
+
+// Lint verifies the provided manifest data and returns the list of findings.
+//
+// In addition to the schema and semantic checks performed when the manifest
+// is parsed, the linter reports usage of deprecated fields, invalid hook job
+// references and a base image version that does not match the runtime version
+// the application is built with
+func Lint(data []byte, config LintConfig) (findings Findings) {
+	data, err := yaml.YAMLToJSON(ExpandEnvVars(data))
+	if err != nil {
+		return Findings{newFinding(LintRuleSyntax, "", "%v", err)}
+	}
+	var header Header
+	if err := json.Unmarshal(data, &header); err != nil {
+		return Findings{newFinding(LintRuleSyntax, "", "%v", err)}
+	}
+	findings = append(findings, lintDeprecated(header)...)
+	switch header.APIVersion {
+	case APIVersionV2, APIVersionV2Cluster, APIVersionV2App:
+		if err := schema.Validate(bytes.NewReader(data)); err != nil {
+			return append(findings, schemaFindings(err)...)
+		}
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return append(findings, newFinding(LintRuleSchema, "", "%v", err))
+	}
+	if manifest.Hooks != nil {
+		findings = append(findings, lintHooks(*manifest.Hooks, config.ManifestPath)...)
+	}
+	findings = append(findings, lintRuntimeVersion(manifest, config.RuntimeVersion)...)
+	if err := CheckAndSetDefaults(&manifest); err != nil {
+		findings = append(findings, validationFindings(err)...)
+	}
+	return findings
+}
+
+// Finding describes a single problem found in the manifest
+type Finding struct {
+	// Severity is the finding severity, error or warning
+	Severity LintSeverity `json:"severity"`
+	// Rule identifies the check that produced the finding
+	Rule string `json:"rule"`
+	// Path is the path to the offending manifest field, if known
+	Path string `json:"path,omitempty"`
+	// Message describes the problem
+	Message string `json:"message"`
+}
+
+// String returns a textual representation of this finding
+func (r Finding) String() string {
+	if r.Path == "" {
+		return fmt.Sprintf("%v: %v", r.Severity, r.Message)
+	}
+	return fmt.Sprintf("%v: %v: %v", r.Severity, r.Path, r.Message)
+}
+
+// Findings is a list of linter findings
+type Findings []Finding
+
+// HasErrors returns true if any of the findings is an error
+func (r Findings) HasErrors() bool {
+	for _, finding := range r {
+		if finding.Severity == LintSeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// LintSeverity defines the severity of a linter finding
+type LintSeverity string
+
+const (
+	// LintSeverityError marks problems that will fail the build or the installation
+	LintSeverityError LintSeverity = "error"
+	// LintSeverityWarning marks problems that do not prevent the build
+	LintSeverityWarning LintSeverity = "warning"
+)
+
+const (
+	// LintRuleSyntax reports manifests that cannot be parsed
+	LintRuleSyntax = "syntax"
+	// LintRuleSchema reports manifest schema violations
+	LintRuleSchema = "schema"
+	// LintRuleValidation reports semantic manifest errors
+	LintRuleValidation = "validation"
+	// LintRuleDeprecated reports usage of deprecated fields
+	LintRuleDeprecated = "deprecated"
+	// LintRuleHook reports invalid hook jobs
+	LintRuleHook = "hook"
+	// LintRuleRuntimeVersion reports runtime version mismatches
+	LintRuleRuntimeVersion = "runtime-version"
+	// LintRuleImage reports unavailable container images
+	LintRuleImage = "image"
+)
+
+func lintDeprecated(header Header) (findings Findings) {
+	if header.APIVersion == APIVersionV1 {
+		findings = append(findings, newWarning(LintRuleDeprecated, "/apiVersion",
+			"apiVersion %v is deprecated, use %v", header.APIVersion, APIVersionV2Cluster))
+	}
+	if header.Kind == KindBundle {
+		findings = append(findings, newWarning(LintRuleDeprecated, "/kind",
+			"kind %v is deprecated, use %v", KindBundle, KindCluster))
+	}
+	return findings
+}
+
+func lintHooks(hooks Hooks, manifestPath string) (findings Findings) {
+	for _, hook := range namedHooks(hooks) {
+		path := fmt.Sprintf("/hooks/%v/job", hook.name)
+		spec := hook.Job
+		switch {
+		case strings.HasPrefix(spec, "file://"):
+			data, _, err := valueFromFile(spec, manifestPath)
+			if err != nil {
+				findings = append(findings, newFinding(LintRuleHook, path,
+					"failed to read job spec from %v: %v", spec, trace.UserMessage(err)))
+				continue
+			}
+			spec = string(data)
+		case strings.HasPrefix(spec, "http://"), strings.HasPrefix(spec, "https://"):
+			// remote job specs are only fetched during the build
+			continue
+		}
+		job, err := Hook{Job: spec}.GetJob()
+		if err != nil {
+			findings = append(findings, newFinding(LintRuleHook, path,
+				"invalid job spec: %v", trace.UserMessage(err)))
+			continue
+		}
+		containers := job.Spec.Template.Spec.Containers
+		if len(containers) == 0 {
+			findings = append(findings, newFinding(LintRuleHook, path,
+				"job does not define any containers"))
+		}
+		for _, container := range containers {
+			if container.Image == "" {
+				findings = append(findings, newFinding(LintRuleHook, path,
+					"container %q does not specify an image", container.Name))
+			}
+		}
+	}
+	return findings
+}
+
+// namedHooks returns all non-nil hooks along with their manifest field names
+func namedHooks(hooks Hooks) (named []namedHook) {
+	value := reflect.ValueOf(hooks)
+	for i := 0; i < value.NumField(); i++ {
+		if value.Field(i).IsNil() {
+			continue
+		}
+		named = append(named, namedHook{
+			Hook: value.Field(i).Interface().(*Hook),
+			name: strings.Split(value.Type().Field(i).Tag.Get("json"), ",")[0],
+		})
+	}
+	return named
+}
+
+type namedHook struct {
+	*Hook
+	name string
+}
+
+func lintRuntimeVersion(manifest Manifest, runtimeVersion *semver.Version) Findings {
+	base := manifest.Base()
+	if runtimeVersion == nil || base == nil || base.Version == loc.LatestVersion {
+		return nil
+	}
+	version, err := base.SemVer()
+	if err != nil {
+		return Findings{newFinding(LintRuleRuntimeVersion, "/baseImage",
+			"invalid base image version %v: %v", base.Version, err)}
+	}
+	if !version.Equal(*runtimeVersion) {
+		return Findings{newWarning(LintRuleRuntimeVersion, "/baseImage",
+			"manifest pins base image version %v, runtime version %v will not be used",
+			version, runtimeVersion)}
+	}
+	return nil
+}
+
+// schemaFindings converts the schema validation error into findings,
+// one per failed leaf check
+func schemaFindings(err error) (findings Findings) {
+	validationErr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return Findings{newFinding(LintRuleSchema, "", "%v", err)}
+	}
+	if len(validationErr.Causes) == 0 {
+		return Findings{newFinding(LintRuleSchema, strings.TrimPrefix(validationErr.InstancePtr, "#"),
+			"%v", validationErr.Message)}
+	}
+	for _, cause := range validationErr.Causes {
+		findings = append(findings, schemaFindings(cause)...)
+	}
+	return findings
+}
+
+// validationFindings converts the (possibly aggregate) manifest validation
+// error into findings
+func validationFindings(err error) (findings Findings) {
+	if aggregate, ok := trace.Unwrap(err).(trace.Aggregate); ok {
+		for _, err := range aggregate.Errors() {
+			findings = append(findings, validationFindings(err)...)
+		}
+		return findings
+	}
+	return Findings{newFinding(LintRuleValidation, "", "%v", trace.UserMessage(err))}
+}
+
+func newFinding(rule, path, format string, args ...interface{}) Finding {
+	return Finding{
+		Severity: LintSeverityError,
+		Rule:     rule,
+		Path:     path,
+		Message:  fmt.Sprintf(format, args...),
+	}
+}
+
+func newWarning(rule, path, format string, args ...interface{}) Finding {
+	finding := newFinding(rule, path, format, args...)
+	finding.Severity = LintSeverityWarning
+	return finding
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schema
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/coreos/go-semver/semver"
+	. "gopkg.in/check.v1"
+)
+
+type LintSuite struct{}
+
+var _ = Suite(&LintSuite{})
+
+func (s *LintSuite) TestValidManifest(c *C) {
+	findings := Lint([]byte(`apiVersion: cluster.gravitational.io/v2
+kind: Cluster
+metadata:
+  name: test
+  resourceVersion: 1.0.0
+hooks:
+  install:
+    job: |
+      apiVersion: batch/v1
+      kind: Job
+      spec:
+        template:
+          spec:
+            containers:
+              - name: install
+                image: quay.io/gravitational/debian-tall:0.0.1
+`), LintConfig{})
+	c.Assert(findings, HasLen, 0)
+}
+
+func (s *LintSuite) TestReportsFindings(c *C) {
+	dir := c.MkDir()
+	manifestPath := filepath.Join(dir, "app.yaml")
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "install.yaml"), []byte(`apiVersion: batch/v1
+kind: Job
+spec:
+  template:
+    spec:
+      containers:
+        - name: install
+`), 0644), IsNil)
+
+	findings := Lint([]byte(`apiVersion: bundle.gravitational.io/v2
+kind: Bundle
+metadata:
+  name: test
+  resourceVersion: 1.0.0
+baseImage: gravity:5.5.1
+hooks:
+  install:
+    job: file://install.yaml
+  update:
+    job: file://update.yaml
+`), LintConfig{
+		ManifestPath:   manifestPath,
+		RuntimeVersion: semver.New("5.5.2"),
+	})
+	c.Assert(findings, DeepEquals, Findings{
+		{
+			Severity: LintSeverityWarning,
+			Rule:     LintRuleDeprecated,
+			Path:     "/kind",
+			Message:  "kind Bundle is deprecated, use Cluster",
+		},
+		{
+			Severity: LintSeverityError,
+			Rule:     LintRuleHook,
+			Path:     "/hooks/install/job",
+			Message:  `container "install" does not specify an image`,
+		},
+		{
+			Severity: LintSeverityError,
+			Rule:     LintRuleHook,
+			Path:     "/hooks/update/job",
+			Message:  findings[2].Message,
+		},
+		{
+			Severity: LintSeverityWarning,
+			Rule:     LintRuleRuntimeVersion,
+			Path:     "/baseImage",
+			Message:  "manifest pins base image version 5.5.1, runtime version 5.5.2 will not be used",
+		},
+	})
+	c.Assert(findings.HasErrors(), Equals, true)
+}
+
+func (s *LintSuite) TestReportsSchemaViolations(c *C) {
+	findings := Lint([]byte(`apiVersion: cluster.gravitational.io/v2
+kind: Cluster
+metadata:
+  name: test
+  resourceVersion: 1.0.0
+unknownField: true
+`), LintConfig{})
+	c.Assert(findings, HasLen, 1)
+	c.Assert(findings[0].Rule, Equals, LintRuleSchema)
+	c.Assert(findings.HasErrors(), Equals, true)
+}
@@ -32,9 +32,11 @@ import (
 
 	"github.com/gravitational/trace"
 	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	kubeschema "k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/client-go/kubernetes/scheme"
 )
 
@@ -71,6 +73,18 @@ type Manifest struct {
 	SystemOptions *SystemOptions `json:"systemOptions,omitempty"`
 	// Extensions allows to enable/disable various custom features
 	Extensions *Extensions `json:"extensions,omitempty"`
+	// Compatibility defines the node OS and kernel compatibility matrix
+	Compatibility *Compatibility `json:"compatibility,omitempty"`
+	// Snapshots declares scheduled snapshots of application persistent volumes
+	Snapshots []SnapshotPolicy `json:"snapshots,omitempty"`
+	// Namespaces declares Kubernetes namespaces gravity creates and
+	// reconciles for the application
+	Namespaces []Namespace `json:"namespaces,omitempty"`
+	// Storage declares the persistent storage provider the application requires
+	Storage *Storage `json:"storage,omitempty"`
+	// UpgradePath declares the runtime versions a runtime can be
+	// upgraded from directly
+	UpgradePath *UpgradePath `json:"upgradePath,omitempty"`
 	// WebConfig allows to specify config.js used by UI to customize installer
 	WebConfig string `json:"webConfig,omitempty"`
 }
@@ -187,6 +201,14 @@ func (m Manifest) FlavorNames() []string {
 	return names
 }
 
+// InstallerParameters returns the configuration parameters set during installation
+func (m Manifest) InstallerParameters() Parameters {
+	if m.Installer != nil {
+		return m.Installer.Parameters
+	}
+	return nil
+}
+
 // SetupEndpoint returns the endpoint that is used at the post-installation step
 //
 // Currently only one setup endpoint is supported, so if multiple
@@ -287,6 +309,38 @@ func (m Manifest) HairpinMode(profile NodeProfile) string {
 	return defaults.HairpinMode
 }
 
+// UpgradeTaint returns the taint placed on nodes during the system software update
+func (m Manifest) UpgradeTaint() v1.Taint {
+	if taint := m.SystemOptions.UpgradeTaint(); taint != nil {
+		return *taint
+	}
+	return v1.Taint{
+		Key:    defaults.RunLevelLabel,
+		Value:  defaults.RunLevelSystem,
+		Effect: v1.TaintEffectNoExecute,
+	}
+}
+
+// CompatibilityMatrix returns the node OS and kernel compatibility matrix.
+// If the manifest does not define one, the default matrix is returned.
+// The matrix includes the kernel modules required by the storage provider
+func (m Manifest) CompatibilityMatrix() Compatibility {
+	matrix := DefaultCompatibility
+	if m.Compatibility != nil {
+		matrix = *m.Compatibility
+	}
+	if m.Storage == nil {
+		return matrix
+	}
+	provider, err := GetStorageProvider(m.Storage.Provider)
+	if err != nil {
+		return matrix
+	}
+	modules := make([]KernelModule, 0, len(matrix.KernelModules)+len(provider.KernelModules))
+	matrix.KernelModules = append(append(modules, matrix.KernelModules...), provider.KernelModules...)
+	return matrix
+}
+
 // EtcdArgs returns the list of additional etcd arguments for the specified node profile
 func (m Manifest) EtcdArgs(profile NodeProfile) []string {
 	args := profile.SystemOptions.EtcdArgs()
@@ -504,6 +558,15 @@ type Installer struct {
 	SetupEndpoints []string `json:"setupEndpoints,omitempty"`
 	// Flavors defines application flavors
 	Flavors Flavors `json:"flavors,omitempty"`
+	// Parameters defines application configuration parameters
+	// set during installation
+	Parameters Parameters `json:"parameters,omitempty"`
+	// ParameterGroups defines groups of configuration parameters
+	// in the installer UI
+	ParameterGroups []ParameterGroup `json:"parameterGroups,omitempty"`
+	// Profile is the installer profile the application was built with.
+	// Set by tele build
+	Profile string `json:"profile,omitempty"`
 }
 
 // EULA describes the application end user license agreement
@@ -642,6 +705,8 @@ type Requirements struct {
 	Devices []Device `json:"devices,omitempty"`
 	// CustomChecks lists additional preflight checks as inline scripts
 	CustomChecks []CustomCheck `json:"customChecks,omitempty"`
+	// OSPackages lists OS packages required on the node
+	OSPackages []OSPackage `json:"osPackages,omitempty"`
 }
 
 // Device describes a device that should be created inside container
@@ -719,6 +784,29 @@ type CustomCheck struct {
 	Script string `json:"script,omitempty"`
 }
 
+// OSPackage describes an OS package required on the node.
+//
+// Packages missing on the node are installed during the installation
+// from the files bundled with the installer, if there are any for
+// the node's OS distribution
+type OSPackage struct {
+	// Name is the package name as known to the OS package manager, e.g. lvm2
+	Name string `json:"name"`
+	// Bundled lists the package files bundled with the installer
+	Bundled []OSPackageFiles `json:"bundled,omitempty"`
+}
+
+// OSPackageFiles lists the package files for a set of OS distributions
+type OSPackageFiles struct {
+	// OS lists the OS distributions the files are for, matched against
+	// the distribution ID and the IDs it is derived from, e.g. centos or debian
+	OS []string `json:"os"`
+	// Files lists glob patterns of the package files, relative to
+	// the application resources directory. The files should include
+	// all dependencies of the package missing on the node
+	Files []string `json:"files"`
+}
+
 // DevicesForProfile returns a list of required devices for the specified profile
 func (m Manifest) DevicesForProfile(profileName string) ([]Device, error) {
 	profile, err := m.NodeProfiles.ByName(profileName)
@@ -953,6 +1041,41 @@ func (r *SystemOptions) KubeletArgs() []string {
 	return r.Kubelet.Args
 }
 
+// UpgradeTaint returns the configured upgrade taint or nil
+func (r *SystemOptions) UpgradeTaint() *v1.Taint {
+	if r == nil || r.Upgrade == nil {
+		return nil
+	}
+	return r.Upgrade.Taint
+}
+
+// UpgradeAutoRollback returns true if a failed automatic upgrade
+// should be rolled back when the application smoke tests fail
+func (r *SystemOptions) UpgradeAutoRollback() bool {
+	if r == nil || r.Upgrade == nil {
+		return false
+	}
+	return r.Upgrade.AutoRollback
+}
+
+// UpgradeGates returns the configured upgrade health gates or nil
+func (r *SystemOptions) UpgradeGates() *UpgradeGates {
+	if r == nil || r.Upgrade == nil || r.Upgrade.Gates == nil ||
+		len(r.Upgrade.Gates.Checks) == 0 {
+		return nil
+	}
+	return r.Upgrade.Gates
+}
+
+// ManagedFirewall returns the host firewall options if gravity should
+// manage the host firewall or nil otherwise
+func (r *SystemOptions) ManagedFirewall() *Firewall {
+	if r == nil || r.Firewall == nil || !r.Firewall.Managed {
+		return nil
+	}
+	return r.Firewall
+}
+
 // RuntimeArgs returns a list of additional runtime arguments
 func (r *SystemOptions) RuntimeArgs() []string {
 	if r == nil {
@@ -980,6 +1103,147 @@ type SystemOptions struct {
 	BaseImage string `json:"baseImage,omitempty"`
 	// Dependencies defines additional package dependencies
 	Dependencies SystemDependencies `json:"dependencies"`
+	// Upgrade describes cluster upgrade options
+	Upgrade *Upgrade `json:"upgrade,omitempty"`
+	// Firewall describes host firewall management options
+	Firewall *Firewall `json:"firewall,omitempty"`
+}
+
+// Firewall describes host firewall management options
+type Firewall struct {
+	// Managed enables opening the ports required by the cluster in the
+	// host firewall during install, join and upgrade
+	Managed bool `json:"managed,omitempty"`
+	// Backend forces the firewall implementation: firewalld, nftables
+	// or iptables. Detected on every node if unspecified
+	Backend string `json:"backend,omitempty"`
+}
+
+// Upgrade describes cluster upgrade options
+type Upgrade struct {
+	// Taint overrides the taint placed on a node after its system software
+	// has been updated and until system services on the node are ready.
+	// Pods that declare a toleration for the taint keep running on the node
+	Taint *v1.Taint `json:"taint,omitempty"`
+	// AutoRollback specifies whether a failed automatic upgrade is rolled
+	// back if the application smoke tests fail
+	AutoRollback bool `json:"autoRollback,omitempty"`
+	// Gates defines the health gates evaluated after the system software
+	// on each node has been updated
+	Gates *UpgradeGates `json:"gates,omitempty"`
+}
+
+// UpgradeGates defines health gates evaluated between node upgrades.
+// If any gate fails, the rolling upgrade is paused
+type UpgradeGates struct {
+	// Window is how long the gates are evaluated after each node, e.g. "2m"
+	Window string `json:"window,omitempty"`
+	// Interval is how often the gates are evaluated within the window
+	Interval string `json:"interval,omitempty"`
+	// Checks lists the gates
+	Checks []UpgradeGate `json:"checks"`
+}
+
+// Check makes sure the upgrade gates are well-formed
+func (r UpgradeGates) Check() error {
+	if r.Window != "" {
+		if _, err := time.ParseDuration(r.Window); err != nil {
+			return trace.BadParameter("invalid upgrade gates window %q", r.Window)
+		}
+	}
+	if r.Interval != "" {
+		if _, err := time.ParseDuration(r.Interval); err != nil {
+			return trace.BadParameter("invalid upgrade gates interval %q", r.Interval)
+		}
+	}
+	if r.GetInterval() > r.GetWindow() {
+		return trace.BadParameter("upgrade gates interval %v exceeds window %v",
+			r.GetInterval(), r.GetWindow())
+	}
+	names := make(map[string]struct{})
+	for _, gate := range r.Checks {
+		if err := gate.Check(); err != nil {
+			return trace.Wrap(err)
+		}
+		if _, ok := names[gate.Name]; ok {
+			return trace.BadParameter("duplicate upgrade gate %q", gate.Name)
+		}
+		names[gate.Name] = struct{}{}
+	}
+	return nil
+}
+
+// GetWindow returns how long the gates are evaluated after each node
+func (r UpgradeGates) GetWindow() time.Duration {
+	if window, err := time.ParseDuration(r.Window); err == nil && window > 0 {
+		return window
+	}
+	return defaults.UpgradeGateWindow
+}
+
+// GetInterval returns how often the gates are evaluated within the window
+func (r UpgradeGates) GetInterval() time.Duration {
+	if interval, err := time.ParseDuration(r.Interval); err == nil && interval > 0 {
+		return interval
+	}
+	return defaults.UpgradeGateInterval
+}
+
+// UpgradeGate defines a single health gate.
+// Exactly one of Query or PodRestarts should be specified
+type UpgradeGate struct {
+	// Name identifies the gate
+	Name string `json:"name"`
+	// Query is an InfluxQL query against the cluster monitoring database
+	// that returns a single value, e.g. the error rate
+	Query string `json:"query,omitempty"`
+	// PodRestarts counts container restarts of the selected pods
+	// since the node upgrade has completed
+	PodRestarts *PodRestartsGate `json:"podRestarts,omitempty"`
+	// Max is the maximum allowed value
+	Max *float64 `json:"max,omitempty"`
+	// Min is the minimum allowed value
+	Min *float64 `json:"min,omitempty"`
+}
+
+// Check makes sure the upgrade gate is well-formed
+func (r UpgradeGate) Check() error {
+	if r.Name == "" {
+		return trace.BadParameter("upgrade gate name cannot be empty")
+	}
+	if (r.Query == "") == (r.PodRestarts == nil) {
+		return trace.BadParameter("upgrade gate %q should specify either query or podRestarts",
+			r.Name)
+	}
+	if r.Max == nil && r.Min == nil {
+		return trace.BadParameter("upgrade gate %q should specify max or min", r.Name)
+	}
+	if r.Max != nil && r.Min != nil && *r.Min > *r.Max {
+		return trace.BadParameter("upgrade gate %q min exceeds max", r.Name)
+	}
+	return nil
+}
+
+// Evaluate returns an error if the specified value violates the gate bounds
+func (r UpgradeGate) Evaluate(value float64) error {
+	if r.Max != nil && value > *r.Max {
+		return trace.LimitExceeded("upgrade gate %q: value %v is above maximum %v",
+			r.Name, value, *r.Max)
+	}
+	if r.Min != nil && value < *r.Min {
+		return trace.LimitExceeded("upgrade gate %q: value %v is below minimum %v",
+			r.Name, value, *r.Min)
+	}
+	return nil
+}
+
+// PodRestartsGate selects the pods whose container restarts are counted
+type PodRestartsGate struct {
+	// Namespace is the namespace of the pods. All namespaces
+	// are considered if unspecified
+	Namespace string `json:"namespace,omitempty"`
+	// Selector selects pods by labels. All pods are selected if unspecified
+	Selector map[string]string `json:"selector,omitempty"`
 }
 
 // Runtime describes the application runtime
@@ -1081,6 +1345,8 @@ type Extensions struct {
 	Configuration *ConfigurationExtension `json:"configuration,omitempty"`
 	// Catalog allows to customize application catalog feature
 	Catalog *CatalogExtension `json:"catalog,omitempty"`
+	// Ingress allows to enable the bundled ingress controller
+	Ingress *IngressExtension `json:"ingress,omitempty"`
 }
 
 // EncryptionExtension describes installer encryption extension
@@ -1109,6 +1375,12 @@ type CatalogExtension struct {
 	Disabled bool `json:"disabled,omitempty"`
 }
 
+// IngressExtension allows to enable the bundled ingress controller
+type IngressExtension struct {
+	// Enabled installs the bundled ingress controller
+	Enabled bool `json:"enabled,omitempty"`
+}
+
 // Kubernetes allows to customize kubernetes feature
 type KubernetesExtension struct {
 	// Disabled allows to disable Kubernetes tab
@@ -1121,6 +1393,269 @@ type ConfigurationExtension struct {
 	Disabled bool `json:"disabled,omitempty"`
 }
 
+// SnapshotPolicy describes scheduled CSI snapshots of a set of
+// application persistent volume claims
+type SnapshotPolicy struct {
+	// Name identifies the policy among the application snapshot policies
+	Name string `json:"name"`
+	// Namespace is the namespace of the persistent volume claims
+	Namespace string `json:"namespace,omitempty"`
+	// Selector selects persistent volume claims by labels. All claims
+	// in the namespace are selected if unspecified
+	Selector map[string]string `json:"selector,omitempty"`
+	// SnapshotClass is the name of the volume snapshot class to use.
+	// The default snapshot class is used if unspecified
+	SnapshotClass string `json:"snapshotClass,omitempty"`
+	// Interval is how often the volumes are snapshotted, e.g. "6h"
+	Interval string `json:"interval"`
+	// Retain is how many most recent snapshots of every volume to keep
+	Retain int `json:"retain,omitempty"`
+}
+
+// Check makes sure the snapshot policy is well-formed
+func (r SnapshotPolicy) Check() error {
+	if r.Name == "" {
+		return trace.BadParameter("snapshot policy name cannot be empty")
+	}
+	interval, err := time.ParseDuration(r.Interval)
+	if err != nil {
+		return trace.BadParameter("snapshot policy %q has invalid interval %q",
+			r.Name, r.Interval)
+	}
+	if interval < defaults.SnapshotMinInterval {
+		return trace.BadParameter("snapshot policy %q interval should be at least %v",
+			r.Name, defaults.SnapshotMinInterval)
+	}
+	if r.Retain < 0 {
+		return trace.BadParameter("snapshot policy %q retain count cannot be negative", r.Name)
+	}
+	return nil
+}
+
+// GetNamespace returns the namespace of the persistent volume claims
+func (r SnapshotPolicy) GetNamespace() string {
+	if r.Namespace == "" {
+		return defaults.Namespace
+	}
+	return r.Namespace
+}
+
+// GetInterval returns how often the volumes are snapshotted
+func (r SnapshotPolicy) GetInterval() time.Duration {
+	interval, err := time.ParseDuration(r.Interval)
+	if err != nil {
+		return 0
+	}
+	return interval
+}
+
+// GetRetain returns how many most recent snapshots of every volume to keep
+func (r SnapshotPolicy) GetRetain() int {
+	if r.Retain == 0 {
+		return defaults.SnapshotRetain
+	}
+	return r.Retain
+}
+
+// Namespace describes a Kubernetes namespace created for the application
+// along with its resource quota and default container limits
+type Namespace struct {
+	// Name is the namespace name
+	Name string `json:"name"`
+	// Labels is a set of labels to set on the namespace
+	Labels map[string]string `json:"labels,omitempty"`
+	// ResourceQuota maps resource names to hard limits for the namespace,
+	// e.g. "requests.cpu": "4"
+	ResourceQuota map[string]string `json:"resourceQuota,omitempty"`
+	// LimitRange defines default and bounding container resources
+	LimitRange *LimitRange `json:"limitRange,omitempty"`
+}
+
+// LimitRange defines container resource limits in a namespace
+type LimitRange struct {
+	// Default is the default container resource limits
+	Default map[string]string `json:"default,omitempty"`
+	// DefaultRequest is the default container resource requests
+	DefaultRequest map[string]string `json:"defaultRequest,omitempty"`
+	// Max is the maximum container resource limits
+	Max map[string]string `json:"max,omitempty"`
+	// Min is the minimum container resource requests
+	Min map[string]string `json:"min,omitempty"`
+}
+
+// Check makes sure the namespace is well-formed
+func (r Namespace) Check() error {
+	if errs := validation.IsDNS1123Label(r.Name); len(errs) != 0 {
+		return trace.BadParameter("invalid namespace name %q: %v",
+			r.Name, strings.Join(errs, ", "))
+	}
+	if _, err := r.ResourceQuotaSpec(); err != nil {
+		return trace.Wrap(err)
+	}
+	if _, err := r.LimitRangeSpec(); err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// ResourceQuotaSpec returns the resource quota spec of the namespace.
+// Returns nil if the namespace does not declare a quota
+func (r Namespace) ResourceQuotaSpec() (*v1.ResourceQuotaSpec, error) {
+	if len(r.ResourceQuota) == 0 {
+		return nil, nil
+	}
+	hard, err := resourceList(r.ResourceQuota)
+	if err != nil {
+		return nil, trace.Wrap(err, "invalid resource quota for namespace %q", r.Name)
+	}
+	return &v1.ResourceQuotaSpec{Hard: hard}, nil
+}
+
+// LimitRangeSpec returns the container limit range spec of the namespace.
+// Returns nil if the namespace does not declare limits
+func (r Namespace) LimitRangeSpec() (*v1.LimitRangeSpec, error) {
+	if r.LimitRange == nil {
+		return nil, nil
+	}
+	item := v1.LimitRangeItem{Type: v1.LimitTypeContainer}
+	for _, limit := range []struct {
+		from map[string]string
+		to   *v1.ResourceList
+	}{
+		{r.LimitRange.Default, &item.Default},
+		{r.LimitRange.DefaultRequest, &item.DefaultRequest},
+		{r.LimitRange.Max, &item.Max},
+		{r.LimitRange.Min, &item.Min},
+	} {
+		list, err := resourceList(limit.from)
+		if err != nil {
+			return nil, trace.Wrap(err, "invalid limit range for namespace %q", r.Name)
+		}
+		*limit.to = list
+	}
+	return &v1.LimitRangeSpec{Limits: []v1.LimitRangeItem{item}}, nil
+}
+
+// resourceList converts the map of resource names to quantities
+// into a resource list
+func resourceList(resources map[string]string) (v1.ResourceList, error) {
+	if len(resources) == 0 {
+		return nil, nil
+	}
+	list := make(v1.ResourceList, len(resources))
+	for name, value := range resources {
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, trace.BadParameter("invalid quantity %q for %v: %v", value, name, err)
+		}
+		list[v1.ResourceName(name)] = quantity
+	}
+	return list, nil
+}
+
+// Compatibility defines the node OS and kernel compatibility matrix
+// of the cluster runtime
+type Compatibility struct {
+	// Kernel specifies the range of supported kernel versions
+	Kernel *KernelVersionRange `json:"kernel,omitempty"`
+	// KernelModules lists kernel modules required on cluster nodes
+	KernelModules []KernelModule `json:"kernelModules,omitempty"`
+	// UnsupportedOS lists OS distributions known to be incompatible
+	UnsupportedOS []UnsupportedOS `json:"unsupportedOS,omitempty"`
+}
+
+// Check makes sure the compatibility matrix is well-formed
+func (r Compatibility) Check() error {
+	var errors []error
+	if r.Kernel != nil {
+		for _, version := range []string{r.Kernel.Min, r.Kernel.Max} {
+			if version == "" {
+				continue
+			}
+			if _, err := parseKernelVersion(version); err != nil {
+				errors = append(errors, trace.Wrap(err))
+			}
+		}
+	}
+	for _, module := range r.KernelModules {
+		if module.Name == "" {
+			errors = append(errors, trace.BadParameter("kernel module name cannot be empty"))
+		}
+	}
+	for _, distro := range r.UnsupportedOS {
+		if _, err := regexp.Compile(distro.Name); err != nil {
+			errors = append(errors, trace.BadParameter(
+				"unsupported OS name %q is not a valid regular expression", distro.Name))
+		}
+	}
+	return trace.NewAggregate(errors...)
+}
+
+// KernelVersionRange defines a range of kernel versions.
+// Versions are compared component-wise only up to the number of
+// components specified, i.e. a maximum of 4.19 includes 4.19.100
+type KernelVersionRange struct {
+	// Min is the minimum supported kernel version
+	Min string `json:"min,omitempty"`
+	// Max is the maximum supported kernel version
+	Max string `json:"max,omitempty"`
+}
+
+// KernelModule describes a required kernel module
+type KernelModule struct {
+	// Name is the name of the kernel module
+	Name string `json:"name"`
+	// Names lists alternative names the module is known under
+	Names []string `json:"names,omitempty"`
+	// HairpinModes optionally limits the requirement to nodes
+	// configured with one of the specified hairpin modes
+	HairpinModes []string `json:"hairpinModes,omitempty"`
+	// StorageDrivers optionally limits the requirement to nodes
+	// configured with one of the specified docker storage drivers
+	StorageDrivers []string `json:"storageDrivers,omitempty"`
+}
+
+// IsRequired returns true if the module is required for a node with
+// the specified hairpin mode and docker storage driver
+func (r KernelModule) IsRequired(hairpinMode, storageDriver string) bool {
+	if len(r.HairpinModes) != 0 && !utils.StringInSlice(r.HairpinModes, hairpinMode) {
+		return false
+	}
+	if len(r.StorageDrivers) != 0 && !utils.StringInSlice(r.StorageDrivers, storageDriver) {
+		return false
+	}
+	return true
+}
+
+// UnsupportedOS describes an incompatible OS distribution
+type UnsupportedOS struct {
+	// Name is the OS distribution ID (e.g. "centos", "ubuntu").
+	// The value is a regular expression
+	Name string `json:"name"`
+	// Versions optionally lists incompatible versions.
+	// Versions are matched as prefixes, all versions match if unspecified
+	Versions []string `json:"versions,omitempty"`
+	// Reason explains why the distribution is not supported
+	Reason string `json:"reason,omitempty"`
+}
+
+// DefaultCompatibility is the compatibility matrix used for runtimes that
+// do not define one
+var DefaultCompatibility = Compatibility{
+	KernelModules: []KernelModule{
+		{Name: "overlay", StorageDrivers: []string{
+			constants.DockerStorageDriverOverlay,
+			constants.DockerStorageDriverOverlay2,
+		}},
+		{Name: "ebtables", HairpinModes: []string{constants.HairpinModePromiscuousBridge}},
+		{Name: "ip_tables", HairpinModes: []string{constants.HairpinModePromiscuousBridge}},
+		{Name: "iptable_filter", HairpinModes: []string{constants.HairpinModePromiscuousBridge}},
+		{Name: "iptable_nat", HairpinModes: []string{constants.HairpinModePromiscuousBridge}},
+		{Name: "br_netfilter", Names: []string{"bridge"},
+			HairpinModes: []string{constants.HairpinModePromiscuousBridge}},
+	},
+}
+
 func init() {
 	addKnownTypes(scheme.Scheme)
 }
@@ -1186,6 +1721,12 @@ func ShouldSkipApp(manifest Manifest, app loc.Locator) bool {
 		if ext != nil && ext.Catalog != nil && ext.Catalog.Disabled {
 			return true
 		}
+	case defaults.IngressAppName:
+		// do not install ingress-app unless the bundled ingress controller is enabled
+		ext := manifest.Extensions
+		if ext == nil || ext.Ingress == nil || !ext.Ingress.Enabled {
+			return true
+		}
 	}
 	return false
 }
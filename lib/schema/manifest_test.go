@@ -17,7 +17,9 @@ limitations under the License.
 package schema
 
 import (
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/gravitational/gravity/lib/compare"
 	"github.com/gravitational/gravity/lib/constants"
@@ -28,6 +30,7 @@ import (
 	"github.com/gravitational/trace"
 	. "gopkg.in/check.v1"
 	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -362,6 +365,157 @@ metadata:
 	c.Assert(err, NotNil)
 }
 
+func (s *ManifestSuite) TestInvalidKernelVersionInCompatibility(c *C) {
+	bytes := []byte(`apiVersion: bundle.gravitational.io/v2
+kind: Runtime
+metadata:
+  name: kubernetes
+  resourceVersion: 0.0.1
+compatibility:
+  kernel:
+    min: latest`)
+	_, err := ParseManifestYAML(bytes)
+	c.Assert(err, NotNil)
+}
+
+func (s *ManifestSuite) TestSnapshotPolicies(c *C) {
+	bytes := []byte(`apiVersion: app.gravitational.io/v2
+kind: Application
+metadata:
+  name: postgres
+  resourceVersion: 0.0.1
+snapshots:
+  - name: data
+    selector:
+      app: postgres
+    snapshotClass: csi-rbd
+    interval: 6h`)
+	manifest, err := ParseManifestYAML(bytes)
+	c.Assert(err, IsNil)
+	c.Assert(manifest.Snapshots, DeepEquals, []SnapshotPolicy{{
+		Name:          "data",
+		Selector:      map[string]string{"app": "postgres"},
+		SnapshotClass: "csi-rbd",
+		Interval:      "6h",
+	}})
+	policy := manifest.Snapshots[0]
+	c.Assert(policy.GetNamespace(), Equals, defaults.Namespace)
+	c.Assert(policy.GetInterval(), Equals, 6*time.Hour)
+	c.Assert(policy.GetRetain(), Equals, defaults.SnapshotRetain)
+
+	for _, interval := range []string{"daily", "1m"} {
+		bytes := []byte(fmt.Sprintf(`apiVersion: app.gravitational.io/v2
+kind: Application
+metadata:
+  name: postgres
+  resourceVersion: 0.0.1
+snapshots:
+  - name: data
+    interval: %v`, interval))
+		_, err := ParseManifestYAML(bytes)
+		c.Assert(err, NotNil, Commentf("interval %v", interval))
+	}
+}
+
+func (s *ManifestSuite) TestNamespaces(c *C) {
+	bytes := []byte(`apiVersion: app.gravitational.io/v2
+kind: Application
+metadata:
+  name: postgres
+  resourceVersion: 0.0.1
+namespaces:
+  - name: postgres
+    labels:
+      team: db
+    resourceQuota:
+      requests.cpu: "4"
+      pods: "20"
+    limitRange:
+      default:
+        memory: 512Mi
+      max:
+        cpu: "2"`)
+	manifest, err := ParseManifestYAML(bytes)
+	c.Assert(err, IsNil)
+	c.Assert(manifest.Namespaces, HasLen, 1)
+	namespace := manifest.Namespaces[0]
+	c.Assert(namespace.Labels, DeepEquals, map[string]string{"team": "db"})
+
+	quota, err := namespace.ResourceQuotaSpec()
+	c.Assert(err, IsNil)
+	c.Assert(quota.Hard, DeepEquals, v1.ResourceList{
+		v1.ResourceRequestsCPU: resource.MustParse("4"),
+		v1.ResourcePods:        resource.MustParse("20"),
+	})
+	limits, err := namespace.LimitRangeSpec()
+	c.Assert(err, IsNil)
+	c.Assert(limits.Limits, DeepEquals, []v1.LimitRangeItem{{
+		Type:    v1.LimitTypeContainer,
+		Default: v1.ResourceList{v1.ResourceMemory: resource.MustParse("512Mi")},
+		Max:     v1.ResourceList{v1.ResourceCPU: resource.MustParse("2")},
+	}})
+
+	for _, namespace := range []string{
+		"name: Invalid_Name",
+		"name: ok\n    resourceQuota:\n      requests.cpu: lots",
+	} {
+		bytes := []byte(fmt.Sprintf(`apiVersion: app.gravitational.io/v2
+kind: Application
+metadata:
+  name: postgres
+  resourceVersion: 0.0.1
+namespaces:
+  - %v`, namespace))
+		_, err := ParseManifestYAML(bytes)
+		c.Assert(err, NotNil, Commentf("namespace %q", namespace))
+	}
+}
+
+func (s *ManifestSuite) TestFirewall(c *C) {
+	manifest, err := ParseManifestYAML([]byte(`apiVersion: app.gravitational.io/v2
+kind: Application
+metadata:
+  name: app
+  resourceVersion: 0.0.1
+systemOptions:
+  runtime:
+    version: "1.4.6"
+  firewall:
+    managed: true
+    backend: nftables`))
+	c.Assert(err, IsNil)
+	c.Assert(manifest.SystemOptions.ManagedFirewall(), DeepEquals, &Firewall{
+		Managed: true,
+		Backend: "nftables",
+	})
+
+	manifest, err = ParseManifestYAML([]byte(`apiVersion: app.gravitational.io/v2
+kind: Application
+metadata:
+  name: app
+  resourceVersion: 0.0.1
+systemOptions:
+  runtime:
+    version: "1.4.6"
+  firewall:
+    backend: iptables`))
+	c.Assert(err, IsNil)
+	c.Assert(manifest.SystemOptions.ManagedFirewall(), IsNil)
+
+	_, err = ParseManifestYAML([]byte(`apiVersion: app.gravitational.io/v2
+kind: Application
+metadata:
+  name: app
+  resourceVersion: 0.0.1
+systemOptions:
+  runtime:
+    version: "1.4.6"
+  firewall:
+    managed: true
+    backend: pf`))
+	c.Assert(err, NotNil)
+}
+
 func (s *ManifestSuite) TestInvalidProfileInFlavor(c *C) {
 	bytes := []byte(`apiVersion: bundle.gravitational.io/v2
 kind: Bundle
@@ -552,6 +706,114 @@ systemOptions:
 	c.Assert(err, NotNil)
 }
 
+func (s *ManifestSuite) TestUpgradeTaint(c *C) {
+	m, err := ParseManifestYAML([]byte(`apiVersion: bundle.gravitational.io/v2
+kind: Bundle
+metadata:
+  name: myapp
+  resourceVersion: 0.0.1`))
+	c.Assert(err, IsNil)
+	compare.DeepCompare(c, m.UpgradeTaint(), v1.Taint{
+		Key:    defaults.RunLevelLabel,
+		Value:  defaults.RunLevelSystem,
+		Effect: v1.TaintEffectNoExecute,
+	})
+
+	m, err = ParseManifestYAML([]byte(`apiVersion: bundle.gravitational.io/v2
+kind: Bundle
+metadata:
+  name: myapp
+  resourceVersion: 0.0.1
+systemOptions:
+  runtime:
+    version: 0.0.1
+  upgrade:
+    taint:
+      key: example.com/upgrade
+      effect: NoSchedule`))
+	c.Assert(err, IsNil)
+	compare.DeepCompare(c, m.UpgradeTaint(), v1.Taint{
+		Key:    "example.com/upgrade",
+		Effect: v1.TaintEffectNoSchedule,
+	})
+
+	_, err = ParseManifestYAML([]byte(`apiVersion: bundle.gravitational.io/v2
+kind: Bundle
+metadata:
+  name: myapp
+  resourceVersion: 0.0.1
+systemOptions:
+  runtime:
+    version: 0.0.1
+  upgrade:
+    taint:
+      key: example.com/upgrade
+      effect: Evict`))
+	c.Assert(err, NotNil)
+}
+
+func (s *ManifestSuite) TestUpgradeGates(c *C) {
+	m, err := ParseManifestYAML([]byte(`apiVersion: bundle.gravitational.io/v2
+kind: Bundle
+metadata:
+  name: myapp
+  resourceVersion: 0.0.1
+systemOptions:
+  runtime:
+    version: 0.0.1
+  upgrade:
+    gates:
+      window: 5m
+      checks:
+      - name: error-rate
+        query: SELECT last("value") FROM "http_errors"
+        max: 0.05
+      - name: restarts
+        podRestarts:
+          namespace: default
+          selector:
+            app: web
+        max: 0`))
+	c.Assert(err, IsNil)
+	gates := m.SystemOptions.UpgradeGates()
+	c.Assert(gates, NotNil)
+	c.Assert(gates.GetWindow(), Equals, 5*time.Minute)
+	c.Assert(gates.GetInterval(), Equals, defaults.UpgradeGateInterval)
+	c.Assert(gates.Checks, HasLen, 2)
+	c.Assert(gates.Checks[0].Evaluate(0.01), IsNil)
+	c.Assert(gates.Checks[0].Evaluate(0.1), NotNil)
+	c.Assert(gates.Checks[1].PodRestarts.Selector, DeepEquals, map[string]string{"app": "web"})
+	c.Assert(gates.Checks[1].Evaluate(1), NotNil)
+
+	for _, gate := range []string{
+		// neither query nor podRestarts
+		`- name: empty
+        max: 1`,
+		// no bounds
+		`- name: error-rate
+        query: SELECT 1`,
+		// both query and podRestarts
+		`- name: error-rate
+        query: SELECT 1
+        podRestarts: {}
+        max: 1`,
+	} {
+		_, err = ParseManifestYAML([]byte(`apiVersion: bundle.gravitational.io/v2
+kind: Bundle
+metadata:
+  name: myapp
+  resourceVersion: 0.0.1
+systemOptions:
+  runtime:
+    version: 0.0.1
+  upgrade:
+    gates:
+      checks:
+      ` + gate))
+		c.Assert(err, NotNil, Commentf(gate))
+	}
+}
+
 func (s *ManifestSuite) TestCanOverrideBooleans(c *C) {
 	bytes := []byte(`apiVersion: bundle.gravitational.io/v2
 kind: Bundle
@@ -637,9 +899,16 @@ extensions:
 			name: defaults.BandwagonPackageName,
 			skip: true,
 		},
+		{
+			name: defaults.IngressAppName,
+			skip: true,
+		},
 	}
 	for _, tc := range testCases {
 		c.Assert(ShouldSkipApp(*m, loc.Locator{Name: tc.name}), Equals, tc.skip,
 			Commentf("Test case %v failed", tc))
 	}
+
+	m.Extensions.Ingress = &IngressExtension{Enabled: true}
+	c.Assert(ShouldSkipApp(*m, loc.Locator{Name: defaults.IngressAppName}), Equals, false)
 }
@@ -0,0 +1,155 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schema
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/gravitational/gravity/lib/utils"
+
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/satellite/monitoring"
+	"github.com/gravitational/trace"
+)
+
+// ValidateOSPackages verifies that the OS packages required by the specified
+// node profile are either installed on the local node or bundled with
+// the installer for the node's OS distribution, and returns the list of
+// failed probes
+func ValidateOSPackages(profile NodeProfile) (failed []*pb.Probe, err error) {
+	if len(profile.Requirements.OSPackages) == 0 {
+		return nil, nil
+	}
+	return validateOSPackages(profile.Requirements.OSPackages, hostPackages{})
+}
+
+func validateOSPackages(packages []OSPackage, node packageInfo) (failed []*pb.Probe, err error) {
+	release, err := node.osRelease()
+	if err != nil {
+		return nil, trace.Wrap(err, "failed to query OS version")
+	}
+	missing, err := missingOSPackages(packages, node)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	for _, pkg := range missing {
+		if len(pkg.FilesFor(*release)) != 0 {
+			// the package will be installed from the bundled files
+			continue
+		}
+		failed = append(failed, &pb.Probe{
+			Checker: osPackagesCheckerID,
+			Detail: fmt.Sprintf("OS package %v is not installed and is not bundled for %v. "+
+				"Install the package on the node", pkg.Name, release.Name()),
+			Status: pb.Probe_Failed,
+		})
+	}
+	return failed, nil
+}
+
+// MissingOSPackages returns the packages from the specified list
+// that are not installed on the local node
+func MissingOSPackages(packages []OSPackage) ([]OSPackage, error) {
+	return missingOSPackages(packages, hostPackages{})
+}
+
+func missingOSPackages(packages []OSPackage, node packageInfo) (missing []OSPackage, err error) {
+	for _, pkg := range packages {
+		installed, err := node.isPackageInstalled(pkg.Name)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		if !installed {
+			missing = append(missing, pkg)
+		}
+	}
+	return missing, nil
+}
+
+// FilesFor returns the glob patterns of the package files bundled
+// for the specified OS distribution
+func (p OSPackage) FilesFor(release monitoring.OSRelease) []string {
+	for _, bundle := range p.Bundled {
+		for _, os := range bundle.OS {
+			if strings.EqualFold(os, release.ID) || utils.StringInSlice(release.Like, os) {
+				return bundle.Files
+			}
+		}
+	}
+	return nil
+}
+
+// MatchesFile returns true if the specified path, relative to the
+// application resources directory, matches any of the patterns
+func MatchesFile(patterns []string, path string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := filepath.Match(filepath.Clean(pattern), filepath.Clean(path)); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// packageInfo provides access to the OS packages installed on the node
+type packageInfo interface {
+	// osRelease returns the OS distribution information
+	osRelease() (*monitoring.OSRelease, error)
+	// isPackageInstalled returns true if the specified package is installed
+	isPackageInstalled(name string) (bool, error)
+}
+
+// hostPackages implements packageInfo for the local host
+type hostPackages struct{}
+
+func (hostPackages) osRelease() (*monitoring.OSRelease, error) {
+	return monitoring.GetOSRelease()
+}
+
+// isPackageInstalled queries the package database of either dpkg or rpm,
+// whichever is available on the host
+func (hostPackages) isPackageInstalled(name string) (bool, error) {
+	if _, err := exec.LookPath("dpkg-query"); err == nil {
+		out, err := exec.Command("dpkg-query", "-W", "-f=${Status}", name).Output()
+		if err != nil {
+			if _, ok := err.(*exec.ExitError); ok {
+				// dpkg-query exits with an error for unknown packages
+				return false, nil
+			}
+			return false, trace.Wrap(err)
+		}
+		return strings.TrimSpace(string(out)) == "install ok installed", nil
+	}
+	if _, err := exec.LookPath("rpm"); err == nil {
+		err := exec.Command("rpm", "-q", "--quiet", name).Run()
+		if err != nil {
+			if _, ok := err.(*exec.ExitError); ok {
+				return false, nil
+			}
+			return false, trace.Wrap(err)
+		}
+		return true, nil
+	}
+	return false, trace.NotFound("neither dpkg nor rpm package manager found")
+}
+
+const (
+	// osPackagesCheckerID identifies the OS packages checker
+	osPackagesCheckerID = "os-packages"
+)
@@ -0,0 +1,77 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schema
+
+import (
+	"github.com/gravitational/satellite/monitoring"
+	. "gopkg.in/check.v1"
+)
+
+type OSPackagesSuite struct{}
+
+var _ = Suite(&OSPackagesSuite{})
+
+func (s *OSPackagesSuite) TestValidatesPackages(c *C) {
+	packages := []OSPackage{
+		{Name: "chrony"},
+		{
+			Name: "lvm2",
+			Bundled: []OSPackageFiles{
+				{OS: []string{"rhel"}, Files: []string{"packages/el7/*.rpm"}},
+				{OS: []string{"ubuntu"}, Files: []string{"packages/bionic/*.deb"}},
+			},
+		},
+	}
+	centos := monitoring.OSRelease{ID: "centos", VersionID: "7", Like: []string{"rhel", "fedora"}}
+	debian := monitoring.OSRelease{ID: "debian", VersionID: "9"}
+	var testCases = []struct {
+		comment string
+		node    testNode
+		failed  []string
+	}{
+		{
+			comment: "all packages installed",
+			node:    testNode{os: debian, packages: []string{"chrony", "lvm2"}},
+		},
+		{
+			comment: "missing package is bundled for the derived distribution",
+			node:    testNode{os: centos, packages: []string{"chrony"}},
+		},
+		{
+			comment: "missing packages are not bundled",
+			node:    testNode{os: debian},
+			failed: []string{
+				"OS package chrony is not installed and is not bundled for debian 9. Install the package on the node",
+				"OS package lvm2 is not installed and is not bundled for debian 9. Install the package on the node",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		comment := Commentf(tc.comment)
+		failed, err := validateOSPackages(packages, tc.node)
+		c.Assert(err, IsNil, comment)
+		c.Assert(details(failed), DeepEquals, tc.failed, comment)
+	}
+}
+
+func (s *OSPackagesSuite) TestMatchesFiles(c *C) {
+	patterns := []string{"packages/el7/*.rpm", "./lvm2.deb"}
+	c.Assert(MatchesFile(patterns, "packages/el7/lvm2-2.02.rpm"), Equals, true)
+	c.Assert(MatchesFile(patterns, "lvm2.deb"), Equals, true)
+	c.Assert(MatchesFile(patterns, "packages/el8/lvm2-2.03.rpm"), Equals, false)
+	c.Assert(MatchesFile(patterns, "packages/el7/nested/lvm2-2.02.rpm"), Equals, false)
+}
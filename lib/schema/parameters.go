@@ -0,0 +1,286 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schema
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/trace"
+)
+
+const (
+	// ParameterTypeString is a parameter with an arbitrary string value
+	ParameterTypeString = "string"
+	// ParameterTypeInteger is a parameter with an integer value
+	ParameterTypeInteger = "integer"
+	// ParameterTypeBoolean is a parameter with a true/false value
+	ParameterTypeBoolean = "boolean"
+)
+
+// ParameterTypes lists all supported parameter types
+var ParameterTypes = []string{
+	ParameterTypeString,
+	ParameterTypeInteger,
+	ParameterTypeBoolean,
+}
+
+// Parameter describes an application configuration parameter the user
+// provides during installation, either in the installer UI or with
+// gravity install --set
+type Parameter struct {
+	// Name is the parameter name, a valid environment variable name
+	Name string `json:"name"`
+	// Title is the parameter label displayed in the installer UI
+	Title string `json:"title,omitempty"`
+	// Description is a verbose parameter description
+	Description string `json:"description,omitempty"`
+	// Group is the name of the parameter group in the installer UI
+	Group string `json:"group,omitempty"`
+	// Type is the parameter type: string, integer or boolean
+	Type string `json:"type,omitempty"`
+	// Default is the value used if the parameter is not set
+	Default string `json:"default,omitempty"`
+	// Required is whether the parameter must be set
+	Required bool `json:"required,omitempty"`
+	// Secret is whether the parameter value should be masked in the UI
+	Secret bool `json:"secret,omitempty"`
+	// Validation defines additional rules for parameter values
+	Validation ParameterValidation `json:"validation,omitempty"`
+}
+
+// ParameterValidation defines validation rules for parameter values
+type ParameterValidation struct {
+	// Pattern is a regular expression string values must match
+	Pattern string `json:"pattern,omitempty"`
+	// Values is the list of allowed values
+	Values []string `json:"values,omitempty"`
+	// Min is the minimum value of integer parameters
+	Min *int64 `json:"min,omitempty"`
+	// Max is the maximum value of integer parameters
+	Max *int64 `json:"max,omitempty"`
+	// Message is an optional message displayed when the value is invalid
+	Message string `json:"message,omitempty"`
+}
+
+// ParameterGroup groups related parameters in the installer UI
+type ParameterGroup struct {
+	// Name is the group name parameters refer to
+	Name string `json:"name"`
+	// Title is the group title displayed in the installer UI
+	Title string `json:"title,omitempty"`
+	// Description is a verbose group description
+	Description string `json:"description,omitempty"`
+}
+
+// Check makes sure the parameter definition is valid
+func (p Parameter) Check() error {
+	if !parameterNameRe.MatchString(p.Name) {
+		return trace.BadParameter("invalid parameter name %q: should start with a letter "+
+			"and contain only letters, digits and underscores", p.Name)
+	}
+	if !utils.StringInSlice(ParameterTypes, p.getType()) {
+		return trace.BadParameter("parameter %v has unsupported type %q, supported are: %v",
+			p.Name, p.Type, ParameterTypes)
+	}
+	if p.Validation.Pattern != "" {
+		if _, err := regexp.Compile(p.Validation.Pattern); err != nil {
+			return trace.BadParameter("parameter %v has invalid pattern %q: %v",
+				p.Name, p.Validation.Pattern, err)
+		}
+	}
+	if (p.Validation.Min != nil || p.Validation.Max != nil) && p.getType() != ParameterTypeInteger {
+		return trace.BadParameter("parameter %v: min and max are only supported for integer parameters",
+			p.Name)
+	}
+	if p.Validation.Min != nil && p.Validation.Max != nil && *p.Validation.Min > *p.Validation.Max {
+		return trace.BadParameter("parameter %v: min is greater than max", p.Name)
+	}
+	if p.Default != "" {
+		if err := p.CheckValue(p.Default); err != nil {
+			return trace.Wrap(err, "invalid default")
+		}
+	}
+	return nil
+}
+
+// CheckValue makes sure the specified value satisfies the parameter type
+// and validation rules
+func (p Parameter) CheckValue(value string) error {
+	if err := p.checkValue(value); err != nil {
+		if p.Validation.Message != "" {
+			return trace.BadParameter("invalid value %q for parameter %v: %v",
+				value, p.Name, p.Validation.Message)
+		}
+		return trace.BadParameter("invalid value %q for parameter %v: %v",
+			value, p.Name, err)
+	}
+	return nil
+}
+
+func (p Parameter) checkValue(value string) error {
+	switch p.getType() {
+	case ParameterTypeInteger:
+		number, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return trace.BadParameter("should be an integer")
+		}
+		if p.Validation.Min != nil && number < *p.Validation.Min {
+			return trace.BadParameter("should be at least %v", *p.Validation.Min)
+		}
+		if p.Validation.Max != nil && number > *p.Validation.Max {
+			return trace.BadParameter("should be at most %v", *p.Validation.Max)
+		}
+	case ParameterTypeBoolean:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return trace.BadParameter("should be true or false")
+		}
+	}
+	if len(p.Validation.Values) != 0 && !utils.StringInSlice(p.Validation.Values, value) {
+		return trace.BadParameter("should be one of %v", p.Validation.Values)
+	}
+	if p.Validation.Pattern != "" {
+		re, err := regexp.Compile(fmt.Sprintf("^(?:%v)$", p.Validation.Pattern))
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if !re.MatchString(value) {
+			return trace.BadParameter("should match %v", p.Validation.Pattern)
+		}
+	}
+	return nil
+}
+
+func (p Parameter) getType() string {
+	if p.Type == "" {
+		return ParameterTypeString
+	}
+	return p.Type
+}
+
+// Parameters is a list of application configuration parameters
+type Parameters []Parameter
+
+// ByName returns a parameter by its name
+func (r Parameters) ByName(name string) (*Parameter, error) {
+	for _, parameter := range r {
+		if parameter.Name == name {
+			return &parameter, nil
+		}
+	}
+	return nil, trace.NotFound("parameter %q is not defined", name)
+}
+
+// Check makes sure the parameter definitions are valid and
+// only refer to the specified groups
+func (r Parameters) Check(groups []ParameterGroup) error {
+	var errors []error
+	groupNames := make(map[string]struct{})
+	for _, group := range groups {
+		if group.Name == "" {
+			errors = append(errors, trace.BadParameter("parameter group name cannot be empty"))
+		}
+		groupNames[group.Name] = struct{}{}
+	}
+	names := make(map[string]struct{})
+	for _, parameter := range r {
+		if err := parameter.Check(); err != nil {
+			errors = append(errors, trace.Wrap(err))
+		}
+		if _, ok := names[parameter.Name]; ok {
+			errors = append(errors, trace.BadParameter("parameter %v is defined more than once",
+				parameter.Name))
+		}
+		names[parameter.Name] = struct{}{}
+		if _, ok := groupNames[parameter.Group]; parameter.Group != "" && !ok {
+			errors = append(errors, trace.BadParameter("parameter %v refers to undefined group %q",
+				parameter.Name, parameter.Group))
+		}
+	}
+	return trace.NewAggregate(errors...)
+}
+
+// Validate makes sure the specified values are set for defined parameters
+// and are valid. Unlike Resolve, it does not require required parameters
+// to be set which allows validating values that are provided in steps
+func (r Parameters) Validate(values map[string]string) error {
+	var errors []error
+	for _, name := range sortedNames(values) {
+		parameter, err := r.ByName(name)
+		if err != nil {
+			errors = append(errors, trace.Wrap(err))
+			continue
+		}
+		if err := parameter.CheckValue(values[name]); err != nil {
+			errors = append(errors, trace.Wrap(err))
+		}
+	}
+	return trace.NewAggregate(errors...)
+}
+
+// Resolve validates the specified values and returns them along with
+// the default values of parameters that have not been set.
+// Returns an error if any of the required parameters is not set
+func (r Parameters) Resolve(values map[string]string) (map[string]string, error) {
+	if err := r.Validate(values); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var errors []error
+	resolved := make(map[string]string)
+	for _, parameter := range r {
+		value, ok := values[parameter.Name]
+		switch {
+		case ok:
+			resolved[parameter.Name] = value
+		case parameter.Default != "":
+			resolved[parameter.Name] = parameter.Default
+		case parameter.Required:
+			errors = append(errors, trace.BadParameter("parameter %v is required", parameter.Name))
+		}
+	}
+	if len(errors) != 0 {
+		return nil, trace.NewAggregate(errors...)
+	}
+	return resolved, nil
+}
+
+// ParameterEnv returns the environment variables that pass the specified
+// parameter values to application hooks
+func ParameterEnv(values map[string]string) map[string]string {
+	env := make(map[string]string, len(values))
+	for name, value := range values {
+		env[fmt.Sprintf(constants.EnvTelekubeParameterTemplate, strings.ToUpper(name))] = value
+	}
+	return env
+}
+
+func sortedNames(values map[string]string) []string {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var parameterNameRe = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]*$`)
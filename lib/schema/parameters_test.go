@@ -0,0 +1,185 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schema
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+type ParametersSuite struct{}
+
+var _ = Suite(&ParametersSuite{})
+
+func (s *ParametersSuite) TestParsesParameters(c *C) {
+	manifest, err := ParseManifestYAML([]byte(`apiVersion: bundle.gravitational.io/v2
+kind: Bundle
+metadata:
+  name: myapp
+  resourceVersion: 0.0.1
+installer:
+  parameterGroups:
+    - name: database
+      title: Database
+  parameters:
+    - name: db_host
+      title: Database host
+      group: database
+      required: true
+    - name: db_port
+      group: database
+      type: integer
+      default: "5432"
+      validation:
+        min: 1
+        max: 65535
+    - name: log_level
+      validation:
+        values: [debug, info]
+        message: use debug or info`))
+	c.Assert(err, IsNil)
+	parameters := manifest.InstallerParameters()
+	c.Assert(parameters, HasLen, 3)
+	c.Assert(parameters[0].Title, Equals, "Database host")
+	c.Assert(*parameters[1].Validation.Max, Equals, int64(65535))
+	c.Assert(manifest.Installer.ParameterGroups, DeepEquals, []ParameterGroup{{Name: "database", Title: "Database"}})
+}
+
+func (s *ParametersSuite) TestRejectsInvalidDefinitions(c *C) {
+	var testCases = []struct {
+		comment    string
+		parameters string
+	}{
+		{
+			comment: "invalid name",
+			parameters: `
+    - name: db-host`,
+		},
+		{
+			comment: "unsupported type",
+			parameters: `
+    - name: size
+      type: float`,
+		},
+		{
+			comment: "undefined group",
+			parameters: `
+    - name: host
+      group: database`,
+		},
+		{
+			comment: "duplicate name",
+			parameters: `
+    - name: host
+    - name: host`,
+		},
+		{
+			comment: "invalid default",
+			parameters: `
+    - name: port
+      type: integer
+      default: http`,
+		},
+		{
+			comment: "range on string",
+			parameters: `
+    - name: host
+      validation:
+        min: 1`,
+		},
+	}
+	for _, tc := range testCases {
+		_, err := ParseManifestYAML([]byte(`apiVersion: bundle.gravitational.io/v2
+kind: Bundle
+metadata:
+  name: myapp
+  resourceVersion: 0.0.1
+installer:
+  parameters:` + tc.parameters))
+		c.Assert(err, NotNil, Commentf(tc.comment))
+	}
+}
+
+func (s *ParametersSuite) TestResolvesValues(c *C) {
+	min, max := int64(1), int64(65535)
+	parameters := Parameters{
+		{Name: "host", Required: true, Validation: ParameterValidation{Pattern: `[a-z.]+`}},
+		{Name: "port", Type: ParameterTypeInteger, Default: "5432",
+			Validation: ParameterValidation{Min: &min, Max: &max}},
+		{Name: "tls", Type: ParameterTypeBoolean},
+		{Name: "level", Validation: ParameterValidation{Values: []string{"debug", "info"},
+			Message: "use debug or info"}},
+	}
+	var testCases = []struct {
+		comment  string
+		values   map[string]string
+		resolved map[string]string
+		err      string
+	}{
+		{
+			comment:  "defaults are applied",
+			values:   map[string]string{"host": "db.local"},
+			resolved: map[string]string{"host": "db.local", "port": "5432"},
+		},
+		{
+			comment:  "all values set",
+			values:   map[string]string{"host": "db", "port": "80", "tls": "true", "level": "info"},
+			resolved: map[string]string{"host": "db", "port": "80", "tls": "true", "level": "info"},
+		},
+		{
+			comment: "missing required",
+			values:  map[string]string{"port": "80"},
+			err:     ".*parameter host is required.*",
+		},
+		{
+			comment: "pattern mismatch",
+			values:  map[string]string{"host": "db:80"},
+			err:     `.*invalid value "db:80" for parameter host: should match.*`,
+		},
+		{
+			comment: "out of range",
+			values:  map[string]string{"host": "db", "port": "70000"},
+			err:     ".*should be at most 65535.*",
+		},
+		{
+			comment: "not a boolean",
+			values:  map[string]string{"host": "db", "tls": "maybe"},
+			err:     ".*should be true or false.*",
+		},
+		{
+			comment: "custom message",
+			values:  map[string]string{"host": "db", "level": "trace"},
+			err:     ".*parameter level: use debug or info.*",
+		},
+		{
+			comment: "undefined parameter",
+			values:  map[string]string{"host": "db", "hots": "db"},
+			err:     `.*parameter "hots" is not defined.*`,
+		},
+	}
+	for _, tc := range testCases {
+		comment := Commentf(tc.comment)
+		resolved, err := parameters.Resolve(tc.values)
+		if tc.err != "" {
+			c.Assert(err, ErrorMatches, tc.err, comment)
+			continue
+		}
+		c.Assert(err, IsNil, comment)
+		c.Assert(resolved, DeepEquals, tc.resolved, comment)
+	}
+	// partial values are valid before required parameters are set
+	c.Assert(parameters.Validate(map[string]string{"port": "80"}), IsNil)
+}
@@ -91,6 +91,55 @@ func CheckAndSetDefaults(manifest *Manifest) error {
 		errors = append(errors, trace.Wrap(err))
 	}
 
+	if manifest.Compatibility != nil {
+		err = manifest.Compatibility.Check()
+		if err != nil {
+			errors = append(errors, trace.Wrap(err))
+		}
+	}
+
+	names := make(map[string]struct{})
+	for _, policy := range manifest.Snapshots {
+		if err := policy.Check(); err != nil {
+			errors = append(errors, trace.Wrap(err))
+		}
+		if _, ok := names[policy.Name]; ok {
+			errors = append(errors, trace.BadParameter(
+				"duplicate snapshot policy %q", policy.Name))
+		}
+		names[policy.Name] = struct{}{}
+	}
+
+	if gates := manifest.SystemOptions.UpgradeGates(); gates != nil {
+		if err := gates.Check(); err != nil {
+			errors = append(errors, trace.Wrap(err))
+		}
+	}
+
+	namespaces := make(map[string]struct{})
+	for _, namespace := range manifest.Namespaces {
+		if err := namespace.Check(); err != nil {
+			errors = append(errors, trace.Wrap(err))
+		}
+		if _, ok := namespaces[namespace.Name]; ok {
+			errors = append(errors, trace.BadParameter(
+				"duplicate namespace %q", namespace.Name))
+		}
+		namespaces[namespace.Name] = struct{}{}
+	}
+
+	if manifest.Storage != nil {
+		if err := manifest.Storage.Check(); err != nil {
+			errors = append(errors, trace.Wrap(err))
+		}
+	}
+
+	if manifest.UpgradePath != nil {
+		if err := manifest.UpgradePath.Check(); err != nil {
+			errors = append(errors, trace.Wrap(err))
+		}
+	}
+
 	// the rest of the checks apply only to user apps
 	// TODO Do specific checks for Cluster VS Application
 	switch manifest.Kind {
@@ -110,6 +159,11 @@ func CheckAndSetDefaults(manifest *Manifest) error {
 		if err != nil {
 			errors = append(errors, trace.Wrap(err))
 		}
+
+		err = manifest.Installer.Parameters.Check(manifest.Installer.ParameterGroups)
+		if err != nil {
+			errors = append(errors, trace.Wrap(err))
+		}
 	}
 
 	for _, profile := range manifest.NodeProfiles {
@@ -154,6 +208,14 @@ func CheckAndSetDefaults(manifest *Manifest) error {
 		}
 	}
 
+	if manifest.Hooks != nil {
+		for _, hook := range manifest.Hooks.AllHooks() {
+			if err := hook.Values.Check(); err != nil {
+				errors = append(errors, trace.Wrap(err, "invalid values for hook %v", hook.Type))
+			}
+		}
+	}
+
 	for i, nodeProfile := range manifest.NodeProfiles {
 		for j := range nodeProfile.Requirements.Volumes {
 			if err := manifest.NodeProfiles[i].Requirements.Volumes[j].CheckAndSetDefaults(); err != nil {
@@ -0,0 +1,143 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schema
+
+import (
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/trace"
+)
+
+const (
+	// InstallerProfileEdge produces a slimmed down cluster image for low-resource
+	// edge devices: optional system applications are left out of the installer,
+	// the cluster defaults to a single node and system services are tuned
+	// for a small memory footprint
+	InstallerProfileEdge = "edge"
+)
+
+// InstallerProfiles lists all supported installer profiles
+var InstallerProfiles = []string{InstallerProfileEdge}
+
+// ApplyInstallerProfile updates the manifest according to the specified installer profile.
+// Settings explicitly specified in the manifest are preserved
+func ApplyInstallerProfile(manifest *Manifest, profile string) error {
+	switch profile {
+	case "":
+		return nil
+	case InstallerProfileEdge:
+		if manifest.Base() == nil {
+			return trace.BadParameter("installer profile %q only applies to cluster images", profile)
+		}
+		applyEdgeProfile(manifest)
+		return nil
+	}
+	return trace.BadParameter("unsupported installer profile %q, supported profiles are %v",
+		profile, InstallerProfiles)
+}
+
+// InstallerProfile returns the installer profile the application was built with
+func (m Manifest) InstallerProfile() string {
+	if m.Installer == nil {
+		return ""
+	}
+	return m.Installer.Profile
+}
+
+// ShouldOmitApp returns true if the specified application should not be
+// included in the installer of the application described by the provided manifest.
+//
+// Only installers built with the edge profile omit the system applications
+// disabled in the manifest, regular installers carry them so they can be
+// enabled later
+func ShouldOmitApp(manifest Manifest, app loc.Locator) bool {
+	return manifest.InstallerProfile() == InstallerProfileEdge &&
+		ShouldSkipApp(manifest, app)
+}
+
+func applyEdgeProfile(manifest *Manifest) {
+	if manifest.Installer == nil {
+		manifest.Installer = &Installer{}
+	}
+	manifest.Installer.Profile = InstallerProfileEdge
+
+	if manifest.Extensions == nil {
+		manifest.Extensions = &Extensions{}
+	}
+	if manifest.Extensions.Logs == nil {
+		manifest.Extensions.Logs = &LogsExtension{Disabled: true}
+	}
+	if manifest.Extensions.Monitoring == nil {
+		manifest.Extensions.Monitoring = &MonitoringExtension{Disabled: true}
+	}
+	if manifest.Extensions.Catalog == nil {
+		manifest.Extensions.Catalog = &CatalogExtension{Disabled: true}
+	}
+
+	// default to a single node with requirements that fit the edge devices
+	if len(manifest.NodeProfiles) == 0 && len(manifest.FlavorNames()) == 0 {
+		manifest.NodeProfiles = NodeProfiles{edgeNodeProfile}
+		manifest.Installer.Flavors.Items = []Flavor{defaultFlavor}
+	}
+
+	if manifest.SystemOptions == nil {
+		manifest.SystemOptions = &SystemOptions{
+			Runtime: &Runtime{Locator: *manifest.Base()},
+		}
+	}
+	if manifest.SystemOptions.Kubelet == nil {
+		manifest.SystemOptions.Kubelet = &Kubelet{}
+	}
+	if len(manifest.SystemOptions.Kubelet.Args) == 0 {
+		manifest.SystemOptions.Kubelet.Args = edgeKubeletArgs
+	}
+	if manifest.SystemOptions.Etcd == nil {
+		manifest.SystemOptions.Etcd = &Etcd{}
+	}
+	if len(manifest.SystemOptions.Etcd.Args) == 0 {
+		manifest.SystemOptions.Etcd.Args = edgeEtcdArgs
+	}
+}
+
+var (
+	// edgeNodeProfile is the default node profile of the edge installer
+	edgeNodeProfile = NodeProfile{
+		Name:        defaultNodeProfile.Name,
+		Description: "Edge node",
+		Requirements: Requirements{
+			CPU: CPU{Min: 2},
+			RAM: RAM{Min: utils.MustParseCapacity("3GB")},
+		},
+	}
+
+	// edgeKubeletArgs reduces the resources reserved by kubelet
+	// and evicts pods earlier under memory pressure
+	edgeKubeletArgs = []string{
+		"--kube-reserved=cpu=100m,memory=256Mi",
+		"--system-reserved=cpu=100m,memory=256Mi",
+		"--eviction-hard=memory.available<200Mi,nodefs.available<10%",
+		"--image-gc-high-threshold=70",
+		"--image-gc-low-threshold=50",
+	}
+
+	// edgeEtcdArgs limits the memory used by etcd
+	edgeEtcdArgs = []string{
+		"--snapshot-count=5000",
+		"--quota-backend-bytes=536870912",
+	}
+)
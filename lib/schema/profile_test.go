@@ -0,0 +1,98 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schema
+
+import (
+	"github.com/gravitational/gravity/lib/loc"
+
+	"github.com/ghodss/yaml"
+	. "gopkg.in/check.v1"
+)
+
+type ProfileSuite struct{}
+
+var _ = Suite(&ProfileSuite{})
+
+func (s *ProfileSuite) TestEdgeProfile(c *C) {
+	manifest := MustParseManifestYAML([]byte(`apiVersion: cluster.gravitational.io/v2
+kind: Cluster
+metadata:
+  name: test
+  resourceVersion: 1.0.0
+extensions:
+  monitoring:
+    disabled: false
+`))
+	c.Assert(ApplyInstallerProfile(&manifest, InstallerProfileEdge), IsNil)
+	c.Assert(manifest.InstallerProfile(), Equals, InstallerProfileEdge)
+	c.Assert(manifest.NodeProfiles, DeepEquals, NodeProfiles{edgeNodeProfile})
+	c.Assert(manifest.FlavorNames(), DeepEquals, []string{defaultFlavor.Name})
+	c.Assert(manifest.SystemOptions.Kubelet.Args, DeepEquals, edgeKubeletArgs)
+	c.Assert(manifest.SystemOptions.Etcd.Args, DeepEquals, edgeEtcdArgs)
+
+	c.Assert(ShouldOmitApp(manifest, loc.MustParseLocator("gravitational.io/logging-app:0.0.1")), Equals, true)
+	c.Assert(ShouldOmitApp(manifest, loc.MustParseLocator("gravitational.io/tiller-app:0.0.1")), Equals, true)
+	c.Assert(ShouldOmitApp(manifest, loc.MustParseLocator("gravitational.io/monitoring-app:0.0.1")), Equals, false)
+	c.Assert(ShouldOmitApp(manifest, loc.MustParseLocator("gravitational.io/dns-app:0.0.1")), Equals, false)
+
+	// the resulting manifest is valid
+	data, err := yaml.Marshal(manifest)
+	c.Assert(err, IsNil)
+	_, err = ParseManifestYAML(data)
+	c.Assert(err, IsNil)
+}
+
+func (s *ProfileSuite) TestEdgeProfilePreservesSettings(c *C) {
+	manifest := MustParseManifestYAML([]byte(`apiVersion: cluster.gravitational.io/v2
+kind: Cluster
+metadata:
+  name: test
+  resourceVersion: 1.0.0
+nodeProfiles:
+  - name: worker
+installer:
+  flavors:
+    items:
+      - name: three
+        nodes:
+          - profile: worker
+            count: 3
+systemOptions:
+  kubelet:
+    args: ["--max-pods=50"]
+`))
+	c.Assert(ApplyInstallerProfile(&manifest, InstallerProfileEdge), IsNil)
+	c.Assert(manifest.FlavorNames(), DeepEquals, []string{"three"})
+	c.Assert(manifest.NodeProfiles[0].Name, Equals, "worker")
+	c.Assert(manifest.SystemOptions.Kubelet.Args, DeepEquals, []string{"--max-pods=50"})
+	c.Assert(manifest.SystemOptions.Etcd.Args, DeepEquals, edgeEtcdArgs)
+}
+
+func (s *ProfileSuite) TestOnlyEdgeProfileOmitsApps(c *C) {
+	manifest := MustParseManifestYAML([]byte(`apiVersion: cluster.gravitational.io/v2
+kind: Cluster
+metadata:
+  name: test
+  resourceVersion: 1.0.0
+extensions:
+  logs:
+    disabled: true
+`))
+	c.Assert(ApplyInstallerProfile(&manifest, ""), IsNil)
+	c.Assert(ShouldOmitApp(manifest, loc.MustParseLocator("gravitational.io/logging-app:0.0.1")), Equals, false)
+	c.Assert(ApplyInstallerProfile(&manifest, "unknown"), NotNil)
+}
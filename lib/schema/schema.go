@@ -119,6 +119,7 @@ const manifestSchema = `
               "type": "array",
               "items": {"type": "string"}
             },
+            "profile": {"type": "string", "enum": ["edge"]},
             "flavors": {
               "type": "object",
               "required": ["items"],
@@ -152,6 +153,51 @@ const manifestSchema = `
                   }
                 }
               }
+            },
+            "parameters": {
+              "type": "array",
+              "items": {
+                "type": "object",
+                "required": ["name"],
+                "additionalProperties": false,
+                "properties": {
+                  "name": {"type": "string"},
+                  "title": {"type": "string"},
+                  "description": {"type": "string"},
+                  "group": {"type": "string"},
+                  "type": {"type": "string"},
+                  "default": {"type": "string"},
+                  "required": {"type": "boolean"},
+                  "secret": {"type": "boolean"},
+                  "validation": {
+                    "type": "object",
+                    "additionalProperties": false,
+                    "properties": {
+                      "pattern": {"type": "string"},
+                      "values": {
+                        "type": "array",
+                        "items": {"type": "string"}
+                      },
+                      "min": {"type": "integer"},
+                      "max": {"type": "integer"},
+                      "message": {"type": "string"}
+                    }
+                  }
+                }
+              }
+            },
+            "parameterGroups": {
+              "type": "array",
+              "items": {
+                "type": "object",
+                "required": ["name"],
+                "additionalProperties": false,
+                "properties": {
+                  "name": {"type": "string"},
+                  "title": {"type": "string"},
+                  "description": {"type": "string"}
+                }
+              }
             }
           }
         },
@@ -272,6 +318,29 @@ const manifestSchema = `
                         "script": {"type": "string"}
                       }
                     }
+                  },
+                  "osPackages": {
+                    "type": "array",
+                    "items": {
+                      "type": "object",
+                      "required": ["name"],
+                      "additionalProperties": false,
+                      "properties": {
+                        "name": {"type": "string"},
+                        "bundled": {
+                          "type": "array",
+                          "items": {
+                            "type": "object",
+                            "required": ["os", "files"],
+                            "additionalProperties": false,
+                            "properties": {
+                              "os": {"type": "array", "items": {"type": "string"}},
+                              "files": {"type": "array", "items": {"type": "string"}}
+                            }
+                          }
+                        }
+                      }
+                    }
                   }
                 }
               },
@@ -336,7 +405,8 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "clusterProvision"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "values": {"$ref": "#/definitions/hookValues"}
               }
             },
             "clusterDeprovision": {
@@ -344,7 +414,8 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "clusterDeprovision"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "values": {"$ref": "#/definitions/hookValues"}
               }
             },
             "nodesProvision": {
@@ -352,7 +423,8 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "nodesProvision"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "values": {"$ref": "#/definitions/hookValues"}
               }
             },
             "nodesDeprovision": {
@@ -360,7 +432,8 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "nodesDeprovision"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "values": {"$ref": "#/definitions/hookValues"}
               }
             },
             "install": {
@@ -368,7 +441,8 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "install"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "values": {"$ref": "#/definitions/hookValues"}
               }
             },
             "postInstall": {
@@ -376,7 +450,8 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "postInstall"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "values": {"$ref": "#/definitions/hookValues"}
               }
             },
             "uninstall": {
@@ -384,7 +459,8 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "uninstall"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "values": {"$ref": "#/definitions/hookValues"}
               }
             },
             "preUninstall": {
@@ -392,7 +468,8 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "preUninstall"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "values": {"$ref": "#/definitions/hookValues"}
               }
             },
             "preNodeAdd": {
@@ -400,7 +477,8 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "preNodeAdd"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "values": {"$ref": "#/definitions/hookValues"}
               }
             },
             "postNodeAdd": {
@@ -408,7 +486,8 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "postNodeAdd"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "values": {"$ref": "#/definitions/hookValues"}
               }
             },
             "preNodeRemove": {
@@ -416,7 +495,8 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "preNodeRemove"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "values": {"$ref": "#/definitions/hookValues"}
               }
             },
             "postNodeRemove": {
@@ -424,7 +504,8 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "postNodeRemove"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "values": {"$ref": "#/definitions/hookValues"}
               }
             },
             "preUpdate": {
@@ -432,7 +513,8 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "preUpdate"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "values": {"$ref": "#/definitions/hookValues"}
               }
             },
             "update": {
@@ -440,7 +522,8 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "update"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "values": {"$ref": "#/definitions/hookValues"}
               }
             },
             "postUpdate": {
@@ -448,7 +531,8 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "postUpdate"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "values": {"$ref": "#/definitions/hookValues"}
               }
             },
             "rollback": {
@@ -456,7 +540,8 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "rollback"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "values": {"$ref": "#/definitions/hookValues"}
               }
             },
             "postRollback": {
@@ -464,7 +549,17 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "postRollback"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "values": {"$ref": "#/definitions/hookValues"}
+              }
+            },
+            "smokeTest": {
+              "type": "object",
+              "additionalProperties": false,
+              "properties": {
+                "type": {"type": "string", "default": "smokeTest"},
+                "job": {"type": "string"},
+                "values": {"$ref": "#/definitions/hookValues"}
               }
             },
             "status": {
@@ -472,7 +567,8 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "status"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "values": {"$ref": "#/definitions/hookValues"}
               }
             },
             "info": {
@@ -480,7 +576,8 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "info"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "values": {"$ref": "#/definitions/hookValues"}
               }
             },
             "licenseUpdated": {
@@ -488,7 +585,8 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "licenseUpdated"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "values": {"$ref": "#/definitions/hookValues"}
               }
             },
             "start": {
@@ -496,7 +594,8 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "start"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "values": {"$ref": "#/definitions/hookValues"}
               }
             },
             "stop": {
@@ -504,7 +603,8 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "stop"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "values": {"$ref": "#/definitions/hookValues"}
               }
             },
             "dump": {
@@ -512,7 +612,8 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "dump"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "values": {"$ref": "#/definitions/hookValues"}
               }
             },
             "backup": {
@@ -520,7 +621,8 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "backup"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "values": {"$ref": "#/definitions/hookValues"}
               }
             },
             "restore": {
@@ -528,7 +630,8 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "restore"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "values": {"$ref": "#/definitions/hookValues"}
               }
             },
             "networkInstall": {
@@ -536,7 +639,8 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "networkInstall"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "values": {"$ref": "#/definitions/hookValues"}
               }
             },
             "networkUpdate": {
@@ -544,7 +648,8 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "networkUpdate"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "values": {"$ref": "#/definitions/hookValues"}
               }
             },
             "networkRollback": {
@@ -552,7 +657,8 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "networkRollback"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "values": {"$ref": "#/definitions/hookValues"}
               }
             }
           }
@@ -575,7 +681,129 @@ const manifestSchema = `
             "monitoring": {"$ref": "#/definitions/onOff"},
             "catalog": {"$ref": "#/definitions/onOff"},
             "kubernetes": {"$ref": "#/definitions/onOff"},
-            "configuration": {"$ref": "#/definitions/onOff"}
+            "configuration": {"$ref": "#/definitions/onOff"},
+            "ingress": {
+              "type": "object",
+              "additionalProperties": false,
+              "properties": {
+                "enabled": {"type": "boolean"}
+              }
+            }
+          }
+        },
+        "compatibility": {
+          "type": "object",
+          "additionalProperties": false,
+          "properties": {
+            "kernel": {
+              "type": "object",
+              "additionalProperties": false,
+              "properties": {
+                "min": {"type": "string"},
+                "max": {"type": "string"}
+              }
+            },
+            "kernelModules": {
+              "type": "array",
+              "items": {
+                "type": "object",
+                "required": ["name"],
+                "additionalProperties": false,
+                "properties": {
+                  "name": {"type": "string"},
+                  "names": {"type": "array", "items": {"type": "string"}},
+                  "hairpinModes": {"type": "array", "items": {"type": "string"}},
+                  "storageDrivers": {"type": "array", "items": {"type": "string"}}
+                }
+              }
+            },
+            "unsupportedOS": {
+              "type": "array",
+              "items": {
+                "type": "object",
+                "required": ["name"],
+                "additionalProperties": false,
+                "properties": {
+                  "name": {"type": "string"},
+                  "versions": {"type": "array", "items": {"type": "string"}},
+                  "reason": {"type": "string"}
+                }
+              }
+            }
+          }
+        },
+        "snapshots": {
+          "type": "array",
+          "items": {
+            "type": "object",
+            "required": ["name", "interval"],
+            "additionalProperties": false,
+            "properties": {
+              "name": {"type": "string"},
+              "namespace": {"type": "string"},
+              "selector": {"type": "object", "patternProperties": {"^.*$": {"type": "string"}}},
+              "snapshotClass": {"type": "string"},
+              "interval": {"type": "string"},
+              "retain": {"type": "integer", "minimum": 0}
+            }
+          }
+        },
+        "namespaces": {
+          "type": "array",
+          "items": {
+            "type": "object",
+            "required": ["name"],
+            "additionalProperties": false,
+            "properties": {
+              "name": {"type": "string"},
+              "labels": {"type": "object", "patternProperties": {"^.*$": {"type": "string"}}},
+              "resourceQuota": {"type": "object", "patternProperties": {"^.*$": {"type": "string"}}},
+              "limitRange": {
+                "type": "object",
+                "additionalProperties": false,
+                "properties": {
+                  "default": {"type": "object", "patternProperties": {"^.*$": {"type": "string"}}},
+                  "defaultRequest": {"type": "object", "patternProperties": {"^.*$": {"type": "string"}}},
+                  "max": {"type": "object", "patternProperties": {"^.*$": {"type": "string"}}},
+                  "min": {"type": "object", "patternProperties": {"^.*$": {"type": "string"}}}
+                }
+              }
+            }
+          }
+        },
+        "upgradePath": {
+          "type": "object",
+          "additionalProperties": false,
+          "properties": {
+            "minVersion": {"type": "string"},
+            "via": {"type": "array", "items": {"type": "string"}}
+          }
+        },
+        "storage": {
+          "type": "object",
+          "required": ["provider"],
+          "additionalProperties": false,
+          "properties": {
+            "provider": {"type": "string"},
+            "storageClass": {
+              "type": "object",
+              "additionalProperties": false,
+              "properties": {
+                "name": {"type": "string"},
+                "provisioner": {"type": "string"},
+                "parameters": {"type": "object", "patternProperties": {"^.*$": {"type": "string"}}},
+                "reclaimPolicy": {"type": "string"}
+              }
+            },
+            "rawDisks": {
+              "type": "object",
+              "additionalProperties": false,
+              "properties": {
+                "count": {"type": "integer", "minimum": 0},
+                "minSize": {"type": "string"},
+                "profiles": {"type": "array", "items": {"type": "string"}}
+              }
+            }
           }
         },
         "webConfig": {"type": "string"}
@@ -686,6 +914,62 @@ const manifestSchema = `
           "properties": {
             "runtimePackage": {"type": "string"}
           }
+        },
+        "upgrade": {
+          "type": "object",
+          "additionalProperties": false,
+          "properties": {
+            "taint": {
+              "type": "object",
+              "additionalProperties": false,
+              "required": ["key", "effect"],
+              "properties": {
+                "key": {"type": "string"},
+                "value": {"type": "string"},
+                "effect": {"enum": ["NoSchedule", "PreferNoSchedule", "NoExecute"]}
+              }
+            },
+            "autoRollback": {"type": "boolean"},
+            "gates": {
+              "type": "object",
+              "additionalProperties": false,
+              "required": ["checks"],
+              "properties": {
+                "window": {"type": "string"},
+                "interval": {"type": "string"},
+                "checks": {
+                  "type": "array",
+                  "items": {
+                    "type": "object",
+                    "additionalProperties": false,
+                    "required": ["name"],
+                    "properties": {
+                      "name": {"type": "string"},
+                      "query": {"type": "string"},
+                      "podRestarts": {
+                        "type": "object",
+                        "additionalProperties": false,
+                        "properties": {
+                          "namespace": {"type": "string"},
+                          "selector": {"type": "object", "patternProperties": {"^.*$": {"type": "string"}}}
+                        }
+                      },
+                      "max": {"type": "number"},
+                      "min": {"type": "number"}
+                    }
+                  }
+                }
+              }
+            }
+          }
+        },
+        "firewall": {
+          "type": "object",
+          "additionalProperties": false,
+          "properties": {
+            "managed": {"type": "boolean"},
+            "backend": {"enum": ["firewalld", "nftables", "iptables"]}
+          }
         }
       }
     },
@@ -705,6 +989,14 @@ const manifestSchema = `
       "properties": {
         "disabled": {"type": "boolean"}
       }
+    },
+    "hookValues": {
+      "type": "object",
+      "additionalProperties": false,
+      "properties": {
+        "required": {"type": "array", "items": {"type": "string"}},
+        "configMaps": {"type": "array", "items": {"type": "string"}}
+      }
     }
   }
 }
@@ -0,0 +1,228 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schema
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/trace"
+)
+
+// Storage declares the persistent storage provider the application requires.
+//
+// The provider itself is deployed by the application, gravity verifies
+// the node prerequisites of the provider during preflight checks and
+// creates the default storage class during installation
+type Storage struct {
+	// Provider is the name of the storage provider, e.g. openebs, ceph or portworx
+	Provider string `json:"provider"`
+	// StorageClass customizes the default storage class
+	StorageClass *StorageClass `json:"storageClass,omitempty"`
+	// RawDisks customizes the raw disk requirements of the provider
+	RawDisks *RawDisks `json:"rawDisks,omitempty"`
+}
+
+// StorageClass describes the default storage class
+type StorageClass struct {
+	// Name is the name of the storage class
+	Name string `json:"name,omitempty"`
+	// Provisioner is the volume provisioner of the storage class
+	Provisioner string `json:"provisioner,omitempty"`
+	// Parameters are the provisioner parameters
+	Parameters map[string]string `json:"parameters,omitempty"`
+	// ReclaimPolicy is the reclaim policy of the provisioned volumes,
+	// Delete or Retain
+	ReclaimPolicy string `json:"reclaimPolicy,omitempty"`
+}
+
+// RawDisks describes the unformatted block devices a storage provider
+// requires on cluster nodes
+type RawDisks struct {
+	// Count is the minimum number of raw disks on each node
+	Count int `json:"count,omitempty"`
+	// MinSize is the minimum size of each raw disk
+	MinSize utils.Capacity `json:"minSize,omitempty"`
+	// Profiles optionally limits the requirement to nodes with one
+	// of the specified profiles
+	Profiles []string `json:"profiles,omitempty"`
+}
+
+// IsRequired returns true if the raw disks are required on the nodes
+// with the specified profile
+func (r RawDisks) IsRequired(profile string) bool {
+	if r.Count == 0 {
+		return false
+	}
+	return len(r.Profiles) == 0 || utils.StringInSlice(r.Profiles, profile)
+}
+
+// Check makes sure the storage declaration is valid
+func (r Storage) Check() error {
+	if _, err := GetStorageProvider(r.Provider); err != nil {
+		return trace.Wrap(err)
+	}
+	if r.StorageClass != nil {
+		switch r.StorageClass.ReclaimPolicy {
+		case "", ReclaimPolicyDelete, ReclaimPolicyRetain:
+		default:
+			return trace.BadParameter("storage class reclaim policy should be either %v or %v, got %q",
+				ReclaimPolicyDelete, ReclaimPolicyRetain, r.StorageClass.ReclaimPolicy)
+		}
+	}
+	if r.RawDisks != nil && r.RawDisks.Count < 0 {
+		return trace.BadParameter("raw disk count cannot be negative")
+	}
+	return nil
+}
+
+// GetStorageClass returns the default storage class with the provider
+// defaults overridden by the manifest
+func (r Storage) GetStorageClass() (*StorageClass, error) {
+	provider, err := GetStorageProvider(r.Provider)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	class := provider.StorageClass
+	class.Parameters = make(map[string]string)
+	for name, value := range provider.StorageClass.Parameters {
+		class.Parameters[name] = value
+	}
+	if override := r.StorageClass; override != nil {
+		if override.Name != "" {
+			class.Name = override.Name
+		}
+		if override.Provisioner != "" {
+			class.Provisioner = override.Provisioner
+		}
+		if override.ReclaimPolicy != "" {
+			class.ReclaimPolicy = override.ReclaimPolicy
+		}
+		for name, value := range override.Parameters {
+			class.Parameters[name] = value
+		}
+	}
+	if class.ReclaimPolicy == "" {
+		class.ReclaimPolicy = ReclaimPolicyDelete
+	}
+	return &class, nil
+}
+
+// GetRawDisks returns the raw disk requirements of the provider.
+// Returns nil if the provider does not need raw disks
+func (r Storage) GetRawDisks() (*RawDisks, error) {
+	provider, err := GetStorageProvider(r.Provider)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if r.RawDisks != nil {
+		return r.RawDisks, nil
+	}
+	if provider.RequiresRawDisks {
+		return &RawDisks{Count: 1}, nil
+	}
+	return nil, nil
+}
+
+// StorageProvider describes the integration of a storage provider
+type StorageProvider struct {
+	// Name is the name of the provider used in the manifest
+	Name string
+	// StorageClass is the default storage class of the provider
+	StorageClass StorageClass
+	// KernelModules lists kernel modules the provider requires on cluster nodes
+	KernelModules []KernelModule
+	// RequiresRawDisks is whether the provider needs at least one
+	// unformatted block device on each node
+	RequiresRawDisks bool
+}
+
+// RegisterStorageProvider makes the storage provider available to manifests.
+// A provider registered under an existing name replaces it
+func RegisterStorageProvider(provider StorageProvider) {
+	storageProvidersMu.Lock()
+	defer storageProvidersMu.Unlock()
+	storageProviders[provider.Name] = provider
+}
+
+// GetStorageProvider returns the storage provider with the specified name
+func GetStorageProvider(name string) (*StorageProvider, error) {
+	storageProvidersMu.RLock()
+	defer storageProvidersMu.RUnlock()
+	provider, ok := storageProviders[name]
+	if !ok {
+		var names []string
+		for name := range storageProviders {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, trace.NotFound("unknown storage provider %q, supported are: %v", name, names)
+	}
+	return &provider, nil
+}
+
+const (
+	// StorageProviderOpenEBS is the OpenEBS cStor storage provider
+	StorageProviderOpenEBS = "openebs"
+	// StorageProviderCeph is the Ceph storage provider deployed with Rook
+	StorageProviderCeph = "ceph"
+	// StorageProviderPortworx is the Portworx storage provider
+	StorageProviderPortworx = "portworx"
+
+	// ReclaimPolicyDelete deletes the volume when the claim is released
+	ReclaimPolicyDelete = "Delete"
+	// ReclaimPolicyRetain keeps the volume when the claim is released
+	ReclaimPolicyRetain = "Retain"
+)
+
+var (
+	storageProvidersMu sync.RWMutex
+	storageProviders   = map[string]StorageProvider{
+		StorageProviderOpenEBS: {
+			Name: StorageProviderOpenEBS,
+			StorageClass: StorageClass{
+				Name:        "openebs-cstor",
+				Provisioner: "openebs.io/provisioner-iscsi",
+			},
+			KernelModules:    []KernelModule{{Name: "iscsi_tcp"}},
+			RequiresRawDisks: true,
+		},
+		StorageProviderCeph: {
+			Name: StorageProviderCeph,
+			StorageClass: StorageClass{
+				Name:        "rook-ceph-block",
+				Provisioner: "ceph.rook.io/block",
+				Parameters: map[string]string{
+					"blockPool":        "replicapool",
+					"clusterNamespace": "rook-ceph",
+				},
+			},
+			KernelModules:    []KernelModule{{Name: "rbd"}},
+			RequiresRawDisks: true,
+		},
+		StorageProviderPortworx: {
+			Name: StorageProviderPortworx,
+			StorageClass: StorageClass{
+				Name:        "portworx",
+				Provisioner: "kubernetes.io/portworx-volume",
+			},
+			RequiresRawDisks: true,
+		},
+	}
+)
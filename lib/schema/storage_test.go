@@ -0,0 +1,133 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schema
+
+import (
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/trace"
+	. "gopkg.in/check.v1"
+)
+
+type StorageSuite struct{}
+
+var _ = Suite(&StorageSuite{})
+
+func (s *StorageSuite) TestParsesStorage(c *C) {
+	bytes := []byte(`apiVersion: bundle.gravitational.io/v2
+kind: Bundle
+metadata:
+  name: app
+  resourceVersion: 0.0.1
+storage:
+  provider: ceph
+  storageClass:
+    parameters:
+      blockPool: fast
+    reclaimPolicy: Retain
+  rawDisks:
+    count: 2
+    minSize: 10GB
+    profiles: [storage]`)
+	manifest, err := ParseManifestYAML(bytes)
+	c.Assert(err, IsNil)
+	c.Assert(manifest.Storage, NotNil)
+
+	class, err := manifest.Storage.GetStorageClass()
+	c.Assert(err, IsNil)
+	c.Assert(*class, DeepEquals, StorageClass{
+		Name:        "rook-ceph-block",
+		Provisioner: "ceph.rook.io/block",
+		Parameters: map[string]string{
+			"blockPool":        "fast",
+			"clusterNamespace": "rook-ceph",
+		},
+		ReclaimPolicy: ReclaimPolicyRetain,
+	})
+	provider, err := GetStorageProvider(StorageProviderCeph)
+	c.Assert(err, IsNil)
+	c.Assert(provider.StorageClass.Parameters["blockPool"], Equals, "replicapool",
+		Commentf("provider defaults should not be modified"))
+
+	disks, err := manifest.Storage.GetRawDisks()
+	c.Assert(err, IsNil)
+	c.Assert(disks.Count, Equals, 2)
+	c.Assert(disks.MinSize, Equals, utils.Capacity(10*1000*1000*1000))
+	c.Assert(disks.IsRequired("storage"), Equals, true)
+	c.Assert(disks.IsRequired("worker"), Equals, false)
+
+	matrix := manifest.CompatibilityMatrix()
+	c.Assert(matrix.KernelModules[len(matrix.KernelModules)-1].Name, Equals, "rbd")
+	c.Assert(DefaultCompatibility.KernelModules, HasLen, len(matrix.KernelModules)-1)
+}
+
+func (s *StorageSuite) TestDefaults(c *C) {
+	storage := Storage{Provider: StorageProviderPortworx}
+	class, err := storage.GetStorageClass()
+	c.Assert(err, IsNil)
+	c.Assert(class.Name, Equals, "portworx")
+	c.Assert(class.ReclaimPolicy, Equals, ReclaimPolicyDelete)
+	disks, err := storage.GetRawDisks()
+	c.Assert(err, IsNil)
+	c.Assert(*disks, DeepEquals, RawDisks{Count: 1})
+
+	storage = Storage{Provider: StorageProviderOpenEBS, RawDisks: &RawDisks{}}
+	disks, err = storage.GetRawDisks()
+	c.Assert(err, IsNil)
+	c.Assert(disks.IsRequired("node"), Equals, false)
+}
+
+func (s *StorageSuite) TestRejectsInvalidStorage(c *C) {
+	var testCases = []struct {
+		storage Storage
+		comment string
+	}{
+		{
+			storage: Storage{Provider: "glusterfs"},
+			comment: "unknown provider",
+		},
+		{
+			storage: Storage{Provider: StorageProviderCeph, StorageClass: &StorageClass{ReclaimPolicy: "Recycle"}},
+			comment: "invalid reclaim policy",
+		},
+		{
+			storage: Storage{Provider: StorageProviderCeph, RawDisks: &RawDisks{Count: -1}},
+			comment: "negative disk count",
+		},
+	}
+	for _, tc := range testCases {
+		c.Assert(tc.storage.Check(), NotNil, Commentf(tc.comment))
+	}
+}
+
+func (s *StorageSuite) TestRegistersProviders(c *C) {
+	_, err := GetStorageProvider("test")
+	c.Assert(trace.IsNotFound(err), Equals, true)
+
+	RegisterStorageProvider(StorageProvider{
+		Name:         "test",
+		StorageClass: StorageClass{Name: "test", Provisioner: "example.com/test"},
+	})
+	storage := Storage{Provider: "test"}
+	c.Assert(storage.Check(), IsNil)
+	class, err := storage.GetStorageClass()
+	c.Assert(err, IsNil)
+	c.Assert(class.Provisioner, Equals, "example.com/test")
+	disks, err := storage.GetRawDisks()
+	c.Assert(err, IsNil)
+	c.Assert(disks, IsNil)
+}
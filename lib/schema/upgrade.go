@@ -0,0 +1,106 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schema
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/coreos/go-semver/semver"
+	"github.com/gravitational/trace"
+)
+
+// UpgradePath declares the runtime versions a runtime can be upgraded from.
+//
+// It is consulted when a cluster is upgraded to an application based
+// on the runtime and fails the upgrade early with the suggested upgrade path
+// if the installed runtime cannot be upgraded directly
+type UpgradePath struct {
+	// MinVersion is the minimum runtime version that can be upgraded
+	// to this runtime directly
+	MinVersion string `json:"minVersion,omitempty"`
+	// Via lists the intermediate runtime versions an upgrade from an older
+	// runtime version has to go through, e.g. because they perform
+	// migrations later versions no longer implement
+	Via []string `json:"via,omitempty"`
+}
+
+// Check makes sure all versions of the upgrade path are valid
+func (r UpgradePath) Check() error {
+	var errors []error
+	for _, version := range append([]string{r.MinVersion}, r.Via...) {
+		if version == "" {
+			continue
+		}
+		if _, err := semver.NewVersion(version); err != nil {
+			errors = append(errors, trace.BadParameter(
+				"invalid upgrade path version %q: %v", version, err))
+		}
+	}
+	return trace.NewAggregate(errors...)
+}
+
+// CheckUpgrade returns an error with the suggested upgrade path if the runtime
+// with version to cannot be upgraded directly from the runtime version from
+func (r UpgradePath) CheckUpgrade(from, to semver.Version) error {
+	path, err := r.Path(from, to)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if len(path) == 1 {
+		return nil
+	}
+	steps := []string{from.String()}
+	for _, version := range path {
+		steps = append(steps, version.String())
+	}
+	return trace.BadParameter("installed runtime version %v cannot be upgraded to %v directly, "+
+		"upgrade the cluster using the following upgrade path: %v",
+		from, to, strings.Join(steps, " -> "))
+}
+
+// Path returns the runtime versions to upgrade through, in order,
+// when upgrading from the runtime version from.
+// The last version of the path is always to
+func (r UpgradePath) Path(from, to semver.Version) (path []semver.Version, err error) {
+	for _, version := range r.Via {
+		via, err := semver.NewVersion(version)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		if from.LessThan(*via) && via.LessThan(to) {
+			path = append(path, *via)
+		}
+	}
+	sort.Slice(path, func(i, j int) bool {
+		return path[i].LessThan(path[j])
+	})
+	if r.MinVersion != "" {
+		min, err := semver.NewVersion(r.MinVersion)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		last := from
+		if len(path) != 0 {
+			last = path[len(path)-1]
+		}
+		if last.LessThan(*min) && min.LessThan(to) {
+			path = append(path, *min)
+		}
+	}
+	return append(path, to), nil
+}
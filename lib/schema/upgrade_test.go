@@ -0,0 +1,109 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schema
+
+import (
+	"github.com/coreos/go-semver/semver"
+	"github.com/gravitational/trace"
+	. "gopkg.in/check.v1"
+)
+
+type UpgradePathSuite struct{}
+
+var _ = Suite(&UpgradePathSuite{})
+
+func (s *UpgradePathSuite) TestParsesUpgradePath(c *C) {
+	manifest, err := ParseManifestYAML([]byte(`apiVersion: bundle.gravitational.io/v2
+kind: Runtime
+metadata:
+  name: kubernetes
+  resourceVersion: 5.4.0
+upgradePath:
+  minVersion: 5.2.0
+  via: [5.0.0, 5.2.0]`))
+	c.Assert(err, IsNil)
+	c.Assert(*manifest.UpgradePath, DeepEquals, UpgradePath{
+		MinVersion: "5.2.0",
+		Via:        []string{"5.0.0", "5.2.0"},
+	})
+
+	_, err = ParseManifestYAML([]byte(`apiVersion: bundle.gravitational.io/v2
+kind: Runtime
+metadata:
+  name: kubernetes
+  resourceVersion: 5.4.0
+upgradePath:
+  minVersion: five`))
+	c.Assert(err, NotNil)
+}
+
+func (s *UpgradePathSuite) TestSuggestsUpgradePath(c *C) {
+	path := UpgradePath{
+		MinVersion: "5.2.0",
+		Via:        []string{"5.2.0", "5.0.0"},
+	}
+	to := *semver.New("5.4.0")
+	var testCases = []struct {
+		from    string
+		path    []string
+		comment string
+	}{
+		{
+			from:    "5.2.1",
+			path:    []string{"5.4.0"},
+			comment: "direct upgrade",
+		},
+		{
+			from:    "5.2.0",
+			path:    []string{"5.4.0"},
+			comment: "direct upgrade from the minimum version",
+		},
+		{
+			from:    "5.0.3",
+			path:    []string{"5.2.0", "5.4.0"},
+			comment: "upgrade via one hop",
+		},
+		{
+			from:    "4.68.0",
+			path:    []string{"5.0.0", "5.2.0", "5.4.0"},
+			comment: "upgrade via sorted hops",
+		},
+	}
+	for _, tc := range testCases {
+		comment := Commentf(tc.comment)
+		from := *semver.New(tc.from)
+		versions, err := path.Path(from, to)
+		c.Assert(err, IsNil, comment)
+		var out []string
+		for _, version := range versions {
+			out = append(out, version.String())
+		}
+		c.Assert(out, DeepEquals, tc.path, comment)
+		err = path.CheckUpgrade(from, to)
+		if len(tc.path) == 1 {
+			c.Assert(err, IsNil, comment)
+		} else {
+			c.Assert(trace.IsBadParameter(err), Equals, true, comment)
+		}
+	}
+}
+
+func (s *UpgradePathSuite) TestMinVersionWithoutHops(c *C) {
+	path := UpgradePath{MinVersion: "5.2.0"}
+	err := path.CheckUpgrade(*semver.New("5.0.0"), *semver.New("5.4.0"))
+	c.Assert(err, ErrorMatches, ".*5.0.0 -> 5.2.0 -> 5.4.0")
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package secretbackend sources sensitive cluster configuration
+// from external secret backends
+package secretbackend
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+)
+
+// Backend reads secrets from an external secret backend
+type Backend interface {
+	// Read returns the values of the secret at the specified path
+	Read(ctx context.Context, path string) (map[string]string, error)
+	// Close releases resources held by the backend
+	Close() error
+}
+
+// New returns a new backend for the specified secret backend resource
+func New(backend storage.SecretBackend) (Backend, error) {
+	switch backend.GetType() {
+	case storage.SecretBackendVault:
+		return newVault(*backend.GetVault())
+	}
+	return nil, trace.BadParameter("unsupported secret backend type %q", backend.GetType())
+}
+
+// ReadSecret returns the value of the specified secret from the backend
+func ReadSecret(ctx context.Context, backend Backend, secret storage.SecretSpec) (string, error) {
+	values, err := backend.Read(ctx, secret.Path)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	value, ok := values[secret.Key]
+	if !ok {
+		return "", trace.NotFound("secret %v has no key %q", secret.Path, secret.Key)
+	}
+	return value, nil
+}
+
+// digest returns the digest of the secret value used to detect rotation
+// without keeping the value itself in memory
+func digest(value string) string {
+	hash := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(hash[:])
+}
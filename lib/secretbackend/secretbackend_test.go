@@ -0,0 +1,261 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretbackend
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gravitational/gravity/lib/compare"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/storage"
+
+	teleservices "github.com/gravitational/teleport/lib/services"
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+	"gopkg.in/check.v1"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+func TestSecretBackend(t *testing.T) { check.TestingT(t) }
+
+type SecretBackendSuite struct{}
+
+var _ = check.Suite(&SecretBackendSuite{})
+
+func (s *SecretBackendSuite) TestReadsVaultSecrets(c *check.C) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/registry":
+			w.Write([]byte(`{"data": {"data": {"password": "secret", "port": 5000}, "metadata": {"version": 2}}}`))
+		case "/v1/kv/github":
+			w.Write([]byte(`{"data": {"client_secret": "github-secret"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	backend, err := newVault(storage.VaultSpec{Address: server.URL, Token: "token"})
+	c.Assert(err, check.IsNil)
+
+	values, err := backend.Read(context.TODO(), "secret/data/registry")
+	c.Assert(err, check.IsNil)
+	c.Assert(values, compare.DeepEquals, map[string]string{"password": "secret", "port": "5000"})
+
+	values, err = backend.Read(context.TODO(), "kv/github")
+	c.Assert(err, check.IsNil)
+	c.Assert(values, compare.DeepEquals, map[string]string{"client_secret": "github-secret"})
+
+	_, err = backend.Read(context.TODO(), "kv/missing")
+	c.Assert(trace.IsNotFound(err), check.Equals, true, check.Commentf("%v", err))
+
+	backend, err = newVault(storage.VaultSpec{Address: server.URL, Token: "invalid"})
+	c.Assert(err, check.IsNil)
+	_, err = backend.Read(context.TODO(), "kv/github")
+	c.Assert(trace.IsAccessDenied(err), check.Equals, true, check.Commentf("%v", err))
+}
+
+func (s *SecretBackendSuite) TestSyncsRotatedSecrets(c *check.C) {
+	resource := newBackend(c)
+	backend := &testBackend{secrets: map[string]map[string]string{
+		"secret/data/registry": {"password": "v1"},
+		"secret/data/github":   {"client_secret": "github-v1"},
+	}}
+	connector := teleservices.NewGithubConnector("github", teleservices.GithubConnectorSpecV3{})
+	operator := &testOperator{
+		backends:  []storage.SecretBackend{resource},
+		connector: connector,
+	}
+	secrets := &testSecrets{secrets: map[string]*v1.Secret{
+		"registry": {
+			ObjectMeta: metav1.ObjectMeta{Name: "registry", Namespace: "default"},
+			Data:       map[string][]byte{"username": []byte("admin")},
+		},
+	}}
+	clock := clockwork.NewFakeClock()
+	syncer, err := NewSyncer(SyncerConfig{
+		Operator: operator,
+		Secrets:  secrets,
+		Clock:    clock,
+		New: func(storage.SecretBackend) (Backend, error) {
+			return backend, nil
+		},
+	})
+	c.Assert(err, check.IsNil)
+
+	c.Assert(syncer.Sync(context.TODO()), check.IsNil)
+	c.Assert(secrets.secrets["registry"].Data, compare.DeepEquals, map[string][]byte{
+		"username": []byte("admin"),
+		"password": []byte("v1"),
+	})
+	c.Assert(connector.GetClientSecret(), check.Equals, "github-v1")
+	c.Assert(operator.upserts, check.Equals, 1)
+
+	// secrets are not read again until the refresh interval passes
+	backend.secrets["secret/data/registry"]["password"] = "v2"
+	c.Assert(syncer.Sync(context.TODO()), check.IsNil)
+	c.Assert(string(secrets.secrets["registry"].Data["password"]), check.Equals, "v1")
+
+	// only the rotated secret is written
+	clock.Advance(time.Hour)
+	c.Assert(syncer.Sync(context.TODO()), check.IsNil)
+	c.Assert(string(secrets.secrets["registry"].Data["password"]), check.Equals, "v2")
+	c.Assert(operator.upserts, check.Equals, 1)
+
+	operator.backends = nil
+	c.Assert(syncer.Sync(context.TODO()), check.IsNil)
+	c.Assert(backend.closed, check.Equals, true)
+}
+
+func (s *SecretBackendSuite) TestCreatesMissingSecret(c *check.C) {
+	resource := newBackend(c)
+	secrets := &testSecrets{secrets: make(map[string]*v1.Secret)}
+	syncer, err := NewSyncer(SyncerConfig{
+		Operator: &testOperator{backends: []storage.SecretBackend{resource}},
+		Secrets:  secrets,
+	})
+	c.Assert(err, check.IsNil)
+
+	secret := resource.GetSecrets()[0]
+	c.Assert(syncer.applyKubernetes(resource, *secret.Kubernetes, "v1"), check.IsNil)
+	created := secrets.secrets["registry"]
+	c.Assert(created, check.NotNil)
+	c.Assert(created.Type, check.Equals, v1.SecretTypeOpaque)
+	c.Assert(created.Data, compare.DeepEquals, map[string][]byte{"password": []byte("v1")})
+	c.Assert(created.Annotations, compare.DeepEquals, map[string]string{
+		"gravitational.io/secret-backend-source": "vault",
+	})
+}
+
+func newBackend(c *check.C) storage.SecretBackend {
+	resource := storage.NewSecretBackend("vault", storage.SecretBackendSpecV2{
+		Type: storage.SecretBackendVault,
+		Vault: &storage.VaultSpec{
+			Address: "https://vault:8200",
+			Token:   "token",
+		},
+		Secrets: []storage.SecretSpec{
+			{
+				Path: "secret/data/registry",
+				Key:  "password",
+				Kubernetes: &storage.KubernetesSecretTarget{
+					Namespace: "default",
+					Name:      "registry",
+					Key:       "password",
+				},
+			},
+			{
+				Path:   "secret/data/github",
+				Key:    "client_secret",
+				Github: &storage.GithubConnectorTarget{Connector: "github"},
+			},
+		},
+	})
+	c.Assert(resource.CheckAndSetDefaults(), check.IsNil)
+	return resource
+}
+
+type testBackend struct {
+	secrets map[string]map[string]string
+	closed  bool
+}
+
+func (r *testBackend) Read(ctx context.Context, path string) (map[string]string, error) {
+	values, ok := r.secrets[path]
+	if !ok {
+		return nil, trace.NotFound("secret %v not found", path)
+	}
+	out := make(map[string]string, len(values))
+	for key, value := range values {
+		out[key] = value
+	}
+	return out, nil
+}
+
+func (r *testBackend) Close() error {
+	r.closed = true
+	return nil
+}
+
+// testOperator implements the subset of the operator service used by the syncer
+type testOperator struct {
+	ops.Operator
+	backends  []storage.SecretBackend
+	connector teleservices.GithubConnector
+	upserts   int
+}
+
+func (r *testOperator) GetLocalSite() (*ops.Site, error) {
+	return &ops.Site{AccountID: "account", Domain: "example.com"}, nil
+}
+
+func (r *testOperator) GetSecretBackends(ops.SiteKey, bool) ([]storage.SecretBackend, error) {
+	return r.backends, nil
+}
+
+func (r *testOperator) GetGithubConnector(key ops.SiteKey, name string, withSecrets bool) (teleservices.GithubConnector, error) {
+	if r.connector == nil || r.connector.GetName() != name {
+		return nil, trace.NotFound("connector %v not found", name)
+	}
+	return r.connector, nil
+}
+
+func (r *testOperator) UpsertGithubConnector(key ops.SiteKey, connector teleservices.GithubConnector) error {
+	r.connector = connector
+	r.upserts++
+	return nil
+}
+
+// testSecrets implements the subset of the Kubernetes secrets client used by the syncer
+type testSecrets struct {
+	corev1.SecretInterface
+	secrets map[string]*v1.Secret
+}
+
+func (r *testSecrets) Secrets(namespace string) corev1.SecretInterface {
+	return r
+}
+
+func (r *testSecrets) Get(name string, options metav1.GetOptions) (*v1.Secret, error) {
+	secret, ok := r.secrets[name]
+	if !ok {
+		return nil, errors.NewNotFound(schema.GroupResource{Resource: "secrets"}, name)
+	}
+	return secret.DeepCopy(), nil
+}
+
+func (r *testSecrets) Create(secret *v1.Secret) (*v1.Secret, error) {
+	r.secrets[secret.Name] = secret
+	return secret, nil
+}
+
+func (r *testSecrets) Update(secret *v1.Secret) (*v1.Secret, error) {
+	r.secrets[secret.Name] = secret
+	return secret, nil
+}
@@ -0,0 +1,271 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretbackend
+
+import (
+	"context"
+	"time"
+
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/rigging"
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+	"github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+// SyncerConfig configures the secret backend syncer
+type SyncerConfig struct {
+	// Operator is the cluster operator service
+	Operator ops.Operator
+	// Secrets is the Kubernetes secrets client
+	Secrets corev1.SecretsGetter
+	// Interval is how often backends are checked for secrets due for refresh
+	Interval time.Duration
+	// New creates a backend for the specified resource.
+	// Defaults to New
+	New func(storage.SecretBackend) (Backend, error)
+	// Clock is used to mock time in tests
+	Clock clockwork.Clock
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+}
+
+// CheckAndSetDefaults validates the configuration and sets default values
+func (r *SyncerConfig) CheckAndSetDefaults() error {
+	if r.Operator == nil {
+		return trace.BadParameter("missing Operator")
+	}
+	if r.Secrets == nil {
+		return trace.BadParameter("missing Secrets")
+	}
+	if r.Interval == 0 {
+		r.Interval = defaults.SecretBackendMinRefreshInterval
+	}
+	if r.New == nil {
+		r.New = New
+	}
+	if r.Clock == nil {
+		r.Clock = clockwork.NewRealClock()
+	}
+	if r.FieldLogger == nil {
+		r.FieldLogger = logrus.WithField(trace.Component, "secretbackend")
+	}
+	return nil
+}
+
+// NewSyncer returns a new secret backend syncer
+func NewSyncer(config SyncerConfig) (*Syncer, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &Syncer{
+		SyncerConfig: config,
+		backends:     make(map[string]*syncedBackend),
+	}, nil
+}
+
+// Syncer keeps the secrets sourced from all configured secret backends
+// in sync with their targets in the cluster
+type Syncer struct {
+	SyncerConfig
+	backends map[string]*syncedBackend
+}
+
+// Run syncs secrets periodically until the context is canceled
+func (r *Syncer) Run(ctx context.Context) error {
+	defer r.closeAll()
+	utils.RunPeriodically(ctx, r.Interval, r.FieldLogger,
+		"sync secrets", r.Sync)
+	return nil
+}
+
+// Sync reads the secrets from the backends that are due for refresh
+// and writes the secrets that have changed since the last time to their targets
+func (r *Syncer) Sync(ctx context.Context) error {
+	cluster, err := r.Operator.GetLocalSite()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	resources, err := r.Operator.GetSecretBackends(cluster.Key(), true)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	active := make(map[string]struct{})
+	var errors []error
+	for _, resource := range resources {
+		active[resource.GetName()] = struct{}{}
+		if err := r.sync(ctx, cluster.Key(), resource); err != nil {
+			errors = append(errors, trace.Wrap(err, "failed to sync secret backend %v", resource.GetName()))
+		}
+	}
+	for name := range r.backends {
+		if _, ok := active[name]; !ok {
+			r.Infof("Secret backend %v has been removed.", name)
+			r.close(name)
+		}
+	}
+	return trace.NewAggregate(errors...)
+}
+
+func (r *Syncer) sync(ctx context.Context, key ops.SiteKey, resource storage.SecretBackend) error {
+	if err := resource.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	synced, ok := r.backends[resource.GetName()]
+	if ok && !specEqual(synced.resource, resource) {
+		r.Infof("Secret backend %v has been updated.", resource.GetName())
+		r.close(resource.GetName())
+		ok = false
+	}
+	if !ok {
+		backend, err := r.New(resource)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		synced = &syncedBackend{
+			resource: resource,
+			backend:  backend,
+			applied:  make(map[string]string),
+		}
+		r.backends[resource.GetName()] = synced
+	}
+	now := r.Clock.Now()
+	if !synced.refreshed.IsZero() && now.Sub(synced.refreshed) < resource.GetRefreshInterval() {
+		return nil
+	}
+	var errors []error
+	for _, secret := range resource.GetSecrets() {
+		value, err := ReadSecret(ctx, synced.backend, secret)
+		if err != nil {
+			errors = append(errors, trace.Wrap(err))
+			continue
+		}
+		hash := digest(value)
+		if synced.applied[secret.Target()] == hash {
+			continue
+		}
+		r.Infof("Writing secret %v from backend %v to %v.", secret.Path, resource.GetName(), secret.Target())
+		if err := r.apply(key, resource, secret, value); err != nil {
+			errors = append(errors, trace.Wrap(err, "failed to write secret %v to %v",
+				secret.Path, secret.Target()))
+			continue
+		}
+		synced.applied[secret.Target()] = hash
+	}
+	if len(errors) == 0 {
+		synced.refreshed = now
+	}
+	return trace.NewAggregate(errors...)
+}
+
+// apply writes the secret value to the secret target
+func (r *Syncer) apply(key ops.SiteKey, resource storage.SecretBackend, secret storage.SecretSpec, value string) error {
+	switch {
+	case secret.Kubernetes != nil:
+		return r.applyKubernetes(resource, *secret.Kubernetes, value)
+	case secret.Github != nil:
+		connector, err := r.Operator.GetGithubConnector(key, secret.Github.Connector, true)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if connector.GetClientSecret() == value {
+			return nil
+		}
+		connector.SetClientSecret(value)
+		return trace.Wrap(r.Operator.UpsertGithubConnector(key, connector))
+	}
+	return trace.BadParameter("secret %v is missing target", secret.Path)
+}
+
+// applyKubernetes sets the key of the target Kubernetes secret to the specified
+// value, creating the secret if necessary. Other keys of the secret are preserved
+func (r *Syncer) applyKubernetes(resource storage.SecretBackend, target storage.KubernetesSecretTarget, value string) error {
+	client := r.Secrets.Secrets(target.GetNamespace())
+	secret, err := client.Get(target.Name, metav1.GetOptions{})
+	err = rigging.ConvertError(err)
+	if err != nil && !trace.IsNotFound(err) {
+		return trace.Wrap(err)
+	}
+	if err != nil {
+		secretType := v1.SecretTypeOpaque
+		if target.Type != "" {
+			secretType = v1.SecretType(target.Type)
+		}
+		_, err = client.Create(&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      target.Name,
+				Namespace: target.GetNamespace(),
+				Annotations: map[string]string{
+					constants.SecretBackendSourceAnnotation: resource.GetName(),
+				},
+			},
+			Data: map[string][]byte{
+				target.Key: []byte(value),
+			},
+			Type: secretType,
+		})
+		return trace.Wrap(rigging.ConvertError(err))
+	}
+	if secret.Data == nil {
+		secret.Data = make(map[string][]byte)
+	}
+	secret.Data[target.Key] = []byte(value)
+	if secret.Annotations == nil {
+		secret.Annotations = make(map[string]string)
+	}
+	secret.Annotations[constants.SecretBackendSourceAnnotation] = resource.GetName()
+	_, err = client.Update(secret)
+	return trace.Wrap(rigging.ConvertError(err))
+}
+
+func (r *Syncer) close(name string) {
+	if err := r.backends[name].backend.Close(); err != nil {
+		r.Warnf("Failed to close secret backend %v: %v.", name, err)
+	}
+	delete(r.backends, name)
+}
+
+func (r *Syncer) closeAll() {
+	for name := range r.backends {
+		r.close(name)
+	}
+}
+
+// syncedBackend is a backend along with the digests
+// of the secret values last written to their targets
+type syncedBackend struct {
+	resource storage.SecretBackend
+	backend  Backend
+	// applied maps secret targets to the digests of the values written to them
+	applied map[string]string
+	// refreshed is the time the secrets were last read successfully
+	refreshed time.Time
+}
+
+func specEqual(a, b storage.SecretBackend) bool {
+	dataA, errA := storage.MarshalSecretBackend(a)
+	dataB, errB := storage.MarshalSecretBackend(b)
+	return errA == nil && errB == nil && string(dataA) == string(dataB)
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretbackend
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/httplib"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+)
+
+// newVault returns a backend that reads secrets from
+// the HashiCorp Vault key/value secrets engine
+func newVault(spec storage.VaultSpec) (*vault, error) {
+	options := []httplib.ClientOption{httplib.WithTimeout(defaults.SecretBackendTimeout)}
+	if spec.CACert != "" {
+		options = append(options, httplib.WithCA([]byte(spec.CACert)))
+	}
+	return &vault{
+		spec:   spec,
+		client: httplib.GetClient(false, options...),
+	}, nil
+}
+
+type vault struct {
+	spec   storage.VaultSpec
+	client *http.Client
+}
+
+// Read returns the values of the secret at the specified path.
+// Both versions of the key/value secrets engine are supported
+func (r *vault) Read(ctx context.Context, path string) (map[string]string, error) {
+	url := strings.TrimSuffix(r.spec.Address, "/") + "/v1/" + strings.TrimPrefix(path, "/")
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("X-Vault-Token", r.spec.Token)
+	if r.spec.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", r.spec.Namespace)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, trace.NotFound("secret %v not found in vault", path)
+	case http.StatusForbidden:
+		return nil, trace.AccessDenied("access to secret %v denied by vault: %s", path, body)
+	default:
+		return nil, trace.BadParameter("failed to read secret %v from vault: %v %s",
+			path, resp.Status, body)
+	}
+	return parseVaultSecret(body)
+}
+
+// Close is a no-op for Vault
+func (r *vault) Close() error {
+	return nil
+}
+
+// parseVaultSecret returns the values of the secret from the specified
+// Vault response. Secrets of the key/value engine version 2 nest the values
+// and the version metadata within the data field.
+// Values that are not strings are returned in JSON format
+func parseVaultSecret(body []byte) (map[string]string, error) {
+	var resp struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, trace.Wrap(err, "failed to parse vault response")
+	}
+	data := resp.Data
+	nested, hasData := data["data"]
+	_, hasMetadata := data["metadata"]
+	if hasData && hasMetadata {
+		data = nil
+		if err := json.Unmarshal(nested, &data); err != nil {
+			return nil, trace.Wrap(err, "failed to parse vault response")
+		}
+	}
+	values := make(map[string]string, len(data))
+	for key, raw := range data {
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			value = string(raw)
+		}
+		values[key] = value
+	}
+	return values, nil
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package skew enforces the version skew policy between gravity components
+// taking part in a cluster operation: the gravity binary that starts the
+// operation, the cluster controller (gravity-site), the RPC agents that
+// execute operation phases on nodes and the runtime container (planet).
+//
+// The versions are verified before the operation starts so the mismatch
+// is reported up front, instead of failing an operation phase later.
+package skew
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/gravitational/gravity/lib/loc"
+
+	"github.com/coreos/go-semver/semver"
+	"github.com/gravitational/trace"
+)
+
+// Versions describes versions of gravity components taking part in an operation
+type Versions struct {
+	// Binary is the version of the gravity binary starting the operation
+	Binary semver.Version
+	// Cluster is the version of the cluster controller
+	Cluster semver.Version
+	// Target is the version of gravity the cluster is being upgraded to.
+	// Only set for upgrades
+	Target *semver.Version
+	// Agents maps addresses of nodes with running agents to agent versions
+	Agents map[string]semver.Version
+	// Runtime is the runtime package installed on this node.
+	// Not set if this node is not a cluster node
+	Runtime *loc.Locator
+	// ExpectedRuntime is the runtime package the cluster expects on this node
+	ExpectedRuntime *loc.Locator
+}
+
+// Check verifies the versions against the version skew policy:
+//
+//  * the binary matches the cluster controller version, or the target
+//    version for upgrades, in which case the cluster cannot be newer
+//    than the binary
+//  * running agents match the binary
+//  * the runtime on this node is the one the cluster expects
+//
+// Returns an error describing all violations along with their resolutions
+func (r Versions) Check() error {
+	var problems []problem
+	required := r.Cluster
+	if r.Target != nil {
+		required = *r.Target
+		if r.Binary.LessThan(r.Cluster) {
+			problems = append(problems, problem{
+				message: fmt.Sprintf("gravity binary version %v is older than the cluster version %v",
+					&r.Binary, &r.Cluster),
+				resolution: fmt.Sprintf("Use the gravity binary from the installer tarball of version %v.",
+					r.Target),
+			})
+		}
+	}
+	if !r.Binary.Equal(required) {
+		resolution := fmt.Sprintf("Download the gravity binary matching the cluster version with:\n\n"+
+			"$ sudo gravity system download-binary --version=%v\n\n"+
+			"and run the command with the downloaded binary.", &required)
+		if r.Target != nil {
+			resolution = fmt.Sprintf("Use the gravity binary from the installer tarball of version %v.",
+				&required)
+		}
+		problems = append(problems, problem{
+			message: fmt.Sprintf("gravity binary version %v does not match the %v version %v",
+				&r.Binary, describeRequired(r.Target), &required),
+			resolution: resolution,
+		})
+	}
+	for _, addr := range sortedKeys(r.Agents) {
+		agent := r.Agents[addr]
+		if agent.Equal(r.Binary) {
+			continue
+		}
+		problems = append(problems, problem{
+			message: fmt.Sprintf("agent on node %v is running version %v while gravity binary version is %v",
+				addr, &agent, &r.Binary),
+			resolution: "Shut down the agents with 'sudo gravity agent shutdown' " +
+				"and resume the operation to redeploy them.",
+		})
+	}
+	if r.Runtime != nil && r.ExpectedRuntime != nil && !r.Runtime.IsEqualTo(*r.ExpectedRuntime) {
+		problems = append(problems, problem{
+			message: fmt.Sprintf("runtime package %v installed on this node does not match %v required by the cluster",
+				r.Runtime, r.ExpectedRuntime),
+			resolution: "The node may have been left behind by an interrupted upgrade. " +
+				"Complete or roll back the upgrade with 'gravity plan' before starting another operation.",
+		})
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return trace.BadParameter("%v", formatProblems(problems))
+}
+
+// problem describes a single version skew policy violation
+type problem struct {
+	// message describes the violation
+	message string
+	// resolution describes how to resolve the violation
+	resolution string
+}
+
+func formatProblems(problems []problem) string {
+	var buf bytes.Buffer
+	fmt.Fprintln(&buf, "version skew between gravity components detected:")
+	for _, problem := range problems {
+		fmt.Fprintf(&buf, "\n  * %v.\n%v\n", problem.message, problem.resolution)
+	}
+	return buf.String()
+}
+
+func describeRequired(target *semver.Version) string {
+	if target != nil {
+		return "upgrade"
+	}
+	return "cluster"
+}
+
+func sortedKeys(agents map[string]semver.Version) (keys []string) {
+	for key := range agents {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package skew
+
+import (
+	"testing"
+
+	"github.com/gravitational/gravity/lib/loc"
+
+	"github.com/coreos/go-semver/semver"
+	"gopkg.in/check.v1"
+)
+
+func TestSkew(t *testing.T) { check.TestingT(t) }
+
+type SkewSuite struct{}
+
+var _ = check.Suite(&SkewSuite{})
+
+func (s *SkewSuite) TestVersionSkewPolicy(c *check.C) {
+	runtime := loc.MustParseLocator("gravitational.io/planet:5.2.1")
+	newerRuntime := loc.MustParseLocator("gravitational.io/planet:5.2.2")
+	var testCases = []struct {
+		versions Versions
+		err      string
+		comment  string
+	}{
+		{
+			versions: Versions{
+				Binary:  *semver.New("5.2.1"),
+				Cluster: *semver.New("5.2.1"),
+				Agents: map[string]semver.Version{
+					"10.0.0.1": *semver.New("5.2.1"),
+				},
+				Runtime:         &runtime,
+				ExpectedRuntime: &runtime,
+			},
+			comment: "matching versions",
+		},
+		{
+			versions: Versions{
+				Binary:  *semver.New("5.2.1+abc"),
+				Cluster: *semver.New("5.2.1"),
+			},
+			comment: "build metadata is ignored",
+		},
+		{
+			versions: Versions{
+				Binary:  *semver.New("5.2.0"),
+				Cluster: *semver.New("5.2.1"),
+			},
+			err:     "gravity system download-binary --version=5.2.1",
+			comment: "binary does not match cluster",
+		},
+		{
+			versions: Versions{
+				Binary:  *semver.New("5.3.0"),
+				Cluster: *semver.New("5.2.1"),
+				Target:  semver.New("5.3.0"),
+			},
+			comment: "upgrade to the binary version",
+		},
+		{
+			versions: Versions{
+				Binary:  *semver.New("5.2.0"),
+				Cluster: *semver.New("5.2.1"),
+				Target:  semver.New("5.2.0"),
+			},
+			err:     "older than the cluster version",
+			comment: "upgrade with an older binary",
+		},
+		{
+			versions: Versions{
+				Binary:  *semver.New("5.3.0"),
+				Cluster: *semver.New("5.2.1"),
+				Target:  semver.New("5.3.1"),
+			},
+			err:     "does not match the upgrade version 5.3.1",
+			comment: "binary does not match upgrade",
+		},
+		{
+			versions: Versions{
+				Binary:  *semver.New("5.2.1"),
+				Cluster: *semver.New("5.2.1"),
+				Agents: map[string]semver.Version{
+					"10.0.0.2": *semver.New("5.2.0"),
+				},
+			},
+			err:     "agent on node 10.0.0.2 is running version 5.2.0",
+			comment: "stale agent",
+		},
+		{
+			versions: Versions{
+				Binary:          *semver.New("5.2.1"),
+				Cluster:         *semver.New("5.2.1"),
+				Runtime:         &runtime,
+				ExpectedRuntime: &newerRuntime,
+			},
+			err:     "interrupted upgrade",
+			comment: "runtime does not match",
+		},
+	}
+	for _, tc := range testCases {
+		comment := check.Commentf(tc.comment)
+		err := tc.versions.Check()
+		if tc.err == "" {
+			c.Assert(err, check.IsNil, comment)
+			continue
+		}
+		c.Assert(err, check.NotNil, comment)
+		c.Assert(err, check.ErrorMatches, "(?s).*"+tc.err+".*", comment)
+	}
+}
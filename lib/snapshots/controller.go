@@ -0,0 +1,191 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snapshots
+
+import (
+	"context"
+	"time"
+
+	"github.com/gravitational/gravity/lib/app"
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/schema"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/rigging"
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ControllerConfig configures the snapshot controller
+type ControllerConfig struct {
+	// Operator is the cluster operator service
+	Operator ops.Operator
+	// Apps is the cluster application service
+	Apps app.Applications
+	// Client is the Kubernetes client
+	Client *kubernetes.Clientset
+	// Interval is how often snapshot policies are evaluated
+	Interval time.Duration
+	// Clock is used to determine when snapshots are due
+	Clock func() time.Time
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+}
+
+// CheckAndSetDefaults validates the configuration and sets default values
+func (r *ControllerConfig) CheckAndSetDefaults() error {
+	if r.Operator == nil {
+		return trace.BadParameter("missing Operator")
+	}
+	if r.Apps == nil {
+		return trace.BadParameter("missing Apps")
+	}
+	if r.Client == nil {
+		return trace.BadParameter("missing Client")
+	}
+	if r.Interval == 0 {
+		r.Interval = defaults.SnapshotSyncInterval
+	}
+	if r.Clock == nil {
+		r.Clock = time.Now
+	}
+	if r.FieldLogger == nil {
+		r.FieldLogger = logrus.WithField(trace.Component, "snapshots")
+	}
+	return nil
+}
+
+// NewController returns a new snapshot controller
+func NewController(config ControllerConfig) (*Controller, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &Controller{
+		ControllerConfig: config,
+		snapshots:        NewClient(config.Client.Discovery().RESTClient()),
+	}, nil
+}
+
+// Controller takes scheduled snapshots of application persistent volumes
+// and removes the snapshots exceeding the retention count according to
+// the snapshot policies of the cluster application and its dependencies
+type Controller struct {
+	ControllerConfig
+	snapshots *Client
+}
+
+// Run evaluates snapshot policies periodically until the context is canceled
+func (r *Controller) Run(ctx context.Context) error {
+	utils.RunPeriodically(ctx, r.Interval, r.FieldLogger,
+		"take scheduled volume snapshots", func(context.Context) error {
+			return r.Sync()
+		})
+	return nil
+}
+
+// Sync evaluates all snapshot policies once
+func (r *Controller) Sync() error {
+	policies, err := r.policies()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	var errors []error
+	for _, policy := range policies {
+		if err := r.syncPolicy(policy.app, policy.SnapshotPolicy); err != nil {
+			errors = append(errors, trace.Wrap(err, "snapshot policy %v/%v",
+				policy.app, policy.Name))
+		}
+	}
+	return trace.NewAggregate(errors...)
+}
+
+func (r *Controller) syncPolicy(app string, policy schema.SnapshotPolicy) error {
+	namespace := policy.GetNamespace()
+	claims, err := r.Client.CoreV1().PersistentVolumeClaims(namespace).List(metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(policy.Selector).String(),
+	})
+	if err != nil {
+		return trace.Wrap(rigging.ConvertError(err))
+	}
+	var names []string
+	for _, claim := range claims.Items {
+		names = append(names, claim.Name)
+	}
+	existing, err := r.snapshots.List(namespace, map[string]string{
+		constants.SnapshotAppLabel:    app,
+		constants.SnapshotPolicyLabel: policy.Name,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	now := r.Clock()
+	due, expired := Plan(policy, names, existing, now)
+	for _, claim := range due {
+		snapshot := NewSnapshot(app, policy, claim, now)
+		r.Infof("Creating snapshot %v/%v of volume claim %v.", namespace, snapshot.Name, claim)
+		if err := r.snapshots.Create(snapshot); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	for _, name := range expired {
+		r.Infof("Deleting expired snapshot %v/%v.", namespace, name)
+		if err := r.snapshots.Delete(namespace, name); err != nil && !trace.IsNotFound(err) {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// policies returns snapshot policies declared by the cluster application
+// and the applications it depends on
+func (r *Controller) policies() (policies []appPolicy, err error) {
+	cluster, err := r.Operator.GetLocalSite()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	manifest := cluster.App.Manifest
+	for _, policy := range manifest.Snapshots {
+		policies = append(policies, appPolicy{app: manifest.Metadata.Name, SnapshotPolicy: policy})
+	}
+	for _, dependency := range manifest.Dependencies.GetApps() {
+		if schema.ShouldSkipApp(manifest, dependency) {
+			continue
+		}
+		application, err := r.Apps.GetApp(dependency)
+		if err != nil {
+			if trace.IsNotFound(err) {
+				continue
+			}
+			return nil, trace.Wrap(err)
+		}
+		for _, policy := range application.Manifest.Snapshots {
+			policies = append(policies, appPolicy{app: dependency.Name, SnapshotPolicy: policy})
+		}
+	}
+	return policies, nil
+}
+
+// appPolicy is a snapshot policy declared by the specific application
+type appPolicy struct {
+	schema.SnapshotPolicy
+	app string
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package snapshots implements scheduled CSI snapshots of application
+// persistent volumes according to the snapshot policies declared
+// in application manifests
+package snapshots
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/schema"
+
+	"github.com/gravitational/rigging"
+	"github.com/gravitational/trace"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	// snapshotGroupVersion is the API group version of the volume snapshot resource
+	snapshotGroupVersion = "snapshot.storage.k8s.io/v1alpha1"
+	// snapshotKind is the kind of the volume snapshot resource
+	snapshotKind = "VolumeSnapshot"
+	// claimKind is the kind of the snapshot source
+	claimKind = "PersistentVolumeClaim"
+)
+
+// VolumeSnapshot is a CSI snapshot of a persistent volume claim
+type VolumeSnapshot struct {
+	metav1.TypeMeta `json:",inline"`
+	// ObjectMeta is the snapshot metadata
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	// Spec defines the snapshot source and class
+	Spec VolumeSnapshotSpec `json:"spec"`
+}
+
+// VolumeSnapshotSpec defines the snapshot source and class
+type VolumeSnapshotSpec struct {
+	// Source is the persistent volume claim to snapshot
+	Source *VolumeSnapshotSource `json:"source,omitempty"`
+	// SnapshotClassName is the name of the volume snapshot class
+	SnapshotClassName string `json:"snapshotClassName,omitempty"`
+}
+
+// VolumeSnapshotSource references the snapshotted persistent volume claim
+type VolumeSnapshotSource struct {
+	// Name is the persistent volume claim name
+	Name string `json:"name"`
+	// Kind is the source kind, always PersistentVolumeClaim
+	Kind string `json:"kind"`
+}
+
+// volumeSnapshotList is a list of volume snapshots
+type volumeSnapshotList struct {
+	// Items lists the snapshots
+	Items []VolumeSnapshot `json:"items"`
+}
+
+// Claim returns the name of the snapshotted persistent volume claim
+func (r VolumeSnapshot) Claim() string {
+	if r.Spec.Source != nil {
+		return r.Spec.Source.Name
+	}
+	return r.Labels[constants.SnapshotClaimLabel]
+}
+
+// NewSnapshot returns a new scheduled snapshot of the specified
+// persistent volume claim created by the policy of the application
+func NewSnapshot(app string, policy schema.SnapshotPolicy, claim string, now time.Time) VolumeSnapshot {
+	return VolumeSnapshot{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       snapshotKind,
+			APIVersion: snapshotGroupVersion,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%v-%v", claim, now.UTC().Format("20060102-150405")),
+			Namespace: policy.GetNamespace(),
+			Labels: map[string]string{
+				constants.SnapshotAppLabel:    app,
+				constants.SnapshotPolicyLabel: policy.Name,
+				constants.SnapshotClaimLabel:  claim,
+			},
+		},
+		Spec: VolumeSnapshotSpec{
+			Source:            &VolumeSnapshotSource{Name: claim, Kind: claimKind},
+			SnapshotClassName: policy.SnapshotClass,
+		},
+	}
+}
+
+// Plan returns the claims that are due for a new snapshot and the names
+// of snapshots exceeding the retention count of the policy. Snapshots
+// are the existing snapshots created by the policy
+func Plan(policy schema.SnapshotPolicy, claims []string, snapshots []VolumeSnapshot, now time.Time) (due []string, expired []string) {
+	byClaim := make(map[string][]VolumeSnapshot)
+	for _, snapshot := range snapshots {
+		byClaim[snapshot.Claim()] = append(byClaim[snapshot.Claim()], snapshot)
+	}
+	for _, claim := range claims {
+		existing := byClaim[claim]
+		sort.Slice(existing, func(i, j int) bool {
+			return existing[i].CreationTimestamp.After(existing[j].CreationTimestamp.Time)
+		})
+		retain := policy.GetRetain()
+		if len(existing) == 0 || now.Sub(existing[0].CreationTimestamp.Time) >= policy.GetInterval() {
+			due = append(due, claim)
+			// make room for the new snapshot
+			retain--
+		}
+		if len(existing) > retain {
+			for _, snapshot := range existing[retain:] {
+				expired = append(expired, snapshot.Name)
+			}
+		}
+	}
+	return due, expired
+}
+
+// NewClient returns a new volume snapshot client that uses
+// the specified REST client for the Kubernetes API server
+func NewClient(client rest.Interface) *Client {
+	return &Client{client: client}
+}
+
+// Client manages volume snapshots via the Kubernetes API
+type Client struct {
+	client rest.Interface
+}
+
+// List returns snapshots matching the labels in the specified namespace
+func (c *Client) List(namespace string, selector map[string]string) ([]VolumeSnapshot, error) {
+	data, err := c.client.Get().AbsPath(snapshotsPath(namespace)...).
+		Param("labelSelector", labels.SelectorFromSet(selector).String()).
+		Do().Raw()
+	if err != nil {
+		return nil, trace.Wrap(rigging.ConvertError(err))
+	}
+	var list volumeSnapshotList
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return list.Items, nil
+}
+
+// Create creates the specified snapshot
+func (c *Client) Create(snapshot VolumeSnapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = c.client.Post().AbsPath(snapshotsPath(snapshot.Namespace)...).
+		SetHeader("Content-Type", "application/json").
+		Body(data).Do().Error()
+	return trace.Wrap(rigging.ConvertError(err))
+}
+
+// Delete deletes the snapshot with the specified name
+func (c *Client) Delete(namespace, name string) error {
+	err := c.client.Delete().AbsPath(append(snapshotsPath(namespace), name)...).Do().Error()
+	return trace.Wrap(rigging.ConvertError(err))
+}
+
+func snapshotsPath(namespace string) []string {
+	return []string{"/apis", snapshotGroupVersion, "namespaces", namespace, "volumesnapshots"}
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snapshots
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/schema"
+
+	check "gopkg.in/check.v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSnapshots(t *testing.T) { check.TestingT(t) }
+
+type SnapshotsSuite struct{}
+
+var _ = check.Suite(&SnapshotsSuite{})
+
+func (s *SnapshotsSuite) TestNewSnapshot(c *check.C) {
+	policy := schema.SnapshotPolicy{Name: "data", SnapshotClass: "csi-rbd", Interval: "1h"}
+	now := time.Date(2019, time.March, 1, 12, 30, 0, 0, time.UTC)
+	snapshot := NewSnapshot("postgres", policy, "data-postgres-0", now)
+	c.Assert(snapshot.Name, check.Equals, "data-postgres-0-20190301-123000")
+	c.Assert(snapshot.Namespace, check.Equals, "default")
+	c.Assert(snapshot.Claim(), check.Equals, "data-postgres-0")
+	c.Assert(snapshot.Spec.SnapshotClassName, check.Equals, "csi-rbd")
+	c.Assert(snapshot.Labels, check.DeepEquals, map[string]string{
+		constants.SnapshotAppLabel:    "postgres",
+		constants.SnapshotPolicyLabel: "data",
+		constants.SnapshotClaimLabel:  "data-postgres-0",
+	})
+}
+
+func (s *SnapshotsSuite) TestPlan(c *check.C) {
+	policy := schema.SnapshotPolicy{Name: "data", Interval: "1h", Retain: 2}
+	now := time.Date(2019, time.March, 1, 12, 0, 0, 0, time.UTC)
+	snapshots := []VolumeSnapshot{
+		newSnapshot("a-1", "a", now.Add(-3*time.Hour)),
+		newSnapshot("a-3", "a", now.Add(-1*time.Hour)),
+		newSnapshot("a-2", "a", now.Add(-2*time.Hour)),
+		newSnapshot("b-1", "b", now.Add(-30*time.Minute)),
+		newSnapshot("b-0", "b", now.Add(-90*time.Minute)),
+		newSnapshot("removed-1", "removed", now.Add(-5*time.Hour)),
+	}
+	due, expired := Plan(policy, []string{"a", "b", "c"}, snapshots, now)
+	// snapshots of removed claims are kept
+	c.Assert(due, check.DeepEquals, []string{"a", "c"})
+	c.Assert(expired, check.DeepEquals, []string{"a-2", "a-1"})
+}
+
+func newSnapshot(name, claim string, created time.Time) VolumeSnapshot {
+	snapshot := NewSnapshot("app", schema.SnapshotPolicy{Name: "data"}, claim, created)
+	snapshot.Name = name
+	snapshot.CreationTimestamp = metav1.NewTime(created)
+	return snapshot
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+)
+
+// FlapDetectorConfig configures the flapping node detector
+type FlapDetectorConfig struct {
+	// Window is the time window membership transitions are counted in
+	Window time.Duration
+	// Threshold is the number of transitions within the window
+	// after which the node is considered flapping
+	Threshold int
+	// Clock is used to mock time in tests
+	Clock clockwork.Clock
+}
+
+// CheckAndSetDefaults validates the configuration and sets default values
+func (r *FlapDetectorConfig) CheckAndSetDefaults() error {
+	if r.Window == 0 {
+		r.Window = defaults.FlapDetectionWindow
+	}
+	if r.Threshold == 0 {
+		r.Threshold = defaults.FlapThreshold
+	}
+	if r.Threshold < 0 {
+		return trace.BadParameter("threshold can not be negative")
+	}
+	if r.Clock == nil {
+		r.Clock = clockwork.NewRealClock()
+	}
+	return nil
+}
+
+// NewFlapDetector returns a new detector of nodes that repeatedly
+// join and leave the cluster
+func NewFlapDetector(config FlapDetectorConfig) (*FlapDetector, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &FlapDetector{
+		FlapDetectorConfig: config,
+		nodes:              make(map[string]*nodeHistory),
+	}, nil
+}
+
+// FlapDetector tracks the membership transitions of cluster nodes
+type FlapDetector struct {
+	FlapDetectorConfig
+	// nodes maps node advertise address to its membership history
+	nodes map[string]*nodeHistory
+}
+
+// nodeHistory is the membership history of a single node
+type nodeHistory struct {
+	// online is whether the node was online when last observed
+	online bool
+	// changed is the time of the last transition or when the node
+	// was first observed
+	changed time.Time
+	// transitions lists the times of the node's recent membership transitions
+	transitions []time.Time
+}
+
+// Observe records the current membership of the nodes given as a map of
+// node advertise address to whether the node is online.
+// Nodes that are no longer part of the cluster are forgotten
+func (r *FlapDetector) Observe(online map[string]bool) {
+	now := r.Clock.Now()
+	for addr, isOnline := range online {
+		history, ok := r.nodes[addr]
+		if !ok {
+			r.nodes[addr] = &nodeHistory{online: isOnline, changed: now}
+			continue
+		}
+		if history.online != isOnline {
+			history.transitions = append(history.transitions, now)
+			history.online = isOnline
+			history.changed = now
+		}
+		history.expire(now.Add(-r.Window))
+	}
+	for addr := range r.nodes {
+		if _, ok := online[addr]; !ok {
+			delete(r.nodes, addr)
+		}
+	}
+}
+
+// Transitions returns the number of membership transitions the node
+// with the specified advertise address made within the detection window
+func (r *FlapDetector) Transitions(addr string) int {
+	history, ok := r.nodes[addr]
+	if !ok {
+		return 0
+	}
+	return len(history.transitions)
+}
+
+// IsFlapping returns true if the node with the specified advertise
+// address has reached the transition threshold
+func (r *FlapDetector) IsFlapping(addr string) bool {
+	return r.Transitions(addr) >= r.Threshold
+}
+
+// IsStable returns true if the node with the specified advertise address
+// is online and has not changed its membership for the specified duration
+func (r *FlapDetector) IsStable(addr string, period time.Duration) bool {
+	history, ok := r.nodes[addr]
+	if !ok || !history.online {
+		return false
+	}
+	return r.Clock.Now().Sub(history.changed) >= period
+}
+
+// Reset forgets the membership transitions of the node with the
+// specified advertise address
+func (r *FlapDetector) Reset(addr string) {
+	if history, ok := r.nodes[addr]; ok {
+		history.transitions = nil
+	}
+}
+
+// expire drops the transitions that happened before the specified time
+func (r *nodeHistory) expire(before time.Time) {
+	var i int
+	for i < len(r.transitions) && r.transitions[i].Before(before) {
+		i++
+	}
+	r.transitions = r.transitions[i:]
+}
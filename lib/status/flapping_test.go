@@ -0,0 +1,82 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	check "gopkg.in/check.v1"
+)
+
+func TestStatus(t *testing.T) { check.TestingT(t) }
+
+type FlapDetectorSuite struct {
+	clock    clockwork.FakeClock
+	detector *FlapDetector
+}
+
+var _ = check.Suite(&FlapDetectorSuite{})
+
+func (s *FlapDetectorSuite) SetUpTest(c *check.C) {
+	s.clock = clockwork.NewFakeClock()
+	var err error
+	s.detector, err = NewFlapDetector(FlapDetectorConfig{
+		Window:    10 * time.Minute,
+		Threshold: 4,
+		Clock:     s.clock,
+	})
+	c.Assert(err, check.IsNil)
+}
+
+func (s *FlapDetectorSuite) TestDetectsFlappingNode(c *check.C) {
+	for i := 0; i < 5; i++ {
+		s.detector.Observe(map[string]bool{"10.0.0.1": i%2 == 0, "10.0.0.2": true})
+		s.clock.Advance(time.Minute)
+	}
+	c.Assert(s.detector.Transitions("10.0.0.1"), check.Equals, 4)
+	c.Assert(s.detector.IsFlapping("10.0.0.1"), check.Equals, true)
+	c.Assert(s.detector.IsFlapping("10.0.0.2"), check.Equals, false)
+}
+
+func (s *FlapDetectorSuite) TestExpiresTransitions(c *check.C) {
+	for i := 0; i < 5; i++ {
+		s.detector.Observe(map[string]bool{"10.0.0.1": i%2 == 0})
+		s.clock.Advance(time.Minute)
+	}
+	c.Assert(s.detector.IsFlapping("10.0.0.1"), check.Equals, true)
+	c.Assert(s.detector.IsStable("10.0.0.1", 30*time.Minute), check.Equals, false)
+
+	s.clock.Advance(30 * time.Minute)
+	s.detector.Observe(map[string]bool{"10.0.0.1": true})
+	c.Assert(s.detector.Transitions("10.0.0.1"), check.Equals, 0)
+	c.Assert(s.detector.IsStable("10.0.0.1", 30*time.Minute), check.Equals, true)
+}
+
+func (s *FlapDetectorSuite) TestResetsAndForgetsNodes(c *check.C) {
+	for i := 0; i < 5; i++ {
+		s.detector.Observe(map[string]bool{"10.0.0.1": i%2 == 0})
+		s.clock.Advance(time.Minute)
+	}
+	s.detector.Reset("10.0.0.1")
+	c.Assert(s.detector.IsFlapping("10.0.0.1"), check.Equals, false)
+
+	s.detector.Observe(map[string]bool{"10.0.0.1": false})
+	s.detector.Observe(map[string]bool{})
+	c.Assert(s.detector.nodes, check.HasLen, 0)
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/kubelet"
+
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+)
+
+// KubeletCertificate describes the serving certificate of the node's kubelet
+type KubeletCertificate struct {
+	// NotAfter is the expiration time of the certificate
+	NotAfter time.Time `json:"not_after"`
+	// Expired is whether the certificate has expired
+	Expired bool `json:"expired,omitempty"`
+	// Expiring is whether the certificate is about to expire
+	Expiring bool `json:"expiring,omitempty"`
+}
+
+// markKubeletCertificates records the expiration of the kubelet
+// serving certificate of each node that can be reached
+func (r *Agent) markKubeletCertificates(ctx context.Context, now time.Time) {
+	var wg sync.WaitGroup
+	for i := range r.Nodes {
+		wg.Add(1)
+		go func(node *ClusterServer) {
+			defer wg.Done()
+			cert, err := kubelet.ServingCertificate(ctx, node.AdvertiseIP)
+			if err != nil {
+				logrus.WithField("node", node.AdvertiseIP).Debugf("Failed to retrieve kubelet certificate: %v.",
+					trace.DebugReport(err))
+				return
+			}
+			node.KubeletCertificate = &KubeletCertificate{
+				NotAfter: cert.NotAfter,
+				Expired:  kubelet.IsExpiring(*cert, now, 0),
+				Expiring: kubelet.IsExpiring(*cert, now, defaults.KubeletCertExpiryWarning),
+			}
+		}(&r.Nodes[i])
+	}
+	wg.Wait()
+}
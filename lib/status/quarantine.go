@@ -0,0 +1,242 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/rigging"
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+// QuarantineConfig configures the flapping node quarantine
+type QuarantineConfig struct {
+	// FlapDetectorConfig configures detection of flapping nodes
+	FlapDetectorConfig
+	// Operator is the cluster operator service
+	Operator ops.Operator
+	// Client is the Kubernetes client used to emit node events
+	Client *kubernetes.Clientset
+	// Interval is how often the node membership is sampled
+	Interval time.Duration
+	// ReleasePeriod is how long a quarantined node has to stay online
+	// without transitions before it is released
+	ReleasePeriod time.Duration
+	// Members returns the membership of the specified cluster nodes
+	// as a map of node advertise address to whether the node is online
+	Members func(context.Context, []storage.Server) (map[string]bool, error)
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+}
+
+// CheckAndSetDefaults validates the configuration and sets default values
+func (r *QuarantineConfig) CheckAndSetDefaults() error {
+	if r.Operator == nil {
+		return trace.BadParameter("missing Operator")
+	}
+	if r.Client == nil {
+		return trace.BadParameter("missing Client")
+	}
+	if err := r.FlapDetectorConfig.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	if r.Interval == 0 {
+		r.Interval = defaults.FlapDetectionInterval
+	}
+	if r.ReleasePeriod == 0 {
+		r.ReleasePeriod = defaults.QuarantineReleasePeriod
+	}
+	if r.Members == nil {
+		r.Members = planetMembers
+	}
+	if r.FieldLogger == nil {
+		r.FieldLogger = logrus.WithField(trace.Component, "quarantine")
+	}
+	return nil
+}
+
+// NewQuarantine returns a new quarantine of flapping nodes
+func NewQuarantine(config QuarantineConfig) (*Quarantine, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	detector, err := NewFlapDetector(config.FlapDetectorConfig)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &Quarantine{
+		QuarantineConfig: config,
+		detector:         detector,
+		quarantined:      make(map[string]struct{}),
+	}, nil
+}
+
+// Quarantine watches the membership of cluster nodes and quarantines
+// the nodes that repeatedly join and leave the cluster, e.g. because
+// of a bad NIC or OOM loops, so that no operations are started while
+// they are flapping. Quarantined nodes are released once they have been
+// stable for the release period
+type Quarantine struct {
+	QuarantineConfig
+	detector *FlapDetector
+	// quarantined is the set of nodes observed as quarantined last time
+	quarantined map[string]struct{}
+}
+
+// Run samples the node membership periodically until the context is canceled
+func (r *Quarantine) Run(ctx context.Context) error {
+	utils.RunPeriodically(ctx, r.Interval, r.FieldLogger,
+		"check for flapping nodes", r.Check)
+	return nil
+}
+
+// Check samples the current node membership and quarantines flapping
+// nodes or releases the quarantined nodes that have become stable
+func (r *Quarantine) Check(ctx context.Context) error {
+	cluster, err := r.Operator.GetLocalSite()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	servers := cluster.ClusterState.Servers
+	members, err := r.Members(ctx, servers)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	r.detector.Observe(members)
+	nodes, err := r.Operator.GetQuarantinedNodes(cluster.Key())
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	// nodes released manually start over with a clean history
+	for addr := range r.quarantined {
+		if nodes.Find(addr) == nil {
+			r.detector.Reset(addr)
+			delete(r.quarantined, addr)
+		}
+	}
+	var errors []error
+	for _, server := range servers {
+		addr := server.AdvertiseIP
+		node := nodes.Find(addr)
+		switch {
+		case node == nil && r.detector.IsFlapping(addr):
+			errors = append(errors, r.quarantine(cluster.Key(), server))
+		case node != nil && r.detector.IsStable(addr, r.ReleasePeriod):
+			errors = append(errors, r.release(cluster.Key(), server))
+		case node != nil:
+			r.quarantined[addr] = struct{}{}
+		}
+	}
+	return trace.NewAggregate(errors...)
+}
+
+func (r *Quarantine) quarantine(key ops.SiteKey, server storage.Server) error {
+	node := storage.QuarantinedNode{
+		AdvertiseIP: server.AdvertiseIP,
+		Hostname:    server.Hostname,
+		Transitions: r.detector.Transitions(server.AdvertiseIP),
+		Since:       r.Clock.Now().UTC(),
+	}
+	r.Warnf("Quarantining flapping %v.", node)
+	if err := r.Operator.QuarantineNode(key, node); err != nil {
+		return trace.Wrap(err)
+	}
+	r.quarantined[server.AdvertiseIP] = struct{}{}
+	r.emitEvent(server, v1.EventTypeWarning, eventReasonQuarantined, fmt.Sprintf(
+		"Node %v has changed its cluster membership %v times in %v and has been quarantined.",
+		server.AdvertiseIP, node.Transitions, r.Window))
+	return nil
+}
+
+func (r *Quarantine) release(key ops.SiteKey, server storage.Server) error {
+	r.Infof("Releasing node %v from quarantine.", server.AdvertiseIP)
+	err := r.Operator.ReleaseQuarantinedNode(key, server.AdvertiseIP)
+	if err != nil && !trace.IsNotFound(err) {
+		return trace.Wrap(err)
+	}
+	delete(r.quarantined, server.AdvertiseIP)
+	r.emitEvent(server, v1.EventTypeNormal, eventReasonReleased, fmt.Sprintf(
+		"Node %v has been stable for %v and has been released from quarantine.",
+		server.AdvertiseIP, r.ReleasePeriod))
+	return nil
+}
+
+// emitEvent records a Kubernetes event for the node
+func (r *Quarantine) emitEvent(server storage.Server, eventType, reason, message string) {
+	now := metav1.NewTime(r.Clock.Now())
+	name := server.KubeNodeID()
+	event := &v1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: fmt.Sprintf("%v.", name),
+			Namespace:    metav1.NamespaceDefault,
+		},
+		InvolvedObject: v1.ObjectReference{
+			Kind: "Node",
+			Name: name,
+			// node events use node name as UID
+			UID: types.UID(name),
+		},
+		Reason:         reason,
+		Message:        message,
+		Type:           eventType,
+		Source:         v1.EventSource{Component: eventSource},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+	_, err := r.Client.CoreV1().Events(metav1.NamespaceDefault).Create(event)
+	if err != nil {
+		r.Warnf("Failed to emit %v event for node %v: %v.",
+			reason, name, trace.DebugReport(rigging.ConvertError(err)))
+	}
+}
+
+// planetMembers returns the node membership as reported by the planet agent
+func planetMembers(ctx context.Context, servers []storage.Server) (map[string]bool, error) {
+	agent, err := FromPlanetAgent(ctx, servers)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	members := make(map[string]bool, len(agent.Nodes))
+	for _, node := range agent.Nodes {
+		members[node.AdvertiseIP] = node.Status != NodeOffline
+	}
+	return members, nil
+}
+
+const (
+	// eventReasonQuarantined is the reason of the event emitted
+	// when a node is quarantined
+	eventReasonQuarantined = "NodeQuarantined"
+	// eventReasonReleased is the reason of the event emitted
+	// when a node is released from quarantine
+	eventReasonReleased = "NodeReleased"
+	// eventSource is the component that emits node quarantine events
+	eventSource = "gravity-site"
+)
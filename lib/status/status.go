@@ -40,6 +40,16 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// Empty returns an empty cluster status with the status extension
+// initialized, e.g. to unmarshal the previously collected status into
+func Empty() *Status {
+	return &Status{
+		Cluster: &Cluster{
+			Extension: newExtension(),
+		},
+	}
+}
+
 // FromCluster collects cluster status information.
 // The function returns the partial status if not all details can be collected
 func FromCluster(ctx context.Context, operator ops.Operator, cluster ops.Site, operationID string) (status *Status, err error) {
@@ -50,6 +60,7 @@ func FromCluster(ctx context.Context, operator ops.Operator, cluster ops.Site, o
 			State:     ops.SiteStateDegraded,
 			Reason:    cluster.Reason,
 			App:       cluster.App.Package,
+			Lifecycle: cluster.App.PackageEnvelope.Lifecycle(),
 			Extension: newExtension(),
 		},
 	}
@@ -124,6 +135,13 @@ func FromCluster(ctx context.Context, operator ops.Operator, cluster ops.Site, o
 		return status, trace.Wrap(err, "failed to collect system status from agents")
 	}
 
+	quarantined, err := operator.GetQuarantinedNodes(cluster.Key())
+	if err != nil && !trace.IsNotFound(err) {
+		return status, trace.Wrap(err)
+	}
+	status.Agent.markQuarantined(quarantined)
+	status.Agent.markKubeletCertificates(ctx, time.Now())
+
 	status.State = cluster.State
 	return status, nil
 }
@@ -160,6 +178,9 @@ type Status struct {
 type Cluster struct {
 	// App references the installed application
 	App loc.Locator `json:"application"`
+	// Lifecycle is the lifecycle status of the installed application version,
+	// e.g. deprecated or eol
+	Lifecycle string `json:"lifecycle,omitempty"`
 	// State describes the cluster state
 	State string `json:"state"`
 	// Reason specifies the reason for the state
@@ -320,6 +341,19 @@ type ClusterServer struct {
 	Status string `json:"status"`
 	// FailedProbes lists all failed probes if the node is not healthy
 	FailedProbes []string `json:"failed_probes,omitempty"`
+	// Quarantine describes the quarantine if the node is quarantined
+	// for repeatedly joining and leaving the cluster
+	Quarantine *storage.QuarantinedNode `json:"quarantine,omitempty"`
+	// KubeletCertificate describes the kubelet serving certificate
+	// if it could be retrieved
+	KubeletCertificate *KubeletCertificate `json:"kubelet_certificate,omitempty"`
+}
+
+// markQuarantined marks the nodes that are quarantined for flapping
+func (r *Agent) markQuarantined(nodes storage.QuarantinedNodes) {
+	for i, server := range r.Nodes {
+		r.Nodes[i].Quarantine = nodes.Find(server.AdvertiseIP)
+	}
 }
 
 func (r ClusterOperation) isFailed() bool {
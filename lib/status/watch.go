@@ -0,0 +1,156 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gravitational/gravity/lib/ops"
+
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+)
+
+const (
+	// TransitionCluster is a change of the cluster state
+	TransitionCluster = "cluster"
+	// TransitionNode is a change of a node status
+	TransitionNode = "node"
+	// TransitionLeader is a change of the active master node
+	TransitionLeader = "leader"
+	// TransitionService is a system service restarted by the service watchdog
+	TransitionService = "service"
+)
+
+// Snapshot is the cluster status observed at a point in time
+type Snapshot struct {
+	// Status is the observed cluster status
+	Status Status
+	// Leader is the address of the active master node, if known
+	Leader string
+	// Time is the time of the observation
+	Time time.Time
+}
+
+// Transition describes a change between two consecutive status snapshots
+type Transition struct {
+	// Time is the time the change has been observed
+	Time time.Time `json:"time"`
+	// Type is the transition type: cluster, node or leader
+	Type string `json:"type"`
+	// Node is the advertise address of the node for node transitions
+	Node string `json:"node,omitempty"`
+	// Hostname is the hostname of the node for node transitions
+	Hostname string `json:"hostname,omitempty"`
+	// Service is the name of the system service for service transitions
+	Service string `json:"service,omitempty"`
+	// From is the previous state
+	From string `json:"from"`
+	// To is the new state
+	To string `json:"to"`
+}
+
+// IsDegradation returns true if the transition is a change for the worse
+func (r Transition) IsDegradation() bool {
+	switch r.Type {
+	case TransitionCluster:
+		return r.To == ops.SiteStateDegraded
+	case TransitionNode:
+		return r.To != NodeHealthy
+	case TransitionService:
+		return r.To != ServiceActive
+	}
+	// leader changes are always worth attention
+	return true
+}
+
+// String returns a human-readable description of the transition
+func (r Transition) String() string {
+	switch r.Type {
+	case TransitionCluster:
+		return fmt.Sprintf("cluster is %v (was %v)", r.To, r.From)
+	case TransitionNode:
+		return fmt.Sprintf("node %v (%v) is %v (was %v)", r.Hostname, r.Node, r.To, r.From)
+	case TransitionLeader:
+		return fmt.Sprintf("leader changed from %v to %v", r.From, r.To)
+	case TransitionService:
+		return fmt.Sprintf("service %v on node %v is %v (was %v)", r.Service, r.Node, r.To, r.From)
+	}
+	return fmt.Sprintf("%v changed from %v to %v", r.Type, r.From, r.To)
+}
+
+// Transitions returns the changes between the previous and the next snapshot:
+// cluster state changes, node status changes and leader changes.
+// Nodes missing in either snapshot and unknown leaders are not reported
+func Transitions(prev, next Snapshot) (transitions []Transition) {
+	prevState, nextState := clusterState(prev.Status), clusterState(next.Status)
+	if prevState != nextState {
+		transitions = append(transitions, Transition{
+			Time: next.Time,
+			Type: TransitionCluster,
+			From: prevState,
+			To:   nextState,
+		})
+	}
+	prevNodes := nodeStatuses(prev.Status)
+	if next.Status.Agent != nil {
+		for _, node := range next.Status.Agent.Nodes {
+			prevNode, ok := prevNodes[node.AdvertiseIP]
+			if !ok || prevNode.Status == node.Status {
+				continue
+			}
+			transitions = append(transitions, Transition{
+				Time:     next.Time,
+				Type:     TransitionNode,
+				Node:     node.AdvertiseIP,
+				Hostname: node.Hostname,
+				From:     prevNode.Status,
+				To:       node.Status,
+			})
+		}
+	}
+	if prev.Leader != "" && next.Leader != "" && prev.Leader != next.Leader {
+		transitions = append(transitions, Transition{
+			Time: next.Time,
+			Type: TransitionLeader,
+			From: prev.Leader,
+			To:   next.Leader,
+		})
+	}
+	return transitions
+}
+
+// clusterState returns the state of the cluster as displayed by the status
+// command: the cluster is degraded if any component reports degraded status
+func clusterState(status Status) string {
+	if status.Cluster == nil || status.Agent == nil ||
+		status.Agent.GetSystemStatus() != pb.SystemStatus_Running {
+		return ops.SiteStateDegraded
+	}
+	return status.Cluster.State
+}
+
+func nodeStatuses(status Status) map[string]ClusterServer {
+	nodes := make(map[string]ClusterServer)
+	if status.Agent == nil {
+		return nodes
+	}
+	for _, node := range status.Agent.Nodes {
+		nodes[node.AdvertiseIP] = node
+	}
+	return nodes
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"time"
+
+	"github.com/gravitational/gravity/lib/ops"
+
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	check "gopkg.in/check.v1"
+)
+
+type WatchSuite struct{}
+
+var _ = check.Suite(&WatchSuite{})
+
+func (s *WatchSuite) TestDetectsTransitions(c *check.C) {
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	prev := newSnapshot(pb.SystemStatus_Running, "10.0.0.1", NodeHealthy, NodeHealthy)
+	next := newSnapshot(pb.SystemStatus_Degraded, "10.0.0.2", NodeHealthy, NodeDegraded)
+	next.Time = now
+	// nodes that have just joined are not reported
+	next.Status.Agent.Nodes = append(next.Status.Agent.Nodes, ClusterServer{
+		Hostname:    "node-3",
+		AdvertiseIP: "10.0.0.3",
+		Status:      NodeHealthy,
+	})
+
+	transitions := Transitions(prev, next)
+	c.Assert(transitions, check.DeepEquals, []Transition{
+		{Time: now, Type: TransitionCluster, From: ops.SiteStateActive, To: ops.SiteStateDegraded},
+		{Time: now, Type: TransitionNode, Node: "10.0.0.2", Hostname: "node-2", From: NodeHealthy, To: NodeDegraded},
+		{Time: now, Type: TransitionLeader, From: "10.0.0.1", To: "10.0.0.2"},
+	})
+	for _, transition := range transitions {
+		c.Assert(transition.IsDegradation(), check.Equals, true, check.Commentf("%v", transition))
+	}
+
+	recovered := Transitions(next, prev)
+	c.Assert(recovered[0].IsDegradation(), check.Equals, false)
+	c.Assert(recovered[1].IsDegradation(), check.Equals, false)
+	c.Assert(recovered[1].String(), check.Equals, "node node-2 (10.0.0.2) is healthy (was degraded)")
+}
+
+func (s *WatchSuite) TestNoTransitions(c *check.C) {
+	snapshot := newSnapshot(pb.SystemStatus_Running, "10.0.0.1", NodeHealthy, NodeHealthy)
+	c.Assert(Transitions(snapshot, snapshot), check.HasLen, 0)
+
+	// unknown leader is not reported as a change
+	unknown := snapshot
+	unknown.Leader = ""
+	c.Assert(Transitions(snapshot, unknown), check.HasLen, 0)
+}
+
+func newSnapshot(systemStatus pb.SystemStatus_Type, leader string, node1, node2 string) Snapshot {
+	return Snapshot{
+		Status: Status{
+			Cluster: &Cluster{State: ops.SiteStateActive},
+			Agent: &Agent{
+				SystemStatus: SystemStatus(systemStatus),
+				Nodes: []ClusterServer{
+					{Hostname: "node-1", AdvertiseIP: "10.0.0.1", Status: node1},
+					{Hostname: "node-2", AdvertiseIP: "10.0.0.2", Status: node2},
+				},
+			},
+		},
+		Leader: leader,
+	}
+}
@@ -0,0 +1,285 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// ServiceActive is the state of a running system service
+	ServiceActive = "active"
+	// ServiceFailed is the state of a system service that failed to restart
+	ServiceFailed = "failed"
+)
+
+// ServiceManager queries and restarts system services
+type ServiceManager interface {
+	// State returns the state of the specified service, e.g. active or failed
+	State(ctx context.Context, service string) (string, error)
+	// Restart restarts the specified service
+	Restart(ctx context.Context, service string) error
+}
+
+// WatchdogConfig configures the system service watchdog
+type WatchdogConfig struct {
+	// Node is the advertise address of the node the watchdog is running on
+	Node string
+	// Services lists the monitored services
+	Services []string
+	// Interval is how often the services are checked
+	Interval time.Duration
+	// Window is the time window service restarts are counted in
+	Window time.Duration
+	// MaxRestarts is the number of restarts of a service within the window
+	// after which the watchdog stops restarting it and escalates
+	MaxRestarts int
+	// Manager queries and restarts services.
+	// Defaults to planet services
+	Manager ServiceManager
+	// Record is invoked for each service restart and escalation
+	Record func(Transition)
+	// Escalate is invoked when a service keeps failing after MaxRestarts restarts
+	Escalate func(ctx context.Context, reason string) error
+	// Recover is invoked when all services that caused escalation are active again
+	Recover func(ctx context.Context) error
+	// Clock is used to mock time in tests
+	Clock clockwork.Clock
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+}
+
+// CheckAndSetDefaults validates the configuration and sets default values
+func (r *WatchdogConfig) CheckAndSetDefaults() error {
+	if r.Node == "" {
+		return trace.BadParameter("node address is required")
+	}
+	if len(r.Services) == 0 {
+		r.Services = defaults.WatchdogServices
+	}
+	if r.Interval == 0 {
+		r.Interval = defaults.WatchdogInterval
+	}
+	if r.Window == 0 {
+		r.Window = defaults.WatchdogRestartWindow
+	}
+	if r.MaxRestarts == 0 {
+		r.MaxRestarts = defaults.WatchdogMaxRestarts
+	}
+	if r.MaxRestarts < 0 {
+		return trace.BadParameter("max restarts can not be negative")
+	}
+	if r.FieldLogger == nil {
+		r.FieldLogger = logrus.WithField(trace.Component, "watchdog")
+	}
+	if r.Manager == nil {
+		r.Manager = PlanetServices(r.FieldLogger)
+	}
+	if r.Clock == nil {
+		r.Clock = clockwork.NewRealClock()
+	}
+	return nil
+}
+
+// NewWatchdog returns a new watchdog that restarts failed system services
+// and escalates if a service keeps failing
+func NewWatchdog(config WatchdogConfig) (*Watchdog, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &Watchdog{
+		WatchdogConfig: config,
+		restarts:       make(map[string][]time.Time),
+		escalated:      make(map[string]struct{}),
+	}, nil
+}
+
+// Watchdog monitors system services and restarts the failed ones
+type Watchdog struct {
+	WatchdogConfig
+	// restarts maps service name to the times it has been restarted
+	restarts map[string][]time.Time
+	// escalated is the set of services the watchdog gave up on
+	escalated map[string]struct{}
+}
+
+// Run checks the services periodically until the context is canceled
+func (r *Watchdog) Run(ctx context.Context) error {
+	utils.RunPeriodically(ctx, r.Interval, r.FieldLogger, "check system services", r.Check)
+	return nil
+}
+
+// Check restarts the services that are not active.
+//
+// A service that has been restarted MaxRestarts times within the window
+// is not restarted anymore and the watchdog escalates instead.
+// Once all such services are active again, the watchdog recovers
+func (r *Watchdog) Check(ctx context.Context) error {
+	var errors []error
+	for _, service := range r.Services {
+		if err := r.checkService(ctx, service); err != nil {
+			errors = append(errors, err)
+		}
+	}
+	return trace.NewAggregate(errors...)
+}
+
+func (r *Watchdog) checkService(ctx context.Context, service string) error {
+	state, err := r.Manager.State(ctx, service)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, escalated := r.escalated[service]
+	if state == ServiceActive {
+		if escalated {
+			return trace.Wrap(r.recover(ctx, service))
+		}
+		return nil
+	}
+	if escalated {
+		return nil
+	}
+	now := r.Clock.Now().UTC()
+	restarts := r.recentRestarts(service, now)
+	if len(restarts) >= r.MaxRestarts {
+		return trace.Wrap(r.escalate(ctx, service, now))
+	}
+	r.Infof("Restarting service %v which is %v.", service, state)
+	restartErr := r.Manager.Restart(ctx, service)
+	r.restarts[service] = append(restarts, now)
+	transition := Transition{
+		Time:    now,
+		Type:    TransitionService,
+		Node:    r.Node,
+		Service: service,
+		From:    state,
+		To:      ServiceActive,
+	}
+	if restartErr != nil {
+		transition.To = ServiceFailed
+	}
+	r.record(transition)
+	return trace.Wrap(restartErr)
+}
+
+// recentRestarts returns the restarts of the service within the window
+func (r *Watchdog) recentRestarts(service string, now time.Time) (restarts []time.Time) {
+	for _, restart := range r.restarts[service] {
+		if now.Sub(restart) < r.Window {
+			restarts = append(restarts, restart)
+		}
+	}
+	return restarts
+}
+
+func (r *Watchdog) escalate(ctx context.Context, service string, now time.Time) error {
+	reason := fmt.Sprintf("service %v on node %v failed after %v restarts in %v",
+		service, r.Node, r.MaxRestarts, r.Window)
+	r.Warnf("Giving up on %v.", reason)
+	if r.Escalate != nil {
+		if err := r.Escalate(ctx, reason); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	r.escalated[service] = struct{}{}
+	if len(r.escalated) > 1 {
+		// the cluster has already been degraded by another service
+		return nil
+	}
+	r.record(Transition{
+		Time: now,
+		Type: TransitionCluster,
+		From: ops.SiteStateActive,
+		To:   ops.SiteStateDegraded,
+	})
+	return nil
+}
+
+func (r *Watchdog) recover(ctx context.Context, service string) error {
+	delete(r.escalated, service)
+	delete(r.restarts, service)
+	if len(r.escalated) != 0 {
+		return nil
+	}
+	r.Infof("Service %v has recovered.", service)
+	if r.Recover != nil {
+		if err := r.Recover(ctx); err != nil {
+			// retry on the next check
+			r.escalated[service] = struct{}{}
+			return trace.Wrap(err)
+		}
+	}
+	r.record(Transition{
+		Time: r.Clock.Now().UTC(),
+		Type: TransitionCluster,
+		From: ops.SiteStateDegraded,
+		To:   ops.SiteStateActive,
+	})
+	return nil
+}
+
+func (r *Watchdog) record(transition Transition) {
+	if r.Record != nil {
+		r.Record(transition)
+	}
+}
+
+// PlanetServices returns the service manager for the services
+// running inside planet on this node
+func PlanetServices(logger logrus.FieldLogger) ServiceManager {
+	return &planetServices{FieldLogger: logger}
+}
+
+type planetServices struct {
+	logrus.FieldLogger
+}
+
+// State returns the state of the specified planet service
+func (r *planetServices) State(ctx context.Context, service string) (string, error) {
+	out, err := utils.RunCommand(ctx, r.FieldLogger,
+		utils.PlanetCommandArgs(defaults.SystemctlBin, "is-active", service)...)
+	// is-active exits with a non-zero code for inactive services
+	// but still outputs the state
+	if state := strings.TrimSpace(string(out)); state != "" {
+		return state, nil
+	}
+	if err != nil {
+		return "", trace.Wrap(err, "failed to query state of %v", service)
+	}
+	return "", trace.NotFound("no state reported for %v", service)
+}
+
+// Restart restarts the specified planet service
+func (r *planetServices) Restart(ctx context.Context, service string) error {
+	out, err := utils.RunCommand(ctx, r.FieldLogger,
+		utils.PlanetCommandArgs(defaults.SystemctlBin, "restart", service)...)
+	if err != nil {
+		return trace.Wrap(err, "failed to restart %v: %s", service, out)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"context"
+	"time"
+
+	"github.com/gravitational/gravity/lib/ops"
+
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+	check "gopkg.in/check.v1"
+)
+
+type WatchdogSuite struct {
+	clock       clockwork.FakeClock
+	services    *fakeServices
+	transitions []Transition
+	escalations []string
+	recoveries  int
+	watchdog    *Watchdog
+}
+
+var _ = check.Suite(&WatchdogSuite{})
+
+func (s *WatchdogSuite) SetUpTest(c *check.C) {
+	s.clock = clockwork.NewFakeClock()
+	s.services = &fakeServices{states: map[string]string{
+		"etcd.service":         ServiceActive,
+		"kube-kubelet.service": ServiceActive,
+	}}
+	s.transitions = nil
+	s.escalations = nil
+	s.recoveries = 0
+	var err error
+	s.watchdog, err = NewWatchdog(WatchdogConfig{
+		Node:        "10.0.0.1",
+		Services:    []string{"etcd.service", "kube-kubelet.service"},
+		Window:      10 * time.Minute,
+		MaxRestarts: 2,
+		Manager:     s.services,
+		Record: func(transition Transition) {
+			s.transitions = append(s.transitions, transition)
+		},
+		Escalate: func(ctx context.Context, reason string) error {
+			s.escalations = append(s.escalations, reason)
+			return nil
+		},
+		Recover: func(ctx context.Context) error {
+			s.recoveries++
+			return nil
+		},
+		Clock: s.clock,
+	})
+	c.Assert(err, check.IsNil)
+}
+
+func (s *WatchdogSuite) TestRestartsFailedServices(c *check.C) {
+	s.services.states["etcd.service"] = "failed"
+
+	c.Assert(s.watchdog.Check(context.TODO()), check.IsNil)
+	c.Assert(s.services.restarted, check.DeepEquals, []string{"etcd.service"})
+	c.Assert(s.transitions, check.DeepEquals, []Transition{{
+		Time:    s.clock.Now().UTC(),
+		Type:    TransitionService,
+		Node:    "10.0.0.1",
+		Service: "etcd.service",
+		From:    "failed",
+		To:      ServiceActive,
+	}})
+	c.Assert(s.transitions[0].IsDegradation(), check.Equals, false)
+	c.Assert(s.transitions[0].String(), check.Equals,
+		"service etcd.service on node 10.0.0.1 is active (was failed)")
+	c.Assert(s.escalations, check.HasLen, 0)
+}
+
+func (s *WatchdogSuite) TestReportsFailedRestarts(c *check.C) {
+	s.services.states["etcd.service"] = "inactive"
+	s.services.restartErr = trace.BadParameter("restart failed")
+
+	c.Assert(s.watchdog.Check(context.TODO()), check.NotNil)
+	c.Assert(s.transitions, check.HasLen, 1)
+	c.Assert(s.transitions[0].To, check.Equals, ServiceFailed)
+	c.Assert(s.transitions[0].IsDegradation(), check.Equals, true)
+}
+
+func (s *WatchdogSuite) TestEscalatesAndRecovers(c *check.C) {
+	s.services.states["etcd.service"] = "failed"
+	for i := 0; i < 3; i++ {
+		c.Assert(s.watchdog.Check(context.TODO()), check.IsNil)
+		s.clock.Advance(time.Minute)
+	}
+	c.Assert(s.services.restarted, check.HasLen, 2)
+	c.Assert(s.escalations, check.DeepEquals, []string{
+		"service etcd.service on node 10.0.0.1 failed after 2 restarts in 10m0s",
+	})
+	c.Assert(s.transitions[2].Type, check.Equals, TransitionCluster)
+	c.Assert(s.transitions[2].To, check.Equals, ops.SiteStateDegraded)
+
+	// the watchdog gives up on the escalated service
+	c.Assert(s.watchdog.Check(context.TODO()), check.IsNil)
+	c.Assert(s.services.restarted, check.HasLen, 2)
+	c.Assert(s.escalations, check.HasLen, 1)
+
+	s.services.states["etcd.service"] = ServiceActive
+	c.Assert(s.watchdog.Check(context.TODO()), check.IsNil)
+	c.Assert(s.recoveries, check.Equals, 1)
+	c.Assert(s.transitions[3].Type, check.Equals, TransitionCluster)
+	c.Assert(s.transitions[3].To, check.Equals, ops.SiteStateActive)
+}
+
+func (s *WatchdogSuite) TestExpiresRestarts(c *check.C) {
+	s.services.states["etcd.service"] = "failed"
+	for i := 0; i < 4; i++ {
+		c.Assert(s.watchdog.Check(context.TODO()), check.IsNil)
+		s.clock.Advance(6 * time.Minute)
+	}
+	c.Assert(s.services.restarted, check.HasLen, 4)
+	c.Assert(s.escalations, check.HasLen, 0)
+}
+
+type fakeServices struct {
+	states     map[string]string
+	restarted  []string
+	restartErr error
+}
+
+func (r *fakeServices) State(ctx context.Context, service string) (string, error) {
+	return r.states[service], nil
+}
+
+func (r *fakeServices) Restart(ctx context.Context, service string) error {
+	r.restarted = append(r.restarted, service)
+	return r.restartErr
+}
@@ -0,0 +1,237 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/gravitational/gravity/lib/defaults"
+
+	teleservices "github.com/gravitational/teleport/lib/services"
+	teleutils "github.com/gravitational/teleport/lib/utils"
+	"github.com/gravitational/trace"
+)
+
+// AppOverlay describes local customizations of a bundled system
+// application, such as monitoring or logging, that are applied on top of
+// the application after it has been installed or upgraded
+type AppOverlay interface {
+	// Resource provides common resource methods
+	teleservices.Resource
+	// CheckAndSetDefaults verifies that the object is valid
+	CheckAndSetDefaults() error
+	// GetResources returns Kubernetes resources to create or update
+	GetResources() string
+	// GetPatches returns patches of the application objects
+	GetPatches() []AppOverlayPatch
+}
+
+// NewAppOverlay returns a new overlay resource for the specified application
+func NewAppOverlay(appName string, spec AppOverlaySpecV2) AppOverlay {
+	return &AppOverlayV2{
+		Kind:    KindAppOverlay,
+		Version: teleservices.V2,
+		Metadata: teleservices.Metadata{
+			Name:      appName,
+			Namespace: defaults.Namespace,
+		},
+		Spec: spec,
+	}
+}
+
+// AppOverlayV2 defines the customizations of a bundled system application
+type AppOverlayV2 struct {
+	// Metadata is resource metadata, the name is the name of the application
+	teleservices.Metadata `json:"metadata"`
+	// Kind is a resource kind
+	Kind string `json:"kind"`
+	// Version is a resource version
+	Version string `json:"version"`
+	// Spec defines the application customizations
+	Spec AppOverlaySpecV2 `json:"spec"`
+}
+
+// GetResources returns Kubernetes resources to create or update
+func (r *AppOverlayV2) GetResources() string {
+	return r.Spec.Resources
+}
+
+// GetPatches returns patches of the application objects
+func (r *AppOverlayV2) GetPatches() []AppOverlayPatch {
+	return r.Spec.Patches
+}
+
+// CheckAndSetDefaults checks validity of all parameters and sets defaults
+func (r *AppOverlayV2) CheckAndSetDefaults() error {
+	if err := r.Metadata.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	if !isOverlayApp(r.Metadata.Name) {
+		return trace.BadParameter("overlays are supported for %v, got %q",
+			OverlayApps, r.Metadata.Name)
+	}
+	if r.Spec.Resources == "" && len(r.Spec.Patches) == 0 {
+		return trace.BadParameter("overlay %q has neither resources nor patches",
+			r.Metadata.Name)
+	}
+	for i := range r.Spec.Patches {
+		if err := r.Spec.Patches[i].CheckAndSetDefaults(); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// AppOverlaySpecV2 defines the customizations of a bundled system application
+type AppOverlaySpecV2 struct {
+	// Resources lists Kubernetes resources in YAML format to create or
+	// update after the application has been installed or upgraded,
+	// e.g. config maps with additional Grafana dashboards
+	Resources string `json:"resources,omitempty"`
+	// Patches lists patches of the objects created by the application
+	Patches []AppOverlayPatch `json:"patches,omitempty"`
+}
+
+// AppOverlayPatch describes a patch of a single Kubernetes object
+type AppOverlayPatch struct {
+	// Kind is the kind of the patched object
+	Kind string `json:"kind"`
+	// Namespace is the namespace of the patched object
+	Namespace string `json:"namespace,omitempty"`
+	// Name is the name of the patched object
+	Name string `json:"name"`
+	// Type is the patch type: strategic (default), merge or json
+	Type string `json:"type,omitempty"`
+	// Patch is the patch in YAML or JSON format
+	Patch string `json:"patch"`
+}
+
+// CheckAndSetDefaults checks validity of the patch and sets defaults
+func (r *AppOverlayPatch) CheckAndSetDefaults() error {
+	if r.Kind == "" || r.Name == "" {
+		return trace.BadParameter("patch requires both kind and name")
+	}
+	if r.Patch == "" {
+		return trace.BadParameter("empty patch for %v %v", r.Kind, r.Name)
+	}
+	if r.Namespace == "" {
+		r.Namespace = defaults.KubeSystemNamespace
+	}
+	if r.Type == "" {
+		r.Type = AppOverlayPatchStrategic
+	}
+	switch r.Type {
+	case AppOverlayPatchStrategic, AppOverlayPatchMerge, AppOverlayPatchJSON:
+	default:
+		return trace.BadParameter("unsupported patch type %q for %v %v",
+			r.Type, r.Kind, r.Name)
+	}
+	return nil
+}
+
+// String returns a textual representation of the patch target
+func (r AppOverlayPatch) String() string {
+	return fmt.Sprintf("%v %v/%v", r.Kind, r.Namespace, r.Name)
+}
+
+const (
+	// AppOverlayPatchStrategic is the strategic merge patch type
+	AppOverlayPatchStrategic = "strategic"
+	// AppOverlayPatchMerge is the JSON merge patch type
+	AppOverlayPatchMerge = "merge"
+	// AppOverlayPatchJSON is the JSON patch type
+	AppOverlayPatchJSON = "json"
+)
+
+// OverlayApps lists the bundled applications that can be customized with overlays
+var OverlayApps = []string{defaults.MonitoringAppName, defaults.LoggingAppName}
+
+func isOverlayApp(name string) bool {
+	for _, app := range OverlayApps {
+		if app == name {
+			return true
+		}
+	}
+	return false
+}
+
+// UnmarshalAppOverlay unmarshals application overlay from JSON
+func UnmarshalAppOverlay(data []byte) (AppOverlay, error) {
+	if len(data) == 0 {
+		return nil, trace.BadParameter("empty application overlay")
+	}
+
+	jsonData, err := teleutils.ToJSON(data)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var hdr teleservices.ResourceHeader
+	err = json.Unmarshal(jsonData, &hdr)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	switch hdr.Version {
+	case teleservices.V2:
+		var overlay AppOverlayV2
+		err := teleutils.UnmarshalWithSchema(GetAppOverlaySchema(), &overlay, jsonData)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		overlay.Metadata.CheckAndSetDefaults()
+		return &overlay, nil
+	}
+	return nil, trace.BadParameter(
+		"%v resource version %q is not supported", KindAppOverlay, hdr.Version)
+}
+
+// MarshalAppOverlay marshals application overlay into JSON
+func MarshalAppOverlay(overlay AppOverlay, opts ...teleservices.MarshalOption) ([]byte, error) {
+	return json.Marshal(overlay)
+}
+
+// AppOverlaySpecV2Schema is JSON schema for application overlay
+const AppOverlaySpecV2Schema = `{
+  "type": "object",
+  "additionalProperties": false,
+  "properties": {
+    "resources": {"type": "string"},
+    "patches": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "additionalProperties": false,
+        "required": ["kind", "name", "patch"],
+        "properties": {
+          "kind": {"type": "string"},
+          "namespace": {"type": "string"},
+          "name": {"type": "string"},
+          "type": {"type": "string"},
+          "patch": {"type": "string"}
+        }
+      }
+    }
+  }
+}`
+
+// GetAppOverlaySchema returns application overlay schema for version V2
+func GetAppOverlaySchema() string {
+	return fmt.Sprintf(teleservices.V2SchemaTemplate, MetadataSchema,
+		AppOverlaySpecV2Schema, "")
+}
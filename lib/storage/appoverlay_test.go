@@ -0,0 +1,75 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"github.com/gravitational/gravity/lib/compare"
+
+	check "gopkg.in/check.v1"
+)
+
+type AppOverlaySuite struct{}
+
+var _ = check.Suite(&AppOverlaySuite{})
+
+func (s *AppOverlaySuite) TestResourceParsing(c *check.C) {
+	spec := `kind: appoverlay
+version: v2
+metadata:
+  name: monitoring-app
+spec:
+  resources: |
+    apiVersion: v1
+    kind: ConfigMap
+    metadata:
+      name: dashboards
+  patches:
+  - kind: ConfigMap
+    namespace: monitoring
+    name: influxdb
+    patch: |
+      data:
+        retention: 30d
+`
+	overlay, err := UnmarshalAppOverlay([]byte(spec))
+	c.Assert(err, check.IsNil)
+	c.Assert(overlay.CheckAndSetDefaults(), check.IsNil)
+	expected := NewAppOverlay("monitoring-app", AppOverlaySpecV2{
+		Resources: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: dashboards\n",
+		Patches: []AppOverlayPatch{{
+			Kind:      "ConfigMap",
+			Namespace: "monitoring",
+			Name:      "influxdb",
+			Type:      AppOverlayPatchStrategic,
+			Patch:     "data:\n  retention: 30d\n",
+		}},
+	})
+	c.Assert(overlay, compare.DeepEquals, expected)
+}
+
+func (s *AppOverlaySuite) TestValidatesOverlay(c *check.C) {
+	overlay := NewAppOverlay("monitoring-app", AppOverlaySpecV2{})
+	c.Assert(overlay.CheckAndSetDefaults(), check.NotNil)
+
+	overlay = NewAppOverlay("site", AppOverlaySpecV2{Resources: "kind: ConfigMap"})
+	c.Assert(overlay.CheckAndSetDefaults(), check.NotNil)
+
+	overlay = NewAppOverlay("logging-app", AppOverlaySpecV2{
+		Patches: []AppOverlayPatch{{Kind: "Deployment", Name: "log-collector", Type: "apply", Patch: "{}"}},
+	})
+	c.Assert(overlay.CheckAndSetDefaults(), check.NotNil)
+}
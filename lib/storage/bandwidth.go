@@ -0,0 +1,340 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+
+	teleservices "github.com/gravitational/teleport/lib/services"
+	teleutils "github.com/gravitational/teleport/lib/utils"
+	"github.com/gravitational/trace"
+)
+
+const (
+	// BandwidthModeNormal is the default bandwidth mode
+	BandwidthModeNormal = "normal"
+	// BandwidthModeLow reduces the traffic between the cluster and the
+	// Ops Center for clusters on slow or metered links
+	BandwidthModeLow = "low"
+)
+
+// BandwidthProfile controls how much traffic the cluster exchanges
+// with the Ops Center
+type BandwidthProfile interface {
+	// Resource provides common resource methods
+	teleservices.Resource
+	// CheckAndSetDefaults verifies that the object is valid
+	CheckAndSetDefaults() error
+	// GetMode returns the bandwidth mode
+	GetMode() string
+	// IsLow returns true if the low-bandwidth mode is on
+	IsLow() bool
+	// GetHeartbeatInterval returns how often the cluster refreshes
+	// the status it reports to the Ops Center
+	GetHeartbeatInterval() time.Duration
+	// GetInventoryInterval returns how often the Ops Center collects
+	// the cluster inventory
+	GetInventoryInterval() time.Duration
+	// GetEventBatchInterval returns how long cluster events are buffered
+	// before they are sent to the Ops Center. Zero means events are not batched
+	GetEventBatchInterval() time.Duration
+	// GetCompressInventory returns true if the inventory should be sent
+	// as compressed deltas against the previously sent inventory
+	GetCompressInventory() bool
+	// GetPackagePullWindow returns the daily window non-critical package
+	// pulls are deferred to. Nil means packages can be pulled any time
+	GetPackagePullWindow() *TimeWindow
+	// CanPullPackages returns true if non-critical packages can be pulled
+	// at the specified time
+	CanPullPackages(now time.Time) bool
+}
+
+// NewBandwidthProfile returns a new bandwidth profile resource
+func NewBandwidthProfile(spec BandwidthProfileSpecV1) BandwidthProfile {
+	return &BandwidthProfileV1{
+		Kind:    KindBandwidthProfile,
+		Version: teleservices.V1,
+		Metadata: teleservices.Metadata{
+			Name:      KindBandwidthProfile,
+			Namespace: defaults.Namespace,
+		},
+		Spec: spec,
+	}
+}
+
+// DefaultBandwidthProfile returns the bandwidth profile used when
+// the cluster has none configured
+func DefaultBandwidthProfile() BandwidthProfile {
+	profile := NewBandwidthProfile(BandwidthProfileSpecV1{
+		Mode: BandwidthModeNormal,
+	})
+	profile.CheckAndSetDefaults()
+	return profile
+}
+
+// BandwidthProfileV1 defines the cluster bandwidth profile
+type BandwidthProfileV1 struct {
+	// Metadata is resource metadata
+	teleservices.Metadata `json:"metadata"`
+	// Kind is a resource kind
+	Kind string `json:"kind"`
+	// Version is a resource version
+	Version string `json:"version"`
+	// Spec defines the bandwidth profile
+	Spec BandwidthProfileSpecV1 `json:"spec"`
+}
+
+// BandwidthProfileSpecV1 defines the bandwidth profile
+type BandwidthProfileSpecV1 struct {
+	// Mode is the bandwidth mode, either normal or low
+	Mode string `json:"mode,omitempty"`
+	// HeartbeatInterval is how often the cluster refreshes the status
+	// it reports to the Ops Center
+	HeartbeatInterval *teleservices.Duration `json:"heartbeat_interval,omitempty"`
+	// InventoryInterval is how often the Ops Center collects the cluster inventory
+	InventoryInterval *teleservices.Duration `json:"inventory_interval,omitempty"`
+	// EventBatchInterval is how long cluster events are buffered before
+	// they are sent to the Ops Center
+	EventBatchInterval *teleservices.Duration `json:"event_batch_interval,omitempty"`
+	// CompressInventory enables sending the inventory as compressed deltas
+	CompressInventory *bool `json:"compress_inventory,omitempty"`
+	// PackagePullWindow is the daily UTC time window, e.g. "01:00-05:00",
+	// non-critical package pulls are deferred to
+	PackagePullWindow string `json:"package_pull_window,omitempty"`
+}
+
+// GetMode returns the bandwidth mode
+func (r *BandwidthProfileV1) GetMode() string {
+	return r.Spec.Mode
+}
+
+// IsLow returns true if the low-bandwidth mode is on
+func (r *BandwidthProfileV1) IsLow() bool {
+	return r.Spec.Mode == BandwidthModeLow
+}
+
+// GetHeartbeatInterval returns how often the cluster refreshes
+// the status it reports to the Ops Center
+func (r *BandwidthProfileV1) GetHeartbeatInterval() time.Duration {
+	return durationValue(r.Spec.HeartbeatInterval)
+}
+
+// GetInventoryInterval returns how often the Ops Center collects
+// the cluster inventory
+func (r *BandwidthProfileV1) GetInventoryInterval() time.Duration {
+	return durationValue(r.Spec.InventoryInterval)
+}
+
+// GetEventBatchInterval returns how long cluster events are buffered
+// before they are sent to the Ops Center
+func (r *BandwidthProfileV1) GetEventBatchInterval() time.Duration {
+	return durationValue(r.Spec.EventBatchInterval)
+}
+
+// GetCompressInventory returns true if the inventory should be sent
+// as compressed deltas
+func (r *BandwidthProfileV1) GetCompressInventory() bool {
+	return r.Spec.CompressInventory != nil && *r.Spec.CompressInventory
+}
+
+// GetPackagePullWindow returns the daily window non-critical package
+// pulls are deferred to
+func (r *BandwidthProfileV1) GetPackagePullWindow() *TimeWindow {
+	if r.Spec.PackagePullWindow == "" {
+		return nil
+	}
+	window, err := ParseTimeWindow(r.Spec.PackagePullWindow)
+	if err != nil {
+		return nil
+	}
+	return window
+}
+
+// CanPullPackages returns true if non-critical packages can be pulled
+// at the specified time
+func (r *BandwidthProfileV1) CanPullPackages(now time.Time) bool {
+	window := r.GetPackagePullWindow()
+	return window == nil || window.Contains(now)
+}
+
+// CheckAndSetDefaults checks validity of all parameters and sets defaults
+func (r *BandwidthProfileV1) CheckAndSetDefaults() error {
+	if r.Metadata.Name == "" {
+		r.Metadata.Name = KindBandwidthProfile
+	}
+	if err := r.Metadata.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	if r.Spec.Mode == "" {
+		r.Spec.Mode = BandwidthModeNormal
+	}
+	heartbeat, inventory, events, compress := defaults.SiteStatusCheckInterval,
+		defaults.InventorySyncInterval, time.Duration(0), false
+	switch r.Spec.Mode {
+	case BandwidthModeNormal:
+	case BandwidthModeLow:
+		heartbeat, inventory, events, compress = defaults.LowBandwidthHeartbeatInterval,
+			defaults.LowBandwidthInventoryInterval, defaults.LowBandwidthEventBatchInterval, true
+	default:
+		return trace.BadParameter("unsupported bandwidth mode %q, supported are: %v",
+			r.Spec.Mode, []string{BandwidthModeNormal, BandwidthModeLow})
+	}
+	for _, interval := range []*teleservices.Duration{r.Spec.HeartbeatInterval,
+		r.Spec.InventoryInterval, r.Spec.EventBatchInterval} {
+		if interval != nil && interval.Value() < 0 {
+			return trace.BadParameter("interval %v should not be negative", interval.Value())
+		}
+	}
+	if r.Spec.HeartbeatInterval != nil && r.Spec.HeartbeatInterval.Value() < defaults.SiteStatusCheckInterval {
+		return trace.BadParameter("heartbeat interval should be at least %v", defaults.SiteStatusCheckInterval)
+	}
+	if r.Spec.HeartbeatInterval == nil {
+		value := teleservices.NewDuration(heartbeat)
+		r.Spec.HeartbeatInterval = &value
+	}
+	if r.Spec.InventoryInterval == nil {
+		value := teleservices.NewDuration(inventory)
+		r.Spec.InventoryInterval = &value
+	}
+	if r.Spec.EventBatchInterval == nil {
+		value := teleservices.NewDuration(events)
+		r.Spec.EventBatchInterval = &value
+	}
+	if r.Spec.CompressInventory == nil {
+		r.Spec.CompressInventory = &compress
+	}
+	if r.Spec.PackagePullWindow != "" {
+		if _, err := ParseTimeWindow(r.Spec.PackagePullWindow); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+func durationValue(d *teleservices.Duration) time.Duration {
+	if d == nil {
+		return 0
+	}
+	return d.Value()
+}
+
+// TimeWindow is a daily time window in UTC.
+// The window can span midnight, e.g. 22:00-04:00
+type TimeWindow struct {
+	// Start is the offset of the window start from midnight
+	Start time.Duration
+	// End is the offset of the window end from midnight
+	End time.Duration
+}
+
+// ParseTimeWindow parses the time window in the HH:MM-HH:MM format
+func ParseTimeWindow(value string) (*TimeWindow, error) {
+	parts := strings.Split(value, "-")
+	if len(parts) != 2 {
+		return nil, trace.BadParameter("time window %q should be in the HH:MM-HH:MM format", value)
+	}
+	var offsets []time.Duration
+	for _, part := range parts {
+		t, err := time.Parse("15:04", strings.TrimSpace(part))
+		if err != nil {
+			return nil, trace.BadParameter("time window %q should be in the HH:MM-HH:MM format", value)
+		}
+		offsets = append(offsets, time.Duration(t.Hour())*time.Hour+time.Duration(t.Minute())*time.Minute)
+	}
+	if offsets[0] == offsets[1] {
+		return nil, trace.BadParameter("time window %q is empty", value)
+	}
+	return &TimeWindow{Start: offsets[0], End: offsets[1]}, nil
+}
+
+// Contains returns true if the specified time falls within the window
+func (r TimeWindow) Contains(t time.Time) bool {
+	t = t.UTC()
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	if r.Start < r.End {
+		return offset >= r.Start && offset < r.End
+	}
+	return offset >= r.Start || offset < r.End
+}
+
+// String returns the window in the HH:MM-HH:MM format
+func (r TimeWindow) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d",
+		int(r.Start.Hours()), int(r.Start.Minutes())%60,
+		int(r.End.Hours()), int(r.End.Minutes())%60)
+}
+
+// UnmarshalBandwidthProfile unmarshals bandwidth profile from JSON
+func UnmarshalBandwidthProfile(data []byte) (BandwidthProfile, error) {
+	if len(data) == 0 {
+		return nil, trace.BadParameter("empty bandwidth profile")
+	}
+
+	jsonData, err := teleutils.ToJSON(data)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var hdr teleservices.ResourceHeader
+	err = json.Unmarshal(jsonData, &hdr)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	switch hdr.Version {
+	case teleservices.V1:
+		var profile BandwidthProfileV1
+		err := teleutils.UnmarshalWithSchema(GetBandwidthProfileSchema(), &profile, jsonData)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		profile.Metadata.CheckAndSetDefaults()
+		return &profile, nil
+	}
+	return nil, trace.BadParameter(
+		"%v resource version %q is not supported", KindBandwidthProfile, hdr.Version)
+}
+
+// MarshalBandwidthProfile marshals bandwidth profile into JSON
+func MarshalBandwidthProfile(profile BandwidthProfile, opts ...teleservices.MarshalOption) ([]byte, error) {
+	return json.Marshal(profile)
+}
+
+// BandwidthProfileSpecV1Schema is JSON schema for bandwidth profile
+const BandwidthProfileSpecV1Schema = `{
+  "type": "object",
+  "additionalProperties": false,
+  "properties": {
+    "mode": {"type": "string"},
+    "heartbeat_interval": {"type": "string"},
+    "inventory_interval": {"type": "string"},
+    "event_batch_interval": {"type": "string"},
+    "compress_inventory": {"type": "boolean"},
+    "package_pull_window": {"type": "string"}
+  }
+}`
+
+// GetBandwidthProfileSchema returns bandwidth profile schema for version V1
+func GetBandwidthProfileSchema() string {
+	return fmt.Sprintf(teleservices.V2SchemaTemplate, MetadataSchema,
+		BandwidthProfileSpecV1Schema, "")
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+
+	check "gopkg.in/check.v1"
+)
+
+type BandwidthProfileSuite struct{}
+
+var _ = check.Suite(&BandwidthProfileSuite{})
+
+func (s *BandwidthProfileSuite) TestLowBandwidthDefaults(c *check.C) {
+	spec := `kind: bandwidthprofile
+version: v1
+spec:
+  mode: low
+  heartbeat_interval: 30m
+  package_pull_window: "22:00-04:00"
+`
+	profile, err := UnmarshalBandwidthProfile([]byte(spec))
+	c.Assert(err, check.IsNil)
+	c.Assert(profile.CheckAndSetDefaults(), check.IsNil)
+	c.Assert(profile.IsLow(), check.Equals, true)
+	c.Assert(profile.GetHeartbeatInterval(), check.Equals, 30*time.Minute)
+	c.Assert(profile.GetInventoryInterval(), check.Equals, defaults.LowBandwidthInventoryInterval)
+	c.Assert(profile.GetEventBatchInterval(), check.Equals, defaults.LowBandwidthEventBatchInterval)
+	c.Assert(profile.GetCompressInventory(), check.Equals, true)
+	c.Assert(profile.GetPackagePullWindow().String(), check.Equals, "22:00-04:00")
+
+	day := time.Date(2019, time.March, 1, 0, 0, 0, 0, time.UTC)
+	c.Assert(profile.CanPullPackages(day.Add(23*time.Hour)), check.Equals, true)
+	c.Assert(profile.CanPullPackages(day.Add(3*time.Hour)), check.Equals, true)
+	c.Assert(profile.CanPullPackages(day.Add(12*time.Hour)), check.Equals, false)
+}
+
+func (s *BandwidthProfileSuite) TestNormalDefaults(c *check.C) {
+	profile := DefaultBandwidthProfile()
+	c.Assert(profile.IsLow(), check.Equals, false)
+	c.Assert(profile.GetHeartbeatInterval(), check.Equals, defaults.SiteStatusCheckInterval)
+	c.Assert(profile.GetEventBatchInterval(), check.Equals, time.Duration(0))
+	c.Assert(profile.GetCompressInventory(), check.Equals, false)
+	c.Assert(profile.CanPullPackages(time.Now()), check.Equals, true)
+}
+
+func (s *BandwidthProfileSuite) TestValidatesProfile(c *check.C) {
+	for _, spec := range []BandwidthProfileSpecV1{
+		{Mode: "slow"},
+		{Mode: BandwidthModeLow, PackagePullWindow: "1am-5am"},
+		{Mode: BandwidthModeLow, PackagePullWindow: "01:00-01:00"},
+	} {
+		c.Assert(NewBandwidthProfile(spec).CheckAndSetDefaults(), check.NotNil,
+			check.Commentf("expected %#v to be rejected", spec))
+	}
+}
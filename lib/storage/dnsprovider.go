@@ -0,0 +1,314 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+
+	teleservices "github.com/gravitational/teleport/lib/services"
+	teleutils "github.com/gravitational/teleport/lib/utils"
+	"github.com/gravitational/trace"
+)
+
+const (
+	// DNSProviderRoute53 publishes records to an AWS Route 53 hosted zone
+	DNSProviderRoute53 = "route53"
+	// DNSProviderZoneTransfer serves records as a zone that secondary
+	// DNS servers (e.g. CoreDNS with secondary plugin) transfer via AXFR
+	DNSProviderZoneTransfer = "zonetransfer"
+
+	// DNSTargetCluster resolves the record to the cluster master nodes
+	// that serve the cluster (ops) endpoint
+	DNSTargetCluster = "cluster"
+	// DNSTargetIngress resolves the record to the nodes that run
+	// application ingress
+	DNSTargetIngress = "ingress"
+)
+
+// DNSProvider describes an external DNS provider cluster endpoints
+// are published to
+type DNSProvider interface {
+	// Resource provides common resource methods
+	teleservices.Resource
+	// CheckAndSetDefaults verifies that the object is valid
+	CheckAndSetDefaults() error
+	// GetType returns the provider type
+	GetType() string
+	// GetZone returns the fully qualified name of the DNS zone
+	GetZone() string
+	// GetTTL returns the TTL of published records
+	GetTTL() time.Duration
+	// GetRecords returns the records to publish
+	GetRecords() []DNSRecordSpec
+	// GetRoute53 returns Route 53 provider settings
+	GetRoute53() *Route53Spec
+	// GetZoneTransfer returns zone transfer provider settings
+	GetZoneTransfer() *ZoneTransferSpec
+}
+
+// NewDNSProvider returns a new DNS provider resource
+func NewDNSProvider(name string, spec DNSProviderSpecV2) DNSProvider {
+	return &DNSProviderV2{
+		Kind:    KindDNSProvider,
+		Version: teleservices.V2,
+		Metadata: teleservices.Metadata{
+			Name:      name,
+			Namespace: defaults.Namespace,
+		},
+		Spec: spec,
+	}
+}
+
+// DNSProviderV2 defines an external DNS provider
+type DNSProviderV2 struct {
+	// Metadata is resource metadata
+	teleservices.Metadata `json:"metadata"`
+	// Kind is a resource kind
+	Kind string `json:"kind"`
+	// Version is a resource version
+	Version string `json:"version"`
+	// Spec defines the DNS provider
+	Spec DNSProviderSpecV2 `json:"spec"`
+}
+
+// GetType returns the provider type
+func (r *DNSProviderV2) GetType() string {
+	return r.Spec.Type
+}
+
+// GetZone returns the fully qualified name of the DNS zone
+func (r *DNSProviderV2) GetZone() string {
+	return r.Spec.Zone
+}
+
+// GetTTL returns the TTL of published records
+func (r *DNSProviderV2) GetTTL() time.Duration {
+	return r.Spec.TTL.Value()
+}
+
+// GetRecords returns the records to publish
+func (r *DNSProviderV2) GetRecords() []DNSRecordSpec {
+	return r.Spec.Records
+}
+
+// GetRoute53 returns Route 53 provider settings
+func (r *DNSProviderV2) GetRoute53() *Route53Spec {
+	return r.Spec.Route53
+}
+
+// GetZoneTransfer returns zone transfer provider settings
+func (r *DNSProviderV2) GetZoneTransfer() *ZoneTransferSpec {
+	return r.Spec.ZoneTransfer
+}
+
+// CheckAndSetDefaults checks validity of all parameters and sets defaults
+func (r *DNSProviderV2) CheckAndSetDefaults() error {
+	if r.Metadata.Name == "" {
+		return trace.BadParameter("missing parameter Name")
+	}
+	if r.Spec.Zone == "" {
+		return trace.BadParameter("missing parameter Zone")
+	}
+	if !strings.HasSuffix(r.Spec.Zone, ".") {
+		r.Spec.Zone = r.Spec.Zone + "."
+	}
+	if r.Spec.TTL.Value() == 0 {
+		r.Spec.TTL = teleservices.NewDuration(defaults.DNSProviderRecordTTL)
+	}
+	if len(r.Spec.Records) == 0 {
+		return trace.BadParameter("at least one record is required")
+	}
+	for _, record := range r.Spec.Records {
+		if err := record.Check(); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	switch r.Spec.Type {
+	case DNSProviderRoute53:
+		if r.Spec.Route53 == nil || r.Spec.Route53.HostedZoneID == "" {
+			return trace.BadParameter("route53 provider requires hosted_zone_id")
+		}
+	case DNSProviderZoneTransfer:
+		if r.Spec.ZoneTransfer == nil {
+			r.Spec.ZoneTransfer = &ZoneTransferSpec{}
+		}
+		if r.Spec.ZoneTransfer.ListenAddr == "" {
+			r.Spec.ZoneTransfer.ListenAddr = defaults.DNSZoneTransferListenAddr
+		}
+	default:
+		return trace.BadParameter("unsupported DNS provider type %q, supported are: %v",
+			r.Spec.Type, []string{DNSProviderRoute53, DNSProviderZoneTransfer})
+	}
+	return nil
+}
+
+// DNSProviderSpecV2 defines an external DNS provider
+type DNSProviderSpecV2 struct {
+	// Type is the provider type: route53 or zonetransfer
+	Type string `json:"type"`
+	// Zone is the DNS zone the records are published to
+	Zone string `json:"zone"`
+	// TTL is the TTL of the published records
+	TTL teleservices.Duration `json:"ttl,omitempty"`
+	// Records lists the records to publish
+	Records []DNSRecordSpec `json:"records"`
+	// Route53 defines Route 53 provider settings
+	Route53 *Route53Spec `json:"route53,omitempty"`
+	// ZoneTransfer defines zone transfer provider settings
+	ZoneTransfer *ZoneTransferSpec `json:"zonetransfer,omitempty"`
+}
+
+// DNSRecordSpec defines a single published record
+type DNSRecordSpec struct {
+	// Name is the record name, relative to the zone or fully qualified
+	Name string `json:"name"`
+	// Target selects the nodes the record resolves to: cluster or ingress
+	Target string `json:"target"`
+	// Role optionally limits ingress records to nodes with the specified role
+	Role string `json:"role,omitempty"`
+}
+
+// Check makes sure the record is valid
+func (r DNSRecordSpec) Check() error {
+	if r.Name == "" {
+		return trace.BadParameter("record name cannot be empty")
+	}
+	switch r.Target {
+	case DNSTargetCluster, DNSTargetIngress:
+	default:
+		return trace.BadParameter("unsupported record target %q, supported are: %v",
+			r.Target, []string{DNSTargetCluster, DNSTargetIngress})
+	}
+	return nil
+}
+
+// FQDN returns the fully qualified name of the record in the specified zone
+func (r DNSRecordSpec) FQDN(zone string) string {
+	if strings.HasSuffix(r.Name, ".") {
+		return r.Name
+	}
+	return fmt.Sprintf("%v.%v", r.Name, zone)
+}
+
+// Route53Spec defines Route 53 provider settings
+type Route53Spec struct {
+	// HostedZoneID is the ID of the hosted zone
+	HostedZoneID string `json:"hosted_zone_id"`
+	// AccessKeyID is the AWS access key ID.
+	// If unspecified, the default AWS credentials chain is used
+	AccessKeyID string `json:"access_key_id,omitempty"`
+	// SecretAccessKey is the AWS secret access key
+	SecretAccessKey string `json:"secret_access_key,omitempty"`
+}
+
+// ZoneTransferSpec defines zone transfer provider settings
+type ZoneTransferSpec struct {
+	// ListenAddr is the address the zone is served on
+	ListenAddr string `json:"listen_addr,omitempty"`
+	// Notify lists addresses of secondary servers notified about zone changes
+	Notify []string `json:"notify,omitempty"`
+}
+
+// UnmarshalDNSProvider unmarshals a DNS provider from JSON
+func UnmarshalDNSProvider(data []byte) (DNSProvider, error) {
+	if len(data) == 0 {
+		return nil, trace.BadParameter("empty DNS provider")
+	}
+
+	jsonData, err := teleutils.ToJSON(data)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var hdr teleservices.ResourceHeader
+	err = json.Unmarshal(jsonData, &hdr)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	switch hdr.Version {
+	case teleservices.V2:
+		var provider DNSProviderV2
+		err := teleutils.UnmarshalWithSchema(GetDNSProviderSchema(), &provider, jsonData)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		provider.Metadata.CheckAndSetDefaults()
+		return &provider, nil
+	}
+	return nil, trace.BadParameter(
+		"%v resource version %q is not supported", KindDNSProvider, hdr.Version)
+}
+
+// MarshalDNSProvider marshals a DNS provider into JSON
+func MarshalDNSProvider(provider DNSProvider, opts ...teleservices.MarshalOption) ([]byte, error) {
+	return json.Marshal(provider)
+}
+
+// DNSProviderSpecV2Schema is JSON schema for a DNS provider
+const DNSProviderSpecV2Schema = `{
+  "type": "object",
+  "additionalProperties": false,
+  "required": ["type", "zone", "records"],
+  "properties": {
+    "type": {"type": "string"},
+    "zone": {"type": "string"},
+    "ttl": {"type": "string"},
+    "records": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "additionalProperties": false,
+        "required": ["name", "target"],
+        "properties": {
+          "name": {"type": "string"},
+          "target": {"type": "string"},
+          "role": {"type": "string"}
+        }
+      }
+    },
+    "route53": {
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["hosted_zone_id"],
+      "properties": {
+        "hosted_zone_id": {"type": "string"},
+        "access_key_id": {"type": "string"},
+        "secret_access_key": {"type": "string"}
+      }
+    },
+    "zonetransfer": {
+      "type": "object",
+      "additionalProperties": false,
+      "properties": {
+        "listen_addr": {"type": "string"},
+        "notify": {"type": "array", "items": {"type": "string"}}
+      }
+    }
+  }
+}`
+
+// GetDNSProviderSchema returns DNS provider schema for version V2
+func GetDNSProviderSchema() string {
+	return fmt.Sprintf(teleservices.V2SchemaTemplate, teleservices.MetadataSchema,
+		DNSProviderSpecV2Schema, "")
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"time"
+
+	"github.com/gravitational/gravity/lib/compare"
+
+	teleservices "github.com/gravitational/teleport/lib/services"
+	check "gopkg.in/check.v1"
+)
+
+type DNSProviderSuite struct{}
+
+var _ = check.Suite(&DNSProviderSuite{})
+
+func (s *DNSProviderSuite) TestResourceParsing(c *check.C) {
+	spec := `kind: dnsprovider
+version: v2
+metadata:
+  name: internal
+spec:
+  type: zonetransfer
+  zone: example.com
+  records:
+  - name: ops
+    target: cluster
+  - name: "*.apps"
+    target: ingress
+    role: worker
+  zonetransfer:
+    notify: ["10.0.0.1:53"]
+`
+	provider, err := UnmarshalDNSProvider([]byte(spec))
+	c.Assert(err, check.IsNil)
+	c.Assert(provider.CheckAndSetDefaults(), check.IsNil)
+	expected := NewDNSProvider("internal", DNSProviderSpecV2{
+		Type: DNSProviderZoneTransfer,
+		Zone: "example.com.",
+		TTL:  teleservices.NewDuration(time.Minute),
+		Records: []DNSRecordSpec{
+			{Name: "ops", Target: DNSTargetCluster},
+			{Name: "*.apps", Target: DNSTargetIngress, Role: "worker"},
+		},
+		ZoneTransfer: &ZoneTransferSpec{
+			ListenAddr: "0.0.0.0:10053",
+			Notify:     []string{"10.0.0.1:53"},
+		},
+	})
+	c.Assert(provider, compare.DeepEquals, expected)
+	c.Assert(provider.GetRecords()[1].FQDN(provider.GetZone()), check.Equals, "*.apps.example.com.")
+}
+
+func (s *DNSProviderSuite) TestValidatesProvider(c *check.C) {
+	provider := NewDNSProvider("aws", DNSProviderSpecV2{
+		Type:    DNSProviderRoute53,
+		Zone:    "example.com",
+		Records: []DNSRecordSpec{{Name: "ops", Target: DNSTargetCluster}},
+	})
+	c.Assert(provider.CheckAndSetDefaults(), check.NotNil)
+
+	provider = NewDNSProvider("aws", DNSProviderSpecV2{
+		Type:    DNSProviderRoute53,
+		Zone:    "example.com",
+		Records: []DNSRecordSpec{{Name: "ops", Target: "unknown"}},
+		Route53: &Route53Spec{HostedZoneID: "Z1"},
+	})
+	c.Assert(provider.CheckAndSetDefaults(), check.NotNil)
+}
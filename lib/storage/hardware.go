@@ -0,0 +1,112 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"github.com/gravitational/gravity/lib/utils"
+)
+
+// HardwareProfile describes the hardware of a node collected during discovery
+type HardwareProfile struct {
+	// CPU describes the node's processors
+	CPU CPUInfo `json:"cpu"`
+	// NUMANodes lists the NUMA nodes of the system
+	NUMANodes []NUMANode `json:"numa_nodes,omitempty"`
+	// Disks lists the block devices attached to the node
+	Disks []DiskInfo `json:"disks,omitempty"`
+	// NICs lists the physical network interfaces of the node
+	NICs []NICInfo `json:"nics,omitempty"`
+}
+
+// CPUInfo describes the processors of a node
+type CPUInfo struct {
+	// ModelName is the processor model name
+	ModelName string `json:"model_name,omitempty"`
+	// Sockets is the number of physical processor packages
+	Sockets int `json:"sockets,omitempty"`
+	// Cores is the number of logical processors
+	Cores int `json:"cores"`
+	// Flags lists the processor feature flags, e.g. avx2
+	Flags []string `json:"flags,omitempty"`
+}
+
+// HasFlag returns true if the processor supports the specified feature flag
+func (r CPUInfo) HasFlag(flag string) bool {
+	return utils.StringInSlice(r.Flags, flag)
+}
+
+// NUMANode describes a single NUMA node
+type NUMANode struct {
+	// ID is the NUMA node number
+	ID int `json:"id"`
+	// CPUs is the list of logical processors of this node, e.g. 0-7
+	CPUs string `json:"cpus,omitempty"`
+	// MemoryKB is the amount of memory attached to this node, in kilobytes
+	MemoryKB uint64 `json:"memory_kb,omitempty"`
+}
+
+// DiskInfo describes a block device
+type DiskInfo struct {
+	// Name is the device name, e.g. sda
+	Name string `json:"name"`
+	// SizeBytes is the device capacity
+	SizeBytes uint64 `json:"size_bytes"`
+	// Rotational is true for spinning disks
+	Rotational bool `json:"rotational"`
+	// Model is the device model if available
+	Model string `json:"model,omitempty"`
+}
+
+// NICInfo describes a physical network interface
+type NICInfo struct {
+	// Name is the interface name
+	Name string `json:"name"`
+	// MAC is the hardware address of the interface
+	MAC string `json:"mac,omitempty"`
+	// SpeedMbps is the link speed in megabits per second, 0 if unknown
+	SpeedMbps int `json:"speed_mbps,omitempty"`
+	// Driver is the kernel driver of the interface
+	Driver string `json:"driver,omitempty"`
+}
+
+// TotalDiskBytes returns the combined capacity of all disks
+func (r HardwareProfile) TotalDiskBytes() (total uint64) {
+	for _, disk := range r.Disks {
+		total += disk.SizeBytes
+	}
+	return total
+}
+
+// HasSSD returns true if the node has at least one non-rotational disk
+func (r HardwareProfile) HasSSD() bool {
+	for _, disk := range r.Disks {
+		if !disk.Rotational {
+			return true
+		}
+	}
+	return false
+}
+
+// MaxLinkSpeedMbps returns the fastest link speed among the network interfaces
+func (r HardwareProfile) MaxLinkSpeedMbps() (speed int) {
+	for _, nic := range r.NICs {
+		if nic.SpeedMbps > speed {
+			speed = nic.SpeedMbps
+		}
+	}
+	return speed
+}
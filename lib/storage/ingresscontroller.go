@@ -0,0 +1,232 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gravitational/gravity/lib/defaults"
+
+	teleservices "github.com/gravitational/teleport/lib/services"
+	teleutils "github.com/gravitational/teleport/lib/utils"
+	"github.com/gravitational/trace"
+)
+
+const (
+	// IngressControllerNginx is the nginx ingress controller
+	IngressControllerNginx = "nginx"
+	// IngressControllerHAProxy is the HAProxy ingress controller
+	IngressControllerHAProxy = "haproxy"
+)
+
+// IngressController describes the configuration of the ingress
+// controller bundled with the cluster
+type IngressController interface {
+	// Resource provides common resource methods
+	teleservices.Resource
+	// CheckAndSetDefaults verifies that the object is valid
+	CheckAndSetDefaults() error
+	// GetType returns the ingress controller type
+	GetType() string
+	// GetHTTPPort returns the host port HTTP traffic is served on
+	GetHTTPPort() int
+	// GetHTTPSPort returns the host port HTTPS traffic is served on
+	GetHTTPSPort() int
+	// GetReplicas returns the number of controller replicas
+	GetReplicas() int
+	// GetDefaultCertificate returns the secret with the default TLS
+	// certificate in namespace/name format
+	GetDefaultCertificate() string
+	// GetNodeSelector returns the labels of nodes the controller runs on
+	GetNodeSelector() map[string]string
+}
+
+// NewIngressController returns a new ingress controller resource
+func NewIngressController(spec IngressControllerSpecV2) IngressController {
+	return &IngressControllerV2{
+		Kind:    KindIngressController,
+		Version: teleservices.V2,
+		Metadata: teleservices.Metadata{
+			Name:      KindIngressController,
+			Namespace: defaults.Namespace,
+		},
+		Spec: spec,
+	}
+}
+
+// IngressControllerV2 defines the bundled ingress controller configuration
+type IngressControllerV2 struct {
+	// Metadata is resource metadata
+	teleservices.Metadata `json:"metadata"`
+	// Kind is a resource kind
+	Kind string `json:"kind"`
+	// Version is a resource version
+	Version string `json:"version"`
+	// Spec defines the ingress controller
+	Spec IngressControllerSpecV2 `json:"spec"`
+}
+
+// GetType returns the ingress controller type
+func (r *IngressControllerV2) GetType() string {
+	return r.Spec.Type
+}
+
+// GetHTTPPort returns the host port HTTP traffic is served on
+func (r *IngressControllerV2) GetHTTPPort() int {
+	return r.Spec.HTTPPort
+}
+
+// GetHTTPSPort returns the host port HTTPS traffic is served on
+func (r *IngressControllerV2) GetHTTPSPort() int {
+	return r.Spec.HTTPSPort
+}
+
+// GetReplicas returns the number of controller replicas
+func (r *IngressControllerV2) GetReplicas() int {
+	return r.Spec.Replicas
+}
+
+// GetDefaultCertificate returns the secret with the default TLS certificate
+func (r *IngressControllerV2) GetDefaultCertificate() string {
+	return r.Spec.DefaultCertificate
+}
+
+// GetNodeSelector returns the labels of nodes the controller runs on
+func (r *IngressControllerV2) GetNodeSelector() map[string]string {
+	return r.Spec.NodeSelector
+}
+
+// CheckAndSetDefaults checks validity of all parameters and sets defaults
+func (r *IngressControllerV2) CheckAndSetDefaults() error {
+	if r.Metadata.Name == "" {
+		r.Metadata.Name = KindIngressController
+	}
+	if err := r.Metadata.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	switch r.Spec.Type {
+	case "":
+		r.Spec.Type = IngressControllerNginx
+	case IngressControllerNginx, IngressControllerHAProxy:
+	default:
+		return trace.BadParameter("unsupported ingress controller type %q, supported are: %v",
+			r.Spec.Type, []string{IngressControllerNginx, IngressControllerHAProxy})
+	}
+	if r.Spec.HTTPPort == 0 {
+		r.Spec.HTTPPort = defaults.IngressControllerHTTPPort
+	}
+	if r.Spec.HTTPSPort == 0 {
+		r.Spec.HTTPSPort = defaults.IngressControllerHTTPSPort
+	}
+	if r.Spec.HTTPPort == r.Spec.HTTPSPort {
+		return trace.BadParameter("HTTP and HTTPS ports must be different")
+	}
+	if r.Spec.Replicas < 0 {
+		return trace.BadParameter("replicas cannot be negative")
+	}
+	if r.Spec.Replicas == 0 {
+		r.Spec.Replicas = defaults.IngressControllerReplicas
+	}
+	if r.Spec.DefaultCertificate != "" {
+		parts := strings.Split(r.Spec.DefaultCertificate, "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return trace.BadParameter("default certificate should be in namespace/name format, got %q",
+				r.Spec.DefaultCertificate)
+		}
+	}
+	return nil
+}
+
+// IngressControllerSpecV2 defines the bundled ingress controller configuration
+type IngressControllerSpecV2 struct {
+	// Type is the ingress controller type: nginx or haproxy
+	Type string `json:"type,omitempty"`
+	// HTTPPort is the host port HTTP traffic is served on
+	HTTPPort int `json:"http_port,omitempty"`
+	// HTTPSPort is the host port HTTPS traffic is served on
+	HTTPSPort int `json:"https_port,omitempty"`
+	// Replicas is the number of controller replicas
+	Replicas int `json:"replicas,omitempty"`
+	// DefaultCertificate is the secret with the TLS certificate served
+	// for hosts without a certificate of their own, in namespace/name format
+	DefaultCertificate string `json:"default_certificate,omitempty"`
+	// NodeSelector optionally limits the controller to nodes with matching labels
+	NodeSelector map[string]string `json:"node_selector,omitempty"`
+}
+
+// UnmarshalIngressController unmarshals ingress controller configuration from JSON
+func UnmarshalIngressController(data []byte) (IngressController, error) {
+	if len(data) == 0 {
+		return nil, trace.BadParameter("empty ingress controller configuration")
+	}
+
+	jsonData, err := teleutils.ToJSON(data)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var hdr teleservices.ResourceHeader
+	err = json.Unmarshal(jsonData, &hdr)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	switch hdr.Version {
+	case teleservices.V2:
+		var controller IngressControllerV2
+		err := teleutils.UnmarshalWithSchema(GetIngressControllerSchema(), &controller, jsonData)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		controller.Metadata.CheckAndSetDefaults()
+		return &controller, nil
+	}
+	return nil, trace.BadParameter(
+		"%v resource version %q is not supported", KindIngressController, hdr.Version)
+}
+
+// MarshalIngressController marshals ingress controller configuration into JSON
+func MarshalIngressController(controller IngressController, opts ...teleservices.MarshalOption) ([]byte, error) {
+	return json.Marshal(controller)
+}
+
+// IngressControllerSpecV2Schema is JSON schema for ingress controller configuration
+const IngressControllerSpecV2Schema = `{
+  "type": "object",
+  "additionalProperties": false,
+  "properties": {
+    "type": {"type": "string"},
+    "http_port": {"type": "integer"},
+    "https_port": {"type": "integer"},
+    "replicas": {"type": "integer"},
+    "default_certificate": {"type": "string"},
+    "node_selector": {
+      "type": "object",
+      "patternProperties": {
+        "^.*$": {"type": "string"}
+      }
+    }
+  }
+}`
+
+// GetIngressControllerSchema returns ingress controller schema for version V2
+func GetIngressControllerSchema() string {
+	return fmt.Sprintf(teleservices.V2SchemaTemplate, MetadataSchema,
+		IngressControllerSpecV2Schema, "")
+}
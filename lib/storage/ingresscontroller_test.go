@@ -0,0 +1,62 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"github.com/gravitational/gravity/lib/compare"
+
+	check "gopkg.in/check.v1"
+)
+
+type IngressControllerSuite struct{}
+
+var _ = check.Suite(&IngressControllerSuite{})
+
+func (s *IngressControllerSuite) TestResourceParsing(c *check.C) {
+	spec := `kind: ingresscontroller
+version: v2
+spec:
+  type: haproxy
+  https_port: 8443
+  default_certificate: default/ingress-tls
+  node_selector:
+    role: ingress
+`
+	controller, err := UnmarshalIngressController([]byte(spec))
+	c.Assert(err, check.IsNil)
+	c.Assert(controller.CheckAndSetDefaults(), check.IsNil)
+	expected := NewIngressController(IngressControllerSpecV2{
+		Type:               IngressControllerHAProxy,
+		HTTPPort:           80,
+		HTTPSPort:          8443,
+		Replicas:           2,
+		DefaultCertificate: "default/ingress-tls",
+		NodeSelector:       map[string]string{"role": "ingress"},
+	})
+	c.Assert(controller, compare.DeepEquals, expected)
+}
+
+func (s *IngressControllerSuite) TestValidatesController(c *check.C) {
+	controller := NewIngressController(IngressControllerSpecV2{Type: "traefik"})
+	c.Assert(controller.CheckAndSetDefaults(), check.NotNil)
+
+	controller = NewIngressController(IngressControllerSpecV2{HTTPPort: 443})
+	c.Assert(controller.CheckAndSetDefaults(), check.NotNil)
+
+	controller = NewIngressController(IngressControllerSpecV2{DefaultCertificate: "ingress-tls"})
+	c.Assert(controller.CheckAndSetDefaults(), check.NotNil)
+}
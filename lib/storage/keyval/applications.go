@@ -23,7 +23,7 @@ import (
 )
 
 func (b *backend) GetApplication(repository, packageName, packageVersion string) (*storage.Package, error) {
-	return b.GetPackage(repository, packageName, packageVersion)
+	return b.GetPackage(repository, packageName, packageVersion, "")
 }
 
 func (b *backend) GetApplications(repository string, appType storage.AppType) ([]storage.Package, error) {
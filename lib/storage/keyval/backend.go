@@ -19,6 +19,7 @@ package keyval
 import (
 	"encoding/base64"
 	"encoding/json"
+	"sync"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -30,6 +31,11 @@ import (
 type backend struct {
 	clockwork.Clock
 	kvengine
+
+	// blobMu guards blobRefsReady
+	blobMu sync.Mutex
+	// blobRefsReady is set once the BLOB references have been initialized
+	blobRefsReady bool
 }
 
 func (b *backend) ttl(t time.Time) time.Duration {
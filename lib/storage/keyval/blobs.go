@@ -0,0 +1,328 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyval
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// Packages with identical contents share a single BLOB. The number of
+// packages referencing a BLOB is kept in a reference record next to the
+// packages and is updated with compare-and-swap by the same backend calls
+// that create, replace and delete packages, so the count stays consistent
+// across gravity-site replicas without locks.
+//
+// A BLOB is deleted only from the state where its record has no references.
+// The record is marked as being deleted for the duration, so the packages
+// that reference the BLOB can not be created until the deletion is over.
+
+// blobReferences is the reference record of a BLOB
+type blobReferences struct {
+	// Count is the number of packages referencing the BLOB
+	Count int `json:"count"`
+	// Deleting is the time the BLOB deletion started, if it is being deleted
+	Deleting *time.Time `json:"deleting,omitempty"`
+}
+
+// isDeleting returns true if the BLOB is being deleted.
+// Deletions that have not completed within the timeout, e.g. because
+// the process deleting the BLOB has crashed, are ignored
+func (r blobReferences) isDeleting(now time.Time) bool {
+	return r.Deleting != nil && now.Sub(*r.Deleting) < defaults.BLOBDeleteTimeout
+}
+
+// GetBLOBReferences returns the number of packages that reference the BLOB
+// with the specified hash
+func (b *backend) GetBLOBReferences(hash string) (int, error) {
+	if err := b.initBLOBReferences(); err != nil {
+		return 0, trace.Wrap(err)
+	}
+	refs, _, err := b.getBLOBReferences(hash)
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return 0, nil
+		}
+		return 0, trace.Wrap(err)
+	}
+	return refs.Count, nil
+}
+
+// DeleteBLOB invokes deleteFn to delete the BLOB with the specified hash
+// unless the BLOB is referenced by a package
+func (b *backend) DeleteBLOB(hash string, deleteFn func() error) error {
+	if err := b.initBLOBReferences(); err != nil {
+		return trace.Wrap(err)
+	}
+	var marked []byte
+	err := b.updateBLOBReferences(hash, func(refs *blobReferences, exists bool) (bool, error) {
+		if refs.Count > 0 {
+			log.Debugf("BLOB %v is still referenced.", hash)
+			return false, nil
+		}
+		now := b.Now().UTC()
+		if refs.isDeleting(now) {
+			log.Debugf("BLOB %v is already being deleted.", hash)
+			return false, nil
+		}
+		refs.Deleting = &now
+		return true, nil
+	}, &marked)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if marked == nil {
+		return nil
+	}
+	deleteErr := deleteFn()
+	deleted, err := json.Marshal(blobReferences{})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	var out []byte
+	err = b.compareAndSwapBytes(b.key(blobsP, hash), deleted, marked, &out, forever)
+	if err != nil {
+		log.Warnf("Failed to complete deletion of BLOB %v: %v.", hash, trace.DebugReport(err))
+	}
+	return trace.Wrap(deleteErr)
+}
+
+// addBLOBReference records a new package reference to the BLOB.
+// Returns CompareFailed if the BLOB is being deleted
+func (b *backend) addBLOBReference(hash string) error {
+	if hash == "" {
+		// package without contents
+		return nil
+	}
+	return b.updateBLOBReferences(hash, func(refs *blobReferences, exists bool) (bool, error) {
+		if refs.isDeleting(b.Now().UTC()) {
+			return false, trace.CompareFailed("package contents %v are being deleted, retry later", hash)
+		}
+		refs.Count++
+		refs.Deleting = nil
+		return true, nil
+	}, nil)
+}
+
+// removeBLOBReference removes a package reference to the BLOB
+func (b *backend) removeBLOBReference(hash string) error {
+	if hash == "" {
+		return nil
+	}
+	return b.updateBLOBReferences(hash, func(refs *blobReferences, exists bool) (bool, error) {
+		if !exists || refs.Count == 0 {
+			log.Warnf("BLOB %v has no references to remove.", hash)
+			return false, nil
+		}
+		refs.Count--
+		return true, nil
+	}, nil)
+}
+
+// updateBLOBReferences applies the update to the reference record of the BLOB
+// with compare-and-swap, retrying if the record has been changed concurrently.
+// The update returns false if the record should stay unchanged.
+// If out is not nil, it is set to the stored value of the updated record
+func (b *backend) updateBLOBReferences(hash string, update func(refs *blobReferences, exists bool) (bool, error), out *[]byte) error {
+	key := b.key(blobsP, hash)
+	for {
+		refs, prev, err := b.getBLOBReferences(hash)
+		if err != nil && !trace.IsNotFound(err) {
+			return trace.Wrap(err)
+		}
+		exists := err == nil
+		if !exists {
+			refs = &blobReferences{}
+		}
+		changed, err := update(refs, exists)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if !changed {
+			return nil
+		}
+		data, err := json.Marshal(refs)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		var prevOut []byte
+		err = b.compareAndSwapBytes(key, data, prev, &prevOut, forever)
+		if err == nil {
+			if out != nil {
+				*out = data
+			}
+			return nil
+		}
+		if !trace.IsCompareFailed(err) && !trace.IsAlreadyExists(err) {
+			return trace.Wrap(err)
+		}
+		// the record has been updated concurrently
+	}
+}
+
+// getBLOBReferences returns the reference record of the BLOB
+// along with its stored value
+func (b *backend) getBLOBReferences(hash string) (*blobReferences, []byte, error) {
+	data, err := b.getValBytes(b.key(blobsP, hash))
+	if err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+	var refs blobReferences
+	if err := json.Unmarshal(data, &refs); err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+	return &refs, data, nil
+}
+
+// initBLOBReferences creates the reference records for the packages
+// created before the references were tracked. It only runs once per backend
+func (b *backend) initBLOBReferences() error {
+	b.blobMu.Lock()
+	defer b.blobMu.Unlock()
+	if b.blobRefsReady {
+		return nil
+	}
+	err := b.getVal(b.key(blobsP, blobsInitializedP), &struct{}{})
+	if err == nil {
+		b.blobRefsReady = true
+		return nil
+	}
+	if !trace.IsNotFound(err) {
+		return trace.Wrap(err)
+	}
+	counts := make(map[string]int)
+	repos, err := b.GetRepositories()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	for _, repo := range repos {
+		packages, err := b.GetPackages(repo.GetName())
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		for _, pkg := range packages {
+			if pkg.SHA512 != "" {
+				counts[pkg.SHA512]++
+			}
+		}
+	}
+	for hash, count := range counts {
+		// records that already exist have been initialized by another process
+		err := b.createVal(b.key(blobsP, hash), blobReferences{Count: count}, forever)
+		if err != nil && !trace.IsAlreadyExists(err) {
+			return trace.Wrap(err)
+		}
+	}
+	err = b.upsertVal(b.key(blobsP, blobsInitializedP), struct{}{}, forever)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	log.Infof("Initialized references of %v BLOBs.", len(counts))
+	b.blobRefsReady = true
+	return nil
+}
+
+// createPackage creates the package record that references the BLOB
+// with the package contents
+func (b *backend) createPackage(p storage.Package) error {
+	if err := b.initBLOBReferences(); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := b.addBLOBReference(p.SHA512); err != nil {
+		return trace.Wrap(err)
+	}
+	err := b.createVal(b.packageKey(p.Repository, p.Name, p.Version, p.Arch), p, forever)
+	if err != nil {
+		if errRemove := b.removeBLOBReference(p.SHA512); errRemove != nil {
+			log.Warnf("Failed to remove reference to BLOB %v: %v.", p.SHA512, errRemove)
+		}
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// upsertPackage creates or replaces the package record.
+// The BLOB referenced by the replaced package loses the reference
+func (b *backend) upsertPackage(p storage.Package) error {
+	if err := b.initBLOBReferences(); err != nil {
+		return trace.Wrap(err)
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if err := b.addBLOBReference(p.SHA512); err != nil {
+		return trace.Wrap(err)
+	}
+	key := b.packageKey(p.Repository, p.Name, p.Version, p.Arch)
+	for {
+		prev, err := b.getValBytes(key)
+		if err != nil && !trace.IsNotFound(err) {
+			return trace.NewAggregate(err, b.removeBLOBReference(p.SHA512))
+		}
+		var out []byte
+		err = b.compareAndSwapBytes(key, data, prev, &out, forever)
+		if err != nil {
+			if trace.IsCompareFailed(err) || trace.IsAlreadyExists(err) {
+				// the package has been updated concurrently
+				continue
+			}
+			return trace.NewAggregate(err, b.removeBLOBReference(p.SHA512))
+		}
+		if prev == nil {
+			return nil
+		}
+		var replaced storage.Package
+		if err := json.Unmarshal(prev, &replaced); err != nil {
+			return trace.Wrap(err)
+		}
+		return trace.Wrap(b.removeBLOBReference(replaced.SHA512))
+	}
+}
+
+// deletePackage deletes the package record and its reference to the BLOB
+func (b *backend) deletePackage(repository, packageName, packageVersion, arch string) error {
+	if err := b.initBLOBReferences(); err != nil {
+		return trace.Wrap(err)
+	}
+	key := b.packageKey(repository, packageName, packageVersion, arch)
+	for {
+		data, err := b.getValBytes(key)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		err = b.compareAndDelete(key, json.RawMessage(data))
+		if err != nil {
+			if trace.IsCompareFailed(err) {
+				// the package has been updated concurrently
+				continue
+			}
+			return trace.Wrap(err)
+		}
+		var deleted storage.Package
+		if err := json.Unmarshal(data, &deleted); err != nil {
+			return trace.Wrap(err)
+		}
+		return trace.Wrap(b.removeBLOBReference(deleted.SHA512))
+	}
+}
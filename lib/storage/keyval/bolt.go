@@ -468,17 +468,16 @@ func (b *blt) compareAndDelete(k key, prevVal interface{}) error {
 		if err != nil {
 			return trace.Wrap(err)
 		}
-		bytes := bkt.Get([]byte(key))
-		if bytes == nil {
+		currentVal := bkt.Get([]byte(key))
+		if currentVal == nil {
 			return trace.NotFound("%v is not found", key)
 		}
-		var outVal interface{}
-		err = b.codec.DecodeFromBytes(bytes, &outVal)
+		encoded, err := b.codec.EncodeToBytes(prevVal)
 		if err != nil {
 			return trace.Wrap(err)
 		}
-		if outVal != prevVal {
-			return trace.BadParameter("%v: expected %v, but got %v", key, prevVal, outVal)
+		if !bytes.Equal(currentVal, encoded) {
+			return trace.CompareFailed("%v: expected %q, but got %q", key, encoded, currentVal)
 		}
 		return bkt.Delete([]byte(key))
 	})
@@ -105,6 +105,14 @@ func (s *BSuite) TestRepositoriesCRUD(c *C) {
 	s.suite.RepositoriesCRUD(c)
 }
 
+func (s *BSuite) TestBLOBReferences(c *C) {
+	s.suite.BLOBReferences(c)
+}
+
+func (s *BSuite) TestPackageAliasesCRUD(c *C) {
+	s.suite.PackageAliasesCRUD(c)
+}
+
 func (s *BSuite) TestSitesCRUD(c *C) {
 	s.suite.SitesCRUD(c)
 }
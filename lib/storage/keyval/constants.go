@@ -41,6 +41,10 @@ const (
 	repositoriesP               = "repos"
 	packagesP                   = "packages"
 	versionsP                   = "versions"
+	archsP                      = "archs"
+	aliasesP                    = "aliases"
+	blobsP                      = "blobs"
+	blobsInitializedP           = "initialized"
 	valP                        = "val"
 	progressP                   = "progress"
 	connectorsP                 = "connectors"
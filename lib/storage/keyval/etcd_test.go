@@ -129,6 +129,14 @@ func (s *ESuite) TestRepositoriesCRUD(c *C) {
 	s.suite.RepositoriesCRUD(c)
 }
 
+func (s *ESuite) TestBLOBReferences(c *C) {
+	s.suite.BLOBReferences(c)
+}
+
+func (s *ESuite) TestPackageAliasesCRUD(c *C) {
+	s.suite.PackageAliasesCRUD(c)
+}
+
 func (s *ESuite) TestSitesCRUD(c *C) {
 	s.suite.SitesCRUD(c)
 }
@@ -90,7 +90,7 @@ func (b *backend) CreatePackage(p storage.Package) (*storage.Package, error) {
 	if err := p.Check(); err != nil {
 		return nil, trace.Wrap(err)
 	}
-	if err := b.createVal(b.key(repositoriesP, p.Repository, packagesP, p.Name, versionsP, p.Version), p, forever); err != nil {
+	if err := b.createPackage(p); err != nil {
 		if trace.IsAlreadyExists(err) {
 			return nil, trace.AlreadyExists("%v already exists", &p)
 		}
@@ -103,31 +103,28 @@ func (b *backend) UpsertPackage(p storage.Package) (*storage.Package, error) {
 	if err := p.Check(); err != nil {
 		return nil, trace.Wrap(err)
 	}
-	if err := b.upsertVal(b.key(repositoriesP, p.Repository, packagesP, p.Name, versionsP, p.Version), p, forever); err != nil {
-		if trace.IsAlreadyExists(err) {
-			return nil, trace.AlreadyExists("%v already exists", &p)
-		}
+	if err := b.upsertPackage(p); err != nil {
 		return nil, trace.Wrap(err)
 	}
 	return &p, nil
 }
 
-func (b *backend) DeletePackage(repository string, packageName, packageVersion string) error {
-	err := b.deleteKey(b.key(repositoriesP, repository, packagesP, packageName, versionsP, packageVersion))
+func (b *backend) DeletePackage(repository string, packageName, packageVersion, arch string) error {
+	err := b.deletePackage(repository, packageName, packageVersion, arch)
 	if err != nil {
 		if trace.IsNotFound(err) {
-			return trace.NotFound("package(%v/%v:%v) not found", repository, packageName, packageVersion)
+			return trace.NotFound("%v not found", packageString(repository, packageName, packageVersion, arch))
 		}
 		return trace.Wrap(err)
 	}
 	return nil
 }
 
-func (b *backend) GetPackage(repository string, packageName, packageVersion string) (*storage.Package, error) {
+func (b *backend) GetPackage(repository string, packageName, packageVersion, arch string) (*storage.Package, error) {
 	var p storage.Package
-	if err := b.getVal(b.key(repositoriesP, repository, packagesP, packageName, versionsP, packageVersion), &p); err != nil {
+	if err := b.getVal(b.packageKey(repository, packageName, packageVersion, arch), &p); err != nil {
 		if trace.IsNotFound(err) {
-			return nil, trace.NotFound("package(%v/%v:%v) not found", repository, packageName, packageVersion)
+			return nil, trace.NotFound("%v not found", packageString(repository, packageName, packageVersion, arch))
 		}
 		return nil, trace.Wrap(err)
 	}
@@ -142,28 +139,52 @@ func (b *backend) GetPackages(repository string) ([]storage.Package, error) {
 	sort.Sort(sort.StringSlice(packageNames))
 	out := make([]storage.Package, 0)
 	for _, packageName := range packageNames {
-		packageVers, err := b.getKeys(b.key(repositoriesP, repository, packagesP, packageName, versionsP))
+		packages, err := b.getPackageVersions(repository, packageName, "")
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		out = append(out, packages...)
+		archs, err := b.getKeys(b.key(repositoriesP, repository, packagesP, packageName, archsP))
 		if err != nil {
 			return nil, trace.Wrap(err)
 		}
-		sort.Sort(sort.StringSlice(packageVers))
-		for _, packageVer := range packageVers {
-			p, err := b.GetPackage(repository, packageName, packageVer)
+		sort.Sort(sort.StringSlice(archs))
+		for _, arch := range archs {
+			packages, err := b.getPackageVersions(repository, packageName, arch)
 			if err != nil {
 				return nil, trace.Wrap(err)
 			}
-			out = append(out, *p)
+			out = append(out, packages...)
+		}
+	}
+	return out, nil
+}
+
+// getPackageVersions returns all versions of the package built
+// for the specified architecture
+func (b *backend) getPackageVersions(repository, packageName, arch string) ([]storage.Package, error) {
+	packageVers, err := b.getKeys(b.packageKey(repository, packageName, "", arch))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	sort.Sort(sort.StringSlice(packageVers))
+	out := make([]storage.Package, 0, len(packageVers))
+	for _, packageVer := range packageVers {
+		p, err := b.GetPackage(repository, packageName, packageVer, arch)
+		if err != nil {
+			return nil, trace.Wrap(err)
 		}
+		out = append(out, *p)
 	}
 	return out, nil
 }
 
-func (b *backend) UpdatePackageRuntimeLabels(repository, packageName, packageVersion string, addLabels map[string]string, removeLabels []string) error {
+func (b *backend) UpdatePackageRuntimeLabels(repository, packageName, packageVersion, arch string, addLabels map[string]string, removeLabels []string) error {
 	var p storage.Package
-	err := b.getVal(b.key(repositoriesP, repository, packagesP, packageName, versionsP, packageVersion), &p)
+	err := b.getVal(b.packageKey(repository, packageName, packageVersion, arch), &p)
 	if err != nil {
 		if trace.IsNotFound(err) {
-			return trace.Wrap(err, "package(%v/%v:%v) not found", repository, packageName, packageVersion)
+			return trace.Wrap(err, "%v not found", packageString(repository, packageName, packageVersion, arch))
 		}
 		return trace.Wrap(err)
 	}
@@ -179,3 +200,85 @@ func (b *backend) UpdatePackageRuntimeLabels(repository, packageName, packageVer
 	_, err = b.UpsertPackage(p)
 	return trace.Wrap(err)
 }
+
+// packageKey returns the key of the package record. Variants of the package
+// built for other architectures are stored separately from the package, e.g.
+// repos/example.com/packages/planet/archs/arm64/versions/1.0.0
+func (b *backend) packageKey(repository, packageName, packageVersion, arch string) key {
+	k := b.key(repositoriesP, repository, packagesP, packageName)
+	if arch != "" {
+		k = append(k, archsP, arch)
+	}
+	k = append(k, versionsP)
+	if packageVersion != "" {
+		k = append(k, packageVersion)
+	}
+	return k
+}
+
+func packageString(repository, packageName, packageVersion, arch string) string {
+	return storage.Package{
+		Repository: repository,
+		Name:       packageName,
+		Version:    packageVersion,
+		Arch:       arch,
+	}.String()
+}
+
+func (b *backend) UpsertPackageAlias(alias storage.PackageAlias) (*storage.PackageAlias, error) {
+	if err := alias.Check(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	// the alias is stored in a single key so updating its target is atomic
+	err := b.upsertVal(b.key(repositoriesP, alias.Repository, aliasesP, alias.Name, alias.Alias), alias, forever)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &alias, nil
+}
+
+func (b *backend) GetPackageAlias(repository, packageName, alias string) (*storage.PackageAlias, error) {
+	var a storage.PackageAlias
+	if err := b.getVal(b.key(repositoriesP, repository, aliasesP, packageName, alias), &a); err != nil {
+		if trace.IsNotFound(err) {
+			return nil, trace.NotFound("package alias(%v/%v:%v) not found", repository, packageName, alias)
+		}
+		return nil, trace.Wrap(err)
+	}
+	return &a, nil
+}
+
+func (b *backend) GetPackageAliases(repository string) ([]storage.PackageAlias, error) {
+	packageNames, err := b.getKeys(b.key(repositoriesP, repository, aliasesP))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	sort.Sort(sort.StringSlice(packageNames))
+	out := make([]storage.PackageAlias, 0)
+	for _, packageName := range packageNames {
+		aliases, err := b.getKeys(b.key(repositoriesP, repository, aliasesP, packageName))
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		sort.Sort(sort.StringSlice(aliases))
+		for _, alias := range aliases {
+			a, err := b.GetPackageAlias(repository, packageName, alias)
+			if err != nil {
+				return nil, trace.Wrap(err)
+			}
+			out = append(out, *a)
+		}
+	}
+	return out, nil
+}
+
+func (b *backend) DeletePackageAlias(repository, packageName, alias string) error {
+	err := b.deleteKey(b.key(repositoriesP, repository, aliasesP, packageName, alias))
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return trace.NotFound("package alias(%v/%v:%v) not found", repository, packageName, alias)
+		}
+		return trace.Wrap(err)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// OperationLockScopeCluster is the scope of a lock held on the whole cluster
+	OperationLockScopeCluster = "cluster"
+	// OperationLockScopeNode is the scope of a lock held on a single node
+	OperationLockScopeNode = "node"
+)
+
+// OperationLock describes a cluster resource held by an active operation
+type OperationLock struct {
+	// Scope is the lock scope, either cluster or node
+	Scope string `json:"scope"`
+	// Resource identifies the locked resource: the cluster name for
+	// cluster-scoped locks or the node advertise address for node-scoped locks
+	Resource string `json:"resource"`
+	// OperationID is the ID of the operation holding the lock
+	OperationID string `json:"operation_id"`
+	// OperationType is the type of the operation holding the lock
+	OperationType string `json:"operation_type"`
+	// OperationState is the state of the operation holding the lock
+	OperationState string `json:"operation_state"`
+	// Since is the time the lock has been acquired
+	Since time.Time `json:"since"`
+}
+
+// String returns a textual representation of this lock
+func (r OperationLock) String() string {
+	return fmt.Sprintf("lock(%v=%v, operation=%v/%v)",
+		r.Scope, r.Resource, r.OperationType, r.OperationID)
+}
+
+// OperationLocksRelease is the audit record of a forced release
+// of the resources held by an operation
+type OperationLocksRelease struct {
+	// User is the name of the user who released the locks
+	User string `json:"user"`
+	// Reason is the reason given for the release
+	Reason string `json:"reason"`
+	// Released is the time the locks were released
+	Released time.Time `json:"released"`
+	// Locks lists the released locks
+	Locks []OperationLock `json:"locks,omitempty"`
+}
@@ -0,0 +1,220 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/utils"
+
+	teleservices "github.com/gravitational/teleport/lib/services"
+	teleutils "github.com/gravitational/teleport/lib/utils"
+	"github.com/gravitational/trace"
+)
+
+// LogRotation describes the rotation and retention policy of the planet
+// systemd journal and the container logs enforced on every cluster node
+type LogRotation interface {
+	// Resource provides common resource methods
+	teleservices.Resource
+	// CheckAndSetDefaults verifies that the object is valid
+	CheckAndSetDefaults() error
+	// GetJournal returns the journal retention policy, nil if the
+	// journald defaults are used
+	GetJournal() *JournalRotation
+	// GetContainers returns the container log rotation policy, nil if
+	// container logs are not rotated
+	GetContainers() *ContainerLogRotation
+}
+
+// NewLogRotation returns a new log rotation policy resource
+func NewLogRotation(spec LogRotationSpecV2) LogRotation {
+	return &LogRotationV2{
+		Kind:    KindLogRotation,
+		Version: teleservices.V2,
+		Metadata: teleservices.Metadata{
+			Name:      KindLogRotation,
+			Namespace: defaults.Namespace,
+		},
+		Spec: spec,
+	}
+}
+
+// LogRotationV2 defines the log rotation policy
+type LogRotationV2 struct {
+	// Metadata is resource metadata
+	teleservices.Metadata `json:"metadata"`
+	// Kind is a resource kind
+	Kind string `json:"kind"`
+	// Version is a resource version
+	Version string `json:"version"`
+	// Spec defines the log rotation policy
+	Spec LogRotationSpecV2 `json:"spec"`
+}
+
+// GetJournal returns the journal retention policy
+func (r *LogRotationV2) GetJournal() *JournalRotation {
+	return r.Spec.Journal
+}
+
+// GetContainers returns the container log rotation policy
+func (r *LogRotationV2) GetContainers() *ContainerLogRotation {
+	return r.Spec.Containers
+}
+
+// CheckAndSetDefaults checks validity of all parameters and sets defaults
+func (r *LogRotationV2) CheckAndSetDefaults() error {
+	if r.Metadata.Name == "" {
+		r.Metadata.Name = KindLogRotation
+	}
+	if err := r.Metadata.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	if r.Spec.Journal == nil && r.Spec.Containers == nil {
+		return trace.BadParameter("either journal or containers policy is required")
+	}
+	if journal := r.Spec.Journal; journal != nil {
+		if journal.MaxUse == 0 && journal.MaxFileSize == 0 && journal.MaxRetention == nil {
+			return trace.BadParameter("journal policy requires at least one of max_use, max_file_size or max_retention")
+		}
+		if journal.MaxUse != 0 && journal.MaxFileSize > journal.MaxUse {
+			return trace.BadParameter("journal max_file_size %v exceeds max_use %v",
+				journal.MaxFileSize, journal.MaxUse)
+		}
+		if journal.MaxRetention != nil && journal.MaxRetention.Value() < time.Second {
+			return trace.BadParameter("journal max_retention should be at least 1s")
+		}
+	}
+	if containers := r.Spec.Containers; containers != nil {
+		if containers.MaxSize == 0 {
+			return trace.BadParameter("containers policy requires max_size")
+		}
+		if containers.MaxFiles < 0 {
+			return trace.BadParameter("containers max_files can not be negative")
+		}
+		if containers.MaxFiles == 0 {
+			containers.MaxFiles = defaults.ContainerLogMaxFiles
+		}
+		if containers.CheckInterval == nil {
+			interval := teleservices.NewDuration(defaults.ContainerLogCheckInterval)
+			containers.CheckInterval = &interval
+		}
+		if containers.CheckInterval.Value() < time.Second {
+			return trace.BadParameter("containers check_interval should be at least 1s")
+		}
+	}
+	return nil
+}
+
+// LogRotationSpecV2 defines the log rotation policy
+type LogRotationSpecV2 struct {
+	// Journal is the retention policy of the planet systemd journal
+	Journal *JournalRotation `json:"journal,omitempty"`
+	// Containers is the rotation policy of the container logs
+	Containers *ContainerLogRotation `json:"containers,omitempty"`
+}
+
+// JournalRotation defines the retention policy of the planet systemd journal
+type JournalRotation struct {
+	// MaxUse limits the disk space the journal can use, e.g. "2GB"
+	MaxUse utils.Capacity `json:"max_use,omitempty"`
+	// MaxFileSize limits the size of individual journal files
+	MaxFileSize utils.Capacity `json:"max_file_size,omitempty"`
+	// MaxRetention is how long journal entries are kept
+	MaxRetention *teleservices.Duration `json:"max_retention,omitempty"`
+}
+
+// ContainerLogRotation defines the rotation policy of the container logs
+type ContainerLogRotation struct {
+	// MaxSize is the size a container log is rotated at, e.g. "100MB"
+	MaxSize utils.Capacity `json:"max_size,omitempty"`
+	// MaxFiles is the number of rotated logs kept for every container
+	MaxFiles int `json:"max_files,omitempty"`
+	// CheckInterval is how often the size of the container logs is checked
+	CheckInterval *teleservices.Duration `json:"check_interval,omitempty"`
+}
+
+// UnmarshalLogRotation unmarshals log rotation policy from JSON
+func UnmarshalLogRotation(data []byte) (LogRotation, error) {
+	if len(data) == 0 {
+		return nil, trace.BadParameter("empty log rotation policy")
+	}
+
+	jsonData, err := teleutils.ToJSON(data)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var hdr teleservices.ResourceHeader
+	err = json.Unmarshal(jsonData, &hdr)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	switch hdr.Version {
+	case teleservices.V2:
+		var policy LogRotationV2
+		err := teleutils.UnmarshalWithSchema(GetLogRotationSchema(), &policy, jsonData)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		policy.Metadata.CheckAndSetDefaults()
+		return &policy, nil
+	}
+	return nil, trace.BadParameter(
+		"%v resource version %q is not supported", KindLogRotation, hdr.Version)
+}
+
+// MarshalLogRotation marshals log rotation policy into JSON
+func MarshalLogRotation(policy LogRotation, opts ...teleservices.MarshalOption) ([]byte, error) {
+	return json.Marshal(policy)
+}
+
+// LogRotationSpecV2Schema is JSON schema for log rotation policy
+const LogRotationSpecV2Schema = `{
+  "type": "object",
+  "additionalProperties": false,
+  "properties": {
+    "journal": {
+      "type": "object",
+      "additionalProperties": false,
+      "properties": {
+        "max_use": {"type": "string"},
+        "max_file_size": {"type": "string"},
+        "max_retention": {"type": "string"}
+      }
+    },
+    "containers": {
+      "type": "object",
+      "additionalProperties": false,
+      "properties": {
+        "max_size": {"type": "string"},
+        "max_files": {"type": "number"},
+        "check_interval": {"type": "string"}
+      }
+    }
+  }
+}`
+
+// GetLogRotationSchema returns log rotation policy schema for version V2
+func GetLogRotationSchema() string {
+	return fmt.Sprintf(teleservices.V2SchemaTemplate, MetadataSchema,
+		LogRotationSpecV2Schema, "")
+}
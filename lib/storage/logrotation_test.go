@@ -0,0 +1,76 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"time"
+
+	"github.com/gravitational/gravity/lib/compare"
+	"github.com/gravitational/gravity/lib/utils"
+
+	teleservices "github.com/gravitational/teleport/lib/services"
+	check "gopkg.in/check.v1"
+)
+
+type LogRotationSuite struct{}
+
+var _ = check.Suite(&LogRotationSuite{})
+
+func (s *LogRotationSuite) TestResourceParsing(c *check.C) {
+	spec := `kind: logrotation
+version: v2
+spec:
+  journal:
+    max_use: 2GB
+    max_retention: 168h
+  containers:
+    max_size: 100MB
+`
+	policy, err := UnmarshalLogRotation([]byte(spec))
+	c.Assert(err, check.IsNil)
+	c.Assert(policy.CheckAndSetDefaults(), check.IsNil)
+	retention := teleservices.NewDuration(168 * time.Hour)
+	interval := teleservices.NewDuration(5 * time.Minute)
+	expected := NewLogRotation(LogRotationSpecV2{
+		Journal: &JournalRotation{
+			MaxUse:       utils.MustParseCapacity("2GB"),
+			MaxRetention: &retention,
+		},
+		Containers: &ContainerLogRotation{
+			MaxSize:       utils.MustParseCapacity("100MB"),
+			MaxFiles:      5,
+			CheckInterval: &interval,
+		},
+	})
+	c.Assert(policy, compare.DeepEquals, expected)
+}
+
+func (s *LogRotationSuite) TestValidatesPolicy(c *check.C) {
+	for _, spec := range []LogRotationSpecV2{
+		{},
+		{Journal: &JournalRotation{}},
+		{Journal: &JournalRotation{
+			MaxUse:      utils.MustParseCapacity("1GB"),
+			MaxFileSize: utils.MustParseCapacity("2GB"),
+		}},
+		{Containers: &ContainerLogRotation{MaxFiles: 3}},
+		{Containers: &ContainerLogRotation{MaxSize: utils.MustParseCapacity("10MB"), MaxFiles: -1}},
+	} {
+		policy := NewLogRotation(spec)
+		c.Assert(policy.CheckAndSetDefaults(), check.NotNil, check.Commentf("%#v", spec))
+	}
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+
+	teleservices "github.com/gravitational/teleport/lib/services"
+	teleutils "github.com/gravitational/teleport/lib/utils"
+	"github.com/gravitational/trace"
+)
+
+// OperationReaper configures the automatic cleanup of operations that have
+// been abandoned in progress: operations that have shown no activity for
+// longer than the threshold are failed, their locks released and their
+// plans archived
+type OperationReaper interface {
+	// Resource provides common resource methods
+	teleservices.Resource
+	// CheckAndSetDefaults verifies that the object is valid
+	CheckAndSetDefaults() error
+	// IsEnabled returns true if abandoned operations are cleaned up
+	IsEnabled() bool
+	// GetThreshold returns how long an operation has to show no activity
+	// before it is considered abandoned
+	GetThreshold() time.Duration
+}
+
+// NewOperationReaper returns a new operation reaper resource
+func NewOperationReaper(spec OperationReaperSpecV1) OperationReaper {
+	return &OperationReaperV1{
+		Kind:    KindOperationReaper,
+		Version: teleservices.V1,
+		Metadata: teleservices.Metadata{
+			Name:      KindOperationReaper,
+			Namespace: defaults.Namespace,
+		},
+		Spec: spec,
+	}
+}
+
+// OperationReaperV1 defines the cleanup of abandoned operations
+type OperationReaperV1 struct {
+	// Metadata is resource metadata
+	teleservices.Metadata `json:"metadata"`
+	// Kind is a resource kind
+	Kind string `json:"kind"`
+	// Version is a resource version
+	Version string `json:"version"`
+	// Spec defines the cleanup of abandoned operations
+	Spec OperationReaperSpecV1 `json:"spec"`
+}
+
+// OperationReaperSpecV1 defines the cleanup of abandoned operations
+type OperationReaperSpecV1 struct {
+	// Enabled turns on the cleanup of abandoned operations
+	Enabled bool `json:"enabled"`
+	// Threshold is how long an operation has to show no activity
+	// before it is considered abandoned
+	Threshold *teleservices.Duration `json:"threshold,omitempty"`
+}
+
+// IsEnabled returns true if abandoned operations are cleaned up
+func (r *OperationReaperV1) IsEnabled() bool {
+	return r.Spec.Enabled
+}
+
+// GetThreshold returns how long an operation has to show no activity
+// before it is considered abandoned
+func (r *OperationReaperV1) GetThreshold() time.Duration {
+	return durationValue(r.Spec.Threshold)
+}
+
+// CheckAndSetDefaults checks validity of all parameters and sets defaults
+func (r *OperationReaperV1) CheckAndSetDefaults() error {
+	if r.Metadata.Name == "" {
+		r.Metadata.Name = KindOperationReaper
+	}
+	if err := r.Metadata.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	if r.Spec.Threshold == nil {
+		value := teleservices.NewDuration(defaults.OperationReaperThreshold)
+		r.Spec.Threshold = &value
+	}
+	// operations are not reaped sooner than their locks
+	// can be released without forcing
+	if r.Spec.Threshold.Value() < defaults.OperationLockStaleTimeout {
+		return trace.BadParameter("threshold should be at least %v",
+			defaults.OperationLockStaleTimeout)
+	}
+	return nil
+}
+
+// UnmarshalOperationReaper unmarshals operation reaper from JSON
+func UnmarshalOperationReaper(data []byte) (OperationReaper, error) {
+	if len(data) == 0 {
+		return nil, trace.BadParameter("empty operation reaper")
+	}
+
+	jsonData, err := teleutils.ToJSON(data)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var hdr teleservices.ResourceHeader
+	err = json.Unmarshal(jsonData, &hdr)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	switch hdr.Version {
+	case teleservices.V1:
+		var reaper OperationReaperV1
+		err := teleutils.UnmarshalWithSchema(GetOperationReaperSchema(), &reaper, jsonData)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		reaper.Metadata.CheckAndSetDefaults()
+		return &reaper, nil
+	}
+	return nil, trace.BadParameter(
+		"%v resource version %q is not supported", KindOperationReaper, hdr.Version)
+}
+
+// MarshalOperationReaper marshals operation reaper into JSON
+func MarshalOperationReaper(reaper OperationReaper, opts ...teleservices.MarshalOption) ([]byte, error) {
+	return json.Marshal(reaper)
+}
+
+// OperationReaperSpecV1Schema is JSON schema for operation reaper
+const OperationReaperSpecV1Schema = `{
+  "type": "object",
+  "additionalProperties": false,
+  "properties": {
+    "enabled": {"type": "boolean"},
+    "threshold": {"type": "string"}
+  }
+}`
+
+// GetOperationReaperSchema returns operation reaper schema for version V1
+func GetOperationReaperSchema() string {
+	return fmt.Sprintf(teleservices.V2SchemaTemplate, MetadataSchema,
+		OperationReaperSpecV1Schema, "")
+}
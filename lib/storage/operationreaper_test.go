@@ -0,0 +1,71 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+
+	teleservices "github.com/gravitational/teleport/lib/services"
+	check "gopkg.in/check.v1"
+)
+
+type OperationReaperSuite struct{}
+
+var _ = check.Suite(&OperationReaperSuite{})
+
+func (s *OperationReaperSuite) TestUnmarshalsReaper(c *check.C) {
+	spec := `kind: operationreaper
+version: v1
+spec:
+  enabled: true
+  threshold: 2h
+`
+	reaper, err := UnmarshalOperationReaper([]byte(spec))
+	c.Assert(err, check.IsNil)
+	c.Assert(reaper.CheckAndSetDefaults(), check.IsNil)
+	c.Assert(reaper.IsEnabled(), check.Equals, true)
+	c.Assert(reaper.GetThreshold(), check.Equals, 2*time.Hour)
+
+	data, err := MarshalOperationReaper(reaper)
+	c.Assert(err, check.IsNil)
+	unmarshaled, err := UnmarshalOperationReaper(data)
+	c.Assert(err, check.IsNil)
+	c.Assert(unmarshaled, check.DeepEquals, reaper)
+}
+
+func (s *OperationReaperSuite) TestDefaults(c *check.C) {
+	reaper := NewOperationReaper(OperationReaperSpecV1{})
+	c.Assert(reaper.CheckAndSetDefaults(), check.IsNil)
+	c.Assert(reaper.IsEnabled(), check.Equals, false)
+	c.Assert(reaper.GetThreshold(), check.Equals, defaults.OperationReaperThreshold)
+}
+
+func (s *OperationReaperSuite) TestValidatesThreshold(c *check.C) {
+	threshold := teleservices.NewDuration(time.Minute)
+	reaper := NewOperationReaper(OperationReaperSpecV1{Enabled: true, Threshold: &threshold})
+	c.Assert(reaper.CheckAndSetDefaults(), check.NotNil)
+
+	reaper, err := UnmarshalOperationReaper([]byte(`kind: operationreaper
+version: v1
+spec:
+  enabled: true
+  threshold: 5m`))
+	c.Assert(err, check.IsNil)
+	c.Assert(reaper.CheckAndSetDefaults(), check.NotNil)
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+
+	teleservices "github.com/gravitational/teleport/lib/services"
+	teleutils "github.com/gravitational/teleport/lib/utils"
+	"github.com/gravitational/trace"
+)
+
+// PackageRetentionPolicy defines which versions of the packages in a repository
+// are kept when the repository is pruned periodically.
+//
+// A package version is kept if it is among the KeepVersions latest versions
+// of the package or is younger than MaxAge. Installed and pinned packages
+// and the packages they depend on are always kept
+type PackageRetentionPolicy interface {
+	// Resource provides common resource methods
+	teleservices.Resource
+	// CheckAndSetDefaults verifies that the object is valid
+	CheckAndSetDefaults() error
+	// GetRepository returns the repository the policy applies to
+	GetRepository() string
+	// GetKeepVersions returns the number of latest versions of each
+	// package to keep. Zero means no version is kept by count
+	GetKeepVersions() int
+	// GetMaxAge returns the age of package versions to keep.
+	// Zero means no version is kept by age
+	GetMaxAge() time.Duration
+}
+
+// NewPackageRetentionPolicy returns a new retention policy resource
+func NewPackageRetentionPolicy(name string, spec PackageRetentionPolicySpecV2) PackageRetentionPolicy {
+	return &PackageRetentionPolicyV2{
+		Kind:    KindPackageRetention,
+		Version: teleservices.V2,
+		Metadata: teleservices.Metadata{
+			Name:      name,
+			Namespace: defaults.Namespace,
+		},
+		Spec: spec,
+	}
+}
+
+// PackageRetentionPolicyV2 defines the package retention policy of a repository
+type PackageRetentionPolicyV2 struct {
+	// Metadata is resource metadata
+	teleservices.Metadata `json:"metadata"`
+	// Kind is a resource kind
+	Kind string `json:"kind"`
+	// Version is a resource version
+	Version string `json:"version"`
+	// Spec defines the retention policy
+	Spec PackageRetentionPolicySpecV2 `json:"spec"`
+}
+
+// GetRepository returns the repository the policy applies to
+func (r *PackageRetentionPolicyV2) GetRepository() string {
+	return r.Spec.Repository
+}
+
+// GetKeepVersions returns the number of latest versions of each package to keep
+func (r *PackageRetentionPolicyV2) GetKeepVersions() int {
+	return r.Spec.KeepVersions
+}
+
+// GetMaxAge returns the age of package versions to keep
+func (r *PackageRetentionPolicyV2) GetMaxAge() time.Duration {
+	if r.Spec.MaxAge == nil {
+		return 0
+	}
+	return r.Spec.MaxAge.Value()
+}
+
+// CheckAndSetDefaults checks validity of all parameters and sets defaults
+func (r *PackageRetentionPolicyV2) CheckAndSetDefaults() error {
+	if r.Metadata.Name == "" {
+		return trace.BadParameter("missing parameter Name")
+	}
+	if r.Spec.Repository == "" {
+		return trace.BadParameter("missing parameter Repository")
+	}
+	if r.Spec.KeepVersions < 0 {
+		return trace.BadParameter("keep_versions can not be negative")
+	}
+	if r.GetMaxAge() < 0 {
+		return trace.BadParameter("max_age can not be negative")
+	}
+	if r.Spec.KeepVersions == 0 && r.GetMaxAge() == 0 {
+		return trace.BadParameter("retention policy %q must specify either keep_versions or max_age",
+			r.Metadata.Name)
+	}
+	return nil
+}
+
+// PackageRetentionPolicySpecV2 defines the package retention policy of a repository
+type PackageRetentionPolicySpecV2 struct {
+	// Repository is the package repository the policy applies to
+	Repository string `json:"repository"`
+	// KeepVersions is the number of latest versions of each package to keep
+	KeepVersions int `json:"keep_versions,omitempty"`
+	// MaxAge is the age of package versions to keep
+	MaxAge *teleservices.Duration `json:"max_age,omitempty"`
+}
+
+// UnmarshalPackageRetentionPolicy unmarshals a retention policy from JSON
+func UnmarshalPackageRetentionPolicy(data []byte) (PackageRetentionPolicy, error) {
+	if len(data) == 0 {
+		return nil, trace.BadParameter("empty retention policy")
+	}
+
+	jsonData, err := teleutils.ToJSON(data)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var hdr teleservices.ResourceHeader
+	err = json.Unmarshal(jsonData, &hdr)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	switch hdr.Version {
+	case teleservices.V2:
+		var policy PackageRetentionPolicyV2
+		err := teleutils.UnmarshalWithSchema(GetPackageRetentionPolicySchema(), &policy, jsonData)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		policy.Metadata.CheckAndSetDefaults()
+		return &policy, nil
+	}
+	return nil, trace.BadParameter(
+		"%v resource version %q is not supported", KindPackageRetention, hdr.Version)
+}
+
+// MarshalPackageRetentionPolicy marshals a retention policy into JSON
+func MarshalPackageRetentionPolicy(policy PackageRetentionPolicy, opts ...teleservices.MarshalOption) ([]byte, error) {
+	return json.Marshal(policy)
+}
+
+// PackageRetentionPolicySpecV2Schema is JSON schema for a retention policy
+const PackageRetentionPolicySpecV2Schema = `{
+  "type": "object",
+  "additionalProperties": false,
+  "required": ["repository"],
+  "properties": {
+    "repository": {"type": "string"},
+    "keep_versions": {"type": "number"},
+    "max_age": {"type": "string"}
+  }
+}`
+
+// GetPackageRetentionPolicySchema returns retention policy schema for version V2
+func GetPackageRetentionPolicySchema() string {
+	return fmt.Sprintf(teleservices.V2SchemaTemplate, teleservices.MetadataSchema,
+		PackageRetentionPolicySpecV2Schema, "")
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"time"
+
+	check "gopkg.in/check.v1"
+)
+
+type PackageRetentionSuite struct{}
+
+var _ = check.Suite(&PackageRetentionSuite{})
+
+func (s *PackageRetentionSuite) TestUnmarshalsPolicy(c *check.C) {
+	spec := `kind: packageretention
+version: v2
+metadata:
+  name: ci-builds
+spec:
+  repository: example.com
+  keep_versions: 5
+  max_age: 720h
+`
+	policy, err := UnmarshalPackageRetentionPolicy([]byte(spec))
+	c.Assert(err, check.IsNil)
+	c.Assert(policy.CheckAndSetDefaults(), check.IsNil)
+	c.Assert(policy.GetRepository(), check.Equals, "example.com")
+	c.Assert(policy.GetKeepVersions(), check.Equals, 5)
+	c.Assert(policy.GetMaxAge(), check.Equals, 720*time.Hour)
+
+	data, err := MarshalPackageRetentionPolicy(policy)
+	c.Assert(err, check.IsNil)
+	unmarshaled, err := UnmarshalPackageRetentionPolicy(data)
+	c.Assert(err, check.IsNil)
+	c.Assert(unmarshaled, check.DeepEquals, policy)
+}
+
+func (s *PackageRetentionSuite) TestValidatesPolicy(c *check.C) {
+	policy := NewPackageRetentionPolicy("ci-builds", PackageRetentionPolicySpecV2{
+		Repository: "example.com",
+	})
+	c.Assert(policy.CheckAndSetDefaults(), check.NotNil)
+
+	policy = NewPackageRetentionPolicy("ci-builds", PackageRetentionPolicySpecV2{
+		Repository:   "example.com",
+		KeepVersions: -1,
+	})
+	c.Assert(policy.CheckAndSetDefaults(), check.NotNil)
+
+	policy = NewPackageRetentionPolicy("ci-builds", PackageRetentionPolicySpecV2{
+		KeepVersions: 3,
+	})
+	c.Assert(policy.CheckAndSetDefaults(), check.NotNil)
+}
@@ -84,6 +84,24 @@ type OperationPhase struct {
 	Data *OperationPhaseData `json:"data,omitempty" yaml:"data,omitempty"`
 	// Error is the error that happened during phase execution
 	Error *trace.RawTrace `json:"error"`
+	// Approval is the optional operator approval gate of the phase
+	Approval *PhaseApproval `json:"approval,omitempty" yaml:"approval,omitempty"`
+}
+
+// PhaseApproval describes the operator approval gate of a phase.
+// A phase that requires approval is not executed until it has been approved
+type PhaseApproval struct {
+	// Required is whether the phase requires approval before it is executed
+	Required bool `json:"required" yaml:"required"`
+	// ApprovedBy is the user who has approved the phase
+	ApprovedBy string `json:"approved_by,omitempty" yaml:"approved_by,omitempty"`
+	// Approved is the approval time
+	Approved time.Time `json:"approved,omitempty" yaml:"approved,omitempty"`
+}
+
+// IsApproved returns true if the phase has been approved
+func (r PhaseApproval) IsApproved() bool {
+	return !r.Approved.IsZero()
 }
 
 // OperationPhaseData represents data attached to an operation phase
@@ -118,10 +136,29 @@ type OperationPhaseData struct {
 	// ServiceUser specifies the optional service user to use as a context
 	// for file operations
 	ServiceUser *OSUser `json:"service_user,omitempty" yaml:"service_user,omitempty"`
+	// PackageCache is the directory with pre-seeded packages on the node
+	PackageCache string `json:"package_cache,omitempty" yaml:"package_cache,omitempty"`
 	// Data is arbitrary text data to provide to a phase executor
 	Data string `json:"data,omitempty" yaml:"data,omitempty"`
 	// DNSConfig specifies custom cluster DNS configuration
 	DNSConfig *DNSConfig `json:"dns_config,omitempty" yaml:"dns_config,omitempty"`
+	// Taint is the node taint the phase operates on
+	Taint *NodeTaint `json:"taint,omitempty" yaml:"taint,omitempty"`
+	// Images is the list of Docker images the phase operates on
+	Images []string `json:"images,omitempty" yaml:"images,omitempty"`
+	// RollbackOnFailure specifies whether the operation should be rolled back
+	// automatically if this phase fails
+	RollbackOnFailure bool `json:"rollback_on_failure,omitempty" yaml:"rollback_on_failure,omitempty"`
+}
+
+// NodeTaint describes a Kubernetes node taint
+type NodeTaint struct {
+	// Key is the taint key
+	Key string `json:"key" yaml:"key"`
+	// Value is the taint value
+	Value string `json:"value,omitempty" yaml:"value,omitempty"`
+	// Effect is the taint effect
+	Effect string `json:"effect" yaml:"effect"`
 }
 
 // ElectionChange describes changes to make to cluster elections
@@ -148,16 +185,20 @@ type PlanChange struct {
 	Created time.Time `json:"created"`
 	// Error is the error that happened during phase execution
 	Error *trace.RawTrace `json:"error"`
+	// Approval is set if the change updates the phase approval gate
+	// instead of the phase state. NewState of such a change is the
+	// unchanged state of the phase
+	Approval *PhaseApproval `json:"approval,omitempty"`
 }
 
 // PlanChangelog is a list of plan state changes
 type PlanChangelog []PlanChange
 
-// Latest returns the most recent plan change entry for the specified phase
+// Latest returns the most recent plan state change entry for the specified phase
 func (c PlanChangelog) Latest(phaseID string) *PlanChange {
 	var latest *PlanChange
 	for i, change := range c {
-		if change.PhaseID != phaseID {
+		if change.PhaseID != phaseID || change.Approval != nil {
 			continue
 		}
 		if latest == nil || change.Created.After(latest.Created) {
@@ -167,6 +208,27 @@ func (c PlanChangelog) Latest(phaseID string) *PlanChange {
 	return latest
 }
 
+// LatestApproval returns the most recent approval gate change entry
+// for the specified phase
+func (c PlanChangelog) LatestApproval(phaseID string) *PlanChange {
+	var latest *PlanChange
+	for i, change := range c {
+		if change.PhaseID != phaseID || change.Approval == nil {
+			continue
+		}
+		if latest == nil || change.Created.After(latest.Created) {
+			latest = &(c[i])
+		}
+	}
+	return latest
+}
+
+// IsAwaitingApproval returns true if the phase requires approval
+// and has not been approved yet
+func (p OperationPhase) IsAwaitingApproval() bool {
+	return p.Approval != nil && p.Approval.Required && !p.Approval.IsApproved()
+}
+
 // HasSubphases returns true if the phase has 1 or more subphases
 func (p OperationPhase) HasSubphases() bool {
 	return len(p.Phases) > 0
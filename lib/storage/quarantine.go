@@ -0,0 +1,55 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// QuarantinedNode describes a cluster node quarantined for repeatedly
+// joining and leaving the cluster
+type QuarantinedNode struct {
+	// AdvertiseIP is the advertise IP address of the node
+	AdvertiseIP string `json:"advertise_ip"`
+	// Hostname is the node hostname
+	Hostname string `json:"hostname,omitempty"`
+	// Transitions is the number of membership transitions the node has
+	// made within the detection window when it was quarantined
+	Transitions int `json:"transitions"`
+	// Since is the time the node was quarantined
+	Since time.Time `json:"since"`
+}
+
+// String returns a textual representation of this quarantined node
+func (n QuarantinedNode) String() string {
+	return fmt.Sprintf("node(addr=%v, hostname=%v, transitions=%v, since=%v)",
+		n.AdvertiseIP, n.Hostname, n.Transitions, n.Since.Format(time.RFC3339))
+}
+
+// QuarantinedNodes is a list of quarantined nodes
+type QuarantinedNodes []QuarantinedNode
+
+// Find returns the quarantined node with the specified advertise address
+func (r QuarantinedNodes) Find(advertiseIP string) *QuarantinedNode {
+	for i, node := range r {
+		if node.AdvertiseIP == advertiseIP {
+			return &r[i]
+		}
+	}
+	return nil
+}
@@ -56,6 +56,31 @@ const (
 	KindEndpoints = "endpoints"
 	// KindAuthGateway defines the auth gateway resource type
 	KindAuthGateway = "authgateway"
+	// KindDNSProvider defines the external DNS provider resource type
+	KindDNSProvider = "dnsprovider"
+	// KindIngressController defines the bundled ingress controller resource type
+	KindIngressController = "ingresscontroller"
+	// KindTimeSync defines the cluster time synchronization resource type
+	KindTimeSync = "timesync"
+	// KindBandwidthProfile defines the cluster bandwidth profile resource type
+	KindBandwidthProfile = "bandwidthprofile"
+	// KindAppOverlay defines the bundled application overlay resource type
+	KindAppOverlay = "appoverlay"
+	// KindLogRotation defines the node log rotation policy resource type
+	KindLogRotation = "logrotation"
+	// KindWebhook defines the cluster lifecycle events webhook resource type
+	KindWebhook = "webhook"
+	// KindTrustedCA defines the trusted CA bundle resource type
+	KindTrustedCA = "trustedca"
+	// KindOperationReaper defines the abandoned operations cleanup resource type
+	KindOperationReaper = "operationreaper"
+	// KindPackageRetention defines the package repository retention policy resource type
+	KindPackageRetention = "packageretention"
+	// KindSecretBackend defines the external secret backend resource type
+	KindSecretBackend = "secretbackend"
+	// KindOperation represents cluster operations in role rules.
+	// The verbs of the rules are operation types, e.g. "expand" or "update"
+	KindOperation = "operation"
 )
 
 // SupportedGravityResources is a list of resources supported by
@@ -71,6 +96,17 @@ var SupportedGravityResources = []string{
 	KindAlertTarget,
 	KindTLSKeyPair,
 	KindAuthGateway,
+	KindDNSProvider,
+	KindIngressController,
+	KindTimeSync,
+	KindBandwidthProfile,
+	KindAppOverlay,
+	KindLogRotation,
+	KindWebhook,
+	KindTrustedCA,
+	KindOperationReaper,
+	KindPackageRetention,
+	KindSecretBackend,
 }
 
 // SupportedGravityResourcesToRemove is a list of resources supported by
@@ -84,6 +120,17 @@ var SupportedGravityResourcesToRemove = []string{
 	KindAlert,
 	KindAlertTarget,
 	KindTLSKeyPair,
+	KindDNSProvider,
+	KindIngressController,
+	KindTimeSync,
+	KindBandwidthProfile,
+	KindAppOverlay,
+	KindLogRotation,
+	KindWebhook,
+	KindTrustedCA,
+	KindOperationReaper,
+	KindPackageRetention,
+	KindSecretBackend,
 }
 
 // MetadataSchema is a copy of teleport/lib/services.MetadataSchema but with
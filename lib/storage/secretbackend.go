@@ -0,0 +1,368 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+
+	teleservices "github.com/gravitational/teleport/lib/services"
+	teleutils "github.com/gravitational/teleport/lib/utils"
+	"github.com/gravitational/trace"
+)
+
+const (
+	// SecretBackendVault reads secrets from the HashiCorp Vault
+	// key/value secrets engine
+	SecretBackendVault = "vault"
+)
+
+// SecretBackend describes an external secret backend sensitive cluster
+// configuration is sourced from.
+//
+// Every secret read from the backend is written to its target in the cluster:
+// a key of a Kubernetes secret or the client secret of a Github connector.
+// Secrets are read periodically so the targets pick up rotated values
+type SecretBackend interface {
+	// Resource provides common resource methods
+	teleservices.Resource
+	// CheckAndSetDefaults verifies that the object is valid
+	CheckAndSetDefaults() error
+	// GetType returns the backend type
+	GetType() string
+	// GetRefreshInterval returns how often secrets are read from the backend
+	GetRefreshInterval() time.Duration
+	// GetVault returns Vault backend settings
+	GetVault() *VaultSpec
+	// GetSecrets returns the secrets sourced from the backend
+	GetSecrets() []SecretSpec
+	// WithoutSecrets returns a copy of the backend without credentials
+	WithoutSecrets() SecretBackend
+}
+
+// NewSecretBackend returns a new secret backend resource
+func NewSecretBackend(name string, spec SecretBackendSpecV2) SecretBackend {
+	return &SecretBackendV2{
+		Kind:    KindSecretBackend,
+		Version: teleservices.V2,
+		Metadata: teleservices.Metadata{
+			Name:      name,
+			Namespace: defaults.Namespace,
+		},
+		Spec: spec,
+	}
+}
+
+// SecretBackendV2 defines an external secret backend
+type SecretBackendV2 struct {
+	// Metadata is resource metadata
+	teleservices.Metadata `json:"metadata"`
+	// Kind is a resource kind
+	Kind string `json:"kind"`
+	// Version is a resource version
+	Version string `json:"version"`
+	// Spec defines the secret backend
+	Spec SecretBackendSpecV2 `json:"spec"`
+}
+
+// GetType returns the backend type
+func (r *SecretBackendV2) GetType() string {
+	return r.Spec.Type
+}
+
+// GetRefreshInterval returns how often secrets are read from the backend
+func (r *SecretBackendV2) GetRefreshInterval() time.Duration {
+	return r.Spec.RefreshInterval.Value()
+}
+
+// GetVault returns Vault backend settings
+func (r *SecretBackendV2) GetVault() *VaultSpec {
+	return r.Spec.Vault
+}
+
+// GetSecrets returns the secrets sourced from the backend
+func (r *SecretBackendV2) GetSecrets() []SecretSpec {
+	return r.Spec.Secrets
+}
+
+// WithoutSecrets returns a copy of the backend without credentials
+func (r *SecretBackendV2) WithoutSecrets() SecretBackend {
+	out := *r
+	if r.Spec.Vault != nil {
+		vault := *r.Spec.Vault
+		vault.Token = ""
+		out.Spec.Vault = &vault
+	}
+	return &out
+}
+
+// CheckAndSetDefaults checks validity of all parameters and sets defaults
+func (r *SecretBackendV2) CheckAndSetDefaults() error {
+	if r.Metadata.Name == "" {
+		return trace.BadParameter("missing parameter Name")
+	}
+	if r.Spec.RefreshInterval.Value() == 0 {
+		r.Spec.RefreshInterval = teleservices.NewDuration(defaults.SecretBackendRefreshInterval)
+	}
+	if r.Spec.RefreshInterval.Value() < defaults.SecretBackendMinRefreshInterval {
+		return trace.BadParameter("refresh_interval can not be less than %v",
+			defaults.SecretBackendMinRefreshInterval)
+	}
+	switch r.Spec.Type {
+	case SecretBackendVault:
+		if r.Spec.Vault == nil {
+			return trace.BadParameter("vault backend requires vault settings")
+		}
+		if err := r.Spec.Vault.Check(); err != nil {
+			return trace.Wrap(err)
+		}
+	default:
+		return trace.BadParameter("unsupported secret backend type %q, supported are: %v",
+			r.Spec.Type, []string{SecretBackendVault})
+	}
+	if len(r.Spec.Secrets) == 0 {
+		return trace.BadParameter("at least one secret is required")
+	}
+	targets := make(map[string]struct{})
+	for _, secret := range r.Spec.Secrets {
+		if err := secret.Check(); err != nil {
+			return trace.Wrap(err)
+		}
+		if _, ok := targets[secret.Target()]; ok {
+			return trace.BadParameter("multiple secrets are written to %v", secret.Target())
+		}
+		targets[secret.Target()] = struct{}{}
+	}
+	return nil
+}
+
+// SecretBackendSpecV2 defines an external secret backend
+type SecretBackendSpecV2 struct {
+	// Type is the backend type: vault
+	Type string `json:"type"`
+	// RefreshInterval is how often secrets are read from the backend
+	RefreshInterval teleservices.Duration `json:"refresh_interval,omitempty"`
+	// Vault defines Vault backend settings
+	Vault *VaultSpec `json:"vault,omitempty"`
+	// Secrets lists the secrets sourced from the backend
+	Secrets []SecretSpec `json:"secrets"`
+}
+
+// VaultSpec defines HashiCorp Vault backend settings
+type VaultSpec struct {
+	// Address is the address of the Vault server, e.g. https://vault:8200
+	Address string `json:"address"`
+	// Token is the Vault token used to read secrets
+	Token string `json:"token,omitempty"`
+	// Namespace is the optional Vault Enterprise namespace
+	Namespace string `json:"namespace,omitempty"`
+	// CACert is the optional PEM-encoded CA certificate to verify the server with
+	CACert string `json:"ca_cert,omitempty"`
+}
+
+// Check makes sure the Vault settings are valid
+func (r VaultSpec) Check() error {
+	if r.Address == "" {
+		return trace.BadParameter("vault backend requires address")
+	}
+	if _, err := url.ParseRequestURI(r.Address); err != nil {
+		return trace.BadParameter("invalid vault address %q: %v", r.Address, err)
+	}
+	if r.Token == "" {
+		return trace.BadParameter("vault backend requires token")
+	}
+	return nil
+}
+
+// SecretSpec defines a single secret read from the backend along with
+// the target it is written to. Exactly one target has to be specified
+type SecretSpec struct {
+	// Path is the path of the secret in the backend.
+	// For the Vault key/value secrets engine version 2, the path
+	// includes the data prefix, e.g. secret/data/gravity/registry
+	Path string `json:"path"`
+	// Key is the key of the value within the secret
+	Key string `json:"key"`
+	// Kubernetes writes the value to a Kubernetes secret
+	Kubernetes *KubernetesSecretTarget `json:"kubernetes,omitempty"`
+	// Github writes the value as the client secret of a Github connector
+	Github *GithubConnectorTarget `json:"github,omitempty"`
+}
+
+// Check makes sure the secret is valid
+func (r SecretSpec) Check() error {
+	if r.Path == "" {
+		return trace.BadParameter("secret path cannot be empty")
+	}
+	if r.Key == "" {
+		return trace.BadParameter("secret %v is missing key", r.Path)
+	}
+	switch {
+	case r.Kubernetes != nil && r.Github != nil:
+		return trace.BadParameter("secret %v has multiple targets", r.Path)
+	case r.Kubernetes != nil:
+		if r.Kubernetes.Name == "" || r.Kubernetes.Key == "" {
+			return trace.BadParameter("secret %v requires kubernetes secret name and key", r.Path)
+		}
+	case r.Github != nil:
+		if r.Github.Connector == "" {
+			return trace.BadParameter("secret %v requires github connector name", r.Path)
+		}
+	default:
+		return trace.BadParameter("secret %v is missing target", r.Path)
+	}
+	return nil
+}
+
+// Target returns the human-readable description of the secret target
+func (r SecretSpec) Target() string {
+	switch {
+	case r.Kubernetes != nil:
+		return fmt.Sprintf("secret %v/%v:%v", r.Kubernetes.GetNamespace(),
+			r.Kubernetes.Name, r.Kubernetes.Key)
+	case r.Github != nil:
+		return fmt.Sprintf("github connector %v", r.Github.Connector)
+	}
+	return ""
+}
+
+// KubernetesSecretTarget defines a key of a Kubernetes secret
+type KubernetesSecretTarget struct {
+	// Namespace is the secret namespace, defaults to kube-system
+	Namespace string `json:"namespace,omitempty"`
+	// Name is the secret name
+	Name string `json:"name"`
+	// Key is the key within the secret
+	Key string `json:"key"`
+	// Type is the type the secret is created with, defaults to Opaque
+	Type string `json:"type,omitempty"`
+}
+
+// GetNamespace returns the secret namespace
+func (r KubernetesSecretTarget) GetNamespace() string {
+	if r.Namespace == "" {
+		return defaults.KubeSystemNamespace
+	}
+	return r.Namespace
+}
+
+// GithubConnectorTarget defines a Github auth connector
+type GithubConnectorTarget struct {
+	// Connector is the name of the Github connector
+	Connector string `json:"connector"`
+}
+
+// UnmarshalSecretBackend unmarshals a secret backend from JSON
+func UnmarshalSecretBackend(data []byte) (SecretBackend, error) {
+	if len(data) == 0 {
+		return nil, trace.BadParameter("empty secret backend")
+	}
+
+	jsonData, err := teleutils.ToJSON(data)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var hdr teleservices.ResourceHeader
+	err = json.Unmarshal(jsonData, &hdr)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	switch hdr.Version {
+	case teleservices.V2:
+		var backend SecretBackendV2
+		err := teleutils.UnmarshalWithSchema(GetSecretBackendSchema(), &backend, jsonData)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		backend.Metadata.CheckAndSetDefaults()
+		backend.Spec.Type = strings.ToLower(backend.Spec.Type)
+		return &backend, nil
+	}
+	return nil, trace.BadParameter(
+		"%v resource version %q is not supported", KindSecretBackend, hdr.Version)
+}
+
+// MarshalSecretBackend marshals a secret backend into JSON
+func MarshalSecretBackend(backend SecretBackend, opts ...teleservices.MarshalOption) ([]byte, error) {
+	return json.Marshal(backend)
+}
+
+// SecretBackendSpecV2Schema is JSON schema for a secret backend
+const SecretBackendSpecV2Schema = `{
+  "type": "object",
+  "additionalProperties": false,
+  "required": ["type", "secrets"],
+  "properties": {
+    "type": {"type": "string"},
+    "refresh_interval": {"type": "string"},
+    "vault": {
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["address"],
+      "properties": {
+        "address": {"type": "string"},
+        "token": {"type": "string"},
+        "namespace": {"type": "string"},
+        "ca_cert": {"type": "string"}
+      }
+    },
+    "secrets": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "additionalProperties": false,
+        "required": ["path", "key"],
+        "properties": {
+          "path": {"type": "string"},
+          "key": {"type": "string"},
+          "kubernetes": {
+            "type": "object",
+            "additionalProperties": false,
+            "required": ["name", "key"],
+            "properties": {
+              "namespace": {"type": "string"},
+              "name": {"type": "string"},
+              "key": {"type": "string"},
+              "type": {"type": "string"}
+            }
+          },
+          "github": {
+            "type": "object",
+            "additionalProperties": false,
+            "required": ["connector"],
+            "properties": {
+              "connector": {"type": "string"}
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+
+// GetSecretBackendSchema returns secret backend schema for version V2
+func GetSecretBackendSchema() string {
+	return fmt.Sprintf(teleservices.V2SchemaTemplate, teleservices.MetadataSchema,
+		SecretBackendSpecV2Schema, "")
+}
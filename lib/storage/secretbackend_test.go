@@ -0,0 +1,109 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"github.com/gravitational/gravity/lib/defaults"
+
+	check "gopkg.in/check.v1"
+)
+
+type SecretBackendSuite struct{}
+
+var _ = check.Suite(&SecretBackendSuite{})
+
+func (s *SecretBackendSuite) TestUnmarshalsBackend(c *check.C) {
+	spec := `kind: secretbackend
+version: v2
+metadata:
+  name: vault
+spec:
+  type: vault
+  vault:
+    address: https://vault.example.com:8200
+    token: s.token
+  secrets:
+  - path: secret/data/gravity/registry
+    key: dockerconfigjson
+    kubernetes:
+      namespace: default
+      name: registry-credentials
+      key: .dockerconfigjson
+      type: kubernetes.io/dockerconfigjson
+  - path: secret/data/gravity/github
+    key: client_secret
+    github:
+      connector: github
+`
+	backend, err := UnmarshalSecretBackend([]byte(spec))
+	c.Assert(err, check.IsNil)
+	c.Assert(backend.CheckAndSetDefaults(), check.IsNil)
+	c.Assert(backend.GetRefreshInterval(), check.Equals, defaults.SecretBackendRefreshInterval)
+	c.Assert(backend.GetSecrets(), check.HasLen, 2)
+	c.Assert(backend.GetSecrets()[0].Target(), check.Equals,
+		"secret default/registry-credentials:.dockerconfigjson")
+	c.Assert(backend.GetSecrets()[1].Target(), check.Equals, "github connector github")
+
+	data, err := MarshalSecretBackend(backend)
+	c.Assert(err, check.IsNil)
+	unmarshaled, err := UnmarshalSecretBackend(data)
+	c.Assert(err, check.IsNil)
+	c.Assert(unmarshaled, check.DeepEquals, backend)
+
+	c.Assert(backend.WithoutSecrets().GetVault().Token, check.Equals, "")
+	c.Assert(backend.GetVault().Token, check.Equals, "s.token")
+}
+
+func (s *SecretBackendSuite) TestValidatesBackend(c *check.C) {
+	vault := &VaultSpec{Address: "https://vault:8200", Token: "token"}
+	target := &KubernetesSecretTarget{Name: "registry", Key: "password"}
+	testCases := []struct {
+		spec    SecretBackendSpecV2
+		comment string
+	}{
+		{
+			spec:    SecretBackendSpecV2{Type: "keyring", Vault: vault},
+			comment: "unsupported type",
+		},
+		{
+			spec:    SecretBackendSpecV2{Type: SecretBackendVault, Vault: vault},
+			comment: "no secrets",
+		},
+		{
+			spec: SecretBackendSpecV2{Type: SecretBackendVault, Vault: &VaultSpec{Address: "https://vault:8200"},
+				Secrets: []SecretSpec{{Path: "secret/registry", Key: "password", Kubernetes: target}}},
+			comment: "missing token",
+		},
+		{
+			spec: SecretBackendSpecV2{Type: SecretBackendVault, Vault: vault,
+				Secrets: []SecretSpec{{Path: "secret/registry", Key: "password"}}},
+			comment: "missing target",
+		},
+		{
+			spec: SecretBackendSpecV2{Type: SecretBackendVault, Vault: vault,
+				Secrets: []SecretSpec{
+					{Path: "secret/registry", Key: "password", Kubernetes: target},
+					{Path: "secret/registry2", Key: "password", Kubernetes: target},
+				}},
+			comment: "duplicate target",
+		},
+	}
+	for _, tc := range testCases {
+		backend := NewSecretBackend("vault", tc.spec)
+		c.Assert(backend.CheckAndSetDefaults(), check.NotNil, check.Commentf(tc.comment))
+	}
+}
@@ -432,6 +432,9 @@ type SiteOperation struct {
 	Uninstall *UninstallOperationState `json:"uninstall,omitempty"`
 	// Update is for updating application on the gravity site
 	Update *UpdateOperationState `json:"update,omitempty"`
+	// LocksRelease records the forced release of the resources
+	// held by this operation
+	LocksRelease *OperationLocksRelease `json:"locks_release,omitempty"`
 }
 
 func (s *SiteOperation) Check() error {
@@ -493,6 +496,9 @@ const (
 	ReasonStatusCheckFailed Reason = "status_check_failed"
 	// ReasonClusterDegraded means one or more of cluster nodes are degraded
 	ReasonClusterDegraded Reason = "cluster_degraded"
+	// ReasonServiceFailed means a critical system service on one of the nodes
+	// keeps failing after being restarted
+	ReasonServiceFailed Reason = "service_failed"
 )
 
 // Description returns human-readable description of the reason
@@ -504,6 +510,8 @@ func (r *Reason) Description() string {
 		return "application status check failed"
 	case ReasonClusterDegraded:
 		return "one or more of cluster nodes are not healthy"
+	case ReasonServiceFailed:
+		return "a system service keeps failing on one of cluster nodes"
 	default:
 		return "unknown reason"
 	}
@@ -511,7 +519,7 @@ func (r *Reason) Description() string {
 
 func (r *Reason) Check() error {
 	switch *r {
-	case "", ReasonLicenseInvalid, ReasonStatusCheckFailed, ReasonClusterDegraded:
+	case "", ReasonLicenseInvalid, ReasonStatusCheckFailed, ReasonClusterDegraded, ReasonServiceFailed:
 		return nil
 	}
 	return trace.BadParameter("unsupported reason: %s", *r)
@@ -851,8 +859,14 @@ type Package struct {
 	Name string `json:"name"`
 	// Version is a package version in SemVer format
 	Version string `json:"version"`
+	// Arch is the architecture of the package variant, e.g. arm64.
+	// Empty for packages built for the default architecture
+	Arch string `json:"arch,omitempty"`
 	// SHA512 is a sha512 hash of the data in storage
 	SHA512 string `json:"checksum"`
+	// SHA256 is a sha256 digest of the package data used to verify
+	// the package contents when it is read
+	SHA256 string `json:"sha256,omitempty"`
 	// SizePytes is a package size in bytes
 	SizeBytes int `json:"size_bytes"`
 	// Created is the time the package was created at
@@ -883,6 +897,7 @@ func (p *Package) Locator() loc.Locator {
 		Repository: p.Repository,
 		Name:       p.Name,
 		Version:    p.Version,
+		Arch:       p.Arch,
 	}
 }
 
@@ -896,7 +911,7 @@ func (p *Package) SetRuntimeLabel(name, val string) {
 }
 
 func (p Package) String() string {
-	return fmt.Sprintf("package(%v/%v:%v)", p.Repository, p.Name, p.Version)
+	return fmt.Sprintf("package(%v)", p.Locator())
 }
 
 func (p *Package) Check() error {
@@ -912,6 +927,56 @@ func (p *Package) Check() error {
 	return nil
 }
 
+// PackageAlias maps an alias version of a package (e.g. "@current")
+// to a concrete package version in the same repository
+type PackageAlias struct {
+	// Repository is a package repository
+	Repository string `json:"repository"`
+	// Name is a package name
+	Name string `json:"name"`
+	// Alias is the alias version, e.g. "@current"
+	Alias string `json:"alias"`
+	// Version is the version of the package the alias points to
+	Version string `json:"version"`
+	// Updated is the time the alias was last updated
+	Updated time.Time `json:"updated"`
+}
+
+// Locator returns the alias locator, e.g. gravitational.io/runtime:@current
+func (a PackageAlias) Locator() loc.Locator {
+	return loc.Locator{
+		Repository: a.Repository,
+		Name:       a.Name,
+		Version:    a.Alias,
+	}
+}
+
+// Target returns the locator of the package the alias points to
+func (a PackageAlias) Target() loc.Locator {
+	return loc.Locator{
+		Repository: a.Repository,
+		Name:       a.Name,
+		Version:    a.Version,
+	}
+}
+
+// Check checks the validity of the alias
+func (a PackageAlias) Check() error {
+	if a.Repository == "" {
+		return trace.BadParameter("missing repository name")
+	}
+	if a.Name == "" {
+		return trace.BadParameter("missing package name")
+	}
+	if !loc.IsAlias(a.Alias) {
+		return trace.BadParameter("invalid package alias %q", a.Alias)
+	}
+	if a.Version == "" || loc.IsAlias(a.Version) {
+		return trace.BadParameter("invalid package alias target version %q", a.Version)
+	}
+	return nil
+}
+
 // Repositories interface provides operations on repositories and
 // packages. Repository is a collection of packages - arbitrary blobs
 // with metadata, name and version.
@@ -936,11 +1001,22 @@ type Repositories interface {
 	// UpsertPackage creates or updates a package in a repository
 	UpsertPackage(p Package) (*Package, error)
 
-	// DeletePackage deletes a package from repository
-	DeletePackage(repository string, packageName, packageVersion string) error
+	// DeletePackage deletes a package from repository.
+	// arch specifies the architecture of the package variant, if any
+	DeletePackage(repository string, packageName, packageVersion, arch string) error
 
-	// GetPackage returns a package by it's name and version a repository
-	GetPackage(repository string, packageName, packageVersion string) (*Package, error)
+	// GetBLOBReferences returns the number of packages that reference
+	// the BLOB with the specified hash
+	GetBLOBReferences(hash string) (int, error)
+
+	// DeleteBLOB invokes deleteFn to delete the BLOB with the specified hash
+	// unless the BLOB is referenced by a package. Packages referencing the BLOB
+	// can not be created while it is being deleted
+	DeleteBLOB(hash string, deleteFn func() error) error
+
+	// GetPackage returns a package by it's name and version a repository.
+	// arch specifies the architecture of the package variant, if any
+	GetPackage(repository string, packageName, packageVersion, arch string) (*Package, error)
 
 	// GetPackages returns s list of packages in a repository, in case if
 	// if prevName and prevVersion are not empty, returns packages greater
@@ -949,7 +1025,19 @@ type Repositories interface {
 
 	// UpdatePackageRuntimeLabels is an atomic operation that sets runtime labels
 	// for a set of package, adding and removing labels in one atomic operation
-	UpdatePackageRuntimeLabels(repository, packageName, packageVersion string, addLabels map[string]string, removeLabels []string) error
+	UpdatePackageRuntimeLabels(repository, packageName, packageVersion, arch string, addLabels map[string]string, removeLabels []string) error
+
+	// UpsertPackageAlias creates or atomically updates the target of a package alias
+	UpsertPackageAlias(alias PackageAlias) (*PackageAlias, error)
+
+	// GetPackageAlias returns the package alias by repository, package name and alias
+	GetPackageAlias(repository, packageName, alias string) (*PackageAlias, error)
+
+	// GetPackageAliases returns all package aliases in a repository
+	GetPackageAliases(repository string) ([]PackageAlias, error)
+
+	// DeletePackageAlias deletes the package alias
+	DeletePackageAlias(repository, packageName, alias string) error
 }
 
 // Permission represent action that user can perform on objects
@@ -1019,6 +1107,9 @@ type LoginEntry struct {
 	AccountID string `yaml:"account_id"`
 	// Created is when the entry was created
 	Created time.Time `yaml:"created"`
+	// RateLimit optionally limits the rate of package transfers
+	// to and from the OpsCenter
+	RateLimit utils.TransferRate `yaml:"rate_limit,omitempty"`
 }
 
 func (l *LoginEntry) Check() error {
@@ -1523,6 +1614,9 @@ type Server struct {
 	Provisioner string `json:"provisioner"`
 	// OSInfo identifies the host operating system
 	OSInfo OSInfo `json:"os"`
+	// Arch is the server architecture, e.g. arm64.
+	// Empty for servers that joined before the architecture was recorded
+	Arch string `json:"arch,omitempty"`
 	// Mounts lists mount configurations for a server profile instance
 	Mounts []Mount `json:"mounts"`
 	// SystemState defines the system configuration for gravity - location
@@ -1534,6 +1628,8 @@ type Server struct {
 	Docker Docker `json:"docker"`
 	// User is current OS user information
 	User OSUser `json:"user"`
+	// Hardware is the server hardware profile collected during discovery
+	Hardware *HardwareProfile `json:"hardware,omitempty"`
 	// Created is the timestamp when the server was created
 	Created time.Time `json:"created"`
 }
@@ -1739,6 +1835,8 @@ type OperationVariables struct {
 	OnPrem OnPremVariables `json:"onprem"`
 	// AWS is a set of AWS-specific variables
 	AWS AWSVariables `json:"aws"`
+	// Parameters maps application configuration parameters to values
+	Parameters map[string]string `json:"parameters,omitempty"`
 }
 
 // ToMap converts operation variables into a JSON object for easier use in templates
@@ -1769,6 +1867,21 @@ type SystemVariables struct {
 	TeleportProxyAddress string `json:"teleport_proxy_address"`
 	// Docker overrides configuration from the manifest
 	Docker DockerConfig `json:"docker"`
+	// Lightweight is whether the operation installs a minimal
+	// single-node cluster for local development
+	Lightweight bool `json:"lightweight,omitempty"`
+	// BatchNodes lists nodes admitted to join the cluster as a part of
+	// a single batch expand operation
+	BatchNodes []BatchNode `json:"batch_nodes,omitempty"`
+}
+
+// BatchNode describes a node expected to join the cluster within
+// a batch expand operation
+type BatchNode struct {
+	// AdvertiseIP is the advertise IP address of the node
+	AdvertiseIP string `json:"advertise_ip"`
+	// Role is the node profile name
+	Role string `json:"role"`
 }
 
 // IsEmpty returns whether this configuration is empty
@@ -558,7 +558,7 @@ func (s *StorageSuite) RepositoriesCRUD(c *C) {
 	c.Assert(*out, DeepEquals, p2)
 
 	// now get the package p1 from repository a
-	op1, err := s.Backend.GetPackage(a.GetName(), p1.Name, p1.Version)
+	op1, err := s.Backend.GetPackage(a.GetName(), p1.Name, p1.Version, "")
 	c.Assert(err, IsNil)
 	c.Assert(op1, DeepEquals, p1)
 
@@ -568,11 +568,11 @@ func (s *StorageSuite) RepositoriesCRUD(c *C) {
 	c.Assert(ops1, DeepEquals, []storage.Package{*p1})
 
 	// the package p2 is not found in repository a
-	_, err = s.Backend.GetPackage(a.GetName(), p2.Name, p2.Version)
+	_, err = s.Backend.GetPackage(a.GetName(), p2.Name, p2.Version, "")
 	c.Assert(trace.IsNotFound(err), Equals, true, Commentf("unexpected type: %T", err))
 
 	// the package p2 is found in repository b
-	op2, err := s.Backend.GetPackage(b.GetName(), p2.Name, p2.Version)
+	op2, err := s.Backend.GetPackage(b.GetName(), p2.Name, p2.Version, "")
 	c.Assert(err, IsNil)
 	c.Assert(*op2, DeepEquals, p2)
 
@@ -585,36 +585,36 @@ func (s *StorageSuite) RepositoriesCRUD(c *C) {
 	c.Assert(*op2, DeepEquals, p2)
 
 	// make sure package was updated
-	op2, err = s.Backend.GetPackage(b.GetName(), p2.Name, p2.Version)
+	op2, err = s.Backend.GetPackage(b.GetName(), p2.Name, p2.Version, "")
 	c.Assert(err, IsNil)
 	c.Assert(*op2, DeepEquals, p2)
 
 	// add some labels to p1 and remove some labels to p2
 	err = s.Backend.UpdatePackageRuntimeLabels(
-		a.GetName(), p1.Name, p1.Version, map[string]string{"a": "b"}, nil)
+		a.GetName(), p1.Name, p1.Version, "", map[string]string{"a": "b"}, nil)
 	c.Assert(err, IsNil)
 
-	op1, err = s.Backend.GetPackage(a.GetName(), p1.Name, p1.Version)
+	op1, err = s.Backend.GetPackage(a.GetName(), p1.Name, p1.Version, "")
 	c.Assert(err, IsNil)
 	c.Assert(op1.RuntimeLabels, DeepEquals, map[string]string{"a": "b"})
 
 	err = s.Backend.UpdatePackageRuntimeLabels(
-		b.GetName(), p2.Name, p2.Version, map[string]string{"new": "newval"}, []string{"key", "key1"})
+		b.GetName(), p2.Name, p2.Version, "", map[string]string{"new": "newval"}, []string{"key", "key1"})
 	c.Assert(err, IsNil)
-	op2, err = s.Backend.GetPackage(b.GetName(), p2.Name, p2.Version)
+	op2, err = s.Backend.GetPackage(b.GetName(), p2.Name, p2.Version, "")
 	c.Assert(err, IsNil)
 	c.Assert(op2.RuntimeLabels, DeepEquals, map[string]string{"new": "newval"})
 
 	err = s.Backend.UpdatePackageRuntimeLabels(
-		b.GetName(), "not", "here", map[string]string{"new": "newval"}, []string{"key", "key1"})
+		b.GetName(), "not", "here", "", map[string]string{"new": "newval"}, []string{"key", "key1"})
 	c.Assert(trace.IsNotFound(err), Equals, true, Commentf("unexpected type: %T", err))
 
 	// remove package p1 from repository a
-	err = s.Backend.DeletePackage(a.GetName(), p1.Name, p1.Version)
+	err = s.Backend.DeletePackage(a.GetName(), p1.Name, p1.Version, "")
 	c.Assert(err, IsNil)
 
 	// package p1 is no longer found in repository a
-	_, err = s.Backend.GetPackage(a.GetName(), p1.Name, p1.Version)
+	_, err = s.Backend.GetPackage(a.GetName(), p1.Name, p1.Version, "")
 	c.Assert(trace.IsNotFound(err), Equals, true, Commentf("unexpected type: %T", err))
 
 	// delete repository a
@@ -625,6 +625,132 @@ func (s *StorageSuite) RepositoriesCRUD(c *C) {
 	c.Assert(trace.IsNotFound(err), Equals, true, Commentf("unexpected type: %T", err))
 }
 
+func (s *StorageSuite) BLOBReferences(c *C) {
+	_, err := s.Backend.CreateRepository(storage.NewRepository("a.example.com"))
+	c.Assert(err, IsNil)
+
+	// packages a and b share the BLOB
+	for _, name := range []string{"a", "b"} {
+		_, err = s.Backend.CreatePackage(storage.Package{
+			Repository: "a.example.com",
+			Name:       name,
+			Version:    "0.0.1",
+			SHA512:     "hash",
+			Created:    now,
+		})
+		c.Assert(err, IsNil)
+	}
+	refs, err := s.Backend.GetBLOBReferences("hash")
+	c.Assert(err, IsNil)
+	c.Assert(refs, Equals, 2)
+
+	// the package can not be created twice
+	_, err = s.Backend.CreatePackage(storage.Package{
+		Repository: "a.example.com",
+		Name:       "a",
+		Version:    "0.0.1",
+		SHA512:     "hash",
+		Created:    now,
+	})
+	c.Assert(trace.IsAlreadyExists(err), Equals, true, Commentf("unexpected type: %T", err))
+	refs, err = s.Backend.GetBLOBReferences("hash")
+	c.Assert(err, IsNil)
+	c.Assert(refs, Equals, 2)
+
+	// referenced BLOB is not deleted
+	deleted := false
+	deleteFn := func() error {
+		deleted = true
+		return nil
+	}
+	c.Assert(s.Backend.DeleteBLOB("hash", deleteFn), IsNil)
+	c.Assert(deleted, Equals, false)
+
+	// replacing the package moves the reference to the new BLOB
+	_, err = s.Backend.UpsertPackage(storage.Package{
+		Repository: "a.example.com",
+		Name:       "a",
+		Version:    "0.0.1",
+		SHA512:     "hash2",
+		Created:    now,
+	})
+	c.Assert(err, IsNil)
+	refs, err = s.Backend.GetBLOBReferences("hash")
+	c.Assert(err, IsNil)
+	c.Assert(refs, Equals, 1)
+	refs, err = s.Backend.GetBLOBReferences("hash2")
+	c.Assert(err, IsNil)
+	c.Assert(refs, Equals, 1)
+
+	// the BLOB is deleted once the last package referencing it is gone
+	c.Assert(s.Backend.DeletePackage("a.example.com", "b", "0.0.1", ""), IsNil)
+	refs, err = s.Backend.GetBLOBReferences("hash")
+	c.Assert(err, IsNil)
+	c.Assert(refs, Equals, 0)
+	c.Assert(s.Backend.DeleteBLOB("hash", deleteFn), IsNil)
+	c.Assert(deleted, Equals, true)
+
+	// the BLOB can be referenced again after it has been deleted
+	_, err = s.Backend.CreatePackage(storage.Package{
+		Repository: "a.example.com",
+		Name:       "b",
+		Version:    "0.0.1",
+		SHA512:     "hash",
+		Created:    now,
+	})
+	c.Assert(err, IsNil)
+	refs, err = s.Backend.GetBLOBReferences("hash")
+	c.Assert(err, IsNil)
+	c.Assert(refs, Equals, 1)
+}
+
+func (s *StorageSuite) PackageAliasesCRUD(c *C) {
+	_, err := s.Backend.CreateRepository(storage.NewRepository("a.example.com"))
+	c.Assert(err, IsNil)
+
+	alias := storage.PackageAlias{
+		Repository: "a.example.com",
+		Name:       "runtime",
+		Alias:      "@current",
+		Version:    "1.0.0",
+		Updated:    now,
+	}
+	out, err := s.Backend.UpsertPackageAlias(alias)
+	c.Assert(err, IsNil)
+	c.Assert(*out, DeepEquals, alias)
+
+	// the alias can be retargeted
+	alias.Version = "2.0.0"
+	_, err = s.Backend.UpsertPackageAlias(alias)
+	c.Assert(err, IsNil)
+
+	out, err = s.Backend.GetPackageAlias("a.example.com", "runtime", "@current")
+	c.Assert(err, IsNil)
+	c.Assert(*out, DeepEquals, alias)
+
+	// aliases can not point to other aliases
+	_, err = s.Backend.UpsertPackageAlias(storage.PackageAlias{
+		Repository: "a.example.com",
+		Name:       "runtime",
+		Alias:      "@previous",
+		Version:    "@current",
+	})
+	c.Assert(trace.IsBadParameter(err), Equals, true, Commentf("unexpected type: %T", err))
+
+	aliases, err := s.Backend.GetPackageAliases("a.example.com")
+	c.Assert(err, IsNil)
+	c.Assert(aliases, DeepEquals, []storage.PackageAlias{alias})
+
+	err = s.Backend.DeletePackageAlias("a.example.com", "runtime", "@current")
+	c.Assert(err, IsNil)
+
+	_, err = s.Backend.GetPackageAlias("a.example.com", "runtime", "@current")
+	c.Assert(trace.IsNotFound(err), Equals, true, Commentf("unexpected type: %T", err))
+
+	err = s.Backend.DeletePackageAlias("a.example.com", "runtime", "@current")
+	c.Assert(trace.IsNotFound(err), Equals, true, Commentf("unexpected type: %T", err))
+}
+
 func (s *StorageSuite) PermissionsCRUD(c *C) {
 	u := storage.NewUser("bob@example.com",
 		storage.UserSpecV2{
@@ -1060,7 +1186,7 @@ func (s *StorageSuite) DeletesApplication(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(app, NotNil)
 
-	err = s.Backend.DeletePackage(repository, packageName, version)
+	err = s.Backend.DeletePackage(repository, packageName, version, "")
 	c.Assert(err, IsNil)
 
 	_, err = s.Backend.GetApplication(repository, packageName, version)
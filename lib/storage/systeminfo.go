@@ -68,10 +68,14 @@ type System interface {
 	GetSystemPackages() []SystemPackage
 	// GetOS identifies the host operating system or distribution
 	GetOS() OSInfo
+	// GetArch returns the host architecture
+	GetArch() string
 	// GetLVMSystemDirectory returns the location of the LVM system directory
 	GetLVMSystemDirectory() string
 	// GetUser returns the information about the user the agent is running under
 	GetUser() OSUser
+	// GetHardware returns the node hardware profile
+	GetHardware() *HardwareProfile
 }
 
 // UnmarshalSystemInfo unmarshals system info from JSON specified with data
@@ -170,6 +174,11 @@ func (r *SystemV2) GetOS() OSInfo {
 	return r.Spec.OS
 }
 
+// GetArch returns the host architecture
+func (r *SystemV2) GetArch() string {
+	return r.Spec.Arch
+}
+
 // GetLVMSystemDirectory returns the location of the LVM system directory
 func (r *SystemV2) GetLVMSystemDirectory() string {
 	return r.Spec.LVMSystemDirectory
@@ -180,6 +189,11 @@ func (r *SystemV2) GetUser() OSUser {
 	return r.Spec.User
 }
 
+// GetHardware returns the node hardware profile
+func (r *SystemV2) GetHardware() *HardwareProfile {
+	return r.Spec.Hardware
+}
+
 // SystemV2 describes a system
 type SystemV2 struct {
 	// Kind is resource kind, "systeminfo"
@@ -218,11 +232,15 @@ type SystemSpecV2 struct {
 	SystemPackages []SystemPackage `json:"system_packages"`
 	// OS identifies the host operating system
 	OS OSInfo `json:"os"`
+	// Arch is the host architecture, e.g. amd64 or arm64
+	Arch string `json:"arch,omitempty"`
 	// LVMSystemDirectory specifies the location of the LVM system directory if the
 	// docker storage driver is devicemapper, empty otherwise
 	LVMSystemDirectory string `json:"lvm_system_dir"`
 	// User specifies the agent's user identity
 	User OSUser `json:"user"`
+	// Hardware describes the node hardware
+	Hardware *HardwareProfile `json:"hardware,omitempty"`
 }
 
 // String returns a textual representation of this system info
@@ -354,6 +372,7 @@ const SystemSpecV2Schema = `{
         "version": {"type": "string"}
       }
     },
+    "arch": {"type": "string"},
     "lvm_system_dir": {"type": "string"},
     "user": {
       "type": "object",
@@ -364,6 +383,60 @@ const SystemSpecV2Schema = `{
         "uid": {"type": "string"},
         "gid": {"type": "string"}
       }
+    },
+    "hardware": {
+      "type": "object",
+      "additionalProperties": false,
+      "properties": {
+        "cpu": {
+          "type": "object",
+          "additionalProperties": false,
+          "properties": {
+            "model_name": {"type": "string"},
+            "sockets": {"type": "integer"},
+            "cores": {"type": "integer"},
+            "flags": {"type": ["array", "null"], "items": {"type": "string"}}
+          }
+        },
+        "numa_nodes": {
+          "type": ["array", "null"],
+          "items": {
+            "type": "object",
+            "additionalProperties": false,
+            "properties": {
+              "id": {"type": "integer"},
+              "cpus": {"type": "string"},
+              "memory_kb": {"type": "integer"}
+            }
+          }
+        },
+        "disks": {
+          "type": ["array", "null"],
+          "items": {
+            "type": "object",
+            "additionalProperties": false,
+            "properties": {
+              "name": {"type": "string"},
+              "size_bytes": {"type": "integer"},
+              "rotational": {"type": "boolean"},
+              "model": {"type": "string"}
+            }
+          }
+        },
+        "nics": {
+          "type": ["array", "null"],
+          "items": {
+            "type": "object",
+            "additionalProperties": false,
+            "properties": {
+              "name": {"type": "string"},
+              "mac": {"type": "string"},
+              "speed_mbps": {"type": "integer"},
+              "driver": {"type": "string"}
+            }
+          }
+        }
+      }
     }
   }
 }`
@@ -0,0 +1,172 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gravitational/gravity/lib/defaults"
+
+	teleservices "github.com/gravitational/teleport/lib/services"
+	teleutils "github.com/gravitational/teleport/lib/utils"
+	"github.com/gravitational/trace"
+)
+
+// TimeSync describes the time synchronization configuration
+// applied on every cluster node
+type TimeSync interface {
+	// Resource provides common resource methods
+	teleservices.Resource
+	// CheckAndSetDefaults verifies that the object is valid
+	CheckAndSetDefaults() error
+	// GetServers returns the NTP servers to synchronize with
+	GetServers() []string
+	// GetPools returns the NTP server pools to synchronize with
+	GetPools() []string
+	// GetOptions returns additional chrony configuration directives
+	GetOptions() []string
+}
+
+// NewTimeSync returns a new time synchronization configuration resource
+func NewTimeSync(spec TimeSyncSpecV2) TimeSync {
+	return &TimeSyncV2{
+		Kind:    KindTimeSync,
+		Version: teleservices.V2,
+		Metadata: teleservices.Metadata{
+			Name:      KindTimeSync,
+			Namespace: defaults.Namespace,
+		},
+		Spec: spec,
+	}
+}
+
+// TimeSyncV2 defines the time synchronization configuration
+type TimeSyncV2 struct {
+	// Metadata is resource metadata
+	teleservices.Metadata `json:"metadata"`
+	// Kind is a resource kind
+	Kind string `json:"kind"`
+	// Version is a resource version
+	Version string `json:"version"`
+	// Spec defines the time synchronization configuration
+	Spec TimeSyncSpecV2 `json:"spec"`
+}
+
+// GetServers returns the NTP servers to synchronize with
+func (r *TimeSyncV2) GetServers() []string {
+	return r.Spec.Servers
+}
+
+// GetPools returns the NTP server pools to synchronize with
+func (r *TimeSyncV2) GetPools() []string {
+	return r.Spec.Pools
+}
+
+// GetOptions returns additional chrony configuration directives
+func (r *TimeSyncV2) GetOptions() []string {
+	return r.Spec.Options
+}
+
+// CheckAndSetDefaults checks validity of all parameters and sets defaults
+func (r *TimeSyncV2) CheckAndSetDefaults() error {
+	if r.Metadata.Name == "" {
+		r.Metadata.Name = KindTimeSync
+	}
+	if err := r.Metadata.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	if len(r.Spec.Servers) == 0 && len(r.Spec.Pools) == 0 {
+		return trace.BadParameter("at least one NTP server or pool is required")
+	}
+	for _, server := range append(r.Spec.Servers, r.Spec.Pools...) {
+		if server == "" || strings.ContainsAny(server, " \t\r\n") {
+			return trace.BadParameter("invalid NTP server %q", server)
+		}
+	}
+	for _, option := range r.Spec.Options {
+		if strings.ContainsAny(option, "\r\n") {
+			return trace.BadParameter("chrony option %q should be a single line", option)
+		}
+	}
+	return nil
+}
+
+// TimeSyncSpecV2 defines the time synchronization configuration
+type TimeSyncSpecV2 struct {
+	// Servers lists the NTP servers to synchronize with
+	Servers []string `json:"servers,omitempty"`
+	// Pools lists the NTP server pools to synchronize with
+	Pools []string `json:"pools,omitempty"`
+	// Options lists additional chrony configuration directives,
+	// e.g. "maxdistance 16.0"
+	Options []string `json:"options,omitempty"`
+}
+
+// UnmarshalTimeSync unmarshals time synchronization configuration from JSON
+func UnmarshalTimeSync(data []byte) (TimeSync, error) {
+	if len(data) == 0 {
+		return nil, trace.BadParameter("empty time synchronization configuration")
+	}
+
+	jsonData, err := teleutils.ToJSON(data)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var hdr teleservices.ResourceHeader
+	err = json.Unmarshal(jsonData, &hdr)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	switch hdr.Version {
+	case teleservices.V2:
+		var config TimeSyncV2
+		err := teleutils.UnmarshalWithSchema(GetTimeSyncSchema(), &config, jsonData)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		config.Metadata.CheckAndSetDefaults()
+		return &config, nil
+	}
+	return nil, trace.BadParameter(
+		"%v resource version %q is not supported", KindTimeSync, hdr.Version)
+}
+
+// MarshalTimeSync marshals time synchronization configuration into JSON
+func MarshalTimeSync(config TimeSync, opts ...teleservices.MarshalOption) ([]byte, error) {
+	return json.Marshal(config)
+}
+
+// TimeSyncSpecV2Schema is JSON schema for time synchronization configuration
+const TimeSyncSpecV2Schema = `{
+  "type": "object",
+  "additionalProperties": false,
+  "properties": {
+    "servers": {"type": "array", "items": {"type": "string"}},
+    "pools": {"type": "array", "items": {"type": "string"}},
+    "options": {"type": "array", "items": {"type": "string"}}
+  }
+}`
+
+// GetTimeSyncSchema returns time synchronization schema for version V2
+func GetTimeSyncSchema() string {
+	return fmt.Sprintf(teleservices.V2SchemaTemplate, MetadataSchema,
+		TimeSyncSpecV2Schema, "")
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"github.com/gravitational/gravity/lib/compare"
+
+	check "gopkg.in/check.v1"
+)
+
+type TimeSyncSuite struct{}
+
+var _ = check.Suite(&TimeSyncSuite{})
+
+func (s *TimeSyncSuite) TestResourceParsing(c *check.C) {
+	spec := `kind: timesync
+version: v2
+spec:
+  servers: [ntp1.example.com, 10.0.0.1]
+  options:
+  - maxdistance 16.0
+`
+	config, err := UnmarshalTimeSync([]byte(spec))
+	c.Assert(err, check.IsNil)
+	c.Assert(config.CheckAndSetDefaults(), check.IsNil)
+	expected := NewTimeSync(TimeSyncSpecV2{
+		Servers: []string{"ntp1.example.com", "10.0.0.1"},
+		Options: []string{"maxdistance 16.0"},
+	})
+	c.Assert(config, compare.DeepEquals, expected)
+}
+
+func (s *TimeSyncSuite) TestValidatesConfig(c *check.C) {
+	config := NewTimeSync(TimeSyncSpecV2{})
+	c.Assert(config.CheckAndSetDefaults(), check.NotNil)
+
+	config = NewTimeSync(TimeSyncSpecV2{Servers: []string{"ntp1 iburst"}})
+	c.Assert(config.CheckAndSetDefaults(), check.NotNil)
+
+	config = NewTimeSync(TimeSyncSpecV2{
+		Pools:   []string{"pool.ntp.org"},
+		Options: []string{"makestep 1.0 3\nrtcsync"},
+	})
+	c.Assert(config.CheckAndSetDefaults(), check.NotNil)
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"github.com/gravitational/gravity/lib/defaults"
+
+	teleservices "github.com/gravitational/teleport/lib/services"
+	teleutils "github.com/gravitational/teleport/lib/utils"
+	"github.com/gravitational/trace"
+)
+
+// TrustedCA describes the bundle of custom CA certificates installed
+// into the trust stores on every cluster node
+type TrustedCA interface {
+	// Resource provides common resource methods
+	teleservices.Resource
+	// CheckAndSetDefaults verifies that the object is valid
+	CheckAndSetDefaults() error
+	// GetCertificates returns the PEM-encoded certificate bundle
+	GetCertificates() string
+	// GetRegistries returns the Docker registries that are configured
+	// to trust the bundle explicitly
+	GetRegistries() []string
+	// ParseCertificates returns the parsed certificates from the bundle
+	ParseCertificates() ([]*x509.Certificate, error)
+}
+
+// NewTrustedCA returns a new trusted CA bundle resource
+func NewTrustedCA(spec TrustedCASpecV2) TrustedCA {
+	return &TrustedCAV2{
+		Kind:    KindTrustedCA,
+		Version: teleservices.V2,
+		Metadata: teleservices.Metadata{
+			Name:      KindTrustedCA,
+			Namespace: defaults.Namespace,
+		},
+		Spec: spec,
+	}
+}
+
+// TrustedCAV2 defines the trusted CA bundle
+type TrustedCAV2 struct {
+	// Metadata is resource metadata
+	teleservices.Metadata `json:"metadata"`
+	// Kind is a resource kind
+	Kind string `json:"kind"`
+	// Version is a resource version
+	Version string `json:"version"`
+	// Spec defines the trusted CA bundle
+	Spec TrustedCASpecV2 `json:"spec"`
+}
+
+// GetCertificates returns the PEM-encoded certificate bundle
+func (r *TrustedCAV2) GetCertificates() string {
+	return r.Spec.Certificates
+}
+
+// GetRegistries returns the Docker registries that trust the bundle explicitly
+func (r *TrustedCAV2) GetRegistries() []string {
+	return r.Spec.Registries
+}
+
+// ParseCertificates returns the parsed certificates from the bundle
+func (r *TrustedCAV2) ParseCertificates() ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	rest := []byte(r.Spec.Certificates)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			return nil, trace.BadParameter("expected CERTIFICATE PEM block, got %q", block.Type)
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, trace.Wrap(err, "failed to parse certificate %v", len(certs)+1)
+		}
+		certs = append(certs, cert)
+	}
+	if strings.TrimSpace(string(rest)) != "" {
+		return nil, trace.BadParameter("certificates contain data that is not PEM-encoded")
+	}
+	return certs, nil
+}
+
+// CheckAndSetDefaults checks validity of all parameters and sets defaults
+func (r *TrustedCAV2) CheckAndSetDefaults() error {
+	if r.Metadata.Name == "" {
+		r.Metadata.Name = KindTrustedCA
+	}
+	if err := r.Metadata.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	certs, err := r.ParseCertificates()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if len(certs) == 0 {
+		return trace.BadParameter("at least one certificate is required")
+	}
+	for _, cert := range certs {
+		if !cert.IsCA {
+			return trace.BadParameter("certificate %q is not a CA certificate",
+				cert.Subject.CommonName)
+		}
+	}
+	for _, registry := range r.Spec.Registries {
+		if registry == "" || strings.ContainsAny(registry, "/ ") {
+			return trace.BadParameter("registry should be a host[:port], got %q", registry)
+		}
+	}
+	return nil
+}
+
+// TrustedCASpecV2 defines the trusted CA bundle
+type TrustedCASpecV2 struct {
+	// Certificates is the PEM-encoded bundle of CA certificates
+	Certificates string `json:"certificates"`
+	// Registries is an optional list of Docker registries, as host[:port],
+	// configured to trust the bundle explicitly
+	Registries []string `json:"registries,omitempty"`
+}
+
+// UnmarshalTrustedCA unmarshals trusted CA bundle from JSON
+func UnmarshalTrustedCA(data []byte) (TrustedCA, error) {
+	if len(data) == 0 {
+		return nil, trace.BadParameter("empty trusted CA bundle")
+	}
+
+	jsonData, err := teleutils.ToJSON(data)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var hdr teleservices.ResourceHeader
+	err = json.Unmarshal(jsonData, &hdr)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	switch hdr.Version {
+	case teleservices.V2:
+		var bundle TrustedCAV2
+		err := teleutils.UnmarshalWithSchema(GetTrustedCASchema(), &bundle, jsonData)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		bundle.Metadata.CheckAndSetDefaults()
+		return &bundle, nil
+	}
+	return nil, trace.BadParameter(
+		"%v resource version %q is not supported", KindTrustedCA, hdr.Version)
+}
+
+// MarshalTrustedCA marshals trusted CA bundle into JSON
+func MarshalTrustedCA(bundle TrustedCA, opts ...teleservices.MarshalOption) ([]byte, error) {
+	return json.Marshal(bundle)
+}
+
+// TrustedCASpecV2Schema is JSON schema for trusted CA bundle
+const TrustedCASpecV2Schema = `{
+  "type": "object",
+  "additionalProperties": false,
+  "required": ["certificates"],
+  "properties": {
+    "certificates": {"type": "string"},
+    "registries": {"type": "array", "items": {"type": "string"}}
+  }
+}`
+
+// GetTrustedCASchema returns trusted CA bundle schema for version V2
+func GetTrustedCASchema() string {
+	return fmt.Sprintf(teleservices.V2SchemaTemplate, MetadataSchema,
+		TrustedCASpecV2Schema, "")
+}
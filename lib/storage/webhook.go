@@ -0,0 +1,218 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/utils"
+
+	teleservices "github.com/gravitational/teleport/lib/services"
+	teleutils "github.com/gravitational/teleport/lib/utils"
+	"github.com/gravitational/trace"
+)
+
+const (
+	// WebhookEventInstallCompleted is sent when the cluster has been installed
+	WebhookEventInstallCompleted = "install.completed"
+	// WebhookEventNodeAdded is sent when a node has joined the cluster
+	WebhookEventNodeAdded = "node.added"
+	// WebhookEventNodeRemoved is sent when a node has been removed from the cluster
+	WebhookEventNodeRemoved = "node.removed"
+	// WebhookEventUpgradeStarted is sent when a cluster upgrade has started
+	WebhookEventUpgradeStarted = "upgrade.started"
+	// WebhookEventUpgradeFinished is sent when a cluster upgrade has either
+	// completed or failed
+	WebhookEventUpgradeFinished = "upgrade.finished"
+)
+
+// WebhookEvents lists all cluster lifecycle events webhooks can subscribe to
+var WebhookEvents = []string{
+	WebhookEventInstallCompleted,
+	WebhookEventNodeAdded,
+	WebhookEventNodeRemoved,
+	WebhookEventUpgradeStarted,
+	WebhookEventUpgradeFinished,
+}
+
+// Webhook describes an outbound webhook cluster lifecycle events are sent to
+type Webhook interface {
+	// Resource provides common resource methods
+	teleservices.Resource
+	// CheckAndSetDefaults verifies that the object is valid
+	CheckAndSetDefaults() error
+	// GetURL returns the URL events are posted to
+	GetURL() string
+	// GetEvents returns the events the webhook is subscribed to
+	GetEvents() []string
+	// GetSecret returns the key used to sign the events
+	GetSecret() string
+	// GetTimeout returns the timeout of a single delivery attempt
+	GetTimeout() time.Duration
+	// Matches returns true if the webhook is subscribed to the specified event
+	Matches(event string) bool
+}
+
+// NewWebhook returns a new webhook resource
+func NewWebhook(name string, spec WebhookSpecV2) Webhook {
+	return &WebhookV2{
+		Kind:    KindWebhook,
+		Version: teleservices.V2,
+		Metadata: teleservices.Metadata{
+			Name:      name,
+			Namespace: defaults.Namespace,
+		},
+		Spec: spec,
+	}
+}
+
+// WebhookV2 defines an outbound webhook for cluster lifecycle events
+type WebhookV2 struct {
+	// Metadata is resource metadata
+	teleservices.Metadata `json:"metadata"`
+	// Kind is a resource kind
+	Kind string `json:"kind"`
+	// Version is a resource version
+	Version string `json:"version"`
+	// Spec defines the webhook
+	Spec WebhookSpecV2 `json:"spec"`
+}
+
+// GetURL returns the URL events are posted to
+func (r *WebhookV2) GetURL() string {
+	return r.Spec.URL
+}
+
+// GetEvents returns the events the webhook is subscribed to
+func (r *WebhookV2) GetEvents() []string {
+	return r.Spec.Events
+}
+
+// GetSecret returns the key used to sign the events
+func (r *WebhookV2) GetSecret() string {
+	return r.Spec.Secret
+}
+
+// GetTimeout returns the timeout of a single delivery attempt
+func (r *WebhookV2) GetTimeout() time.Duration {
+	return r.Spec.Timeout.Value()
+}
+
+// Matches returns true if the webhook is subscribed to the specified event.
+// A webhook without explicit events is subscribed to all of them
+func (r *WebhookV2) Matches(event string) bool {
+	return len(r.Spec.Events) == 0 || utils.StringInSlice(r.Spec.Events, event)
+}
+
+// CheckAndSetDefaults checks validity of all parameters and sets defaults
+func (r *WebhookV2) CheckAndSetDefaults() error {
+	if r.Metadata.Name == "" {
+		return trace.BadParameter("missing parameter Name")
+	}
+	if r.Spec.URL == "" {
+		return trace.BadParameter("missing parameter URL")
+	}
+	u, err := url.Parse(r.Spec.URL)
+	if err != nil {
+		return trace.BadParameter("invalid webhook URL %q: %v", r.Spec.URL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return trace.BadParameter("webhook URL %q must use http or https scheme", r.Spec.URL)
+	}
+	for _, event := range r.Spec.Events {
+		if !utils.StringInSlice(WebhookEvents, event) {
+			return trace.BadParameter("unsupported webhook event %q, supported are: %v",
+				event, WebhookEvents)
+		}
+	}
+	if r.Spec.Timeout.Value() == 0 {
+		r.Spec.Timeout = teleservices.NewDuration(defaults.WebhookTimeout)
+	}
+	return nil
+}
+
+// WebhookSpecV2 defines an outbound webhook for cluster lifecycle events
+type WebhookSpecV2 struct {
+	// URL is the URL events are posted to
+	URL string `json:"url"`
+	// Events lists the events to send. If empty, all events are sent
+	Events []string `json:"events,omitempty"`
+	// Secret is the key used to sign the events with HMAC-SHA256.
+	// If empty, the events are not signed
+	Secret string `json:"secret,omitempty"`
+	// Timeout is the timeout of a single delivery attempt
+	Timeout teleservices.Duration `json:"timeout,omitempty"`
+}
+
+// UnmarshalWebhook unmarshals a webhook from JSON
+func UnmarshalWebhook(data []byte) (Webhook, error) {
+	if len(data) == 0 {
+		return nil, trace.BadParameter("empty webhook")
+	}
+
+	jsonData, err := teleutils.ToJSON(data)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var hdr teleservices.ResourceHeader
+	err = json.Unmarshal(jsonData, &hdr)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	switch hdr.Version {
+	case teleservices.V2:
+		var webhook WebhookV2
+		err := teleutils.UnmarshalWithSchema(GetWebhookSchema(), &webhook, jsonData)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		webhook.Metadata.CheckAndSetDefaults()
+		return &webhook, nil
+	}
+	return nil, trace.BadParameter(
+		"%v resource version %q is not supported", KindWebhook, hdr.Version)
+}
+
+// MarshalWebhook marshals a webhook into JSON
+func MarshalWebhook(webhook Webhook, opts ...teleservices.MarshalOption) ([]byte, error) {
+	return json.Marshal(webhook)
+}
+
+// WebhookSpecV2Schema is JSON schema for a webhook
+const WebhookSpecV2Schema = `{
+  "type": "object",
+  "additionalProperties": false,
+  "required": ["url"],
+  "properties": {
+    "url": {"type": "string"},
+    "events": {"type": "array", "items": {"type": "string"}},
+    "secret": {"type": "string"},
+    "timeout": {"type": "string"}
+  }
+}`
+
+// GetWebhookSchema returns webhook schema for version V2
+func GetWebhookSchema() string {
+	return fmt.Sprintf(teleservices.V2SchemaTemplate, teleservices.MetadataSchema,
+		WebhookSpecV2Schema, "")
+}
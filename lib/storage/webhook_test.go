@@ -0,0 +1,77 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"time"
+
+	"github.com/gravitational/gravity/lib/compare"
+
+	teleservices "github.com/gravitational/teleport/lib/services"
+	check "gopkg.in/check.v1"
+)
+
+type WebhookSuite struct{}
+
+var _ = check.Suite(&WebhookSuite{})
+
+func (s *WebhookSuite) TestResourceParsing(c *check.C) {
+	spec := `kind: webhook
+version: v2
+metadata:
+  name: cmdb
+spec:
+  url: https://cmdb.example.com/hooks/gravity
+  events: ["node.added", "node.removed"]
+  secret: s3cr3t
+`
+	webhook, err := UnmarshalWebhook([]byte(spec))
+	c.Assert(err, check.IsNil)
+	c.Assert(webhook.CheckAndSetDefaults(), check.IsNil)
+	expected := NewWebhook("cmdb", WebhookSpecV2{
+		URL:     "https://cmdb.example.com/hooks/gravity",
+		Events:  []string{WebhookEventNodeAdded, WebhookEventNodeRemoved},
+		Secret:  "s3cr3t",
+		Timeout: teleservices.NewDuration(10 * time.Second),
+	})
+	c.Assert(webhook, compare.DeepEquals, expected)
+	c.Assert(webhook.Matches(WebhookEventNodeAdded), check.Equals, true)
+	c.Assert(webhook.Matches(WebhookEventUpgradeStarted), check.Equals, false)
+}
+
+func (s *WebhookSuite) TestMatchesAllEventsByDefault(c *check.C) {
+	webhook := NewWebhook("itsm", WebhookSpecV2{URL: "http://itsm.example.com"})
+	c.Assert(webhook.CheckAndSetDefaults(), check.IsNil)
+	for _, event := range WebhookEvents {
+		c.Assert(webhook.Matches(event), check.Equals, true)
+	}
+}
+
+func (s *WebhookSuite) TestValidatesWebhook(c *check.C) {
+	var testCases = []struct {
+		spec    WebhookSpecV2
+		comment string
+	}{
+		{spec: WebhookSpecV2{}, comment: "missing URL"},
+		{spec: WebhookSpecV2{URL: "ftp://example.com"}, comment: "unsupported scheme"},
+		{spec: WebhookSpecV2{URL: "https://example.com", Events: []string{"node.rebooted"}}, comment: "unknown event"},
+	}
+	for _, tc := range testCases {
+		webhook := NewWebhook("test", tc.spec)
+		c.Assert(webhook.CheckAndSetDefaults(), check.NotNil, check.Commentf(tc.comment))
+	}
+}
@@ -0,0 +1,206 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package systeminfo
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+)
+
+// QueryHardware returns the hardware profile of the local node
+func QueryHardware() (*storage.HardwareProfile, error) {
+	return queryHardware("/")
+}
+
+// queryHardware collects the hardware profile from procfs and sysfs
+// mounted under the specified root directory
+func queryHardware(root string) (*storage.HardwareProfile, error) {
+	cpu, err := queryCPUInfo(filepath.Join(root, "proc", "cpuinfo"))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	numaNodes, err := queryNUMANodes(filepath.Join(root, "sys", "devices", "system", "node"))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	disks, err := queryDisks(filepath.Join(root, "sys", "block"))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	nics, err := queryNICs(filepath.Join(root, "sys", "class", "net"))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &storage.HardwareProfile{
+		CPU:       *cpu,
+		NUMANodes: numaNodes,
+		Disks:     disks,
+		NICs:      nics,
+	}, nil
+}
+
+// queryCPUInfo parses the processor information from the specified cpuinfo file
+func queryCPUInfo(path string) (*storage.CPUInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	defer f.Close()
+
+	var info storage.CPUInfo
+	sockets := make(map[string]struct{})
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key, value := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		switch key {
+		case "processor":
+			info.Cores++
+		case "model name":
+			info.ModelName = value
+		case "physical id":
+			sockets[value] = struct{}{}
+		case "flags":
+			if len(info.Flags) == 0 {
+				info.Flags = strings.Fields(value)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	info.Sockets = len(sockets)
+	return &info, nil
+}
+
+// queryNUMANodes returns the list of NUMA nodes from the specified sysfs directory
+func queryNUMANodes(dir string) (nodes []storage.NUMANode, err error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "node[0-9]*"))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	for _, match := range matches {
+		id, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(match), "node"))
+		if err != nil {
+			continue
+		}
+		node := storage.NUMANode{
+			ID:   id,
+			CPUs: readSysfsValue(filepath.Join(match, "cpulist")),
+		}
+		meminfo := readSysfsValue(filepath.Join(match, "meminfo"))
+		if submatch := numaMemTotal.FindStringSubmatch(meminfo); submatch != nil {
+			node.MemoryKB, _ = strconv.ParseUint(submatch[1], 10, 64)
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+// queryDisks returns the list of block devices from the specified sysfs directory.
+// Virtual devices like loop or ram disks are skipped
+func queryDisks(dir string) (disks []storage.DiskInfo, err error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, trace.ConvertSystemError(err)
+	}
+	for _, entry := range entries {
+		if isVirtualDisk(entry.Name()) {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		sectors, _ := strconv.ParseUint(readSysfsValue(filepath.Join(path, "size")), 10, 64)
+		disks = append(disks, storage.DiskInfo{
+			Name:       entry.Name(),
+			SizeBytes:  sectors * sectorSize,
+			Rotational: readSysfsValue(filepath.Join(path, "queue", "rotational")) == "1",
+			Model:      readSysfsValue(filepath.Join(path, "device", "model")),
+		})
+	}
+	return disks, nil
+}
+
+// queryNICs returns the list of physical network interfaces from the specified sysfs directory
+func queryNICs(dir string) (nics []storage.NICInfo, err error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, trace.ConvertSystemError(err)
+	}
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		// only physical interfaces are backed by a device
+		if _, err := os.Stat(filepath.Join(path, "device")); err != nil {
+			continue
+		}
+		nic := storage.NICInfo{
+			Name: entry.Name(),
+			MAC:  readSysfsValue(filepath.Join(path, "address")),
+		}
+		// speed is -1 or unreadable if the link is down
+		if speed, err := strconv.Atoi(readSysfsValue(filepath.Join(path, "speed"))); err == nil && speed > 0 {
+			nic.SpeedMbps = speed
+		}
+		if driver, err := os.Readlink(filepath.Join(path, "device", "driver")); err == nil {
+			nic.Driver = filepath.Base(driver)
+		}
+		nics = append(nics, nic)
+	}
+	return nics, nil
+}
+
+// readSysfsValue returns the trimmed contents of the specified file
+// or an empty string if the file cannot be read
+func readSysfsValue(path string) string {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func isVirtualDisk(name string) bool {
+	for _, prefix := range []string{"loop", "ram", "zram", "sr", "fd", "dm-", "md"} {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// sectorSize is the size of the unit the block device size is reported in by sysfs
+const sectorSize = 512
+
+// numaMemTotal matches the total memory line of a NUMA node meminfo file
+var numaMemTotal = regexp.MustCompile(`MemTotal:\s+(\d+)\s+kB`)
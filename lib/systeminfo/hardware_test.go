@@ -0,0 +1,87 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package systeminfo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/gravitational/gravity/lib/storage"
+
+	. "gopkg.in/check.v1"
+)
+
+type HardwareSuite struct{}
+
+var _ = Suite(&HardwareSuite{})
+
+func (r *HardwareSuite) TestQueriesHardware(c *C) {
+	root := c.MkDir()
+	writeFiles(c, root, map[string]string{
+		"proc/cpuinfo": `processor	: 0
+model name	: Intel(R) Xeon(R) CPU E5-2686 v4 @ 2.30GHz
+physical id	: 0
+flags		: fpu sse4_2 avx2
+
+processor	: 1
+model name	: Intel(R) Xeon(R) CPU E5-2686 v4 @ 2.30GHz
+physical id	: 1
+flags		: fpu sse4_2 avx2
+`,
+		"sys/devices/system/node/node0/cpulist": "0-1\n",
+		"sys/devices/system/node/node0/meminfo": "Node 0 MemTotal:       16303492 kB\nNode 0 MemFree:  1000 kB\n",
+		"sys/block/sda/size":                    "2097152\n",
+		"sys/block/sda/queue/rotational":        "0\n",
+		"sys/block/sda/device/model":            "Samsung SSD  \n",
+		"sys/block/loop0/size":                  "100\n",
+		"sys/class/net/eth0/address":            "02:42:ac:11:00:02\n",
+		"sys/class/net/eth0/speed":              "10000\n",
+		"sys/class/net/eth0/device/vendor":      "0x8086\n",
+		"sys/class/net/lo/address":              "00:00:00:00:00:00\n",
+	})
+	c.Assert(os.Symlink("../../../../bus/pci/drivers/ixgbe",
+		filepath.Join(root, "sys/class/net/eth0/device/driver")), IsNil)
+
+	profile, err := queryHardware(root)
+	c.Assert(err, IsNil)
+	c.Assert(profile, DeepEquals, &storage.HardwareProfile{
+		CPU: storage.CPUInfo{
+			ModelName: "Intel(R) Xeon(R) CPU E5-2686 v4 @ 2.30GHz",
+			Sockets:   2,
+			Cores:     2,
+			Flags:     []string{"fpu", "sse4_2", "avx2"},
+		},
+		NUMANodes: []storage.NUMANode{{ID: 0, CPUs: "0-1", MemoryKB: 16303492}},
+		Disks: []storage.DiskInfo{
+			{Name: "sda", SizeBytes: 1024 * 1024 * 1024, Model: "Samsung SSD"},
+		},
+		NICs: []storage.NICInfo{
+			{Name: "eth0", MAC: "02:42:ac:11:00:02", SpeedMbps: 10000, Driver: "ixgbe"},
+		},
+	})
+	c.Assert(profile.CPU.HasFlag("avx2"), Equals, true)
+	c.Assert(profile.HasSSD(), Equals, true)
+}
+
+func writeFiles(c *C, root string, files map[string]string) {
+	for path, contents := range files {
+		path = filepath.Join(root, path)
+		c.Assert(os.MkdirAll(filepath.Dir(path), 0755), IsNil)
+		c.Assert(ioutil.WriteFile(path, []byte(contents), 0644), IsNil)
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strconv"
 
 	"github.com/gravitational/gravity/lib/devicemapper"
@@ -28,6 +29,7 @@ import (
 	sigar "github.com/cloudfoundry/gosigar"
 	"github.com/gravitational/trace"
 	"github.com/mitchellh/go-ps"
+	log "github.com/sirupsen/logrus"
 )
 
 // New returns a new instance of system information
@@ -44,20 +46,9 @@ func New() (*storage.SystemV2, error) {
 // If dirName is not explicitly mounted, the function will return filesystem information about one
 // of its parent directories.
 func FilesystemForDir(system storage.System, dirName string) (*FilesystemInfo, error) {
-	var fileSystem *storage.Filesystem
-L:
-	for {
-		for _, fs := range system.GetFilesystems() {
-			// do not take rootfs into account as it is considered always mounted on `/`
-			if fs.DirName == dirName && fs.Type != "rootfs" {
-				fileSystem = &fs
-				break L
-			}
-		}
-		if filepath.Dir(dirName) == dirName {
-			return nil, trace.NotFound("no matching filesystem found for %v", dirName)
-		}
-		dirName = filepath.Dir(dirName)
+	fileSystem, err := findFilesystem(system.GetFilesystems(), dirName)
+	if err != nil {
+		return nil, trace.Wrap(err)
 	}
 	var usage *storage.FilesystemUsage
 	stats := system.GetFilesystemStats()
@@ -77,6 +68,43 @@ L:
 	}, nil
 }
 
+// LocalFilesystemForDir returns information about the file system of the local host
+// where the directory dirName is mounted, see FilesystemForDir
+func LocalFilesystemForDir(dirName string) (*FilesystemInfo, error) {
+	filesystems, err := queryFilesystems()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	fileSystem, err := findFilesystem(filesystems, dirName)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	usage, err := collectFilesystemUsage([]storage.Filesystem{*fileSystem})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &FilesystemInfo{
+		Filesystem: *fileSystem,
+		Usage:      usage[fileSystem.DirName],
+	}, nil
+}
+
+// findFilesystem returns the filesystem mounted on dirName or the closest of its parents
+func findFilesystem(filesystems []storage.Filesystem, dirName string) (*storage.Filesystem, error) {
+	for {
+		for _, fs := range filesystems {
+			// do not take rootfs into account as it is considered always mounted on `/`
+			if fs.DirName == dirName && fs.Type != "rootfs" {
+				return &fs, nil
+			}
+		}
+		if filepath.Dir(dirName) == dirName {
+			return nil, trace.NotFound("no matching filesystem found for %v", dirName)
+		}
+		dirName = filepath.Dir(dirName)
+	}
+}
+
 // FilesystemInfo groups a filesystem and usage statistics
 type FilesystemInfo struct {
 	// Filesystem describes a filesystem on host
@@ -145,6 +173,7 @@ func collect() (*storage.SystemSpecV2, error) {
 		return nil, trace.Wrap(err, "failed to query operating system details")
 	}
 	info.OS = storage.OSInfo(*osInfo)
+	info.Arch = runtime.GOARCH
 
 	info.Processes, err = queryProcesses()
 	if err != nil {
@@ -163,6 +192,11 @@ func collect() (*storage.SystemSpecV2, error) {
 		return nil, trace.Wrap(err, "failed to query LVM system directory")
 	}
 
+	info.Hardware, err = QueryHardware()
+	if err != nil {
+		log.Warnf("Failed to query hardware profile: %v.", trace.DebugReport(err))
+	}
+
 	userInfo, err := GetRealUser()
 	if err != nil {
 		return nil, trace.Wrap(err, "failed to obtain current user info")
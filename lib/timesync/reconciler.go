@@ -0,0 +1,138 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package timesync
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ReconcilerConfig configures the time synchronization reconciler
+type ReconcilerConfig struct {
+	// Operator is the cluster operator service
+	Operator ops.Operator
+	// Client is the Kubernetes client
+	Client *kubernetes.Clientset
+	// Interval is how often the configuration is reconciled
+	Interval time.Duration
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+}
+
+// CheckAndSetDefaults validates the configuration and sets default values
+func (r *ReconcilerConfig) CheckAndSetDefaults() error {
+	if r.Operator == nil {
+		return trace.BadParameter("missing Operator")
+	}
+	if r.Client == nil {
+		return trace.BadParameter("missing Client")
+	}
+	if r.Interval == 0 {
+		r.Interval = defaults.TimeSyncSyncInterval
+	}
+	if r.FieldLogger == nil {
+		r.FieldLogger = logrus.WithField(trace.Component, "timesync")
+	}
+	return nil
+}
+
+// NewReconciler returns a new time synchronization reconciler
+func NewReconciler(config ReconcilerConfig) (*Reconciler, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &Reconciler{ReconcilerConfig: config}, nil
+}
+
+// Reconciler keeps chrony configuration on cluster nodes in sync
+// with the time synchronization resource
+type Reconciler struct {
+	ReconcilerConfig
+	// applied is the rendered configuration applied last
+	applied string
+	// removed is whether the objects have been removed after
+	// the configuration has been deleted
+	removed bool
+}
+
+// Run reconciles the configuration periodically until the context is canceled
+func (r *Reconciler) Run(ctx context.Context) error {
+	utils.RunPeriodically(ctx, r.Interval, r.FieldLogger,
+		"reconcile time sync configuration", r.Sync)
+	return nil
+}
+
+// Sync applies the time synchronization configuration if it has changed
+// since the last time and removes the objects that apply it if the
+// configuration has been deleted
+func (r *Reconciler) Sync(ctx context.Context) error {
+	cluster, err := r.Operator.GetLocalSite()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	config, err := r.Operator.GetTimeSync(cluster.Key())
+	if err != nil && !trace.IsNotFound(err) {
+		return trace.Wrap(err)
+	}
+	if config == nil {
+		return trace.Wrap(r.remove())
+	}
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	if r.applied == Render(config) {
+		return nil
+	}
+	r.Infof("Applying time sync configuration: %v.", describe(config))
+	if err := Apply(ctx, r.Client, config); err != nil {
+		return trace.Wrap(err)
+	}
+	r.applied = Render(config)
+	r.removed = false
+	return nil
+}
+
+// remove deletes the objects that apply the configuration once
+// after the configuration has been deleted
+func (r *Reconciler) remove() error {
+	if r.removed {
+		return nil
+	}
+	if r.applied != "" {
+		r.Info("Time sync configuration has been deleted.")
+	}
+	if err := Delete(r.Client); err != nil {
+		return trace.Wrap(err)
+	}
+	r.applied = ""
+	r.removed = true
+	return nil
+}
+
+func describe(config storage.TimeSync) string {
+	return fmt.Sprintf("servers=%v pools=%v", config.GetServers(), config.GetPools())
+}
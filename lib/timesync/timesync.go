@@ -0,0 +1,199 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package timesync renders the chrony configuration from the cluster
+// time synchronization resource and applies it inside planet on every node
+package timesync
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/rigging"
+	"github.com/gravitational/trace"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// Name is the name of the objects that apply the configuration
+	Name = "time-sync"
+	// configVolume is the name of the volume with rendered configuration
+	configVolume = "config"
+	// hostConfigVolume is the name of the volume with chrony configuration directory
+	hostConfigVolume = "chrony"
+	// configHashAnnotation is the pod annotation with the hash of the
+	// rendered configuration which rolls the pods on every change
+	configHashAnnotation = "gravitational.io/time-sync-config-hash"
+)
+
+// Render returns the chrony configuration for the specified time
+// synchronization resource
+func Render(config storage.TimeSync) string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# This file is managed by gravity, do not edit.\n")
+	fmt.Fprintf(&buf, "# Use \"gravity resource create\" with %v resource instead.\n", storage.KindTimeSync)
+	for _, server := range config.GetServers() {
+		fmt.Fprintf(&buf, "server %v iburst\n", server)
+	}
+	for _, pool := range config.GetPools() {
+		fmt.Fprintf(&buf, "pool %v iburst\n", pool)
+	}
+	fmt.Fprintf(&buf, "driftfile /var/lib/chrony/chrony.drift\n")
+	fmt.Fprintf(&buf, "makestep 1.0 3\n")
+	fmt.Fprintf(&buf, "rtcsync\n")
+	for _, option := range config.GetOptions() {
+		fmt.Fprintf(&buf, "%v\n", option)
+	}
+	return buf.String()
+}
+
+// Objects returns the config map with rendered chrony configuration and
+// the daemon set that installs it inside planet on every cluster node
+// and restarts chrony. Nodes that join the cluster later get the
+// configuration as soon as the daemon set pod is scheduled on them
+func Objects(config storage.TimeSync) (*v1.ConfigMap, *appsv1.DaemonSet) {
+	rendered := Render(config)
+	labels := map[string]string{constants.TimeSyncLabel: Name}
+	configMap := &v1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      configMapName,
+			Namespace: defaults.KubeSystemNamespace,
+			Labels:    labels,
+		},
+		Data: map[string]string{
+			defaults.ChronyConfigFile: rendered,
+		},
+	}
+	script := fmt.Sprintf("cp /config/%[1]v %[2]v/%[1]v && "+
+		"nsenter -t 1 -m -- systemctl restart %[3]v && "+
+		"while true; do sleep 3600; done",
+		defaults.ChronyConfigFile, defaults.ChronyConfigDir, defaults.ChronyServiceName)
+	privileged := true
+	daemonSet := &appsv1.DaemonSet{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "DaemonSet",
+			APIVersion: appsv1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      Name,
+			Namespace: defaults.KubeSystemNamespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+					Annotations: map[string]string{
+						configHashAnnotation: fmt.Sprintf("%x", sha256.Sum256([]byte(rendered))),
+					},
+				},
+				Spec: v1.PodSpec{
+					HostPID:     true,
+					Tolerations: []v1.Toleration{{Operator: v1.TolerationOpExists}},
+					Containers: []v1.Container{{
+						Name:    Name,
+						Image:   fmt.Sprintf("%v/%v", constants.DockerRegistry, defaults.HookContainerNameTag),
+						Command: []string{"/bin/sh", "-c", script},
+						SecurityContext: &v1.SecurityContext{
+							Privileged: &privileged,
+						},
+						VolumeMounts: []v1.VolumeMount{
+							{Name: configVolume, MountPath: "/config"},
+							{Name: hostConfigVolume, MountPath: defaults.ChronyConfigDir},
+						},
+					}},
+					Volumes: []v1.Volume{
+						{
+							Name: configVolume,
+							VolumeSource: v1.VolumeSource{
+								ConfigMap: &v1.ConfigMapVolumeSource{
+									LocalObjectReference: v1.LocalObjectReference{Name: configMapName},
+								},
+							},
+						},
+						{
+							Name: hostConfigVolume,
+							VolumeSource: v1.VolumeSource{
+								HostPath: &v1.HostPathVolumeSource{Path: defaults.ChronyConfigDir},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	return configMap, daemonSet
+}
+
+// Apply creates or updates the objects that apply the specified configuration
+func Apply(ctx context.Context, client *kubernetes.Clientset, config storage.TimeSync) error {
+	configMap, daemonSet := Objects(config)
+	configMapControl, err := rigging.NewConfigMapControl(rigging.ConfigMapConfig{
+		ConfigMap: configMap,
+		Client:    client,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if err := configMapControl.Upsert(ctx); err != nil {
+		return trace.Wrap(err)
+	}
+	daemonSetControl, err := rigging.NewDSControl(rigging.DSConfig{
+		DaemonSet: daemonSet,
+		Client:    client,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(daemonSetControl.Upsert(ctx))
+}
+
+// Delete removes the objects that apply the configuration. The chrony
+// configuration already installed on the nodes is left intact
+func Delete(client *kubernetes.Clientset) error {
+	var errors []error
+	err := rigging.ConvertError(client.AppsV1().DaemonSets(defaults.KubeSystemNamespace).
+		Delete(Name, &metav1.DeleteOptions{PropagationPolicy: &propagationPolicy}))
+	if err != nil && !trace.IsNotFound(err) {
+		errors = append(errors, trace.Wrap(err))
+	}
+	err = rigging.ConvertError(client.CoreV1().ConfigMaps(defaults.KubeSystemNamespace).
+		Delete(configMapName, nil))
+	if err != nil && !trace.IsNotFound(err) {
+		errors = append(errors, trace.Wrap(err))
+	}
+	return trace.NewAggregate(errors...)
+}
+
+// configMapName is the name of the config map with rendered chrony
+// configuration. It is different from the one that stores the resource
+var configMapName = fmt.Sprintf("%v-chrony", Name)
+
+var propagationPolicy = metav1.DeletePropagationForeground
@@ -0,0 +1,66 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package timesync
+
+import (
+	"testing"
+
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/storage"
+
+	check "gopkg.in/check.v1"
+)
+
+func TestTimeSync(t *testing.T) { check.TestingT(t) }
+
+type TimeSyncSuite struct{}
+
+var _ = check.Suite(&TimeSyncSuite{})
+
+func (s *TimeSyncSuite) TestRendersConfig(c *check.C) {
+	config := storage.NewTimeSync(storage.TimeSyncSpecV2{
+		Servers: []string{"ntp1.example.com", "10.0.0.1"},
+		Pools:   []string{"pool.ntp.org"},
+		Options: []string{"maxdistance 16.0"},
+	})
+	c.Assert(config.CheckAndSetDefaults(), check.IsNil)
+	c.Assert(Render(config), check.Equals, `# This file is managed by gravity, do not edit.
+# Use "gravity resource create" with timesync resource instead.
+server ntp1.example.com iburst
+server 10.0.0.1 iburst
+pool pool.ntp.org iburst
+driftfile /var/lib/chrony/chrony.drift
+makestep 1.0 3
+rtcsync
+maxdistance 16.0
+`)
+}
+
+func (s *TimeSyncSuite) TestObjectsTrackConfig(c *check.C) {
+	config := storage.NewTimeSync(storage.TimeSyncSpecV2{Servers: []string{"ntp1.example.com"}})
+	configMap, daemonSet := Objects(config)
+	c.Assert(configMap.Data[defaults.ChronyConfigFile], check.Equals, Render(config))
+	c.Assert(daemonSet.Labels[constants.TimeSyncLabel], check.Equals, Name)
+	c.Assert(daemonSet.Spec.Template.Spec.HostPID, check.Equals, true)
+	hash := daemonSet.Spec.Template.Annotations[configHashAnnotation]
+	c.Assert(hash, check.Not(check.Equals), "")
+
+	config = storage.NewTimeSync(storage.TimeSyncSpecV2{Servers: []string{"ntp2.example.com"}})
+	_, daemonSet = Objects(config)
+	c.Assert(daemonSet.Spec.Template.Annotations[configHashAnnotation], check.Not(check.Equals), hash)
+}
@@ -0,0 +1,284 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+)
+
+// Config defines the configuration of the span exporter
+type Config struct {
+	// Endpoint is the URL of the OTLP/HTTP traces endpoint,
+	// e.g. http://jaeger:4318/v1/traces
+	Endpoint string
+	// ServiceName is the name of the service to report spans under
+	ServiceName string
+	// Client is the HTTP client used to export spans
+	Client *http.Client
+	// FlushInterval specifies how often the collected spans are exported
+	FlushInterval time.Duration
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+}
+
+// CheckAndSetDefaults validates the config and sets defaults
+func (r *Config) CheckAndSetDefaults() error {
+	if r.Endpoint == "" {
+		return trace.BadParameter("missing Endpoint")
+	}
+	if r.ServiceName == "" {
+		r.ServiceName = serviceName
+	}
+	if r.Client == nil {
+		r.Client = &http.Client{Timeout: defaults.TracingExportTimeout}
+	}
+	if r.FlushInterval == 0 {
+		r.FlushInterval = defaults.TracingFlushInterval
+	}
+	if r.FieldLogger == nil {
+		r.FieldLogger = logrus.WithField(trace.Component, "tracing")
+	}
+	return nil
+}
+
+// NewExporter returns a new exporter that periodically sends
+// completed spans to the collector
+func NewExporter(config Config) (*Exporter, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	exporter := &Exporter{
+		Config: config,
+		flushC: make(chan struct{}, 1),
+		doneC:  make(chan struct{}),
+	}
+	exporter.wg.Add(1)
+	go exporter.loop()
+	return exporter, nil
+}
+
+// Exporter sends completed spans to an OTLP/HTTP collector
+type Exporter struct {
+	// Config is the exporter configuration
+	Config
+	mu      sync.Mutex
+	spans   []*Span
+	dropped int
+	flushC  chan struct{}
+	doneC   chan struct{}
+	wg      sync.WaitGroup
+}
+
+// Flush exports all collected spans
+func (r *Exporter) Flush(ctx context.Context) error {
+	r.mu.Lock()
+	spans := r.spans
+	r.spans = nil
+	r.mu.Unlock()
+	if len(spans) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(r.newRequest(spans))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	req, err := http.NewRequest(http.MethodPost, r.Endpoint, bytes.NewReader(data))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := r.Client.Do(req.WithContext(ctx))
+	if err != nil {
+		return trace.ConnectionProblem(err, "failed to export %v spans", len(spans))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return trace.BadParameter("failed to export %v spans: %v %s",
+			len(spans), resp.Status, body)
+	}
+	return nil
+}
+
+// Close exports the remaining spans and stops the exporter
+func (r *Exporter) Close() error {
+	close(r.doneC)
+	r.wg.Wait()
+	ctx, cancel := context.WithTimeout(context.Background(), defaults.TracingExportTimeout)
+	defer cancel()
+	return trace.Wrap(r.Flush(ctx))
+}
+
+func (r *Exporter) export(span *Span) {
+	r.mu.Lock()
+	if len(r.spans) >= defaults.TracingMaxQueueSize {
+		r.dropped++
+		r.mu.Unlock()
+		return
+	}
+	r.spans = append(r.spans, span)
+	full := len(r.spans) >= defaults.TracingMaxQueueSize/2
+	r.mu.Unlock()
+	if full {
+		select {
+		case r.flushC <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (r *Exporter) loop() {
+	defer r.wg.Done()
+	ticker := time.NewTicker(r.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-r.flushC:
+		case <-r.doneC:
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), defaults.TracingExportTimeout)
+		if err := r.Flush(ctx); err != nil {
+			r.Warnf("Failed to export spans: %v.", err)
+		}
+		cancel()
+		r.mu.Lock()
+		if r.dropped != 0 {
+			r.Warnf("Dropped %v spans, the collector is not keeping up.", r.dropped)
+			r.dropped = 0
+		}
+		r.mu.Unlock()
+	}
+}
+
+func (r *Exporter) newRequest(spans []*Span) exportRequest {
+	result := make([]otlpSpan, 0, len(spans))
+	for _, span := range spans {
+		result = append(result, newOTLPSpan(span))
+	}
+	return exportRequest{
+		ResourceSpans: []resourceSpans{{
+			Resource: resource{
+				Attributes: []keyValue{newKeyValue(String("service.name", r.ServiceName))},
+			},
+			ScopeSpans: []scopeSpans{{
+				Scope: scope{Name: instrumentationScope},
+				Spans: result,
+			}},
+		}},
+	}
+}
+
+func newOTLPSpan(span *Span) otlpSpan {
+	span.mu.Lock()
+	defer span.mu.Unlock()
+	result := otlpSpan{
+		TraceID:           span.traceID(),
+		SpanID:            span.spanID(),
+		Name:              span.name,
+		Kind:              int(span.kind),
+		StartTimeUnixNano: uint64(span.start.UnixNano()),
+		EndTimeUnixNano:   uint64(span.end.UnixNano()),
+		Status:            status{Code: statusCodeOK},
+	}
+	if span.parentID != [8]byte{} {
+		result.ParentSpanID = SpanContext{SpanID: span.parentID}.spanID()
+	}
+	for _, attr := range span.attributes {
+		result.Attributes = append(result.Attributes, newKeyValue(attr))
+	}
+	if span.err != nil {
+		result.Status = status{Code: statusCodeError, Message: span.err.Error()}
+	}
+	return result
+}
+
+func newKeyValue(attr Attribute) keyValue {
+	return keyValue{Key: attr.Key, Value: anyValue{StringValue: attr.Value}}
+}
+
+// exportRequest is the OTLP/JSON trace export request, see
+// https://github.com/open-telemetry/opentelemetry-proto/blob/main/opentelemetry/proto/trace/v1/trace.proto
+type exportRequest struct {
+	ResourceSpans []resourceSpans `json:"resourceSpans"`
+}
+
+type resourceSpans struct {
+	Resource   resource     `json:"resource"`
+	ScopeSpans []scopeSpans `json:"scopeSpans"`
+}
+
+type resource struct {
+	Attributes []keyValue `json:"attributes"`
+}
+
+type scopeSpans struct {
+	Scope scope      `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type scope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string     `json:"traceId"`
+	SpanID            string     `json:"spanId"`
+	ParentSpanID      string     `json:"parentSpanId,omitempty"`
+	Name              string     `json:"name"`
+	Kind              int        `json:"kind"`
+	StartTimeUnixNano uint64     `json:"startTimeUnixNano,string"`
+	EndTimeUnixNano   uint64     `json:"endTimeUnixNano,string"`
+	Attributes        []keyValue `json:"attributes,omitempty"`
+	Status            status     `json:"status"`
+}
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+type anyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type status struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+const (
+	// serviceName is the default name of the service to report spans under
+	serviceName = "gravity"
+	// instrumentationScope names the instrumentation library
+	instrumentationScope = "github.com/gravitational/gravity/lib/tracing"
+
+	statusCodeOK    = 1
+	statusCodeError = 2
+)
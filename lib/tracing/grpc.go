@@ -0,0 +1,141 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// UnaryServerInterceptor records a span for every unary RPC served
+func UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if !shouldTrace(info.FullMethod) {
+		return handler(ctx, req)
+	}
+	ctx, span := startSpan(incomingContext(ctx), spanKindServer, info.FullMethod,
+		String("rpc.method", info.FullMethod))
+	resp, err := handler(ctx, req)
+	span.End(err)
+	return resp, err
+}
+
+// StreamServerInterceptor records a span for every streaming RPC served
+func StreamServerInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if !shouldTrace(info.FullMethod) {
+		return handler(srv, stream)
+	}
+	ctx, span := startSpan(incomingContext(stream.Context()), spanKindServer, info.FullMethod,
+		String("rpc.method", info.FullMethod))
+	err := handler(srv, &serverStream{ServerStream: stream, ctx: ctx})
+	span.End(err)
+	return err
+}
+
+// UnaryClientInterceptor records a span for every unary RPC issued
+// and propagates the trace context to the server
+func UnaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if !shouldTrace(method) {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	ctx, span := startSpan(ctx, spanKindClient, method,
+		String("rpc.method", method),
+		String("net.peer.name", cc.Target()))
+	err := invoker(outgoingContext(ctx), method, req, reply, cc, opts...)
+	span.End(err)
+	return err
+}
+
+// StreamClientInterceptor records a span for every streaming RPC issued
+// and propagates the trace context to the server.
+// The span is completed once the stream has been fully received
+func StreamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	if !shouldTrace(method) {
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+	ctx, span := startSpan(ctx, spanKindClient, method,
+		String("rpc.method", method),
+		String("net.peer.name", cc.Target()))
+	stream, err := streamer(outgoingContext(ctx), desc, cc, method, opts...)
+	if err != nil {
+		span.End(err)
+		return nil, err
+	}
+	return &clientStream{ClientStream: stream, span: span}, nil
+}
+
+// shouldTrace returns true if the specified RPC method should be traced.
+// Health checks are not traced as they are frequent and uninteresting
+func shouldTrace(method string) bool {
+	return Enabled() && !strings.HasPrefix(method, healthServicePrefix)
+}
+
+func incomingContext(ctx context.Context) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+	values := md.Get(traceParentHeader)
+	if len(values) == 0 {
+		return ctx
+	}
+	return WithTraceParent(ctx, values[0])
+}
+
+func outgoingContext(ctx context.Context) context.Context {
+	traceParent := TraceParent(ctx)
+	if traceParent == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, traceParentHeader, traceParent)
+}
+
+// serverStream overrides the context of the wrapped stream
+// to pass the span to the handler
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the context with the span of this stream
+func (r *serverStream) Context() context.Context {
+	return r.ctx
+}
+
+// clientStream completes the span once the stream has been received
+type clientStream struct {
+	grpc.ClientStream
+	span *Span
+}
+
+// RecvMsg receives a message from the wrapped stream
+func (r *clientStream) RecvMsg(m interface{}) error {
+	err := r.ClientStream.RecvMsg(m)
+	switch {
+	case err == io.EOF:
+		r.span.End(nil)
+	case err != nil:
+		r.span.End(err)
+	}
+	return err
+}
+
+// healthServicePrefix is the method prefix of the gRPC health service
+const healthServicePrefix = "/grpc.health.v1.Health/"
@@ -0,0 +1,138 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gravitational/trace"
+)
+
+// TraceParent returns the W3C trace context of the span in ctx
+// or an empty string if there is no span
+func TraceParent(ctx context.Context) string {
+	span := SpanFromContext(ctx)
+	if span == nil {
+		return ""
+	}
+	return fmt.Sprintf("00-%v-%v-01", span.traceID(), span.spanID())
+}
+
+// WithTraceParent returns a new context that continues the trace
+// described by the specified W3C trace context.
+// Returns ctx unmodified if traceParent is empty or invalid
+func WithTraceParent(ctx context.Context, traceParent string) context.Context {
+	if traceParent == "" || !Enabled() {
+		return ctx
+	}
+	parent, err := parseTraceParent(traceParent)
+	if err != nil {
+		return ctx
+	}
+	return context.WithValue(ctx, remoteSpanKey{}, parent)
+}
+
+// Environ returns the environment for a child process that continues
+// the trace in ctx
+func Environ(ctx context.Context) []string {
+	traceParent := TraceParent(ctx)
+	if traceParent == "" {
+		return nil
+	}
+	return []string{fmt.Sprintf("%v=%v", EnvTraceParent, traceParent)}
+}
+
+// Handler returns an HTTP handler that records a span for every request
+// served by next. The span names are prefixed with name
+func Handler(name string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !Enabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx := WithTraceParent(r.Context(), r.Header.Get(traceParentHeader))
+		ctx, span := startSpan(ctx, spanKindServer,
+			fmt.Sprintf("%v %v %v", name, r.Method, r.URL.Path),
+			String("http.method", r.Method),
+			String("http.target", r.URL.Path))
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+		span.SetAttributes(String("http.status_code", strconv.Itoa(recorder.status)))
+		var err error
+		if recorder.status >= http.StatusInternalServerError {
+			err = trace.Errorf("%v", http.StatusText(recorder.status))
+		}
+		span.End(err)
+	})
+}
+
+// parseTraceParent parses the W3C trace context in the format
+// version-traceid-spanid-flags
+func parseTraceParent(value string) (parent SpanContext, err error) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) != 4 || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return parent, trace.BadParameter("invalid trace context %q", value)
+	}
+	if _, err := hex.Decode(parent.TraceID[:], []byte(parts[1])); err != nil {
+		return parent, trace.BadParameter("invalid trace ID in %q", value)
+	}
+	if _, err := hex.Decode(parent.SpanID[:], []byte(parts[2])); err != nil {
+		return parent, trace.BadParameter("invalid span ID in %q", value)
+	}
+	if !parent.IsValid() {
+		return parent, trace.BadParameter("invalid trace context %q", value)
+	}
+	return parent, nil
+}
+
+// statusRecorder records the status code of an HTTP response
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader records the status code and writes it to the underlying writer
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Flush implements http.Flusher
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker so websocket handlers keep working
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, trace.BadParameter("response writer does not support hijacking")
+	}
+	return hijacker.Hijack()
+}
+
+// traceParentHeader is the W3C trace context header and gRPC metadata key
+const traceParentHeader = "traceparent"
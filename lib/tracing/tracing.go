@@ -0,0 +1,259 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tracing implements distributed tracing of cluster operations.
+//
+// Spans are exported using the OpenTelemetry protocol (OTLP) over HTTP
+// to any compatible collector, e.g. Jaeger or the OpenTelemetry collector.
+// Trace context is propagated between processes in the W3C trace context
+// format, via HTTP headers, gRPC metadata and the TRACEPARENT environment
+// variable for child processes.
+//
+// Tracing is disabled unless the collector endpoint is configured with
+// the standard OTEL_EXPORTER_OTLP_ENDPOINT environment variable. When
+// disabled, StartSpan returns a nil span which is safe to use.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gravitational/trace"
+)
+
+// SpanContext identifies a span within a trace
+type SpanContext struct {
+	// TraceID identifies the trace
+	TraceID [16]byte
+	// SpanID identifies the span
+	SpanID [8]byte
+}
+
+// IsValid returns true if this is a valid span context
+func (r SpanContext) IsValid() bool {
+	return r.TraceID != [16]byte{} && r.SpanID != [8]byte{}
+}
+
+// Span describes a single timed operation within a trace
+type Span struct {
+	// SpanContext identifies this span
+	SpanContext
+	parentID [8]byte
+	name     string
+	kind     spanKind
+	start    time.Time
+	exporter *Exporter
+
+	mu         sync.Mutex
+	end        time.Time
+	attributes []Attribute
+	err        error
+}
+
+// SetAttributes adds the specified attributes to the span
+func (r *Span) SetAttributes(attrs ...Attribute) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.attributes = append(r.attributes, attrs...)
+	r.mu.Unlock()
+}
+
+// End completes the span and queues it for export.
+// If err is not nil, the span is marked as failed
+func (r *Span) End(err error) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	if !r.end.IsZero() {
+		r.mu.Unlock()
+		return
+	}
+	r.end = time.Now()
+	r.err = err
+	r.mu.Unlock()
+	r.exporter.export(r)
+}
+
+// Attribute is a key/value pair attached to a span
+type Attribute struct {
+	// Key is the attribute name
+	Key string
+	// Value is the attribute value
+	Value string
+}
+
+// String returns a new attribute with the specified key and value
+func String(key, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// StartSpan starts a new span with the specified name as a child of the span
+// in ctx, if any. It returns the context with the new span.
+// The returned span must be completed with End
+func StartSpan(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	return startSpan(ctx, spanKindInternal, name, attrs...)
+}
+
+// SpanFromContext returns the span stored in ctx or nil
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// Enabled returns true if spans are being exported
+func Enabled() bool {
+	return getExporter() != nil
+}
+
+// Init installs exporter as the process-wide span exporter
+func Init(exporter *Exporter) {
+	mu.Lock()
+	defer mu.Unlock()
+	global = exporter
+}
+
+// InitFromEnvironment configures the process-wide span exporter using the standard
+// OpenTelemetry environment variables.
+// Tracing stays disabled if no collector endpoint has been configured
+func InitFromEnvironment() error {
+	endpoint := os.Getenv(EnvTracesEndpoint)
+	if endpoint == "" {
+		endpoint = os.Getenv(EnvEndpoint)
+		if endpoint == "" {
+			return nil
+		}
+		endpoint = endpoint + tracesPath
+	}
+	exporter, err := NewExporter(Config{
+		Endpoint:    endpoint,
+		ServiceName: os.Getenv(EnvServiceName),
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if parent, err := parseTraceParent(os.Getenv(EnvTraceParent)); err == nil {
+		processParent = parent
+	}
+	Init(exporter)
+	return nil
+}
+
+// Close flushes the collected spans and stops the process-wide exporter
+func Close() error {
+	mu.Lock()
+	exporter := global
+	global = nil
+	mu.Unlock()
+	if exporter == nil {
+		return nil
+	}
+	return trace.Wrap(exporter.Close())
+}
+
+func startSpan(ctx context.Context, kind spanKind, name string, attrs ...Attribute) (context.Context, *Span) {
+	exporter := getExporter()
+	if exporter == nil {
+		return ctx, nil
+	}
+	span := &Span{
+		name:       name,
+		kind:       kind,
+		start:      time.Now(),
+		attributes: attrs,
+		exporter:   exporter,
+	}
+	if parent, ok := parentFromContext(ctx); ok {
+		span.TraceID = parent.TraceID
+		span.parentID = parent.SpanID
+	} else {
+		rand.Read(span.TraceID[:])
+	}
+	rand.Read(span.SpanID[:])
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// parentFromContext returns the context of the parent span for a new span
+// started with ctx: either a local span, a span propagated from a remote
+// process, or the span this process has been started from
+func parentFromContext(ctx context.Context) (SpanContext, bool) {
+	if span := SpanFromContext(ctx); span != nil {
+		return span.SpanContext, true
+	}
+	if parent, ok := ctx.Value(remoteSpanKey{}).(SpanContext); ok {
+		return parent, true
+	}
+	if processParent.IsValid() {
+		return processParent, true
+	}
+	return SpanContext{}, false
+}
+
+func getExporter() *Exporter {
+	mu.Lock()
+	defer mu.Unlock()
+	return global
+}
+
+func (r SpanContext) traceID() string {
+	return hex.EncodeToString(r.TraceID[:])
+}
+
+func (r SpanContext) spanID() string {
+	return hex.EncodeToString(r.SpanID[:])
+}
+
+type spanKind int
+
+const (
+	spanKindInternal spanKind = 1
+	spanKindServer   spanKind = 2
+	spanKindClient   spanKind = 3
+)
+
+type spanKey struct{}
+
+type remoteSpanKey struct{}
+
+const (
+	// EnvEndpoint is the environment variable with the base URL of the
+	// OTLP/HTTP collector, e.g. http://jaeger:4318
+	EnvEndpoint = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	// EnvTracesEndpoint is the environment variable with the complete URL
+	// of the OTLP/HTTP traces endpoint. Takes precedence over EnvEndpoint
+	EnvTracesEndpoint = "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"
+	// EnvServiceName is the environment variable with the service name
+	// to report spans under
+	EnvServiceName = "OTEL_SERVICE_NAME"
+	// EnvTraceParent is the environment variable with the W3C trace context
+	// of the span the process has been started from
+	EnvTraceParent = "TRACEPARENT"
+
+	tracesPath = "/v1/traces"
+)
+
+var (
+	mu     sync.Mutex
+	global *Exporter
+	// processParent is the span this process has been started from
+	processParent SpanContext
+)
@@ -0,0 +1,151 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gravitational/trace"
+	. "gopkg.in/check.v1"
+)
+
+func TestTracing(t *testing.T) { TestingT(t) }
+
+type TracingSuite struct {
+	collector *testCollector
+	server    *httptest.Server
+}
+
+var _ = Suite(&TracingSuite{})
+
+func (s *TracingSuite) SetUpTest(c *C) {
+	s.collector = &testCollector{}
+	s.server = httptest.NewServer(s.collector)
+	exporter, err := NewExporter(Config{
+		Endpoint:      s.server.URL + tracesPath,
+		FlushInterval: time.Hour,
+	})
+	c.Assert(err, IsNil)
+	Init(exporter)
+}
+
+func (s *TracingSuite) TearDownTest(c *C) {
+	c.Assert(Close(), IsNil)
+	s.server.Close()
+}
+
+func (s *TracingSuite) TestExportsSpans(c *C) {
+	ctx, parent := StartSpan(context.TODO(), "phase /masters", String("node", "node-1"))
+	_, child := StartSpan(ctx, "phase /masters/node-1")
+	child.End(trace.BadParameter("boom"))
+	parent.End(nil)
+	c.Assert(Close(), IsNil)
+
+	spans := s.collector.getSpans()
+	c.Assert(spans, HasLen, 2)
+	c.Assert(spans[0].Name, Equals, "phase /masters/node-1")
+	c.Assert(spans[0].TraceID, Equals, spans[1].TraceID)
+	c.Assert(spans[0].ParentSpanID, Equals, spans[1].SpanID)
+	c.Assert(spans[0].Status, DeepEquals, status{Code: statusCodeError, Message: "boom"})
+	c.Assert(spans[1].ParentSpanID, Equals, "")
+	c.Assert(spans[1].Status, DeepEquals, status{Code: statusCodeOK})
+	c.Assert(spans[1].Attributes, DeepEquals, []keyValue{
+		{Key: "node", Value: anyValue{StringValue: "node-1"}}})
+}
+
+func (s *TracingSuite) TestPropagatesOverHTTP(c *C) {
+	var serverTraceParent string
+	handler := Handler("ops", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serverTraceParent = TraceParent(r.Context())
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	ctx, span := StartSpan(context.TODO(), "client")
+	req := httptest.NewRequest(http.MethodGet, "/portal/v1/status", nil)
+	req.Header.Set(traceParentHeader, TraceParent(ctx))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	span.End(nil)
+	c.Assert(Close(), IsNil)
+
+	server, err := parseTraceParent(serverTraceParent)
+	c.Assert(err, IsNil)
+	c.Assert(server.TraceID, Equals, span.TraceID)
+
+	spans := s.collector.getSpans()
+	c.Assert(spans, HasLen, 2)
+	c.Assert(spans[0].Name, Equals, "ops GET /portal/v1/status")
+	c.Assert(spans[0].ParentSpanID, Equals, span.spanID())
+	c.Assert(spans[0].Status, DeepEquals, status{Code: statusCodeOK})
+}
+
+func (s *TracingSuite) TestNoopWhenDisabled(c *C) {
+	c.Assert(Close(), IsNil)
+	ctx, span := StartSpan(context.TODO(), "phase /init")
+	c.Assert(span, IsNil)
+	c.Assert(TraceParent(ctx), Equals, "")
+	span.SetAttributes(String("node", "node-1"))
+	span.End(nil)
+}
+
+func (s *TracingSuite) TestParsesTraceParent(c *C) {
+	parent, err := parseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	c.Assert(err, IsNil)
+	c.Assert(parent.traceID(), Equals, "4bf92f3577b34da6a3ce929d0e0e4736")
+	c.Assert(parent.spanID(), Equals, "00f067aa0ba902b7")
+
+	for _, value := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473x-00f067aa0ba902b7-01",
+	} {
+		_, err := parseTraceParent(value)
+		c.Assert(err, NotNil, Commentf(value))
+	}
+}
+
+// testCollector is an OTLP/HTTP collector that records received spans
+type testCollector struct {
+	sync.Mutex
+	spans []otlpSpan
+}
+
+func (r *testCollector) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var request exportRequest
+	if err := json.NewDecoder(req.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	r.Lock()
+	defer r.Unlock()
+	for _, resource := range request.ResourceSpans {
+		for _, scope := range resource.ScopeSpans {
+			r.spans = append(r.spans, scope.Spans...)
+		}
+	}
+}
+
+func (r *testCollector) getSpans() []otlpSpan {
+	r.Lock()
+	defer r.Unlock()
+	return r.spans
+}
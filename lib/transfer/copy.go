@@ -46,7 +46,7 @@ func copySite(site *storage.Site, dst storage.Backend, src ExportBackend, cluste
 	}
 
 	a := site.App
-	pkg, err := src.GetPackage(a.Repository, a.Name, a.Version)
+	pkg, err := src.GetPackage(a.Repository, a.Name, a.Version, a.Arch)
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -179,7 +179,7 @@ func copySite(site *storage.Site, dst storage.Backend, src ExportBackend, cluste
 type ExportBackend interface {
 	GetAccount(accountID string) (*storage.Account, error)
 	GetSiteUsers(domain string) ([]storage.User, error)
-	GetPackage(repository, packageName, packageVersion string) (*storage.Package, error)
+	GetPackage(repository, packageName, packageVersion, arch string) (*storage.Package, error)
 	GetAPIKeys(email string) ([]storage.APIKey, error)
 	GetUserRoles(email string) ([]teleservices.Role, error)
 	GetSiteOperations(domain string) ([]storage.SiteOperation, error)
@@ -0,0 +1,159 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trustedca
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/rigging"
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ReconcilerConfig configures the trusted CA bundle reconciler
+type ReconcilerConfig struct {
+	// Operator is the cluster operator service
+	Operator ops.Operator
+	// Client is the Kubernetes client
+	Client *kubernetes.Clientset
+	// Interval is how often the bundle is reconciled
+	Interval time.Duration
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+}
+
+// CheckAndSetDefaults validates the configuration and sets default values
+func (r *ReconcilerConfig) CheckAndSetDefaults() error {
+	if r.Operator == nil {
+		return trace.BadParameter("missing Operator")
+	}
+	if r.Client == nil {
+		return trace.BadParameter("missing Client")
+	}
+	if r.Interval == 0 {
+		r.Interval = defaults.TrustedCASyncInterval
+	}
+	if r.FieldLogger == nil {
+		r.FieldLogger = logrus.WithField(trace.Component, "trustedca")
+	}
+	return nil
+}
+
+// NewReconciler returns a new trusted CA bundle reconciler
+func NewReconciler(config ReconcilerConfig) (*Reconciler, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &Reconciler{ReconcilerConfig: config}, nil
+}
+
+// Reconciler keeps the trusted CA bundle installed on cluster nodes
+// in sync with the trusted CA resource
+type Reconciler struct {
+	ReconcilerConfig
+	// applied is the hash of the configuration applied last
+	applied string
+}
+
+// Run reconciles the bundle periodically until the context is canceled
+func (r *Reconciler) Run(ctx context.Context) error {
+	utils.RunPeriodically(ctx, r.Interval, r.FieldLogger,
+		"reconcile trusted CA bundle", r.Sync)
+	return nil
+}
+
+// Sync applies the trusted CA bundle if it has changed since the last time.
+//
+// After the bundle has been deleted, the daemon set is kept running with
+// an empty configuration so the certificates are also removed from the
+// nodes that were offline at the time
+func (r *Reconciler) Sync(ctx context.Context) error {
+	cluster, err := r.Operator.GetLocalSite()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	bundle, err := r.Operator.GetTrustedCA(cluster.Key())
+	if err != nil && !trace.IsNotFound(err) {
+		return trace.Wrap(err)
+	}
+	if bundle != nil {
+		if err := bundle.CheckAndSetDefaults(); err != nil {
+			return trace.Wrap(err)
+		}
+	} else {
+		installed, err := r.installed()
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if !installed {
+			return nil
+		}
+	}
+	files, err := Render(bundle)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	rendered := hash(files)
+	if r.applied == rendered {
+		return nil
+	}
+	if bundle != nil {
+		r.Infof("Installing trusted CA bundle: %v.", describe(bundle))
+	} else {
+		r.Info("Trusted CA bundle has been deleted, removing it from nodes.")
+	}
+	if err := Apply(ctx, r.Client, bundle); err != nil {
+		return trace.Wrap(err)
+	}
+	r.applied = rendered
+	return nil
+}
+
+// installed returns true if the daemon set that installs the bundle exists
+func (r *Reconciler) installed() (bool, error) {
+	_, err := r.Client.AppsV1().DaemonSets(defaults.KubeSystemNamespace).
+		Get(Name, metav1.GetOptions{})
+	err = rigging.ConvertError(err)
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return false, nil
+		}
+		return false, trace.Wrap(err)
+	}
+	return true, nil
+}
+
+func describe(bundle storage.TrustedCA) string {
+	certs, err := bundle.ParseCertificates()
+	if err != nil {
+		return err.Error()
+	}
+	subjects := make([]string, 0, len(certs))
+	for _, cert := range certs {
+		subjects = append(subjects, cert.Subject.CommonName)
+	}
+	return fmt.Sprintf("certificates=%v registries=%v", subjects, bundle.GetRegistries())
+}
@@ -0,0 +1,281 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package trustedca installs the cluster trusted CA bundle into the
+// trust stores on every node: the planet system trust store and the
+// certificate directories of the Docker registries
+package trustedca
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/pem"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/rigging"
+	"github.com/gravitational/trace"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// Name is the name of the objects that install the bundle
+	Name = "trusted-ca"
+	// bundleFile is the name of the file with the complete bundle
+	bundleFile = "bundle.crt"
+	// registryCertFile is the name of the bundle file installed
+	// into the registry certificate directories
+	registryCertFile = "gravity-ca.crt"
+	// scriptFile is the name of the script that installs the bundle
+	scriptFile = "trust.sh"
+	// certificatesSubdir is the subdirectory of the local CA certificates
+	// directory the bundle is installed into
+	certificatesSubdir = "gravity"
+	// configVolume is the name of the volume with rendered configuration
+	configVolume = "config"
+	// certificatesVolume is the name of the volume with local CA certificates
+	certificatesVolume = "ca-certificates"
+	// registriesVolume is the name of the volume with registry certificates
+	registriesVolume = "certs"
+	// configHashAnnotation is the pod annotation with the hash of the
+	// rendered configuration which rolls the pods on every change
+	configHashAnnotation = "gravitational.io/trusted-ca-config-hash"
+)
+
+// Render returns the configuration files that install the specified bundle
+// keyed by file name. Every certificate is stored in a separate file as
+// update-ca-certificates only picks up the first certificate of a file.
+// If bundle is nil, the configuration removes the bundle installed previously
+func Render(bundle storage.TrustedCA) (map[string]string, error) {
+	files := make(map[string]string)
+	var certNames, registries []string
+	if bundle != nil {
+		certs, err := bundle.ParseCertificates()
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		var all bytes.Buffer
+		for i, cert := range certs {
+			data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+			name := fmt.Sprintf("ca-%v.crt", i)
+			files[name] = string(data)
+			certNames = append(certNames, name)
+			all.Write(data)
+		}
+		files[bundleFile] = all.String()
+		registries = append(registries, bundle.GetRegistries()...)
+		sort.Strings(registries)
+	}
+	files[scriptFile] = renderScript(certNames, registries)
+	return files, nil
+}
+
+// renderScript returns the shell script that keeps the specified
+// certificates installed in the planet trust store and in the certificate
+// directories of the specified registries, and removes any other
+// certificates it has installed before
+func renderScript(certs, registries []string) string {
+	return fmt.Sprintf(`# This file is managed by gravity, do not edit.
+# Use "gravity resource create" with %[1]v resource instead.
+certs="%[2]v"
+registries="%[3]v"
+while true; do
+  updated=
+  mkdir -p /ca-certificates/%[4]v
+  for cert in $certs; do
+    if ! cmp -s "/config/$cert" "/ca-certificates/%[4]v/$cert"; then
+      cp "/config/$cert" "/ca-certificates/%[4]v/$cert" && updated=1
+    fi
+  done
+  for cert in /ca-certificates/%[4]v/*.crt; do
+    [ -f "$cert" ] || continue
+    case " $certs " in
+      *" ${cert##*/} "*) ;;
+      *) rm -f "$cert" && updated=1 ;;
+    esac
+  done
+  if [ -n "$updated" ]; then
+    nsenter -t 1 -m -- update-ca-certificates --fresh
+  fi
+  for registry in $registries; do
+    mkdir -p "/certs/$registry"
+    if ! cmp -s /config/%[5]v "/certs/$registry/%[6]v"; then
+      cp /config/%[5]v "/certs/$registry/%[6]v"
+    fi
+  done
+  for cert in /certs/*/%[6]v; do
+    [ -f "$cert" ] || continue
+    registry=${cert%%/*}
+    case " $registries " in
+      *" ${registry##*/} "*) ;;
+      *) rm -f "$cert" ;;
+    esac
+  done
+  sleep %[7]v
+done
+`, storage.KindTrustedCA, strings.Join(certs, " "), strings.Join(registries, " "),
+		certificatesSubdir, bundleFile, registryCertFile,
+		int64(defaults.TrustedCACheckInterval.Seconds()))
+}
+
+// Objects returns the config map with rendered configuration and the
+// daemon set that installs the bundle on every cluster node. Nodes that
+// join the cluster later get the bundle as soon as the daemon set pod
+// is scheduled on them
+func Objects(bundle storage.TrustedCA) (*v1.ConfigMap, *appsv1.DaemonSet, error) {
+	files, err := Render(bundle)
+	if err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+	labels := map[string]string{constants.TrustedCALabel: Name}
+	configMap := &v1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      configMapName,
+			Namespace: defaults.KubeSystemNamespace,
+			Labels:    labels,
+		},
+		Data: files,
+	}
+	privileged := true
+	directoryOrCreate := v1.HostPathDirectoryOrCreate
+	daemonSet := &appsv1.DaemonSet{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "DaemonSet",
+			APIVersion: appsv1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      Name,
+			Namespace: defaults.KubeSystemNamespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+					Annotations: map[string]string{
+						configHashAnnotation: hash(files),
+					},
+				},
+				Spec: v1.PodSpec{
+					HostPID:     true,
+					Tolerations: []v1.Toleration{{Operator: v1.TolerationOpExists}},
+					Containers: []v1.Container{{
+						Name:    Name,
+						Image:   fmt.Sprintf("%v/%v", constants.DockerRegistry, defaults.HookContainerNameTag),
+						Command: []string{"/bin/sh", filepath.Join("/config", scriptFile)},
+						SecurityContext: &v1.SecurityContext{
+							Privileged: &privileged,
+						},
+						VolumeMounts: []v1.VolumeMount{
+							{Name: configVolume, MountPath: "/config"},
+							{Name: certificatesVolume, MountPath: "/ca-certificates"},
+							{Name: registriesVolume, MountPath: "/certs"},
+						},
+					}},
+					Volumes: []v1.Volume{
+						{
+							Name: configVolume,
+							VolumeSource: v1.VolumeSource{
+								ConfigMap: &v1.ConfigMapVolumeSource{
+									LocalObjectReference: v1.LocalObjectReference{Name: configMapName},
+								},
+							},
+						},
+						{
+							Name: certificatesVolume,
+							VolumeSource: v1.VolumeSource{
+								HostPath: &v1.HostPathVolumeSource{
+									Path: defaults.LocalCACertificatesDir,
+									Type: &directoryOrCreate,
+								},
+							},
+						},
+						{
+							Name: registriesVolume,
+							VolumeSource: v1.VolumeSource{
+								HostPath: &v1.HostPathVolumeSource{
+									Path: defaults.DockerCertsDir,
+									Type: &directoryOrCreate,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	return configMap, daemonSet, nil
+}
+
+// Apply creates or updates the objects that install the specified bundle.
+// If bundle is nil, the objects remove the bundle installed previously
+func Apply(ctx context.Context, client *kubernetes.Clientset, bundle storage.TrustedCA) error {
+	configMap, daemonSet, err := Objects(bundle)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	configMapControl, err := rigging.NewConfigMapControl(rigging.ConfigMapConfig{
+		ConfigMap: configMap,
+		Client:    client,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if err := configMapControl.Upsert(ctx); err != nil {
+		return trace.Wrap(err)
+	}
+	daemonSetControl, err := rigging.NewDSControl(rigging.DSConfig{
+		DaemonSet: daemonSet,
+		Client:    client,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(daemonSetControl.Upsert(ctx))
+}
+
+// hash returns the hash of the rendered configuration files
+func hash(files map[string]string) string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	h := sha256.New()
+	for _, name := range names {
+		fmt.Fprintf(h, "%v\n%v\n", name, files[name])
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// configMapName is the name of the config map with rendered configuration.
+// It is different from the one that stores the resource
+var configMapName = fmt.Sprintf("%v-config", Name)
@@ -0,0 +1,88 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trustedca
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/cloudflare/cfssl/csr"
+	"github.com/gravitational/license/authority"
+	check "gopkg.in/check.v1"
+)
+
+func TestTrustedCA(t *testing.T) { check.TestingT(t) }
+
+type TrustedCASuite struct{}
+
+var _ = check.Suite(&TrustedCASuite{})
+
+func (s *TrustedCASuite) TestRendersBundle(c *check.C) {
+	ca1, ca2 := generateCA(c, "ca1"), generateCA(c, "ca2")
+	bundle := storage.NewTrustedCA(storage.TrustedCASpecV2{
+		Certificates: ca1 + ca2,
+		Registries:   []string{"registry.example.com:5000", "apps.example.com"},
+	})
+	c.Assert(bundle.CheckAndSetDefaults(), check.IsNil)
+	files, err := Render(bundle)
+	c.Assert(err, check.IsNil)
+	c.Assert(files["ca-0.crt"], check.Equals, ca1)
+	c.Assert(files["ca-1.crt"], check.Equals, ca2)
+	c.Assert(files[bundleFile], check.Equals, ca1+ca2)
+	script := files[scriptFile]
+	c.Assert(strings.Contains(script, `certs="ca-0.crt ca-1.crt"`), check.Equals, true)
+	c.Assert(strings.Contains(script, `registries="apps.example.com registry.example.com:5000"`), check.Equals, true)
+	c.Assert(strings.Contains(script, "update-ca-certificates --fresh"), check.Equals, true)
+	c.Assert(strings.Contains(script, "registry=${cert%/*}"), check.Equals, true)
+	c.Assert(strings.Contains(script, "sleep 60\n"), check.Equals, true)
+}
+
+func (s *TrustedCASuite) TestRendersRemoval(c *check.C) {
+	files, err := Render(nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(files, check.HasLen, 1)
+	script := files[scriptFile]
+	c.Assert(strings.Contains(script, `certs=""`), check.Equals, true)
+	c.Assert(strings.Contains(script, `registries=""`), check.Equals, true)
+}
+
+func (s *TrustedCASuite) TestObjectsTrackBundle(c *check.C) {
+	bundle := storage.NewTrustedCA(storage.TrustedCASpecV2{
+		Certificates: generateCA(c, "ca"),
+	})
+	c.Assert(bundle.CheckAndSetDefaults(), check.IsNil)
+	configMap, daemonSet, err := Objects(bundle)
+	c.Assert(err, check.IsNil)
+	c.Assert(configMap.Data[bundleFile], check.Equals, bundle.GetCertificates())
+	c.Assert(daemonSet.Labels[constants.TrustedCALabel], check.Equals, Name)
+	c.Assert(daemonSet.Spec.Template.Spec.HostPID, check.Equals, true)
+	hash := daemonSet.Spec.Template.Annotations[configHashAnnotation]
+	c.Assert(hash, check.Not(check.Equals), "")
+
+	_, daemonSet, err = Objects(nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(daemonSet.Spec.Template.Annotations[configHashAnnotation], check.Not(check.Equals), hash)
+}
+
+func generateCA(c *check.C, commonName string) string {
+	ca, err := authority.GenerateSelfSignedCA(csr.CertificateRequest{CN: commonName})
+	c.Assert(err, check.IsNil)
+	return string(ca.CertPEM)
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/gravitational/gravity/lib/fsm"
 	"github.com/gravitational/gravity/lib/localenv"
 	"github.com/gravitational/gravity/lib/rpc"
+	"github.com/gravitational/gravity/lib/storage"
 	"github.com/gravitational/gravity/lib/utils"
 	"github.com/gravitational/gravity/lib/utils/kubectl"
 
@@ -56,23 +57,30 @@ func AutomaticUpgrade(ctx context.Context, localEnv, updateEnv *localenv.LocalEn
 		Remote:            runner,
 	}
 
-	fsm, err := NewFSM(ctx, config)
+	machine, err := NewFSM(ctx, config)
 	if err != nil {
 		return trace.Wrap(err, "failed to load or initialize upgrade plan")
 	}
-	defer fsm.Close()
+	defer machine.Close()
 
 	progress := utils.NewProgress(ctx, "automatic upgrade", -1, false)
 	defer progress.Stop()
 
 	force := false
-	fsmErr := fsm.ExecutePlan(ctx, progress, force)
+	fsmErr := machine.ExecutePlan(ctx, progress, force)
+	if fsm.IsAwaitingApproval(fsmErr) {
+		// the operation stays in progress until the phase is approved
+		return trace.Wrap(fsmErr)
+	}
 	if fsmErr != nil {
 		log.Warnf("Failed to execute plan: %v.", fsmErr)
+		if err := rollbackOnFailure(ctx, machine, progress); err != nil {
+			log.Warnf("Failed to rollback operation: %v.", trace.DebugReport(err))
+		}
 		// fallthrough
 	}
 
-	err = fsm.Complete(fsmErr)
+	err = machine.Complete(fsmErr)
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -86,6 +94,31 @@ func AutomaticUpgrade(ctx context.Context, localEnv, updateEnv *localenv.LocalEn
 	return trace.Wrap(fsmErr)
 }
 
+// rollbackOnFailure rolls back the operation if any of the failed
+// phases requests automatic rollback
+func rollbackOnFailure(ctx context.Context, machine *fsm.FSM, progress utils.Progress) error {
+	plan, err := machine.GetPlan()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if !shouldRollback(plan) {
+		return nil
+	}
+	log.Info("Rolling back the operation.")
+	return trace.Wrap(machine.RollbackPlan(ctx, progress, false))
+}
+
+// shouldRollback returns true if the plan has a failed phase
+// that requests automatic rollback of the operation
+func shouldRollback(plan *storage.OperationPlan) bool {
+	for _, phase := range fsm.FlattenPlan(plan) {
+		if phase.IsFailed() && phase.Data != nil && phase.Data.RollbackOnFailure {
+			return true
+		}
+	}
+	return false
+}
+
 // ShutdownClusterAgents fetches all nodes in a cluster
 // and submits a shutdown request
 func ShutdownClusterAgents(ctx context.Context, remote rpc.AgentRepository) error {
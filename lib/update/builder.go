@@ -76,6 +76,26 @@ func (r phaseBuilder) bootstrap(servers []storage.Server, installed, update loc.
 	return &root
 }
 
+// prePull returns a phase that pulls the specified images from the cluster
+// registry on each node before any application pods are restarted
+func (r phaseBuilder) prePull(servers []storage.Server, images []string) *phase {
+	root := root(phase{
+		ID:          "prepull",
+		Description: "Pre-pull application images on nodes",
+	})
+
+	for i, server := range servers {
+		node := r.node(server, root, "Pre-pull application images on node %q")
+		node.Executor = prePullImages
+		node.Data = &storage.OperationPhaseData{
+			Server: &servers[i],
+			Images: images,
+		}
+		root.AddParallel(node)
+	}
+	return &root
+}
+
 func (r phaseBuilder) preUpdate(appPackage loc.Locator) *phase {
 	phase := root(phase{
 		ID:          "pre-update",
@@ -107,6 +127,22 @@ func (r phaseBuilder) app(updates []loc.Locator) *phase {
 	return &root
 }
 
+// smokeTest returns a phase that runs application smoke tests after the update.
+// If rollback is set, a failure of the smoke tests triggers automatic
+// rollback of the operation
+func (r phaseBuilder) smokeTest(appPackage loc.Locator, rollback bool) *phase {
+	phase := root(phase{
+		ID:          "smoke-test",
+		Description: "Run application smoke tests",
+		Executor:    smokeTest,
+		Data: &storage.OperationPhaseData{
+			Package:           &appPackage,
+			RollbackOnFailure: rollback,
+		},
+	})
+	return &phase
+}
+
 // migration constructs a migration phase based on the plan params.
 //
 // If there are no migrations to perform, returns nil.
@@ -344,6 +380,7 @@ func (r phaseBuilder) commonNode(server storage.Server, runtimePackage loc.Locat
 			Data: &storage.OperationPhaseData{
 				Server:     &server,
 				ExecServer: &leadMaster,
+				Taint:      r.taint,
 			}})
 	}
 	phases = append(phases, phase{
@@ -372,6 +409,18 @@ func (r phaseBuilder) commonNode(server storage.Server, runtimePackage loc.Locat
 			Data: &storage.OperationPhaseData{
 				Server:     &server,
 				ExecServer: &leadMaster,
+				Taint:      r.taint,
+			}})
+	}
+	if r.gates != nil {
+		phases = append(phases, phase{
+			ID:          "gates",
+			Executor:    upgradeGates,
+			Description: fmt.Sprintf("Evaluate upgrade health gates after node %q", server.Hostname),
+			Data: &storage.OperationPhaseData{
+				Server:     &server,
+				ExecServer: &leadMaster,
+				Package:    r.gates,
 			}})
 	}
 	return phases
@@ -394,7 +443,13 @@ func (r phaseBuilder) cleanup(nodes []storage.Server) *phase {
 	return &root
 }
 
-type phaseBuilder struct{}
+type phaseBuilder struct {
+	// taint is the taint placed on nodes after the system software update
+	taint *storage.NodeTaint
+	// gates is the application package with the upgrade health gates
+	// evaluated after each node. No gates are evaluated if unset
+	gates *loc.Locator
+}
 
 // AddSequential will append sub-phases which depend one upon another
 func (p *phase) AddSequential(sub ...phase) {
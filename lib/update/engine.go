@@ -19,7 +19,6 @@ package update
 import (
 	"bytes"
 	"context"
-	"fmt"
 	"os"
 	"os/exec"
 	"time"
@@ -39,88 +38,61 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-// fsmUpdateEngine is the update FSM engine
+// fsmUpdateEngine is the update FSM engine.
+//
+// It runs on the shared operator engine with the plan kept in the node-local
+// backend (see localPlanStore) and additionally updates the cluster
+// state once the operation has completed
 type fsmUpdateEngine struct {
+	// OperatorEngine implements the common engine functionality
+	*fsm.OperatorEngine
 	// FSMConfig is the state machine configuration
 	FSMConfig
-	// FieldLogger is used for logging
-	logrus.FieldLogger
-	// plan is the update operation plan
-	plan *storage.OperationPlan
-	// useEtcd indicated whether the engine should attempt to use etcd or not
-	useEtcd bool
 }
 
-// NewUpdateEngine returns a new FSM instance
-func NewUpdateEngine(c FSMConfig) (*fsmUpdateEngine, error) {
-	logger := logrus.WithFields(logrus.Fields{
-		trace.Component: "engine:update",
+// newUpdateEngine returns a new update engine for the plan from the specified store
+func newUpdateEngine(c FSMConfig, store *localPlanStore) (*fsmUpdateEngine, error) {
+	plan, err := store.GetPlan()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	engine, err := fsm.NewOperatorEngine(fsm.OperatorEngineConfig{
+		Operator:    c.Operator,
+		Operation:   clusterOperationKey(*plan),
+		Spec:        c.Spec,
+		Command:     []string{"upgrade"},
+		Store:       store,
+		FieldLogger: store.FieldLogger,
 	})
-	engine := &fsmUpdateEngine{
-		FSMConfig:   c,
-		FieldLogger: logger,
-		useEtcd:     true,
+	if err != nil {
+		return nil, trace.Wrap(err)
 	}
-
-	return engine, nil
-}
-
-// GetExecutor returns the appropriate update phase executor based on the
-// provided parameters
-func (f *fsmUpdateEngine) GetExecutor(p fsm.ExecutorParams, remote fsm.Remote) (fsm.PhaseExecutor, error) {
-	return f.Spec(p, remote)
-}
-
-// RunCommand executes the phase specified by params on the specified server
-// using the provided runner
-func (f *fsmUpdateEngine) RunCommand(ctx context.Context, runner fsm.RemoteRunner, server storage.Server, p fsm.Params) error {
-	args := []string{"upgrade", "--phase", p.PhaseID, fmt.Sprintf("--force=%v", p.Force)}
-	return runner.Run(ctx, server, args...)
-}
-
-// PreExecute is no-op for the update engine
-func (f *fsmUpdateEngine) PreExecute(ctx context.Context, p fsm.Params) error {
-	return nil
-}
-
-// PostExecute reconciles the operation plan after phase execution
-func (f *fsmUpdateEngine) PostExecute(ctx context.Context, p fsm.Params) error {
-	return nil
-}
-
-// PreRollback is no-op for the update engine
-func (f *fsmUpdateEngine) PreRollback(ctx context.Context, p fsm.Params) error {
-	return nil
+	return &fsmUpdateEngine{
+		OperatorEngine: engine,
+		FSMConfig:      c,
+	}, nil
 }
 
 // Complete marks the provided update operation as completed or failed
 // and moves the cluster into active state
 func (f *fsmUpdateEngine) Complete(fsmErr error) error {
-	plan, err := f.GetPlan()
-	if err != nil {
+	if err := f.OperatorEngine.Complete(fsmErr); err != nil {
 		return trace.Wrap(err)
 	}
 
-	opKey := clusterOperationKey(*plan)
-	op, err := f.Operator.GetSiteOperation(opKey)
+	plan, err := f.GetPlan()
 	if err != nil {
 		return trace.Wrap(err)
 	}
-
-	completed := fsm.IsCompleted(plan)
-	if completed {
-		err = ops.CompleteOperation(opKey, f.Operator)
-	} else {
-		err = ops.FailOperation(opKey, f.Operator, trace.Unwrap(fsmErr).Error())
+	if !fsm.IsCompleted(plan) {
+		return nil
 	}
+
+	op, err := f.Operator.GetSiteOperation(f.Operation)
 	if err != nil {
 		return trace.Wrap(err)
 	}
 
-	if !completed {
-		return nil
-	}
-
 	cluster, err := f.Backend.GetLocalSite(defaults.SystemAccountID)
 	if err != nil {
 		return trace.Wrap(err)
@@ -135,11 +107,6 @@ func (f *fsmUpdateEngine) Complete(fsmErr error) error {
 	return trace.Wrap(err)
 }
 
-// GetPlan returns an up-to-date plan
-func (f *fsmUpdateEngine) GetPlan() (*storage.OperationPlan, error) {
-	return f.plan, nil
-}
-
 func (f *fsmUpdateEngine) commitClusterChanges(cluster *storage.Site, op ops.SiteOperation) error {
 	updateAppLoc, err := op.Update.Package()
 	if err != nil {
@@ -184,7 +151,49 @@ func (f *fsmUpdateEngine) activateCluster(cluster storage.Site) error {
 	return nil
 }
 
-func (f *fsmUpdateEngine) loadPlan() error {
+// localPlanStore keeps the update operation plan in the node-local backend
+// so the update can proceed while the cluster etcd is down.
+// The plan changelog is synchronized with the cluster backend whenever
+// etcd is available
+type localPlanStore struct {
+	// Backend is the cluster etcd backend
+	Backend storage.Backend
+	// LocalBackend is the node-local backend that keeps the authoritative plan
+	LocalBackend storage.Backend
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+	// plan is the update operation plan
+	plan *storage.OperationPlan
+	// useEtcd indicated whether the store should attempt to use etcd or not
+	useEtcd bool
+}
+
+// newLocalPlanStore returns a new store for the last operation
+// from the local backend of the specified configuration
+func newLocalPlanStore(ctx context.Context, c FSMConfig) (*localPlanStore, error) {
+	store := &localPlanStore{
+		Backend:      c.Backend,
+		LocalBackend: c.LocalBackend,
+		FieldLogger:  logrus.WithField(trace.Component, "engine:update"),
+		useEtcd:      true,
+	}
+	err := store.loadPlan()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	err = store.reconcilePlan(ctx)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return store, nil
+}
+
+// GetPlan returns an up-to-date plan
+func (f *localPlanStore) GetPlan() (*storage.OperationPlan, error) {
+	return f.plan, nil
+}
+
+func (f *localPlanStore) loadPlan() error {
 	op, err := storage.GetLastOperation(f.LocalBackend)
 	if err != nil {
 		if !trace.IsNotFound(err) {
@@ -215,7 +224,7 @@ func (f *fsmUpdateEngine) loadPlan() error {
 	return nil
 }
 
-func (f *fsmUpdateEngine) reconcilePlan(ctx context.Context) error {
+func (f *localPlanStore) reconcilePlan(ctx context.Context) error {
 	err := f.trySyncChangelogFromEtcd(ctx)
 	if err != nil {
 		return trace.Wrap(err)
@@ -235,7 +244,7 @@ func (f *fsmUpdateEngine) reconcilePlan(ctx context.Context) error {
 	return nil
 }
 
-func (f *fsmUpdateEngine) trySyncChangelogToEtcd(ctx context.Context) error {
+func (f *localPlanStore) trySyncChangelogToEtcd(ctx context.Context) error {
 	shouldSync, err := f.isEtcdAvailable(ctx)
 	if err != nil {
 		return trace.Wrap(err)
@@ -248,7 +257,7 @@ func (f *fsmUpdateEngine) trySyncChangelogToEtcd(ctx context.Context) error {
 	return nil
 }
 
-func (f *fsmUpdateEngine) trySyncChangelogFromEtcd(ctx context.Context) error {
+func (f *localPlanStore) trySyncChangelogFromEtcd(ctx context.Context) error {
 	shouldSync, err := f.isEtcdAvailable(ctx)
 	if err != nil {
 		return trace.Wrap(err)
@@ -262,7 +271,7 @@ func (f *fsmUpdateEngine) trySyncChangelogFromEtcd(ctx context.Context) error {
 }
 
 // syncChangelog will sync changelog entries from src to dst storage
-func (f *fsmUpdateEngine) syncChangelog(src storage.Backend, dst storage.Backend) error {
+func (f *localPlanStore) syncChangelog(src storage.Backend, dst storage.Backend) error {
 	return trace.Wrap(syncChangelog(src, dst, f.plan.ClusterName, f.plan.OperationID))
 }
 
@@ -290,7 +299,7 @@ func syncChangelog(src storage.Backend, dst storage.Backend, clusterName string,
 }
 
 // isEtdAvailable checks the local backend, and checks if we're in an upgrade phase where we expect etcd to be available
-func (f *fsmUpdateEngine) isEtcdAvailable(ctx context.Context) (bool, error) {
+func (f *localPlanStore) isEtcdAvailable(ctx context.Context) (bool, error) {
 	if !f.useEtcd {
 		return false, nil
 	}
@@ -306,7 +315,9 @@ func (f *fsmUpdateEngine) isEtcdAvailable(ctx context.Context) (bool, error) {
 	return true, nil
 }
 
-func (f *fsmUpdateEngine) ChangePhaseState(ctx context.Context, change fsm.StateChange) error {
+// ChangePhaseState records the phase state change in the local backend
+// and synchronizes the changelog with the cluster backend
+func (f *localPlanStore) ChangePhaseState(ctx context.Context, change fsm.StateChange) error {
 	f.Debugf("%s.", change)
 
 	id := uuid.New()
@@ -34,10 +34,16 @@ const (
 	updateSystem = "update_system"
 	// preUpdate is the phase to run pre-update application hook
 	preUpdate = "pre_update"
+	// prePullImages is the phase to pull application images on a node
+	prePullImages = "prepull_images"
 	// coredns is a phase to create coredns related roles
 	coredns = "coredns"
+	// namespaces is the phase to reconcile application namespaces
+	namespaces = "namespaces"
 	// updateApp is the phase to update the application
 	updateApp = "update_app"
+	// smokeTest is the phase to run application smoke tests
+	smokeTest = "smoke_test"
 	// electionStatus is the phase to control node leader elections
 	electionStatus = "election_status"
 	// taintNode is the phase to taint a node
@@ -48,6 +54,8 @@ const (
 	drainNode = "drain_node"
 	// uncordonNode is the phase to uncordon a node
 	uncordonNode = "uncordon_node"
+	// upgradeGates is the phase to evaluate upgrade health gates after a node
+	upgradeGates = "upgrade_gates"
 	// endpoints is the phase to wait for system service endpoints
 	endpoints = "endpoints"
 	// config is the phase that updates system configuration
@@ -78,6 +86,7 @@ const (
 
 // fsmSpec returns the function that returns an appropriate phase executor
 func fsmSpec(c FSMConfig) fsm.FSMSpecFunc {
+	registry := newExecutorRegistry(c)
 	return func(p fsm.ExecutorParams, remote fsm.Remote) (fsm.PhaseExecutor, error) {
 		if p.Phase.Executor == "" {
 			return nil, trace.BadParameter("error in plan, executor for phase %q was not specified", p.Phase.ID)
@@ -85,62 +94,109 @@ func fsmSpec(c FSMConfig) fsm.FSMSpecFunc {
 		if p.Plan.OperationType != ops.OperationUpdate {
 			return nil, trace.BadParameter("unsupported operation %q", p.Plan.OperationType)
 		}
-
-		switch p.Phase.Executor {
-		case updateInit:
-			return NewUpdatePhaseInit(c, p.Plan, p.Phase)
-		case updateChecks:
-			return NewUpdatePhaseChecks(c, p.Plan, p.Phase, c.Remote)
-		case updateBootstrap:
-			return NewUpdatePhaseBootstrap(c, p.Plan, p.Phase, remote)
-		case coredns:
-			return NewPhaseCoreDNS(c, p.Plan, p.Phase)
-		case updateSystem:
-			return NewUpdatePhaseSystem(c, p.Plan, p.Phase, remote)
-		case preUpdate:
-			return NewUpdatePhaseBeforeApp(c, p.Plan, p.Phase)
-		case updateApp:
-			return NewUpdatePhaseApp(c, p.Plan, p.Phase)
-		case electionStatus:
-			return NewPhaseElectionChange(p.Plan, p.Phase, remote, c.Operator)
-		case taintNode:
-			return NewPhaseTaint(c, p.Plan, p.Phase)
-		case untaintNode:
-			return NewPhaseUntaint(c, p.Plan, p.Phase)
-		case drainNode:
-			return NewPhaseDrain(c, p.Plan, p.Phase)
-		case uncordonNode:
-			return NewPhaseUncordon(c, p.Plan, p.Phase)
-		case endpoints:
-			return NewPhaseEndpoints(c, p.Plan, p.Phase)
-		case config:
-			return NewUpdatePhaseConfig(c, p, remote)
-		case kubeletPermissions:
-			return NewPhaseKubeletPermissions(c, p.Plan, p.Phase)
-		case migrateLinks:
-			return NewPhaseMigrateLinks(c, p.Plan, p.Phase)
-		case updateLabels:
-			return NewPhaseUpdateLabels(c, p.Plan, p.Phase)
-		case migrateRoles:
-			return NewPhaseMigrateRoles(c, p.Plan, p.Phase)
-		case updateEtcdBackup:
-			return NewPhaseUpgradeEtcdBackup(c, p.Plan, p.Phase)
-		case updateEtcdShutdown:
-			return NewPhaseUpgradeEtcdShutdown(c, p.Plan, p.Phase)
-		case updateEtcdMaster:
-			return NewPhaseUpgradeEtcd(c, p.Plan, p.Phase)
-		case updateEtcdRestore:
-			return NewPhaseUpgradeEtcdRestore(c, p.Plan, p.Phase)
-		case updateEtcdRestart:
-			return NewPhaseUpgradeEtcdRestart(c, p.Plan, p.Phase)
-		case updateEtcdRestartGravity:
-			return NewPhaseUpgradeGravitySiteRestart(c, p.Plan, p.Phase)
-		case cleanupNode:
-			return NewGarbageCollectPhase(p.Plan, p.Phase, remote)
-		default:
-			return nil, trace.BadParameter(
-				"phase %q requires executor %q (potential mismatch between upgrade versions)",
-				p.Phase.ID, p.Phase.Executor)
+		executor, err := registry.GetExecutor(p, remote)
+		if err != nil {
+			if trace.IsNotFound(err) {
+				return nil, trace.BadParameter(
+					"phase %q requires executor %q (potential mismatch between upgrade versions)",
+					p.Phase.ID, p.Phase.Executor)
+			}
+			return nil, trace.Wrap(err)
 		}
+		return executor, nil
 	}
 }
+
+// newExecutorRegistry returns the registry of update phase executors
+// keyed by executor names
+func newExecutorRegistry(c FSMConfig) *fsm.Registry {
+	registry := fsm.NewRegistry()
+	registry.MustRegisterExecutor(updateInit, func(p fsm.ExecutorParams, remote fsm.Remote) (fsm.PhaseExecutor, error) {
+		return NewUpdatePhaseInit(c, p.Plan, p.Phase)
+	})
+	registry.MustRegisterExecutor(updateChecks, func(p fsm.ExecutorParams, remote fsm.Remote) (fsm.PhaseExecutor, error) {
+		return NewUpdatePhaseChecks(c, p.Plan, p.Phase, c.Remote)
+	})
+	registry.MustRegisterExecutor(updateBootstrap, func(p fsm.ExecutorParams, remote fsm.Remote) (fsm.PhaseExecutor, error) {
+		return NewUpdatePhaseBootstrap(c, p.Plan, p.Phase, remote)
+	})
+	registry.MustRegisterExecutor(coredns, func(p fsm.ExecutorParams, remote fsm.Remote) (fsm.PhaseExecutor, error) {
+		return NewPhaseCoreDNS(c, p.Plan, p.Phase)
+	})
+	registry.MustRegisterExecutor(updateSystem, func(p fsm.ExecutorParams, remote fsm.Remote) (fsm.PhaseExecutor, error) {
+		return NewUpdatePhaseSystem(c, p.Plan, p.Phase, remote)
+	})
+	registry.MustRegisterExecutor(prePullImages, func(p fsm.ExecutorParams, remote fsm.Remote) (fsm.PhaseExecutor, error) {
+		return NewPhasePrePull(p.Plan, p.Phase)
+	})
+	registry.MustRegisterExecutor(preUpdate, func(p fsm.ExecutorParams, remote fsm.Remote) (fsm.PhaseExecutor, error) {
+		return NewUpdatePhaseBeforeApp(c, p.Plan, p.Phase)
+	})
+	registry.MustRegisterExecutor(namespaces, func(p fsm.ExecutorParams, remote fsm.Remote) (fsm.PhaseExecutor, error) {
+		return NewPhaseNamespaces(c, p.Plan, p.Phase)
+	})
+	registry.MustRegisterExecutor(updateApp, func(p fsm.ExecutorParams, remote fsm.Remote) (fsm.PhaseExecutor, error) {
+		return NewUpdatePhaseApp(c, p.Plan, p.Phase)
+	})
+	registry.MustRegisterExecutor(smokeTest, func(p fsm.ExecutorParams, remote fsm.Remote) (fsm.PhaseExecutor, error) {
+		return NewUpdatePhaseSmokeTest(c, p.Plan, p.Phase)
+	})
+	registry.MustRegisterExecutor(electionStatus, func(p fsm.ExecutorParams, remote fsm.Remote) (fsm.PhaseExecutor, error) {
+		return NewPhaseElectionChange(p.Plan, p.Phase, remote, c.Operator)
+	})
+	registry.MustRegisterExecutor(taintNode, func(p fsm.ExecutorParams, remote fsm.Remote) (fsm.PhaseExecutor, error) {
+		return NewPhaseTaint(c, p.Plan, p.Phase)
+	})
+	registry.MustRegisterExecutor(untaintNode, func(p fsm.ExecutorParams, remote fsm.Remote) (fsm.PhaseExecutor, error) {
+		return NewPhaseUntaint(c, p.Plan, p.Phase)
+	})
+	registry.MustRegisterExecutor(drainNode, func(p fsm.ExecutorParams, remote fsm.Remote) (fsm.PhaseExecutor, error) {
+		return NewPhaseDrain(c, p.Plan, p.Phase)
+	})
+	registry.MustRegisterExecutor(uncordonNode, func(p fsm.ExecutorParams, remote fsm.Remote) (fsm.PhaseExecutor, error) {
+		return NewPhaseUncordon(c, p.Plan, p.Phase)
+	})
+	registry.MustRegisterExecutor(endpoints, func(p fsm.ExecutorParams, remote fsm.Remote) (fsm.PhaseExecutor, error) {
+		return NewPhaseEndpoints(c, p.Plan, p.Phase)
+	})
+	registry.MustRegisterExecutor(upgradeGates, func(p fsm.ExecutorParams, remote fsm.Remote) (fsm.PhaseExecutor, error) {
+		return NewPhaseGates(c, p.Plan, p.Phase)
+	})
+	registry.MustRegisterExecutor(config, func(p fsm.ExecutorParams, remote fsm.Remote) (fsm.PhaseExecutor, error) {
+		return NewUpdatePhaseConfig(c, p, remote)
+	})
+	registry.MustRegisterExecutor(kubeletPermissions, func(p fsm.ExecutorParams, remote fsm.Remote) (fsm.PhaseExecutor, error) {
+		return NewPhaseKubeletPermissions(c, p.Plan, p.Phase)
+	})
+	registry.MustRegisterExecutor(migrateLinks, func(p fsm.ExecutorParams, remote fsm.Remote) (fsm.PhaseExecutor, error) {
+		return NewPhaseMigrateLinks(c, p.Plan, p.Phase)
+	})
+	registry.MustRegisterExecutor(updateLabels, func(p fsm.ExecutorParams, remote fsm.Remote) (fsm.PhaseExecutor, error) {
+		return NewPhaseUpdateLabels(c, p.Plan, p.Phase)
+	})
+	registry.MustRegisterExecutor(migrateRoles, func(p fsm.ExecutorParams, remote fsm.Remote) (fsm.PhaseExecutor, error) {
+		return NewPhaseMigrateRoles(c, p.Plan, p.Phase)
+	})
+	registry.MustRegisterExecutor(updateEtcdBackup, func(p fsm.ExecutorParams, remote fsm.Remote) (fsm.PhaseExecutor, error) {
+		return NewPhaseUpgradeEtcdBackup(c, p.Plan, p.Phase)
+	})
+	registry.MustRegisterExecutor(updateEtcdShutdown, func(p fsm.ExecutorParams, remote fsm.Remote) (fsm.PhaseExecutor, error) {
+		return NewPhaseUpgradeEtcdShutdown(c, p.Plan, p.Phase)
+	})
+	registry.MustRegisterExecutor(updateEtcdMaster, func(p fsm.ExecutorParams, remote fsm.Remote) (fsm.PhaseExecutor, error) {
+		return NewPhaseUpgradeEtcd(c, p.Plan, p.Phase)
+	})
+	registry.MustRegisterExecutor(updateEtcdRestore, func(p fsm.ExecutorParams, remote fsm.Remote) (fsm.PhaseExecutor, error) {
+		return NewPhaseUpgradeEtcdRestore(c, p.Plan, p.Phase)
+	})
+	registry.MustRegisterExecutor(updateEtcdRestart, func(p fsm.ExecutorParams, remote fsm.Remote) (fsm.PhaseExecutor, error) {
+		return NewPhaseUpgradeEtcdRestart(c, p.Plan, p.Phase)
+	})
+	registry.MustRegisterExecutor(updateEtcdRestartGravity, func(p fsm.ExecutorParams, remote fsm.Remote) (fsm.PhaseExecutor, error) {
+		return NewPhaseUpgradeGravitySiteRestart(c, p.Plan, p.Phase)
+	})
+	registry.MustRegisterExecutor(cleanupNode, func(p fsm.ExecutorParams, remote fsm.Remote) (fsm.PhaseExecutor, error) {
+		return NewGarbageCollectPhase(p.Plan, p.Phase, remote)
+	})
+	return registry
+}
@@ -74,16 +74,11 @@ func NewFSM(ctx context.Context, c FSMConfig) (*fsm.FSM, error) {
 		trace.Component: "fsm:update",
 	})
 
-	updateEngine, err := NewUpdateEngine(c)
+	store, err := newLocalPlanStore(ctx, c)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-
-	err = updateEngine.loadPlan()
-	if err != nil {
-		return nil, trace.Wrap(err)
-	}
-	err = updateEngine.reconcilePlan(ctx)
+	updateEngine, err := newUpdateEngine(c, store)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -105,6 +100,9 @@ func (c *FSMConfig) checkAndSetDefaults() error {
 	if c.Backend == nil {
 		return trace.BadParameter("parameter Backend must be set")
 	}
+	if c.Operator == nil {
+		return trace.BadParameter("parameter Operator must be set")
+	}
 	if c.Spec == nil {
 		c.Spec = fsmSpec(*c)
 	}
@@ -159,7 +157,15 @@ func RollbackPhase(ctx context.Context, config FSMConfig, params fsm.Params, ski
 }
 
 func resumeUpdate(ctx context.Context, machine *fsm.FSM, p fsm.Params, runner rpc.AgentRepository) error {
+	if p.Until != "" {
+		// The operation is not complete until the rest of the plan is executed
+		return trace.Wrap(machine.ExecutePlanUntil(ctx, p.Progress, p.Force, p.Until))
+	}
 	fsmErr := machine.ExecutePlan(ctx, p.Progress, p.Force)
+	if fsm.IsAwaitingApproval(fsmErr) {
+		// the operation stays in progress until the phase is approved
+		return trace.Wrap(fsmErr)
+	}
 	if fsmErr != nil {
 		logrus.Warnf("Failed to execute plan: %v.", fsmErr)
 		// fallthrough
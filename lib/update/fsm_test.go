@@ -33,7 +33,7 @@ import (
 func TestUpdate(t *testing.T) { check.TestingT(t) }
 
 type FSMSuite struct {
-	engine *fsmUpdateEngine
+	config FSMConfig
 	fsm    *fsm.FSM
 }
 
@@ -41,18 +41,26 @@ var _ = check.Suite(&FSMSuite{})
 
 func (s *FSMSuite) SetUpTest(c *check.C) {
 	services := opsservice.SetupTestServices(c)
-	s.engine = &fsmUpdateEngine{
-		FSMConfig: FSMConfig{
-			LocalBackend: services.Backend,
-			Packages:     services.Packages,
-			Apps:         services.Apps,
-			Operator:     services.Operator,
-			Spec:         getTestExecutor(),
-		},
-		FieldLogger: logrus.WithField(trace.Component, "fsm-suite"),
+	s.config = FSMConfig{
+		LocalBackend: services.Backend,
+		Packages:     services.Packages,
+		Apps:         services.Apps,
+		Operator:     services.Operator,
+		Spec:         getTestExecutor(),
+	}
+}
+
+// setPlan sets up the state machine to execute the specified plan
+func (s *FSMSuite) setPlan(c *check.C, plan storage.OperationPlan) {
+	store := &localPlanStore{
+		LocalBackend: s.config.LocalBackend,
+		FieldLogger:  logrus.WithField(trace.Component, "fsm-suite"),
+		plan:         &plan,
 	}
+	engine, err := newUpdateEngine(s.config, store)
+	c.Assert(err, check.IsNil)
 	s.fsm = &fsm.FSM{
-		Config:      fsm.Config{Engine: s.engine},
+		Config:      fsm.Config{Engine: engine},
 		FieldLogger: logrus.WithField(trace.Component, "fsm-suite"),
 	}
 }
@@ -73,7 +81,7 @@ func (s *FSMSuite) TestFSMBasic(c *check.C) {
 		"/phase2": storage.OperationPhaseStateUnstarted,
 	})
 
-	s.engine.plan = &plan
+	s.setPlan(c, plan)
 	ctx := context.TODO()
 
 	// phase2 requires phase1 to be completed first
@@ -116,7 +124,7 @@ func (s *FSMSuite) TestFSMExecuteSubphase(c *check.C) {
 		},
 	}
 
-	s.engine.plan = &plan
+	s.setPlan(c, plan)
 
 	err := s.fsm.ExecutePhase(context.TODO(), fsm.Params{
 		PhaseID: "/phase1/sub1",
@@ -143,7 +151,7 @@ func (s *FSMSuite) TestFSMExecutePhaseWithSubphases(c *check.C) {
 		},
 	}
 
-	s.engine.plan = &plan
+	s.setPlan(c, plan)
 
 	err := s.fsm.ExecutePhase(context.TODO(), fsm.Params{
 		PhaseID: "/phase1",
@@ -158,7 +166,7 @@ func (s *FSMSuite) TestFSMExecutePhaseWithSubphases(c *check.C) {
 }
 
 func (s *FSMSuite) resolvePlan(c *check.C, plan storage.OperationPlan) *storage.OperationPlan {
-	changelog, err := s.engine.LocalBackend.GetOperationPlanChangelog(plan.ClusterName, plan.OperationID)
+	changelog, err := s.config.LocalBackend.GetOperationPlanChangelog(plan.ClusterName, plan.OperationID)
 	c.Assert(err, check.IsNil)
 	return fsm.ResolvePlan(plan, changelog)
 }
@@ -19,21 +19,24 @@ package update
 import (
 	"bufio"
 	"context"
+	"fmt"
 	"io"
 	"path/filepath"
+	"time"
 
 	"github.com/gravitational/gravity/lib/app"
 	"github.com/gravitational/gravity/lib/app/resources"
+	"github.com/gravitational/gravity/lib/appoverlay"
 	"github.com/gravitational/gravity/lib/archive"
 	"github.com/gravitational/gravity/lib/constants"
 	"github.com/gravitational/gravity/lib/defaults"
 	"github.com/gravitational/gravity/lib/fsm"
 	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/ops"
 	"github.com/gravitational/gravity/lib/schema"
 	"github.com/gravitational/gravity/lib/storage"
 	"github.com/gravitational/gravity/lib/utils"
 
-	dockerarchive "github.com/docker/docker/pkg/archive"
 	"github.com/gravitational/trace"
 	log "github.com/sirupsen/logrus"
 	"k8s.io/client-go/kubernetes"
@@ -43,6 +46,10 @@ import (
 type updatePhaseApp struct {
 	log.FieldLogger
 	phaseApp
+	// Operator is the cluster operator service
+	Operator ops.Operator
+	// ClusterKey identifies the cluster
+	ClusterKey ops.SiteKey
 }
 
 // NewUpdatePhaseApp returns a new app phase executor
@@ -63,7 +70,10 @@ func NewUpdatePhaseApp(c FSMConfig, plan storage.OperationPlan, phase storage.Op
 			Package:        *phase.Data.Package,
 			Servers:        plan.Servers,
 			ServiceUser:    cluster.ServiceUser,
-		}}, nil
+		},
+		Operator:   c.Operator,
+		ClusterKey: cluster.Key(),
+	}, nil
 }
 
 // Execute runs update/post-update hooks for the app
@@ -81,6 +91,32 @@ func (p *updatePhaseApp) Execute(ctx context.Context) error {
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	err = p.applyOverlay(ctx)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// applyOverlay applies the local customizations of the bundled application
+// right after it has been updated so they are not lost until the overlay
+// reconciler notices the new application version
+func (p *updatePhaseApp) applyOverlay(ctx context.Context) error {
+	overlays, err := p.Operator.GetAppOverlays(p.ClusterKey)
+	if err != nil {
+		if trace.IsNotFound(err) {
+			// cluster controller of the previous version does not support overlays
+			return nil
+		}
+		return trace.Wrap(err)
+	}
+	for _, overlay := range overlays {
+		if overlay.GetName() != p.Package.Name {
+			continue
+		}
+		p.Infof("Applying overlay of %v.", p.Package)
+		return trace.Wrap(appoverlay.Apply(ctx, p.Client, overlay, p.FieldLogger))
+	}
 	return nil
 }
 
@@ -100,7 +136,7 @@ func (p *updatePhaseApp) createBootstrapResources() error {
 	}
 	defer reader.Close()
 	err = utils.WithTempDir(func(dir string) error {
-		err := dockerarchive.Untar(reader, dir, archive.DefaultOptions())
+		err := archive.Untar(reader, dir, archive.DefaultOptions())
 		if err != nil {
 			return trace.Wrap(err)
 		}
@@ -147,6 +183,78 @@ func (p *updatePhaseBeforeApp) Rollback(context.Context) error {
 	return nil
 }
 
+// updatePhaseSmokeTest is an executor for application smoke tests
+type updatePhaseSmokeTest struct {
+	log.FieldLogger
+	phaseApp
+	// Operator is the cluster operator service
+	Operator ops.Operator
+	// OperationKey identifies the update operation
+	OperationKey ops.SiteOperationKey
+}
+
+// NewUpdatePhaseSmokeTest returns a new executor for running application smoke tests
+func NewUpdatePhaseSmokeTest(c FSMConfig, plan storage.OperationPlan, phase storage.OperationPhase) (*updatePhaseSmokeTest, error) {
+	if phase.Data.Package == nil {
+		return nil, trace.NotFound("no package specified for phase %q", phase.ID)
+	}
+	cluster, err := c.Operator.GetLocalSite()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &updatePhaseSmokeTest{
+		FieldLogger: log.NewEntry(log.New()),
+		phaseApp: phaseApp{
+			Apps:           c.Apps,
+			Client:         c.Client,
+			GravityPackage: plan.GravityPackage,
+			Package:        *phase.Data.Package,
+			Servers:        plan.Servers,
+			ServiceUser:    cluster.ServiceUser,
+		},
+		Operator:     c.Operator,
+		OperationKey: clusterOperationKey(plan),
+	}, nil
+}
+
+// Execute runs the smoke test hook for the app and records the result
+// in the operation progress
+func (p *updatePhaseSmokeTest) Execute(ctx context.Context) error {
+	err := p.runHooks(ctx, schema.HookSmokeTest)
+	if err != nil {
+		p.recordResult(fmt.Sprintf("Smoke tests for %v failed: %v",
+			p.Package, trace.Unwrap(err)))
+		return trace.Wrap(err)
+	}
+	p.recordResult(fmt.Sprintf("Smoke tests for %v passed", p.Package))
+	return nil
+}
+
+// Rollback is a no-op for this phase
+func (p *updatePhaseSmokeTest) Rollback(context.Context) error {
+	return nil
+}
+
+func (p *updatePhaseSmokeTest) recordResult(message string) {
+	progress, err := p.Operator.GetSiteOperationProgress(p.OperationKey)
+	if err != nil {
+		p.Warnf("Failed to query operation progress: %v.", trace.DebugReport(err))
+		return
+	}
+	entry := ops.ProgressEntry{
+		SiteDomain:  p.OperationKey.SiteDomain,
+		OperationID: p.OperationKey.OperationID,
+		Completion:  progress.Completion,
+		Step:        progress.Step,
+		State:       ops.ProgressStateInProgress,
+		Message:     message,
+		Created:     time.Now().UTC(),
+	}
+	if err := p.Operator.CreateProgressEntry(p.OperationKey, entry); err != nil {
+		p.Warnf("Failed to record smoke test result: %v.", trace.DebugReport(err))
+	}
+}
+
 type phaseApp struct {
 	// Apps is the cluster apps service
 	Apps app.Applications
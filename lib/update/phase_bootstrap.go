@@ -25,6 +25,7 @@ import (
 	"github.com/gravitational/gravity/lib/app/service"
 	"github.com/gravitational/gravity/lib/constants"
 	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/firewall"
 	"github.com/gravitational/gravity/lib/fsm"
 	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/ops"
@@ -79,6 +80,9 @@ type updatePhaseBootstrap struct {
 	runtimePackage loc.Locator
 	// installedRuntime specifies the installed runtime package
 	installedRuntime loc.Locator
+	// firewall specifies the host firewall configuration for the node.
+	// Nil if the application does not manage the host firewall
+	firewall *firewall.Config
 }
 
 // NewUpdatePhaseBootstrap creates a new bootstrap phase executor
@@ -114,6 +118,23 @@ func NewUpdatePhaseBootstrap(c FSMConfig, plan storage.OperationPlan, phase stor
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+	runtimePackage, installedRuntime, err = resolveServerRuntime(c.ClusterPackages,
+		*runtimePackage, *installedRuntime, phase.Data.Server.Arch)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var firewallConfig *firewall.Config
+	if app.Manifest.SystemOptions.ManagedFirewall() != nil {
+		installOperation, err := ops.GetCompletedInstallOperation(cluster.Key(), c.Operator)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		firewallConfig, err = firewall.NodeConfig(app.Manifest, phase.Data.Server.Role,
+			installOperation.InstallExpand.Vars.OnPrem)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
 	return &updatePhaseBootstrap{
 		Operator:         c.Operator,
 		Backend:          c.Backend,
@@ -131,6 +152,7 @@ func NewUpdatePhaseBootstrap(c FSMConfig, plan storage.OperationPlan, phase stor
 		remote:           remote,
 		runtimePackage:   *runtimePackage,
 		installedRuntime: *installedRuntime,
+		firewall:         firewallConfig,
 	}, nil
 }
 
@@ -170,7 +192,7 @@ func (p *updatePhaseBootstrap) Execute(ctx context.Context) error {
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	err = p.pullSystemUpdates()
+	err = p.pullSystemUpdates(ctx)
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -178,6 +200,10 @@ func (p *updatePhaseBootstrap) Execute(ctx context.Context) error {
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	err = p.configureFirewall(ctx)
+	if err != nil {
+		return trace.Wrap(err)
+	}
 	return nil
 }
 
@@ -186,6 +212,22 @@ func (p *updatePhaseBootstrap) Rollback(context.Context) error {
 	return nil
 }
 
+// configureFirewall reconciles the host firewall rules with the ports
+// required by the updated application
+func (p *updatePhaseBootstrap) configureFirewall(ctx context.Context) error {
+	if p.firewall == nil {
+		return nil
+	}
+	config := *p.firewall
+	config.FieldLogger = p.FieldLogger
+	backend, err := firewall.Apply(ctx, config)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	p.Infof("Reconciled %v firewall ports with %v.", len(config.Rules), backend)
+	return nil
+}
+
 func (p *updatePhaseBootstrap) configureNode() error {
 	err := p.Operator.ConfigureNode(ops.ConfigureNodeRequest{
 		AccountID:   p.Operation.AccountID,
@@ -227,7 +269,7 @@ func (p *updatePhaseBootstrap) updateDNSConfig() error {
 	return nil
 }
 
-func (p *updatePhaseBootstrap) pullSystemUpdates() error {
+func (p *updatePhaseBootstrap) pullSystemUpdates(ctx context.Context) error {
 	out, err := fsm.RunCommand(utils.PlanetCommandArgs(
 		filepath.Join(defaults.GravityUpdateDir, constants.GravityBin),
 		"--quiet", "--insecure", "system", "pull-updates",
@@ -246,17 +288,22 @@ func (p *updatePhaseBootstrap) pullSystemUpdates() error {
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	requests := make([]service.PackagePullRequest, 0, len(updates))
 	for _, update := range updates {
 		p.Infof("Pulling package update: %v.", update)
-		_, err := service.PullPackage(service.PackagePullRequest{
+		requests = append(requests, service.PackagePullRequest{
 			SrcPack: p.Packages,
 			DstPack: p.LocalPackages,
 			Package: update,
 			Upsert:  true,
 		})
-		if err != nil {
-			return trace.Wrap(err)
-		}
+	}
+	err = service.PullPackages(ctx, service.PackagesPullRequest{
+		Requests: requests,
+		Parallel: defaults.PullPackagesConcurrency,
+	})
+	if err != nil {
+		return trace.Wrap(err)
 	}
 	// after having pulled packages as root we need to set proper ownership
 	// on the blobs dir
@@ -386,6 +433,22 @@ func getInstalledRuntime(apps app.Applications, installedApp loc.Locator, profil
 	return installedRuntime, nil
 }
 
+// resolveServerRuntime returns the update and installed runtime packages
+// for a server with the specified architecture.
+// Servers with a different architecture run the runtime variant
+// built for their architecture
+func resolveServerRuntime(packages pack.PackageService, runtimePackage, installedRuntime loc.Locator, arch string) (update, installed *loc.Locator, err error) {
+	update, err = pack.ResolveArch(packages, runtimePackage, arch)
+	if err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+	installed, err = pack.ResolveArch(packages, installedRuntime, arch)
+	if err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+	return update, installed, nil
+}
+
 // getGravityPath returns path to the new gravity binary
 func getGravityPath() (string, error) {
 	stateDir, err := state.GetStateDir()
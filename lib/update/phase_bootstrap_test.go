@@ -0,0 +1,97 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package update
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/ops/opsservice"
+	"github.com/gravitational/gravity/lib/pack"
+
+	"gopkg.in/check.v1"
+)
+
+type PhaseBootstrapSuite struct {
+	packages pack.PackageService
+}
+
+var _ = check.Suite(&PhaseBootstrapSuite{})
+
+func (s *PhaseBootstrapSuite) SetUpTest(c *check.C) {
+	services := opsservice.SetupTestServices(c)
+	s.packages = services.Packages
+	c.Assert(s.packages.UpsertRepository("gravitational.io", time.Time{}), check.IsNil)
+	for _, locator := range []string{
+		"gravitational.io/planet:1.0.0",
+		"gravitational.io/planet:1.0.0_arm64",
+		"gravitational.io/planet:2.0.0",
+		"gravitational.io/planet:2.0.0_arm64",
+		"gravitational.io/planet:3.0.0",
+	} {
+		_, err := s.packages.CreatePackage(loc.MustParseLocator(locator), bytes.NewBufferString(locator))
+		c.Assert(err, check.IsNil)
+	}
+}
+
+func (s *PhaseBootstrapSuite) TestResolvesRuntimeForServerArch(c *check.C) {
+	var testCases = []struct {
+		comment   string
+		update    string
+		installed string
+		arch      string
+		expected  [2]string
+	}{
+		{
+			comment:   "default architecture",
+			update:    "gravitational.io/planet:2.0.0",
+			installed: "gravitational.io/planet:1.0.0",
+			arch:      loc.DefaultArch,
+			expected:  [2]string{"gravitational.io/planet:2.0.0", "gravitational.io/planet:1.0.0"},
+		},
+		{
+			comment:   "server without architecture",
+			update:    "gravitational.io/planet:2.0.0",
+			installed: "gravitational.io/planet:1.0.0",
+			expected:  [2]string{"gravitational.io/planet:2.0.0", "gravitational.io/planet:1.0.0"},
+		},
+		{
+			comment:   "architecture variants",
+			update:    "gravitational.io/planet:2.0.0",
+			installed: "gravitational.io/planet:1.0.0",
+			arch:      "arm64",
+			expected:  [2]string{"gravitational.io/planet:2.0.0_arm64", "gravitational.io/planet:1.0.0_arm64"},
+		},
+		{
+			comment:   "update without architecture variant",
+			update:    "gravitational.io/planet:3.0.0",
+			installed: "gravitational.io/planet:2.0.0",
+			arch:      "arm64",
+			expected:  [2]string{"gravitational.io/planet:3.0.0", "gravitational.io/planet:2.0.0_arm64"},
+		},
+	}
+	for _, tc := range testCases {
+		comment := check.Commentf(tc.comment)
+		update, installed, err := resolveServerRuntime(s.packages,
+			loc.MustParseLocator(tc.update), loc.MustParseLocator(tc.installed), tc.arch)
+		c.Assert(err, check.IsNil, comment)
+		c.Assert([2]string{update.String(), installed.String()}, check.Equals, tc.expected, comment)
+		// variants keep the name of the runtime package
+		c.Assert(update.Name, check.Equals, loc.MustParseLocator(tc.update).Name, comment)
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 
 	"github.com/gravitational/gravity/lib/app"
+	"github.com/gravitational/gravity/lib/checks"
 	"github.com/gravitational/gravity/lib/fsm"
 	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/storage"
@@ -103,7 +104,23 @@ func (p *updatePhaseChecks) Execute(ctx context.Context) error {
 	}
 
 	err = validate(ctx, p.remote, p.servers, installedApp.Manifest, app.Manifest, dockerConfig)
-	return trace.Wrap(err, "failed to validate requirements")
+	if err != nil {
+		return trace.Wrap(err, "failed to validate requirements")
+	}
+
+	matrix, err := checks.CheckNetwork(ctx, checks.NetworkCheckConfig{
+		FieldLogger: p.FieldLogger,
+		Servers:     p.servers,
+		Dialer:      checks.NewAgentDialer(p.remote),
+	})
+	if err != nil {
+		return trace.Wrap(err, "failed to check network connectivity")
+	}
+	if len(matrix.Blocked()) != 0 {
+		return trace.BadParameter("nodes failed to reach each other on the following paths:\n%v\n%v",
+			matrix.FormatBlocked(), matrix)
+	}
+	return nil
 }
 
 // Rollback is a no-op for this phase
@@ -0,0 +1,226 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package update
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gravitational/gravity/lib/ops/monitoring"
+	"github.com/gravitational/gravity/lib/schema"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/rigging"
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+// phaseGates defines the operation of evaluating the upgrade health gates
+// after the system software on a node has been updated.
+// A failed gate fails the phase which pauses the rolling upgrade until
+// the operation is either resumed or rolled back
+type phaseGates struct {
+	kubernetesOperation
+	log.FieldLogger
+	// gates are the upgrade health gates to evaluate
+	gates *schema.UpgradeGates
+}
+
+// NewPhaseGates returns a new executor for evaluating the upgrade health gates
+func NewPhaseGates(c FSMConfig, plan storage.OperationPlan, phase storage.OperationPhase) (*phaseGates, error) {
+	if phase.Data == nil || phase.Data.Package == nil {
+		return nil, trace.NotFound("no application package specified for phase %q", phase.ID)
+	}
+	op, err := newKubernetesOperation(c, plan, phase)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	application, err := c.Apps.GetApp(*phase.Data.Package)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &phaseGates{
+		kubernetesOperation: *op,
+		FieldLogger: log.WithFields(log.Fields{
+			trace.Component: "gates",
+			"node":          phase.Data.Server.Hostname,
+		}),
+		gates: application.Manifest.SystemOptions.UpgradeGates(),
+	}, nil
+}
+
+// Execute evaluates the upgrade health gates periodically
+// for the duration of the configured window
+func (p *phaseGates) Execute(ctx context.Context) error {
+	if p.gates == nil {
+		p.Info("No upgrade health gates configured.")
+		return nil
+	}
+	baseline, err := p.restartBaseline()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	ticker := time.NewTicker(p.gates.GetInterval())
+	defer ticker.Stop()
+	deadline := time.After(p.gates.GetWindow())
+	for {
+		select {
+		case <-ticker.C:
+			if err := p.evaluate(baseline); err != nil {
+				p.emitEvent(v1.EventTypeWarning, eventReasonUpgradeGateFailed, err.Error())
+				return trace.Wrap(err, "upgrade paused after node %v, resume the "+
+					"operation once the cluster is healthy or roll it back",
+					p.Server.Hostname)
+			}
+		case <-deadline:
+			p.Infof("All upgrade health gates passed in %v.", p.gates.GetWindow())
+			return nil
+		case <-ctx.Done():
+			return trace.Wrap(ctx.Err())
+		}
+	}
+}
+
+// Rollback is a no-op for this phase
+func (p *phaseGates) Rollback(context.Context) error {
+	return nil
+}
+
+// restartBaseline returns the container restart counts of the pods
+// selected by the pod restart gates, keyed by the gate name
+func (p *phaseGates) restartBaseline() (map[string]podRestarts, error) {
+	baseline := make(map[string]podRestarts)
+	for _, gate := range p.gates.Checks {
+		if gate.PodRestarts == nil {
+			continue
+		}
+		restarts, err := getPodRestarts(p.Client.CoreV1(), *gate.PodRestarts)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		baseline[gate.Name] = restarts
+	}
+	return baseline, nil
+}
+
+// evaluate evaluates all gates once and returns the first gate failure
+func (p *phaseGates) evaluate(baseline map[string]podRestarts) error {
+	for _, gate := range p.gates.Checks {
+		var value float64
+		if gate.PodRestarts != nil {
+			restarts, err := getPodRestarts(p.Client.CoreV1(), *gate.PodRestarts)
+			if err != nil {
+				return trace.Wrap(err)
+			}
+			value = float64(restarts.since(baseline[gate.Name]))
+		} else {
+			var err error
+			value, err = monitoring.QueryValue(p.Client.CoreV1(), gate.Query)
+			if trace.IsNotFound(err) {
+				p.Warnf("Upgrade gate %q query returned no data: %v.", gate.Name, err)
+				continue
+			}
+			if err != nil {
+				return trace.Wrap(err, "failed to evaluate upgrade gate %q", gate.Name)
+			}
+		}
+		p.Debugf("Upgrade gate %q value: %v.", gate.Name, value)
+		if err := gate.Evaluate(value); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// emitEvent records a Kubernetes event for the node being updated
+func (p *phaseGates) emitEvent(eventType, reason, message string) {
+	now := metav1.Now()
+	name := p.Server.KubeNodeID()
+	event := &v1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: fmt.Sprintf("%v.", name),
+			Namespace:    metav1.NamespaceDefault,
+		},
+		InvolvedObject: v1.ObjectReference{
+			Kind: "Node",
+			Name: name,
+			// node events use node name as UID
+			UID: types.UID(name),
+		},
+		Reason:         reason,
+		Message:        message,
+		Type:           eventType,
+		Source:         v1.EventSource{Component: eventSourceUpdate},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+	_, err := p.Client.CoreV1().Events(metav1.NamespaceDefault).Create(event)
+	if err != nil {
+		p.Warnf("Failed to emit %v event for node %v: %v.",
+			reason, name, trace.DebugReport(rigging.ConvertError(err)))
+	}
+}
+
+// getPodRestarts returns the container restart counts of the pods
+// selected by the specified gate
+func getPodRestarts(client corev1.PodsGetter, gate schema.PodRestartsGate) (podRestarts, error) {
+	pods, err := client.Pods(gate.Namespace).List(metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(gate.Selector).String(),
+	})
+	if err != nil {
+		return nil, trace.Wrap(rigging.ConvertError(err))
+	}
+	restarts := make(podRestarts, len(pods.Items))
+	for _, pod := range pods.Items {
+		var count int32
+		for _, status := range pod.Status.ContainerStatuses {
+			count += status.RestartCount
+		}
+		restarts[pod.UID] = count
+	}
+	return restarts, nil
+}
+
+// podRestarts maps pod UIDs to the total number of container restarts
+type podRestarts map[types.UID]int32
+
+// since returns the number of container restarts since the specified baseline.
+// Pods created after the baseline count all their restarts
+func (r podRestarts) since(baseline podRestarts) int32 {
+	var total int32
+	for uid, count := range r {
+		if count > baseline[uid] {
+			total += count - baseline[uid]
+		}
+	}
+	return total
+}
+
+const (
+	// eventReasonUpgradeGateFailed is the reason of the event emitted
+	// when an upgrade health gate fails
+	eventReasonUpgradeGateFailed = "UpgradeGateFailed"
+	// eventSourceUpdate is the source component of update events
+	eventSourceUpdate = "gravity-update"
+)
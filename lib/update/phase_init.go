@@ -154,24 +154,39 @@ func (p *updatePhaseInit) Execute(context.Context) error {
 		return trace.Wrap(err, "failed to update Docker configuration")
 	}
 	for _, server := range p.Servers {
-		if err := p.rotateSecrets(server); err != nil {
-			return trace.Wrap(err, "failed to rotate secrets for %v", server)
-		}
-		updatePlanet, updateTeleport, err := systemNeedsUpdate(server.Role, p.installedApp, p.app)
-		if err != nil {
+		if err := p.rotatePackages(server); err != nil {
 			return trace.Wrap(err)
 		}
-		if updatePlanet {
-			if err := p.rotatePlanetConfig(server); err != nil {
-				return trace.Wrap(err, "failed to rotate planet configuration for %v", server)
-			}
+	}
+	return nil
+}
+
+// rotatePackages generates new secrets and configuration packages for the
+// specified server. The packages are created in a single transaction so the
+// server never references a partially updated set of packages
+func (p *updatePhaseInit) rotatePackages(server storage.Server) error {
+	tx := pack.NewTransaction(p.Packages)
+	if err := p.rotateSecrets(tx, server); err != nil {
+		return trace.Wrap(err, "failed to rotate secrets for %v", server)
+	}
+	updatePlanet, updateTeleport, err := systemNeedsUpdate(server.Role, p.installedApp, p.app)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if updatePlanet {
+		if err := p.rotatePlanetConfig(tx, server); err != nil {
+			return trace.Wrap(err, "failed to rotate planet configuration for %v", server)
 		}
-		if updateTeleport {
-			if err := p.rotateTeleportConfig(server); err != nil {
-				return trace.Wrap(err, "failed to rotate teleport configuration for %v", server)
-			}
+	}
+	if updateTeleport {
+		if err := p.rotateTeleportConfig(tx, server); err != nil {
+			return trace.Wrap(err, "failed to rotate teleport configuration for %v", server)
 		}
 	}
+	if err := tx.Commit(); err != nil {
+		return trace.Wrap(err, "failed to update packages for %v", server)
+	}
+	p.Debugf("Rotated packages for %v.", server)
 	return nil
 }
 
@@ -306,7 +321,7 @@ func (p *updatePhaseInit) createAdminAgent() error {
 	return nil
 }
 
-func (p *updatePhaseInit) rotateSecrets(server storage.Server) error {
+func (p *updatePhaseInit) rotateSecrets(tx *pack.Transaction, server storage.Server) error {
 	resp, err := p.Operator.RotateSecrets(ops.RotateSecretsRequest{
 		AccountID:   p.Operation.AccountID,
 		ClusterName: p.Operation.SiteDomain,
@@ -315,15 +330,12 @@ func (p *updatePhaseInit) rotateSecrets(server storage.Server) error {
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	_, err = p.Packages.CreatePackage(resp.Locator, resp.Reader, pack.WithLabels(resp.Labels))
-	if err != nil {
-		return trace.Wrap(err)
-	}
-	p.Debugf("Rotated secrets package for %v: %v.", server, resp.Locator)
+	tx.CreatePackage(resp.Locator, resp.Reader, pack.WithLabels(resp.Labels))
+	p.Debugf("Generated secrets package for %v: %v.", server, resp.Locator)
 	return nil
 }
 
-func (p *updatePhaseInit) rotatePlanetConfig(server storage.Server) error {
+func (p *updatePhaseInit) rotatePlanetConfig(tx *pack.Transaction, server storage.Server) error {
 	resp, err := p.Operator.RotatePlanetConfig(ops.RotateConfigPackageRequest{
 		AccountID:   p.Operation.AccountID,
 		ClusterName: p.Operation.SiteDomain,
@@ -334,16 +346,12 @@ func (p *updatePhaseInit) rotatePlanetConfig(server storage.Server) error {
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	_, err = p.Packages.UpsertPackage(resp.Locator, resp.Reader,
-		pack.WithLabels(resp.Labels))
-	if err != nil {
-		return trace.Wrap(err)
-	}
-	p.Debugf("Rotated planet config package for %v: %v.", server, resp.Locator)
+	tx.UpsertPackage(resp.Locator, resp.Reader, pack.WithLabels(resp.Labels))
+	p.Debugf("Generated planet config package for %v: %v.", server, resp.Locator)
 	return nil
 }
 
-func (p *updatePhaseInit) rotateTeleportConfig(server storage.Server) error {
+func (p *updatePhaseInit) rotateTeleportConfig(tx *pack.Transaction, server storage.Server) error {
 	masterConf, nodeConf, err := p.Operator.RotateTeleportConfig(ops.RotateConfigPackageRequest{
 		AccountID:   p.Operation.AccountID,
 		ClusterName: p.Operation.SiteDomain,
@@ -355,17 +363,11 @@ func (p *updatePhaseInit) rotateTeleportConfig(server storage.Server) error {
 		return trace.Wrap(err)
 	}
 	if masterConf != nil {
-		_, err = p.Packages.UpsertPackage(masterConf.Locator, masterConf.Reader, pack.WithLabels(masterConf.Labels))
-		if err != nil {
-			return trace.Wrap(err)
-		}
-		p.Debugf("Rotated teleport master config package for %v: %v.", server, masterConf.Locator)
-	}
-	_, err = p.Packages.UpsertPackage(nodeConf.Locator, nodeConf.Reader, pack.WithLabels(nodeConf.Labels))
-	if err != nil {
-		return trace.Wrap(err)
+		tx.UpsertPackage(masterConf.Locator, masterConf.Reader, pack.WithLabels(masterConf.Labels))
+		p.Debugf("Generated teleport master config package for %v: %v.", server, masterConf.Locator)
 	}
-	p.Debugf("Rotated teleport node config package for %v: %v.", server, nodeConf.Locator)
+	tx.UpsertPackage(nodeConf.Locator, nodeConf.Reader, pack.WithLabels(nodeConf.Labels))
+	p.Debugf("Generated teleport node config package for %v: %v.", server, nodeConf.Locator)
 	return nil
 }
 
@@ -406,7 +408,7 @@ func (p *updatePhaseInit) removeConfiguredPackages() error {
 	p.Info("Removing configured packages.")
 	return pack.ForeachPackageInRepo(p.Packages, p.Operation.SiteDomain,
 		func(e pack.PackageEnvelope) error {
-			if e.HasLabel(pack.OperationIDLabel, p.Operation.ID) {
+			if e.HasLabel(pack.OperationIDLabel, p.Operation.ID) && !e.IsPinned() {
 				p.Infof("Removing package %q.", e.Locator)
 				return p.Packages.DeletePackage(e.Locator)
 			}
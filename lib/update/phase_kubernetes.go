@@ -45,6 +45,8 @@ import (
 type phaseTaint struct {
 	kubernetesOperation
 	log.FieldLogger
+	// taint is the taint to add
+	taint v1.Taint
 }
 
 // NewPhaseTaint returns a new executor for adding a taint to a node
@@ -56,28 +58,51 @@ func NewPhaseTaint(c FSMConfig, plan storage.OperationPlan, phase storage.Operat
 	return &phaseTaint{
 		kubernetesOperation: *op,
 		FieldLogger:         log.NewEntry(log.New()),
+		taint:               phaseTaintSpec(phase),
 	}, nil
 }
 
 // Execute adds a taint on the specified node.
 func (p *phaseTaint) Execute(ctx context.Context) error {
-	err := taint(ctx, p.Client.CoreV1().Nodes(), p.Server.KubeNodeID(), addTaint(true))
+	p.warnIntolerantDaemonSets()
+	err := taint(ctx, p.Client.CoreV1().Nodes(), p.Server.KubeNodeID(), p.taint, addTaint(true))
 	return trace.Wrap(err)
 }
 
 // Rollback removes the taint from the node
 func (p *phaseTaint) Rollback(ctx context.Context) error {
-	err := taint(ctx, p.Client.CoreV1().Nodes(), p.Server.KubeNodeID(), addTaint(false))
+	err := taint(ctx, p.Client.CoreV1().Nodes(), p.Server.KubeNodeID(), p.taint, addTaint(false))
 	if err != nil && !trace.IsNotFound(err) {
 		return trace.Wrap(err)
 	}
 	return nil
 }
 
+// warnIntolerantDaemonSets logs the DaemonSets that do not tolerate the taint
+// and hence will have their pods evicted from the node while it is tainted
+func (p *phaseTaint) warnIntolerantDaemonSets() {
+	if p.taint.Effect != v1.TaintEffectNoExecute {
+		return
+	}
+	daemonSets, err := p.Client.AppsV1().DaemonSets(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		p.Warnf("Failed to query DaemonSets: %v.", trace.DebugReport(rigging.ConvertError(err)))
+		return
+	}
+	for _, daemonSet := range daemonSets.Items {
+		if !toleratesTaint(daemonSet.Spec.Template.Spec.Tolerations, p.taint) {
+			p.Infof("DaemonSet %v/%v does not tolerate %v and will be evicted from node %v until it is untainted.",
+				daemonSet.Namespace, daemonSet.Name, p.taint.ToString(), p.Server.KubeNodeID())
+		}
+	}
+}
+
 // phaseUntaint defines the operation of removing a taint from the node
 type phaseUntaint struct {
 	kubernetesOperation
 	log.FieldLogger
+	// taint is the taint to remove
+	taint v1.Taint
 }
 
 // NewPhaseUntaint returns a new executor for removing a taint from a node
@@ -89,12 +114,13 @@ func NewPhaseUntaint(c FSMConfig, plan storage.OperationPlan, phase storage.Oper
 	return &phaseUntaint{
 		kubernetesOperation: *op,
 		FieldLogger:         log.NewEntry(log.New()),
+		taint:               phaseTaintSpec(phase),
 	}, nil
 }
 
 // Execute removes a taint from the specified node.
 func (p *phaseUntaint) Execute(ctx context.Context) error {
-	err := taint(ctx, p.Client.CoreV1().Nodes(), p.Server.KubeNodeID(), addTaint(false))
+	err := taint(ctx, p.Client.CoreV1().Nodes(), p.Server.KubeNodeID(), p.taint, addTaint(false))
 	return trace.Wrap(err)
 }
 
@@ -266,13 +292,34 @@ func (p *kubernetesOperation) PostCheck(context.Context) error {
 	return nil
 }
 
-func taint(ctx context.Context, client corev1.NodeInterface, node string, add addTaint) error {
-	taint := v1.Taint{
-		Key:    defaults.RunLevelLabel,
-		Value:  defaults.RunLevelSystem,
-		Effect: v1.TaintEffectNoExecute,
+// phaseTaintSpec returns the taint specified in the phase data.
+// Plans created before the taint became configurable use the run-level taint
+func phaseTaintSpec(phase storage.OperationPhase) v1.Taint {
+	if phase.Data == nil || phase.Data.Taint == nil {
+		return v1.Taint{
+			Key:    defaults.RunLevelLabel,
+			Value:  defaults.RunLevelSystem,
+			Effect: v1.TaintEffectNoExecute,
+		}
+	}
+	return v1.Taint{
+		Key:    phase.Data.Taint.Key,
+		Value:  phase.Data.Taint.Value,
+		Effect: v1.TaintEffect(phase.Data.Taint.Effect),
 	}
+}
+
+// toleratesTaint returns true if any of the tolerations tolerates the taint
+func toleratesTaint(tolerations []v1.Toleration, taint v1.Taint) bool {
+	for _, toleration := range tolerations {
+		if toleration.ToleratesTaint(&taint) {
+			return true
+		}
+	}
+	return false
+}
 
+func taint(ctx context.Context, client corev1.NodeInterface, node string, taint v1.Taint, add addTaint) error {
 	var taintsToAdd, taintsToRemove []v1.Taint
 	if add {
 		taintsToAdd = append(taintsToAdd, taint)
@@ -0,0 +1,85 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package update
+
+import (
+	"context"
+
+	"github.com/gravitational/gravity/lib/app"
+	libkubernetes "github.com/gravitational/gravity/lib/kubernetes"
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+func (r phaseBuilder) namespaces(leadMaster storage.Server, appPackage loc.Locator) *phase {
+	phase := root(phase{
+		ID:          "namespaces",
+		Description: "Reconcile application namespaces",
+		Executor:    namespaces,
+		Data: &storage.OperationPhaseData{
+			Server:  &leadMaster,
+			Package: &appPackage,
+		},
+	})
+	return &phase
+}
+
+// updatePhaseNamespaces reconciles namespaces declared in the manifest
+// of the application being updated
+type updatePhaseNamespaces struct {
+	kubernetesOperation
+	log.FieldLogger
+	// app is the application being updated
+	app app.Application
+}
+
+// NewPhaseNamespaces returns a new executor that reconciles application namespaces
+func NewPhaseNamespaces(c FSMConfig, plan storage.OperationPlan, phase storage.OperationPhase) (*updatePhaseNamespaces, error) {
+	op, err := newKubernetesOperation(c, plan, phase)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if phase.Data.Package == nil {
+		return nil, trace.BadParameter("no application package specified for phase %q", phase.ID)
+	}
+	application, err := c.Apps.GetApp(*phase.Data.Package)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &updatePhaseNamespaces{
+		kubernetesOperation: *op,
+		FieldLogger:         log.WithField("phase", phase.ID),
+		app:                 *application,
+	}, nil
+}
+
+// Execute creates namespaces added to the manifest and updates quotas
+// and limit ranges of the existing ones
+func (p *updatePhaseNamespaces) Execute(context.Context) error {
+	err := libkubernetes.UpsertNamespaces(p.Client.CoreV1(), p.app.Package.Name,
+		p.app.Manifest.Namespaces, p.FieldLogger)
+	return trace.Wrap(err)
+}
+
+// Rollback is a no-op for this phase: the previous application version
+// tolerates the updated quotas and namespaces are never removed
+func (p *updatePhaseNamespaces) Rollback(context.Context) error {
+	return nil
+}
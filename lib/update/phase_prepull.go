@@ -0,0 +1,91 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package update
+
+import (
+	"context"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/fsm"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// NewPhasePrePull returns a new executor for the image pre-pull phase
+func NewPhasePrePull(plan storage.OperationPlan, phase storage.OperationPhase) (*phasePrePull, error) {
+	if phase.Data == nil || phase.Data.Server == nil {
+		return nil, trace.BadParameter("no server specified for phase %q", phase.ID)
+	}
+	return &phasePrePull{
+		FieldLogger: log.WithFields(log.Fields{
+			"phase":  phase.ID,
+			"server": phase.Data.Server.AdvertiseIP,
+		}),
+		Server: *phase.Data.Server,
+		Images: phase.Data.Images,
+	}, nil
+}
+
+// Execute pulls the application images from the cluster registry.
+//
+// Images are pulled one at a time and nodes are processed one after another
+// so the cluster registry is not flooded with concurrent requests.
+func (r *phasePrePull) Execute(ctx context.Context) error {
+	for _, image := range r.Images {
+		select {
+		case <-ctx.Done():
+			return trace.Wrap(ctx.Err())
+		default:
+		}
+		r.Infof("Pulling image %v.", image)
+		command := utils.PlanetCommandArgs(defaults.DockerBin, "pull", image)
+		out, err := fsm.RunCommand(command)
+		if err != nil {
+			return trace.Wrap(err, "failed to pull image %v: %s", image, out)
+		}
+	}
+	return nil
+}
+
+// Rollback is a no-op for this phase: the pulled images are left
+// on the node and will be removed by the regular image garbage collection
+func (*phasePrePull) Rollback(context.Context) error {
+	return nil
+}
+
+// PreCheck is no-op for this phase
+func (*phasePrePull) PreCheck(context.Context) error {
+	return nil
+}
+
+// PostCheck is no-op for this phase
+func (*phasePrePull) PostCheck(context.Context) error {
+	return nil
+}
+
+// phasePrePull is the phase that pulls the new application images on a node
+// before the application is updated
+type phasePrePull struct {
+	log.FieldLogger
+	// Server is the server this phase operates on
+	Server storage.Server
+	// Images is the list of images to pull
+	Images []string
+}
@@ -191,7 +191,7 @@ func (p *updatePhaseConfig) Rollback(context.Context) error {
 		pack.PurposeLabel:     pack.PurposeTeleportMasterConfig,
 	}
 	return pack.ForeachPackage(p.LocalPackages, func(e pack.PackageEnvelope) error {
-		if e.HasLabels(labels) {
+		if e.HasLabels(labels) && !e.IsPinned() {
 			p.Infof("Removing package %v.", e.Locator)
 			return p.LocalPackages.DeletePackage(e.Locator)
 		}
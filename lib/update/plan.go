@@ -22,6 +22,8 @@ import (
 	"path"
 
 	"github.com/gravitational/gravity/lib/app"
+	"github.com/gravitational/gravity/lib/app/docker"
+	"github.com/gravitational/gravity/lib/archive"
 	"github.com/gravitational/gravity/lib/constants"
 	"github.com/gravitational/gravity/lib/defaults"
 	"github.com/gravitational/gravity/lib/fsm"
@@ -216,6 +218,11 @@ func NewOperationPlan(env *localenv.ClusterEnvironment, op storage.SiteOperation
 		return nil, trace.Wrap(err)
 	}
 
+	images, err := getPrePullImages(env.ClusterPackages, *installedApp, *updateApp)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
 	plan, err := newOperationPlan(newPlanParams{
 		operation:         op,
 		servers:           servers,
@@ -230,6 +237,7 @@ func NewOperationPlan(env *localenv.ClusterEnvironment, op storage.SiteOperation
 		updateCoreDNS:     updateCoreDNS,
 		updateDNSAppEarly: updateDNSAppEarly,
 		roles:             roles,
+		images:            images,
 	})
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -267,6 +275,8 @@ type newPlanParams struct {
 	updateDNSAppEarly bool
 	// roles is the existing cluster roles
 	roles []teleservices.Role
+	// images is the list of updated application images to pre-pull on nodes
+	images []string
 }
 
 func newOperationPlan(p newPlanParams) (*storage.OperationPlan, error) {
@@ -284,19 +294,35 @@ func newOperationPlan(p newPlanParams) (*storage.OperationPlan, error) {
 		GravityPackage: *gravityPackage,
 	}
 
-	builder := phaseBuilder{}
+	builder := phaseBuilder{taint: upgradeTaint(p.updateApp.Manifest)}
+	if p.updateApp.Manifest.SystemOptions.UpgradeGates() != nil {
+		builder.gates = &p.updateApp.Package
+	}
 	initPhase := *builder.init(p.installedApp.Package, p.updateApp.Package)
 	checksPhase := *builder.checks(p.installedApp.Package, p.updateApp.Package).Require(initPhase)
 	preUpdatePhase := *builder.preUpdate(p.updateApp.Package).Require(initPhase)
 	bootstrapPhase := *builder.bootstrap(p.servers,
 		p.installedApp.Package, p.updateApp.Package).Require(initPhase)
 
+	// images are pulled on all nodes before any pods are restarted to avoid
+	// the application pods pulling them all at once after the update
+	var prePullPhase *phase
+	if len(p.images) != 0 {
+		prePullPhase = builder.prePull(p.servers, p.images).Require(initPhase)
+	}
+
 	var masters, nodes runtimeServers
 	for _, server := range p.servers {
 		runtimePackage, err := p.updateApp.Manifest.RuntimePackageForProfile(server.Role)
 		if err != nil {
 			return nil, trace.Wrap(err)
 		}
+		// update servers with a different architecture to the runtime variant
+		// built for their architecture
+		runtimePackage, err = pack.ResolveArch(p.packageService, *runtimePackage, server.Arch)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
 
 		if fsm.IsMasterServer(server) {
 			masters = append(masters, runtimeServer{Server: server, runtime: *runtimePackage})
@@ -328,6 +354,9 @@ func newOperationPlan(p newPlanParams) (*storage.OperationPlan, error) {
 
 	mastersPhase := *builder.masters(leadMaster, masters[1:], supportsTaints).
 		Require(checksPhase, bootstrapPhase, preUpdatePhase)
+	if prePullPhase != nil {
+		mastersPhase.Require(*prePullPhase)
+	}
 	nodesPhase := *builder.nodes(leadMaster.Server, nodes, supportsTaints).
 		Require(mastersPhase)
 
@@ -361,6 +390,9 @@ func newOperationPlan(p newPlanParams) (*storage.OperationPlan, error) {
 	}
 
 	appPhase := *builder.app(appUpdates)
+	if prePullPhase != nil {
+		appPhase.Require(*prePullPhase)
+	}
 	if len(runtimeUpdates) != 0 {
 		appPhase.Require(mastersPhase)
 	}
@@ -368,6 +400,17 @@ func newOperationPlan(p newPlanParams) (*storage.OperationPlan, error) {
 		appPhase.RequireLiteral(runtimePhase.ChildLiteral(constants.BootstrapConfigPackage))
 	}
 
+	// namespaces declared by the new application version are reconciled
+	// before the application is updated so its resources can use them
+	var namespacesPhase *phase
+	if len(p.updateApp.Manifest.Namespaces) != 0 {
+		namespacesPhase = builder.namespaces(leadMaster.Server, p.updateApp.Package)
+		if len(runtimeUpdates) != 0 {
+			namespacesPhase.Require(mastersPhase)
+		}
+		appPhase.Require(*namespacesPhase)
+	}
+
 	// check if etcd upgrade is required or not
 	updateEtcd, currentVersion, desiredVersion, err := p.shouldUpdateEtcd(p)
 	if err != nil {
@@ -376,8 +419,20 @@ func newOperationPlan(p newPlanParams) (*storage.OperationPlan, error) {
 
 	cleanupPhase := *builder.cleanup(p.servers).Require(appPhase)
 
+	// smoke tests verify the updated application before the cleanup
+	// removes anything that might be needed to roll back
+	var smokeTestPhase *phase
+	if hooks := p.updateApp.Manifest.Hooks; hooks != nil && hooks.SmokeTest != nil {
+		smokeTestPhase = builder.smokeTest(p.updateApp.Package,
+			p.updateApp.Manifest.SystemOptions.UpgradeAutoRollback()).Require(appPhase)
+		cleanupPhase.Require(*smokeTestPhase)
+	}
+
 	// Order the phases
 	phases := phases{initPhase, checksPhase, preUpdatePhase}
+	if prePullPhase != nil {
+		phases = append(phases, *prePullPhase)
+	}
 	if len(runtimeUpdates) > 0 {
 		if p.updateCoreDNS {
 			corednsPhase := *builder.corednsPhase(leadMaster.Server)
@@ -418,13 +473,65 @@ func newOperationPlan(p newPlanParams) (*storage.OperationPlan, error) {
 		configPhase := *builder.config(masters.asServers()).Require(mastersPhase)
 		phases = append(phases, configPhase, runtimePhase)
 	}
-	phases = append(phases, appPhase, cleanupPhase)
+	if namespacesPhase != nil {
+		phases = append(phases, *namespacesPhase)
+	}
+	phases = append(phases, appPhase)
+	if smokeTestPhase != nil {
+		phases = append(phases, *smokeTestPhase)
+	}
+	phases = append(phases, cleanupPhase)
 	plan.Phases = phases.asPhases()
 	resolve(&plan)
 
 	return &plan, nil
 }
 
+// getPrePullImages returns the list of images from the updated application
+// packages, as referenced in the cluster registry
+func getPrePullImages(packages pack.PackageService, installed, update app.Application) ([]string, error) {
+	updates, err := app.GetUpdatedDependencies(installed, update)
+	if err != nil && !trace.IsNotFound(err) {
+		return nil, trace.Wrap(err)
+	}
+	var images []string
+	seen := make(map[string]struct{})
+	for _, locator := range updates {
+		tags, err := getPackageImages(packages, locator)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		for _, tag := range tags {
+			image := path.Join(constants.DockerRegistry, tag.String())
+			if _, ok := seen[image]; ok {
+				continue
+			}
+			seen[image] = struct{}{}
+			images = append(images, image)
+		}
+	}
+	return images, nil
+}
+
+// getPackageImages returns the list of images in the registry of the specified package
+func getPackageImages(packages pack.PackageService, locator loc.Locator) ([]docker.TagSpec, error) {
+	_, reader, err := packages.ReadPackage(locator)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer reader.Close()
+	stream, err := archive.DecompressStream(reader)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer stream.Close()
+	images, err := docker.ListTarballImages(stream)
+	if err != nil {
+		return nil, trace.Wrap(err, "failed to list images in %v", locator)
+	}
+	return images, nil
+}
+
 func shouldUpdateEtcd(p newPlanParams) (updateEtcd bool, installedEtcdVersion string, updateEtcdVersion string, err error) {
 	// TODO: should somehow maintain etcd version invariant across runtime packages
 	runtimePackage, err := p.installedRuntime.Manifest.DefaultRuntimePackage()
@@ -563,3 +670,14 @@ func checkAndSetServerDefaults(servers []storage.Server, client corev1.NodeInter
 	}
 	return servers, nil
 }
+
+// upgradeTaint returns the taint to place on nodes after the system software
+// update as configured in the specified manifest
+func upgradeTaint(manifest schema.Manifest) *storage.NodeTaint {
+	taint := manifest.UpgradeTaint()
+	return &storage.NodeTaint{
+		Key:    taint.Key,
+		Value:  taint.Value,
+		Effect: string(taint.Effect),
+	}
+}
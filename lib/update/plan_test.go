@@ -20,6 +20,7 @@ import (
 	"github.com/gravitational/gravity/lib/app"
 	"github.com/gravitational/gravity/lib/compare"
 	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/fsm"
 	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/ops"
 	"github.com/gravitational/gravity/lib/pack"
@@ -160,6 +161,130 @@ func (s *PlanSuite) TestPlanWithoutRuntimeUpdate(c *check.C) {
 	compare.DeepCompare(c, *obtainedPlan, plan)
 }
 
+func (s *PlanSuite) TestPlanWithSmokeTest(c *check.C) {
+	// setup
+	runtimeLoc1 := loc.MustParseLocator("gravitational.io/runtime:1.0.0")
+	appLoc1 := loc.MustParseLocator("gravitational.io/app:1.0.0")
+	appLoc2 := loc.MustParseLocator("gravitational.io/app:2.0.0")
+
+	plan, params := newTestPlan(c, params{
+		installedRuntime:         runtimeLoc1,
+		installedApp:             appLoc1,
+		updateRuntime:            runtimeLoc1,
+		updateApp:                appLoc2,
+		installedRuntimeManifest: installedRuntimeManifest,
+		installedAppManifest:     installedAppManifest,
+		updateRuntimeManifest:    installedRuntimeManifest,
+		updateAppManifest:        updateAppManifestWithSmokeTest,
+	})
+
+	builder := phaseBuilder{}
+	init := *builder.init(appLoc1, appLoc2)
+	checks := *builder.checks(appLoc1, appLoc2).Require(init)
+	preUpdate := *builder.preUpdate(appLoc2).Require(init)
+	appLocs := []loc.Locator{loc.MustParseLocator("gravitational.io/app-dep-2:2.0.0"), appLoc2}
+	app := *builder.app(appLocs)
+	smokeTest := *builder.smokeTest(appLoc2, true).Require(app)
+	cleanup := *builder.cleanup(params.servers).Require(app, smokeTest)
+
+	plan.Phases = phases{init, checks, preUpdate, app, smokeTest, cleanup}.asPhases()
+	resolve(&plan)
+
+	// exercise
+	obtainedPlan, err := newOperationPlan(params)
+	c.Assert(err, check.IsNil)
+	// Reset the capacity so the plans can be compared
+	obtainedPlan.Phases = resetCap(obtainedPlan.Phases)
+	resolve(obtainedPlan)
+
+	// verify
+	compare.DeepCompare(c, *obtainedPlan, plan)
+}
+
+func (s *PlanSuite) TestPlanWithPrePull(c *check.C) {
+	// setup
+	runtimeLoc1 := loc.MustParseLocator("gravitational.io/runtime:1.0.0")
+	appLoc1 := loc.MustParseLocator("gravitational.io/app:1.0.0")
+	appLoc2 := loc.MustParseLocator("gravitational.io/app:2.0.0")
+	images := []string{"leader.telekube.local:5000/nginx:1.15"}
+
+	plan, params := newTestPlan(c, params{
+		installedRuntime:         runtimeLoc1,
+		installedApp:             appLoc1,
+		updateRuntime:            runtimeLoc1,
+		updateApp:                appLoc2,
+		installedRuntimeManifest: installedRuntimeManifest,
+		installedAppManifest:     installedAppManifest,
+		updateRuntimeManifest:    installedRuntimeManifest,
+		updateAppManifest:        updateAppManifest,
+		images:                   images,
+	})
+
+	builder := phaseBuilder{}
+	init := *builder.init(appLoc1, appLoc2)
+	checks := *builder.checks(appLoc1, appLoc2).Require(init)
+	preUpdate := *builder.preUpdate(appLoc2).Require(init)
+	prePull := *builder.prePull(params.servers, images).Require(init)
+	appLocs := []loc.Locator{loc.MustParseLocator("gravitational.io/app-dep-2:2.0.0"), appLoc2}
+	app := *builder.app(appLocs).Require(prePull)
+	cleanup := *builder.cleanup(params.servers).Require(app)
+
+	plan.Phases = phases{init, checks, preUpdate, prePull, app, cleanup}.asPhases()
+	resolve(&plan)
+
+	// exercise
+	obtainedPlan, err := newOperationPlan(params)
+	c.Assert(err, check.IsNil)
+	// Reset the capacity so the plans can be compared
+	obtainedPlan.Phases = resetCap(obtainedPlan.Phases)
+	resolve(obtainedPlan)
+
+	// verify
+	compare.DeepCompare(c, *obtainedPlan, plan)
+}
+
+func (s *PlanSuite) TestPlanWithUpgradeGates(c *check.C) {
+	// setup
+	runtimeLoc1 := loc.MustParseLocator("gravitational.io/runtime:1.0.0")
+	appLoc1 := loc.MustParseLocator("gravitational.io/app:1.0.0")
+	runtimeLoc2 := loc.MustParseLocator("gravitational.io/runtime:2.0.0")
+	appLoc2 := loc.MustParseLocator("gravitational.io/app:2.0.0")
+
+	_, params := newTestPlan(c, params{
+		installedRuntime:         runtimeLoc1,
+		installedApp:             appLoc1,
+		updateRuntime:            runtimeLoc2,
+		updateApp:                appLoc2,
+		installedRuntimeManifest: installedRuntimeManifest,
+		installedAppManifest:     installedAppManifest,
+		updateRuntimeManifest:    updateRuntimeManifest,
+		updateAppManifest:        updateAppManifestWithGates,
+	})
+
+	// exercise
+	obtainedPlan, err := newOperationPlan(params)
+	c.Assert(err, check.IsNil)
+
+	// verify
+	var gates []storage.OperationPhase
+	for _, id := range []string{"/masters", "/nodes"} {
+		root, err := fsm.FindPhase(obtainedPlan, id)
+		c.Assert(err, check.IsNil)
+		for _, node := range root.Phases {
+			for _, phase := range node.Phases {
+				if phase.Executor == upgradeGates {
+					gates = append(gates, phase)
+				}
+			}
+		}
+	}
+	c.Assert(gates, check.HasLen, len(params.servers))
+	for _, phase := range gates {
+		c.Assert(phase.Data.Package, check.DeepEquals, &appLoc2)
+		c.Assert(phase.Data.ExecServer, check.DeepEquals, &params.servers[0])
+	}
+}
+
 func newTestPlan(c *check.C, p params) (storage.OperationPlan, newPlanParams) {
 	servers := []storage.Server{
 		{
@@ -220,6 +345,7 @@ func newTestPlan(c *check.C, p params) (storage.OperationPlan, newPlanParams) {
 		trustedClusters:  p.trustedClusters,
 		shouldUpdateEtcd: shouldUpdateEtcdTeest,
 		updateCoreDNS:    p.updateCoreDNS,
+		images:           p.images,
 	}
 
 	gravityPackage, err := params.updateRuntime.Manifest.Dependencies.ByName(
@@ -245,6 +371,7 @@ type params struct {
 	updateRuntimeManifest    string
 	updateAppManifest        string
 	updateCoreDNS            bool
+	images                   []string
 	links                    []storage.OpsCenterLink
 	trustedClusters          []teleservices.TrustedCluster
 }
@@ -316,3 +443,51 @@ systemOptions:
   dependencies:
     runtimePackage: gravitational.io/planet:2.0.0
 `
+
+const updateAppManifestWithSmokeTest = `apiVersion: bundle.gravitational.io/v2
+kind: Bundle
+metadata:
+  name: app
+  resourceVersion: 2.0.0
+dependencies:
+  apps:
+    - gravitational.io/app-dep-1:1.0.0
+    - gravitational.io/app-dep-2:2.0.0
+nodeProfiles:
+  - name: node
+hooks:
+  smokeTest:
+    job: |
+      apiVersion: batch/v1
+      kind: Job
+      metadata:
+        name: smoke-test
+systemOptions:
+  dependencies:
+    runtimePackage: gravitational.io/planet:2.0.0
+  upgrade:
+    autoRollback: true
+`
+
+const updateAppManifestWithGates = `apiVersion: bundle.gravitational.io/v2
+kind: Bundle
+metadata:
+  name: app
+  resourceVersion: 2.0.0
+dependencies:
+  apps:
+    - gravitational.io/app-dep-1:1.0.0
+    - gravitational.io/app-dep-2:2.0.0
+nodeProfiles:
+  - name: node
+systemOptions:
+  dependencies:
+    runtimePackage: gravitational.io/planet:2.0.0
+  upgrade:
+    gates:
+      checks:
+      - name: restarts
+        podRestarts:
+          namespace: default
+        max: 0
+`
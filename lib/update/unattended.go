@@ -0,0 +1,275 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package update
+
+import (
+	"context"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/fsm"
+	"github.com/gravitational/gravity/lib/status"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// UnattendedCompleted means the upgrade has completed and the cluster is healthy
+	UnattendedCompleted = "completed"
+	// UnattendedRolledBack means the upgrade has failed and has been rolled back
+	UnattendedRolledBack = "rolled_back"
+	// UnattendedFailed means the upgrade has failed and could not be rolled back
+	UnattendedFailed = "failed"
+	// UnattendedNotStarted means the upgrade has not been started because
+	// the cluster was not healthy, so there was nothing to roll back
+	UnattendedNotStarted = "not_started"
+	// UnattendedAwaitingApproval means the upgrade has paused before a phase
+	// that requires operator approval
+	UnattendedAwaitingApproval = "awaiting_approval"
+)
+
+// UnattendedConfig configures an unattended upgrade
+type UnattendedConfig struct {
+	// FSMConfig is the upgrade state machine configuration
+	FSMConfig
+	// Retries is the number of times the plan execution is retried after a failure
+	Retries int
+	// RetryInterval is the interval between retries
+	RetryInterval time.Duration
+	// HealthTimeout is the maximum time to wait for the cluster to become healthy
+	HealthTimeout time.Duration
+	// RollbackTimeout is the maximum time to wait for the operation to roll back
+	RollbackTimeout time.Duration
+	// ForceRollback rolls the operation back even after a successful upgrade.
+	// Used to exercise the rollback path in upgrade tests
+	ForceRollback bool
+	// CheckHealth checks the health of the cluster.
+	// Defaults to querying the status of planet agents
+	CheckHealth func(context.Context) error
+	// Progress reports the upgrade progress
+	Progress utils.Progress
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+}
+
+// CheckAndSetDefaults validates the configuration and sets default values
+func (r *UnattendedConfig) CheckAndSetDefaults() error {
+	if r.Retries < 0 {
+		return trace.BadParameter("number of retries cannot be negative")
+	}
+	if r.RetryInterval == 0 {
+		r.RetryInterval = defaults.UnattendedUpgradeRetryInterval
+	}
+	if r.HealthTimeout == 0 {
+		r.HealthTimeout = defaults.UnattendedUpgradeHealthTimeout
+	}
+	if r.RollbackTimeout == 0 {
+		r.RollbackTimeout = defaults.UnattendedUpgradeRollbackTimeout
+	}
+	if r.CheckHealth == nil {
+		r.CheckHealth = checkPlanetHealth
+	}
+	if r.Progress == nil {
+		r.Progress = utils.NewNopProgress()
+	}
+	if r.FieldLogger == nil {
+		r.FieldLogger = logrus.WithField(trace.Component, "update:unattended")
+	}
+	return nil
+}
+
+// UnattendedResult describes the outcome of an unattended upgrade
+type UnattendedResult struct {
+	// OperationID is the ID of the upgrade operation
+	OperationID string `json:"operation_id"`
+	// State is the final state of the upgrade
+	State string `json:"state"`
+	// Attempts is the number of times the plan has been executed
+	Attempts int `json:"attempts"`
+	// Error is the error the upgrade has failed with
+	Error string `json:"error,omitempty"`
+	// RollbackError is the error the rollback has failed with
+	RollbackError string `json:"rollback_error,omitempty"`
+	// Started is when the upgrade has started
+	Started time.Time `json:"started"`
+	// Finished is when the upgrade has finished
+	Finished time.Time `json:"finished"`
+}
+
+// UnattendedUpgrade executes the upgrade operation plan to completion.
+//
+// Plan execution only starts if the cluster is healthy and is retried up
+// to the configured number of times. If the plan fails to execute or the cluster
+// does not become healthy after the upgrade, the operation is rolled back.
+// If the cluster is not healthy to begin with, the upgrade is not started.
+// The operation is completed in either case and the result is returned
+// along with the error the upgrade has failed with, if any
+func UnattendedUpgrade(ctx context.Context, config UnattendedConfig) (*UnattendedResult, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	machine, err := NewFSM(ctx, config.FSMConfig)
+	if err != nil {
+		return nil, trace.Wrap(err, "failed to load or initialize upgrade plan")
+	}
+	defer machine.Close()
+
+	result, err := runUnattended(ctx, machine, config)
+	if err != nil {
+		return result, trace.Wrap(err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, defaults.RPCAgentShutdownTimeout)
+	defer cancel()
+	if err := ShutdownClusterAgents(ctx, config.Remote); err != nil {
+		config.Warnf("Failed to shutdown cluster agents: %v.", trace.DebugReport(err))
+	}
+	return result, nil
+}
+
+// unattendedMachine is the subset of the state machine used by unattended upgrade
+type unattendedMachine interface {
+	GetPlan() (*storage.OperationPlan, error)
+	ExecutePlan(ctx context.Context, progress utils.Progress, force bool) error
+	RollbackPlan(ctx context.Context, progress utils.Progress, force bool) error
+	Complete(fsmErr error) error
+}
+
+func runUnattended(ctx context.Context, machine unattendedMachine, config UnattendedConfig) (*UnattendedResult, error) {
+	plan, err := machine.GetPlan()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	result := &UnattendedResult{
+		OperationID: plan.OperationID,
+		Started:     time.Now().UTC(),
+	}
+
+	upgradeErr := executeUnattended(ctx, machine, config, result)
+	if fsm.IsAwaitingApproval(upgradeErr) {
+		// the operation stays in progress until the phase is approved
+		// and the operation is resumed
+		config.Infof("Upgrade paused: %v.", upgradeErr)
+		result.State = UnattendedAwaitingApproval
+		result.Error = trace.UserMessage(upgradeErr)
+		return result, trace.Wrap(upgradeErr)
+	}
+	if upgradeErr == nil && config.ForceRollback {
+		upgradeErr = trace.CompareFailed("rollback has been requested")
+	}
+	if upgradeErr == nil {
+		result.State = UnattendedCompleted
+	} else {
+		config.Warnf("Upgrade failed: %v.", trace.DebugReport(upgradeErr))
+		result.Error = trace.UserMessage(upgradeErr)
+		if result.Attempts == 0 {
+			result.State = UnattendedNotStarted
+		} else {
+			result.State = UnattendedRolledBack
+			if err := rollbackUnattended(machine, config); err != nil {
+				config.Warnf("Failed to rollback operation: %v.", trace.DebugReport(err))
+				result.State = UnattendedFailed
+				result.RollbackError = trace.UserMessage(err)
+			}
+		}
+	}
+
+	if err := machine.Complete(upgradeErr); err != nil {
+		result.State = UnattendedFailed
+		result.Finished = time.Now().UTC()
+		return result, trace.NewAggregate(upgradeErr, err)
+	}
+	result.Finished = time.Now().UTC()
+	return result, trace.Wrap(upgradeErr)
+}
+
+// rollbackUnattended rolls back the operation plan.
+// The rollback uses its own context since the upgrade might have stopped
+// exactly because its context has been cancelled or has expired
+func rollbackUnattended(machine unattendedMachine, config UnattendedConfig) error {
+	config.Info("Rolling back the operation.")
+	ctx, cancel := context.WithTimeout(context.Background(), config.RollbackTimeout)
+	defer cancel()
+	return trace.Wrap(machine.RollbackPlan(ctx, config.Progress, false))
+}
+
+// executeUnattended executes the plan gating it on cluster health and
+// retrying it on failure
+func executeUnattended(ctx context.Context, machine unattendedMachine, config UnattendedConfig, result *UnattendedResult) (err error) {
+	if err := waitForHealthy(ctx, config); err != nil {
+		return trace.Wrap(err, "cluster is not healthy, will not start the upgrade")
+	}
+	for {
+		result.Attempts++
+		config.Infof("Executing operation plan, attempt %v.", result.Attempts)
+		err = machine.ExecutePlan(ctx, config.Progress, false)
+		if err == nil {
+			break
+		}
+		if fsm.IsAwaitingApproval(err) || result.Attempts > config.Retries {
+			return trace.Wrap(err)
+		}
+		config.Warnf("Failed to execute plan: %v, will retry in %v.", err, config.RetryInterval)
+		select {
+		case <-time.After(config.RetryInterval):
+		case <-ctx.Done():
+			return trace.Wrap(err)
+		}
+		if errHealth := waitForHealthy(ctx, config); errHealth != nil {
+			return trace.NewAggregate(err, errHealth)
+		}
+	}
+	if err := waitForHealthy(ctx, config); err != nil {
+		return trace.Wrap(err, "cluster is not healthy after the upgrade")
+	}
+	return nil
+}
+
+// waitForHealthy waits until the cluster health check passes
+func waitForHealthy(ctx context.Context, config UnattendedConfig) error {
+	ctx, cancel := context.WithTimeout(ctx, config.HealthTimeout)
+	defer cancel()
+	for {
+		err := config.CheckHealth(ctx)
+		if err == nil {
+			return nil
+		}
+		config.Infof("Waiting for the cluster to become healthy: %v.", err)
+		select {
+		case <-time.After(defaults.RetryInterval):
+		case <-ctx.Done():
+			return trace.Wrap(err)
+		}
+	}
+}
+
+// checkPlanetHealth returns an error if planet agents report
+// the cluster as degraded
+func checkPlanetHealth(ctx context.Context) error {
+	planetStatus, err := status.FromPlanetAgent(ctx, nil)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if planetStatus.GetSystemStatus() != agentpb.SystemStatus_Running {
+		return trace.BadParameter("cluster is degraded")
+	}
+	return nil
+}
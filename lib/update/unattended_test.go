@@ -0,0 +1,180 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package update
+
+import (
+	"context"
+	"time"
+
+	"github.com/gravitational/gravity/lib/fsm"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/trace"
+	"gopkg.in/check.v1"
+)
+
+type UnattendedSuite struct{}
+
+var _ = check.Suite(&UnattendedSuite{})
+
+func (s *UnattendedSuite) TestCompletesAfterRetries(c *check.C) {
+	machine := &testMachine{executeErrors: []error{trace.ConnectionProblem(nil, "etcd is down")}}
+	result, err := runUnattended(context.TODO(), machine, newUnattendedConfig(c, 1, nil))
+	c.Assert(err, check.IsNil)
+	c.Assert(result.OperationID, check.Equals, "operation-1")
+	c.Assert(result.State, check.Equals, UnattendedCompleted)
+	c.Assert(result.Attempts, check.Equals, 2)
+	c.Assert(machine.rolledBack, check.Equals, false)
+	c.Assert(machine.completed, check.Equals, true)
+	c.Assert(machine.completeErr, check.IsNil)
+}
+
+func (s *UnattendedSuite) TestRollsBackAfterRetries(c *check.C) {
+	machine := &testMachine{executeErrors: []error{
+		trace.BadParameter("phase failed"),
+		trace.BadParameter("phase failed"),
+	}}
+	result, err := runUnattended(context.TODO(), machine, newUnattendedConfig(c, 1, nil))
+	c.Assert(err, check.NotNil)
+	c.Assert(result.State, check.Equals, UnattendedRolledBack)
+	c.Assert(result.Attempts, check.Equals, 2)
+	c.Assert(result.Error, check.Equals, "phase failed")
+	c.Assert(machine.rolledBack, check.Equals, true)
+	c.Assert(machine.completeErr, check.NotNil)
+}
+
+func (s *UnattendedSuite) TestRollsBackIfUnhealthyAfterUpgrade(c *check.C) {
+	machine := &testMachine{}
+	healthChecks := 0
+	checkHealth := func(context.Context) error {
+		healthChecks++
+		if healthChecks > 1 {
+			return trace.BadParameter("cluster is degraded")
+		}
+		return nil
+	}
+	result, err := runUnattended(context.TODO(), machine, newUnattendedConfig(c, 0, checkHealth))
+	c.Assert(err, check.NotNil)
+	c.Assert(result.State, check.Equals, UnattendedRolledBack)
+	c.Assert(result.Attempts, check.Equals, 1)
+	c.Assert(machine.rolledBack, check.Equals, true)
+}
+
+func (s *UnattendedSuite) TestForcesRollback(c *check.C) {
+	machine := &testMachine{}
+	config := newUnattendedConfig(c, 0, nil)
+	config.ForceRollback = true
+	result, err := runUnattended(context.TODO(), machine, config)
+	c.Assert(err, check.NotNil)
+	c.Assert(result.State, check.Equals, UnattendedRolledBack)
+	c.Assert(result.Error, check.Equals, "rollback has been requested")
+	c.Assert(machine.rolledBack, check.Equals, true)
+	c.Assert(machine.completeErr, check.NotNil)
+}
+
+func (s *UnattendedSuite) TestDoesNotStartIfUnhealthy(c *check.C) {
+	machine := &testMachine{}
+	checkHealth := func(context.Context) error {
+		return trace.BadParameter("cluster is degraded")
+	}
+	result, err := runUnattended(context.TODO(), machine, newUnattendedConfig(c, 1, checkHealth))
+	c.Assert(err, check.NotNil)
+	c.Assert(result.State, check.Equals, UnattendedNotStarted)
+	c.Assert(result.Attempts, check.Equals, 0)
+	c.Assert(machine.rolledBack, check.Equals, false)
+	c.Assert(machine.completeErr, check.NotNil)
+}
+
+func (s *UnattendedSuite) TestRollsBackAfterCancel(c *check.C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	machine := &testMachine{
+		executeErrors: []error{trace.ConnectionProblem(nil, "interrupted")},
+		onExecute:     cancel,
+	}
+	result, err := runUnattended(ctx, machine, newUnattendedConfig(c, 3, nil))
+	c.Assert(err, check.NotNil)
+	c.Assert(result.State, check.Equals, UnattendedRolledBack)
+	c.Assert(result.Attempts, check.Equals, 1)
+	c.Assert(machine.rolledBack, check.Equals, true)
+}
+
+func (s *UnattendedSuite) TestPausesBeforeApprovalGate(c *check.C) {
+	machine := &testMachine{executeErrors: []error{
+		trace.Wrap(&fsm.ApprovalRequiredError{PhaseID: "/etcd"}),
+	}}
+	result, err := runUnattended(context.TODO(), machine, newUnattendedConfig(c, 3, nil))
+	c.Assert(err, check.NotNil)
+	c.Assert(result.State, check.Equals, UnattendedAwaitingApproval)
+	c.Assert(result.Attempts, check.Equals, 1)
+	c.Assert(machine.rolledBack, check.Equals, false)
+	c.Assert(machine.completed, check.Equals, false)
+}
+
+func newUnattendedConfig(c *check.C, retries int, checkHealth func(context.Context) error) UnattendedConfig {
+	if checkHealth == nil {
+		checkHealth = func(context.Context) error { return nil }
+	}
+	config := UnattendedConfig{
+		Retries:       retries,
+		RetryInterval: time.Millisecond,
+		HealthTimeout: 10 * time.Millisecond,
+		CheckHealth:   checkHealth,
+	}
+	c.Assert(config.CheckAndSetDefaults(), check.IsNil)
+	return config
+}
+
+// testMachine fails plan execution with the configured errors
+type testMachine struct {
+	executeErrors []error
+	// onExecute is invoked on every plan execution
+	onExecute   func()
+	rolledBack  bool
+	completed   bool
+	completeErr error
+}
+
+func (r *testMachine) GetPlan() (*storage.OperationPlan, error) {
+	return &storage.OperationPlan{OperationID: "operation-1"}, nil
+}
+
+func (r *testMachine) ExecutePlan(context.Context, utils.Progress, bool) error {
+	if r.onExecute != nil {
+		r.onExecute()
+	}
+	if len(r.executeErrors) == 0 {
+		return nil
+	}
+	err := r.executeErrors[0]
+	r.executeErrors = r.executeErrors[1:]
+	return err
+}
+
+func (r *testMachine) RollbackPlan(ctx context.Context, _ utils.Progress, _ bool) error {
+	if ctx.Err() != nil {
+		return trace.Wrap(ctx.Err())
+	}
+	r.rolledBack = true
+	return nil
+}
+
+func (r *testMachine) Complete(fsmErr error) error {
+	r.completed = true
+	r.completeErr = fsmErr
+	return nil
+}
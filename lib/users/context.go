@@ -63,6 +63,41 @@ func NewActionsParser(ctx teleservices.RuleContext) (predicate.Parser, error) {
 	})
 }
 
+// IsDenied returns true if any role of the checker has a deny rule that
+// matches the verb on the resource kind in the specified context.
+// Unlike CheckAccessToRule, it tells an explicit denial apart from
+// the lack of an allow rule
+func IsDenied(checker teleservices.AccessChecker, ctx teleservices.RuleContext, namespace, resource, verb string) (bool, error) {
+	roles, ok := checker.(teleservices.RoleSet)
+	if !ok {
+		return false, nil
+	}
+	whereParser, err := teleservices.GetWhereParserFn()(ctx)
+	if err != nil {
+		return false, trace.Wrap(err)
+	}
+	actionsParser, err := teleservices.GetActionsParserFn()(ctx)
+	if err != nil {
+		return false, trace.Wrap(err)
+	}
+	for _, role := range roles {
+		matchNamespace, _ := teleservices.MatchNamespace(role.GetNamespaces(teleservices.Deny),
+			teleservices.ProcessNamespace(namespace))
+		if !matchNamespace {
+			continue
+		}
+		matched, err := teleservices.MakeRuleSet(role.GetRules(teleservices.Deny)).Match(
+			whereParser, actionsParser, resource, verb)
+		if err != nil {
+			return false, trace.Wrap(err)
+		}
+		if matched {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 // ExtractKubeGroups returns a list of Kubernetes groups extracted from
 // the provided assignKubernetesGroups action string
 func ExtractKubeGroups(action string) ([]string, error) {
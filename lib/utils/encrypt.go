@@ -22,6 +22,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/gravitational/trace"
 	"golang.org/x/crypto/openpgp"
+	pgperrors "golang.org/x/crypto/openpgp/errors"
 )
 
 // EncryptPGP returns a stream with "data" encrypted by the provided passphrase
@@ -48,7 +49,13 @@ func EncryptPGP(data io.Reader, passphrase string) (io.ReadCloser, error) {
 
 // DecryptPGP returns a stream with "data" decrypted by the provided passphrase
 func DecryptPGP(data io.Reader, passphrase string) (io.Reader, error) {
+	var prompted bool
 	promptFn := func(_ []openpgp.Key, _ bool) ([]byte, error) {
+		// openpgp prompts again for as long as the passphrase is incorrect
+		if prompted {
+			return nil, pgperrors.ErrKeyIncorrect
+		}
+		prompted = true
 		return []byte(passphrase), nil
 	}
 
@@ -245,12 +245,16 @@ func ConvertS3Error(err error) error {
 		return err
 	}
 	switch awsErr.Code() {
-	case s3.ErrCodeNoSuchKey, s3.ErrCodeNoSuchBucket:
+	case s3.ErrCodeNoSuchKey, s3.ErrCodeNoSuchBucket, s3ErrCodeNotFound:
 		return trace.NotFound(awsErr.Message())
 	}
 	return err
 }
 
+// s3ErrCodeNotFound is the error code returned for HEAD requests
+// of missing objects since the response has no body with the error details
+const s3ErrCodeNotFound = "NotFound"
+
 // UnsupportedFilesystemError represents a condition when an action is being
 // performed on an unsupported filesystem, for example an attempt to create
 // a bolt database file on filesystem that does not support mmap
@@ -0,0 +1,124 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+
+	"github.com/gravitational/trace"
+)
+
+// FileLock is an advisory lock on a file held by this process
+type FileLock struct {
+	file *os.File
+	path string
+}
+
+// LockFile acquires an exclusive advisory lock on the file at the specified
+// path creating the file if necessary.
+//
+// If the file is locked by another process, the lock is retried until it is
+// acquired or the context expires if wait is set, otherwise a CompareFailed
+// error is returned immediately.
+//
+// If the file is removed by the holder of the lock with Remove while waiting,
+// the lock is retried on the new file at the same path
+func LockFile(ctx context.Context, path string, wait bool) (*FileLock, error) {
+	for {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_RDONLY, defaults.SharedReadMask)
+		if err != nil {
+			return nil, trace.ConvertSystemError(err)
+		}
+		err = flock(ctx, file, path, wait)
+		if err != nil {
+			file.Close()
+			return nil, trace.Wrap(err)
+		}
+		removed, err := isRemoved(file, path)
+		if err != nil {
+			file.Close()
+			return nil, trace.Wrap(err)
+		}
+		if !removed {
+			return &FileLock{file: file, path: path}, nil
+		}
+		// the file has been removed by the previous holder of the lock
+		file.Close()
+	}
+}
+
+// Unlock releases the lock
+func (r *FileLock) Unlock() error {
+	err := syscall.Flock(int(r.file.Fd()), syscall.LOCK_UN)
+	if err != nil {
+		r.file.Close()
+		return trace.ConvertSystemError(err)
+	}
+	return trace.ConvertSystemError(r.file.Close())
+}
+
+// Remove removes the lock file and releases the lock
+func (r *FileLock) Remove() error {
+	err := os.Remove(r.path)
+	if err != nil && !os.IsNotExist(err) {
+		r.Unlock()
+		return trace.ConvertSystemError(err)
+	}
+	return trace.Wrap(r.Unlock())
+}
+
+// flock acquires an exclusive lock on the file
+func flock(ctx context.Context, file *os.File, path string, wait bool) error {
+	for {
+		err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			return nil
+		}
+		if err != syscall.EWOULDBLOCK {
+			return trace.ConvertSystemError(err)
+		}
+		if !wait {
+			return trace.CompareFailed("%v is locked by another process", path)
+		}
+		select {
+		case <-time.After(defaults.FileLockRetryPeriod):
+		case <-ctx.Done():
+			return trace.LimitExceeded("timed out waiting for the lock on %v", path)
+		}
+	}
+}
+
+// isRemoved returns true if the opened file is no longer at the specified path
+func isRemoved(file *os.File, path string) (bool, error) {
+	opened, err := file.Stat()
+	if err != nil {
+		return false, trace.ConvertSystemError(err)
+	}
+	current, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return true, nil
+		}
+		return false, trace.ConvertSystemError(err)
+	}
+	return !os.SameFile(opened, current), nil
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gravitational/trace"
+	"gopkg.in/check.v1"
+)
+
+type FileLockSuite struct{}
+
+var _ = check.Suite(&FileLockSuite{})
+
+func (s *FileLockSuite) TestLocksFile(c *check.C) {
+	path := filepath.Join(c.MkDir(), "test.lock")
+	lock, err := LockFile(context.TODO(), path, false)
+	c.Assert(err, check.IsNil)
+
+	_, err = LockFile(context.TODO(), path, false)
+	c.Assert(trace.IsCompareFailed(err), check.Equals, true, check.Commentf("%v", err))
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 100*time.Millisecond)
+	defer cancel()
+	_, err = LockFile(ctx, path, true)
+	c.Assert(trace.IsLimitExceeded(err), check.Equals, true, check.Commentf("%v", err))
+
+	locked := make(chan error, 1)
+	go func() {
+		lock, err := LockFile(context.TODO(), path, true)
+		if err == nil {
+			err = lock.Unlock()
+		}
+		locked <- err
+	}()
+	c.Assert(lock.Unlock(), check.IsNil)
+	select {
+	case err := <-locked:
+		c.Assert(err, check.IsNil)
+	case <-time.After(5 * time.Second):
+		c.Fatal("timed out waiting for the lock")
+	}
+}
+
+func (s *FileLockSuite) TestRetriesRemovedFile(c *check.C) {
+	path := filepath.Join(c.MkDir(), "test.lock")
+	lock, err := LockFile(context.TODO(), path, false)
+	c.Assert(err, check.IsNil)
+
+	locked := make(chan *FileLock, 1)
+	go func() {
+		lock, err := LockFile(context.TODO(), path, true)
+		c.Assert(err, check.IsNil)
+		locked <- lock
+	}()
+	c.Assert(lock.Remove(), check.IsNil)
+	select {
+	case lock = <-locked:
+	case <-time.After(5 * time.Second):
+		c.Fatal("timed out waiting for the lock")
+	}
+
+	// the lock is held on the file that is now at the path
+	_, err = LockFile(context.TODO(), path, false)
+	c.Assert(trace.IsCompareFailed(err), check.Equals, true, check.Commentf("%v", err))
+	c.Assert(lock.Remove(), check.IsNil)
+	_, err = os.Stat(path)
+	c.Assert(os.IsNotExist(err), check.Equals, true)
+}
@@ -34,6 +34,16 @@ func SHA512Half(v []byte) (string, error) {
 	return fmt.Sprintf("%x", h.Sum(nil)[:sha512.Size/2]), nil
 }
 
+// SHA512HalfReader returns the first half of SHA512 hash of the data read from r
+func SHA512HalfReader(r io.Reader) (string, error) {
+	h := sha512.New()
+	_, err := io.Copy(h, r)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)[:sha512.Size/2]), nil
+}
+
 // MustSHA512Half panics if it fails to compute SHA512 hash,
 // use only in tests
 func MustSHA512Half(v []byte) string {
@@ -0,0 +1,54 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"time"
+
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/check.v1"
+)
+
+type PeriodicSuite struct{}
+
+var _ = check.Suite(&PeriodicSuite{})
+
+func (s *PeriodicSuite) TestRunsUntilCanceled(c *check.C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls int
+	done := make(chan struct{})
+	go func() {
+		RunPeriodically(ctx, time.Millisecond, logrus.StandardLogger(), "test",
+			func(context.Context) error {
+				calls++
+				if calls == 3 {
+					cancel()
+				}
+				// errors do not stop the loop
+				return trace.BadParameter("failure %v", calls)
+			})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		c.Fatal("timeout waiting for the loop to stop")
+	}
+	c.Assert(calls, check.Equals, 3)
+}
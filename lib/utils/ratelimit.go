@@ -0,0 +1,81 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"io"
+
+	"github.com/gravitational/trace"
+	"golang.org/x/time/rate"
+)
+
+// NewRateLimiter returns a new limiter that allows transfers at the specified rate.
+// The limiter can be shared by several readers to limit their combined rate
+func NewRateLimiter(limit TransferRate) *rate.Limiter {
+	// allow bursts of a tenth of a second worth of data
+	// so that the transfer proceeds in small steps
+	burst := int(limit.BytesPerSecond() / 10)
+	if burst < minRateLimitBurst {
+		burst = minRateLimitBurst
+	}
+	return rate.NewLimiter(rate.Limit(limit.BytesPerSecond()), burst)
+}
+
+// NewRateLimitedReader returns a reader that reads from r no faster
+// than the limiter allows
+func NewRateLimitedReader(r io.Reader, limiter *rate.Limiter) io.Reader {
+	return &rateLimitedReader{Reader: r, limiter: limiter}
+}
+
+// NewRateLimitedReadCloser returns a reader that reads from r no faster
+// than the limiter allows and closes r when closed
+func NewRateLimitedReadCloser(r io.ReadCloser, limiter *rate.Limiter) io.ReadCloser {
+	return &rateLimitedReadCloser{
+		Reader: NewRateLimitedReader(r, limiter),
+		Closer: r,
+	}
+}
+
+type rateLimitedReader struct {
+	io.Reader
+	limiter *rate.Limiter
+}
+
+// Read reads at most a burst of data and waits until the limiter
+// allows the amount of data read
+func (r *rateLimitedReader) Read(p []byte) (int, error) {
+	if len(p) > r.limiter.Burst() {
+		p = p[:r.limiter.Burst()]
+	}
+	n, err := r.Reader.Read(p)
+	if n > 0 {
+		if errWait := r.limiter.WaitN(context.TODO(), n); errWait != nil {
+			return n, trace.Wrap(errWait)
+		}
+	}
+	return n, err
+}
+
+type rateLimitedReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// minRateLimitBurst is the minimum amount of data read at once from
+// a rate limited reader
+const minRateLimitBurst = 4 * 1024
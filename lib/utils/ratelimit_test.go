@@ -0,0 +1,44 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"bytes"
+	"io/ioutil"
+	"time"
+
+	"gopkg.in/check.v1"
+)
+
+type RateLimitSuite struct{}
+
+var _ = check.Suite(&RateLimitSuite{})
+
+func (s *RateLimitSuite) TestLimitsReadRate(c *check.C) {
+	data := bytes.Repeat([]byte("a"), 24*1024)
+	// the first burst of 4KB is allowed immediately,
+	// the remaining 20KB take half a second
+	limiter := NewRateLimiter(TransferRate(40 * 1024))
+	reader := NewRateLimitedReader(bytes.NewReader(data), limiter)
+
+	start := time.Now()
+	read, err := ioutil.ReadAll(reader)
+	c.Assert(err, check.IsNil)
+	c.Assert(read, check.DeepEquals, data)
+	c.Assert(time.Since(start) >= 400*time.Millisecond, check.Equals, true,
+		check.Commentf("read took %v", time.Since(start)))
+}
@@ -29,7 +29,6 @@ import (
 	"github.com/gravitational/gravity/lib/defaults"
 
 	cfsslhelpers "github.com/cloudflare/cfssl/helpers"
-	dockerarchive "github.com/docker/docker/pkg/archive"
 	"github.com/gravitational/license/authority"
 	"github.com/gravitational/trace"
 )
@@ -127,7 +126,7 @@ func CreateTLSArchive(a TLSArchive) (io.ReadCloser, error) {
 // ReadTLSArchive reads TLS packed archive, where keys are stored with extension ".key"
 // and certificates are stored with extension ".cert"
 func ReadTLSArchive(source io.Reader) (TLSArchive, error) {
-	decompressed, err := dockerarchive.DecompressStream(source)
+	decompressed, err := archive.DecompressStream(source)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
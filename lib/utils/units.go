@@ -67,6 +67,20 @@ func (c *Capacity) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// UnmarshalYAML unmarshals capacity from a human friendly form
+func (c *Capacity) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var capacity string
+	if err := unmarshal(&capacity); err != nil {
+		return trace.Wrap(err)
+	}
+	bytes, err := humanize.ParseBytes(capacity)
+	if err != nil {
+		return trace.Wrap(err, "could not parse %q as bytes", capacity)
+	}
+	*c = Capacity(bytes)
+	return nil
+}
+
 // MustParseCapacity parses the provided string as capacity or panics
 func MustParseCapacity(data string) Capacity {
 	bytes, err := humanize.ParseBytes(data)
@@ -126,6 +140,26 @@ func MustParseTransferRate(data string) TransferRate {
 	return TransferRate(bytes)
 }
 
+// ParseTransferRate parses the provided data as a transfer rate,
+// e.g. "10MB/s". The "/s" suffix is optional
+func ParseTransferRate(data string) (TransferRate, error) {
+	bytes, err := humanize.ParseBytes(strings.TrimSuffix(data, "/s"))
+	if err != nil {
+		return 0, trace.BadParameter("could not parse %q as transfer rate", data)
+	}
+	return TransferRate(bytes), nil
+}
+
+// Set parses the transfer rate from the command line flag value
+func (r *TransferRate) Set(v string) error {
+	rate, err := ParseTransferRate(v)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	*r = rate
+	return nil
+}
+
 // Int64Ptr returns a pointer to an int64 with value v
 func Int64Ptr(v int64) *int64 {
 	return &v
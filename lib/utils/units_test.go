@@ -38,6 +38,16 @@ func (s *UnitsSuite) TestCapacityAndTransferRate(c *check.C) {
 	c.Assert(o.Rate.BytesPerSecond(), check.Equals, uint64(50000000))
 }
 
+func (s *UnitsSuite) TestParsesTransferRate(c *check.C) {
+	for _, input := range []string{"2MB/s", "2MB", "2000000"} {
+		rate, err := ParseTransferRate(input)
+		c.Assert(err, check.IsNil, check.Commentf(input))
+		c.Assert(rate.BytesPerSecond(), check.Equals, uint64(2000000), check.Commentf(input))
+	}
+	_, err := ParseTransferRate("fast")
+	c.Assert(err, check.NotNil)
+}
+
 type capacityAndRate struct {
 	Capacity Capacity     `json:"capacity"`
 	Rate     TransferRate `json:"rate"`
@@ -241,8 +241,8 @@ func configToExecutor(config Config) libfsm.FSMSpecFunc {
 		case strings.HasPrefix(params.Phase.ID, libphase.Registry):
 			return libphase.NewRegistry(
 				params,
-				config.App.Locator,
-				config.Apps,
+				*config.App,
+				config.RemoteApps,
 				config.Packages,
 				config.Emitter)
 
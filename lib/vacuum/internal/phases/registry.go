@@ -19,62 +19,52 @@ package phases
 import (
 	"context"
 
-	"github.com/gravitational/gravity/lib/app"
-	"github.com/gravitational/gravity/lib/app/docker"
-	"github.com/gravitational/gravity/lib/constants"
-	"github.com/gravitational/gravity/lib/defaults"
 	libfsm "github.com/gravitational/gravity/lib/fsm"
 	"github.com/gravitational/gravity/lib/loc"
-	"github.com/gravitational/gravity/lib/pack"
+	libpack "github.com/gravitational/gravity/lib/pack"
 	"github.com/gravitational/gravity/lib/state"
 	"github.com/gravitational/gravity/lib/utils"
 	"github.com/gravitational/gravity/lib/vacuum/prune"
+	"github.com/gravitational/gravity/lib/vacuum/prune/pack"
 	"github.com/gravitational/gravity/lib/vacuum/prune/registry"
 
 	"github.com/gravitational/trace"
 	log "github.com/sirupsen/logrus"
 )
 
-// NewRegistry creates a new executor to prune unused docker images on a node
+// NewRegistry creates a new executor to prune unused docker image layers on a node
 func NewRegistry(
 	params libfsm.ExecutorParams,
-	clusterApp loc.Locator,
-	clusterApps app.Applications,
-	clusterPackages pack.PackageService,
+	clusterApp pack.Application,
+	remoteApps []pack.Application,
+	clusterPackages libpack.PackageService,
 	emitter utils.Emitter,
 ) (*registryExecutor, error) {
+	var apps []loc.Locator
+	for _, app := range append([]pack.Application{clusterApp}, remoteApps...) {
+		apps = append(apps, app.Locator)
+		apps = append(apps, app.Manifest.Dependencies.GetApps()...)
+	}
 	return &registryExecutor{
 		Emitter:     emitter,
 		FieldLogger: log.WithField("phase", params.Phase),
-		app:         clusterApp,
-		apps:        clusterApps,
+		apps:        apps,
 		packages:    clusterPackages,
 	}, nil
 }
 
-// Execute prunes unused docker images on this node
+// Execute prunes docker image layers on this node that are not referenced
+// by any of the installed applications
 func (r *registryExecutor) Execute(ctx context.Context) error {
 	stateDir, err := state.GetStateDir()
 	if err != nil {
 		return trace.Wrap(err)
 	}
 
-	imageService, err := docker.NewImageService(docker.RegistryConnectionRequest{
-		RegistryAddress: constants.LocalRegistryAddr,
-		CertName:        constants.DockerRegistry,
-		CACertPath:      state.Secret(stateDir, defaults.RootCertFilename),
-		ClientCertPath:  state.Secret(stateDir, "kubelet.cert"),
-		ClientKeyPath:   state.Secret(stateDir, "kubelet.key"),
-	})
-	if err != nil {
-		return trace.Wrap(err)
-	}
-
-	pruner, err := registry.New(registry.Config{
-		App:          &r.app,
-		Apps:         r.apps,
-		Packages:     r.packages,
-		ImageService: imageService,
+	pruner, err := registry.NewLayerCollector(registry.LayerConfig{
+		Apps:        r.apps,
+		Packages:    r.packages,
+		RegistryDir: state.RegistryDir(stateDir),
 		Config: prune.Config{
 			Emitter:     r.Emitter,
 			FieldLogger: r.FieldLogger.WithField(trace.Component, "gc:registry"),
@@ -108,7 +98,8 @@ type registryExecutor struct {
 	log.FieldLogger
 	// Emitter outputs progress messages to stdout
 	utils.Emitter
-	app      loc.Locator
-	apps     app.Applications
-	packages pack.PackageService
+	// apps lists the installed applications
+	apps []loc.Locator
+	// packages is the cluster package service
+	packages libpack.PackageService
 }
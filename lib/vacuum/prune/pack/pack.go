@@ -249,6 +249,11 @@ func (r *cleanup) deletePackage(item statePackage) error {
 func (r *cleanup) shouldDeletePackage(pkg existingPackage, required packageMap) (delete bool, err error) {
 	log := r.WithField("package", pkg.Locator)
 
+	if pkg.IsPinned() {
+		log.Debug("Will not delete a pinned package.")
+		return false, nil
+	}
+
 	if existingVersion, exists := required[pkg.Locator.ZeroVersion()]; exists {
 		if existingVersion.Compare(pkg.Version) > 0 {
 			log.Debug("Will delete an obsolete package.")
@@ -326,7 +331,10 @@ func (r *cleanup) withDependencies(pkg existingPackage, owner ownerFunc, state m
 type ownerFunc func(pack.PackageEnvelope, packageService) ([]pack.PackageEnvelope, error)
 
 func packageForConfig(envelope pack.PackageEnvelope, service packageService) ([]pack.PackageEnvelope, error) {
-	parentPackageRef := envelope.RuntimeLabels[pack.ConfigLabel]
+	parentPackageRef, ok := envelope.RuntimeLabels[pack.ConfigLabel]
+	if !ok {
+		parentPackageRef = envelope.RuntimeLabels[pack.ConfigBaseForLabel]
+	}
 	parentPackageFilter, err := loc.ParseLocator(parentPackageRef)
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -368,7 +376,7 @@ func isAppResourcesPackage(envelope pack.PackageEnvelope) bool {
 
 func isPlanetConfigPackage(envelope pack.PackageEnvelope) bool {
 	label, exists := envelope.RuntimeLabels[pack.PurposeLabel]
-	return exists && label == pack.PurposePlanetConfig
+	return exists && (label == pack.PurposePlanetConfig || label == pack.PurposePlanetConfigBase)
 }
 
 // isRPCUpdateCredentialsPackage returns true if the specified package is an RPC credentials
@@ -191,7 +191,16 @@ func (*S) TestPrunesOldPlanetConfiguration(c *C) {
 		pack.PurposeLabel, pack.PurposePlanetConfig,
 		pack.ConfigLabel, runtimePackage.Locator.ZeroVersion().String(),
 	)
-	allPackages := append(dependencies, planetConfig, oldPlanetConfig, oldRuntimePackage)
+	planetConfigBase := newPackage("cluster/planet-config-base-0123456789ab:0.0.3",
+		pack.PurposeLabel, pack.PurposePlanetConfigBase,
+		pack.ConfigBaseForLabel, runtimePackage.Locator.ZeroVersion().String(),
+	)
+	oldPlanetConfigBase := newPackage("cluster/planet-config-base-0123456789ab:0.0.2",
+		pack.PurposeLabel, pack.PurposePlanetConfigBase,
+		pack.ConfigBaseForLabel, runtimePackage.Locator.ZeroVersion().String(),
+	)
+	allPackages := append(dependencies, planetConfig, oldPlanetConfig, oldRuntimePackage,
+		planetConfigBase, oldPlanetConfigBase)
 
 	// exercise
 	p, err := New(Config{
@@ -207,7 +216,7 @@ func (*S) TestPrunesOldPlanetConfiguration(c *C) {
 	c.Assert(err, IsNil)
 
 	// verify
-	expected := append(dependencies, planetConfig)
+	expected := append(dependencies, planetConfig, planetConfigBase)
 	c.Assert(byLocator(allPackages), compare.SortedSliceEquals, byLocator(expected),
 		Commentf("Should prune old planet configuration package"))
 }
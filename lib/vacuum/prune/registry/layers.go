@@ -0,0 +1,487 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/pack"
+	"github.com/gravitational/gravity/lib/utils"
+	"github.com/gravitational/gravity/lib/vacuum/prune"
+
+	dockerarchive "github.com/docker/docker/pkg/archive"
+	"github.com/dustin/go-humanize"
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// NewLayerCollector creates a new pruner that removes docker image layers
+// not referenced by any of the installed application versions
+func NewLayerCollector(config LayerConfig) (*layerCollector, error) {
+	if err := config.checkAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return &layerCollector{
+		LayerConfig: config,
+	}, nil
+}
+
+func (r *LayerConfig) checkAndSetDefaults() error {
+	if len(r.Apps) == 0 {
+		return trace.BadParameter("at least a single application package is required")
+	}
+	if r.Packages == nil {
+		return trace.BadParameter("cluster package service is required")
+	}
+	if r.RegistryDir == "" {
+		return trace.BadParameter("registry directory is required")
+	}
+	if r.FieldLogger == nil {
+		r.FieldLogger = log.WithField(trace.Component, "gc:registry")
+	}
+	if r.Emitter == nil {
+		r.Emitter = utils.NopEmitter()
+	}
+	return nil
+}
+
+// LayerConfig describes configuration for the registry layer collector
+type LayerConfig struct {
+	// Config specifies the common pruner configuration
+	prune.Config
+	// Apps lists the installed application packages.
+	// Images from these applications are retained in the registry
+	Apps []loc.Locator
+	// Packages specifies the cluster package service
+	Packages pack.PackageService
+	// RegistryDir specifies the root of the docker registry storage on this node
+	RegistryDir string
+	// SkipServiceRestart specifies whether the registry service is left
+	// running during the collection
+	SkipServiceRestart bool
+}
+
+// Prune removes docker image layers that are not referenced by any
+// of the installed applications.
+//
+// Collection is performed in two passes: the mark pass collects the set
+// of image tags referenced by the installed applications and untags the rest,
+// the sweep pass then removes blobs that are no longer reachable from any of the
+// remaining manifests.
+func (r *layerCollector) Prune(ctx context.Context) (err error) {
+	required := make(imageTags)
+	for _, app := range r.Apps {
+		r.PrintStep("Mark images of application %v as required", app)
+		err = r.markApp(app, required)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
+
+	if !r.DryRun && !r.SkipServiceRestart {
+		r.PrintStep("Stop registry service")
+		err = stopService(ctx, r.FieldLogger)
+		defer func() {
+			r.PrintStep("Start registry service")
+			if errStart := startService(ctx, r.FieldLogger); errStart != nil {
+				r.Warn(errStart)
+				if err == nil {
+					err = errStart
+				}
+			}
+		}()
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
+
+	result, err := collect(r.RegistryDir, required, r.Config)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	r.PrintStep("Removed %v unreferenced blobs, reclaimed %v",
+		result.blobs, humanize.Bytes(uint64(result.bytes)))
+	return nil
+}
+
+func (r *layerCollector) markApp(app loc.Locator, required imageTags) error {
+	_, reader, err := r.Packages.ReadPackage(app)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer reader.Close()
+
+	tags, err := readImageTags(reader)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	for tag, digest := range tags {
+		r.Debugf("Mark %v (%v) as required.", tag, digest)
+		required[tag] = digest
+	}
+	return nil
+}
+
+// readImageTags reads the docker image tags from the registry
+// embedded in the application package given with r
+func readImageTags(r io.Reader) (imageTags, error) {
+	decompressed, err := dockerarchive.DecompressStream(r)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer decompressed.Close()
+
+	tags := make(imageTags)
+	tarball := tar.NewReader(decompressed)
+	for {
+		header, err := tarball.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		match := tagLinkRegexp.FindStringSubmatch(path.Clean(header.Name))
+		if match == nil {
+			continue
+		}
+		data, err := ioutil.ReadAll(tarball)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		tags[imageTag{repository: match[1], tag: match[2]}] = strings.TrimSpace(string(data))
+	}
+	return tags, nil
+}
+
+// collect removes image tags not present in required from the registry
+// storage in registryDir and sweeps the blobs no longer referenced by
+// any of the remaining manifests
+func collect(registryDir string, required imageTags, config prune.Config) (*collectResult, error) {
+	root := filepath.Join(registryDir, registryStoragePath)
+	repos, err := listRepositories(filepath.Join(root, "repositories"))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	marked := make(map[string]struct{})
+	for _, repo := range repos {
+		manifests, err := markRepository(root, repo, required, config)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		for _, digest := range manifests {
+			if err := markManifest(root, digest, marked, config.FieldLogger); err != nil {
+				return nil, trace.Wrap(err)
+			}
+		}
+	}
+
+	for _, repo := range repos {
+		if err := sweepLayerLinks(root, repo, marked, config); err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+
+	result, err := sweepBlobs(root, marked, config)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return result, nil
+}
+
+// listRepositories returns the names of all repositories found in dir
+func listRepositories(dir string) (repos []string, err error) {
+	err = filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return trace.ConvertSystemError(err)
+		}
+		if !fi.IsDir() {
+			return nil
+		}
+		if fi.Name() == manifestsDir {
+			repo, err := filepath.Rel(dir, filepath.Dir(path))
+			if err != nil {
+				return trace.Wrap(err)
+			}
+			repos = append(repos, filepath.ToSlash(repo))
+			return filepath.SkipDir
+		}
+		if strings.HasPrefix(fi.Name(), "_") {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return repos, nil
+}
+
+// markRepository untags the images in the specified repository that are not required
+// and returns the list of manifest digests that should be retained
+func markRepository(root, repo string, required imageTags, config prune.Config) (manifests []string, err error) {
+	repoDir := filepath.Join(root, "repositories", filepath.FromSlash(repo))
+	tagsDir := filepath.Join(repoDir, manifestsDir, "tags")
+	tags, err := readDirNames(tagsDir)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	retained := make(map[string]struct{})
+	for _, tag := range tags {
+		ref := imageTag{repository: repo, tag: tag}
+		digest, ok := required[ref]
+		if !ok {
+			config.PrintStep("Untag image %v", ref)
+			if !config.DryRun {
+				if err := os.RemoveAll(filepath.Join(tagsDir, tag)); err != nil {
+					return nil, trace.ConvertSystemError(err)
+				}
+			}
+			continue
+		}
+		retained[digest] = struct{}{}
+	}
+
+	revisionsDir := filepath.Join(repoDir, manifestsDir, "revisions", digestAlgorithm)
+	revisions, err := readDirNames(revisionsDir)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	for _, hex := range revisions {
+		digest := digestAlgorithm + ":" + hex
+		if _, ok := retained[digest]; ok {
+			manifests = append(manifests, digest)
+			continue
+		}
+		config.Debugf("Remove manifest %v from repository %v.", digest, repo)
+		if !config.DryRun {
+			if err := os.RemoveAll(filepath.Join(revisionsDir, hex)); err != nil {
+				return nil, trace.ConvertSystemError(err)
+			}
+		}
+	}
+
+	if len(manifests) == 0 {
+		config.PrintStep("Remove repository %v", repo)
+		if !config.DryRun {
+			if err := os.RemoveAll(repoDir); err != nil {
+				return nil, trace.ConvertSystemError(err)
+			}
+		}
+	}
+	return manifests, nil
+}
+
+// markManifest marks the manifest blob given with digest and all blobs it references
+func markManifest(root, digest string, marked map[string]struct{}, log log.FieldLogger) error {
+	if _, ok := marked[digest]; ok {
+		return nil
+	}
+	marked[digest] = struct{}{}
+
+	data, err := ioutil.ReadFile(filepath.Join(blobPath(root, digest), "data"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			log.Warnf("Manifest %v is missing from the registry.", digest)
+			return nil
+		}
+		return trace.ConvertSystemError(err)
+	}
+
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return trace.Wrap(err, "failed to parse manifest %v", digest)
+	}
+	if m.Config != nil {
+		marked[m.Config.Digest] = struct{}{}
+	}
+	for _, layer := range m.Layers {
+		marked[layer.Digest] = struct{}{}
+	}
+	for _, layer := range m.FSLayers {
+		marked[layer.BlobSum] = struct{}{}
+	}
+	for _, item := range m.Manifests {
+		if err := markManifest(root, item.Digest, marked, log); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// sweepLayerLinks removes the links to unmarked layers from the specified repository
+func sweepLayerLinks(root, repo string, marked map[string]struct{}, config prune.Config) error {
+	dir := filepath.Join(root, "repositories", filepath.FromSlash(repo), layersDir, digestAlgorithm)
+	links, err := readDirNames(dir)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	for _, hex := range links {
+		if _, ok := marked[digestAlgorithm+":"+hex]; ok {
+			continue
+		}
+		if !config.DryRun {
+			if err := os.RemoveAll(filepath.Join(dir, hex)); err != nil {
+				return trace.ConvertSystemError(err)
+			}
+		}
+	}
+	return nil
+}
+
+// sweepBlobs removes all blobs not present in marked
+func sweepBlobs(root string, marked map[string]struct{}, config prune.Config) (*collectResult, error) {
+	var result collectResult
+	blobsDir := filepath.Join(root, "blobs", digestAlgorithm)
+	prefixes, err := readDirNames(blobsDir)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	for _, prefix := range prefixes {
+		hexes, err := readDirNames(filepath.Join(blobsDir, prefix))
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		for _, hex := range hexes {
+			digest := digestAlgorithm + ":" + hex
+			if _, ok := marked[digest]; ok {
+				continue
+			}
+			dir := blobPath(root, digest)
+			fi, err := os.Stat(filepath.Join(dir, "data"))
+			if err != nil && !os.IsNotExist(err) {
+				return nil, trace.ConvertSystemError(err)
+			}
+			if fi != nil {
+				result.bytes += fi.Size()
+			}
+			result.blobs++
+			config.Debugf("Remove blob %v.", digest)
+			if !config.DryRun {
+				if err := os.RemoveAll(dir); err != nil {
+					return nil, trace.ConvertSystemError(err)
+				}
+			}
+		}
+	}
+	return &result, nil
+}
+
+// blobPath returns the path to the directory of the blob given with digest
+func blobPath(root, digest string) string {
+	hex := strings.TrimPrefix(digest, digestAlgorithm+":")
+	if len(hex) < 2 {
+		return filepath.Join(root, "blobs", digestAlgorithm, hex)
+	}
+	return filepath.Join(root, "blobs", digestAlgorithm, hex[:2], hex)
+}
+
+// readDirNames returns the names of the entries in dir.
+// Returns an empty list if the directory does not exist
+func readDirNames(dir string) ([]string, error) {
+	f, err := os.Open(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, trace.ConvertSystemError(err)
+	}
+	defer f.Close()
+	names, err := f.Readdirnames(-1)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	return names, nil
+}
+
+type layerCollector struct {
+	// LayerConfig specifies the configuration for the collector
+	LayerConfig
+}
+
+// imageTags maps an image tag to the digest of its manifest
+type imageTags map[imageTag]string
+
+// imageTag identifies a tagged image in a registry repository
+type imageTag struct {
+	repository string
+	tag        string
+}
+
+// String returns a textual representation of this image tag
+func (r imageTag) String() string {
+	return r.repository + ":" + r.tag
+}
+
+// collectResult describes the outcome of the sweep pass
+type collectResult struct {
+	// blobs is the number of removed blobs
+	blobs int
+	// bytes is the total size of the removed blobs
+	bytes int64
+}
+
+// manifest describes the subset of the docker image manifest
+// (schema1, schema2 and manifest lists) required to resolve blob references
+type manifest struct {
+	Config   *descriptor  `json:"config,omitempty"`
+	Layers   []descriptor `json:"layers,omitempty"`
+	FSLayers []struct {
+		BlobSum string `json:"blobSum"`
+	} `json:"fsLayers,omitempty"`
+	Manifests []descriptor `json:"manifests,omitempty"`
+}
+
+type descriptor struct {
+	Digest string `json:"digest"`
+}
+
+// tagLinkRegexp matches the tag link files in the registry embedded
+// in an application package
+var tagLinkRegexp = regexp.MustCompile(
+	"^" + defaults.RegistryDir + "/" + registryStoragePath +
+		"/repositories/(.+)/" + manifestsDir + "/tags/([^/]+)/current/link$")
+
+const (
+	// registryStoragePath is the location of the registry storage relative
+	// to the registry root directory
+	registryStoragePath = "docker/registry/v2"
+	// manifestsDir is the name of the repository directory with manifest links
+	manifestsDir = "_manifests"
+	// layersDir is the name of the repository directory with layer links
+	layersDir = "_layers"
+	// digestAlgorithm is the only digest algorithm used by the registry
+	digestAlgorithm = "sha256"
+)
@@ -0,0 +1,184 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gravitational/gravity/lib/compare"
+	"github.com/gravitational/gravity/lib/utils"
+	"github.com/gravitational/gravity/lib/vacuum/prune"
+
+	log "github.com/sirupsen/logrus"
+	. "gopkg.in/check.v1"
+)
+
+func TestRegistry(t *testing.T) { TestingT(t) }
+
+type LayerSuite struct{}
+
+var _ = Suite(&LayerSuite{})
+
+func (*LayerSuite) TestReadsImageTagsFromApplicationPackage(c *C) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	files := map[string]string{
+		"registry/docker/registry/v2/repositories/gravitational/debian-tall/_manifests/tags/0.0.1/current/link": "sha256:aaaa\n",
+		"registry/docker/registry/v2/repositories/nginx/_manifests/tags/1.15/current/link":                      "sha256:bbbb",
+		"registry/docker/registry/v2/repositories/nginx/_manifests/tags/1.15/index/sha256/bbbb/link":            "sha256:bbbb",
+		"resources/app.yaml": "kind: Bundle",
+	}
+	for name, data := range files {
+		c.Assert(tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(data))}), IsNil)
+		_, err := tw.Write([]byte(data))
+		c.Assert(err, IsNil)
+	}
+	c.Assert(tw.Close(), IsNil)
+	c.Assert(gz.Close(), IsNil)
+
+	tags, err := readImageTags(&buf)
+	c.Assert(err, IsNil)
+	compare.DeepCompare(c, tags, imageTags{
+		{repository: "gravitational/debian-tall", tag: "0.0.1"}: "sha256:aaaa",
+		{repository: "nginx", tag: "1.15"}:                      "sha256:bbbb",
+	})
+}
+
+func (*LayerSuite) TestRemovesUnreferencedLayers(c *C) {
+	dir := c.MkDir()
+	r := newTestRegistry(c, dir)
+	current := r.image("app/foo", "1.0", "layer-1", "layer-2")
+	r.image("app/foo", "0.9", "layer-0", "layer-1")
+	r.image("custom/bar", "latest", "layer-3")
+
+	required := imageTags{{repository: "app/foo", tag: "1.0"}: current.manifest}
+	result, err := collect(dir, required, prune.Config{
+		FieldLogger: log.StandardLogger(),
+		Emitter:     utils.NopEmitter(),
+	})
+	c.Assert(err, IsNil)
+	c.Assert(result.blobs, Equals, 6)
+
+	c.Assert(r.blobs(), DeepEquals, current.blobs())
+	c.Assert(r.exists("repositories/app/foo/_manifests/tags/1.0"), Equals, true)
+	c.Assert(r.exists("repositories/app/foo/_manifests/tags/0.9"), Equals, false)
+	c.Assert(r.exists("repositories/custom/bar"), Equals, false)
+}
+
+func (*LayerSuite) TestDryRunKeepsRegistryIntact(c *C) {
+	dir := c.MkDir()
+	r := newTestRegistry(c, dir)
+	r.image("app/foo", "1.0", "layer-1")
+	r.image("custom/bar", "latest", "layer-3")
+	before := r.blobs()
+
+	result, err := collect(dir, imageTags{}, prune.Config{
+		DryRun:      true,
+		FieldLogger: log.StandardLogger(),
+		Emitter:     utils.NopEmitter(),
+	})
+	c.Assert(err, IsNil)
+	c.Assert(result.blobs, Equals, 6)
+	c.Assert(r.blobs(), DeepEquals, before)
+	c.Assert(r.exists("repositories/custom/bar/_manifests/tags/latest"), Equals, true)
+}
+
+func newTestRegistry(c *C, dir string) *testRegistry {
+	return &testRegistry{c: c, root: filepath.Join(dir, registryStoragePath)}
+}
+
+// image creates an image with the specified layers in the registry
+func (r *testRegistry) image(repo, tag string, layers ...string) testImage {
+	var img testImage
+	var m manifest
+	config := r.blob([]byte(repo + tag))
+	m.Config = &descriptor{Digest: config}
+	img.refs = append(img.refs, config)
+	for _, layer := range layers {
+		digest := r.blob([]byte(layer))
+		r.link(fmt.Sprintf("repositories/%v/_layers", repo), digest)
+		m.Layers = append(m.Layers, descriptor{Digest: digest})
+		img.refs = append(img.refs, digest)
+	}
+	data, err := json.Marshal(m)
+	r.c.Assert(err, IsNil)
+	img.manifest = r.blob(data)
+	r.link(fmt.Sprintf("repositories/%v/_manifests/revisions", repo), img.manifest)
+	r.write(fmt.Sprintf("repositories/%v/_manifests/tags/%v/current/link", repo, tag), []byte(img.manifest))
+	return img
+}
+
+func (r *testRegistry) blob(data []byte) string {
+	digest := fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+	r.write(filepath.Join(blobPath("", digest), "data"), data)
+	return digest
+}
+
+func (r *testRegistry) link(dir, digest string) {
+	hex := digest[len(digestAlgorithm)+1:]
+	r.write(filepath.Join(dir, digestAlgorithm, hex, "link"), []byte(digest))
+}
+
+func (r *testRegistry) write(path string, data []byte) {
+	path = filepath.Join(r.root, path)
+	r.c.Assert(os.MkdirAll(filepath.Dir(path), 0755), IsNil)
+	r.c.Assert(ioutil.WriteFile(path, data, 0644), IsNil)
+}
+
+func (r *testRegistry) exists(path string) bool {
+	_, err := os.Stat(filepath.Join(r.root, path))
+	return err == nil
+}
+
+// blobs returns the set of blob digests in the registry
+func (r *testRegistry) blobs() map[string]struct{} {
+	result := make(map[string]struct{})
+	paths, err := filepath.Glob(filepath.Join(r.root, "blobs", digestAlgorithm, "*", "*"))
+	r.c.Assert(err, IsNil)
+	for _, path := range paths {
+		result[digestAlgorithm+":"+filepath.Base(path)] = struct{}{}
+	}
+	return result
+}
+
+func (r testImage) blobs() map[string]struct{} {
+	result := map[string]struct{}{r.manifest: struct{}{}}
+	for _, ref := range r.refs {
+		result[ref] = struct{}{}
+	}
+	return result
+}
+
+type testRegistry struct {
+	c    *C
+	root string
+}
+
+type testImage struct {
+	manifest string
+	refs     []string
+}
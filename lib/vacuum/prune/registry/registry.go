@@ -143,25 +143,35 @@ func (r *cleanup) Prune(ctx context.Context) (err error) {
 }
 
 func (r *cleanup) registryStart(ctx context.Context) error {
-	out, err := r.serviceStart(ctx)
+	return trace.Wrap(startService(ctx, r.FieldLogger))
+}
+
+func (r *cleanup) registryStop(ctx context.Context) error {
+	return trace.Wrap(stopService(ctx, r.FieldLogger))
+}
+
+// startService starts the registry service and waits for it to become active
+func startService(ctx context.Context, log log.FieldLogger) error {
+	out, err := serviceCtl(ctx, log, "start")
 	if err != nil {
 		return trace.Wrap(err, "failed to start the registry service: %s.", out)
 	}
 
-	err = r.waitForService(ctx, systemservice.ServiceStatusActive)
+	err = waitForService(ctx, log, systemservice.ServiceStatusActive)
 	if err != nil {
 		return trace.Wrap(err, "failed to wait for the registry service to start")
 	}
 	return nil
 }
 
-func (r *cleanup) registryStop(ctx context.Context) error {
-	out, err := r.serviceStop(ctx)
+// stopService stops the registry service and waits for it to become inactive
+func stopService(ctx context.Context, log log.FieldLogger) error {
+	out, err := serviceCtl(ctx, log, "stop")
 	if err != nil {
 		return trace.Wrap(err, "failed to stop the registry service: %s.", out)
 	}
 
-	err = r.waitForService(ctx, systemservice.ServiceStatusInactive)
+	err = waitForService(ctx, log, systemservice.ServiceStatusInactive)
 	if err != nil {
 		return trace.Wrap(err, "failed to wait for the registry service to stop")
 	}
@@ -169,12 +179,12 @@ func (r *cleanup) registryStop(ctx context.Context) error {
 	return nil
 }
 
-func (r *cleanup) waitForService(ctx context.Context, status string) error {
+func waitForService(ctx context.Context, log log.FieldLogger, status string) error {
 	localCtx, cancel := defaults.WithTimeout(ctx)
 	defer cancel()
 	b := utils.NewUnlimitedExponentialBackOff()
 	err := utils.RetryWithInterval(localCtx, b, func() error {
-		out, err := r.serviceStatus(localCtx)
+		out, err := serviceCtl(localCtx, log, "is-active")
 		actualStatus := strings.TrimSpace(string(out))
 		if strings.HasPrefix(actualStatus, status) {
 			return nil
@@ -184,18 +194,6 @@ func (r *cleanup) waitForService(ctx context.Context, status string) error {
 	return trace.Wrap(err)
 }
 
-func (r *cleanup) serviceStop(ctx context.Context) (output []byte, err error) {
-	return serviceCtl(ctx, r.FieldLogger, "stop")
-}
-
-func (r *cleanup) serviceStart(ctx context.Context) (output []byte, err error) {
-	return serviceCtl(ctx, r.FieldLogger, "start")
-}
-
-func (r *cleanup) serviceStatus(ctx context.Context) (output []byte, err error) {
-	return serviceCtl(ctx, r.FieldLogger, "is-active")
-}
-
 type cleanup struct {
 	// Config specifies the configuration for the cleanup
 	Config
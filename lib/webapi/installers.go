@@ -0,0 +1,86 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webapi
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gravitational/gravity/lib/installers"
+
+	telehttplib "github.com/gravitational/teleport/lib/httplib"
+	"github.com/gravitational/trace"
+	"github.com/julienschmidt/httprouter"
+)
+
+// getInstallerVersions returns the versions of the application
+// installers can be generated for, newest first
+//
+// GET /portalapi/v1/installers/:repository/:package
+//
+// Output:
+// [
+//   {
+//     "app": "gravitational.io/app:1.2.0",
+//     "runtime": "gravitational.io/kubernetes:5.2.3"
+//   }
+// ]
+func (m *Handler) getInstallerVersions(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *AuthContext) (interface{}, error) {
+	if m.cfg.Installers == nil {
+		return nil, trace.NotImplemented("installer generation is not enabled")
+	}
+	return installers.Versions(ctx.Applications, p.ByName("repository"), p.ByName("package"))
+}
+
+// getInstaller streams the installer tarball for the selected application
+// version with an optional license embedded. The tarball is generated on
+// the first request and cached
+//
+// POST /portalapi/v1/installers
+//
+// Input:
+// {
+//   "repository": "gravitational.io",
+//   "name": "app",
+//   "version": "1.2.0",
+//   "runtime_version": "5.2.3",
+//   "license": "<license>"
+// }
+//
+// Output:
+//
+//   installer tarball
+func (m *Handler) getInstaller(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *AuthContext) (interface{}, error) {
+	if m.cfg.Installers == nil {
+		return nil, trace.NotImplemented("installer generation is not enabled")
+	}
+	var req installers.Request
+	if err := telehttplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	req.AccountID = ctx.User.GetAccountID()
+	installer, err := m.cfg.Installers.Get(ctx.Applications, ctx.Operator, req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer installer.Close()
+	w.Header().Set("Content-Type", "application/x-gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(
+		`attachment; filename="%v-%v-installer.tar.gz"`, installer.App.Name, installer.App.Version))
+	http.ServeContent(w, r, installer.Name(), installer.Modified, installer)
+	return nil, nil
+}
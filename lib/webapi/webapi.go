@@ -31,12 +31,14 @@ import (
 
 	"github.com/gravitational/gravity/lib/app"
 	appsapi "github.com/gravitational/gravity/lib/app"
+	"github.com/gravitational/gravity/lib/checks"
 	"github.com/gravitational/gravity/lib/clients"
 	"github.com/gravitational/gravity/lib/cloudprovider/aws"
 	awsservice "github.com/gravitational/gravity/lib/cloudprovider/aws/service"
 	"github.com/gravitational/gravity/lib/constants"
 	"github.com/gravitational/gravity/lib/defaults"
 	"github.com/gravitational/gravity/lib/httplib"
+	"github.com/gravitational/gravity/lib/installers"
 	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/ops"
 	"github.com/gravitational/gravity/lib/ops/resources"
@@ -102,6 +104,8 @@ type Config struct {
 	// ServiceUser specifies the service user to use to
 	// create a cluster with for wizard-based installation
 	ServiceUser systeminfo.User
+	// Installers is the optional cache of installers generated on demand
+	Installers *installers.Cache
 }
 
 // Check validates the config
@@ -276,6 +280,10 @@ func NewAPI(cfg Config) (*Handler, error) {
 	h.GET("/apps/:repository/:package/:version", h.needsAuth(h.getAppPackage))
 	h.GET("/apps/:repository/:package/:version/installer", h.needsAuth(h.getAppInstaller))
 
+	// Installers generated on demand
+	h.GET("/installers/:repository/:package", h.needsAuth(h.getInstallerVersions))
+	h.POST("/installers", h.needsAuth(h.getInstaller))
+
 	// User
 	h.GET("/user/context", h.needsAuth(h.getWebContext))
 	h.GET("/user/status", h.needsAuth(h.getUserStatus))
@@ -940,7 +948,9 @@ func (m *Handler) agentReport(w http.ResponseWriter, r *http.Request, p httprout
 			Devices:    server.GetDevices(),
 			Role:       server.Role,
 			OSInfo:     server.GetOS(),
+			Arch:       server.GetArch(),
 			Mounts:     mounts,
+			Hardware:   server.GetHardware(),
 		})
 	}
 
@@ -948,7 +958,8 @@ func (m *Handler) agentReport(w http.ResponseWriter, r *http.Request, p httprout
 		Message: agentReport.Message,
 		Servers: servers,
 		// TODO: docker configuration needs to be per-node profile
-		Docker: app.Manifest.SystemDocker(),
+		Docker:         app.Manifest.SystemDocker(),
+		Recommendation: agentReport.Recommendation,
 	}, nil
 }
 
@@ -961,6 +972,9 @@ type agentInfo struct {
 	// Docker describes docker configuration from the application
 	// manifest
 	Docker schema.Docker `json:"docker"`
+	// Recommendation is the flavor and profile placement recommended
+	// for the servers based on their hardware
+	Recommendation *checks.Recommendation `json:"recommendation,omitempty"`
 }
 
 type serverInfo struct {
@@ -974,8 +988,12 @@ type serverInfo struct {
 	Role string `json:"role"`
 	// OSInfo identifies the host operating system
 	OSInfo storage.OSInfo `json:"os"`
+	// Arch is the host architecture
+	Arch string `json:"arch,omitempty"`
 	// Mounts lists mount overrides
 	Mounts []storage.Mount `json:"mounts"`
+	// Hardware is the server hardware profile
+	Hardware *storage.HardwareProfile `json:"hardware,omitempty"`
 }
 
 type siteCreateInput struct {
@@ -0,0 +1,145 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/storage"
+)
+
+const (
+	// EventHeader is the HTTP header with the event type
+	EventHeader = "X-Gravity-Event"
+	// DeliveryHeader is the HTTP header with the event ID. Retried
+	// deliveries of the same event carry the same ID
+	DeliveryHeader = "X-Gravity-Delivery"
+	// SignatureHeader is the HTTP header with the HMAC-SHA256 signature
+	// of the request body, in the form sha256=<hex digest>
+	SignatureHeader = "X-Gravity-Signature"
+)
+
+// Event is a cluster lifecycle event sent to webhooks
+type Event struct {
+	// ID uniquely identifies the event
+	ID string `json:"id"`
+	// Type is the event type, e.g. node.added
+	Type string `json:"type"`
+	// Cluster is the name of the cluster
+	Cluster string `json:"cluster"`
+	// Time is the time of the event
+	Time time.Time `json:"time"`
+	// OperationID is the ID of the cluster operation that triggered the event
+	OperationID string `json:"operation_id"`
+	// State is the state of the operation that triggered the event
+	State string `json:"state"`
+	// Nodes lists the nodes affected by the event
+	Nodes []Node `json:"nodes,omitempty"`
+	// Package is the application package the cluster is upgraded to
+	Package string `json:"package,omitempty"`
+}
+
+// Node describes a cluster node in an event
+type Node struct {
+	// Hostname is the node hostname
+	Hostname string `json:"hostname"`
+	// AdvertiseIP is the node advertise address
+	AdvertiseIP string `json:"advertise_ip"`
+	// Role is the node cluster role: master or node
+	Role string `json:"role"`
+}
+
+// Sign returns the HMAC-SHA256 signature of the payload
+// computed with the specified secret, formatted for SignatureHeader
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return fmt.Sprintf("sha256=%v", hex.EncodeToString(mac.Sum(nil)))
+}
+
+// eventsFor returns the lifecycle events for the operation that has
+// moved from prevState to its current state. known is false for
+// operations that have not been observed before
+func eventsFor(cluster string, operation storage.SiteOperation, prevState string, known bool) (events []Event) {
+	completed := operation.State == ops.OperationStateCompleted && prevState != ops.OperationStateCompleted
+	switch operation.Type {
+	case ops.OperationInstall:
+		if completed {
+			events = append(events, newEvent(cluster, storage.WebhookEventInstallCompleted, operation))
+		}
+	case ops.OperationExpand:
+		if completed {
+			event := newEvent(cluster, storage.WebhookEventNodeAdded, operation)
+			event.Nodes = nodes(operation.Servers)
+			events = append(events, event)
+		}
+	case ops.OperationShrink:
+		if completed && operation.Shrink != nil {
+			event := newEvent(cluster, storage.WebhookEventNodeRemoved, operation)
+			event.Nodes = nodes(operation.Shrink.Servers)
+			events = append(events, event)
+		}
+	case ops.OperationUpdate:
+		if !known {
+			events = append(events, newUpgradeEvent(cluster, storage.WebhookEventUpgradeStarted, operation))
+		}
+		if isFinished(operation.State) && !isFinished(prevState) {
+			events = append(events, newUpgradeEvent(cluster, storage.WebhookEventUpgradeFinished, operation))
+		}
+	}
+	return events
+}
+
+func newEvent(cluster, eventType string, operation storage.SiteOperation) Event {
+	return Event{
+		// the ID is stable so receivers can discard duplicate deliveries
+		ID:          fmt.Sprintf("%v-%v", operation.ID, eventType),
+		Type:        eventType,
+		Cluster:     cluster,
+		Time:        operation.Updated,
+		OperationID: operation.ID,
+		State:       operation.State,
+	}
+}
+
+func newUpgradeEvent(cluster, eventType string, operation storage.SiteOperation) Event {
+	event := newEvent(cluster, eventType, operation)
+	if operation.Update != nil {
+		event.Package = operation.Update.UpdatePackage
+	}
+	return event
+}
+
+func nodes(servers []storage.Server) (nodes []Node) {
+	for _, server := range servers {
+		nodes = append(nodes, Node{
+			Hostname:    server.Hostname,
+			AdvertiseIP: server.AdvertiseIP,
+			Role:        server.ClusterRole,
+		})
+	}
+	return nodes
+}
+
+func isFinished(state string) bool {
+	return state == ops.OperationStateCompleted || state == ops.OperationStateFailed
+}
@@ -0,0 +1,222 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/cenkalti/backoff"
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+)
+
+// Operator is the subset of the cluster operator service used by the notifier
+type Operator interface {
+	// GetLocalSite returns the local cluster
+	GetLocalSite() (*ops.Site, error)
+	// GetSiteOperations returns the list of cluster operations
+	GetSiteOperations(ops.SiteKey) (ops.SiteOperations, error)
+	// GetWebhooks returns the list of configured webhooks
+	GetWebhooks(ops.SiteKey) ([]storage.Webhook, error)
+}
+
+// NotifierConfig configures the cluster lifecycle events notifier
+type NotifierConfig struct {
+	// Operator is the cluster operator service
+	Operator Operator
+	// Interval is how often cluster operations are checked for new events
+	Interval time.Duration
+	// MaxDeliveryTime limits the time spent retrying a single delivery
+	MaxDeliveryTime time.Duration
+	// Client is the HTTP client used to deliver events
+	Client *http.Client
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+}
+
+// CheckAndSetDefaults validates the configuration and sets default values
+func (r *NotifierConfig) CheckAndSetDefaults() error {
+	if r.Operator == nil {
+		return trace.BadParameter("missing Operator")
+	}
+	if r.Interval == 0 {
+		r.Interval = defaults.WebhookPollInterval
+	}
+	if r.MaxDeliveryTime == 0 {
+		r.MaxDeliveryTime = defaults.WebhookMaxDeliveryTime
+	}
+	if r.Client == nil {
+		r.Client = &http.Client{}
+	}
+	if r.FieldLogger == nil {
+		r.FieldLogger = logrus.WithField(trace.Component, "webhook")
+	}
+	return nil
+}
+
+// NewNotifier returns a new notifier of cluster lifecycle events
+func NewNotifier(config NotifierConfig) (*Notifier, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &Notifier{
+		NotifierConfig: config,
+	}, nil
+}
+
+// Notifier watches cluster operations and sends lifecycle events
+// to all configured webhooks
+type Notifier struct {
+	NotifierConfig
+	// states maps IDs of observed operations to their last seen state.
+	// It is nil until the first successful sync
+	states map[string]string
+}
+
+// Run sends cluster lifecycle events until the context is canceled
+func (r *Notifier) Run(ctx context.Context) error {
+	utils.RunPeriodically(ctx, r.Interval, r.FieldLogger,
+		"send cluster lifecycle events", r.Sync)
+	return nil
+}
+
+// Sync detects lifecycle events from the changes in cluster operations
+// since the last sync and delivers them to the subscribed webhooks.
+// The first sync only records the current state of operations so
+// events that happened before the notifier started are not resent
+func (r *Notifier) Sync(ctx context.Context) error {
+	cluster, err := r.Operator.GetLocalSite()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	operations, err := r.Operator.GetSiteOperations(cluster.Key())
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	states := make(map[string]string, len(operations))
+	var events []Event
+	for _, operation := range operations {
+		states[operation.ID] = operation.State
+		if r.states == nil {
+			continue
+		}
+		prevState, known := r.states[operation.ID]
+		events = append(events, eventsFor(cluster.Domain, operation, prevState, known)...)
+	}
+	r.states = states
+	if len(events) == 0 {
+		return nil
+	}
+	webhooks, err := r.Operator.GetWebhooks(cluster.Key())
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	r.notify(ctx, events, webhooks)
+	return nil
+}
+
+// notify delivers the events to the subscribed webhooks and waits
+// for all deliveries to complete
+func (r *Notifier) notify(ctx context.Context, events []Event, webhooks []storage.Webhook) {
+	var wg sync.WaitGroup
+	for _, event := range events {
+		for _, webhook := range webhooks {
+			if !webhook.Matches(event.Type) {
+				continue
+			}
+			wg.Add(1)
+			go func(event Event, webhook storage.Webhook) {
+				defer wg.Done()
+				if err := r.deliver(ctx, event, webhook); err != nil {
+					r.Warnf("Failed to deliver event %v to webhook %v: %v.",
+						event.ID, webhook.GetName(), trace.DebugReport(err))
+				}
+			}(event, webhook)
+		}
+	}
+	wg.Wait()
+}
+
+// deliver sends the event to the webhook retrying on failures
+func (r *Notifier) deliver(ctx context.Context, event Event, webhook storage.Webhook) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	interval := backoff.NewExponentialBackOff()
+	interval.MaxElapsedTime = r.MaxDeliveryTime
+	err = utils.RetryWithInterval(ctx, interval, func() error {
+		return r.send(ctx, event, webhook, payload)
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	r.Infof("Delivered event %v to webhook %v.", event.ID, webhook.GetName())
+	return nil
+}
+
+func (r *Notifier) send(ctx context.Context, event Event, webhook storage.Webhook, payload []byte) error {
+	req, err := http.NewRequest(http.MethodPost, webhook.GetURL(), bytes.NewReader(payload))
+	if err != nil {
+		return &backoff.PermanentError{Err: trace.Wrap(err)}
+	}
+	ctx, cancel := context.WithTimeout(ctx, webhook.GetTimeout())
+	defer cancel()
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, event.Type)
+	req.Header.Set(DeliveryHeader, event.ID)
+	if secret := webhook.GetSecret(); secret != "" {
+		req.Header.Set(SignatureHeader, Sign(secret, payload))
+	}
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	err = trace.BadParameter("webhook %v responded with %v", webhook.GetName(), resp.Status)
+	if isRetryableStatus(resp.StatusCode) {
+		return err
+	}
+	return &backoff.PermanentError{Err: err}
+}
+
+// isRetryableStatus returns true if the delivery that has failed
+// with the specified HTTP status code should be retried
+func isRetryableStatus(code int) bool {
+	switch code {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return true
+	}
+	return code >= http.StatusInternalServerError
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gravitational/gravity/lib/compare"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"gopkg.in/check.v1"
+)
+
+func TestWebhook(t *testing.T) { check.TestingT(t) }
+
+type WebhookSuite struct{}
+
+var _ = check.Suite(&WebhookSuite{})
+
+func (s *WebhookSuite) TestDetectsEvents(c *check.C) {
+	expand := storage.SiteOperation{
+		ID:      "expand-1",
+		Type:    ops.OperationExpand,
+		State:   ops.OperationStateCompleted,
+		Servers: []storage.Server{testServer},
+	}
+	c.Assert(eventsFor("example.com", expand, ops.OperationStateExpandDeploying, true), compare.DeepEquals, []Event{{
+		ID:          "expand-1-node.added",
+		Type:        storage.WebhookEventNodeAdded,
+		Cluster:     "example.com",
+		OperationID: "expand-1",
+		State:       ops.OperationStateCompleted,
+		Nodes:       []Node{{Hostname: "node-1", AdvertiseIP: "10.0.0.1", Role: "node"}},
+	}})
+	c.Assert(eventsFor("example.com", expand, ops.OperationStateCompleted, true), check.HasLen, 0)
+
+	update := storage.SiteOperation{
+		ID:     "update-1",
+		Type:   ops.OperationUpdate,
+		State:  ops.OperationStateUpdateInProgress,
+		Update: &storage.UpdateOperationState{UpdatePackage: "example.com/app:2.0.0"},
+	}
+	events := eventsFor("example.com", update, "", false)
+	c.Assert(events, check.HasLen, 1)
+	c.Assert(events[0].Type, check.Equals, storage.WebhookEventUpgradeStarted)
+	c.Assert(events[0].Package, check.Equals, "example.com/app:2.0.0")
+	c.Assert(eventsFor("example.com", update, ops.OperationStateUpdateInProgress, true), check.HasLen, 0)
+
+	update.State = ops.OperationStateFailed
+	events = eventsFor("example.com", update, ops.OperationStateUpdateInProgress, true)
+	c.Assert(events, check.HasLen, 1)
+	c.Assert(events[0].Type, check.Equals, storage.WebhookEventUpgradeFinished)
+	c.Assert(events[0].State, check.Equals, ops.OperationStateFailed)
+}
+
+func (s *WebhookSuite) TestDeliversSignedEvents(c *check.C) {
+	var mu sync.Mutex
+	var attempts int
+	received := make(chan *http.Request, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		payload, err := ioutil.ReadAll(r.Body)
+		c.Assert(err, check.IsNil)
+		c.Assert(r.Header.Get(SignatureHeader), check.Equals, Sign("secret", payload))
+		var event Event
+		c.Assert(json.Unmarshal(payload, &event), check.IsNil)
+		c.Assert(event.Type, check.Equals, storage.WebhookEventNodeAdded)
+		received <- r
+	}))
+	defer server.Close()
+
+	operator := &testOperator{
+		webhooks: []storage.Webhook{
+			newWebhook(c, "cmdb", server.URL, "secret"),
+		},
+	}
+	notifier, err := NewNotifier(NotifierConfig{Operator: operator, MaxDeliveryTime: time.Minute})
+	c.Assert(err, check.IsNil)
+	c.Assert(notifier.Sync(context.TODO()), check.IsNil)
+
+	// operations that exist at startup do not produce events
+	operator.operations = ops.SiteOperations{{
+		ID:      "expand-1",
+		Type:    ops.OperationExpand,
+		State:   ops.OperationStateCompleted,
+		Servers: []storage.Server{testServer},
+	}}
+	c.Assert(notifier.Sync(context.TODO()), check.IsNil)
+
+	select {
+	case r := <-received:
+		c.Assert(r.Header.Get(EventHeader), check.Equals, storage.WebhookEventNodeAdded)
+		c.Assert(r.Header.Get(DeliveryHeader), check.Equals, "expand-1-node.added")
+	default:
+		c.Fatal("event has not been delivered")
+	}
+	c.Assert(attempts, check.Equals, 2)
+}
+
+func (s *WebhookSuite) TestDoesNotRetryClientErrors(c *check.C) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	notifier, err := NewNotifier(NotifierConfig{Operator: &testOperator{}, MaxDeliveryTime: time.Minute})
+	c.Assert(err, check.IsNil)
+	err = notifier.deliver(context.TODO(), Event{ID: "install-1-install.completed"},
+		newWebhook(c, "cmdb", server.URL, ""))
+	c.Assert(err, check.NotNil)
+	c.Assert(attempts, check.Equals, 1)
+}
+
+func newWebhook(c *check.C, name, url, secret string) storage.Webhook {
+	webhook := storage.NewWebhook(name, storage.WebhookSpecV2{URL: url, Secret: secret})
+	c.Assert(webhook.CheckAndSetDefaults(), check.IsNil)
+	return webhook
+}
+
+type testOperator struct {
+	operations ops.SiteOperations
+	webhooks   []storage.Webhook
+}
+
+func (r *testOperator) GetLocalSite() (*ops.Site, error) {
+	return &ops.Site{Domain: "example.com", AccountID: "account"}, nil
+}
+
+func (r *testOperator) GetSiteOperations(ops.SiteKey) (ops.SiteOperations, error) {
+	return r.operations, nil
+}
+
+func (r *testOperator) GetWebhooks(ops.SiteKey) ([]storage.Webhook, error) {
+	return r.webhooks, nil
+}
+
+var testServer = storage.Server{
+	Hostname:    "node-1",
+	AdvertiseIP: "10.0.0.1",
+	ClusterRole: "node",
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"strings"
+
+	"github.com/gravitational/trace"
+)
+
+// EncryptionKey returns the installer encryption key: either the one specified
+// explicitly or the output of the specified shell command.
+//
+// The command allows to keep the key in an external key management service,
+// e.g. to decrypt a data key with 'aws kms decrypt'
+func EncryptionKey(ctx context.Context, key, command string) (string, error) {
+	if key != "" && command != "" {
+		return "", trace.BadParameter("only one of the encryption key " +
+			"or the encryption key command can be specified")
+	}
+	if command == "" {
+		return key, nil
+	}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", command)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", trace.Wrap(err, "failed to run encryption key command: %s",
+			bytes.TrimSpace(stderr.Bytes()))
+	}
+	key = strings.TrimSpace(string(out))
+	if key == "" {
+		return "", trace.BadParameter("encryption key command returned an empty key")
+	}
+	return key, nil
+}
@@ -142,7 +142,7 @@ func uninstallAppPackage(env *localenv.LocalEnvironment, appPackage loc.Locator)
 // importApp imports an application from the specified directory creating a new
 // package named packageName.
 func importApp(env *localenv.LocalEnvironment, registryURL, dockerURL, source string, req *appservice.ImportRequest,
-	opsCenterURL string, silent bool, parallel int) error {
+	opsCenterURL string, silent bool, parallel int, rateLimit utils.TransferRate) error {
 	apps, err := env.AppService(opsCenterURL, localenv.AppConfig{
 		DockerURL:   dockerURL,
 		RegistryURL: registryURL,
@@ -213,6 +213,10 @@ func importApp(env *localenv.LocalEnvironment, registryURL, dockerURL, source st
 		}
 	}
 
+	if rateLimit != 0 {
+		stream = utils.NewRateLimitedReadCloser(stream, utils.NewRateLimiter(rateLimit))
+	}
+
 	progressC := make(chan *appservice.ProgressEntry)
 	errorC := make(chan error, 1)
 	req.Source = stream
@@ -424,7 +428,7 @@ func unpackAppResources(env *localenv.LocalEnvironment, loc loc.Locator, dir, op
 		}
 		defer reader.Close()
 
-		if err := dockerarchive.Untar(reader, dir, archive.DefaultOptions()); err != nil {
+		if err := archive.Untar(reader, dir, archive.DefaultOptions()); err != nil {
 			return trace.Wrap(err)
 		}
 
@@ -0,0 +1,51 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"github.com/gravitational/gravity/lib/baker"
+	"github.com/gravitational/gravity/lib/localenv"
+
+	"github.com/gravitational/trace"
+)
+
+// bake pre-installs the packages of the cluster image at the specified path
+// into this node, so a node image can be created from it
+func bake(env *localenv.LocalEnvironment, path, role, arch string) error {
+	imageEnv, err := localenv.NewImageEnvironment(path)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer imageEnv.Close()
+	locator := imageEnv.Manifest.Locator()
+	env.PrintStep("Baking %v", locator)
+	unpacked, err := baker.Bake(baker.Config{
+		Packages: imageEnv.Packages,
+		Apps:     imageEnv.Apps,
+		Package:  locator,
+		Role:     role,
+		Arch:     arch,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	for _, locator := range unpacked {
+		env.PrintStep("Unpacked %v", locator)
+	}
+	env.PrintStep("Done. Nodes booted from an image of this machine can join a cluster with gravity join")
+	return nil
+}
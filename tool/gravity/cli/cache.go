@@ -0,0 +1,133 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"github.com/gravitational/gravity/lib/localenv"
+	"github.com/gravitational/gravity/lib/pack"
+	statusapi "github.com/gravitational/gravity/lib/status"
+
+	"github.com/gravitational/trace"
+)
+
+// getPackagesCached returns the packages matching the repository filter and
+// the selector from the specified package service.
+// Packages of remote package services are cached locally for a short time
+// so that listing them repeatedly does not query the remote service every time.
+// If refresh is set, the packages are always queried and the cache is updated
+func getPackagesCached(env *localenv.LocalEnvironment, repositoryFilter, selector, opsCenterURL string, refresh bool) ([]pack.PackageEnvelope, error) {
+	if opsCenterURL == "" {
+		// local packages are fast to query
+		return getPackages(env, repositoryFilter, selector, opsCenterURL)
+	}
+	cache, err := env.MetadataCache()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	key := packagesCacheKey + opsCenterURL + "/" + repositoryFilter + "/" + selector
+	var envelopes []pack.PackageEnvelope
+	if !refresh {
+		err := cache.Get(key, &envelopes)
+		if err == nil {
+			return envelopes, nil
+		}
+		if !trace.IsNotFound(err) {
+			log.Warnf("Failed to read cached packages: %v.", trace.DebugReport(err))
+		}
+	}
+	envelopes, err = getPackages(env, repositoryFilter, selector, opsCenterURL)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := cache.Put(key, envelopes); err != nil {
+		log.Warnf("Failed to cache packages: %v.", trace.DebugReport(err))
+	}
+	return envelopes, nil
+}
+
+// getCachedStatus returns the cluster status cached by a recent invocation
+// of the status command
+func getCachedStatus(env *localenv.LocalEnvironment) (*statusapi.Status, error) {
+	cache, err := env.MetadataCache()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	status := statusapi.Empty()
+	if err := cache.Get(statusCacheKey, status); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return status, nil
+}
+
+// cacheStatus caches the cluster status for subsequent invocations of the
+// status command. The status of a cluster with active operations
+// is not cached to show the operation progress as it happens
+func cacheStatus(env *localenv.LocalEnvironment, status statusapi.Status) {
+	if status.Cluster == nil || len(status.Cluster.ActiveOperations) != 0 {
+		invalidateMetadataCache(env, statusCacheKey)
+		return
+	}
+	cache, err := env.MetadataCache()
+	if err == nil {
+		err = cache.Put(statusCacheKey, status)
+	}
+	if err != nil {
+		log.Warnf("Failed to cache cluster status: %v.", trace.DebugReport(err))
+	}
+}
+
+// invalidateMetadataCache removes the cached entries with keys
+// starting with the specified prefix
+func invalidateMetadataCache(env *localenv.LocalEnvironment, prefix string) {
+	cache, err := env.MetadataCache()
+	if err == nil {
+		err = cache.Invalidate(prefix)
+	}
+	if err != nil {
+		log.Warnf("Failed to invalidate cached %v: %v.", prefix, trace.DebugReport(err))
+	}
+}
+
+// isPackageChangeCommand returns true if the specified command
+// creates, updates or deletes packages
+func (g *Application) isPackageChangeCommand(cmd string) bool {
+	switch cmd {
+	case g.AppImportCmd.FullCommand(),
+		g.AppDeleteCmd.FullCommand(),
+		g.AppPullCmd.FullCommand(),
+		g.AppPushCmd.FullCommand(),
+		g.PackImportCmd.FullCommand(),
+		g.PackDeleteCmd.FullCommand(),
+		g.PackPinCmd.FullCommand(),
+		g.PackUnpinCmd.FullCommand(),
+		g.PackPushCmd.FullCommand(),
+		g.PackPullCmd.FullCommand(),
+		g.PackLabelsCmd.FullCommand(),
+		g.PackLifecycleCmd.FullCommand(),
+		g.UpdateUploadCmd.FullCommand(),
+		g.GarbageCollectCmd.FullCommand():
+		return true
+	}
+	return false
+}
+
+const (
+	// packagesCacheKey is the prefix of the keys of cached package lists
+	packagesCacheKey = "packages/"
+	// statusCacheKey is the key of the cached cluster status
+	statusCacheKey = "status"
+)
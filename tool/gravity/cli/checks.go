@@ -17,18 +17,29 @@ limitations under the License.
 package cli
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"os"
+	"text/tabwriter"
+	"time"
 
 	"github.com/gravitational/gravity/lib/checks"
+	libfsm "github.com/gravitational/gravity/lib/fsm"
 	"github.com/gravitational/gravity/lib/localenv"
 	"github.com/gravitational/gravity/lib/schema"
+	"github.com/gravitational/gravity/lib/state"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/systeminfo"
+
+	"github.com/dustin/go-humanize"
 
 	pb "github.com/gravitational/satellite/agent/proto/agentpb"
 	"github.com/gravitational/trace"
 )
 
-func checkManifest(env *localenv.LocalEnvironment, manifestPath, profileName string, autoFix bool) error {
+func checkManifest(env *localenv.LocalEnvironment, manifestPath, profileName string, autoFix, recommend bool) error {
 	data, err := ioutil.ReadFile(manifestPath)
 	if err != nil {
 		return trace.Wrap(err)
@@ -39,6 +50,18 @@ func checkManifest(env *localenv.LocalEnvironment, manifestPath, profileName str
 		return trace.Wrap(err)
 	}
 
+	if recommend {
+		if err := printRecommendation(env, *manifest); err != nil {
+			return trace.Wrap(err)
+		}
+		if profileName == "" {
+			return nil
+		}
+	}
+	if profileName == "" {
+		return trace.BadParameter("specify the profile to check with --profile")
+	}
+
 	result, err := checks.ValidateLocal(checks.LocalChecksRequest{
 		Manifest: *manifest,
 		Role:     profileName,
@@ -61,6 +84,153 @@ func checkManifest(env *localenv.LocalEnvironment, manifestPath, profileName str
 	return trace.NewAggregate(failedErr, fixableErr)
 }
 
+// printRecommendation displays the flavor and profile recommended
+// for the local node based on its hardware
+func printRecommendation(env *localenv.LocalEnvironment, manifest schema.Manifest) error {
+	info, err := systeminfo.New()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	recommendation := checks.Recommend(manifest, []storage.System{info})
+	env.Printf("%v", checks.FormatRecommendation(recommendation))
+	return nil
+}
+
+// checkDisks validates the disk layout of the local node and prints the
+// suggested layout along with the commands that fix it if requested
+func checkDisks(env *localenv.LocalEnvironment, fixPlan bool) error {
+	stateDir, err := state.GetStateDir()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	report, err := checks.ValidateDisks(checks.DiskLocations(stateDir))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 8, 1, '\t', 0)
+	fmt.Fprintf(w, "Location\tPath\tFilesystem\tFree\tIOPS\tRequired\n")
+	for _, status := range report.Locations {
+		iops := "-"
+		if status.MinIOPS != 0 {
+			iops = fmt.Sprint(status.IOPS)
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\n", status.Name, status.Path,
+			status.Filesystem.Filesystem.DirName, humanize.Bytes(status.Filesystem.FreeBytes()),
+			iops, status.Requirements())
+	}
+	w.Flush()
+
+	if fixPlan {
+		if plan := report.FixPlan(); plan != "" {
+			fmt.Printf("\nRun the following commands to fix the disk layout:\n\n%v", plan)
+		} else {
+			fmt.Printf("\nDisk layout does not need fixing.\n")
+		}
+	} else if len(report.Relocate) != 0 {
+		fmt.Printf("\nProvide --fix-plan flag to see the commands that fix the disk layout.\n")
+	}
+
+	if len(report.Failed) != 0 {
+		return trace.BadParameter("The following checks failed:\n%v",
+			checks.FormatFailedChecks(report.Failed))
+	}
+	return nil
+}
+
+// checkDNS validates cluster DNS resolution from the pod and the host
+// networks of the specified nodes and prints the results
+func checkDNS(env *localenv.LocalEnvironment, nodes, names []string, timeout time.Duration) error {
+	clusterEnv, err := env.NewClusterEnvironment()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if clusterEnv.Client == nil {
+		return trace.BadParameter("this operation can only be executed on one of the master nodes")
+	}
+	cluster, err := clusterEnv.Operator.GetLocalSite()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	report, err := checks.CheckDNS(context.TODO(), checks.DNSCheckConfig{
+		Client:    clusterEnv.Client,
+		Nodes:     nodes,
+		Overrides: cluster.DNSOverrides,
+		Names:     names,
+		Timeout:   timeout,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 8, 1, '\t', 0)
+	fmt.Fprintf(w, "Node\tNetwork\tCheck\tName\tResult\n")
+	for _, result := range report.Results {
+		status := "ok"
+		if result.Failed() {
+			status = result.Failure()
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\n", result.Node, result.Network(),
+			result.Kind, result.Name, status)
+	}
+	w.Flush()
+
+	if len(report.Failed()) != 0 {
+		return trace.BadParameter("The following DNS checks failed:\n%v",
+			report.FormatFailures())
+	}
+	return nil
+}
+
+// checkNetwork checks network connectivity between all pairs of cluster nodes
+// on the cluster ports using the agents running on the nodes
+func checkNetwork(env *localenv.LocalEnvironment, timeout time.Duration) error {
+	operator, err := env.SiteOperator()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	cluster, err := operator.GetLocalSite()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	creds, err := libfsm.GetClientCredentials()
+	if err != nil {
+		return trace.Wrap(err, "failed to load agent credentials.\n"+
+			"Make sure the agents are running by issuing `gravity agent deploy`")
+	}
+	runner := libfsm.NewAgentRunner(creds)
+	defer runner.Close()
+	matrix, err := checks.CheckNetwork(context.TODO(), checks.NetworkCheckConfig{
+		Servers: cluster.ClusterState.Servers,
+		Dialer:  checks.NewAgentDialer(runner),
+		Timeout: timeout,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	fmt.Print(matrix.String())
+	if len(matrix.Blocked()) != 0 {
+		return trace.BadParameter("Nodes failed to reach each other on the following paths:\n%v",
+			matrix.FormatBlocked())
+	}
+	return nil
+}
+
+// checkDial dials the specified addresses from this node
+// and outputs the results in JSON format
+func checkDial(addrs []string, timeout time.Duration) error {
+	results := checks.DialPorts(context.TODO(), addrs, timeout)
+	bytes, err := json.Marshal(results)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Println(string(bytes))
+	return nil
+}
+
 func printFailedChecks(failed []*pb.Probe) {
 	if len(failed) == 0 {
 		return
@@ -23,6 +23,7 @@ import (
 	"github.com/gravitational/gravity/lib/constants"
 	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
 
 	"github.com/gravitational/configure"
 	"gopkg.in/alecthomas/kingpin.v2"
@@ -62,12 +63,30 @@ type Application struct {
 	JoinCmd JoinCmd
 	// AutoJoinCmd uses cloud provider info to join existing cluster
 	AutoJoinCmd AutoJoinCmd
+	// BakerCmd pre-installs a cluster image into a node image
+	BakerCmd BakerCmd
 	// LeaveCmd removes the current node from the cluster
 	LeaveCmd LeaveCmd
 	// RemoveCmd removes the specified node from the cluster
 	RemoveCmd RemoveCmd
-	// PlanCmd displays current operation plan
+	// ReplaceCmd replaces a failed node preserving its identity
+	ReplaceCmd ReplaceCmd
+	// DevCmd combines local development environment commands
+	DevCmd DevCmd
+	// DevUpCmd starts a lightweight single-node development cluster
+	DevUpCmd DevUpCmd
+	// DevDownCmd removes the development cluster
+	DevDownCmd DevDownCmd
+	// PlanCmd combines operation plan commands
 	PlanCmd PlanCmd
+	// PlanDisplayCmd displays current operation plan
+	PlanDisplayCmd PlanDisplayCmd
+	// PlanExecuteCmd executes current operation plan
+	PlanExecuteCmd PlanExecuteCmd
+	// PlanApproveCmd approves the execution of an operation phase
+	PlanApproveCmd PlanApproveCmd
+	// PlanRequireApprovalCmd marks an operation phase as requiring approval
+	PlanRequireApprovalCmd PlanRequireApprovalCmd
 	// RollbackCmd rolls back the specified operation plan phase
 	RollbackCmd RollbackCmd
 	// UpdateCmd combines app update related commands
@@ -88,12 +107,44 @@ type Application struct {
 	StatusCmd StatusCmd
 	// StatusResetCmd resets the cluster to active state
 	StatusResetCmd StatusResetCmd
+	// ReleaseNodeCmd releases the node from quarantine
+	ReleaseNodeCmd ReleaseNodeCmd
+	// OperationCmd combines cluster operation commands
+	OperationCmd OperationCmd
+	// OperationLocksCmd displays resources held by active operations
+	OperationLocksCmd OperationLocksCmd
+	// OperationReleaseLocksCmd forcibly releases resources held by an operation
+	OperationReleaseLocksCmd OperationReleaseLocksCmd
+	// OperationLogsCmd displays the filtered operation log entries
+	OperationLogsCmd OperationLogsCmd
 	// BackupCmd launches app backup hook
 	BackupCmd BackupCmd
 	// RestoreCmd launches app restore hook
 	RestoreCmd RestoreCmd
-	// CheckCmd checks that the host satisfies app manifest requirements
+	// CheckCmd combines host environment checks
 	CheckCmd CheckCmd
+	// CheckManifestCmd checks that the host satisfies app manifest requirements
+	CheckManifestCmd CheckManifestCmd
+	// CheckDisksCmd checks the disk layout of the host
+	CheckDisksCmd CheckDisksCmd
+	// CheckDNSCmd checks cluster DNS resolution from cluster nodes
+	CheckDNSCmd CheckDNSCmd
+	// CheckNetworkCmd checks network connectivity between cluster nodes
+	CheckNetworkCmd CheckNetworkCmd
+	// CheckDialCmd dials the specified addresses from the local node
+	CheckDialCmd CheckDialCmd
+	// EtcdCmd combines etcd backup subcommands
+	EtcdCmd EtcdCmd
+	// EtcdBackupCmd takes an etcd backup
+	EtcdBackupCmd EtcdBackupCmd
+	// EtcdRestoreCmd restores etcd from a backup
+	EtcdRestoreCmd EtcdRestoreCmd
+	// EtcdListCmd lists etcd backups
+	EtcdListCmd EtcdListCmd
+	// EtcdScheduleCmd schedules periodic etcd backups
+	EtcdScheduleCmd EtcdScheduleCmd
+	// EtcdUnscheduleCmd disables periodic etcd backups
+	EtcdUnscheduleCmd EtcdUnscheduleCmd
 	// AppCmd combines subcommands for app service
 	AppCmd AppCmd
 	// AppInstallCmd installs an application from an application image
@@ -148,6 +199,8 @@ type Application struct {
 	OpsDisconnectCmd OpsDisconnectCmd
 	// OpsListCmd lists ops credentials
 	OpsListCmd OpsListCmd
+	// OpsMigrateCmd migrates the cluster to another Ops Center
+	OpsMigrateCmd OpsMigrateCmd
 	// OpsAgentCmd launches install agent
 	OpsAgentCmd OpsAgentCmd
 	// PackCmd combines subcommands for package service
@@ -162,6 +215,10 @@ type Application struct {
 	PackListCmd PackListCmd
 	// PackDeleteCmd deletes specified package
 	PackDeleteCmd PackDeleteCmd
+	// PackPinCmd protects specified package from deletion
+	PackPinCmd PackPinCmd
+	// PackUnpinCmd removes deletion protection from specified package
+	PackUnpinCmd PackUnpinCmd
 	// PackConfigureCmd configures package
 	PackConfigureCmd PackConfigureCmd
 	// PackCommandCmd launches package command
@@ -172,6 +229,18 @@ type Application struct {
 	PackPullCmd PackPullCmd
 	// PackLabelsCmd updates package labels
 	PackLabelsCmd PackLabelsCmd
+	// PackLifecycleCmd marks package versions as deprecated or end-of-life
+	PackLifecycleCmd PackLifecycleCmd
+	// PackDiffCmd compares files of two packages
+	PackDiffCmd PackDiffCmd
+	// PackAliasCmd creates or updates a package alias
+	PackAliasCmd PackAliasCmd
+	// PackUnaliasCmd deletes a package alias
+	PackUnaliasCmd PackUnaliasCmd
+	// PackDedupConfigCmd moves planet configuration shared between nodes into common packages
+	PackDedupConfigCmd PackDedupConfigCmd
+	// PackStatsCmd displays package store usage statistics
+	PackStatsCmd PackStatsCmd
 	// UserCmd combines user related subcommands
 	UserCmd UserCmd
 	// UserCreateCmd creates a new user
@@ -192,8 +261,14 @@ type Application struct {
 	APIKeyListCmd APIKeyListCmd
 	// APIKeyDeleteCmd deletes specified token
 	APIKeyDeleteCmd APIKeyDeleteCmd
-	// ReportCmd generates cluster debug report
+	// ReportCmd combines cluster diagnostics report subcommands
 	ReportCmd ReportCmd
+	// ReportGenerateCmd generates cluster debug report
+	ReportGenerateCmd ReportGenerateCmd
+	// ReportDiffCmd compares two cluster debug reports
+	ReportDiffCmd ReportDiffCmd
+	// ReportAnonymizeCmd anonymizes cluster debug report
+	ReportAnonymizeCmd ReportAnonymizeCmd
 	// SiteCmd combines cluster related subcommands
 	SiteCmd SiteCmd
 	// SiteListCmd lists all clusters
@@ -222,10 +297,16 @@ type Application struct {
 	RPCAgentInstallCmd RPCAgentInstallCmd
 	// RPCAgentRunCmd runs RPC agent
 	RPCAgentRunCmd RPCAgentRunCmd
+	// RPCAgentJoinCmd adds a batch of nodes to the cluster
+	RPCAgentJoinCmd RPCAgentJoinCmd
 	// SystemCmd combines system subcommands
 	SystemCmd SystemCmd
 	// SystemRotateCertsCmd renews cluster certificates on local node
 	SystemRotateCertsCmd SystemRotateCertsCmd
+	// SystemRepairKubeletCertsCmd renews expired kubelet certificates on local node
+	SystemRepairKubeletCertsCmd SystemRepairKubeletCertsCmd
+	// SystemRenewCertsCmd renews all certificates on local node
+	SystemRenewCertsCmd SystemRenewCertsCmd
 	// SystemExportCACmd exports cluster CA
 	SystemExportCACmd SystemExportCACmd
 	// SystemUninstallCmd uninstalls all gravity services from local node
@@ -240,6 +321,8 @@ type Application struct {
 	SystemHistoryCmd SystemHistoryCmd
 	// SystemStepDownCmd asks active gravity master to step down
 	SystemStepDownCmd SystemStepDownCmd
+	// SystemWatchdogCmd monitors and restarts critical planet services
+	SystemWatchdogCmd SystemWatchdogCmd
 	// SystemRollbackCmd rolls back last system update
 	SystemRollbackCmd SystemRollbackCmd
 	// SystemServiceCmd combines subcommands for systems services
@@ -278,6 +361,22 @@ type Application struct {
 	SystemGCPackageCmd SystemGCPackageCmd
 	// SystemGCRegistryCmd removes unused docker images
 	SystemGCRegistryCmd SystemGCRegistryCmd
+	// SystemOSUpdateCmd coordinates host OS updates with the cluster
+	SystemOSUpdateCmd SystemOSUpdateCmd
+	// SystemFirewallCmd combines host firewall related subcommands
+	SystemFirewallCmd SystemFirewallCmd
+	// SystemFirewallApplyCmd opens the ports required by the node
+	SystemFirewallApplyCmd SystemFirewallApplyCmd
+	// SystemFirewallRemoveCmd removes gravity firewall rules
+	SystemFirewallRemoveCmd SystemFirewallRemoveCmd
+	// SystemFirewallShowCmd displays the ports required by the node
+	SystemFirewallShowCmd SystemFirewallShowCmd
+	// SystemRollingRestartCmd restarts the runtime container on cluster nodes one by one
+	SystemRollingRestartCmd SystemRollingRestartCmd
+	// SystemMigrateStorageDriverCmd migrates docker storage driver on cluster nodes one by one
+	SystemMigrateStorageDriverCmd SystemMigrateStorageDriverCmd
+	// SystemDownloadBinaryCmd downloads the gravity binary matching the cluster version
+	SystemDownloadBinaryCmd SystemDownloadBinaryCmd
 	// GarbageCollectCmd prunes unused resources (package/journal files/docker images)
 	// in the cluster
 	GarbageCollectCmd GarbageCollectCmd
@@ -384,6 +483,16 @@ type InstallCmd struct {
 	DNSHosts *[]string
 	// DNSZones is a list of DNS zone overrides
 	DNSZones *[]string
+	// PackageCache is the directory with pre-seeded packages on every node
+	PackageCache *string
+	// Set sets application configuration parameters
+	Set *map[string]string
+	// EncryptionKey is the passphrase to decrypt the installer packages with
+	EncryptionKey *string
+	// EncryptionKeyCommand is the command that outputs the encryption key
+	EncryptionKeyCommand *string
+	// SSHHosts is the path to the file with the nodes to start agents on over SSH
+	SSHHosts *string
 }
 
 // JoinCmd joins to the installer or existing cluster
@@ -438,6 +547,18 @@ type AutoJoinCmd struct {
 	Mounts *configure.KeyVal
 }
 
+// BakerCmd pre-installs the packages of a cluster image into a node image
+// without forming a cluster
+type BakerCmd struct {
+	*kingpin.CmdClause
+	// Path is the path to the cluster image tarball or unpacked image
+	Path *string
+	// Role is the node profile to bake the image for
+	Role *string
+	// Arch is the architecture of the nodes to bake the image for
+	Arch *string
+}
+
 // LeaveCmd removes the current node from the cluster
 type LeaveCmd struct {
 	*kingpin.CmdClause
@@ -456,9 +577,59 @@ type RemoveCmd struct {
 	Force *bool
 	// Confirm suppresses confirmation prompt
 	Confirm *bool
+	// SkipVersionCheck suppresses version mismatch errors
+	SkipVersionCheck *bool
 }
 
-// PlanCmd displays operation plan
+// DevCmd combines local development environment commands
+type DevCmd struct {
+	*kingpin.CmdClause
+}
+
+// DevUpCmd starts a lightweight single-node cluster for
+// application development
+type DevUpCmd struct {
+	*kingpin.CmdClause
+	// Path is the path to the unpacked installer
+	Path *string
+	// AdvertiseAddr is the advertise address of this node
+	AdvertiseAddr *string
+	// Cluster is the optional cluster name
+	Cluster *string
+	// Flavor is the application flavor the node profile is selected from
+	Flavor *string
+	// Role is the node profile
+	Role *string
+	// ResourcesPath is the path to Kubernetes resources to create after install
+	ResourcesPath *string
+	// Set is a list of application configuration parameters
+	Set *map[string]string
+	// PackageCache is the directory with pre-seeded packages
+	PackageCache *string
+}
+
+// DevDownCmd removes the development cluster from this node
+type DevDownCmd struct {
+	*kingpin.CmdClause
+	// Confirm suppresses confirmation prompt
+	Confirm *bool
+}
+
+// ReplaceCmd replaces a failed node with new hardware
+// preserving the node identity
+type ReplaceCmd struct {
+	*kingpin.CmdClause
+	// Node is the node to replace
+	Node *string
+	// Cancel forgets the recorded identity of the node
+	Cancel *bool
+	// Confirm suppresses confirmation prompt
+	Confirm *bool
+	// SkipVersionCheck suppresses version mismatch errors
+	SkipVersionCheck *bool
+}
+
+// PlanCmd combines operation plan commands
 type PlanCmd struct {
 	*kingpin.CmdClause
 	// Init initializes the plan
@@ -471,6 +642,40 @@ type PlanCmd struct {
 	OperationID *string
 }
 
+// PlanDisplayCmd displays operation plan
+type PlanDisplayCmd struct {
+	*kingpin.CmdClause
+}
+
+// PlanExecuteCmd executes operation plan
+type PlanExecuteCmd struct {
+	*kingpin.CmdClause
+	// Phase is the ID of the phase to execute
+	Phase *string
+	// Until specifies the phase to stop the execution at
+	Until *string
+	// Force allows to force phase execution
+	Force *bool
+	// Timeout is plan execution timeout
+	Timeout *time.Duration
+	// SkipVersionCheck allows to override gravity version compatibility check
+	SkipVersionCheck *bool
+}
+
+// PlanApproveCmd approves the execution of an operation phase
+type PlanApproveCmd struct {
+	*kingpin.CmdClause
+	// Phase is the ID of the phase to approve
+	Phase *string
+}
+
+// PlanRequireApprovalCmd marks an operation phase as requiring approval
+type PlanRequireApprovalCmd struct {
+	*kingpin.CmdClause
+	// Phase is the ID of the phase that requires approval
+	Phase *string
+}
+
 // InstallPlanCmd combines subcommands for install plan
 type InstallPlanCmd struct {
 	*kingpin.CmdClause
@@ -527,6 +732,8 @@ type UpdateTriggerCmd struct {
 	App *string
 	// Manual starts operation in manual mode
 	Manual *bool
+	// SkipVersionCheck suppresses version mismatch errors
+	SkipVersionCheck *bool
 }
 
 // UpdateUploadCmd uploads new app version to local cluster
@@ -575,6 +782,14 @@ type UpgradeCmd struct {
 	Resume *bool
 	// SkipVersionCheck suppresses version mismatch errors
 	SkipVersionCheck *bool
+	// Unattended runs the upgrade to completion in the foreground
+	Unattended *bool
+	// Retries is the number of times an unattended upgrade retries the plan
+	Retries *int
+	// HealthTimeout is how long an unattended upgrade waits for the cluster to become healthy
+	HealthTimeout *time.Duration
+	// ForceRollback rolls an unattended upgrade back even if it succeeds
+	ForceRollback *bool
 }
 
 // StatusCmd displays cluster status
@@ -590,6 +805,14 @@ type StatusCmd struct {
 	Seconds *int
 	// Output is output format
 	Output *constants.Format
+	// Watch displays a live status view and highlights status transitions
+	Watch *bool
+	// NotifyWebhook is the URL to post status transitions to in watch mode
+	NotifyWebhook *string
+	// NotifyDesktop enables desktop notifications of status transitions in watch mode
+	NotifyDesktop *bool
+	// Refresh queries the cluster status instead of using the cached status
+	Refresh *bool
 }
 
 // StatusResetCmd resets cluster to active state
@@ -597,6 +820,54 @@ type StatusResetCmd struct {
 	*kingpin.CmdClause
 }
 
+// ReleaseNodeCmd releases the node quarantined for flapping
+type ReleaseNodeCmd struct {
+	*kingpin.CmdClause
+	// Addr is the advertise address of the node to release
+	Addr *string
+}
+
+// OperationCmd combines cluster operation commands
+type OperationCmd struct {
+	*kingpin.CmdClause
+}
+
+// OperationLocksCmd displays the resources held by active operations
+type OperationLocksCmd struct {
+	*kingpin.CmdClause
+	// Output is the output format
+	Output *constants.Format
+}
+
+// OperationReleaseLocksCmd forcibly releases the resources held by an operation
+type OperationReleaseLocksCmd struct {
+	*kingpin.CmdClause
+	// OperationID is the ID of the operation holding the locks
+	OperationID *string
+	// Reason is the reason for the release recorded in the audit trail
+	Reason *string
+	// Force releases the locks even if the operation might still be running
+	Force *bool
+	// Confirm suppresses the confirmation prompt
+	Confirm *bool
+}
+
+// OperationLogsCmd displays the operation log entries
+// selected by component, phase and severity
+type OperationLogsCmd struct {
+	*kingpin.CmdClause
+	// OperationID is the ID of the operation, defaults to the last operation
+	OperationID *string
+	// Component selects the entries of the specified component
+	Component *string
+	// Phase selects the entries of the specified phase and its subphases
+	Phase *string
+	// Level is the minimum severity of the displayed entries
+	Level *string
+	// Output is the output format
+	Output *constants.Format
+}
+
 // BackupCmd launches app backup hook
 type BackupCmd struct {
 	*kingpin.CmdClause
@@ -608,6 +879,58 @@ type BackupCmd struct {
 	Follow *bool
 }
 
+// EtcdCmd combines etcd backup subcommands
+type EtcdCmd struct {
+	*kingpin.CmdClause
+}
+
+// EtcdBackupCmd takes an etcd backup
+type EtcdBackupCmd struct {
+	*kingpin.CmdClause
+	// Location is where the backup is stored: directory or s3://bucket/prefix
+	Location *string
+	// Retain is the number of most recent backups to keep
+	Retain *int
+	// Interval optionally takes backups periodically
+	Interval *time.Duration
+}
+
+// EtcdRestoreCmd restores etcd from a backup
+type EtcdRestoreCmd struct {
+	*kingpin.CmdClause
+	// Location is where the backup is stored: directory or s3://bucket/prefix
+	Location *string
+	// Name is the name of the backup to restore
+	Name *string
+	// VerifyOnly validates the backup without restoring
+	VerifyOnly *bool
+	// Confirmed suppresses the confirmation prompt
+	Confirmed *bool
+}
+
+// EtcdListCmd lists etcd backups
+type EtcdListCmd struct {
+	*kingpin.CmdClause
+	// Location is where the backups are stored: directory or s3://bucket/prefix
+	Location *string
+}
+
+// EtcdScheduleCmd schedules periodic etcd backups
+type EtcdScheduleCmd struct {
+	*kingpin.CmdClause
+	// Location is where the backups are stored: directory or s3://bucket/prefix
+	Location *string
+	// Retain is the number of most recent backups to keep
+	Retain *int
+	// Interval is the interval between backups
+	Interval *time.Duration
+}
+
+// EtcdUnscheduleCmd disables periodic etcd backups
+type EtcdUnscheduleCmd struct {
+	*kingpin.CmdClause
+}
+
 // RestoreCmd launches app restore hook
 type RestoreCmd struct {
 	*kingpin.CmdClause
@@ -619,8 +942,16 @@ type RestoreCmd struct {
 	Follow *bool
 }
 
-// CheckCmd checks that the host satisfies app manifest requirements
+// CheckCmd combines host environment checks
 type CheckCmd struct {
+	*kingpin.CmdClause
+	// Recommend displays the flavor and profile recommended
+	// for this node based on its hardware
+	Recommend *bool
+}
+
+// CheckManifestCmd checks that the host satisfies app manifest requirements
+type CheckManifestCmd struct {
 	*kingpin.CmdClause
 	// ManifestFile is path to app manifest file
 	ManifestFile *string
@@ -630,6 +961,41 @@ type CheckCmd struct {
 	AutoFix *bool
 }
 
+// CheckDisksCmd checks the disk layout of the host against
+// the storage requirements of the cluster data
+type CheckDisksCmd struct {
+	*kingpin.CmdClause
+	// FixPlan outputs the commands that fix the disk layout
+	FixPlan *bool
+}
+
+// CheckDNSCmd checks cluster DNS resolution paths from cluster nodes
+type CheckDNSCmd struct {
+	*kingpin.CmdClause
+	// Nodes lists the nodes to run the check on
+	Nodes *[]string
+	// Names lists additional stub domain or upstream names to resolve
+	Names *[]string
+	// Timeout limits the time to wait for the check on a node
+	Timeout *time.Duration
+}
+
+// CheckNetworkCmd checks network connectivity between all pairs of cluster nodes
+type CheckNetworkCmd struct {
+	*kingpin.CmdClause
+	// Timeout limits the time to wait for the check on a node
+	Timeout *time.Duration
+}
+
+// CheckDialCmd dials the specified TCP addresses from the local node
+type CheckDialCmd struct {
+	*kingpin.CmdClause
+	// Addrs lists the addresses to dial
+	Addrs *[]string
+	// Timeout limits the time to establish a connection
+	Timeout *time.Duration
+}
+
 // AppCmd combines subcommands for app service
 type AppCmd struct {
 	*kingpin.CmdClause
@@ -780,6 +1146,8 @@ type AppImportCmd struct {
 	SetDeps *loc.Locators
 	// Parallel defines the number of tasks to execute concurrently
 	Parallel *int
+	// RateLimit limits the rate of the application upload
+	RateLimit *utils.TransferRate
 }
 
 // AppExportCmd exports specified app into registry
@@ -907,6 +1275,8 @@ type OpsConnectCmd struct {
 	Username *string
 	// Password is agent password
 	Password *string
+	// RateLimit limits the rate of package transfers to and from the ops service
+	RateLimit *utils.TransferRate
 }
 
 // OpsDisconnectCmd logs out of specified cluster
@@ -921,6 +1291,17 @@ type OpsListCmd struct {
 	*kingpin.CmdClause
 }
 
+// OpsMigrateCmd migrates the cluster to another Ops Center
+type OpsMigrateCmd struct {
+	*kingpin.CmdClause
+	// Path is the path to the trusted cluster resource of the new Ops Center
+	Path *string
+	// From is the name of the Ops Center to migrate from
+	From *string
+	// Confirm suppresses confirmation prompt
+	Confirm *bool
+}
+
 // OpsAgentCmd launches install agent
 type OpsAgentCmd struct {
 	*kingpin.CmdClause
@@ -962,6 +1343,10 @@ type PackImportCmd struct {
 	Locator *loc.Locator
 	// Labels is labels to update pulled package with
 	Labels *configure.KeyVal
+	// Force replaces the package if it already exists
+	Force *bool
+	// Arch imports the package as the variant built for the specified architecture
+	Arch *string
 }
 
 // PackUnpackCmd unpacks specified package
@@ -993,8 +1378,12 @@ type PackListCmd struct {
 	*kingpin.CmdClause
 	// Repository is repository to list packages from
 	Repository *string
+	// Selector is the label selector the listed packages should match
+	Selector *string
 	// OpsCenterURL is pack service URL
 	OpsCenterURL *string
+	// Refresh queries the packages instead of using the cached list
+	Refresh *bool
 }
 
 // PackDeleteCmd deletes specified package
@@ -1008,6 +1397,24 @@ type PackDeleteCmd struct {
 	OpsCenterURL *string
 }
 
+// PackPinCmd protects specified package from deletion
+type PackPinCmd struct {
+	*kingpin.CmdClause
+	// Locator is package locator
+	Locator *loc.Locator
+	// OpsCenterURL is pack service URL
+	OpsCenterURL *string
+}
+
+// PackUnpinCmd removes deletion protection from specified package
+type PackUnpinCmd struct {
+	*kingpin.CmdClause
+	// Locator is package locator
+	Locator *loc.Locator
+	// OpsCenterURL is pack service URL
+	OpsCenterURL *string
+}
+
 // PackConfigureCmd configures package
 type PackConfigureCmd struct {
 	*kingpin.CmdClause
@@ -1039,6 +1446,13 @@ type PackPushCmd struct {
 	Package *loc.Locator
 	// OpsCenterURL is pack service URL to push into
 	OpsCenterURL *string
+	// To is the URL of the cluster to push the package to
+	To *string
+	// From is the optional URL of the cluster to push the package from.
+	// Local packages are pushed if unspecified
+	From *string
+	// TransferFlags specifies the remote cluster credentials
+	TransferFlags
 }
 
 // PackPullCmd pulls package from specified cluster
@@ -1048,10 +1462,29 @@ type PackPullCmd struct {
 	Package *loc.Locator
 	// OpsCenterURL is pack service URL to pull from
 	OpsCenterURL *string
+	// From is the URL of the cluster to pull the package from
+	From *string
+	// To is the optional URL of the cluster to pull the package into.
+	// The package is pulled into local packages if unspecified
+	To *string
 	// Labels is labels to update pulled package with
 	Labels *configure.KeyVal
 	// Force overwrites existing package
 	Force *bool
+	// TransferFlags specifies the remote cluster credentials
+	TransferFlags
+}
+
+// TransferFlags specifies credentials for transferring packages
+// between clusters
+type TransferFlags struct {
+	// CertFile is the path to the client certificate used to authenticate
+	// with remote clusters
+	CertFile *string
+	// KeyFile is the path to the client certificate private key
+	KeyFile *string
+	// CAFile is the path to the CA certificate used to verify remote clusters
+	CAFile *string
 }
 
 // PackLabelsCmd updates package labels
@@ -1067,6 +1500,69 @@ type PackLabelsCmd struct {
 	Remove *[]string
 }
 
+// PackLifecycleCmd marks package versions as deprecated or end-of-life
+type PackLifecycleCmd struct {
+	*kingpin.CmdClause
+	// Package is package name
+	Package *loc.Locator
+	// Lifecycle is the lifecycle status to set
+	Lifecycle *string
+	// OpsCenterURL is pack service URL
+	OpsCenterURL *string
+}
+
+// PackDiffCmd compares files of two packages
+type PackDiffCmd struct {
+	*kingpin.CmdClause
+	// From is the package to compare against
+	From *loc.Locator
+	// To is the package to compare
+	To *loc.Locator
+	// OpsCenterURL is pack service URL
+	OpsCenterURL *string
+	// Output is output format
+	Output *constants.Format
+}
+
+// PackAliasCmd creates or updates a package alias
+type PackAliasCmd struct {
+	*kingpin.CmdClause
+	// Alias is the alias locator, e.g. gravitational.io/runtime:@current
+	Alias *loc.Locator
+	// Package is the package the alias should point to
+	Package *loc.Locator
+	// OpsCenterURL is pack service URL
+	OpsCenterURL *string
+}
+
+// PackUnaliasCmd deletes a package alias
+type PackUnaliasCmd struct {
+	*kingpin.CmdClause
+	// Alias is the alias locator
+	Alias *loc.Locator
+	// OpsCenterURL is pack service URL
+	OpsCenterURL *string
+}
+
+// PackDedupConfigCmd moves planet configuration shared between nodes
+// into common packages
+type PackDedupConfigCmd struct {
+	*kingpin.CmdClause
+	// OpsCenterURL is pack service URL
+	OpsCenterURL *string
+	// DryRun displays the packages to be converted
+	DryRun *bool
+}
+
+// PackStatsCmd displays package store usage statistics
+type PackStatsCmd struct {
+	*kingpin.CmdClause
+	// OpsCenterURL is the URL of the cluster to query
+	OpsCenterURL *string
+	// Output is output format
+	Output *constants.Format
+}
+
 // UserCmd combines user related subcommands
 type UserCmd struct {
 	*kingpin.CmdClause
@@ -1153,11 +1649,37 @@ type APIKeyDeleteCmd struct {
 	OpsCenterURL *string
 }
 
-// ReportCmd generates cluster debug report
+// ReportCmd combines cluster diagnostics report subcommands
 type ReportCmd struct {
 	*kingpin.CmdClause
+}
+
+// ReportGenerateCmd generates cluster debug report
+type ReportGenerateCmd struct {
+	*kingpin.CmdClause
 	// FilePath is the report tarball path
 	FilePath *string
+	// Anonymize replaces hostnames, IP addresses and cluster names
+	// in the report with pseudonyms
+	Anonymize *bool
+}
+
+// ReportDiffCmd compares two cluster debug reports
+type ReportDiffCmd struct {
+	*kingpin.CmdClause
+	// Before is the path of the earlier report
+	Before *string
+	// After is the path of the later report
+	After *string
+}
+
+// ReportAnonymizeCmd anonymizes cluster debug report
+type ReportAnonymizeCmd struct {
+	*kingpin.CmdClause
+	// Path is the path of the report to anonymize
+	Path *string
+	// FilePath is the anonymized report tarball path
+	FilePath *string
 }
 
 // SiteCmd combines cluster related subcommands
@@ -1252,6 +1774,16 @@ type RPCAgentRunCmd struct {
 	Args *[]string
 }
 
+// RPCAgentJoinCmd adds a batch of nodes to the cluster within
+// a single expand operation
+type RPCAgentJoinCmd struct {
+	*kingpin.CmdClause
+	// Batch is the path to the file describing the batch of nodes
+	Batch *string
+	// Timeout is how long to wait for all nodes in the batch to join
+	Timeout *time.Duration
+}
+
 // SystemCmd combines system subcommands
 type SystemCmd struct {
 	*kingpin.CmdClause
@@ -1268,6 +1800,30 @@ type SystemRotateCertsCmd struct {
 	CAPath *string
 }
 
+// SystemRepairKubeletCertsCmd renews expired kubelet certificates on local node
+type SystemRepairKubeletCertsCmd struct {
+	*kingpin.CmdClause
+	// ClusterName is local cluster name
+	ClusterName *string
+	// ValidFor is validity period for new certificates
+	ValidFor *time.Duration
+	// CAPath is CA to use
+	CAPath *string
+}
+
+// SystemRenewCertsCmd renews all certificates on local node
+type SystemRenewCertsCmd struct {
+	*kingpin.CmdClause
+	// ClusterName is local cluster name
+	ClusterName *string
+	// ValidFor is validity period for new certificates
+	ValidFor *time.Duration
+	// CAPath is CA to use
+	CAPath *string
+	// Offline means the cluster is not reachable
+	Offline *bool
+}
+
 // SystemExportCACmd exports cluster CA
 type SystemExportCACmd struct {
 	*kingpin.CmdClause
@@ -1327,6 +1883,22 @@ type SystemStepDownCmd struct {
 	*kingpin.CmdClause
 }
 
+// SystemWatchdogCmd monitors and restarts critical planet services
+type SystemWatchdogCmd struct {
+	*kingpin.CmdClause
+	// Services lists the planet services to monitor
+	Services *[]string
+	// Interval is how often the services are checked
+	Interval *time.Duration
+	// Window is the time window service restarts are counted in
+	Window *time.Duration
+	// MaxRestarts is the number of restarts within the window after
+	// which the cluster is marked degraded
+	MaxRestarts *int
+	// NotifyWebhook is an optional URL to post service restarts to
+	NotifyWebhook *string
+}
+
 // SystemRollbackCmd rolls back last system update
 type SystemRollbackCmd struct {
 	*kingpin.CmdClause
@@ -1490,6 +2062,101 @@ type SystemGCRegistryCmd struct {
 	DryRun *bool
 }
 
+// SystemFirewallCmd combines host firewall related subcommands
+type SystemFirewallCmd struct {
+	*kingpin.CmdClause
+}
+
+// SystemFirewallApplyCmd opens the ports required by the node
+type SystemFirewallApplyCmd struct {
+	*kingpin.CmdClause
+	// Backend optionally forces the firewall backend
+	Backend *string
+}
+
+// SystemFirewallRemoveCmd removes gravity firewall rules
+type SystemFirewallRemoveCmd struct {
+	*kingpin.CmdClause
+	// Backend optionally forces the firewall backend
+	Backend *string
+}
+
+// SystemFirewallShowCmd displays the ports required by the node
+type SystemFirewallShowCmd struct {
+	*kingpin.CmdClause
+}
+
+// SystemOSUpdateCmd coordinates host OS updates with the cluster
+type SystemOSUpdateCmd struct {
+	*kingpin.CmdClause
+	// Hook is the shell command that updates a node
+	Hook *string
+	// Nodes optionally lists the nodes to update
+	Nodes *[]string
+	// Reboot specifies whether the hook reboots the node
+	Reboot *bool
+	// HookTimeout limits the time the hook is allowed to run
+	HookTimeout *time.Duration
+	// HealthTimeout limits the time to wait for a node to become healthy
+	HealthTimeout *time.Duration
+	// SkipVersionCheck suppresses version mismatch errors
+	SkipVersionCheck *bool
+}
+
+// SystemRollingRestartCmd restarts the runtime container, or a single
+// service inside it, on cluster nodes one node at a time
+type SystemRollingRestartCmd struct {
+	*kingpin.CmdClause
+	// Unit optionally specifies the service inside the runtime container to restart
+	Unit *string
+	// Phase is the specific phase to run
+	Phase *string
+	// PhaseTimeout is the phase execution timeout
+	PhaseTimeout *time.Duration
+	// Resume is whether to resume a failed rolling restart
+	Resume *bool
+	// Manual is whether the operation is not executed automatically
+	Manual *bool
+	// Force forces phase execution
+	Force *bool
+	// HealthTimeout limits the time to wait for the cluster to become healthy
+	HealthTimeout *time.Duration
+	// SkipVersionCheck suppresses version mismatch errors
+	SkipVersionCheck *bool
+}
+
+// SystemMigrateStorageDriverCmd migrates docker storage driver
+// from devicemapper to overlay2 on cluster nodes one node at a time
+type SystemMigrateStorageDriverCmd struct {
+	*kingpin.CmdClause
+	// Phase is the specific phase to run
+	Phase *string
+	// PhaseTimeout is the phase execution timeout
+	PhaseTimeout *time.Duration
+	// Resume is whether to resume a failed migration
+	Resume *bool
+	// Manual is whether the operation is not executed automatically
+	Manual *bool
+	// Force forces phase execution
+	Force *bool
+	// Confirm suppresses the confirmation prompt
+	Confirm *bool
+	// HealthTimeout limits the time to wait for the cluster to become healthy
+	HealthTimeout *time.Duration
+	// SkipVersionCheck suppresses version mismatch errors
+	SkipVersionCheck *bool
+}
+
+// SystemDownloadBinaryCmd downloads the gravity binary
+// matching the cluster version from the cluster
+type SystemDownloadBinaryCmd struct {
+	*kingpin.CmdClause
+	// Version is the version of the binary to download
+	Version *string
+	// Path is the path to save the binary to
+	Path *string
+}
+
 // GarbageCollectCmd prunes unused cluster resources
 type GarbageCollectCmd struct {
 	*kingpin.CmdClause
@@ -1506,6 +2173,8 @@ type GarbageCollectCmd struct {
 	Confirmed *bool
 	// Force forces phase execution
 	Force *bool
+	// SkipVersionCheck suppresses version mismatch errors
+	SkipVersionCheck *bool
 }
 
 // GarbageCollectPlanCmd displays the plan of the garbage collection operation
@@ -1578,10 +2247,12 @@ type ResourceRemoveCmd struct {
 	Kind *string
 	// Name is resource name
 	Name *string
-	// Force suppresses not found errors
+	// Force suppresses not found errors and removes resources with dependents
 	Force *bool
 	// User is resource owner
 	User *string
+	// DryRun only reports objects depending on the resource
+	DryRun *bool
 }
 
 // ResourceGetCmd shows specified resource
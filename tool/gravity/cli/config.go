@@ -21,6 +21,7 @@ import (
 	"io/ioutil"
 	"net"
 	"os"
+	"path/filepath"
 
 	"github.com/gravitational/gravity/lib/constants"
 	"github.com/gravitational/gravity/lib/defaults"
@@ -65,6 +66,8 @@ type InstallConfig struct {
 	SiteDomain string
 	// Flavor is the Flavor name to install
 	Flavor string
+	// Parameters maps application configuration parameters to values
+	Parameters map[string]string
 	// Role is this node Role
 	Role string
 	// ResourcesPath is the additional Kubernetes resources to create
@@ -107,6 +110,17 @@ type InstallConfig struct {
 	NodeTags []string
 	// NewProcess is used to launch gravity API server process
 	NewProcess process.NewGravityProcess
+	// PackageCache is the directory with pre-seeded packages on every node
+	PackageCache string
+	// Lightweight installs a minimal single-node development cluster
+	Lightweight bool
+	// EncryptionKey is the passphrase to decrypt the installer packages with
+	EncryptionKey string
+	// EncryptionKeyCommand is the shell command that outputs the encryption key
+	EncryptionKeyCommand string
+	// SSHHostsPath is the path to the file with the nodes the installer
+	// connects to over SSH to start agents on
+	SSHHostsPath string
 }
 
 // NewInstallConfig creates install config from the passed CLI args and flags
@@ -130,6 +144,7 @@ func NewInstallConfig(g *Application) InstallConfig {
 		SiteDomain:    *g.InstallCmd.Cluster,
 		AppPackage:    *g.InstallCmd.App,
 		Flavor:        *g.InstallCmd.Flavor,
+		Parameters:    *g.InstallCmd.Set,
 		Role:          *g.InstallCmd.Role,
 		ResourcesPath: *g.InstallCmd.ResourcesPath,
 		SystemDevice:  *g.InstallCmd.SystemDevice,
@@ -144,11 +159,39 @@ func NewInstallConfig(g *Application) InstallConfig {
 			StorageDriver: g.InstallCmd.DockerStorageDriver.value,
 			Args:          *g.InstallCmd.DockerArgs,
 		},
-		DNSConfig:  g.InstallCmd.DNSConfig(),
-		Manual:     *g.InstallCmd.Manual,
-		ServiceUID: *g.InstallCmd.ServiceUID,
-		ServiceGID: *g.InstallCmd.ServiceGID,
-		NodeTags:   *g.InstallCmd.GCENodeTags,
+		DNSConfig:            g.InstallCmd.DNSConfig(),
+		Manual:               *g.InstallCmd.Manual,
+		ServiceUID:           *g.InstallCmd.ServiceUID,
+		ServiceGID:           *g.InstallCmd.ServiceGID,
+		NodeTags:             *g.InstallCmd.GCENodeTags,
+		PackageCache:         *g.InstallCmd.PackageCache,
+		EncryptionKey:        *g.InstallCmd.EncryptionKey,
+		EncryptionKeyCommand: *g.InstallCmd.EncryptionKeyCommand,
+		SSHHostsPath:         *g.InstallCmd.SSHHosts,
+	}
+}
+
+// NewDevInstallConfig creates the config for a lightweight single-node
+// development cluster from the passed CLI args and flags
+func NewDevInstallConfig(g *Application) InstallConfig {
+	return InstallConfig{
+		Mode:          constants.InstallModeCLI,
+		Insecure:      *g.Insecure,
+		ReadStateDir:  *g.DevUpCmd.Path,
+		UserLogFile:   *g.UserLogFile,
+		SystemLogFile: *g.SystemLogFile,
+		AdvertiseAddr: *g.DevUpCmd.AdvertiseAddr,
+		SiteDomain:    *g.DevUpCmd.Cluster,
+		Flavor:        *g.DevUpCmd.Flavor,
+		Parameters:    *g.DevUpCmd.Set,
+		Role:          *g.DevUpCmd.Role,
+		ResourcesPath: *g.DevUpCmd.ResourcesPath,
+		PodCIDR:       defaults.PodSubnet,
+		ServiceCIDR:   defaults.ServiceSubnet,
+		VxlanPort:     defaults.VxlanPort,
+		DNSConfig:     storage.DefaultDNSConfig,
+		PackageCache:  *g.DevUpCmd.PackageCache,
+		Lightweight:   true,
 	}
 }
 
@@ -198,6 +241,10 @@ func (i *InstallConfig) CheckAndSetDefaults() (err error) {
 	if err := i.validateDNSConfig(); err != nil {
 		return trace.Wrap(err)
 	}
+	if i.SSHHostsPath != "" && i.Mode != constants.InstallModeCLI {
+		return trace.BadParameter("agents can be started over SSH only in %q install mode",
+			constants.InstallModeCLI)
+	}
 	i.ServiceUser = *serviceUser
 	if i.NewProcess == nil {
 		i.NewProcess = process.NewProcess
@@ -249,6 +296,18 @@ func (i *InstallConfig) GetAppPackage() (*loc.Locator, error) {
 	return locator, nil
 }
 
+// GetSSHHosts returns the nodes to start agents on over SSH
+func (i *InstallConfig) GetSSHHosts() (*install.SSHHosts, error) {
+	if i.SSHHostsPath == "" {
+		return nil, trace.NotFound("no SSH hosts provided")
+	}
+	hosts, err := install.ReadSSHHosts(i.SSHHostsPath)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return hosts, nil
+}
+
 // GetResouces returns additional Kubernetes resources
 func (i *InstallConfig) GetResources() ([]byte, error) {
 	if i.ResourcesPath == "" {
@@ -261,6 +320,19 @@ func (i *InstallConfig) GetResources() ([]byte, error) {
 	return resources, nil
 }
 
+// GetLicense returns the cluster license, either the one specified
+// explicitly or the one embedded into the installer
+func (i *InstallConfig) GetLicense() (string, error) {
+	if i.License != "" {
+		return i.License, nil
+	}
+	license, err := utils.ReadPath(filepath.Join(i.ReadStateDir, defaults.LicenseFileName))
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	return string(license), nil
+}
+
 // getDNSOverrides converts DNS overrides specified on CLI to the storage format
 func (i *InstallConfig) getDNSOverrides() (*storage.DNSOverrides, error) {
 	overrides := &storage.DNSOverrides{
@@ -302,6 +374,10 @@ func (i *InstallConfig) ToInstallerConfig(env *localenv.LocalEnvironment) (*inst
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+	license, err := i.GetLicense()
+	if err != nil && !trace.IsNotFound(err) {
+		return nil, trace.Wrap(err)
+	}
 	ctx, cancel := context.WithCancel(context.Background())
 	return &install.Config{
 		Context:       ctx,
@@ -309,6 +385,7 @@ func (i *InstallConfig) ToInstallerConfig(env *localenv.LocalEnvironment) (*inst
 		EventsC:       make(chan install.Event, 100),
 		AdvertiseAddr: advertiseAddr,
 		Resources:     resources,
+		License:       license,
 		AppPackage:    appPackage,
 		LocalPackages: env.Packages,
 		LocalApps:     env.Apps,
@@ -322,6 +399,7 @@ func (i *InstallConfig) ToInstallerConfig(env *localenv.LocalEnvironment) (*inst
 		Token:         i.InstallToken,
 		CloudProvider: i.CloudProvider,
 		Flavor:        i.Flavor,
+		Parameters:    i.Parameters,
 		Role:          i.Role,
 		SystemDevice:  i.SystemDevice,
 		DockerDevice:  i.DockerDevice,
@@ -338,6 +416,8 @@ func (i *InstallConfig) ToInstallerConfig(env *localenv.LocalEnvironment) (*inst
 		ServiceUser:   i.ServiceUser,
 		GCENodeTags:   i.NodeTags,
 		NewProcess:    i.NewProcess,
+		PackageCache:  i.PackageCache,
+		Lightweight:   i.Lightweight,
 	}, nil
 }
 
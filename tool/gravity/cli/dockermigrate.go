@@ -0,0 +1,233 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"context"
+	"time"
+
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/dockermigrate"
+	libfsm "github.com/gravitational/gravity/lib/fsm"
+	"github.com/gravitational/gravity/lib/localenv"
+	"github.com/gravitational/gravity/lib/ops"
+
+	"github.com/gravitational/trace"
+)
+
+func migrateStorageDriver(env *localenv.LocalEnvironment, healthTimeout time.Duration, manual, confirmed bool) error {
+	if !confirmed {
+		err := enforceConfirmation("Migrating the storage driver removes all docker images and containers " +
+			"on every node which then have to be pulled from the cluster registry again. Please confirm")
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
+
+	migrator, err := newDockerMigrator(env, healthTimeout)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	ctx := context.TODO()
+	if !manual {
+		err = migrator.Run(ctx, false)
+		return trace.Wrap(err)
+	}
+
+	err = migrator.Create(ctx)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	env.Println(`
+The storage driver migration operation has been created in manual mode.
+
+To view the operation plan, run:
+
+$ gravity plan
+
+To perform the migration, execute each phase in the order it appears in
+the plan by running:
+
+$ sudo gravity system migrate-storage-driver --phase=<phase-id>
+
+To resume automatic migration from any point, run:
+
+$ sudo gravity system migrate-storage-driver --resume`)
+	return nil
+}
+
+func newDockerMigrator(env *localenv.LocalEnvironment, healthTimeout time.Duration) (*dockermigrate.Migrator, error) {
+	operator, err := env.SiteOperator()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	cluster, err := operator.GetLocalSite()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	teleportClient, err := env.TeleportClient(constants.Localhost)
+	if err != nil {
+		return nil, trace.Wrap(err, "failed to create a teleport client")
+	}
+
+	proxy, err := teleportClient.ConnectToProxy(context.TODO())
+	if err != nil {
+		return nil, trace.Wrap(err, "failed to connect to teleport proxy")
+	}
+
+	key, err := operator.CreateClusterDockerMigrationOperation(
+		ops.CreateClusterDockerMigrationOperationRequest{
+			AccountID:   cluster.AccountID,
+			ClusterName: cluster.Domain,
+		},
+	)
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil, trace.NotImplemented(
+				"cluster operator does not implement the API required for docker storage driver migration. " +
+					"Please make sure you're running the command on a compatible cluster.")
+		}
+		return nil, trace.Wrap(err)
+	}
+
+	defer func() {
+		r := recover()
+		triggered := err == nil && r == nil
+		if !triggered {
+			if errDelete := operator.DeleteSiteOperation(*key); errDelete != nil {
+				log.Warnf("Failed to clean up storage driver migration operation %v: %v.",
+					key, trace.DebugReport(errDelete))
+			}
+		}
+		if r != nil {
+			panic(r)
+		}
+	}()
+
+	operation, err := operator.GetSiteOperation(*key)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	runtimePackage, err := findAnyRuntimePackage(env.Packages)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	clusterEnv, err := env.NewClusterEnvironment()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	if clusterEnv.Client == nil {
+		return nil, trace.BadParameter("this operation can only be executed on one of the master nodes")
+	}
+
+	req := deployAgentsRequest{
+		clusterState: cluster.ClusterState,
+		clusterName:  cluster.Domain,
+		clusterEnv:   clusterEnv,
+		proxy:        proxy,
+	}
+	creds, err := deployAgents(context.TODO(), env, req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	migrator, err := dockermigrate.New(dockermigrate.Config{
+		Operator:       operator,
+		Operation:      operation,
+		Servers:        cluster.ClusterState.Servers,
+		Packages:       env.Packages,
+		RuntimePackage: runtimePackage,
+		Backend:        clusterEnv.Backend,
+		Client:         clusterEnv.Client,
+		Runner:         libfsm.NewAgentRunner(creds),
+		HealthTimeout:  healthTimeout,
+		Emitter:        env,
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return migrator, nil
+}
+
+func migrateStorageDriverPhase(env *localenv.LocalEnvironment, phase string, phaseTimeout, healthTimeout time.Duration, force bool) error {
+	operator, err := env.SiteOperator()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	cluster, err := operator.GetLocalSite()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	operation, _, err := ops.GetLastOperation(cluster.Key(), operator)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if operation.Type != ops.OperationDockerMigrate {
+		return trace.BadParameter("last operation is not a storage driver migration: %v", operation)
+	}
+
+	runtimePackage, err := findAnyRuntimePackage(env.Packages)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	creds, err := libfsm.GetClientCredentials()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	config := dockermigrate.Config{
+		Operator:       operator,
+		Operation:      operation,
+		Servers:        cluster.ClusterState.Servers,
+		Packages:       env.Packages,
+		RuntimePackage: runtimePackage,
+		Runner:         libfsm.NewAgentRunner(creds),
+		HealthTimeout:  healthTimeout,
+		Emitter:        env,
+	}
+	// cluster services are only available on master nodes
+	// and are required by the phases executed there
+	server, err := findLocalServer(*cluster)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if server.IsMaster() {
+		clusterEnv, err := env.NewClusterEnvironment()
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		config.Backend = clusterEnv.Backend
+		config.Client = clusterEnv.Client
+	}
+
+	migrator, err := dockermigrate.New(config)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	err = migrator.RunPhase(context.TODO(), phase, phaseTimeout, force)
+	return trace.Wrap(err)
+}
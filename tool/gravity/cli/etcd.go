@@ -0,0 +1,174 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/etcdbackup"
+	"github.com/gravitational/gravity/lib/localenv"
+	"github.com/gravitational/gravity/lib/state"
+	"github.com/gravitational/gravity/lib/systemservice"
+
+	"github.com/gravitational/trace"
+)
+
+// etcdBackup takes an etcd backup and uploads it to the specified location.
+// If interval is set, it keeps taking backups every interval
+func etcdBackup(env *localenv.LocalEnvironment, location string, retain int, interval time.Duration) error {
+	manager, err := newEtcdBackupManager(location, retain)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	ctx := context.TODO()
+	if interval != 0 {
+		return trace.Wrap(manager.Run(ctx, interval))
+	}
+	backup, err := manager.Backup(ctx)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	env.Printf("etcd backup %v saved to %v\n", backup, manager.Storage)
+	return nil
+}
+
+// etcdRestore validates the specified etcd backup and restores etcd from it.
+// If name is empty, the most recent backup is used
+func etcdRestore(env *localenv.LocalEnvironment, location, name string, verifyOnly, confirmed bool) error {
+	manager, err := newEtcdBackupManager(location, 0)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if verifyOnly {
+		if err := manager.Verify(name); err != nil {
+			return trace.Wrap(err)
+		}
+		env.Println("etcd backup is valid")
+		return nil
+	}
+	if !confirmed {
+		env.Println("This operation will replace the contents of the etcd database on this node. Are you sure?")
+		resp, err := confirm()
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if !resp {
+			env.Println("Action cancelled by user.")
+			return nil
+		}
+	}
+	if err := manager.Restore(context.TODO(), name); err != nil {
+		return trace.Wrap(err)
+	}
+	env.Println("etcd has been restored")
+	return nil
+}
+
+// etcdBackupList lists etcd backups stored at the specified location
+func etcdBackupList(env *localenv.LocalEnvironment, location string) error {
+	manager, err := newEtcdBackupManager(location, 0)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	backups, err := manager.List()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if len(backups) == 0 {
+		env.Printf("no etcd backups found in %v\n", manager.Storage)
+		return nil
+	}
+	for _, backup := range backups {
+		env.Printf("%v\t%v\n", backup.Name, backup.Created.Format(constants.HumanDateFormat))
+	}
+	return nil
+}
+
+// etcdBackupSchedule installs a system service that takes an etcd backup
+// every interval and keeps the specified number of most recent backups
+func etcdBackupSchedule(env *localenv.LocalEnvironment, location string, retain int, interval time.Duration) error {
+	// Validate the location before installing the service
+	if _, err := etcdbackup.NewStorage(location); err != nil {
+		return trace.Wrap(err)
+	}
+	gravityPath, err := os.Executable()
+	if err != nil {
+		return trace.Wrap(err, "failed to determine gravity executable path")
+	}
+	services, err := systemservice.New()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	args := []string{gravityPath, "etcd", "backup",
+		"--to", location,
+		"--keep", fmt.Sprint(retain),
+		"--every", interval.String(),
+	}
+	err = services.InstallService(systemservice.NewServiceRequest{
+		Name:    defaults.EtcdBackupServiceName,
+		NoBlock: true,
+		ServiceSpec: systemservice.ServiceSpec{
+			User:         constants.RootUIDString,
+			StartCommand: strings.Join(args, " "),
+			Restart:      "always",
+			RestartSec:   defaults.SystemServiceRestartSec,
+			WantedBy:     defaults.SystemServiceWantedBy,
+		},
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	env.Printf("etcd backups to %v scheduled every %v, keeping last %v\n", location, interval, retain)
+	return nil
+}
+
+// etcdBackupUnschedule removes the scheduled etcd backup service
+func etcdBackupUnschedule(env *localenv.LocalEnvironment) error {
+	services, err := systemservice.New()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if err := services.UninstallService(defaults.EtcdBackupServiceName); err != nil {
+		return trace.Wrap(err)
+	}
+	env.Println("scheduled etcd backups have been disabled")
+	return nil
+}
+
+func newEtcdBackupManager(location string, retain int) (*etcdbackup.Manager, error) {
+	storage, err := etcdbackup.NewStorage(location)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	stateDir, err := state.GetStateDir()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return etcdbackup.New(etcdbackup.Config{
+		Storage: storage,
+		Retain:  retain,
+		// The update directory is shared with the planet container
+		WorkDir: filepath.Join(state.GravityUpdateDir(stateDir), "etcd"),
+	})
+}
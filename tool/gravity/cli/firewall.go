@@ -0,0 +1,112 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/gravitational/gravity/lib/firewall"
+	"github.com/gravitational/gravity/lib/localenv"
+	"github.com/gravitational/gravity/lib/ops"
+
+	"github.com/gravitational/trace"
+)
+
+// applyFirewall opens the ports required by this node in the host firewall
+func applyFirewall(env *localenv.LocalEnvironment, backend string) error {
+	config, err := getFirewallConfig(env, backend)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	backend, err = firewall.Apply(context.TODO(), *config)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	env.PrintStep("Opened %v ports with %v.", len(config.Rules), backend)
+	return nil
+}
+
+// removeFirewall removes the rules managed by gravity from the host firewall
+func removeFirewall(env *localenv.LocalEnvironment, backend string) error {
+	config, err := getFirewallConfig(env, backend)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = firewall.Remove(context.TODO(), *config)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	env.PrintStep("Removed gravity firewall rules.")
+	return nil
+}
+
+// showFirewall displays the ports this node requires to be open
+func showFirewall(env *localenv.LocalEnvironment) error {
+	config, err := getFirewallConfig(env, "")
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 8, 1, '\t', 0)
+	fmt.Fprintf(w, "Protocol\tPorts\tDescription\n")
+	fmt.Fprintf(w, "--------\t-----\t-----------\n")
+	for _, rule := range config.Rules {
+		fmt.Fprintf(w, "%v\t%v\t%v\n", rule.Protocol, rule.Ports(), rule.Description)
+	}
+	w.Flush()
+	for _, subnet := range config.TrustedSubnets {
+		env.Printf("Trusted subnet: %v\n", subnet)
+	}
+	return nil
+}
+
+// getFirewallConfig returns the firewall configuration for this node
+// computed from the cluster application and its install operation
+func getFirewallConfig(env *localenv.LocalEnvironment, backend string) (*firewall.Config, error) {
+	clusterEnv, err := env.NewClusterEnvironment()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	cluster, err := clusterEnv.Operator.GetLocalSite()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	server, err := findLocalServer(*cluster)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	operation, err := ops.GetCompletedInstallOperation(cluster.Key(), clusterEnv.Operator)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	manifest := cluster.App.Manifest
+	if manifest.SystemOptions.ManagedFirewall() == nil {
+		return nil, trace.BadParameter("host firewall management is not enabled " +
+			"in the application manifest, see systemOptions.firewall")
+	}
+	config, err := firewall.NodeConfig(manifest, server.Role, operation.InstallExpand.Vars.OnPrem)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if backend != "" {
+		config.Backend = backend
+	}
+	return config, nil
+}
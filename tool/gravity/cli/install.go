@@ -32,12 +32,14 @@ import (
 	"github.com/gravitational/gravity/lib/install"
 	"github.com/gravitational/gravity/lib/localenv"
 	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/pack/encryptedpack"
 	pb "github.com/gravitational/gravity/lib/rpc/proto"
 	rpcserver "github.com/gravitational/gravity/lib/rpc/server"
 	"github.com/gravitational/gravity/lib/storage"
 	"github.com/gravitational/gravity/lib/systeminfo"
 	"github.com/gravitational/gravity/lib/systemservice"
 	"github.com/gravitational/gravity/lib/utils"
+	"github.com/gravitational/gravity/tool/common"
 
 	"github.com/gravitational/configure"
 	"github.com/gravitational/trace"
@@ -57,6 +59,16 @@ func startInstall(env *localenv.LocalEnvironment, i InstallConfig) error {
 		return trace.Wrap(err)
 	}
 
+	err = decryptInstallerPackages(env, i)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	sshHosts, err := i.GetSSHHosts()
+	if err != nil && !trace.IsNotFound(err) {
+		return trace.Wrap(err)
+	}
+
 	installerConfig, err := i.ToInstallerConfig(env)
 	if err != nil {
 		return trace.Wrap(err)
@@ -85,6 +97,14 @@ func startInstall(env *localenv.LocalEnvironment, i InstallConfig) error {
 		return trace.Wrap(err)
 	}
 
+	if sshHosts != nil {
+		agents, err := startSSHAgents(env, *sshHosts, installer)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		defer stopSSHAgents(env, agents)
+	}
+
 	err = installer.Wait()
 	if utils.IsContextCancelledError(err) {
 		return trace.BadParameter("cancelled")
@@ -92,6 +112,66 @@ func startInstall(env *localenv.LocalEnvironment, i InstallConfig) error {
 	return trace.Wrap(err)
 }
 
+// startSSHAgents connects to the specified nodes over SSH and starts
+// agents on them that join the installer
+func startSSHAgents(env *localenv.LocalEnvironment, hosts install.SSHHosts, installer *install.Installer) (*install.SSHAgents, error) {
+	env.PrintStep("Starting agents on %v nodes over SSH", len(hosts.Hosts))
+	agents, err := install.DeploySSHAgents(installer.Context, install.SSHAgentsConfig{
+		Hosts:         hosts,
+		InstallerAddr: installer.AdvertiseAddr,
+		Token:         installer.Token.Token,
+		CloudProvider: installer.CloudProvider,
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return agents, nil
+}
+
+// stopSSHAgents stops the agents started over SSH once the installer exits
+func stopSSHAgents(env *localenv.LocalEnvironment, agents *install.SSHAgents) {
+	env.PrintStep("Stopping agents started over SSH")
+	ctx, cancel := context.WithTimeout(context.Background(), defaults.RPCAgentShutdownTimeout)
+	defer cancel()
+	if err := agents.Teardown(ctx); err != nil {
+		logrus.WithError(err).Warn("Failed to stop agents started over SSH.")
+	}
+}
+
+// decryptInstallerPackages decrypts the installer packages in place
+// if the installer has been built with encryption
+func decryptInstallerPackages(env *localenv.LocalEnvironment, i InstallConfig) error {
+	installerEnv, err := localenv.New(i.ReadStateDir)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer installerEnv.Close()
+	locators, err := encryptedpack.FindEncryptedPackages(installerEnv.Packages)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if len(locators) == 0 {
+		return nil
+	}
+	encryptionKey, err := common.EncryptionKey(context.TODO(),
+		i.EncryptionKey, i.EncryptionKeyCommand)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if encryptionKey == "" {
+		return trace.BadParameter("the installer is encrypted, please provide " +
+			"the passphrase with --encryption-key or --encryption-key-command")
+	}
+	env.PrintStep("Decrypting installer packages")
+	for _, locator := range locators {
+		err := encryptedpack.DecryptPackage(installerEnv.Packages, locator, encryptionKey)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
 func Join(env, joinEnv *localenv.LocalEnvironment, j JoinConfig) error {
 	err := CheckLocalState(env)
 	if err != nil {
@@ -131,6 +211,50 @@ func Join(env, joinEnv *localenv.LocalEnvironment, j JoinConfig) error {
 
 }
 
+// joinBatch adds the batch of nodes described in the specified file
+// to the cluster within a single expand operation
+func joinBatch(env, joinEnv *localenv.LocalEnvironment, batchPath string, timeout time.Duration) error {
+	batch, err := expand.ReadBatch(batchPath)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	operator, err := env.SiteOperator()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	apps, err := env.SiteApps()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	packages, err := env.ClusterPackages()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	batchJoin, err := expand.NewBatchJoin(expand.BatchConfig{
+		Batch:         *batch,
+		Operator:      operator,
+		Apps:          apps,
+		Packages:      packages,
+		LocalBackend:  env.Backend,
+		LocalApps:     env.Apps,
+		LocalPackages: env.Packages,
+		JoinBackend:   joinEnv.Backend,
+		JoinTimeout:   timeout,
+		Silent:        env.Silent,
+		DebugMode:     env.Debug,
+		Insecure:      env.Insecure,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	utils.WatchTerminationSignals(ctx, cancel, utils.StopperFunc(func(context.Context) error {
+		return nil
+	}), logrus.StandardLogger())
+	return trace.Wrap(batchJoin.Run(ctx))
+}
+
 type leaveConfig struct {
 	force     bool
 	confirmed bool
@@ -466,6 +590,10 @@ type PhaseParams struct {
 	Timeout time.Duration
 	// Complete marks operation complete
 	Complete bool
+	// Until optionally specifies the phase to stop the plan execution at
+	Until string
+	// OperationID is the ID of the operation the phase belongs to
+	OperationID string
 }
 
 func executeInstallPhase(localEnv *localenv.LocalEnvironment, p PhaseParams) error {
@@ -505,6 +633,9 @@ func executeInstallPhase(localEnv *localenv.LocalEnvironment, p PhaseParams) err
 	defer progress.Stop()
 
 	if p.PhaseID == fsm.RootPhase {
+		if p.Until != "" {
+			return trace.Wrap(installFSM.ExecutePlanUntil(ctx, progress, p.Force, p.Until))
+		}
 		return trace.Wrap(ResumeInstall(ctx, installFSM, progress, p.Force))
 	}
 
@@ -520,13 +651,21 @@ func executeInstallPhase(localEnv *localenv.LocalEnvironment, p PhaseParams) err
 }
 
 func executeJoinPhase(localEnv, joinEnv *localenv.LocalEnvironment, p PhaseParams) error {
-	// determine the ongoing expand operation, it should be the only
-	// operation present in the local join-specific backend
-	operation, err := ops.GetExpandOperation(joinEnv.Backend)
+	operator, err := joinEnv.CurrentOperator(httplib.WithInsecure())
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	operator, err := joinEnv.CurrentOperator(httplib.WithInsecure())
+	// phases of nodes joining in a batch are executed remotely so
+	// the operation may not have been synchronized to this node yet
+	if p.OperationID != "" {
+		err = expand.SyncOperation(operator, joinEnv.Backend, p.OperationID)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	// determine the ongoing expand operation, it should be the only
+	// operation present in the local join-specific backend
+	operation, err := ops.GetExpandOperation(joinEnv.Backend)
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -566,6 +705,9 @@ func executeJoinPhase(localEnv, joinEnv *localenv.LocalEnvironment, p PhaseParam
 	progress := utils.NewProgress(ctx, fmt.Sprintf("Executing join phase %q", p.PhaseID), -1, false)
 	defer progress.Stop()
 	if p.PhaseID == fsm.RootPhase {
+		if p.Until != "" {
+			return trace.Wrap(joinFSM.ExecutePlanUntil(ctx, progress, p.Force, p.Until))
+		}
 		return trace.Wrap(ResumeInstall(ctx, joinFSM, progress, p.Force))
 	}
 	return joinFSM.ExecutePhase(ctx, fsm.Params{
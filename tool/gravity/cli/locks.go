@@ -0,0 +1,132 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/localenv"
+	"github.com/gravitational/gravity/lib/ops"
+
+	yaml "github.com/ghodss/yaml"
+	"github.com/gravitational/trace"
+)
+
+// showOperationLocks displays the resources held by active cluster operations
+func showOperationLocks(env *localenv.LocalEnvironment, format constants.Format) error {
+	operator, err := env.SiteOperator()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	cluster, err := operator.GetLocalSite()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	locks, err := operator.GetOperationLocks(cluster.Key())
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	switch format {
+	case constants.EncodingJSON:
+		bytes, err := json.MarshalIndent(locks, "", "    ")
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		fmt.Println(string(bytes))
+	case constants.EncodingYAML:
+		bytes, err := yaml.Marshal(locks)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		fmt.Println(string(bytes))
+	case constants.EncodingText:
+		if len(locks) == 0 {
+			env.Println("No locks are held by cluster operations.")
+			return nil
+		}
+		w := new(tabwriter.Writer)
+		w.Init(os.Stdout, 0, 8, 1, '\t', 0)
+		fmt.Fprintf(w, "Scope\tResource\tOperation\tType\tState\tSince\n")
+		fmt.Fprintf(w, "-----\t--------\t---------\t----\t-----\t-----\n")
+		for _, lock := range locks {
+			fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\n", lock.Scope, lock.Resource,
+				lock.OperationID, lock.OperationType, lock.OperationState,
+				lock.Since.Format(constants.HumanDateFormatSeconds))
+		}
+		w.Flush()
+	default:
+		return trace.BadParameter("unknown output format: %s", format)
+	}
+	return nil
+}
+
+type releaseLocksConfig struct {
+	operationID string
+	reason      string
+	force       bool
+	confirmed   bool
+}
+
+// releaseOperationLocks forcibly releases the resources held by the specified
+// operation by marking it failed
+func releaseOperationLocks(env *localenv.LocalEnvironment, c releaseLocksConfig) error {
+	operator, err := env.SiteOperator()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	cluster, err := operator.GetLocalSite()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	key := ops.SiteOperationKey{
+		AccountID:   cluster.AccountID,
+		SiteDomain:  cluster.Domain,
+		OperationID: c.operationID,
+	}
+	operation, err := operator.GetSiteOperation(key)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	locks := ops.GetOperationLocks(*operation)
+	if len(locks) == 0 {
+		return trace.NotFound("operation %v is %v and holds no locks",
+			operation.ID, operation.State)
+	}
+	if !c.confirmed {
+		env.Printf("Operation %v will be marked failed and the following locks released:\n", operation)
+		for _, lock := range locks {
+			env.Printf("  * %v %v\n", lock.Scope, lock.Resource)
+		}
+		if err := enforceConfirmation("Release the locks?"); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	err = operator.ReleaseOperationLocks(ops.ReleaseOperationLocksRequest{
+		SiteOperationKey: key,
+		Reason:           c.reason,
+		Force:            c.force,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	env.Printf("Locks held by operation %v have been released.\n", operation.ID)
+	return nil
+}
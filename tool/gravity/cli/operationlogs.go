@@ -0,0 +1,104 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/localenv"
+	"github.com/gravitational/gravity/lib/ops"
+
+	yaml "github.com/ghodss/yaml"
+	"github.com/gravitational/trace"
+)
+
+// showOperationLogs displays the log entries of the specified operation
+// that match the filter. If no operation is specified, the last
+// operation is used
+func showOperationLogs(env *localenv.LocalEnvironment, operationID string, filter ops.LogFilter, format constants.Format) error {
+	if err := filter.Check(); err != nil {
+		return trace.Wrap(err)
+	}
+	operator, err := env.SiteOperator()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	cluster, err := operator.GetLocalSite()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	key := ops.SiteOperationKey{
+		AccountID:   cluster.AccountID,
+		SiteDomain:  cluster.Domain,
+		OperationID: operationID,
+	}
+	if operationID == "" {
+		operation, _, err := ops.GetLastOperation(cluster.Key(), operator)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		key = operation.Key()
+	}
+	entries, err := operator.GetSiteOperationLogEntries(key, filter)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	switch format {
+	case constants.EncodingJSON:
+		bytes, err := json.MarshalIndent(entries, "", "    ")
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		fmt.Println(string(bytes))
+	case constants.EncodingYAML:
+		bytes, err := yaml.Marshal(entries)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		fmt.Println(string(bytes))
+	case constants.EncodingText:
+		if len(entries) == 0 {
+			env.Printf("No log entries of operation %v match the filter.\n", key.OperationID)
+			return nil
+		}
+		for _, entry := range entries {
+			fmt.Print(formatLogEntry(entry))
+		}
+	default:
+		return trace.BadParameter("unknown output format: %s", format)
+	}
+	return nil
+}
+
+// formatLogEntry formats the log entry along with its component and phase tags
+func formatLogEntry(entry ops.LogEntry) string {
+	var tags []string
+	if entry.Component != "" {
+		tags = append(tags, entry.Component)
+	}
+	if entry.Phase != "" {
+		tags = append(tags, entry.Phase)
+	}
+	if len(tags) == 0 {
+		return entry.String()
+	}
+	entry.Message = fmt.Sprintf("[%v] %v", strings.Join(tags, " "), entry.Message)
+	return entry.String()
+}
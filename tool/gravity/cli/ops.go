@@ -234,7 +234,7 @@ func getRegistries(ctx context.Context, env *localenv.LocalEnvironment, servers
 }
 
 // connectToOpsCenter
-func connectToOpsCenter(env *localenv.LocalEnvironment, opsCenterURL, username, password string) (err error) {
+func connectToOpsCenter(env *localenv.LocalEnvironment, opsCenterURL, username, password string, rateLimit utils.TransferRate) (err error) {
 	if username == "" || password == "" {
 		username, password, err = common.ReadUserPass()
 		if err != nil {
@@ -245,7 +245,9 @@ func connectToOpsCenter(env *localenv.LocalEnvironment, opsCenterURL, username,
 		users.LoginEntry{
 			OpsCenterURL: opsCenterURL,
 			Email:        username,
-			Password:     password})
+			Password:     password,
+			RateLimit:    rateLimit,
+		})
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -0,0 +1,111 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"context"
+	"time"
+
+	"github.com/gravitational/gravity/lib/localenv"
+	"github.com/gravitational/gravity/lib/osupdate"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+)
+
+type osUpdateConfig struct {
+	// hook is the shell command that updates a node
+	hook string
+	// nodes optionally lists hostnames or addresses of the nodes to update
+	nodes []string
+	// reboot specifies whether the hook reboots the node
+	reboot bool
+	// hookTimeout limits the time the hook is allowed to run
+	hookTimeout time.Duration
+	// healthTimeout limits the time to wait for a node to become healthy
+	healthTimeout time.Duration
+}
+
+// updateOS updates the host OS on cluster nodes one node at a time
+func updateOS(env *localenv.LocalEnvironment, config osUpdateConfig) error {
+	clusterEnv, err := env.NewClusterEnvironment()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if clusterEnv.Client == nil {
+		return trace.BadParameter("this operation must be run on a master node")
+	}
+	cluster, err := clusterEnv.Operator.GetLocalSite()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	servers, err := selectOSUpdateServers(cluster.ClusterState.Servers, config.nodes)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	coordinator, err := osupdate.New(osupdate.Config{
+		Emitter:       env,
+		Client:        clusterEnv.Client,
+		Servers:       servers,
+		Hook:          []string{"/bin/sh", "-c", config.hook},
+		ExpectReboot:  config.reboot,
+		HookTimeout:   config.hookTimeout,
+		HealthTimeout: config.healthTimeout,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if err := coordinator.Run(context.TODO()); err != nil {
+		return trace.Wrap(err)
+	}
+	env.PrintStep("OS update completed on %v node(s)", len(servers))
+	return nil
+}
+
+// selectOSUpdateServers returns the servers to update in order.
+// If names is empty, all servers are returned with masters ordered last.
+// Otherwise servers are returned in the order they are specified in names
+func selectOSUpdateServers(servers []storage.Server, names []string) (result []storage.Server, err error) {
+	if len(names) == 0 {
+		var masters []storage.Server
+		for _, server := range servers {
+			if server.IsMaster() {
+				masters = append(masters, server)
+			} else {
+				result = append(result, server)
+			}
+		}
+		return append(result, masters...), nil
+	}
+	for _, name := range names {
+		server, err := findOSUpdateServer(servers, name)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		result = append(result, *server)
+	}
+	return result, nil
+}
+
+func findOSUpdateServer(servers []storage.Server, name string) (*storage.Server, error) {
+	for _, server := range servers {
+		if server.Hostname == name || server.AdvertiseIP == name || server.Nodename == name {
+			return &server, nil
+		}
+	}
+	return nil, trace.NotFound("node %q is not part of the cluster", name)
+}
@@ -17,30 +17,38 @@ limitations under the License.
 package cli
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
+	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"syscall"
+	"text/tabwriter"
 	"time"
 
 	"github.com/gravitational/gravity/lib/app/service"
+	"github.com/gravitational/gravity/lib/constants"
 	"github.com/gravitational/gravity/lib/defaults"
 	"github.com/gravitational/gravity/lib/httplib"
 	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/localenv"
 	"github.com/gravitational/gravity/lib/pack"
+	"github.com/gravitational/gravity/lib/pack/webpack"
 	"github.com/gravitational/gravity/lib/utils"
 	"github.com/gravitational/gravity/tool/common"
 
 	"github.com/docker/docker/pkg/archive"
+	"github.com/dustin/go-humanize"
 	"github.com/gravitational/configure"
 	"github.com/gravitational/trace"
 )
 
 func importPackage(env *localenv.LocalEnvironment, path string, loc loc.Locator, checkManifest bool, opsCenterURL string,
-	labels map[string]string) error {
+	labels map[string]string, force bool, arch string) error {
 	var file io.ReadCloser
 
 	fileInfo, err := os.Stat(path)
@@ -70,12 +78,22 @@ func importPackage(env *localenv.LocalEnvironment, path string, loc loc.Locator,
 		return trace.Wrap(err)
 	}
 
+	if arch != "" {
+		loc = loc.WithArch(arch)
+	}
+
 	var opts []pack.PackageOption
 	if len(labels) != 0 {
 		opts = append(opts, pack.WithLabels(labels))
 	}
 
-	envelope, err := packages.CreatePackage(loc, file, opts...)
+	var envelope *pack.PackageEnvelope
+	if force {
+		// replace the existing package in place so it is never missing
+		envelope, err = packages.UpsertPackage(loc, file, opts...)
+	} else {
+		envelope, err = packages.CreatePackage(loc, file, opts...)
+	}
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -111,7 +129,12 @@ func unpackPackage(env *localenv.LocalEnvironment, loc loc.Locator, dir, opsCent
 	}
 	loc = *locPtr
 
-	err = pack.Unpack(packageService, loc, dir, tarOptions)
+	err = pack.UnpackWithOptions(packageService, loc, dir, pack.UnpackOptions{
+		TarOptions: tarOptions,
+		Progress: func(progress pack.Progress) {
+			env.Reporter.Report(progress.Current, progress.Total)
+		},
+	})
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -146,9 +169,13 @@ func exportPackage(env *localenv.LocalEnvironment, loc loc.Locator, opsCenterURL
 	return nil
 }
 
-func listPackages(app *localenv.LocalEnvironment, repositoryFilter string, opsCenterURL string) error {
+func listPackages(app *localenv.LocalEnvironment, repositoryFilter, selector, opsCenterURL string, refresh bool) error {
+	envelopes, err := getPackagesCached(app, repositoryFilter, selector, opsCenterURL, refresh)
+	if err != nil {
+		return trace.Wrap(err)
+	}
 	var repository string
-	return foreachPackage(app, repositoryFilter, opsCenterURL, func(env pack.PackageEnvelope) error {
+	for _, env := range envelopes {
 		if repository != env.Locator.Repository {
 			repository = env.Locator.Repository
 			common.PrintHeader(repository)
@@ -160,18 +187,33 @@ func listPackages(app *localenv.LocalEnvironment, repositoryFilter string, opsCe
 		} else {
 			app.Printf("* %v\n", env)
 		}
+	}
+	return nil
+}
+
+// getPackages returns packages in the specified repository, or all
+// repositories if repositoryFilter is empty, with labels matching the selector
+func getPackages(app *localenv.LocalEnvironment, repositoryFilter, selector, opsCenterURL string) (envelopes []pack.PackageEnvelope, err error) {
+	err = foreachPackage(app, repositoryFilter, selector, opsCenterURL, func(env pack.PackageEnvelope) error {
+		envelopes = append(envelopes, env)
 		return nil
 	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return envelopes, nil
 }
 
-func foreachPackage(app *localenv.LocalEnvironment, repositoryFilter string, opsCenterURL string, fn func(env pack.PackageEnvelope) error) error {
+// foreachPackage invokes fn for every package in the specified repository, or all
+// repositories if repositoryFilter is empty, with labels matching the selector
+func foreachPackage(app *localenv.LocalEnvironment, repositoryFilter, selector, opsCenterURL string, fn func(env pack.PackageEnvelope) error) error {
 	packageService, err := app.PackageService(opsCenterURL)
 	if err != nil {
 		return trace.Wrap(err)
 	}
 
 	if err = foreachRepository(repositoryFilter, packageService, func(repository string) error {
-		envelopes, err := packageService.GetPackages(repository)
+		envelopes, err := packageService.GetPackagesBySelector(repository, selector)
 		if err != nil {
 			return trace.Wrap(err)
 		}
@@ -212,6 +254,50 @@ func deletePackage(app *localenv.LocalEnvironment, loc loc.Locator, force bool,
 	return nil
 }
 
+func pinPackage(app *localenv.LocalEnvironment, loc loc.Locator, opsCenterURL string) error {
+	packageService, err := app.PackageService(opsCenterURL)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	locPtr, err := pack.ProcessMetadata(packageService, &loc)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if err := packageService.PinPackage(*locPtr); err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Printf("%v pinned\n", *locPtr)
+	return nil
+}
+
+func unpinPackage(app *localenv.LocalEnvironment, loc loc.Locator, opsCenterURL string) error {
+	packageService, err := app.PackageService(opsCenterURL)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	locPtr, err := pack.ProcessMetadata(packageService, &loc)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if err := packageService.UnpinPackage(*locPtr); err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Printf("%v unpinned\n", *locPtr)
+	return nil
+}
+
+func setPackageLifecycle(app *localenv.LocalEnvironment, loc loc.Locator, lifecycle, opsCenterURL string) error {
+	packageService, err := app.PackageService(opsCenterURL)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if err := pack.SetLifecycle(packageService, loc, lifecycle); err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Printf("%v marked as %v\n", loc, lifecycle)
+	return nil
+}
+
 func configurePackage(s *localenv.LocalEnvironment, loc loc.Locator, confLoc loc.Locator, args []string) error {
 	log.Infof("configure %v into %v", loc, confLoc)
 
@@ -246,6 +332,126 @@ func updatePackageLabels(s *localenv.LocalEnvironment, loc loc.Locator, opsCente
 	return nil
 }
 
+func upsertPackageAlias(s *localenv.LocalEnvironment, alias, target loc.Locator, opsCenterURL string) error {
+	packages, err := s.PackageService(opsCenterURL)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = packages.UpsertPackageAlias(alias, target)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Printf("%v now points to %v\n", alias, target)
+	return nil
+}
+
+func deletePackageAlias(s *localenv.LocalEnvironment, alias loc.Locator, opsCenterURL string) error {
+	packages, err := s.PackageService(opsCenterURL)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = packages.DeletePackageAlias(alias)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Printf("%v deleted\n", alias)
+	return nil
+}
+
+// dedupPlanetConfigPackages converts planet configuration packages of the cluster
+// into layered configuration packages that keep the configuration shared between
+// nodes in a common package
+func dedupPlanetConfigPackages(env *localenv.LocalEnvironment, opsCenterURL string, dryRun bool) error {
+	packages, err := env.PackageService(opsCenterURL)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	var envelopes []pack.PackageEnvelope
+	err = pack.ForeachPackage(packages, func(e pack.PackageEnvelope) error {
+		if e.HasLabel(pack.PurposeLabel, pack.PurposePlanetConfig) && e.RuntimeLabels[pack.ConfigBaseLabel] == "" {
+			envelopes = append(envelopes, e)
+		}
+		return nil
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	bases := make(map[loc.Locator]struct{})
+	for _, e := range envelopes {
+		config, err := newLayeredPlanetConfig(packages, e)
+		if err != nil {
+			if !trace.IsNotFound(err) {
+				return trace.Wrap(err)
+			}
+			env.PrintStep("Skipping %v: %v", e.Locator, err)
+			continue
+		}
+		bases[config.Base] = struct{}{}
+		env.PrintStep("Converting %v to use shared configuration %v", e.Locator, config.Base)
+		if dryRun {
+			continue
+		}
+		err = config.CreateBase(packages, map[string]string{
+			pack.PurposeLabel:       pack.PurposePlanetConfigBase,
+			pack.ConfigBaseForLabel: e.RuntimeLabels[pack.ConfigLabel],
+		})
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		var buf bytes.Buffer
+		if err := config.Write(&buf); err != nil {
+			return trace.Wrap(err)
+		}
+		labels := map[string]string{pack.ConfigBaseLabel: config.Base.String()}
+		for k, v := range e.RuntimeLabels {
+			labels[k] = v
+		}
+		_, err = packages.UpsertPackage(e.Locator, &buf, pack.WithLabels(labels))
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	env.Printf("%v configuration packages share %v base packages\n", len(envelopes), len(bases))
+	return nil
+}
+
+// newLayeredPlanetConfig splits the variables of the specified planet
+// configuration package into shared and node-specific variables
+func newLayeredPlanetConfig(packages pack.PackageService, e pack.PackageEnvelope) (*pack.LayeredConfig, error) {
+	planetFilter, err := loc.ParseLocator(e.RuntimeLabels[pack.ConfigLabel])
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	// configuration packages are versioned after the package they configure
+	planetPackage := loc.Locator{
+		Repository: planetFilter.Repository,
+		Name:       planetFilter.Name,
+		Version:    e.Locator.Version,
+	}
+	manifest, err := pack.GetPackageManifest(packages, planetPackage)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if manifest.Config == nil {
+		return nil, trace.NotFound("%v has no configuration parameters", planetPackage)
+	}
+	vars, err := pack.ReadConfigVars(packages, e.Locator)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	config, err := pack.NewLayeredConfig(vars,
+		pack.ConfigEnvVars(manifest.Config, defaults.PlanetNodeConfigParams),
+		loc.Locator{
+			Repository: e.Locator.Repository,
+			Name:       constants.PlanetConfigBasePackage,
+			Version:    e.Locator.Version,
+		})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return config, nil
+}
+
 func executePackageCommand(s *localenv.LocalEnvironment, cmd string, loc loc.Locator, confLoc *loc.Locator, execArgs []string) error {
 	log.Infof("exec with config %v %v", loc, confLoc)
 
@@ -284,13 +490,7 @@ func executePackageCommand(s *localenv.LocalEnvironment, cmd string, loc loc.Loc
 	env := []string{fmt.Sprintf("PATH=%v", os.Getenv("PATH"))}
 	// read package with configuration if it's provided
 	if confLoc.Name != "" {
-		_, reader, err := s.Packages.ReadPackage(*confLoc)
-		if err != nil {
-			return trace.Wrap(err)
-		}
-		defer reader.Close()
-
-		vars, err := pack.ReadConfigPackage(reader)
+		vars, err := pack.ReadConfigVars(s.Packages, *confLoc)
 		if err != nil {
 			return trace.Wrap(err)
 		}
@@ -311,41 +511,59 @@ func executePackageCommand(s *localenv.LocalEnvironment, cmd string, loc loc.Loc
 	return syscall.Exec(command.Args[0], args, env)
 }
 
-func pushPackage(app *localenv.LocalEnvironment, loc loc.Locator, opsCenterURL string) error {
-	dstPackages, err := app.PackageService(opsCenterURL)
+// pushPackage pushes the package to the cluster specified with toURL (or opsCenterURL).
+// The package is pushed from the cluster specified with fromURL or from local packages
+func pushPackage(env *localenv.LocalEnvironment, loc loc.Locator, fromURL, toURL, opsCenterURL string, flags TransferFlags) error {
+	if toURL == "" {
+		toURL = opsCenterURL
+	}
+	srcPackages, err := transferPackages(env, fromURL, flags)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	dstPackages, err := transferPackages(env, toURL, flags)
 	if err != nil {
 		return trace.Wrap(err)
 	}
 
 	req := service.PackagePullRequest{
-		SrcPack:  app.Packages,
+		SrcPack:  srcPackages,
 		DstPack:  dstPackages,
 		Package:  loc,
-		Progress: app.Reporter,
+		Progress: env.Reporter,
 	}
 
 	if _, err = service.PullPackage(req); err != nil {
 		return trace.Wrap(err)
 	}
 
-	fmt.Printf("%v pushed to %v\n", loc, opsCenterURL)
+	fmt.Printf("%v pushed to %v\n", loc, toURL)
 	return nil
 }
 
-func pullPackage(app *localenv.LocalEnvironment, loc loc.Locator, opsCenterURL string, labels map[string]string, force bool) error {
-	log.Infof("start download: %v from %v", loc, opsCenterURL)
+// pullPackage pulls the package from the cluster specified with fromURL (or opsCenterURL).
+// The package is pulled into the cluster specified with toURL or into local packages
+func pullPackage(env *localenv.LocalEnvironment, loc loc.Locator, fromURL, toURL, opsCenterURL string, labels map[string]string, force bool, flags TransferFlags) error {
+	if fromURL == "" {
+		fromURL = opsCenterURL
+	}
+	log.Infof("start download: %v from %v", loc, fromURL)
 
-	sourcePackages, err := app.PackageService(opsCenterURL)
+	srcPackages, err := transferPackages(env, fromURL, flags)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	dstPackages, err := transferPackages(env, toURL, flags)
 	if err != nil {
 		return trace.Wrap(err)
 	}
 
 	req := service.PackagePullRequest{
-		SrcPack:  sourcePackages,
-		DstPack:  app.Packages,
+		SrcPack:  srcPackages,
+		DstPack:  dstPackages,
 		Package:  loc,
 		Labels:   labels,
-		Progress: app.Reporter,
+		Progress: env.Reporter,
 		Upsert:   force,
 	}
 
@@ -353,10 +571,45 @@ func pullPackage(app *localenv.LocalEnvironment, loc loc.Locator, opsCenterURL s
 		return trace.Wrap(err)
 	}
 
-	fmt.Printf("%v pulled from %v\n", loc, opsCenterURL)
+	fmt.Printf("%v pulled from %v\n", loc, fromURL)
 	return nil
 }
 
+// transferPackages returns the package service of the cluster with the specified URL
+// or local packages if the URL is empty.
+//
+// If the client certificate is provided, it is used to authenticate with the cluster
+// instead of the login entry, and the cluster is verified against the CA certificate
+// which is required in this case, so both sides of the transfer are authenticated
+func transferPackages(env *localenv.LocalEnvironment, clusterURL string, flags TransferFlags) (pack.PackageService, error) {
+	if clusterURL == "" {
+		return env.Packages, nil
+	}
+	if *flags.CertFile == "" && *flags.KeyFile == "" {
+		packages, err := env.PackageService(clusterURL)
+		return packages, trace.Wrap(err)
+	}
+	if *flags.CertFile == "" || *flags.KeyFile == "" {
+		return nil, trace.BadParameter("both --cert and --key must be specified")
+	}
+	if *flags.CAFile == "" {
+		return nil, trace.BadParameter("--ca is required with --cert and --key to verify %v", clusterURL)
+	}
+	cert, err := tls.LoadX509KeyPair(*flags.CertFile, *flags.KeyFile)
+	if err != nil {
+		return nil, trace.Wrap(err, "failed to load client certificate")
+	}
+	ca, err := ioutil.ReadFile(*flags.CAFile)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	packages, err := webpack.NewClientWithCert(clusterURL, cert, ca)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return packages, nil
+}
+
 func foreachRepository(repository string, packageService pack.PackageService, fn func(repository string) error) (err error) {
 	var repositories []string
 	if repository != "" {
@@ -377,3 +630,102 @@ func foreachRepository(repository string, packageService pack.PackageService, fn
 
 // dialTimeout is used in calls to some APIs
 const dialTimeout = 10 * time.Second
+
+func printPackageStats(env *localenv.LocalEnvironment, opsCenterURL string, format constants.Format) error {
+	operator, err := env.SiteOperator()
+	if opsCenterURL != "" {
+		operator, err = env.OperatorService(opsCenterURL)
+	}
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	cluster, err := operator.GetLocalSite()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	stats, err := operator.GetPackageStats(cluster.Key())
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	switch format {
+	case constants.EncodingJSON:
+		bytes, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		fmt.Println(string(bytes))
+	case constants.EncodingText:
+		w := new(tabwriter.Writer)
+		w.Init(os.Stdout, 0, 8, 1, '\t', 0)
+		fmt.Fprintf(w, "Repository\tPackages\tSize\n")
+		fmt.Fprintf(w, "----------\t--------\t----\n")
+		for _, repo := range stats.Repositories {
+			fmt.Fprintf(w, "%v\t%v\t%v\n", repo.Repository, repo.Packages,
+				humanize.Bytes(uint64(repo.SizeBytes)))
+		}
+		fmt.Fprintf(w, "\nTotal:\t%v\t%v\n", stats.TotalPackages,
+			humanize.Bytes(uint64(stats.TotalSizeBytes)))
+		fmt.Fprintf(w, "Stored:\t\t%v\n", humanize.Bytes(uint64(stats.StoredSizeBytes)))
+		fmt.Fprintf(w, "Unpacked:\t\t%v\n", humanize.Bytes(uint64(stats.UnpackedSizeBytes)))
+		fmt.Fprintf(w, "Orphaned BLOBs:\t%v\t%v\n", stats.OrphanedBLOBs,
+			humanize.Bytes(uint64(stats.OrphanedSizeBytes)))
+		w.Flush()
+	default:
+		return trace.BadParameter("unknown output format: %v", format)
+	}
+	return nil
+}
+
+func diffPackages(env *localenv.LocalEnvironment, from, to loc.Locator, opsCenterURL string, format constants.Format) error {
+	packages, err := env.PackageService(opsCenterURL)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	diff, err := pack.DiffPackages(packages, from, to)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	switch format {
+	case constants.EncodingJSON:
+		bytes, err := json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		fmt.Println(string(bytes))
+	case constants.EncodingText:
+		if diff.IsEmpty() {
+			fmt.Printf("No changes between %v and %v.\n", from, to)
+			return nil
+		}
+		w := new(tabwriter.Writer)
+		w.Init(os.Stdout, 0, 8, 1, '\t', 0)
+		for _, file := range diff.Added {
+			fmt.Fprintf(w, "+ %v\t%v\n", file.Path, formatPackageFile(file))
+		}
+		for _, file := range diff.Removed {
+			fmt.Fprintf(w, "- %v\t%v\n", file.Path, formatPackageFile(file))
+		}
+		for _, change := range diff.Changed {
+			fmt.Fprintf(w, "~ %v\t%v -> %v\n", change.Path,
+				formatPackageFile(change.Before), formatPackageFile(change.After))
+		}
+		w.Flush()
+		fmt.Printf("\n%v added, %v removed, %v changed\n",
+			len(diff.Added), len(diff.Removed), len(diff.Changed))
+	default:
+		return trace.BadParameter("unknown output format: %v", format)
+	}
+	return nil
+}
+
+// formatPackageFile formats the file size and digest, or the link target for links
+func formatPackageFile(file pack.FileInfo) string {
+	if file.Link != "" {
+		return fmt.Sprintf("link to %v", file.Link)
+	}
+	digest := file.Digest
+	if len(digest) > 12 {
+		digest = digest[:12]
+	}
+	return fmt.Sprintf("%v %v (%o)", humanize.Bytes(uint64(file.SizeBytes)), digest, file.Mode)
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/localenv"
+
+	"github.com/gravitational/trace"
+	"gopkg.in/check.v1"
+)
+
+func TestCLI(t *testing.T) { check.TestingT(t) }
+
+type PackageSuite struct {
+	env *localenv.LocalEnvironment
+}
+
+var _ = check.Suite(&PackageSuite{})
+
+func (s *PackageSuite) SetUpTest(c *check.C) {
+	var err error
+	s.env, err = localenv.New(c.MkDir())
+	c.Assert(err, check.IsNil)
+	s.env.Silent = true
+}
+
+func (s *PackageSuite) TearDownTest(c *check.C) {
+	c.Assert(s.env.Close(), check.IsNil)
+}
+
+func (s *PackageSuite) TestImportReplacesPackageWithForce(c *check.C) {
+	locator := loc.MustParseLocator("example.com/package:1.0.0")
+	path := filepath.Join(c.MkDir(), "package.tar")
+	importFile := func(contents string, labels map[string]string, force bool) error {
+		err := ioutil.WriteFile(path, []byte(contents), defaults.SharedReadMask)
+		c.Assert(err, check.IsNil)
+		return importPackage(s.env, path, locator, false, "", labels, force, "")
+	}
+
+	err := importFile("v1", map[string]string{"version": "v1"}, false)
+	c.Assert(err, check.IsNil)
+	s.assertPackage(c, locator, "v1")
+
+	// existing packages are only replaced with force
+	err = importFile("v2", map[string]string{"version": "v2"}, false)
+	c.Assert(trace.IsAlreadyExists(err), check.Equals, true, check.Commentf("%v", err))
+	s.assertPackage(c, locator, "v1")
+
+	err = importFile("v2", map[string]string{"version": "v2"}, true)
+	c.Assert(err, check.IsNil)
+	s.assertPackage(c, locator, "v2")
+}
+
+// assertPackage verifies that the package has the specified contents
+// and the version label with the same value
+func (s *PackageSuite) assertPackage(c *check.C, locator loc.Locator, contents string) {
+	envelope, reader, err := s.env.Packages.ReadPackage(locator)
+	c.Assert(err, check.IsNil)
+	defer reader.Close()
+	data, err := ioutil.ReadAll(reader)
+	c.Assert(err, check.IsNil)
+	c.Assert(string(data), check.Equals, contents)
+	c.Assert(envelope.RuntimeLabels["version"], check.Equals, contents)
+}
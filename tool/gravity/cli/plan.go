@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/gravitational/gravity/lib/constants"
 	"github.com/gravitational/gravity/lib/defaults"
@@ -106,21 +107,7 @@ func displayClusterOperationPlan(env *localenv.LocalEnvironment, operationID str
 		return trace.Wrap(err)
 	}
 
-	cluster, err := operator.GetLocalSite()
-	if err != nil {
-		return trace.Wrap(err)
-	}
-
-	var op *ops.SiteOperation
-	if operationID != "" {
-		op, err = operator.GetSiteOperation(ops.SiteOperationKey{
-			AccountID:   cluster.AccountID,
-			SiteDomain:  cluster.Domain,
-			OperationID: operationID,
-		})
-	} else {
-		op, _, err = ops.GetLastOperation(cluster.Key(), operator)
-	}
+	op, err := getClusterOperation(operator, operationID)
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -144,6 +131,7 @@ func displayUpdateOperationPlan(localEnv, updateEnv *localenv.LocalEnvironment,
 		update.FSMConfig{
 			Backend:      clusterEnv.Backend,
 			LocalBackend: updateEnv.Backend,
+			Operator:     clusterEnv.Operator,
 		})
 	if err != nil {
 		return trace.Wrap(err)
@@ -265,6 +253,120 @@ func outputPhaseError(phase storage.OperationPhase) error {
 	return nil
 }
 
+// executePlanParams combines parameters for the operation plan execution
+type executePlanParams struct {
+	// phaseID is the ID of the phase to execute
+	phaseID string
+	// until is the ID of the phase to stop the execution at
+	until string
+	// force allows to force phase execution
+	force bool
+	// skipVersionCheck allows to override gravity version compatibility check
+	skipVersionCheck bool
+	// timeout is plan execution timeout
+	timeout time.Duration
+}
+
+// executePlan executes the specified phase of the ongoing operation.
+// If until is set, the execution of the plan stops right before the specified phase
+// and the operation is left in progress so it can be continued manually
+func executePlan(env, updateEnv, joinEnv *localenv.LocalEnvironment, p executePlanParams) error {
+	if p.until != "" && p.phaseID != fsm.RootPhase {
+		return trace.BadParameter("--until can only be used when executing the entire plan")
+	}
+	var err error
+	switch {
+	case hasUpdateOperation(updateEnv):
+		err = executeUpgradePhase(env, updateEnv, upgradePhaseParams{
+			phaseID:          p.phaseID,
+			force:            p.force,
+			skipVersionCheck: p.skipVersionCheck,
+			timeout:          p.timeout,
+			until:            p.until,
+		})
+	case joinEnv != nil && hasExpandOperation(joinEnv):
+		err = executeJoinPhase(env, joinEnv, PhaseParams{
+			PhaseID: p.phaseID,
+			Force:   p.force,
+			Timeout: p.timeout,
+			Until:   p.until,
+		})
+	default:
+		err = executeInstallPhase(env, PhaseParams{
+			PhaseID: p.phaseID,
+			Force:   p.force,
+			Timeout: p.timeout,
+			Until:   p.until,
+		})
+	}
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if p.until != "" {
+		env.Printf("Operation plan has been executed up to phase %q.\n", p.until)
+		env.Println("Execute the remaining phases manually or run 'gravity plan execute' to resume.")
+	}
+	return nil
+}
+
+// getClusterOperation returns the cluster operation with the specified ID
+// or the last cluster operation if the ID is not specified
+func getClusterOperation(operator ops.Operator, operationID string) (*ops.SiteOperation, error) {
+	cluster, err := operator.GetLocalSite()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if operationID != "" {
+		op, err := operator.GetSiteOperation(ops.SiteOperationKey{
+			AccountID:   cluster.AccountID,
+			SiteDomain:  cluster.Domain,
+			OperationID: operationID,
+		})
+		return op, trace.Wrap(err)
+	}
+	op, _, err := ops.GetLastOperation(cluster.Key(), operator)
+	return op, trace.Wrap(err)
+}
+
+// approvePhase approves the execution of the specified phase of the cluster
+// operation plan. If require is set, the phase is marked as requiring approval
+// instead
+func approvePhase(localEnv, updateEnv *localenv.LocalEnvironment, operationID, phaseID string, require bool) error {
+	operator, err := localEnv.SiteOperator()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	op, err := getClusterOperation(operator, operationID)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	// the approver is recorded by the cluster from the authenticated user
+	change, err := operator.ApprovePlanPhase(ops.ApprovePlanPhaseRequest{
+		SiteOperationKey: op.Key(),
+		PhaseID:          phaseID,
+		Require:          require,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if op.Type == ops.OperationUpdate && updateEnv != nil {
+		// the upgrade uses the local copy of the plan as authoritative
+		local, err := storage.GetLastOperation(updateEnv.Backend)
+		if err == nil && local.ID == op.ID {
+			_, err = updateEnv.Backend.CreateOperationPlanChange(*change)
+			if err != nil {
+				return trace.Wrap(err)
+			}
+		}
+	}
+	if require {
+		localEnv.Printf("Phase %v of operation %v now requires approval.\n", phaseID, op.ID)
+	} else {
+		localEnv.Printf("Phase %v of operation %v has been approved, resume the operation to continue.\n", phaseID, op.ID)
+	}
+	return nil
+}
+
 const recoveryModeWarning = "Failed to retrieve plan from etcd, showing cached plan. If etcd went down as a result of a system upgrade, you can perform a rollback phase. Run 'gravity plan --repair' when etcd connection is restored.\n"
 
 // hasUpdateOperation returns true if there is an upgrade operation found
@@ -19,10 +19,12 @@ package cli
 import (
 	"context"
 	"fmt"
+	"os"
 
 	"github.com/gravitational/gravity/lib/constants"
 	"github.com/gravitational/gravity/lib/defaults"
 	"github.com/gravitational/gravity/lib/localenv"
+	"github.com/gravitational/gravity/lib/pack"
 	"github.com/gravitational/gravity/lib/schema"
 	libstatus "github.com/gravitational/gravity/lib/status"
 	"github.com/gravitational/gravity/lib/storage"
@@ -91,8 +93,16 @@ func getPlanetStatus(env *localenv.LocalEnvironment, args []string) error {
 	args = append(args, "--ca-file", caFile)
 	args = append(args, "--client-cert-file", clientCertFile)
 	args = append(args, "--client-key-file", clientKeyFile)
-	return executePackageCommand(
-		env, "status", *planetPackage, planetConfigPackage, args)
+	ctx, cancel := context.WithTimeout(context.Background(), defaults.PlanetStatusTimeout)
+	defer cancel()
+	// run the status command from the directory used by the running planet
+	// container, it must never be unpacked again by a read-only command
+	unpackedPath, err := env.Packages.UnpackedPath(*planetPackage)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return pack.ExecuteUnpackedPackageCommand(ctx, env.Packages, "status",
+		unpackedPath, planetConfigPackage, args, os.Stdout)
 }
 
 // planetVersion returns version of the currently installed planet
@@ -18,14 +18,19 @@ package cli
 
 import (
 	"fmt"
+	"runtime"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gravitational/gravity/lib/constants"
 	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/firewall"
+	"github.com/gravitational/gravity/lib/fsm"
 	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/modules"
+	"github.com/gravitational/gravity/lib/osupdate"
+	"github.com/gravitational/gravity/lib/pack"
 	"github.com/gravitational/gravity/lib/schema"
 	"github.com/gravitational/gravity/lib/utils"
 	"github.com/gravitational/gravity/tool/common"
@@ -62,6 +67,7 @@ func RegisterCommands(app *kingpin.Application) *Application {
 	g.InstallCmd.Cluster = g.InstallCmd.Flag("cluster", "Cluster name, optional").String()
 	g.InstallCmd.App = g.InstallCmd.Flag("app", "Application to install, optional").Hidden().String()
 	g.InstallCmd.Flavor = g.InstallCmd.Flag("flavor", "Application flavor, optional").String()
+	g.InstallCmd.Set = g.InstallCmd.Flag("set", "Set application configuration parameter as key=value. Can be specified multiple times").StringMap()
 	g.InstallCmd.Role = g.InstallCmd.Flag("role", "Role of this node, optional").String()
 	g.InstallCmd.ResourcesPath = g.InstallCmd.Flag("config", "Kubernetes configuration resources, will be injected at cluster creation time").String()
 	g.InstallCmd.Wizard = g.InstallCmd.Flag("wizard", "(Obsolete, superseded by 'mode') Start installer with web wizard interface").Bool()
@@ -97,6 +103,10 @@ func RegisterCommands(app *kingpin.Application) *Application {
 	g.InstallCmd.GCENodeTags = g.InstallCmd.Flag("gce-node-tag", "Override node tag on the instance in GCE required for load balanacing. Defaults to cluster name.").Strings()
 	g.InstallCmd.DNSHosts = g.InstallCmd.Flag("dns-host", "Specify an IP address that will be returned for the given domain within the cluster. Accepts <domain>/<ip> format. Can be specified multiple times.").Hidden().Strings()
 	g.InstallCmd.DNSZones = g.InstallCmd.Flag("dns-zone", "Specify an upstream server for the given zone within the cluster. Accepts <zone>/<nameserver> format where <nameserver> can be either <ip> or <ip>:<port>. Can be specified multiple times.").Strings()
+	g.InstallCmd.PackageCache = g.InstallCmd.Flag("package-cache", "Directory with pre-seeded packages present on every node. Packages found in it with a matching digest are not downloaded from the installer.").String()
+	g.InstallCmd.EncryptionKey = g.InstallCmd.Flag("encryption-key", "Passphrase to decrypt the encrypted installer packages with").Envar(constants.InstallerEncryptionKeyEnvVar).String()
+	g.InstallCmd.EncryptionKeyCommand = g.InstallCmd.Flag("encryption-key-command", "Shell command that outputs the passphrase to decrypt the encrypted installer packages with, e.g. to retrieve it from a key management service").String()
+	g.InstallCmd.SSHHosts = g.InstallCmd.Flag("ssh-hosts", "YAML file with the nodes to connect to over SSH and start agents on automatically").String()
 
 	g.JoinCmd.CmdClause = g.Command("join", "Join existing cluster or on-going install operation")
 	g.JoinCmd.PeerAddr = g.JoinCmd.Arg("peer-addrs", "One or several IP addresses of cluster node to join, as comma-separated values").String()
@@ -123,6 +133,11 @@ func RegisterCommands(app *kingpin.Application) *Application {
 	g.AutoJoinCmd.SystemDevice = g.AutoJoinCmd.Flag("system-device", "Device to use for system data directory").Hidden().String()
 	g.AutoJoinCmd.Mounts = configure.KeyValParam(g.AutoJoinCmd.Flag("mount", "One or several mounts in form <mount-name>:<path>, e.g. data:/var/lib/data"))
 
+	g.BakerCmd.CmdClause = g.Command("baker", "Pre-install cluster image packages, but not container images, into a node image without forming a cluster")
+	g.BakerCmd.Path = g.BakerCmd.Arg("path", "Path to the cluster image tarball or the directory with the unpacked image").Default(".").String()
+	g.BakerCmd.Role = g.BakerCmd.Flag("role", "Node profile to bake the image for, by default the runtime packages of all profiles are pre-installed").String()
+	g.BakerCmd.Arch = g.BakerCmd.Flag("arch", "Architecture of the nodes to bake the image for").Default(runtime.GOARCH).String()
+
 	g.LeaveCmd.CmdClause = g.Command("leave", "Decommission this node from the cluster")
 	g.LeaveCmd.Force = g.LeaveCmd.Flag("force", "Force local state cleanup").Bool()
 	g.LeaveCmd.Confirm = g.LeaveCmd.Flag("confirm", "Do not ask for confirmation").Bool()
@@ -132,6 +147,27 @@ func RegisterCommands(app *kingpin.Application) *Application {
 		Required().String()
 	g.RemoveCmd.Force = g.RemoveCmd.Flag("force", "Force removal of offline node").Bool()
 	g.RemoveCmd.Confirm = g.RemoveCmd.Flag("confirm", "Do not ask for confirmation").Bool()
+	g.RemoveCmd.SkipVersionCheck = g.RemoveCmd.Flag("skip-version-check", "Bypass version compatibility check").Hidden().Bool()
+
+	g.ReplaceCmd.CmdClause = g.Command("replace", "Replace a failed node with new hardware that takes over its hostname, address, role, labels and taints")
+	g.ReplaceCmd.Node = g.ReplaceCmd.Arg("node", "Node to replace: can be IP address, hostname or name from `kubectl get nodes` output").
+		Required().String()
+	g.ReplaceCmd.Cancel = g.ReplaceCmd.Flag("cancel", "Forget the recorded identity of the node so it is not restored on a joining node").Bool()
+	g.ReplaceCmd.Confirm = g.ReplaceCmd.Flag("confirm", "Do not ask for confirmation").Bool()
+	g.ReplaceCmd.SkipVersionCheck = g.ReplaceCmd.Flag("skip-version-check", "Bypass version compatibility check").Hidden().Bool()
+
+	g.DevCmd.CmdClause = g.Command("dev", "Lightweight single-node cluster for application development")
+	g.DevUpCmd.CmdClause = g.DevCmd.Command("up", "Start a single-node development cluster on this node without preflight checks and optional system applications")
+	g.DevUpCmd.Path = g.DevUpCmd.Arg("appdir", "Path to directory with application package. Uses current directory by default").String()
+	g.DevUpCmd.AdvertiseAddr = g.DevUpCmd.Flag("advertise-addr", "The IP address to advertise").String()
+	g.DevUpCmd.Cluster = g.DevUpCmd.Flag("cluster", "Cluster name, optional").String()
+	g.DevUpCmd.Flavor = g.DevUpCmd.Flag("flavor", "Application flavor to select the node profile from, optional").String()
+	g.DevUpCmd.Role = g.DevUpCmd.Flag("role", "Node profile, optional. Defaults to the first master profile of the flavor").String()
+	g.DevUpCmd.ResourcesPath = g.DevUpCmd.Flag("config", "Kubernetes resources to create in the cluster after installation").String()
+	g.DevUpCmd.Set = g.DevUpCmd.Flag("set", "Set application configuration parameter as key=value. Can be specified multiple times").StringMap()
+	g.DevUpCmd.PackageCache = g.DevUpCmd.Flag("package-cache", "Directory with pre-seeded packages. Packages found in it with a matching digest are not unpacked from the installer.").String()
+	g.DevDownCmd.CmdClause = g.DevCmd.Command("down", "Remove the development cluster and all its data from this node")
+	g.DevDownCmd.Confirm = g.DevDownCmd.Flag("confirm", "Do not ask for confirmation").Bool()
 
 	g.PlanCmd.CmdClause = g.Command("plan", "Display a plan for an ongoing operation")
 	g.PlanCmd.Init = g.PlanCmd.Flag("init", "Initialize operation plan").Bool()
@@ -139,6 +175,21 @@ func RegisterCommands(app *kingpin.Application) *Application {
 	g.PlanCmd.Output = common.Format(g.PlanCmd.Flag("output", "Output format for the plan, text, json or yaml").Short('o').Default(string(constants.EncodingText)))
 	g.PlanCmd.OperationID = g.PlanCmd.Flag("operation-id", "ID of the operation to display the plan for. It not specified, the last operation plan will be displayed").String()
 
+	g.PlanDisplayCmd.CmdClause = g.PlanCmd.Command("display", "Display a plan for an ongoing operation").Default()
+
+	g.PlanExecuteCmd.CmdClause = g.PlanCmd.Command("execute", "Execute specified operation phase")
+	g.PlanExecuteCmd.Phase = g.PlanExecuteCmd.Flag("phase", "Phase ID to execute. If not specified, the entire plan is executed").Default(fsm.RootPhase).String()
+	g.PlanExecuteCmd.Until = g.PlanExecuteCmd.Flag("until", "Stop the execution right before the specified phase. If not specified, the entire plan is executed").String()
+	g.PlanExecuteCmd.Force = g.PlanExecuteCmd.Flag("force", "Force phase execution").Bool()
+	g.PlanExecuteCmd.Timeout = g.PlanExecuteCmd.Flag("timeout", "Plan execution timeout").Default(defaults.PhaseTimeout).Hidden().Duration()
+	g.PlanExecuteCmd.SkipVersionCheck = g.PlanExecuteCmd.Flag("skip-version-check", "Bypass version compatibility check").Hidden().Bool()
+
+	g.PlanApproveCmd.CmdClause = g.PlanCmd.Command("approve", "Approve the execution of the phase that requires approval")
+	g.PlanApproveCmd.Phase = g.PlanApproveCmd.Arg("phase", "Phase ID to approve").Required().String()
+
+	g.PlanRequireApprovalCmd.CmdClause = g.PlanCmd.Command("require-approval", "Pause the operation before the specified phase until it is approved")
+	g.PlanRequireApprovalCmd.Phase = g.PlanRequireApprovalCmd.Arg("phase", "Phase ID that requires approval").Required().String()
+
 	g.RollbackCmd.CmdClause = g.Command("rollback", "Rollback actions")
 	g.RollbackCmd.Phase = g.RollbackCmd.Flag("phase", "Operation phase to rollback").Required().String()
 	g.RollbackCmd.PhaseTimeout = g.RollbackCmd.Flag("timeout", "Phase rollback timeout").Default(defaults.PhaseTimeout).Hidden().Duration()
@@ -148,15 +199,16 @@ func RegisterCommands(app *kingpin.Application) *Application {
 	g.UpdateCmd.CmdClause = g.Command("update", "Update actions on cluster")
 
 	g.UpdateCheckCmd.CmdClause = g.UpdateCmd.Command("check", "Check if an update is available for the specified application").Hidden()
-	g.UpdateCheckCmd.App = g.UpdateCheckCmd.Arg("app", "Application version to update to, in the 'name:version' or 'name' (for latest version) format. If unspecified, currently installed application is updated").String()
+	g.UpdateCheckCmd.App = g.UpdateCheckCmd.Arg("app", "Application version to update to, in the 'name:version', 'name:range' (for latest version in the semver range, e.g. 'name:~5.5') or 'name' (for latest version) format. If unspecified, currently installed application is updated").String()
 
 	g.UpdateTriggerCmd.CmdClause = g.UpdateCmd.Command("trigger", "Trigger an update operation for given application").Hidden()
-	g.UpdateTriggerCmd.App = g.UpdateTriggerCmd.Arg("app", "Application version to update to, in the 'name:version' or 'name' (for latest version) format. If unspecified, currently installed application is updated").String()
+	g.UpdateTriggerCmd.App = g.UpdateTriggerCmd.Arg("app", "Application version to update to, in the 'name:version', 'name:range' (for latest version in the semver range, e.g. 'name:~5.5') or 'name' (for latest version) format. If unspecified, currently installed application is updated").String()
 	g.UpdateTriggerCmd.Manual = g.UpdateTriggerCmd.Flag("manual", "Manual operation. Do not trigger automatic update").Short('m').Bool()
+	g.UpdateTriggerCmd.SkipVersionCheck = g.UpdateTriggerCmd.Flag("skip-version-check", "Bypass version compatibility check").Hidden().Bool()
 
 	// upgrade is aliased to "update trigger"
 	g.UpgradeCmd.CmdClause = g.Command("upgrade", "Trigger an update operation for given application").Hidden()
-	g.UpgradeCmd.App = g.UpgradeCmd.Arg("app", "Application version to update to, in the 'name:version' or 'name' (for latest version) format. If unspecified, currently installed application is updated").String()
+	g.UpgradeCmd.App = g.UpgradeCmd.Arg("app", "Application version to update to, in the 'name:version', 'name:range' (for latest version in the semver range, e.g. 'name:~5.5') or 'name' (for latest version) format. If unspecified, currently installed application is updated").String()
 	g.UpgradeCmd.Manual = g.UpgradeCmd.Flag("manual", "Manual upgrade mode").Short('m').Bool()
 	g.UpgradeCmd.Phase = g.UpgradeCmd.Flag("phase", "Operation phase to execute").String()
 	g.UpgradeCmd.Timeout = g.UpgradeCmd.Flag("timeout", "Phase execution timeout").Default(defaults.PhaseTimeout).Hidden().Duration()
@@ -164,6 +216,10 @@ func RegisterCommands(app *kingpin.Application) *Application {
 	g.UpgradeCmd.Complete = g.UpgradeCmd.Flag("complete", "Complete update operation").Bool()
 	g.UpgradeCmd.Resume = g.UpgradeCmd.Flag("resume", "Resume upgrade from the last failed step").Bool()
 	g.UpgradeCmd.SkipVersionCheck = g.UpgradeCmd.Flag("skip-version-check", "Bypass version compatibility check").Hidden().Bool()
+	g.UpgradeCmd.Unattended = g.UpgradeCmd.Flag("unattended", "Run the upgrade to completion with retries and automatic rollback, and output the result as JSON").Bool()
+	g.UpgradeCmd.Retries = g.UpgradeCmd.Flag("retries", "Number of times an unattended upgrade retries the operation after a failure").Default(strconv.Itoa(defaults.UnattendedUpgradeRetries)).Int()
+	g.UpgradeCmd.ForceRollback = g.UpgradeCmd.Flag("force-rollback", "Roll back an unattended upgrade even if it succeeds, to test the rollback path").Hidden().Bool()
+	g.UpgradeCmd.HealthTimeout = g.UpgradeCmd.Flag("health-timeout", "Maximum time an unattended upgrade waits for the cluster to become healthy").Default(defaults.UnattendedUpgradeHealthTimeout.String()).Duration()
 
 	g.UpdateUploadCmd.CmdClause = g.UpdateCmd.Command("upload", "Upload update package to locally running site").Hidden()
 	g.UpdateUploadCmd.OpsCenterURL = g.UpdateUploadCmd.Flag("ops-url", "Optional OpsCenter URL to upload new packages to (defaults to local gravity site)").Default(defaults.GravityServiceURL).String()
@@ -185,20 +241,57 @@ func RegisterCommands(app *kingpin.Application) *Application {
 	g.StatusCmd.OperationID = g.StatusCmd.Flag("operation-id", "Check status of operation with given ID").Short('o').String()
 	g.StatusCmd.Seconds = g.StatusCmd.Flag("seconds", "Continuously display status every N seconds").Short('s').Int()
 	g.StatusCmd.Output = common.Format(g.StatusCmd.Flag("output", "output format: json or text").Default(string(constants.EncodingText)))
+	g.StatusCmd.Watch = g.StatusCmd.Flag("watch", "Keep a live status view and highlight node and leader transitions").Short('w').Bool()
+	g.StatusCmd.NotifyWebhook = g.StatusCmd.Flag("notify-webhook", "URL to post status transitions to in watch mode").String()
+	g.StatusCmd.NotifyDesktop = g.StatusCmd.Flag("notify-desktop", "Show desktop notifications of status transitions in watch mode").Bool()
+	g.StatusCmd.Refresh = g.StatusCmd.Flag("refresh", "Query the cluster status instead of using the status cached by a recent invocation").Bool()
 
 	// reset cluster state, for debugging/emergencies
 	g.StatusResetCmd.CmdClause = g.Command("status-reset", "Reset the cluster state to 'active'").Hidden()
 
+	// release the node quarantined for flapping
+	g.ReleaseNodeCmd.CmdClause = g.Command("release-node", "Release the node quarantined for repeatedly joining and leaving the cluster")
+	g.ReleaseNodeCmd.Addr = g.ReleaseNodeCmd.Arg("addr", "Advertise address of the node to release").Required().String()
+
+	// inspect and release resources held by cluster operations
+	g.OperationCmd.CmdClause = g.Command("operation", "Operations on cluster operations")
+	g.OperationLocksCmd.CmdClause = g.OperationCmd.Command("locks", "Display the resources held by active cluster operations")
+	g.OperationLocksCmd.Output = common.Format(g.OperationLocksCmd.Flag("output", "Output format, text, json or yaml").Short('o').Default(string(constants.EncodingText)))
+	g.OperationReleaseLocksCmd.CmdClause = g.OperationCmd.Command("release-locks", "Forcibly release the resources held by a stuck operation by marking it failed")
+	g.OperationReleaseLocksCmd.OperationID = g.OperationReleaseLocksCmd.Arg("operation-id", "ID of the operation holding the locks").Required().String()
+	g.OperationReleaseLocksCmd.Reason = g.OperationReleaseLocksCmd.Flag("reason", "Reason for the release, recorded in the audit trail").Required().String()
+	g.OperationReleaseLocksCmd.Force = g.OperationReleaseLocksCmd.Flag("force", "Release the locks even if the operation has made progress recently and might still be running").Bool()
+	g.OperationReleaseLocksCmd.Confirm = g.OperationReleaseLocksCmd.Flag("confirm", "Do not ask for confirmation").Bool()
+	g.OperationLogsCmd.CmdClause = g.OperationCmd.Command("logs", "Display the operation log entries filtered by component, phase and severity")
+	g.OperationLogsCmd.OperationID = g.OperationLogsCmd.Arg("operation-id", "ID of the operation, defaults to the last operation").String()
+	g.OperationLogsCmd.Component = g.OperationLogsCmd.Flag("component", "Display only the entries of the specified component, e.g. etcd").String()
+	g.OperationLogsCmd.Phase = g.OperationLogsCmd.Flag("phase", "Display only the entries of the specified phase and its subphases, e.g. /masters").String()
+	g.OperationLogsCmd.Level = g.OperationLogsCmd.Flag("level", "Minimum severity of the displayed entries: debug, info, warn or error").Enum("debug", "info", "warn", "warning", "error")
+	g.OperationLogsCmd.Output = common.Format(g.OperationLogsCmd.Flag("output", "Output format, text, json or yaml").Short('o').Default(string(constants.EncodingText)))
+
 	// backup
 	g.BackupCmd.CmdClause = g.Command("backup", "Backup the local application state")
 	g.BackupCmd.Tarball = g.BackupCmd.Arg("to", "Tarball to create with results of the backup hook").Required().String()
 	g.BackupCmd.Timeout = g.BackupCmd.Flag("timeout", "Active deadline for the backup job, in Go duration format (e.g. 30s, 5m, etc.). If not specified, the value from manifest is used. If that is not specified as well, the default value of 20 minutes is used").Duration()
 	g.BackupCmd.Follow = g.BackupCmd.Flag("follow", "Output backup job logs to the stdout").Bool()
 
-	g.CheckCmd.CmdClause = g.Command("check", "check host environment to match manifest")
-	g.CheckCmd.ManifestFile = g.CheckCmd.Arg("manifest", "application manifest in YAML format").Default(defaults.ManifestFileName).String()
-	g.CheckCmd.Profile = g.CheckCmd.Flag("profile", "profile to check").Short('p').Required().String()
-	g.CheckCmd.AutoFix = g.CheckCmd.Flag("autofix", "attempt to fix some of the problems").Bool()
+	g.CheckCmd.CmdClause = g.Command("check", "check host environment")
+	g.CheckCmd.Recommend = g.CheckCmd.Flag("recommend", "display the flavor and profile recommended for this node based on its hardware").Bool()
+	g.CheckManifestCmd.CmdClause = g.CheckCmd.Command("manifest", "check host environment to match manifest").Default()
+	g.CheckManifestCmd.ManifestFile = g.CheckManifestCmd.Arg("manifest", "application manifest in YAML format").Default(defaults.ManifestFileName).String()
+	g.CheckManifestCmd.Profile = g.CheckManifestCmd.Flag("profile", "profile to check, required unless --recommend is given").Short('p').String()
+	g.CheckManifestCmd.AutoFix = g.CheckManifestCmd.Flag("autofix", "attempt to fix some of the problems").Bool()
+	g.CheckDisksCmd.CmdClause = g.CheckCmd.Command("disks", "check disk layout of the state directory, etcd and docker storage")
+	g.CheckDisksCmd.FixPlan = g.CheckDisksCmd.Flag("fix-plan", "output the commands that mount dedicated disks to fix the layout").Bool()
+	g.CheckDNSCmd.CmdClause = g.CheckCmd.Command("dns", "check cluster DNS resolution from the pod and host networks of cluster nodes")
+	g.CheckDNSCmd.Nodes = g.CheckDNSCmd.Flag("node", "name of the node to run the check on, can be repeated. Defaults to all nodes").Strings()
+	g.CheckDNSCmd.Names = g.CheckDNSCmd.Flag("name", "additional stub domain or upstream name to resolve, can be repeated").Strings()
+	g.CheckDNSCmd.Timeout = g.CheckDNSCmd.Flag("timeout", "maximum time to wait for the check on a node").Default(defaults.DNSCheckTimeout.String()).Duration()
+	g.CheckNetworkCmd.CmdClause = g.CheckCmd.Command("network", "check network connectivity between all pairs of cluster nodes on the cluster ports")
+	g.CheckNetworkCmd.Timeout = g.CheckNetworkCmd.Flag("timeout", "maximum time to wait for the check on a node").Default(defaults.NetworkCheckTimeout.String()).Duration()
+	g.CheckDialCmd.CmdClause = g.CheckCmd.Command("dial", "dial the specified addresses from this node").Hidden()
+	g.CheckDialCmd.Addrs = g.CheckDialCmd.Arg("addr", "TCP address to dial in host:port format").Required().Strings()
+	g.CheckDialCmd.Timeout = g.CheckDialCmd.Flag("timeout", "maximum time to establish a connection").Default(defaults.NetworkCheckDialTimeout.String()).Duration()
 
 	// restore
 	g.RestoreCmd.CmdClause = g.Command("restore", "Restore state of the local application from a previously taken backup")
@@ -206,6 +299,30 @@ func RegisterCommands(app *kingpin.Application) *Application {
 	g.RestoreCmd.Follow = g.RestoreCmd.Flag("follow", "Output restore job logs to the stdout").Bool()
 	g.RestoreCmd.Timeout = g.RestoreCmd.Flag("timeout", fmt.Sprintf("Maximum time a restore job is active. Defaults to the value from the manifest or %v if unspecified", defaults.HookJobDeadline)).Duration()
 
+	// etcd backups
+	g.EtcdCmd.CmdClause = g.Command("etcd", "Operations with etcd backups")
+
+	g.EtcdBackupCmd.CmdClause = g.EtcdCmd.Command("backup", "Take etcd backup on this master node")
+	g.EtcdBackupCmd.Location = g.EtcdBackupCmd.Flag("to", "Directory or S3 URL (s3://bucket/prefix[?region=region]) to store the backup in").Required().String()
+	g.EtcdBackupCmd.Retain = g.EtcdBackupCmd.Flag("keep", "Number of most recent backups to keep, 0 keeps all backups").Default("0").Int()
+	g.EtcdBackupCmd.Interval = g.EtcdBackupCmd.Flag("every", "Keep taking backups with the specified interval").Hidden().Duration()
+
+	g.EtcdRestoreCmd.CmdClause = g.EtcdCmd.Command("restore", "Restore etcd on this master node from a previously taken backup")
+	g.EtcdRestoreCmd.Name = g.EtcdRestoreCmd.Arg("name", "Name of the backup to restore. Defaults to the most recent backup").String()
+	g.EtcdRestoreCmd.Location = g.EtcdRestoreCmd.Flag("from", "Directory or S3 URL (s3://bucket/prefix[?region=region]) with backups").Required().String()
+	g.EtcdRestoreCmd.VerifyOnly = g.EtcdRestoreCmd.Flag("verify-only", "Only validate the backup without restoring it").Bool()
+	g.EtcdRestoreCmd.Confirmed = g.EtcdRestoreCmd.Flag("confirm", "Do not ask for confirmation").Bool()
+
+	g.EtcdListCmd.CmdClause = g.EtcdCmd.Command("ls", "List etcd backups")
+	g.EtcdListCmd.Location = g.EtcdListCmd.Flag("from", "Directory or S3 URL (s3://bucket/prefix[?region=region]) with backups").Required().String()
+
+	g.EtcdScheduleCmd.CmdClause = g.EtcdCmd.Command("schedule", "Take etcd backups on this master node periodically")
+	g.EtcdScheduleCmd.Location = g.EtcdScheduleCmd.Flag("to", "Directory or S3 URL (s3://bucket/prefix[?region=region]) to store backups in").Required().String()
+	g.EtcdScheduleCmd.Retain = g.EtcdScheduleCmd.Flag("keep", "Number of most recent backups to keep, 0 keeps all backups").Default(strconv.Itoa(defaults.EtcdBackupRetain)).Int()
+	g.EtcdScheduleCmd.Interval = g.EtcdScheduleCmd.Flag("every", "Interval between backups").Default(defaults.EtcdBackupInterval.String()).Duration()
+
+	g.EtcdUnscheduleCmd.CmdClause = g.EtcdCmd.Command("unschedule", "Stop taking periodic etcd backups on this node")
+
 	// operations on gravity applications
 	g.AppCmd.CmdClause = g.Command("app", "Operations with application images and releases.")
 
@@ -277,6 +394,7 @@ func RegisterCommands(app *kingpin.Application) *Application {
 	g.AppImportCmd.VendorIgnorePatterns = g.AppImportCmd.Flag("ignore", "ignore files matching this regular expression when searching for container references").Strings()
 	g.AppImportCmd.SetImages = loc.ImagesSlice(g.AppImportCmd.Flag("set-image", "rewrite docker image versions in the app's resource files during vendoring, e.g. 'postgres:9.3.4' will rewrite all images with name 'postgres' to 'postgres:9.3.4'"))
 	g.AppImportCmd.SetDeps = loc.LocatorSlice(g.AppImportCmd.Flag("set-dep", "rewrite dependencies section in app's manifest file during vendoring, e.g. 'gravitational.io/site-app:0.0.39' will overwrite dependency to 'gravitational.io/site-app:0.0.39'"))
+	g.AppImportCmd.RateLimit = TransferRate(g.AppImportCmd.Flag("rate-limit", "limit the rate of the application upload, e.g. 10MB/s"))
 	g.AppImportCmd.Parallel = g.AppImportCmd.Flag("parallel", "specifies number of concurrent tasks. If < 0, the number of tasks is not restricted, if unspecified, then tasks are capped at the number of logical CPU cores.").Hidden().Int()
 
 	// export gravity application
@@ -345,12 +463,18 @@ func RegisterCommands(app *kingpin.Application) *Application {
 	g.OpsConnectCmd.OpsCenterURL = g.OpsConnectCmd.Arg("ops-url", "remote OpsCenter URL").Default(defaults.GravityServiceURL).String()
 	g.OpsConnectCmd.Username = g.OpsConnectCmd.Arg("username", "remote OpsCenter username").String()
 	g.OpsConnectCmd.Password = g.OpsConnectCmd.Arg("password", "remote OpsCenter password").String()
+	g.OpsConnectCmd.RateLimit = TransferRate(g.OpsConnectCmd.Flag("rate-limit", "limit the rate of package transfers to and from the OpsCenter, e.g. 10MB/s"))
 
 	g.OpsDisconnectCmd.CmdClause = g.OpsCmd.Command("disconnect", "disconnect and log out from OpsCenter").Hidden()
 	g.OpsDisconnectCmd.OpsCenterURL = g.OpsDisconnectCmd.Arg("ops-url", "remote OpsCenter URL").Required().String()
 
 	g.OpsListCmd.CmdClause = g.OpsCmd.Command("ls", "list connected OpsCenters").Hidden()
 
+	g.OpsMigrateCmd.CmdClause = g.OpsCmd.Command("migrate", "Connect the cluster to another Ops Center and disconnect it from the current one")
+	g.OpsMigrateCmd.Path = g.OpsMigrateCmd.Arg("filename", "Path to the trusted cluster resource of the new Ops Center").Required().String()
+	g.OpsMigrateCmd.From = g.OpsMigrateCmd.Flag("from", "Name of the Ops Center to migrate from, required if the cluster is connected to several").String()
+	g.OpsMigrateCmd.Confirm = g.OpsMigrateCmd.Flag("confirm", "Do not ask for confirmation").Bool()
+
 	// TODO: move this functionality to crpcAgent
 	g.OpsAgentCmd.CmdClause = g.OpsCmd.Command("agent", "Start an agent to perform a set of tasks").Hidden()
 	g.OpsAgentCmd.PackageAddr = g.OpsAgentCmd.Arg("package-addr", "Address of the package service").Required().String()
@@ -373,6 +497,8 @@ func RegisterCommands(app *kingpin.Application) *Application {
 	g.PackImportCmd.Path = g.PackImportCmd.Arg("path", "file or directory to import as a package").Required().ExistingFileOrDir()
 	g.PackImportCmd.Locator = Locator(g.PackImportCmd.Arg("pkg", "package name").Required())
 	g.PackImportCmd.Labels = configure.KeyValParam(g.PackImportCmd.Flag("labels", "labels to add to the package"))
+	g.PackImportCmd.Force = g.PackImportCmd.Flag("force", "atomically replace the package if it already exists").Bool()
+	g.PackImportCmd.Arch = g.PackImportCmd.Flag("arch", "import the package as the variant built for the specified architecture, e.g. arm64").String()
 
 	// unpack package
 	g.PackUnpackCmd.CmdClause = g.PackCmd.Command("unpack", "unpack package into internal 'unpacked' directory").Hidden()
@@ -390,7 +516,9 @@ func RegisterCommands(app *kingpin.Application) *Application {
 	// list packages
 	g.PackListCmd.CmdClause = g.PackCmd.Command("list", "list local packages").Hidden()
 	g.PackListCmd.Repository = g.PackListCmd.Arg("repository", "repository name, if omitted will list all packages").String()
+	g.PackListCmd.Selector = g.PackListCmd.Flag("selector", "label selector to filter packages with, e.g. 'purpose=runtime,!pinned' or 'purpose in (runtime,planet-config)'").Short('l').String()
 	g.PackListCmd.OpsCenterURL = g.PackListCmd.Flag("ops-url", "optional remote OpsCenter URL").String()
+	g.PackListCmd.Refresh = g.PackListCmd.Flag("refresh", "query the remote OpsCenter instead of using the list cached by a recent invocation").Bool()
 
 	// delete package
 	g.PackDeleteCmd.CmdClause = g.PackCmd.Command("delete", "delete a package from repository").Hidden()
@@ -398,6 +526,14 @@ func RegisterCommands(app *kingpin.Application) *Application {
 	g.PackDeleteCmd.Locator = Locator(g.PackDeleteCmd.Arg("pkg", "package name"))
 	g.PackDeleteCmd.OpsCenterURL = g.PackDeleteCmd.Flag("ops-url", "optional remote OpsCenter URL").String()
 
+	g.PackPinCmd.CmdClause = g.PackCmd.Command("pin", "protect a package from deletion and garbage collection").Hidden()
+	g.PackPinCmd.Locator = Locator(g.PackPinCmd.Arg("pkg", "package name").Required())
+	g.PackPinCmd.OpsCenterURL = g.PackPinCmd.Flag("ops-url", "optional remote OpsCenter URL").String()
+
+	g.PackUnpinCmd.CmdClause = g.PackCmd.Command("unpin", "remove the deletion protection from a package").Hidden()
+	g.PackUnpinCmd.Locator = Locator(g.PackUnpinCmd.Arg("pkg", "package name").Required())
+	g.PackUnpinCmd.OpsCenterURL = g.PackUnpinCmd.Flag("ops-url", "optional remote OpsCenter URL").String()
+
 	// configure package
 	g.PackConfigureCmd.CmdClause = g.PackCmd.Command("configure", "configure a package").Interspersed(false).Hidden()
 	g.PackConfigureCmd.Package = Locator(g.PackConfigureCmd.Arg("pkg", "package name to configure").Required())
@@ -412,16 +548,22 @@ func RegisterCommands(app *kingpin.Application) *Application {
 	g.PackCommandCmd.Args = g.PackCommandCmd.Arg("arg", "additional arguments to command").Strings()
 
 	// push package to remote OpsCenter
-	g.PackPushCmd.CmdClause = g.PackCmd.Command("push", "push package to remote OpsCenter").Hidden()
+	g.PackPushCmd.CmdClause = g.PackCmd.Command("push", "push package to remote OpsCenter or cluster").Hidden()
 	g.PackPushCmd.Package = Locator(g.PackPushCmd.Arg("pkg", "package name to push").Required())
 	g.PackPushCmd.OpsCenterURL = g.PackPushCmd.Flag("ops-url", "optional remote OpsCenter URL").String()
+	g.PackPushCmd.To = g.PackPushCmd.Flag("to", "URL of the cluster to push the package to").String()
+	g.PackPushCmd.From = g.PackPushCmd.Flag("from", "optional URL of the cluster to push the package from, local packages are pushed by default").String()
+	g.PackPushCmd.TransferFlags = registerTransferFlags(g.PackPushCmd.CmdClause)
 
 	// pull package from remote OpsCenter
-	g.PackPullCmd.CmdClause = g.PackCmd.Command("pull", "pull package from remote OpsCenter").Hidden()
+	g.PackPullCmd.CmdClause = g.PackCmd.Command("pull", "pull package from remote OpsCenter or cluster").Hidden()
 	g.PackPullCmd.Package = Locator(g.PackPullCmd.Arg("pkg", "package name to pull").Required())
 	g.PackPullCmd.OpsCenterURL = g.PackPullCmd.Flag("ops-url", "remote OpsCenter URL").String()
+	g.PackPullCmd.From = g.PackPullCmd.Flag("from", "URL of the cluster to pull the package from").String()
+	g.PackPullCmd.To = g.PackPullCmd.Flag("to", "optional URL of the cluster to pull the package into, local packages are used by default").String()
 	g.PackPullCmd.Labels = configure.KeyValParam(g.PackPullCmd.Flag("labels", "labels to add to the package"))
 	g.PackPullCmd.Force = g.PackPullCmd.Flag("force", "overwrite destination package if it already exists").Bool()
+	g.PackPullCmd.TransferFlags = registerTransferFlags(g.PackPullCmd.CmdClause)
 
 	// labels changes package labels
 	g.PackLabelsCmd.CmdClause = g.PackCmd.Command("labels", "change package labels").Hidden()
@@ -430,6 +572,35 @@ func RegisterCommands(app *kingpin.Application) *Application {
 	g.PackLabelsCmd.Add = configure.KeyValParam(g.PackLabelsCmd.Flag("add", "labels to add to the package"))
 	g.PackLabelsCmd.Remove = g.PackLabelsCmd.Flag("remove", "labels to remove from the package").Strings()
 
+	g.PackLifecycleCmd.CmdClause = g.PackCmd.Command("lifecycle", "mark a package version as deprecated or end-of-life, end-of-life versions are skipped when resolving the latest version").Hidden()
+	g.PackLifecycleCmd.Package = Locator(g.PackLifecycleCmd.Arg("pkg", "package name to mark").Required())
+	g.PackLifecycleCmd.Lifecycle = g.PackLifecycleCmd.Arg("status", "lifecycle status: supported, deprecated or eol").Required().Enum(
+		pack.LifecycleSupported, pack.LifecycleDeprecated, pack.LifecycleEndOfLife)
+	g.PackLifecycleCmd.OpsCenterURL = g.PackLifecycleCmd.Flag("ops-url", "remote OpsCenter URL").String()
+
+	g.PackDiffCmd.CmdClause = g.PackCmd.Command("diff", "show files added, removed or changed between two packages").Hidden()
+	g.PackDiffCmd.From = Locator(g.PackDiffCmd.Arg("from", "package to compare against").Required())
+	g.PackDiffCmd.To = Locator(g.PackDiffCmd.Arg("to", "package to compare").Required())
+	g.PackDiffCmd.OpsCenterURL = g.PackDiffCmd.Flag("ops-url", "optional remote OpsCenter URL").String()
+	g.PackDiffCmd.Output = common.Format(g.PackDiffCmd.Flag("output", "output format: json or text").Short('o').Default(string(constants.EncodingText)))
+
+	g.PackAliasCmd.CmdClause = g.PackCmd.Command("alias", "create or update package alias, e.g. gravitational.io/runtime:@current").Hidden()
+	g.PackAliasCmd.Alias = Locator(g.PackAliasCmd.Arg("alias", "package alias to create or update").Required())
+	g.PackAliasCmd.Package = Locator(g.PackAliasCmd.Arg("pkg", "package the alias should point to").Required())
+	g.PackAliasCmd.OpsCenterURL = g.PackAliasCmd.Flag("ops-url", "optional remote OpsCenter URL").String()
+
+	g.PackUnaliasCmd.CmdClause = g.PackCmd.Command("unalias", "delete package alias").Hidden()
+	g.PackUnaliasCmd.Alias = Locator(g.PackUnaliasCmd.Arg("alias", "package alias to delete").Required())
+	g.PackUnaliasCmd.OpsCenterURL = g.PackUnaliasCmd.Flag("ops-url", "optional remote OpsCenter URL").String()
+
+	g.PackDedupConfigCmd.CmdClause = g.PackCmd.Command("dedup-config", "move planet configuration shared between nodes into common packages").Hidden()
+	g.PackDedupConfigCmd.OpsCenterURL = g.PackDedupConfigCmd.Flag("ops-url", "optional remote OpsCenter URL").String()
+	g.PackDedupConfigCmd.DryRun = g.PackDedupConfigCmd.Flag("dry-run", "only display the packages to be converted").Bool()
+
+	g.PackStatsCmd.CmdClause = g.PackCmd.Command("stats", "display package store usage statistics")
+	g.PackStatsCmd.OpsCenterURL = g.PackStatsCmd.Flag("ops-url", "optional remote cluster URL").String()
+	g.PackStatsCmd.Output = common.Format(g.PackStatsCmd.Flag("output", "output format: json or text").Short('o').Default(string(constants.EncodingText)))
+
 	// operations with users
 	g.UserCmd.CmdClause = g.Command("user", "operations with gravity users, only agent users are supported")
 
@@ -486,8 +657,20 @@ func RegisterCommands(app *kingpin.Application) *Application {
 	g.APIKeyDeleteCmd.OpsCenterURL = g.APIKeyDeleteCmd.Flag("ops-url", "remote OpsCenter URL").Required().String()
 
 	// get cluster diagnostics report
-	g.ReportCmd.CmdClause = g.Command("report", "Generate cluster diagnostics report")
-	g.ReportCmd.FilePath = g.ReportCmd.Flag("file", "target report file name").Default("report.tar.gz").String()
+	g.ReportCmd.CmdClause = g.Command("report", "Generate and compare cluster diagnostics reports")
+	g.ReportGenerateCmd.CmdClause = g.ReportCmd.Command("generate", "Generate cluster diagnostics report").Default()
+	g.ReportGenerateCmd.FilePath = g.ReportGenerateCmd.Flag("file", "target report file name").Default("report.tar.gz").String()
+	g.ReportGenerateCmd.Anonymize = g.ReportGenerateCmd.Flag("anonymize", "replace hostnames, IP addresses and cluster names with pseudonyms to share the report externally").Bool()
+
+	// compare cluster diagnostics reports
+	g.ReportDiffCmd.CmdClause = g.ReportCmd.Command("diff", "Show changes in package versions, configuration and health between two cluster diagnostics reports")
+	g.ReportDiffCmd.Before = g.ReportDiffCmd.Arg("before", "path to the earlier report").Required().String()
+	g.ReportDiffCmd.After = g.ReportDiffCmd.Arg("after", "path to the later report").Required().String()
+
+	// anonymize cluster diagnostics report
+	g.ReportAnonymizeCmd.CmdClause = g.ReportCmd.Command("anonymize", "Replace hostnames, IP addresses and cluster names in cluster diagnostics report with pseudonyms")
+	g.ReportAnonymizeCmd.Path = g.ReportAnonymizeCmd.Arg("report", "path to the report to anonymize").Required().String()
+	g.ReportAnonymizeCmd.FilePath = g.ReportAnonymizeCmd.Flag("file", "target report file name").Default("report-anonymized.tar.gz").String()
 
 	// operations on sites
 	g.SiteCmd.CmdClause = g.Command("site", "operations on gravity sites")
@@ -537,6 +720,10 @@ func RegisterCommands(app *kingpin.Application) *Application {
 	g.RPCAgentRunCmd.CmdClause = g.RPCAgentCmd.Command("run", "run RPC agent").Hidden()
 	g.RPCAgentRunCmd.Args = g.RPCAgentRunCmd.Arg("arg", "additional arguments").Strings()
 
+	g.RPCAgentJoinCmd.CmdClause = g.RPCAgentCmd.Command("join", "Add a batch of nodes to the cluster within a single expand operation")
+	g.RPCAgentJoinCmd.Batch = g.RPCAgentJoinCmd.Flag("batch", "Path to the YAML file listing the nodes to add").Required().String()
+	g.RPCAgentJoinCmd.Timeout = g.RPCAgentJoinCmd.Flag("timeout", "How long to wait for all nodes in the batch to join").Default(defaults.BatchJoinTimeout.String()).Duration()
+
 	g.SystemCmd.CmdClause = g.Command("system", "operations on system components")
 
 	g.SystemRotateCertsCmd.CmdClause = g.SystemCmd.Command("rotate-certs", "Renew cluster certificates on a node").Hidden()
@@ -544,6 +731,17 @@ func RegisterCommands(app *kingpin.Application) *Application {
 	g.SystemRotateCertsCmd.ValidFor = g.SystemRotateCertsCmd.Flag("valid-for", "Validity duration in Go format").Default("26280h").Duration()
 	g.SystemRotateCertsCmd.CAPath = g.SystemRotateCertsCmd.Flag("ca-path", "Use previously exported CA file instead of package").String()
 
+	g.SystemRepairKubeletCertsCmd.CmdClause = g.SystemCmd.Command("repair-kubelet-certs", "Renew expired kubelet certificates on a node")
+	g.SystemRepairKubeletCertsCmd.ClusterName = g.SystemRepairKubeletCertsCmd.Arg("cluster-name", "Name of the local cluster").Required().String()
+	g.SystemRepairKubeletCertsCmd.ValidFor = g.SystemRepairKubeletCertsCmd.Flag("valid-for", "Validity duration in Go format").Default(renewDuration).Duration()
+	g.SystemRepairKubeletCertsCmd.CAPath = g.SystemRepairKubeletCertsCmd.Flag("ca-path", "Use previously exported CA file instead of package").String()
+
+	g.SystemRenewCertsCmd.CmdClause = g.SystemCmd.Command("renew-certs", "Renew all certificates on a node and restart the services using them")
+	g.SystemRenewCertsCmd.ClusterName = g.SystemRenewCertsCmd.Arg("cluster-name", "Name of the local cluster").Required().String()
+	g.SystemRenewCertsCmd.ValidFor = g.SystemRenewCertsCmd.Flag("valid-for", "Validity duration in Go format").Default(renewDuration).Duration()
+	g.SystemRenewCertsCmd.CAPath = g.SystemRenewCertsCmd.Flag("ca-path", "Use previously exported CA file instead of package").String()
+	g.SystemRenewCertsCmd.Offline = g.SystemRenewCertsCmd.Flag("offline", "Read the certificate authority from the local package service when the cluster is not reachable").Bool()
+
 	g.SystemExportCACmd.CmdClause = g.SystemCmd.Command("export-ca", "Export cluster CA, must be run on a master node").Hidden()
 	g.SystemExportCACmd.ClusterName = g.SystemExportCACmd.Arg("cluster-name", "Name of the local cluster").Required().String()
 	g.SystemExportCACmd.CAPath = g.SystemExportCACmd.Arg("path", "File path to export CA at").Required().String()
@@ -571,6 +769,13 @@ func RegisterCommands(app *kingpin.Application) *Application {
 	// ask the current active master to step down
 	g.SystemStepDownCmd.CmdClause = g.SystemCmd.Command("step-down", "Ask the active master to step down").Hidden()
 
+	g.SystemWatchdogCmd.CmdClause = g.SystemCmd.Command("watchdog", "Monitor critical planet services on this node and restart them when they fail").Hidden()
+	g.SystemWatchdogCmd.Services = g.SystemWatchdogCmd.Flag("service", "Planet service to monitor, can be repeated").Default(defaults.WatchdogServices...).Strings()
+	g.SystemWatchdogCmd.Interval = g.SystemWatchdogCmd.Flag("interval", "How often to check the services").Default(defaults.WatchdogInterval.String()).Duration()
+	g.SystemWatchdogCmd.Window = g.SystemWatchdogCmd.Flag("window", "Time window to count service restarts in").Default(defaults.WatchdogRestartWindow.String()).Duration()
+	g.SystemWatchdogCmd.MaxRestarts = g.SystemWatchdogCmd.Flag("max-restarts", "Number of service restarts within the window after which the cluster is marked degraded").Default(strconv.Itoa(defaults.WatchdogMaxRestarts)).Int()
+	g.SystemWatchdogCmd.NotifyWebhook = g.SystemWatchdogCmd.Flag("notify-webhook", "URL to post service restarts to").String()
+
 	g.SystemRollbackCmd.CmdClause = g.SystemCmd.Command("rollback", "starts rollback").Hidden()
 	g.SystemRollbackCmd.ChangesetID = g.SystemRollbackCmd.Flag("changeset-id", "optionally select changeset id to rollback to").String()
 	g.SystemRollbackCmd.ServiceName = g.SystemRollbackCmd.Flag("service-name", "setting service name starts upgrade as a system service instead of foreground process").String()
@@ -643,6 +848,7 @@ func RegisterCommands(app *kingpin.Application) *Application {
 	g.GarbageCollectCmd.Manual = g.GarbageCollectCmd.Flag("manual", "Do not start the operation automatically").Short('m').Bool()
 	g.GarbageCollectCmd.Confirmed = g.GarbageCollectCmd.Flag("confirm", "Confirm to remove unrelated docker images").Short('c').Bool()
 	g.GarbageCollectCmd.Force = g.GarbageCollectCmd.Flag("force", "Force phase execution").Bool()
+	g.GarbageCollectCmd.SkipVersionCheck = g.GarbageCollectCmd.Flag("skip-version-check", "Bypass version compatibility check").Hidden().Bool()
 
 	// system clean up tasks
 	systemGCCmd := g.SystemCmd.Command("gc", "Run system clean up tasks")
@@ -665,6 +871,58 @@ func RegisterCommands(app *kingpin.Application) *Application {
 	g.SystemGCRegistryCmd.Confirm = g.SystemGCRegistryCmd.Flag("confirm", "Confirm to remove unrelated docker").Bool()
 	g.SystemGCRegistryCmd.DryRun = g.SystemGCRegistryCmd.Flag("dry-run", "Only list docker images to remove w/o removing them").Bool()
 
+	g.SystemFirewallCmd.CmdClause = g.SystemCmd.Command("firewall", "Manage host firewall rules for cluster ports.")
+	g.SystemFirewallApplyCmd.CmdClause = g.SystemFirewallCmd.Command("apply", "Open the ports required by this node in the host firewall.")
+	g.SystemFirewallApplyCmd.Backend = g.SystemFirewallApplyCmd.Flag("backend",
+		fmt.Sprintf("Firewall backend to use, one of %v. Detected if unspecified", firewall.Backends)).
+		Enum(firewall.Backends...)
+	g.SystemFirewallRemoveCmd.CmdClause = g.SystemFirewallCmd.Command("remove", "Remove gravity rules from the host firewall.")
+	g.SystemFirewallRemoveCmd.Backend = g.SystemFirewallRemoveCmd.Flag("backend",
+		fmt.Sprintf("Firewall backend to use, one of %v. Detected if unspecified", firewall.Backends)).
+		Enum(firewall.Backends...)
+	g.SystemFirewallShowCmd.CmdClause = g.SystemFirewallCmd.Command("show", "Display the ports required by this node.")
+
+	g.SystemOSUpdateCmd.CmdClause = g.SystemCmd.Command("os-update", "Update host OS on cluster nodes one node at a time. "+
+		"Each node is drained, updated with the provided hook, checked for health and uncordoned. "+
+		"The hook is executed on this node with the details of the node to update in "+
+		fmt.Sprintf("%v, %v, %v and %v environment variables.", osupdate.EnvNodeName, osupdate.EnvNodeAddr, osupdate.EnvNodeHostname, osupdate.EnvNodeRole))
+	g.SystemOSUpdateCmd.Hook = g.SystemOSUpdateCmd.Flag("hook", "Shell command that updates a node, e.g. ssh ${GRAVITY_NODE_ADDR} 'yum -y update && reboot'").Required().String()
+	g.SystemOSUpdateCmd.Nodes = g.SystemOSUpdateCmd.Flag("node", "Hostname or address of the node to update, can be repeated. Defaults to all nodes with masters updated last").Strings()
+	g.SystemOSUpdateCmd.Reboot = g.SystemOSUpdateCmd.Flag("reboot", "Wait for the node to reboot after the hook has completed").Bool()
+	g.SystemOSUpdateCmd.HookTimeout = g.SystemOSUpdateCmd.Flag("hook-timeout", "Maximum time the hook is allowed to run on a single node").Default(defaults.OSUpdateHookTimeout.String()).Duration()
+	g.SystemOSUpdateCmd.HealthTimeout = g.SystemOSUpdateCmd.Flag("health-timeout", "Maximum time to wait for a node to become healthy after update").Default(defaults.OSUpdateHealthTimeout.String()).Duration()
+	g.SystemOSUpdateCmd.SkipVersionCheck = g.SystemOSUpdateCmd.Flag("skip-version-check", "Bypass version compatibility check").Hidden().Bool()
+
+	g.SystemDownloadBinaryCmd.CmdClause = g.SystemCmd.Command("download-binary", "Download the gravity binary matching the cluster version from the cluster")
+	g.SystemDownloadBinaryCmd.Version = g.SystemDownloadBinaryCmd.Flag("version", "Version of the gravity binary to download. Defaults to the cluster version").String()
+	g.SystemDownloadBinaryCmd.Path = g.SystemDownloadBinaryCmd.Flag("output", "Path to save the binary to").Short('o').Default(constants.GravityBin).String()
+
+	g.SystemRollingRestartCmd.CmdClause = g.SystemCmd.Command("rolling-restart", "Restart the runtime container, or a single service inside it, on cluster nodes one node at a time")
+	g.SystemRollingRestartCmd.Unit = g.SystemRollingRestartCmd.Arg("service", "Service inside the runtime container to restart, e.g. kubelet or etcd. Defaults to the whole container").String()
+	g.SystemRollingRestartCmd.Phase = g.SystemRollingRestartCmd.Flag("phase", "Specific phase to execute").String()
+	g.SystemRollingRestartCmd.PhaseTimeout = g.SystemRollingRestartCmd.Flag("timeout", "Phase execution timeout").
+		Default(defaults.PhaseTimeout).
+		Hidden().
+		Duration()
+	g.SystemRollingRestartCmd.Resume = g.SystemRollingRestartCmd.Flag("resume", "Resume aborted operation").Bool()
+	g.SystemRollingRestartCmd.Manual = g.SystemRollingRestartCmd.Flag("manual", "Do not start the operation automatically").Short('m').Bool()
+	g.SystemRollingRestartCmd.Force = g.SystemRollingRestartCmd.Flag("force", "Force phase execution").Bool()
+	g.SystemRollingRestartCmd.SkipVersionCheck = g.SystemRollingRestartCmd.Flag("skip-version-check", "Bypass version compatibility check").Hidden().Bool()
+	g.SystemRollingRestartCmd.HealthTimeout = g.SystemRollingRestartCmd.Flag("health-timeout", "Maximum time to wait for the cluster to become healthy after a node has been restarted").Default(defaults.RollingRestartHealthTimeout.String()).Duration()
+
+	g.SystemMigrateStorageDriverCmd.CmdClause = g.SystemCmd.Command("migrate-storage-driver", "Migrate docker storage driver from devicemapper to overlay2 on cluster nodes one node at a time")
+	g.SystemMigrateStorageDriverCmd.Phase = g.SystemMigrateStorageDriverCmd.Flag("phase", "Specific phase to execute").String()
+	g.SystemMigrateStorageDriverCmd.PhaseTimeout = g.SystemMigrateStorageDriverCmd.Flag("timeout", "Phase execution timeout").
+		Default(defaults.PhaseTimeout).
+		Hidden().
+		Duration()
+	g.SystemMigrateStorageDriverCmd.Resume = g.SystemMigrateStorageDriverCmd.Flag("resume", "Resume aborted operation").Bool()
+	g.SystemMigrateStorageDriverCmd.Manual = g.SystemMigrateStorageDriverCmd.Flag("manual", "Do not start the operation automatically").Short('m').Bool()
+	g.SystemMigrateStorageDriverCmd.Force = g.SystemMigrateStorageDriverCmd.Flag("force", "Force phase execution").Bool()
+	g.SystemMigrateStorageDriverCmd.Confirm = g.SystemMigrateStorageDriverCmd.Flag("confirm", "Do not ask for confirmation").Short('y').Bool()
+	g.SystemMigrateStorageDriverCmd.SkipVersionCheck = g.SystemMigrateStorageDriverCmd.Flag("skip-version-check", "Bypass version compatibility check").Hidden().Bool()
+	g.SystemMigrateStorageDriverCmd.HealthTimeout = g.SystemMigrateStorageDriverCmd.Flag("health-timeout", "Maximum time to wait for the cluster to become healthy after a node has been migrated").Default(defaults.DockerMigrationHealthTimeout.String()).Duration()
+
 	// operations on planet (planet plugin)
 	g.PlanetCmd.CmdClause = g.Command("planet", "operations with planet").Hidden()
 
@@ -696,8 +954,9 @@ func RegisterCommands(app *kingpin.Application) *Application {
 	g.ResourceRemoveCmd.CmdClause = g.ResourceCmd.Command("rm", fmt.Sprintf("Remove a configuration resource, e.g. gravity resource rm oidc google. Supported resources are: %v", modules.Get().SupportedResourcesToRemove()))
 	g.ResourceRemoveCmd.Kind = g.ResourceRemoveCmd.Arg("kind", fmt.Sprintf("resource kind, one of %v", modules.Get().SupportedResourcesToRemove())).Required().String()
 	g.ResourceRemoveCmd.Name = g.ResourceRemoveCmd.Arg("name", "resource name, e.g. github").Required().String()
-	g.ResourceRemoveCmd.Force = g.ResourceRemoveCmd.Flag("force", "Do not return errors if a resource is not found").Short('f').Bool()
+	g.ResourceRemoveCmd.Force = g.ResourceRemoveCmd.Flag("force", "Do not return errors if a resource is not found and remove it even if other objects depend on it").Short('f').Bool()
 	g.ResourceRemoveCmd.User = g.ResourceRemoveCmd.Flag("user", "user to remove resource for, defaults to currently logged in user").String()
+	g.ResourceRemoveCmd.DryRun = g.ResourceRemoveCmd.Flag("dry-run", "Report objects that depend on the resource without removing it").Bool()
 
 	// get resources returns resources
 	g.ResourceGetCmd.CmdClause = g.ResourceCmd.Command("get", fmt.Sprintf("Get configuration resources, e.g. gravity get oidc. Supported resources are: %v", modules.Get().SupportedResources()))
@@ -718,6 +977,14 @@ func Locator(s kingpin.Settings) *loc.Locator {
 	return l
 }
 
+// TransferRate defines a command line flag that accepts input
+// in transfer rate format, e.g. 10MB/s
+func TransferRate(s kingpin.Settings) *utils.TransferRate {
+	r := new(utils.TransferRate)
+	s.SetValue(r)
+	return r
+}
+
 // DockerStorageDriver defines a command line flag that recognizes
 // Docker storage drivers
 func DockerStorageDriver(s kingpin.Settings, allowed []string) *dockerStorageDriver {
@@ -750,3 +1017,13 @@ type dockerStorageDriver struct {
 	allowed []string
 	value   string
 }
+
+// registerTransferFlags registers flags with credentials
+// for transferring packages between clusters
+func registerTransferFlags(cmd *kingpin.CmdClause) TransferFlags {
+	return TransferFlags{
+		CertFile: cmd.Flag("cert", "path to the client certificate to authenticate with remote clusters").String(),
+		KeyFile:  cmd.Flag("key", "path to the client certificate private key").String(),
+		CAFile:   cmd.Flag("ca", "path to the CA certificate to verify remote clusters with").String(),
+	}
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"github.com/gravitational/gravity/lib/localenv"
+	"github.com/gravitational/gravity/lib/rehome"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/trace"
+)
+
+type migrateOpsCenterConfig struct {
+	// path is the path to the trusted cluster resource of the new Ops Center
+	path string
+	// source is the name of the Ops Center to migrate from
+	source string
+	// confirmed suppresses confirmation prompt
+	confirmed bool
+}
+
+// migrateOpsCenter connects the cluster to a new Ops Center and disconnects
+// it from the one it is currently connected to
+func migrateOpsCenter(env *localenv.LocalEnvironment, c migrateOpsCenterConfig) error {
+	data, err := utils.ReadPath(c.path)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	target, err := storage.UnmarshalTrustedCluster(data)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	clusterEnv, err := env.NewClusterEnvironment()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	cluster, err := clusterEnv.Operator.GetLocalSite()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	source, err := rehome.FindSource(clusterEnv.Users, c.source)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if !c.confirmed {
+		err = enforceConfirmation("Cluster %v will be disconnected from %v and connected to %v. Please confirm",
+			cluster.Domain, source.GetName(), target.GetName())
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
+
+	result, err := rehome.Migrate(rehome.Config{
+		Backend:     clusterEnv.Backend,
+		Users:       clusterEnv.Users,
+		ClusterName: cluster.Domain,
+		Source:      source.GetName(),
+		Target:      target,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	env.Printf("Cluster %v has been migrated from %v to %v.\n",
+		cluster.Domain, result.Source.GetName(), result.Target.GetName())
+	if result.Target.GetPullUpdates() {
+		env.Printf("Application updates will be pulled from %v.\n", result.Target.GetName())
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	kubeutils "github.com/gravitational/gravity/lib/kubernetes"
+	"github.com/gravitational/gravity/lib/localenv"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/replace"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+	"k8s.io/client-go/kubernetes"
+)
+
+type replaceConfig struct {
+	// node is the hostname, address or Kubernetes name of the node to replace
+	node string
+	// cancel forgets the recorded identity of the node
+	cancel bool
+	// confirmed suppresses confirmation prompt
+	confirmed bool
+}
+
+// replaceNode records the identity of the failed node, removes it from
+// the cluster and prints instructions to join the replacement node
+func replaceNode(env *localenv.LocalEnvironment, c replaceConfig) error {
+	if err := checkRunningAsRoot(); err != nil {
+		return trace.Wrap(err)
+	}
+
+	clusterEnv, err := env.NewClusterEnvironment()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if clusterEnv.Client == nil {
+		return trace.BadParameter("this operation can only be executed on one of the master nodes")
+	}
+
+	if c.cancel {
+		return cancelReplace(env, clusterEnv.Client, c.node)
+	}
+
+	operator := clusterEnv.Operator
+	cluster, err := operator.GetLocalSite()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	server, err := findServer(*cluster, []string{c.node})
+	if err != nil && !trace.IsNotFound(err) {
+		return trace.Wrap(err)
+	}
+
+	var identity *replace.Identity
+	if server != nil {
+		node, err := kubeutils.GetNode(clusterEnv.Client, *server)
+		if err != nil {
+			log.Warnf("Failed to query Kubernetes node for %v, labels and taints will not be restored: %v.",
+				server, trace.DebugReport(err))
+		}
+		recorded := replace.NewIdentity(*server, node, time.Now())
+		identity = &recorded
+	} else {
+		// the node has already been removed, print the remaining steps
+		identity, err = findIdentity(clusterEnv.Client, c.node)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
+
+	peer, err := findReplacePeer(*cluster, identity.Hostname)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	token, err := operator.GetExpandToken(cluster.Key())
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	steps, err := replace.NewPlan(replace.PlanConfig{
+		Identity: *identity,
+		Peer:     peer.AdvertiseIP,
+		Token:    token.Token,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	if server == nil {
+		env.Printf("Node %v has already been removed from the cluster.\n", identity.Hostname)
+		printReplacePlan(env, steps[2:], 3)
+		return nil
+	}
+
+	printReplacePlan(env, steps, 1)
+	if !c.confirmed {
+		err = enforceConfirmation("Please confirm replacing %v (%v)", server.Hostname, server.AdvertiseIP)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
+
+	if err := replace.Save(clusterEnv.Client, *identity); err != nil {
+		return trace.Wrap(err)
+	}
+	env.PrintStep("Recorded the identity of %v", identity)
+
+	key, err := operator.CreateSiteShrinkOperation(
+		ops.CreateSiteShrinkOperationRequest{
+			AccountID:  cluster.AccountID,
+			SiteDomain: cluster.Domain,
+			Servers:    []string{server.Hostname},
+			Force:      true,
+		})
+	if err != nil {
+		return trace.Wrap(err, "failed to remove node %v. Run 'gravity replace --cancel %v' "+
+			"to forget its identity", server.Hostname, server.Hostname)
+	}
+	env.PrintStep("Removing %v from the cluster", server.Hostname)
+	if err := tailOperationLogs(operator, *key); err != nil {
+		return trace.Wrap(err, "failed to remove node %v. Run 'gravity replace %v' to retry",
+			server.Hostname, server.Hostname)
+	}
+
+	env.Println("\nThe failed node has been removed. Complete the replacement by following the steps below.")
+	printReplacePlan(env, steps[2:], 3)
+	return nil
+}
+
+// cancelReplace forgets the recorded identity of the specified node
+func cancelReplace(env *localenv.LocalEnvironment, client kubernetes.Interface, name string) error {
+	identity, err := findIdentity(client, name)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if err := replace.Delete(client, identity.Hostname); err != nil {
+		return trace.Wrap(err)
+	}
+	env.Printf("Replacement of %v has been cancelled.\n", identity.Hostname)
+	return nil
+}
+
+// findIdentity returns the recorded identity of the node with
+// the specified hostname, address or Kubernetes node name
+func findIdentity(client kubernetes.Interface, name string) (*replace.Identity, error) {
+	identities, err := replace.List(client)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	for _, identity := range identities {
+		switch name {
+		case identity.Hostname, identity.AdvertiseIP, identity.NodeName:
+			return &identity, nil
+		}
+	}
+	return nil, trace.NotFound("node %v is neither a cluster node nor being replaced", name)
+}
+
+// findReplacePeer returns a master node other than the one
+// being replaced for the replacement node to join to
+func findReplacePeer(cluster ops.Site, hostname string) (*storage.Server, error) {
+	for _, server := range cluster.ClusterState.Servers {
+		if server.IsMaster() && server.Hostname != hostname {
+			return &server, nil
+		}
+	}
+	return nil, trace.BadParameter("cannot replace the last master node")
+}
+
+func printReplacePlan(env *localenv.LocalEnvironment, steps []replace.Step, first int) {
+	w := new(tabwriter.Writer)
+	w.Init(env, 0, 8, 1, '\t', 0)
+	for i, step := range steps {
+		fmt.Fprintf(w, "\n%v.\t%v\n", first+i, step.Description)
+		if step.Command != "" {
+			fmt.Fprintf(w, "\tRun:\t%v\n", step.Command)
+		}
+		fmt.Fprintf(w, "\tRollback:\t%v\n", step.Rollback)
+	}
+	w.Flush()
+	env.Println()
+}
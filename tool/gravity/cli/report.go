@@ -18,9 +18,11 @@ package cli
 
 import (
 	"compress/gzip"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/gravitational/gravity/lib/archive"
@@ -95,14 +97,14 @@ func systemReport(env *localenv.LocalEnvironment, filters []string, compressed b
 // Collect iterates through the system packages and outputs them
 // using the specified reportWriter.
 func (r packageCollector) Collect(reportWriter report.Writer, runner utils.CommandRunner) error {
-	w, err := reportWriter("gravity-packages.yaml")
+	w, err := reportWriter(report.PackagesFilename)
 	if err != nil {
 		return trace.Wrap(err)
 	}
 	framer := serializer.YAMLFramer.NewFrameWriter(w)
 	defer w.Close()
 
-	return foreachPackage(r.env, "", defaults.GravityServiceURL, formatPackage(framer))
+	return foreachPackage(r.env, "", "", defaults.GravityServiceURL, formatPackage(framer))
 }
 
 func formatPackage(w io.Writer) func(pack.PackageEnvelope) error {
@@ -169,3 +171,59 @@ func (r manifest) MarshalYAML() (interface{}, error) {
 }
 
 type manifest []byte
+
+// diffClusterReports outputs the changes between the specified cluster
+// diagnostics reports grouped by report section
+func diffClusterReports(beforePath, afterPath string) error {
+	before, err := report.LoadSummary(beforePath)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	after, err := report.LoadSummary(afterPath)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	changes := report.Diff(before, after)
+	if len(changes) == 0 {
+		fmt.Println("No changes between reports.")
+		return nil
+	}
+	var section string
+	for _, change := range changes {
+		if change.Section != section {
+			section = change.Section
+			fmt.Printf("%v:\n", section)
+		}
+		fmt.Printf("  %v\n", change)
+	}
+	return nil
+}
+
+// getAnonymizedClusterReport downloads the cluster diagnostics report
+// into a temporary file and writes its anonymized copy into targetFile
+func getAnonymizedClusterReport(env *localenv.LocalEnvironment, targetFile string) error {
+	dir, err := ioutil.TempDir("", "report")
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "report.tar.gz")
+	if err := getClusterReport(env, path, false); err != nil {
+		return trace.Wrap(err)
+	}
+	return anonymizeClusterReport(path, targetFile)
+}
+
+func anonymizeClusterReport(path, targetFile string) error {
+	f, err := os.Create(targetFile)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	defer f.Close()
+	if err := report.Anonymize(path, f); err != nil {
+		os.Remove(targetFile)
+		return trace.Wrap(err)
+	}
+	fmt.Printf("anonymized report exported to %v\n", targetFile)
+	return nil
+}
@@ -55,7 +55,7 @@ func createResource(env *localenv.LocalEnvironment, filename string, upsert bool
 }
 
 // removeResource deletes resource by name
-func removeResource(env *localenv.LocalEnvironment, kind string, name string, force bool, user string) error {
+func removeResource(env *localenv.LocalEnvironment, kind string, name string, force bool, user string, dryRun bool) error {
 	operator, err := env.SiteOperator()
 	if err != nil {
 		return trace.Wrap(err)
@@ -68,7 +68,11 @@ func removeResource(env *localenv.LocalEnvironment, kind string, name string, fo
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	err = resources.NewControl(gravityResources).Remove(kind, name, force, user)
+	control := resources.NewControl(gravityResources)
+	if dryRun {
+		return trace.Wrap(control.DryRun(os.Stdout, kind, name, user))
+	}
+	err = control.Remove(kind, name, force, user)
 	if err != nil {
 		return trace.Wrap(err)
 	}
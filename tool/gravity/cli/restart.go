@@ -0,0 +1,211 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"context"
+	"time"
+
+	"github.com/gravitational/gravity/lib/constants"
+	libfsm "github.com/gravitational/gravity/lib/fsm"
+	"github.com/gravitational/gravity/lib/localenv"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/restart"
+
+	"github.com/gravitational/trace"
+)
+
+func rollingRestart(env *localenv.LocalEnvironment, unit string, healthTimeout time.Duration, manual bool) error {
+	unit, err := restart.NormalizeUnit(unit)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	restarter, err := newRestarter(env, unit, healthTimeout)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	ctx := context.TODO()
+	if !manual {
+		err = restarter.Run(ctx, false)
+		return trace.Wrap(err)
+	}
+
+	err = restarter.Create(ctx)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	env.Println(`
+The rolling restart operation has been created in manual mode.
+
+To view the operation plan, run:
+
+$ gravity plan
+
+To perform the restart, execute each phase in the order it appears in
+the plan by running:
+
+$ sudo gravity system rolling-restart --phase=<phase-id>
+
+To resume automatic restart from any point, run:
+
+$ sudo gravity system rolling-restart --resume`)
+	return nil
+}
+
+func newRestarter(env *localenv.LocalEnvironment, unit string, healthTimeout time.Duration) (*restart.Restarter, error) {
+	operator, err := env.SiteOperator()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	cluster, err := operator.GetLocalSite()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	teleportClient, err := env.TeleportClient(constants.Localhost)
+	if err != nil {
+		return nil, trace.Wrap(err, "failed to create a teleport client")
+	}
+
+	proxy, err := teleportClient.ConnectToProxy(context.TODO())
+	if err != nil {
+		return nil, trace.Wrap(err, "failed to connect to teleport proxy")
+	}
+
+	key, err := operator.CreateClusterRollingRestartOperation(
+		ops.CreateClusterRollingRestartOperationRequest{
+			AccountID:   cluster.AccountID,
+			ClusterName: cluster.Domain,
+		},
+	)
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil, trace.NotImplemented(
+				"cluster operator does not implement the API required for rolling restart. " +
+					"Please make sure you're running the command on a compatible cluster.")
+		}
+		return nil, trace.Wrap(err)
+	}
+
+	defer func() {
+		r := recover()
+		triggered := err == nil && r == nil
+		if !triggered {
+			if errDelete := operator.DeleteSiteOperation(*key); errDelete != nil {
+				log.Warnf("Failed to clean up rolling restart operation %v: %v.",
+					key, trace.DebugReport(errDelete))
+			}
+		}
+		if r != nil {
+			panic(r)
+		}
+	}()
+
+	operation, err := operator.GetSiteOperation(*key)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	runtimePackage, err := findAnyRuntimePackage(env.Packages)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	clusterEnv, err := env.NewClusterEnvironment()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	if clusterEnv.Client == nil {
+		return nil, trace.BadParameter("this operation can only be executed on one of the master nodes")
+	}
+
+	req := deployAgentsRequest{
+		clusterState: cluster.ClusterState,
+		clusterName:  cluster.Domain,
+		clusterEnv:   clusterEnv,
+		proxy:        proxy,
+	}
+	creds, err := deployAgents(context.TODO(), env, req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	restarter, err := restart.New(restart.Config{
+		Operator:       operator,
+		Operation:      operation,
+		Servers:        cluster.ClusterState.Servers,
+		Unit:           unit,
+		RuntimePackage: runtimePackage,
+		Runner:         libfsm.NewAgentRunner(creds),
+		HealthTimeout:  healthTimeout,
+		Emitter:        env,
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return restarter, nil
+}
+
+func rollingRestartPhase(env *localenv.LocalEnvironment, phase string, phaseTimeout, healthTimeout time.Duration, force bool) error {
+	operator, err := env.SiteOperator()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	cluster, err := operator.GetLocalSite()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	operation, _, err := ops.GetLastOperation(cluster.Key(), operator)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if operation.Type != ops.OperationRollingRestart {
+		return trace.BadParameter("last operation is not a rolling restart: %v", operation)
+	}
+
+	runtimePackage, err := findAnyRuntimePackage(env.Packages)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	creds, err := libfsm.GetClientCredentials()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	restarter, err := restart.New(restart.Config{
+		Operator:       operator,
+		Operation:      operation,
+		Servers:        cluster.ClusterState.Servers,
+		RuntimePackage: runtimePackage,
+		Runner:         libfsm.NewAgentRunner(creds),
+		HealthTimeout:  healthTimeout,
+		Emitter:        env,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	err = restarter.RunPhase(context.TODO(), phase, phaseTimeout, force)
+	return trace.Wrap(err)
+}
@@ -18,24 +18,25 @@ package cli
 
 import (
 	"bytes"
-	"crypto/x509"
-	"encoding/pem"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"time"
 
 	"github.com/gravitational/gravity/lib/constants"
 	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/kubelet"
 	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/localenv"
 	"github.com/gravitational/gravity/lib/pack"
+	"github.com/gravitational/gravity/lib/renew"
 	"github.com/gravitational/gravity/lib/state"
 	"github.com/gravitational/gravity/lib/users"
 	"github.com/gravitational/gravity/lib/utils"
 
-	"github.com/cloudflare/cfssl/csr"
 	"github.com/gravitational/license/authority"
 	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
 )
 
 type rotateOptions struct {
@@ -47,7 +48,108 @@ type rotateOptions struct {
 	caPath string
 }
 
-func rotateCertificates(env *localenv.LocalEnvironment, o rotateOptions) (err error) {
+func rotateCertificates(env *localenv.LocalEnvironment, o rotateOptions) error {
+	return renewCertificates(env, o, certNames)
+}
+
+// repairKubeletCertificates renews the kubelet certificate on the local
+// node and makes kubelet use it instead of the certificates it has
+// obtained by rotation, which might have expired while the node was down
+func repairKubeletCertificates(env *localenv.LocalEnvironment, o rotateOptions) error {
+	err := renewCertificates(env, o, []string{constants.KubeletKeyPair})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	env.Println("Restarting kubelet")
+	err = kubelet.ResetRotatedCertificates(context.TODO(), logrus.WithField(trace.Component, "kubelet"))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	env.Println("Kubelet will request new certificates from the cluster")
+	return nil
+}
+
+// renewCertificatesOptions controls the renewal of all node certificates
+type renewCertificatesOptions struct {
+	rotateOptions
+	// offline means the cluster is not reachable and the certificate
+	// authority is read from the local package service
+	offline bool
+}
+
+// renewAllCertificates reissues every node certificate signed by the
+// cluster certificate authority and restarts the runtime container
+// services in dependency order
+func renewAllCertificates(env *localenv.LocalEnvironment, o renewCertificatesOptions) error {
+	archive, err := readCertAuthority(env, o)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	caKeyPair, err := archive.GetKeyPair(constants.RootKeyPair)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	stateDir, err := state.GetStateDir()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = backupSecrets(env, state.SecretDir(stateDir))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	logger := logrus.WithField(trace.Component, "renew")
+	renewed, err := renew.Renew(renew.Config{
+		SecretsDir:    state.SecretDir(stateDir),
+		CertAuthority: caKeyPair,
+		ValidFor:      o.validFor,
+		FieldLogger:   logger,
+	})
+	for _, cert := range renewed {
+		env.Printf("Renewed certificate %v.cert, valid until %v\n", cert.Name,
+			cert.NotAfter.Format(constants.HumanDateFormat))
+	}
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if len(renewed) == 0 {
+		env.Println("No certificates issued by the cluster certificate authority found")
+		return nil
+	}
+	ctx := context.TODO()
+	err = kubelet.RemoveRotatedCertificates(ctx, logger)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = renew.RestartServices(ctx, renew.NewPlanetServices(logger), func(service string) {
+		env.Printf("Restarting %v\n", service)
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	env.Println("Certificates have been renewed")
+	return nil
+}
+
+// readCertAuthority returns the cluster certificate authority from the file
+// if specified, otherwise from the local package service when offline or
+// from the cluster package service
+func readCertAuthority(env *localenv.LocalEnvironment, o renewCertificatesOptions) (utils.TLSArchive, error) {
+	if o.caPath != "" {
+		env.Printf("Using certificate authority from %v\n", o.caPath)
+		return readCertAuthorityFromFile(o.caPath)
+	}
+	if o.offline {
+		return readCertAuthorityPackage(env.Packages, o.clusterName)
+	}
+	packages, err := env.ClusterPackages()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return readCertAuthorityPackage(packages, o.clusterName)
+}
+
+// renewCertificates renews the specified certificates on the local node
+func renewCertificates(env *localenv.LocalEnvironment, o rotateOptions, names []string) (err error) {
 	var archive utils.TLSArchive
 	if o.caPath != "" {
 		archive, err = readCertAuthorityFromFile(o.caPath)
@@ -74,9 +176,9 @@ func rotateCertificates(env *localenv.LocalEnvironment, o rotateOptions) (err er
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	for _, certName := range certNames {
+	for _, certName := range names {
 		// read x509 cert from disk
-		cert, err := readCertificate(state.Secret(stateDir, certName+".cert"))
+		cert, err := renew.ReadCertificate(state.Secret(stateDir, certName+".cert"))
 		if err != nil {
 			if !trace.IsNotFound(err) {
 				return trace.Wrap(err)
@@ -86,7 +188,7 @@ func rotateCertificates(env *localenv.LocalEnvironment, o rotateOptions) (err er
 		}
 		env.Printf("Renewing certificate %v.cert\n", certName)
 		// copy all data from the old cert into the new csr
-		req := certToCSR(cert)
+		req := renew.CertificateRequest(cert)
 		// generate a new key pair
 		keyPair, err := authority.GenerateCertificate(req, caKeyPair, baseKeyPair.KeyPEM, o.validFor)
 		if err != nil {
@@ -142,38 +244,6 @@ func backupSecrets(env *localenv.LocalEnvironment, path string) error {
 	return nil
 }
 
-// readCertificate returns the parsed x509 cert from the provided path
-func readCertificate(path string) (*x509.Certificate, error) {
-	certBytes, err := ioutil.ReadFile(path)
-	if err != nil {
-		return nil, trace.ConvertSystemError(err)
-	}
-	block, _ := pem.Decode(certBytes)
-	if block == nil {
-		return nil, trace.BadParameter("failed to decode certificate at %v", path)
-	}
-	cert, err := x509.ParseCertificate(block.Bytes)
-	if err != nil {
-		return nil, trace.Wrap(err)
-	}
-	return cert, nil
-}
-
-// certToCSR creates a new CSR using the data from the provided certificate
-func certToCSR(cert *x509.Certificate) csr.CertificateRequest {
-	req := csr.CertificateRequest{
-		CN:    cert.Subject.CommonName,
-		Hosts: cert.DNSNames,
-	}
-	for _, ip := range cert.IPAddresses {
-		req.Hosts = append(req.Hosts, ip.String())
-	}
-	for _, o := range cert.Subject.Organization {
-		req.Names = append(req.Names, csr.Name{O: o})
-	}
-	return req
-}
-
 func readCertAuthorityPackage(packages pack.PackageService, clusterName string) (utils.TLSArchive, error) {
 	locator, err := loc.ParseLocator(fmt.Sprintf("%v/%v:0.0.1", clusterName,
 		constants.CertAuthorityPackage))
@@ -26,6 +26,7 @@ import (
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	appapi "github.com/gravitational/gravity/lib/app"
 	"github.com/gravitational/gravity/lib/constants"
@@ -34,9 +35,11 @@ import (
 	"github.com/gravitational/gravity/lib/httplib"
 	"github.com/gravitational/gravity/lib/install"
 	"github.com/gravitational/gravity/lib/localenv"
+	"github.com/gravitational/gravity/lib/ops"
 	"github.com/gravitational/gravity/lib/process"
 	"github.com/gravitational/gravity/lib/schema"
 	"github.com/gravitational/gravity/lib/systemservice"
+	"github.com/gravitational/gravity/lib/tracing"
 	"github.com/gravitational/gravity/lib/utils"
 
 	"github.com/gravitational/configure/cstrings"
@@ -76,6 +79,10 @@ func Run(g *Application) error {
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	if err := tracing.InitFromEnvironment(); err != nil {
+		log.Warnf("Failed to initialize tracing: %v.", trace.DebugReport(err))
+	}
+	defer tracing.Close()
 	return Execute(g, cmd, extraArgs)
 }
 
@@ -94,7 +101,8 @@ func InitAndCheck(g *Application, cmd string) error {
 	case g.RPCAgentDeployCmd.FullCommand(),
 		g.RPCAgentInstallCmd.FullCommand(),
 		g.RPCAgentRunCmd.FullCommand(),
-		g.PlanCmd.FullCommand(),
+		g.PlanDisplayCmd.FullCommand(),
+		g.PlanExecuteCmd.FullCommand(),
 		g.UpgradeCmd.FullCommand(),
 		g.RollbackCmd.FullCommand(),
 		g.ResourceCreateCmd.FullCommand():
@@ -110,6 +118,7 @@ func InitAndCheck(g *Application, cmd string) error {
 	// addition to journald)
 	switch cmd {
 	case g.InstallCmd.FullCommand(),
+		g.DevUpCmd.FullCommand(),
 		g.WizardCmd.FullCommand(),
 		g.JoinCmd.FullCommand(),
 		g.AutoJoinCmd.FullCommand(),
@@ -118,13 +127,14 @@ func InitAndCheck(g *Application, cmd string) error {
 		g.RPCAgentRunCmd.FullCommand(),
 		g.LeaveCmd.FullCommand(),
 		g.RemoveCmd.FullCommand(),
+		g.ReplaceCmd.FullCommand(),
 		g.OpsAgentCmd.FullCommand():
 		install.InitLogging(*g.SystemLogFile)
 		// install and join command also duplicate their logs to the file in
 		// the current directory for convenience, unless the user set their
 		// own location
 		switch cmd {
-		case g.InstallCmd.FullCommand(), g.JoinCmd.FullCommand():
+		case g.InstallCmd.FullCommand(), g.DevUpCmd.FullCommand(), g.JoinCmd.FullCommand():
 			if *g.SystemLogFile == defaults.TelekubeSystemLog {
 				install.InitLogging(defaults.TelekubeSystemLogFile)
 			}
@@ -144,7 +154,10 @@ func InitAndCheck(g *Application, cmd string) error {
 	switch cmd {
 	case g.UpdateCompleteCmd.FullCommand(),
 		g.UpdateTriggerCmd.FullCommand(),
-		g.RemoveCmd.FullCommand():
+		g.RemoveCmd.FullCommand(),
+		g.ReplaceCmd.FullCommand(),
+		g.OpsMigrateCmd.FullCommand(),
+		g.RPCAgentJoinCmd.FullCommand():
 		localEnv, err := g.LocalEnv(cmd)
 		if err != nil {
 			return trace.Wrap(err)
@@ -166,12 +179,19 @@ func InitAndCheck(g *Application, cmd string) error {
 		g.RPCAgentShutdownCmd.FullCommand(),
 		g.RPCAgentInstallCmd.FullCommand(),
 		g.RPCAgentRunCmd.FullCommand(),
+		g.RPCAgentJoinCmd.FullCommand(),
+		g.BakerCmd.FullCommand(),
 		g.SystemServiceInstallCmd.FullCommand(),
 		g.SystemServiceUninstallCmd.FullCommand(),
 		g.EnterCmd.FullCommand(),
 		g.PlanetEnterCmd.FullCommand(),
-		g.PlanCmd.FullCommand(),
+		g.PlanDisplayCmd.FullCommand(),
+		g.PlanExecuteCmd.FullCommand(),
+		g.PlanApproveCmd.FullCommand(),
+		g.PlanRequireApprovalCmd.FullCommand(),
 		g.InstallCmd.FullCommand(),
+		g.DevUpCmd.FullCommand(),
+		g.DevDownCmd.FullCommand(),
 		g.JoinCmd.FullCommand(),
 		g.AutoJoinCmd.FullCommand(),
 		g.SystemDevicemapperMountCmd.FullCommand(),
@@ -180,7 +200,19 @@ func InitAndCheck(g *Application, cmd string) error {
 		g.RestoreCmd.FullCommand(),
 		g.GarbageCollectCmd.FullCommand(),
 		g.SystemGCRegistryCmd.FullCommand(),
-		g.CheckCmd.FullCommand():
+		g.EtcdBackupCmd.FullCommand(),
+		g.EtcdRestoreCmd.FullCommand(),
+		g.EtcdScheduleCmd.FullCommand(),
+		g.EtcdUnscheduleCmd.FullCommand(),
+		g.SystemOSUpdateCmd.FullCommand(),
+		g.SystemRollingRestartCmd.FullCommand(),
+		g.SystemMigrateStorageDriverCmd.FullCommand(),
+		g.SystemFirewallApplyCmd.FullCommand(),
+		g.SystemFirewallRemoveCmd.FullCommand(),
+		g.CheckManifestCmd.FullCommand(),
+		g.CheckDisksCmd.FullCommand(),
+		g.CheckDNSCmd.FullCommand(),
+		g.CheckNetworkCmd.FullCommand():
 		if err := checkRunningAsRoot(); err != nil {
 			return trace.Wrap(err)
 		}
@@ -191,7 +223,12 @@ func InitAndCheck(g *Application, cmd string) error {
 	case g.SystemUpdateCmd.FullCommand(),
 		g.UpdateSystemCmd.FullCommand(),
 		g.UpgradeCmd.FullCommand(),
+		g.PlanExecuteCmd.FullCommand(),
 		g.SystemGCRegistryCmd.FullCommand(),
+		g.SystemFirewallApplyCmd.FullCommand(),
+		g.SystemFirewallRemoveCmd.FullCommand(),
+		g.EtcdBackupCmd.FullCommand(),
+		g.EtcdRestoreCmd.FullCommand(),
 		g.PlanetEnterCmd.FullCommand(),
 		g.EnterCmd.FullCommand():
 		if utils.CheckInPlanet() {
@@ -223,6 +260,10 @@ func Execute(g *Application, cmd string, extraArgs []string) error {
 		return initCluster(*g.SiteInitCmd.ConfigPath, *g.SiteInitCmd.InitPath)
 	case g.SiteStatusCmd.FullCommand():
 		return statusSite()
+	case g.ReportDiffCmd.FullCommand():
+		return diffClusterReports(*g.ReportDiffCmd.Before, *g.ReportDiffCmd.After)
+	case g.ReportAnonymizeCmd.FullCommand():
+		return anonymizeClusterReport(*g.ReportAnonymizeCmd.Path, *g.ReportAnonymizeCmd.FilePath)
 	}
 
 	localEnv, err := g.LocalEnv(cmd)
@@ -231,6 +272,11 @@ func Execute(g *Application, cmd string, extraArgs []string) error {
 	}
 	defer localEnv.Close()
 
+	if g.isPackageChangeCommand(cmd) {
+		// drop package lists cached by previous commands
+		defer invalidateMetadataCache(localEnv, packagesCacheKey)
+	}
+
 	// the following commands must run when Kubernetes is available (can
 	// be inside gravity cluster or generic Kubernetes cluster)
 	switch cmd {
@@ -261,7 +307,8 @@ func Execute(g *Application, cmd string, extraArgs []string) error {
 	// create an environment where join-specific data is stored
 	var joinEnv *localenv.LocalEnvironment
 	switch cmd {
-	case g.JoinCmd.FullCommand(), g.AutoJoinCmd.FullCommand(), g.PlanCmd.FullCommand(), g.RollbackCmd.FullCommand():
+	case g.JoinCmd.FullCommand(), g.AutoJoinCmd.FullCommand(), g.PlanDisplayCmd.FullCommand(),
+		g.PlanExecuteCmd.FullCommand(), g.RollbackCmd.FullCommand(), g.RPCAgentJoinCmd.FullCommand():
 		joinEnv, err = g.JoinEnv()
 		if err != nil {
 			return trace.Wrap(err)
@@ -269,6 +316,14 @@ func Execute(g *Application, cmd string, extraArgs []string) error {
 		defer joinEnv.Close()
 	}
 
+	// make sure the versions of gravity components are compatible
+	// before an operation is started or resumed
+	if checked, withAgents := g.isVersionSkewChecked(cmd); checked {
+		if err := checkVersionSkew(localEnv, withAgents); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+
 	switch cmd {
 	case g.OpsAgentCmd.FullCommand():
 		return agent(localEnv, agentConfig{
@@ -311,10 +366,11 @@ func Execute(g *Application, cmd string, extraArgs []string) error {
 		}
 		if *g.JoinCmd.Phase != "" || *g.JoinCmd.Complete {
 			return executeJoinPhase(localEnv, joinEnv, PhaseParams{
-				PhaseID:  *g.JoinCmd.Phase,
-				Force:    *g.JoinCmd.Force,
-				Timeout:  *g.JoinCmd.PhaseTimeout,
-				Complete: *g.JoinCmd.Complete,
+				PhaseID:     *g.JoinCmd.Phase,
+				Force:       *g.JoinCmd.Force,
+				Timeout:     *g.JoinCmd.PhaseTimeout,
+				Complete:    *g.JoinCmd.Complete,
+				OperationID: *g.JoinCmd.OperationID,
 			})
 		}
 		return Join(localEnv, joinEnv, NewJoinConfig(g))
@@ -328,13 +384,19 @@ func Execute(g *Application, cmd string, extraArgs []string) error {
 			dockerDevice:  *g.AutoJoinCmd.DockerDevice,
 			mounts:        *g.AutoJoinCmd.Mounts,
 		})
+	case g.BakerCmd.FullCommand():
+		return bake(localEnv,
+			*g.BakerCmd.Path,
+			*g.BakerCmd.Role,
+			*g.BakerCmd.Arch)
 	case g.UpdateCheckCmd.FullCommand():
 		return updateCheck(localEnv, *g.UpdateCheckCmd.App)
 	case g.UpdateTriggerCmd.FullCommand():
 		return updateTrigger(localEnv,
 			upgradeEnv,
 			*g.UpdateTriggerCmd.App,
-			*g.UpdateTriggerCmd.Manual)
+			*g.UpdateTriggerCmd.Manual,
+			*g.UpdateTriggerCmd.SkipVersionCheck)
 	case g.UpgradeCmd.FullCommand():
 		if *g.UpgradeCmd.Resume {
 			*g.UpgradeCmd.Phase = fsm.RootPhase
@@ -351,10 +413,20 @@ func Execute(g *Application, cmd string, extraArgs []string) error {
 		if *g.UpgradeCmd.Complete {
 			return completeUpgrade(localEnv, upgradeEnv)
 		}
+		if *g.UpgradeCmd.Unattended {
+			return unattendedUpgrade(localEnv, upgradeEnv, unattendedUpgradeParams{
+				app:              *g.UpgradeCmd.App,
+				retries:          *g.UpgradeCmd.Retries,
+				healthTimeout:    *g.UpgradeCmd.HealthTimeout,
+				forceRollback:    *g.UpgradeCmd.ForceRollback,
+				skipVersionCheck: *g.UpgradeCmd.SkipVersionCheck,
+			})
+		}
 		return updateTrigger(localEnv,
 			upgradeEnv,
 			*g.UpgradeCmd.App,
-			*g.UpgradeCmd.Manual)
+			*g.UpgradeCmd.Manual,
+			*g.UpgradeCmd.SkipVersionCheck)
 	case g.RollbackCmd.FullCommand():
 		return rollbackOperationPhase(localEnv,
 			upgradeEnv,
@@ -365,7 +437,7 @@ func Execute(g *Application, cmd string, extraArgs []string) error {
 				skipVersionCheck: *g.RollbackCmd.SkipVersionCheck,
 				timeout:          *g.RollbackCmd.PhaseTimeout,
 			})
-	case g.PlanCmd.FullCommand():
+	case g.PlanDisplayCmd.FullCommand():
 		if *g.PlanCmd.Init {
 			return initOperationPlan(localEnv, upgradeEnv)
 		}
@@ -373,27 +445,57 @@ func Execute(g *Application, cmd string, extraArgs []string) error {
 			return syncOperationPlan(localEnv, upgradeEnv)
 		}
 		return displayOperationPlan(localEnv, upgradeEnv, joinEnv, *g.PlanCmd.OperationID, *g.PlanCmd.Output)
+	case g.PlanExecuteCmd.FullCommand():
+		return executePlan(localEnv, upgradeEnv, joinEnv, executePlanParams{
+			phaseID:          *g.PlanExecuteCmd.Phase,
+			until:            *g.PlanExecuteCmd.Until,
+			force:            *g.PlanExecuteCmd.Force,
+			skipVersionCheck: *g.PlanExecuteCmd.SkipVersionCheck,
+			timeout:          *g.PlanExecuteCmd.Timeout,
+		})
+	case g.PlanApproveCmd.FullCommand():
+		return approvePhase(localEnv, upgradeEnv, *g.PlanCmd.OperationID, *g.PlanApproveCmd.Phase, false)
+	case g.PlanRequireApprovalCmd.FullCommand():
+		return approvePhase(localEnv, upgradeEnv, *g.PlanCmd.OperationID, *g.PlanRequireApprovalCmd.Phase, true)
 	case g.LeaveCmd.FullCommand():
 		return leave(localEnv, leaveConfig{
 			force:     *g.LeaveCmd.Force,
 			confirmed: *g.LeaveCmd.Confirm,
 		})
+	case g.DevUpCmd.FullCommand():
+		return startInstall(localEnv, NewDevInstallConfig(g))
+	case g.DevDownCmd.FullCommand():
+		return systemUninstall(localEnv, *g.DevDownCmd.Confirm)
 	case g.RemoveCmd.FullCommand():
 		return remove(localEnv, removeConfig{
 			server:    *g.RemoveCmd.Node,
 			force:     *g.RemoveCmd.Force,
 			confirmed: *g.RemoveCmd.Confirm,
 		})
+	case g.ReplaceCmd.FullCommand():
+		return replaceNode(localEnv, replaceConfig{
+			node:      *g.ReplaceCmd.Node,
+			cancel:    *g.ReplaceCmd.Cancel,
+			confirmed: *g.ReplaceCmd.Confirm,
+		})
 	case g.StatusCmd.FullCommand():
 		printOptions := printOptions{
 			token:       *g.StatusCmd.Token,
 			operationID: *g.StatusCmd.OperationID,
 			quiet:       *g.Silent,
 			format:      *g.StatusCmd.Output,
+			refresh:     *g.StatusCmd.Refresh,
 		}
 		if *g.StatusCmd.Tail {
 			return tailStatus(localEnv, *g.StatusCmd.OperationID)
 		}
+		if *g.StatusCmd.Watch {
+			return statusWatch(localEnv, printOptions, watchConfig{
+				interval:   time.Duration(*g.StatusCmd.Seconds) * time.Second,
+				webhookURL: *g.StatusCmd.NotifyWebhook,
+				desktop:    *g.StatusCmd.NotifyDesktop,
+			})
+		}
 		if *g.StatusCmd.Seconds != 0 {
 			return statusPeriodic(localEnv, printOptions, *g.StatusCmd.Seconds)
 		} else {
@@ -501,7 +603,8 @@ func Execute(g *Application, cmd string, extraArgs []string) error {
 			req,
 			*g.AppImportCmd.OpsCenterURL,
 			*g.Silent,
-			*g.AppImportCmd.Parallel)
+			*g.AppImportCmd.Parallel,
+			*g.AppImportCmd.RateLimit)
 	case g.AppExportCmd.FullCommand():
 		return exportApp(localEnv,
 			*g.AppExportCmd.Locator,
@@ -555,7 +658,9 @@ func Execute(g *Application, cmd string, extraArgs []string) error {
 			*g.PackImportCmd.Locator,
 			*g.PackImportCmd.CheckManifest,
 			*g.PackImportCmd.OpsCenterURL,
-			*g.PackImportCmd.Labels)
+			*g.PackImportCmd.Labels,
+			*g.PackImportCmd.Force,
+			*g.PackImportCmd.Arch)
 	case g.PackUnpackCmd.FullCommand():
 		return unpackPackage(localEnv,
 			*g.PackUnpackCmd.Locator,
@@ -575,12 +680,22 @@ func Execute(g *Application, cmd string, extraArgs []string) error {
 	case g.PackListCmd.FullCommand():
 		return listPackages(localEnv,
 			*g.PackListCmd.Repository,
-			*g.PackListCmd.OpsCenterURL)
+			*g.PackListCmd.Selector,
+			*g.PackListCmd.OpsCenterURL,
+			*g.PackListCmd.Refresh)
 	case g.PackDeleteCmd.FullCommand():
 		return deletePackage(localEnv,
 			*g.PackDeleteCmd.Locator,
 			*g.PackDeleteCmd.Force,
 			*g.PackDeleteCmd.OpsCenterURL)
+	case g.PackPinCmd.FullCommand():
+		return pinPackage(localEnv,
+			*g.PackPinCmd.Locator,
+			*g.PackPinCmd.OpsCenterURL)
+	case g.PackUnpinCmd.FullCommand():
+		return unpinPackage(localEnv,
+			*g.PackUnpinCmd.Locator,
+			*g.PackUnpinCmd.OpsCenterURL)
 	case g.PackConfigureCmd.FullCommand():
 		return configurePackage(localEnv,
 			*g.PackConfigureCmd.Package,
@@ -595,30 +710,71 @@ func Execute(g *Application, cmd string, extraArgs []string) error {
 	case g.PackPushCmd.FullCommand():
 		return pushPackage(localEnv,
 			*g.PackPushCmd.Package,
-			*g.PackPushCmd.OpsCenterURL)
+			*g.PackPushCmd.From,
+			*g.PackPushCmd.To,
+			*g.PackPushCmd.OpsCenterURL,
+			g.PackPushCmd.TransferFlags)
 	case g.PackPullCmd.FullCommand():
 		return pullPackage(localEnv,
 			*g.PackPullCmd.Package,
+			*g.PackPullCmd.From,
+			*g.PackPullCmd.To,
 			*g.PackPullCmd.OpsCenterURL,
 			*g.PackPullCmd.Labels,
-			*g.PackPullCmd.Force)
+			*g.PackPullCmd.Force,
+			g.PackPullCmd.TransferFlags)
 	case g.PackLabelsCmd.FullCommand():
 		return updatePackageLabels(localEnv,
 			*g.PackLabelsCmd.Package,
 			*g.PackLabelsCmd.OpsCenterURL,
 			*g.PackLabelsCmd.Add,
 			*g.PackLabelsCmd.Remove)
+	case g.PackLifecycleCmd.FullCommand():
+		return setPackageLifecycle(localEnv,
+			*g.PackLifecycleCmd.Package,
+			*g.PackLifecycleCmd.Lifecycle,
+			*g.PackLifecycleCmd.OpsCenterURL)
+	case g.PackDiffCmd.FullCommand():
+		return diffPackages(localEnv,
+			*g.PackDiffCmd.From,
+			*g.PackDiffCmd.To,
+			*g.PackDiffCmd.OpsCenterURL,
+			*g.PackDiffCmd.Output)
+	case g.PackAliasCmd.FullCommand():
+		return upsertPackageAlias(localEnv,
+			*g.PackAliasCmd.Alias,
+			*g.PackAliasCmd.Package,
+			*g.PackAliasCmd.OpsCenterURL)
+	case g.PackUnaliasCmd.FullCommand():
+		return deletePackageAlias(localEnv,
+			*g.PackUnaliasCmd.Alias,
+			*g.PackUnaliasCmd.OpsCenterURL)
+	case g.PackDedupConfigCmd.FullCommand():
+		return dedupPlanetConfigPackages(localEnv,
+			*g.PackDedupConfigCmd.OpsCenterURL,
+			*g.PackDedupConfigCmd.DryRun)
+	case g.PackStatsCmd.FullCommand():
+		return printPackageStats(localEnv,
+			*g.PackStatsCmd.OpsCenterURL,
+			*g.PackStatsCmd.Output)
 		// OpsCenter commands
 	case g.OpsConnectCmd.FullCommand():
 		return connectToOpsCenter(localEnv,
 			*g.OpsConnectCmd.OpsCenterURL,
 			*g.OpsConnectCmd.Username,
-			*g.OpsConnectCmd.Password)
+			*g.OpsConnectCmd.Password,
+			*g.OpsConnectCmd.RateLimit)
 	case g.OpsDisconnectCmd.FullCommand():
 		return disconnectFromOpsCenter(localEnv,
 			*g.OpsDisconnectCmd.OpsCenterURL)
 	case g.OpsListCmd.FullCommand():
 		return listOpsCenters(localEnv)
+	case g.OpsMigrateCmd.FullCommand():
+		return migrateOpsCenter(localEnv, migrateOpsCenterConfig{
+			path:      *g.OpsMigrateCmd.Path,
+			source:    *g.OpsMigrateCmd.From,
+			confirmed: *g.OpsMigrateCmd.Confirm,
+		})
 	case g.UserCreateCmd.FullCommand():
 		return createUser(localEnv,
 			*g.UserCreateCmd.OpsCenterURL,
@@ -642,8 +798,9 @@ func Execute(g *Application, cmd string, extraArgs []string) error {
 			*g.APIKeyDeleteCmd.OpsCenterURL,
 			*g.APIKeyDeleteCmd.Email,
 			*g.APIKeyDeleteCmd.Token)
-	case g.ReportCmd.FullCommand():
-		return getClusterReport(localEnv, *g.ReportCmd.FilePath)
+	case g.ReportGenerateCmd.FullCommand():
+		return getClusterReport(localEnv, *g.ReportGenerateCmd.FilePath,
+			*g.ReportGenerateCmd.Anonymize)
 	// cluster commands
 	case g.SiteListCmd.FullCommand():
 		return listSites(localEnv, *g.SiteListCmd.OpsCenterURL)
@@ -657,6 +814,23 @@ func Execute(g *Application, cmd string, extraArgs []string) error {
 		return resetPassword(localEnv)
 	case g.StatusResetCmd.FullCommand():
 		return resetClusterState(localEnv)
+	case g.ReleaseNodeCmd.FullCommand():
+		return releaseQuarantinedNode(localEnv, *g.ReleaseNodeCmd.Addr)
+	case g.OperationLocksCmd.FullCommand():
+		return showOperationLocks(localEnv, *g.OperationLocksCmd.Output)
+	case g.OperationLogsCmd.FullCommand():
+		return showOperationLogs(localEnv, *g.OperationLogsCmd.OperationID, ops.LogFilter{
+			Component: *g.OperationLogsCmd.Component,
+			Phase:     *g.OperationLogsCmd.Phase,
+			Level:     *g.OperationLogsCmd.Level,
+		}, *g.OperationLogsCmd.Output)
+	case g.OperationReleaseLocksCmd.FullCommand():
+		return releaseOperationLocks(localEnv, releaseLocksConfig{
+			operationID: *g.OperationReleaseLocksCmd.OperationID,
+			reason:      *g.OperationReleaseLocksCmd.Reason,
+			force:       *g.OperationReleaseLocksCmd.Force,
+			confirmed:   *g.OperationReleaseLocksCmd.Confirm,
+		})
 	case g.LocalSiteCmd.FullCommand():
 		return getLocalSite(localEnv)
 	// system service commands
@@ -666,6 +840,21 @@ func Execute(g *Application, cmd string, extraArgs []string) error {
 			validFor:    *g.SystemRotateCertsCmd.ValidFor,
 			caPath:      *g.SystemRotateCertsCmd.CAPath,
 		})
+	case g.SystemRepairKubeletCertsCmd.FullCommand():
+		return repairKubeletCertificates(localEnv, rotateOptions{
+			clusterName: *g.SystemRepairKubeletCertsCmd.ClusterName,
+			validFor:    *g.SystemRepairKubeletCertsCmd.ValidFor,
+			caPath:      *g.SystemRepairKubeletCertsCmd.CAPath,
+		})
+	case g.SystemRenewCertsCmd.FullCommand():
+		return renewAllCertificates(localEnv, renewCertificatesOptions{
+			rotateOptions: rotateOptions{
+				clusterName: *g.SystemRenewCertsCmd.ClusterName,
+				validFor:    *g.SystemRenewCertsCmd.ValidFor,
+				caPath:      *g.SystemRenewCertsCmd.CAPath,
+			},
+			offline: *g.SystemRenewCertsCmd.Offline,
+		})
 	case g.SystemExportCACmd.FullCommand():
 		return exportCertificateAuthority(localEnv,
 			*g.SystemExportCACmd.ClusterName,
@@ -700,12 +889,40 @@ func Execute(g *Application, cmd string, extraArgs []string) error {
 			*g.SystemRollbackCmd.WithStatus)
 	case g.SystemStepDownCmd.FullCommand():
 		return stepDown(localEnv)
+	case g.SystemWatchdogCmd.FullCommand():
+		return systemWatchdog(localEnv, watchdogConfig{
+			services:    *g.SystemWatchdogCmd.Services,
+			interval:    *g.SystemWatchdogCmd.Interval,
+			window:      *g.SystemWatchdogCmd.Window,
+			maxRestarts: *g.SystemWatchdogCmd.MaxRestarts,
+			webhookURL:  *g.SystemWatchdogCmd.NotifyWebhook,
+		})
 	case g.BackupCmd.FullCommand():
 		return backup(localEnv,
 			*g.BackupCmd.Tarball,
 			*g.BackupCmd.Timeout,
 			*g.BackupCmd.Follow,
 			*g.Silent)
+	case g.EtcdBackupCmd.FullCommand():
+		return etcdBackup(localEnv,
+			*g.EtcdBackupCmd.Location,
+			*g.EtcdBackupCmd.Retain,
+			*g.EtcdBackupCmd.Interval)
+	case g.EtcdRestoreCmd.FullCommand():
+		return etcdRestore(localEnv,
+			*g.EtcdRestoreCmd.Location,
+			*g.EtcdRestoreCmd.Name,
+			*g.EtcdRestoreCmd.VerifyOnly,
+			*g.EtcdRestoreCmd.Confirmed)
+	case g.EtcdListCmd.FullCommand():
+		return etcdBackupList(localEnv, *g.EtcdListCmd.Location)
+	case g.EtcdScheduleCmd.FullCommand():
+		return etcdBackupSchedule(localEnv,
+			*g.EtcdScheduleCmd.Location,
+			*g.EtcdScheduleCmd.Retain,
+			*g.EtcdScheduleCmd.Interval)
+	case g.EtcdUnscheduleCmd.FullCommand():
+		return etcdBackupUnschedule(localEnv)
 	case g.RestoreCmd.FullCommand():
 		return restore(localEnv,
 			*g.RestoreCmd.Tarball,
@@ -774,10 +991,49 @@ func Execute(g *Application, cmd string, extraArgs []string) error {
 		return removeUnusedPackages(localEnv,
 			*g.SystemGCPackageCmd.DryRun,
 			*g.SystemGCPackageCmd.Cluster)
+	case g.SystemFirewallApplyCmd.FullCommand():
+		return applyFirewall(localEnv, *g.SystemFirewallApplyCmd.Backend)
+	case g.SystemFirewallRemoveCmd.FullCommand():
+		return removeFirewall(localEnv, *g.SystemFirewallRemoveCmd.Backend)
+	case g.SystemFirewallShowCmd.FullCommand():
+		return showFirewall(localEnv)
 	case g.SystemGCRegistryCmd.FullCommand():
 		return removeUnusedImages(localEnv,
 			*g.SystemGCRegistryCmd.DryRun,
 			*g.SystemGCRegistryCmd.Confirm)
+	case g.SystemRollingRestartCmd.FullCommand():
+		phase := *g.SystemRollingRestartCmd.Phase
+		if *g.SystemRollingRestartCmd.Resume {
+			phase = fsm.RootPhase
+		}
+		if phase != "" {
+			return rollingRestartPhase(localEnv, phase, *g.SystemRollingRestartCmd.PhaseTimeout,
+				*g.SystemRollingRestartCmd.HealthTimeout, *g.SystemRollingRestartCmd.Force)
+		}
+		return rollingRestart(localEnv, *g.SystemRollingRestartCmd.Unit,
+			*g.SystemRollingRestartCmd.HealthTimeout, *g.SystemRollingRestartCmd.Manual)
+	case g.SystemMigrateStorageDriverCmd.FullCommand():
+		phase := *g.SystemMigrateStorageDriverCmd.Phase
+		if *g.SystemMigrateStorageDriverCmd.Resume {
+			phase = fsm.RootPhase
+		}
+		if phase != "" {
+			return migrateStorageDriverPhase(localEnv, phase, *g.SystemMigrateStorageDriverCmd.PhaseTimeout,
+				*g.SystemMigrateStorageDriverCmd.HealthTimeout, *g.SystemMigrateStorageDriverCmd.Force)
+		}
+		return migrateStorageDriver(localEnv, *g.SystemMigrateStorageDriverCmd.HealthTimeout,
+			*g.SystemMigrateStorageDriverCmd.Manual, *g.SystemMigrateStorageDriverCmd.Confirm)
+	case g.SystemDownloadBinaryCmd.FullCommand():
+		return downloadBinary(localEnv, *g.SystemDownloadBinaryCmd.Version,
+			*g.SystemDownloadBinaryCmd.Path)
+	case g.SystemOSUpdateCmd.FullCommand():
+		return updateOS(localEnv, osUpdateConfig{
+			hook:          *g.SystemOSUpdateCmd.Hook,
+			nodes:         *g.SystemOSUpdateCmd.Nodes,
+			reboot:        *g.SystemOSUpdateCmd.Reboot,
+			hookTimeout:   *g.SystemOSUpdateCmd.HookTimeout,
+			healthTimeout: *g.SystemOSUpdateCmd.HealthTimeout,
+		})
 	case g.PlanetEnterCmd.FullCommand(), g.EnterCmd.FullCommand():
 		return planetEnter(localEnv, extraArgs)
 	case g.ExecCmd.FullCommand():
@@ -815,7 +1071,8 @@ func Execute(g *Application, cmd string, extraArgs []string) error {
 			*g.ResourceRemoveCmd.Kind,
 			*g.ResourceRemoveCmd.Name,
 			*g.ResourceRemoveCmd.Force,
-			*g.ResourceRemoveCmd.User)
+			*g.ResourceRemoveCmd.User,
+			*g.ResourceRemoveCmd.DryRun)
 	case g.ResourceGetCmd.FullCommand():
 		return getResources(localEnv,
 			*g.ResourceGetCmd.Kind,
@@ -832,11 +1089,24 @@ func Execute(g *Application, cmd string, extraArgs []string) error {
 			*g.RPCAgentRunCmd.Args)
 	case g.RPCAgentShutdownCmd.FullCommand():
 		return rpcAgentShutdown(localEnv)
-	case g.CheckCmd.FullCommand():
+	case g.RPCAgentJoinCmd.FullCommand():
+		return joinBatch(localEnv, joinEnv, *g.RPCAgentJoinCmd.Batch,
+			*g.RPCAgentJoinCmd.Timeout)
+	case g.CheckManifestCmd.FullCommand():
 		return checkManifest(localEnv,
-			*g.CheckCmd.ManifestFile,
-			*g.CheckCmd.Profile,
-			*g.CheckCmd.AutoFix)
+			*g.CheckManifestCmd.ManifestFile,
+			*g.CheckManifestCmd.Profile,
+			*g.CheckManifestCmd.AutoFix,
+			*g.CheckCmd.Recommend)
+	case g.CheckDisksCmd.FullCommand():
+		return checkDisks(localEnv, *g.CheckDisksCmd.FixPlan)
+	case g.CheckDNSCmd.FullCommand():
+		return checkDNS(localEnv, *g.CheckDNSCmd.Nodes, *g.CheckDNSCmd.Names,
+			*g.CheckDNSCmd.Timeout)
+	case g.CheckNetworkCmd.FullCommand():
+		return checkNetwork(localEnv, *g.CheckNetworkCmd.Timeout)
+	case g.CheckDialCmd.FullCommand():
+		return checkDial(*g.CheckDialCmd.Addrs, *g.CheckDialCmd.Timeout)
 	}
 	return trace.NotFound("unknown command %v", cmd)
 }
@@ -122,7 +122,10 @@ func listSites(env *localenv.LocalEnvironment, opsCenterURL string) error {
 	return nil
 }
 
-func getClusterReport(env *localenv.LocalEnvironment, targetFile string) error {
+func getClusterReport(env *localenv.LocalEnvironment, targetFile string, anonymize bool) error {
+	if anonymize {
+		return getAnonymizedClusterReport(env, targetFile)
+	}
 	f, err := os.Create(targetFile)
 	if err != nil {
 		return trace.Wrap(err)
@@ -314,6 +317,28 @@ func resetClusterState(app *localenv.LocalEnvironment) error {
 	return nil
 }
 
+// releaseQuarantinedNode releases the node with the specified advertise
+// address from quarantine so operations can be started again
+func releaseQuarantinedNode(env *localenv.LocalEnvironment, addr string) error {
+	operator, err := env.SiteOperator()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	cluster, err := operator.GetLocalSite()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	err = operator.ReleaseQuarantinedNode(cluster.Key(), addr)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	env.Printf("node %v has been released from quarantine\n", addr)
+	return nil
+}
+
 func stepDown(env *localenv.LocalEnvironment) error {
 	operator, err := env.SiteOperator()
 	if err != nil {
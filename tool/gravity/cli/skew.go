@@ -0,0 +1,199 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/defaults"
+	libfsm "github.com/gravitational/gravity/lib/fsm"
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/localenv"
+	"github.com/gravitational/gravity/lib/modules"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/schema"
+	"github.com/gravitational/gravity/lib/skew"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/coreos/go-semver/semver"
+	"github.com/gravitational/trace"
+	"github.com/gravitational/version"
+)
+
+// checkVersionSkew verifies that the versions of gravity components satisfy
+// the version skew policy before an operation is started or resumed.
+// If withAgents is set, the versions of the running agents are verified too
+func checkVersionSkew(env *localenv.LocalEnvironment, withAgents bool) error {
+	operator, err := env.SiteOperator()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	cluster, err := operator.GetLocalSite()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	versions, err := collectVersions(env, *cluster)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if withAgents {
+		versions.Agents = collectAgentVersions(context.TODO(), cluster.ClusterState.Servers)
+	}
+	return trace.Wrap(versions.Check())
+}
+
+// checkUpgradeVersionSkew verifies that the versions of gravity components
+// allow to upgrade the cluster to the application with the specified manifest
+func checkUpgradeVersionSkew(env *localenv.LocalEnvironment, cluster ops.Site, manifest schema.Manifest) error {
+	versions, err := collectVersions(env, cluster)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	gravityPackage, err := manifest.Dependencies.ByName(constants.GravityPackage)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	versions.Target, err = gravityPackage.SemVer()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(versions.Check())
+}
+
+// collectVersions returns versions of this binary, the cluster controller
+// and, if this is a cluster node, the runtime installed on this node
+func collectVersions(env *localenv.LocalEnvironment, cluster ops.Site) (*skew.Versions, error) {
+	binaryVersion, err := semver.NewVersion(version.Get().Version)
+	if err != nil {
+		return nil, trace.Wrap(err, "failed to parse this binary version: %v",
+			version.Get().Version)
+	}
+	gravityPackage, err := cluster.App.Manifest.Dependencies.ByName(constants.GravityPackage)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	clusterVersion, err := gravityPackage.SemVer()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	versions := &skew.Versions{
+		Binary:  *binaryVersion,
+		Cluster: *clusterVersion,
+	}
+	server, err := findLocalServer(cluster)
+	if err != nil {
+		log.Debugf("Not verifying runtime version: %v.", err)
+		return versions, nil
+	}
+	versions.Runtime, err = findAnyRuntimePackage(env.Packages)
+	if err != nil {
+		log.Warnf("Failed to find installed runtime package: %v.", trace.DebugReport(err))
+		return versions, nil
+	}
+	versions.ExpectedRuntime, err = cluster.App.Manifest.RuntimePackageForProfile(server.Role)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return versions, nil
+}
+
+// collectAgentVersions returns versions of the agents running on the
+// specified servers. Servers without a running agent are skipped
+func collectAgentVersions(ctx context.Context, servers []storage.Server) map[string]semver.Version {
+	creds, err := libfsm.GetClientCredentials()
+	if err != nil {
+		log.Debugf("Not verifying agent versions: %v.", err)
+		return nil
+	}
+	runner := libfsm.NewAgentRunner(creds)
+	defer runner.Close()
+	versions := make(map[string]semver.Version)
+	for _, server := range servers {
+		ver, err := getAgentVersion(ctx, runner, server)
+		if err != nil {
+			log.Debugf("Failed to query agent version on %v: %v.", server.AdvertiseIP, err)
+			continue
+		}
+		versions[server.AdvertiseIP] = *ver
+	}
+	return versions
+}
+
+func getAgentVersion(ctx context.Context, agents libfsm.AgentRepository, server storage.Server) (*semver.Version, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaults.DialTimeout)
+	defer cancel()
+	clt, err := agents.GetClient(ctx, server.AdvertiseIP)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var buf bytes.Buffer
+	err = clt.GravityCommand(ctx, log.WithField("server", server.AdvertiseIP), &buf,
+		"version", "--output=json")
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var ver modules.Version
+	if err := json.Unmarshal(buf.Bytes(), &ver); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return semver.NewVersion(ver.Version)
+}
+
+// downloadBinary saves the gravity binary of the specified version, or of
+// the cluster version if unspecified, from the cluster package service
+func downloadBinary(env *localenv.LocalEnvironment, ver, path string) error {
+	if ver == "" {
+		operator, err := env.SiteOperator()
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		cluster, err := operator.GetLocalSite()
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		gravityPackage, err := cluster.App.Manifest.Dependencies.ByName(constants.GravityPackage)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		ver = gravityPackage.Version
+	}
+	packages, err := env.ClusterPackages()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	locator := loc.Locator{
+		Repository: defaults.SystemAccountOrg,
+		Name:       constants.GravityPackage,
+		Version:    ver,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaults.TransientErrorTimeout)
+	defer cancel()
+	err = utils.CopyWithRetries(ctx, path, func() (io.ReadCloser, error) {
+		_, rc, err := packages.ReadPackage(locator)
+		return rc, trace.Wrap(err)
+	}, defaults.SharedExecutableMask)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	env.Printf("Gravity binary %v has been saved to %v.\n", ver, path)
+	return nil
+}
@@ -30,6 +30,7 @@ import (
 	"github.com/gravitational/gravity/lib/defaults"
 	"github.com/gravitational/gravity/lib/localenv"
 	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/pack"
 	"github.com/gravitational/gravity/lib/schema"
 	statusapi "github.com/gravitational/gravity/lib/status"
 
@@ -40,6 +41,16 @@ import (
 )
 
 func status(env *localenv.LocalEnvironment, printOptions printOptions) error {
+	if printOptions.operationID == "" && !printOptions.refresh {
+		status, err := getCachedStatus(env)
+		if err == nil {
+			return trace.Wrap(printStatus(nil, clusterStatus{*status, nil}, printOptions))
+		}
+		if !trace.IsNotFound(err) {
+			log.Warnf("Failed to read cached cluster status: %v.", trace.DebugReport(err))
+		}
+	}
+
 	clusterEnv, err := env.NewClusterEnvironment()
 	if err != nil {
 		return trace.Wrap(err)
@@ -48,10 +59,15 @@ func status(env *localenv.LocalEnvironment, printOptions printOptions) error {
 
 	status, err := statusOnce(context.TODO(), operator, printOptions.operationID)
 	if err == nil {
+		if printOptions.operationID == "" {
+			cacheStatus(env, *status)
+		}
 		err = printStatus(operator, clusterStatus{*status, nil}, printOptions)
 		return trace.Wrap(err)
 	} else {
 		log.Errorf(trace.DebugReport(err))
+		// do not show the stale status cached before the failure
+		invalidateMetadataCache(env, statusCacheKey)
 	}
 
 	if printOptions.operationID != "" {
@@ -284,6 +300,14 @@ func printClusterStatus(cluster statusapi.Cluster, w io.Writer) {
 		fmt.Fprintf(w, "Application:\t%v, version %v\n", cluster.App.Name,
 			cluster.App.Version)
 	}
+	switch cluster.Lifecycle {
+	case pack.LifecycleDeprecated:
+		fmt.Fprintf(w, "Version status:\t%v\n", color.YellowString(
+			"deprecated, upgrade before it reaches the end of life"))
+	case pack.LifecycleEndOfLife:
+		fmt.Fprintf(w, "Version status:\t%v\n", color.RedString(
+			"end of life, the version is no longer supported"))
+	}
 	if cluster.Token.Token != "" {
 		fmt.Fprintf(w, "Join token:\t%v\n", cluster.Token.Token)
 	}
@@ -369,6 +393,21 @@ func printNodeStatus(node statusapi.ClusterServer, w io.Writer) {
 			fmt.Fprintf(w, "            [%v]\t%v\n", constants.FailureMark, color.New(color.FgRed).SprintFunc()(probe))
 		}
 	}
+	if node.Quarantine != nil {
+		fmt.Fprintf(w, "            Quarantined:\t%v\n", color.RedString("since %v after %v membership changes",
+			node.Quarantine.Since.Format(constants.HumanDateFormat), node.Quarantine.Transitions))
+	}
+	if cert := node.KubeletCertificate; cert != nil {
+		switch {
+		case cert.Expired:
+			fmt.Fprintf(w, "            Kubelet certificate:\t%v\n", color.RedString(
+				"expired on %v, run 'gravity system repair-kubelet-certs' on the node",
+				cert.NotAfter.Format(constants.HumanDateFormat)))
+		case cert.Expiring:
+			fmt.Fprintf(w, "            Kubelet certificate:\t%v\n", color.YellowString("expires on %v",
+				cert.NotAfter.Format(constants.HumanDateFormat)))
+		}
+	}
 }
 
 func isClusterDegrated(status clusterStatus) bool {
@@ -395,6 +434,8 @@ type printOptions struct {
 	operationID string
 	// format specifies the output format (JSON or text)
 	format constants.Format
+	// refresh means query the cluster status instead of using the cached status
+	refresh bool
 }
 
 type clusterStatus struct {
@@ -0,0 +1,227 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os/exec"
+	"time"
+
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/localenv"
+	"github.com/gravitational/gravity/lib/ops"
+	statusapi "github.com/gravitational/gravity/lib/status"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/fatih/color"
+	"github.com/gravitational/trace"
+)
+
+// watchConfig controls the status watch mode
+type watchConfig struct {
+	// interval is how often the status is refreshed
+	interval time.Duration
+	// webhookURL is an optional URL to post status transitions to
+	webhookURL string
+	// desktop enables desktop notifications of status transitions
+	desktop bool
+}
+
+// statusWatch keeps a live view of the cluster status and highlights
+// the status transitions, e.g. a node becoming degraded or a leader change,
+// optionally sending a notification for each transition
+func statusWatch(env *localenv.LocalEnvironment, printOptions printOptions, config watchConfig) error {
+	if config.interval == 0 {
+		config.interval = defaults.StatusWatchInterval
+	}
+	if config.desktop {
+		if _, err := exec.LookPath(desktopNotifyCommand); err != nil {
+			return trace.NotFound("desktop notifications require %v: %v",
+				desktopNotifyCommand, err)
+		}
+	}
+	operator, err := env.SiteOperator()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	cluster, err := operator.GetLocalSite()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	notifier := statusNotifier{
+		watchConfig: config,
+		cluster:     cluster.Domain,
+		client:      &http.Client{Timeout: defaults.WebhookTimeout},
+	}
+
+	ticker := time.NewTicker(config.interval)
+	defer ticker.Stop()
+	var prev *statusapi.Snapshot
+	var history []statusapi.Transition
+	for {
+		snapshot := statusSnapshot(env, operator, printOptions.operationID)
+		var transitions []statusapi.Transition
+		if prev != nil {
+			transitions = statusapi.Transitions(*prev, snapshot)
+		}
+		prev = &snapshot
+		for _, transition := range transitions {
+			notifier.notify(transition)
+		}
+		history = append(history, transitions...)
+		if len(history) > defaults.StatusWatchHistory {
+			history = history[len(history)-defaults.StatusWatchHistory:]
+		}
+		if printOptions.format == constants.EncodingJSON {
+			// JSON output is a stream of transitions
+			if err := printTransitionsJSON(transitions); err != nil {
+				return trace.Wrap(err)
+			}
+		} else {
+			// clear the screen before redrawing the status
+			fmt.Print("\033[H\033[2J")
+			printStatusText(clusterStatus{snapshot.Status, nil})
+			printTransitions(history, config.interval)
+		}
+		<-ticker.C
+	}
+}
+
+// statusSnapshot collects the cluster status and the active master node.
+// Failure to collect the status is reported as the degraded cluster
+func statusSnapshot(env *localenv.LocalEnvironment, operator ops.Operator, operationID string) statusapi.Snapshot {
+	snapshot := statusapi.Snapshot{Time: time.Now().UTC()}
+	status, err := statusOnce(context.TODO(), operator, operationID)
+	if err != nil {
+		log.Warnf("Failed to collect cluster status: %v.", trace.DebugReport(err))
+	}
+	if status != nil {
+		snapshot.Status = *status
+	}
+	if snapshot.Status.Cluster == nil {
+		snapshot.Status.Cluster = &statusapi.Cluster{State: ops.SiteStateDegraded}
+	}
+	addr, err := utils.ResolveAddr(env.DNS.Addr(), constants.APIServerDomainName)
+	if err != nil {
+		log.Warnf("Failed to resolve leader address: %v.", trace.DebugReport(err))
+	} else if host, _, err := net.SplitHostPort(addr); err == nil {
+		snapshot.Leader = host
+	} else {
+		snapshot.Leader = addr
+	}
+	return snapshot
+}
+
+func printTransitions(transitions []statusapi.Transition, interval time.Duration) {
+	fmt.Printf("\nRecent transitions (refreshed every %v):\n", interval)
+	if len(transitions) == 0 {
+		fmt.Println("    none")
+		return
+	}
+	for _, transition := range transitions {
+		message := fmt.Sprintf("%v %v", transition.Time.Format(constants.HumanDateFormat), transition)
+		if transition.IsDegradation() {
+			fmt.Printf("    %v\n", color.RedString(message))
+		} else {
+			fmt.Printf("    %v\n", color.GreenString(message))
+		}
+	}
+}
+
+func printTransitionsJSON(transitions []statusapi.Transition) error {
+	for _, transition := range transitions {
+		bytes, err := json.Marshal(transition)
+		if err != nil {
+			return trace.Wrap(err, "failed to marshal")
+		}
+		fmt.Println(string(bytes))
+	}
+	return nil
+}
+
+// statusNotifier sends notifications of status transitions
+type statusNotifier struct {
+	watchConfig
+	cluster string
+	client  *http.Client
+}
+
+// statusNotification is the webhook payload of a status transition
+type statusNotification struct {
+	// Cluster is the name of the cluster
+	Cluster string `json:"cluster"`
+	// Message is the human-readable description of the transition
+	Message string `json:"message"`
+	statusapi.Transition
+}
+
+// notify sends the configured notifications for the transition.
+// Notification failures are logged and do not interrupt the watch
+func (r statusNotifier) notify(transition statusapi.Transition) {
+	if r.webhookURL != "" {
+		if err := r.notifyWebhook(transition); err != nil {
+			log.Warnf("Failed to notify webhook: %v.", trace.DebugReport(err))
+		}
+	}
+	if r.desktop {
+		if err := r.notifyDesktop(transition); err != nil {
+			log.Warnf("Failed to show desktop notification: %v.", trace.DebugReport(err))
+		}
+	}
+}
+
+func (r statusNotifier) notifyWebhook(transition statusapi.Transition) error {
+	payload, err := json.Marshal(statusNotification{
+		Cluster:    r.cluster,
+		Message:    transition.String(),
+		Transition: transition,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	resp, err := r.client.Post(r.webhookURL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return trace.BadParameter("webhook returned %v", resp.Status)
+	}
+	return nil
+}
+
+func (r statusNotifier) notifyDesktop(transition statusapi.Transition) error {
+	urgency := "normal"
+	if transition.IsDegradation() {
+		urgency = "critical"
+	}
+	out, err := exec.Command(desktopNotifyCommand, "--urgency", urgency,
+		fmt.Sprintf("Cluster %v", r.cluster), transition.String()).CombinedOutput()
+	if err != nil {
+		return trace.Wrap(err, "%s", out)
+	}
+	return nil
+}
+
+// desktopNotifyCommand is the command that shows desktop notifications
+const desktopNotifyCommand = "notify-send"
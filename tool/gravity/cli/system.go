@@ -24,6 +24,7 @@ import (
 	"os/exec"
 	"os/user"
 	"path/filepath"
+	"runtime"
 	"sort"
 	"strconv"
 	"strings"
@@ -1182,7 +1183,9 @@ func findPackageUpdateHelper(packages pack.PackageService, filter loc.Locator, u
 	if updateFilter == nil {
 		updateFilter = &filter
 	}
-	latestPackage, err := pack.FindLatestPackage(packages, *updateFilter)
+	// the packages are updated on the node so select the package
+	// built for the architecture of the node
+	latestPackage, err := pack.FindLatestPackageForArch(packages, *updateFilter, runtime.GOARCH)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
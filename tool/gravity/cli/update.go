@@ -19,6 +19,7 @@ package cli
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	appservice "github.com/gravitational/gravity/lib/app"
 	"github.com/gravitational/gravity/lib/constants"
@@ -30,6 +31,7 @@ import (
 	"github.com/gravitational/gravity/lib/pack"
 	"github.com/gravitational/gravity/lib/schema"
 
+	"github.com/fatih/color"
 	"github.com/gravitational/trace"
 )
 
@@ -53,49 +55,112 @@ func updateTrigger(
 	upgradeEnv *localenv.LocalEnvironment,
 	appPackage string,
 	manual bool,
+	skipVersionCheck bool,
 ) error {
-	clusterEnv, err := localEnv.NewClusterEnvironment()
+	opKey, err := createUpdateOperation(localEnv, upgradeEnv, appPackage, manual, skipVersionCheck)
 	if err != nil {
 		return trace.Wrap(err)
 	}
 
+	if localEnv.Silent {
+		fmt.Printf("%v", opKey.OperationID)
+		return nil
+	}
+
+	localEnv.Printf("Upgrade operation (%v) has been started.\n", opKey.OperationID)
+
+	if !manual {
+		localEnv.Println("The cluster is being upgraded in the background.")
+		return nil
+	}
+
+	localEnv.Println(`
+The upgrade operation has been created in manual mode.
+
+To view the operation plan, run:
+
+$ gravity plan
+
+To perform the upgrade, execute all upgrade phases in the order they appear in
+the plan by running:
+
+$ sudo gravity upgrade --phase=<phase-id>
+
+To rollback an unsuccessful phase, you can run:
+
+$ sudo gravity rollback --phase=<phase-id>
+
+Once all phases have been successfully completed, run the following command to
+mark the operation as "completed" and return the cluster to the "active" state:
+
+$ gravity upgrade --complete
+
+To abort an unsuccessful operation, rollback all completed/failed phases and
+run the same command. The operation will be marked as "failed" and the cluster
+will be returned to the "active" state.`)
+
+	return nil
+}
+
+// createUpdateOperation creates the update operation for the specified application
+// and deploys update agents on cluster nodes.
+// In automatic mode, the agent on this node executes the operation in the background
+func createUpdateOperation(
+	localEnv *localenv.LocalEnvironment,
+	upgradeEnv *localenv.LocalEnvironment,
+	appPackage string,
+	manual bool,
+	skipVersionCheck bool,
+) (opKey *ops.SiteOperationKey, err error) {
+	clusterEnv, err := localEnv.NewClusterEnvironment()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
 	if clusterEnv.Client == nil {
-		return trace.BadParameter("this operation can only be executed on one of the master nodes")
+		return nil, trace.BadParameter("this operation can only be executed on one of the master nodes")
 	}
 	operator := clusterEnv.Operator
 
 	cluster, err := operator.GetLocalSite()
 	if err != nil {
-		return trace.Wrap(err)
+		return nil, trace.Wrap(err)
 	}
 
 	teleportClient, err := localEnv.TeleportClient(constants.Localhost)
 	if err != nil {
-		return trace.Wrap(err, "failed to create a teleport client")
+		return nil, trace.Wrap(err, "failed to create a teleport client")
 	}
 
 	proxy, err := teleportClient.ConnectToProxy(context.TODO())
 	if err != nil {
-		return trace.Wrap(err, "failed to connect to teleport proxy")
+		return nil, trace.Wrap(err, "failed to connect to teleport proxy")
 	}
 
 	app, err := checkForUpdate(localEnv, operator, cluster, appPackage)
 	if err != nil {
-		return trace.Wrap(err)
+		return nil, trace.Wrap(err)
 	}
 
 	err = checkCanUpdate(*cluster, operator, app.Manifest)
 	if err != nil {
-		return trace.Wrap(err)
+		return nil, trace.Wrap(err)
 	}
 
-	opKey, err := operator.CreateSiteAppUpdateOperation(ops.CreateSiteAppUpdateOperationRequest{
+	if !skipVersionCheck {
+		err = checkUpgradeVersionSkew(localEnv, *cluster, app.Manifest)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+
+	opKey, err = operator.CreateSiteAppUpdateOperation(ops.CreateSiteAppUpdateOperationRequest{
 		AccountID:  cluster.AccountID,
 		SiteDomain: cluster.Domain,
 		App:        app.Package.String(),
 	})
 	if err != nil {
-		return trace.Wrap(err)
+		return nil, trace.Wrap(err)
 	}
 
 	defer func() {
@@ -133,47 +198,9 @@ func updateTrigger(
 	ctx := context.TODO()
 	err = deployUpdateAgents(ctx, localEnv, upgradeEnv, req)
 	if err != nil {
-		return trace.Wrap(err)
-	}
-
-	if localEnv.Silent {
-		fmt.Printf("%v", opKey.OperationID)
-		return nil
-	}
-
-	localEnv.Printf("Upgrade operation (%v) has been started.\n", opKey.OperationID)
-
-	if !manual {
-		localEnv.Println("The cluster is being upgraded in the background.")
-		return nil
+		return nil, trace.Wrap(err)
 	}
-
-	localEnv.Println(`
-The upgrade operation has been created in manual mode.
-
-To view the operation plan, run:
-
-$ gravity plan
-
-To perform the upgrade, execute all upgrade phases in the order they appear in
-the plan by running:
-
-$ sudo gravity upgrade --phase=<phase-id>
-
-To rollback an unsuccessful phase, you can run:
-
-$ sudo gravity rollback --phase=<phase-id>
-
-Once all phases have been successfully completed, run the following command to
-mark the operation as "completed" and return the cluster to the "active" state:
-
-$ gravity upgrade --complete
-
-To abort an unsuccessful operation, rollback all completed/failed phases and
-run the same command. The operation will be marked as "failed" and the cluster
-will be returned to the "active" state.`)
-
-	return nil
+	return opKey, nil
 }
 
 func checkCanUpdate(cluster ops.Site, operator ops.Operator, manifest schema.Manifest) error {
@@ -207,7 +234,15 @@ func checkForUpdate(env *localenv.LocalEnvironment, operator ops.Operator, site
 		updatePackage = site.App.Package.Name
 	}
 
-	updateLoc, err := loc.MakeLocator(updatePackage)
+	packages, err := env.PackageService(
+		defaults.GravityServiceURL,
+		httplib.WithLocalResolver(env.DNS.Addr()),
+		httplib.WithInsecure())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	updateLoc, err := resolveUpdatePackage(packages, updatePackage)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -231,12 +266,49 @@ func checkForUpdate(env *localenv.LocalEnvironment, operator ops.Operator, site
 		return nil, trace.Wrap(err)
 	}
 
+	switch {
+	case update.PackageEnvelope.IsEndOfLife():
+		env.Println(color.RedString("Version %v of %v has reached the end of life and is no longer supported.",
+			update.Package.Version, update.Package.Name))
+	case update.PackageEnvelope.IsDeprecated():
+		env.Println(color.YellowString("Version %v of %v is deprecated, consider upgrading to a newer version.",
+			update.Package.Version, update.Package.Name))
+	}
+
 	env.Printf("Upgrading application %v from %v to %v.\n",
 		update.Package.Name, site.App.Package.Version, update.Package.Version)
 
 	return update, nil
 }
 
+// resolveUpdatePackage returns the locator of the update package specified
+// with updatePackage.
+// The version of the update package can be given as a semver range, e.g.
+// 'name:~5.5', in which case the latest version in the range is used
+func resolveUpdatePackage(packages pack.PackageService, updatePackage string) (*loc.Locator, error) {
+	locator, err := loc.MakeLocator(updatePackage)
+	if err == nil {
+		return locator, nil
+	}
+	i := strings.LastIndex(updatePackage, ":")
+	if i < 0 {
+		return nil, trace.Wrap(err)
+	}
+	constraint := updatePackage[i+1:]
+	if _, errConstraint := pack.ParseVersionConstraint(constraint); errConstraint != nil {
+		return nil, trace.Wrap(err)
+	}
+	filter, err := loc.MakeLocator(updatePackage[:i])
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	locator, err = pack.FindLatestPackageInRange(packages, *filter, constraint)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return locator, nil
+}
+
 func supportsUpdate(gravityPackage loc.Locator) (supports bool, err error) {
 	ver, err := gravityPackage.SemVer()
 	if err != nil {
@@ -18,7 +18,9 @@ package cli
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/gravitational/gravity/lib/defaults"
@@ -44,6 +46,8 @@ type upgradePhaseParams struct {
 	skipVersionCheck bool
 	// timeout is phase execution timeout
 	timeout time.Duration
+	// until optionally specifies the phase to stop the plan execution at
+	until string
 }
 
 func executeUpgradePhase(localEnv, upgradeEnv *localenv.LocalEnvironment, p upgradePhaseParams) error {
@@ -80,11 +84,82 @@ func executeUpgradePhase(localEnv, upgradeEnv *localenv.LocalEnvironment, p upgr
 		PhaseID:  p.phaseID,
 		Force:    p.force,
 		Progress: progress,
+		Until:    p.until,
 	}, p.skipVersionCheck)
 
 	return trace.Wrap(err)
 }
 
+// unattendedUpgradeParams combines parameters for an unattended upgrade
+type unattendedUpgradeParams struct {
+	// app is the application to upgrade to
+	app string
+	// retries is the number of times the plan is retried after a failure
+	retries int
+	// healthTimeout is how long to wait for the cluster to become healthy
+	healthTimeout time.Duration
+	// forceRollback rolls the upgrade back even if it succeeds
+	forceRollback bool
+	// skipVersionCheck allows to override gravity version compatibility check
+	skipVersionCheck bool
+}
+
+// unattendedUpgrade creates the upgrade operation and runs it to completion
+// in the foreground rolling it back on failure.
+// The result is output to stdout as JSON
+func unattendedUpgrade(localEnv, upgradeEnv *localenv.LocalEnvironment, p unattendedUpgradeParams) error {
+	// only the result should be output to stdout
+	localEnv.Silent = true
+	result, err := runUnattendedUpgrade(localEnv, upgradeEnv, p)
+	if result == nil {
+		result = &update.UnattendedResult{State: update.UnattendedFailed}
+		if err != nil {
+			result.Error = trace.UserMessage(err)
+		}
+	}
+	if errOutput := json.NewEncoder(os.Stdout).Encode(result); errOutput != nil {
+		return trace.NewAggregate(err, errOutput)
+	}
+	return trace.Wrap(err)
+}
+
+func runUnattendedUpgrade(localEnv, upgradeEnv *localenv.LocalEnvironment, p unattendedUpgradeParams) (*update.UnattendedResult, error) {
+	_, err := createUpdateOperation(localEnv, upgradeEnv, p.app, true, p.skipVersionCheck)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	clusterEnv, err := localEnv.NewClusterEnvironment()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	creds, err := fsm.GetClientCredentials()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	runner := fsm.NewAgentRunner(creds)
+
+	return update.UnattendedUpgrade(context.TODO(), update.UnattendedConfig{
+		FSMConfig: update.FSMConfig{
+			Backend:           clusterEnv.Backend,
+			LocalBackend:      upgradeEnv.Backend,
+			HostLocalBackend:  localEnv.Backend,
+			HostLocalPackages: localEnv.Packages,
+			Packages:          clusterEnv.Packages,
+			ClusterPackages:   clusterEnv.ClusterPackages,
+			Apps:              clusterEnv.Apps,
+			Client:            clusterEnv.Client,
+			Operator:          clusterEnv.Operator,
+			Users:             clusterEnv.Users,
+			Remote:            runner,
+		},
+		Retries:       p.retries,
+		HealthTimeout: p.healthTimeout,
+		ForceRollback: p.forceRollback,
+	})
+}
+
 func rollbackUpgradePhase(localEnv, updateEnv *localenv.LocalEnvironment, p rollbackParams) error {
 	clusterEnv, err := localEnv.NewClusterEnvironment()
 	if err != nil {
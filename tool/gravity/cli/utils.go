@@ -127,11 +127,38 @@ func (g *Application) isJoinCommand(cmd string) bool {
 	return false
 }
 
+// isVersionSkewChecked returns whether the versions of gravity components
+// are verified before the specified command starts or resumes an operation,
+// and whether the versions of the running agents are verified as well
+func (g *Application) isVersionSkewChecked(cmd string) (checked, withAgents bool) {
+	switch cmd {
+	case g.GarbageCollectCmd.FullCommand():
+		return !*g.GarbageCollectCmd.SkipVersionCheck,
+			*g.GarbageCollectCmd.Phase != "" || *g.GarbageCollectCmd.Resume
+	case g.SystemRollingRestartCmd.FullCommand():
+		return !*g.SystemRollingRestartCmd.SkipVersionCheck,
+			*g.SystemRollingRestartCmd.Phase != "" || *g.SystemRollingRestartCmd.Resume
+	case g.SystemMigrateStorageDriverCmd.FullCommand():
+		return !*g.SystemMigrateStorageDriverCmd.SkipVersionCheck,
+			*g.SystemMigrateStorageDriverCmd.Phase != "" || *g.SystemMigrateStorageDriverCmd.Resume
+	case g.ReplaceCmd.FullCommand():
+		return !*g.ReplaceCmd.SkipVersionCheck && !*g.ReplaceCmd.Cancel, false
+	case g.RemoveCmd.FullCommand():
+		return !*g.RemoveCmd.SkipVersionCheck, false
+	case g.SystemOSUpdateCmd.FullCommand():
+		return !*g.SystemOSUpdateCmd.SkipVersionCheck, false
+	}
+	return false, false
+}
+
 // isUpgradeCommand returns true if the specified commans is
 // an upgrade related command
 func (g *Application) isUpgradeCommand(cmd string) bool {
 	switch cmd {
-	case g.PlanCmd.FullCommand(),
+	case g.PlanDisplayCmd.FullCommand(),
+		g.PlanExecuteCmd.FullCommand(),
+		g.PlanApproveCmd.FullCommand(),
+		g.PlanRequireApprovalCmd.FullCommand(),
 		g.UpdateTriggerCmd.FullCommand(),
 		g.RollbackCmd.FullCommand(),
 		g.UpgradeCmd.FullCommand():
@@ -0,0 +1,113 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/localenv"
+	"github.com/gravitational/gravity/lib/ops"
+	statusapi "github.com/gravitational/gravity/lib/status"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/trace"
+)
+
+// watchdogConfig controls the system service watchdog
+type watchdogConfig struct {
+	// services lists the planet services to monitor
+	services []string
+	// interval is how often the services are checked
+	interval time.Duration
+	// window is the time window service restarts are counted in
+	window time.Duration
+	// maxRestarts is the number of restarts within the window after which
+	// the cluster is marked degraded
+	maxRestarts int
+	// webhookURL is an optional URL to post service restarts to
+	webhookURL string
+}
+
+// systemWatchdog monitors the critical planet services on this node, restarts
+// the failed ones and marks the cluster degraded if a service keeps failing
+func systemWatchdog(env *localenv.LocalEnvironment, config watchdogConfig) error {
+	operator, err := env.SiteOperator()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	cluster, err := operator.GetLocalSite()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	server, err := findLocalServer(*cluster)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	notifier := statusNotifier{
+		watchConfig: watchConfig{webhookURL: config.webhookURL},
+		cluster:     cluster.Domain,
+		client:      &http.Client{Timeout: defaults.WebhookTimeout},
+	}
+	key := cluster.Key()
+	watchdog, err := statusapi.NewWatchdog(statusapi.WatchdogConfig{
+		Node:        server.AdvertiseIP,
+		Services:    config.services,
+		Interval:    config.interval,
+		Window:      config.window,
+		MaxRestarts: config.maxRestarts,
+		Record: func(transition statusapi.Transition) {
+			env.Printf("%v %v\n", transition.Time.Format(time.RFC3339), transition)
+			notifier.notify(transition)
+		},
+		Escalate: func(ctx context.Context, reason string) error {
+			return operator.DeactivateSite(ops.DeactivateSiteRequest{
+				AccountID:  key.AccountID,
+				SiteDomain: key.SiteDomain,
+				Reason:     storage.ReasonServiceFailed,
+			})
+		},
+		Recover: func(ctx context.Context) error {
+			cluster, err := operator.GetSite(key)
+			if err != nil {
+				return trace.Wrap(err)
+			}
+			// do not activate the cluster degraded for other reasons
+			if cluster.State != ops.SiteStateDegraded || cluster.Reason != storage.ReasonServiceFailed {
+				return nil
+			}
+			return operator.ActivateSite(ops.ActivateSiteRequest{
+				AccountID:  key.AccountID,
+				SiteDomain: key.SiteDomain,
+			})
+		},
+		FieldLogger: log,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	utils.WatchTerminationSignals(ctx, cancel, utils.StopperFunc(func(context.Context) error {
+		return nil
+	}), log)
+	env.Printf("Watching services %v on node %v.\n", watchdog.Services, server.AdvertiseIP)
+	return trace.Wrap(watchdog.Run(ctx))
+}
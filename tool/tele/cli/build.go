@@ -21,6 +21,7 @@ import (
 
 	"github.com/gravitational/gravity/lib/app/service"
 	"github.com/gravitational/gravity/lib/builder"
+	"github.com/gravitational/gravity/lib/pack"
 	"github.com/gravitational/gravity/lib/utils"
 
 	"github.com/gravitational/trace"
@@ -44,6 +45,10 @@ type BuildParameters struct {
 	Silent bool
 	// Insecure turns on insecure verify mode
 	Insecure bool
+	// EncryptionKey is the optional passphrase to encrypt the installer packages with
+	EncryptionKey string
+	// Signer optionally signs the installer packages
+	Signer pack.Signer
 }
 
 // build builds an installer tarball according to the provided parameters
@@ -58,6 +63,8 @@ func build(ctx context.Context, params BuildParameters, req service.VendorReques
 		Repository:       params.Repository,
 		SkipVersionCheck: params.SkipVersionCheck,
 		VendorReq:        req,
+		EncryptionKey:    params.EncryptionKey,
+		Signer:           params.Signer,
 		Progress:         utils.NewProgress(ctx, "Build", 6, params.Silent),
 	})
 	if err != nil {
@@ -0,0 +1,69 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"net/http"
+
+	"github.com/gravitational/gravity/lib/builder"
+
+	"github.com/gravitational/trace"
+)
+
+type buildServerParams struct {
+	// listenAddr is the address to listen on
+	listenAddr string
+	// token is the token clients authenticate with
+	token string
+	// certFile is the path to the TLS certificate file
+	certFile string
+	// keyFile is the path to the TLS private key file
+	keyFile string
+	// repository is where packages are downloaded from
+	repository string
+	// stateDir is the optional build state directory
+	stateDir string
+	// insecure disables verification of the repository TLS certificate chain
+	insecure bool
+}
+
+// serveBuilds runs the remote builder service
+func serveBuilds(params buildServerParams) error {
+	if (params.certFile == "") != (params.keyFile == "") {
+		return trace.BadParameter("both --cert-file and --key-file should be specified")
+	}
+	handler, err := builder.NewRemoteBuilder(builder.RemoteBuilderConfig{
+		StateDir:   params.stateDir,
+		Repository: params.repository,
+		Insecure:   params.insecure,
+		Token:      params.token,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	server := &http.Server{
+		Addr:    params.listenAddr,
+		Handler: handler,
+	}
+	if params.certFile == "" {
+		log.Warn("No TLS certificate specified, build requests are served over plain HTTP.")
+		log.Infof("Serving build requests on http://%v.", params.listenAddr)
+		return trace.Wrap(server.ListenAndServe())
+	}
+	log.Infof("Serving build requests on https://%v.", params.listenAddr)
+	return trace.Wrap(server.ListenAndServeTLS(params.certFile, params.keyFile))
+}
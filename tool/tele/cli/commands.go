@@ -37,10 +37,18 @@ type Application struct {
 	VersionCmd VersionCmd
 	// BuildCmd builds app installer tarball
 	BuildCmd BuildCmd
+	// BuildServerCmd runs the remote builder service
+	BuildServerCmd BuildServerCmd
+	// LintCmd verifies the application manifest without building it
+	LintCmd LintCmd
 	// ListCmd lists available apps and runtimes
 	ListCmd ListCmd
 	// PullCmd downloads app installer from Ops Center
 	PullCmd PullCmd
+	// TestCmd combines subcommands for testing app installers
+	TestCmd TestCmd
+	// TestUpgradeCmd tests the upgrade path of an app on a throwaway cluster
+	TestUpgradeCmd TestUpgradeCmd
 }
 
 // VersionCmd outputs the binary version
@@ -79,6 +87,50 @@ type BuildCmd struct {
 	Parallel *int
 	// Quiet allows to suppress console output
 	Quiet *bool
+	// Profile is the installer profile
+	Profile *string
+	// Remote is the address of the remote builder to delegate the build to
+	Remote *string
+	// RemoteToken is the token to authenticate with the remote builder
+	RemoteToken *string
+	// EncryptionKey is the passphrase to encrypt the installer packages with
+	EncryptionKey *string
+	// EncryptionKeyCommand is the command that outputs the encryption key
+	EncryptionKeyCommand *string
+	// SigningKey is the path to the private key to sign the installer packages with
+	SigningKey *string
+}
+
+// BuildServerCmd runs the remote builder service
+type BuildServerCmd struct {
+	*kingpin.CmdClause
+	// ListenAddr is the address to listen on
+	ListenAddr *string
+	// Token is the token clients authenticate with
+	Token *string
+	// CertFile is the path to the TLS certificate file
+	CertFile *string
+	// KeyFile is the path to the TLS private key file
+	KeyFile *string
+	// Repository is where packages are downloaded from
+	Repository *string
+}
+
+// LintCmd verifies the application manifest without building it
+type LintCmd struct {
+	*kingpin.CmdClause
+	// ManifestPath is the path to app manifest file
+	ManifestPath *string
+	// RuntimeVersion is the runtime version the app is going to be built with
+	RuntimeVersion *string
+	// SkipImages disables the image availability check
+	SkipImages *bool
+	// VendorPatters is file pattern to search for images
+	VendorPatterns *[]string
+	// VendorIgnorePatterns if file pattern to ignore when searching for images
+	VendorIgnorePatterns *[]string
+	// Output is the findings output format
+	Output *constants.Format
 }
 
 type ListCmd struct {
@@ -103,3 +155,37 @@ type PullCmd struct {
 	// Quiet allows to suppress console output
 	Quiet *bool
 }
+
+// TestCmd combines subcommands for testing app installers
+type TestCmd struct {
+	*kingpin.CmdClause
+}
+
+// TestUpgradeCmd tests the upgrade path of an app on a throwaway cluster
+type TestUpgradeCmd struct {
+	*kingpin.CmdClause
+	// Installer is the installer tarball to install the cluster from
+	Installer *string
+	// Upgrades lists the installer tarballs to upgrade the cluster to
+	Upgrades *[]string
+	// Nodes is the number of cluster nodes
+	Nodes *int
+	// Flavor is the application flavor to install
+	Flavor *string
+	// Provisioner selects how nodes are provisioned: docker or ssh
+	Provisioner *string
+	// Image is the docker image of the cluster nodes
+	Image *string
+	// Hosts lists the addresses of the hosts provisioned over SSH
+	Hosts *[]string
+	// SSHUser is the SSH user
+	SSHUser *string
+	// SSHKey is the path to the SSH private key
+	SSHKey *string
+	// Rollback rolls each upgrade back first
+	Rollback *bool
+	// Keep keeps the cluster after the test
+	Keep *bool
+	// Output is the report output format
+	Output *constants.Format
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/gravitational/gravity/lib/builder"
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/schema"
+
+	"github.com/coreos/go-semver/semver"
+	"github.com/gravitational/trace"
+)
+
+type lintParams struct {
+	manifestPath   string
+	runtimeVersion string
+	skipImages     bool
+	patterns       []string
+	ignorePatterns []string
+	output         constants.Format
+}
+
+func lint(ctx context.Context, params lintParams) error {
+	config := builder.LintConfig{
+		ManifestPath:           params.manifestPath,
+		SkipImages:             params.skipImages,
+		ResourcePatterns:       params.patterns,
+		IgnoreResourcePatterns: params.ignorePatterns,
+	}
+	if params.runtimeVersion != "" {
+		version, err := semver.NewVersion(params.runtimeVersion)
+		if err != nil {
+			return trace.BadParameter("invalid runtime version %q: %v", params.runtimeVersion, err)
+		}
+		config.RuntimeVersion = version
+	}
+	findings, err := builder.Lint(ctx, config)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	switch params.output {
+	case constants.EncodingJSON:
+		if findings == nil {
+			findings = []schema.Finding{}
+		}
+		bytes, err := json.MarshalIndent(findings, "", "  ")
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		fmt.Println(string(bytes))
+	default:
+		for _, finding := range findings {
+			fmt.Println(finding)
+		}
+		if len(findings) == 0 {
+			fmt.Printf("%v: no problems found\n", params.manifestPath)
+		}
+	}
+	if findings.HasErrors() {
+		return trace.CompareFailed("%v has errors", params.manifestPath)
+	}
+	return nil
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/gravitational/gravity/lib/constants"
 	"github.com/gravitational/gravity/lib/defaults"
 	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/schema"
 	"github.com/gravitational/gravity/tool/common"
 
 	"gopkg.in/alecthomas/kingpin.v2"
@@ -54,6 +55,27 @@ func RegisterCommands(app *kingpin.Application) Application {
 	tele.BuildCmd.SkipVersionCheck = tele.BuildCmd.Flag("skip-version-check", "Skip version compatibility check").Hidden().Bool()
 	tele.BuildCmd.Parallel = tele.BuildCmd.Flag("parallel", "Specifies the number of concurrent tasks. If < 0, the number of tasks is not restricted, if unspecified, then tasks are capped at the number of logical CPU cores").Int()
 	tele.BuildCmd.Quiet = tele.BuildCmd.Flag("quiet", "Suppress any extra output to stdout").Short('q').Bool()
+	tele.BuildCmd.Profile = tele.BuildCmd.Flag("profile", fmt.Sprintf("Installer profile, one of %v. The edge profile produces a slimmed installer for low-resource devices", schema.InstallerProfiles)).Enum(schema.InstallerProfiles...)
+	tele.BuildCmd.Remote = tele.BuildCmd.Flag("remote", "Address of the remote builder to delegate image pulling and package assembly to, e.g. https://builder.example.com:3030").String()
+	tele.BuildCmd.RemoteToken = tele.BuildCmd.Flag("remote-token", "Token to authenticate with the remote builder").Envar(constants.RemoteBuilderTokenEnvVar).String()
+	tele.BuildCmd.EncryptionKey = tele.BuildCmd.Flag("encryption-key", "Passphrase to encrypt the installer packages with").Envar(constants.TeleEncryptionKeyEnvVar).String()
+	tele.BuildCmd.EncryptionKeyCommand = tele.BuildCmd.Flag("encryption-key-command", "Shell command that outputs the passphrase to encrypt the installer packages with, e.g. to retrieve it from a key management service").String()
+	tele.BuildCmd.SigningKey = tele.BuildCmd.Flag("signing-key", "Path to the PEM-encoded ECDSA or RSA private key to sign the installer packages with").ExistingFile()
+
+	tele.BuildServerCmd.CmdClause = app.Command("build-server", "Run the remote builder service that builds application installers on behalf of tele build --remote")
+	tele.BuildServerCmd.ListenAddr = tele.BuildServerCmd.Flag("listen-addr", "Address to listen on").Default(defaults.RemoteBuilderListenAddr).String()
+	tele.BuildServerCmd.Token = tele.BuildServerCmd.Flag("token", "Token clients authenticate with").Envar(constants.RemoteBuilderTokenEnvVar).Required().String()
+	tele.BuildServerCmd.CertFile = tele.BuildServerCmd.Flag("cert-file", "Path to the TLS certificate file").ExistingFile()
+	tele.BuildServerCmd.KeyFile = tele.BuildServerCmd.Flag("key-file", "Path to the TLS private key file").ExistingFile()
+	tele.BuildServerCmd.Repository = tele.BuildServerCmd.Flag("repository", "Optional address of Ops Center to download dependencies from").Hidden().String()
+
+	tele.LintCmd.CmdClause = app.Command("lint", "Verify the application manifest and referenced images without building the installer")
+	tele.LintCmd.ManifestPath = tele.LintCmd.Arg("manifest-path", fmt.Sprintf("Path to the application manifest file, must be %q", defaults.ManifestFileName)).Default(defaults.ManifestFileName).String()
+	tele.LintCmd.RuntimeVersion = tele.LintCmd.Flag("runtime-version", "Runtime version the application is going to be built with, defaults to the tele version").String()
+	tele.LintCmd.SkipImages = tele.LintCmd.Flag("skip-images", "Skip the container image availability check").Bool()
+	tele.LintCmd.VendorPatterns = tele.LintCmd.Flag("glob", "File pattern to search for container image references").Default(defaults.VendorPattern).Hidden().Strings()
+	tele.LintCmd.VendorIgnorePatterns = tele.LintCmd.Flag("ignore", "Ignore files matching this regular expression when searching for container references").Hidden().Strings()
+	tele.LintCmd.Output = common.Format(tele.LintCmd.Flag("output", "Findings output format, text or json").Short('o').Default(string(constants.EncodingText)))
 
 	tele.ListCmd.CmdClause = app.Command("ls", "Display a list of user applications published in remote Ops Center")
 	tele.ListCmd.Runtimes = tele.ListCmd.Flag("runtimes", "Show only runtimes").Short('r').Hidden().Bool()
@@ -66,5 +88,21 @@ func RegisterCommands(app *kingpin.Application) Application {
 	tele.PullCmd.Force = tele.PullCmd.Flag("force", "Overwrite existing tarball").Short('f').Bool()
 	tele.PullCmd.Quiet = tele.PullCmd.Flag("quiet", "Suppress any extra output to stdout").Short('q').Bool()
 
+	tele.TestCmd.CmdClause = app.Command("test", "Test application installers on throwaway clusters")
+
+	tele.TestUpgradeCmd.CmdClause = tele.TestCmd.Command("upgrade", "Install a cluster from the installer and upgrade it through the specified upgrades in order")
+	tele.TestUpgradeCmd.Installer = tele.TestUpgradeCmd.Arg("installer", "Installer tarball to install the cluster from").Required().ExistingFile()
+	tele.TestUpgradeCmd.Upgrades = tele.TestUpgradeCmd.Arg("upgrades", "Installer tarballs to upgrade the cluster to").Required().ExistingFiles()
+	tele.TestUpgradeCmd.Nodes = tele.TestUpgradeCmd.Flag("nodes", "Number of cluster nodes").Default("1").Int()
+	tele.TestUpgradeCmd.Flavor = tele.TestUpgradeCmd.Flag("flavor", "Application flavor to install").String()
+	tele.TestUpgradeCmd.Provisioner = tele.TestUpgradeCmd.Flag("provisioner", "How to provision cluster nodes: docker to run them as containers on this host, or ssh to use existing hosts").Default(harnessProvisionerDocker).Enum(harnessProvisionerDocker, harnessProvisionerSSH)
+	tele.TestUpgradeCmd.Image = tele.TestUpgradeCmd.Flag("image", "Docker image of the cluster nodes, should run systemd").Default(defaults.HarnessDockerImage).String()
+	tele.TestUpgradeCmd.Hosts = tele.TestUpgradeCmd.Flag("host", "Address of the host to use as a cluster node with ssh provisioner, can be repeated").Strings()
+	tele.TestUpgradeCmd.SSHUser = tele.TestUpgradeCmd.Flag("ssh-user", "SSH user with passwordless sudo on the hosts").Default("root").String()
+	tele.TestUpgradeCmd.SSHKey = tele.TestUpgradeCmd.Flag("ssh-key", "Path to the SSH private key").String()
+	tele.TestUpgradeCmd.Rollback = tele.TestUpgradeCmd.Flag("rollback", "Roll back each upgrade first and verify the cluster is left intact").Bool()
+	tele.TestUpgradeCmd.Keep = tele.TestUpgradeCmd.Flag("keep", "Keep the cluster after the test").Bool()
+	tele.TestUpgradeCmd.Output = common.Format(tele.TestUpgradeCmd.Flag("output", "Report output format, text or json").Short('o').Default(string(constants.EncodingText)))
+
 	return tele
 }
@@ -22,7 +22,10 @@ import (
 	"os"
 
 	"github.com/gravitational/gravity/lib/app/service"
+	"github.com/gravitational/gravity/lib/builder"
 	"github.com/gravitational/gravity/lib/localenv"
+	"github.com/gravitational/gravity/lib/pack"
+	"github.com/gravitational/gravity/tool/common"
 
 	teleutils "github.com/gravitational/teleport/lib/utils"
 	"github.com/gravitational/trace"
@@ -50,6 +53,43 @@ func Run(tele Application) error {
 	case tele.VersionCmd.FullCommand():
 		return printVersion(*tele.VersionCmd.Output)
 	case tele.BuildCmd.FullCommand():
+		encryptionKey, err := common.EncryptionKey(context.Background(),
+			*tele.BuildCmd.EncryptionKey, *tele.BuildCmd.EncryptionKeyCommand)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		var signer pack.Signer
+		if *tele.BuildCmd.SigningKey != "" {
+			if *tele.BuildCmd.Remote != "" {
+				return trace.BadParameter("--signing-key is not supported with --remote")
+			}
+			signer, err = pack.ReadSigningKey(*tele.BuildCmd.SigningKey)
+			if err != nil {
+				return trace.Wrap(err)
+			}
+		}
+		if *tele.BuildCmd.Remote != "" {
+			return builder.RemoteBuild(context.Background(), builder.RemoteBuildConfig{
+				Address:      *tele.BuildCmd.Remote,
+				Token:        *tele.BuildCmd.RemoteToken,
+				Insecure:     *tele.Insecure,
+				ManifestPath: *tele.BuildCmd.ManifestPath,
+				OutPath:      *tele.BuildCmd.OutFile,
+				Overwrite:    *tele.BuildCmd.Overwrite,
+				Request: builder.RemoteBuildRequest{
+					PackageName:            *tele.BuildCmd.Name,
+					PackageVersion:         *tele.BuildCmd.Version,
+					ResourcePatterns:       *tele.BuildCmd.VendorPatterns,
+					IgnoreResourcePatterns: *tele.BuildCmd.VendorIgnorePatterns,
+					SetImages:              *tele.BuildCmd.SetImages,
+					SetDeps:                *tele.BuildCmd.SetDeps,
+					SkipVersionCheck:       *tele.BuildCmd.SkipVersionCheck,
+					InstallerProfile:       *tele.BuildCmd.Profile,
+					EncryptionKey:          encryptionKey,
+				},
+				Silent: *tele.BuildCmd.Quiet,
+			})
+		}
 		return build(context.Background(), BuildParameters{
 			StateDir:         *tele.StateDir,
 			ManifestPath:     *tele.BuildCmd.ManifestPath,
@@ -59,6 +99,8 @@ func Run(tele Application) error {
 			SkipVersionCheck: *tele.BuildCmd.SkipVersionCheck,
 			Silent:           *tele.BuildCmd.Quiet,
 			Insecure:         *tele.Insecure,
+			EncryptionKey:    encryptionKey,
+			Signer:           signer,
 		}, service.VendorRequest{
 			PackageName:            *tele.BuildCmd.Name,
 			PackageVersion:         *tele.BuildCmd.Version,
@@ -68,6 +110,41 @@ func Run(tele Application) error {
 			SetDeps:                *tele.BuildCmd.SetDeps,
 			Parallel:               *tele.BuildCmd.Parallel,
 			VendorRuntime:          true,
+			InstallerProfile:       *tele.BuildCmd.Profile,
+		})
+	case tele.BuildServerCmd.FullCommand():
+		return serveBuilds(buildServerParams{
+			listenAddr: *tele.BuildServerCmd.ListenAddr,
+			token:      *tele.BuildServerCmd.Token,
+			certFile:   *tele.BuildServerCmd.CertFile,
+			keyFile:    *tele.BuildServerCmd.KeyFile,
+			repository: *tele.BuildServerCmd.Repository,
+			stateDir:   *tele.StateDir,
+			insecure:   *tele.Insecure,
+		})
+	case tele.LintCmd.FullCommand():
+		return lint(context.Background(), lintParams{
+			manifestPath:   *tele.LintCmd.ManifestPath,
+			runtimeVersion: *tele.LintCmd.RuntimeVersion,
+			skipImages:     *tele.LintCmd.SkipImages,
+			patterns:       *tele.LintCmd.VendorPatterns,
+			ignorePatterns: *tele.LintCmd.VendorIgnorePatterns,
+			output:         *tele.LintCmd.Output,
+		})
+	case tele.TestUpgradeCmd.FullCommand():
+		return testUpgrade(context.Background(), testUpgradeParams{
+			installer:   *tele.TestUpgradeCmd.Installer,
+			upgrades:    *tele.TestUpgradeCmd.Upgrades,
+			nodes:       *tele.TestUpgradeCmd.Nodes,
+			flavor:      *tele.TestUpgradeCmd.Flavor,
+			provisioner: *tele.TestUpgradeCmd.Provisioner,
+			image:       *tele.TestUpgradeCmd.Image,
+			hosts:       *tele.TestUpgradeCmd.Hosts,
+			sshUser:     *tele.TestUpgradeCmd.SSHUser,
+			sshKey:      *tele.TestUpgradeCmd.SSHKey,
+			rollback:    *tele.TestUpgradeCmd.Rollback,
+			keep:        *tele.TestUpgradeCmd.Keep,
+			output:      *tele.TestUpgradeCmd.Output,
 		})
 	}
 
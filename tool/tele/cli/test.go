@@ -0,0 +1,118 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/harness"
+
+	"github.com/gravitational/trace"
+)
+
+const (
+	// harnessProvisionerDocker provisions test cluster nodes as containers
+	harnessProvisionerDocker = "docker"
+	// harnessProvisionerSSH uses existing hosts accessed over SSH as test cluster nodes
+	harnessProvisionerSSH = "ssh"
+)
+
+type testUpgradeParams struct {
+	installer   string
+	upgrades    []string
+	nodes       int
+	flavor      string
+	provisioner string
+	image       string
+	hosts       []string
+	sshUser     string
+	sshKey      string
+	rollback    bool
+	keep        bool
+	output      constants.Format
+}
+
+func testUpgrade(ctx context.Context, params testUpgradeParams) error {
+	var provisioner harness.Provisioner
+	var err error
+	switch params.provisioner {
+	case harnessProvisionerDocker:
+		provisioner, err = harness.NewDockerProvisioner(harness.DockerConfig{
+			Image: params.image,
+		})
+	case harnessProvisionerSSH:
+		if len(params.hosts) < params.nodes {
+			return trace.BadParameter("%v hosts are required with ssh provisioner, provide them with --host",
+				params.nodes)
+		}
+		provisioner, err = harness.NewSSHProvisioner(harness.SSHConfig{
+			Hosts:   params.hosts,
+			User:    params.sshUser,
+			KeyPath: params.sshKey,
+		})
+	default:
+		return trace.BadParameter("unsupported provisioner %q", params.provisioner)
+	}
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	report, err := harness.UpgradePath(ctx, harness.UpgradePathConfig{
+		Config: harness.Config{
+			Provisioner: provisioner,
+			Nodes:       params.nodes,
+			Flavor:      params.flavor,
+		},
+		Installer: params.installer,
+		Upgrades:  params.upgrades,
+		Rollback:  params.rollback,
+		Keep:      params.keep,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	switch params.output {
+	case constants.EncodingJSON:
+		bytes, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		fmt.Println(string(bytes))
+	default:
+		w := new(tabwriter.Writer)
+		w.Init(os.Stdout, 0, 8, 1, '\t', 0)
+		fmt.Fprintf(w, "Step\tApplication\tDuration\tResult\n")
+		for _, step := range report.Steps {
+			result := "passed"
+			if step.Error != "" {
+				result = fmt.Sprintf("failed: %v", step.Error)
+			}
+			fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", step.Name, step.App,
+				step.Duration.Round(time.Second), result)
+		}
+		w.Flush()
+	}
+	if report.Failed() {
+		return trace.CompareFailed("upgrade path test has failed")
+	}
+	return nil
+}
@@ -90,8 +90,16 @@ const ServerList =  React.createClass({
   },
 
   renderServer(server, key){
-    let { vars, hostName } = server;
+    let { vars, hostName, serverRole, recommendedProfile } = server;
     let $variables = vars.map(this.renderVars);
+    let $recommendation = null;
+    if(recommendedProfile && recommendedProfile !== serverRole){
+      $recommendation = (
+        <div className="grv-provision-req-server-recommendation text-warning">
+          <small>Better suited for "{recommendedProfile}"</small>
+        </div>
+      )
+    }
 
     return (
       <div className="row grv-provision-req-server" key={key+hostName}>
@@ -105,6 +113,7 @@ const ServerList =  React.createClass({
               <div className="grv-provision-req-server-hostname">
                 <span style={{wordWrap: "break-word"}} className="m-r-xs">{hostName}</span>
               </div>
+              {$recommendation}
             </div>
             <div>
               <div className="grv-provision-req-server-inputs">
@@ -92,6 +92,7 @@ const serverVarsByRole = (opId, role) => [ ['opAgent', opId], reportMap => {
 
   let serverList = reportMap.get('servers')
   let hasDocker = reportMap.getIn(['docker', 'storageDriver']) === NEEDS_DOCKER_DISK;
+  let recommendedProfiles = getRecommendedProfiles(reportMap);
   
   return serverList
     .filter(itemMap => itemMap.get('role') === role)
@@ -110,6 +111,7 @@ const serverVarsByRole = (opId, role) => [ ['opAgent', opId], reportMap => {
       return {
         serverRole: itemMap.get('role'),
         hostName: itemMap.get('hostname'),
+        recommendedProfile: recommendedProfiles[itemMap.get('hostname')],
         vars,
         osInfo
       }
@@ -118,6 +120,16 @@ const serverVarsByRole = (opId, role) => [ ['opAgent', opId], reportMap => {
     .toJS();
 }];
 
+function getRecommendedProfiles(reportMap){
+  let nodeList = reportMap.getIn(['recommendation', 'nodes']) || toImmutable([]);
+  let profiles = {};
+  nodeList.forEach(nodeMap => {
+    profiles[nodeMap.get('hostname')] = nodeMap.get('profile');
+  });
+
+  return profiles;
+}
+
 function getDiskVar(varMap){
   let disk = varMap.get('value');
   let device = {}
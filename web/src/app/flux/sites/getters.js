@@ -32,6 +32,11 @@ const siteProfiles = id => [['sites', id], siteMap => {
   return siteMap.getIn('app.manifest.nodeProfiles'.split('.'));
 }];
 
+const siteInstaller = id => [['sites', id], siteMap => {
+  let installerMap = siteMap.getIn('app.manifest.installer'.split('.'));
+  return installerMap ? installerMap.toJS() : {};
+}];
+
 const siteToDelete = [['sitesDialogs', 'siteToDelete'], siteId => {
   if(!siteId){
     return null;
@@ -125,6 +130,7 @@ export default {
   siteStateById,
   siteLogo,
   siteProfiles,
+  siteInstaller,
   siteLicense,  
   siteToDelete,
   deleteSiteAttemp: requestStatus(TRYING_TO_DELETE_SITE),
@@ -23,6 +23,7 @@ import getters from './../../flux/provision/getters';
 
 
 import Profiles from './profiles';
+import Parameters from './parameters';
 import Footer, { Warning } from'./../footer';
 import {FlavorSelector} from'./flavorSelector';
 
@@ -141,6 +142,7 @@ const Provision = React.createClass({
           </div>
         }
         <Profiles {...model} />                                
+        <Parameters {...model} />
         <ProvisionFooter
           text="Start Installation"
           onClick={actions.startInstall}
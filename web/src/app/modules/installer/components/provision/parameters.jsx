@@ -0,0 +1,83 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import React from 'react';
+import * as actions from './../../flux/provision/actions';
+import { validateParameter, groupParameters } from './../../flux/provision/parameters';
+
+const ParameterInput = ({ parameter, value }) => {
+  let { name, type, secret, validation = {} } = parameter;
+  let { values = [] } = validation;
+  let onChange = e => actions.setParameter(name, e.target.value);
+
+  if (type === 'boolean') {
+    values = ['true', 'false'];
+  }
+
+  if (values.length > 0) {
+    return (
+      <select className="form-control" name={name} value={value || ''} onChange={onChange}>
+        <option value="" />
+        { values.map(item => <option key={item} value={item}>{item}</option>) }
+      </select>
+    );
+  }
+
+  return (
+    <input className="form-control" name={name} autoComplete="off"
+      type={secret ? 'password' : 'text'}
+      value={value || ''}
+      onChange={onChange}/>
+  );
+}
+
+const Parameter = ({ parameter, value }) => {
+  let { name, title, description, required } = parameter;
+  let error = validateParameter(parameter, value);
+  return (
+    <div className={error ? 'form-group has-error' : 'form-group'}>
+      <label htmlFor={name}>{title || name}{ required && ' *' }</label>
+      <ParameterInput parameter={parameter} value={value} />
+      { error && <label className="error" htmlFor={name}>{error}</label> }
+      { description && <div className="help-block">{description}</div> }
+    </div>
+  );
+}
+
+const Parameters = ({ parameters, parameterGroups, parameterValues }) => {
+  if (parameters.length === 0) {
+    return null;
+  }
+
+  let $groups = groupParameters(parameters, parameterGroups).map(group => (
+    <div key={group.name} className="m-b">
+      { group.title && <h3>{group.title}</h3> }
+      { group.description && <div className="help-block">{group.description}</div> }
+      { group.parameters.map(parameter =>
+        <Parameter key={parameter.name} parameter={parameter}
+          value={parameterValues[parameter.name]} />) }
+    </div>
+  ));
+
+  return (
+    <div className="m-b-lg">
+      <h2>Configure Application</h2>
+      {$groups}
+    </div>
+  );
+}
+
+export default Parameters;
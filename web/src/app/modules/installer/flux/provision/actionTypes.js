@@ -17,4 +17,5 @@ limitations under the License.
 export const INSTALLER_PROVISION = 'INSTALLER_PROVISION';
 export const INSTALLER_PROVISION_INIT = 'INSTALLER_PROVISION_INIT';
 export const INSTALLER_PROVISION_SET_FLAVOR = 'INSTALLER_PROVISION_SET_FLAVOR';
-export const INSTALLER_PROVISION_SET_INSTANCE_TYPE = 'INSTALLER_PROVISION_SET_INSTANCE_TYPE';
\ No newline at end of file
+export const INSTALLER_PROVISION_SET_INSTANCE_TYPE = 'INSTALLER_PROVISION_SET_INSTANCE_TYPE';
+export const INSTALLER_PROVISION_SET_PARAMETER = 'INSTALLER_PROVISION_SET_PARAMETER';
\ No newline at end of file
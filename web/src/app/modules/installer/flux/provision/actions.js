@@ -28,7 +28,8 @@ import { forIn } from 'lodash';
 import {
   INSTALLER_PROVISION_INIT,
   INSTALLER_PROVISION_SET_FLAVOR,
-  INSTALLER_PROVISION_SET_INSTANCE_TYPE } from './actionTypes';
+  INSTALLER_PROVISION_SET_INSTANCE_TYPE,
+  INSTALLER_PROVISION_SET_PARAMETER } from './actionTypes';
 
 import { TRYING_TO_START_INSTALL } from 'app/flux/restApi/constants';
 import restApiActions from 'app/flux/restApi/actions';
@@ -51,10 +52,11 @@ export function startInstallPrecheck() {
 
 export function startInstall(precheckOnly){
   let {opId, siteId} = reactor.evaluate(installerGetters.installer);
-  let {profilesToProvision, isOnPrem, license} = reactor.evaluate(getters.provision);
+  let {profilesToProvision, isOnPrem, license, parameterValues} = reactor.evaluate(getters.provision);
   let data = {
     license,
-    profiles: {}
+    profiles: {},
+    parameters: parameterValues
   };
 
   if(isOnPrem){
@@ -89,6 +91,13 @@ export function startInstall(precheckOnly){
     })
 }
 
+export function setParameter(name, value){
+  reactor.dispatch(INSTALLER_PROVISION_SET_PARAMETER, {
+    name,
+    value
+  })
+}
+
 export function setFlavorNumber(value){
   reactor.dispatch(INSTALLER_PROVISION_SET_FLAVOR, value);
 }
@@ -96,12 +105,14 @@ export function setFlavorNumber(value){
 export function initProvision(siteId, opId, flavors){
   let isOnPrem = reactor.evaluate(opGetters.isOnPrem(opId));
   let profiles = reactor.evaluate(siteGetters.siteProfiles(siteId));
+  let installer = reactor.evaluate(siteGetters.siteInstaller(siteId));
   reactor.dispatch(INSTALLER_PROVISION_INIT,
     {
       siteId,
       opId,
       isOnPrem,
       flavors,
-      profiles
+      profiles,
+      installer
     });
 }
\ No newline at end of file
@@ -0,0 +1,62 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// validateParameter mirrors the server-side validation of application
+// configuration parameters and returns the error message if the value is invalid
+export function validateParameter(parameter, value) {
+  let { type, required, validation = {} } = parameter;
+  let { pattern, values, min, max, message } = validation;
+
+  if (value === undefined || value === '') {
+    return required ? 'This field is required' : null;
+  }
+
+  let error = null;
+  if (type === 'integer') {
+    if (!/^[-+]?\d+$/.test(value)) {
+      error = 'Should be an integer';
+    } else if (min !== undefined && Number(value) < min) {
+      error = `Should be at least ${min}`;
+    } else if (max !== undefined && Number(value) > max) {
+      error = `Should be at most ${max}`;
+    }
+  } else if (type === 'boolean' && value !== 'true' && value !== 'false') {
+    error = 'Should be true or false';
+  }
+
+  if (!error && values && values.length > 0 && values.indexOf(value) === -1) {
+    error = `Should be one of ${values.join(', ')}`;
+  }
+
+  if (!error && pattern && !new RegExp(`^(?:${pattern})$`).test(value)) {
+    error = `Should match ${pattern}`;
+  }
+
+  return error && message ? message : error;
+}
+
+// groupParameters returns parameters grouped according to the group
+// definitions, parameters without a group go first
+export function groupParameters(parameters, parameterGroups) {
+  let groups = [{ name: '', title: '', parameters: [] }];
+  parameterGroups.forEach(group => groups.push({ ...group, parameters: [] }));
+  parameters.forEach(parameter => {
+    let group = groups.find(g => g.name === (parameter.group || '')) || groups[0];
+    group.parameters.push(parameter);
+  });
+
+  return groups.filter(g => g.parameters.length > 0);
+}
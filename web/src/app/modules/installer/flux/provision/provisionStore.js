@@ -22,7 +22,8 @@ import { ProvisionerEnum } from 'app/services/enums';
 import {
   INSTALLER_PROVISION_INIT,
   INSTALLER_PROVISION_SET_FLAVOR,
-  INSTALLER_PROVISION_SET_INSTANCE_TYPE } from './actionTypes';
+  INSTALLER_PROVISION_SET_INSTANCE_TYPE,
+  INSTALLER_PROVISION_SET_PARAMETER } from './actionTypes';
 
 import Logger from 'app/lib/logger';
 
@@ -38,7 +39,10 @@ export default Store({
       flavorsTitle: null,
       flavorsSelector: null,
       profiles: {},
-      profilesToProvision: {}      
+      profilesToProvision: {},
+      parameters: [],
+      parameterGroups: [],
+      parameterValues: {}
     });
   },
 
@@ -46,15 +50,17 @@ export default Store({
     this.on(INSTALLER_PROVISION_INIT, init);
     this.on(INSTALLER_PROVISION_SET_FLAVOR, setFlavorNumber);
     this.on(INSTALLER_PROVISION_SET_INSTANCE_TYPE, setProfileInstance);
+    this.on(INSTALLER_PROVISION_SET_PARAMETER, setParameter);
   }
 })
 
-function init(state, {profiles, isOnPrem = false, siteId, opId, flavors}) {  
+function init(state, {profiles, isOnPrem = false, siteId, opId, flavors, installer}) {  
   state = state.set('siteId', siteId)
                .set('opId', opId)
                .set('isOnPrem', isOnPrem);
 
   state = initFlavors(state, flavors);
+  state = initParameters(state, installer);
   state = initProfiles(state, profiles);
   state = updateProfilesToProvision(state);
   return state;
@@ -121,6 +127,24 @@ function initFlavors(state, json = {}) {
   }
 }
 
+function initParameters(state, installer = {}) {
+  let parameters = installer.parameters || [];
+  let parameterValues = {};
+  parameters.forEach(({ name, default: value }) => {
+    if (value !== undefined) {
+      parameterValues[name] = value;
+    }
+  });
+
+  return state.set('parameters', toImmutable(parameters))
+              .set('parameterGroups', toImmutable(installer.parameterGroups || []))
+              .set('parameterValues', toImmutable(parameterValues));
+}
+
+function setParameter(state, { name, value }){
+  return state.setIn(['parameterValues', name], value);
+}
+
 function setProfileInstance(state, { profileName, instanceType }){
   return state.setIn(['profilesToProvision', profileName, 'instanceType'], instanceType);
 }